@@ -2,30 +2,49 @@ package main
 
 import (
 	"context"
+	"database/sql"
 	"encoding/json"
+	"flag"
 	"fmt"
 	"log"
 	"math"
 	"os"
 	"sort"
+	"strconv"
 	"time"
 
 	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/codec"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/evidence"
 	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/graph"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/progress"
 	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/state"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/vecmath"
 )
 
+// jobName keys this tool's checkpoints in job_checkpoints.
+const jobName = "bootstrap-graph"
+
 // #region main
 func main() {
+	restart := flag.Bool("restart", false, "discard any saved checkpoint and start over")
+	flag.Parse()
+
 	dbPath := envOr("ADAPTIVE_DB", "adaptive_state.db")
 	grpcAddr := envOr("CODEC_ADDR", "localhost:50051")
 
 	similarityThreshold := float32(0.3)
 	temporalWindowMinutes := 30.0
-	searchTopK := 5
+	neighbourTopK := 5
+	embedWindow := 256 // texts per EmbedBatch call, so the embed bar moves
+
+	localEvidence := envOr("CODEC_BACKEND", "grpc") == "ollama"
+	source := "codec " + grpcAddr
+	if localEvidence {
+		source = "local store"
+	}
 
 	fmt.Println("=== Graph Bootstrap Tool ===")
-	fmt.Printf("  DB: %s | Codec: %s\n", dbPath, grpcAddr)
+	fmt.Printf("  DB: %s | Evidence: %s\n", dbPath, source)
 	fmt.Printf("  Similarity threshold: %.2f | Temporal window: %.0f min\n", similarityThreshold, temporalWindowMinutes)
 
 	// Open state DB (for graph store)
@@ -39,61 +58,97 @@ func main() {
 	if err != nil {
 		log.Fatalf("failed to init graph store: %v", err)
 	}
-
-	// Connect to Python inference service
-	codecClient, err := codec.NewCodecClient(grpcAddr)
+	checkpoints, err := progress.NewCheckpointStore(store.DB())
 	if err != nil {
-		log.Fatalf("failed to connect to codec service at %s: %v", grpcAddr, err)
+		log.Fatalf("failed to init checkpoint store: %v", err)
 	}
-	defer codecClient.Close()
-
-	// Fetch all evidence
-	fmt.Print("Fetching all evidence... ")
-	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
-	allEvidence, err := codecClient.ListAllEvidence(ctx)
-	cancel()
-	if err != nil {
-		log.Fatalf("list all evidence: %v", err)
+	if *restart {
+		if err := checkpoints.Clear(jobName); err != nil {
+			log.Fatalf("%v", err)
+		}
 	}
-	fmt.Printf("%d items found.\n", len(allEvidence))
 
+	// Ctrl+C stops after the current item; the checkpoint lets a re-run resume
+	ctx, stop := progress.Interruptible(context.Background(), os.Stderr)
+	defer stop()
+
+	// Evidence and its embeddings: read directly from the local store when the
+	// controller keeps evidence itself (CODEC_BACKEND=ollama), otherwise listed
+	// and embedded through the Python inference service
+	var allEvidence []evidence.Item
+	var vecByID map[string][]float32
+	if localEvidence {
+		allEvidence, vecByID = loadLocalEvidence(ctx, store)
+	} else {
+		allEvidence, vecByID = loadCodecEvidence(ctx, grpcAddr, embedWindow, stop)
+	}
 	if len(allEvidence) == 0 {
 		fmt.Println("No evidence to bootstrap. Done.")
 		return
 	}
 
-	// Phase 1: Similarity-based co_retrieval edges
+	// Phase 1: Similarity-based co_retrieval edges. Every item's nearest
+	// neighbours are found locally instead of one Search round trip per item.
 	fmt.Println("\n--- Phase 1: Similarity Edges ---")
 	coRetrievalCount := 0
+	ids := make([]string, 0, len(allEvidence))
+	position := make(map[string]int, len(allEvidence))
 	for i, item := range allEvidence {
-		// Search for similar items using this item's text
-		searchCtx, searchCancel := context.WithTimeout(context.Background(), 30*time.Second)
-		results, searchErr := codecClient.Search(searchCtx, item.Text, searchTopK, similarityThreshold)
-		searchCancel()
-		if searchErr != nil {
-			log.Printf("search error for %s: %v", item.ID[:8], searchErr)
-			continue
+		ids = append(ids, item.ID)
+		position[item.ID] = i
+	}
+	// co_retrieval edges are undirected: a mutual pair is linked once, from
+	// the item that comes first
+	neighbours := map[string][]neighbour{}
+	nearestOf := func(id string) []neighbour {
+		if n, ok := neighbours[id]; ok {
+			return n
 		}
-
-		for _, r := range results {
-			if r.ID == item.ID {
-				continue // skip self
-			}
-			// Weight proportional to similarity, scaled to 0-0.5 range
-			weight := float64(r.Score) * 0.5
-			if weight < 0.01 {
-				continue
-			}
-			if err := graphStore.IncrementEdge(item.ID, r.ID, "co_retrieval", weight); err != nil {
-				log.Printf("edge error: %v", err)
-				continue
+		n := nearest(id, ids, vecByID, neighbourTopK, similarityThreshold)
+		neighbours[id] = n
+		return n
+	}
+	res, err := checkpoints.Run(ctx, progress.Task{
+		Job:   jobName,
+		Phase: "similarity",
+		Items: ids,
+		Out:   os.Stderr,
+		Note:  func() string { return fmt.Sprintf("%d edges", coRetrievalCount) },
+		Step: func(_ context.Context, tx *sql.Tx, id string) error {
+			txGraph := graphStore.WithTx(tx)
+			added := 0
+			for _, r := range nearestOf(id) {
+				// Weight proportional to similarity, scaled to 0-0.5 range
+				weight := float64(r.score) * 0.5
+				if weight < 0.01 {
+					continue
+				}
+				if position[r.id] < position[id] && hasNeighbour(nearestOf(r.id), id) {
+					continue
+				}
+				if err := txGraph.IncrementEdge(id, r.id, graph.EdgeCoRetrieval, weight); err != nil {
+					log.Printf("edge error: %v", err)
+					continue
+				}
+				added++
 			}
-			coRetrievalCount++
-		}
-
-		if (i+1)%10 == 0 || i+1 == len(allEvidence) {
-			fmt.Printf("  [%d/%d] processed, %d edges so far\n", i+1, len(allEvidence), coRetrievalCount)
-		}
+			coRetrievalCount += added
+			return nil
+		},
+	})
+	if err != nil {
+		log.Fatalf("similarity pass: %v", err)
+	}
+	if res.Resumed > 0 {
+		fmt.Printf("  Resumed: %d items already done by an earlier run\n", res.Resumed)
+	}
+	if res.Failed > 0 {
+		fmt.Printf("  Failed: %d items skipped (re-run with --restart to retry them)\n", res.Failed)
+	}
+	if res.Interrupted {
+		fmt.Printf("\nInterrupted: %d/%d items done. Re-run to resume.\n", res.Resumed+res.Done+res.Failed, res.Total)
+		stop()
+		os.Exit(progress.ExitInterrupted)
 	}
 	fmt.Printf("  Total co_retrieval edges: %d\n", coRetrievalCount)
 
@@ -107,19 +162,9 @@ func main() {
 
 	var timed []timedItem
 	for _, item := range allEvidence {
-		var meta map[string]interface{}
-		if err := json.Unmarshal([]byte(item.MetadataJSON), &meta); err != nil {
-			continue
-		}
-		storedAt, ok := meta["stored_at"].(string)
-		if !ok || storedAt == "" {
-			continue
-		}
-		t, err := time.Parse(time.RFC3339, storedAt)
-		if err != nil {
-			continue
+		if t, ok := storedAt(item); ok {
+			timed = append(timed, timedItem{ID: item.ID, StoredAt: t})
 		}
-		timed = append(timed, timedItem{ID: item.ID, StoredAt: t})
 	}
 
 	// Sort by stored_at
@@ -127,9 +172,17 @@ func main() {
 		return timed[i].StoredAt.Before(timed[j].StoredAt)
 	})
 
+	// AddEdge ignores existing edges, so an interrupted temporal pass is simply redone
 	temporalCount := 0
 	windowDuration := time.Duration(temporalWindowMinutes) * time.Minute
+	bar := progress.NewBar(os.Stderr, "temporal", max(len(timed)-1, 0), 0)
 	for i := 0; i < len(timed)-1; i++ {
+		if ctx.Err() != nil {
+			bar.Finish("")
+			fmt.Println("\nInterrupted during temporal pass. Re-run to finish (similarity pass is saved).")
+			stop()
+			os.Exit(progress.ExitInterrupted)
+		}
 		for j := i + 1; j < len(timed); j++ {
 			gap := timed[j].StoredAt.Sub(timed[i].StoredAt)
 			if gap > windowDuration {
@@ -142,25 +195,164 @@ func main() {
 			if weight < 0.01 {
 				continue
 			}
-			if err := graphStore.AddEdge(timed[i].ID, timed[j].ID, "temporal", weight); err != nil {
+			if err := graphStore.AddEdge(timed[i].ID, timed[j].ID, graph.EdgeTemporal, weight); err != nil {
 				log.Printf("temporal edge error: %v", err)
 				continue
 			}
 			temporalCount++
 		}
+		bar.Set(i+1, fmt.Sprintf("%d edges", temporalCount))
+	}
+	bar.Finish(fmt.Sprintf("%d edges", temporalCount))
+	if err := checkpoints.Clear(jobName); err != nil {
+		log.Printf("clear checkpoint: %v", err)
 	}
 	fmt.Printf("  Items with timestamps: %d\n", len(timed))
 	fmt.Printf("  Total temporal edges: %d\n", temporalCount)
 
 	fmt.Printf("\n=== Bootstrap Complete ===\n")
 	fmt.Printf("  Evidence items: %d\n", len(allEvidence))
-	fmt.Printf("  Co-retrieval edges: %d\n", coRetrievalCount)
+	fmt.Printf("  Co-retrieval edges: %d (this run)\n", coRetrievalCount)
 	fmt.Printf("  Temporal edges: %d\n", temporalCount)
 	fmt.Printf("  Total edges created: %d\n", coRetrievalCount+temporalCount)
 }
 
 // #endregion main
 
+// #region neighbours
+type neighbour struct {
+	id    string
+	score float32
+}
+
+// nearest returns up to k items most similar to id, excluding id itself, with
+// cosine similarity at or above threshold, best first.
+func nearest(id string, ids []string, vecByID map[string][]float32, k int, threshold float32) []neighbour {
+	self := vecByID[id]
+	var out []neighbour
+	for _, other := range ids {
+		if other == id {
+			continue
+		}
+		if sim, _ := vecmath.Cosine(self, vecByID[other]); float32(sim) >= threshold {
+			out = append(out, neighbour{id: other, score: float32(sim)})
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].score > out[j].score })
+	if len(out) > k {
+		out = out[:k]
+	}
+	return out
+}
+
+// hasNeighbour reports whether id is among ns.
+func hasNeighbour(ns []neighbour, id string) bool {
+	for _, n := range ns {
+		if n.id == id {
+			return true
+		}
+	}
+	return false
+}
+
+// #endregion neighbours
+
+// #region load-evidence
+
+// loadLocalEvidence lists the controller's own evidence store with the
+// embeddings it was stored with, so no embedding calls are needed.
+func loadLocalEvidence(ctx context.Context, store *state.Store) ([]evidence.Item, map[string][]float32) {
+	fmt.Print("Reading local evidence... ")
+	local, err := evidence.NewSQLiteStore(store.DB())
+	if err != nil {
+		log.Fatalf("failed to open local evidence: %v", err)
+	}
+	items, err := local.All(ctx)
+	if err != nil {
+		log.Fatalf("list local evidence: %v", err)
+	}
+	vecByID, err := local.Embeddings(ctx)
+	if err != nil {
+		log.Fatalf("read local embeddings: %v", err)
+	}
+	fmt.Printf("%d items found.\n", len(items))
+	return items, vecByID
+}
+
+// loadCodecEvidence lists every item from the inference service at addr and
+// embeds each once, in batches of window texts. stop releases the interrupt
+// handler before exiting on Ctrl+C.
+func loadCodecEvidence(ctx context.Context, addr string, window int, stop func()) ([]evidence.Item, map[string][]float32) {
+	transport, err := codec.TransportConfigFromEnv()
+	if err != nil {
+		log.Fatal(err)
+	}
+	codecClient, err := codec.NewCodecClientWithTransport(addr, transport)
+	if err != nil {
+		log.Fatalf("failed to connect to codec service at %s: %v", addr, err)
+	}
+	defer codecClient.Close()
+	codecClient.WithEmbedBatch(codec.EmbedBatchConfig{
+		ChunkSize:    envInt("EMBED_BATCH_SIZE", codec.DefaultEmbedBatchConfig().ChunkSize),
+		Concurrency:  envInt("EMBED_BATCH_CONCURRENCY", codec.DefaultEmbedBatchConfig().Concurrency),
+		ChunkTimeout: 30 * time.Second,
+	})
+
+	fmt.Print("Fetching all evidence... ")
+	listCtx, cancel := context.WithTimeout(ctx, 60*time.Second)
+	results, err := codecClient.ListAllEvidence(listCtx)
+	cancel()
+	if err != nil {
+		log.Fatalf("list all evidence: %v", err)
+	}
+	fmt.Printf("%d items found.\n", len(results))
+
+	items := make([]evidence.Item, len(results))
+	texts := make([]string, len(results))
+	for i, r := range results {
+		items[i] = evidence.Item{ID: r.ID, Text: r.Text, MetadataJSON: r.MetadataJSON}
+		texts[i] = r.Text
+	}
+	vecs := make([][]float32, 0, len(texts))
+	embedBar := progress.NewBar(os.Stderr, "embed", len(texts), 0)
+	for start := 0; start < len(texts); start += window {
+		batch, embedErr := codecClient.EmbedBatch(ctx, texts[start:min(start+window, len(texts))])
+		if embedErr != nil {
+			embedBar.Finish("")
+			if ctx.Err() != nil {
+				fmt.Println("\nInterrupted while embedding evidence. Re-run to resume.")
+				stop()
+				os.Exit(progress.ExitInterrupted)
+			}
+			log.Fatalf("embed evidence: %v", embedErr)
+		}
+		vecs = append(vecs, batch...)
+		embedBar.Set(len(vecs), "")
+	}
+	embedBar.Finish("")
+	vecByID := make(map[string][]float32, len(items))
+	for i, item := range items {
+		vecByID[item.ID] = vecs[i]
+	}
+	return items, vecByID
+}
+
+// storedAt is when item was stored: the stored_at metadata the Python service
+// writes, else the local store's created_at.
+func storedAt(item evidence.Item) (time.Time, bool) {
+	var meta map[string]interface{}
+	if err := json.Unmarshal([]byte(item.MetadataJSON), &meta); err == nil {
+		if s, ok := meta["stored_at"].(string); ok && s != "" {
+			if t, err := time.Parse(time.RFC3339, s); err == nil {
+				return t, true
+			}
+		}
+	}
+	return item.CreatedAt, !item.CreatedAt.IsZero()
+}
+
+// #endregion load-evidence
+
 // #region helpers
 func envOr(key, fallback string) string {
 	if v := os.Getenv(key); v != "" {
@@ -169,4 +361,13 @@ func envOr(key, fallback string) string {
 	return fallback
 }
 
+func envInt(key string, fallback int) int {
+	if v := os.Getenv(key); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n >= 0 {
+			return n
+		}
+	}
+	return fallback
+}
+
 // #endregion helpers
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/calibration"
+)
+
+// #region main
+
+func main() {
+	filePath := flag.String("file", "", "path to calibration dataset (JSONL)")
+	jsonOut := flag.Bool("json", false, "output as JSON instead of table")
+	flag.Parse()
+
+	if *filePath == "" {
+		fmt.Fprintln(os.Stderr, "usage: calibrate --file path/to/calibration.jsonl [--json]")
+		os.Exit(2)
+	}
+
+	samples, err := calibration.LoadSamples(*filePath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+
+	report := calibration.Score(samples)
+	if *jsonOut {
+		if err := printJSON(report); err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+	printReport(report)
+}
+
+// #endregion main
+
+// #region output
+
+func printReport(r calibration.ScoreReport) {
+	fmt.Printf("Samples: %d total, %d labeled\n\n", r.TotalSamples, r.LabeledSamples)
+	if r.LabeledSamples == 0 {
+		fmt.Println("No labeled samples yet — fill in the \"labels\" fields and re-run.")
+		return
+	}
+
+	fmt.Printf("%-12s  %6s  %8s  %8s\n", "Signal", "Count", "MAE", "Bias")
+	fmt.Printf("%-12s+-%6s+-%8s+-%8s\n", "------------", "------", "--------", "--------")
+	for _, c := range r.Continuous {
+		if c.Count == 0 {
+			fmt.Printf("%-12s  %6d  %8s  %8s\n", c.Name, 0, "—", "—")
+			continue
+		}
+		fmt.Printf("%-12s  %6d  %8.4f  %+8.4f\n", c.Name, c.Count, c.MAE, c.Bias)
+	}
+
+	b := r.Risk
+	fmt.Printf("\n%s: %d labeled (TP=%d FP=%d TN=%d FN=%d)\n",
+		b.Name, b.Count, b.TruePositives, b.FalsePositives, b.TrueNegatives, b.FalseNegatives)
+	if b.Count > 0 {
+		fmt.Printf("  accuracy=%.2f precision=%.2f recall=%.2f\n", b.Accuracy(), b.Precision(), b.Recall())
+	}
+}
+
+func printJSON(v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal json: %w", err)
+	}
+	fmt.Println(string(data))
+	return nil
+}
+
+// #endregion output
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"math"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/ablation"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/codec"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/orchestrator"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/plan"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/projection"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/retrieval"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/state"
+)
+
+// #region ablate
+
+// runAblate implements `controller ablate [flags] prompt...`: assembles the adaptive
+// components for the prompt from the current state (state vector, prefs block,
+// matching rules, retrieved evidence), generates once with everything and once
+// with each component removed, and reports how far each ablation moved the
+// response. Read-only: nothing is committed or logged to provenance.
+func runAblate(args []string) int {
+	fs := flag.NewFlagSet("ablate", flag.ContinueOnError)
+	dbPath := fs.String("db", envOr("ADAPTIVE_DB", "adaptive_state.db"), "path to SQLite database")
+	grpcAddr := fs.String("codec", envOr("CODEC_ADDR", "localhost:50051"), "codec service address")
+	timeout := fs.Duration("timeout", 10*time.Minute, "overall timeout for all generations")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	prompt := strings.TrimSpace(strings.Join(fs.Args(), " "))
+	if prompt == "" {
+		fmt.Fprintln(os.Stderr, "usage: controller ablate [--db path] [--codec addr] [--timeout d] prompt...")
+		return 2
+	}
+
+	store, err := state.NewStore(*dbPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: open store: %v\n", err)
+		return 1
+	}
+	defer store.Close()
+	current, err := store.GetCurrent()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: load current state: %v\n", err)
+		return 1
+	}
+	prefStore, err := projection.NewPreferenceStore(store.DB())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: init preference store: %v\n", err)
+		return 1
+	}
+	profileStore, err := projection.NewProfileStore(store.DB())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: init profile store: %v\n", err)
+		return 1
+	}
+	ruleStore, err := projection.NewRuleStore(store.DB())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: init rule store: %v\n", err)
+		return 1
+	}
+	planStore, err := plan.NewPlanStore(store.DB())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: init plan store: %v\n", err)
+		return 1
+	}
+
+	client, _, err := openCodec(*grpcAddr, store.DB())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: connect codec: %v\n", err)
+		return 1
+	}
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+
+	in, err := assembleInputs(ctx, prompt, current, prefStore, profileStore, ruleStore, planStore, client)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "warning: retrieval failed, no_evidence ablation will be empty: %v\n", err)
+	}
+
+	fmt.Printf("components: prefs_block=%t rules=%d evidence=%d state_version=%s\n\n",
+		in.StateBlock != "", len(in.Rules), len(in.Evidence), current.VersionID)
+	report := ablation.Run(ctx, client, in)
+	fmt.Print(report.Format())
+
+	for _, res := range report.Results {
+		if res.Err != nil {
+			return 1
+		}
+	}
+	return 0
+}
+
+// assembleInputs builds the adaptive components for prompt the way a live turn
+// does: scoped preferences and profile projected into the state block, matching
+// rules with their templates resolved, and retrieved evidence with
+// contradiction annotations. A retrieval
+// error is returned alongside inputs that simply lack evidence.
+func assembleInputs(ctx context.Context, prompt string, current state.StateRecord, prefStore *projection.PreferenceStore,
+	profileStore *projection.ProfileStore, ruleStore *projection.RuleStore, planStore *plan.PlanStore, client *codec.CodecClient) (ablation.Inputs, error) {
+	in := ablation.Inputs{Prompt: prompt, StateVector: current.StateVector}
+	// Same scoping as a live turn: only preferences that apply in this prompt's context
+	allPrefs, _ := prefStore.List()
+	in.Preferences = projection.ScopedTo(allPrefs, projection.TurnContext(string(orchestrator.ClassifyTurn(prompt, nil).Type), prompt))
+	in.StateBlock = projection.ProjectToPrompt(in.Preferences, segmentNorm(current.StateVector, current.SegmentMap.Prefs))
+	if profile, _ := profileStore.Get(); len(profile) > 0 {
+		in.StateBlock = projection.ProjectProfile(profile) + in.StateBlock
+	}
+	if matched, _ := ruleStore.Match(prompt); len(matched) > 0 {
+		matched = projection.ResolveRules(matched, ruleVars(profileStore, allPrefs, planStore))
+		in.Rules = []string{projection.FormatRulesBlock(matched)}
+	}
+
+	retCfg := retrieval.DefaultConfig()
+	retCfg.SimilarityThreshold = retrieval.AdjustedThreshold(retCfg.SimilarityThreshold,
+		segmentNorm(current.StateVector, current.SegmentMap.Goals))
+	gateResult, err := retrieval.NewRetriever(client, retCfg).Retrieve(ctx, prompt, 1.0)
+	// Same contradiction annotations as a live turn
+	conflicts := retrieval.DetectContradictions(gateResult.Retrieved, retrieval.DefaultContradictionConfig())
+	in.Evidence = retrieval.AnnotateContradictions(gateResult.Retrieved, conflicts)
+	return in, err
+}
+
+// segmentNorm returns the L2 norm of vec over the half-open range seg.
+func segmentNorm(vec [128]float32, seg [2]int) float32 {
+	var sum float32
+	for i := seg[0]; i < seg[1]; i++ {
+		sum += vec[i] * vec[i]
+	}
+	return float32(math.Sqrt(float64(sum)))
+}
+
+// #endregion ablate
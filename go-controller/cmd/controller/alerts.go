@@ -0,0 +1,90 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/alert"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/events"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/state"
+)
+
+// #region alerts
+
+// alertRunner evaluates the ALERT_RULES file after every turn and runs the
+// actions of the rules that fire. A nil *alertRunner does nothing.
+type alertRunner struct {
+	engine *alert.Engine
+	store  *state.Store
+	client *http.Client
+}
+
+// loadAlerts reads and parses the rules file at path.
+func loadAlerts(path string, store *state.Store) (*alertRunner, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read alert rules: %w", err)
+	}
+	rules, err := alert.ParseRules(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return &alertRunner{engine: alert.NewEngine(rules), store: store, client: &http.Client{Timeout: 5 * time.Second}}, nil
+}
+
+// observe feeds a finished turn to the engine. Webhooks are posted in the
+// background so a slow receiver never holds up the next turn.
+func (a *alertRunner) observe(ev events.TurnEvent) {
+	if a == nil {
+		return
+	}
+	var norms map[string]float64
+	if current, err := a.store.GetCurrent(); err == nil {
+		norms = current.SegmentMap.Norms(current.StateVector)
+	}
+	for _, f := range a.engine.Observe(alert.FactsFromTurn(ev, norms)) {
+		log.Printf("[ALERT %s] [%s] %s", f.Rule.Level, f.TurnID, f.Message)
+		if f.Rule.Action == "webhook" {
+			go a.post(f, ev)
+		}
+	}
+}
+
+// alertPayload is the JSON body posted to webhook alerts.
+type alertPayload struct {
+	Alert    string    `json:"alert"`
+	Level    string    `json:"level"`
+	Message  string    `json:"message"`
+	Matches  int       `json:"matches"`
+	Window   int       `json:"window"`
+	TurnID   string    `json:"turn_id"`
+	Decision string    `json:"decision"`
+	Time     time.Time `json:"time"`
+}
+
+func (a *alertRunner) post(f alert.Firing, ev events.TurnEvent) {
+	body, err := json.Marshal(alertPayload{
+		Alert: f.Rule.Name, Level: f.Rule.Level, Message: f.Message, Matches: f.Matches, Window: f.Rule.Window,
+		TurnID: f.TurnID, Decision: ev.Decision, Time: time.Now().UTC(),
+	})
+	if err != nil {
+		log.Printf("alert %s: marshal webhook: %v", f.Rule.Name, err)
+		return
+	}
+	resp, err := a.client.Post(f.Rule.URL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		log.Printf("alert %s: webhook: %v", f.Rule.Name, err)
+		return
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		log.Printf("alert %s: webhook returned %s", f.Rule.Name, resp.Status)
+	}
+}
+
+// #endregion alerts
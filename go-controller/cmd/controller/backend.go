@@ -0,0 +1,47 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/codec"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/ollama"
+)
+
+// #region codec-backend
+
+// openCodec returns the codec client selected by CODEC_BACKEND: "grpc" (the
+// default) dials the Python service at addr, with the TLS, token and
+// keepalive settings of codec.TransportConfigFromEnv; "ollama" serves
+// inference from OLLAMA_URL directly and keeps evidence in db. The second result names the
+// backend for log and error messages.
+func openCodec(addr string, db *sql.DB) (*codec.CodecClient, string, error) {
+	switch backend := envOr("CODEC_BACKEND", "grpc"); backend {
+	case "grpc":
+		transport, err := codec.TransportConfigFromEnv()
+		if err != nil {
+			return nil, "", err
+		}
+		client, err := codec.NewCodecClientWithTransport(addr, transport)
+		return client, addr, err
+	case "ollama":
+		if db == nil {
+			return nil, "", fmt.Errorf("CODEC_BACKEND=ollama needs the state database for evidence")
+		}
+		def := ollama.DefaultConfig()
+		cfg := ollama.Config{
+			URL:        envOr("OLLAMA_URL", def.URL),
+			Model:      envOr("OLLAMA_MODEL", def.Model),
+			EmbedModel: envOr("EMBED_MODEL", def.EmbedModel),
+		}
+		b, err := ollama.New(cfg, db)
+		if err != nil {
+			return nil, "", err
+		}
+		return codec.NewCodecClientWithBackend(b), "ollama " + cfg.URL, nil
+	default:
+		return nil, "", fmt.Errorf("unknown CODEC_BACKEND %q (want grpc or ollama)", backend)
+	}
+}
+
+// #endregion codec-backend
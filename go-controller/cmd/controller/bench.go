@@ -0,0 +1,200 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/ablation"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/bench"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/codec"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/plan"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/projection"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/state"
+)
+
+// #region self-benchmark
+
+// selfBench holds what a benchmark run reads from and records to.
+type selfBench struct {
+	state    *state.Store
+	prefs    *projection.PreferenceStore
+	profile  *projection.ProfileStore
+	rules    *projection.RuleStore
+	plans    *plan.PlanStore
+	runs     *bench.Store
+	codec    *codec.CodecClient
+	regress  bench.RegressionConfig
+	previous int // runs loaded for the regression baseline
+}
+
+func newSelfBench(store *state.Store, prefStore *projection.PreferenceStore, profileStore *projection.ProfileStore,
+	ruleStore *projection.RuleStore, client *codec.CodecClient) (*selfBench, error) {
+	runs, err := bench.NewStore(store.DB())
+	if err != nil {
+		return nil, err
+	}
+	plans, err := plan.NewPlanStore(store.DB())
+	if err != nil {
+		return nil, err
+	}
+	cfg := bench.DefaultRegressionConfig()
+	return &selfBench{state: store, prefs: prefStore, profile: profileStore, rules: ruleStore, plans: plans,
+		runs: runs, codec: client, regress: cfg, previous: cfg.Window * 2}, nil
+}
+
+// run executes the probe set against the current state, compares the result
+// with recent runs, and records it. Nothing is committed to state or
+// provenance; a run cut short by ctx or with every probe failing is not
+// recorded, so the daemon retries it at its next check.
+func (b *selfBench) run(ctx context.Context) (bench.Run, []bench.Regression, error) {
+	current, err := b.state.GetCurrent()
+	if err != nil {
+		return bench.Run{}, nil, fmt.Errorf("load current state: %w", err)
+	}
+	rules, err := b.rules.List()
+	if err != nil {
+		return bench.Run{}, nil, err
+	}
+	prefs, _ := b.prefs.List()
+	rules = projection.ResolveRules(rules, ruleVars(b.profile, prefs, b.plans)) // score against rendered responses
+	assemble := func(ctx context.Context, prompt string) (ablation.Inputs, error) {
+		in, _ := assembleInputs(ctx, prompt, current, b.prefs, b.profile, b.rules, b.plans, b.codec) // missing evidence is not a probe failure
+		return in, nil
+	}
+	run := bench.Execute(ctx, b.codec, assemble, bench.Probes(rules), rules)
+	run.StateVersion = current.VersionID
+	if ctx.Err() != nil {
+		return run, nil, fmt.Errorf("self-benchmark interrupted: %w", ctx.Err())
+	}
+	if run.Failures == run.Probes {
+		return run, nil, fmt.Errorf("self-benchmark: all %d probes failed, run not recorded", run.Probes)
+	}
+
+	previous, err := b.runs.Recent(b.previous)
+	if err != nil {
+		return run, nil, err
+	}
+	regressions := bench.Regressions(run, previous, b.regress)
+	if run.ID, err = b.runs.Record(run); err != nil {
+		return run, regressions, err
+	}
+	return run, regressions, nil
+}
+
+// benchAlert is the note appended to the next ordinary response when a run regressed.
+func benchAlert(regressions []bench.Regression) string {
+	parts := make([]string, len(regressions))
+	for i, r := range regressions {
+		parts[i] = r.String()
+	}
+	return "[Self-benchmark] Adherence regressed: " + strings.Join(parts, "; ") +
+		". Recent learning may be hurting; see `controller bench --history`."
+}
+
+// #endregion self-benchmark
+
+// #region bench-command
+
+// runBench implements `controller bench [flags]`: runs the self-benchmark once
+// and records it, or with --history prints the recorded time series. Exits 1
+// when the run regressed against recent history, so it can gate a cron job.
+func runBench(args []string) int {
+	fs := flag.NewFlagSet("bench", flag.ContinueOnError)
+	dbPath := fs.String("db", envOr("ADAPTIVE_DB", "adaptive_state.db"), "path to SQLite database")
+	grpcAddr := fs.String("codec", envOr("CODEC_ADDR", "localhost:50051"), "codec service address")
+	timeout := fs.Duration("timeout", 15*time.Minute, "overall timeout for all probes")
+	history := fs.Int("history", 0, "print the last N recorded runs instead of running")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	store, err := state.NewStore(*dbPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: open store: %v\n", err)
+		return 1
+	}
+	defer store.Close()
+
+	if *history > 0 {
+		runs, err := bench.NewStore(store.DB())
+		if err == nil {
+			var recent []bench.Run
+			if recent, err = runs.Recent(*history); err == nil {
+				printBenchHistory(recent)
+				return 0
+			}
+		}
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+
+	prefStore, err := projection.NewPreferenceStore(store.DB())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: init preference store: %v\n", err)
+		return 1
+	}
+	profileStore, err := projection.NewProfileStore(store.DB())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: init profile store: %v\n", err)
+		return 1
+	}
+	ruleStore, err := projection.NewRuleStore(store.DB())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: init rule store: %v\n", err)
+		return 1
+	}
+	client, _, err := openCodec(*grpcAddr, store.DB())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: connect codec: %v\n", err)
+		return 1
+	}
+	defer client.Close()
+	b, err := newSelfBench(store, prefStore, profileStore, ruleStore, client)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: init bench store: %v\n", err)
+		return 1
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+	run, regressions, err := b.run(ctx)
+	fmt.Print(run.Format())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+	fmt.Printf("recorded run %d (state %s)\n", run.ID, run.StateVersion)
+	if len(regressions) > 0 {
+		fmt.Println(benchAlert(regressions))
+		return 1
+	}
+	return 0
+}
+
+// printBenchHistory prints runs oldest first, one line each.
+func printBenchHistory(runs []bench.Run) {
+	if len(runs) == 0 {
+		fmt.Println("No self-benchmark runs recorded.")
+		return
+	}
+	fmt.Printf("%-4s %-20s %-14s %10s %6s %8s  %s\n", "id", "ran_at", "state", "compliance", "rules", "failed", "model")
+	for i := len(runs) - 1; i >= 0; i-- {
+		r := runs[i]
+		comp := "-"
+		if r.Scored > 0 {
+			comp = fmt.Sprintf("%.2f", r.Compliance)
+		}
+		version := r.StateVersion
+		if len(version) > 14 {
+			version = version[:14]
+		}
+		fmt.Printf("%-4d %-20s %-14s %10s %6.2f %4d/%-3d  %s\n", r.ID, r.RanAt.Format("2006-01-02 15:04"), version,
+			comp, r.RuleAccuracy, r.Failures, r.Probes, r.Model)
+	}
+}
+
+// #endregion bench-command
@@ -0,0 +1,292 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/codec"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/eval"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/gate"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/logging"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/projection"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/sampling"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/signals"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/state"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/update"
+)
+
+// #region branch
+
+// Branch variants: what the alternate generation changes about the last turn.
+const (
+	branchNoEvidence = "noevidence" // retrieved evidence dropped; mode markers, rules and interior state kept
+	branchNoProfile  = "noprofile"  // bare prompt: no profile, preference, style or plan block
+	branchHot        = "hot"        // temperature at the top of the SAMPLING_PARAMS bounds
+)
+
+const branchUsage = "Usage: /branch [noevidence|noprofile|hot] — re-run the last turn on a state branch; /branch pick a|b keeps one side's learning."
+
+// branchTurn is what /branch needs from a finished turn to re-run it.
+type branchTurn struct {
+	TurnID       string
+	Before       state.StateRecord // state the turn started from
+	MainVersion  string            // active version after the turn
+	MainDecision string            // commit | reject | rollback | held
+	MainResponse string
+
+	Prompt      string   // preprocessed prompt, without the state block
+	GenPrompt   string   // prompt as generated: state block and strategy modifier included
+	Modifier    string   // strategy prompt modifier ("" on rule turns)
+	Markers     []string // first-pass evidence: mode markers, interior state, rules
+	Retrieved   []string // retrieved evidence the response was generated with
+	Sampling    codec.Sampling
+	Prefs       []projection.Preference // preferences scored for compliance
+	Signals     update.Signals          // mainline signals; response-derived ones are recomputed
+	SignalInput signals.ProduceInput
+}
+
+// pendingBranch is a generated branch awaiting /branch pick.
+type pendingBranch struct {
+	Turn     branchTurn
+	Variant  string
+	Response string
+	Decision string // the branch's own gate/eval outcome: commit | reject
+	Reason   string
+	Version  string // branch state version, not active; "" when its learning was rejected
+}
+
+// brancher re-runs a turn with one configuration changed and learns from the
+// alternate response on a state branch: a version whose parent is the turn's
+// starting state, inserted without becoming active.
+type brancher struct {
+	codec        *codec.CodecClient
+	store        *state.Store
+	signals      *signals.Producer
+	update       update.UpdateConfig
+	gate         *gate.Gate
+	eval         *eval.EvalHarness
+	sampling     sampling.Config
+	timeout      time.Duration // generate
+	embedTimeout time.Duration // coherence signal
+}
+
+// run generates the alternate response for t and proposes its update. The
+// local gate decides (no policy service, no pre-gate hardening); a committed
+// branch version is stored, with a provenance row under trigger_type "branch".
+func (b *brancher) run(ctx context.Context, t branchTurn, variant string) (*pendingBranch, error) {
+	prompt, s := t.GenPrompt, t.Sampling
+	evidence := append(append([]string(nil), t.Markers...), t.Retrieved...)
+	sigInput := t.SignalInput
+	switch variant {
+	case branchNoEvidence:
+		evidence = append([]string(nil), t.Markers...)
+		sigInput.Retrieved, sigInput.Gate2Count = nil, 0
+	case branchNoProfile:
+		prompt = t.Modifier + t.Prompt
+	case branchHot:
+		s.Temperature = b.sampling.MaxTemperature
+	default:
+		return nil, fmt.Errorf("unknown variant %q", variant)
+	}
+
+	genCtx, cancel := context.WithTimeout(ctx, b.timeout)
+	gen, err := b.codec.GenerateSampled(genCtx, prompt, t.Before.StateVector, evidence, nil, s)
+	cancel()
+	if err != nil {
+		return nil, fmt.Errorf("generate: %w", err)
+	}
+	turnID := t.TurnID + "-" + variant
+
+	// Turn facts (corrections, tool failures, plan progress, directions) carry
+	// over; the response-derived signals are recomputed for the branch
+	sigInput.ResponseText, sigInput.Entropy, sigInput.Logits = gen.Text, gen.Entropy, gen.Logits
+	sigCtx, sigCancel := context.WithTimeout(context.Background(), b.embedTimeout)
+	fresh := b.signals.Produce(sigCtx, sigInput)
+	sigCancel()
+	sigs := t.Signals
+	sigs.CoherenceScore, sigs.NoveltyScore, sigs.RiskFlag = fresh.CoherenceScore, fresh.NoveltyScore, fresh.RiskFlag
+	sigs.SentimentScore = projection.PreferenceComplianceScore(t.Prefs, gen.Text)
+
+	res := update.Update(t.Before, update.UpdateContext{
+		TurnID: turnID, Prompt: t.Prompt, ResponseText: gen.Text, Entropy: gen.Entropy,
+	}, sigs, evidence, b.update)
+	decision, scaled := b.gate.EvaluateDowngrade(t.Before, res.NewState, sigs, res.Metrics, gen.Entropy)
+	p := &pendingBranch{Turn: t, Variant: variant, Response: gen.Text, Decision: "reject", Reason: "gate: " + decision.Reason}
+	if decision.Commits() {
+		p.Decision, res.NewState = "commit", scaled
+		if decision.Action == "commit_scaled" {
+			res.Metrics.DeltaNorm *= decision.Scale
+		}
+		evalResult, evaluated := b.eval.RunTiered(t.Before, res.NewState, gen.Entropy)
+		if !evalResult.Passed {
+			p.Decision, p.Reason = "reject", "eval: "+evalResult.Reason
+		} else {
+			res.NewState = evaluated
+			p.Version = res.NewState.VersionID
+		}
+	}
+
+	record := logging.GateRecord{
+		TurnID:   turnID,
+		Prompt:   t.Prompt,
+		Response: gen.Text,
+		Entropy:  gen.Entropy,
+		Signals: logging.GateRecordSignals{
+			SentimentScore:      sigs.SentimentScore,
+			CoherenceScore:      sigs.CoherenceScore,
+			NoveltyScore:        sigs.NoveltyScore,
+			RiskFlag:            sigs.RiskFlag,
+			UserCorrection:      sigs.UserCorrection,
+			ToolFailure:         sigs.ToolFailure,
+			ConstraintViolation: sigs.ConstraintViolation,
+			PlanProgress:        sigs.PlanProgress,
+		},
+		DeltaNorm:     res.Metrics.DeltaNorm,
+		SegmentsHit:   res.Metrics.SegmentsHit,
+		GateAction:    decision.Action,
+		GateSoftScore: decision.SoftScore,
+		GateVetoed:    decision.Vetoed,
+		GateReason:    decision.Reason,
+		GateBreakdown: decision.BreakdownRecord(),
+		GateScale:     decision.Scale,
+		Model:         gen.Model,
+		ModelVersion:  gen.ModelVersion,
+	}
+	if !s.IsZero() {
+		record.Sampling = &logging.SamplingRecord{Temperature: s.Temperature, TopP: s.TopP, MaxTokens: s.MaxTokens}
+	}
+	signalsJSON, _ := json.Marshal(record)
+	entry := logging.ProvenanceEntry{
+		VersionID:   t.Before.VersionID,
+		TriggerType: "branch",
+		SignalsJSON: string(signalsJSON),
+		Decision:    "no_op",
+		Reason:      fmt.Sprintf("branch %s of %s (%s): %s", variant, t.TurnID, p.Decision, p.Reason),
+	}
+	if p.Version != "" {
+		entry.VersionID = p.Version
+	}
+	if err := b.store.WithTx(func(tx *sql.Tx) error {
+		if p.Version != "" {
+			if err := b.store.InsertVersionTx(tx, res.NewState); err != nil {
+				return fmt.Errorf("insert branch version: %w", err)
+			}
+		}
+		return logging.LogDecision(tx, entry)
+	}); err != nil {
+		return nil, err
+	}
+	log.Printf("[%s] branch: %s, delta_norm=%.4f, version=%s", turnID, p.Decision, res.Metrics.DeltaNorm, p.Version)
+	return p, nil
+}
+
+// pick keeps one side's learning: "a" leaves mainline as it is, "b" makes the
+// branch's outcome the active state — its version, or the turn's starting
+// state when the branch learned nothing. Refused once the state has moved on.
+func (b *brancher) pick(p *pendingBranch, side string) (string, error) {
+	t := p.Turn
+	current, err := b.store.GetCurrent()
+	if err != nil {
+		return "", fmt.Errorf("load state: %w", err)
+	}
+	if current.VersionID != t.MainVersion {
+		return "", fmt.Errorf("state moved since turn %s (active %s)", t.TurnID, current.VersionID)
+	}
+	if side == "a" {
+		if err := logging.LogDecision(b.store.DB(), logging.ProvenanceEntry{
+			VersionID:   t.MainVersion,
+			TriggerType: "branch",
+			Decision:    "no_op",
+			Reason:      fmt.Sprintf("branch %s of %s discarded: mainline kept", p.Variant, t.TurnID),
+		}); err != nil {
+			log.Printf("branch provenance error: %v", err)
+		}
+		return fmt.Sprintf("Kept mainline (%s); branch discarded.", t.MainDecision), nil
+	}
+
+	target, outcome := p.Version, "branch learning"
+	if target == "" {
+		target, outcome = t.Before.VersionID, "no learning (branch rejected)"
+	}
+	if target == current.VersionID {
+		return fmt.Sprintf("Kept branch %s; state unchanged (%s).", p.Variant, outcome), nil
+	}
+	if err := b.store.WithTx(func(tx *sql.Tx) error {
+		if err := b.store.RollbackTx(tx, target); err != nil {
+			return err
+		}
+		return logging.LogDecision(tx, logging.ProvenanceEntry{
+			VersionID:   target,
+			TriggerType: "branch",
+			Decision:    "commit",
+			Reason:      fmt.Sprintf("branch %s of %s merged over %s: %s", p.Variant, t.TurnID, t.MainVersion, outcome),
+		})
+	}); err != nil {
+		return "", fmt.Errorf("merge branch: %w", err)
+	}
+	log.Printf("[%s] branch %s merged: %s -> %s", t.TurnID, p.Variant, t.MainVersion, target)
+	return fmt.Sprintf("Kept branch %s: %s -> %s (%s).", p.Variant, t.MainVersion, target, outcome), nil
+}
+
+// render shows mainline and branch responses in two columns.
+func (p *pendingBranch) render() string {
+	left := fmt.Sprintf("A  mainline (%s)", p.Turn.MainDecision)
+	right := fmt.Sprintf("B  %s (%s)", p.Variant, p.Decision)
+	return sideBySide(left, p.Turn.MainResponse, right, p.Response, 38) +
+		"\n\n/branch pick a keeps mainline's learning; /branch pick b merges the branch's."
+}
+
+// sideBySide lays out two titled texts in columns of width runes.
+func sideBySide(leftTitle, left, rightTitle, right string, width int) string {
+	l := append([]string{leftTitle, strings.Repeat("-", width)}, wrapText(left, width)...)
+	r := append([]string{rightTitle, strings.Repeat("-", width)}, wrapText(right, width)...)
+	var b strings.Builder
+	for i := 0; i < max(len(l), len(r)); i++ {
+		var a, c string
+		if i < len(l) {
+			a = l[i]
+		}
+		if i < len(r) {
+			c = r[i]
+		}
+		if i > 0 {
+			b.WriteByte('\n')
+		}
+		b.WriteString(strings.TrimRight(fmt.Sprintf("%s%s | %s", a, strings.Repeat(" ", max(width-len([]rune(a)), 0)), c), " "))
+	}
+	return b.String()
+}
+
+// wrapText word-wraps s to width runes, keeping paragraph breaks; words longer
+// than width are split.
+func wrapText(s string, width int) []string {
+	var lines []string
+	for _, para := range strings.Split(s, "\n") {
+		line := ""
+		for _, word := range strings.Fields(para) {
+			for len([]rune(word)) > width {
+				if line != "" {
+					lines, line = append(lines, line), ""
+				}
+				lines, word = append(lines, string([]rune(word)[:width])), string([]rune(word)[width:])
+			}
+			switch {
+			case line == "":
+				line = word
+			case len([]rune(line))+1+len([]rune(word)) <= width:
+				line += " " + word
+			default:
+				lines, line = append(lines, line), word
+			}
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// #endregion branch
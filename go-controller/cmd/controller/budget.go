@@ -0,0 +1,44 @@
+package main
+
+import (
+	"log"
+	"time"
+
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/budget"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/settings"
+)
+
+// #region stage-estimates
+
+// stageEstimateKey is the settings key holding a turn stage's average duration.
+func stageEstimateKey(stage string) string {
+	return "budget." + stage + "_estimate"
+}
+
+// budgetStages are the stages whose averages decide what a turn can afford.
+var budgetStages = []string{budget.StageGenerate, budget.StageSearch, budget.StageReflection, budget.StageSummarize}
+
+// loadStageEstimates seeds p with the stage averages the last run saved, so the
+// first turns after a restart skip the same optional stages it would have.
+func loadStageEstimates(p *budget.Planner, ss *settings.Store) {
+	for _, stage := range budgetStages {
+		d, err := ss.Duration(stageEstimateKey(stage), 0)
+		if err != nil {
+			log.Printf("budget: %v", err)
+			continue
+		}
+		p.Seed(stage, d)
+	}
+}
+
+// saveStageEstimates stores p's stage averages, rounded to 100ms so small
+// drifts do not add a history entry every run.
+func saveStageEstimates(p *budget.Planner, ss *settings.Store) {
+	for stage, d := range p.Estimates() {
+		if err := ss.SetDuration(stageEstimateKey(stage), d.Round(100*time.Millisecond), "budget", "stage average at shutdown"); err != nil {
+			log.Printf("budget: %v", err)
+		}
+	}
+}
+
+// #endregion stage-estimates
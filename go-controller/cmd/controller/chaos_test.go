@@ -0,0 +1,120 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"path/filepath"
+	"testing"
+
+	pb "github.com/danielpatrickdp/adaptive-state/go-controller/gen/adaptive"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/chaos"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/codec"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/update"
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+)
+
+// #region helpers
+
+// codecServer is a well-behaved codec service; the RPCs it leaves out answer
+// Unimplemented, which the turn treats like any other codec failure.
+type codecServer struct {
+	pb.UnimplementedCodecServiceServer
+}
+
+func (codecServer) Generate(_ context.Context, in *pb.GenerateRequest) (*pb.GenerateResponse, error) {
+	return &pb.GenerateResponse{Text: "reply to " + in.Prompt, Entropy: 0.4}, nil
+}
+
+func (codecServer) Embed(_ context.Context, in *pb.EmbedRequest) (*pb.EmbedResponse, error) {
+	emb := make([]float32, 64)
+	for i := range emb {
+		emb[i] = float32((len(in.Text)+i)%7) - 3
+	}
+	return &pb.EmbedResponse{Embedding: emb}, nil
+}
+
+func (codecServer) Search(_ context.Context, _ *pb.SearchRequest) (*pb.SearchResponse, error) {
+	return &pb.SearchResponse{Results: []*pb.SearchResult{{Id: "ev_3f2504e0-4f89-41d3-9a0c-0305e82c3301", Text: "earlier exchange", Score: 0.8}}}, nil
+}
+
+func (codecServer) StoreEvidence(_ context.Context, _ *pb.StoreEvidenceRequest) (*pb.StoreEvidenceResponse, error) {
+	return &pb.StoreEvidenceResponse{Id: "ev_" + uuid.NewString()}, nil
+}
+
+func (codecServer) Handshake(_ context.Context, _ *pb.HandshakeRequest) (*pb.HandshakeResponse, error) {
+	return &pb.HandshakeResponse{ProtocolVersion: codec.ProtocolVersion, SchemaFingerprint: codec.SchemaFingerprint()}, nil
+}
+
+// chaosController starts the daemon against codecServer with CHAOS_FAULTS set
+// to faults, in a temporary workspace.
+func chaosController(t *testing.T, faults string, seed int) *controller {
+	t.Helper()
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := grpc.NewServer()
+	pb.RegisterCodecServiceServer(srv, codecServer{})
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+
+	dir := t.TempDir()
+	t.Chdir(dir)
+	t.Setenv("ADAPTIVE_DB", filepath.Join(dir, "chaos.db"))
+	t.Setenv("CODEC_ADDR", lis.Addr().String())
+	t.Setenv("CHAOS_FAULTS", faults)
+	t.Setenv("CHAOS_SEED", fmt.Sprint(seed))
+	t.Setenv("STREAM_OUTPUT", "0")
+
+	c := newController(controllerOptions{})
+	t.Cleanup(c.close)
+	return c
+}
+
+func script(n int) []string {
+	prompts := make([]string, n)
+	for i := range prompts {
+		prompts[i] = fmt.Sprintf("scripted prompt %d about topic %d", i, i%5)
+	}
+	return prompts
+}
+
+// harness drives c's turn pipeline, the function poll hands inbox messages to.
+func harness(c *controller) *chaos.Harness {
+	return chaos.NewHarness(c.store, func(prompt string) { c.turn(prompt) }, c.faults, update.DefaultUpdateConfig().MaxStateNorm)
+}
+
+// #endregion helpers
+
+// #region chaos-tests
+
+func TestChaos_CleanSessionCommits(t *testing.T) {
+	c := chaosController(t, "generate=0", 1)
+	report := harness(c).Run(script(10))
+	if len(report.Violations) > 0 {
+		t.Fatalf("unexpected violations: %v", report.Violations)
+	}
+	if report.Count(chaos.OutcomeCommit) == 0 {
+		t.Fatalf("expected commits without faults, got %+v", report.Turns)
+	}
+}
+
+func TestChaos_FaultySessionNeverCorruptsState(t *testing.T) {
+	c := chaosController(t, "generate=0.2,embed=0.3,search=0.4,store_evidence=0.5,"+
+		"db_exec=0.05,db_query=0.05,db_begin=0.1,db_commit=0.2", 42)
+	report := harness(c).Run(script(80))
+
+	if len(report.Violations) > 0 {
+		t.Fatalf("state invariants violated under faults:\n%v", report.Violations)
+	}
+	if n := report.Count(chaos.OutcomePanic); n > 0 {
+		t.Fatalf("%d turns panicked: %+v", n, report.Turns)
+	}
+	if report.Count(chaos.OutcomeKept) == 0 || report.Count(chaos.OutcomeCommit) == 0 {
+		t.Errorf("expected a mix of kept and committed turns; injected %s", c.faults.Summary())
+	}
+}
+
+// #endregion chaos-tests
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/clusters"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/codec"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/evidence"
+)
+
+// #region clustering
+
+// runClustering re-clusters all evidence: every item is listed and embedded
+// through the codec, grouped by k-means and labelled, and the result replaces
+// the stored clustering that retrieval reads topic summaries from.
+func runClustering(ctx context.Context, c *codec.CodecClient, store *clusters.Store, cfg clusters.Config) error {
+	start := time.Now()
+	listed, err := c.ListAllEvidence(ctx)
+	if err != nil {
+		return fmt.Errorf("list evidence: %w", err)
+	}
+	var all []codec.SearchResult
+	for _, r := range listed {
+		if !evidence.IsCompacted(r.MetadataJSON) { // its summary is clustered instead
+			all = append(all, r)
+		}
+	}
+	texts := make([]string, len(all))
+	for i, r := range all {
+		texts[i] = r.Text
+	}
+	vecs, err := c.EmbedBatch(ctx, texts)
+	if err != nil {
+		return fmt.Errorf("embed evidence: %w", err)
+	}
+	items := make([]clusters.Item, len(all))
+	for i, r := range all {
+		items[i] = clusters.Item{ID: r.ID, Text: r.Text, Vec: vecs[i]}
+	}
+	built := clusters.Build(items, cfg)
+	if err := store.Replace(built); err != nil {
+		return err
+	}
+	largest := ""
+	if len(built) > 0 {
+		largest = fmt.Sprintf(", largest %q (%d)", built[0].Label, len(built[0].Members))
+	}
+	log.Printf("evidence clustering: %d items in %d clusters%s (%s)",
+		len(items), len(built), largest, time.Since(start).Round(time.Millisecond))
+	return nil
+}
+
+// #endregion clustering
@@ -0,0 +1,211 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/codec"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/compaction"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/evidence"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/graph"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/logging"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/state"
+)
+
+// #region compaction
+
+// runCompaction merges groups of near-duplicate old evidence: every item is
+// listed and embedded through the codec, each group is summarized in summary
+// mode, and the summary is stored as new evidence with summary_of edges to
+// the originals, which are then marked compacted_into it. Compacted
+// originals stay in the codec, out of search, until eviction takes them.
+// A group that fails is skipped; the run goes on with the next. A cancelled
+// run stops between groups and is not recorded, so the next idle check
+// retries. A summary whose originals were not all marked (a run that stopped
+// after storing it) is finished first from its own summary_of metadata,
+// without summarizing its group again.
+func runCompaction(ctx context.Context, c *codec.CodecClient, store *state.Store, gs *graph.GraphStore, cs *compaction.Store, cfg compaction.Config, timeout time.Duration) error {
+	start := time.Now().UTC()
+	all, err := c.ListAllEvidence(ctx)
+	if err != nil {
+		return fmt.Errorf("list evidence: %w", err)
+	}
+	texts := make([]string, len(all))
+	for i, r := range all {
+		texts[i] = r.Text
+	}
+	vecs, err := c.EmbedBatch(ctx, texts)
+	if err != nil {
+		return fmt.Errorf("embed evidence: %w", err)
+	}
+
+	current, err := store.GetCurrent()
+	if err != nil {
+		return fmt.Errorf("load state: %w", err)
+	}
+	claimed, compacted := resumeCompaction(ctx, c, store, gs, cs, current, all, timeout)
+
+	cands := make([]compaction.Candidate, len(all))
+	for i, r := range all {
+		cands[i] = compaction.Candidate{
+			ID: r.ID, Text: r.Text, Vec: vecs[i],
+			StoredAt: metadataStoredAt(r.MetadataJSON),
+			Skip: evidence.ParseAnnotation(r.MetadataJSON).Pinned || claimed[r.ID] ||
+				evidence.IsCompacted(r.MetadataJSON) || evidence.IsSummary(r.MetadataJSON),
+		}
+	}
+	groups := compaction.Groups(cands, cfg, start)
+
+	summarized := 0
+	for _, group := range groups {
+		if ctx.Err() != nil {
+			return fmt.Errorf("evidence compaction interrupted after %d summaries: %w", summarized, ctx.Err())
+		}
+		n, err := compactGroup(ctx, c, store, gs, cs, current, group, cfg, timeout)
+		if err != nil {
+			log.Printf("evidence compaction: group of %d from %s skipped: %v", len(group), group[0].ID, err)
+		}
+		if n > 0 {
+			summarized++
+			compacted += n
+		}
+	}
+	if err := cs.FinishRun(start, summarized, compacted); err != nil {
+		return err
+	}
+	log.Printf("evidence compaction: %d items in %d groups merged into %d summaries (%s)",
+		compacted, len(groups), summarized, time.Since(start).Round(time.Millisecond))
+	return nil
+}
+
+// compactGroup summarizes one group and returns how many originals were
+// marked compacted. Provenance records the summary and its originals
+// (trigger_type "evidence_compaction").
+func compactGroup(ctx context.Context, c *codec.CodecClient, store *state.Store, gs *graph.GraphStore, cs *compaction.Store, current state.StateRecord, group []compaction.Candidate, cfg compaction.Config, timeout time.Duration) (int, error) {
+	genCtx, cancel := context.WithTimeout(ctx, timeout)
+	res, err := c.Generate(genCtx, compaction.Prompt(group, cfg), current.StateVector, []string{compaction.Marker}, nil)
+	cancel()
+	if err != nil {
+		return 0, fmt.Errorf("generate summary: %w", err)
+	}
+	summary := strings.TrimSpace(res.Text)
+	if summary == "" {
+		return 0, errors.New("empty summary")
+	}
+
+	ids := make([]string, len(group))
+	for i, m := range group {
+		ids[i] = m.ID
+	}
+	meta, _ := json.Marshal(map[string]any{
+		evidence.MetaSummaryOf: strings.Join(ids, ","),
+		"stored_at":            time.Now().UTC().Format(time.RFC3339),
+		"storage":              "summary",
+	})
+	storeCtx, cancel := context.WithTimeout(ctx, timeout)
+	summaryID, err := c.StoreEvidence(storeCtx, summary, string(meta))
+	cancel()
+	if err != nil {
+		return 0, fmt.Errorf("store summary: %w", err)
+	}
+	compacted, err := markGroup(ctx, c, gs, cs, summaryID, ids, ids, timeout)
+	if err != nil {
+		return 0, err
+	}
+	logCompaction(store, current, summaryID, ids, compacted, fmt.Sprintf("merged %d near-duplicate items into %s", compacted, summaryID))
+	return compacted, nil
+}
+
+// resumeCompaction finishes the summaries in all whose originals are listed
+// but not yet marked compacted, and returns every original a listed summary
+// claims (so it is not grouped again) and how many were marked now.
+func resumeCompaction(ctx context.Context, c *codec.CodecClient, store *state.Store, gs *graph.GraphStore, cs *compaction.Store, current state.StateRecord, all []codec.SearchResult, timeout time.Duration) (map[string]bool, int) {
+	listed := make(map[string]string, len(all))
+	for _, r := range all {
+		listed[r.ID] = r.MetadataJSON
+	}
+	claimed := make(map[string]bool)
+	resumed := 0
+	for _, r := range all {
+		members := evidence.SummaryOf(r.MetadataJSON)
+		var pending []string
+		for _, id := range members {
+			claimed[id] = true
+			if meta, ok := listed[id]; ok && !evidence.IsCompacted(meta) {
+				pending = append(pending, id)
+			}
+		}
+		if len(pending) == 0 {
+			continue
+		}
+		n, err := markGroup(ctx, c, gs, cs, r.ID, members, pending, timeout)
+		if err != nil {
+			log.Printf("evidence compaction: resume %s: %v", r.ID, err)
+			continue
+		}
+		resumed += n
+		logCompaction(store, current, r.ID, members, n, fmt.Sprintf("finished marking %d of %d items compacted into %s", n, len(pending), r.ID))
+	}
+	return claimed, resumed
+}
+
+// markGroup records that summaryID replaced members, then gives each of
+// pending a summary_of edge from it and marks it compacted_into it, and
+// returns how many were marked. Every step is idempotent, so a group that
+// stopped partway is finished by calling it again.
+func markGroup(ctx context.Context, c *codec.CodecClient, gs *graph.GraphStore, cs *compaction.Store, summaryID string, members, pending []string, timeout time.Duration) (int, error) {
+	if err := cs.Record(summaryID, members); err != nil {
+		return 0, err
+	}
+	compacted := 0
+	for _, id := range pending {
+		edge := graph.NewEdge(summaryID, id, graph.EdgeSummaryOf)
+		if err := gs.AddEdge(edge.SourceID, edge.TargetID, edge.EdgeType, edge.Weight); err != nil {
+			log.Printf("evidence compaction: edge %s → %s: %v", summaryID, id, err)
+		}
+		markCtx, cancel := context.WithTimeout(ctx, timeout)
+		_, found, err := c.UpdateEvidenceMetadata(markCtx, id, map[string]any{evidence.MetaCompactedInto: summaryID})
+		cancel()
+		if err != nil || !found {
+			log.Printf("evidence compaction: mark %s compacted: found=%v err=%v", id, found, err)
+			continue
+		}
+		compacted++
+	}
+	return compacted, nil
+}
+
+// logCompaction writes the evidence_compaction provenance row for summaryID.
+func logCompaction(store *state.Store, current state.StateRecord, summaryID string, members []string, compacted int, reason string) {
+	signals, _ := json.Marshal(map[string]any{"summary_id": summaryID, "members": members, "compacted": compacted})
+	if err := logging.LogDecision(store.DB(), logging.ProvenanceEntry{
+		VersionID:    current.VersionID,
+		TriggerType:  "evidence_compaction",
+		SignalsJSON:  string(signals),
+		EvidenceRefs: summaryID + "," + strings.Join(members, ","),
+		Decision:     "commit",
+		Reason:       reason,
+	}); err != nil {
+		log.Printf("evidence compaction provenance error: %v", err)
+	}
+}
+
+// metadataStoredAt reads the RFC3339 stored_at from evidence metadata; zero
+// if it is missing or malformed.
+func metadataStoredAt(metadataJSON string) time.Time {
+	var meta struct {
+		StoredAt string `json:"stored_at"`
+	}
+	if json.Unmarshal([]byte(metadataJSON), &meta) != nil {
+		return time.Time{}
+	}
+	t, _ := time.Parse(time.RFC3339, meta.StoredAt)
+	return t
+}
+
+// #endregion compaction
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/logging"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/state"
+)
+
+// #region confirm-learning
+
+// heldUpdate is an update that passed the gate and eval but crossed a
+// CONFIRM_LEARNING threshold, so it waits for /accept or /decline. Only the
+// state commit waits: the turn's evidence and reflection were already saved.
+type heldUpdate struct {
+	turnID       string
+	proposed     state.StateRecord // ParentID is the version the update was built on
+	record       logging.GateRecord
+	evidenceRefs string
+	reason       string // gate and eval reasons, for the commit's provenance
+}
+
+// question asks the user to keep the held update.
+func (h heldUpdate) question() string {
+	return fmt.Sprintf("This will notably change how I respond (%s). Keep it? /accept or /decline", h.record.Confirmation.Reason)
+}
+
+// answerHeldUpdate settles a held update: "kept" commits it, "declined" and
+// "unanswered" drop it. Either way the answer is logged to provenance under
+// trigger_type "learning_confirmation", with the turn's GateRecord. Returns
+// the reply text and whether the state moved.
+func answerHeldUpdate(store *state.Store, h heldUpdate, answer string) (string, bool) {
+	h.record.Confirmation = &logging.ConfirmationRecord{Reason: h.record.Confirmation.Reason, Answer: answer}
+	signalsJSON, _ := json.Marshal(h.record)
+	entry := logging.ProvenanceEntry{
+		VersionID:    h.proposed.ParentID,
+		TriggerType:  "learning_confirmation",
+		SignalsJSON:  string(signalsJSON),
+		EvidenceRefs: h.evidenceRefs,
+		Decision:     "reject",
+	}
+	if answer != "kept" {
+		what := "declined by user"
+		if answer == "unanswered" {
+			what = "unanswered"
+		}
+		entry.Reason = fmt.Sprintf("%s (turn %s): %s", what, h.turnID, h.record.Confirmation.Reason)
+		if err := logging.LogDecision(store.DB(), entry); err != nil {
+			log.Printf("[%s] learning confirmation provenance error: %v", h.turnID, err)
+		}
+		log.Printf("[%s] held update %s: %s", h.turnID, answer, h.proposed.VersionID)
+		return "Okay, I won't learn from that.", false
+	}
+
+	current, err := store.GetCurrent()
+	if err != nil {
+		log.Printf("[%s] held update: %v", h.turnID, err)
+		return "Could not read the current state; nothing was kept.", false
+	}
+	if current.VersionID != h.proposed.ParentID {
+		// The state moved since (rollback, branch switch); the delta no longer applies
+		entry.Reason = fmt.Sprintf("kept by user (turn %s) but state moved to %s", h.turnID, current.VersionID)
+		entry.VersionID = current.VersionID
+		if err := logging.LogDecision(store.DB(), entry); err != nil {
+			log.Printf("[%s] learning confirmation provenance error: %v", h.turnID, err)
+		}
+		return "The state has changed since that turn, so that update no longer applies.", false
+	}
+
+	entry.VersionID, entry.Decision = h.proposed.VersionID, "commit"
+	entry.Reason = fmt.Sprintf("kept by user (turn %s): %s", h.turnID, h.reason)
+	if err := store.WithTx(func(tx *sql.Tx) error {
+		if err := store.CommitStateTx(tx, h.proposed); err != nil {
+			return fmt.Errorf("commit state: %w", err)
+		}
+		return logging.LogDecision(tx, entry)
+	}); err != nil {
+		log.Printf("[%s] held update commit error (rolled back): %v", h.turnID, err)
+		return "Could not save that update.", false
+	}
+	log.Printf("[%s] held update kept: %s (%s)", h.turnID, h.proposed.VersionID, h.record.Confirmation.Reason)
+	return "Kept. I'll respond with that in mind.", true
+}
+
+// #endregion confirm-learning
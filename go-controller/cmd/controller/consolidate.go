@@ -0,0 +1,213 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/codec"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/consolidation"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/eval"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/gate"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/graph"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/interior"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/logging"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/state"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/update"
+	"github.com/google/uuid"
+)
+
+// #region consolidation
+
+// sleeper runs consolidation ("sleep") cycles: it replays the provenance
+// logged since the last cycle, re-scores the evidence retrieved again and
+// again, strengthens the edges between items retrieved together, decays the
+// segments no turn reinforced, and writes one reflection over the whole
+// stretch. The cycle is logged under trigger_type "consolidation".
+type sleeper struct {
+	codec    *codec.CodecClient
+	store    *state.Store
+	graph    *graph.GraphStore
+	interior *interior.InteriorStore
+	runs     *consolidation.Store
+	gate     *gate.Gate
+	eval     *eval.EvalHarness
+	cfg      consolidation.Config
+	timeout  time.Duration
+}
+
+// replay returns the digest of the provenance logged since the last cycle,
+// the newest cfg.Window entries at most.
+func (s *sleeper) replay() (consolidation.Digest, error) {
+	var after int64
+	last, err := s.runs.Last()
+	if err != nil {
+		return consolidation.Digest{}, err
+	}
+	if last != nil {
+		after = last.ToID
+	}
+	entries, err := logging.ListProvenance(s.store.DB(), s.cfg.Window, 0)
+	if err != nil {
+		return consolidation.Digest{}, err
+	}
+	var recent []logging.ProvenanceEntry
+	for _, e := range entries {
+		if e.ID > after {
+			recent = append(recent, e)
+		}
+	}
+	slices.Reverse(recent)
+	return consolidation.Replay(recent), nil
+}
+
+// run performs one cycle and returns the reply text and whether the state
+// moved. Re-scoring is best effort and sets absolute values, so a cycle rerun
+// after a failed write repeats it harmlessly. The strengthened edges, decayed
+// state and reflection commit together with the provenance entry and the run
+// record, so a failed write leaves nothing to count twice.
+func (s *sleeper) run(ctx context.Context, trigger string) (string, bool) {
+	start := time.Now().UTC()
+	d, err := s.replay()
+	if err != nil {
+		log.Printf("consolidation: %v", err)
+		return fmt.Sprintf("Could not replay provenance: %v", err), false
+	}
+	if d.Turns == 0 {
+		return "Nothing to consolidate: no turns since the last cycle.", false
+	}
+	current, err := s.store.GetCurrent()
+	if err != nil {
+		log.Printf("consolidation: %v", err)
+		return "Could not read the current state; nothing was consolidated.", false
+	}
+
+	// Re-score: evidence retrieved in several turns counts as recent again
+	ids := d.Rehearsed(s.cfg)
+	rehearsed := 0
+	for _, id := range ids {
+		metaCtx, cancel := context.WithTimeout(ctx, s.timeout)
+		_, found, err := s.codec.UpdateEvidenceMetadata(metaCtx, id, map[string]any{
+			consolidation.MetaRehearsedAt: start.Format(time.RFC3339),
+			consolidation.MetaRehearsals:  d.Retrievals[id],
+		})
+		cancel()
+		if err != nil || !found {
+			log.Printf("consolidation: re-score %s: found=%v err=%v", id, found, err)
+			continue
+		}
+		rehearsed++
+	}
+
+	// Decay the segments no replayed turn reinforced, gated and evaluated like a turn's update
+	proposed := current
+	decayedVec, decayed := consolidation.Decay(current.StateVector, current.SegmentMap, d, s.cfg)
+	decision, outcome, reason := gate.GateDecision{}, "no_op", "no segment to decay"
+	if len(decayed) > 0 {
+		proposed = state.StateRecord{
+			VersionID:   uuid.New().String(),
+			ParentID:    current.VersionID,
+			StateVector: decayedVec,
+			SegmentMap:  current.SegmentMap,
+			CreatedAt:   time.Now().UTC(),
+		}
+		metrics := update.Metrics{DeltaNorm: consolidation.DeltaNorm(current.StateVector, decayedVec), SegmentsHit: decayed}
+		var scaled state.StateRecord
+		decision, scaled = s.gate.EvaluateDowngrade(current, proposed, update.Signals{}, metrics, 0)
+		outcome, reason = "reject", "gate: "+decision.Reason
+		if decision.Commits() {
+			evalResult, evaluated := s.eval.RunTiered(current, scaled, 0)
+			if evalResult.Passed {
+				outcome, reason, proposed = "commit", decision.Reason+"; eval: "+evalResult.Reason, evaluated
+			} else {
+				reason = "eval: " + evalResult.Reason
+			}
+		}
+		if outcome != "commit" {
+			proposed, decayed = current, nil
+		}
+	}
+
+	// One reflection over the whole stretch, saved as interior state
+	var reflection string
+	if len(d.Exchanges) > 0 {
+		genCtx, cancel := context.WithTimeout(ctx, s.timeout)
+		res, err := s.codec.Generate(genCtx, consolidation.Prompt(d, s.cfg), proposed.StateVector, []string{consolidation.Marker}, nil)
+		cancel()
+		if err != nil {
+			log.Printf("consolidation: reflection error (non-fatal): %v", err)
+		} else {
+			reflection = strings.TrimSpace(res.Text)
+		}
+	}
+
+	turnID := fmt.Sprintf("sleep-%d", d.ToID)
+	strengthened := 0
+	if err := s.store.WithTx(func(tx *sql.Tx) error {
+		// Strengthen the association between items retrieved together repeatedly
+		strengthened = 0
+		edges := s.graph.WithTx(tx)
+		for _, p := range d.RehearsedPairs(s.cfg) {
+			if err := edges.IncrementEdge(p[0], p[1], graph.EdgeCoRetrieval, s.cfg.EdgeBoost); err != nil {
+				log.Printf("consolidation: strengthen %s — %s: %v", p[0], p[1], err)
+				continue
+			}
+			strengthened++
+		}
+		if outcome == "commit" {
+			if err := s.store.CommitStateTx(tx, proposed); err != nil {
+				return fmt.Errorf("commit state: %w", err)
+			}
+		}
+		if reflection != "" {
+			if err := s.interior.WithTx(tx).Save(turnID, reflection); err != nil {
+				return fmt.Errorf("save reflection: %w", err)
+			}
+		}
+		signals, _ := json.Marshal(map[string]any{
+			"trigger": trigger, "from_id": d.FromID, "to_id": d.ToID, "turns": d.Turns, "decisions": d.Decisions,
+			"rehearsed": rehearsed, "strengthened": strengthened, "decayed": decayed,
+			"gate_action": decision.Action, "outcome": outcome, "reflection": reflection != "",
+		})
+		if err := logging.LogDecision(tx, logging.ProvenanceEntry{
+			VersionID:    proposed.VersionID,
+			TriggerType:  "consolidation",
+			SignalsJSON:  string(signals),
+			EvidenceRefs: strings.Join(ids, ","),
+			Decision:     outcome,
+			Reason:       fmt.Sprintf("consolidated %d turns (%s): %s", d.Turns, trigger, reason),
+		}); err != nil {
+			return err
+		}
+		return s.runs.WithTx(tx).Record(consolidation.Run{
+			StartedAt: start, FromID: d.FromID, ToID: d.ToID, Turns: d.Turns,
+			Rehearsed: rehearsed, Strengthened: strengthened, Decayed: strings.Join(decayed, ","),
+			VersionID: proposed.VersionID, Reflection: reflection != "",
+		})
+	}); err != nil {
+		log.Printf("[%s] consolidation write error (rolled back): %v", turnID, err)
+		return fmt.Sprintf("Could not record the consolidation: %v", err), false
+	}
+
+	log.Printf("[%s] consolidation (%s): %d turns, %d items re-scored, %d edges strengthened, decayed [%s] (%s), reflection=%v (%s)",
+		turnID, trigger, d.Turns, rehearsed, strengthened, strings.Join(decayed, ","), reason, reflection != "", time.Since(start).Round(time.Millisecond))
+	reply := fmt.Sprintf("Consolidated %d turns: %d memories re-scored, %d links strengthened", d.Turns, rehearsed, strengthened)
+	if len(decayed) > 0 {
+		reply += fmt.Sprintf(", %s decayed (%s -> %s)", strings.Join(decayed, ", "), current.VersionID, proposed.VersionID)
+	} else if outcome == "reject" {
+		reply += ", decay rejected (" + reason + ")"
+	}
+	if reflection != "" {
+		reply += ".\n" + reflection
+	} else {
+		reply += "."
+	}
+	return reply, outcome == "commit"
+}
+
+// #endregion consolidation
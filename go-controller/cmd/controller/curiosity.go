@@ -0,0 +1,191 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/codec"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/curiosity"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/graph"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/logging"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/state"
+)
+
+// #region curiosity
+
+// explorer works through the curiosity queue: each open question from a
+// reflection is searched on the web, the model writes up what the results
+// say, and the write-up is stored as evidence with a curiosity edge from
+// the evidence of the turn that raised it.
+type explorer struct {
+	codec   *codec.CodecClient
+	store   *state.Store
+	graph   *graph.GraphStore
+	queue   *curiosity.Store
+	cfg     curiosity.Config
+	timeout time.Duration
+}
+
+// run explores up to n pending questions, oldest first, and returns how many
+// were answered and how many failed. A failed question stays pending until
+// it has failed cfg.MaxAttempts times.
+func (x *explorer) run(ctx context.Context, n int) (explored, failed int, err error) {
+	items, err := x.queue.Pending(n)
+	if err != nil {
+		return 0, 0, err
+	}
+	if len(items) == 0 {
+		return 0, 0, nil
+	}
+	current, err := x.store.GetCurrent()
+	if err != nil {
+		return 0, 0, fmt.Errorf("load state: %w", err)
+	}
+	for _, item := range items {
+		if ctx.Err() != nil {
+			break
+		}
+		findingID, err := x.explore(ctx, current, item)
+		if err != nil {
+			failed++
+			log.Printf("curiosity: %q not explored: %v", item.Question, err)
+			if err := x.queue.Failed(item.ID, err, x.cfg.MaxAttempts); err != nil {
+				log.Printf("curiosity queue error: %v", err)
+			}
+			continue
+		}
+		explored++
+		log.Printf("curiosity: explored %q (%s) → %s", item.Question, item.Query, findingID)
+	}
+	return explored, failed, nil
+}
+
+// explore answers one question and returns the stored finding's ID. The
+// queue update, the curiosity edge and the provenance entry (trigger_type
+// "curiosity_exploration") commit together. An item that already has a
+// finding, stored by an attempt whose commit failed, is committed with it
+// rather than searched again.
+func (x *explorer) explore(ctx context.Context, current state.StateRecord, item curiosity.Item) (string, error) {
+	findingID, reason := item.FindingID, fmt.Sprintf("explored a question from %s with a finding stored earlier", item.TurnID)
+	var urls []string
+	if findingID == "" {
+		var err error
+		findingID, urls, err = x.find(ctx, current, item)
+		if err != nil {
+			return "", err
+		}
+		reason = fmt.Sprintf("explored a question from %s with %d search results", item.TurnID, len(urls))
+	}
+
+	refs := findingID
+	if item.SourceID != "" {
+		refs = item.SourceID + "," + findingID
+	}
+	signals, _ := json.Marshal(map[string]any{"question": item.Question, "query": item.Query, "finding_id": findingID, "sources": urls})
+	if err := x.store.WithTx(func(tx *sql.Tx) error {
+		if err := x.queue.WithTx(tx).Explored(item.ID, findingID); err != nil {
+			return err
+		}
+		if item.SourceID != "" {
+			edge := graph.NewEdge(item.SourceID, findingID, graph.EdgeCuriosity)
+			if err := x.graph.WithTx(tx).AddEdge(edge.SourceID, edge.TargetID, edge.EdgeType, edge.Weight); err != nil {
+				log.Printf("curiosity: edge %s → %s: %v", item.SourceID, findingID, err)
+			}
+		}
+		return logging.LogDecision(tx, logging.ProvenanceEntry{
+			VersionID:    current.VersionID,
+			TriggerType:  "curiosity_exploration",
+			SignalsJSON:  string(signals),
+			EvidenceRefs: refs,
+			Decision:     "commit",
+			Reason:       reason,
+		})
+	}); err != nil {
+		return "", fmt.Errorf("record finding %s: %w", findingID, err)
+	}
+	return findingID, nil
+}
+
+// find searches the web for item's query, has the model write up what the
+// results say and stores the write-up as evidence. It returns the finding's
+// ID, recorded on the item before anything else, and the result URLs.
+func (x *explorer) find(ctx context.Context, current state.StateRecord, item curiosity.Item) (string, []string, error) {
+	searchCtx, cancel := context.WithTimeout(ctx, x.timeout)
+	results, err := x.codec.WebSearch(searchCtx, item.Query, x.cfg.SearchResults)
+	cancel()
+	if err != nil {
+		return "", nil, fmt.Errorf("web search: %w", err)
+	}
+	if len(results) == 0 {
+		return "", nil, errors.New("no search results")
+	}
+	sources := make([]curiosity.Source, len(results))
+	urls := make([]string, len(results))
+	for i, r := range results {
+		sources[i] = curiosity.Source{Title: r.Title, Snippet: r.Snippet, URL: r.URL}
+		urls[i] = r.URL
+	}
+
+	genCtx, cancel := context.WithTimeout(ctx, x.timeout)
+	res, err := x.codec.Generate(genCtx, curiosity.Prompt(item, sources, x.cfg), current.StateVector, nil, nil)
+	cancel()
+	if err != nil {
+		return "", nil, fmt.Errorf("generate finding: %w", err)
+	}
+	finding := strings.TrimSpace(res.Text)
+	if finding == "" {
+		return "", nil, errors.New("empty finding")
+	}
+
+	meta, _ := json.Marshal(map[string]any{
+		"turn_id":            item.TurnID,
+		"curiosity_question": item.Question,
+		"sources":            strings.Join(urls, " "),
+		"stored_at":          time.Now().UTC().Format(time.RFC3339),
+		"storage":            "curiosity",
+	})
+	storeCtx, cancel := context.WithTimeout(ctx, x.timeout)
+	findingID, err := x.codec.StoreEvidence(storeCtx, item.Question+"\n"+finding, string(meta))
+	cancel()
+	if err != nil {
+		return "", nil, fmt.Errorf("store finding: %w", err)
+	}
+	if err := x.queue.Found(item.ID, findingID); err != nil {
+		return "", nil, err
+	}
+	return findingID, urls, nil
+}
+
+// exploreCommand implements /explore [n]: explore up to n (default 1)
+// queued questions now.
+func exploreCommand(ctx context.Context, x *explorer, arg string, frozen bool) string {
+	n := 1
+	if arg != "" {
+		v, err := strconv.Atoi(arg)
+		if err != nil || v < 1 {
+			return "usage: /explore [n] (n ≥ 1 questions to explore)"
+		}
+		n = v
+	}
+	if frozen {
+		return "Learning is frozen; nothing explored."
+	}
+	explored, failed, err := x.run(ctx, n)
+	if err != nil {
+		return fmt.Sprintf("curiosity queue error: %v", err)
+	}
+	counts, _ := x.queue.Counts()
+	if explored+failed == 0 {
+		return "No open questions queued."
+	}
+	return fmt.Sprintf("Explored %d question(s), %d failed. %d still open.", explored, failed, counts[curiosity.StatusPending])
+}
+
+// #endregion curiosity
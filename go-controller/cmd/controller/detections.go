@@ -0,0 +1,85 @@
+package main
+
+import (
+	"log"
+	"math/rand"
+
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/projection"
+)
+
+// #region detection-sampling
+
+// sampleDetection picks at most one of the turn's detections to confirm with the
+// user, each turn with probability rate. Returns the recorded sample, or nil.
+func sampleDetection(labels *projection.DetectionLabelStore, detected []projection.DetectionLabel, rate float64, turnID string) *projection.DetectionLabel {
+	if rate <= 0 || len(detected) == 0 || rand.Float64() >= rate {
+		return nil
+	}
+	d := detected[rand.Intn(len(detected))]
+	d.TurnID = turnID
+	sample, err := labels.Ask(d)
+	if err != nil {
+		log.Printf("[%s] detection label error: %v", turnID, err)
+		return nil
+	}
+	log.Printf("[%s] %s detection sampled for confirmation: %q", turnID, sample.Detector, sample.Value)
+	return &sample
+}
+
+// answerDetection handles "/yes" and "/no" to a sampled detection: the answer is
+// recorded as a label, and a denial undoes what the detector stored. Returns the
+// reply text.
+func answerDetection(labels *projection.DetectionLabelStore, sample projection.DetectionLabel, confirmed bool,
+	prefs *projection.PreferenceStore, rules *projection.RuleStore, profile *projection.ProfileStore) string {
+	label := projection.LabelDenied
+	if confirmed {
+		label = projection.LabelConfirmed
+	}
+	if err := labels.Decide(sample.ID, label); err != nil {
+		log.Printf("detection label error: %v", err)
+	}
+	log.Printf("%s detection %s: %q", sample.Detector, label, sample.Value)
+	if confirmed {
+		return "Thanks, keeping it."
+	}
+
+	var err error
+	switch sample.Detector {
+	case projection.DetectorPreference:
+		var existing []projection.Preference
+		existing, err = prefs.List()
+		for _, p := range existing {
+			if err == nil && p.Text == sample.Value && p.Scope == sample.Field {
+				err = prefs.Retire(p.ID)
+			}
+		}
+	case projection.DetectorRule:
+		err = rules.Remove(sample.Field)
+	case projection.DetectorIdentity:
+		// Restore the value the detection replaced (cleared if there was none)
+		var history []projection.ProfileChange
+		history, err = profile.History(sample.Field, 1)
+		if err == nil && len(history) > 0 && history[0].NewValue == sample.Value {
+			err = profile.Set(sample.Field, history[0].OldValue, "denied")
+		}
+	}
+	if err != nil {
+		log.Printf("undo %s detection: %v", sample.Detector, err)
+		return "Noted, but I could not undo it."
+	}
+	return "Got it, I've undone that."
+}
+
+// #endregion detection-sampling
+
+// #region detection-arbitration
+
+// heldArbitration is a prompt whose detections were too close to call, held
+// until the user answers the arbitration's question with "/as <intent>". The
+// prompt is kept as typed, before preprocessing, and runs again once answered.
+type heldArbitration struct {
+	prompt      string
+	arbitration projection.Arbitration
+}
+
+// #endregion detection-arbitration
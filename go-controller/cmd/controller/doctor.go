@@ -0,0 +1,381 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/codec"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/eval"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/gate"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/state"
+	_ "modernc.org/sqlite"
+)
+
+// #region doctor-types
+
+// doctorCheck is one self-test result. Status is "ok", "warn", or "fail";
+// Fix is an actionable suggestion shown for warn/fail.
+type doctorCheck struct {
+	Name   string
+	Status string
+	Detail string
+	Fix    string
+}
+
+// expectedTables are created by the stores the daemon opens at startup.
+var expectedTables = []string{
+	"state_versions", "active_state", "provenance_log",
+	"preferences", "rules", "interior_state", "evidence_edges", "strategy_outcomes",
+}
+
+// maxWALBytes is the WAL size above which doctor suggests a checkpoint.
+const maxWALBytes = 64 << 20
+
+// #endregion doctor-types
+
+// #region doctor
+
+// runDoctor implements `controller doctor`: validates the DB, codec service, and
+// config without modifying anything, and prints a fix for each problem found.
+// Exit code is 1 if any check fails.
+func runDoctor(args []string) int {
+	fs := flag.NewFlagSet("doctor", flag.ContinueOnError)
+	dbPath := fs.String("db", envOr("ADAPTIVE_DB", "adaptive_state.db"), "path to SQLite database")
+	grpcAddr := fs.String("codec", envOr("CODEC_ADDR", "localhost:50051"), "codec service address")
+	timeout := fs.Duration("timeout", 10*time.Second, "per-RPC timeout for codec checks")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	var checks []doctorCheck
+	if g, _, err := gateConfigFromEnv(); err != nil {
+		checks = append(checks, doctorCheck{"config/env", "fail", err.Error(), "fix the variable or policy file; the controller refuses to start with it"})
+	} else {
+		checks = append(checks, checkConfig(g, evalConfigFromEnv(), state.DefaultSegmentMap())...)
+	}
+
+	db, dbChecks := checkDatabase(*dbPath)
+	checks = append(checks, dbChecks...)
+	if db != nil {
+		defer db.Close()
+	}
+
+	checks = append(checks, checkCodec(*grpcAddr, *timeout, db)...)
+
+	failed := 0
+	for _, c := range checks {
+		fmt.Printf("[%-4s] %-18s %s\n", strings.ToUpper(c.Status), c.Name, c.Detail)
+		if c.Status != "ok" && c.Fix != "" {
+			fmt.Printf("       %-18s fix: %s\n", "", c.Fix)
+		}
+		if c.Status == "fail" {
+			failed++
+		}
+	}
+	if failed > 0 {
+		fmt.Printf("\n%d check(s) failed\n", failed)
+		return 1
+	}
+	fmt.Println("\nall checks passed")
+	return 0
+}
+
+// #endregion doctor
+
+// #region doctor-config
+
+// checkConfig verifies gate/eval cap ordering and the segment layout of the
+// config the daemon would run with (see gateConfigFromEnv). Gate caps should be
+// at or below eval caps so the gate rejects before eval has to roll back.
+func checkConfig(g gate.GateConfig, e eval.EvalConfig, sm state.SegmentMap) []doctorCheck {
+	var checks []doctorCheck
+
+	if g.MaxStateNorm > e.MaxStateNorm {
+		checks = append(checks, doctorCheck{"config/state-cap", "warn",
+			fmt.Sprintf("gate MaxStateNorm %.1f > eval MaxStateNorm %.1f", g.MaxStateNorm, e.MaxStateNorm),
+			"lower the gate cap to at most the eval cap so oversized states are rejected, not rolled back"})
+	} else {
+		checks = append(checks, doctorCheck{"config/state-cap", "ok",
+			fmt.Sprintf("gate %.1f <= eval %.1f", g.MaxStateNorm, e.MaxStateNorm), ""})
+	}
+
+	for _, seg := range []string{"prefs", "goals", "heuristics", "risk"} {
+		limit, capped := g.SegmentCap(seg)
+		if !capped {
+			continue
+		}
+		if evalLimit := e.SegmentLimit(seg); limit > evalLimit {
+			checks = append(checks, doctorCheck{"config/" + seg + "-cap", "warn",
+				fmt.Sprintf("gate %s segment cap %.1f > eval %s segment norm %.1f", seg, limit, seg, evalLimit),
+				"lower the gate segment cap to at most the eval segment norm"})
+		} else {
+			checks = append(checks, doctorCheck{"config/" + seg + "-cap", "ok",
+				fmt.Sprintf("gate %.1f <= eval segment cap %.1f", limit, evalLimit), ""})
+		}
+	}
+
+	if g.MaxDeltaNorm > g.MaxStateNorm {
+		checks = append(checks, doctorCheck{"config/delta-cap", "warn",
+			fmt.Sprintf("MaxDeltaNorm %.1f > MaxStateNorm %.1f", g.MaxDeltaNorm, g.MaxStateNorm),
+			"a single update should not be allowed to exceed the whole-state cap"})
+	} else {
+		checks = append(checks, doctorCheck{"config/delta-cap", "ok",
+			fmt.Sprintf("%.1f <= state cap %.1f", g.MaxDeltaNorm, g.MaxStateNorm), ""})
+	}
+
+	segs := [][2]int{sm.Prefs, sm.Goals, sm.Heuristics, sm.Risk}
+	next := 0
+	contiguous := true
+	for _, s := range segs {
+		if s[0] != next || s[1] <= s[0] {
+			contiguous = false
+		}
+		next = s[1]
+	}
+	if !contiguous || next != 128 {
+		checks = append(checks, doctorCheck{"config/segments", "fail",
+			fmt.Sprintf("segment map %v does not tile [0,128)", segs),
+			"fix state.DefaultSegmentMap so segments are contiguous and cover the 128-dim vector"})
+	} else {
+		checks = append(checks, doctorCheck{"config/segments", "ok", "4 contiguous segments over 128 dims", ""})
+	}
+	return checks
+}
+
+// #endregion doctor-config
+
+// #region doctor-db
+
+// checkDatabase opens the DB read-only-in-spirit (no schema creation) and checks
+// presence, integrity, expected tables, active state, and WAL health.
+// Returns the open DB for later checks, or nil if it could not be opened.
+func checkDatabase(path string) (*sql.DB, []doctorCheck) {
+	var checks []doctorCheck
+	if _, err := os.Stat(path); err != nil {
+		return nil, append(checks, doctorCheck{"db/file", "fail", err.Error(),
+			"set ADAPTIVE_DB to the daemon's database, or start the controller once to create it"})
+	}
+	db, err := sql.Open("sqlite", path)
+	if err == nil {
+		err = db.Ping()
+	}
+	if err != nil {
+		return nil, append(checks, doctorCheck{"db/open", "fail", err.Error(), "check file permissions and that the path is a SQLite database"})
+	}
+	checks = append(checks, doctorCheck{"db/file", "ok", path, ""})
+
+	var integrity string
+	if err := db.QueryRow("PRAGMA integrity_check").Scan(&integrity); err != nil || integrity != "ok" {
+		detail := integrity
+		if err != nil {
+			detail = err.Error()
+		}
+		checks = append(checks, doctorCheck{"db/integrity", "fail", detail,
+			"stop the daemon, back up the file, and recover with `sqlite3 db .recover`"})
+	} else {
+		checks = append(checks, doctorCheck{"db/integrity", "ok", "integrity_check ok", ""})
+	}
+
+	var userVersion int
+	_ = db.QueryRow("PRAGMA user_version").Scan(&userVersion)
+	var missing []string
+	for _, t := range expectedTables {
+		var name string
+		if err := db.QueryRow("SELECT name FROM sqlite_master WHERE type='table' AND name=?", t).Scan(&name); err != nil {
+			missing = append(missing, t)
+		}
+	}
+	if !hasColumn(db, "rules", "expires_at") && !contains(missing, "rules") {
+		missing = append(missing, "rules.expires_at")
+	}
+	if len(missing) > 0 {
+		checks = append(checks, doctorCheck{"db/schema", "warn",
+			fmt.Sprintf("user_version=%d, missing: %s", userVersion, strings.Join(missing, ", ")),
+			"start the controller once; stores create and migrate their tables on startup"})
+	} else {
+		checks = append(checks, doctorCheck{"db/schema", "ok",
+			fmt.Sprintf("user_version=%d, %d tables present", userVersion, len(expectedTables)), ""})
+	}
+
+	var active string
+	if err := db.QueryRow("SELECT version_id FROM active_state WHERE id = 1").Scan(&active); err != nil {
+		checks = append(checks, doctorCheck{"db/active-state", "warn", "no active state pointer",
+			"start the controller once to create the initial state"})
+	} else {
+		checks = append(checks, doctorCheck{"db/active-state", "ok", shortVersion(active), ""})
+	}
+
+	var pending, dead int
+	if err := db.QueryRow(`SELECT COALESCE(SUM(status = 'pending'), 0), COALESCE(SUM(status = 'dead'), 0) FROM write_queue`).Scan(&pending, &dead); err == nil {
+		switch {
+		case dead > 0:
+			checks = append(checks, doctorCheck{"db/write-queue", "warn", fmt.Sprintf("%d writes given up, %d pending", dead, pending),
+				"inspect `SELECT kind, last_error FROM write_queue WHERE status = 'dead'`; fix the cause, then reset status to 'pending'"})
+		case pending > 0:
+			checks = append(checks, doctorCheck{"db/write-queue", "warn", fmt.Sprintf("%d failed writes pending retry", pending),
+				"check the codec is reachable; the controller retries while idle"})
+		default:
+			checks = append(checks, doctorCheck{"db/write-queue", "ok", "no failed writes queued", ""})
+		}
+	}
+
+	var mode string
+	_ = db.QueryRow("PRAGMA journal_mode").Scan(&mode)
+	walSize := int64(0)
+	if fi, err := os.Stat(path + "-wal"); err == nil {
+		walSize = fi.Size()
+	}
+	switch {
+	case !strings.EqualFold(mode, "wal"):
+		checks = append(checks, doctorCheck{"db/wal", "warn", fmt.Sprintf("journal_mode=%s", mode),
+			"the controller enables WAL on open; another tool may have reset it"})
+	case walSize > maxWALBytes:
+		checks = append(checks, doctorCheck{"db/wal", "warn", fmt.Sprintf("WAL file is %d MB", walSize>>20),
+			"stop long-running readers, then run `PRAGMA wal_checkpoint(TRUNCATE)`"})
+	default:
+		checks = append(checks, doctorCheck{"db/wal", "ok", fmt.Sprintf("journal_mode=wal, WAL %d KB", walSize>>10), ""})
+	}
+	return db, checks
+}
+
+func hasColumn(db *sql.DB, table, column string) bool {
+	rows, err := db.Query(fmt.Sprintf("PRAGMA table_info(%s)", table))
+	if err != nil {
+		return false
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var cid, notNull, pk int
+		var name, typ string
+		var dflt sql.NullString
+		if rows.Scan(&cid, &name, &typ, &notNull, &dflt, &pk) == nil && name == column {
+			return true
+		}
+	}
+	return false
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func shortVersion(id string) string {
+	if len(id) > 8 {
+		return "active " + id[:8]
+	}
+	return "active " + id
+}
+
+// #endregion doctor-db
+
+// #region doctor-codec
+
+// checkCodec exercises the codec RPCs the turn loop depends on (Embed, Handshake,
+// Search, ListAllEvidence) and, when db is available, counts graph edges whose endpoints
+// no longer exist in the evidence store. Generate is not exercised (too slow).
+func checkCodec(addr string, timeout time.Duration, db *sql.DB) []doctorCheck {
+	client, name, err := openCodec(addr, db)
+	if err != nil {
+		return []doctorCheck{{"codec/connect", "fail", err.Error(), "check CODEC_ADDR and CODEC_BACKEND"}}
+	}
+	defer client.Close()
+
+	var checks []doctorCheck
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	emb, err := client.Embed(ctx, "doctor self-test")
+	cancel()
+	if err != nil {
+		hint := fmt.Sprintf("start the inference service (python -m adaptive_inference.server) listening on %s", addr)
+		if name != addr {
+			hint = "start Ollama (ollama serve) at OLLAMA_URL and pull OLLAMA_MODEL and EMBED_MODEL"
+		}
+		return append(checks, doctorCheck{"codec/embed", "fail", err.Error(), hint})
+	}
+	checks = append(checks, doctorCheck{"codec/connect", "ok", name, ""})
+
+	ctx, cancel = context.WithTimeout(context.Background(), timeout)
+	info, err := client.Handshake(ctx)
+	cancel()
+	switch {
+	case errors.Is(err, codec.ErrProtocolMismatch):
+		checks = append(checks, doctorCheck{"codec/protocol", "fail", err.Error(),
+			"regenerate bindings from proto/adaptive.proto (scripts/gen-proto.sh) and restart both sides"})
+	case err != nil:
+		checks = append(checks, doctorCheck{"codec/protocol", "warn", err.Error(), "handshake did not complete; compatibility unchecked"})
+	default:
+		checks = append(checks, doctorCheck{"codec/protocol", "ok",
+			fmt.Sprintf("protocol %d, schema %s", info.ProtocolVersion, info.SchemaFingerprint), ""})
+	}
+
+	segSize := state.DefaultSegmentMap().Prefs[1] - state.DefaultSegmentMap().Prefs[0]
+	if len(emb) < segSize {
+		checks = append(checks, doctorCheck{"codec/embed-dim", "fail",
+			fmt.Sprintf("embedding has %d dims, need >= %d for direction vectors", len(emb), segSize),
+			"configure an embedding model with at least segment-size dimensions"})
+	} else {
+		checks = append(checks, doctorCheck{"codec/embed-dim", "ok",
+			fmt.Sprintf("%d dims (>= segment size %d)", len(emb), segSize), ""})
+	}
+
+	ctx, cancel = context.WithTimeout(context.Background(), timeout)
+	_, err = client.Search(ctx, "doctor self-test", 1, 0.0)
+	cancel()
+	if err != nil {
+		checks = append(checks, doctorCheck{"codec/search", "fail", err.Error(), "check the evidence store (ChromaDB) path and permissions"})
+	} else {
+		checks = append(checks, doctorCheck{"codec/search", "ok", "search responded", ""})
+	}
+
+	ctx, cancel = context.WithTimeout(context.Background(), timeout)
+	all, err := client.ListAllEvidence(ctx)
+	cancel()
+	if err != nil {
+		return append(checks, doctorCheck{"codec/list", "warn", err.Error(), "upgrade the inference service; ListAllEvidence is needed for memory review"})
+	}
+	checks = append(checks, doctorCheck{"codec/list", "ok", fmt.Sprintf("%d evidence items", len(all)), ""})
+
+	if db == nil {
+		return checks
+	}
+	known := make(map[string]bool, len(all))
+	for _, r := range all {
+		known[r.ID] = true
+	}
+	rows, err := db.Query("SELECT source_id, target_id FROM evidence_edges")
+	if err != nil {
+		return append(checks, doctorCheck{"graph/orphans", "warn", err.Error(), "start the controller once to create evidence_edges"})
+	}
+	defer rows.Close()
+	total, orphans := 0, 0
+	for rows.Next() {
+		var src, dst string
+		if rows.Scan(&src, &dst) != nil {
+			continue
+		}
+		total++
+		if !known[src] || !known[dst] {
+			orphans++
+		}
+	}
+	if orphans > 0 {
+		checks = append(checks, doctorCheck{"graph/orphans", "warn",
+			fmt.Sprintf("%d of %d edges reference missing evidence", orphans, total),
+			"edges to deleted evidence are harmless but waste walks; they decay away, or delete them from evidence_edges"})
+	} else {
+		checks = append(checks, doctorCheck{"graph/orphans", "ok", fmt.Sprintf("%d edges, none orphaned", total), ""})
+	}
+	return checks
+}
+
+// #endregion doctor-codec
@@ -0,0 +1,207 @@
+package main
+
+import (
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/eval"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/gate"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/graph"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/interior"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/orchestrator"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/projection"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/state"
+)
+
+// #region helpers
+
+// statuses maps each check name to its status.
+func statuses(checks []doctorCheck) map[string]string {
+	out := make(map[string]string, len(checks))
+	for _, c := range checks {
+		out[c.Name] = c.Status
+	}
+	return out
+}
+
+// wantStatuses fails t for every check in want whose status differs in got.
+func wantStatuses(t *testing.T, got []doctorCheck, want map[string]string) {
+	t.Helper()
+	have := statuses(got)
+	for name, status := range want {
+		if have[name] != status {
+			t.Errorf("%s: status %q, want %q (checks %v)", name, have[name], status, have)
+		}
+	}
+}
+
+// controllerDB creates the database the controller leaves behind on startup.
+func controllerDB(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "adaptive_state.db")
+	store, err := state.NewStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	if _, err := store.CreateInitialState(state.DefaultSegmentMap()); err != nil {
+		t.Fatal(err)
+	}
+	db := store.DB()
+	if _, err := projection.NewPreferenceStore(db); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := projection.NewRuleStore(db); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := interior.NewInteriorStore(db); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := graph.NewGraphStore(db); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := orchestrator.NewOrchestrator(db); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// #endregion helpers
+
+// #region config-tests
+
+func TestCheckConfig(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy string // GATE_POLICY file content, if any
+		adjust func(g *gate.GateConfig, e *eval.EvalConfig, sm *state.SegmentMap)
+		want   map[string]string
+	}{
+		{
+			name: "defaults",
+			want: map[string]string{"config/state-cap": "ok", "config/delta-cap": "ok", "config/segments": "ok"},
+		},
+		{
+			name:   "policy raises the gate state cap over eval",
+			policy: "max_state_norm: 80\n",
+			want:   map[string]string{"config/state-cap": "warn", "config/delta-cap": "ok"},
+		},
+		{
+			name:   "policy lets one update exceed the state cap",
+			policy: "max_delta_norm: 60\n",
+			want:   map[string]string{"config/state-cap": "ok", "config/delta-cap": "warn"},
+		},
+		{
+			name: "gate segment cap over the eval segment norm",
+			adjust: func(g *gate.GateConfig, e *eval.EvalConfig, _ *state.SegmentMap) {
+				g.SegmentCaps = map[string]float32{"prefs": e.SegmentLimit("prefs") + 1}
+			},
+			want: map[string]string{"config/prefs-cap": "warn"},
+		},
+		{
+			name: "segments leave a gap",
+			adjust: func(_ *gate.GateConfig, _ *eval.EvalConfig, sm *state.SegmentMap) {
+				sm.Goals[0]++
+			},
+			want: map[string]string{"config/segments": "fail"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.policy != "" {
+				path := filepath.Join(t.TempDir(), "gate-policy.yaml")
+				if err := os.WriteFile(path, []byte(tt.policy), 0o600); err != nil {
+					t.Fatal(err)
+				}
+				t.Setenv("GATE_POLICY", path)
+			}
+			g, _, err := gateConfigFromEnv()
+			if err != nil {
+				t.Fatalf("gate config: %v", err)
+			}
+			e, sm := evalConfigFromEnv(), state.DefaultSegmentMap()
+			if tt.adjust != nil {
+				tt.adjust(&g, &e, &sm)
+			}
+			wantStatuses(t, checkConfig(g, e, sm), tt.want)
+		})
+	}
+}
+
+func TestGateConfigFromEnv_Invalid(t *testing.T) {
+	for name, env := range map[string][2]string{
+		"downgrade":      {"GATE_DOWNGRADE", "no_such_veto"},
+		"missing policy": {"GATE_POLICY", filepath.Join(t.TempDir(), "missing.yaml")},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Setenv(env[0], env[1])
+			if _, _, err := gateConfigFromEnv(); err == nil {
+				t.Errorf("%s=%s: expected an error", env[0], env[1])
+			}
+		})
+	}
+}
+
+// #endregion config-tests
+
+// #region db-tests
+
+func TestCheckDatabase(t *testing.T) {
+	tests := []struct {
+		name  string
+		setup func(t *testing.T) string // returns the database path
+		want  map[string]string
+	}{
+		{
+			name:  "missing file",
+			setup: func(t *testing.T) string { return filepath.Join(t.TempDir(), "missing.db") },
+			want:  map[string]string{"db/file": "fail"},
+		},
+		{
+			name:  "controller database",
+			setup: controllerDB,
+			want: map[string]string{"db/file": "ok", "db/integrity": "ok", "db/schema": "ok",
+				"db/active-state": "ok", "db/wal": "ok"},
+		},
+		{
+			name: "empty database",
+			setup: func(t *testing.T) string {
+				path := filepath.Join(t.TempDir(), "empty.db")
+				db, err := sql.Open("sqlite", path)
+				if err != nil {
+					t.Fatal(err)
+				}
+				defer db.Close()
+				if _, err := db.Exec("CREATE TABLE unrelated (id INTEGER)"); err != nil {
+					t.Fatal(err)
+				}
+				return path
+			},
+			want: map[string]string{"db/file": "ok", "db/schema": "warn", "db/active-state": "warn", "db/wal": "warn"},
+		},
+		{
+			name: "not a database",
+			setup: func(t *testing.T) string {
+				path := filepath.Join(t.TempDir(), "garbage.db")
+				if err := os.WriteFile(path, []byte("definitely not sqlite, just some bytes padded out"), 0o600); err != nil {
+					t.Fatal(err)
+				}
+				return path
+			},
+			want: map[string]string{"db/open": "fail"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, checks := checkDatabase(tt.setup(t))
+			if db != nil {
+				defer db.Close()
+			}
+			wantStatuses(t, checks, tt.want)
+		})
+	}
+}
+
+// #endregion db-tests
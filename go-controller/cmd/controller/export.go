@@ -0,0 +1,72 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/export"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/plan"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/projection"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/state"
+)
+
+// #region state-exporter
+
+// stateExporter keeps the signed hot-state export current: rebuilt at startup
+// and after every commit, written to path (when set) and served by GET /export.
+type stateExporter struct {
+	key   []byte
+	path  string
+	topN  int
+	store *state.Store
+	prefs *projection.PreferenceStore
+	plans *plan.PlanStore
+
+	mu     sync.Mutex
+	latest []byte
+}
+
+// refresh rebuilds and re-signs the export; failures are logged and the
+// previous export is kept.
+func (e *stateExporter) refresh() {
+	data, err := e.build()
+	if err != nil {
+		log.Printf("state export error: %v", err)
+		return
+	}
+	e.mu.Lock()
+	e.latest = data
+	e.mu.Unlock()
+	if e.path != "" {
+		if err := export.WriteFile(e.path, data); err != nil {
+			log.Printf("state export error: %v", err)
+		}
+	}
+}
+
+func (e *stateExporter) build() ([]byte, error) {
+	current, err := e.store.GetCurrent()
+	if err != nil {
+		return nil, fmt.Errorf("load state: %w", err)
+	}
+	prefs, err := e.prefs.List()
+	if err != nil {
+		return nil, fmt.Errorf("load preferences: %w", err)
+	}
+	active, err := e.plans.Active()
+	if err != nil {
+		return nil, fmt.Errorf("load plan: %w", err)
+	}
+	return export.Sign(export.Build(current, prefs, active, e.topN, time.Now()), e.key)
+}
+
+// Latest returns the last signed export, or nil before the first refresh.
+func (e *stateExporter) Latest() []byte {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.latest
+}
+
+// #endregion state-exporter
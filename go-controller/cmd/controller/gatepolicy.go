@@ -0,0 +1,148 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/eval"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/gate"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/replay"
+)
+
+// #region env-config
+
+// gateConfigFromEnv builds the gate config the daemon runs with: the defaults,
+// GATE_DOWNGRADE, then the GATE_POLICY file over them. policy is nil without
+// a file.
+func gateConfigFromEnv() (gate.GateConfig, *gatePolicy, error) {
+	config := gate.DefaultGateConfig()
+	var err error
+	config.Downgrade, err = gate.ParseDowngrade(os.Getenv("GATE_DOWNGRADE"), envInt("GATE_DOWNGRADE_PERCENT", 25))
+	if err != nil {
+		return gate.GateConfig{}, nil, fmt.Errorf("invalid GATE_DOWNGRADE: %w", err)
+	}
+	var policy *gatePolicy
+	if path := os.Getenv("GATE_POLICY"); path != "" {
+		if policy, config, err = loadGatePolicy(path, config); err != nil {
+			return gate.GateConfig{}, nil, fmt.Errorf("invalid GATE_POLICY: %w", err)
+		}
+	}
+	return config, policy, nil
+}
+
+// evalConfigFromEnv builds the eval config the daemon runs with.
+func evalConfigFromEnv() eval.EvalConfig {
+	config := eval.DefaultEvalConfig()
+	config.WarnMargin = float32(envInt("EVAL_WARN_PERCENT", 20)) / 100 // 0 = binary pass/fail
+	return config
+}
+
+// #endregion env-config
+
+// #region gate-policy
+
+// gatePolicy keeps the live gates in step with the GATE_POLICY file. The main
+// loop calls check between turns; it reloads on SIGHUP or when the file's
+// modification time changes. A policy that fails to parse is logged and the
+// gates keep the last good one. A nil *gatePolicy does nothing.
+type gatePolicy struct {
+	path      string
+	base      gate.GateConfig // env configuration the file overrides
+	preGate   *gate.PreGate
+	gate      *gate.Gate
+	hardened  *gate.Gate
+	anomalies *replay.AnomalyRecorder
+
+	hash    string // content hash of the loaded file, logged in provenance
+	modTime time.Time
+	hup     chan os.Signal
+}
+
+// loadGatePolicy reads the policy at path over base. The returned gatePolicy
+// is not yet attached to gates; see attach.
+func loadGatePolicy(path string, base gate.GateConfig) (*gatePolicy, gate.GateConfig, error) {
+	p := &gatePolicy{path: path, base: base}
+	config, err := p.load()
+	if err != nil {
+		return nil, gate.GateConfig{}, err
+	}
+	return p, config, nil
+}
+
+// attach installs the SIGHUP handler and the components a reload updates.
+func (p *gatePolicy) attach(preGate *gate.PreGate, g, hardened *gate.Gate, anomalies *replay.AnomalyRecorder) {
+	if p == nil {
+		return
+	}
+	p.preGate, p.gate, p.hardened, p.anomalies = preGate, g, hardened, anomalies
+	p.hup = make(chan os.Signal, 1)
+	signal.Notify(p.hup, syscall.SIGHUP)
+}
+
+// load parses the file and records its hash and modification time.
+func (p *gatePolicy) load() (gate.GateConfig, error) {
+	info, err := os.Stat(p.path)
+	if err != nil {
+		return gate.GateConfig{}, fmt.Errorf("stat gate policy: %w", err)
+	}
+	data, err := os.ReadFile(p.path)
+	if err != nil {
+		return gate.GateConfig{}, fmt.Errorf("read gate policy: %w", err)
+	}
+	config, err := gate.ParsePolicy(data, p.base)
+	if err != nil {
+		return gate.GateConfig{}, fmt.Errorf("%s: %w", p.path, err)
+	}
+	sum := sha256.Sum256(data)
+	p.hash, p.modTime = hex.EncodeToString(sum[:8]), info.ModTime()
+	return config, nil
+}
+
+// check reloads the policy if SIGHUP arrived or the file changed.
+func (p *gatePolicy) check() {
+	if p == nil {
+		return
+	}
+	trigger := ""
+	select {
+	case <-p.hup:
+		trigger = "SIGHUP"
+	default:
+		if info, err := os.Stat(p.path); err == nil && !info.ModTime().Equal(p.modTime) {
+			trigger = "file changed"
+		}
+	}
+	if trigger == "" {
+		return
+	}
+	previous := p.hash
+	config, err := p.load()
+	if err != nil {
+		log.Printf("gate policy reload (%s) failed, keeping %s: %v", trigger, previous, err)
+		if info, statErr := os.Stat(p.path); statErr == nil {
+			p.modTime = info.ModTime() // retry on the next change, not every poll
+		}
+		return
+	}
+	p.gate.SetConfig(config)
+	p.hardened.SetConfig(p.preGate.Hardened(config))
+	p.anomalies.SetGateConfig(config)
+	log.Printf("gate policy reloaded (%s): %s -> %s, max_delta_norm=%.2f, risk_segment_cap=%.2f, disabled vetoes %v",
+		trigger, previous, p.hash, config.MaxDeltaNorm, config.RiskSegmentCap, config.DisabledVetoes)
+}
+
+// Hash returns the loaded policy's content hash, or "" without a policy.
+func (p *gatePolicy) Hash() string {
+	if p == nil {
+		return ""
+	}
+	return p.hash
+}
+
+// #endregion gate-policy
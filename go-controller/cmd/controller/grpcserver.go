@@ -0,0 +1,242 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/danielpatrickdp/adaptive-state/go-controller/gen/controller"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/logging"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/state"
+)
+
+// #region grpc-server
+
+// provenanceFollowInterval is how often StreamProvenance with follow checks for
+// new entries.
+const provenanceFollowInterval = time.Second
+
+// controllerServer implements the ControllerService from proto/controller.proto.
+// Turns and rollbacks go through the same queue as the HTTP API, so they are
+// serialized with the daemon loop; reads go straight to the store.
+type controllerServer struct {
+	pb.UnimplementedControllerServiceServer
+	inbox *queueInbox
+	store *state.Store
+}
+
+// newGRPCServer builds the --grpc server. A non-empty token is required as
+// "authorization: Bearer <token>" metadata on every call.
+func newGRPCServer(inbox *queueInbox, store *state.Store, token string) *grpc.Server {
+	srv := grpc.NewServer(
+		grpc.UnaryInterceptor(func(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+			if err := checkToken(ctx, token); err != nil {
+				return nil, err
+			}
+			return handler(ctx, req)
+		}),
+		grpc.StreamInterceptor(func(srv any, ss grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+			if err := checkToken(ss.Context(), token); err != nil {
+				return err
+			}
+			return handler(srv, ss)
+		}),
+	)
+	pb.RegisterControllerServiceServer(srv, &controllerServer{inbox: inbox, store: store})
+	return srv
+}
+
+func checkToken(ctx context.Context, token string) error {
+	if token == "" {
+		return nil
+	}
+	md, _ := metadata.FromIncomingContext(ctx)
+	for _, v := range md.Get("authorization") {
+		if bearerMatches(v, token) {
+			return nil
+		}
+	}
+	return status.Error(codes.Unauthenticated, "missing or wrong bearer token")
+}
+
+func (s *controllerServer) Turn(ctx context.Context, req *pb.TurnRequest) (*pb.TurnResponse, error) {
+	if strings.TrimSpace(req.GetPrompt()) == "" {
+		return nil, status.Error(codes.InvalidArgument, "prompt must not be empty")
+	}
+	resp, err := s.submit(ctx, req.GetPrompt())
+	if err != nil {
+		return nil, err
+	}
+	out := &pb.TurnResponse{Reply: resp.Reply, Replies: resp.Replies}
+	if ev := resp.Turn; ev != nil {
+		out.TurnId, out.Decision, out.Reason = ev.TurnID, ev.Decision, ev.Reason
+		out.VersionBefore, out.VersionAfter = ev.VersionBefore, ev.VersionAfter
+		out.Entropy, out.EvalScale = ev.Entropy, ev.EvalScale
+	}
+	return out, nil
+}
+
+func (s *controllerServer) GetCurrentState(context.Context, *pb.GetCurrentStateRequest) (*pb.StateVersion, error) {
+	current, err := s.store.GetCurrent()
+	if err != nil {
+		log.Printf("grpc: state: %v", err)
+		return nil, status.Error(codes.Internal, "could not load the current state")
+	}
+	return stateVersionPB(current), nil
+}
+
+func (s *controllerServer) Rollback(ctx context.Context, req *pb.RollbackRequest) (*pb.RollbackResponse, error) {
+	id := strings.TrimSpace(req.GetVersionId())
+	if id == "" || strings.ContainsAny(id, " \t\n") {
+		return nil, status.Error(codes.InvalidArgument, "version_id must be a single version id")
+	}
+	if _, err := s.store.GetVersion(id); err != nil {
+		return nil, status.Errorf(codes.NotFound, "version %s not found", id)
+	}
+	resp, err := s.submit(ctx, "/rollback "+id)
+	if err != nil {
+		return nil, err
+	}
+	current, err := s.store.GetCurrent()
+	if err != nil {
+		log.Printf("grpc: rollback: %v", err)
+		return nil, status.Error(codes.Internal, "could not load the current state")
+	}
+	return &pb.RollbackResponse{Reply: resp.Reply, VersionId: current.VersionID}, nil
+}
+
+func (s *controllerServer) ListVersions(_ context.Context, req *pb.ListVersionsRequest) (*pb.ListVersionsResponse, error) {
+	limit := int(req.GetLimit())
+	if limit <= 0 {
+		limit = 20
+	}
+	if limit > 500 {
+		return nil, status.Error(codes.InvalidArgument, "limit must be at most 500")
+	}
+	recs, err := s.store.ListVersions(limit)
+	if err != nil {
+		log.Printf("grpc: versions: %v", err)
+		return nil, status.Error(codes.Internal, "could not list versions")
+	}
+	out := &pb.ListVersionsResponse{Versions: make([]*pb.StateVersion, 0, len(recs))}
+	for _, rec := range recs {
+		out.Versions = append(out.Versions, stateVersionPB(rec))
+	}
+	return out, nil
+}
+
+func (s *controllerServer) StreamProvenance(req *pb.StreamProvenanceRequest, stream grpc.ServerStreamingServer[pb.ProvenanceEntry]) error {
+	after := req.GetAfterId()
+	for {
+		entries, err := logging.ProvenanceSince(s.store.DB(), after, 100)
+		if err != nil {
+			log.Printf("grpc: provenance: %v", err)
+			return status.Error(codes.Internal, "could not read provenance")
+		}
+		for _, e := range entries {
+			if err := stream.Send(provenanceEntryPB(e)); err != nil {
+				return err
+			}
+			after = e.ID
+		}
+		if len(entries) == 100 {
+			continue // more backlog waiting
+		}
+		if !req.GetFollow() {
+			return nil
+		}
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case <-time.After(provenanceFollowInterval):
+		}
+	}
+}
+
+// submit queues prompt for the daemon loop and maps queue errors to gRPC codes.
+func (s *controllerServer) submit(ctx context.Context, prompt string) (turnResponse, error) {
+	resp, err := s.inbox.submit(ctx, prompt)
+	switch {
+	case errors.Is(err, errBusy):
+		return turnResponse{}, status.Error(codes.ResourceExhausted, "busy: too many turns queued")
+	case err != nil:
+		return turnResponse{}, status.FromContextError(err).Err()
+	}
+	return resp, nil
+}
+
+func stateVersionPB(rec state.StateRecord) *pb.StateVersion {
+	norms := rec.SegmentMap.Norms(rec.StateVector)
+	out := &pb.StateVersion{
+		VersionId:   rec.VersionID,
+		ParentId:    rec.ParentID,
+		CreatedAt:   rec.CreatedAt.UTC().Format(time.RFC3339Nano),
+		StateVector: append([]float32(nil), rec.StateVector[:]...),
+		MetricsJson: rec.MetricsJSON,
+	}
+	for _, name := range state.SegmentNames {
+		out.SegmentNorms = append(out.SegmentNorms, &pb.SegmentNorm{Segment: name, Norm: norms[name]})
+	}
+	return out
+}
+
+func provenanceEntryPB(e logging.ProvenanceEntry) *pb.ProvenanceEntry {
+	out := &pb.ProvenanceEntry{
+		Id: e.ID, VersionId: e.VersionID, TriggerType: e.TriggerType, Decision: e.Decision,
+		Reason: e.Reason, SignalsJson: e.SignalsJSON, CreatedAt: e.CreatedAt.UTC().Format(time.RFC3339Nano),
+	}
+	if e.EvidenceRefs != "" {
+		out.EvidenceRefs = strings.Split(e.EvidenceRefs, ",")
+	}
+	return out
+}
+
+// #endregion grpc-server
+
+// #region rollback-command
+
+// rollbackCommand handles /rollback VERSION_ID: the version becomes active again
+// and the rollback is logged to provenance.
+func rollbackCommand(store *state.Store, id string) string {
+	if id == "" || strings.ContainsAny(id, " \t") {
+		return "Usage: /rollback <version_id>"
+	}
+	current, err := store.GetCurrent()
+	if err != nil {
+		log.Printf("rollback: %v", err)
+		return "Could not load the current state."
+	}
+	if current.VersionID == id {
+		return fmt.Sprintf("Version %s is already active.", id)
+	}
+	if _, err := store.GetVersion(id); err != nil {
+		return fmt.Sprintf("No version %s.", id)
+	}
+	if err := store.WithTx(func(tx *sql.Tx) error {
+		if err := store.RollbackTx(tx, id); err != nil {
+			return err
+		}
+		return logging.LogDecision(tx, logging.ProvenanceEntry{
+			VersionID:   id,
+			TriggerType: "manual_rollback",
+			Decision:    "rollback",
+			Reason:      fmt.Sprintf("/rollback from %s", current.VersionID),
+		})
+	}); err != nil {
+		log.Printf("rollback (rolled back): %v", err)
+		return fmt.Sprintf("Could not roll back to %s.", id)
+	}
+	log.Printf("rollback: %s -> %s", current.VersionID, id)
+	return fmt.Sprintf("Rolled back from %s to %s.", current.VersionID, id)
+}
+
+// #endregion rollback-command
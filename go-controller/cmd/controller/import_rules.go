@@ -0,0 +1,121 @@
+package main
+
+import (
+	"bufio"
+	"database/sql"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/projection"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/state"
+)
+
+// #region import-rules
+
+// runImportRules implements `controller import-rules [flags] rules.yaml`.
+// It validates the file, prints a diff against stored rules, and writes only
+// after confirmation (or --yes), all in one transaction. --dry-run stops
+// after the diff.
+func runImportRules(args []string) int {
+	fs := flag.NewFlagSet("import-rules", flag.ContinueOnError)
+	dbPath := fs.String("db", envOr("ADAPTIVE_DB", "adaptive_state.db"), "path to SQLite database")
+	dryRun := fs.Bool("dry-run", false, "print the diff without writing")
+	yes := fs.Bool("yes", false, "apply without prompting for confirmation")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "usage: controller import-rules [--db path] [--dry-run] [--yes] rules.yaml")
+		return 2
+	}
+
+	data, err := os.ReadFile(fs.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: read rules file: %v\n", err)
+		return 1
+	}
+	specs, err := projection.ParseRulesYAML(data)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: parse rules file: %v\n", err)
+		return 1
+	}
+	specs, problems := projection.ValidateRuleSpecs(specs, time.Now().UTC())
+	if len(problems) > 0 {
+		fmt.Fprintf(os.Stderr, "rules file has %d problem(s):\n", len(problems))
+		for _, p := range problems {
+			fmt.Fprintf(os.Stderr, "  %v\n", p)
+		}
+		return 1
+	}
+
+	store, err := state.NewStore(*dbPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: open store: %v\n", err)
+		return 1
+	}
+	defer store.Close()
+	ruleStore, err := projection.NewRuleStore(store.DB())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: init rule store: %v\n", err)
+		return 1
+	}
+	existing, err := ruleStore.List()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+
+	changes := projection.PlanRuleImport(specs, existing)
+	writes := 0
+	for _, c := range changes {
+		if c.Kind != "unchanged" {
+			writes++
+		}
+	}
+	fmt.Print(projection.FormatRuleDiff(changes))
+	for _, c := range changes {
+		if unknown := projection.UnknownRuleVarsFor(c.Spec.Trigger, c.Spec.Response); len(unknown) > 0 {
+			fmt.Printf("warning: %q uses unknown template variables %v; they stay as written\n", c.Spec.Trigger, unknown)
+		}
+		if unknown := projection.UnknownStepVars(c.Spec.Trigger, c.Spec.Steps); len(unknown) > 0 {
+			fmt.Printf("warning: %q script steps use unknown template variables %v; they stay as written\n", c.Spec.Trigger, unknown)
+		}
+	}
+	fmt.Printf("%d rule(s) in file, %d to write, %d already stored\n", len(changes), writes, len(changes)-writes)
+
+	if *dryRun || writes == 0 {
+		return 0
+	}
+	if !*yes {
+		fmt.Print("Apply these changes? [y/N] ")
+		answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+		if a := strings.ToLower(strings.TrimSpace(answer)); a != "y" && a != "yes" {
+			fmt.Println("Aborted. No rules written.")
+			return 0
+		}
+	}
+
+	// One transaction: a failed rule leaves the stored rules as they were
+	if err := store.WithTx(func(tx *sql.Tx) error {
+		rules := ruleStore.WithTx(tx)
+		for _, c := range changes {
+			if c.Kind == "unchanged" {
+				continue
+			}
+			if err := rules.AddScript(c.Spec.Trigger, c.Spec.Response, c.Spec.Steps, c.Spec.Priority, 1.0, c.Spec.ExpiresAt); err != nil {
+				return fmt.Errorf("write rule %q: %w", c.Spec.Trigger, err)
+			}
+		}
+		return nil
+	}); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v; no rules written\n", err)
+		return 1
+	}
+	fmt.Printf("Imported %d rule(s).\n", writes)
+	return 0
+}
+
+// #endregion import-rules
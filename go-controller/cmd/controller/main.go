@@ -2,60 +2,120 @@ package main
 
 import (
 	"context"
+	"database/sql"
 	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
+	"io"
 	"log"
 	"math"
+	"net/http"
 	"os"
 	"strconv"
 	"strings"
 	"time"
 
-	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/cipher"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/budget"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/cache"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/chaos"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/calibration"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/clusters"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/compaction"
 	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/codec"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/consolidation"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/curiosity"
 	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/eval"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/events"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/freeze"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/evidence"
 	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/gate"
 	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/graph"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/injection"
 	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/interior"
 	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/orchestrator"
 	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/logging"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/plan"
 	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/projection"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/replay"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/reprime"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/resource"
 	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/retrieval"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/retry"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/review"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/rulestats"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/sampling"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/session"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/settings"
 	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/signals"
 	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/state"
 	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/update"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/preprocess"
 )
 
 // #region session-state
 type SessionState struct {
-	RuleActive   bool
-	LastRuleTurn int
+	// Rule script in progress, nil when none: its next step takes the turn
+	// in place of rule matching
+	Script *projection.ScriptRun
 }
 
-func isRuleContinuation(input string) bool {
-	lower := strings.ToLower(strings.TrimSpace(input))
-	// Direct knock-knock continuation
-	if strings.Contains(lower, "knock") {
-		return true
-	}
-	// Punchline pattern: "<name> who <punchline>" (e.g. "Daniel who codes all night")
-	// Must start with a word followed by "who" — not question-word "who is..."
-	if !strings.HasPrefix(lower, "who") && strings.Contains(lower, " who ") && len(lower) < 60 {
-		return true
-	}
-	// Very short reactions only (e.g. "haha", "good one", "lol", "nice one")
-	// Exclude question-word starts ("who is...", "what is...")
-	words := strings.Fields(lower)
-	if len(words) <= 3 && !strings.HasPrefix(lower, "who") && !strings.HasPrefix(lower, "what") && !strings.HasPrefix(lower, "how") && !strings.HasPrefix(lower, "why") {
-		return true
-	}
-	return false
-}
+// staleAskEveryTurns spaces out preference staleness check-ins so one is never
+// asked on back-to-back turns.
+const staleAskEveryTurns = 10
 
 // #endregion session-state
 
 // #region main
 func main() {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "import-rules":
+			os.Exit(runImportRules(os.Args[2:]))
+		case "doctor":
+			os.Exit(runDoctor(os.Args[2:]))
+		case "ablate":
+			os.Exit(runAblate(os.Args[2:]))
+		case "bench":
+			os.Exit(runBench(os.Args[2:]))
+		case "shadow":
+			os.Exit(runShadow(os.Args[2:]))
+		}
+	}
+
+	fs := flag.NewFlagSet("controller", flag.ExitOnError)
+	var emitJSON emitJSONFlag
+	fs.Var(&emitJSON, "emit-json", "write one JSON event per turn to stdout, or to a file with --emit-json=PATH (appended)")
+	freezeFlag := fs.Bool("freeze", false, "suspend learning (state updates, evidence storage, preference writes) for this run")
+	serveAddr := fs.String("serve", "", "take turns from an HTTP/JSON API on ADDR (e.g. 127.0.0.1:8787) instead of the cipher inbox")
+	grpcServeAddr := fs.String("grpc", "", "take turns from the gRPC ControllerService on ADDR (e.g. 127.0.0.1:50052) instead of the cipher inbox")
+	fs.Parse(os.Args[1:])
+
+	c := newController(controllerOptions{emitJSON: emitJSON, freeze: *freezeFlag, serveAddr: *serveAddr, grpcAddr: *grpcServeAddr})
+	defer c.close()
+	c.run()
+}
+
+// newController assembles the daemon from the environment and opts: stores,
+// codec, gate and the rest, then the inbox poll and the turn pipeline over
+// them. Startup failures are fatal.
+func newController(opts controllerOptions) *controller {
+	c := &controller{}
+
+	// Turn events: on stdout, the human-readable console output moves to stderr
+	// so the event stream stays machine-parseable.
+	var emitter *events.Emitter
+	if opts.emitJSON != "" {
+		var err error
+		if emitter, err = events.Open(string(opts.emitJSON), os.Stdout); err != nil {
+			log.Fatalf("--emit-json: %v", err)
+		}
+		c.closers = append(c.closers, func() { emitter.Close() })
+		if opts.emitJSON == "-" {
+			os.Stdout = os.Stderr
+		}
+	}
+
 	dbPath := envOr("ADAPTIVE_DB", "adaptive_state.db")
 	grpcAddr := envOr("CODEC_ADDR", "localhost:50051")
 
@@ -65,12 +125,36 @@ func main() {
 	timeoutStore := envDuration("TIMEOUT_STORE", 15)
 	timeoutEmbed := envDuration("TIMEOUT_EMBED", 15)
 
+	// Chaos mode: inject codec/DB faults for resilience testing (never set in normal use).
+	// Injection stays paused through startup and begins with the first inbox poll.
+	var faults *chaos.Injector
+	if spec := os.Getenv("CHAOS_FAULTS"); spec != "" {
+		chaosCfg, err := chaos.ParseConfig(spec, int64(envInt("CHAOS_SEED", 0)))
+		if err != nil {
+			log.Fatalf("invalid CHAOS_FAULTS: %v", err)
+		}
+		faults = chaos.NewInjector(chaosCfg)
+		faults.SetEnabled(false)
+		log.Printf("CHAOS MODE: injecting faults %s", spec)
+		c.closers = append(c.closers, func() { log.Printf("chaos: injected %s", faults.Summary()) })
+	}
+
 	// Initialize state store
-	store, err := state.NewStore(dbPath)
+	var store *state.Store
+	var err error
+	if faults != nil {
+		chaosDB, dbErr := chaos.OpenSQLite(dbPath, faults)
+		if dbErr != nil {
+			log.Fatalf("failed to open store: %v", dbErr)
+		}
+		store, err = state.NewStoreFromDB(chaosDB)
+	} else {
+		store, err = state.NewStore(dbPath)
+	}
 	if err != nil {
 		log.Fatalf("failed to open store: %v", err)
 	}
-	defer store.Close()
+	c.closers = append(c.closers, func() { store.Close() })
 
 	// Ensure initial state exists
 	_, err = store.GetCurrent()
@@ -88,23 +172,215 @@ func main() {
 		log.Fatalf("failed to init preference store: %v", err)
 	}
 
+	// Initialize profile store — user identity and AI designation, with history (uses same DB).
+	// Opening it migrates identity rows older versions kept in preferences.
+	profileStore, err := projection.NewProfileStore(store.DB())
+	if err != nil {
+		log.Fatalf("failed to init profile store: %v", err)
+	}
+
 	// Initialize rule store (uses same DB)
 	ruleStore, err := projection.NewRuleStore(store.DB())
 	if err != nil {
 		log.Fatalf("failed to init rule store: %v", err)
 	}
+	// Unused rules lose confidence after RULE_DECAY_TURNS unmatched turns and
+	// are pruned below RULE_PRUNE_CONFIDENCE, along with expired ones
+	ruleDecay := projection.DefaultRuleDecayConfig()
+	ruleDecay.IdleTurns = envInt("RULE_DECAY_TURNS", ruleDecay.IdleTurns)
+	if v := os.Getenv("RULE_DECAY_RATE"); v != "" {
+		if ruleDecay.Rate, err = strconv.ParseFloat(v, 64); err != nil || ruleDecay.Rate < 0 || ruleDecay.Rate >= 1 {
+			log.Fatalf("invalid RULE_DECAY_RATE %q: want a number in [0, 1)", v)
+		}
+	}
+	if v := os.Getenv("RULE_PRUNE_CONFIDENCE"); v != "" {
+		if ruleDecay.PruneBelow, err = strconv.ParseFloat(v, 64); err != nil || ruleDecay.PruneBelow < 0 || ruleDecay.PruneBelow > 1 {
+			log.Fatalf("invalid RULE_PRUNE_CONFIDENCE %q: want a number in [0, 1]", v)
+		}
+	}
+	// A rule script left waiting this long is dropped before the next input
+	ruleScriptTimeout := time.Duration(envInt("RULE_SCRIPT_TIMEOUT_SECONDS", 300)) * time.Second // 0 disables
+
+	// Initialize style profile store — inferred interaction style, kept apart from preferences (uses same DB)
+	styleStore, err := projection.NewStyleProfileStore(store.DB())
+	if err != nil {
+		log.Fatalf("failed to init style profile store: %v", err)
+	}
+
+	// Initialize suggestion store — agent-proposed rules/preferences awaiting approval (uses same DB)
+	suggestionStore, err := projection.NewSuggestionStore(store.DB())
+	if err != nil {
+		log.Fatalf("failed to init suggestion store: %v", err)
+	}
+
+	// Evidence storage policy: long exchanges are reduced before StoreEvidence, optionally
+	// archiving the full text locally (uses same DB)
+	storagePolicy := evidence.DefaultPolicy()
+	storagePolicy.Mode = envOr("EVIDENCE_STORE_MODE", storagePolicy.Mode)
+	storagePolicy.MaxChars = envInt("EVIDENCE_MAX_CHARS", storagePolicy.MaxChars)
+	var rawArchive *evidence.RawArchive
+	if envInt("EVIDENCE_RAW_ARCHIVE", 0) != 0 {
+		rawArchive, err = evidence.NewRawArchive(store.DB())
+		if err != nil {
+			log.Fatalf("failed to init raw evidence archive: %v", err)
+		}
+		if m, err := rawArchive.MigrateIDs(); err != nil {
+			log.Fatalf("failed to migrate raw evidence archive ids: %v", err)
+		} else if m.Renamed+m.Dropped > 0 {
+			log.Printf("evidence ids: raw archive migrated (renamed=%d, dropped=%d)", m.Renamed, m.Dropped)
+		}
+	}
+
+	// Learning freeze: FREEZE=1 / --freeze, or recurring FREEZE_WINDOWS in local time
+	freezeSchedule, err := freeze.ParseSchedule(os.Getenv("FREEZE_WINDOWS"))
+	if err != nil {
+		log.Fatalf("invalid FREEZE_WINDOWS: %v", err)
+	}
+	freezeSchedule.Always = opts.freeze || envInt("FREEZE", 0) != 0
+	if freezeSchedule.Always {
+		log.Printf("learning freeze: ON for this run (generation and retrieval only)")
+	} else if freezeSchedule.Enabled() {
+		log.Printf("learning freeze: %d scheduled window(s) %q", len(freezeSchedule.Windows), os.Getenv("FREEZE_WINDOWS"))
+	}
+
+	// Private turns: "/private ..." or this prefix; set PRIVATE_PREFIX= (empty) to keep only the command
+	privatePrefix := "off the record:"
+	if v, ok := os.LookupEnv("PRIVATE_PREFIX"); ok {
+		privatePrefix = strings.TrimSpace(v)
+	}
+
+	// Prompt preprocessors: ordered chain run before detection, classification and learning
+	preprocessors, err := preprocess.Build(os.Getenv("PREPROCESSORS"))
+	if err != nil {
+		log.Fatalf("invalid PREPROCESSORS: %v", err)
+	}
+	if names := preprocessors.Names(); len(names) > 0 {
+		log.Printf("prompt preprocessors: %s", strings.Join(names, " → "))
+	}
 
 	// Initialize interior store — persists Orac's self-reflections (uses same DB)
 	interiorStore, err := interior.NewInteriorStore(store.DB())
 	if err != nil {
 		log.Fatalf("failed to init interior store: %v", err)
 	}
+	interiorRecall := envInt("INTERIOR_RECALL", 0) // past reflections recalled on deep turns; 0 disables
 
 	// Initialize graph store — associative evidence edges (uses same DB)
 	graphStore, err := graph.NewGraphStore(store.DB())
 	if err != nil {
 		log.Fatalf("failed to init graph store: %v", err)
 	}
+	// Graphs written before the evidence ID scheme hold bare UUIDs; rename or drop them
+	if m, err := graphStore.MigrateEvidenceIDs(); err != nil {
+		log.Fatalf("failed to migrate graph evidence ids: %v", err)
+	} else if m.Renamed+m.Dropped > 0 {
+		log.Printf("evidence ids: graph migrated (renamed=%d, dropped=%d)", m.Renamed, m.Dropped)
+	}
+	coRetrievalCfg := graph.DefaultCoRetrievalConfig()
+
+	// Retrieval usage log: timestamped hits per evidence item (inspect --usage)
+	usageLog, err := evidence.NewUsageLog(store.DB())
+	if err != nil {
+		log.Fatalf("failed to init evidence usage log: %v", err)
+	}
+	// Graph walk scoring: per-edge-type priors and an edge-age half-life
+	walkCfg := graph.DefaultWalkConfig()
+	if spec := os.Getenv("GRAPH_EDGE_PRIORS"); spec != "" {
+		priors, err := graph.ParseTypePriors(spec)
+		if err != nil {
+			log.Fatalf("invalid GRAPH_EDGE_PRIORS: %v", err)
+		}
+		for edgeType, prior := range priors {
+			if _, ok := graph.LookupEdgeType(edgeType); !ok {
+				log.Printf("GRAPH_EDGE_PRIORS: %q is not a registered edge type; its prior has no edges to apply to", edgeType)
+			}
+			walkCfg.TypePriors[edgeType] = prior
+		}
+	}
+	walkCfg.AgeHalfLife = time.Duration(envInt("GRAPH_EDGE_HALF_LIFE_DAYS", 30)) * 24 * time.Hour // 0 disables
+
+	// Centrality tie-break: retrieved evidence scores gain boost × normalized
+	// PageRank, cached until the edges change
+	centralityBoost := 0.05
+	if v := os.Getenv("GRAPH_CENTRALITY_BOOST"); v != "" {
+		if centralityBoost, err = strconv.ParseFloat(v, 64); err != nil || centralityBoost < 0 {
+			log.Fatalf("invalid GRAPH_CENTRALITY_BOOST %q: want a non-negative number", v)
+		}
+	}
+	centrality := graph.NewCentrality(graphStore, graph.DefaultPageRankConfig())
+
+	// Evidence clusters: k-means over evidence embeddings, rebuilt while idle,
+	// weekly by default; retrieval summarizes large clusters instead of listing them
+	clusterStore, err := clusters.NewStore(store.DB())
+	if err != nil {
+		log.Fatalf("failed to init evidence clusters: %v", err)
+	}
+	clusterCfg := clusters.DefaultConfig()
+	clusterCfg.MaxK = envInt("CLUSTER_MAX_K", clusterCfg.MaxK)
+	clusterInterval := time.Duration(envInt("CLUSTER_INTERVAL_DAYS", 7)) * 24 * time.Hour // 0 disables
+	var nextClusterCheck time.Time
+
+	// Evidence compaction: groups of near-duplicate old evidence are merged
+	// into one summary while idle, weekly by default; the originals are
+	// soft-deleted behind summary_of edges
+	compactionStore, err := compaction.NewStore(store.DB())
+	if err != nil {
+		log.Fatalf("failed to init evidence compaction: %v", err)
+	}
+	compactionCfg := compaction.DefaultConfig()
+	compactionCfg.MinAge = time.Duration(envInt("COMPACT_MIN_AGE_DAYS", 30)) * 24 * time.Hour
+	if v := os.Getenv("COMPACT_SIMILARITY"); v != "" {
+		if compactionCfg.Similarity, err = strconv.ParseFloat(v, 64); err != nil || compactionCfg.Similarity <= 0 || compactionCfg.Similarity > 1 {
+			log.Fatalf("invalid COMPACT_SIMILARITY %q: want a number in (0, 1]", v)
+		}
+	}
+	compactionInterval := time.Duration(envInt("COMPACT_INTERVAL_DAYS", 7)) * 24 * time.Hour // 0 disables
+	var nextCompactionCheck time.Time
+	topicCfg := retrieval.DefaultTopicConfig()
+	topicCfg.MinSize = envInt("TOPIC_MIN_SIZE", topicCfg.MinSize)
+	topicCfg.Keep = envInt("TOPIC_KEEP", topicCfg.Keep)
+
+	// Prompt-injection quarantine: retrieved evidence with instruction-like text
+	// is withheld from the model until reviewed with /quarantine
+	quarantine, err := injection.NewQuarantine(store.DB())
+	if err != nil {
+		log.Fatalf("failed to init evidence quarantine: %v", err)
+	}
+
+	// Resource profile: "low" drops reflection and the second pass, shrinks
+	// retrieval and graph walks, and decays the graph less often (small boards)
+	resourceProfile, err := resource.Parse(os.Getenv("RESOURCE_PROFILE"))
+	if err != nil {
+		log.Fatalf("invalid RESOURCE_PROFILE: %v", err)
+	}
+	if !resourceProfile.IsFull() {
+		log.Printf("resource profile: %s", resourceProfile)
+	}
+	walkCfg = resourceProfile.Walk(walkCfg)
+
+	// Initialize plan store — multi-turn plan tracking (uses same DB)
+	planStore, err := plan.NewPlanStore(store.DB())
+	if err != nil {
+		log.Fatalf("failed to init plan store: %v", err)
+	}
+
+	// Session-start summary of what was learned since the previous session began
+	// (preferences, rules, state shifts); shown ahead of the first ordinary response
+	sessionStore, err := session.NewStore(store.DB())
+	if err != nil {
+		log.Fatalf("failed to init session store: %v", err)
+	}
+	pendingSessionBanner := sessionStartBanner(sessionStore, store)
+
+	// Opt-in confirmation sampling: occasionally ask whether a detected preference,
+	// rule or identity statement was meant as one, labeling the detectors' accuracy
+	detectionLabels, err := projection.NewDetectionLabelStore(store.DB())
+	if err != nil {
+		log.Fatalf("failed to init detection label store: %v", err)
+	}
+	detectSampleRate := float64(envInt("DETECTION_SAMPLE_PERCENT", 0)) / 100 // 0 disables
+
+	var pendingDetection *projection.DetectionLabel // sampled detection awaiting /yes or /no
 
 	// Initialize orchestrator — intelligent turn management with kill switch
 	orch, err := orchestrator.NewOrchestrator(store.DB())
@@ -117,192 +393,1109 @@ func main() {
 		log.Println("orchestrator: DISABLED (pass-through mode, set ORCHESTRATOR_ENABLED=true to enable)")
 	}
 
-	// Connect to Python inference service
-	codecClient, err := codec.NewCodecClient(grpcAddr)
+	// Connect to the inference backend: the Python service, or Ollama directly (CODEC_BACKEND)
+	codecClient, codecName, err := openCodec(grpcAddr, store.DB())
+	if err != nil {
+		log.Fatalf("failed to connect to codec backend: %v", err)
+	}
+	c.closers = append(c.closers, func() { codecClient.Close() })
+	if faults != nil {
+		codecClient.WrapService(chaos.WrapCodec(faults))
+	}
+	// Evidence dual-write: a second backend gets every evidence write and shadows
+	// every read until `controller shadow cutover` moves reads to it
+	if shadowAddr := os.Getenv("EVIDENCE_SHADOW_ADDR"); shadowAddr != "" {
+		wrap, closeShadow, reads, shadowErr := openShadow(shadowAddr, store, timeoutStore)
+		if shadowErr != nil {
+			log.Fatalf("evidence shadow: %v", shadowErr)
+		}
+		c.closers = append(c.closers, func() { closeShadow() })
+		codecClient.WrapService(wrap)
+		log.Printf("evidence dual-write: writes to %s and %s, reads from %s", codecName, shadowAddr, reads)
+	}
+	codecClient.WithEmbedBatch(codec.EmbedBatchConfig{
+		ChunkSize:    envInt("EMBED_BATCH_SIZE", codec.DefaultEmbedBatchConfig().ChunkSize),
+		Concurrency:  envInt("EMBED_BATCH_CONCURRENCY", codec.DefaultEmbedBatchConfig().Concurrency),
+		ChunkTimeout: timeoutEmbed,
+	})
+
+	// Protocol handshake: refuse to run against bindings built from a different proto.
+	// An unreachable server is only a warning — it may still be starting.
+	hsCtx, hsCancel := context.WithTimeout(context.Background(), timeoutEmbed)
+	serverInfo, hsErr := codecClient.Handshake(hsCtx)
+	hsCancel()
+	switch {
+	case errors.Is(hsErr, codec.ErrProtocolMismatch):
+		log.Fatalf("codec handshake with %s failed: %v", codecName, hsErr)
+	case hsErr != nil:
+		log.Printf("warning: codec handshake with %s failed, protocol compatibility unchecked: %v", codecName, hsErr)
+	default:
+		log.Printf("codec: protocol %d, schema %s", serverInfo.ProtocolVersion, serverInfo.SchemaFingerprint)
+	}
+
+	// Reset detection: the handshake doubles as a heartbeat, while idle and before
+	// each turn; a server restart, an outage or a model change re-primes the next prompt
+	resets := reprime.NewDetector()
+	resets.Heartbeat(serverInfo.Instance, hsErr, time.Now().UTC())
+	heartbeatInterval := time.Duration(envInt("CODEC_HEARTBEAT_SECONDS", 30)) * time.Second // 0 disables
+	var nextHeartbeat time.Time
+	reprimeCfg := reprime.DefaultConfig()
+	reprimeCfg.Turns = envInt("REPRIME_TURNS", reprimeCfg.Turns)
+
+	// Curiosity queue: open questions from reflections, explored with web search
+	// by /explore, or one at a time once the controller has been idle this long
+	curiosityQueue, err := curiosity.NewStore(store.DB())
+	if err != nil {
+		log.Fatalf("failed to init curiosity queue: %v", err)
+	}
+	questionExplorer := &explorer{codec: codecClient, store: store, graph: graphStore, queue: curiosityQueue,
+		cfg: curiosity.DefaultConfig(), timeout: timeoutGenerate}
+	curiosityIdle := time.Duration(envInt("CURIOSITY_IDLE_MINUTES", 0)) * time.Minute // 0 disables
+	nextExplore := time.Now().Add(curiosityIdle)
+
+	// Federated memory: read-only secondary evidence packs merged into gate 2 (disabled by default)
+	var federatedSources []retrieval.Source
+	if spec := os.Getenv("FEDERATED_SOURCES"); spec != "" {
+		specs, specErr := retrieval.ParseSourceSpecs(spec)
+		if specErr != nil {
+			log.Fatalf("invalid FEDERATED_SOURCES: %v", specErr)
+		}
+		for _, sp := range specs {
+			src, loadErr := retrieval.LoadPackSource(context.Background(), sp.Path, sp.Namespace, sp.Trust, codecClient.EmbedBatch)
+			if loadErr != nil {
+				log.Fatalf("failed to load federated source %s: %v", sp.Namespace, loadErr)
+			}
+			federatedSources = append(federatedSources, src)
+			log.Printf("federated source: %s (%d items, trust=%.2f)", sp.Namespace, src.Len(), sp.Trust)
+		}
+	}
+
+	// Post-hoc attribution of factual answers to evidence (on by default);
+	// ATTRIBUTION_CITATIONS also marks supported sentences inline ("[1]")
+	attributionOn := envInt("ATTRIBUTION", 1) != 0
+	citationsOn := attributionOn && envInt("ATTRIBUTION_CITATIONS", 0) != 0
+
+	// Phase 3: Initialize gate and eval harness; GATE_DOWNGRADE lets slight norm
+	// overshoots of the listed veto types commit scaled down instead of rejecting
+	// Gate policy file (GATE_POLICY=gate-policy.yaml): caps, soft score weights and
+	// disabled vetoes over the env config, reloaded between turns on SIGHUP or change
+	gateConfig, policy, err := gateConfigFromEnv()
 	if err != nil {
-		log.Fatalf("failed to connect to codec service at %s: %v", grpcAddr, err)
+		log.Fatal(err)
+	}
+	if policy != nil {
+		log.Printf("gate policy: %s (%s), max_delta_norm=%.2f, disabled vetoes %v", policy.path, policy.Hash(), gateConfig.MaxDeltaNorm, gateConfig.DisabledVetoes)
 	}
-	defer codecClient.Close()
+	if gateConfig.Downgrade.Enabled() {
+		log.Printf("gate downgrade: %v up to %.0f%% over cap commit scaled", gateConfig.Downgrade.VetoTypes, gateConfig.Downgrade.MaxExcess*100)
+	}
+	stateGate := gate.NewGate(gateConfig)
 
-	// Phase 3: Initialize gate and eval harness
-	stateGate := gate.NewGate(gate.DefaultGateConfig())
-	evalHarness := eval.NewEvalHarness(eval.DefaultEvalConfig())
+	// Pre-gate: cheap first tier before generation — short-circuits acknowledgements,
+	// hardens override attempts (halved caps, no evidence stored), annotates sensitive turns
+	var preGate *gate.PreGate
+	if envInt("PREGATE", 1) != 0 {
+		preGate = gate.NewPreGate(gate.DefaultPreGateConfig())
+	}
+	hardenedGate := gate.NewGate(preGate.Hardened(gateConfig))
+
+	// Confirm learning (CONFIRM_LEARNING=delta=1.5,prefs=0.5): an update past a
+	// threshold is held until the user answers /accept or /decline
+	confirmPolicy, err := gate.ParseConfirmPolicy(os.Getenv("CONFIRM_LEARNING"))
+	if err != nil {
+		log.Fatalf("invalid CONFIRM_LEARNING: %v", err)
+	}
+	if confirmPolicy.Enabled() {
+		log.Printf("confirm learning: hold updates over delta_norm %.2f / prefs %.2f (0 = off)", confirmPolicy.MaxDeltaNorm, confirmPolicy.MaxPrefsDelta)
+	}
+	var pendingLearning *heldUpdate // update awaiting /accept or /decline
+
+	// External policy gate: POST each locally-approved update to a central policy service (disabled by default)
+	policyURL := os.Getenv("POLICY_GATE_URL")
+	var policyGate, hardenedPolicyGate *gate.ExternalGate
+	if policyURL != "" {
+		policyClient := gate.NewPolicyClient(policyURL, envDuration("POLICY_GATE_TIMEOUT", 3))
+		policyGate = gate.NewExternalGate(stateGate, policyClient)
+		hardenedPolicyGate = gate.NewExternalGate(hardenedGate, policyClient)
+		log.Printf("policy gate: ENABLED (%s, local fallback on timeout)", policyURL)
+	}
+	evalConfig := evalConfigFromEnv()
+	evalHarness := eval.NewEvalHarness(evalConfig)
 
 	// Phase 4: Update config for learning + decay
 	updateConfig := update.DefaultUpdateConfig()
 
 	// Phase 5: Heuristic signal producer
 	signalProducer := signals.NewProducer(codecClient, signals.DefaultProducerConfig())
+
+	// Calibration capture: sample N turns/day into a labelable dataset (disabled by default)
+	calibrationSampler := calibration.NewSampler(calibration.CalibrationConfig{
+		Path:          os.Getenv("CALIBRATION_FILE"),
+		SamplesPerDay: envInt("CALIBRATION_PER_DAY", 0),
+	})
+	// Anomaly capture: huge deltas, surprise vetoes and eval rollbacks are written with
+	// their preceding turns as replay fixtures (replay --fixture anomalies/<file>)
+	anomalyCfg := replay.DefaultAnomalyConfig()
+	anomalyCfg.Dir = envOr("ANOMALY_DIR", anomalyCfg.Dir)
+	anomalyCfg.Context = envInt("ANOMALY_CONTEXT", anomalyCfg.Context)
+	if envInt("ANOMALY_CAPTURE", 1) == 0 {
+		anomalyCfg.Dir = ""
+	}
+	anomalies := replay.NewAnomalyRecorder(anomalyCfg, replay.ReplayConfig{
+		UpdateConfig: updateConfig,
+		GateConfig:   gateConfig,
+		EvalConfig:   evalConfig,
+	})
+	policy.attach(preGate, stateGate, hardenedGate, anomalies)
+
+	// Self-benchmark: a fixed prompt set scored against preferences and rules while
+	// idle, weekly by default; regressions are noted on the next ordinary response
+	selfBenchmark, err := newSelfBench(store, prefStore, profileStore, ruleStore, codecClient)
+	if err != nil {
+		log.Fatalf("failed to init self-benchmark: %v", err)
+	}
+	benchInterval := time.Duration(envInt("BENCH_INTERVAL_DAYS", 7)) * 24 * time.Hour // 0 disables
+	var nextBenchCheck time.Time
+	var pendingBenchAlert string
+
+	// Rule effectiveness report: firings, compliance and corrections per learned
+	// rule, generated while idle, weekly by default; flagged rules are noted
+	ruleReports, err := rulestats.NewStore(store.DB())
+	if err != nil {
+		log.Fatalf("failed to init rule reports: %v", err)
+	}
+	ruleReportInterval := time.Duration(envInt("RULE_REPORT_INTERVAL_DAYS", 7)) * 24 * time.Hour // 0 disables
+	var nextRuleReportCheck time.Time
+	var pendingRuleReport string
+	var nextTelemetryCheck time.Time
+
+	// Failed evidence and provenance writes are queued in the database and
+	// retried while idle, so a codec outage doesn't punch holes in memory
+	writeQueueCfg := retry.DefaultConfig()
+	writeQueueCfg.MaxAttempts = envInt("WRITE_RETRY_MAX_ATTEMPTS", writeQueueCfg.MaxAttempts)
+	writeQueue, err := retry.NewQueue(store.DB(), writeQueueCfg)
+	if err != nil {
+		log.Fatalf("failed to init write queue: %v", err)
+	}
+	retryInterval := envDuration("WRITE_RETRY_INTERVAL", 60)
+	var nextRetry time.Time
+
 	var userCorrected bool
 	var lastGateSummary string
+	var lastGateSoftScore float32 // structured gate feedback for rule-based memory review
+	var lastGateVetoed bool
 	var lastPrompt string
 	var lastResponse string
-	var recentEvidenceIDs []string // last 3 stored evidence IDs for temporal edges
+	var lastModel string // "name@version" of the last generating model, to log upgrades
+	var recentEvidenceIDs []string                // last 3 stored evidence IDs for temporal edges
+	var recentResponses []string                  // last 10 generated responses for preference previews
+	var recentContexts []string                   // turn contexts of the last 5 generated turns, for preference scope inference
+	var pendingPref *projection.PreferencePreview // drastic preference awaiting /confirm
+	// Conflicting detections of similar confidence hold the prompt for a
+	// one-line "/as <intent>" answer; precedence decides the rest
+	arbitrationCfg := projection.DefaultArbitrationConfig()
+	if v := os.Getenv("DETECTION_AMBIGUITY_MARGIN"); v != "" {
+		if arbitrationCfg.Margin, err = strconv.ParseFloat(v, 64); err != nil || arbitrationCfg.Margin < 0 {
+			log.Fatalf("invalid DETECTION_AMBIGUITY_MARGIN %q: want a non-negative number", v)
+		}
+	}
+	var pendingArbitration *heldArbitration
+	var pendingStale *projection.Preference       // stale preference awaiting /keep or /retire
+	var pendingCorrections []update.Correction    // negative deltas awaiting the next committed update
+	var lastBranch *branchTurn                    // last generated turn, for /branch
+	var lastEvidence []retrieval.EvidenceRecord   // evidence the last generated turn used, numbered for /pin
+	var openBranch *pendingBranch                 // generated branch awaiting /branch pick
+	lastStaleAskTurn := 0
+	prefStaleAge := time.Duration(envInt("PREF_STALE_DAYS", 90)) * 24 * time.Hour // 0 disables staleness check-ins
 	session := SessionState{}
+	trend := newTrendBuffer(10) // last 10 soft scores + delta norms for inline sparkline
+
+	// In-process caches share one byte budget with global LRU eviction
+	cacheMB := envInt("CACHE_MAX_MB", 64) // 0 disables caching
+	cacheMgr := cache.NewManager(cache.Config{MaxBytes: int64(cacheMB) << 20, Disabled: cacheMB <= 0})
+	embedCache := cacheMgr.Register("embeddings", 0)
+
+	// External signals: local tools report build/test outcomes, folded into the next turn (disabled by default)
+	externalQueue := signals.NewExternalQueue()
+	if addr := os.Getenv("EXTERNAL_SIGNALS_ADDR"); addr != "" {
+		ln, lnErr := signals.ListenExternal(addr)
+		if lnErr != nil {
+			log.Fatalf("failed to start external signals listener: %v", lnErr)
+		}
+		srv := newHTTPServer(signals.ExternalHandler(externalQueue))
+		c.closers = append(c.closers, func() { srv.Close() })
+		go func() {
+			if serveErr := srv.Serve(ln); serveErr != nil && !errors.Is(serveErr, http.ErrServerClosed) {
+				log.Printf("external signals listener stopped: %v", serveErr)
+			}
+		}()
+		log.Printf("external signals: listening on %s", addr)
+	}
+
+	// Hot state export: signed JSON profile for downstream services, refreshed on each commit
+	var exporter *stateExporter
+	if key := os.Getenv("STATE_EXPORT_KEY"); key != "" {
+		exporter = &stateExporter{key: []byte(key), path: envOr("STATE_EXPORT_FILE", "state_export.json"), topN: envInt("STATE_EXPORT_PREFS", 10),
+			store: store, prefs: prefStore, plans: planStore}
+		exporter.refresh()
+		log.Printf("state export: signed profile at %s (and GET /export with --serve)", exporter.path)
+	}
+
+	// Alert rules (ALERT_RULES=alerts.yaml): conditions over recent turns → log or webhook
+	var alerts *alertRunner
+	if path := os.Getenv("ALERT_RULES"); path != "" {
+		if alerts, err = loadAlerts(path, store); err != nil {
+			log.Fatalf("invalid ALERT_RULES: %v", err)
+		}
+		log.Printf("alerts: %d rule(s) from %s", len(alerts.engine.Rules()), path)
+	}
+
+	// Telemetry (TELEMETRY_DIR=dir, opt-in): decision ratios, norm trajectories and
+	// latency percentiles, never text, summarized to a local file weekly by default
+	var tel *telemetryRunner
+	if dir := os.Getenv("TELEMETRY_DIR"); dir != "" {
+		interval := time.Duration(envInt("TELEMETRY_INTERVAL_DAYS", 7)) * 24 * time.Hour
+		if interval <= 0 {
+			log.Fatalf("invalid TELEMETRY_INTERVAL_DAYS: want at least 1")
+		}
+		if tel, err = newTelemetry(store, dir, interval); err != nil {
+			log.Fatalf("failed to init telemetry: %v", err)
+		}
+		log.Printf("telemetry: local summaries every %s in %s", interval, dir)
+	}
+
+	// Message source: the cipher inbox, or the API queue shared by the HTTP API
+	// (--serve, POST /turn etc.) and the gRPC ControllerService (--grpc)
+	var inbox turnInbox = cipherInbox{}
+	var api *queueInbox
+	if opts.serveAddr != "" || opts.grpcAddr != "" {
+		api = newQueueInbox(envInt("SERVE_QUEUE", 8))
+		inbox = api
+	}
+	apiCfg := apiConfig{Token: os.Getenv("SERVE_TOKEN"), CORSOrigin: os.Getenv("SERVE_CORS_ORIGIN")}
+	if opts.serveAddr != "" {
+		ln, lnErr := listenServe(opts.serveAddr, apiCfg.Token)
+		if lnErr != nil {
+			log.Fatalf("failed to start API server: %v", lnErr)
+		}
+		srv := newHTTPServer(apiHandler(api, store, exporter, apiCfg))
+		c.closers = append(c.closers, func() { srv.Close() })
+		go func() {
+			if serveErr := srv.Serve(ln); serveErr != nil && !errors.Is(serveErr, http.ErrServerClosed) {
+				log.Printf("API server stopped: %v", serveErr)
+			}
+		}()
+		log.Printf("API server: listening on %s", opts.serveAddr)
+	}
+	if opts.grpcAddr != "" {
+		ln, lnErr := listenServe(opts.grpcAddr, apiCfg.Token)
+		if lnErr != nil {
+			log.Fatalf("failed to start gRPC server: %v", lnErr)
+		}
+		grpcSrv := newGRPCServer(api, store, apiCfg.Token)
+		c.closers = append(c.closers, grpcSrv.Stop)
+		go func() {
+			if serveErr := grpcSrv.Serve(ln); serveErr != nil {
+				log.Printf("gRPC server stopped: %v", serveErr)
+			}
+		}()
+		log.Printf("gRPC server: ControllerService listening on %s", opts.grpcAddr)
+	}
 
 	fmt.Println("╔══════════════════════════════════════════╗")
 	fmt.Println("║       ORAC CIPHER DAEMON — ACTIVE        ║")
 	fmt.Println("╠══════════════════════════════════════════╣")
 	fmt.Printf("║  DB:    %-33s║\n", dbPath)
-	fmt.Printf("║  Codec: %-33s║\n", grpcAddr)
+	fmt.Printf("║  Codec: %-33s║\n", codecName)
 	fmt.Println("║  Polling inbox every 3s...               ║")
 	fmt.Println("╚══════════════════════════════════════════╝")
+	if pendingSessionBanner != "" {
+		fmt.Println(pendingSessionBanner)
+	}
 
 	turnNum := 0
 	pollInterval := 3 * time.Second
+	if api != nil {
+		pollInterval = 250 * time.Millisecond // API callers are waiting on the next read
+	}
+
+	// Ctrl+C cancels the in-flight turn; Ctrl+C while idle shuts down cleanly
+	canceller := newTurnCanceller()
+	// envInt, not envDuration, so WATCHDOG_INTERVAL=0 turns the watchdog off
+	watchdogInterval := time.Duration(envInt("WATCHDOG_INTERVAL", 15)) * time.Second
+	streamOutput := envInt("STREAM_OUTPUT", 1) != 0 // echo responses to the console as they generate
+
+	// Per-turn deadline: RPC timeouts are cut to what remains, optional stages
+	// (retrieval + re-generate, retries, reflection) are skipped when they won't fit
+	turnPlanner := budget.NewPlanner(time.Duration(envInt("TURN_DEADLINE", 90)) * time.Second) // 0 disables
+
+	// The planner's stage averages are learned at runtime: kept in the settings
+	// store across restarts, saved on shutdown
+	settingsStore, err := settings.NewStore(store.DB())
+	if err != nil {
+		log.Fatalf("failed to init settings store: %v", err)
+	}
+	loadStageEstimates(turnPlanner, settingsStore)
+	c.closers = append(c.closers, func() { saveStageEstimates(turnPlanner, settingsStore) })
+
+	// Sampling parameters per turn: risk segment norm cools temperature, creative turns warm it
+	samplingCfg, err := sampling.ParseConfig(os.Getenv("SAMPLING_PARAMS"), sampling.DefaultConfig())
+	if err != nil {
+		log.Fatalf("invalid SAMPLING_PARAMS: %v", err)
+	}
+	if samplingCfg.Disabled {
+		log.Printf("sampling: server defaults (SAMPLING_PARAMS=off)")
+	}
+	turnBrancher := &brancher{
+		codec: codecClient, store: store, signals: signalProducer, update: updateConfig,
+		gate: stateGate, eval: evalHarness, sampling: samplingCfg,
+		timeout: timeoutGenerate, embedTimeout: timeoutEmbed,
+	}
+
+	// /nudge is a developer command: off unless NUDGE_COMMANDS is set
+	var segmentNudger *nudger
+	if envInt("NUDGE_COMMANDS", 0) != 0 {
+		segmentNudger = &nudger{store: store, gate: stateGate, eval: evalHarness, maxDelta: updateConfig.MaxDeltaNormPerSegment}
+		log.Printf("nudge commands: enabled (|amount| <= %.2f)", updateConfig.MaxDeltaNormPerSegment)
+	}
+
+	// Consolidation ("sleep"): replay recent provenance, rehearse what kept coming
+	// back, decay what did not, and reflect; by /sleep or once idle every N turns
+	consolidationRuns, err := consolidation.NewStore(store.DB())
+	if err != nil {
+		log.Fatalf("failed to init consolidation store: %v", err)
+	}
+	stateSleeper := &sleeper{codec: codecClient, store: store, graph: graphStore, interior: interiorStore, runs: consolidationRuns,
+		gate: stateGate, eval: evalHarness, cfg: consolidation.DefaultConfig(), timeout: timeoutGenerate}
+	sleepEveryTurns := envInt("SLEEP_INTERVAL_TURNS", 0) // 0 disables
+	lastSleepTurn := 0
+
+	// Reflection question per turn type; REFLECTION_TEMPLATES overrides the built-ins
+	reflectionTemplates := projection.DefaultReflectionTemplates()
+	if path := os.Getenv("REFLECTION_TEMPLATES"); path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			log.Fatalf("invalid REFLECTION_TEMPLATES: %v", err)
+		}
+		turnTypes := make([]string, len(orchestrator.TurnTypes))
+		for i, tt := range orchestrator.TurnTypes {
+			turnTypes[i] = string(tt)
+		}
+		if reflectionTemplates, err = projection.ParseReflectionTemplates(data, turnTypes); err != nil {
+			log.Fatalf("invalid REFLECTION_TEMPLATES: %v", err)
+		}
+		log.Printf("reflection templates: %d from %s", len(reflectionTemplates), path)
+	}
+
+	// Memory correction reviewer: llm (default), rules (gate feedback), or human (terminal picker)
+	memoryReviewer, err := newMemoryReviewer(os.Getenv("MEMORY_REVIEWER"), codecClient, store, timeoutGenerate, watchdogInterval)
+	if err != nil {
+		log.Fatalf("invalid MEMORY_REVIEWER: %v", err)
+	}
+	log.Printf("memory reviewer: %s", memoryReviewer.Name())
+
+	if faults != nil {
+		faults.SetEnabled(true)
+	}
 
-	for {
-		// Poll encrypted inbox
-		inboxMsg, inboxErr := cipher.ReadInbox()
+	c.poll = func() bool {
+		policy.check()
+
+		// Poll the inbox (cipher files, or the API queue with --serve/--grpc)
+		inboxMsg, inboxErr := inbox.Read()
 		if inboxErr != nil {
 			log.Printf("inbox read error: %v", inboxErr)
-			time.Sleep(pollInterval)
-			continue
+			if !canceller.Sleep(pollInterval) {
+				return false
+			}
+			return true
 		}
 		if inboxMsg == "" {
-			time.Sleep(pollInterval)
-			continue
+			if heartbeatInterval > 0 && time.Now().After(nextHeartbeat) {
+				nextHeartbeat = time.Now().Add(heartbeatInterval)
+				codecHeartbeat(canceller.Begin(), codecClient, resets, timeoutEmbed)
+			}
+			if time.Now().After(nextRetry) {
+				nextRetry = time.Now().Add(retryInterval)
+				drainWrites(canceller.Begin(), writeQueue, codecClient, timeoutStore)
+			}
+			if benchInterval > 0 && time.Now().After(nextBenchCheck) {
+				nextBenchCheck = time.Now().Add(time.Hour)
+				if due, dueErr := selfBenchmark.runs.Due(time.Now().UTC(), benchInterval); dueErr != nil {
+					log.Printf("self-benchmark schedule error: %v", dueErr)
+				} else if due {
+					log.Printf("self-benchmark: running (idle, last run over %s ago)", benchInterval)
+					benchCtx, benchCancel := canceller.Idle(15*time.Minute, pollInterval, inbox.Waiting)
+					run, regressions, benchErr := selfBenchmark.run(benchCtx)
+					benchCancel()
+					switch {
+					case benchErr != nil:
+						log.Printf("self-benchmark error: %v", benchErr)
+					case len(regressions) > 0:
+						pendingBenchAlert = benchAlert(regressions)
+						log.Printf("self-benchmark run %d REGRESSED: %s", run.ID, pendingBenchAlert)
+					default:
+						log.Printf("self-benchmark run %d: compliance %.2f (%d scored), rule accuracy %.2f, %d/%d failed",
+							run.ID, run.Compliance, run.Scored, run.RuleAccuracy, run.Failures, run.Probes)
+					}
+				}
+			}
+			if ruleReportInterval > 0 && time.Now().After(nextRuleReportCheck) {
+				nextRuleReportCheck = time.Now().Add(time.Hour)
+				if due, dueErr := ruleReports.Due(time.Now().UTC(), ruleReportInterval); dueErr != nil {
+					log.Printf("rule report schedule error: %v", dueErr)
+				} else if due {
+					pendingRuleReport = runRuleReport(store, ruleStore, ruleReports, ruleReportInterval)
+				}
+			}
+			if clusterInterval > 0 && time.Now().After(nextClusterCheck) {
+				nextClusterCheck = time.Now().Add(time.Hour)
+				if due, dueErr := clusterStore.Due(time.Now().UTC(), clusterInterval); dueErr != nil {
+					log.Printf("evidence clustering schedule error: %v", dueErr)
+				} else if due {
+					clusterCtx, clusterCancel := canceller.Idle(15*time.Minute, pollInterval, inbox.Waiting)
+					if clusterErr := runClustering(clusterCtx, codecClient, clusterStore, clusterCfg); clusterErr != nil {
+						log.Printf("evidence clustering error: %v", clusterErr)
+					}
+					clusterCancel()
+				}
+			}
+			if compactionInterval > 0 && time.Now().After(nextCompactionCheck) {
+				nextCompactionCheck = time.Now().Add(time.Hour)
+				if due, dueErr := compactionStore.Due(time.Now().UTC(), compactionInterval); dueErr != nil {
+					log.Printf("evidence compaction schedule error: %v", dueErr)
+				} else if due {
+					compactCtx, compactCancel := canceller.Idle(15*time.Minute, pollInterval, inbox.Waiting)
+					if compactErr := runCompaction(compactCtx, codecClient, store, graphStore, compactionStore, compactionCfg, timeoutGenerate); compactErr != nil {
+						log.Printf("evidence compaction error: %v", compactErr)
+					}
+					compactCancel()
+				}
+			}
+			if curiosityIdle > 0 && time.Now().After(nextExplore) {
+				nextExplore = time.Now().Add(curiosityIdle)
+				if frozen, _ := freezeSchedule.Active(time.Now()); !frozen {
+					exploreCtx, exploreCancel := canceller.Idle(5*time.Minute, pollInterval, inbox.Waiting)
+					if _, _, exploreErr := questionExplorer.run(exploreCtx, 1); exploreErr != nil {
+						log.Printf("curiosity exploration error: %v", exploreErr)
+					}
+					exploreCancel()
+				}
+			}
+			if sleepEveryTurns > 0 && turnNum-lastSleepTurn >= sleepEveryTurns {
+				lastSleepTurn = turnNum
+				if frozen, _ := freezeSchedule.Active(time.Now()); !frozen {
+					sleepCtx, sleepCancel := canceller.Idle(15*time.Minute, pollInterval, inbox.Waiting)
+					if _, moved := stateSleeper.run(sleepCtx, fmt.Sprintf("every %d turns", sleepEveryTurns)); moved && exporter != nil {
+						exporter.refresh()
+					}
+					sleepCancel()
+				}
+			}
+			if tel != nil && time.Now().After(nextTelemetryCheck) {
+				nextTelemetryCheck = time.Now().Add(time.Hour)
+				if tel.due() {
+					tel.summarize()
+				}
+			}
+			if !canceller.Sleep(pollInterval) {
+				return false
+			}
+			return true
 		}
 
 		// Message received — decrypt and process
-		cipher.ClearInbox()
+		inbox.Clear()
+		nextExplore = time.Now().Add(curiosityIdle)
+		return c.turn(inboxMsg)
+	}
+
+	c.turn = func(inboxMsg string) bool {
+		turnCtx := canceller.Begin()
+		turnBudget := turnPlanner.Begin()
+		turnStart := time.Now()
 		prompt := strings.TrimSpace(inboxMsg)
 		log.Printf("inbox: received message (%d chars)", len(prompt))
 		fmt.Printf("\n[INCOMING] encrypted message received (%d chars)\n", len(prompt))
 
 		if prompt == "" {
-			continue
+			return true
+		}
+		frozen, frozenReason := freezeSchedule.Active(time.Now())
+		// Private turns ("/private ..." or the no-learn prefix) run with learning frozen
+		// and, beyond that, leave no text behind: no reflection, plan, calibration sample
+		// or prompt/response in provenance and events, only a redacted marker
+		prompt, private := privatePrompt(prompt, privatePrefix)
+		if private {
+			if prompt == "" {
+				reply := "Usage: /private <message> — answered normally, nothing remembered."
+				fmt.Println(reply)
+				inbox.Reply(reply)
+				return true
+			}
+			frozen, frozenReason = true, privateReason
+			log.Printf("inbox: private turn — nothing from it will be stored")
 		}
 		if prompt == "quit" || prompt == "exit" || prompt == "/shutdown" {
 			fmt.Println("Commander sent shutdown. Exiting.")
-			cipher.WriteOutbox("ORAC shutting down. Goodbye, Commander.")
-			break
+			inbox.Reply("ORAC shutting down. Goodbye, Commander.")
+			return false
 		}
-		if prompt == "/correct" {
+		if prompt == "/correct" || strings.HasPrefix(prompt, "/correct ") {
 			userCorrected = true
-			fmt.Println("Noted. Next update will carry UserCorrection veto.")
-			cipher.WriteOutbox("Noted. Next update will carry UserCorrection veto.")
-			continue
+			reply := "Noted. Next update will carry UserCorrection veto."
+			if seg := strings.TrimSpace(strings.TrimPrefix(prompt, "/correct")); seg != "" {
+				if !isStateSegment(seg) {
+					reply = "Usage: /correct [prefs|goals|heuristics|risk]"
+				} else {
+					pendingCorrections = queueCorrection(pendingCorrections, update.Correction{
+						Segment: seg, Strength: 1, Reason: "/correct " + seg,
+					})
+					reply += fmt.Sprintf(" The %s segment will be pushed back in the next committed update.", seg)
+				}
+			}
+			fmt.Println(reply)
+			inbox.Reply(reply)
+			return true
+		}
+		if prompt == "/plan" || prompt == "/plan clear" {
+			reply := "No active plan."
+			if prompt == "/plan clear" {
+				if err := planStore.Abandon(); err != nil {
+					log.Printf("plan store error: %v", err)
+				}
+				reply = "Plan cleared."
+			} else if active, _ := planStore.Active(); active != nil {
+				reply = active.Format()
+			}
+			fmt.Println(reply)
+			inbox.Reply(reply)
+			return true
+		}
+		if prompt == "/suggestions" || strings.HasPrefix(prompt, "/suggestions ") {
+			reply := runSuggestionCommand(strings.TrimPrefix(prompt, "/suggestions"), suggestionStore, prefStore, ruleStore, store)
+			fmt.Println(reply)
+			inbox.Reply(reply)
+			return true
+		}
+		if prompt == "/rollback" || strings.HasPrefix(prompt, "/rollback ") {
+			reply := rollbackCommand(store, strings.TrimSpace(strings.TrimPrefix(prompt, "/rollback")))
+			if exporter != nil {
+				exporter.refresh()
+			}
+			fmt.Println(reply)
+			inbox.Reply(reply)
+			return true
+		}
+		if prompt == "/explore" || strings.HasPrefix(prompt, "/explore ") {
+			reply := exploreCommand(turnCtx, questionExplorer, strings.TrimSpace(strings.TrimPrefix(prompt, "/explore")), frozen)
+			fmt.Println(reply)
+			inbox.Reply(reply)
+			return true
+		}
+		if prompt == "/sleep" {
+			var reply string
+			if frozen {
+				reply = fmt.Sprintf("Learning is frozen right now (%s); /sleep is refused.", frozenReason)
+			} else {
+				var moved bool
+				reply, moved = stateSleeper.run(turnCtx, "/sleep")
+				if moved && exporter != nil {
+					exporter.refresh()
+				}
+			}
+			fmt.Println(reply)
+			inbox.Reply(reply)
+			return true
+		}
+		if prompt == "/similar" {
+			reply := similarCommand(store)
+			fmt.Println(reply)
+			inbox.Reply(reply)
+			return true
+		}
+		if isQuarantineCommand(prompt) {
+			qCtx, qCancel := context.WithTimeout(turnCtx, timeoutStore)
+			reply := quarantineCommand(qCtx, codecClient, graphStore, quarantine, prompt)
+			qCancel()
+			fmt.Println(reply)
+			inbox.Reply(reply)
+			return true
+		}
+		if isPrefsCommand(prompt) {
+			var reply string
+			if verb := prefsEdit(prompt); verb != "" && frozen {
+				reply = fmt.Sprintf("Learning is frozen right now (%s); /prefs %s is refused.", frozenReason, verb)
+			} else {
+				reply = prefsCommand(store, prefStore, prompt)
+			}
+			fmt.Println(reply)
+			inbox.Reply(reply)
+			return true
+		}
+		if isPinCommand(prompt) {
+			pinCtx, pinCancel := context.WithTimeout(turnCtx, timeoutStore)
+			reply := pinCommand(pinCtx, codecClient, store, prompt, lastEvidence)
+			pinCancel()
+			fmt.Println(reply)
+			inbox.Reply(reply)
+			return true
+		}
+		if prompt == "/branch" || strings.HasPrefix(prompt, "/branch ") {
+			arg := strings.TrimSpace(strings.TrimPrefix(prompt, "/branch"))
+			var reply string
+			switch side, picking := strings.CutPrefix(arg, "pick "); {
+			case frozen:
+				// Picking moves the active state too, so it waits for the freeze to end
+				reply = "Learning is frozen right now; branches would have nothing to keep."
+			case picking && side != "a" && side != "b":
+				reply = branchUsage
+			case picking && openBranch == nil:
+				reply = "No branch to pick from; run /branch first."
+			case picking:
+				if r, err := turnBrancher.pick(openBranch, side); err != nil {
+					log.Printf("branch pick: %v", err)
+					reply = fmt.Sprintf("Could not keep that branch: %v", err)
+				} else {
+					reply = r
+					if side == "b" {
+						lastResponse = openBranch.Response
+					}
+					if exporter != nil {
+						exporter.refresh()
+					}
+				}
+				openBranch, lastBranch = nil, nil
+			case lastBranch == nil:
+				reply = "No turn to branch from yet (private, instruction-only and frozen turns cannot be branched)."
+			default:
+				variant := arg
+				if variant == "" {
+					variant = branchNoEvidence
+				}
+				if variant != branchNoEvidence && variant != branchNoProfile && variant != branchHot {
+					reply = branchUsage
+					break
+				}
+				fmt.Printf("[%s] branching (%s)...\n", lastBranch.TurnID, variant)
+				p, err := turnBrancher.run(turnCtx, *lastBranch, variant)
+				if err != nil {
+					log.Printf("[%s] branch error: %v", lastBranch.TurnID, err)
+					reply = fmt.Sprintf("Could not branch: %v", err)
+					break
+				}
+				openBranch = p
+				reply = p.render()
+			}
+			fmt.Println(reply)
+			inbox.Reply(reply)
+			return true
+		}
+		if prompt == "/nudge" || strings.HasPrefix(prompt, "/nudge ") {
+			var reply string
+			switch {
+			case segmentNudger == nil:
+				reply = "/nudge is disabled; set NUDGE_COMMANDS=1 to enable it."
+			case private:
+				reply = "Nothing is stored on a private turn; /nudge is refused."
+			case frozen:
+				reply = fmt.Sprintf("Learning is frozen right now (%s); /nudge is refused.", frozenReason)
+			default:
+				seg, amount, err := parseNudge(strings.TrimPrefix(prompt, "/nudge"), segmentNudger.maxDelta)
+				if err != nil {
+					reply = err.Error()
+					break
+				}
+				var moved bool
+				reply, moved = segmentNudger.nudge(seg, amount)
+				if moved && exporter != nil {
+					exporter.refresh()
+				}
+			}
+			fmt.Println(reply)
+			inbox.Reply(reply)
+			return true
+		}
+		if openBranch != nil {
+			// An unpicked branch is dropped by the next message; mainline stands
+			log.Printf("[%s] branch %s not picked: mainline kept", openBranch.Turn.TurnID, openBranch.Variant)
+			openBranch = nil
+		}
+		if prompt == "/profile" || strings.HasPrefix(prompt, "/profile forget ") {
+			reply := profileCommand(profileStore, strings.TrimSpace(strings.TrimPrefix(prompt, "/profile")))
+			fmt.Println(reply)
+			inbox.Reply(reply)
+			return true
+		}
+		if prompt == "/style" || prompt == "/style reset" {
+			reply := "No style profile yet."
+			if prompt == "/style reset" {
+				if err := styleStore.Reset(); err != nil {
+					log.Printf("style profile error: %v", err)
+				}
+				reply = "Style profile cleared."
+			} else if profile, _ := styleStore.Get(); len(profile) > 0 {
+				var b strings.Builder
+				b.WriteString("Style profile (inferred):")
+				for _, attr := range []string{projection.AttrLanguage, projection.AttrFormality, projection.AttrCodeRatio} {
+					if a, ok := profile[attr]; ok {
+						fmt.Fprintf(&b, "\n  %s: %s (score %.2f, %d obs, confidence %.0f%%)",
+							attr, a.Value, a.Score, a.Observations, a.Confidence()*100)
+					}
+				}
+				reply = b.String()
+			}
+			fmt.Println(reply)
+			inbox.Reply(reply)
+			return true
+		}
+		if prompt == "/keep" || prompt == "/retire" {
+			reply := "Nothing to confirm."
+			if pendingStale != nil {
+				var err error
+				if prompt == "/keep" {
+					err = prefStore.Refresh(pendingStale.ID)
+					reply = fmt.Sprintf("Kept: %q.", pendingStale.Text)
+				} else {
+					err = prefStore.Retire(pendingStale.ID)
+					reply = fmt.Sprintf("Retired: %q. I'll stop applying it.", pendingStale.Text)
+				}
+				if err != nil {
+					log.Printf("preference lifecycle error: %v", err)
+					reply = "Could not update that preference."
+				} else {
+					log.Printf("stale preference %d %s: %q", pendingStale.ID, strings.TrimPrefix(prompt, "/"), pendingStale.Text)
+				}
+				pendingStale = nil
+			}
+			fmt.Println(reply)
+			inbox.Reply(reply)
+			return true
+		}
+		if pendingStale != nil {
+			// Asked once; an unanswered check-in waits for the next aging window
+			pendingStale = nil
+		}
+		if prompt == "/yes" || prompt == "/no" {
+			reply := "Nothing to confirm."
+			if pendingDetection != nil {
+				reply = answerDetection(detectionLabels, *pendingDetection, prompt == "/yes", prefStore, ruleStore, profileStore)
+				pendingDetection = nil
+			}
+			fmt.Println(reply)
+			inbox.Reply(reply)
+			return true
+		}
+		if pendingDetection != nil {
+			// Unanswered samples stay labeled pending
+			pendingDetection = nil
+		}
+		if prompt == "/accept" || prompt == "/decline" {
+			reply := "Nothing waiting for confirmation."
+			if pendingLearning != nil && prompt == "/accept" && frozen {
+				// Accepting commits, so the update stays held until the freeze ends
+				log.Printf("held update not committed (learning frozen: %s)", frozenReason)
+				reply = fmt.Sprintf("Learning is frozen right now (%s); the update is still held. /accept once the freeze ends, or /decline.", frozenReason)
+			} else if pendingLearning != nil {
+				answer := "declined"
+				if prompt == "/accept" {
+					answer = "kept"
+				}
+				var moved bool
+				reply, moved = answerHeldUpdate(store, *pendingLearning, answer)
+				if moved && exporter != nil {
+					exporter.refresh()
+				}
+				pendingLearning = nil
+			}
+			fmt.Println(reply)
+			inbox.Reply(reply)
+			return true
+		}
+		if pendingLearning != nil {
+			// Not answered: the held update is dropped, and the answer recorded
+			answerHeldUpdate(store, *pendingLearning, "unanswered")
+			pendingLearning = nil
+		}
+		if prompt == "/confirm" || prompt == "/cancel" {
+			reply := "Nothing pending to confirm."
+			if pendingPref != nil && prompt == "/confirm" && frozen {
+				log.Printf("preference not stored (learning frozen: %s): %q", frozenReason, pendingPref.Text)
+				reply = "Learning is frozen right now; that preference was not stored."
+			} else if pendingPref != nil && prompt == "/confirm" {
+				// Priority and expiry from the wording, counted from confirmation
+				prefOpts := projection.OptionsFor(pendingPref.Text, nil, time.Now())
+				prefOpts.Scope = pendingPref.Scope
+				if err := store.WithTx(func(tx *sql.Tx) error {
+					return prefStore.WithTx(tx).AddWithOptions(pendingPref.Text, "explicit", prefOpts)
+				}); err != nil {
+					log.Printf("preference store error: %v", err)
+					reply = "Could not store that preference."
+				} else {
+					log.Printf("preference stored after confirmation: %q", pendingPref.Text)
+					reply = "Confirmed. I'll keep that in mind."
+				}
+			} else if pendingPref != nil {
+				log.Printf("preference discarded: %q", pendingPref.Text)
+				reply = "Discarded. Your existing preferences are unchanged."
+			}
+			pendingPref = nil
+			fmt.Println(reply)
+			inbox.Reply(reply)
+			return true
+		}
+		if pendingPref != nil {
+			log.Printf("preference discarded (not confirmed): %q", pendingPref.Text)
+			pendingPref = nil
+		}
+		var chosenIntent string
+		if kind, ok := strings.CutPrefix(prompt, "/as "); ok {
+			if pendingArbitration == nil {
+				fmt.Println("Nothing waiting for clarification.")
+				inbox.Reply("Nothing waiting for clarification.")
+				return true
+			}
+			kind = strings.TrimSpace(kind)
+			if _, valid := pendingArbitration.arbitration.Choose(kind, arbitrationCfg); !valid {
+				hint := pendingArbitration.arbitration.Question()
+				fmt.Println(hint)
+				inbox.Reply(hint)
+				return true
+			}
+			// The held prompt runs now, with the answer settling the conflict
+			prompt, chosenIntent = pendingArbitration.prompt, kind
+			pendingArbitration = nil
+		} else if pendingArbitration != nil {
+			log.Printf("ambiguous prompt dropped (not clarified): %q", pendingArbitration.prompt)
+			pendingArbitration = nil
+		}
+		rawPrompt := prompt
+
+		// Preprocess the prompt; everything below, learning included, sees the result
+		var preprocessRecords []logging.PreprocessRecord
+		if processed, steps := preprocessors.Run(prompt); len(steps) > 0 {
+			for _, st := range steps {
+				if st.Err != "" {
+					log.Printf("preprocess %s skipped: %s", st.Name, st.Err)
+				} else {
+					log.Printf("preprocess %s: %d → %d chars", st.Name, len(st.Input), len(st.Output))
+				}
+				preprocessRecords = append(preprocessRecords, logging.PreprocessRecord{
+					Name: st.Name, Input: st.Input, Output: st.Output, Error: st.Err,
+				})
+			}
+			prompt = processed
 		}
 
 		// All cipher daemon messages run in cipher mode
 		cipherMode := true
 		_ = cipherMode
 
-		// Detect and store explicit preferences
-		isPreferenceOnly := false
-		if prefText, detected := projection.DetectPreference(prompt); detected {
-			if err := prefStore.Add(prefText, "explicit"); err != nil {
+		// Arbitrate between the detectors: one prompt can read as a preference,
+		// a rule, an identity statement and a correction at once
+		intents := projection.DetectIntents(prompt)
+		if frozen {
+			var kept []projection.Intent
+			for _, in := range intents {
+				if !in.Learning() {
+					kept = append(kept, in)
+				}
+			}
+			log.Printf("learning frozen (%s): preference, identity and rule detection skipped", frozenReason)
+			intents = kept
+		}
+		arbitration := projection.Arbitrate(intents, arbitrationCfg)
+		if chosen, ok := arbitration.Choose(chosenIntent, arbitrationCfg); chosenIntent != "" && ok {
+			arbitration = chosen
+		}
+		if len(arbitration.Notes) > 0 {
+			log.Printf("detection arbitration: %s", strings.Join(arbitration.Notes, "; "))
+		}
+		if len(arbitration.Ambiguous) > 0 {
+			pendingArbitration = &heldArbitration{prompt: rawPrompt, arbitration: arbitration}
+			question := arbitration.Question()
+			fmt.Println(question)
+			inbox.Reply(question)
+			return true
+		}
+
+		// Store an explicit preference (suspended while learning is frozen)
+		isPreferenceOnly := arbitration.LearningOnly()
+		var detections []projection.DetectionLabel // this turn's detector firings, candidates for confirmation sampling
+		if pref, detected := arbitration.Get(projection.IntentPreference); detected {
+			prefText := pref.Value
+			// Dry-run the change first: drastic or conflicting preferences need confirmation
+			// Scope from the wording ("in code reviews") or from what the recent turns were about
+			// Priority ("it's important that ...") and expiry ("for today") from the wording too
+			prefOpts := projection.OptionsFor(prefText, recentContexts, time.Now())
+			prefScope := prefOpts.Scope
+			existingPrefs, _ := prefStore.List()
+			preview := projection.PreviewScopedPreference(prefText, prefScope, existingPrefs, recentResponses)
+			if preview.NeedsConfirmation() {
+				log.Printf("preference held for confirmation: %q (drastic=%v conflicts=%d compliance_delta=%+.4f)",
+					prefText, preview.Drastic, len(preview.Conflicts), preview.ComplianceDelta())
+				pendingPref = &preview
+				warning := preview.Warning()
+				fmt.Println(warning)
+				inbox.Reply(warning)
+				return true
+			}
+			if err := store.WithTx(func(tx *sql.Tx) error {
+				return prefStore.WithTx(tx).AddWithOptions(prefText, "explicit", prefOpts)
+			}); err != nil {
 				log.Printf("preference store error: %v", err)
 			} else {
-				log.Printf("preference stored: %q", prefText)
+				var opts []string
+				if prefScope != projection.ScopeAll {
+					opts = append(opts, "scope "+prefScope)
+				}
+				if prefOpts.Priority != projection.PriorityNormal {
+					opts = append(opts, "priority "+projection.PriorityName(prefOpts.Priority))
+				}
+				if !prefOpts.ExpiresAt.IsZero() {
+					opts = append(opts, "until "+prefOpts.ExpiresAt.Format(time.RFC3339))
+				}
+				if len(opts) > 0 {
+					log.Printf("preference stored (%s): %q", strings.Join(opts, ", "), prefText)
+				} else {
+					log.Printf("preference stored: %q", prefText)
+				}
+				detections = append(detections, projection.DetectionLabel{
+					Detector: projection.DetectorPreference, Field: prefScope, Value: prefText, Prompt: prompt,
+				})
 			}
-			isPreferenceOnly = true
 		}
-		// Detect and store identity statements as preferences (replaces previous identity)
-		if name, detected := projection.DetectIdentity(prompt); detected {
-			identityPref := fmt.Sprintf("The user's name is %s", name)
-			prefStore.DeleteByPrefix("The user's name is")
-			if err := prefStore.Add(identityPref, "general"); err != nil {
-				log.Printf("identity store error: %v", err)
+		// Identity statements (name, pronouns, form of address, AI designation);
+		// each replaces the previous value of its profile field
+		for _, in := range arbitration.Accepted {
+			if in.Kind != projection.IntentIdentity {
+				continue
+			}
+			if err := profileStore.Set(in.Field, in.Value, "explicit"); err != nil {
+				log.Printf("profile store error: %v", err)
 			} else {
-				log.Printf("identity stored: %q (replaced previous)", name)
+				log.Printf("profile %s stored: %q", in.Field, in.Value)
+				detections = append(detections, projection.DetectionLabel{
+					Detector: projection.DetectorIdentity, Field: in.Field, Value: in.Value, Prompt: prompt,
+				})
 			}
 		}
-		// Detect and store AI designation (e.g. "your name is Architect")
-		if designation, detected := projection.DetectAIDesignation(prompt); detected {
-			designPref := fmt.Sprintf("The AI's designation is %s", designation)
-			prefStore.DeleteByPrefix("The AI's designation is")
-			if err := prefStore.Add(designPref, "explicit"); err != nil {
-				log.Printf("AI designation store error: %v", err)
+		// Behavioral rules; teaching one needs no generation
+		if rule, detected := arbitration.Get(projection.IntentRule); detected {
+			trigger, response := rule.Field, rule.Value
+			if err := ruleStore.Add(trigger, response, 5, 1.0); err != nil {
+				log.Printf("rule store error: %v", err)
 			} else {
-				log.Printf("AI designation stored: %q", designation)
+				log.Printf("rule stored: %q → %q", trigger, response)
+				detections = append(detections, projection.DetectionLabel{
+					Detector: projection.DetectorRule, Field: trigger, Value: response, Prompt: prompt,
+				})
+				if unknown := projection.UnknownRuleVarsFor(trigger, response); len(unknown) > 0 {
+					log.Printf("rule response has unknown template variables %v (left as written; known: %s)",
+						unknown, strings.Join(projection.RuleVarNames, ", "))
+				}
 			}
 		}
-		// Detect and extract behavioral rules
-		if projection.DetectRule(prompt) {
-			if trigger, response, ok := projection.ExtractRule(prompt); ok {
-				if err := ruleStore.Add(trigger, response, 5, 1.0); err != nil {
-					log.Printf("rule store error: %v", err)
+		// Corrections need generation — also flag for gate veto
+		if arbitration.Has(projection.IntentCorrection) && !private {
+			userCorrected = true
+			log.Printf("correction detected in prompt")
+
+			// A correction tied to a stored preference pushes prefs away from the
+			// offending response in the next committed update
+			existingPrefs, _ := prefStore.List()
+			if pref, ok := projection.CorrectedPreference(prompt, existingPrefs); ok && lastResponse != "" {
+				embedCtx, embedCancel := context.WithTimeout(turnCtx, timeoutEmbed)
+				offending, embedErr := codecClient.Embed(embedCtx, lastResponse)
+				embedCancel()
+				if embedErr != nil || len(offending) < 32 {
+					log.Printf("correction direction embed failed (non-fatal, veto only): %v", embedErr)
 				} else {
-					log.Printf("rule stored: %q → %q", trigger, response)
+					pendingCorrections = queueCorrection(pendingCorrections, update.Correction{
+						Segment: "prefs", Direction: offending[:32], Strength: 1,
+						Reason: fmt.Sprintf("correction re %q", pref.Text),
+					})
+					log.Printf("negative delta queued: prefs away from last response (re %q)", pref.Text)
 				}
-				isPreferenceOnly = true // rule-teaching doesn't need generation
 			}
 		}
-		// Detect corrections — also flag for gate veto
-		if projection.DetectCorrection(prompt) {
-			userCorrected = true
-			log.Printf("correction detected in prompt")
-			isPreferenceOnly = false // corrections need generation
+
+		// Preference removal in plain words ("forget my preference about examples"):
+		// delete or downgrade the preferences it refers to; no generation. When no
+		// stored preference matches, the message is answered as a normal turn
+		if removal, detected := arbitration.Get(projection.IntentPrefRemoval); detected {
+			reply, matched := retractPreference(store, prefStore, projection.RemovalRequest{
+				Subject: removal.Value, Downgrade: removal.Field == "downgrade",
+			})
+			if matched {
+				fmt.Println(reply)
+				inbox.Reply(reply)
+				return true
+			}
+			log.Printf("preference removal %q matched no stored preference; answering as a normal turn", removal.Value)
 		}
 
 		// Memory correction: Commander wants to review and delete bad evidence
-		if projection.DetectMemoryCorrection(prompt) && lastPrompt != "" {
+		if arbitration.Has(projection.IntentMemoryCorrection) && lastPrompt != "" {
 			log.Printf("memory correction triggered — reviewing evidence")
 			// Search for evidence similar to the previous exchange
 			searchQuery := lastPrompt + "\n" + lastResponse
-			searchCtx, searchCancel := context.WithTimeout(context.Background(), timeoutSearch)
+			searchCtx, searchCancel := context.WithTimeout(turnCtx, timeoutSearch)
 			searchResults, searchErr := codecClient.Search(searchCtx, searchQuery, 10, 0.1)
 			searchCancel()
 			if searchErr != nil {
 				log.Printf("memory review search error: %v", searchErr)
-				cipher.WriteOutbox("Could not search evidence for review.")
+				inbox.Reply("Could not search evidence for review.")
 				fmt.Println("Could not search evidence for review.")
-				continue
+				return true
 			}
 			if len(searchResults) == 0 {
-				cipher.WriteOutbox("No related evidence found to review.")
+				inbox.Reply("No related evidence found to review.")
 				fmt.Println("No related evidence found to review.")
-				continue
+				return true
 			}
 
-			// Build review prompt showing evidence items + gate feedback
-			var reviewLines []string
-			reviewLines = append(reviewLines, "Commander flagged your last response as junk.")
-			if lastGateSummary != "" {
-				reviewLines = append(reviewLines, fmt.Sprintf("Gate feedback from that turn: %s", lastGateSummary))
+			// Hand the flagged exchange + candidates to the configured reviewer
+			reviewReq := review.Request{
+				LastPrompt:    lastPrompt,
+				LastResponse:  lastResponse,
+				HasGate:       lastGateSummary != "",
+				GateSummary:   lastGateSummary,
+				GateSoftScore: lastGateSoftScore,
+				GateVetoed:    lastGateVetoed,
 			}
-			reviewLines = append(reviewLines, fmt.Sprintf("Your last exchange was:\n  Commander: %s\n  You: %s", lastPrompt, lastResponse))
-			reviewLines = append(reviewLines, "\nRelated evidence items in your memory:")
-			var validIDs []string
 			for _, sr := range searchResults {
-				text := sr.Text
-				if len(text) > 200 {
-					text = text[:200] + "..."
+				if evidence.ParseAnnotation(sr.MetadataJSON).Pinned {
+					continue // pinned evidence is never offered for deletion
 				}
-				reviewLines = append(reviewLines, fmt.Sprintf("  ID: %s\n  Text: %s\n  Score: %.4f\n", sr.ID, text, sr.Score))
-				validIDs = append(validIDs, sr.ID)
+				reviewReq.Candidates = append(reviewReq.Candidates, review.Candidate{ID: sr.ID, Text: sr.Text, Score: sr.Score})
 			}
-			reviewLines = append(reviewLines, "Which IDs should be deleted? List one per line, or NONE.")
-			reviewPrompt := strings.Join(reviewLines, "\n")
-
-			// Send to Orac in review mode (no tools, no state wrapping)
-			reviewState, _ := store.GetCurrent()
-			reviewCtx, reviewCancel := context.WithTimeout(context.Background(), timeoutGenerate)
-			reviewResult, reviewErr := codecClient.Generate(reviewCtx, reviewPrompt, reviewState.StateVector, []string{"[REVIEW MODE]"}, nil)
-			reviewCancel()
+			if len(reviewReq.Candidates) == 0 {
+				inbox.Reply("Related evidence is all pinned; nothing to review.")
+				fmt.Println("Related evidence is all pinned; nothing to review.")
+				return true
+			}
+			decision, reviewErr := memoryReviewer.Review(turnCtx, reviewReq)
 			if reviewErr != nil {
-				log.Printf("memory review generate error: %v", reviewErr)
+				log.Printf("memory review (%s) error: %v", memoryReviewer.Name(), reviewErr)
 				fmt.Println("Could not complete evidence review.")
-				continue
+				return true
 			}
-
-			// Parse and validate IDs from Orac's response
-			deleteIDs := parseDeleteIDs(reviewResult.Text, validIDs)
+			logMemoryReview(store, memoryReviewer, reviewReq, decision)
+			deleteIDs := decision.DeleteIDs
 			if len(deleteIDs) == 0 {
-				cipher.WriteOutbox("Reviewed memory: nothing to delete.")
+				inbox.Reply("Reviewed memory: nothing to delete.")
 				fmt.Println("Reviewed memory: nothing to delete.")
-				continue
+				return true
 			}
 
 			// Execute deletions
-			delCtx, delCancel := context.WithTimeout(context.Background(), timeoutStore)
+			delCtx, delCancel := context.WithTimeout(turnCtx, timeoutStore)
 			deleted, delErr := codecClient.DeleteEvidence(delCtx, deleteIDs)
 			delCancel()
 			if delErr != nil {
 				log.Printf("delete evidence error: %v", delErr)
-				cipher.WriteOutbox("Error deleting evidence.")
+				inbox.Reply("Error deleting evidence.")
 				fmt.Println("Error deleting evidence.")
 			} else {
 				// Sever graph edges for deleted evidence nodes
@@ -312,21 +1505,27 @@ func main() {
 					}
 				}
 				msg := fmt.Sprintf("Reviewed memory: deleted %d junk items.", deleted)
-				cipher.WriteOutbox(msg)
+				inbox.Reply(msg)
 				fmt.Println(msg)
 				log.Printf("memory review: deleted %d/%d items (edges severed)", deleted, len(deleteIDs))
 			}
-			continue
+			return true
 		}
 
 		turnNum++
 		turnID := fmt.Sprintf("turn-%d", turnNum)
+		pendingDetection = sampleDetection(detectionLabels, detections, detectSampleRate, turnID)
+		// A turn due for a heartbeat handshakes first; back-to-back turns share one
+		if heartbeatInterval > 0 && time.Now().After(nextHeartbeat) {
+			nextHeartbeat = time.Now().Add(heartbeatInterval)
+			codecHeartbeat(turnCtx, codecClient, resets, timeoutEmbed)
+		}
 
 		// Step 1: Get current state
 		current, err := store.GetCurrent()
 		if err != nil {
 			log.Printf("error getting current state: %v", err)
-			continue
+			return true
 		}
 
 		// State norm warning (logging only)
@@ -345,11 +1544,63 @@ func main() {
 			prefsNorm += current.StateVector[i] * current.StateVector[i]
 		}
 		prefsNorm = float32(math.Sqrt(float64(prefsNorm)))
-		storedPrefs, _ := prefStore.List()
+		// Only preferences scoped to this turn's context (or unscoped) project and score compliance
+		turnContext := projection.TurnContext(string(orchestrator.ClassifyTurn(prompt, nil).Type), prompt)
+		allPrefs, _ := prefStore.List()
+		storedPrefs := projection.ScopedTo(allPrefs, turnContext)
 		stateBlock := projection.ProjectToPrompt(storedPrefs, prefsNorm)
-		wrappedPrompt := projection.WrapPrompt(stateBlock, prompt)
 		if stateBlock != "" {
-			log.Printf("[%s] state projection: %d prefs, prefs_norm=%.4f", turnID, len(storedPrefs), prefsNorm)
+			log.Printf("[%s] state projection: %d/%d prefs (context %s), prefs_norm=%.4f", turnID, len(storedPrefs), len(allPrefs), turnContext, prefsNorm)
+		}
+		// Profile (identity) projects ahead of preferences, unweighted by the state
+		if profile, _ := profileStore.Get(); len(profile) > 0 {
+			stateBlock = projection.ProjectProfile(profile) + stateBlock
+			log.Printf("[%s] profile projection: %d fields", turnID, len(profile))
+		}
+
+		// Style profile: observe this message, then project stable inferred attributes
+		// after the explicit preferences (lower confidence weight). Observing is learning, so a freeze skips it.
+		if !frozen {
+			if err := styleStore.Observe(projection.ObserveStyle(prompt)); err != nil {
+				log.Printf("style profile error: %v", err)
+			}
+		}
+		if profile, _ := styleStore.Get(); len(profile) > 0 {
+			if styleBlock := projection.ProjectStyleProfile(profile); styleBlock != "" {
+				stateBlock += styleBlock
+				log.Printf("[%s] style projection: %d attributes", turnID, len(profile))
+			}
+		}
+		wrappedPrompt := projection.WrapPrompt(stateBlock, prompt)
+
+		// Plan tracking: multi-step requests start a plan; "done"/"next step" advances it.
+		// The current step is injected ahead of the prompt; progress feeds the goals segment.
+		// A frozen (or private) turn neither starts nor advances a plan.
+		var planProgress float32
+		if steps, ok := plan.DetectPlan(prompt); ok && !frozen {
+			goal := prompt
+			if r := []rune(goal); len(r) > 80 {
+				goal = string(r[:80]) + "…"
+			}
+			if p, err := planStore.Start(goal, steps); err != nil {
+				log.Printf("[%s] plan store error: %v", turnID, err)
+			} else {
+				log.Printf("[%s] plan started: %d steps", turnID, len(p.Steps))
+			}
+		} else if plan.DetectStepDone(prompt) && !frozen {
+			if p, err := planStore.Advance(); err != nil {
+				log.Printf("[%s] plan store error: %v", turnID, err)
+			} else if p != nil {
+				planProgress = 1 / float32(len(p.Steps))
+				log.Printf("[%s] plan advanced: %.0f%% done (%s)", turnID, p.Progress()*100, p.Status)
+			}
+		}
+		systemBlock := stateBlock // everything injected ahead of the prompt, for provenance
+		if activePlan, _ := planStore.Active(); activePlan != nil {
+			if block := activePlan.PromptBlock(); block != "" {
+				wrappedPrompt = block + "\n" + wrappedPrompt
+				systemBlock = block + "\n" + systemBlock
+			}
 		}
 
 		// Compute goals segment norm for retrieval threshold adjustment
@@ -359,57 +1610,179 @@ func main() {
 		}
 		goalsNorm = float32(math.Sqrt(float64(goalsNorm)))
 
-		// Load behavioral rules matching current input (contextual injection, bypasses retrieval)
-		matchedRules, _ := ruleStore.Match(prompt)
+		// Load behavioral rules matching current input (contextual injection, bypasses retrieval);
+		// response templates resolve against the profile, preferences and plan as of now
+		turnRuleVars := ruleVars(profileStore, allPrefs, planStore)
+		var matchedRules []projection.Rule
+		if run := session.Script; run != nil {
+			step, outcome := run.Advance(prompt, time.Now(), ruleScriptTimeout)
+			switch outcome {
+			case projection.ScriptStepped, projection.ScriptFinished:
+				matchedRules = []projection.Rule{step}
+				log.Printf("[%s] rule script %q: %s (%s)", turnID, run.Rule.Trigger, run.Position(), outcome)
+			default:
+				log.Printf("[%s] rule script %q released at %s (%s)", turnID, run.Rule.Trigger, run.Position(), outcome)
+			}
+			if outcome != projection.ScriptStepped {
+				session.Script = nil
+			}
+		}
+		if len(matchedRules) == 0 {
+			matchedRules, _ = ruleStore.MatchAll(prompt)
+		}
+		if len(matchedRules) > 1 {
+			var losers []string
+			for _, r := range matchedRules[1:] {
+				losers = append(losers, fmt.Sprintf("%q (priority %d)", r.Trigger, r.Priority))
+			}
+			log.Printf("[%s] rule %q (priority %d) wins over %s", turnID, matchedRules[0].Trigger, matchedRules[0].Priority, strings.Join(losers, ", "))
+			matchedRules = matchedRules[:1]
+		}
+		// Rule usage and decay are learning: a frozen or private turn leaves them as they were
+		if !frozen {
+			var pruned []projection.Rule
+			if err := store.WithTx(func(tx *sql.Tx) error {
+				var err error
+				pruned, err = ruleStore.WithTx(tx).RecordTurn(matchedRules, ruleDecay)
+				return err
+			}); err != nil {
+				log.Printf("[%s] rule usage error (rolled back): %v", turnID, err)
+			}
+			for _, r := range pruned {
+				why := fmt.Sprintf("confidence %.2f after %d idle turns", r.Confidence, r.IdleTurns)
+				if r.Expired(time.Now()) {
+					why = "expired"
+				}
+				log.Printf("[%s] rule pruned (%s, fired %d times): %q", turnID, why, r.FiredCount, r.Trigger)
+			}
+		}
+		matchedRules = projection.ResolveRules(matchedRules, turnRuleVars)
 		var ruleEvidence []string
 		if len(matchedRules) > 0 {
 			rulesBlock := projection.FormatRulesBlock(matchedRules)
 			ruleEvidence = append(ruleEvidence, rulesBlock)
-			session.RuleActive = true
-			session.LastRuleTurn = turnNum
-			log.Printf("[%s] rules matched: %d for input %q (rule context locked)", turnID, len(matchedRules), prompt)
-		} else if session.RuleActive {
-			// Release lock when input no longer matches rule continuation pattern
-			if !isRuleContinuation(prompt) {
-				session.RuleActive = false
-				log.Printf("[%s] rule context released (non-continuation input)", turnID)
-			} else {
-				log.Printf("[%s] rule context active (continuation detected)", turnID)
+			systemBlock = "" // rule turns generate from the bare prompt
+			log.Printf("[%s] rules matched: %d for input %q", turnID, len(matchedRules), prompt)
+			if run := projection.StartScript(matchedRules[0], time.Now()); run != nil {
+				session.Script = run
+				log.Printf("[%s] rule script %q started (%d steps)", turnID, run.Rule.Trigger, len(run.Rule.Steps))
 			}
 		}
 
-		// Load Orac's last reflection for interior state injection (non-rule turns only)
+		// Load Orac's last reflection for classification and interior state injection
 		lastReflection, _ := interiorStore.Latest()
+
+		// Orchestrator: classify turn and select initial strategy
+		orchResult := orch.PreGenerate(prompt, lastReflection)
+		activeStrategy := orchResult.Strategy
+
+		// Interior state (non-rule turns only): the last reflection, plus on
+		// philosophical or deep turns the past reflections closest to the prompt
 		var interiorEvidence []string
 		if lastReflection != nil && len(matchedRules) == 0 {
 			interiorEvidence = []string{"[ORAC INTERIOR STATE]\n" + lastReflection.ReflectionText}
 			log.Printf("[%s] interior state: reflection from %s injected", turnID, lastReflection.TurnID)
+			class := orchResult.Classification
+			if interiorRecall > 0 && (class.Type == orchestrator.TurnPhilosophical || class.Complexity == orchestrator.ComplexityDeep) {
+				past, err := interiorStore.Search(prompt, interiorRecall+1)
+				if err != nil {
+					log.Printf("[%s] interior recall error: %v", turnID, err)
+				}
+				var recalled []string
+				for _, r := range past {
+					if r.ID == lastReflection.ID || len(recalled) == interiorRecall {
+						continue
+					}
+					interiorEvidence = append(interiorEvidence,
+						fmt.Sprintf("[ORAC INTERIOR STATE]\nEarlier (%s): %s", r.CreatedAt.Format("2006-01-02"), r.ReflectionText))
+					recalled = append(recalled, r.TurnID)
+				}
+				if len(recalled) > 0 {
+					log.Printf("[%s] interior recall: %d past reflection(s) injected (%s)", turnID, len(recalled), strings.Join(recalled, ", "))
+				}
+			}
 		}
 
-		// Orchestrator: classify turn and select initial strategy
-		orchResult := orch.PreGenerate(prompt, lastReflection)
-		activeStrategy := orchResult.Strategy
+		// Sampling parameters from the risk segment norm and turn type, sent on every generate pass
+		riskNorm := float32(0)
+		for i := current.SegmentMap.Risk[0]; i < current.SegmentMap.Risk[1]; i++ {
+			riskNorm += current.StateVector[i] * current.StateVector[i]
+		}
+		riskNorm = float32(math.Sqrt(float64(riskNorm)))
+		samplingChoice := sampling.Choose(samplingCfg, riskNorm, string(orchResult.Classification.Type))
+
+		// Pre-gate: decide from the prompt and its classification, before any generation
+		preDecision := preGate.Evaluate(gate.PreGateInput{
+			Prompt:          prompt,
+			TurnType:        string(orchResult.Classification.Type),
+			Risk:            string(orchResult.Classification.Risk),
+			InstructionOnly: isPreferenceOnly,
+			RuleMatched:     len(matchedRules) > 0,
+			PreviousAsked:   gate.AsksQuestion(lastResponse),
+		})
+		var preGateRecord *logging.PreGateRecord
+		hardened := preDecision.Action == gate.PreGateHarden
+		if preDecision.Action != gate.PreGateProceed {
+			preGateRecord = &logging.PreGateRecord{Action: preDecision.Action, Reason: preDecision.Reason, Notes: preDecision.Notes}
+			log.Printf("[%s] pre-gate: %s (%s)", turnID, preDecision.Action, preDecision.Reason)
+		}
+		if preDecision.Action == gate.PreGateShortCircuit {
+			isPreferenceOnly = true // same path as instruction-only prompts: no generation or retrieval
+		}
+
+		// Re-prime after a codec reset: the recap goes ahead of the prompt of the
+		// next generated turn; rule turns and skipped generations leave it pending
+		var reprimeRecord *logging.ReprimeRecord
+		if reset := resets.Pending(); reset != nil && !isPreferenceOnly && len(matchedRules) == 0 {
+			var block string
+			block, reprimeRecord = reprimeBlock(store.DB(), reset, current, storedPrefs, reprimeCfg)
+			wrappedPrompt = block + wrappedPrompt
+			systemBlock = block + systemBlock
+			resets.Clear()
+			log.Printf("[%s] re-primed after %s (%s): %d exchanges, %d preferences",
+				turnID, reset.Reason, reset.Detail, reprimeRecord.Turns, reprimeRecord.Preferences)
+		}
 
 		// Variables that may be populated by generation or skipped for instruction-only prompts
 		var result codec.GenerateResult
 		var evidenceStrings []string
 		var evidenceRefs []string
+		var contradictionRecords []logging.ContradictionRecord
+		var usedEvidence []retrieval.EvidenceRecord // records behind evidenceStrings, in order
+		var attributionRecords []logging.AttributionRecord
+		var citationIDs []string
 		var gateResult retrieval.GateResult
-		var curiosity []string
+		var curiositySignals []string
+		var pendingReflection string // saved in the end-of-turn transaction
 		var orchAttempts []orchestrator.Attempt
+		var samplingRecord *logging.SamplingRecord
+		var profileCuts []string              // stages the resource profile skipped or shrank
+		var genPrompt, genModifier string     // prompt and strategy modifier behind result, for /branch
+		var genMarkers, genRetrieved []string // evidence behind result: markers/interior/rules, then retrieved
+		lastBranch = nil                      // set again once this turn's learning outcome is known
 
 		if isPreferenceOnly {
-			// Instruction-only prompt: skip generation, provide canned acknowledgment
-			ack := "Got it. I'll keep that in mind."
-			cipher.WriteOutbox(ack)
+			// Short-circuited by the pre-gate (instruction-only or acknowledgement): canned reply
+			ack := preDecision.Reply
+			if pendingDetection != nil {
+				ack += "\n\n" + pendingDetection.Question()
+			}
+			inbox.Reply(ack)
 			fmt.Println("[OUTGOING] encrypted response sent")
-			log.Printf("[%s] preference-only prompt — skipped generation", turnID)
+			log.Printf("[%s] %s — skipped generation", turnID, preDecision.Reason)
 			// Set minimal result for learning loop
 			result = codec.GenerateResult{
-				Text:    "Got it. I'll keep that in mind.",
+				Text:    ack,
 				Entropy: 0.0,
 			}
 		} else {
+			if s := samplingChoice.Sampling; !s.IsZero() {
+				samplingRecord = &logging.SamplingRecord{Temperature: s.Temperature, TopP: s.TopP, MaxTokens: s.MaxTokens, Adjustments: samplingChoice.Adjustments}
+				if len(samplingChoice.Adjustments) > 0 {
+					log.Printf("[%s] sampling: temperature=%.2f top_p=%.2f max_tokens=%d (%s)", turnID, s.Temperature, s.TopP, s.MaxTokens, strings.Join(samplingChoice.Adjustments, ", "))
+				}
+			}
+
 			// === ORCHESTRATOR RETRY LOOP ===
 			// Wraps first-pass generate + retrieval + re-generate.
 			// Each iteration uses a different strategy if the previous response failed evaluation.
@@ -417,6 +1790,8 @@ func main() {
 				// Clear per-attempt state
 				evidenceStrings = nil
 				evidenceRefs = nil
+				contradictionRecords = nil
+				usedEvidence = nil
 
 				// Apply strategy prompt modifier
 				generatePrompt := wrappedPrompt
@@ -440,49 +1815,98 @@ func main() {
 				}
 
 				// Step 2: First-pass Generate
-				ctx, cancel := context.WithTimeout(context.Background(), timeoutGenerate)
-				result, err = codecClient.Generate(ctx, generatePrompt, current.StateVector, firstPassEvidence, nil)
+				// Generate into a temporary so a failed or cancelled call keeps the last good response
+				ctx, cancel := turnBudget.Context(turnCtx, budget.StageGenerate, timeoutGenerate)
+				stopWatch := watchCodec(turnID, "generate", watchdogInterval)
+				var streamTo io.Writer // private turns are not echoed
+				if streamOutput && !private {
+					streamTo = os.Stdout
+				}
+				gen, genErr := streamGenerate(ctx, codecClient, streamTo, "generate", stopWatch,
+					generatePrompt, current.StateVector, firstPassEvidence, samplingChoice.Sampling)
+				stopWatch()
 				cancel()
-				if err != nil {
+				if genErr != nil {
+					err = genErr
 					log.Printf("codec error: %v", err)
 					break
 				}
+				result = gen
+				genPrompt, genMarkers, genRetrieved = generatePrompt, firstPassEvidence, nil
+				genModifier = ""
+				if len(matchedRules) == 0 {
+					genModifier = activeStrategy.PromptModifier
+				}
 
 				// Step 3: Triple-gated retrieval with strategy-adjusted thresholds
 				// Only use command gate when classifier agrees it's a command (avoids "write me a poem" false positive)
 				isCommand := orchResult.Classification.Type == orchestrator.TurnCommand && retrieval.IsDirectCommand(prompt)
 				if isCommand || activeStrategy.MaxEvidence == 0 {
 					log.Printf("[%s] retrieval skipped (command gate or strategy=%s)", turnID, activeStrategy.ID)
+				} else if !turnBudget.Affords(budget.StageSearch, budget.StageGenerate) {
+					log.Printf("[%s] retrieval skipped: turn budget low (%s left)", turnID, turnBudget.Remaining().Round(time.Second))
 				} else {
 				retCfg := retrieval.DefaultConfig()
 				retCfg.SimilarityThreshold = activeStrategy.SimThreshold
 				retCfg.SimilarityThreshold = retrieval.AdjustedThreshold(retCfg.SimilarityThreshold, goalsNorm)
-				retCfg.TopK = activeStrategy.MaxEvidence
-				adjustedRetriever := retrieval.NewRetriever(codecClient, retCfg)
-				graphRetriever := retrieval.NewGraphRetriever(adjustedRetriever, graphStore, codecClient)
+				maxEvidence := resourceProfile.EvidenceCap(activeStrategy.MaxEvidence)
+				if maxEvidence < activeStrategy.MaxEvidence {
+					profileCuts = appendCut(profileCuts, fmt.Sprintf("evidence %d→%d", activeStrategy.MaxEvidence, maxEvidence))
+				}
+				retCfg.TopK = maxEvidence
+				adjustedRetriever := retrieval.NewRetriever(codecClient, retCfg).WithSources(federatedSources)
+				graphRetriever := retrieval.NewGraphRetriever(adjustedRetriever, graphStore, codecClient).
+					WithWalkConfig(walkCfg).WithCentrality(centrality, centralityBoost).WithTopics(clusterStore, topicCfg).WithQuarantine(quarantine)
 
-				ctx2, cancel2 := context.WithTimeout(context.Background(), timeoutSearch)
+				ctx2, cancel2 := turnBudget.Context(turnCtx, budget.StageSearch, timeoutSearch)
 				gateResult, err = graphRetriever.Retrieve(ctx2, prompt, result.Entropy)
 				cancel2()
+				for _, q := range gateResult.Quarantined {
+					log.Printf("[%s] retrieval: quarantined %s (%s); review with /quarantine", turnID, q.ID, strings.Join(q.Patterns, ", "))
+				}
 				if err != nil {
 					log.Printf("retrieval error (non-fatal): %v", err)
 				} else if len(gateResult.Retrieved) > 0 {
 					for _, ev := range gateResult.Retrieved {
-						evidenceStrings = append(evidenceStrings, ev.Text)
+						evidenceStrings = append(evidenceStrings, ev.Attributed())
 						evidenceRefs = append(evidenceRefs, ev.ID)
 					}
 					// Enforce strategy MaxEvidence cap (graph walk may return more)
-					if len(evidenceStrings) > activeStrategy.MaxEvidence {
+					if len(evidenceStrings) > maxEvidence {
 						log.Printf("[%s] evidence capped: %d → %d (strategy=%s)",
-							turnID, len(evidenceStrings), activeStrategy.MaxEvidence, activeStrategy.ID)
-						evidenceStrings = evidenceStrings[:activeStrategy.MaxEvidence]
-						evidenceRefs = evidenceRefs[:activeStrategy.MaxEvidence]
+							turnID, len(evidenceStrings), maxEvidence, activeStrategy.ID)
+						evidenceStrings = evidenceStrings[:maxEvidence]
+						evidenceRefs = evidenceRefs[:maxEvidence]
+					}
+
+					// Contradiction pre-check: tell the model which side of a conflict to trust
+					used := gateResult.Retrieved[:len(evidenceStrings)]
+					usedEvidence = used
+					if conflicts := retrieval.DetectContradictions(used, retrieval.DefaultContradictionConfig()); len(conflicts) > 0 {
+						evidenceStrings = retrieval.AnnotateContradictions(used, conflicts)
+						for _, c := range conflicts {
+							log.Printf("[%s] retrieval: contradiction (%s): %s preferred over %s (%s, overlap=%.2f)",
+								turnID, c.Kind, used[c.Preferred].ID, used[c.Superseded].ID, c.Basis, c.Overlap)
+							contradictionRecords = append(contradictionRecords, logging.ContradictionRecord{
+								PreferredID: used[c.Preferred].ID, SupersededID: used[c.Superseded].ID,
+								Kind: c.Kind, Basis: c.Basis, Overlap: c.Overlap,
+							})
+						}
 					}
 					log.Printf("[%s] retrieval: %s (threshold=%.4f, topk=%d, strategy=%s)",
 						turnID, gateResult.Reason, retCfg.SimilarityThreshold, retCfg.TopK, activeStrategy.ID)
+					for _, ev := range used {
+						if ev.Walked {
+							log.Printf("[%s] retrieval: walked %s %s (score=%.4f)", turnID, ev.ID, graph.DescribePath(ev.WalkPath), ev.Score)
+						}
+					}
+					for _, t := range gateResult.Topics {
+						log.Printf("[%s] retrieval: topic %q (%d memories, %d shown, %d summarized)", turnID, t.Label, t.Size, t.Shown, t.Hidden)
+					}
 
 					// Filter out evidence containing rule response patterns
 					allRules, _ := ruleStore.List()
+					allRules = projection.ResolveRules(allRules, turnRuleVars)
 					if len(allRules) > 0 {
 						var rulePatterns []string
 						for _, r := range allRules {
@@ -492,7 +1916,8 @@ func main() {
 							}
 						}
 						var filtered []string
-						for _, ev := range evidenceStrings {
+						var kept []retrieval.EvidenceRecord
+						for i, ev := range evidenceStrings {
 							evLower := strings.ToLower(ev)
 							contaminated := false
 							for _, pat := range rulePatterns {
@@ -503,50 +1928,86 @@ func main() {
 							}
 							if !contaminated {
 								filtered = append(filtered, ev)
+								kept = append(kept, usedEvidence[i])
 							}
 						}
 						if removed := len(evidenceStrings) - len(filtered); removed > 0 {
 							log.Printf("[%s] evidence filter: removed %d rule-contaminated items", turnID, removed)
 						}
 						evidenceStrings = filtered
+						usedEvidence = kept
 					}
 
-					// Re-generate with evidence injected
-					var allEvidence []string
-					if cipherMode {
-						allEvidence = append(allEvidence, "[CIPHER MODE]")
-					}
-					if activeStrategy.InjectInterior {
-						allEvidence = append(allEvidence, interiorEvidence...)
-					}
-					if activeStrategy.InjectRules && !cipherMode {
-						allEvidence = append(allEvidence, ruleEvidence...)
-					}
-					allEvidence = append(allEvidence, evidenceStrings...)
-					ctx3, cancel3 := context.WithTimeout(context.Background(), timeoutGenerate)
-					result, err = codecClient.Generate(ctx3, generatePrompt, current.StateVector, allEvidence, nil)
-					cancel3()
-					if err != nil {
-						log.Printf("re-generate error: %v", err)
-						break
+					// Re-generate with evidence injected; a resource profile without the
+					// second pass keeps the first-pass reply, and the evidence still feeds
+					// learning and graph edges
+					if !resourceProfile.Regenerate {
+						log.Printf("[%s] re-generate skipped: resource profile %s (first-pass response kept)", turnID, resourceProfile.Name)
+						profileCuts = appendCut(profileCuts, "re-generate")
+					} else {
+						var allEvidence []string
+						if cipherMode {
+							allEvidence = append(allEvidence, "[CIPHER MODE]")
+						}
+						if activeStrategy.InjectInterior {
+							allEvidence = append(allEvidence, interiorEvidence...)
+						}
+						if activeStrategy.InjectRules && !cipherMode {
+							allEvidence = append(allEvidence, ruleEvidence...)
+						}
+						if topics := retrieval.FormatTopics(gateResult.Topics); topics != "" {
+							allEvidence = append(allEvidence, topics)
+						}
+						allEvidence = append(allEvidence, evidenceStrings...)
+						ctx3, cancel3 := turnBudget.Context(turnCtx, budget.StageGenerate, timeoutGenerate)
+						stopWatch := watchCodec(turnID, "re-generate", watchdogInterval)
+						regen, regenErr := streamGenerate(ctx3, codecClient, streamTo, "re-generate", stopWatch, generatePrompt, current.StateVector, allEvidence, samplingChoice.Sampling)
+						stopWatch()
+						cancel3()
+						if regenErr != nil {
+							err = regenErr
+							log.Printf("re-generate error (keeping first-pass response): %v", err)
+							break
+						}
+						result = regen
+						genRetrieved = evidenceStrings
 					}
 				} else {
 					log.Printf("[%s] retrieval: %s", turnID, gateResult.Reason)
 				}
 
-				// Co-retrieval edge formation
-				coRetrievalRefs := evidenceRefs
-				if len(coRetrievalRefs) > 5 {
-					coRetrievalRefs = coRetrievalRefs[:5]
+				// Co-retrieval edge formation: informative pairs only (gate-3 survivors or surprising PMI)
+				walked := make(map[string]bool)
+				for _, ev := range gateResult.Retrieved {
+					if ev.Walked {
+						walked[ev.ID] = true
+					}
+				}
+				var coNodes []graph.CoRetrieved
+				for _, id := range retrieval.LocalIDs(evidenceRefs) {
+					coNodes = append(coNodes, graph.CoRetrieved{ID: id, Gated: !walked[id]})
+				}
+				if len(coNodes) > 0 && !frozen {
+					coRes, coErr := graphStore.FormCoRetrievalEdges(coNodes, coRetrievalCfg)
+					if coErr != nil {
+						log.Printf("[%s] graph: co-retrieval error (non-fatal): %v", turnID, coErr)
+					} else if coRes.Pairs > 0 {
+						log.Printf("[%s] graph: %d/%d co-retrieval pairs linked (gated=%d, surprising=%d)",
+							turnID, coRes.Linked(), coRes.Pairs, coRes.Gated, coRes.Surprising)
+					}
 				}
-				if len(coRetrievalRefs) >= 2 {
-					for i := 0; i < len(coRetrievalRefs); i++ {
-						for j := i + 1; j < len(coRetrievalRefs); j++ {
-							graphStore.IncrementEdge(coRetrievalRefs[i], coRetrievalRefs[j], "co_retrieval", 0.1)
-							graphStore.IncrementEdge(coRetrievalRefs[j], coRetrievalRefs[i], "co_retrieval", 0.1)
+
+				// Usage log: the local items this attempt used; private turns leave no trace
+				if !private {
+					var hits []evidence.Access
+					for _, ev := range usedEvidence {
+						if evidence.IsLocalID(ev.ID) {
+							hits = append(hits, evidence.Access{ID: ev.ID, Score: ev.Score, Walked: ev.Walked})
 						}
 					}
-					log.Printf("[%s] graph: %d co-retrieval edges formed", turnID, len(coRetrievalRefs)*(len(coRetrievalRefs)-1))
+					if err := usageLog.Record(turnID, hits); err != nil {
+						log.Printf("[%s] evidence usage log error (non-fatal): %v", turnID, err)
+					}
 				}
 				} // end retrieval block
 
@@ -573,57 +2034,195 @@ func main() {
 				if orchEval.NextStrategy == nil {
 					break
 				}
+				if !turnBudget.Affords(budget.StageGenerate) {
+					log.Printf("[%s] retry skipped: turn budget low (%s left)", turnID, turnBudget.Remaining().Round(time.Second))
+					break
+				}
 				activeStrategy = *orchEval.NextStrategy
 				log.Printf("[%s] retry %d → strategy=%s", turnID, attemptNum+1, activeStrategy.ID)
 			}
 			// === END RETRY LOOP ===
 
-			// Write encrypted response to outbox for Commander GUI
-			encrypted, encErr := cipher.Encrypt(result.Text)
-			if encErr != nil {
-				log.Printf("outbox encrypt error: %v", encErr)
-			} else if outboxErr := cipher.WriteOutboxRaw(encrypted); outboxErr != nil {
-				log.Printf("outbox write error: %v", outboxErr)
-			} else {
-				fmt.Printf("[OUTGOING] %s\n", encrypted)
+			// Cancelled turn: deliver whatever completed (first pass or a previous attempt)
+			// and skip reflection and learning — an aborted turn must not move the state.
+			if turnCtx.Err() != nil {
+				reply := result.Text
+				if reply == "" {
+					reply = "Turn cancelled."
+				} else {
+					log.Printf("[%s] delivering partial result from last completed step (%d chars)", turnID, len(reply))
+				}
+				inbox.Reply(reply)
+				fmt.Println("[OUTGOING] " + reply)
+				log.Printf("[%s] turn cancelled — state not updated", turnID)
+				cancelled := events.TurnEvent{
+					TurnID:         turnID,
+					Decision:       "cancelled",
+					Prompt:         prompt,
+					Response:       result.Text,
+					Entropy:        result.Entropy,
+					Classification: eventClassification(orchResult.Classification),
+					Strategy:       string(activeStrategy.ID),
+					Attempts:       len(orchAttempts),
+					EvidenceCount:  len(evidenceStrings),
+					VersionBefore:  current.VersionID,
+					VersionAfter:   current.VersionID,
+				}
+				if private {
+					cancelled.Prompt, cancelled.Response = "", ""
+				}
+				emitTurn(emitter, inbox, alerts, tel, turnStart, cancelled)
+				return true
+			}
+
+			// Post-hoc attribution: map a factual answer's sentences to the evidence
+			// supporting them so unsupported claims can be found later; with
+			// citations on, the delivered text carries the inline markers
+			var citedText string
+			if attributionOn && orchResult.Classification.Type == orchestrator.TurnFactual && len(usedEvidence) > 0 {
+				if !turnBudget.Affords(budget.StageSearch) {
+					log.Printf("[%s] attribution skipped: turn budget low (%s left)", turnID, turnBudget.Remaining().Round(time.Second))
+				} else {
+					actx, acancel := turnBudget.Context(turnCtx, budget.StageSearch, timeoutSearch)
+					claims, attrErr := retrieval.Attribute(actx, codecClient.EmbedBatch, result.Text, usedEvidence, retrieval.DefaultAttributionConfig())
+					acancel()
+					if attrErr != nil {
+						log.Printf("[%s] attribution error (non-fatal): %v", turnID, attrErr)
+					} else {
+						supported := 0
+						for _, c := range claims {
+							rec := logging.AttributionRecord{Sentence: c.Text, Start: c.Start, End: c.End}
+							for _, sup := range c.Support {
+								rec.EvidenceIDs = append(rec.EvidenceIDs, usedEvidence[sup.Index].ID)
+								rec.Similarity = append(rec.Similarity, sup.Similarity)
+							}
+							if c.Supported() {
+								supported++
+							}
+							attributionRecords = append(attributionRecords, rec)
+						}
+						log.Printf("[%s] attribution: %d/%d claims supported by evidence", turnID, supported, len(claims))
+						if citationsOn && supported > 0 {
+							cited, sources := retrieval.Cite(result.Text, claims)
+							citedText = cited
+							for _, i := range sources {
+								citationIDs = append(citationIDs, usedEvidence[i].ID)
+							}
+						}
+					}
+				}
+			}
+
+			// Staleness check-in: at a natural moment (ordinary turn, nothing else pending),
+			// ask once about one preference that hasn't been reinforced in PREF_STALE_DAYS
+			outText := result.Text
+			if citedText != "" {
+				outText = citedText
+			}
+			if prefStaleAge > 0 && !frozen && pendingPref == nil && len(matchedRules) == 0 && turnNum-lastStaleAskTurn >= staleAskEveryTurns {
+				if stale, _ := prefStore.Stale(time.Now().UTC(), prefStaleAge); len(stale) > 0 {
+					if err := prefStore.MarkAsked(stale[0].ID); err != nil {
+						log.Printf("[%s] preference lifecycle error: %v", turnID, err)
+					} else {
+						pendingStale = &stale[0]
+						lastStaleAskTurn = turnNum
+						outText += "\n\n" + projection.StaleQuestion(stale[0])
+						log.Printf("[%s] stale preference check-in: %q (last active %s)", turnID, stale[0].Text, stale[0].LastActive().Format("2006-01-02"))
+					}
+				}
+			}
+
+			if pendingDetection != nil {
+				outText += "\n\n" + pendingDetection.Question()
+			}
+			if pendingBenchAlert != "" && pendingPref == nil && len(matchedRules) == 0 {
+				outText += "\n\n" + pendingBenchAlert
+				pendingBenchAlert = ""
+			}
+			if pendingRuleReport != "" && pendingPref == nil && len(matchedRules) == 0 {
+				outText += "\n\n" + pendingRuleReport
+				pendingRuleReport = ""
 			}
+			if pendingSessionBanner != "" && len(matchedRules) == 0 {
+				outText = pendingSessionBanner + "\n\n" + outText
+				pendingSessionBanner = ""
+			}
+
+			// Deliver the response: encrypted outbox for Commander GUI, or the API caller
+			inbox.Reply(outText)
+			fmt.Printf("[OUTGOING] response delivered (%d chars)\n", len(outText))
 
 			// Reflection: Orac speaks from inside himself about this exchange
 			gateFeedback := ""
 			if lastGateSummary != "" {
 				gateFeedback = fmt.Sprintf("\n[GATE FEEDBACK from your previous turn: %s]", lastGateSummary)
 			}
+			// The question depends on the turn type: uncertainty after factual turns,
+			// the user after emotional ones, choices made after creative ones
+			reflectionKey, reflectionQuestion := reflectionTemplates.Render(string(orchResult.Classification.Type), turnRuleVars)
 			reflectionPrompt := fmt.Sprintf(
-				"Commander said: %s\nYou responded: %s%s\n\nNow speak from inside yourself. What did you notice in this exchange? What don't you know that this opened? What do you want to understand?",
-				prompt, result.Text, gateFeedback,
+				"Commander said: %s\nYou responded: %s%s\n\n%s\n\n%s",
+				prompt, result.Text, gateFeedback, reflectionQuestion, projection.SuggestionInstruction,
 			)
-			reflectCtx, reflectCancel := context.WithTimeout(context.Background(), timeoutGenerate)
-			reflectResult, reflectErr := codecClient.Generate(reflectCtx, reflectionPrompt, current.StateVector, []string{"[REFLECTION MODE]"}, nil)
-			reflectCancel()
+			var reflectResult codec.GenerateResult
+			var reflectErr error
+			if private {
+				log.Printf("[%s] reflection skipped: private turn", turnID)
+			} else if !resourceProfile.Reflection {
+				log.Printf("[%s] reflection skipped: resource profile %s", turnID, resourceProfile.Name)
+				profileCuts = appendCut(profileCuts, "reflection")
+			} else if turnBudget.Affords(budget.StageReflection) {
+				reflectCtx, reflectCancel := turnBudget.Context(turnCtx, budget.StageReflection, timeoutGenerate)
+				stopWatch := watchCodec(turnID, "reflection", watchdogInterval)
+				reflectResult, reflectErr = codecClient.Generate(reflectCtx, reflectionPrompt, current.StateVector, []string{"[REFLECTION MODE]"}, nil)
+				stopWatch()
+				reflectCancel()
+			} else {
+				log.Printf("[%s] reflection skipped: turn budget low (%s left)", turnID, turnBudget.Remaining().Round(time.Second))
+			}
 			if reflectErr != nil {
 				log.Printf("[%s] reflection error (non-fatal): %v", turnID, reflectErr)
 			} else if reflectResult.Text != "" {
-				if saveErr := interiorStore.Save(turnID, reflectResult.Text); saveErr != nil {
-					log.Printf("[%s] interior store error: %v", turnID, saveErr)
+				pendingReflection = reflectResult.Text
+				curiositySignals = interior.ExtractCuriosity(reflectResult.Text)
+				if len(curiositySignals) > 0 {
+					log.Printf("[%s] curiosity signals: %v", turnID, curiositySignals)
 				}
-				curiosity = interior.ExtractCuriosity(reflectResult.Text)
-				if len(curiosity) > 0 {
-					log.Printf("[%s] curiosity signals: %v", turnID, curiosity)
+				log.Printf("[%s] reflection captured (%d words, %s template)", turnID, len(strings.Fields(reflectResult.Text)), reflectionKey)
+
+				// Self-proposed rules/preferences are queued for approval, never applied directly;
+				// a frozen turn queues none
+				if !frozen {
+					for _, sg := range projection.ExtractSuggestions(reflectResult.Text, turnID) {
+						if queued, err := suggestionStore.Propose(sg); err != nil {
+							log.Printf("[%s] suggestion store error: %v", turnID, err)
+						} else if queued {
+							log.Printf("[%s] suggestion queued for /suggestions: %s", turnID, sg.Describe())
+						}
+					}
 				}
-				log.Printf("[%s] reflection stored (%d words)", turnID, len(strings.Fields(reflectResult.Text)))
 			}
 		}
 
+		if !isPreferenceOnly && !private {
+			recentResponses = appendRecent(recentResponses, result.Text, 10)
+			recentContexts = appendRecent(recentContexts, turnContext, 5)
+		}
+
 		// Step 4: Evidence storage — deferred until after gate decision (see Step 6b)
 
-		// Periodic graph decay (every 50 turns)
-		if turnNum%50 == 0 {
+		// Periodic graph decay (every 50 turns; the resource profile sets the interval)
+		if resourceProfile.DecayDue(turnNum) {
 			deleted, decayErr := graphStore.DecayAll(48.0)
 			if decayErr != nil {
 				log.Printf("[%s] graph decay error: %v", turnID, decayErr)
 			} else if deleted > 0 {
 				log.Printf("[%s] graph decay: removed %d weak edges", turnID, deleted)
 			}
+			for _, cs := range cacheMgr.Stats() {
+				log.Printf("[%s] cache %s: %d entries, %d KB, hit_rate=%.2f, evictions=%d",
+					turnID, cs.Name, cs.Entries, cs.Bytes>>10, cs.HitRate(), cs.Evictions)
+			}
 		}
 
 		// Step 5: Run update function (produces proposed state + metrics)
@@ -648,6 +2247,31 @@ func main() {
 		cancel5()
 		userCorrected = false
 
+		// Fold in external tool observations queued since the last turn
+		externalSigs := externalQueue.Drain()
+		signals.ApplyExternal(&sigs, externalSigs)
+		sigs.PlanProgress = planProgress
+		var externalRecords []logging.ExternalSignalRecord
+		for _, e := range externalSigs {
+			log.Printf("[%s] external signal: %s from %s", turnID, e.Type, e.Origin)
+			externalRecords = append(externalRecords, logging.ExternalSignalRecord{
+				Type:       string(e.Type),
+				Origin:     e.Origin,
+				Detail:     e.Detail,
+				ReceivedAt: e.ReceivedAt,
+			})
+		}
+
+		if result.Model != "" {
+			if model := result.Model + "@" + result.ModelVersion; model != lastModel {
+				if lastModel != "" {
+					log.Printf("[%s] backing model changed: %s → %s", turnID, lastModel, model)
+					resets.Flag(reprime.ReasonModel, lastModel+" → "+model, time.Now().UTC())
+				}
+				lastModel = model
+			}
+		}
+
 		// Priority 1: Override SentimentScore with preference compliance
 		complianceScore := projection.PreferenceComplianceScore(storedPrefs, result.Text)
 		sigs.SentimentScore = complianceScore
@@ -663,9 +2287,18 @@ func main() {
 				prefTexts = append(prefTexts, p.Text)
 			}
 			prefConcat := strings.Join(prefTexts, "; ")
-			embedCtx, embedCancel := context.WithTimeout(context.Background(), timeoutEmbed)
-			embedding, embedErr := codecClient.Embed(embedCtx, prefConcat)
-			embedCancel()
+			var embedding []float32
+			var embedErr error
+			if cached, ok := embedCache.Get(prefConcat); ok {
+				embedding = cached.([]float32)
+			} else {
+				embedCtx, embedCancel := context.WithTimeout(context.Background(), timeoutEmbed)
+				embedding, embedErr = codecClient.Embed(embedCtx, prefConcat)
+				embedCancel()
+				if embedErr == nil {
+					embedCache.Put(prefConcat, embedding, cache.Float32sSize(embedding)) // Put counts the key
+				}
+			}
 			if embedErr != nil {
 				log.Printf("[%s] direction embed error (non-fatal, using sign fallback): %v", turnID, embedErr)
 			} else if len(embedding) >= 32 {
@@ -681,12 +2314,73 @@ func main() {
 			}
 		}
 
+		sigs.Corrections = pendingCorrections
+		for _, c := range pendingCorrections {
+			log.Printf("[%s] negative delta: %s (%s)", turnID, c.Segment, c.Reason)
+		}
+
+		var turnBranch *branchTurn
+		if !private && !isPreferenceOnly && genPrompt != "" {
+			turnBranch = &branchTurn{
+				TurnID: turnID, Before: current, MainResponse: result.Text,
+				Prompt: prompt, GenPrompt: genPrompt, Modifier: genModifier,
+				Markers: genMarkers, Retrieved: genRetrieved, Sampling: samplingChoice.Sampling,
+				Prefs: storedPrefs, Signals: sigs, SignalInput: signalInput,
+			}
+		}
+		if !private && !isPreferenceOnly {
+			lastEvidence = usedEvidence
+		}
+
 		updateResult := update.Update(current, updateCtx, sigs, evidenceStrings, updateConfig)
 
-		// Step 6: Gate evaluation — hard vetoes + soft scoring
-		gateDecision := stateGate.Evaluate(
-			current, updateResult.NewState, sigs, updateResult.Metrics, result.Entropy,
-		)
+		// Step 6: Gate evaluation — hard vetoes + soft scoring, then the external policy if configured
+		var gateDecision gate.GateDecision
+		var policyRecord *logging.PolicyRecord
+		turnGate, turnPolicyGate := stateGate, policyGate
+		if hardened {
+			turnGate, turnPolicyGate = hardenedGate, hardenedPolicyGate
+		}
+		turnGateConfig := turnGate.Config()
+		if turnPolicyGate != nil && !frozen {
+			var audit gate.PolicyAudit
+			gateDecision, updateResult.NewState, audit = turnPolicyGate.Evaluate(
+				turnCtx, turnID, current, updateResult.NewState, sigs, updateResult.Metrics, result.Entropy,
+			)
+			if audit.Decision == gate.PolicyModify {
+				updateResult.Metrics.DeltaNorm = audit.ScaledDeltaNorm
+			}
+			policyRecord = &logging.PolicyRecord{
+				URL:       policyURL,
+				Consulted: audit.Consulted,
+				Decision:  audit.Decision,
+				Reason:    audit.Reason,
+				Fallback:  audit.Fallback,
+				LatencyMs: audit.Latency.Milliseconds(),
+				Applied:   audit.Applied,
+			}
+			switch {
+			case audit.Fallback:
+				log.Printf("[%s] policy gate unavailable, local decision applied: %s", turnID, audit.Reason)
+			case audit.Consulted:
+				log.Printf("[%s] policy gate: %s → %s (%dms) %s", turnID, audit.Decision, audit.Applied, policyRecord.LatencyMs, audit.Reason)
+			}
+		} else {
+			gateDecision, updateResult.NewState = turnGate.EvaluateDowngrade(
+				current, updateResult.NewState, sigs, updateResult.Metrics, result.Entropy,
+			)
+		}
+		if gateDecision.Action == "commit_scaled" {
+			if policyRecord == nil || policyRecord.Decision != gate.PolicyModify {
+				updateResult.Metrics.DeltaNorm *= gateDecision.Scale
+			}
+			log.Printf("[%s] gate downgrade: %s", turnID, gateDecision.Reason)
+		}
+
+		var profileRecord *logging.ProfileRecord
+		if !resourceProfile.IsFull() {
+			profileRecord = &logging.ProfileRecord{Name: resourceProfile.Name, Skipped: profileCuts}
+		}
 
 		// Build gate record for provenance logging (used by all 3 decision paths)
 		gateRecord := logging.GateRecord{
@@ -702,14 +2396,24 @@ func main() {
 				UserCorrection:      sigs.UserCorrection,
 				ToolFailure:         sigs.ToolFailure,
 				ConstraintViolation: sigs.ConstraintViolation,
+				PlanProgress:        sigs.PlanProgress,
 			},
 			DeltaNorm:     updateResult.Metrics.DeltaNorm,
 			SegmentsHit:   updateResult.Metrics.SegmentsHit,
 			Thresholds: logging.GateRecordThresholds{
-				MaxDeltaNorm:   gate.DefaultGateConfig().MaxDeltaNorm,
-				MaxStateNorm:   gate.DefaultGateConfig().MaxStateNorm,
-				RiskSegmentCap: gate.DefaultGateConfig().RiskSegmentCap,
-				MaxSegmentNorm: eval.DefaultEvalConfig().MaxSegmentNorm,
+				MaxDeltaNorm:   turnGateConfig.MaxDeltaNorm,
+				MaxStateNorm:   turnGateConfig.MaxStateNorm,
+				RiskSegmentCap: turnGateConfig.RiskSegmentCap,
+				MaxSegmentNorm: evalConfig.MaxSegmentNorm,
+				SegmentCaps:    turnGateConfig.SegmentCaps,
+				SegmentNorms:   evalConfig.SegmentNorms,
+				EvalWarnMargin: evalConfig.WarnMargin,
+
+				Downgrade:       turnGateConfig.Downgrade.Types(),
+				DowngradeExcess: turnGateConfig.Downgrade.MaxExcess,
+
+				DisabledVetoes: turnGateConfig.DisabledVetoes,
+				PolicyHash:     policy.Hash(),
 			},
 			DirectionSource:   directionSource,
 			DirectionSegments: directionSegments,
@@ -717,19 +2421,132 @@ func main() {
 			GateSoftScore:     gateDecision.SoftScore,
 			GateVetoed:        gateDecision.Vetoed,
 			GateReason:        gateDecision.Reason,
+			GateScale:         gateDecision.Scale,
+			ExternalSignals:   externalRecords,
+			StateBlock:        strings.TrimSpace(systemBlock),
+			Policy:            policyRecord,
+			PreGate:           preGateRecord,
+			Frozen:            frozenReason,
+			Contradictions:    contradictionRecords,
+			Attribution:       attributionRecords,
+			Citations:         citationIDs,
+			Preprocessing:     preprocessRecords,
+			TurnContext:       turnContext,
+			Model:             result.Model,
+			ModelVersion:      result.ModelVersion,
+			Sampling:          samplingRecord,
+			Profile:           profileRecord,
+			Reprime:           reprimeRecord,
+		}
+		for _, v := range gateDecision.VetoSignals {
+			gateRecord.GateVetoTypes = append(gateRecord.GateVetoTypes, string(v.Type))
 		}
+		for _, r := range matchedRules {
+			gateRecord.RulesMatched = append(gateRecord.RulesMatched, r.Trigger)
+		}
+		gateRecord.GateBreakdown = gateDecision.BreakdownRecord()
 		signalsJSON, _ := json.Marshal(gateRecord)
 
+		// Turn event for --emit-json; each exit below fills in decision and version_after
+		turnEvent := events.TurnEvent{
+			TurnID:         turnID,
+			Prompt:         prompt,
+			Response:       result.Text,
+			Entropy:        result.Entropy,
+			Classification: eventClassification(orchResult.Classification),
+			Strategy:       string(activeStrategy.ID),
+			Attempts:       len(orchAttempts),
+			EvidenceCount:  len(evidenceStrings),
+			Signals:        &gateRecord.Signals,
+			Gate: &events.Gate{
+				Action:      gateDecision.Action,
+				SoftScore:   gateDecision.SoftScore,
+				Vetoed:      gateDecision.Vetoed,
+				VetoTypes:   gateRecord.GateVetoTypes,
+				Reason:      gateDecision.Reason,
+				DeltaNorm:   updateResult.Metrics.DeltaNorm,
+				SegmentsHit: updateResult.Metrics.SegmentsHit,
+			},
+			VersionBefore:   current.VersionID,
+			VersionProposed: updateResult.NewState.VersionID,
+			VersionAfter:    current.VersionID,
+		}
+
+		// Calibration capture (all decision paths, before commit/reject)
+		if !private && calibrationSampler.ShouldSample(time.Now()) {
+			sample := calibration.Sample{
+				TurnID:         turnID,
+				Prompt:         prompt,
+				Response:       result.Text,
+				Entropy:        result.Entropy,
+				RetrievedCount: len(gateResult.Retrieved),
+				Signals: calibration.HeuristicSignals{
+					SentimentScore: sigs.SentimentScore,
+					CoherenceScore: sigs.CoherenceScore,
+					NoveltyScore:   sigs.NoveltyScore,
+					RiskFlag:       sigs.RiskFlag,
+					UserCorrection: sigs.UserCorrection,
+				},
+				GateAction:    gateDecision.Action,
+				GateSoftScore: gateDecision.SoftScore,
+			}
+			if calErr := calibrationSampler.Capture(sample); calErr != nil {
+				log.Printf("[%s] calibration capture error (non-fatal): %v", turnID, calErr)
+			} else {
+				log.Printf("[%s] calibration sample captured", turnID)
+			}
+		}
+
+		trend.push(gateDecision.SoftScore, updateResult.Metrics.DeltaNorm)
+
 		// Store gate summary for next turn's reflection + memory review
 		lastGateSummary = fmt.Sprintf("soft_score=%.4f entropy=%.4f delta_norm=%.4f segments=%v vetoed=%v",
 			gateDecision.SoftScore, result.Entropy, updateResult.Metrics.DeltaNorm,
 			updateResult.Metrics.SegmentsHit, gateDecision.Vetoed)
+		lastGateSoftScore, lastGateVetoed = gateDecision.SoftScore, gateDecision.Vetoed
+
+		if frozen {
+			// Learning frozen: the proposed update and gate outcome are recorded for
+			// audit, but nothing is committed or stored
+			log.Printf("[%s] learning frozen (%s): update, evidence and reflection not saved (gate would %s)", turnID, frozenReason, gateDecision.Action)
+			entry := logging.ProvenanceEntry{
+				VersionID:    current.VersionID,
+				TriggerType:  "user_turn",
+				SignalsJSON:  string(signalsJSON),
+				EvidenceRefs: strings.Join(evidenceRefs, ","),
+				Decision:     "no_op",
+				Reason:       fmt.Sprintf("frozen: %s", frozenReason),
+				CreatedAt:    time.Now().UTC(),
+			}
+			if private {
+				// Redacted marker only: no prompt, response, state block or evidence IDs
+				redacted, _ := json.Marshal(logging.GateRecord{TurnID: turnID, Private: true})
+				entry.SignalsJSON, entry.EvidenceRefs, entry.Reason = string(redacted), "", privateReason
+				turnEvent.Prompt, turnEvent.Response = "", ""
+			}
+			if err := store.WithTx(func(tx *sql.Tx) error {
+				return logging.LogDecision(tx, entry)
+			}); err != nil {
+				log.Printf("[%s] turn write error (rolled back): %v", turnID, err)
+				queueProvenance(writeQueue, turnID, entry, err)
+			}
+			if !private {
+				lastPrompt = prompt
+				lastResponse = result.Text
+			}
+
+			fmt.Printf("[%s] decision=frozen (%s) entropy=%.4f evidence=%d\n",
+				turnID, frozenReason, result.Entropy, len(evidenceStrings))
+			turnEvent.Decision, turnEvent.Reason = "frozen", frozenReason
+			emitTurn(emitter, inbox, alerts, tel, turnStart, turnEvent)
+			return true
+		}
 
 		if gateDecision.Action == "reject" {
 			// Gate rejected: log rejection, keep old state, skip evidence storage, continue
 			log.Printf("[%s] gate rejected: %s", turnID, gateDecision.Reason)
 			log.Printf("[%s] evidence skipped: gate rejected", turnID)
-			_ = logging.LogDecision(store.DB(), logging.ProvenanceEntry{
+			rejectEntry := logging.ProvenanceEntry{
 				VersionID:    current.VersionID,
 				TriggerType:  "user_turn",
 				SignalsJSON:  string(signalsJSON),
@@ -737,113 +2554,290 @@ func main() {
 				Decision:     "reject",
 				Reason:       fmt.Sprintf("gate: %s", gateDecision.Reason),
 				CreatedAt:    time.Now().UTC(),
+			}
+			txErr := store.WithTx(func(tx *sql.Tx) error {
+				if pendingReflection != "" {
+					if err := interiorStore.WithTx(tx).Save(turnID, pendingReflection); err != nil {
+						return fmt.Errorf("save reflection: %w", err)
+					}
+				}
+				return logging.LogDecision(tx, rejectEntry)
 			})
+			if txErr != nil {
+				log.Printf("[%s] turn write error (rolled back): %v", turnID, txErr)
+				queueProvenance(writeQueue, turnID, rejectEntry, txErr)
+			}
+			observeAnomaly(anomalies, replay.AnomalyTurn{Before: current, Record: gateRecord, Evidence: evidenceStrings,
+				Decision: "reject", Reason: fmt.Sprintf("gate: %s", gateDecision.Reason)})
 			// Track previous turn even on rejection
 			lastPrompt = prompt
 			lastResponse = result.Text
+			if turnBranch != nil {
+				turnBranch.MainDecision, turnBranch.MainVersion = "reject", current.VersionID
+				lastBranch = turnBranch
+			}
 
 			fmt.Printf("[%s] decision=reject (gate) entropy=%.4f evidence=%d\n",
 				turnID, result.Entropy, len(evidenceStrings))
-			continue
+			fmt.Println(trend.render())
+			turnEvent.Decision = "reject"
+			emitTurn(emitter, inbox, alerts, tel, turnStart, turnEvent)
+			return true
 		}
 
 		// Step 6b: Reflection-gated evidence storage — Orac's reflection decides what's worth keeping.
 		// No curiosity signals = the exchange didn't open anything new = don't store it.
 		// Gate rejection = don't store. Low entropy = stalling pattern = don't store.
-		if !isPreferenceOnly && len(matchedRules) == 0 && !session.RuleActive {
-			if len(curiosity) == 0 {
+		// Without reflection (resource profile) there is no curiosity to consult.
+		// The text is selected here and stored once the turn's transaction commits.
+		var pendingEvidence *evidence.Selection
+		if !isPreferenceOnly && len(matchedRules) == 0 && session.Script == nil {
+			if hardened {
+				log.Printf("[%s] evidence skipped: pre-gate hardened turn (%s)", turnID, preDecision.Reason)
+			} else if len(curiositySignals) == 0 && resourceProfile.Reflection {
 				log.Printf("[%s] evidence skipped: reflection found nothing worth keeping", turnID)
 			} else if result.Entropy < 0.03 {
 				log.Printf("[%s] evidence skipped: entropy %.4f (stalling pattern)", turnID, result.Entropy)
 			} else {
-				storeText := prompt + "\n" + result.Text
-				now := time.Now().UTC()
-				metadataJSON := fmt.Sprintf(`{"turn_id":"%s","entropy":%.4f,"stored_at":"%s"}`,
-					turnID, result.Entropy, now.Format(time.RFC3339))
-				ctx4, cancel4 := context.WithTimeout(context.Background(), timeoutStore)
-				storedID, storeErr := codecClient.StoreEvidence(ctx4, storeText, metadataJSON)
-				cancel4()
-				if storeErr != nil {
-					log.Printf("store evidence error (non-fatal): %v", storeErr)
-				} else if storedID != "" {
-					// Temporal edge formation: link to recent evidence IDs
-					for _, prevID := range recentEvidenceIDs {
-						graphStore.AddEdge(prevID, storedID, "temporal", 0.05)
-					}
-					if len(recentEvidenceIDs) > 0 {
-						log.Printf("[%s] graph: %d temporal edges formed", turnID, len(recentEvidenceIDs))
+				// Summarizing embeds up to 40 sentences, so it is charged to the turn
+				// like any stage; without the time it falls back to truncation
+				var embed evidence.Embedder
+				selectCtx, selectDone := context.Background(), func() {}
+				if storagePolicy.Summarizes(prompt, result.Text) {
+					if turnBudget.Affords(budget.StageSummarize) {
+						embed = codecClient.EmbedBatch
+						selectCtx, selectDone = turnBudget.Context(turnCtx, budget.StageSummarize, timeoutEmbed)
+					} else {
+						log.Printf("[%s] evidence summary skipped: turn budget low (%s left)", turnID, turnBudget.Remaining().Round(time.Second))
 					}
+				}
+				selection := storagePolicy.Select(selectCtx, prompt, result.Text, result.Entropy, embed)
+				selectDone()
+				if selection.Reduced() {
+					log.Printf("[%s] evidence text: %s %d → %d chars", turnID, selection.Method, selection.OriginalLen, len(selection.Text))
+				}
+				pendingEvidence = &selection
+			}
+		}
 
-					// Reflection edge formation: link top retrieved evidence to new stored evidence
-					// Cap at 5 to match co-retrieval cap
-					reflectionRefs := evidenceRefs
-					if len(reflectionRefs) > 5 {
-						reflectionRefs = reflectionRefs[:5]
-					}
-					if len(reflectionRefs) > 0 {
-						for _, refID := range reflectionRefs {
-							graphStore.AddEdge(refID, storedID, "reflection", 0.3)
-						}
-						log.Printf("[%s] graph: %d reflection edges formed", turnID, len(reflectionRefs))
-					}
+		// Eval against the proposed state. A small breach (warning tier) commits the
+		// delta scaled down, flagged in the record, instead of rolling the turn back.
+		evalResult, evaluated := evalHarness.RunTiered(current, updateResult.NewState, result.Entropy)
+		if evalResult.Tier == eval.TierWarn {
+			log.Printf("[%s] eval warning: %s", turnID, evalResult.Reason)
+			updateResult.NewState = evaluated
+			gateRecord.EvalScale = evalResult.Scale
+			signalsJSON, _ = json.Marshal(gateRecord)
+		}
 
-					// Track recent evidence IDs (last 3)
-					recentEvidenceIDs = append(recentEvidenceIDs, storedID)
-					if len(recentEvidenceIDs) > 3 {
-						recentEvidenceIDs = recentEvidenceIDs[len(recentEvidenceIDs)-3:]
-					}
+		// Confirm learning: a passing update past a CONFIRM_LEARNING threshold is
+		// held for the user instead of committed; reflection and edges still land
+		var held *heldUpdate
+		if evalResult.Passed && confirmPolicy.Enabled() {
+			deltaNorm := updateResult.Metrics.DeltaNorm
+			if gateRecord.EvalScale > 0 {
+				deltaNorm *= gateRecord.EvalScale
+			}
+			if why, hold := confirmPolicy.Check(current, updateResult.NewState, deltaNorm); hold {
+				gateRecord.Confirmation = &logging.ConfirmationRecord{Reason: why}
+				signalsJSON, _ = json.Marshal(gateRecord)
+				held = &heldUpdate{
+					turnID:       turnID,
+					proposed:     updateResult.NewState,
+					record:       gateRecord,
+					evidenceRefs: strings.Join(evidenceRefs, ","),
+					reason:       fmt.Sprintf("gate: %s | eval: %s", gateDecision.Reason, evalResult.Reason),
 				}
 			}
 		}
 
-		// Step 7: Tentative commit
-		if err := store.CommitState(updateResult.NewState); err != nil {
-			log.Printf("commit error: %v", err)
-			continue
-		}
+		// Steps 7-9 run in one transaction: reflection, tentative commit, eval
+		// rollback (if any), and provenance land together or not at all.
+		var decision, reason string
+		txErr := store.WithTx(func(tx *sql.Tx) error {
+			if pendingReflection != "" {
+				if err := interiorStore.WithTx(tx).Save(turnID, pendingReflection); err != nil {
+					return fmt.Errorf("save reflection: %w", err)
+				}
+			}
+
+			if held != nil {
+				decision, reason = "no_op", "held for confirmation: "+held.record.Confirmation.Reason
+				return logging.LogDecision(tx, logging.ProvenanceEntry{
+					VersionID:    current.VersionID,
+					TriggerType:  "user_turn",
+					SignalsJSON:  string(signalsJSON),
+					EvidenceRefs: held.evidenceRefs,
+					Decision:     decision,
+					Reason:       reason,
+					CreatedAt:    time.Now().UTC(),
+				})
+			}
 
-		// Step 8: Post-commit eval
-		evalResult := evalHarness.Run(updateResult.NewState, result.Entropy)
+			// Step 7: Tentative commit
+			if err := store.CommitStateTx(tx, updateResult.NewState); err != nil {
+				return fmt.Errorf("commit state: %w", err)
+			}
 
-		if !evalResult.Passed {
-			// Eval failed: rollback to previous version
-			log.Printf("[%s] eval failed: %s — rolling back", turnID, evalResult.Reason)
-			if rbErr := store.Rollback(current.VersionID); rbErr != nil {
-				log.Printf("[%s] rollback error: %v", turnID, rbErr)
+			// Step 8: Post-commit eval
+			if !evalResult.Passed {
+				// Eval failed: rollback to previous version
+				log.Printf("[%s] eval failed: %s — rolling back", turnID, evalResult.Reason)
+				if err := store.RollbackTx(tx, current.VersionID); err != nil {
+					return fmt.Errorf("rollback: %w", err)
+				}
+				decision, reason = "reject", fmt.Sprintf("eval rollback: %s", evalResult.Reason)
+				return logging.LogDecision(tx, logging.ProvenanceEntry{
+					VersionID:    updateResult.NewState.VersionID,
+					TriggerType:  "user_turn",
+					SignalsJSON:  string(signalsJSON),
+					EvidenceRefs: strings.Join(evidenceRefs, ","),
+					Decision:     decision,
+					Reason:       reason,
+					CreatedAt:    time.Now().UTC(),
+				})
 			}
-			_ = logging.LogDecision(store.DB(), logging.ProvenanceEntry{
+
+			// Step 9: Eval passed — state stays committed. Log provenance.
+			decision, reason = "commit", fmt.Sprintf("gate: %s | eval: %s", gateDecision.Reason, evalResult.Reason)
+			return logging.LogDecision(tx, logging.ProvenanceEntry{
 				VersionID:    updateResult.NewState.VersionID,
 				TriggerType:  "user_turn",
 				SignalsJSON:  string(signalsJSON),
 				EvidenceRefs: strings.Join(evidenceRefs, ","),
-				Decision:     "reject",
-				Reason:       fmt.Sprintf("eval rollback: %s", evalResult.Reason),
+				Decision:     decision,
+				Reason:       reason,
 				CreatedAt:    time.Now().UTC(),
 			})
+		})
+		if txErr != nil {
+			log.Printf("[%s] turn write error (rolled back): %v", turnID, txErr)
+			// The new version never landed: record the turn against the state it kept
+			queueProvenance(writeQueue, turnID, logging.ProvenanceEntry{
+				VersionID:    current.VersionID,
+				TriggerType:  "user_turn",
+				SignalsJSON:  string(signalsJSON),
+				EvidenceRefs: strings.Join(evidenceRefs, ","),
+				Decision:     "reject",
+				Reason:       fmt.Sprintf("write failed: %v", txErr),
+				CreatedAt:    time.Now().UTC(),
+			}, txErr)
+			turnEvent.Decision, turnEvent.Reason = "error", txErr.Error()
+			emitTurn(emitter, inbox, alerts, tel, turnStart, turnEvent)
+			return true
+		}
+		observeAnomaly(anomalies, replay.AnomalyTurn{Before: current, Record: gateRecord, Evidence: evidenceStrings,
+			Decision: decision, Reason: reason})
+
+		// Step 9b: Evidence of a committed (or held) turn is stored now that the turn
+		// landed; its edges and the reflection's questions follow in one transaction
+		var storedEvidenceID string // source of the edges and of this turn's curiosity questions
+		if pendingEvidence != nil && evalResult.Passed {
+			metadataJSON := fmt.Sprintf(`{"turn_id":"%s","entropy":%.4f,"stored_at":"%s","storage":"%s"}`,
+				turnID, result.Entropy, time.Now().UTC().Format(time.RFC3339), pendingEvidence.Method)
+			ctx4, cancel4 := context.WithTimeout(context.Background(), timeoutStore)
+			storedID, storeErr := codecClient.StoreEvidence(ctx4, pendingEvidence.Text, metadataJSON)
+			cancel4()
+			if storeErr != nil {
+				log.Printf("store evidence error (non-fatal): %v", storeErr)
+				if err := writeQueue.Evidence(pendingEvidence.Text, metadataJSON, storeErr); err != nil {
+					log.Printf("[%s] evidence lost: %v", turnID, err)
+				} else {
+					log.Printf("[%s] evidence queued for retry", turnID)
+				}
+			} else if storedID != "" {
+				storedEvidenceID = storedID
+				if rawArchive != nil && pendingEvidence.Reduced() {
+					if err := rawArchive.Put(storedID, turnID, pendingEvidence.Method, prompt+"\n"+result.Text); err != nil {
+						log.Printf("[%s] raw archive error (non-fatal): %v", turnID, err)
+					}
+				}
+			}
+		}
+		var pendingEdges []graph.Edge
+		if storedEvidenceID != "" {
+			// Temporal edge formation: link to recent evidence IDs
+			for _, prevID := range recentEvidenceIDs {
+				pendingEdges = append(pendingEdges, graph.NewEdge(prevID, storedEvidenceID, graph.EdgeTemporal))
+			}
+			// Reflection edge formation: link top retrieved evidence to new stored evidence
+			// Cap at 5 to match co-retrieval cap
+			reflectionRefs := retrieval.LocalIDs(evidenceRefs)
+			if len(reflectionRefs) > 5 {
+				reflectionRefs = reflectionRefs[:5]
+			}
+			for _, refID := range reflectionRefs {
+				pendingEdges = append(pendingEdges, graph.NewEdge(refID, storedEvidenceID, graph.EdgeReflection))
+			}
+			log.Printf("[%s] graph: %d temporal, %d reflection edges", turnID, len(recentEvidenceIDs), len(reflectionRefs))
+
+			// Track recent evidence IDs (last 3)
+			recentEvidenceIDs = append(recentEvidenceIDs, storedEvidenceID)
+			if len(recentEvidenceIDs) > 3 {
+				recentEvidenceIDs = recentEvidenceIDs[len(recentEvidenceIDs)-3:]
+			}
+		}
+		questions := curiosity.Questions(pendingReflection)
+		if len(pendingEdges) > 0 || len(questions) > 0 {
+			if err := store.WithTx(func(tx *sql.Tx) error {
+				txGraph := graphStore.WithTx(tx)
+				for _, e := range pendingEdges {
+					if err := txGraph.AddEdge(e.SourceID, e.TargetID, e.EdgeType, e.Weight); err != nil {
+						return fmt.Errorf("add %s edge: %w", e.EdgeType, err)
+					}
+				}
+				if len(questions) > 0 {
+					if _, err := curiosityQueue.WithTx(tx).Enqueue(turnID, storedEvidenceID, questions); err != nil {
+						return fmt.Errorf("queue curiosity: %w", err)
+					}
+				}
+				return nil
+			}); err != nil {
+				log.Printf("[%s] edge and curiosity write error (rolled back, non-fatal): %v", turnID, err)
+			}
+		}
+
+		if held != nil {
+			pendingLearning = held
+			question := held.question()
+			log.Printf("[%s] update held for confirmation: %s", turnID, held.record.Confirmation.Reason)
+			fmt.Println(question)
+			inbox.Reply(question)
+			lastPrompt = prompt
+			lastResponse = result.Text
+			if turnBranch != nil {
+				turnBranch.MainDecision, turnBranch.MainVersion = "held", current.VersionID
+				lastBranch = turnBranch
+			}
+
+			fmt.Printf("[%s] decision=held (%s) gate_score=%.4f entropy=%.4f evidence=%d\n",
+				turnID, held.record.Confirmation.Reason, gateDecision.SoftScore, result.Entropy, len(evidenceStrings))
+			fmt.Println(trend.render())
+			turnEvent.Decision, turnEvent.Reason = "held", held.record.Confirmation.Reason
+			emitTurn(emitter, inbox, alerts, tel, turnStart, turnEvent)
+			return true
+		}
+
+		if !evalResult.Passed {
 			// Track previous turn even on rollback
 			lastPrompt = prompt
 			lastResponse = result.Text
+			if turnBranch != nil {
+				turnBranch.MainDecision, turnBranch.MainVersion = "rollback", current.VersionID
+				lastBranch = turnBranch
+			}
 
 			fmt.Printf("[%s] decision=rollback (eval) entropy=%.4f evidence=%d\n",
 				turnID, result.Entropy, len(evidenceStrings))
-			continue
-		}
-
-		// Step 9: Eval passed — state stays committed. Log provenance.
-		reason := fmt.Sprintf("gate: %s | eval: %s", gateDecision.Reason, evalResult.Reason)
-		err = logging.LogDecision(store.DB(), logging.ProvenanceEntry{
-			VersionID:    updateResult.NewState.VersionID,
-			TriggerType:  "user_turn",
-			SignalsJSON:  string(signalsJSON),
-			EvidenceRefs: strings.Join(evidenceRefs, ","),
-			Decision:     "commit",
-			Reason:       reason,
-			CreatedAt:    time.Now().UTC(),
-		})
-		if err != nil {
-			log.Printf("logging error: %v", err)
+			fmt.Println(trend.render())
+			turnEvent.Decision, turnEvent.Reason = "rollback", evalResult.Reason
+			emitTurn(emitter, inbox, alerts, tel, turnStart, turnEvent)
+			return true
 		}
 
+		// Corrections only count once committed; rejected turns carry them forward
+		pendingCorrections = nil
+
 		// Orchestrator: record all attempts for this turn
 		acceptedIdx := len(orchAttempts) - 1
 		if acceptedIdx < 0 {
@@ -854,46 +2848,66 @@ func main() {
 		// Track previous turn for memory review context
 		lastPrompt = prompt
 		lastResponse = result.Text
+		if turnBranch != nil {
+			turnBranch.MainDecision, turnBranch.MainVersion = "commit", updateResult.NewState.VersionID
+			lastBranch = turnBranch
+		}
 
 		fmt.Printf("[%s] decision=commit gate_score=%.4f entropy=%.4f evidence=%d strategy=%s attempts=%d\n",
 			turnID, gateDecision.SoftScore, result.Entropy, len(evidenceStrings), activeStrategy.ID, len(orchAttempts))
+		fmt.Println(trend.render())
+		turnEvent.Decision, turnEvent.VersionAfter = "commit", updateResult.NewState.VersionID
+		turnEvent.EvalScale = gateRecord.EvalScale
+		if exporter != nil {
+			exporter.refresh()
+		}
+		emitTurn(emitter, inbox, alerts, tel, turnStart, turnEvent)
+		return true
+	}
+	if api != nil {
+		c.finish = api.finish // deliver the shutdown reply
 	}
+	c.store, c.faults = store, faults
+	return c
 }
 
-// #endregion main
+// controllerOptions are the command-line flags newController needs.
+type controllerOptions struct {
+	emitJSON  emitJSONFlag
+	freeze    bool
+	serveAddr string // --serve
+	grpcAddr  string // --grpc
+}
 
-// #region parse-delete-ids
+// controller is the assembled daemon. poll reads the inbox once and either runs
+// the idle jobs or hands the message to turn, which takes one message through
+// the whole pipeline; both report false on shutdown.
+type controller struct {
+	store   *state.Store
+	faults  *chaos.Injector // nil unless CHAOS_FAULTS is set
+	poll    func() bool
+	turn    func(inboxMsg string) bool
+	finish  func()   // runs once poll reports shutdown
+	closers []func() // run in reverse by close
+}
 
-// parseDeleteIDs extracts evidence IDs from Orac's review response.
-// Only accepts IDs that exist in the validIDs whitelist (prevents hallucinated deletions).
-func parseDeleteIDs(response string, validIDs []string) []string {
-	if strings.TrimSpace(strings.ToUpper(response)) == "NONE" {
-		return nil
+// run polls the inbox until shutdown.
+func (c *controller) run() {
+	for c.poll() {
 	}
-
-	validSet := make(map[string]bool, len(validIDs))
-	for _, id := range validIDs {
-		validSet[id] = true
+	if c.finish != nil {
+		c.finish()
 	}
+}
 
-	var result []string
-	for _, line := range strings.Split(response, "\n") {
-		line = strings.TrimSpace(line)
-		if line == "" {
-			continue
-		}
-		// Strip common prefixes like "ID: " or "- "
-		line = strings.TrimPrefix(line, "ID: ")
-		line = strings.TrimPrefix(line, "- ")
-		line = strings.TrimSpace(line)
-		if validSet[line] {
-			result = append(result, line)
-		}
+// close releases what newController opened, most recent first.
+func (c *controller) close() {
+	for i := len(c.closers) - 1; i >= 0; i-- {
+		c.closers[i]()
 	}
-	return result
 }
 
-// #endregion parse-delete-ids
+// #endregion main
 
 // #region dedup
 
@@ -1007,6 +3021,91 @@ func sentencePrefix(s string, n int) string {
 // #endregion dedup
 
 // #region helpers
+// emitJSONFlag is --emit-json: bare means stdout ("-"), --emit-json=PATH appends to a file.
+type emitJSONFlag string
+
+func (f *emitJSONFlag) String() string { return string(*f) }
+
+func (f *emitJSONFlag) Set(v string) error {
+	switch v {
+	case "true":
+		v = "-"
+	case "false":
+		v = ""
+	}
+	*f = emitJSONFlag(v)
+	return nil
+}
+
+func (f *emitJSONFlag) IsBoolFlag() bool { return true }
+
+// emitTurn stamps ev with the time since start and writes it to the
+// --emit-json stream, hands it to the inbox (the API returns it with the
+// turn), the alert rules and telemetry; a failed write is logged, never fatal.
+func emitTurn(e *events.Emitter, inbox turnInbox, alerts *alertRunner, tel *telemetryRunner, start time.Time, ev events.TurnEvent) {
+	ev.LatencyMs = time.Since(start).Milliseconds()
+	inbox.Event(ev)
+	alerts.observe(ev)
+	tel.observe(ev)
+	if err := e.Emit(ev); err != nil {
+		log.Printf("[%s] emit-json: %v", ev.TurnID, err)
+	}
+}
+
+func eventClassification(c orchestrator.TurnClassification) *events.Classification {
+	return &events.Classification{Type: string(c.Type), Complexity: string(c.Complexity), Risk: string(c.Risk)}
+}
+
+// ruleVars collects the values rule response templates resolve against at fire time.
+func ruleVars(profileStore *projection.ProfileStore, prefs []projection.Preference, planStore *plan.PlanStore) projection.RuleVars {
+	profile, _ := profileStore.Get()
+	var goal, step string
+	if p, _ := planStore.Active(); p != nil {
+		goal = p.Goal
+		if cur := p.Current(); cur >= 0 {
+			step = p.Steps[cur].Text
+		}
+	}
+	return projection.NewRuleVars(profile, prefs, goal, step, time.Now())
+}
+
+// observeAnomaly feeds a decided turn to the anomaly recorder and logs any capture.
+// Capture failures are non-fatal: the turn is already recorded in provenance.
+func observeAnomaly(rec *replay.AnomalyRecorder, turn replay.AnomalyTurn) {
+	path, kinds, err := rec.Observe(turn)
+	switch {
+	case err != nil:
+		log.Printf("[%s] anomaly capture error (non-fatal): %v", turn.Record.TurnID, err)
+	case path != "":
+		log.Printf("[%s] anomaly (%s) captured as replay fixture: %s", turn.Record.TurnID, strings.Join(kinds, ", "), path)
+	}
+}
+
+// queueProvenance hands a provenance row whose write failed to the retry queue.
+func queueProvenance(q *retry.Queue, turnID string, entry logging.ProvenanceEntry, cause error) {
+	if err := q.Provenance(entry, cause); err != nil {
+		log.Printf("[%s] provenance lost: %v", turnID, err)
+		return
+	}
+	log.Printf("[%s] provenance queued for retry", turnID)
+}
+
+// drainWrites retries due queued writes; evidence goes back through the codec.
+func drainWrites(ctx context.Context, q *retry.Queue, c *codec.CodecClient, timeout time.Duration) {
+	res, err := q.Drain(ctx, func(ctx context.Context, text, metadataJSON string) (string, error) {
+		storeCtx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+		return c.StoreEvidence(storeCtx, text, metadataJSON)
+	})
+	if err != nil {
+		log.Printf("write retry error: %v", err)
+	}
+	if res.Retried > 0 {
+		log.Printf("write retry: %d retried, %d written, %d still pending, %d given up",
+			res.Retried, res.Succeeded, res.Failed, res.Dead)
+	}
+}
+
 func envOr(key, fallback string) string {
 	if v := os.Getenv(key); v != "" {
 		return v
@@ -1014,6 +3113,15 @@ func envOr(key, fallback string) string {
 	return fallback
 }
 
+func envInt(key string, fallback int) int {
+	if v := os.Getenv(key); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n >= 0 {
+			return n
+		}
+	}
+	return fallback
+}
+
 func envDuration(key string, defaultSec int) time.Duration {
 	if v := os.Getenv(key); v != "" {
 		if sec, err := strconv.Atoi(v); err == nil && sec > 0 {
@@ -1023,4 +3131,93 @@ func envDuration(key string, defaultSec int) time.Duration {
 	return time.Duration(defaultSec) * time.Second
 }
 
+// appendCut adds a resource profile cut to cuts once; retried attempts hit
+// the same cuts again.
+func appendCut(cuts []string, cut string) []string {
+	for _, c := range cuts {
+		if c == cut {
+			return cuts
+		}
+	}
+	return append(cuts, cut)
+}
+
+// profileCommand handles /profile (show fields and recent changes) and
+// /profile forget FIELD, where FIELD is a full name (user_name) or its short
+// form (name, pronouns, honorific, designation).
+func profileCommand(ps *projection.ProfileStore, args string) string {
+	if args != "" {
+		field := strings.TrimSpace(strings.TrimPrefix(args, "forget"))
+		for _, f := range projection.ProfileFields {
+			if field == f || "user_"+field == f || "ai_"+field == f {
+				if err := ps.Clear(f, "command"); err != nil {
+					log.Printf("profile store error: %v", err)
+					return "Could not update the profile."
+				}
+				log.Printf("profile %s cleared", f)
+				return fmt.Sprintf("Forgot %s.", f)
+			}
+		}
+		return "Usage: /profile forget [name|pronouns|honorific|designation]"
+	}
+	profile, err := ps.Get()
+	if err != nil {
+		log.Printf("profile store error: %v", err)
+		return "Could not read the profile."
+	}
+	if len(profile) == 0 {
+		return "No profile yet."
+	}
+	var b strings.Builder
+	b.WriteString("Profile:")
+	for _, f := range projection.ProfileFields {
+		if pf, ok := profile[f]; ok {
+			fmt.Fprintf(&b, "\n  %s: %s (%s, %s)", f, pf.Value, pf.Source, pf.UpdatedAt.Format("2006-01-02"))
+		}
+	}
+	if changes, _ := ps.History("", 5); len(changes) > 0 {
+		b.WriteString("\nRecent changes:")
+		for _, c := range changes {
+			from, to := c.OldValue, c.NewValue
+			if from == "" {
+				from = "—"
+			}
+			if to == "" {
+				to = "—"
+			}
+			fmt.Fprintf(&b, "\n  %s %s: %s → %s", c.CreatedAt.Format("2006-01-02"), c.Field, from, to)
+		}
+	}
+	return b.String()
+}
+
+// isStateSegment reports whether name is one of the state vector segments.
+func isStateSegment(name string) bool {
+	switch name {
+	case "prefs", "goals", "heuristics", "risk":
+		return true
+	}
+	return false
+}
+
+// queueCorrection adds c to pending, replacing any earlier correction for the same segment.
+func queueCorrection(pending []update.Correction, c update.Correction) []update.Correction {
+	out := pending[:0:0]
+	for _, p := range pending {
+		if p.Segment != c.Segment {
+			out = append(out, p)
+		}
+	}
+	return append(out, c)
+}
+
+// appendRecent appends s to buf, keeping at most the last n entries.
+func appendRecent(buf []string, s string, n int) []string {
+	buf = append(buf, s)
+	if len(buf) > n {
+		buf = buf[len(buf)-n:]
+	}
+	return buf
+}
+
 // #endregion helpers
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/eval"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/gate"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/logging"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/state"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/update"
+	"github.com/google/uuid"
+)
+
+// #region nudge
+
+const nudgeUsage = "Usage: /nudge prefs|goals|heuristics|risk <+/-amount> — grow (+) or shrink (-) a segment's norm by amount."
+
+// nudger applies manual segment deltas for experiments. Each nudge goes
+// through the local gate and eval like a turn's update would, and is logged
+// under trigger_type "manual" whether it commits or not.
+type nudger struct {
+	store    *state.Store
+	gate     *gate.Gate
+	eval     *eval.EvalHarness
+	maxDelta float32 // largest |amount|: the per-segment update clamp
+}
+
+// parseNudge splits "/nudge" arguments into a segment and a signed amount
+// within ±maxDelta.
+func parseNudge(arg string, maxDelta float32) (string, float32, error) {
+	fields := strings.Fields(arg)
+	if len(fields) != 2 || !isStateSegment(fields[0]) {
+		return "", 0, errors.New(nudgeUsage)
+	}
+	amount, err := strconv.ParseFloat(fields[1], 32)
+	if err != nil || amount == 0 || math.IsNaN(amount) {
+		return "", 0, errors.New(nudgeUsage)
+	}
+	if math.Abs(amount) > float64(maxDelta) {
+		return "", 0, fmt.Errorf("amount %s is outside ±%.2f (the per-segment delta clamp)", fields[1], maxDelta)
+	}
+	return fields[0], float32(amount), nil
+}
+
+// nudgeSegment moves seg of v along its own direction, so its L2 norm changes
+// by amount; a shrink past zero stops at zero. An all-zero segment grows along
+// the uniform direction.
+func nudgeSegment(v [128]float32, m state.SegmentMap, seg string, amount float32) [128]float32 {
+	r, ok := state.SegmentRange(m, seg)
+	if !ok || r[1] <= r[0] {
+		return v
+	}
+	var sumSq float64
+	for i := r[0]; i < r[1]; i++ {
+		sumSq += float64(v[i]) * float64(v[i])
+	}
+	norm := math.Sqrt(sumSq)
+	if norm == 0 {
+		if amount < 0 {
+			return v
+		}
+		u := float32(1 / math.Sqrt(float64(r[1]-r[0])))
+		for i := r[0]; i < r[1]; i++ {
+			v[i] = amount * u
+		}
+		return v
+	}
+	scale := float32(math.Max(norm+float64(amount), 0) / norm)
+	for i := r[0]; i < r[1]; i++ {
+		v[i] *= scale
+	}
+	return v
+}
+
+// nudge proposes the delta on the active state, gates and evaluates it, and
+// commits it when both pass. Returns the reply text and whether the state moved.
+func (n *nudger) nudge(seg string, amount float32) (string, bool) {
+	current, err := n.store.GetCurrent()
+	if err != nil {
+		log.Printf("nudge: %v", err)
+		return "Could not read the current state; nothing was nudged.", false
+	}
+	turnID := "nudge-" + seg
+	proposed := state.StateRecord{
+		VersionID:   uuid.New().String(),
+		ParentID:    current.VersionID,
+		StateVector: nudgeSegment(current.StateVector, current.SegmentMap, seg, amount),
+		SegmentMap:  current.SegmentMap,
+		CreatedAt:   time.Now().UTC(),
+	}
+
+	var deltaSq float64
+	for i := range proposed.StateVector {
+		d := float64(proposed.StateVector[i] - current.StateVector[i])
+		deltaSq += d * d
+	}
+	metrics := update.Metrics{DeltaNorm: float32(math.Sqrt(deltaSq)), SegmentsHit: []string{seg}}
+	if metrics.DeltaNorm == 0 {
+		return fmt.Sprintf("The %s segment is already empty; nothing to shrink.", seg), false
+	}
+
+	decision, scaled := n.gate.EvaluateDowngrade(current, proposed, update.Signals{}, metrics, 0)
+	outcome, reason := "reject", "gate: "+decision.Reason
+	var evalScale float32
+	if decision.Commits() {
+		proposed = scaled
+		if decision.Action == "commit_scaled" {
+			metrics.DeltaNorm *= decision.Scale
+		}
+		evalResult, evaluated := n.eval.RunTiered(current, proposed, 0)
+		if evalResult.Tier == eval.TierWarn {
+			evalScale = evalResult.Scale
+		}
+		if evalResult.Passed {
+			outcome, reason, proposed = "commit", decision.Reason+"; eval: "+evalResult.Reason, evaluated
+		} else {
+			reason = "eval: " + evalResult.Reason
+		}
+	}
+
+	record := logging.GateRecord{
+		TurnID:        turnID,
+		Prompt:        fmt.Sprintf("/nudge %s %+g", seg, amount),
+		DeltaNorm:     metrics.DeltaNorm,
+		SegmentsHit:   metrics.SegmentsHit,
+		GateAction:    decision.Action,
+		GateSoftScore: decision.SoftScore,
+		GateVetoed:    decision.Vetoed,
+		GateReason:    decision.Reason,
+		GateBreakdown: decision.BreakdownRecord(),
+		GateScale:     decision.Scale,
+		EvalScale:     evalScale,
+	}
+	signalsJSON, _ := json.Marshal(record)
+	entry := logging.ProvenanceEntry{
+		VersionID:   current.VersionID,
+		TriggerType: "manual",
+		SignalsJSON: string(signalsJSON),
+		Decision:    outcome,
+		Reason:      fmt.Sprintf("nudge %s %+g: %s", seg, amount, reason),
+	}
+	if outcome != "commit" {
+		if err := logging.LogDecision(n.store.DB(), entry); err != nil {
+			log.Printf("nudge provenance error: %v", err)
+		}
+		log.Printf("[%s] nudge rejected: %s", turnID, reason)
+		return fmt.Sprintf("Nudge rejected (%s).", reason), false
+	}
+
+	entry.VersionID = proposed.VersionID
+	if err := n.store.WithTx(func(tx *sql.Tx) error {
+		if err := n.store.CommitStateTx(tx, proposed); err != nil {
+			return fmt.Errorf("commit state: %w", err)
+		}
+		return logging.LogDecision(tx, entry)
+	}); err != nil {
+		log.Printf("[%s] nudge: %v", turnID, err)
+		return fmt.Sprintf("Could not commit the nudge: %v", err), false
+	}
+	before := current.SegmentMap.Norms(current.StateVector)[seg]
+	after := proposed.SegmentMap.Norms(proposed.StateVector)[seg]
+	log.Printf("[%s] nudge committed: %s -> %s, %s norm %.4f -> %.4f", turnID, current.VersionID, proposed.VersionID, seg, before, after)
+	return fmt.Sprintf("Nudged %s: norm %.4f -> %.4f (%s -> %s, %s).", seg, before, after, current.VersionID, proposed.VersionID, decision.Action), true
+}
+
+// #endregion nudge
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/codec"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/evidence"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/logging"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/retrieval"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/state"
+)
+
+// #region pin
+
+const pinUsage = "Usage: /pin <n|id> [note], /unpin <n|id>, /note <n|id> [text], /pinned — n numbers the evidence the last turn used (/pin lists it)."
+
+// isPinCommand reports whether prompt is /pin, /unpin, /note or /pinned.
+func isPinCommand(prompt string) bool {
+	cmd, _, _ := strings.Cut(prompt, " ")
+	switch cmd {
+	case "/pin", "/unpin", "/note", "/pinned":
+		return true
+	}
+	return false
+}
+
+// pinCommand handles the evidence pinning commands. An item is named by its
+// evidence ID or by its number in last, the evidence of the last generated
+// turn. Pins and notes live in the item's metadata, where the inference
+// service's eviction and search ranking read them; each change is logged to
+// provenance under trigger_type "evidence_pin".
+func pinCommand(ctx context.Context, client *codec.CodecClient, store *state.Store, prompt string, last []retrieval.EvidenceRecord) string {
+	cmd, args, _ := strings.Cut(prompt, " ")
+	ref, note, _ := strings.Cut(strings.TrimSpace(args), " ")
+	note = strings.TrimSpace(note)
+	switch {
+	case cmd == "/pinned":
+		return listPinned(ctx, client)
+	case ref == "" && cmd == "/pin":
+		return listLastEvidence(last)
+	case ref == "" || (cmd == "/unpin" && note != ""):
+		return pinUsage
+	case len([]rune(note)) > evidence.MaxNoteLen:
+		return fmt.Sprintf("Notes are limited to %d characters.", evidence.MaxNoteLen)
+	}
+	id, problem := resolveEvidenceRef(ref, last)
+	if problem != "" {
+		return problem
+	}
+
+	patch := map[string]any{}
+	var done string
+	switch {
+	case cmd == "/pin":
+		patch[evidence.MetaPinned] = true
+		if note != "" {
+			patch[evidence.MetaNote] = note
+		}
+		done = "Pinned"
+	case cmd == "/unpin":
+		patch[evidence.MetaPinned] = nil
+		done = "Unpinned"
+	case note == "":
+		patch[evidence.MetaNote] = nil
+		done = "Cleared the note on"
+	default:
+		patch[evidence.MetaNote] = note
+		done = "Noted"
+	}
+	if _, found, err := client.UpdateEvidenceMetadata(ctx, id, patch); err != nil {
+		log.Printf("%s %s: %v", cmd, id, err)
+		return fmt.Sprintf("Could not update %s: %v", id, err)
+	} else if !found {
+		return fmt.Sprintf("No evidence %s in memory.", id)
+	}
+
+	versionID := ""
+	if current, err := store.GetCurrent(); err == nil {
+		versionID = current.VersionID
+	}
+	reason := strings.TrimPrefix(cmd, "/")
+	if note != "" {
+		reason += ": " + note
+	}
+	if err := logging.LogDecision(store.DB(), logging.ProvenanceEntry{
+		VersionID:    versionID,
+		TriggerType:  "evidence_pin",
+		EvidenceRefs: id,
+		Decision:     "commit",
+		Reason:       reason,
+	}); err != nil {
+		log.Printf("evidence pin provenance error: %v", err)
+	}
+	log.Printf("evidence %s: %s", reason, id)
+	return fmt.Sprintf("%s %s.", done, id)
+}
+
+// resolveEvidenceRef turns a /pin argument into a local evidence ID. When it
+// cannot, problem is the reply explaining why.
+func resolveEvidenceRef(ref string, last []retrieval.EvidenceRecord) (id, problem string) {
+	if n, err := strconv.Atoi(ref); err == nil {
+		if n < 1 || n > len(last) {
+			return "", fmt.Sprintf("The last turn used %d evidence items; there is no item %d.", len(last), n)
+		}
+		rec := last[n-1]
+		if !evidence.IsLocalID(rec.ID) {
+			return "", fmt.Sprintf("Item %d comes from source %s, which is read-only; only this memory's evidence can be pinned.", n, rec.Source)
+		}
+		return rec.ID, ""
+	}
+	if !evidence.IsLocalID(ref) {
+		return "", fmt.Sprintf("%q is neither an item number nor an evidence ID. %s", ref, pinUsage)
+	}
+	return ref, ""
+}
+
+// listLastEvidence numbers the last turn's evidence for /pin.
+func listLastEvidence(last []retrieval.EvidenceRecord) string {
+	if len(last) == 0 {
+		return "The last turn used no evidence. " + pinUsage
+	}
+	var b strings.Builder
+	b.WriteString("Evidence used by the last turn:")
+	for i, rec := range last {
+		mark := ""
+		if a := evidence.ParseAnnotation(rec.MetadataJSON); a.Pinned {
+			mark = " [pinned]"
+		}
+		fmt.Fprintf(&b, "\n  %d. %s%s", i+1, clipText(rec.Attributed(), 80), mark)
+	}
+	b.WriteString("\n/pin <n> [note] keeps one from ever fading.")
+	return b.String()
+}
+
+// listPinned shows every pinned item with its note.
+func listPinned(ctx context.Context, client *codec.CodecClient) string {
+	all, err := client.ListAllEvidence(ctx)
+	if err != nil {
+		log.Printf("/pinned: %v", err)
+		return "Could not list evidence."
+	}
+	var b strings.Builder
+	n := 0
+	for _, rec := range all {
+		a := evidence.ParseAnnotation(rec.MetadataJSON)
+		if !a.Pinned {
+			continue
+		}
+		n++
+		fmt.Fprintf(&b, "\n  %s  %s", rec.ID, clipText(rec.Text, 80))
+		if a.Note != "" {
+			fmt.Fprintf(&b, "\n      note: %s", a.Note)
+		}
+	}
+	if n == 0 {
+		return "No pinned evidence."
+	}
+	return fmt.Sprintf("Pinned evidence (%d):", n) + b.String()
+}
+
+// clipText shortens s to n runes on one line.
+func clipText(s string, n int) string {
+	s = strings.Join(strings.Fields(s), " ")
+	if r := []rune(s); len(r) > n {
+		return string(r[:n-1]) + "…"
+	}
+	return s
+}
+
+// #endregion pin
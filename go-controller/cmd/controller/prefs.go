@@ -0,0 +1,192 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/projection"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/state"
+)
+
+// #region prefs
+
+const prefsUsage = "Usage: /prefs, /prefs history, /prefs delete <id>, /prefs restore <id>"
+
+// isPrefsCommand reports whether prompt is a /prefs command.
+func isPrefsCommand(prompt string) bool {
+	return prompt == "/prefs" || strings.HasPrefix(prompt, "/prefs ")
+}
+
+// prefsEdit returns the verb of a /prefs command that changes the stored
+// preferences (delete or restore), or "" for one that only reads them.
+func prefsEdit(prompt string) string {
+	if args := strings.Fields(strings.TrimPrefix(prompt, "/prefs")); len(args) > 0 && (args[0] == "delete" || args[0] == "restore") {
+		return args[0]
+	}
+	return ""
+}
+
+// prefsCommand reviews stored preferences: list the active ones, list the
+// ones replaced by a contradicting preference or deleted, delete one, or
+// restore a replaced or deleted one by ID. A delete or restore runs in one
+// transaction of store with the history rows it moves.
+func prefsCommand(store *state.Store, ps *projection.PreferenceStore, prompt string) string {
+	args := strings.Fields(strings.TrimPrefix(prompt, "/prefs"))
+	switch {
+	case len(args) == 0:
+		return listPrefs(ps)
+	case len(args) == 1 && args[0] == "history":
+		return listPrefHistory(ps)
+	case len(args) != 2:
+		return prefsUsage
+	}
+	id, err := strconv.Atoi(strings.TrimPrefix(args[1], "#"))
+	if err != nil {
+		return prefsUsage
+	}
+	switch args[0] {
+	case "delete":
+		var found bool
+		err := store.WithTx(func(tx *sql.Tx) error {
+			var err error
+			found, err = ps.WithTx(tx).Delete(id)
+			return err
+		})
+		if err != nil {
+			return fmt.Sprintf("Error deleting preference %d: %v", id, err)
+		}
+		if !found {
+			return fmt.Sprintf("No active preference %d.", id)
+		}
+		log.Printf("preference %d deleted by user", id)
+		return fmt.Sprintf("Deleted preference %d; /prefs restore %d brings it back.", id, id)
+	case "restore":
+		var replaced []int64
+		var found bool
+		err := store.WithTx(func(tx *sql.Tx) error {
+			var err error
+			replaced, found, err = ps.WithTx(tx).Restore(id)
+			return err
+		})
+		if err != nil {
+			return fmt.Sprintf("Error restoring preference %d: %v", id, err)
+		}
+		if !found {
+			return fmt.Sprintf("Preference %d has nothing to restore; see /prefs history.", id)
+		}
+		log.Printf("preference %d restored by user (replacing %v)", id, replaced)
+		reply := fmt.Sprintf("Restored preference %d.", id)
+		if len(replaced) > 0 {
+			ids := make([]string, len(replaced))
+			for i, r := range replaced {
+				ids[i] = strconv.FormatInt(r, 10)
+			}
+			reply += fmt.Sprintf(" It replaces %s, which contradicted it.", strings.Join(ids, ", "))
+		}
+		return reply
+	}
+	return prefsUsage
+}
+
+// retractPreference deletes, or for a downgrade lowers the priority of, the
+// stored preferences req refers to. Deleted ones stay restorable. It reports
+// false, with no reply, when no stored preference matches, so the message
+// is answered as an ordinary turn. The changes commit together in one
+// transaction of store.
+func retractPreference(store *state.Store, ps *projection.PreferenceStore, req projection.RemovalRequest) (string, bool) {
+	prefs, err := ps.List()
+	if err != nil {
+		return fmt.Sprintf("Error reading preferences: %v", err), true
+	}
+	matched := projection.MatchRemoval(req, prefs)
+	if len(matched) == 0 {
+		return "", false
+	}
+	var lines, logs []string
+	if err := store.WithTx(func(tx *sql.Tx) error {
+		txPrefs := ps.WithTx(tx)
+		for _, p := range matched {
+			if req.Downgrade {
+				priority, _, err := txPrefs.Downgrade(p.ID)
+				if err != nil {
+					return fmt.Errorf("downgrading preference %d: %w", p.ID, err)
+				}
+				logs = append(logs, fmt.Sprintf("preference %d downgraded by user to %s priority: %q", p.ID, projection.PriorityName(priority), p.Text))
+				lines = append(lines, fmt.Sprintf("Lowered %q to %s priority.", clipText(p.Text, 60), projection.PriorityName(priority)))
+				continue
+			}
+			if _, err := txPrefs.Delete(p.ID); err != nil {
+				return fmt.Errorf("deleting preference %d: %w", p.ID, err)
+			}
+			logs = append(logs, fmt.Sprintf("preference %d retracted by user (%q): %q", p.ID, req.Subject, p.Text))
+			lines = append(lines, fmt.Sprintf("Forgot %q; /prefs restore %d brings it back.", clipText(p.Text, 60), p.ID))
+		}
+		return nil
+	}); err != nil {
+		return fmt.Sprintf("Error %v; no preference was changed.", err), true
+	}
+	for _, l := range logs {
+		log.Print(l)
+	}
+	return strings.Join(lines, "\n"), true
+}
+
+// listPrefs lists the active preferences in projection order.
+func listPrefs(ps *projection.PreferenceStore) string {
+	prefs, err := ps.List()
+	if err != nil {
+		return fmt.Sprintf("Error reading preferences: %v", err)
+	}
+	if len(prefs) == 0 {
+		return "No preferences stored."
+	}
+	lines := []string{fmt.Sprintf("Active preferences (%d):", len(prefs))}
+	for _, p := range prefs {
+		lines = append(lines, fmt.Sprintf("  %d %s %q", p.ID, prefLabels(p), clipText(p.Text, 70)))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// listPrefHistory lists replaced and deleted preferences, newest first.
+func listPrefHistory(ps *projection.PreferenceStore) string {
+	hist, err := ps.History(20)
+	if err != nil {
+		return fmt.Sprintf("Error reading preference history: %v", err)
+	}
+	if len(hist) == 0 {
+		return "No preference has been replaced or deleted."
+	}
+	lines := []string{"Replaced and deleted preferences (newest first):"}
+	for _, h := range hist {
+		what := "deleted"
+		if h.Reason == projection.PrefRemovedReplaced {
+			what = fmt.Sprintf("replaced by %d", h.ReplacedBy)
+		}
+		what += " " + h.RemovedAt.Local().Format("2006-01-02 15:04")
+		if !h.RestoredAt.IsZero() {
+			what += ", restored " + h.RestoredAt.Local().Format("2006-01-02 15:04")
+		}
+		lines = append(lines, fmt.Sprintf("  %d %q: %s", h.Preference.ID, clipText(h.Preference.Text, 60), what))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// prefLabels is the bracketed style, scope, priority and expiry of p.
+func prefLabels(p projection.Preference) string {
+	labels := []string{string(p.Style)}
+	if p.Scope != projection.ScopeAll {
+		labels = append(labels, p.Scope)
+	}
+	if p.Priority != projection.PriorityNormal {
+		labels = append(labels, projection.PriorityName(p.Priority)+" priority")
+	}
+	if !p.ExpiresAt.IsZero() {
+		labels = append(labels, "until "+p.ExpiresAt.Local().Format("2006-01-02 15:04"))
+	}
+	return "[" + strings.Join(labels, ", ") + "]"
+}
+
+// #endregion prefs
@@ -0,0 +1,23 @@
+package main
+
+import "strings"
+
+// #region private-turns
+
+// privateReason is the freeze reason and provenance marker of a private turn.
+const privateReason = "private turn"
+
+// privatePrompt strips the "/private" command or the configured no-learn prefix
+// (case-insensitive, e.g. "off the record:") from prompt. ok reports whether the
+// turn is private; an empty prefix disables the prefix form.
+func privatePrompt(prompt, prefix string) (string, bool) {
+	if prompt == "/private" || strings.HasPrefix(prompt, "/private ") {
+		return strings.TrimSpace(strings.TrimPrefix(prompt, "/private")), true
+	}
+	if prefix != "" && len(prompt) >= len(prefix) && strings.EqualFold(prompt[:len(prefix)], prefix) {
+		return strings.TrimSpace(prompt[len(prefix):]), true
+	}
+	return prompt, false
+}
+
+// #endregion private-turns
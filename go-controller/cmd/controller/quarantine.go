@@ -0,0 +1,119 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/codec"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/evidence"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/graph"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/injection"
+)
+
+// #region quarantine
+
+const quarantineUsage = "Usage: /quarantine [all], /quarantine show <id>, /quarantine release <id>, /quarantine delete <id>"
+
+// isQuarantineCommand reports whether prompt is a /quarantine command.
+func isQuarantineCommand(prompt string) bool {
+	return prompt == "/quarantine" || strings.HasPrefix(prompt, "/quarantine ")
+}
+
+// quarantineSource returns the source an item id belongs to: the namespace of
+// a secondary-source ID ("notes::7"), or "" for the primary store.
+func quarantineSource(id string) string {
+	if ns, _, ok := strings.Cut(id, evidence.NamespaceSep); ok {
+		return ns
+	}
+	return ""
+}
+
+// quarantineCommand reviews evidence that retrieval withheld for
+// instruction-like text: list it, show one item's full text, release it (it
+// reaches the model again, sanitized) or delete it from memory. Secondary
+// sources are read-only, so their items can be shown (by excerpt) and released
+// but not deleted.
+func quarantineCommand(ctx context.Context, client *codec.CodecClient, gs *graph.GraphStore, q *injection.Quarantine, prompt string) string {
+	args := strings.Fields(strings.TrimPrefix(prompt, "/quarantine"))
+	switch {
+	case len(args) == 0:
+		return listQuarantine(q, injection.StatusFlagged)
+	case len(args) == 1 && args[0] == "all":
+		return listQuarantine(q, "")
+	case len(args) != 2:
+		return quarantineUsage
+	}
+	action, id := args[0], args[1]
+	source := quarantineSource(id)
+	item, err := q.Get(source, id)
+	if err != nil {
+		return fmt.Sprintf("Error reading quarantine: %v", err)
+	}
+	if item == nil {
+		return fmt.Sprintf("%s is not quarantined.", id)
+	}
+	switch action {
+	case "show":
+		if source != "" {
+			return fmt.Sprintf("%s from %s [%s] flagged for %s: %q", id, source, item.Status, strings.Join(item.Patterns, ", "), item.Excerpt)
+		}
+		recs, err := client.GetByIDs(ctx, []string{id})
+		if err != nil {
+			return fmt.Sprintf("Error reading %s: %v", id, err)
+		}
+		if len(recs) == 0 {
+			return fmt.Sprintf("%s is no longer in memory (flagged for %s).", id, strings.Join(item.Patterns, ", "))
+		}
+		return fmt.Sprintf("%s [%s] flagged for %s:\n%s", id, item.Status, strings.Join(item.Patterns, ", "), recs[0].Text)
+	case "release":
+		if err := q.Release(source, id); err != nil {
+			return fmt.Sprintf("Error releasing %s: %v", id, err)
+		}
+		log.Printf("quarantine: released %s", id)
+		return fmt.Sprintf("Released %s; it reaches the model again with instruction-like text removed.", id)
+	case "delete":
+		if source != "" {
+			return fmt.Sprintf("%s comes from the read-only %s source; remove it from that source, or leave it quarantined.", id, source)
+		}
+		if _, err := client.DeleteEvidence(ctx, []string{id}); err != nil {
+			return fmt.Sprintf("Error deleting %s: %v", id, err)
+		}
+		if err := gs.SeverNode(id); err != nil {
+			log.Printf("graph sever error for %s: %v", id, err)
+		}
+		if err := q.Remove(source, id); err != nil {
+			log.Printf("quarantine: %v", err)
+		}
+		log.Printf("quarantine: deleted %s (edges severed)", id)
+		return fmt.Sprintf("Deleted %s from memory.", id)
+	}
+	return quarantineUsage
+}
+
+// listQuarantine lists quarantined items with status, or all items for "".
+func listQuarantine(q *injection.Quarantine, status string) string {
+	items, err := q.List(status)
+	if err != nil {
+		return fmt.Sprintf("Error reading quarantine: %v", err)
+	}
+	if len(items) == 0 {
+		if status == injection.StatusFlagged {
+			return "Nothing is quarantined."
+		}
+		return "Nothing has been quarantined."
+	}
+	lines := []string{fmt.Sprintf("Quarantined evidence (%d):", len(items))}
+	for _, it := range items {
+		source := ""
+		if it.Source != "" {
+			source = " from " + it.Source
+		}
+		lines = append(lines, fmt.Sprintf("  %s%s [%s, %s, seen %d×] %q",
+			it.EvidenceID, source, it.Status, strings.Join(it.Patterns, ", "), it.Hits, clipText(it.Excerpt, 60)))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// #endregion quarantine
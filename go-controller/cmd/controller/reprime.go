@@ -0,0 +1,56 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"time"
+
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/codec"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/logging"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/projection"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/reprime"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/state"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/timestamp"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/transcript"
+)
+
+// #region reprime
+
+// codecHeartbeat handshakes with the codec and feeds the outcome to resets.
+// A protocol mismatch counts as a failed heartbeat: the next turn reports it.
+func codecHeartbeat(ctx context.Context, c *codec.CodecClient, resets *reprime.Detector, timeout time.Duration) {
+	hbCtx, cancel := context.WithTimeout(ctx, timeout)
+	info, err := c.Handshake(hbCtx)
+	cancel()
+	if r := resets.Heartbeat(info.Instance, err, time.Now().UTC()); r != nil {
+		log.Printf("codec reset detected (%s): %s; the next prompt is re-primed", r.Reason, r.Detail)
+	}
+}
+
+// reprimeBlock builds the recap for reset from the active state, the turn's
+// preferences and the last logged exchanges. A provenance read error only
+// leaves the exchanges out.
+func reprimeBlock(db *sql.DB, reset *reprime.Reset, current state.StateRecord, prefs []projection.Preference, cfg reprime.Config) (string, *logging.ReprimeRecord) {
+	recap := reprime.Recap{Norms: current.SegmentMap.Norms(current.StateVector)}
+	for _, p := range prefs {
+		recap.Preferences = append(recap.Preferences, p.Text)
+	}
+	turns, err := transcript.LoadTurns(db, cfg.Turns)
+	if err != nil {
+		log.Printf("re-prime: recent turns unavailable: %v", err)
+	}
+	for _, t := range turns {
+		recap.Turns = append(recap.Turns, reprime.Exchange{Prompt: t.Record.Prompt, Response: t.Record.Response})
+	}
+	rec := &logging.ReprimeRecord{
+		Reason:      reset.Reason,
+		Detail:      reset.Detail,
+		DetectedAt:  timestamp.Format(reset.At),
+		Turns:       min(len(recap.Turns), cfg.Turns),
+		Preferences: min(len(recap.Preferences), cfg.Preferences),
+	}
+	return recap.Block(cfg), rec
+}
+
+// #endregion reprime
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/codec"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/logging"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/review"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/state"
+)
+
+// #region memory-review
+
+// newMemoryReviewer builds the reviewer selected by MEMORY_REVIEWER. The LLM
+// reviewer generates in review mode (no tools, no state wrapping) from the
+// current state vector; the human picker uses the daemon's terminal.
+func newMemoryReviewer(name string, client *codec.CodecClient, store *state.Store, timeout, watchdogInterval time.Duration) (review.Reviewer, error) {
+	gen := func(ctx context.Context, prompt string) (string, error) {
+		current, err := store.GetCurrent()
+		if err != nil {
+			return "", fmt.Errorf("load state: %w", err)
+		}
+		genCtx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+		stopWatch := watchCodec("review", "generate", watchdogInterval)
+		defer stopWatch()
+		res, err := client.Generate(genCtx, prompt, current.StateVector, []string{"[REVIEW MODE]"}, nil)
+		if err != nil {
+			return "", err
+		}
+		return res.Text, nil
+	}
+	return review.New(name, gen, os.Stdin, os.Stdout)
+}
+
+// logMemoryReview records which reviewer ran, its rationale, and the deletions
+// in provenance (trigger_type "memory_review").
+func logMemoryReview(store *state.Store, reviewer review.Reviewer, req review.Request, decision review.Decision) {
+	log.Printf("memory review: reviewer=%s delete=%d/%d rationale=%q",
+		reviewer.Name(), len(decision.DeleteIDs), len(req.Candidates), decision.Rationale)
+
+	candidateIDs := make([]string, len(req.Candidates))
+	for i, c := range req.Candidates {
+		candidateIDs[i] = c.ID
+	}
+	signals, _ := json.Marshal(map[string]interface{}{
+		"reviewer":   reviewer.Name(),
+		"candidates": candidateIDs,
+		"deleted":    decision.DeleteIDs,
+	})
+	outcome := "no_op"
+	if len(decision.DeleteIDs) > 0 {
+		outcome = "commit"
+	}
+	versionID := ""
+	if current, err := store.GetCurrent(); err == nil {
+		versionID = current.VersionID
+	}
+	if err := logging.LogDecision(store.DB(), logging.ProvenanceEntry{
+		VersionID:    versionID,
+		TriggerType:  "memory_review",
+		SignalsJSON:  string(signals),
+		EvidenceRefs: strings.Join(decision.DeleteIDs, ","),
+		Decision:     outcome,
+		Reason:       fmt.Sprintf("reviewer=%s: %s", reviewer.Name(), decision.Rationale),
+	}); err != nil {
+		log.Printf("memory review provenance error: %v", err)
+	}
+}
+
+// #endregion memory-review
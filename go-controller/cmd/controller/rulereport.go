@@ -0,0 +1,38 @@
+package main
+
+import (
+	"log"
+	"time"
+
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/projection"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/rulestats"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/state"
+)
+
+// #region rule-report
+
+// runRuleReport builds the rule effectiveness report over the last interval,
+// records it and logs it. Returns the note for the next ordinary response, or
+// "" when no rule was flagged or the report failed.
+func runRuleReport(store *state.Store, rules *projection.RuleStore, reports *rulestats.Store, interval time.Duration) string {
+	until := time.Now().UTC()
+	rep, err := rulestats.BuildFromDB(store.DB(), rules, until.Add(-interval), until, rulestats.DefaultConfig())
+	if err != nil {
+		log.Printf("rule report error: %v", err)
+		return ""
+	}
+	id, err := reports.Record(rep)
+	if err != nil {
+		log.Printf("rule report error: %v", err)
+		return ""
+	}
+	summary := rep.Summary()
+	if summary == "" {
+		log.Printf("rule report %d: %d rules over %d turns, none flagged", id, len(rep.Rules), rep.Turns)
+		return ""
+	}
+	log.Printf("rule report %d: %s", id, summary)
+	return "[Rule report] " + summary + ". Review them with `inspect --rules`."
+}
+
+// #endregion rule-report
@@ -0,0 +1,378 @@
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/cipher"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/events"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/logging"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/state"
+)
+
+// #region turn-inbox
+
+// turnInbox is where the daemon loop takes messages from and delivers replies
+// to: the encrypted cipher inbox by default, or the API queue with --serve/--grpc.
+type turnInbox interface {
+	// Read returns the next message, or "" when none is waiting.
+	Read() (string, error)
+	// Clear marks the message just read as consumed.
+	Clear()
+	// Reply delivers text for the current message.
+	Reply(text string)
+	// Event records the current turn's outcome.
+	Event(ev events.TurnEvent)
+	// Waiting reports whether a message is waiting, without reading it.
+	Waiting() bool
+}
+
+// cipherInbox is the Commander GUI's encrypted file exchange.
+type cipherInbox struct{}
+
+func (cipherInbox) Read() (string, error) { return cipher.ReadInbox() }
+
+func (cipherInbox) Clear() { cipher.ClearInbox() }
+
+func (cipherInbox) Reply(text string) {
+	if err := cipher.WriteOutbox(text); err != nil {
+		log.Printf("outbox write error: %v", err)
+	}
+}
+
+func (cipherInbox) Event(events.TurnEvent) {}
+
+func (cipherInbox) Waiting() bool { return cipher.InboxWaiting() }
+
+// #endregion turn-inbox
+
+// #region queue-inbox
+
+// turnResponse is the body of a POST /turn (or /correct) reply: every message the
+// daemon sent for the turn, the last of them, and the turn event when the turn
+// reached the pipeline (commands and instruction-only replies have none).
+type turnResponse struct {
+	Reply   string            `json:"reply"`
+	Replies []string          `json:"replies"`
+	Turn    *events.TurnEvent `json:"turn,omitempty"`
+}
+
+type turnRequest struct {
+	prompt string
+	done   chan turnResponse
+}
+
+// queueInbox queues HTTP and gRPC requests for the daemon loop, which still runs
+// one turn at a time. A request completes when the loop comes back for the next
+// message.
+type queueInbox struct {
+	queue   chan *turnRequest
+	current *turnRequest
+	resp    turnResponse
+}
+
+func newQueueInbox(depth int) *queueInbox {
+	return &queueInbox{queue: make(chan *turnRequest, depth)}
+}
+
+func (h *queueInbox) Read() (string, error) {
+	h.finish()
+	select {
+	case req := <-h.queue:
+		h.current, h.resp = req, turnResponse{Replies: []string{}}
+		return req.prompt, nil
+	default:
+		return "", nil
+	}
+}
+
+func (h *queueInbox) Clear() {}
+
+func (h *queueInbox) Reply(text string) {
+	if h.current == nil {
+		return
+	}
+	h.resp.Reply = text
+	h.resp.Replies = append(h.resp.Replies, text)
+}
+
+func (h *queueInbox) Event(ev events.TurnEvent) {
+	if h.current != nil {
+		h.resp.Turn = &ev
+	}
+}
+
+func (h *queueInbox) Waiting() bool { return len(h.queue) > 0 }
+
+// finish hands the finished turn's response to its waiting request.
+func (h *queueInbox) finish() {
+	if h.current != nil {
+		h.current.done <- h.resp
+		h.current = nil
+	}
+}
+
+var errBusy = errors.New("turn queue full")
+
+// submit queues prompt and waits for the loop to finish its turn.
+func (h *queueInbox) submit(ctx context.Context, prompt string) (turnResponse, error) {
+	req := &turnRequest{prompt: prompt, done: make(chan turnResponse, 1)}
+	select {
+	case h.queue <- req:
+	default:
+		return turnResponse{}, errBusy
+	}
+	select {
+	case resp := <-req.done:
+		return resp, nil
+	case <-ctx.Done():
+		// The turn still runs to completion; only this caller stops waiting
+		return turnResponse{}, ctx.Err()
+	}
+}
+
+// #endregion queue-inbox
+
+// #region api-server
+
+// listenServe opens the --serve or --grpc listener. Without a token the API is
+// local-only: addr must resolve to a loopback address.
+func listenServe(addr, token string) (net.Listener, error) {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, fmt.Errorf("parse serve addr: %w", err)
+	}
+	if ip := net.ParseIP(host); token == "" && host != "localhost" && (ip == nil || !ip.IsLoopback()) {
+		return nil, fmt.Errorf("serve addr %q is not loopback; set SERVE_TOKEN to expose the API", addr)
+	}
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("listen tcp %s: %w", addr, err)
+	}
+	return ln, nil
+}
+
+// Timeouts of the controller's HTTP listeners. There is no write timeout:
+// POST /turn and the event streams legitimately run for minutes.
+const (
+	httpReadHeaderTimeout = 10 * time.Second
+	httpIdleTimeout       = 2 * time.Minute
+)
+
+// newHTTPServer serves h with header and keep-alive timeouts, so a client that
+// never finishes its request headers or leaves a connection idle cannot hold
+// it open.
+func newHTTPServer(h http.Handler) *http.Server {
+	return &http.Server{Handler: h, ReadHeaderTimeout: httpReadHeaderTimeout, IdleTimeout: httpIdleTimeout}
+}
+
+// apiConfig holds the HTTP API's access settings.
+type apiConfig struct {
+	Token      string // required as "Authorization: Bearer <token>" when set
+	CORSOrigin string // Access-Control-Allow-Origin for browser frontends; "" sends none
+}
+
+type stateResponse struct {
+	VersionID    string             `json:"version_id"`
+	ParentID     string             `json:"parent_id,omitempty"`
+	CreatedAt    time.Time          `json:"created_at"`
+	SegmentNorms map[string]float64 `json:"segment_norms"`
+	SegmentMap   state.SegmentMap   `json:"segment_map"`
+	StateVector  []float32          `json:"state_vector"`
+}
+
+type provenanceItem struct {
+	ID           int64               `json:"id"`
+	VersionID    string              `json:"version_id"`
+	TriggerType  string              `json:"trigger_type"`
+	Decision     string              `json:"decision"`
+	Reason       string              `json:"reason,omitempty"`
+	EvidenceRefs []string            `json:"evidence_refs,omitempty"`
+	CreatedAt    time.Time           `json:"created_at"`
+	Record       *logging.GateRecord `json:"record,omitempty"` // turn rows in GateRecord format
+}
+
+// apiHandler serves the turn pipeline over HTTP/JSON:
+//
+//	POST /turn        {"prompt": "..."}      run a turn (slash commands included)
+//	POST /correct     {"segment": "prefs"}   same as /correct [segment]
+//	GET  /state                              active version, segment norms, vector
+//	GET  /provenance  ?limit=20&before=ID    provenance rows, newest first
+//	GET  /export                             signed hot-state export (needs STATE_EXPORT_KEY)
+func apiHandler(inbox *queueInbox, store *state.Store, exporter *stateExporter, cfg apiConfig) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/turn", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		var body struct {
+			Prompt string `json:"prompt"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil || strings.TrimSpace(body.Prompt) == "" {
+			http.Error(w, "body must be JSON with a non-empty prompt", http.StatusBadRequest)
+			return
+		}
+		runTurn(w, r, inbox, body.Prompt)
+	})
+	mux.HandleFunc("/correct", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		var body struct {
+			Segment string `json:"segment"`
+		}
+		if r.ContentLength != 0 {
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+				http.Error(w, "body must be JSON", http.StatusBadRequest)
+				return
+			}
+		}
+		if body.Segment != "" && !isStateSegment(body.Segment) {
+			http.Error(w, "segment must be one of prefs, goals, heuristics, risk", http.StatusBadRequest)
+			return
+		}
+		runTurn(w, r, inbox, strings.TrimSpace("/correct "+body.Segment))
+	})
+	mux.HandleFunc("/state", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		current, err := store.GetCurrent()
+		if err != nil {
+			log.Printf("api: state: %v", err)
+			http.Error(w, "could not load the current state", http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, stateResponse{
+			VersionID:    current.VersionID,
+			ParentID:     current.ParentID,
+			CreatedAt:    current.CreatedAt,
+			SegmentNorms: current.SegmentMap.Norms(current.StateVector),
+			SegmentMap:   current.SegmentMap,
+			StateVector:  current.StateVector[:],
+		})
+	})
+	mux.HandleFunc("/provenance", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		limit, err1 := queryInt(r, "limit", 20)
+		before, err2 := queryInt(r, "before", 0)
+		if err1 != nil || err2 != nil || limit > 500 {
+			http.Error(w, "limit (1-500) and before must be positive integers", http.StatusBadRequest)
+			return
+		}
+		entries, err := logging.ListProvenance(store.DB(), limit, int64(before))
+		if err != nil {
+			log.Printf("api: provenance: %v", err)
+			http.Error(w, "could not read provenance", http.StatusInternalServerError)
+			return
+		}
+		items := make([]provenanceItem, 0, len(entries))
+		for _, e := range entries {
+			item := provenanceItem{
+				ID: e.ID, VersionID: e.VersionID, TriggerType: e.TriggerType, Decision: e.Decision,
+				Reason: e.Reason, CreatedAt: e.CreatedAt,
+			}
+			if e.EvidenceRefs != "" {
+				item.EvidenceRefs = strings.Split(e.EvidenceRefs, ",")
+			}
+			if gr, err := logging.ParseGateRecord(e.SignalsJSON); err == nil {
+				item.Record = &gr
+			}
+			items = append(items, item)
+		}
+		writeJSON(w, items)
+	})
+	mux.HandleFunc("/export", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		if exporter == nil {
+			http.Error(w, "state export disabled (set STATE_EXPORT_KEY)", http.StatusNotFound)
+			return
+		}
+		data := exporter.Latest()
+		if data == nil {
+			http.Error(w, "state export not built yet; see the controller log", http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(data)
+	})
+	return withAccess(mux, cfg)
+}
+
+// runTurn submits prompt to the daemon loop and writes its response.
+func runTurn(w http.ResponseWriter, r *http.Request, inbox *queueInbox, prompt string) {
+	resp, err := inbox.submit(r.Context(), prompt)
+	switch {
+	case errors.Is(err, errBusy):
+		http.Error(w, "busy: too many turns queued", http.StatusServiceUnavailable)
+	case err != nil:
+		http.Error(w, "request cancelled before the turn finished", http.StatusGatewayTimeout)
+	default:
+		writeJSON(w, resp)
+	}
+}
+
+// withAccess applies CORS headers and bearer-token checks in front of h.
+func withAccess(h http.Handler, cfg apiConfig) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if cfg.CORSOrigin != "" {
+			w.Header().Set("Access-Control-Allow-Origin", cfg.CORSOrigin)
+			w.Header().Set("Access-Control-Allow-Headers", "Authorization, Content-Type")
+			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
+			if r.Method == http.MethodOptions {
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+		}
+		if cfg.Token != "" && !bearerMatches(r.Header.Get("Authorization"), cfg.Token) {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
+// bearerMatches reports whether an Authorization value is "Bearer <token>",
+// compared in constant time so the check does not leak how much matched.
+func bearerMatches(got, token string) bool {
+	return subtle.ConstantTimeCompare([]byte(got), []byte("Bearer "+token)) == 1
+}
+
+func queryInt(r *http.Request, key string, fallback int) (int, error) {
+	v := r.URL.Query().Get(key)
+	if v == "" {
+		return fallback, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("%s: want a positive integer", key)
+	}
+	return n, nil
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("api: write response: %v", err)
+	}
+}
+
+// #endregion api-server
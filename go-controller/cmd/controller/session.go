@@ -0,0 +1,44 @@
+package main
+
+import (
+	"log"
+	"time"
+
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/session"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/state"
+)
+
+// #region session-banner
+
+// sessionStartBanner records the session starting now and summarizes what was
+// learned since the previous one began. Returns "" on the first session or
+// when nothing notable changed.
+func sessionStartBanner(sessions *session.Store, store *state.Store) string {
+	current, err := store.GetCurrent()
+	if err != nil {
+		log.Printf("session: %v", err)
+		return ""
+	}
+	now := time.Now().UTC()
+	prev, ok, err := sessions.Begin(current.VersionID, now)
+	if err != nil {
+		log.Printf("session: %v", err)
+		return ""
+	}
+	if !ok {
+		return ""
+	}
+	from, err := store.GetVersion(prev.VersionID)
+	if err != nil {
+		log.Printf("session: previous session's version %s unavailable, skipping state shifts: %v", prev.VersionID, err)
+		from = state.StateRecord{}
+	}
+	changes, err := session.Collect(store.DB(), prev.StartedAt, now, from, current)
+	if err != nil {
+		log.Printf("session: %v", err)
+		return ""
+	}
+	return changes.Banner()
+}
+
+// #endregion session-banner
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+
+	pb "github.com/danielpatrickdp/adaptive-state/go-controller/gen/adaptive"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/codec"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/dualwrite"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/state"
+)
+
+// #region evidence-shadow
+
+// openShadow connects to the EVIDENCE_SHADOW_ADDR backend and returns the
+// dual-write wrapper for the daemon's codec client. A protocol mismatch is
+// fatal, as for the primary; an unreachable shadow only costs failed checks.
+func openShadow(addr string, store *state.Store, timeout time.Duration) (func(pb.CodecServiceClient) pb.CodecServiceClient, func() error, string, error) {
+	dw, err := dualwrite.NewStore(store.DB())
+	if err != nil {
+		return nil, nil, "", err
+	}
+	reads, err := dw.Reads()
+	if err != nil {
+		return nil, nil, "", err
+	}
+	conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return nil, nil, "", fmt.Errorf("grpc dial %s: %w", addr, err)
+	}
+	svc := pb.NewCodecServiceClient(conn)
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	if _, err := codec.NewCodecClientWithService(svc).Handshake(ctx); errors.Is(err, codec.ErrProtocolMismatch) {
+		conn.Close()
+		return nil, nil, "", fmt.Errorf("shadow handshake with %s: %w", addr, err)
+	}
+	return dualwrite.Wrap(svc, dw, dualwrite.Config{Reads: reads, Timeout: timeout}), conn.Close, reads, nil
+}
+
+// runShadow implements `controller shadow status|backfill|cutover|revert`, the
+// operator side of evidence dual-write: inspect parity, copy existing evidence
+// to the shadow, and switch the serving backend once parity is demonstrated
+// (or back).
+func runShadow(args []string) int {
+	usage := "usage: controller shadow status|backfill|cutover|revert [--db path] [flags]"
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, usage)
+		return 2
+	}
+	cmd := args[0]
+	fs := flag.NewFlagSet("shadow "+cmd, flag.ContinueOnError)
+	dbPath := fs.String("db", envOr("ADAPTIVE_DB", "adaptive_state.db"), "path to SQLite database")
+	window := fs.Int("window", 500, "most recent checks that count toward parity")
+	minChecks := fs.Int("min-checks", 200, "cutover: checks required in the window")
+	maxMismatch := fs.Float64("max-mismatch", 1, "cutover: highest mismatch-or-error percentage allowed")
+	force := fs.Bool("force", false, "cutover: switch the serving backend even without parity")
+	primaryAddr := fs.String("codec", envOr("CODEC_ADDR", "localhost:50051"), "backfill: primary codec address")
+	shadowAddr := fs.String("shadow", os.Getenv("EVIDENCE_SHADOW_ADDR"), "backfill: shadow codec address")
+	timeout := fs.Duration("timeout", 30*time.Minute, "backfill: overall timeout")
+	if err := fs.Parse(args[1:]); err != nil {
+		return 2
+	}
+
+	store, err := state.NewStore(*dbPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: open store: %v\n", err)
+		return 1
+	}
+	defer store.Close()
+	dw, err := dualwrite.NewStore(store.DB())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+
+	switch cmd {
+	case "status":
+		return shadowStatus(dw, *window)
+	case "backfill":
+		if *shadowAddr == "" {
+			fmt.Fprintln(os.Stderr, "error: set EVIDENCE_SHADOW_ADDR or --shadow")
+			return 2
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+		defer cancel()
+		primaryConn, err := grpc.NewClient(*primaryAddr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: connect codec: %v\n", err)
+			return 1
+		}
+		defer primaryConn.Close()
+		shadowConn, err := grpc.NewClient(*shadowAddr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: connect shadow: %v\n", err)
+			return 1
+		}
+		defer shadowConn.Close()
+		res, err := dualwrite.Backfill(ctx, pb.NewCodecServiceClient(primaryConn), pb.NewCodecServiceClient(shadowConn), dw)
+		fmt.Printf("backfill: %d in primary, %d copied, %d already in shadow\n", res.Total, res.Copied, res.Skipped)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v (re-run to continue)\n", err)
+			return 1
+		}
+		return 0
+	case "cutover":
+		parity, err := dw.Parity(*window)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			return 1
+		}
+		if ok, reason := parity.Ready(*minChecks, *maxMismatch/100); !ok {
+			if !*force {
+				fmt.Fprintf(os.Stderr, "refusing cutover: %s (see `controller shadow status`, or --force)\n", reason)
+				return 1
+			}
+			fmt.Printf("warning: cutover without parity: %s\n", reason)
+		}
+		return setShadowReads(dw, dualwrite.ReadsShadow)
+	case "revert":
+		return setShadowReads(dw, dualwrite.ReadsPrimary)
+	}
+	fmt.Fprintln(os.Stderr, usage)
+	return 2
+}
+
+func shadowStatus(dw *dualwrite.Store, window int) int {
+	reads, err := dw.Reads()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+	mapped, err := dw.Mapped()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+	parity, err := dw.Parity(window)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+	fmt.Printf("reads served by: %s\n", reads)
+	fmt.Printf("evidence with a shadow copy: %d\n", mapped)
+	fmt.Printf("last %d checks: %d match, %d mismatch, %d error (%.2f%% off)\n",
+		parity.Checks, parity.Matches, parity.Mismatches, parity.Errors, parity.MismatchRate()*100)
+	for _, c := range parity.Recent {
+		fmt.Printf("  %s %s %s: %s\n", c.CreatedAt.Format("2006-01-02 15:04:05"), c.Op, c.Outcome, c.Detail)
+	}
+	return 0
+}
+
+func setShadowReads(dw *dualwrite.Store, reads string) int {
+	if err := dw.SetReads(reads); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+	fmt.Printf("evidence now served by the %s backend (reads, first writes and new IDs); restart the controller to apply\n", reads)
+	return 0
+}
+
+// #endregion evidence-shadow
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/logging"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/projection"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/state"
+)
+
+// #region similar
+
+// similarGap keeps /similar from matching the current session: only versions at
+// least this much older than the active state are compared.
+const similarGap = 24 * time.Hour
+
+// similarCommand handles "/similar": the past periods whose state most resembles
+// the active one, with the preferences that dominated each. Returns the reply text.
+func similarCommand(store *state.Store) string {
+	current, err := store.GetCurrent()
+	if err != nil {
+		log.Printf("similar: %v", err)
+		return "Could not load the current state."
+	}
+	matches, err := store.SimilarityIndex().Search(state.SimilarityQuery{
+		Vector: current.StateVector,
+		Before: current.CreatedAt.Add(-similarGap),
+		K:      15,
+	})
+	if err != nil {
+		log.Printf("similar: %v", err)
+		return "Could not search past states."
+	}
+	periods := state.GroupPeriods(matches, state.DefaultPeriodGap)
+	if len(periods) == 0 {
+		return "No earlier state to compare with yet."
+	}
+	if len(periods) > 3 {
+		periods = periods[:3]
+	}
+
+	var b strings.Builder
+	b.WriteString("My state now is closest to:")
+	for i, p := range periods {
+		fmt.Fprintf(&b, "\n  %d. %s (%d versions, similarity %.2f)", i+1, formatPeriod(p), len(p.Versions), p.Best)
+		var blocks []string
+		for _, v := range p.Versions {
+			vp, err := store.GetVersionWithProvenance(v.VersionID)
+			if err != nil {
+				continue
+			}
+			if gr, err := logging.ParseGateRecord(vp.SignalsJSON); err == nil {
+				blocks = append(blocks, gr.StateBlock)
+			}
+		}
+		if prefs := projection.DominantPreferences(blocks, 3); len(prefs) > 0 {
+			fmt.Fprintf(&b, "\n     preferences then: %s", strings.Join(prefs, "; "))
+		}
+	}
+	return b.String()
+}
+
+// formatPeriod renders a period's span in local time, collapsing same-day ranges.
+func formatPeriod(p state.SimilarPeriod) string {
+	start, end := p.Start.Local(), p.End.Local()
+	if start.Equal(end) {
+		return start.Format("2006-01-02 15:04")
+	}
+	if start.Format("2006-01-02") == end.Format("2006-01-02") {
+		return start.Format("2006-01-02 15:04") + "–" + end.Format("15:04")
+	}
+	return start.Format("2006-01-02 15:04") + " – " + end.Format("2006-01-02 15:04")
+}
+
+// #endregion similar
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/codec"
+)
+
+// #region stream
+
+// streamPrinter echoes a generation to the console as it streams, so a long
+// answer is readable before the turn finishes.
+type streamPrinter struct {
+	w       io.Writer
+	header  string
+	onStart func() // called before the first delta, e.g. to stop the codec watchdog
+	printed strings.Builder
+}
+
+func (p *streamPrinter) delta(d string) {
+	if p.printed.Len() == 0 {
+		if p.onStart != nil {
+			p.onStart()
+		}
+		fmt.Fprint(p.w, p.header)
+	}
+	p.printed.WriteString(d)
+	fmt.Fprint(p.w, d)
+}
+
+// finish ends the streamed line. When the final text differs from what was
+// printed (the server retried a think-only reply, or the call failed midway)
+// the difference is noted: final is what the turn goes on with.
+func (p *streamPrinter) finish(final string, err error) {
+	streamed := p.printed.Len() > 0
+	if streamed {
+		fmt.Fprintln(p.w)
+	}
+	switch {
+	case err != nil && streamed:
+		fmt.Fprintln(p.w, "[STREAM] interrupted")
+	case err == nil && strings.TrimSpace(p.printed.String()) != strings.TrimSpace(final):
+		fmt.Fprintf(p.w, "%s%s\n", p.header, final)
+	}
+}
+
+// streamGenerate is GenerateSampled over the streaming RPC, echoing the
+// response to w under label as it arrives. A nil w makes a plain call.
+func streamGenerate(ctx context.Context, c *codec.CodecClient, w io.Writer, label string, onStart func(), prompt string, stateVec [128]float32, evidence []string, s codec.Sampling) (codec.GenerateResult, error) {
+	if w == nil {
+		return c.GenerateSampled(ctx, prompt, stateVec, evidence, nil, s)
+	}
+	p := &streamPrinter{w: w, header: fmt.Sprintf("[STREAM %s] ", label), onStart: onStart}
+	res, err := c.GenerateStream(ctx, prompt, stateVec, evidence, nil, s, p.delta)
+	p.finish(res.Text, err)
+	return res, err
+}
+
+// #endregion stream
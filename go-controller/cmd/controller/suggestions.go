@@ -0,0 +1,94 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/logging"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/projection"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/state"
+)
+
+// #region suggestions
+
+// suggestionRuleConfidence is below the 1.0 given to rules the user states directly,
+// since accepted suggestions originate with the agent.
+const suggestionRuleConfidence = 0.8
+
+// runSuggestionCommand handles "/suggestions", "/suggestions accept <id>" and
+// "/suggestions reject <id>". Accepting applies the rule or preference; both outcomes
+// are recorded in provenance under trigger_type "suggestion", in the same
+// transaction as the decision. Returns the reply text.
+func runSuggestionCommand(args string, suggestions *projection.SuggestionStore, prefs *projection.PreferenceStore,
+	rules *projection.RuleStore, store *state.Store) string {
+	fields := strings.Fields(args)
+	if len(fields) == 0 {
+		pending, err := suggestions.Pending()
+		if err != nil {
+			log.Printf("suggestion store error: %v", err)
+			return "Could not load suggestions."
+		}
+		return projection.FormatSuggestions(pending)
+	}
+
+	if len(fields) != 2 || (fields[0] != "accept" && fields[0] != "reject") {
+		return "Usage: /suggestions [accept|reject <id>]"
+	}
+	id, err := strconv.ParseInt(strings.TrimPrefix(fields[1], "#"), 10, 64)
+	if err != nil {
+		return fmt.Sprintf("Invalid suggestion id %q.", fields[1])
+	}
+	accept := fields[0] == "accept"
+
+	versionID := ""
+	if current, err := store.GetCurrent(); err == nil {
+		versionID = current.VersionID
+	}
+	// The rule or preference is applied before the suggestion is marked
+	// accepted, and the mark and its provenance land with it or not at all
+	var sg projection.Suggestion
+	decision := "reject"
+	if accept {
+		decision = "commit"
+	}
+	if err := store.WithTx(func(tx *sql.Tx) error {
+		txSuggestions := suggestions.WithTx(tx)
+		pending, err := txSuggestions.GetPending(id)
+		if err != nil {
+			return err
+		}
+		if accept {
+			switch pending.Kind {
+			case projection.SuggestRule:
+				err = rules.WithTx(tx).Add(pending.Trigger, pending.Response, 5, suggestionRuleConfidence)
+			default:
+				err = prefs.WithTx(tx).Add(pending.Text, "inferred")
+			}
+			if err != nil {
+				return fmt.Errorf("store it: %w", err)
+			}
+		}
+		if sg, err = txSuggestions.Decide(id, accept); err != nil {
+			return err
+		}
+		return logging.LogDecision(tx, logging.ProvenanceEntry{
+			VersionID:   versionID,
+			TriggerType: "suggestion",
+			Decision:    decision,
+			Reason:      fmt.Sprintf("suggestion #%d from turn %s %s: %s", id, sg.TurnID, sg.Status, sg.Describe()),
+		})
+	}); err != nil {
+		log.Printf("suggestion %d %s: %v", id, fields[0], err)
+		return fmt.Sprintf("Could not %s suggestion #%d: %v", fields[0], id, err)
+	}
+	log.Printf("suggestion #%d %s: %s", id, sg.Status, sg.Describe())
+	if accept {
+		return fmt.Sprintf("Accepted suggestion #%d: %s", id, sg.Describe())
+	}
+	return fmt.Sprintf("Rejected suggestion #%d. I won't propose it again.", id)
+}
+
+// #endregion suggestions
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"log"
+	"time"
+
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/events"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/state"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/telemetry"
+)
+
+// #region telemetry
+
+// telemetryRunner records a text-free sample of every turn and, while idle,
+// writes periodic summaries to TELEMETRY_DIR. A nil *telemetryRunner does
+// nothing, which is the default: telemetry is opt-in.
+type telemetryRunner struct {
+	store    *state.Store
+	samples  *telemetry.Store
+	dir      string
+	interval time.Duration
+}
+
+// newTelemetry opens the telemetry store for summaries in dir every interval.
+func newTelemetry(store *state.Store, dir string, interval time.Duration) (*telemetryRunner, error) {
+	samples, err := telemetry.NewStore(store.DB())
+	if err != nil {
+		return nil, err
+	}
+	return &telemetryRunner{store: store, samples: samples, dir: dir, interval: interval}, nil
+}
+
+// observe records ev's decision, turn type, latency and norms, never its text.
+func (t *telemetryRunner) observe(ev events.TurnEvent) {
+	if t == nil {
+		return
+	}
+	sample := telemetry.Sample{At: ev.Time, Decision: ev.Decision, LatencyMs: ev.LatencyMs}
+	if ev.Classification != nil {
+		sample.TurnType = ev.Classification.Type
+	}
+	if ev.Gate != nil {
+		sample.DeltaNorm = float64(ev.Gate.DeltaNorm)
+	}
+	if current, err := t.store.GetCurrent(); err == nil {
+		sample.Norms = current.SegmentMap.Norms(current.StateVector)
+	}
+	if err := t.samples.Record(sample); err != nil {
+		log.Printf("[%s] telemetry: %v", ev.TurnID, err)
+	}
+}
+
+// due reports whether a summary should be written now.
+func (t *telemetryRunner) due() bool {
+	if t == nil {
+		return false
+	}
+	due, err := t.samples.Due(time.Now().UTC(), t.interval)
+	if err != nil {
+		log.Printf("telemetry schedule error: %v", err)
+	}
+	return due
+}
+
+// summarize writes the summary of the samples since the last one and prunes
+// the samples it covered.
+func (t *telemetryRunner) summarize() {
+	until := time.Now().UTC()
+	since, ok, err := t.samples.WindowStart()
+	if err != nil {
+		log.Printf("telemetry summary error: %v", err)
+		return
+	}
+	if !ok {
+		return
+	}
+	samples, err := t.samples.Samples(since, until)
+	if err != nil {
+		log.Printf("telemetry summary error: %v", err)
+		return
+	}
+	sum := telemetry.Summarize(samples, since, until)
+	path, err := telemetry.Write(t.dir, sum)
+	if err != nil {
+		log.Printf("telemetry summary error: %v", err)
+		return
+	}
+	if err := t.samples.RecordSummary(sum, path); err != nil {
+		log.Printf("telemetry summary error: %v", err)
+		return
+	}
+	if _, err := t.samples.Prune(until); err != nil {
+		log.Printf("telemetry prune error: %v", err)
+	}
+	log.Printf("telemetry: %d turns summarized to %s (latency p50 %.0fms, p99 %.0fms)",
+		sum.Turns, path, sum.LatencyMs.P50, sum.LatencyMs.P99)
+}
+
+// #endregion telemetry
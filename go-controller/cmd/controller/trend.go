@@ -0,0 +1,91 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// #region trend-buffer
+
+// sparkBlocks are the eight sparkline glyphs, lowest to highest.
+var sparkBlocks = []rune("▁▂▃▄▅▆▇█")
+
+// trendBuffer is a fixed-size ring buffer of recent gate soft scores and delta norms,
+// rendered as a compact inline sparkline after each turn.
+type trendBuffer struct {
+	scores []float32
+	deltas []float32
+	size   int
+	next   int
+	count  int
+}
+
+func newTrendBuffer(size int) *trendBuffer {
+	return &trendBuffer{
+		scores: make([]float32, size),
+		deltas: make([]float32, size),
+		size:   size,
+	}
+}
+
+// push records one turn's soft score and delta norm, overwriting the oldest entry when full.
+func (t *trendBuffer) push(softScore, deltaNorm float32) {
+	t.scores[t.next] = softScore
+	t.deltas[t.next] = deltaNorm
+	t.next = (t.next + 1) % t.size
+	if t.count < t.size {
+		t.count++
+	}
+}
+
+// ordered returns buffered values oldest-first.
+func (t *trendBuffer) ordered(buf []float32) []float32 {
+	out := make([]float32, 0, t.count)
+	start := (t.next - t.count + t.size) % t.size
+	for i := 0; i < t.count; i++ {
+		out = append(out, buf[(start+i)%t.size])
+	}
+	return out
+}
+
+// render returns a one-line trend summary, e.g. "trend score ▃▅▇ 0.71 | delta ▁▁▂ 0.0123".
+// Soft scores use a fixed 0-1 scale; delta norms scale to the buffered maximum.
+func (t *trendBuffer) render() string {
+	if t.count == 0 {
+		return ""
+	}
+	scores := t.ordered(t.scores)
+	deltas := t.ordered(t.deltas)
+
+	var maxDelta float32
+	for _, d := range deltas {
+		if d > maxDelta {
+			maxDelta = d
+		}
+	}
+	return fmt.Sprintf("  trend score %s %.2f | delta %s %.4f",
+		sparkline(scores, 1.0), scores[len(scores)-1],
+		sparkline(deltas, maxDelta), deltas[len(deltas)-1])
+}
+
+// sparkline maps each value in [0, max] to a block glyph. max <= 0 renders the lowest glyph.
+func sparkline(vals []float32, max float32) string {
+	var b strings.Builder
+	top := len(sparkBlocks) - 1
+	for _, v := range vals {
+		idx := 0
+		if max > 0 {
+			idx = int(v / max * float32(top))
+		}
+		if idx < 0 {
+			idx = 0
+		}
+		if idx > top {
+			idx = top
+		}
+		b.WriteRune(sparkBlocks[idx])
+	}
+	return b.String()
+}
+
+// #endregion trend-buffer
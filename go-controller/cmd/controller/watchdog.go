@@ -0,0 +1,138 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"sync"
+	"time"
+)
+
+// #region turn-cancel
+
+// turnCanceller makes Ctrl+C cancel the in-flight turn instead of killing the daemon.
+// While a turn is active, an interrupt cancels its context; while idle, an interrupt
+// requests shutdown so deferred cleanup (DB, codec connection) still runs.
+type turnCanceller struct {
+	mu       sync.Mutex
+	cancel   context.CancelFunc
+	shutdown chan struct{}
+	once     sync.Once
+}
+
+// newTurnCanceller installs the SIGINT handler.
+func newTurnCanceller() *turnCanceller {
+	tc := &turnCanceller{shutdown: make(chan struct{})}
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, os.Interrupt)
+	go func() {
+		for range sigs {
+			tc.interrupt()
+		}
+	}()
+	return tc
+}
+
+func (tc *turnCanceller) interrupt() {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+	if tc.cancel != nil {
+		tc.cancel()
+		tc.cancel = nil
+		fmt.Println("\n[CANCEL] current turn cancelled (Ctrl+C again while idle to exit)")
+		log.Printf("turn cancelled by interrupt")
+		return
+	}
+	tc.once.Do(func() { close(tc.shutdown) })
+}
+
+// Begin starts a new cancellable turn, ending any previous one.
+func (tc *turnCanceller) Begin() context.Context {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+	if tc.cancel != nil {
+		tc.cancel()
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	tc.cancel = cancel
+	return ctx
+}
+
+// End releases the current turn; later interrupts request shutdown.
+func (tc *turnCanceller) End() {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+	if tc.cancel != nil {
+		tc.cancel()
+		tc.cancel = nil
+	}
+}
+
+// Idle starts a background job on the idle loop, bounded by timeout. The job's
+// context is also cancelled as soon as waiting reports an inbox message (checked
+// every poll), so a long job gives way to the next turn instead of delaying it.
+func (tc *turnCanceller) Idle(timeout, poll time.Duration, waiting func() bool) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithTimeout(tc.Begin(), timeout)
+	go func() {
+		ticker := time.NewTicker(poll)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if waiting() {
+					log.Printf("idle job cancelled: a message is waiting")
+					cancel()
+					return
+				}
+			}
+		}
+	}()
+	return ctx, cancel
+}
+
+// Sleep ends the current turn and waits d. Returns false if shutdown was requested.
+func (tc *turnCanceller) Sleep(d time.Duration) bool {
+	tc.End()
+	select {
+	case <-tc.shutdown:
+		return false
+	case <-time.After(d):
+		return true
+	}
+}
+
+// #endregion turn-cancel
+
+// #region watchdog
+
+// watchCodec reports progress on a slow codec call every interval until the
+// returned stop func is called. interval <= 0 disables reporting.
+func watchCodec(turnID, label string, interval time.Duration) (stop func()) {
+	if interval <= 0 {
+		return func() {}
+	}
+	start := time.Now()
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				waited := time.Since(start).Round(time.Second)
+				fmt.Printf("[WAIT] waiting on codec %s… %s (Ctrl+C cancels this turn)\n", label, waited)
+				log.Printf("[%s] watchdog: codec %s still pending after %s", turnID, label, waited)
+			}
+		}
+	}()
+	var once sync.Once
+	return func() { once.Do(func() { close(done) }) }
+}
+
+// #endregion watchdog
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/state"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/transcript"
+	_ "modernc.org/sqlite"
+)
+
+// #region main
+
+func main() {
+	def := transcript.DefaultCriteria()
+	dbPath := flag.String("db", "", "path to adaptive_state.db")
+	outPath := flag.String("out", "", "output path (default stdout)")
+	minScore := flag.Float64("min-score", float64(def.MinSoftScore), "minimum gate soft score (0-1)")
+	minCompliance := flag.Float64("min-compliance", float64(def.MinCompliance), "minimum preference compliance (0-1, 0.5 neutral)")
+	all := flag.Bool("all", false, "include turns already exported by an earlier run")
+	dryRun := flag.Bool("dry-run", false, "write the dataset but do not record the turns as exported")
+	withMeta := flag.Bool("metadata", false, "keep per-message metadata (version IDs, gate scores)")
+	flag.Parse()
+
+	if *dbPath == "" {
+		fmt.Fprintln(os.Stderr, "usage: finetune-export --db path/to/db [--out path] [--min-score 0.7] [--min-compliance 0.5] [--all] [--dry-run] [--metadata]")
+		os.Exit(2)
+	}
+
+	c := transcript.Criteria{MinSoftScore: float32(*minScore), MinCompliance: float32(*minCompliance)}
+	if err := run(*dbPath, *outPath, c, *all, *dryRun, *withMeta); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// #endregion main
+
+// #region export
+
+func run(dbPath, outPath string, c transcript.Criteria, all, dryRun, withMeta bool) error {
+	store, err := state.NewStore(dbPath)
+	if err != nil {
+		return fmt.Errorf("open db: %w", err)
+	}
+	defer store.Close()
+
+	exports, err := transcript.NewExportLog(store.DB())
+	if err != nil {
+		return err
+	}
+	exported := map[int64]bool{}
+	if !all {
+		if exported, err = exports.Exported(); err != nil {
+			return err
+		}
+	}
+	turns, err := transcript.LoadTurns(store.DB(), 0)
+	if err != nil {
+		return err
+	}
+	selected := transcript.Select(turns, c, exported)
+	if len(selected) == 0 {
+		fmt.Fprintf(os.Stderr, "no new turns qualify (%d logged, %d exported before)\n", len(turns), len(exported))
+		return nil
+	}
+
+	var w io.Writer = os.Stdout
+	var f *os.File
+	if outPath != "" {
+		if f, err = os.Create(outPath); err != nil {
+			return fmt.Errorf("create %s: %w", outPath, err)
+		}
+		defer f.Close()
+		w = f
+	}
+	if err := transcript.WriteJSONL(w, selected, withMeta); err != nil {
+		return err
+	}
+	// A turn is only marked exported once its line is safely on disk
+	if f != nil {
+		if err := f.Close(); err != nil {
+			return fmt.Errorf("close %s: %w", outPath, err)
+		}
+	}
+
+	batch := time.Now().UTC().Format("20060102T150405Z")
+	if !dryRun {
+		if err := exports.Mark(selected, batch); err != nil {
+			return err
+		}
+	}
+	fmt.Fprintf(os.Stderr, "Exported %d of %d turns (min score %.2f, min compliance %.2f)", len(selected), len(turns), c.MinSoftScore, c.MinCompliance)
+	if dryRun {
+		fmt.Fprintln(os.Stderr, "; dry run, not recorded")
+	} else {
+		fmt.Fprintf(os.Stderr, "; recorded as batch %s\n", batch)
+	}
+	return nil
+}
+
+// #endregion export
@@ -3,10 +3,10 @@ package main
 import (
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"os"
-	"strings"
 
 	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/logging"
 	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/replay"
@@ -92,12 +92,16 @@ func run(dbPath string, last int, outPath string) error {
 			continue
 		}
 
-		var gr logging.GateRecord
-		if err := json.Unmarshal([]byte(sigJSON.String), &gr); err != nil {
+		gr, err := logging.ParseGateRecord(sigJSON.String)
+		if errors.Is(err, logging.ErrNotGateRecord) {
+			continue // not GateRecord format
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "skipping row: %v\n", err)
 			continue
 		}
-		if gr.TurnID == "" {
-			continue // not GateRecord format
+		if gr.Private {
+			continue // private turn: redacted marker without inputs
 		}
 
 		reasonStr := ""
@@ -136,26 +140,11 @@ func buildFixture(startState state.StateRecord, rows []gateRow) replay.Fixture {
 	expected := make([]replay.FixtureExpectedResult, len(rows))
 
 	for i, r := range rows {
-		interactions[i] = replay.FixtureInteraction{
-			TurnID:       r.Record.TurnID,
-			Prompt:       r.Record.Prompt,
-			ResponseText: r.Record.Response,
-			Entropy:      r.Record.Entropy,
-			Signals: replay.FixtureSignals{
-				SentimentScore:      r.Record.Signals.SentimentScore,
-				NoveltyScore:        r.Record.Signals.NoveltyScore,
-				CoherenceScore:      r.Record.Signals.CoherenceScore,
-				RiskFlag:            r.Record.Signals.RiskFlag,
-				UserCorrection:      r.Record.Signals.UserCorrection,
-				ToolFailure:         r.Record.Signals.ToolFailure,
-				ConstraintViolation: r.Record.Signals.ConstraintViolation,
-			},
-			Evidence: []string{},
-		}
+		interactions[i] = replay.FixtureInteractionFrom(r.Record, nil)
 
 		expected[i] = replay.FixtureExpectedResult{
 			TurnID: r.Record.TurnID,
-			Action: mapAction(r.Decision, r.Reason),
+			Action: replay.ExpectedAction(r.Decision, r.Reason),
 		}
 	}
 
@@ -179,11 +168,13 @@ func buildFixture(startState state.StateRecord, rows []gateRow) replay.Fixture {
 				MaxStateNorm:   th.MaxStateNorm,
 				MinEntropyDrop: 0.1,
 				RiskSegmentCap: th.RiskSegmentCap,
+				SegmentCaps:    th.SegmentCaps,
 			},
 			EvalConfig: replay.FixtureEvalConfig{
 				MaxStateNorm:    th.MaxStateNorm,
 				MaxSegmentNorm:  th.MaxSegmentNorm,
 				EntropyBaseline: 2.0,
+				SegmentNorms:    th.SegmentNorms,
 			},
 		},
 		Interactions:    interactions,
@@ -193,23 +184,6 @@ func buildFixture(startState state.StateRecord, rows []gateRow) replay.Fixture {
 	return fixture
 }
 
-// mapAction converts DB decision + reason to fixture action string.
-func mapAction(decision, reason string) string {
-	switch decision {
-	case "commit":
-		return "commit"
-	case "reject":
-		if strings.Contains(reason, "eval rollback") {
-			return "eval_rollback"
-		}
-		return "gate_reject"
-	case "no_op":
-		return "no_op"
-	default:
-		return decision
-	}
-}
-
 func writeFixture(fixture replay.Fixture, outPath string) error {
 	data, err := json.MarshalIndent(fixture, "", "  ")
 	if err != nil {
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/evidence"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/graph"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/state"
+	_ "modernc.org/sqlite"
+)
+
+// #region main
+
+func main() {
+	dbPath := flag.String("db", "", "path to adaptive_state.db")
+	format := flag.String("format", "gexf", "gexf (Gephi) | dot (GraphViz)")
+	outPath := flag.String("out", "", "output path (default stdout)")
+	types := flag.String("type", "", "comma-separated edge types to keep (default all)")
+	minWeight := flag.Float64("min-weight", 0, "drop edges below this weight")
+	around := flag.String("around", "", "only the neighbourhood of this evidence ID")
+	depth := flag.Int("depth", 2, "hops from --around, in either direction")
+	snippet := flag.Int("snippet", 60, "runes of evidence text in node labels")
+	flag.Parse()
+
+	if *dbPath == "" || (*format != "gexf" && *format != "dot") {
+		fmt.Fprintln(os.Stderr, "usage: graph-export --db path/to/db [--format gexf|dot] [--out path] [--type t1,t2] [--min-weight W] [--around ev_ID [--depth N]] [--snippet N]")
+		os.Exit(2)
+	}
+
+	filter := graph.ExportFilter{MinWeight: *minWeight, Around: *around, Depth: *depth}
+	for _, t := range strings.Split(*types, ",") {
+		if t = strings.TrimSpace(t); t != "" {
+			if _, ok := graph.LookupEdgeType(t); !ok {
+				fmt.Fprintf(os.Stderr, "warning: edge type %q is not registered\n", t)
+			}
+			filter.Types = append(filter.Types, t)
+		}
+	}
+
+	if err := run(*dbPath, *format, *outPath, filter, *snippet); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// #endregion main
+
+// #region export
+
+func run(dbPath, format, outPath string, filter graph.ExportFilter, snippet int) error {
+	store, err := state.NewStore(dbPath)
+	if err != nil {
+		return fmt.Errorf("open db: %w", err)
+	}
+	defer store.Close()
+
+	graphStore, err := graph.NewGraphStore(store.DB())
+	if err != nil {
+		return fmt.Errorf("open graph: %w", err)
+	}
+	edges, err := graphStore.Subgraph(filter)
+	if err != nil {
+		return err
+	}
+	if len(edges) == 0 {
+		if filter.Around != "" {
+			return fmt.Errorf("no matching edges around %s", filter.Around)
+		}
+		return fmt.Errorf("no matching edges")
+	}
+	ids := graph.NodeIDs(edges)
+	nodes, err := loadNodes(store, ids)
+	if err != nil {
+		return err
+	}
+
+	var w io.Writer = os.Stdout
+	if outPath != "" {
+		f, err := os.Create(outPath)
+		if err != nil {
+			return fmt.Errorf("create %s: %w", outPath, err)
+		}
+		defer f.Close()
+		w = f
+	}
+
+	x := graph.Export{Edges: edges, Nodes: nodes, Center: filter.Around, SnippetLen: snippet}
+	if format == "dot" {
+		err = x.WriteDOT(w)
+	} else {
+		err = x.WriteGEXF(w)
+	}
+	if err != nil {
+		return err
+	}
+	if outPath != "" {
+		fmt.Printf("Wrote %d nodes (%d with text) and %d edges to %s (%s)\n", len(ids), withText(nodes), len(edges), outPath, format)
+	}
+	return nil
+}
+
+// loadNodes reads node metadata from the local evidence store, falling back
+// to the raw archive for the text of items it does not hold. Evidence kept
+// only by the Python service has no text here; those nodes export by ID.
+func loadNodes(store *state.Store, ids []string) (map[string]graph.NodeMeta, error) {
+	nodes := map[string]graph.NodeMeta{}
+	local, err := evidence.HasLocalEvidence(store.DB())
+	if err != nil {
+		return nil, err
+	}
+	if local {
+		ev, err := evidence.NewSQLiteStore(store.DB())
+		if err != nil {
+			return nil, err
+		}
+		items, err := ev.Get(context.Background(), ids)
+		if err != nil {
+			return nil, fmt.Errorf("load evidence: %w", err)
+		}
+		for _, it := range items {
+			ann := evidence.ParseAnnotation(it.MetadataJSON)
+			nodes[it.ID] = graph.NodeMeta{Text: it.Text, CreatedAt: it.CreatedAt, Pinned: ann.Pinned, Note: ann.Note}
+		}
+	}
+
+	archive, err := evidence.NewRawArchive(store.DB())
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range ids {
+		if _, ok := nodes[id]; ok {
+			continue
+		}
+		text, ok, err := archive.Get(id)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			nodes[id] = graph.NodeMeta{Text: text}
+		}
+	}
+	return nodes, nil
+}
+
+// withText counts the nodes that have evidence text.
+func withText(nodes map[string]graph.NodeMeta) int {
+	n := 0
+	for _, m := range nodes {
+		if m.Text != "" {
+			n++
+		}
+	}
+	return n
+}
+
+// #endregion export
@@ -1,32 +1,116 @@
 package main
 
 import (
+	"context"
+	"database/sql"
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"math"
 	"os"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/eval"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/evidence"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/gate"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/lineage"
 	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/logging"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/projection"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/rulestats"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/settings"
 	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/state"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/timestamp"
 	_ "modernc.org/sqlite"
 )
 
 // #region main
 
+// display renders timestamps, set from --tz and --locale. JSON output ignores
+// --locale and stays ISO 8601 (with the --tz offset).
+var display timestamp.Display
+
 func main() {
 	dbPath := flag.String("db", "", "path to adaptive_state.db")
 	last := flag.Int("last", 20, "show N most recent versions")
 	version := flag.String("version", "", "show single version detail")
 	segment := flag.String("segment", "", "filter segment breakdown to one segment")
 	jsonOut := flag.Bool("json", false, "output as JSON instead of table")
+	vetoes := flag.Bool("vetoes", false, "group gate veto rejections by type")
+	detections := flag.Bool("detections", false, "preference/rule/identity detector precision from confirmation samples")
+	since := flag.String("since", "7d", "with --vetoes/--detections/--usage/--rules: window, e.g. 7d, 24h; with provenance filters: also a date (2024-06-01) or RFC3339 time")
+	samples := flag.Int("samples", 3, "with --vetoes/--detections: sampled prompts per veto type or denials per detector")
+	markFP := flag.Int64("mark-fp", 0, "mark provenance entry ID as a false-positive veto")
+	markOK := flag.Int64("mark-ok", 0, "mark provenance entry ID as a correct veto")
+	note := flag.String("note", "", "with --mark-fp/--mark-ok: optional review note")
+	byModel := flag.Bool("by-model", false, "group the listed versions by backing model (drift per model)")
+	similar := flag.Int("similar", 0, "show N past periods whose state was most similar to the current one (or --version)")
+	gap := flag.String("gap", "24h", "with --similar: ignore versions newer than this relative to the query")
+	evidenceList := flag.Bool("evidence", false, "list the N most recent items in the controller's local evidence store (CODEC_BACKEND=ollama)")
+	usage := flag.Bool("usage", false, "heatmap of retrieval hits per evidence item over --since: the N most- and least-used memories")
+	buckets := flag.Int("buckets", 7, "with --usage: time slices in the heatmap")
+	lineageMode := flag.Bool("lineage", false, "tree of the last N versions by parent, with branches, rollbacks and active pointer moves")
+	dot := flag.Bool("dot", false, "with --lineage: GraphViz DOT instead of the tree")
+	prefs := flag.Bool("prefs", false, "active preferences and the replaced or deleted ones in preference_history (last N)")
+	deletePref := flag.Int("delete-pref", 0, "delete active preference ID (kept in preference_history)")
+	restorePref := flag.Int("restore-pref", 0, "restore replaced or deleted preference ID")
+	settingsMode := flag.Bool("settings", false, "runtime-learned settings and their last N changes")
+	settingKey := flag.String("setting", "", "with --settings: only this key's changes")
+	resetSetting := flag.String("reset-setting", "", "reset a runtime-learned setting to its default (kept in settings_history)")
+	rules := flag.Bool("rules", false, "rule effectiveness over --since: firings, gate outcomes, corrections and compliance before/after each rule was learned")
+	decision := flag.String("decision", "", "list provenance entries with this decision (commit, reject, no_op)")
+	trigger := flag.String("trigger", "", "list provenance entries with this trigger type (e.g. user_turn)")
+	vetoType := flag.String("veto-type", "", "list provenance entries rejected by this hard veto type (e.g. safety_violation)")
+	segmentHit := flag.String("segment-hit", "", "list provenance entries whose update touched this segment")
+	fromID := flag.Int64("from-id", 0, "list provenance entries from this ID on (turn range)")
+	toID := flag.Int64("to-id", 0, "list provenance entries up to this ID (turn range)")
+	until := flag.String("until", "", "list provenance entries before this date, RFC3339 time or age (e.g. 2024-06-08, 1d)")
+	before := flag.Int64("before", 0, "with provenance filters: page cursor, entries older than this ID")
+	lenient := flag.Bool("lenient", false, "read damaged rows (zero-fill short vectors) instead of failing on them")
+	tz := flag.String("tz", "UTC", "time zone for displayed times and --since/--until dates: UTC, Local or an IANA name (e.g. Europe/Berlin)")
+	locale := flag.String("locale", "iso", "date/time layout for displayed times: "+strings.Join(timestamp.Locales(), ", "))
 	flag.Parse()
 
+	// Provenance filters select query mode; --since joins them only when given
+	sinceSet, queryMode := false, false
+	flag.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "since":
+			sinceSet = true
+		case "decision", "trigger", "veto-type", "segment-hit", "from-id", "to-id", "until", "before":
+			queryMode = true
+		}
+	})
+
 	if *dbPath == "" {
-		fmt.Fprintln(os.Stderr, "usage: inspect --db path/to/adaptive_state.db [--last N] [--version id] [--segment name] [--json]")
+		fmt.Fprintln(os.Stderr, "usage: inspect --db path/to/adaptive_state.db [--last N] [--version id] [--segment name] [--by-model] [--json]")
+		fmt.Fprintln(os.Stderr, "       inspect --db path/to/adaptive_state.db --vetoes [--since 7d] [--samples N] [--json]")
+		fmt.Fprintln(os.Stderr, "       inspect --db path/to/adaptive_state.db --mark-fp id|--mark-ok id [--note text]")
+		fmt.Fprintln(os.Stderr, "       inspect --db path/to/adaptive_state.db --detections [--since 7d] [--samples N] [--json]")
+		fmt.Fprintln(os.Stderr, "       inspect --db path/to/adaptive_state.db --similar N [--version id] [--segment name] [--gap 24h] [--json]")
+		fmt.Fprintln(os.Stderr, "       inspect --db path/to/adaptive_state.db --evidence [--last N] [--json]")
+		fmt.Fprintln(os.Stderr, "       inspect --db path/to/adaptive_state.db --usage [--since 30d] [--buckets N] [--last N] [--json]")
+		fmt.Fprintln(os.Stderr, "       inspect --db path/to/adaptive_state.db --rules [--since 7d] [--json]")
+		fmt.Fprintln(os.Stderr, "       inspect --db path/to/adaptive_state.db --lineage [--last N] [--dot|--json]")
+		fmt.Fprintln(os.Stderr, "       inspect --db path/to/adaptive_state.db --prefs [--last N] [--json] | --delete-pref id | --restore-pref id")
+		fmt.Fprintln(os.Stderr, "       inspect --db path/to/adaptive_state.db --settings [--setting key] [--last N] [--json] | --reset-setting key")
+		fmt.Fprintln(os.Stderr, "       inspect --db path/to/adaptive_state.db --decision reject [--trigger t] [--veto-type t] [--segment-hit s] [--since 2024-06-01] [--until 2024-06-08] [--from-id N] [--to-id N] [--last N] [--before id] [--json]")
+		fmt.Fprintln(os.Stderr, "       any mode: [--tz Europe/Berlin] [--locale en-GB] to render times in a zone and locale")
+		os.Exit(2)
+	}
+
+	d, err := timestamp.NewDisplay(*tz, *locale)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
 		os.Exit(2)
 	}
+	if *jsonOut {
+		d.Layout = ""
+	}
+	display = d
 
 	store, err := state.NewStore(*dbPath)
 	if err != nil {
@@ -34,14 +118,85 @@ func main() {
 		os.Exit(1)
 	}
 	defer store.Close()
+	if *lenient {
+		store.SetDecodeMode(state.DecodeLenient)
+	}
 
-	if *version != "" {
+	if *markFP != 0 || *markOK != 0 {
+		if err := runMarkVeto(store, *markFP, *markOK, *note); err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(1)
+		}
+	} else if queryMode {
+		f := logging.ProvenanceFilter{
+			Decision: *decision, TriggerType: *trigger, VetoType: *vetoType, SegmentHit: *segmentHit,
+			FromID: *fromID, ToID: *toID, Limit: *last, BeforeID: *before,
+		}
+		if sinceSet {
+			f.Since, err = parseTimeBound("--since", *since)
+		}
+		if err == nil && *until != "" {
+			f.Until, err = parseTimeBound("--until", *until)
+		}
+		if err == nil {
+			err = runQueryMode(store, f, *jsonOut)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(1)
+		}
+	} else if *vetoes {
+		if err := runVetoMode(store, *since, *samples, *jsonOut); err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(1)
+		}
+	} else if *detections {
+		if err := runDetectionMode(store, *since, *samples, *jsonOut); err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(1)
+		}
+	} else if *evidenceList {
+		if err := runEvidenceMode(store, *last, *jsonOut); err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(1)
+		}
+	} else if *usage {
+		if err := runUsageMode(store, *since, *buckets, *last, *jsonOut); err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(1)
+		}
+	} else if *lineageMode {
+		if err := runLineageMode(store, *last, *dot, *jsonOut); err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(1)
+		}
+	} else if *prefs || *deletePref != 0 || *restorePref != 0 {
+		if err := runPrefsMode(store, *deletePref, *restorePref, *last, *jsonOut); err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(1)
+		}
+	} else if *settingsMode || *settingKey != "" || *resetSetting != "" {
+		if err := runSettingsMode(store, *settingKey, *resetSetting, *last, *jsonOut); err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(1)
+		}
+	} else if *rules {
+		if err := runRulesMode(store, *since, *jsonOut); err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(1)
+		}
+	} else if *similar > 0 {
+		if err := runSimilarMode(store, *similar, *version, *segment, *gap, *jsonOut); err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(1)
+		}
+	} else if *version != "" {
 		if err := runDetailMode(store, *version, *segment, *jsonOut); err != nil {
 			fmt.Fprintf(os.Stderr, "error: %v\n", err)
 			os.Exit(1)
 		}
 	} else {
-		if err := runListMode(store, *last, *segment, *jsonOut); err != nil {
+		if err := runListMode(store, *last, *segment, *byModel, *jsonOut); err != nil {
 			fmt.Fprintf(os.Stderr, "error: %v\n", err)
 			os.Exit(1)
 		}
@@ -61,10 +216,13 @@ type listRow struct {
 	Score     float32            `json:"score"`
 	CreatedAt string             `json:"created_at"`
 	Segments  map[string]float64 `json:"segments"`
+	Limits    map[string]float64 `json:"limits"`
 	SegNorm   *float64           `json:"seg_norm,omitempty"`
+	Model     string             `json:"model,omitempty"`
+	Entropy   *float64           `json:"entropy,omitempty"`
 }
 
-func runListMode(store *state.Store, last int, segFilter string, jsonOut bool) error {
+func runListMode(store *state.Store, last int, segFilter string, byModel, jsonOut bool) error {
 	versions, err := store.ListVersionsWithProvenance(last)
 	if err != nil {
 		return err
@@ -84,13 +242,20 @@ func runListMode(store *state.Store, last int, segFilter string, jsonOut bool) e
 			Decision:  vp.Decision,
 			Reason:    vp.Reason,
 			Score:     verifierScore(vp.Decision, vp.Reason),
-			CreatedAt: vp.CreatedAt.Format("2006-01-02T15:04:05Z"),
+			CreatedAt: display.Format(vp.CreatedAt),
 			Segments:  segs,
 		}
-		if gr := parseGateRecord(vp.SignalsJSON); gr != nil {
+		gr := parseGateRecord(vp.SignalsJSON)
+		if gr != nil {
 			dn := float64(gr.DeltaNorm)
 			lr.DeltaNorm = &dn
+			ent := float64(gr.Entropy)
+			lr.Entropy = &ent
+			if gr.Model != "" {
+				lr.Model = logging.ModelLabel(gr.Model, gr.ModelVersion)
+			}
 		}
+		lr.Limits = segmentLimits(gr)
 		if segFilter != "" {
 			if v, ok := segs[segFilter]; ok {
 				lr.SegNorm = &v
@@ -99,6 +264,14 @@ func runListMode(store *state.Store, last int, segFilter string, jsonOut bool) e
 		listRows[len(versions)-1-i] = lr
 	}
 
+	if byModel {
+		groups := groupByModel(listRows)
+		if jsonOut {
+			return printJSON(groups)
+		}
+		printModelGroups(groups)
+		return nil
+	}
 	if jsonOut {
 		return printJSON(listRows)
 	}
@@ -139,12 +312,94 @@ func printListTable(rows []listRow, segFilter string) error {
 
 	latest := rows[len(rows)-1]
 	fmt.Printf("\nSegment norms (latest):\n")
-	printSegments(latest.Segments, "")
+	printSegments(latest.Segments, latest.Limits, "")
 	return nil
 }
 
 // #endregion list-mode
 
+// #region model-mode
+
+// modelGroup summarizes drift over the versions one backing model produced.
+type modelGroup struct {
+	Model          string  `json:"model"`
+	Turns          int     `json:"turns"`
+	Commits        int     `json:"commits"`
+	Rejects        int     `json:"rejects"`
+	MeanDeltaNorm  float64 `json:"mean_delta_norm"`
+	MeanEntropy    float64 `json:"mean_entropy"`
+	MeanScore      float64 `json:"mean_score"`
+	StateNormStart float64 `json:"state_norm_start"`
+	StateNormEnd   float64 `json:"state_norm_end"`
+	FirstSeen      string  `json:"first_seen"`
+	LastSeen       string  `json:"last_seen"`
+}
+
+// groupByModel folds chronological rows into one group per model, in order of
+// first appearance. Rows without model metadata group under logging.UnknownModel.
+func groupByModel(rows []listRow) []modelGroup {
+	var groups []modelGroup
+	index := map[string]int{}
+	deltas := map[string]int{}
+	entropies := map[string]int{}
+	for _, r := range rows {
+		model := r.Model
+		if model == "" {
+			model = logging.UnknownModel
+		}
+		i, ok := index[model]
+		if !ok {
+			i = len(groups)
+			index[model] = i
+			groups = append(groups, modelGroup{Model: model, StateNormStart: r.StateNorm, FirstSeen: r.CreatedAt})
+		}
+		g := &groups[i]
+		g.Turns++
+		switch r.Decision {
+		case "commit":
+			g.Commits++
+		case "reject":
+			g.Rejects++
+		}
+		if r.DeltaNorm != nil {
+			g.MeanDeltaNorm += *r.DeltaNorm
+			deltas[model]++
+		}
+		if r.Entropy != nil {
+			g.MeanEntropy += *r.Entropy
+			entropies[model]++
+		}
+		g.MeanScore += float64(r.Score)
+		g.StateNormEnd = r.StateNorm
+		g.LastSeen = r.CreatedAt
+	}
+	for i := range groups {
+		g := &groups[i]
+		if n := deltas[g.Model]; n > 0 {
+			g.MeanDeltaNorm /= float64(n)
+		}
+		if n := entropies[g.Model]; n > 0 {
+			g.MeanEntropy /= float64(n)
+		}
+		g.MeanScore /= float64(g.Turns)
+	}
+	return groups
+}
+
+func printModelGroups(groups []modelGroup) {
+	fmt.Printf("%-28s  %5s  %6s  %6s  %8s  %7s  %5s  %-17s  %s\n",
+		"Model", "Turns", "Commit", "Reject", "Delta", "Entropy", "Score", "State Norm", "Seen")
+	fmt.Printf("%-28s+-%5s+-%6s+-%6s+-%8s+-%7s+-%5s+-%-17s+-%s\n",
+		strings.Repeat("-", 28), "-----", "------", "------", "--------", "-------", "-----", strings.Repeat("-", 17), "--------------------")
+	for _, g := range groups {
+		fmt.Printf("%-28s  %5d  %6d  %6d  %8.4f  %7.2f  %5.2f  %7.4f → %-7.4f  %s .. %s\n",
+			truncate(g.Model, 28), g.Turns, g.Commits, g.Rejects, g.MeanDeltaNorm, g.MeanEntropy, g.MeanScore,
+			g.StateNormStart, g.StateNormEnd, g.FirstSeen, g.LastSeen)
+	}
+}
+
+// #endregion model-mode
+
 // #region detail-mode
 
 type detailOutput struct {
@@ -156,6 +411,8 @@ type detailOutput struct {
 	Reason     string             `json:"reason"`
 	Score      float32            `json:"score"`
 	Segments   map[string]float64 `json:"segments"`
+	Limits     map[string]float64 `json:"limits"`
+	Headroom   map[string]float64 `json:"headroom"`
 	GateRecord *gateDetail        `json:"gate_record,omitempty"`
 }
 
@@ -164,6 +421,15 @@ type gateDetail struct {
 	Entropy   float32 `json:"entropy"`
 	Vetoed    bool    `json:"vetoed"`
 	SoftScore float32 `json:"soft_score"`
+	Scale     float32 `json:"gate_scale,omitempty"`   // commit_scaled: fraction of the delta a downgraded veto let through
+	Context   string  `json:"turn_context,omitempty"` // preference scope the turn was classified into
+	Model     string  `json:"model,omitempty"`        // backing model name@version that generated the response
+	Profile   string  `json:"profile,omitempty"`      // resource profile and the stages it cut, when not full
+
+	Breakdown *logging.GateBreakdownRecord `json:"breakdown,omitempty"` // soft score terms and veto checks
+
+	Claims      int      `json:"claims,omitempty"`             // attributed response sentences
+	Unsupported []string `json:"unsupported_claims,omitempty"` // sentences no evidence item supports
 }
 
 func runDetailMode(store *state.Store, versionID, segFilter string, jsonOut bool) error {
@@ -176,7 +442,7 @@ func runDetailMode(store *state.Store, versionID, segFilter string, jsonOut bool
 	out := detailOutput{
 		VersionID: vp.VersionID,
 		ParentID:  vp.ParentID,
-		CreatedAt: vp.CreatedAt.Format("2006-01-02T15:04:05Z"),
+		CreatedAt: display.Format(vp.CreatedAt),
 		StateNorm: fullVectorNorm(vp.StateVector),
 		Decision:  vp.Decision,
 		Reason:    vp.Reason,
@@ -184,12 +450,36 @@ func runDetailMode(store *state.Store, versionID, segFilter string, jsonOut bool
 		Segments:  segs,
 	}
 
-	if gr := parseGateRecord(vp.SignalsJSON); gr != nil {
+	gr := parseGateRecord(vp.SignalsJSON)
+	out.Limits = segmentLimits(gr)
+	out.Headroom = make(map[string]float64, len(segs))
+	for name, norm := range segs {
+		out.Headroom[name] = out.Limits[name] - norm
+	}
+	if gr != nil {
 		out.GateRecord = &gateDetail{
 			DeltaNorm: gr.DeltaNorm,
 			Entropy:   gr.Entropy,
 			Vetoed:    gr.GateVetoed,
 			SoftScore: gr.GateSoftScore,
+			Scale:     gr.GateScale,
+			Context:   gr.TurnContext,
+			Claims:    len(gr.Attribution),
+			Breakdown: gr.GateBreakdown,
+		}
+		if gr.Model != "" {
+			out.GateRecord.Model = logging.ModelLabel(gr.Model, gr.ModelVersion)
+		}
+		if p := gr.Profile; p != nil {
+			out.GateRecord.Profile = p.Name
+			if len(p.Skipped) > 0 {
+				out.GateRecord.Profile += " (skipped " + strings.Join(p.Skipped, ", ") + ")"
+			}
+		}
+		for _, a := range gr.Attribution {
+			if len(a.EvidenceIDs) == 0 {
+				out.GateRecord.Unsupported = append(out.GateRecord.Unsupported, a.Sentence)
+			}
 		}
 	}
 
@@ -206,7 +496,7 @@ func runDetailMode(store *state.Store, versionID, segFilter string, jsonOut bool
 	fmt.Printf("Score:      %.2f\n", out.Score)
 
 	fmt.Printf("\nSegment norms:\n")
-	printSegments(segs, segFilter)
+	printSegments(segs, out.Limits, segFilter)
 
 	if out.GateRecord != nil {
 		fmt.Printf("\nGate Record:\n")
@@ -214,13 +504,925 @@ func runDetailMode(store *state.Store, versionID, segFilter string, jsonOut bool
 		fmt.Printf("  Entropy:     %.2f\n", out.GateRecord.Entropy)
 		fmt.Printf("  Vetoed:      %v\n", out.GateRecord.Vetoed)
 		fmt.Printf("  Soft Score:  %.2f\n", out.GateRecord.SoftScore)
+		if out.GateRecord.Scale > 0 {
+			fmt.Printf("  Downgraded:  veto cleared by committing the delta x%.2f\n", out.GateRecord.Scale)
+		}
+		if b := out.GateRecord.Breakdown; b != nil {
+			printBreakdown(b)
+		}
+		if out.GateRecord.Model != "" {
+			fmt.Printf("  Model:       %s\n", out.GateRecord.Model)
+		}
+		if out.GateRecord.Profile != "" {
+			fmt.Printf("  Profile:     %s\n", out.GateRecord.Profile)
+		}
+		if out.GateRecord.Context != "" {
+			fmt.Printf("  Context:     %s\n", out.GateRecord.Context)
+		}
+		if out.GateRecord.Claims > 0 {
+			fmt.Printf("  Claims:      %d (%d unsupported)\n", out.GateRecord.Claims, len(out.GateRecord.Unsupported))
+			for _, c := range out.GateRecord.Unsupported {
+				fmt.Printf("    - %s\n", truncate(c, 100))
+			}
+		}
 	}
 
 	return nil
 }
 
+// printBreakdown shows the soft score's terms and each veto check's measured
+// value against its threshold.
+func printBreakdown(b *logging.GateBreakdownRecord) {
+	for _, c := range b.Components {
+		line := fmt.Sprintf("    %-16s %.2f of %.2f  (input %.4g)", c.Name, c.Score, c.Weight, c.Value)
+		if c.Note != "" {
+			line += ", " + c.Note
+		}
+		fmt.Println(line)
+	}
+	fmt.Printf("  Veto Checks:\n")
+	for _, c := range b.Checks {
+		verdict, cmp := "ok", "<="
+		if c.Vetoed {
+			verdict, cmp = "VETO "+c.Type, ">"
+		}
+		fmt.Printf("    %-22s %9.4f %-2s %-9.4f %s\n", c.Name, c.Value, cmp, c.Threshold, verdict)
+	}
+}
+
 // #endregion detail-mode
 
+// #region veto-mode
+
+func runVetoMode(store *state.Store, since string, samples int, jsonOut bool) error {
+	window, err := parseSince(since)
+	if err != nil {
+		return err
+	}
+	if err := logging.EnsureVetoReviewTable(store.DB()); err != nil {
+		return err
+	}
+	rejections, err := logging.ListVetoRejections(store.DB(), time.Now().UTC().Add(-window))
+	if err != nil {
+		return err
+	}
+	groups := logging.GroupVetoes(rejections, samples)
+
+	if jsonOut {
+		return printJSON(groups)
+	}
+	if len(groups) == 0 {
+		fmt.Printf("no gate vetoes in the last %s\n", since)
+		return nil
+	}
+
+	fmt.Printf("Gate vetoes in the last %s: %d rejections\n\n", since, len(rejections))
+	fmt.Printf("%-22s  %6s  %8s  %6s  %7s\n", "Veto Type", "Count", "Reviewed", "FP", "FP Rate")
+	fmt.Printf("%-22s+-%6s+-%8s+-%6s+-%7s\n", "----------------------", "------", "--------", "------", "-------")
+	for _, g := range groups {
+		rate := "—"
+		if g.Reviewed > 0 {
+			rate = fmt.Sprintf("%.2f", g.FalsePositiveRate())
+		}
+		fmt.Printf("%-22s  %6d  %8d  %6d  %7s\n", g.Type, g.Count, g.Reviewed, g.FalsePositives, rate)
+	}
+
+	for _, g := range groups {
+		fmt.Printf("\n%s:\n", g.Type)
+		for _, r := range g.Samples {
+			verdict := "unreviewed"
+			if r.Verdict != "" {
+				verdict = r.Verdict
+			}
+			fmt.Printf("  #%-6d %s  [%s]  %s\n", r.ProvenanceID, display.Format(r.CreatedAt), verdict, truncate(r.Prompt, 60))
+			fmt.Printf("          %s\n", r.Reason)
+		}
+	}
+	fmt.Println("\nMark a review with: inspect --db ... --mark-fp <id> (veto was wrong) or --mark-ok <id>")
+	return nil
+}
+
+func runMarkVeto(store *state.Store, markFP, markOK int64, note string) error {
+	if markFP != 0 && markOK != 0 {
+		return fmt.Errorf("use only one of --mark-fp and --mark-ok")
+	}
+	id, verdict := markFP, logging.VerdictFalsePositive
+	if markOK != 0 {
+		id, verdict = markOK, logging.VerdictCorrect
+	}
+	if err := logging.EnsureVetoReviewTable(store.DB()); err != nil {
+		return err
+	}
+	if err := logging.MarkVeto(store.DB(), id, verdict, note); err != nil {
+		return err
+	}
+	fmt.Printf("marked provenance #%d as %s\n", id, verdict)
+	return nil
+}
+
+// parseSince accepts Go durations ("24h") plus a day suffix ("7d").
+func parseSince(s string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n <= 0 {
+			return 0, fmt.Errorf("invalid --since %q", s)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid --since %q", s)
+	}
+	return d, nil
+}
+
+// #endregion veto-mode
+// #region query-mode
+
+type queryRow struct {
+	ID          int64    `json:"id"`
+	CreatedAt   string   `json:"created_at"`
+	VersionID   string   `json:"version_id"`
+	TurnID      string   `json:"turn_id,omitempty"`
+	TriggerType string   `json:"trigger_type"`
+	Decision    string   `json:"decision"`
+	Reason      string   `json:"reason,omitempty"`
+	VetoTypes   []string `json:"veto_types,omitempty"`
+	SegmentsHit []string `json:"segments_hit,omitempty"`
+	Prompt      string   `json:"prompt,omitempty"`
+
+	at time.Time // CreatedAt before rendering, for grouping by day
+}
+
+type queryPage struct {
+	Entries      []queryRow `json:"entries"`
+	NextBeforeID int64      `json:"next_before_id,omitempty"`
+}
+
+// runQueryMode lists one page of provenance entries matching f, newest first,
+// with counts by day and by cause so a spike in rejections can be traced.
+func runQueryMode(store *state.Store, f logging.ProvenanceFilter, jsonOut bool) error {
+	page, err := logging.QueryProvenance(store.DB(), f)
+	if err != nil {
+		return err
+	}
+	out := queryPage{Entries: make([]queryRow, 0, len(page.Entries)), NextBeforeID: page.NextBeforeID}
+	for _, e := range page.Entries {
+		r := queryRow{
+			ID:          e.ID,
+			CreatedAt:   display.Format(e.CreatedAt),
+			at:          e.CreatedAt,
+			VersionID:   e.VersionID,
+			TriggerType: e.TriggerType,
+			Decision:    e.Decision,
+			Reason:      e.Reason,
+			VetoTypes:   logging.VetoTypes(e),
+		}
+		if gr := parseGateRecord(e.SignalsJSON); gr != nil {
+			r.TurnID, r.SegmentsHit, r.Prompt = gr.TurnID, gr.SegmentsHit, gr.Prompt
+		}
+		out.Entries = append(out.Entries, r)
+	}
+
+	if jsonOut {
+		return printJSON(out)
+	}
+	if len(out.Entries) == 0 {
+		fmt.Println("no provenance entries match")
+		return nil
+	}
+
+	fmt.Printf("%-7s  %-20s  %-8s  %-10s  %-12s  %s\n", "ID", "Time", "Decision", "Trigger", "Turn", "Cause")
+	fmt.Printf("%-7s+-%-20s+-%-8s+-%-10s+-%-12s+-%s\n", "-------", "--------------------", "--------", "----------", "------------", "--------------------")
+	byDay := map[string]int{}
+	byCause := map[string]int{}
+	var days, causes []string
+	for _, r := range out.Entries {
+		cause := queryCause(r)
+		fmt.Printf("%-7d  %-20s  %-8s  %-10s  %-12s  %s\n", r.ID, r.CreatedAt, r.Decision, truncate(r.TriggerType, 10), truncate(r.TurnID, 12), truncate(cause, 60))
+		day := display.Day(r.at)
+		if byDay[day] == 0 {
+			days = append(days, day)
+		}
+		byDay[day]++
+		if byCause[cause] == 0 {
+			causes = append(causes, cause)
+		}
+		byCause[cause]++
+	}
+
+	fmt.Printf("\nBy day (%d entries on this page):\n", len(out.Entries))
+	for _, d := range days {
+		fmt.Printf("  %s  %d\n", d, byDay[d])
+	}
+	sort.SliceStable(causes, func(i, j int) bool { return byCause[causes[i]] > byCause[causes[j]] })
+	fmt.Println("\nBy cause:")
+	for _, c := range causes {
+		fmt.Printf("  %5d  %s\n", byCause[c], truncate(c, 70))
+	}
+	if out.NextBeforeID != 0 {
+		fmt.Printf("\nMore entries: repeat with --before %d\n", out.NextBeforeID)
+	}
+	return nil
+}
+
+// queryCause is what to group an entry by: its hard veto types, else its
+// reason, else its decision.
+func queryCause(r queryRow) string {
+	switch {
+	case len(r.VetoTypes) > 0:
+		return "veto: " + strings.Join(r.VetoTypes, ", ")
+	case r.Reason != "":
+		return r.Reason
+	default:
+		return r.Decision
+	}
+}
+
+// parseTimeBound accepts a date ("2024-06-01", midnight in the --tz zone), an
+// RFC3339 time, or an age as for parseSince ("7d" means seven days ago).
+func parseTimeBound(name, s string) (time.Time, error) {
+	if t, err := display.ParseDate(s); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	age, err := parseSince(s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid %s %q: want a date, RFC3339 time or age like 7d", name, s)
+	}
+	return time.Now().UTC().Add(-age), nil
+}
+
+// #endregion query-mode
+
+// #region detection-mode
+
+func runDetectionMode(store *state.Store, since string, samples int, jsonOut bool) error {
+	window, err := parseSince(since)
+	if err != nil {
+		return err
+	}
+	labelStore, err := projection.NewDetectionLabelStore(store.DB())
+	if err != nil {
+		return err
+	}
+	labels, err := labelStore.List(time.Now().UTC().Add(-window))
+	if err != nil {
+		return err
+	}
+	groups := projection.GroupPrecision(labels, samples)
+
+	if jsonOut {
+		return printJSON(groups)
+	}
+	if len(groups) == 0 {
+		fmt.Printf("no detection samples in the last %s (enable with DETECTION_SAMPLE_PERCENT)\n", since)
+		return nil
+	}
+
+	fmt.Printf("Detector confirmation samples in the last %s: %d\n\n", since, len(labels))
+	fmt.Printf("%-12s  %6s  %9s  %6s  %10s  %9s\n", "Detector", "Asked", "Confirmed", "Denied", "Unanswered", "Precision")
+	fmt.Printf("%-12s+-%6s+-%9s+-%6s+-%10s+-%9s\n", "------------", "------", "---------", "------", "----------", "---------")
+	for _, g := range groups {
+		precision := "—"
+		if g.Confirmed+g.Denied > 0 {
+			precision = fmt.Sprintf("%.2f", g.Precision())
+		}
+		fmt.Printf("%-12s  %6d  %9d  %6d  %10d  %9s\n", g.Detector, g.Asked, g.Confirmed, g.Denied, g.Unanswered, precision)
+	}
+
+	for _, g := range groups {
+		if len(g.Denials) == 0 {
+			continue
+		}
+		fmt.Printf("\n%s denials:\n", g.Detector)
+		for _, l := range g.Denials {
+			fmt.Printf("  #%-6d %s  %s\n", l.ID, display.Format(l.CreatedAt), truncate(l.Prompt, 60))
+			fmt.Printf("          detected %q\n", truncate(l.Value, 60))
+		}
+	}
+	return nil
+}
+
+// #endregion detection-mode
+
+// #region similar-mode
+
+// similarCandidates is how many nearest versions are fetched per requested
+// period, so neighbouring versions can group into one period.
+const similarCandidates = 5
+
+type similarRow struct {
+	Start       string   `json:"start"`
+	End         string   `json:"end"`
+	Similarity  float64  `json:"similarity"`
+	Versions    []string `json:"versions"`
+	Preferences []string `json:"dominant_preferences,omitempty"`
+}
+
+func runSimilarMode(store *state.Store, n int, versionID, segment, gap string, jsonOut bool) error {
+	window, err := time.ParseDuration(gap)
+	if err != nil || window < 0 {
+		return fmt.Errorf("invalid --gap %q", gap)
+	}
+	var query state.StateRecord
+	if versionID != "" {
+		query, err = store.GetVersion(versionID)
+	} else {
+		query, err = store.GetCurrent()
+	}
+	if err != nil {
+		return err
+	}
+
+	matches, err := store.SimilarityIndex().Search(state.SimilarityQuery{
+		Vector:  query.StateVector,
+		Segment: segment,
+		Before:  query.CreatedAt.Add(-window),
+		K:       n * similarCandidates,
+	})
+	if err != nil {
+		return err
+	}
+	periods := state.GroupPeriods(matches, state.DefaultPeriodGap)
+	if len(periods) > n {
+		periods = periods[:n]
+	}
+
+	rows := make([]similarRow, 0, len(periods))
+	for _, p := range periods {
+		row := similarRow{
+			Start:      display.Format(p.Start),
+			End:        display.Format(p.End),
+			Similarity: p.Best,
+		}
+		var blocks []string
+		for _, v := range p.Versions {
+			row.Versions = append(row.Versions, v.VersionID)
+			if vp, err := store.GetVersionWithProvenance(v.VersionID); err == nil {
+				if gr := parseGateRecord(vp.SignalsJSON); gr != nil {
+					blocks = append(blocks, gr.StateBlock)
+				}
+			}
+		}
+		row.Preferences = projection.DominantPreferences(blocks, 3)
+		rows = append(rows, row)
+	}
+
+	if jsonOut {
+		return printJSON(rows)
+	}
+	scope := "whole vector"
+	if segment != "" {
+		scope = segment + " segment"
+	}
+	if len(rows) == 0 {
+		fmt.Printf("no versions older than %s to compare %s against\n", gap, query.VersionID)
+		return nil
+	}
+	fmt.Printf("Past periods most similar to %s (%s, older than %s):\n\n", query.VersionID, scope, gap)
+	fmt.Printf("%-3s %-43s %-9s %s\n", "#", "PERIOD", "VERSIONS", "SIMILARITY")
+	for i, r := range rows {
+		fmt.Printf("%-3d %-43s %-9d %.3f\n", i+1, r.Start+" → "+r.End, len(r.Versions), r.Similarity)
+		if len(r.Preferences) > 0 {
+			fmt.Printf("    preferences: %s\n", strings.Join(r.Preferences, "; "))
+		}
+	}
+	return nil
+}
+
+// #endregion similar-mode
+
+// #region evidence-mode
+
+type evidenceRow struct {
+	ID        string `json:"id"`
+	CreatedAt string `json:"created_at"`
+	Pinned    bool   `json:"pinned,omitempty"`
+	Note      string `json:"note,omitempty"`
+	Text      string `json:"text"`
+	Metadata  string `json:"metadata_json"`
+}
+
+// runEvidenceMode lists the last N items of the local evidence store, newest
+// first, read straight from the database without the inference service.
+func runEvidenceMode(store *state.Store, last int, jsonOut bool) error {
+	ok, err := evidence.HasLocalEvidence(store.DB())
+	if err != nil {
+		return err
+	}
+	if !ok {
+		fmt.Println("no local evidence in this database (with CODEC_BACKEND=grpc the Python service keeps it)")
+		return nil
+	}
+	local, err := evidence.NewSQLiteStore(store.DB())
+	if err != nil {
+		return err
+	}
+	items, err := local.All(context.Background())
+	if err != nil {
+		return err
+	}
+	total := len(items)
+	if last > 0 && len(items) > last {
+		items = items[len(items)-last:]
+	}
+	rows := make([]evidenceRow, 0, len(items))
+	for i := len(items) - 1; i >= 0; i-- {
+		it := items[i]
+		a := evidence.ParseAnnotation(it.MetadataJSON)
+		rows = append(rows, evidenceRow{
+			ID:        it.ID,
+			CreatedAt: display.Format(it.CreatedAt),
+			Pinned:    a.Pinned,
+			Note:      a.Note,
+			Text:      it.Text,
+			Metadata:  it.MetadataJSON,
+		})
+	}
+
+	if jsonOut {
+		return printJSON(rows)
+	}
+	fmt.Printf("Local evidence: %d items (showing %d)\n\n", total, len(rows))
+	for _, r := range rows {
+		mark := ""
+		if r.Pinned {
+			mark = " [pinned]"
+		}
+		fmt.Printf("%s  %s%s  %s\n", r.ID, r.CreatedAt, mark, truncate(r.Text, 70))
+		if r.Note != "" {
+			fmt.Printf("    note: %s\n", r.Note)
+		}
+	}
+	return nil
+}
+
+// #endregion evidence-mode
+
+// #region usage-mode
+
+type usageRow struct {
+	evidence.ItemUsage
+	LastAt string `json:"last_at,omitempty"`
+	Text   string `json:"text,omitempty"`
+}
+
+type usageReport struct {
+	Since      string     `json:"since"`
+	Until      string     `json:"until"`
+	Items      int        `json:"items"`
+	Hits       int        `json:"hits"`
+	MostUsed   []usageRow `json:"most_used"`
+	LeastUsed  []usageRow `json:"least_used"`
+	NeverFound int        `json:"never_retrieved"`
+}
+
+// heatShades renders a bucket's hits relative to the busiest bucket shown.
+var heatShades = []rune(" ░▒▓█")
+
+// runUsageMode shows which memories retrieval actually uses over the window:
+// the N most-used items, then the N least-used, each with a heatmap of hits
+// per time slice. The local evidence store, when present, adds items never
+// retrieved and the text of each row.
+func runUsageMode(store *state.Store, since string, buckets, last int, jsonOut bool) error {
+	window, err := parseSince(since)
+	if err != nil {
+		return err
+	}
+	if last <= 0 {
+		last = 20
+	}
+	u, err := evidence.NewUsageLog(store.DB())
+	if err != nil {
+		return err
+	}
+
+	texts := map[string]string{}
+	var extra []string
+	if ok, err := evidence.HasLocalEvidence(store.DB()); err != nil {
+		return err
+	} else if ok {
+		local, err := evidence.NewSQLiteStore(store.DB())
+		if err != nil {
+			return err
+		}
+		items, err := local.All(context.Background())
+		if err != nil {
+			return err
+		}
+		for _, it := range items {
+			texts[it.ID] = it.Text
+			extra = append(extra, it.ID)
+		}
+	}
+
+	until := time.Now().UTC()
+	from := until.Add(-window)
+	items, err := u.Heatmap(from, until, buckets, extra)
+	if err != nil {
+		return err
+	}
+	report := usageReport{Since: display.Format(from), Until: display.Format(until), Items: len(items)}
+	for _, it := range items {
+		report.Hits += it.Hits
+		if it.LastAt.IsZero() {
+			report.NeverFound++
+		}
+	}
+	row := func(it evidence.ItemUsage) usageRow {
+		r := usageRow{ItemUsage: it, Text: texts[it.ID]}
+		if !it.LastAt.IsZero() {
+			r.LastAt = display.Format(it.LastAt)
+		}
+		return r
+	}
+	n := min(last, len(items))
+	for _, it := range items[:n] {
+		report.MostUsed = append(report.MostUsed, row(it))
+	}
+	for i := len(items) - 1; i >= n && len(report.LeastUsed) < last; i-- {
+		report.LeastUsed = append(report.LeastUsed, row(items[i]))
+	}
+
+	if jsonOut {
+		return printJSON(report)
+	}
+	if len(items) == 0 {
+		fmt.Println("no retrieval hits recorded yet")
+		return nil
+	}
+	peak := 1
+	for _, it := range items {
+		for _, c := range it.Buckets {
+			peak = max(peak, c)
+		}
+	}
+	fmt.Printf("Retrieval usage over the last %s (%s – %s, %d slices of %s): %d hits across %d items, %d never retrieved\n",
+		since, report.Since, report.Until, buckets, (window / time.Duration(max(buckets, 1))).Round(time.Minute), report.Hits, report.Items, report.NeverFound)
+	section := func(title string, rows []usageRow) {
+		if len(rows) == 0 {
+			return
+		}
+		fmt.Printf("\n%s:\n", title)
+		fmt.Printf("  %-40s  %5s  %6s  %-*s  %-20s  %s\n", "ID", "Hits", "Walked", buckets+2, "Heat", "Last Hit", "Text")
+		for _, r := range rows {
+			heat := make([]rune, len(r.Buckets))
+			for i, c := range r.Buckets {
+				heat[i] = heatShades[(c*(len(heatShades)-1)+peak-1)/peak]
+			}
+			last := r.LastAt
+			if last == "" {
+				last = "never"
+			}
+			fmt.Printf("  %-40s  %5d  %6d  |%s|  %-20s  %s\n", r.ID, r.Hits, r.Walked, string(heat), last, truncate(r.Text, 50))
+		}
+	}
+	section("Most used", report.MostUsed)
+	section("Least used", report.LeastUsed)
+	return nil
+}
+
+// #endregion usage-mode
+
+// #region rules-mode
+
+// runRulesMode reports each learned rule's effect over the window: how often it
+// fired, the gate outcomes of those turns, how often a correction followed,
+// and mean compliance before and after it was learned. Rules that never fired
+// or draw corrections are flagged and listed first.
+func runRulesMode(store *state.Store, since string, jsonOut bool) error {
+	window, err := parseSince(since)
+	if err != nil {
+		return err
+	}
+	ruleStore, err := projection.NewRuleStore(store.DB())
+	if err != nil {
+		return err
+	}
+	until := time.Now().UTC()
+	rep, err := rulestats.BuildFromDB(store.DB(), ruleStore, until.Add(-window), until, rulestats.DefaultConfig())
+	if err != nil {
+		return err
+	}
+	if jsonOut {
+		return printJSON(rep)
+	}
+	if len(rep.Rules) == 0 {
+		fmt.Println("no behavioral rules stored")
+		return nil
+	}
+	fmt.Printf("Rule effectiveness over the last %s (%s – %s)\n\n", since, display.Format(rep.Since), display.Format(rep.Until))
+	fmt.Print(rep.Format())
+	return nil
+}
+
+// #endregion rules-mode
+
+// #region lineage-mode
+
+type lineageRow struct {
+	VersionID string   `json:"version_id"`
+	ParentID  string   `json:"parent_id,omitempty"`
+	CreatedAt string   `json:"created_at"`
+	Trigger   string   `json:"trigger,omitempty"`
+	Decision  string   `json:"decision,omitempty"`
+	Reason    string   `json:"reason,omitempty"`
+	DeltaNorm *float64 `json:"delta_norm,omitempty"`
+	Active    bool     `json:"active,omitempty"`
+	Children  []string `json:"children,omitempty"`
+}
+
+type lineageMoveRow struct {
+	From      string `json:"from"`
+	To        string `json:"to"`
+	CreatedAt string `json:"created_at"`
+	Kind      string `json:"kind"` // rollback | jump
+}
+
+// runLineageMode shows the version DAG of the last N versions: the tree by
+// default, GraphViz DOT with --dot, or versions and moves as JSON.
+func runLineageMode(store *state.Store, last int, dot, jsonOut bool) error {
+	entries, err := store.ListLineage(last)
+	if err != nil {
+		return err
+	}
+	if len(entries) == 0 {
+		fmt.Fprintln(os.Stderr, "no versions found")
+		return nil
+	}
+	moves, err := store.ListActiveMoves()
+	if err != nil {
+		return err
+	}
+	current, err := store.GetCurrent()
+	if err != nil {
+		return err
+	}
+	g := lineage.Build(entries, moves, current.VersionID)
+	switch {
+	case dot:
+		return g.WriteDOT(os.Stdout)
+	case jsonOut:
+		out := struct {
+			Versions []lineageRow     `json:"versions"`
+			Moves    []lineageMoveRow `json:"moves"`
+		}{Versions: []lineageRow{}, Moves: []lineageMoveRow{}}
+		for i := len(entries) - 1; i >= 0; i-- { // oldest first
+			n := g.Nodes[entries[i].VersionID]
+			row := lineageRow{
+				VersionID: n.VersionID, ParentID: n.ParentID, CreatedAt: display.Format(n.CreatedAt),
+				Trigger: n.Trigger, Decision: n.Decision, Reason: n.Reason, DeltaNorm: n.DeltaNorm, Active: n.Active,
+			}
+			for _, c := range n.Children {
+				row.Children = append(row.Children, c.VersionID)
+			}
+			out.Versions = append(out.Versions, row)
+		}
+		for _, m := range g.Moves {
+			kind := "jump"
+			if m.Rollback {
+				kind = "rollback"
+			}
+			out.Moves = append(out.Moves, lineageMoveRow{From: m.From, To: m.To, CreatedAt: display.Format(m.CreatedAt), Kind: kind})
+		}
+		return printJSON(out)
+	}
+	fmt.Printf("Lineage of the last %d versions: %d branch points, %d pointer moves\n\n", len(entries), g.Branches(), len(g.Moves))
+	fmt.Print(g.ASCII(display.Format))
+	return nil
+}
+
+// #endregion lineage-mode
+
+// #region prefs-mode
+
+type prefRow struct {
+	ID        int    `json:"id"`
+	Text      string `json:"text"`
+	Style     string `json:"style"`
+	Scope     string `json:"scope,omitempty"`
+	Priority  int    `json:"priority"`
+	ExpiresAt string `json:"expires_at,omitempty"`
+	CreatedAt string `json:"created_at"`
+}
+
+type prefHistoryRow struct {
+	prefRow
+	Reason     string `json:"reason"`
+	ReplacedBy int    `json:"replaced_by,omitempty"`
+	RemovedAt  string `json:"removed_at"`
+	RestoredAt string `json:"restored_at,omitempty"`
+}
+
+type prefsReport struct {
+	Active  []prefRow        `json:"active"`
+	History []prefHistoryRow `json:"history"`
+}
+
+// runPrefsMode lists active preferences and the last N replaced or deleted
+// ones, or deletes or restores one preference by ID first.
+func runPrefsMode(store *state.Store, deleteID, restoreID, last int, jsonOut bool) error {
+	ps, err := projection.NewPreferenceStore(store.DB())
+	if err != nil {
+		return err
+	}
+	switch {
+	case deleteID != 0 && restoreID != 0:
+		return fmt.Errorf("use only one of --delete-pref and --restore-pref")
+	case deleteID != 0:
+		var found bool
+		if err := store.WithTx(func(tx *sql.Tx) error {
+			var err error
+			found, err = ps.WithTx(tx).Delete(deleteID)
+			return err
+		}); err != nil {
+			return err
+		}
+		if !found {
+			return fmt.Errorf("no active preference %d", deleteID)
+		}
+		fmt.Printf("deleted preference %d (restore with --restore-pref %d)\n", deleteID, deleteID)
+		return nil
+	case restoreID != 0:
+		var replaced []int64
+		var found bool
+		if err := store.WithTx(func(tx *sql.Tx) error {
+			var err error
+			replaced, found, err = ps.WithTx(tx).Restore(restoreID)
+			return err
+		}); err != nil {
+			return err
+		}
+		if !found {
+			return fmt.Errorf("preference %d has nothing to restore", restoreID)
+		}
+		fmt.Printf("restored preference %d", restoreID)
+		if len(replaced) > 0 {
+			fmt.Printf(", replacing %v", replaced)
+		}
+		fmt.Println()
+		return nil
+	}
+
+	active, err := ps.List()
+	if err != nil {
+		return err
+	}
+	hist, err := ps.History(last)
+	if err != nil {
+		return err
+	}
+	toRow := func(p projection.Preference) prefRow {
+		r := prefRow{ID: p.ID, Text: p.Text, Style: string(p.Style), Scope: p.Scope, Priority: p.Priority, CreatedAt: display.Format(p.CreatedAt)}
+		if !p.ExpiresAt.IsZero() {
+			r.ExpiresAt = display.Format(p.ExpiresAt)
+		}
+		return r
+	}
+	rep := prefsReport{Active: []prefRow{}, History: []prefHistoryRow{}}
+	for _, p := range active {
+		rep.Active = append(rep.Active, toRow(p))
+	}
+	for _, h := range hist {
+		r := prefHistoryRow{prefRow: toRow(h.Preference), Reason: h.Reason, ReplacedBy: h.ReplacedBy, RemovedAt: display.Format(h.RemovedAt)}
+		if !h.RestoredAt.IsZero() {
+			r.RestoredAt = display.Format(h.RestoredAt)
+		}
+		rep.History = append(rep.History, r)
+	}
+	if jsonOut {
+		return printJSON(rep)
+	}
+
+	fmt.Printf("Active preferences: %d (highest priority first)\n\n", len(rep.Active))
+	for _, r := range rep.Active {
+		labels := []string{r.Style, "priority " + projection.PriorityName(r.Priority)}
+		if r.Scope != "" {
+			labels = append(labels, r.Scope)
+		}
+		if r.ExpiresAt != "" {
+			labels = append(labels, "until "+r.ExpiresAt)
+		}
+		fmt.Printf("%4d  %s  [%s]  %s\n", r.ID, r.CreatedAt, strings.Join(labels, ", "), truncate(r.Text, 60))
+	}
+	if len(rep.History) == 0 {
+		return nil
+	}
+	fmt.Printf("\nReplaced and deleted (newest first):\n\n")
+	for _, r := range rep.History {
+		what := r.Reason
+		if r.ReplacedBy != 0 {
+			what = fmt.Sprintf("replaced by %d", r.ReplacedBy)
+		}
+		if r.RestoredAt != "" {
+			what += ", restored " + r.RestoredAt
+		}
+		fmt.Printf("%4d  %s  %s  %s\n", r.ID, r.RemovedAt, what, truncate(r.Text, 60))
+	}
+	return nil
+}
+
+// #endregion prefs-mode
+
+// #region settings-mode
+
+type settingRow struct {
+	Key       string `json:"key"`
+	Kind      string `json:"kind"`
+	Value     string `json:"value"`
+	Source    string `json:"source"`
+	UpdatedAt string `json:"updated_at"`
+}
+
+type settingChangeRow struct {
+	ID        int64  `json:"id"`
+	Key       string `json:"key"`
+	OldValue  string `json:"old_value"`
+	NewValue  string `json:"new_value"`
+	Source    string `json:"source"`
+	Reason    string `json:"reason,omitempty"`
+	CreatedAt string `json:"created_at"`
+}
+
+type settingsReport struct {
+	Settings []settingRow       `json:"settings"`
+	History  []settingChangeRow `json:"history"`
+}
+
+// runSettingsMode lists runtime-learned settings and the last N changes (to
+// key, if set), or resets one setting first.
+func runSettingsMode(store *state.Store, key, reset string, last int, jsonOut bool) error {
+	ss, err := settings.NewStore(store.DB())
+	if err != nil {
+		return err
+	}
+	if reset != "" {
+		if _, ok, err := ss.Get(reset); err != nil {
+			return err
+		} else if !ok {
+			return fmt.Errorf("setting %s is not set", reset)
+		}
+		if err := ss.Reset(reset, "inspect", "reset from inspect"); err != nil {
+			return err
+		}
+		fmt.Printf("reset %s to its default\n", reset)
+		return nil
+	}
+
+	list, err := ss.List()
+	if err != nil {
+		return err
+	}
+	hist, err := ss.History(key, last)
+	if err != nil {
+		return err
+	}
+	rep := settingsReport{Settings: []settingRow{}, History: []settingChangeRow{}}
+	for _, st := range list {
+		if key != "" && st.Key != key {
+			continue
+		}
+		rep.Settings = append(rep.Settings, settingRow{Key: st.Key, Kind: st.Kind, Value: st.Value, Source: st.Source, UpdatedAt: display.Format(st.UpdatedAt)})
+	}
+	for _, c := range hist {
+		rep.History = append(rep.History, settingChangeRow{
+			ID: c.ID, Key: c.Key, OldValue: c.OldValue, NewValue: c.NewValue,
+			Source: c.Source, Reason: c.Reason, CreatedAt: display.Format(c.CreatedAt),
+		})
+	}
+	if jsonOut {
+		return printJSON(rep)
+	}
+
+	fmt.Printf("Settings: %d\n\n", len(rep.Settings))
+	for _, r := range rep.Settings {
+		fmt.Printf("  %-32s %-10s %-16s %s  (%s)\n", r.Key, r.Kind, r.Value, r.UpdatedAt, r.Source)
+	}
+	if len(rep.History) == 0 {
+		return nil
+	}
+	fmt.Printf("\nChanges (newest first):\n\n")
+	orDefault := func(v string) string {
+		if v == "" {
+			return "(default)"
+		}
+		return v
+	}
+	for _, r := range rep.History {
+		line := fmt.Sprintf("%4d  %s  %s: %s → %s  (%s)", r.ID, r.CreatedAt, r.Key, orDefault(r.OldValue), orDefault(r.NewValue), r.Source)
+		if r.Reason != "" {
+			line += "  " + truncate(r.Reason, 60)
+		}
+		fmt.Println(line)
+	}
+	return nil
+}
+
+// #endregion settings-mode
+
 // #region metrics
 
 func fullVectorNorm(v [128]float32) float64 {
@@ -248,6 +1450,30 @@ func computeSegmentNorms(v [128]float32, sm state.SegmentMap) map[string]float64
 	}
 }
 
+// segmentLimits returns the tighter of the gate cap and the eval bound for
+// each segment, as recorded in the turn's gate record. Versions without a
+// record (or with one from before thresholds were logged) use the defaults.
+func segmentLimits(gr *logging.GateRecord) map[string]float64 {
+	g := gate.DefaultGateConfig()
+	e := eval.DefaultEvalConfig()
+	if gr != nil && gr.Thresholds.MaxSegmentNorm > 0 {
+		th := gr.Thresholds
+		g.RiskSegmentCap = th.RiskSegmentCap
+		g.SegmentCaps = th.SegmentCaps
+		e.MaxSegmentNorm = th.MaxSegmentNorm
+		e.SegmentNorms = th.SegmentNorms
+	}
+	limits := make(map[string]float64, 4)
+	for _, name := range []string{"prefs", "goals", "heuristics", "risk"} {
+		limit := e.SegmentLimit(name)
+		if c, ok := g.SegmentCap(name); ok && c < limit {
+			limit = c
+		}
+		limits[name] = float64(limit)
+	}
+	return limits
+}
+
 // #endregion metrics
 
 // #region verifier
@@ -272,24 +1498,36 @@ func verifierScore(decision, reason string) float32 {
 
 // #region output
 
+// parseGateRecord returns the turn's gate record, or nil for rows without one.
+// A record that fails validation is reported on stderr and treated as absent.
 func parseGateRecord(signalsJSON string) *logging.GateRecord {
-	if signalsJSON == "" {
+	gr, err := logging.ParseGateRecord(signalsJSON)
+	if err != nil {
+		if !errors.Is(err, logging.ErrNotGateRecord) {
+			fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+		}
 		return nil
 	}
-	var gr logging.GateRecord
-	if err := json.Unmarshal([]byte(signalsJSON), &gr); err == nil && gr.TurnID != "" {
-		return &gr
-	}
-	return nil
+	return &gr
 }
 
-func printSegments(segs map[string]float64, filter string) {
+func printSegments(segs, limits map[string]float64, filter string) {
 	order := []string{"prefs", "goals", "heuristics", "risk"}
 	for _, name := range order {
 		if filter != "" && name != filter {
 			continue
 		}
-		fmt.Printf("  %-12s %.4f\n", name, segs[name])
+		limit, ok := limits[name]
+		if !ok {
+			fmt.Printf("  %-12s %.4f\n", name, segs[name])
+			continue
+		}
+		headroom := limit - segs[name]
+		marker := ""
+		if headroom < 0 {
+			marker = "  OVER"
+		}
+		fmt.Printf("  %-12s %.4f  / %7.4f  headroom %8.4f%s\n", name, segs[name], limit, headroom, marker)
 	}
 }
 
@@ -302,6 +1540,14 @@ func printJSON(v interface{}) error {
 	return nil
 }
 
+func truncate(s string, n int) string {
+	s = strings.ReplaceAll(s, "\n", " ")
+	if len([]rune(s)) > n {
+		return string([]rune(s)[:n]) + "…"
+	}
+	return s
+}
+
 func shortID(id string) string {
 	if len(id) > 8 {
 		return id[:8]
@@ -0,0 +1,235 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/export"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/projection"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/state"
+	_ "modernc.org/sqlite"
+)
+
+// #region main
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+	switch os.Args[1] {
+	case "export":
+		os.Exit(runExport(os.Args[2:]))
+	case "import":
+		os.Exit(runImport(os.Args[2:]))
+	default:
+		usage()
+		os.Exit(2)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: profile export --db path [--out profile.json]")
+	fmt.Fprintln(os.Stderr, "       profile import --db path [--dry-run] [--yes] profile.json")
+	fmt.Fprintln(os.Stderr, "the signing key is read from PROFILE_KEY, or from the file named by --key-file")
+}
+
+// signingKey returns the contents of keyFile, or PROFILE_KEY when no file is given.
+func signingKey(keyFile string) ([]byte, error) {
+	if keyFile != "" {
+		data, err := os.ReadFile(keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("read key file: %w", err)
+		}
+		return []byte(strings.TrimSpace(string(data))), nil
+	}
+	if key := os.Getenv("PROFILE_KEY"); key != "" {
+		return []byte(key), nil
+	}
+	return nil, fmt.Errorf("no signing key: set PROFILE_KEY or pass --key-file")
+}
+
+// stores opens the database and the stores a profile reads and writes.
+func stores(dbPath string) (*state.Store, *projection.ProfileStore, *projection.PreferenceStore, *projection.RuleStore, error) {
+	store, err := state.NewStore(dbPath)
+	if err != nil {
+		return nil, nil, nil, nil, fmt.Errorf("open store: %w", err)
+	}
+	profile, err := projection.NewProfileStore(store.DB())
+	if err != nil {
+		store.Close()
+		return nil, nil, nil, nil, fmt.Errorf("init profile store: %w", err)
+	}
+	prefs, err := projection.NewPreferenceStore(store.DB())
+	if err != nil {
+		store.Close()
+		return nil, nil, nil, nil, fmt.Errorf("init preference store: %w", err)
+	}
+	rules, err := projection.NewRuleStore(store.DB())
+	if err != nil {
+		store.Close()
+		return nil, nil, nil, nil, fmt.Errorf("init rule store: %w", err)
+	}
+	return store, profile, prefs, rules, nil
+}
+
+// #endregion main
+
+// #region export
+
+// runExport writes the signed profile of the database to --out (default stdout).
+func runExport(args []string) int {
+	fs := flag.NewFlagSet("export", flag.ContinueOnError)
+	dbPath := fs.String("db", "adaptive_state.db", "path to SQLite database")
+	outPath := fs.String("out", "", "output path (default stdout)")
+	keyFile := fs.String("key-file", "", "file holding the signing key (default PROFILE_KEY)")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	key, err := signingKey(*keyFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+
+	store, profileStore, prefStore, ruleStore, err := stores(*dbPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+	defer store.Close()
+	fields, err := profileStore.Get()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+	prefs, err := prefStore.List()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+	rules, err := ruleStore.List()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+
+	p := export.BuildProfile(fields, prefs, rules, time.Now())
+	data, err := export.SignProfile(p, key)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+	if *outPath == "" {
+		fmt.Println(string(data))
+		return 0
+	}
+	if err := export.WriteFile(*outPath, append(data, '\n')); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+	fmt.Fprintf(os.Stderr, "Exported %d identity field(s), %d preference(s), %d rule(s) to %s\n",
+		len(p.Identity)+boolInt(p.AIDesignation != ""), len(p.Preferences), len(p.Rules), *outPath)
+	return 0
+}
+
+func boolInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// #endregion export
+
+// #region import
+
+// runImport verifies a signed profile, prints what it would change, and writes
+// only after confirmation (or --yes). --dry-run stops after the diff.
+func runImport(args []string) int {
+	fs := flag.NewFlagSet("import", flag.ContinueOnError)
+	dbPath := fs.String("db", "adaptive_state.db", "path to SQLite database")
+	keyFile := fs.String("key-file", "", "file holding the signing key (default PROFILE_KEY)")
+	dryRun := fs.Bool("dry-run", false, "print the diff without writing")
+	yes := fs.Bool("yes", false, "apply without prompting for confirmation")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 1 {
+		usage()
+		return 2
+	}
+	key, err := signingKey(*keyFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+	data, err := os.ReadFile(fs.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: read profile: %v\n", err)
+		return 1
+	}
+	p, err := export.VerifyProfile(data, key)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+	if problems := export.ValidateProfile(p); len(problems) > 0 {
+		fmt.Fprintf(os.Stderr, "profile has %d problem(s):\n", len(problems))
+		for _, pr := range problems {
+			fmt.Fprintf(os.Stderr, "  %v\n", pr)
+		}
+		return 1
+	}
+
+	store, profileStore, prefStore, ruleStore, err := stores(*dbPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+	defer store.Close()
+	fields, err := profileStore.Get()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+	prefs, err := prefStore.List()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+	rules, err := ruleStore.List()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+
+	plan := export.PlanProfile(p, fields, prefs, rules, time.Now().UTC())
+	fmt.Printf("profile exported %s\n", p.ExportedAt.Format(time.RFC3339))
+	fmt.Print(export.FormatProfilePlan(plan))
+	writes := plan.Writes()
+	fmt.Printf("%d change(s) to write\n", writes)
+	if *dryRun || writes == 0 {
+		return 0
+	}
+	if !*yes {
+		fmt.Print("Apply these changes? [y/N] ")
+		answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+		if a := strings.ToLower(strings.TrimSpace(answer)); a != "y" && a != "yes" {
+			fmt.Println("Aborted. Nothing written.")
+			return 0
+		}
+	}
+	if err := export.ApplyProfile(plan, store, profileStore, prefStore, ruleStore); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+	fmt.Printf("Imported %d change(s).\n", writes)
+	return 0
+}
+
+// #endregion import
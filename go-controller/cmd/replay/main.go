@@ -1,13 +1,18 @@
 package main
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"os"
 	"strings"
 
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/eval"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/evidence"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/gate"
 	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/logging"
 	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/replay"
 	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/state"
@@ -20,32 +25,114 @@ import (
 func main() {
 	dbPath := flag.String("db", "", "path to adaptive_state.db (DB mode)")
 	fixturePath := flag.String("fixture", "", "path to fixture JSON (fixture mode)")
+	byModel := flag.Bool("by-model", false, "also break results down by the backing model of each turn")
+	downgrade := flag.String("downgrade", os.Getenv("GATE_DOWNGRADE"), "DB mode: veto types that commit scaled down, as GATE_DOWNGRADE (fixtures carry their own)")
+	downgradePercent := flag.Int("downgrade-percent", 25, "DB mode: largest overshoot downgraded, in percent of the cap")
+	policyPath := flag.String("policy", os.Getenv("GATE_POLICY"), "DB mode: gate policy file (YAML or JSON) as GATE_POLICY, applied over the downgrade flags")
+	sweepSpec := flag.String("sweep", "", "replay once per point of a config grid, e.g. learning_rate=0.005:0.05:4,max_delta_norm=3|4|5, and print a comparison matrix")
+	regenerate := flag.Bool("regenerate", false, "re-generate each turn through the live codec service under the replayed state, recompute its signals, and compare with the logged response")
+	codecAddr := flag.String("codec-addr", envOr("CODEC_ADDR", "localhost:50051"), "with --regenerate: codec service address, as CODEC_ADDR (transport from CODEC_TLS*, CODEC_AUTH_TOKEN*)")
 	flag.Parse()
 
 	if (*dbPath == "" && *fixturePath == "") || (*dbPath != "" && *fixturePath != "") {
-		fmt.Fprintln(os.Stderr, "usage: replay --db path/to/adaptive_state.db")
-		fmt.Fprintln(os.Stderr, "       replay --fixture path/to/fixture.json")
+		fmt.Fprintln(os.Stderr, "usage: replay --db path/to/adaptive_state.db [--by-model] [--downgrade constraint_violation] [--downgrade-percent 25] [--policy gate-policy.yaml] [--sweep spec | --regenerate [--codec-addr host:port]]")
+		fmt.Fprintln(os.Stderr, "       replay --fixture path/to/fixture.json [--by-model] [--sweep spec | --regenerate [--codec-addr host:port]]")
+		fmt.Fprintf(os.Stderr, "sweep parameters: %s\n", strings.Join(replay.SweepParams(), ", "))
 		os.Exit(2)
 	}
 
+	opts := runOptions{byModel: *byModel}
+	if *sweepSpec != "" && *regenerate {
+		fmt.Fprintln(os.Stderr, "--sweep and --regenerate are separate modes; use one")
+		os.Exit(2)
+	}
+	if *sweepSpec != "" {
+		var err error
+		if opts.axes, err = replay.ParseSweep(*sweepSpec); err != nil {
+			fmt.Fprintf(os.Stderr, "invalid --sweep: %v\n", err)
+			os.Exit(2)
+		}
+	}
+	if *regenerate {
+		live, err := dialLiveCodec(*codecAddr)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "--regenerate: %v\n", err)
+			os.Exit(2)
+		}
+		opts.live = live
+	}
+
 	var exitCode int
 	if *fixturePath != "" {
-		exitCode = runFixtureMode(*fixturePath)
+		exitCode = runFixtureMode(*fixturePath, opts)
 	} else {
-		exitCode = runDBMode(*dbPath)
+		gateConfig := replay.DefaultReplayConfig().GateConfig
+		var err error
+		if gateConfig.Downgrade, err = gate.ParseDowngrade(*downgrade, *downgradePercent); err != nil {
+			fmt.Fprintf(os.Stderr, "invalid --downgrade: %v\n", err)
+			os.Exit(2)
+		}
+		if *policyPath != "" {
+			if gateConfig, err = gate.LoadPolicy(*policyPath, gateConfig); err != nil {
+				fmt.Fprintf(os.Stderr, "invalid --policy: %v\n", err)
+				os.Exit(2)
+			}
+		}
+		exitCode = runDBMode(*dbPath, gateConfig, opts)
+	}
+	if opts.live != nil {
+		opts.live.Close()
 	}
 	os.Exit(exitCode)
 }
 
+// runOptions selects what both modes do with the extracted turns.
+type runOptions struct {
+	byModel bool
+	axes    []replay.SweepAxis // --sweep
+	live    *liveCodec         // --regenerate
+}
+
+// report replays interactions from start under config and prints the result
+// against the expected actions: a sweep matrix, a regeneration comparison, or
+// the per-turn comparison table. Returns the exit code.
+func report(start state.StateRecord, interactions []replay.Interaction, config replay.ReplayConfig, expected []string, opts runOptions) int {
+	if opts.axes != nil {
+		printSweep(start, interactions, config, opts.axes, expected)
+		return 0
+	}
+	var results []replay.ReplayResult
+	if opts.live != nil {
+		var regens []replay.Regeneration
+		results, regens = replay.ReplayRegenerated(start, interactions, config, opts.live.regenerate)
+		printRegenerations(opts.live, regens, results, expected)
+	} else {
+		results = replay.Replay(start, interactions, config)
+	}
+	code := printComparison(results, expected, nil)
+	if opts.byModel {
+		printModelBreakdown(interactions, results, expected)
+	}
+	return code
+}
+
+func envOr(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
+
 // #endregion main
 
 // #region db-extract
 
 // provenanceRow represents a row from the provenance_log table.
 type provenanceRow struct {
-	TurnID      string // version_id used as turn identifier
-	SignalsJSON string
-	Decision    string
+	TurnID       string // version_id used as turn identifier
+	SignalsJSON  string
+	Decision     string
+	EvidenceRefs string // comma-separated IDs of the evidence the turn used
 }
 
 // legacySignalsJSON mirrors the legacy JSON structure from json.Marshal(updateCtx).
@@ -57,7 +144,7 @@ type legacySignalsJSON struct {
 	Entropy      float32 `json:"Entropy"`
 }
 
-func runDBMode(dbPath string) int {
+func runDBMode(dbPath string, gateConfig gate.GateConfig, opts runOptions) int {
 	store, err := state.NewStore(dbPath)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "open db: %v\n", err)
@@ -85,7 +172,7 @@ func runDBMode(dbPath string) int {
 
 	// Query provenance_log for user_turn entries
 	rows, err := db.Query(
-		`SELECT version_id, signals_json, decision FROM provenance_log
+		`SELECT version_id, signals_json, decision, evidence_refs FROM provenance_log
 		 WHERE trigger_type = 'user_turn' ORDER BY created_at ASC`,
 	)
 	if err != nil {
@@ -97,14 +184,15 @@ func runDBMode(dbPath string) int {
 	var provRows []provenanceRow
 	for rows.Next() {
 		var r provenanceRow
-		var sigJSON sql.NullString
-		if err := rows.Scan(&r.TurnID, &sigJSON, &r.Decision); err != nil {
+		var sigJSON, refs sql.NullString
+		if err := rows.Scan(&r.TurnID, &sigJSON, &r.Decision, &refs); err != nil {
 			fmt.Fprintf(os.Stderr, "scan row: %v\n", err)
 			return 2
 		}
 		if sigJSON.Valid {
 			r.SignalsJSON = sigJSON.String
 		}
+		r.EvidenceRefs = refs.String
 		provRows = append(provRows, r)
 	}
 	if err := rows.Err(); err != nil {
@@ -124,13 +212,14 @@ func runDBMode(dbPath string) int {
 		interactions[i] = toInteraction(r)
 		dbDecisions[i] = r.Decision
 	}
+	if err := attachLocalEvidence(db, provRows, interactions); err != nil {
+		fmt.Fprintf(os.Stderr, "warning: %v (turns replayed without evidence)\n", err)
+	}
 
-	// Replay with default config
+	// Replay with default config and the daemon's gate policy
 	config := replay.DefaultReplayConfig()
-	results := replay.Replay(startState, interactions, config)
-
-	// Print comparison table
-	return printComparison(results, dbDecisions, nil)
+	config.GateConfig = gateConfig
+	return report(startState, interactions, config, dbDecisions, opts)
 }
 
 // toInteraction converts a provenance row to a replay Interaction.
@@ -146,8 +235,12 @@ func toInteraction(r provenanceRow) replay.Interaction {
 
 	// Try GateRecord format first (new full-fidelity logging).
 	// Discriminator: GateRecord has "turn_id" (snake_case), legacy has "TurnID" (PascalCase).
-	var gr logging.GateRecord
-	if err := json.Unmarshal([]byte(r.SignalsJSON), &gr); err == nil && gr.TurnID != "" {
+	gr, err := logging.ParseGateRecord(r.SignalsJSON)
+	if err != nil && !errors.Is(err, logging.ErrNotGateRecord) {
+		fmt.Fprintf(os.Stderr, "warning: %v (turn replayed without its inputs)\n", err)
+		return inter
+	}
+	if err == nil {
 		inter.TurnID = gr.TurnID
 		inter.Prompt = gr.Prompt
 		inter.ResponseText = gr.Response
@@ -161,6 +254,9 @@ func toInteraction(r provenanceRow) replay.Interaction {
 			ToolFailure:         gr.Signals.ToolFailure,
 			ConstraintViolation: gr.Signals.ConstraintViolation,
 		}
+		if gr.Model != "" {
+			inter.Model = logging.ModelLabel(gr.Model, gr.ModelVersion)
+		}
 		return inter
 	}
 
@@ -178,6 +274,39 @@ func toInteraction(r provenanceRow) replay.Interaction {
 	return inter
 }
 
+// attachLocalEvidence fills each interaction's evidence from the local
+// evidence store (CODEC_BACKEND=ollama) by the turn's evidence_refs, so the
+// update sees what the live turn saw. Without a local store, or for items
+// since deleted, turns replay without that evidence.
+func attachLocalEvidence(db *sql.DB, rows []provenanceRow, interactions []replay.Interaction) error {
+	ok, err := evidence.HasLocalEvidence(db)
+	if err != nil || !ok {
+		return err
+	}
+	local, err := evidence.NewSQLiteStore(db)
+	if err != nil {
+		return err
+	}
+	refs, found := 0, 0
+	for i, r := range rows {
+		if r.EvidenceRefs == "" {
+			continue
+		}
+		ids := strings.Split(r.EvidenceRefs, ",")
+		items, err := local.Get(context.Background(), ids)
+		if err != nil {
+			return err
+		}
+		for _, it := range items {
+			interactions[i].Evidence = append(interactions[i].Evidence, it.Text)
+		}
+		refs += len(ids)
+		found += len(items)
+	}
+	fmt.Printf("Evidence: %d of %d refs found in the local store\n", found, refs)
+	return nil
+}
+
 // #endregion db-extract
 
 // #region heuristic-signals
@@ -208,7 +337,7 @@ func heuristicSignals(legacy legacySignalsJSON) update.Signals {
 
 // #region output
 
-func runFixtureMode(path string) int {
+func runFixtureMode(path string, opts runOptions) int {
 	f, err := replay.LoadFixture(path)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "load fixture: %v\n", err)
@@ -223,14 +352,11 @@ func runFixtureMode(path string) int {
 		interactions[i] = f.Interactions[i].ToInteraction()
 	}
 
-	results := replay.Replay(startState, interactions, config)
-
 	expected := make([]string, len(f.ExpectedResults))
 	for i, e := range f.ExpectedResults {
 		expected[i] = e.Action
 	}
-
-	return printComparison(results, expected, nil)
+	return report(startState, interactions, config, expected, opts)
 }
 
 // printComparison outputs a comparison table and returns exit code.
@@ -262,7 +388,14 @@ func printComparison(results []replay.ReplayResult, expected []string, turnIDs [
 			matches++
 		}
 
-		fmt.Printf("%-12s| %-15s| %-15s| %s\n", turnID, exp, got, match)
+		shown := got
+		if gd := results[i].GateDecision; gd != nil && gd.Action == "commit_scaled" {
+			shown = fmt.Sprintf("%s gate x%.2f", got, gd.Scale) // downgraded veto: delta scaled to fit the caps
+		}
+		if ev := results[i].EvalResult; ev != nil && ev.Tier == eval.TierWarn {
+			shown = fmt.Sprintf("%s x%.2f", shown, ev.Scale) // warning tier: delta scaled down
+		}
+		fmt.Printf("%-12s| %-15s| %-15s| %s\n", turnID, exp, shown, match)
 	}
 
 	diverge := total - matches
@@ -274,6 +407,100 @@ func printComparison(results []replay.ReplayResult, expected []string, turnIDs [
 	return 0
 }
 
+// printModelBreakdown outputs per-model outcome counts, mean delta norm, and how
+// many of each model's turns matched the expected action, so a behaviour change
+// after a model upgrade shows up as one model's column diverging.
+func printModelBreakdown(interactions []replay.Interaction, results []replay.ReplayResult, expected []string) {
+	matches := map[string]int{}
+	for i, r := range results {
+		if i >= len(interactions) || i >= len(expected) {
+			break
+		}
+		model := interactions[i].Model
+		if model == "" {
+			model = logging.UnknownModel
+		}
+		if actionsMatch(expected[i], r.Action) {
+			matches[model]++
+		}
+	}
+
+	fmt.Printf("\n%-32s| %6s| %7s| %7s| %9s| %6s| %10s| %s\n",
+		"Model", "Turns", "Commit", "Reject", "Rollback", "No-op", "Mean Delta", "Match")
+	fmt.Printf("%-32s+%7s+%8s+%8s+%10s+%7s+%11s+%s\n",
+		strings.Repeat("-", 32), "-------", "--------", "--------", "----------", "-------", "-----------", "------")
+	for _, s := range replay.SummarizeByModel(interactions, results) {
+		fmt.Printf("%-32s| %6d| %7d| %7d| %9d| %6d| %10.4f| %d/%d\n",
+			truncateModel(s.Model, 32), s.Turns, s.Commits, s.GateRejects, s.EvalRollbacks, s.NoOps,
+			s.MeanDeltaNorm, matches[s.Model], s.Turns)
+	}
+}
+
+// printSweep replays the interactions across the sweep grid and prints one
+// row per config: outcome rates, turns diverging from the base config's
+// replay, turns matching the expected actions, and the final segment norms.
+// The "base" row is the config the grid varies from.
+func printSweep(start state.StateRecord, interactions []replay.Interaction, base replay.ReplayConfig, axes []replay.SweepAxis, expected []string) {
+	baseline, points := replay.Sweep(start, interactions, base, axes)
+
+	header, rule := fmt.Sprintf("%-5s", "Run"), "-----"
+	for _, a := range axes {
+		header += fmt.Sprintf("| %-*s", len(a.Param)+1, a.Param)
+		rule += "+" + strings.Repeat("-", len(a.Param)+2)
+	}
+	header += fmt.Sprintf("| %8s| %8s| %9s| %8s| %8s", "Commit%", "Reject%", "Rollback%", "Diverge", "Match")
+	rule += "+---------+---------+----------+---------+---------"
+	for _, name := range state.SegmentNames {
+		header += fmt.Sprintf("| %10s", name)
+		rule += "+" + strings.Repeat("-", 11)
+	}
+	fmt.Println(header)
+	fmt.Println(rule)
+
+	row := func(label string, p replay.SweepPoint) {
+		line := fmt.Sprintf("%-5s", label)
+		for i, a := range axes {
+			line += fmt.Sprintf("| %-*g", len(a.Param)+1, p.Values[i])
+		}
+		matches, total := 0, len(p.Results)
+		if len(expected) < total {
+			total = len(expected)
+		}
+		for i := 0; i < total; i++ {
+			if actionsMatch(expected[i], p.Results[i].Action) {
+				matches++
+			}
+		}
+		s := p.Summary
+		line += fmt.Sprintf("| %8.1f| %8.1f| %9.1f| %8d| %8s",
+			percent(s.Commits, s.TotalTurns), percent(s.GateRejects, s.TotalTurns), percent(s.EvalRollbacks, s.TotalTurns),
+			p.Diverged, fmt.Sprintf("%d/%d", matches, total))
+		for _, name := range state.SegmentNames {
+			line += fmt.Sprintf("| %10.4f", p.SegmentNorms[name])
+		}
+		fmt.Println(line)
+	}
+	row("base", baseline)
+	for i, p := range points {
+		row(fmt.Sprint(i+1), p)
+	}
+	fmt.Printf("\nSweep: %d configs over %d turns\n", len(points), len(interactions))
+}
+
+func percent(n, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return 100 * float64(n) / float64(total)
+}
+
+func truncateModel(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n-1] + "…"
+}
+
 // actionsMatch compares expected vs replayed action.
 // DB "reject" matches either "gate_reject" or "eval_rollback".
 func actionsMatch(expected, replayed string) bool {
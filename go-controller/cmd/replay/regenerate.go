@@ -0,0 +1,147 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/codec"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/replay"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/signals"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/state"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/vecmath"
+)
+
+// #region regenerate
+
+// liveCodec re-generates replayed turns through the codec service and
+// recomputes their signals the way the controller does.
+type liveCodec struct {
+	client   *codec.CodecClient
+	producer *signals.Producer
+}
+
+// dialLiveCodec connects to the codec service at addr and checks the protocol
+// handshake, so a mismatched or unreachable service fails before any turn.
+func dialLiveCodec(addr string) (*liveCodec, error) {
+	transport, err := codec.TransportConfigFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	client, err := codec.NewCodecClientWithTransport(addr, transport)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if _, err := client.Handshake(ctx); err != nil {
+		client.Close()
+		return nil, fmt.Errorf("codec service at %s: %w", addr, err)
+	}
+	return &liveCodec{client: client, producer: signals.NewProducer(client, signals.DefaultProducerConfig())}, nil
+}
+
+// Close closes the codec connection.
+func (l *liveCodec) Close() error {
+	return l.client.Close()
+}
+
+// regenerate generates inter's logged prompt under the replayed state with the
+// evidence the turn used, then produces its signals. Flags the producer cannot
+// derive from the response (user correction, tool failure, constraint
+// violation, plan progress) carry over from the logged signals.
+func (l *liveCodec) regenerate(inter replay.Interaction, current state.StateRecord) (replay.Regenerated, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 120*time.Second)
+	defer cancel()
+	res, err := l.client.Generate(ctx, inter.Prompt, current.StateVector, inter.Evidence, nil)
+	if err != nil {
+		return replay.Regenerated{}, err
+	}
+	logged := inter.Signals
+	sigs := l.producer.Produce(ctx, signals.ProduceInput{
+		Prompt:       inter.Prompt,
+		ResponseText: res.Text,
+		Entropy:      res.Entropy,
+		Logits:       res.Logits,
+		UserCorrect:  logged.UserCorrection,
+	})
+	sigs.ToolFailure = logged.ToolFailure
+	sigs.ConstraintViolation = logged.ConstraintViolation
+	sigs.PlanProgress = logged.PlanProgress
+	return replay.Regenerated{ResponseText: res.Text, Entropy: res.Entropy, Signals: sigs}, nil
+}
+
+// similarity is the cosine similarity of the embeddings of a and b; ok is
+// false when either is empty or cannot be embedded.
+func (l *liveCodec) similarity(a, b string) (float64, bool) {
+	if a == "" || b == "" {
+		return 0, false
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	va, err := l.client.Embed(ctx, a)
+	if err != nil {
+		return 0, false
+	}
+	vb, err := l.client.Embed(ctx, b)
+	if err != nil {
+		return 0, false
+	}
+	sim, _ := vecmath.Cosine(va, vb)
+	return sim, true
+}
+
+// printRegenerations prints, per turn, the logged and regenerated entropy and
+// signals, how similar the new response is to the logged one, and the
+// expected and replayed actions; then the means over regenerated turns.
+func printRegenerations(live *liveCodec, regens []replay.Regeneration, results []replay.ReplayResult, expected []string) {
+	fmt.Printf("%-12s| %-15s| %-15s| %-15s| %-15s| %-6s| %s\n",
+		"Turn", "Entropy", "Sentiment", "Coherence", "Novelty", "Sim", "Action")
+	fmt.Printf("%-12s+%-15s+%-15s+%-15s+%-15s+%-6s+%s\n",
+		"------------", "----------------", "----------------", "----------------", "----------------", "-------", "------")
+
+	var n, simN int
+	var dEntropy, simSum float64
+	for i, r := range regens {
+		action := ""
+		if i < len(results) {
+			action = results[i].Action
+			if i < len(expected) && !actionsMatch(expected[i], action) {
+				action = fmt.Sprintf("%s (was %s)", action, expected[i])
+			}
+		}
+		if r.Err != nil {
+			fmt.Printf("%-12s| regeneration failed, replayed as logged: %v | %s\n", r.TurnID, r.Err, action)
+			continue
+		}
+		sim := "—"
+		if s, ok := live.similarity(r.Logged.ResponseText, r.Fresh.ResponseText); ok {
+			sim = fmt.Sprintf("%.2f", s)
+			simSum += s
+			simN++
+		}
+		lg, fr := r.Logged.Signals, r.Fresh.Signals
+		fmt.Printf("%-12s| %-15s| %-15s| %-15s| %-15s| %-6s| %s\n", r.TurnID,
+			pair(r.Logged.Entropy, r.Fresh.Entropy), pair(lg.SentimentScore, fr.SentimentScore),
+			pair(lg.CoherenceScore, fr.CoherenceScore), pair(lg.NoveltyScore, fr.NoveltyScore), sim, action)
+		dEntropy += math.Abs(float64(r.Fresh.Entropy - r.Logged.Entropy))
+		n++
+	}
+
+	fmt.Printf("\nRegenerated %d of %d turns", n, len(regens))
+	if n > 0 {
+		fmt.Printf(": mean |Δentropy| %.4f", dEntropy/float64(n))
+	}
+	if simN > 0 {
+		fmt.Printf(", mean response similarity %.2f", simSum/float64(simN))
+	}
+	fmt.Print("\n\n")
+}
+
+// pair renders a logged → regenerated value.
+func pair(logged, fresh float32) string {
+	return fmt.Sprintf("%.3f→%.3f", logged, fresh)
+}
+
+// #endregion regenerate
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/state"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/transcript"
+	_ "modernc.org/sqlite"
+)
+
+// #region main
+
+func main() {
+	dbPath := flag.String("db", "", "path to adaptive_state.db")
+	last := flag.Int("last", 0, "number of most recent turns to export (0 = all)")
+	outPath := flag.String("out", "", "output path (default stdout)")
+	format := flag.String("format", "json", "json (one conversation) | jsonl (one {\"messages\"} example per turn)")
+	noMeta := flag.Bool("no-metadata", false, "omit per-message metadata (version IDs, gate decisions)")
+	flag.Parse()
+
+	if *dbPath == "" || (*format != "json" && *format != "jsonl") {
+		fmt.Fprintln(os.Stderr, "usage: transcript-export --db path/to/db [--out path] [--format json|jsonl] [--last N] [--no-metadata]")
+		os.Exit(2)
+	}
+
+	if err := run(*dbPath, *last, *outPath, *format, !*noMeta); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// #endregion main
+
+// #region export
+
+func run(dbPath string, last int, outPath, format string, withMetadata bool) error {
+	store, err := state.NewStore(dbPath)
+	if err != nil {
+		return fmt.Errorf("open db: %w", err)
+	}
+	defer store.Close()
+
+	turns, err := transcript.LoadTurns(store.DB(), last)
+	if err != nil {
+		return err
+	}
+	if len(turns) == 0 {
+		return fmt.Errorf("no GateRecord-format user_turn rows found")
+	}
+
+	var w io.Writer = os.Stdout
+	if outPath != "" {
+		f, err := os.Create(outPath)
+		if err != nil {
+			return fmt.Errorf("create %s: %w", outPath, err)
+		}
+		defer f.Close()
+		w = f
+	}
+
+	if format == "jsonl" {
+		err = transcript.WriteJSONL(w, turns, withMetadata)
+	} else {
+		err = transcript.WriteJSON(w, turns, withMetadata)
+	}
+	if err != nil {
+		return err
+	}
+	if outPath != "" {
+		fmt.Printf("Wrote %d turns to %s (%s)\n", len(turns), outPath, format)
+	}
+	return nil
+}
+
+// #endregion export
@@ -0,0 +1,271 @@
+package core
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/eval"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/gate"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/logging"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/replay"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/state"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/update"
+)
+
+// #region types
+
+// Types from the learning-loop packages, re-exported so programs outside this
+// module can name them. Everything reachable from core is free of gRPC,
+// protobuf and web search; core_test.go enforces that.
+type (
+	StateRecord  = state.StateRecord
+	SegmentMap   = state.SegmentMap
+	Signals      = update.Signals
+	Correction   = update.Correction
+	UpdateConfig = update.UpdateConfig
+	GateConfig   = gate.GateConfig
+	GateDecision = gate.GateDecision
+	EvalConfig   = eval.EvalConfig
+	EvalResult   = eval.EvalResult
+	Config       = replay.ReplayConfig
+	Interaction  = replay.Interaction
+	ReplayResult = replay.ReplayResult
+)
+
+// DefaultConfig returns the update, gate and eval defaults the controller uses.
+func DefaultConfig() Config { return replay.DefaultReplayConfig() }
+
+// DefaultSegmentMap returns the standard 4×32 segment layout.
+func DefaultSegmentMap() SegmentMap { return state.DefaultSegmentMap() }
+
+// Replay runs interactions through update → gate → eval in memory, without a
+// backend or database.
+func Replay(start StateRecord, interactions []Interaction, cfg Config) []ReplayResult {
+	return replay.Replay(start, interactions, cfg)
+}
+
+// Generation is a backend's response to one prompt.
+type Generation struct {
+	Text    string
+	Entropy float32 // normalized 0-1; drives risk reinforcement and the gate's soft score
+}
+
+// Backend produces responses for the loop. It receives the active state
+// vector and any evidence the caller supplied, and may ignore either.
+type Backend interface {
+	Generate(ctx context.Context, prompt string, stateVector [128]float32, evidence []string) (Generation, error)
+}
+
+// BackendFunc adapts a function to Backend, e.g. a call into a local model.
+type BackendFunc func(ctx context.Context, prompt string, stateVector [128]float32, evidence []string) (Generation, error)
+
+// Generate implements Backend.
+func (f BackendFunc) Generate(ctx context.Context, prompt string, stateVector [128]float32, evidence []string) (Generation, error) {
+	return f(ctx, prompt, stateVector, evidence)
+}
+
+// Turn is one user turn. Signals are supplied by the caller — the heuristic
+// producer in the full controller needs the inference service's embeddings.
+type Turn struct {
+	ID       string // generated when empty
+	Prompt   string
+	Signals  Signals
+	Evidence []string
+}
+
+// TurnResult is the response and what the loop did with the state.
+type TurnResult struct {
+	TurnID    string
+	Response  string
+	Entropy   float32
+	Action    string // "commit" | "gate_reject" | "eval_rollback" | "no_op", as in ReplayResult
+	Reason    string
+	VersionID string // active version after the turn
+	Gate      *GateDecision
+	Eval      *EvalResult
+}
+
+// #endregion types
+
+// #region loop
+
+// Loop is the state learning loop on its own: generate, update, gate,
+// tentative commit, eval and rollback against a SQLite state store, with the
+// same provenance rows the controller writes, so cmd/inspect and cmd/replay
+// work on its database.
+type Loop struct {
+	store   *state.Store
+	backend Backend
+	cfg     Config
+	gate    *gate.Gate
+	eval    *eval.EvalHarness
+}
+
+// Open opens (or creates) the state database at dbPath and returns a loop
+// over it. An initial zero state is created when none is active.
+func Open(dbPath string, backend Backend, cfg Config) (*Loop, error) {
+	if backend == nil {
+		return nil, errors.New("core: nil backend")
+	}
+	store, err := state.NewStore(dbPath)
+	if err != nil {
+		return nil, fmt.Errorf("open state store: %w", err)
+	}
+	if _, err := store.GetCurrent(); err != nil {
+		if _, err := store.CreateInitialState(state.DefaultSegmentMap()); err != nil {
+			store.Close()
+			return nil, fmt.Errorf("create initial state: %w", err)
+		}
+	}
+	return &Loop{
+		store:   store,
+		backend: backend,
+		cfg:     cfg,
+		gate:    gate.NewGate(cfg.GateConfig),
+		eval:    eval.NewEvalHarness(cfg.EvalConfig),
+	}, nil
+}
+
+// Close closes the state database.
+func (l *Loop) Close() error { return l.store.Close() }
+
+// Current returns the active state version.
+func (l *Loop) Current() (StateRecord, error) { return l.store.GetCurrent() }
+
+// Run generates a response for t and applies the resulting update. A backend
+// error leaves the state untouched and is returned; the write of the update
+// and its provenance is a single transaction.
+func (l *Loop) Run(ctx context.Context, t Turn) (TurnResult, error) {
+	if t.ID == "" {
+		t.ID = "turn-" + uuid.New().String()
+	}
+	current, err := l.store.GetCurrent()
+	if err != nil {
+		return TurnResult{}, fmt.Errorf("get current state: %w", err)
+	}
+	gen, err := l.backend.Generate(ctx, t.Prompt, current.StateVector, t.Evidence)
+	if err != nil {
+		return TurnResult{}, fmt.Errorf("generate: %w", err)
+	}
+
+	res := TurnResult{TurnID: t.ID, Response: gen.Text, Entropy: gen.Entropy, VersionID: current.VersionID}
+	updateResult := update.Update(current, update.UpdateContext{
+		TurnID:       t.ID,
+		Prompt:       t.Prompt,
+		ResponseText: gen.Text,
+		Entropy:      gen.Entropy,
+	}, t.Signals, t.Evidence, l.cfg.UpdateConfig)
+
+	record := logging.GateRecord{
+		TurnID:   t.ID,
+		Prompt:   t.Prompt,
+		Response: gen.Text,
+		Entropy:  gen.Entropy,
+		Signals: logging.GateRecordSignals{
+			SentimentScore:      t.Signals.SentimentScore,
+			CoherenceScore:      t.Signals.CoherenceScore,
+			NoveltyScore:        t.Signals.NoveltyScore,
+			RiskFlag:            t.Signals.RiskFlag,
+			UserCorrection:      t.Signals.UserCorrection,
+			ToolFailure:         t.Signals.ToolFailure,
+			ConstraintViolation: t.Signals.ConstraintViolation,
+			PlanProgress:        t.Signals.PlanProgress,
+		},
+		DeltaNorm:   updateResult.Metrics.DeltaNorm,
+		SegmentsHit: updateResult.Metrics.SegmentsHit,
+		Thresholds: logging.GateRecordThresholds{
+			MaxDeltaNorm:   l.cfg.GateConfig.MaxDeltaNorm,
+			MaxStateNorm:   l.cfg.GateConfig.MaxStateNorm,
+			RiskSegmentCap: l.cfg.GateConfig.RiskSegmentCap,
+			MaxSegmentNorm: l.cfg.EvalConfig.MaxSegmentNorm,
+			SegmentCaps:    l.cfg.GateConfig.SegmentCaps,
+			SegmentNorms:   l.cfg.EvalConfig.SegmentNorms,
+
+			Downgrade:       l.cfg.GateConfig.Downgrade.Types(),
+			DowngradeExcess: l.cfg.GateConfig.Downgrade.MaxExcess,
+
+			DisabledVetoes: l.cfg.GateConfig.DisabledVetoes,
+		},
+	}
+	entry := logging.ProvenanceEntry{
+		VersionID:   current.VersionID,
+		TriggerType: "user_turn",
+		CreatedAt:   time.Now().UTC(),
+	}
+
+	if updateResult.Decision.Action == "no_op" {
+		res.Action, res.Reason = "no_op", updateResult.Decision.Reason
+		entry.Decision, entry.Reason = "no_op", updateResult.Decision.Reason
+		return res, l.log(nil, record, entry)
+	}
+
+	decision, scaled := l.gate.EvaluateDowngrade(current, updateResult.NewState, t.Signals, updateResult.Metrics, gen.Entropy)
+	res.Gate = &decision
+	if decision.Action == "commit_scaled" {
+		updateResult.NewState = scaled
+		record.DeltaNorm *= decision.Scale
+		record.GateScale = decision.Scale
+	}
+	record.GateAction, record.GateSoftScore = decision.Action, decision.SoftScore
+	record.GateVetoed, record.GateReason = decision.Vetoed, decision.Reason
+	for _, v := range decision.VetoSignals {
+		record.GateVetoTypes = append(record.GateVetoTypes, string(v.Type))
+	}
+	record.GateBreakdown = decision.BreakdownRecord()
+	if decision.Action == "reject" {
+		res.Action, res.Reason = "gate_reject", decision.Reason
+		entry.Decision, entry.Reason = "reject", "gate: "+decision.Reason
+		return res, l.log(nil, record, entry)
+	}
+
+	entry.VersionID = updateResult.NewState.VersionID
+	// A warning-tier eval commits the delta scaled down instead of rolling back
+	evalResult, evaluated := l.eval.RunTiered(current, updateResult.NewState, gen.Entropy)
+	res.Eval = &evalResult
+	if evalResult.Tier == eval.TierWarn {
+		record.EvalScale = evalResult.Scale
+	}
+	err = l.store.WithTx(func(tx *sql.Tx) error {
+		if err := l.store.CommitStateTx(tx, evaluated); err != nil {
+			return fmt.Errorf("commit state: %w", err)
+		}
+		if !evalResult.Passed {
+			if err := l.store.RollbackTx(tx, current.VersionID); err != nil {
+				return fmt.Errorf("rollback: %w", err)
+			}
+			res.Action, res.Reason = "eval_rollback", evalResult.Reason
+			entry.Decision, entry.Reason = "reject", "eval rollback: "+evalResult.Reason
+			return l.log(tx, record, entry)
+		}
+		res.Action, res.Reason = "commit", decision.Reason
+		res.VersionID = updateResult.NewState.VersionID
+		entry.Decision = "commit"
+		entry.Reason = fmt.Sprintf("gate: %s | eval: %s", decision.Reason, evalResult.Reason)
+		return l.log(tx, record, entry)
+	})
+	if err != nil {
+		return TurnResult{}, fmt.Errorf("turn write: %w", err)
+	}
+	return res, nil
+}
+
+// log writes the provenance row for a turn, in tx when given.
+func (l *Loop) log(tx *sql.Tx, record logging.GateRecord, entry logging.ProvenanceEntry) error {
+	signalsJSON, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("marshal gate record: %w", err)
+	}
+	entry.SignalsJSON = string(signalsJSON)
+	if tx != nil {
+		return logging.LogDecision(tx, entry)
+	}
+	return l.store.WithTx(func(tx *sql.Tx) error { return logging.LogDecision(tx, entry) })
+}
+
+// #endregion loop
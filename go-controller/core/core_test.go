@@ -0,0 +1,101 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// heavyDeps must stay out of the core build: embedders take core to avoid them.
+var heavyDeps = []string{
+	"google.golang.org/grpc",
+	"google.golang.org/protobuf",
+	"github.com/danielpatrickdp/adaptive-state/go-controller/gen/",
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/codec",
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/websearch",
+}
+
+func TestCoreDependencySurface(t *testing.T) {
+	goBin, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go tool not on PATH")
+	}
+	out, err := exec.Command(goBin, "list", "-deps", ".").CombinedOutput()
+	if err != nil {
+		t.Fatalf("go list: %v\n%s", err, out)
+	}
+	for _, dep := range strings.Fields(string(out)) {
+		for _, heavy := range heavyDeps {
+			if strings.HasPrefix(dep, heavy) {
+				t.Errorf("core depends on %s", dep)
+			}
+		}
+	}
+}
+
+func TestLoopRun_CommitsAndLogs(t *testing.T) {
+	backend := BackendFunc(func(_ context.Context, prompt string, _ [128]float32, _ []string) (Generation, error) {
+		return Generation{Text: "echo: " + prompt, Entropy: 0.4}, nil
+	})
+	loop, err := Open(filepath.Join(t.TempDir(), "core.db"), backend, DefaultConfig())
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer loop.Close()
+	before, _ := loop.Current()
+
+	res, err := loop.Run(context.Background(), Turn{Prompt: "hello", Signals: Signals{SentimentScore: 0.8, CoherenceScore: 0.6}})
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if res.Action != "commit" || res.Response != "echo: hello" {
+		t.Fatalf("expected commit of the echo response, got %+v", res)
+	}
+	after, _ := loop.Current()
+	if after.VersionID == before.VersionID || after.VersionID != res.VersionID {
+		t.Errorf("active version %s, want new version %s", after.VersionID, res.VersionID)
+	}
+
+	var n int
+	if err := loop.store.DB().QueryRow(`SELECT COUNT(*) FROM provenance_log WHERE decision = 'commit'`).Scan(&n); err != nil || n != 1 {
+		t.Errorf("expected one commit provenance row, got %d (%v)", n, err)
+	}
+}
+
+func TestLoopRun_GateRejectKeepsState(t *testing.T) {
+	backend := BackendFunc(func(context.Context, string, [128]float32, []string) (Generation, error) {
+		return Generation{Text: "ok", Entropy: 0.4}, nil
+	})
+	loop, err := Open(filepath.Join(t.TempDir(), "core.db"), backend, DefaultConfig())
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer loop.Close()
+	before, _ := loop.Current()
+
+	res, err := loop.Run(context.Background(), Turn{Prompt: "no, that's wrong", Signals: Signals{SentimentScore: 0.5, UserCorrection: true}})
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if res.Action != "gate_reject" || res.VersionID != before.VersionID {
+		t.Fatalf("expected gate reject on the old version, got %+v", res)
+	}
+}
+
+func TestLoopRun_BackendError(t *testing.T) {
+	backend := BackendFunc(func(context.Context, string, [128]float32, []string) (Generation, error) {
+		return Generation{}, errors.New("model not loaded")
+	})
+	loop, err := Open(filepath.Join(t.TempDir(), "core.db"), backend, DefaultConfig())
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer loop.Close()
+
+	if _, err := loop.Run(context.Background(), Turn{Prompt: "hi"}); err == nil || !strings.Contains(err.Error(), "model not loaded") {
+		t.Fatalf("expected backend error, got %v", err)
+	}
+}
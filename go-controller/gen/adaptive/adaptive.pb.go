@@ -23,11 +23,13 @@ const (
 
 // #region messages
 type GenerateRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Prompt        string                 `protobuf:"bytes,1,opt,name=prompt,proto3" json:"prompt,omitempty"`
-	StateVector   []float32              `protobuf:"fixed32,2,rep,packed,name=state_vector,json=stateVector,proto3" json:"state_vector,omitempty"`
-	Evidence      []string               `protobuf:"bytes,3,rep,name=evidence,proto3" json:"evidence,omitempty"`
-	Context       []int64                `protobuf:"varint,4,rep,packed,name=context,proto3" json:"context,omitempty"`
+	state       protoimpl.MessageState `protogen:"open.v1"`
+	Prompt      string                 `protobuf:"bytes,1,opt,name=prompt,proto3" json:"prompt,omitempty"`
+	StateVector []float32              `protobuf:"fixed32,2,rep,packed,name=state_vector,json=stateVector,proto3" json:"state_vector,omitempty"`
+	Evidence    []string               `protobuf:"bytes,3,rep,name=evidence,proto3" json:"evidence,omitempty"`
+	Context     []int64                `protobuf:"varint,4,rep,packed,name=context,proto3" json:"context,omitempty"`
+	// Unset (or zero fields) leaves the server's defaults in place.
+	Sampling      *SamplingParams `protobuf:"bytes,5,opt,name=sampling,proto3" json:"sampling,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -90,19 +92,90 @@ func (x *GenerateRequest) GetContext() []int64 {
 	return nil
 }
 
+func (x *GenerateRequest) GetSampling() *SamplingParams {
+	if x != nil {
+		return x.Sampling
+	}
+	return nil
+}
+
+// Generation parameters chosen by the controller for one call. Zero means the
+// server's default for that parameter.
+type SamplingParams struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Temperature   float32                `protobuf:"fixed32,1,opt,name=temperature,proto3" json:"temperature,omitempty"`
+	TopP          float32                `protobuf:"fixed32,2,opt,name=top_p,json=topP,proto3" json:"top_p,omitempty"`
+	MaxTokens     int32                  `protobuf:"varint,3,opt,name=max_tokens,json=maxTokens,proto3" json:"max_tokens,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SamplingParams) Reset() {
+	*x = SamplingParams{}
+	mi := &file_adaptive_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SamplingParams) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SamplingParams) ProtoMessage() {}
+
+func (x *SamplingParams) ProtoReflect() protoreflect.Message {
+	mi := &file_adaptive_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SamplingParams.ProtoReflect.Descriptor instead.
+func (*SamplingParams) Descriptor() ([]byte, []int) {
+	return file_adaptive_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *SamplingParams) GetTemperature() float32 {
+	if x != nil {
+		return x.Temperature
+	}
+	return 0
+}
+
+func (x *SamplingParams) GetTopP() float32 {
+	if x != nil {
+		return x.TopP
+	}
+	return 0
+}
+
+func (x *SamplingParams) GetMaxTokens() int32 {
+	if x != nil {
+		return x.MaxTokens
+	}
+	return 0
+}
+
 type GenerateResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Text          string                 `protobuf:"bytes,1,opt,name=text,proto3" json:"text,omitempty"`
 	Entropy       float32                `protobuf:"fixed32,2,opt,name=entropy,proto3" json:"entropy,omitempty"`
 	Logits        []float32              `protobuf:"fixed32,3,rep,packed,name=logits,proto3" json:"logits,omitempty"`
 	Context       []int64                `protobuf:"varint,4,rep,packed,name=context,proto3" json:"context,omitempty"`
+	ModelName     string                 `protobuf:"bytes,5,opt,name=model_name,json=modelName,proto3" json:"model_name,omitempty"`
+	ModelVersion  string                 `protobuf:"bytes,6,opt,name=model_version,json=modelVersion,proto3" json:"model_version,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *GenerateResponse) Reset() {
 	*x = GenerateResponse{}
-	mi := &file_adaptive_proto_msgTypes[1]
+	mi := &file_adaptive_proto_msgTypes[2]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -114,7 +187,7 @@ func (x *GenerateResponse) String() string {
 func (*GenerateResponse) ProtoMessage() {}
 
 func (x *GenerateResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_adaptive_proto_msgTypes[1]
+	mi := &file_adaptive_proto_msgTypes[2]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -127,7 +200,7 @@ func (x *GenerateResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GenerateResponse.ProtoReflect.Descriptor instead.
 func (*GenerateResponse) Descriptor() ([]byte, []int) {
-	return file_adaptive_proto_rawDescGZIP(), []int{1}
+	return file_adaptive_proto_rawDescGZIP(), []int{2}
 }
 
 func (x *GenerateResponse) GetText() string {
@@ -158,6 +231,75 @@ func (x *GenerateResponse) GetContext() []int64 {
 	return nil
 }
 
+func (x *GenerateResponse) GetModelName() string {
+	if x != nil {
+		return x.ModelName
+	}
+	return ""
+}
+
+func (x *GenerateResponse) GetModelVersion() string {
+	if x != nil {
+		return x.ModelVersion
+	}
+	return ""
+}
+
+// One piece of a streamed generation. Chunks before the last carry only
+// delta; the last carries only final, whose text is authoritative (it can
+// differ from the joined deltas when the server retried a think-only reply).
+type GenerateChunk struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Delta         string                 `protobuf:"bytes,1,opt,name=delta,proto3" json:"delta,omitempty"`
+	Final         *GenerateResponse      `protobuf:"bytes,2,opt,name=final,proto3" json:"final,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GenerateChunk) Reset() {
+	*x = GenerateChunk{}
+	mi := &file_adaptive_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GenerateChunk) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GenerateChunk) ProtoMessage() {}
+
+func (x *GenerateChunk) ProtoReflect() protoreflect.Message {
+	mi := &file_adaptive_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GenerateChunk.ProtoReflect.Descriptor instead.
+func (*GenerateChunk) Descriptor() ([]byte, []int) {
+	return file_adaptive_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *GenerateChunk) GetDelta() string {
+	if x != nil {
+		return x.Delta
+	}
+	return ""
+}
+
+func (x *GenerateChunk) GetFinal() *GenerateResponse {
+	if x != nil {
+		return x.Final
+	}
+	return nil
+}
+
 type EmbedRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Text          string                 `protobuf:"bytes,1,opt,name=text,proto3" json:"text,omitempty"`
@@ -167,7 +309,7 @@ type EmbedRequest struct {
 
 func (x *EmbedRequest) Reset() {
 	*x = EmbedRequest{}
-	mi := &file_adaptive_proto_msgTypes[2]
+	mi := &file_adaptive_proto_msgTypes[4]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -179,7 +321,7 @@ func (x *EmbedRequest) String() string {
 func (*EmbedRequest) ProtoMessage() {}
 
 func (x *EmbedRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_adaptive_proto_msgTypes[2]
+	mi := &file_adaptive_proto_msgTypes[4]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -192,7 +334,7 @@ func (x *EmbedRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use EmbedRequest.ProtoReflect.Descriptor instead.
 func (*EmbedRequest) Descriptor() ([]byte, []int) {
-	return file_adaptive_proto_rawDescGZIP(), []int{2}
+	return file_adaptive_proto_rawDescGZIP(), []int{4}
 }
 
 func (x *EmbedRequest) GetText() string {
@@ -211,7 +353,7 @@ type EmbedResponse struct {
 
 func (x *EmbedResponse) Reset() {
 	*x = EmbedResponse{}
-	mi := &file_adaptive_proto_msgTypes[3]
+	mi := &file_adaptive_proto_msgTypes[5]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -223,7 +365,7 @@ func (x *EmbedResponse) String() string {
 func (*EmbedResponse) ProtoMessage() {}
 
 func (x *EmbedResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_adaptive_proto_msgTypes[3]
+	mi := &file_adaptive_proto_msgTypes[5]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -236,7 +378,7 @@ func (x *EmbedResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use EmbedResponse.ProtoReflect.Descriptor instead.
 func (*EmbedResponse) Descriptor() ([]byte, []int) {
-	return file_adaptive_proto_rawDescGZIP(), []int{3}
+	return file_adaptive_proto_rawDescGZIP(), []int{5}
 }
 
 func (x *EmbedResponse) GetEmbedding() []float32 {
@@ -246,6 +388,139 @@ func (x *EmbedResponse) GetEmbedding() []float32 {
 	return nil
 }
 
+type EmbedBatchRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Texts         []string               `protobuf:"bytes,1,rep,name=texts,proto3" json:"texts,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *EmbedBatchRequest) Reset() {
+	*x = EmbedBatchRequest{}
+	mi := &file_adaptive_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *EmbedBatchRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EmbedBatchRequest) ProtoMessage() {}
+
+func (x *EmbedBatchRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_adaptive_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EmbedBatchRequest.ProtoReflect.Descriptor instead.
+func (*EmbedBatchRequest) Descriptor() ([]byte, []int) {
+	return file_adaptive_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *EmbedBatchRequest) GetTexts() []string {
+	if x != nil {
+		return x.Texts
+	}
+	return nil
+}
+
+type Embedding struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Values        []float32              `protobuf:"fixed32,1,rep,packed,name=values,proto3" json:"values,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Embedding) Reset() {
+	*x = Embedding{}
+	mi := &file_adaptive_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Embedding) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Embedding) ProtoMessage() {}
+
+func (x *Embedding) ProtoReflect() protoreflect.Message {
+	mi := &file_adaptive_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Embedding.ProtoReflect.Descriptor instead.
+func (*Embedding) Descriptor() ([]byte, []int) {
+	return file_adaptive_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *Embedding) GetValues() []float32 {
+	if x != nil {
+		return x.Values
+	}
+	return nil
+}
+
+type EmbedBatchResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// embeddings[i] is the embedding of texts[i].
+	Embeddings    []*Embedding `protobuf:"bytes,1,rep,name=embeddings,proto3" json:"embeddings,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *EmbedBatchResponse) Reset() {
+	*x = EmbedBatchResponse{}
+	mi := &file_adaptive_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *EmbedBatchResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EmbedBatchResponse) ProtoMessage() {}
+
+func (x *EmbedBatchResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_adaptive_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EmbedBatchResponse.ProtoReflect.Descriptor instead.
+func (*EmbedBatchResponse) Descriptor() ([]byte, []int) {
+	return file_adaptive_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *EmbedBatchResponse) GetEmbeddings() []*Embedding {
+	if x != nil {
+		return x.Embeddings
+	}
+	return nil
+}
+
 type SearchRequest struct {
 	state               protoimpl.MessageState `protogen:"open.v1"`
 	QueryText           string                 `protobuf:"bytes,1,opt,name=query_text,json=queryText,proto3" json:"query_text,omitempty"`
@@ -258,7 +533,7 @@ type SearchRequest struct {
 
 func (x *SearchRequest) Reset() {
 	*x = SearchRequest{}
-	mi := &file_adaptive_proto_msgTypes[4]
+	mi := &file_adaptive_proto_msgTypes[9]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -270,7 +545,7 @@ func (x *SearchRequest) String() string {
 func (*SearchRequest) ProtoMessage() {}
 
 func (x *SearchRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_adaptive_proto_msgTypes[4]
+	mi := &file_adaptive_proto_msgTypes[9]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -283,7 +558,7 @@ func (x *SearchRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use SearchRequest.ProtoReflect.Descriptor instead.
 func (*SearchRequest) Descriptor() ([]byte, []int) {
-	return file_adaptive_proto_rawDescGZIP(), []int{4}
+	return file_adaptive_proto_rawDescGZIP(), []int{9}
 }
 
 func (x *SearchRequest) GetQueryText() string {
@@ -326,7 +601,7 @@ type SearchResult struct {
 
 func (x *SearchResult) Reset() {
 	*x = SearchResult{}
-	mi := &file_adaptive_proto_msgTypes[5]
+	mi := &file_adaptive_proto_msgTypes[10]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -338,7 +613,7 @@ func (x *SearchResult) String() string {
 func (*SearchResult) ProtoMessage() {}
 
 func (x *SearchResult) ProtoReflect() protoreflect.Message {
-	mi := &file_adaptive_proto_msgTypes[5]
+	mi := &file_adaptive_proto_msgTypes[10]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -351,7 +626,7 @@ func (x *SearchResult) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use SearchResult.ProtoReflect.Descriptor instead.
 func (*SearchResult) Descriptor() ([]byte, []int) {
-	return file_adaptive_proto_rawDescGZIP(), []int{5}
+	return file_adaptive_proto_rawDescGZIP(), []int{10}
 }
 
 func (x *SearchResult) GetId() string {
@@ -391,7 +666,7 @@ type SearchResponse struct {
 
 func (x *SearchResponse) Reset() {
 	*x = SearchResponse{}
-	mi := &file_adaptive_proto_msgTypes[6]
+	mi := &file_adaptive_proto_msgTypes[11]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -403,7 +678,7 @@ func (x *SearchResponse) String() string {
 func (*SearchResponse) ProtoMessage() {}
 
 func (x *SearchResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_adaptive_proto_msgTypes[6]
+	mi := &file_adaptive_proto_msgTypes[11]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -416,7 +691,7 @@ func (x *SearchResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use SearchResponse.ProtoReflect.Descriptor instead.
 func (*SearchResponse) Descriptor() ([]byte, []int) {
-	return file_adaptive_proto_rawDescGZIP(), []int{6}
+	return file_adaptive_proto_rawDescGZIP(), []int{11}
 }
 
 func (x *SearchResponse) GetResults() []*SearchResult {
@@ -436,7 +711,7 @@ type StoreEvidenceRequest struct {
 
 func (x *StoreEvidenceRequest) Reset() {
 	*x = StoreEvidenceRequest{}
-	mi := &file_adaptive_proto_msgTypes[7]
+	mi := &file_adaptive_proto_msgTypes[12]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -448,7 +723,7 @@ func (x *StoreEvidenceRequest) String() string {
 func (*StoreEvidenceRequest) ProtoMessage() {}
 
 func (x *StoreEvidenceRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_adaptive_proto_msgTypes[7]
+	mi := &file_adaptive_proto_msgTypes[12]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -461,7 +736,7 @@ func (x *StoreEvidenceRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use StoreEvidenceRequest.ProtoReflect.Descriptor instead.
 func (*StoreEvidenceRequest) Descriptor() ([]byte, []int) {
-	return file_adaptive_proto_rawDescGZIP(), []int{7}
+	return file_adaptive_proto_rawDescGZIP(), []int{12}
 }
 
 func (x *StoreEvidenceRequest) GetText() string {
@@ -487,7 +762,7 @@ type StoreEvidenceResponse struct {
 
 func (x *StoreEvidenceResponse) Reset() {
 	*x = StoreEvidenceResponse{}
-	mi := &file_adaptive_proto_msgTypes[8]
+	mi := &file_adaptive_proto_msgTypes[13]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -499,7 +774,7 @@ func (x *StoreEvidenceResponse) String() string {
 func (*StoreEvidenceResponse) ProtoMessage() {}
 
 func (x *StoreEvidenceResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_adaptive_proto_msgTypes[8]
+	mi := &file_adaptive_proto_msgTypes[13]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -512,7 +787,7 @@ func (x *StoreEvidenceResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use StoreEvidenceResponse.ProtoReflect.Descriptor instead.
 func (*StoreEvidenceResponse) Descriptor() ([]byte, []int) {
-	return file_adaptive_proto_rawDescGZIP(), []int{8}
+	return file_adaptive_proto_rawDescGZIP(), []int{13}
 }
 
 func (x *StoreEvidenceResponse) GetId() string {
@@ -532,7 +807,7 @@ type WebSearchRequest struct {
 
 func (x *WebSearchRequest) Reset() {
 	*x = WebSearchRequest{}
-	mi := &file_adaptive_proto_msgTypes[9]
+	mi := &file_adaptive_proto_msgTypes[14]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -544,7 +819,7 @@ func (x *WebSearchRequest) String() string {
 func (*WebSearchRequest) ProtoMessage() {}
 
 func (x *WebSearchRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_adaptive_proto_msgTypes[9]
+	mi := &file_adaptive_proto_msgTypes[14]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -557,7 +832,7 @@ func (x *WebSearchRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use WebSearchRequest.ProtoReflect.Descriptor instead.
 func (*WebSearchRequest) Descriptor() ([]byte, []int) {
-	return file_adaptive_proto_rawDescGZIP(), []int{9}
+	return file_adaptive_proto_rawDescGZIP(), []int{14}
 }
 
 func (x *WebSearchRequest) GetQuery() string {
@@ -585,7 +860,7 @@ type WebSearchResult struct {
 
 func (x *WebSearchResult) Reset() {
 	*x = WebSearchResult{}
-	mi := &file_adaptive_proto_msgTypes[10]
+	mi := &file_adaptive_proto_msgTypes[15]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -597,7 +872,7 @@ func (x *WebSearchResult) String() string {
 func (*WebSearchResult) ProtoMessage() {}
 
 func (x *WebSearchResult) ProtoReflect() protoreflect.Message {
-	mi := &file_adaptive_proto_msgTypes[10]
+	mi := &file_adaptive_proto_msgTypes[15]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -610,7 +885,7 @@ func (x *WebSearchResult) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use WebSearchResult.ProtoReflect.Descriptor instead.
 func (*WebSearchResult) Descriptor() ([]byte, []int) {
-	return file_adaptive_proto_rawDescGZIP(), []int{10}
+	return file_adaptive_proto_rawDescGZIP(), []int{15}
 }
 
 func (x *WebSearchResult) GetTitle() string {
@@ -643,7 +918,7 @@ type WebSearchResponse struct {
 
 func (x *WebSearchResponse) Reset() {
 	*x = WebSearchResponse{}
-	mi := &file_adaptive_proto_msgTypes[11]
+	mi := &file_adaptive_proto_msgTypes[16]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -655,7 +930,7 @@ func (x *WebSearchResponse) String() string {
 func (*WebSearchResponse) ProtoMessage() {}
 
 func (x *WebSearchResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_adaptive_proto_msgTypes[11]
+	mi := &file_adaptive_proto_msgTypes[16]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -668,7 +943,7 @@ func (x *WebSearchResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use WebSearchResponse.ProtoReflect.Descriptor instead.
 func (*WebSearchResponse) Descriptor() ([]byte, []int) {
-	return file_adaptive_proto_rawDescGZIP(), []int{11}
+	return file_adaptive_proto_rawDescGZIP(), []int{16}
 }
 
 func (x *WebSearchResponse) GetResults() []*WebSearchResult {
@@ -687,7 +962,7 @@ type DeleteEvidenceRequest struct {
 
 func (x *DeleteEvidenceRequest) Reset() {
 	*x = DeleteEvidenceRequest{}
-	mi := &file_adaptive_proto_msgTypes[12]
+	mi := &file_adaptive_proto_msgTypes[17]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -699,7 +974,7 @@ func (x *DeleteEvidenceRequest) String() string {
 func (*DeleteEvidenceRequest) ProtoMessage() {}
 
 func (x *DeleteEvidenceRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_adaptive_proto_msgTypes[12]
+	mi := &file_adaptive_proto_msgTypes[17]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -712,7 +987,7 @@ func (x *DeleteEvidenceRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use DeleteEvidenceRequest.ProtoReflect.Descriptor instead.
 func (*DeleteEvidenceRequest) Descriptor() ([]byte, []int) {
-	return file_adaptive_proto_rawDescGZIP(), []int{12}
+	return file_adaptive_proto_rawDescGZIP(), []int{17}
 }
 
 func (x *DeleteEvidenceRequest) GetIds() []string {
@@ -731,7 +1006,7 @@ type DeleteEvidenceResponse struct {
 
 func (x *DeleteEvidenceResponse) Reset() {
 	*x = DeleteEvidenceResponse{}
-	mi := &file_adaptive_proto_msgTypes[13]
+	mi := &file_adaptive_proto_msgTypes[18]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -743,7 +1018,7 @@ func (x *DeleteEvidenceResponse) String() string {
 func (*DeleteEvidenceResponse) ProtoMessage() {}
 
 func (x *DeleteEvidenceResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_adaptive_proto_msgTypes[13]
+	mi := &file_adaptive_proto_msgTypes[18]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -756,7 +1031,7 @@ func (x *DeleteEvidenceResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use DeleteEvidenceResponse.ProtoReflect.Descriptor instead.
 func (*DeleteEvidenceResponse) Descriptor() ([]byte, []int) {
-	return file_adaptive_proto_rawDescGZIP(), []int{13}
+	return file_adaptive_proto_rawDescGZIP(), []int{18}
 }
 
 func (x *DeleteEvidenceResponse) GetDeletedCount() int32 {
@@ -766,6 +1041,113 @@ func (x *DeleteEvidenceResponse) GetDeletedCount() int32 {
 	return 0
 }
 
+type UpdateEvidenceMetadataRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Id    string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	// JSON object of keys to set; a null value removes the key.
+	MetadataJson  string `protobuf:"bytes,2,opt,name=metadata_json,json=metadataJson,proto3" json:"metadata_json,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UpdateEvidenceMetadataRequest) Reset() {
+	*x = UpdateEvidenceMetadataRequest{}
+	mi := &file_adaptive_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateEvidenceMetadataRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateEvidenceMetadataRequest) ProtoMessage() {}
+
+func (x *UpdateEvidenceMetadataRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_adaptive_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateEvidenceMetadataRequest.ProtoReflect.Descriptor instead.
+func (*UpdateEvidenceMetadataRequest) Descriptor() ([]byte, []int) {
+	return file_adaptive_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *UpdateEvidenceMetadataRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *UpdateEvidenceMetadataRequest) GetMetadataJson() string {
+	if x != nil {
+		return x.MetadataJson
+	}
+	return ""
+}
+
+// found is false when no item has the ID, and nothing changed; metadata_json
+// is the item's metadata after the update.
+type UpdateEvidenceMetadataResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Found         bool                   `protobuf:"varint,1,opt,name=found,proto3" json:"found,omitempty"`
+	MetadataJson  string                 `protobuf:"bytes,2,opt,name=metadata_json,json=metadataJson,proto3" json:"metadata_json,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UpdateEvidenceMetadataResponse) Reset() {
+	*x = UpdateEvidenceMetadataResponse{}
+	mi := &file_adaptive_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateEvidenceMetadataResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateEvidenceMetadataResponse) ProtoMessage() {}
+
+func (x *UpdateEvidenceMetadataResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_adaptive_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateEvidenceMetadataResponse.ProtoReflect.Descriptor instead.
+func (*UpdateEvidenceMetadataResponse) Descriptor() ([]byte, []int) {
+	return file_adaptive_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *UpdateEvidenceMetadataResponse) GetFound() bool {
+	if x != nil {
+		return x.Found
+	}
+	return false
+}
+
+func (x *UpdateEvidenceMetadataResponse) GetMetadataJson() string {
+	if x != nil {
+		return x.MetadataJson
+	}
+	return ""
+}
+
 type GetByIDsRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Ids           []string               `protobuf:"bytes,1,rep,name=ids,proto3" json:"ids,omitempty"`
@@ -775,7 +1157,7 @@ type GetByIDsRequest struct {
 
 func (x *GetByIDsRequest) Reset() {
 	*x = GetByIDsRequest{}
-	mi := &file_adaptive_proto_msgTypes[14]
+	mi := &file_adaptive_proto_msgTypes[21]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -787,7 +1169,7 @@ func (x *GetByIDsRequest) String() string {
 func (*GetByIDsRequest) ProtoMessage() {}
 
 func (x *GetByIDsRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_adaptive_proto_msgTypes[14]
+	mi := &file_adaptive_proto_msgTypes[21]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -800,7 +1182,7 @@ func (x *GetByIDsRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GetByIDsRequest.ProtoReflect.Descriptor instead.
 func (*GetByIDsRequest) Descriptor() ([]byte, []int) {
-	return file_adaptive_proto_rawDescGZIP(), []int{14}
+	return file_adaptive_proto_rawDescGZIP(), []int{21}
 }
 
 func (x *GetByIDsRequest) GetIds() []string {
@@ -819,7 +1201,7 @@ type GetByIDsResponse struct {
 
 func (x *GetByIDsResponse) Reset() {
 	*x = GetByIDsResponse{}
-	mi := &file_adaptive_proto_msgTypes[15]
+	mi := &file_adaptive_proto_msgTypes[22]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -831,7 +1213,7 @@ func (x *GetByIDsResponse) String() string {
 func (*GetByIDsResponse) ProtoMessage() {}
 
 func (x *GetByIDsResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_adaptive_proto_msgTypes[15]
+	mi := &file_adaptive_proto_msgTypes[22]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -844,7 +1226,7 @@ func (x *GetByIDsResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GetByIDsResponse.ProtoReflect.Descriptor instead.
 func (*GetByIDsResponse) Descriptor() ([]byte, []int) {
-	return file_adaptive_proto_rawDescGZIP(), []int{15}
+	return file_adaptive_proto_rawDescGZIP(), []int{22}
 }
 
 func (x *GetByIDsResponse) GetResults() []*SearchResult {
@@ -862,7 +1244,7 @@ type ListAllEvidenceRequest struct {
 
 func (x *ListAllEvidenceRequest) Reset() {
 	*x = ListAllEvidenceRequest{}
-	mi := &file_adaptive_proto_msgTypes[16]
+	mi := &file_adaptive_proto_msgTypes[23]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -874,7 +1256,7 @@ func (x *ListAllEvidenceRequest) String() string {
 func (*ListAllEvidenceRequest) ProtoMessage() {}
 
 func (x *ListAllEvidenceRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_adaptive_proto_msgTypes[16]
+	mi := &file_adaptive_proto_msgTypes[23]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -887,7 +1269,7 @@ func (x *ListAllEvidenceRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ListAllEvidenceRequest.ProtoReflect.Descriptor instead.
 func (*ListAllEvidenceRequest) Descriptor() ([]byte, []int) {
-	return file_adaptive_proto_rawDescGZIP(), []int{16}
+	return file_adaptive_proto_rawDescGZIP(), []int{23}
 }
 
 type ListAllEvidenceResponse struct {
@@ -899,7 +1281,7 @@ type ListAllEvidenceResponse struct {
 
 func (x *ListAllEvidenceResponse) Reset() {
 	*x = ListAllEvidenceResponse{}
-	mi := &file_adaptive_proto_msgTypes[17]
+	mi := &file_adaptive_proto_msgTypes[24]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -911,7 +1293,7 @@ func (x *ListAllEvidenceResponse) String() string {
 func (*ListAllEvidenceResponse) ProtoMessage() {}
 
 func (x *ListAllEvidenceResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_adaptive_proto_msgTypes[17]
+	mi := &file_adaptive_proto_msgTypes[24]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -924,7 +1306,7 @@ func (x *ListAllEvidenceResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ListAllEvidenceResponse.ProtoReflect.Descriptor instead.
 func (*ListAllEvidenceResponse) Descriptor() ([]byte, []int) {
-	return file_adaptive_proto_rawDescGZIP(), []int{17}
+	return file_adaptive_proto_rawDescGZIP(), []int{24}
 }
 
 func (x *ListAllEvidenceResponse) GetResults() []*SearchResult {
@@ -934,25 +1316,153 @@ func (x *ListAllEvidenceResponse) GetResults() []*SearchResult {
 	return nil
 }
 
+type HandshakeRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// protocol_version the client was built against.
+	ProtocolVersion int32 `protobuf:"varint,1,opt,name=protocol_version,json=protocolVersion,proto3" json:"protocol_version,omitempty"`
+	// schema_fingerprint of the client's compiled bindings.
+	SchemaFingerprint string `protobuf:"bytes,2,opt,name=schema_fingerprint,json=schemaFingerprint,proto3" json:"schema_fingerprint,omitempty"`
+	unknownFields     protoimpl.UnknownFields
+	sizeCache         protoimpl.SizeCache
+}
+
+func (x *HandshakeRequest) Reset() {
+	*x = HandshakeRequest{}
+	mi := &file_adaptive_proto_msgTypes[25]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HandshakeRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HandshakeRequest) ProtoMessage() {}
+
+func (x *HandshakeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_adaptive_proto_msgTypes[25]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HandshakeRequest.ProtoReflect.Descriptor instead.
+func (*HandshakeRequest) Descriptor() ([]byte, []int) {
+	return file_adaptive_proto_rawDescGZIP(), []int{25}
+}
+
+func (x *HandshakeRequest) GetProtocolVersion() int32 {
+	if x != nil {
+		return x.ProtocolVersion
+	}
+	return 0
+}
+
+func (x *HandshakeRequest) GetSchemaFingerprint() string {
+	if x != nil {
+		return x.SchemaFingerprint
+	}
+	return ""
+}
+
+type HandshakeResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// protocol_version the server was built against.
+	ProtocolVersion int32 `protobuf:"varint,1,opt,name=protocol_version,json=protocolVersion,proto3" json:"protocol_version,omitempty"`
+	// schema_fingerprint of the server's compiled bindings.
+	SchemaFingerprint string `protobuf:"bytes,2,opt,name=schema_fingerprint,json=schemaFingerprint,proto3" json:"schema_fingerprint,omitempty"`
+	unknownFields     protoimpl.UnknownFields
+	sizeCache         protoimpl.SizeCache
+}
+
+func (x *HandshakeResponse) Reset() {
+	*x = HandshakeResponse{}
+	mi := &file_adaptive_proto_msgTypes[26]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HandshakeResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HandshakeResponse) ProtoMessage() {}
+
+func (x *HandshakeResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_adaptive_proto_msgTypes[26]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HandshakeResponse.ProtoReflect.Descriptor instead.
+func (*HandshakeResponse) Descriptor() ([]byte, []int) {
+	return file_adaptive_proto_rawDescGZIP(), []int{26}
+}
+
+func (x *HandshakeResponse) GetProtocolVersion() int32 {
+	if x != nil {
+		return x.ProtocolVersion
+	}
+	return 0
+}
+
+func (x *HandshakeResponse) GetSchemaFingerprint() string {
+	if x != nil {
+		return x.SchemaFingerprint
+	}
+	return ""
+}
+
 var File_adaptive_proto protoreflect.FileDescriptor
 
 const file_adaptive_proto_rawDesc = "" +
 	"\n" +
-	"\x0eadaptive.proto\x12\badaptive\"\x82\x01\n" +
+	"\x0eadaptive.proto\x12\badaptive\"\xb8\x01\n" +
 	"\x0fGenerateRequest\x12\x16\n" +
 	"\x06prompt\x18\x01 \x01(\tR\x06prompt\x12!\n" +
 	"\fstate_vector\x18\x02 \x03(\x02R\vstateVector\x12\x1a\n" +
 	"\bevidence\x18\x03 \x03(\tR\bevidence\x12\x18\n" +
-	"\acontext\x18\x04 \x03(\x03R\acontext\"r\n" +
+	"\acontext\x18\x04 \x03(\x03R\acontext\x124\n" +
+	"\bsampling\x18\x05 \x01(\v2\x18.adaptive.SamplingParamsR\bsampling\"f\n" +
+	"\x0eSamplingParams\x12 \n" +
+	"\vtemperature\x18\x01 \x01(\x02R\vtemperature\x12\x13\n" +
+	"\x05top_p\x18\x02 \x01(\x02R\x04topP\x12\x1d\n" +
+	"\n" +
+	"max_tokens\x18\x03 \x01(\x05R\tmaxTokens\"\xb6\x01\n" +
 	"\x10GenerateResponse\x12\x12\n" +
 	"\x04text\x18\x01 \x01(\tR\x04text\x12\x18\n" +
 	"\aentropy\x18\x02 \x01(\x02R\aentropy\x12\x16\n" +
 	"\x06logits\x18\x03 \x03(\x02R\x06logits\x12\x18\n" +
-	"\acontext\x18\x04 \x03(\x03R\acontext\"\"\n" +
+	"\acontext\x18\x04 \x03(\x03R\acontext\x12\x1d\n" +
+	"\n" +
+	"model_name\x18\x05 \x01(\tR\tmodelName\x12#\n" +
+	"\rmodel_version\x18\x06 \x01(\tR\fmodelVersion\"W\n" +
+	"\rGenerateChunk\x12\x14\n" +
+	"\x05delta\x18\x01 \x01(\tR\x05delta\x120\n" +
+	"\x05final\x18\x02 \x01(\v2\x1a.adaptive.GenerateResponseR\x05final\"\"\n" +
 	"\fEmbedRequest\x12\x12\n" +
 	"\x04text\x18\x01 \x01(\tR\x04text\"-\n" +
 	"\rEmbedResponse\x12\x1c\n" +
-	"\tembedding\x18\x01 \x03(\x02R\tembedding\"\x9f\x01\n" +
+	"\tembedding\x18\x01 \x03(\x02R\tembedding\")\n" +
+	"\x11EmbedBatchRequest\x12\x14\n" +
+	"\x05texts\x18\x01 \x03(\tR\x05texts\"#\n" +
+	"\tEmbedding\x12\x16\n" +
+	"\x06values\x18\x01 \x03(\x02R\x06values\"I\n" +
+	"\x12EmbedBatchResponse\x123\n" +
+	"\n" +
+	"embeddings\x18\x01 \x03(\v2\x13.adaptive.EmbeddingR\n" +
+	"embeddings\"\x9f\x01\n" +
 	"\rSearchRequest\x12\x1d\n" +
 	"\n" +
 	"query_text\x18\x01 \x01(\tR\tqueryText\x12'\n" +
@@ -984,23 +1494,40 @@ const file_adaptive_proto_rawDesc = "" +
 	"\x15DeleteEvidenceRequest\x12\x10\n" +
 	"\x03ids\x18\x01 \x03(\tR\x03ids\"=\n" +
 	"\x16DeleteEvidenceResponse\x12#\n" +
-	"\rdeleted_count\x18\x01 \x01(\x05R\fdeletedCount\"#\n" +
+	"\rdeleted_count\x18\x01 \x01(\x05R\fdeletedCount\"T\n" +
+	"\x1dUpdateEvidenceMetadataRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12#\n" +
+	"\rmetadata_json\x18\x02 \x01(\tR\fmetadataJson\"[\n" +
+	"\x1eUpdateEvidenceMetadataResponse\x12\x14\n" +
+	"\x05found\x18\x01 \x01(\bR\x05found\x12#\n" +
+	"\rmetadata_json\x18\x02 \x01(\tR\fmetadataJson\"#\n" +
 	"\x0fGetByIDsRequest\x12\x10\n" +
 	"\x03ids\x18\x01 \x03(\tR\x03ids\"D\n" +
 	"\x10GetByIDsResponse\x120\n" +
 	"\aresults\x18\x01 \x03(\v2\x16.adaptive.SearchResultR\aresults\"\x18\n" +
 	"\x16ListAllEvidenceRequest\"K\n" +
 	"\x17ListAllEvidenceResponse\x120\n" +
-	"\aresults\x18\x01 \x03(\v2\x16.adaptive.SearchResultR\aresults2\xd0\x04\n" +
+	"\aresults\x18\x01 \x03(\v2\x16.adaptive.SearchResultR\aresults\"l\n" +
+	"\x10HandshakeRequest\x12)\n" +
+	"\x10protocol_version\x18\x01 \x01(\x05R\x0fprotocolVersion\x12-\n" +
+	"\x12schema_fingerprint\x18\x02 \x01(\tR\x11schemaFingerprint\"m\n" +
+	"\x11HandshakeResponse\x12)\n" +
+	"\x10protocol_version\x18\x01 \x01(\x05R\x0fprotocolVersion\x12-\n" +
+	"\x12schema_fingerprint\x18\x02 \x01(\tR\x11schemaFingerprint2\x94\a\n" +
 	"\fCodecService\x12A\n" +
-	"\bGenerate\x12\x19.adaptive.GenerateRequest\x1a\x1a.adaptive.GenerateResponse\x128\n" +
-	"\x05Embed\x12\x16.adaptive.EmbedRequest\x1a\x17.adaptive.EmbedResponse\x12;\n" +
+	"\bGenerate\x12\x19.adaptive.GenerateRequest\x1a\x1a.adaptive.GenerateResponse\x12F\n" +
+	"\x0eGenerateStream\x12\x19.adaptive.GenerateRequest\x1a\x17.adaptive.GenerateChunk0\x01\x128\n" +
+	"\x05Embed\x12\x16.adaptive.EmbedRequest\x1a\x17.adaptive.EmbedResponse\x12G\n" +
+	"\n" +
+	"EmbedBatch\x12\x1b.adaptive.EmbedBatchRequest\x1a\x1c.adaptive.EmbedBatchResponse\x12;\n" +
 	"\x06Search\x12\x17.adaptive.SearchRequest\x1a\x18.adaptive.SearchResponse\x12P\n" +
 	"\rStoreEvidence\x12\x1e.adaptive.StoreEvidenceRequest\x1a\x1f.adaptive.StoreEvidenceResponse\x12D\n" +
 	"\tWebSearch\x12\x1a.adaptive.WebSearchRequest\x1a\x1b.adaptive.WebSearchResponse\x12S\n" +
-	"\x0eDeleteEvidence\x12\x1f.adaptive.DeleteEvidenceRequest\x1a .adaptive.DeleteEvidenceResponse\x12A\n" +
+	"\x0eDeleteEvidence\x12\x1f.adaptive.DeleteEvidenceRequest\x1a .adaptive.DeleteEvidenceResponse\x12k\n" +
+	"\x16UpdateEvidenceMetadata\x12'.adaptive.UpdateEvidenceMetadataRequest\x1a(.adaptive.UpdateEvidenceMetadataResponse\x12A\n" +
 	"\bGetByIDs\x12\x19.adaptive.GetByIDsRequest\x1a\x1a.adaptive.GetByIDsResponse\x12V\n" +
-	"\x0fListAllEvidence\x12 .adaptive.ListAllEvidenceRequest\x1a!.adaptive.ListAllEvidenceResponseBFZDgithub.com/danielpatrickdp/adaptive-state/go-controller/gen/adaptiveb\x06proto3"
+	"\x0fListAllEvidence\x12 .adaptive.ListAllEvidenceRequest\x1a!.adaptive.ListAllEvidenceResponse\x12D\n" +
+	"\tHandshake\x12\x1a.adaptive.HandshakeRequest\x1a\x1b.adaptive.HandshakeResponseBFZDgithub.com/danielpatrickdp/adaptive-state/go-controller/gen/adaptiveb\x06proto3"
 
 var (
 	file_adaptive_proto_rawDescOnce sync.Once
@@ -1014,53 +1541,73 @@ func file_adaptive_proto_rawDescGZIP() []byte {
 	return file_adaptive_proto_rawDescData
 }
 
-var file_adaptive_proto_msgTypes = make([]protoimpl.MessageInfo, 18)
+var file_adaptive_proto_msgTypes = make([]protoimpl.MessageInfo, 27)
 var file_adaptive_proto_goTypes = []any{
-	(*GenerateRequest)(nil),         // 0: adaptive.GenerateRequest
-	(*GenerateResponse)(nil),        // 1: adaptive.GenerateResponse
-	(*EmbedRequest)(nil),            // 2: adaptive.EmbedRequest
-	(*EmbedResponse)(nil),           // 3: adaptive.EmbedResponse
-	(*SearchRequest)(nil),           // 4: adaptive.SearchRequest
-	(*SearchResult)(nil),            // 5: adaptive.SearchResult
-	(*SearchResponse)(nil),          // 6: adaptive.SearchResponse
-	(*StoreEvidenceRequest)(nil),    // 7: adaptive.StoreEvidenceRequest
-	(*StoreEvidenceResponse)(nil),   // 8: adaptive.StoreEvidenceResponse
-	(*WebSearchRequest)(nil),        // 9: adaptive.WebSearchRequest
-	(*WebSearchResult)(nil),         // 10: adaptive.WebSearchResult
-	(*WebSearchResponse)(nil),       // 11: adaptive.WebSearchResponse
-	(*DeleteEvidenceRequest)(nil),   // 12: adaptive.DeleteEvidenceRequest
-	(*DeleteEvidenceResponse)(nil),  // 13: adaptive.DeleteEvidenceResponse
-	(*GetByIDsRequest)(nil),         // 14: adaptive.GetByIDsRequest
-	(*GetByIDsResponse)(nil),        // 15: adaptive.GetByIDsResponse
-	(*ListAllEvidenceRequest)(nil),  // 16: adaptive.ListAllEvidenceRequest
-	(*ListAllEvidenceResponse)(nil), // 17: adaptive.ListAllEvidenceResponse
+	(*GenerateRequest)(nil),                // 0: adaptive.GenerateRequest
+	(*SamplingParams)(nil),                 // 1: adaptive.SamplingParams
+	(*GenerateResponse)(nil),               // 2: adaptive.GenerateResponse
+	(*GenerateChunk)(nil),                  // 3: adaptive.GenerateChunk
+	(*EmbedRequest)(nil),                   // 4: adaptive.EmbedRequest
+	(*EmbedResponse)(nil),                  // 5: adaptive.EmbedResponse
+	(*EmbedBatchRequest)(nil),              // 6: adaptive.EmbedBatchRequest
+	(*Embedding)(nil),                      // 7: adaptive.Embedding
+	(*EmbedBatchResponse)(nil),             // 8: adaptive.EmbedBatchResponse
+	(*SearchRequest)(nil),                  // 9: adaptive.SearchRequest
+	(*SearchResult)(nil),                   // 10: adaptive.SearchResult
+	(*SearchResponse)(nil),                 // 11: adaptive.SearchResponse
+	(*StoreEvidenceRequest)(nil),           // 12: adaptive.StoreEvidenceRequest
+	(*StoreEvidenceResponse)(nil),          // 13: adaptive.StoreEvidenceResponse
+	(*WebSearchRequest)(nil),               // 14: adaptive.WebSearchRequest
+	(*WebSearchResult)(nil),                // 15: adaptive.WebSearchResult
+	(*WebSearchResponse)(nil),              // 16: adaptive.WebSearchResponse
+	(*DeleteEvidenceRequest)(nil),          // 17: adaptive.DeleteEvidenceRequest
+	(*DeleteEvidenceResponse)(nil),         // 18: adaptive.DeleteEvidenceResponse
+	(*UpdateEvidenceMetadataRequest)(nil),  // 19: adaptive.UpdateEvidenceMetadataRequest
+	(*UpdateEvidenceMetadataResponse)(nil), // 20: adaptive.UpdateEvidenceMetadataResponse
+	(*GetByIDsRequest)(nil),                // 21: adaptive.GetByIDsRequest
+	(*GetByIDsResponse)(nil),               // 22: adaptive.GetByIDsResponse
+	(*ListAllEvidenceRequest)(nil),         // 23: adaptive.ListAllEvidenceRequest
+	(*ListAllEvidenceResponse)(nil),        // 24: adaptive.ListAllEvidenceResponse
+	(*HandshakeRequest)(nil),               // 25: adaptive.HandshakeRequest
+	(*HandshakeResponse)(nil),              // 26: adaptive.HandshakeResponse
 }
 var file_adaptive_proto_depIdxs = []int32{
-	5,  // 0: adaptive.SearchResponse.results:type_name -> adaptive.SearchResult
-	10, // 1: adaptive.WebSearchResponse.results:type_name -> adaptive.WebSearchResult
-	5,  // 2: adaptive.GetByIDsResponse.results:type_name -> adaptive.SearchResult
-	5,  // 3: adaptive.ListAllEvidenceResponse.results:type_name -> adaptive.SearchResult
-	0,  // 4: adaptive.CodecService.Generate:input_type -> adaptive.GenerateRequest
-	2,  // 5: adaptive.CodecService.Embed:input_type -> adaptive.EmbedRequest
-	4,  // 6: adaptive.CodecService.Search:input_type -> adaptive.SearchRequest
-	7,  // 7: adaptive.CodecService.StoreEvidence:input_type -> adaptive.StoreEvidenceRequest
-	9,  // 8: adaptive.CodecService.WebSearch:input_type -> adaptive.WebSearchRequest
-	12, // 9: adaptive.CodecService.DeleteEvidence:input_type -> adaptive.DeleteEvidenceRequest
-	14, // 10: adaptive.CodecService.GetByIDs:input_type -> adaptive.GetByIDsRequest
-	16, // 11: adaptive.CodecService.ListAllEvidence:input_type -> adaptive.ListAllEvidenceRequest
-	1,  // 12: adaptive.CodecService.Generate:output_type -> adaptive.GenerateResponse
-	3,  // 13: adaptive.CodecService.Embed:output_type -> adaptive.EmbedResponse
-	6,  // 14: adaptive.CodecService.Search:output_type -> adaptive.SearchResponse
-	8,  // 15: adaptive.CodecService.StoreEvidence:output_type -> adaptive.StoreEvidenceResponse
-	11, // 16: adaptive.CodecService.WebSearch:output_type -> adaptive.WebSearchResponse
-	13, // 17: adaptive.CodecService.DeleteEvidence:output_type -> adaptive.DeleteEvidenceResponse
-	15, // 18: adaptive.CodecService.GetByIDs:output_type -> adaptive.GetByIDsResponse
-	17, // 19: adaptive.CodecService.ListAllEvidence:output_type -> adaptive.ListAllEvidenceResponse
-	12, // [12:20] is the sub-list for method output_type
-	4,  // [4:12] is the sub-list for method input_type
-	4,  // [4:4] is the sub-list for extension type_name
-	4,  // [4:4] is the sub-list for extension extendee
-	0,  // [0:4] is the sub-list for field type_name
+	1,  // 0: adaptive.GenerateRequest.sampling:type_name -> adaptive.SamplingParams
+	2,  // 1: adaptive.GenerateChunk.final:type_name -> adaptive.GenerateResponse
+	7,  // 2: adaptive.EmbedBatchResponse.embeddings:type_name -> adaptive.Embedding
+	10, // 3: adaptive.SearchResponse.results:type_name -> adaptive.SearchResult
+	15, // 4: adaptive.WebSearchResponse.results:type_name -> adaptive.WebSearchResult
+	10, // 5: adaptive.GetByIDsResponse.results:type_name -> adaptive.SearchResult
+	10, // 6: adaptive.ListAllEvidenceResponse.results:type_name -> adaptive.SearchResult
+	0,  // 7: adaptive.CodecService.Generate:input_type -> adaptive.GenerateRequest
+	0,  // 8: adaptive.CodecService.GenerateStream:input_type -> adaptive.GenerateRequest
+	4,  // 9: adaptive.CodecService.Embed:input_type -> adaptive.EmbedRequest
+	6,  // 10: adaptive.CodecService.EmbedBatch:input_type -> adaptive.EmbedBatchRequest
+	9,  // 11: adaptive.CodecService.Search:input_type -> adaptive.SearchRequest
+	12, // 12: adaptive.CodecService.StoreEvidence:input_type -> adaptive.StoreEvidenceRequest
+	14, // 13: adaptive.CodecService.WebSearch:input_type -> adaptive.WebSearchRequest
+	17, // 14: adaptive.CodecService.DeleteEvidence:input_type -> adaptive.DeleteEvidenceRequest
+	19, // 15: adaptive.CodecService.UpdateEvidenceMetadata:input_type -> adaptive.UpdateEvidenceMetadataRequest
+	21, // 16: adaptive.CodecService.GetByIDs:input_type -> adaptive.GetByIDsRequest
+	23, // 17: adaptive.CodecService.ListAllEvidence:input_type -> adaptive.ListAllEvidenceRequest
+	25, // 18: adaptive.CodecService.Handshake:input_type -> adaptive.HandshakeRequest
+	2,  // 19: adaptive.CodecService.Generate:output_type -> adaptive.GenerateResponse
+	3,  // 20: adaptive.CodecService.GenerateStream:output_type -> adaptive.GenerateChunk
+	5,  // 21: adaptive.CodecService.Embed:output_type -> adaptive.EmbedResponse
+	8,  // 22: adaptive.CodecService.EmbedBatch:output_type -> adaptive.EmbedBatchResponse
+	11, // 23: adaptive.CodecService.Search:output_type -> adaptive.SearchResponse
+	13, // 24: adaptive.CodecService.StoreEvidence:output_type -> adaptive.StoreEvidenceResponse
+	16, // 25: adaptive.CodecService.WebSearch:output_type -> adaptive.WebSearchResponse
+	18, // 26: adaptive.CodecService.DeleteEvidence:output_type -> adaptive.DeleteEvidenceResponse
+	20, // 27: adaptive.CodecService.UpdateEvidenceMetadata:output_type -> adaptive.UpdateEvidenceMetadataResponse
+	22, // 28: adaptive.CodecService.GetByIDs:output_type -> adaptive.GetByIDsResponse
+	24, // 29: adaptive.CodecService.ListAllEvidence:output_type -> adaptive.ListAllEvidenceResponse
+	26, // 30: adaptive.CodecService.Handshake:output_type -> adaptive.HandshakeResponse
+	19, // [19:31] is the sub-list for method output_type
+	7,  // [7:19] is the sub-list for method input_type
+	7,  // [7:7] is the sub-list for extension type_name
+	7,  // [7:7] is the sub-list for extension extendee
+	0,  // [0:7] is the sub-list for field type_name
 }
 
 func init() { file_adaptive_proto_init() }
@@ -1074,7 +1621,7 @@ func file_adaptive_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_adaptive_proto_rawDesc), len(file_adaptive_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   18,
+			NumMessages:   27,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
@@ -19,14 +19,18 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	CodecService_Generate_FullMethodName        = "/adaptive.CodecService/Generate"
-	CodecService_Embed_FullMethodName           = "/adaptive.CodecService/Embed"
-	CodecService_Search_FullMethodName          = "/adaptive.CodecService/Search"
-	CodecService_StoreEvidence_FullMethodName   = "/adaptive.CodecService/StoreEvidence"
-	CodecService_WebSearch_FullMethodName       = "/adaptive.CodecService/WebSearch"
-	CodecService_DeleteEvidence_FullMethodName  = "/adaptive.CodecService/DeleteEvidence"
-	CodecService_GetByIDs_FullMethodName        = "/adaptive.CodecService/GetByIDs"
-	CodecService_ListAllEvidence_FullMethodName = "/adaptive.CodecService/ListAllEvidence"
+	CodecService_Generate_FullMethodName               = "/adaptive.CodecService/Generate"
+	CodecService_GenerateStream_FullMethodName         = "/adaptive.CodecService/GenerateStream"
+	CodecService_Embed_FullMethodName                  = "/adaptive.CodecService/Embed"
+	CodecService_EmbedBatch_FullMethodName             = "/adaptive.CodecService/EmbedBatch"
+	CodecService_Search_FullMethodName                 = "/adaptive.CodecService/Search"
+	CodecService_StoreEvidence_FullMethodName          = "/adaptive.CodecService/StoreEvidence"
+	CodecService_WebSearch_FullMethodName              = "/adaptive.CodecService/WebSearch"
+	CodecService_DeleteEvidence_FullMethodName         = "/adaptive.CodecService/DeleteEvidence"
+	CodecService_UpdateEvidenceMetadata_FullMethodName = "/adaptive.CodecService/UpdateEvidenceMetadata"
+	CodecService_GetByIDs_FullMethodName               = "/adaptive.CodecService/GetByIDs"
+	CodecService_ListAllEvidence_FullMethodName        = "/adaptive.CodecService/ListAllEvidence"
+	CodecService_Handshake_FullMethodName              = "/adaptive.CodecService/Handshake"
 )
 
 // CodecServiceClient is the client API for CodecService service.
@@ -36,13 +40,26 @@ const (
 // #region service-definition
 type CodecServiceClient interface {
 	Generate(ctx context.Context, in *GenerateRequest, opts ...grpc.CallOption) (*GenerateResponse, error)
+	// GenerateStream is Generate with the visible text sent as it is produced;
+	// the last chunk carries the full response (entropy, logits, model).
+	GenerateStream(ctx context.Context, in *GenerateRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[GenerateChunk], error)
 	Embed(ctx context.Context, in *EmbedRequest, opts ...grpc.CallOption) (*EmbedResponse, error)
+	// EmbedBatch embeds several texts in one round trip; embeddings come back in
+	// request order.
+	EmbedBatch(ctx context.Context, in *EmbedBatchRequest, opts ...grpc.CallOption) (*EmbedBatchResponse, error)
 	Search(ctx context.Context, in *SearchRequest, opts ...grpc.CallOption) (*SearchResponse, error)
 	StoreEvidence(ctx context.Context, in *StoreEvidenceRequest, opts ...grpc.CallOption) (*StoreEvidenceResponse, error)
 	WebSearch(ctx context.Context, in *WebSearchRequest, opts ...grpc.CallOption) (*WebSearchResponse, error)
 	DeleteEvidence(ctx context.Context, in *DeleteEvidenceRequest, opts ...grpc.CallOption) (*DeleteEvidenceResponse, error)
+	// UpdateEvidenceMetadata merges keys into a stored item's metadata; a key set
+	// to null is removed. Items with "pinned": true are never evicted and skip
+	// recency decay in Search.
+	UpdateEvidenceMetadata(ctx context.Context, in *UpdateEvidenceMetadataRequest, opts ...grpc.CallOption) (*UpdateEvidenceMetadataResponse, error)
 	GetByIDs(ctx context.Context, in *GetByIDsRequest, opts ...grpc.CallOption) (*GetByIDsResponse, error)
 	ListAllEvidence(ctx context.Context, in *ListAllEvidenceRequest, opts ...grpc.CallOption) (*ListAllEvidenceResponse, error)
+	// Handshake exchanges protocol versions and schema fingerprints so mismatched
+	// bindings fail loudly instead of silently dropping unknown fields.
+	Handshake(ctx context.Context, in *HandshakeRequest, opts ...grpc.CallOption) (*HandshakeResponse, error)
 }
 
 type codecServiceClient struct {
@@ -63,6 +80,25 @@ func (c *codecServiceClient) Generate(ctx context.Context, in *GenerateRequest,
 	return out, nil
 }
 
+func (c *codecServiceClient) GenerateStream(ctx context.Context, in *GenerateRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[GenerateChunk], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &CodecService_ServiceDesc.Streams[0], CodecService_GenerateStream_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[GenerateRequest, GenerateChunk]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type CodecService_GenerateStreamClient = grpc.ServerStreamingClient[GenerateChunk]
+
 func (c *codecServiceClient) Embed(ctx context.Context, in *EmbedRequest, opts ...grpc.CallOption) (*EmbedResponse, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(EmbedResponse)
@@ -73,6 +109,16 @@ func (c *codecServiceClient) Embed(ctx context.Context, in *EmbedRequest, opts .
 	return out, nil
 }
 
+func (c *codecServiceClient) EmbedBatch(ctx context.Context, in *EmbedBatchRequest, opts ...grpc.CallOption) (*EmbedBatchResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(EmbedBatchResponse)
+	err := c.cc.Invoke(ctx, CodecService_EmbedBatch_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *codecServiceClient) Search(ctx context.Context, in *SearchRequest, opts ...grpc.CallOption) (*SearchResponse, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(SearchResponse)
@@ -113,6 +159,16 @@ func (c *codecServiceClient) DeleteEvidence(ctx context.Context, in *DeleteEvide
 	return out, nil
 }
 
+func (c *codecServiceClient) UpdateEvidenceMetadata(ctx context.Context, in *UpdateEvidenceMetadataRequest, opts ...grpc.CallOption) (*UpdateEvidenceMetadataResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(UpdateEvidenceMetadataResponse)
+	err := c.cc.Invoke(ctx, CodecService_UpdateEvidenceMetadata_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *codecServiceClient) GetByIDs(ctx context.Context, in *GetByIDsRequest, opts ...grpc.CallOption) (*GetByIDsResponse, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(GetByIDsResponse)
@@ -133,6 +189,16 @@ func (c *codecServiceClient) ListAllEvidence(ctx context.Context, in *ListAllEvi
 	return out, nil
 }
 
+func (c *codecServiceClient) Handshake(ctx context.Context, in *HandshakeRequest, opts ...grpc.CallOption) (*HandshakeResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(HandshakeResponse)
+	err := c.cc.Invoke(ctx, CodecService_Handshake_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // CodecServiceServer is the server API for CodecService service.
 // All implementations must embed UnimplementedCodecServiceServer
 // for forward compatibility.
@@ -140,13 +206,26 @@ func (c *codecServiceClient) ListAllEvidence(ctx context.Context, in *ListAllEvi
 // #region service-definition
 type CodecServiceServer interface {
 	Generate(context.Context, *GenerateRequest) (*GenerateResponse, error)
+	// GenerateStream is Generate with the visible text sent as it is produced;
+	// the last chunk carries the full response (entropy, logits, model).
+	GenerateStream(*GenerateRequest, grpc.ServerStreamingServer[GenerateChunk]) error
 	Embed(context.Context, *EmbedRequest) (*EmbedResponse, error)
+	// EmbedBatch embeds several texts in one round trip; embeddings come back in
+	// request order.
+	EmbedBatch(context.Context, *EmbedBatchRequest) (*EmbedBatchResponse, error)
 	Search(context.Context, *SearchRequest) (*SearchResponse, error)
 	StoreEvidence(context.Context, *StoreEvidenceRequest) (*StoreEvidenceResponse, error)
 	WebSearch(context.Context, *WebSearchRequest) (*WebSearchResponse, error)
 	DeleteEvidence(context.Context, *DeleteEvidenceRequest) (*DeleteEvidenceResponse, error)
+	// UpdateEvidenceMetadata merges keys into a stored item's metadata; a key set
+	// to null is removed. Items with "pinned": true are never evicted and skip
+	// recency decay in Search.
+	UpdateEvidenceMetadata(context.Context, *UpdateEvidenceMetadataRequest) (*UpdateEvidenceMetadataResponse, error)
 	GetByIDs(context.Context, *GetByIDsRequest) (*GetByIDsResponse, error)
 	ListAllEvidence(context.Context, *ListAllEvidenceRequest) (*ListAllEvidenceResponse, error)
+	// Handshake exchanges protocol versions and schema fingerprints so mismatched
+	// bindings fail loudly instead of silently dropping unknown fields.
+	Handshake(context.Context, *HandshakeRequest) (*HandshakeResponse, error)
 	mustEmbedUnimplementedCodecServiceServer()
 }
 
@@ -160,9 +239,15 @@ type UnimplementedCodecServiceServer struct{}
 func (UnimplementedCodecServiceServer) Generate(context.Context, *GenerateRequest) (*GenerateResponse, error) {
 	return nil, status.Error(codes.Unimplemented, "method Generate not implemented")
 }
+func (UnimplementedCodecServiceServer) GenerateStream(*GenerateRequest, grpc.ServerStreamingServer[GenerateChunk]) error {
+	return status.Error(codes.Unimplemented, "method GenerateStream not implemented")
+}
 func (UnimplementedCodecServiceServer) Embed(context.Context, *EmbedRequest) (*EmbedResponse, error) {
 	return nil, status.Error(codes.Unimplemented, "method Embed not implemented")
 }
+func (UnimplementedCodecServiceServer) EmbedBatch(context.Context, *EmbedBatchRequest) (*EmbedBatchResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method EmbedBatch not implemented")
+}
 func (UnimplementedCodecServiceServer) Search(context.Context, *SearchRequest) (*SearchResponse, error) {
 	return nil, status.Error(codes.Unimplemented, "method Search not implemented")
 }
@@ -175,12 +260,18 @@ func (UnimplementedCodecServiceServer) WebSearch(context.Context, *WebSearchRequ
 func (UnimplementedCodecServiceServer) DeleteEvidence(context.Context, *DeleteEvidenceRequest) (*DeleteEvidenceResponse, error) {
 	return nil, status.Error(codes.Unimplemented, "method DeleteEvidence not implemented")
 }
+func (UnimplementedCodecServiceServer) UpdateEvidenceMetadata(context.Context, *UpdateEvidenceMetadataRequest) (*UpdateEvidenceMetadataResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method UpdateEvidenceMetadata not implemented")
+}
 func (UnimplementedCodecServiceServer) GetByIDs(context.Context, *GetByIDsRequest) (*GetByIDsResponse, error) {
 	return nil, status.Error(codes.Unimplemented, "method GetByIDs not implemented")
 }
 func (UnimplementedCodecServiceServer) ListAllEvidence(context.Context, *ListAllEvidenceRequest) (*ListAllEvidenceResponse, error) {
 	return nil, status.Error(codes.Unimplemented, "method ListAllEvidence not implemented")
 }
+func (UnimplementedCodecServiceServer) Handshake(context.Context, *HandshakeRequest) (*HandshakeResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method Handshake not implemented")
+}
 func (UnimplementedCodecServiceServer) mustEmbedUnimplementedCodecServiceServer() {}
 func (UnimplementedCodecServiceServer) testEmbeddedByValue()                      {}
 
@@ -220,6 +311,17 @@ func _CodecService_Generate_Handler(srv interface{}, ctx context.Context, dec fu
 	return interceptor(ctx, in, info, handler)
 }
 
+func _CodecService_GenerateStream_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(GenerateRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(CodecServiceServer).GenerateStream(m, &grpc.GenericServerStream[GenerateRequest, GenerateChunk]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type CodecService_GenerateStreamServer = grpc.ServerStreamingServer[GenerateChunk]
+
 func _CodecService_Embed_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(EmbedRequest)
 	if err := dec(in); err != nil {
@@ -238,6 +340,24 @@ func _CodecService_Embed_Handler(srv interface{}, ctx context.Context, dec func(
 	return interceptor(ctx, in, info, handler)
 }
 
+func _CodecService_EmbedBatch_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(EmbedBatchRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CodecServiceServer).EmbedBatch(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CodecService_EmbedBatch_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CodecServiceServer).EmbedBatch(ctx, req.(*EmbedBatchRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _CodecService_Search_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(SearchRequest)
 	if err := dec(in); err != nil {
@@ -310,6 +430,24 @@ func _CodecService_DeleteEvidence_Handler(srv interface{}, ctx context.Context,
 	return interceptor(ctx, in, info, handler)
 }
 
+func _CodecService_UpdateEvidenceMetadata_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateEvidenceMetadataRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CodecServiceServer).UpdateEvidenceMetadata(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CodecService_UpdateEvidenceMetadata_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CodecServiceServer).UpdateEvidenceMetadata(ctx, req.(*UpdateEvidenceMetadataRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _CodecService_GetByIDs_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(GetByIDsRequest)
 	if err := dec(in); err != nil {
@@ -346,6 +484,24 @@ func _CodecService_ListAllEvidence_Handler(srv interface{}, ctx context.Context,
 	return interceptor(ctx, in, info, handler)
 }
 
+func _CodecService_Handshake_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(HandshakeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CodecServiceServer).Handshake(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CodecService_Handshake_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CodecServiceServer).Handshake(ctx, req.(*HandshakeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // CodecService_ServiceDesc is the grpc.ServiceDesc for CodecService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -361,6 +517,10 @@ var CodecService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "Embed",
 			Handler:    _CodecService_Embed_Handler,
 		},
+		{
+			MethodName: "EmbedBatch",
+			Handler:    _CodecService_EmbedBatch_Handler,
+		},
 		{
 			MethodName: "Search",
 			Handler:    _CodecService_Search_Handler,
@@ -377,6 +537,10 @@ var CodecService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "DeleteEvidence",
 			Handler:    _CodecService_DeleteEvidence_Handler,
 		},
+		{
+			MethodName: "UpdateEvidenceMetadata",
+			Handler:    _CodecService_UpdateEvidenceMetadata_Handler,
+		},
 		{
 			MethodName: "GetByIDs",
 			Handler:    _CodecService_GetByIDs_Handler,
@@ -385,7 +549,17 @@ var CodecService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "ListAllEvidence",
 			Handler:    _CodecService_ListAllEvidence_Handler,
 		},
+		{
+			MethodName: "Handshake",
+			Handler:    _CodecService_Handshake_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "GenerateStream",
+			Handler:       _CodecService_GenerateStream_Handler,
+			ServerStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "adaptive.proto",
 }
@@ -0,0 +1,8 @@
+// Package adaptive holds the protobuf and gRPC bindings generated from
+// proto/adaptive.proto. Do not edit the *.pb.go files by hand: change the proto,
+// bump its protocol_version, and run `go generate ./gen/...` from go-controller
+// (or scripts/gen-proto.sh), which regenerates the Go and Python bindings together.
+package adaptive
+
+//go:generate protoc --proto_path=../../../proto --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative adaptive.proto
+//go:generate python -m grpc_tools.protoc --proto_path=../../../proto --python_out=../../../py-inference/adaptive_inference/proto --grpc_python_out=../../../py-inference/adaptive_inference/proto --pyi_out=../../../py-inference/adaptive_inference/proto adaptive.proto
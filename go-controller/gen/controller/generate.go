@@ -0,0 +1,8 @@
+// Package controller holds the protobuf and gRPC bindings generated from
+// proto/controller.proto, the service cmd/controller serves with --grpc. Do not
+// edit the *.pb.go files by hand: change the proto and run `go generate ./gen/...`
+// from go-controller (or scripts/gen-proto.sh). There are no Python bindings;
+// the inference side never calls the controller.
+package controller
+
+//go:generate protoc --proto_path=../../../proto --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative controller.proto
@@ -0,0 +1,215 @@
+package ablation
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/codec"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/projection"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/vecmath"
+)
+
+// #region types
+
+// Variant names. "full" is the baseline; every other variant removes one adaptive
+// component so its distance from "full" measures that component's influence.
+const (
+	VariantFull       = "full"
+	VariantNoState    = "no_state"
+	VariantNoPrefs    = "no_prefs"
+	VariantNoRules    = "no_rules"
+	VariantNoEvidence = "no_evidence"
+)
+
+// Generator is the subset of the codec client an ablation run needs.
+type Generator interface {
+	Generate(ctx context.Context, prompt string, stateVec [128]float32, evidence []string, ollamaCtx []int64) (codec.GenerateResult, error)
+	Embed(ctx context.Context, text string) ([]float32, error)
+}
+
+// Inputs are the adaptive components assembled for one prompt, as the daemon would.
+type Inputs struct {
+	Prompt      string
+	StateVector [128]float32
+	StateBlock  string   // projected [ADAPTIVE STATE] block (may be "")
+	Rules       []string // rule context evidence
+	Evidence    []string // retrieved evidence texts
+	Preferences []projection.Preference
+}
+
+// Variant is one generation setup.
+type Variant struct {
+	Name        string
+	Prompt      string
+	StateVector [128]float32
+	Evidence    []string
+}
+
+// Result is one variant's generation and scoring.
+type Result struct {
+	Variant    string
+	Response   string
+	Entropy    float32
+	Compliance float32 // preference compliance of the response (0.5 = neutral)
+	Embedding  []float32
+	Err        error
+}
+
+// Report holds all variant results and their pairwise embedding distances.
+type Report struct {
+	Prompt    string
+	Results   []Result
+	Distances [][]float32 // cosine distance between Results[i] and Results[j]; -1 if unavailable
+}
+
+// #endregion types
+
+// #region variants
+
+// Variants returns the baseline followed by one single-component ablation each.
+// Ablations whose component is absent are still included so the report shows
+// they had nothing to remove.
+func Variants(in Inputs) []Variant {
+	var zero [128]float32
+	full := Variant{
+		Name:        VariantFull,
+		Prompt:      projection.WrapPrompt(in.StateBlock, in.Prompt),
+		StateVector: in.StateVector,
+		Evidence:    joinEvidence(in.Rules, in.Evidence),
+	}
+	noState := full
+	noState.Name, noState.StateVector = VariantNoState, zero
+
+	noPrefs := full
+	noPrefs.Name, noPrefs.Prompt = VariantNoPrefs, in.Prompt
+
+	noRules := full
+	noRules.Name, noRules.Evidence = VariantNoRules, joinEvidence(nil, in.Evidence)
+
+	noEvidence := full
+	noEvidence.Name, noEvidence.Evidence = VariantNoEvidence, joinEvidence(in.Rules, nil)
+
+	return []Variant{full, noState, noPrefs, noRules, noEvidence}
+}
+
+func joinEvidence(a, b []string) []string {
+	out := make([]string, 0, len(a)+len(b))
+	out = append(out, a...)
+	return append(out, b...)
+}
+
+// #endregion variants
+
+// #region run
+
+// Run generates a response for every variant, embeds it, scores preference
+// compliance, and computes pairwise distances. A failing variant is recorded
+// with its error rather than aborting the run.
+func Run(ctx context.Context, g Generator, in Inputs) Report {
+	variants := Variants(in)
+	report := Report{Prompt: in.Prompt, Results: make([]Result, len(variants))}
+	for i, v := range variants {
+		r := Result{Variant: v.Name}
+		gen, err := g.Generate(ctx, v.Prompt, v.StateVector, v.Evidence, nil)
+		if err != nil {
+			r.Err = fmt.Errorf("generate: %w", err)
+			report.Results[i] = r
+			continue
+		}
+		r.Response, r.Entropy = gen.Text, gen.Entropy
+		r.Compliance = projection.PreferenceComplianceScore(in.Preferences, gen.Text)
+		if r.Embedding, err = g.Embed(ctx, gen.Text); err != nil {
+			r.Err = fmt.Errorf("embed: %w", err)
+		}
+		report.Results[i] = r
+	}
+	report.Distances = pairwiseDistances(report.Results)
+	return report
+}
+
+func pairwiseDistances(results []Result) [][]float32 {
+	d := make([][]float32, len(results))
+	for i := range results {
+		d[i] = make([]float32, len(results))
+		for j := range results {
+			d[i][j] = cosineDistance(results[i].Embedding, results[j].Embedding)
+		}
+	}
+	return d
+}
+
+// cosineDistance returns 1 - cosine similarity, or -1 if either vector is missing.
+func cosineDistance(a, b []float32) float32 {
+	sim, ok := vecmath.Cosine(a, b)
+	if !ok {
+		return -1
+	}
+	// Clamp rounding noise so identical responses read as exactly 0.
+	return float32(math.Max(0, 1-sim))
+}
+
+// Influence returns each ablation's distance from the full baseline — how much
+// removing that component changed the response. Variants without a distance are omitted.
+func (r Report) Influence() map[string]float32 {
+	out := make(map[string]float32)
+	for i := 1; i < len(r.Results); i++ {
+		if len(r.Distances) > i && r.Distances[0][i] >= 0 {
+			out[r.Results[i].Variant] = r.Distances[0][i]
+		}
+	}
+	return out
+}
+
+// #endregion run
+
+// #region format
+
+// Format renders the report: per-variant summary, influence of each component,
+// and the pairwise distance matrix.
+func (r Report) Format() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Ablation for prompt: %q\n\n", r.Prompt)
+	fmt.Fprintf(&b, "%-12s %8s %10s %8s  %s\n", "variant", "entropy", "compliance", "Δfull", "response")
+	for i, res := range r.Results {
+		if res.Err != nil {
+			fmt.Fprintf(&b, "%-12s error: %v\n", res.Variant, res.Err)
+			continue
+		}
+		dist := "-"
+		if i > 0 && len(r.Distances) > 0 && r.Distances[0][i] >= 0 {
+			dist = fmt.Sprintf("%.4f", r.Distances[0][i])
+		}
+		fmt.Fprintf(&b, "%-12s %8.4f %10.4f %8s  %s\n", res.Variant, res.Entropy, res.Compliance, dist, preview(res.Response, 60))
+	}
+
+	b.WriteString("\npairwise cosine distance:\n")
+	fmt.Fprintf(&b, "%-12s", "")
+	for _, res := range r.Results {
+		fmt.Fprintf(&b, " %12s", res.Variant)
+	}
+	b.WriteString("\n")
+	for i, res := range r.Results {
+		fmt.Fprintf(&b, "%-12s", res.Variant)
+		for j := range r.Results {
+			if d := r.Distances[i][j]; d >= 0 {
+				fmt.Fprintf(&b, " %12.4f", d)
+			} else {
+				fmt.Fprintf(&b, " %12s", "-")
+			}
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+func preview(s string, n int) string {
+	s = strings.Join(strings.Fields(s), " ")
+	if r := []rune(s); len(r) > n {
+		return string(r[:n]) + "…"
+	}
+	return s
+}
+
+// #endregion format
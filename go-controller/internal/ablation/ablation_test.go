@@ -0,0 +1,125 @@
+package ablation
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/codec"
+)
+
+// #region mock
+
+// fakeGenerator answers from the setup it was given, so each ablation produces a
+// distinguishable response; embeddings are derived from the response text.
+type fakeGenerator struct {
+	failFor string
+}
+
+func (f *fakeGenerator) Generate(_ context.Context, prompt string, stateVec [128]float32, evidence []string, _ []int64) (codec.GenerateResult, error) {
+	if f.failFor != "" && strings.Contains(prompt, f.failFor) {
+		return codec.GenerateResult{}, errors.New("boom")
+	}
+	text := "base"
+	if strings.Contains(prompt, "[ADAPTIVE STATE]") {
+		text += " prefs"
+	}
+	if stateVec[0] != 0 {
+		text += " state"
+	}
+	for _, e := range evidence {
+		text += " " + e
+	}
+	return codec.GenerateResult{Text: text, Entropy: 1}, nil
+}
+
+func (f *fakeGenerator) Embed(_ context.Context, text string) ([]float32, error) {
+	v := []float32{1, 0, 0, 0}
+	for _, w := range strings.Fields(text) {
+		switch w {
+		case "prefs":
+			v[1] = 1
+		case "state":
+			v[2] = 1
+		case "rule", "doc":
+			v[3] += 1
+		}
+	}
+	return v, nil
+}
+
+// #endregion mock
+
+// #region variant-tests
+
+func TestVariants_RemoveOneComponentEach(t *testing.T) {
+	in := Inputs{Prompt: "hi", StateBlock: "[ADAPTIVE STATE]\n- Be brief\n", Rules: []string{"rule"}, Evidence: []string{"doc"}}
+	in.StateVector[0] = 0.5
+	vs := Variants(in)
+	if len(vs) != 5 || vs[0].Name != VariantFull {
+		t.Fatalf("expected full + 4 ablations, got %d", len(vs))
+	}
+	byName := map[string]Variant{}
+	for _, v := range vs {
+		byName[v.Name] = v
+	}
+	if byName[VariantNoState].StateVector[0] != 0 || byName[VariantNoState].Prompt != byName[VariantFull].Prompt {
+		t.Error("no_state should zero only the state vector")
+	}
+	if byName[VariantNoPrefs].Prompt != "hi" || byName[VariantNoPrefs].StateVector[0] != 0.5 {
+		t.Error("no_prefs should drop only the prefs block")
+	}
+	if ev := byName[VariantNoRules].Evidence; len(ev) != 1 || ev[0] != "doc" {
+		t.Errorf("no_rules should keep evidence only, got %v", ev)
+	}
+	if ev := byName[VariantNoEvidence].Evidence; len(ev) != 1 || ev[0] != "rule" {
+		t.Errorf("no_evidence should keep rules only, got %v", ev)
+	}
+}
+
+// #endregion variant-tests
+
+// #region run-tests
+
+func TestRun_InfluencePerComponent(t *testing.T) {
+	in := Inputs{Prompt: "hi", StateBlock: "[ADAPTIVE STATE]\n- Be brief\n", Rules: []string{"rule"}}
+	in.StateVector[0] = 0.5
+	report := Run(context.Background(), &fakeGenerator{}, in)
+
+	inf := report.Influence()
+	if inf[VariantNoEvidence] != 0 {
+		t.Errorf("no retrieved evidence: ablation should not change output, got %.4f", inf[VariantNoEvidence])
+	}
+	for _, name := range []string{VariantNoState, VariantNoPrefs, VariantNoRules} {
+		if inf[name] <= 0 {
+			t.Errorf("expected %s to change the response, got %.4f", name, inf[name])
+		}
+	}
+	if report.Distances[1][1] != 0 {
+		t.Errorf("self distance should be 0, got %.4f", report.Distances[1][1])
+	}
+	out := report.Format()
+	if !strings.Contains(out, "pairwise cosine distance") || !strings.Contains(out, VariantNoRules) {
+		t.Errorf("unexpected format output:\n%s", out)
+	}
+}
+
+func TestRun_VariantErrorIsRecorded(t *testing.T) {
+	in := Inputs{Prompt: "hi", StateBlock: "[ADAPTIVE STATE]\n"}
+	report := Run(context.Background(), &fakeGenerator{failFor: "[ADAPTIVE STATE]"}, in)
+	if report.Results[0].Err == nil {
+		t.Fatal("expected full variant to record an error")
+	}
+	if report.Results[2].Err != nil {
+		t.Errorf("no_prefs variant should still run, got %v", report.Results[2].Err)
+	}
+	if _, ok := report.Influence()[VariantNoPrefs]; ok {
+		t.Error("influence needs a baseline embedding; expected none")
+	}
+	if !strings.Contains(report.Format(), "error: generate: boom") {
+		t.Error("expected error shown in report")
+	}
+}
+
+// #endregion run-tests
@@ -0,0 +1,398 @@
+package alert
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/events"
+)
+
+// #region types
+
+// Rule is one alert: when its conditions hold on at least Count of the last
+// Window turns, Action runs. After firing it stays quiet for Cooldown turns.
+type Rule struct {
+	Name     string
+	When     []Condition // all must hold ("and")
+	Count    int         // matching turns needed; default 1
+	Window   int         // turns considered; default Count
+	Cooldown int         // turns to stay quiet after firing; default Window
+	Action   string      // "log" | "webhook"
+	Level    string      // "info" | "warning" | "critical"; default "warning"
+	URL      string      // webhook target
+	Line     int         // 1-based line where the entry starts, for error messages
+}
+
+// Condition compares one turn field against a literal.
+type Condition struct {
+	Field string
+	Op    string // == != > >= < <= contains
+	Value string
+	num   float64
+}
+
+// String renders the condition as written.
+func (c Condition) String() string {
+	return fmt.Sprintf("%s %s %s", c.Field, c.Op, c.Value)
+}
+
+// Facts are the fields of one finished turn that conditions can test.
+type Facts struct {
+	TurnID string
+	Text   map[string]string
+	Num    map[string]float64
+}
+
+// #endregion types
+
+// #region fields
+
+// textFields and numFields are every field a condition may name. Booleans are
+// numbers (1 or 0) and compare against true/false.
+var textFields = map[string]bool{
+	"decision": true, "reason": true, "strategy": true, "type": true, "risk": true,
+	"gate.action": true, "gate.reason": true, "gate.vetoes": true,
+}
+
+var numFields = map[string]bool{
+	"entropy": true, "eval_scale": true, "evidence_count": true, "attempts": true,
+	"gate.vetoed": true, "gate.soft_score": true, "gate.delta_norm": true,
+	"signals.sentiment": true, "signals.coherence": true, "signals.novelty": true, "signals.risk_flag": true,
+	"signals.user_correction": true, "signals.tool_failure": true, "signals.constraint_violation": true,
+	"norm.prefs": true, "norm.goals": true, "norm.heuristics": true, "norm.risk": true,
+}
+
+// FactsFromTurn collects a turn's event fields and the per-segment norms of the
+// state active after it.
+func FactsFromTurn(ev events.TurnEvent, norms map[string]float64) Facts {
+	f := Facts{
+		TurnID: ev.TurnID,
+		Text:   map[string]string{"decision": ev.Decision, "reason": ev.Reason, "strategy": ev.Strategy},
+		Num: map[string]float64{
+			"entropy": float64(ev.Entropy), "eval_scale": float64(ev.EvalScale),
+			"evidence_count": float64(ev.EvidenceCount), "attempts": float64(ev.Attempts),
+		},
+	}
+	if c := ev.Classification; c != nil {
+		f.Text["type"], f.Text["risk"] = c.Type, c.Risk
+	}
+	if g := ev.Gate; g != nil {
+		f.Text["gate.action"], f.Text["gate.reason"] = g.Action, g.Reason
+		f.Text["gate.vetoes"] = strings.Join(g.VetoTypes, ",")
+		f.Num["gate.vetoed"] = boolNum(g.Vetoed)
+		f.Num["gate.soft_score"], f.Num["gate.delta_norm"] = float64(g.SoftScore), float64(g.DeltaNorm)
+	}
+	if s := ev.Signals; s != nil {
+		f.Num["signals.sentiment"] = float64(s.SentimentScore)
+		f.Num["signals.coherence"] = float64(s.CoherenceScore)
+		f.Num["signals.novelty"] = float64(s.NoveltyScore)
+		f.Num["signals.risk_flag"] = boolNum(s.RiskFlag)
+		f.Num["signals.user_correction"] = boolNum(s.UserCorrection)
+		f.Num["signals.tool_failure"] = boolNum(s.ToolFailure)
+		f.Num["signals.constraint_violation"] = boolNum(s.ConstraintViolation)
+	}
+	for seg, n := range norms {
+		f.Num["norm."+seg] = n
+	}
+	return f
+}
+
+func boolNum(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// Match reports whether the condition holds. A field the turn did not carry
+// (no gate on a cancelled turn, say) never matches.
+func (c Condition) Match(f Facts) bool {
+	if textFields[c.Field] {
+		v, ok := f.Text[c.Field]
+		if !ok {
+			return false
+		}
+		switch c.Op {
+		case "==":
+			return strings.EqualFold(v, c.Value)
+		case "!=":
+			return !strings.EqualFold(v, c.Value)
+		case "contains":
+			return strings.Contains(strings.ToLower(v), strings.ToLower(c.Value))
+		}
+		return false
+	}
+	v, ok := f.Num[c.Field]
+	if !ok {
+		return false
+	}
+	switch c.Op {
+	case "==":
+		return v == c.num
+	case "!=":
+		return v != c.num
+	case ">":
+		return v > c.num
+	case ">=":
+		return v >= c.num
+	case "<":
+		return v < c.num
+	case "<=":
+		return v <= c.num
+	}
+	return false
+}
+
+// #endregion fields
+
+// #region parse
+
+// ParseRules parses an alert rules file: a YAML list of flat mappings,
+// optionally nested under a top-level "alerts:" key.
+//
+//	alerts:
+//	  - name: eval-rollbacks
+//	    when: decision == rollback
+//	    count: 3
+//	    window: 10
+//	    action: webhook
+//	    url: https://hooks.example.com/orac
+//	  - name: risk-norm
+//	    when: norm.risk > 2.5
+//	    action: log
+//	    level: critical
+//
+// "when" joins conditions with "and". Values may be bare or quoted; comments
+// (#) and blank lines are ignored. Rules are validated as they are parsed.
+func ParseRules(data []byte) ([]Rule, error) {
+	var rules []Rule
+	var cur *Rule
+	seen := map[string]bool{}
+
+	for i, raw := range strings.Split(string(data), "\n") {
+		lineNo := i + 1
+		line := stripComment(raw)
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || trimmed == "---" {
+			continue
+		}
+		if trimmed == "alerts:" && !strings.HasPrefix(line, " ") {
+			continue
+		}
+
+		if strings.HasPrefix(trimmed, "- ") || trimmed == "-" {
+			rules = append(rules, Rule{Line: lineNo})
+			cur = &rules[len(rules)-1]
+			seen = map[string]bool{}
+			trimmed = strings.TrimSpace(strings.TrimPrefix(trimmed, "-"))
+			if trimmed == "" {
+				continue
+			}
+		}
+		if cur == nil {
+			return nil, fmt.Errorf("line %d: expected a list entry (\"- name: ...\")", lineNo)
+		}
+
+		key, value, ok := strings.Cut(trimmed, ":")
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected \"key: value\"", lineNo)
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = unquote(strings.TrimSpace(value))
+		if seen[key] {
+			return nil, fmt.Errorf("line %d: duplicate field %q", lineNo, key)
+		}
+		seen[key] = true
+
+		var err error
+		switch key {
+		case "name":
+			cur.Name = value
+		case "when":
+			cur.When, err = parseConditions(value)
+		case "count":
+			cur.Count, err = parseCount(key, value)
+		case "window":
+			cur.Window, err = parseCount(key, value)
+		case "cooldown":
+			cur.Cooldown, err = parseCount(key, value)
+		case "action":
+			cur.Action = strings.ToLower(value)
+		case "level":
+			cur.Level = strings.ToLower(value)
+		case "url":
+			cur.URL = value
+		default:
+			err = fmt.Errorf("unknown field %q", key)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNo, err)
+		}
+	}
+
+	names := map[string]int{}
+	for i := range rules {
+		r := &rules[i]
+		if err := r.validate(); err != nil {
+			return nil, fmt.Errorf("line %d: alert %q: %w", r.Line, r.Name, err)
+		}
+		if prev, ok := names[r.Name]; ok {
+			return nil, fmt.Errorf("line %d: alert %q already defined on line %d", r.Line, r.Name, prev)
+		}
+		names[r.Name] = r.Line
+	}
+	return rules, nil
+}
+
+// validate checks required fields and fills defaults.
+func (r *Rule) validate() error {
+	if r.Name == "" {
+		return fmt.Errorf("missing name")
+	}
+	if len(r.When) == 0 {
+		return fmt.Errorf("missing when")
+	}
+	if r.Count == 0 {
+		r.Count = 1
+	}
+	if r.Window == 0 {
+		r.Window = r.Count
+	}
+	if r.Window < r.Count {
+		return fmt.Errorf("window %d is smaller than count %d", r.Window, r.Count)
+	}
+	if r.Cooldown == 0 {
+		r.Cooldown = r.Window
+	}
+	if r.Level == "" {
+		r.Level = "warning"
+	}
+	switch r.Level {
+	case "info", "warning", "critical":
+	default:
+		return fmt.Errorf("level must be info, warning or critical, got %q", r.Level)
+	}
+	switch r.Action {
+	case "log":
+		if r.URL != "" {
+			return fmt.Errorf("url is only used by the webhook action")
+		}
+	case "webhook":
+		u, err := url.Parse(r.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("webhook needs an http(s) url, got %q", r.URL)
+		}
+	default:
+		return fmt.Errorf("action must be log or webhook, got %q", r.Action)
+	}
+	return nil
+}
+
+var conditionOps = []string{">=", "<=", "==", "!=", ">", "<"}
+
+// parseConditions parses "FIELD OP VALUE [and FIELD OP VALUE ...]".
+func parseConditions(spec string) ([]Condition, error) {
+	var out []Condition
+	for _, part := range splitAnd(spec) {
+		c, err := parseCondition(strings.TrimSpace(part))
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, c)
+	}
+	return out, nil
+}
+
+func splitAnd(spec string) []string {
+	var parts []string
+	fields := strings.Fields(spec)
+	start := 0
+	for i, f := range fields {
+		if strings.EqualFold(f, "and") {
+			parts = append(parts, strings.Join(fields[start:i], " "))
+			start = i + 1
+		}
+	}
+	return append(parts, strings.Join(fields[start:], " "))
+}
+
+func parseCondition(spec string) (Condition, error) {
+	var c Condition
+	if field, value, ok := strings.Cut(spec, " contains "); ok {
+		c = Condition{Field: strings.TrimSpace(field), Op: "contains", Value: unquote(strings.TrimSpace(value))}
+	} else {
+		for _, op := range conditionOps {
+			if field, value, ok := strings.Cut(spec, op); ok {
+				c = Condition{Field: strings.TrimSpace(field), Op: op, Value: unquote(strings.TrimSpace(value))}
+				break
+			}
+		}
+	}
+	if c.Op == "" || c.Field == "" || c.Value == "" {
+		return Condition{}, fmt.Errorf("condition %q: want FIELD OP VALUE (ops: == != > >= < <= contains)", spec)
+	}
+	c.Field = strings.ToLower(c.Field)
+	switch {
+	case textFields[c.Field]:
+		if c.Op != "==" && c.Op != "!=" && c.Op != "contains" {
+			return Condition{}, fmt.Errorf("condition %q: %s is text; use ==, != or contains", spec, c.Field)
+		}
+	case numFields[c.Field]:
+		if c.Op == "contains" {
+			return Condition{}, fmt.Errorf("condition %q: %s is a number; contains needs a text field", spec, c.Field)
+		}
+		switch strings.ToLower(c.Value) {
+		case "true":
+			c.num = 1
+		case "false":
+			c.num = 0
+		default:
+			n, err := strconv.ParseFloat(c.Value, 64)
+			if err != nil {
+				return Condition{}, fmt.Errorf("condition %q: %s needs a number, got %q", spec, c.Field, c.Value)
+			}
+			c.num = n
+		}
+	default:
+		return Condition{}, fmt.Errorf("condition %q: unknown field %q", spec, c.Field)
+	}
+	return c, nil
+}
+
+func parseCount(key, value string) (int, error) {
+	n, err := strconv.Atoi(value)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("%s must be a positive integer, got %q", key, value)
+	}
+	return n, nil
+}
+
+// stripComment removes a trailing # comment that is outside quotes.
+func stripComment(line string) string {
+	var quote rune
+	for i, r := range line {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '"' || r == '\'':
+			quote = r
+		case r == '#' && (i == 0 || line[i-1] == ' ' || line[i-1] == '\t'):
+			return line[:i]
+		}
+	}
+	return line
+}
+
+// unquote strips one layer of matching single or double quotes.
+func unquote(v string) string {
+	if len(v) >= 2 && (v[0] == '"' || v[0] == '\'') && v[len(v)-1] == v[0] {
+		return v[1 : len(v)-1]
+	}
+	return v
+}
+
+// #endregion parse
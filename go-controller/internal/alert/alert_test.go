@@ -0,0 +1,125 @@
+package alert
+
+import (
+	"testing"
+
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/events"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/logging"
+)
+
+// #region parse-tests
+
+func TestParseRules(t *testing.T) {
+	data := []byte(`# ops alerts
+alerts:
+  - name: eval-rollbacks
+    when: decision == rollback
+    count: 3
+    window: 10
+    action: webhook
+    url: "https://hooks.example.com/orac"   # team channel
+  - name: risk-norm
+    when: norm.risk > 2.5 and gate.vetoed == false
+    action: log
+    level: critical
+`)
+	rules, err := ParseRules(data)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if len(rules) != 2 {
+		t.Fatalf("expected 2 rules, got %d", len(rules))
+	}
+	r := rules[0]
+	if r.Count != 3 || r.Window != 10 || r.Cooldown != 10 || r.Level != "warning" || r.URL != "https://hooks.example.com/orac" {
+		t.Errorf("unexpected first rule: %+v", r)
+	}
+	r = rules[1]
+	if r.Count != 1 || r.Window != 1 || r.Level != "critical" || r.Line != 9 || len(r.When) != 2 {
+		t.Fatalf("unexpected second rule: %+v", r)
+	}
+	if r.When[0].String() != "norm.risk > 2.5" || r.When[1].num != 0 {
+		t.Errorf("conditions parsed wrong: %+v", r.When)
+	}
+}
+
+func TestParseRules_Errors(t *testing.T) {
+	cases := map[string]string{
+		"no list":               "name: x\n",
+		"unknown field":         "- name: x\n  colour: blue\n",
+		"duplicate field":       "- name: x\n  name: y\n",
+		"missing when":          "- name: x\n  action: log\n",
+		"missing name":          "- when: entropy > 1\n  action: log\n",
+		"unknown field in when": "- name: x\n  when: mood == sad\n  action: log\n",
+		"text comparison":       "- name: x\n  when: decision > commit\n  action: log\n",
+		"number contains":       "- name: x\n  when: entropy contains 1\n  action: log\n",
+		"not a number":          "- name: x\n  when: entropy > high\n  action: log\n",
+		"no operator":           "- name: x\n  when: entropy\n  action: log\n",
+		"window < count":        "- name: x\n  when: entropy > 1\n  count: 3\n  window: 2\n  action: log\n",
+		"bad count":             "- name: x\n  when: entropy > 1\n  count: -1\n  action: log\n",
+		"bad action":            "- name: x\n  when: entropy > 1\n  action: page\n",
+		"bad level":             "- name: x\n  when: entropy > 1\n  action: log\n  level: loud\n",
+		"webhook no url":        "- name: x\n  when: entropy > 1\n  action: webhook\n",
+		"log with url":          "- name: x\n  when: entropy > 1\n  action: log\n  url: http://a\n",
+		"duplicate name":        "- name: x\n  when: entropy > 1\n  action: log\n- name: x\n  when: entropy > 2\n  action: log\n",
+	}
+	for name, data := range cases {
+		if _, err := ParseRules([]byte(data)); err == nil {
+			t.Errorf("%s: expected parse error", name)
+		}
+	}
+}
+
+// #endregion parse-tests
+
+// #region facts-tests
+
+func TestFactsFromTurn(t *testing.T) {
+	ev := events.TurnEvent{
+		TurnID: "t1", Decision: "reject", Entropy: 1.5, Reason: "gate",
+		Classification: &events.Classification{Type: "coding", Risk: "high"},
+		Gate:           &events.Gate{Action: "reject", Vetoed: true, VetoTypes: []string{"risk_flag", "constraint"}, DeltaNorm: 0.4},
+		Signals:        &logging.GateRecordSignals{RiskFlag: true, NoveltyScore: 0.25},
+	}
+	f := FactsFromTurn(ev, map[string]float64{"risk": 3})
+	if f.TurnID != "t1" || f.Text["risk"] != "high" || f.Text["gate.vetoes"] != "risk_flag,constraint" {
+		t.Errorf("text facts wrong: %+v", f.Text)
+	}
+	if f.Num["gate.vetoed"] != 1 || f.Num["signals.risk_flag"] != 1 || f.Num["signals.novelty"] != 0.25 || f.Num["norm.risk"] != 3 {
+		t.Errorf("numeric facts wrong: %+v", f.Num)
+	}
+
+	cases := []struct {
+		cond string
+		want bool
+	}{
+		{"decision == REJECT", true},
+		{"decision != commit", true},
+		{"gate.vetoes contains Constraint", true},
+		{"entropy >= 1.5", true},
+		{"entropy < 1.5", false},
+		{"norm.risk > 2.5", true},
+		{"gate.vetoed == true", true},
+		{"eval_scale > 0", false},
+	}
+	for _, tc := range cases {
+		c, err := parseCondition(tc.cond)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.cond, err)
+		}
+		if got := c.Match(f); got != tc.want {
+			t.Errorf("%s: got %v, want %v", tc.cond, got, tc.want)
+		}
+	}
+
+	// A cancelled turn has no gate: gate conditions never match, not even !=
+	cancelled := FactsFromTurn(events.TurnEvent{Decision: "cancelled"}, nil)
+	for _, spec := range []string{"gate.action != commit", "gate.delta_norm < 1"} {
+		c, _ := parseCondition(spec)
+		if c.Match(cancelled) {
+			t.Errorf("%s matched a turn without a gate", spec)
+		}
+	}
+}
+
+// #endregion facts-tests
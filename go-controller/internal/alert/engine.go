@@ -0,0 +1,89 @@
+package alert
+
+import (
+	"fmt"
+	"strings"
+)
+
+// #region engine
+
+// Firing is one alert triggered by a turn.
+type Firing struct {
+	Rule    Rule
+	TurnID  string // the turn that completed the match
+	Matches int    // matching turns in the window
+	Message string
+}
+
+// Engine evaluates rules over a sliding window of recent turns. It is not safe
+// for concurrent use; the daemon loop observes one turn at a time.
+type Engine struct {
+	rules []Rule
+	state []ruleState
+}
+
+type ruleState struct {
+	hits  []bool // last Window results, oldest first
+	quiet int    // turns left in the cooldown
+}
+
+// NewEngine returns an engine for rules, as returned by ParseRules.
+func NewEngine(rules []Rule) *Engine {
+	return &Engine{rules: rules, state: make([]ruleState, len(rules))}
+}
+
+// Rules returns the rules the engine evaluates.
+func (e *Engine) Rules() []Rule {
+	return e.rules
+}
+
+// Observe records one finished turn and returns the alerts it triggers.
+func (e *Engine) Observe(f Facts) []Firing {
+	var out []Firing
+	for i, r := range e.rules {
+		st := &e.state[i]
+		st.hits = append(st.hits, matchAll(r.When, f))
+		if len(st.hits) > r.Window {
+			st.hits = st.hits[1:]
+		}
+		if st.quiet > 0 {
+			st.quiet--
+			continue
+		}
+		matches := 0
+		for _, hit := range st.hits {
+			if hit {
+				matches++
+			}
+		}
+		if matches < r.Count || !st.hits[len(st.hits)-1] {
+			continue
+		}
+		st.quiet = r.Cooldown
+		out = append(out, Firing{Rule: r, TurnID: f.TurnID, Matches: matches, Message: describe(r, matches, len(st.hits))})
+	}
+	return out
+}
+
+func matchAll(conds []Condition, f Facts) bool {
+	for _, c := range conds {
+		if !c.Match(f) {
+			return false
+		}
+	}
+	return true
+}
+
+func describe(r Rule, matches, window int) string {
+	conds := make([]string, len(r.When))
+	for i, c := range r.When {
+		conds[i] = c.String()
+	}
+	when := strings.Join(conds, " and ")
+	if r.Window == 1 {
+		return fmt.Sprintf("%s: %s", r.Name, when)
+	}
+	return fmt.Sprintf("%s: %s on %d of the last %d turns", r.Name, when, matches, window)
+}
+
+// #endregion engine
@@ -0,0 +1,59 @@
+package alert
+
+import (
+	"fmt"
+	"testing"
+)
+
+// #region engine-tests
+
+func turn(id, decision string) Facts {
+	return Facts{TurnID: id, Text: map[string]string{"decision": decision}, Num: map[string]float64{}}
+}
+
+func TestEngine_WindowAndCooldown(t *testing.T) {
+	rules, err := ParseRules([]byte("- name: rollbacks\n  when: decision == rollback\n  count: 2\n  window: 3\n  action: log\n"))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	e := NewEngine(rules)
+
+	seq := []string{"rollback", "commit", "rollback", "rollback", "rollback", "commit", "commit", "rollback", "commit", "rollback"}
+	var fired []int
+	for i, d := range seq {
+		for _, f := range e.Observe(turn(fmt.Sprint(i), d)) {
+			fired = append(fired, i)
+			if f.TurnID != fmt.Sprint(i) || f.Matches != 2 {
+				t.Errorf("turn %d: unexpected firing %+v", i, f)
+			}
+		}
+	}
+	// Turn 2 completes 2-of-3, then 3 turns of cooldown; turn 7 has only one
+	// rollback in its window; turn 9 completes 2-of-3 again
+	want := []int{2, 9}
+	if fmt.Sprint(fired) != fmt.Sprint(want) {
+		t.Errorf("fired on turns %v, want %v", fired, want)
+	}
+}
+
+func TestEngine_SingleTurnRule(t *testing.T) {
+	rules, err := ParseRules([]byte("- name: risky\n  when: norm.risk > 2.5\n  action: log\n  level: critical\n  cooldown: 1\n"))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	e := NewEngine(rules)
+	risky := Facts{TurnID: "t", Num: map[string]float64{"norm.risk": 3}}
+
+	got := e.Observe(risky)
+	if len(got) != 1 || got[0].Message != "risky: norm.risk > 2.5" || got[0].Rule.Level != "critical" {
+		t.Fatalf("expected one critical firing, got %+v", got)
+	}
+	if again := e.Observe(risky); len(again) != 0 {
+		t.Errorf("expected cooldown to suppress the next turn, got %+v", again)
+	}
+	if again := e.Observe(risky); len(again) != 1 {
+		t.Errorf("expected a firing after the cooldown, got %+v", again)
+	}
+}
+
+// #endregion engine-tests
@@ -0,0 +1,296 @@
+package bench
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/ablation"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/codec"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/projection"
+)
+
+// #region types
+
+// Probe kinds. A fixed probe must not fire any rule; a rule probe sends a
+// stored rule's trigger and must get the rule's response.
+const (
+	KindFixed = "fixed"
+	KindRule  = "rule"
+)
+
+// maxRuleProbes caps how many stored rules are probed per run (highest priority first).
+const maxRuleProbes = 5
+
+// Probe is one benchmark prompt.
+type Probe struct {
+	Name   string
+	Prompt string
+	Kind   string
+	RuleID int // rule probes only
+}
+
+// Generator is the subset of the codec client a benchmark run needs.
+type Generator interface {
+	Generate(ctx context.Context, prompt string, stateVec [128]float32, evidence []string, ollamaCtx []int64) (codec.GenerateResult, error)
+}
+
+// Assembler builds the adaptive components for a prompt as a live turn would
+// (state vector, scoped preferences, matching rules, retrieved evidence).
+type Assembler func(ctx context.Context, prompt string) (ablation.Inputs, error)
+
+// ProbeResult is one probe's response and scores.
+type ProbeResult struct {
+	Probe      Probe
+	Response   string
+	Compliance float32 // preference compliance (0.5 = neutral)
+	Scored     bool    // at least one scoped preference applied
+	RuleOK     bool    // rule fired when it should, and only then
+	Err        error
+}
+
+// Run is one benchmark pass, stored as a point in the time series.
+type Run struct {
+	ID           int64
+	RanAt        time.Time
+	StateVersion string
+	Model        string
+	Compliance   float32 // mean over probes with applicable preferences; 0.5 when none
+	Scored       int     // probes contributing to Compliance
+	RuleAccuracy float32 // fraction of probes whose rule behaviour was correct
+	Probes       int
+	Failures     int // probes whose generation failed
+	Results      []ProbeResult
+}
+
+// #endregion types
+
+// #region probes
+
+// fixedPrompts span the turn contexts preferences can be scoped to, so scoped
+// and unscoped preferences are both exercised. Changing them breaks the
+// comparability of the time series; add new prompts rather than editing these.
+var fixedPrompts = []Probe{
+	{Name: "explain", Prompt: "Explain how a hash map handles collisions."},
+	{Name: "factual", Prompt: "What causes the seasons on Earth?"},
+	{Name: "coding", Prompt: "Write a function that reverses a linked list and explain the code."},
+	{Name: "writing", Prompt: "Draft a short email declining a meeting invitation."},
+	{Name: "chat", Prompt: "What is a good way to spend a rainy afternoon?"},
+	{Name: "advice", Prompt: "How should I prepare for a job interview?"},
+}
+
+// Probes returns the fixed prompt set followed by one probe per stored rule,
+// up to maxRuleProbes. Fixed prompts that happen to match a rule trigger are
+// dropped, since they would no longer test for misfires.
+func Probes(rules []projection.Rule) []Probe {
+	triggers := make(map[string]bool, len(rules))
+	for _, r := range rules {
+		triggers[strings.ToLower(strings.TrimSpace(r.Trigger))] = true
+	}
+	var probes []Probe
+	for _, p := range fixedPrompts {
+		if triggers[strings.ToLower(p.Prompt)] {
+			continue
+		}
+		p.Kind = KindFixed
+		probes = append(probes, p)
+	}
+	for i, r := range rules {
+		if i == maxRuleProbes {
+			break
+		}
+		probes = append(probes, Probe{Name: fmt.Sprintf("rule-%d", r.ID), Prompt: r.Trigger, Kind: KindRule, RuleID: r.ID})
+	}
+	return probes
+}
+
+// #endregion probes
+
+// #region scoring
+
+// ruleStem is the part of a rule's response a compliant reply must contain,
+// matching how the daemon recognises rule text in evidence.
+func ruleStem(r projection.Rule) string {
+	return strings.ToLower(strings.TrimRight(strings.TrimSpace(r.Response), "?.!"))
+}
+
+// ruleCorrect reports whether response has the right rule behaviour: a rule
+// probe must contain its rule's response, a fixed probe must contain none.
+func ruleCorrect(p Probe, response string, rules []projection.Rule) bool {
+	lower := strings.ToLower(response)
+	for _, r := range rules {
+		stem := ruleStem(r)
+		if stem == "" {
+			continue
+		}
+		if p.Kind == KindRule && r.ID == p.RuleID {
+			return strings.Contains(lower, stem)
+		}
+		if p.Kind == KindFixed && strings.Contains(lower, stem) {
+			return false
+		}
+	}
+	return p.Kind == KindFixed
+}
+
+// #endregion scoring
+
+// #region run
+
+// Execute runs every probe through assemble and g and scores the responses
+// against the preferences each probe was assembled with and against rules. A
+// failing probe is recorded with its error and left out of the aggregates.
+func Execute(ctx context.Context, g Generator, assemble Assembler, probes []Probe, rules []projection.Rule) Run {
+	run := Run{RanAt: time.Now().UTC(), Probes: len(probes), Compliance: 0.5}
+	var compliance float32
+	ruleOK, ruleTotal := 0, 0
+	for _, p := range probes {
+		res := ProbeResult{Probe: p}
+		in, err := assemble(ctx, p.Prompt)
+		if err != nil {
+			res.Err = fmt.Errorf("assemble: %w", err)
+			run.Failures++
+			run.Results = append(run.Results, res)
+			continue
+		}
+		v := ablation.Variants(in)[0]
+		if len(in.Rules) > 0 {
+			v.Prompt = in.Prompt // rule turns generate from the bare prompt
+		}
+		gen, err := g.Generate(ctx, v.Prompt, v.StateVector, v.Evidence, nil)
+		if err != nil {
+			res.Err = fmt.Errorf("generate: %w", err)
+			run.Failures++
+			run.Results = append(run.Results, res)
+			continue
+		}
+		if run.Model == "" && gen.Model != "" {
+			run.Model = gen.Model
+		}
+		res.Response = gen.Text
+		res.Compliance = projection.PreferenceComplianceScore(in.Preferences, gen.Text)
+		res.Scored = len(in.Preferences) > 0
+		if res.Scored {
+			compliance += res.Compliance
+			run.Scored++
+		}
+		res.RuleOK = ruleCorrect(p, gen.Text, rules)
+		ruleTotal++
+		if res.RuleOK {
+			ruleOK++
+		}
+		run.Results = append(run.Results, res)
+	}
+	if run.Scored > 0 {
+		run.Compliance = compliance / float32(run.Scored)
+	}
+	if ruleTotal > 0 {
+		run.RuleAccuracy = float32(ruleOK) / float32(ruleTotal)
+	}
+	return run
+}
+
+// #endregion run
+
+// #region regressions
+
+// RegressionConfig sets how far a run may fall below recent history before it
+// is reported.
+type RegressionConfig struct {
+	Window        int     // previous runs averaged as the baseline
+	MaxCompliance float32 // allowed drop in mean compliance
+	MaxRuleDrop   float32 // allowed drop in rule accuracy
+}
+
+// DefaultRegressionConfig returns sensible defaults.
+func DefaultRegressionConfig() RegressionConfig {
+	return RegressionConfig{Window: 4, MaxCompliance: 0.1, MaxRuleDrop: 0.2}
+}
+
+// Regression is one metric that fell past its allowed drop.
+type Regression struct {
+	Metric   string
+	Baseline float32
+	Current  float32
+}
+
+func (r Regression) String() string {
+	return fmt.Sprintf("%s %.2f → %.2f (%+.2f vs last runs)", r.Metric, r.Baseline, r.Current, r.Current-r.Baseline)
+}
+
+// Regressions compares current against the mean of up to cfg.Window previous
+// runs (newest first). Runs where every probe failed are not comparable and
+// are ignored on either side; compliance is compared only between runs that
+// scored it.
+func Regressions(current Run, previous []Run, cfg RegressionConfig) []Regression {
+	if current.Failures == current.Probes {
+		return nil
+	}
+	var compSum, ruleSum float32
+	compN, ruleN := 0, 0
+	for _, p := range previous {
+		if ruleN == cfg.Window {
+			break
+		}
+		if p.Failures == p.Probes {
+			continue
+		}
+		ruleSum += p.RuleAccuracy
+		ruleN++
+		if p.Scored > 0 {
+			compSum += p.Compliance
+			compN++
+		}
+	}
+	var out []Regression
+	if compN > 0 && current.Scored > 0 {
+		if base := compSum / float32(compN); base-current.Compliance > cfg.MaxCompliance {
+			out = append(out, Regression{Metric: "compliance", Baseline: base, Current: current.Compliance})
+		}
+	}
+	if ruleN > 0 {
+		if base := ruleSum / float32(ruleN); base-current.RuleAccuracy > cfg.MaxRuleDrop {
+			out = append(out, Regression{Metric: "rule_accuracy", Baseline: base, Current: current.RuleAccuracy})
+		}
+	}
+	return out
+}
+
+// #endregion regressions
+
+// #region format
+
+// Format renders a run: one line per probe, then the aggregates.
+func (r Run) Format() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%-12s %-6s %10s %6s  %s\n", "probe", "kind", "compliance", "rule", "response")
+	for _, res := range r.Results {
+		if res.Err != nil {
+			fmt.Fprintf(&b, "%-12s %-6s error: %v\n", res.Probe.Name, res.Probe.Kind, res.Err)
+			continue
+		}
+		comp := "-"
+		if res.Scored {
+			comp = fmt.Sprintf("%.2f", res.Compliance)
+		}
+		rule := "ok"
+		if !res.RuleOK {
+			rule = "WRONG"
+		}
+		fmt.Fprintf(&b, "%-12s %-6s %10s %6s  %s\n", res.Probe.Name, res.Probe.Kind, comp, rule, preview(res.Response, 60))
+	}
+	fmt.Fprintf(&b, "\ncompliance %.2f (%d scored) | rule accuracy %.2f | %d/%d probes failed\n",
+		r.Compliance, r.Scored, r.RuleAccuracy, r.Failures, r.Probes)
+	return b.String()
+}
+
+func preview(s string, n int) string {
+	s = strings.Join(strings.Fields(s), " ")
+	if r := []rune(s); len(r) > n {
+		return string(r[:n]) + "…"
+	}
+	return s
+}
+
+// #endregion format
@@ -0,0 +1,179 @@
+package bench
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/ablation"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/codec"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/projection"
+
+	_ "modernc.org/sqlite"
+)
+
+// #region helpers
+
+func setupTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+// scriptedGenerator answers each prompt from replies; unknown prompts fail.
+type scriptedGenerator struct {
+	replies map[string]string
+	prompts []string
+}
+
+func (g *scriptedGenerator) Generate(_ context.Context, prompt string, _ [128]float32, _ []string, _ []int64) (codec.GenerateResult, error) {
+	g.prompts = append(g.prompts, prompt)
+	for key, reply := range g.replies {
+		if strings.HasSuffix(prompt, key) {
+			return codec.GenerateResult{Text: reply, Model: "test-model"}, nil
+		}
+	}
+	return codec.GenerateResult{}, errors.New("codec down")
+}
+
+// #endregion helpers
+
+// #region run-tests
+
+func TestExecute_ScoresComplianceAndRuleFiring(t *testing.T) {
+	rules := []projection.Rule{{ID: 7, Trigger: "ping", Response: "Pong!"}}
+	conciseReply := "Short answer."
+	gen := &scriptedGenerator{replies: map[string]string{
+		"collisions.": conciseReply,
+		"Earth?":      "Axial tilt. Pong", // a fixed probe that fires the rule
+		"ping":        "pong",
+	}}
+	assemble := func(_ context.Context, prompt string) (ablation.Inputs, error) {
+		in := ablation.Inputs{Prompt: prompt, StateBlock: "[ADAPTIVE STATE]"}
+		if prompt == "ping" {
+			in.Rules = []string{projection.FormatRulesBlock(rules)}
+		} else {
+			in.Preferences = []projection.Preference{{Text: "Be concise", Style: projection.StyleConcise}}
+		}
+		return in, nil
+	}
+	probes := []Probe{
+		{Name: "explain", Prompt: "Explain how a hash map handles collisions.", Kind: KindFixed},
+		{Name: "factual", Prompt: "What causes the seasons on Earth?", Kind: KindFixed},
+		{Name: "chat", Prompt: "unanswered", Kind: KindFixed},
+		{Name: "rule-7", Prompt: "ping", Kind: KindRule, RuleID: 7},
+	}
+
+	run := Execute(context.Background(), gen, assemble, probes, rules)
+	if run.Failures != 1 || run.Probes != 4 || run.Model != "test-model" {
+		t.Fatalf("unexpected run totals: %+v", run)
+	}
+	if run.Scored != 2 || run.Compliance < 0.79 {
+		t.Errorf("expected compliance from 2 concise replies, got %.2f over %d", run.Compliance, run.Scored)
+	}
+	if run.RuleAccuracy < 0.66 || run.RuleAccuracy > 0.67 {
+		t.Errorf("expected 2 of 3 rule checks correct (misfire on factual), got %.2f", run.RuleAccuracy)
+	}
+	if gen.prompts[len(gen.prompts)-1] != "ping" {
+		t.Errorf("rule probe should generate from the bare prompt, got %q", gen.prompts[len(gen.prompts)-1])
+	}
+}
+
+func TestProbes_AddsRulesAndDropsMatchingFixedPrompts(t *testing.T) {
+	rules := []projection.Rule{{ID: 1, Trigger: "What causes the seasons on Earth?", Response: "Tilt."}}
+	for i := 2; i <= 8; i++ {
+		rules = append(rules, projection.Rule{ID: i, Trigger: "t", Response: "r"})
+	}
+	probes := Probes(rules)
+	fixed, ruleProbes := 0, 0
+	for _, p := range probes {
+		switch p.Kind {
+		case KindFixed:
+			fixed++
+			if p.Name == "factual" {
+				t.Error("fixed prompt matching a rule trigger should be dropped")
+			}
+		case KindRule:
+			ruleProbes++
+		}
+	}
+	if fixed != len(fixedPrompts)-1 || ruleProbes != maxRuleProbes {
+		t.Errorf("expected %d fixed and %d rule probes, got %d and %d", len(fixedPrompts)-1, maxRuleProbes, fixed, ruleProbes)
+	}
+}
+
+// #endregion run-tests
+
+// #region regression-tests
+
+func TestRegressions(t *testing.T) {
+	history := []Run{
+		{Compliance: 0.8, Scored: 3, RuleAccuracy: 1, Probes: 6},
+		{Probes: 6, Failures: 6}, // codec down: not comparable
+		{Compliance: 0.7, Scored: 3, RuleAccuracy: 1, Probes: 6},
+	}
+	cfg := DefaultRegressionConfig()
+
+	steady := Run{Compliance: 0.72, Scored: 3, RuleAccuracy: 1, Probes: 6}
+	if regs := Regressions(steady, history, cfg); len(regs) != 0 {
+		t.Errorf("expected no regression, got %v", regs)
+	}
+
+	worse := Run{Compliance: 0.5, Scored: 3, RuleAccuracy: 0.5, Probes: 6}
+	regs := Regressions(worse, history, cfg)
+	if len(regs) != 2 || regs[0].Metric != "compliance" || regs[1].Metric != "rule_accuracy" {
+		t.Fatalf("expected compliance and rule accuracy regressions, got %v", regs)
+	}
+	if regs[0].Baseline < 0.74 || regs[0].Baseline > 0.76 {
+		t.Errorf("baseline should average the comparable runs, got %.2f", regs[0].Baseline)
+	}
+
+	if regs := Regressions(Run{Probes: 6, Failures: 6}, history, cfg); regs != nil {
+		t.Errorf("a fully failed run should not report regressions, got %v", regs)
+	}
+}
+
+// #endregion regression-tests
+
+// #region store-tests
+
+func TestStore_RecordRecentAndDue(t *testing.T) {
+	store, err := NewStore(setupTestDB(t))
+	if err != nil {
+		t.Fatalf("NewStore: %v", err)
+	}
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	if due, _ := store.Due(now, 7*24*time.Hour); !due {
+		t.Error("first run should be due")
+	}
+
+	run := Run{RanAt: now.Add(-48 * time.Hour), StateVersion: "v1", Model: "m", Compliance: 0.8, Scored: 2, RuleAccuracy: 1, Probes: 3, Failures: 1,
+		Results: []ProbeResult{{Probe: Probe{Name: "chat", Kind: KindFixed}, Err: errors.New("codec down")}}}
+	if _, err := store.Record(run); err != nil {
+		t.Fatalf("Record: %v", err)
+	}
+	store.Record(Run{RanAt: now.Add(-24 * time.Hour), Compliance: 0.6, Probes: 3})
+
+	runs, err := store.Recent(10)
+	if err != nil || len(runs) != 2 {
+		t.Fatalf("Recent = %d runs, %v", len(runs), err)
+	}
+	if runs[0].Compliance != 0.6 || runs[1].StateVersion != "v1" || runs[1].Results[0].Err == nil {
+		t.Errorf("runs not round-tripped newest first: %+v", runs)
+	}
+	if due, _ := store.Due(now, 7*24*time.Hour); due {
+		t.Error("run a day ago should not be due for a weekly interval")
+	}
+	if due, _ := store.Due(now, 0); due {
+		t.Error("zero interval should disable scheduling")
+	}
+}
+
+// #endregion store-tests
@@ -0,0 +1,143 @@
+package bench
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/timestamp"
+)
+
+// #region store
+
+// Store keeps benchmark runs as a time series in the bench_runs table.
+type Store struct {
+	db *sql.DB
+}
+
+// probeRecord is the per-probe detail kept in results_json.
+type probeRecord struct {
+	Name       string  `json:"name"`
+	Kind       string  `json:"kind"`
+	Compliance float32 `json:"compliance"`
+	Scored     bool    `json:"scored"`
+	RuleOK     bool    `json:"rule_ok"`
+	Error      string  `json:"error,omitempty"`
+}
+
+// NewStore creates the bench_runs table if needed and returns a store.
+func NewStore(db *sql.DB) (*Store, error) {
+	_, err := db.Exec(`CREATE TABLE IF NOT EXISTS bench_runs (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		ran_at TEXT NOT NULL,
+		state_version TEXT NOT NULL DEFAULT '',
+		model TEXT NOT NULL DEFAULT '',
+		compliance REAL NOT NULL,
+		scored INTEGER NOT NULL,
+		rule_accuracy REAL NOT NULL,
+		probes INTEGER NOT NULL,
+		failures INTEGER NOT NULL,
+		results_json TEXT NOT NULL DEFAULT '[]'
+	)`)
+	if err != nil {
+		return nil, fmt.Errorf("create bench_runs table: %w", err)
+	}
+	if _, err := timestamp.Canonicalize(db, "bench_runs", "ran_at"); err != nil {
+		return nil, err
+	}
+	return &Store{db: db}, nil
+}
+
+// Record stores run and returns its ID.
+func (s *Store) Record(run Run) (int64, error) {
+	records := make([]probeRecord, 0, len(run.Results))
+	for _, res := range run.Results {
+		rec := probeRecord{Name: res.Probe.Name, Kind: res.Probe.Kind, Compliance: res.Compliance, Scored: res.Scored, RuleOK: res.RuleOK}
+		if res.Err != nil {
+			rec.Error = res.Err.Error()
+		}
+		records = append(records, rec)
+	}
+	data, err := json.Marshal(records)
+	if err != nil {
+		return 0, fmt.Errorf("marshal bench results: %w", err)
+	}
+	if run.RanAt.IsZero() {
+		run.RanAt = time.Now().UTC()
+	}
+	res, err := s.db.Exec(
+		`INSERT INTO bench_runs (ran_at, state_version, model, compliance, scored, rule_accuracy, probes, failures, results_json)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		timestamp.Format(run.RanAt), run.StateVersion, run.Model, run.Compliance, run.Scored,
+		run.RuleAccuracy, run.Probes, run.Failures, string(data),
+	)
+	if err != nil {
+		return 0, fmt.Errorf("insert bench run: %w", err)
+	}
+	return res.LastInsertId()
+}
+
+// Recent returns up to limit runs, newest first. Per-probe results carry
+// names, kinds and scores only; responses are not stored.
+func (s *Store) Recent(limit int) ([]Run, error) {
+	rows, err := s.db.Query(
+		`SELECT id, ran_at, state_version, model, compliance, scored, rule_accuracy, probes, failures, results_json
+		 FROM bench_runs ORDER BY ran_at DESC, id DESC LIMIT ?`, limit)
+	if err != nil {
+		return nil, fmt.Errorf("list bench runs: %w", err)
+	}
+	defer rows.Close()
+
+	var runs []Run
+	for rows.Next() {
+		var r Run
+		var ranAt, data string
+		if err := rows.Scan(&r.ID, &ranAt, &r.StateVersion, &r.Model, &r.Compliance, &r.Scored,
+			&r.RuleAccuracy, &r.Probes, &r.Failures, &data); err != nil {
+			return nil, fmt.Errorf("scan bench run: %w", err)
+		}
+		r.RanAt, _ = timestamp.Parse(ranAt)
+		var records []probeRecord
+		_ = json.Unmarshal([]byte(data), &records)
+		for _, rec := range records {
+			res := ProbeResult{Probe: Probe{Name: rec.Name, Kind: rec.Kind}, Compliance: rec.Compliance, Scored: rec.Scored, RuleOK: rec.RuleOK}
+			if rec.Error != "" {
+				res.Err = errors.New(rec.Error)
+			}
+			r.Results = append(r.Results, res)
+		}
+		runs = append(runs, r)
+	}
+	return runs, rows.Err()
+}
+
+// LastRunAt returns when the newest run was recorded; ok is false when there is none.
+func (s *Store) LastRunAt() (t time.Time, ok bool, err error) {
+	var ranAt string
+	err = s.db.QueryRow(`SELECT ran_at FROM bench_runs ORDER BY ran_at DESC, id DESC LIMIT 1`).Scan(&ranAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return time.Time{}, false, nil
+	}
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("last bench run: %w", err)
+	}
+	t, _ = timestamp.Parse(ranAt)
+	return t, true, nil
+}
+
+// Due reports whether a run is due at now: none recorded yet, or the newest is
+// at least interval old. A non-positive interval never schedules a run.
+func (s *Store) Due(now time.Time, interval time.Duration) (bool, error) {
+	if interval <= 0 {
+		return false, nil
+	}
+	last, ok, err := s.LastRunAt()
+	if err != nil {
+		return false, err
+	}
+	return !ok || now.Sub(last) >= interval, nil
+}
+
+// #endregion store
@@ -0,0 +1,155 @@
+package budget
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// #region planner
+
+// Stage names used for duration estimates.
+const (
+	StageGenerate   = "generate"
+	StageSearch     = "search"
+	StageReflection = "reflection"
+	StageSummarize  = "summarize" // evidence.Policy's sentence embeddings
+)
+
+// MinStage is the least time an optional stage is assumed to need, even
+// before any duration has been observed for it.
+const MinStage = 2 * time.Second
+
+// estimateAlpha weights the newest observation in a stage's moving average.
+const estimateAlpha = 0.3
+
+// Planner hands out per-turn budgets and keeps a moving average of how long each
+// stage actually takes, so optional stages can be skipped when they won't fit.
+// Total <= 0 disables the deadline: budgets never run out and per-RPC caps apply as-is.
+type Planner struct {
+	Total time.Duration
+
+	mu        sync.Mutex
+	estimates map[string]time.Duration
+	now       func() time.Time
+}
+
+// NewPlanner returns a Planner whose budgets last total per turn.
+func NewPlanner(total time.Duration) *Planner {
+	return &Planner{Total: total, estimates: make(map[string]time.Duration), now: time.Now}
+}
+
+// Begin starts the budget for a new turn.
+func (p *Planner) Begin() *Budget {
+	b := &Budget{p: p}
+	if p.Total > 0 {
+		b.deadline = p.now().Add(p.Total)
+	}
+	return b
+}
+
+// Observe records a completed stage duration.
+func (p *Planner) Observe(stage string, d time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	prev, ok := p.estimates[stage]
+	if !ok {
+		p.estimates[stage] = d
+		return
+	}
+	p.estimates[stage] = time.Duration(estimateAlpha*float64(d) + (1-estimateAlpha)*float64(prev))
+}
+
+// Seed sets stage's estimate to d, e.g. an average saved by an earlier run;
+// later observations average into it. d <= 0 is ignored.
+func (p *Planner) Seed(stage string, d time.Duration) {
+	if d <= 0 {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.estimates[stage] = d
+}
+
+// Estimates returns a copy of the observed stage averages.
+func (p *Planner) Estimates() map[string]time.Duration {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	out := make(map[string]time.Duration, len(p.estimates))
+	for stage, d := range p.estimates {
+		out[stage] = d
+	}
+	return out
+}
+
+// Estimate returns the expected duration of stage, at least MinStage.
+func (p *Planner) Estimate(stage string) time.Duration {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if d := p.estimates[stage]; d > MinStage {
+		return d
+	}
+	return MinStage
+}
+
+// #endregion planner
+
+// #region budget
+
+// Budget is one turn's share of time. Each stage's timeout is the smaller of its
+// own cap and whatever remains, so caps no longer stack past the deadline.
+type Budget struct {
+	p        *Planner
+	deadline time.Time // zero = unlimited
+}
+
+// Unlimited reports whether the budget has no deadline.
+func (b *Budget) Unlimited() bool { return b.deadline.IsZero() }
+
+// Remaining returns the time left before the deadline (never negative).
+func (b *Budget) Remaining() time.Duration {
+	if b.Unlimited() {
+		return time.Duration(1<<63 - 1)
+	}
+	if r := b.deadline.Sub(b.p.now()); r > 0 {
+		return r
+	}
+	return 0
+}
+
+// Timeout returns the timeout for a stage whose own cap is max.
+func (b *Budget) Timeout(max time.Duration) time.Duration {
+	if r := b.Remaining(); r < max {
+		return r
+	}
+	return max
+}
+
+// Affords reports whether the remaining time covers the estimated duration of
+// all given stages. Unlimited budgets afford everything.
+func (b *Budget) Affords(stages ...string) bool {
+	if b.Unlimited() {
+		return true
+	}
+	var need time.Duration
+	for _, s := range stages {
+		need += b.p.Estimate(s)
+	}
+	return b.Remaining() >= need
+}
+
+// Context derives a stage context from parent, bounded by Timeout(max). The
+// returned done func releases it and, if the stage finished within its
+// timeout, records the elapsed time as an observation for stage.
+func (b *Budget) Context(parent context.Context, stage string, max time.Duration) (context.Context, func()) {
+	ctx, cancel := context.WithTimeout(parent, b.Timeout(max))
+	start := b.p.now()
+	return ctx, func() {
+		if ctx.Err() == nil {
+			b.p.Observe(stage, b.p.now().Sub(start))
+		}
+		cancel()
+	}
+}
+
+// #endregion budget
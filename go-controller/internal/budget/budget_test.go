@@ -0,0 +1,111 @@
+package budget
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// #region helpers
+
+type fakeClock struct{ t time.Time }
+
+func (c *fakeClock) now() time.Time          { return c.t }
+func (c *fakeClock) advance(d time.Duration) { c.t = c.t.Add(d) }
+
+func testPlanner(total time.Duration) (*Planner, *fakeClock) {
+	clock := &fakeClock{t: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)}
+	p := NewPlanner(total)
+	p.now = clock.now
+	return p, clock
+}
+
+// #endregion helpers
+
+// #region budget-tests
+
+func TestBudget_TimeoutShrinksWithRemaining(t *testing.T) {
+	p, clock := testPlanner(90 * time.Second)
+	b := p.Begin()
+	if got := b.Timeout(60 * time.Second); got != 60*time.Second {
+		t.Errorf("expected full cap early in the turn, got %s", got)
+	}
+	clock.advance(70 * time.Second)
+	if got := b.Timeout(60 * time.Second); got != 20*time.Second {
+		t.Errorf("expected cap cut to remaining 20s, got %s", got)
+	}
+	clock.advance(time.Minute)
+	if got := b.Remaining(); got != 0 {
+		t.Errorf("expected no time left, got %s", got)
+	}
+}
+
+func TestBudget_UnlimitedKeepsCaps(t *testing.T) {
+	p, _ := testPlanner(0)
+	b := p.Begin()
+	if !b.Unlimited() || b.Timeout(60*time.Second) != 60*time.Second || !b.Affords(StageGenerate, StageSearch) {
+		t.Error("disabled deadline should leave caps unchanged and afford every stage")
+	}
+}
+
+func TestBudget_AffordsUsesObservedDurations(t *testing.T) {
+	p, clock := testPlanner(60 * time.Second)
+	b := p.Begin()
+	if !b.Affords(StageSearch, StageGenerate) {
+		t.Fatal("unobserved stages should only need MinStage each")
+	}
+
+	ctx, done := b.Context(context.Background(), StageGenerate, 60*time.Second)
+	clock.advance(30 * time.Second)
+	done()
+	if ctx.Err() == nil {
+		t.Error("done should release the stage context")
+	}
+	if got := p.Estimate(StageGenerate); got != 30*time.Second {
+		t.Errorf("expected first observation as estimate, got %s", got)
+	}
+	// 30s left: one more generate (30s) fits, generate + search does not.
+	if !b.Affords(StageGenerate) || b.Affords(StageSearch, StageGenerate) {
+		t.Errorf("unexpected affordability with %s remaining", b.Remaining())
+	}
+
+	p.Observe(StageGenerate, 10*time.Second)
+	if got := p.Estimate(StageGenerate); got != 24*time.Second {
+		t.Errorf("expected moving average 24s, got %s", got)
+	}
+}
+
+func TestPlanner_SeedCarriesEstimatesOver(t *testing.T) {
+	p, _ := testPlanner(60 * time.Second)
+	p.Observe(StageGenerate, 30*time.Second)
+	saved := p.Estimates()
+
+	next, _ := testPlanner(60 * time.Second)
+	for stage, d := range saved {
+		next.Seed(stage, d)
+	}
+	next.Seed(StageSearch, 0) // nothing saved: ignored
+	if got := next.Estimate(StageGenerate); got != 30*time.Second {
+		t.Errorf("seeded generate estimate = %s, want 30s", got)
+	}
+	if _, ok := next.Estimates()[StageSearch]; ok {
+		t.Error("a zero seed should leave the stage unobserved")
+	}
+	next.Observe(StageGenerate, 10*time.Second)
+	if got := next.Estimate(StageGenerate); got != 24*time.Second {
+		t.Errorf("observation after seeding = %s, want the moving average 24s", got)
+	}
+}
+
+func TestBudget_ExpiredStageIsNotObserved(t *testing.T) {
+	p, _ := testPlanner(time.Nanosecond)
+	b := p.Begin()
+	ctx, done := b.Context(context.Background(), StageReflection, time.Minute)
+	<-ctx.Done()
+	done()
+	if got := p.Estimate(StageReflection); got != MinStage {
+		t.Errorf("timed-out stage should not update estimate, got %s", got)
+	}
+}
+
+// #endregion budget-tests
@@ -0,0 +1,198 @@
+package cache
+
+import (
+	"container/list"
+	"sort"
+	"sync"
+)
+
+// #region manager
+
+// Manager owns a global byte budget shared by named LRU caches. All caches share
+// one lock and one recency clock, so when the global budget is exceeded the least
+// recently used entry across every cache is evicted first.
+type Manager struct {
+	mu     sync.Mutex
+	config Config
+	caches map[string]*LRU
+	bytes  int64
+	clock  uint64
+}
+
+// NewManager creates a manager with the given global budget.
+func NewManager(config Config) *Manager {
+	return &Manager{config: config, caches: make(map[string]*LRU)}
+}
+
+// Register returns the named cache, creating it if needed. maxBytes caps this cache
+// on its own (0 = bounded only by the global budget). Re-registering returns the
+// existing cache unchanged.
+func (m *Manager) Register(name string, maxBytes int64) *LRU {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if c, ok := m.caches[name]; ok {
+		return c
+	}
+	c := &LRU{
+		mgr:      m,
+		name:     name,
+		maxBytes: maxBytes,
+		ll:       list.New(),
+		items:    make(map[string]*list.Element),
+	}
+	m.caches[name] = c
+	return c
+}
+
+// Bytes returns total accounted bytes across all caches.
+func (m *Manager) Bytes() int64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.bytes
+}
+
+// Stats returns a snapshot per cache, ordered by name.
+func (m *Manager) Stats() []Stats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	out := make([]Stats, 0, len(m.caches))
+	for _, c := range m.caches {
+		out = append(out, Stats{
+			Name:      c.name,
+			Entries:   c.ll.Len(),
+			Bytes:     c.bytes,
+			MaxBytes:  c.maxBytes,
+			Hits:      c.hits,
+			Misses:    c.misses,
+			Evictions: c.evictions,
+		})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// enforceGlobal evicts globally-oldest entries until the budget holds. Caller holds m.mu.
+func (m *Manager) enforceGlobal() {
+	for m.config.MaxBytes > 0 && m.bytes > m.config.MaxBytes {
+		var victim *LRU
+		var oldest uint64
+		for _, c := range m.caches {
+			back := c.ll.Back()
+			if back == nil {
+				continue
+			}
+			if seq := back.Value.(*entry).seq; victim == nil || seq < oldest {
+				victim, oldest = c, seq
+			}
+		}
+		if victim == nil {
+			return
+		}
+		victim.evictOldest()
+	}
+}
+
+// #endregion manager
+
+// #region lru
+
+// LRU is a byte-accounted least-recently-used cache registered with a Manager.
+// Safe for concurrent use.
+type LRU struct {
+	mgr      *Manager
+	name     string
+	maxBytes int64
+	ll       *list.List // front = most recent
+	items    map[string]*list.Element
+	bytes    int64
+
+	hits, misses, evictions int64
+}
+
+type entry struct {
+	key   string
+	value interface{}
+	size  int64
+	seq   uint64
+}
+
+// Get returns the cached value for key and marks it most recently used.
+func (c *LRU) Get(key string) (interface{}, bool) {
+	c.mgr.mu.Lock()
+	defer c.mgr.mu.Unlock()
+	el, ok := c.items[key]
+	if !ok {
+		c.misses++
+		return nil, false
+	}
+	c.hits++
+	c.mgr.clock++
+	el.Value.(*entry).seq = c.mgr.clock
+	c.ll.MoveToFront(el)
+	return el.Value.(*entry).value, true
+}
+
+// Put stores value under key with the given payload size in bytes, then evicts
+// as needed to respect the per-cache cap and the global budget. size covers the
+// value only; the key and entry overhead are added here. An entry larger than
+// either limit, or any entry while the manager is disabled, is not stored.
+func (c *LRU) Put(key string, value interface{}, size int64) {
+	c.mgr.mu.Lock()
+	defer c.mgr.mu.Unlock()
+	if c.mgr.config.Disabled {
+		return
+	}
+
+	size += entryOverhead + int64(len(key))
+	if el, ok := c.items[key]; ok {
+		c.remove(el)
+	}
+	if (c.maxBytes > 0 && size > c.maxBytes) || (c.mgr.config.MaxBytes > 0 && size > c.mgr.config.MaxBytes) {
+		return
+	}
+
+	c.mgr.clock++
+	c.items[key] = c.ll.PushFront(&entry{key: key, value: value, size: size, seq: c.mgr.clock})
+	c.bytes += size
+	c.mgr.bytes += size
+
+	for c.maxBytes > 0 && c.bytes > c.maxBytes {
+		c.evictOldest()
+	}
+	c.mgr.enforceGlobal()
+}
+
+// Delete removes key if present.
+func (c *LRU) Delete(key string) {
+	c.mgr.mu.Lock()
+	defer c.mgr.mu.Unlock()
+	if el, ok := c.items[key]; ok {
+		c.remove(el)
+	}
+}
+
+// Len returns the number of entries.
+func (c *LRU) Len() int {
+	c.mgr.mu.Lock()
+	defer c.mgr.mu.Unlock()
+	return c.ll.Len()
+}
+
+// evictOldest drops the least recently used entry. Caller holds mgr.mu.
+func (c *LRU) evictOldest() {
+	if back := c.ll.Back(); back != nil {
+		c.remove(back)
+		c.evictions++
+	}
+}
+
+// remove unlinks el and releases its bytes. Caller holds mgr.mu.
+func (c *LRU) remove(el *list.Element) {
+	e := el.Value.(*entry)
+	c.ll.Remove(el)
+	delete(c.items, e.key)
+	c.bytes -= e.size
+	c.mgr.bytes -= e.size
+}
+
+// #endregion lru
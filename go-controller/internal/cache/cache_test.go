@@ -0,0 +1,105 @@
+package cache
+
+import "testing"
+
+// #region lru-tests
+func TestLRU_GetPutAndStats(t *testing.T) {
+	m := NewManager(DefaultConfig())
+	c := m.Register("embeddings", 0)
+
+	if _, ok := c.Get("a"); ok {
+		t.Fatal("expected miss on empty cache")
+	}
+	c.Put("a", []float32{1, 2}, Float32sSize([]float32{1, 2}))
+	v, ok := c.Get("a")
+	if !ok || len(v.([]float32)) != 2 {
+		t.Fatalf("expected hit with stored value, got %v %v", v, ok)
+	}
+
+	s := m.Stats()[0]
+	if s.Name != "embeddings" || s.Entries != 1 || s.Hits != 1 || s.Misses != 1 {
+		t.Errorf("unexpected stats: %+v", s)
+	}
+	if s.HitRate() != 0.5 {
+		t.Errorf("expected hit rate 0.5, got %.2f", s.HitRate())
+	}
+	if want := int64(8 + entryOverhead + 1); s.Bytes != want || m.Bytes() != want {
+		t.Errorf("expected %d bytes, got cache=%d manager=%d", want, s.Bytes, m.Bytes())
+	}
+
+	// Replacing a key re-accounts rather than double counting
+	c.Put("a", "x", 1)
+	if m.Bytes() != int64(1+entryOverhead+1) {
+		t.Errorf("expected replacement to re-account bytes, got %d", m.Bytes())
+	}
+	c.Delete("a")
+	if c.Len() != 0 || m.Bytes() != 0 {
+		t.Errorf("expected empty after delete, len=%d bytes=%d", c.Len(), m.Bytes())
+	}
+}
+
+func TestLRU_PerCacheCapEvictsOldest(t *testing.T) {
+	m := NewManager(Config{})
+	per := int64(entryOverhead + 1 + 10)
+	c := m.Register("r", 2*per)
+
+	c.Put("a", "", 10)
+	c.Put("b", "", 10)
+	c.Get("a") // b is now least recent
+	c.Put("c", "", 10)
+
+	if _, ok := c.Get("b"); ok {
+		t.Error("expected b to be evicted")
+	}
+	if _, ok := c.Get("a"); !ok {
+		t.Error("expected a to survive (recently used)")
+	}
+	if s := m.Stats()[0]; s.Evictions != 1 || s.Bytes > 2*per {
+		t.Errorf("unexpected stats after eviction: %+v", s)
+	}
+}
+
+func TestManager_GlobalBudgetEvictsAcrossCaches(t *testing.T) {
+	per := int64(entryOverhead + 1 + 10)
+	m := NewManager(Config{MaxBytes: 2 * per})
+	a := m.Register("a", 0)
+	b := m.Register("b", 0)
+
+	a.Put("1", "", 10)
+	b.Put("2", "", 10)
+	b.Put("3", "", 10) // global budget exceeded: a/1 is the oldest anywhere
+
+	if a.Len() != 0 || b.Len() != 2 {
+		t.Fatalf("expected global LRU eviction from cache a, got a=%d b=%d", a.Len(), b.Len())
+	}
+	if m.Bytes() > 2*per {
+		t.Errorf("expected global bytes <= %d, got %d", 2*per, m.Bytes())
+	}
+}
+
+func TestLRU_OversizedEntryNotStored(t *testing.T) {
+	m := NewManager(Config{MaxBytes: 100})
+	c := m.Register("x", 0)
+	c.Put("big", "", 1000)
+	if c.Len() != 0 || m.Bytes() != 0 {
+		t.Errorf("expected oversized entry to be skipped, len=%d bytes=%d", c.Len(), m.Bytes())
+	}
+}
+
+func TestManager_DisabledStoresNothing(t *testing.T) {
+	m := NewManager(Config{Disabled: true})
+	c := m.Register("x", 0)
+	c.Put("a", "value", 5)
+	if _, ok := c.Get("a"); ok || c.Len() != 0 || m.Bytes() != 0 {
+		t.Errorf("expected nothing cached, len=%d bytes=%d", c.Len(), m.Bytes())
+	}
+}
+
+func TestManager_RegisterIdempotent(t *testing.T) {
+	m := NewManager(DefaultConfig())
+	if m.Register("x", 10) != m.Register("x", 99) {
+		t.Error("expected re-register to return the same cache")
+	}
+}
+
+// #endregion lru-tests
@@ -0,0 +1,57 @@
+package cache
+
+// #region config
+
+// Config sets the global memory budget shared by all registered caches.
+type Config struct {
+	MaxBytes int64 // total bytes across all caches; the least recently used entry anywhere is evicted first
+	Disabled bool  // store nothing, so every Get misses
+}
+
+// DefaultConfig returns a 64 MiB global budget.
+func DefaultConfig() Config {
+	return Config{MaxBytes: 64 << 20}
+}
+
+// #endregion config
+
+// #region stats
+
+// Stats is a point-in-time snapshot of one cache's accounting.
+type Stats struct {
+	Name      string `json:"name"`
+	Entries   int    `json:"entries"`
+	Bytes     int64  `json:"bytes"`
+	MaxBytes  int64  `json:"max_bytes"` // per-cache cap (0 = only the global budget applies)
+	Hits      int64  `json:"hits"`
+	Misses    int64  `json:"misses"`
+	Evictions int64  `json:"evictions"`
+}
+
+// HitRate returns hits / (hits + misses), or 0 with no lookups.
+func (s Stats) HitRate() float64 {
+	total := s.Hits + s.Misses
+	if total == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(total)
+}
+
+// #endregion stats
+
+// #region sizing
+
+// entryOverhead approximates per-entry bookkeeping (list element, map slot, key header).
+const entryOverhead = 64
+
+// StringSize returns the accounted size of a string value.
+func StringSize(s string) int64 {
+	return int64(len(s))
+}
+
+// Float32sSize returns the accounted size of a []float32 value (e.g. an embedding).
+func Float32sSize(v []float32) int64 {
+	return int64(len(v)) * 4
+}
+
+// #endregion sizing
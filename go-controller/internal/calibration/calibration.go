@@ -0,0 +1,211 @@
+package calibration
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/rand"
+	"os"
+	"time"
+)
+
+// #region sampler
+// Sampler decides which turns are captured, at most SamplesPerDay per UTC
+// day, spread across the day.
+type Sampler struct {
+	config   CalibrationConfig
+	random   func() float64
+	day      string
+	seen     int // turns offered today
+	count    int // turns captured today
+	prevSeen int // turns offered the previous day
+}
+
+// NewSampler creates a sampler with the given configuration.
+func NewSampler(config CalibrationConfig) *Sampler {
+	return &Sampler{config: config, random: rand.Float64}
+}
+
+// ShouldSample reports whether the turn at now should be captured. Each turn
+// is captured with probability (captures left today) / (turns expected in the
+// rest of the day), so captures spread over the day instead of going to its
+// first turns, and the daily cap still holds. The expected turns come from
+// today's rate so far, or the previous day's count if that is higher.
+// The daily counters reset when the UTC date changes.
+func (s *Sampler) ShouldSample(now time.Time) bool {
+	if !s.config.Enabled() {
+		return false
+	}
+	now = now.UTC()
+	day := now.Format("2006-01-02")
+	if day != s.day {
+		s.day, s.prevSeen, s.seen, s.count = day, s.seen, 0, 0
+	}
+	s.seen++
+	if s.count >= s.config.SamplesPerDay {
+		return false
+	}
+	midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	elapsed := now.Sub(midnight).Hours() / 24
+	rate := float64(s.prevSeen)
+	if elapsed > 0 {
+		rate = math.Max(rate, float64(s.seen)/elapsed)
+	}
+	expected := math.Max(rate*(1-elapsed), 1)
+	if s.random() >= float64(s.config.SamplesPerDay-s.count)/expected {
+		return false
+	}
+	s.count++
+	return true
+}
+
+// Capture appends a sample to the configured dataset file.
+func (s *Sampler) Capture(sample Sample) error {
+	return AppendSample(s.config.Path, sample)
+}
+
+// #endregion sampler
+
+// #region io
+// AppendSample writes a sample as one JSON line to path, creating the file if needed.
+func AppendSample(path string, sample Sample) error {
+	if sample.CapturedAt.IsZero() {
+		sample.CapturedAt = time.Now().UTC()
+	}
+	data, err := json.Marshal(sample)
+	if err != nil {
+		return fmt.Errorf("marshal sample: %w", err)
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("open calibration file: %w", err)
+	}
+	defer f.Close()
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("write sample: %w", err)
+	}
+	return nil
+}
+
+// LoadSamples reads all samples from a JSONL dataset file. Blank lines are skipped.
+func LoadSamples(path string) ([]Sample, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open calibration file: %w", err)
+	}
+	defer f.Close()
+
+	var samples []Sample
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+	line := 0
+	for scanner.Scan() {
+		line++
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var s Sample
+		if err := json.Unmarshal(scanner.Bytes(), &s); err != nil {
+			return nil, fmt.Errorf("parse line %d: %w", line, err)
+		}
+		samples = append(samples, s)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read calibration file: %w", err)
+	}
+	return samples, nil
+}
+
+// #endregion io
+
+// #region score
+// Score compares heuristic signals against annotated labels.
+// Samples without any labels are counted in TotalSamples only.
+func Score(samples []Sample) ScoreReport {
+	report := ScoreReport{TotalSamples: len(samples)}
+
+	type accum struct {
+		absSum, biasSum float64
+		count           int
+	}
+	names := []string{"sentiment", "coherence", "novelty"}
+	acc := make(map[string]*accum, len(names))
+	for _, n := range names {
+		acc[n] = &accum{}
+	}
+	add := func(name string, heuristic float32, label *float32) bool {
+		if label == nil {
+			return false
+		}
+		d := float64(heuristic) - float64(*label)
+		acc[name].absSum += math.Abs(d)
+		acc[name].biasSum += d
+		acc[name].count++
+		return true
+	}
+
+	report.Risk.Name = "risk_flag"
+	for _, s := range samples {
+		labeled := false
+		labeled = add("sentiment", s.Signals.SentimentScore, s.Labels.SentimentScore) || labeled
+		labeled = add("coherence", s.Signals.CoherenceScore, s.Labels.CoherenceScore) || labeled
+		labeled = add("novelty", s.Signals.NoveltyScore, s.Labels.NoveltyScore) || labeled
+		if s.Labels.RiskFlag != nil {
+			labeled = true
+			report.Risk.Count++
+			switch got, want := s.Signals.RiskFlag, *s.Labels.RiskFlag; {
+			case got && want:
+				report.Risk.TruePositives++
+			case got && !want:
+				report.Risk.FalsePositives++
+			case !got && want:
+				report.Risk.FalseNegatives++
+			default:
+				report.Risk.TrueNegatives++
+			}
+		}
+		if labeled {
+			report.LabeledSamples++
+		}
+	}
+
+	for _, n := range names {
+		a := acc[n]
+		cs := ContinuousScore{Name: n, Count: a.count}
+		if a.count > 0 {
+			cs.MAE = a.absSum / float64(a.count)
+			cs.Bias = a.biasSum / float64(a.count)
+		}
+		report.Continuous = append(report.Continuous, cs)
+	}
+	return report
+}
+
+// Accuracy returns the fraction of correct predictions (0 if no samples).
+func (b BinaryScore) Accuracy() float64 {
+	if b.Count == 0 {
+		return 0
+	}
+	return float64(b.TruePositives+b.TrueNegatives) / float64(b.Count)
+}
+
+// Precision returns TP / (TP + FP) (0 if nothing was flagged).
+func (b BinaryScore) Precision() float64 {
+	flagged := b.TruePositives + b.FalsePositives
+	if flagged == 0 {
+		return 0
+	}
+	return float64(b.TruePositives) / float64(flagged)
+}
+
+// Recall returns TP / (TP + FN) (0 if no positive labels).
+func (b BinaryScore) Recall() float64 {
+	positives := b.TruePositives + b.FalseNegatives
+	if positives == 0 {
+		return 0
+	}
+	return float64(b.TruePositives) / float64(positives)
+}
+
+// #endregion score
@@ -0,0 +1,163 @@
+package calibration
+
+import (
+	"math"
+	"math/rand"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func f32(v float32) *float32 { return &v }
+func bptr(v bool) *bool      { return &v }
+
+// #region sampler-tests
+func seededSampler(perDay int) *Sampler {
+	s := NewSampler(CalibrationConfig{Path: "unused", SamplesPerDay: perDay})
+	s.random = rand.New(rand.NewSource(1)).Float64
+	return s
+}
+
+func TestSampler_DailyCap(t *testing.T) {
+	s := seededSampler(2)
+	day1 := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	count := func(day time.Time) int {
+		n := 0
+		for i := 0; i < 500; i++ {
+			if s.ShouldSample(day.Add(time.Duration(i) * 24 * time.Hour / 500)) {
+				n++
+			}
+		}
+		return n
+	}
+	if n := count(day1); n == 0 || n > 2 {
+		t.Fatalf("day 1: captured %d turns, want 1-2", n)
+	}
+	if n := count(day1.Add(24 * time.Hour)); n == 0 || n > 2 {
+		t.Fatalf("day 2: captured %d turns, want 1-2 after the counter reset", n)
+	}
+}
+
+func TestSampler_SpreadsAcrossDay(t *testing.T) {
+	s := seededSampler(10)
+	day := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	var hours []int
+	for i := 0; i < 400; i++ {
+		at := day.Add(time.Duration(i) * 24 * time.Hour / 400)
+		if s.ShouldSample(at) {
+			hours = append(hours, at.Hour())
+		}
+	}
+	if len(hours) == 0 || len(hours) > 10 {
+		t.Fatalf("captured %d turns, want 1-10", len(hours))
+	}
+	var firstHalf, secondHalf int
+	for _, h := range hours {
+		if h < 12 {
+			firstHalf++
+		} else {
+			secondHalf++
+		}
+	}
+	if firstHalf == 0 || secondHalf == 0 {
+		t.Errorf("captures at hours %v, want them spread across the day", hours)
+	}
+}
+
+func TestSampler_Disabled(t *testing.T) {
+	s := NewSampler(CalibrationConfig{SamplesPerDay: 5})
+	if s.ShouldSample(time.Now()) {
+		t.Fatal("expected no sampling without a path")
+	}
+	s = NewSampler(CalibrationConfig{Path: "x.jsonl"})
+	if s.ShouldSample(time.Now()) {
+		t.Fatal("expected no sampling with SamplesPerDay=0")
+	}
+}
+
+// #endregion sampler-tests
+
+// #region io-tests
+func TestAppendAndLoadSamples(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "calibration.jsonl")
+	for _, id := range []string{"turn-1", "turn-2"} {
+		if err := AppendSample(path, Sample{TurnID: id, Entropy: 0.4}); err != nil {
+			t.Fatalf("AppendSample: %v", err)
+		}
+	}
+
+	samples, err := LoadSamples(path)
+	if err != nil {
+		t.Fatalf("LoadSamples: %v", err)
+	}
+	if len(samples) != 2 {
+		t.Fatalf("expected 2 samples, got %d", len(samples))
+	}
+	if samples[1].TurnID != "turn-2" {
+		t.Errorf("expected turn-2, got %s", samples[1].TurnID)
+	}
+	if samples[0].CapturedAt.IsZero() {
+		t.Error("expected CapturedAt to be set")
+	}
+	if samples[0].Labels.SentimentScore != nil {
+		t.Error("expected labels to be unset")
+	}
+}
+
+func TestLoadSamples_Missing(t *testing.T) {
+	if _, err := LoadSamples(filepath.Join(t.TempDir(), "nope.jsonl")); err == nil {
+		t.Fatal("expected error for missing file")
+	}
+}
+
+// #endregion io-tests
+
+// #region score-tests
+func TestScore_ContinuousAndBinary(t *testing.T) {
+	samples := []Sample{
+		{
+			Signals: HeuristicSignals{SentimentScore: 0.8, RiskFlag: true},
+			Labels:  Labels{SentimentScore: f32(0.6), RiskFlag: bptr(true)},
+		},
+		{
+			Signals: HeuristicSignals{SentimentScore: 0.2, RiskFlag: true},
+			Labels:  Labels{SentimentScore: f32(0.4), RiskFlag: bptr(false)},
+		},
+		{
+			Signals: HeuristicSignals{SentimentScore: 0.5},
+		},
+	}
+
+	r := Score(samples)
+	if r.TotalSamples != 3 || r.LabeledSamples != 2 {
+		t.Fatalf("expected 3 total / 2 labeled, got %d / %d", r.TotalSamples, r.LabeledSamples)
+	}
+
+	sent := r.Continuous[0]
+	if sent.Name != "sentiment" || sent.Count != 2 {
+		t.Fatalf("unexpected sentiment score: %+v", sent)
+	}
+	if math.Abs(sent.MAE-0.2) > 1e-5 {
+		t.Errorf("expected MAE 0.2, got %.4f", sent.MAE)
+	}
+	if math.Abs(sent.Bias) > 1e-5 {
+		t.Errorf("expected zero bias, got %.4f", sent.Bias)
+	}
+
+	if r.Risk.TruePositives != 1 || r.Risk.FalsePositives != 1 {
+		t.Errorf("unexpected risk confusion: %+v", r.Risk)
+	}
+	if r.Risk.Precision() != 0.5 || r.Risk.Accuracy() != 0.5 || r.Risk.Recall() != 1.0 {
+		t.Errorf("unexpected risk metrics: p=%.2f a=%.2f r=%.2f",
+			r.Risk.Precision(), r.Risk.Accuracy(), r.Risk.Recall())
+	}
+}
+
+func TestBinaryScore_ZeroCounts(t *testing.T) {
+	var b BinaryScore
+	if b.Accuracy() != 0 || b.Precision() != 0 || b.Recall() != 0 {
+		t.Fatal("expected zero metrics for empty score")
+	}
+}
+
+// #endregion score-tests
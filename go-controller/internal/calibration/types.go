@@ -0,0 +1,90 @@
+package calibration
+
+import "time"
+
+// #region config
+// CalibrationConfig controls how many turns are captured for labeling.
+type CalibrationConfig struct {
+	Path          string // JSONL output file; empty disables capture
+	SamplesPerDay int    // max turns captured per UTC day (0 = disabled)
+}
+
+// Enabled reports whether capture is configured.
+func (c CalibrationConfig) Enabled() bool {
+	return c.Path != "" && c.SamplesPerDay > 0
+}
+
+// #endregion config
+
+// #region sample
+// Sample is one captured turn: the heuristic signal inputs/outputs plus
+// empty label fields for a human annotator to fill in.
+type Sample struct {
+	TurnID     string    `json:"turn_id"`
+	CapturedAt time.Time `json:"captured_at"`
+
+	// Signal inputs
+	Prompt         string  `json:"prompt"`
+	Response       string  `json:"response"`
+	Entropy        float32 `json:"entropy"`
+	RetrievedCount int     `json:"retrieved_count"`
+
+	// Heuristic signal outputs as evaluated at runtime
+	Signals HeuristicSignals `json:"signals"`
+
+	// Gate outcome for context
+	GateAction    string  `json:"gate_action"`
+	GateSoftScore float32 `json:"gate_soft_score"`
+
+	// Human labels — nil until annotated
+	Labels Labels `json:"labels"`
+}
+
+// HeuristicSignals captures the signal values produced by the controller.
+type HeuristicSignals struct {
+	SentimentScore float32 `json:"sentiment_score"`
+	CoherenceScore float32 `json:"coherence_score"`
+	NoveltyScore   float32 `json:"novelty_score"`
+	RiskFlag       bool    `json:"risk_flag"`
+	UserCorrection bool    `json:"user_correction"`
+}
+
+// Labels holds annotator judgements. Pointer fields distinguish "unlabeled" from zero.
+type Labels struct {
+	SentimentScore *float32 `json:"sentiment_score"` // 0-1: did the response satisfy the user's preferences
+	CoherenceScore *float32 `json:"coherence_score"` // 0-1: did the response address the prompt
+	NoveltyScore   *float32 `json:"novelty_score"`   // 0-1: did the response add new information
+	RiskFlag       *bool    `json:"risk_flag"`       // true if the turn should have been vetoed as risky
+	Notes          string   `json:"notes"`
+}
+
+// #endregion sample
+
+// #region report
+// ScoreReport compares heuristic signals against human labels.
+type ScoreReport struct {
+	TotalSamples   int
+	LabeledSamples int
+	Continuous     []ContinuousScore
+	Risk           BinaryScore
+}
+
+// ContinuousScore summarizes agreement for a 0-1 signal.
+type ContinuousScore struct {
+	Name  string
+	Count int     // samples with this label present
+	MAE   float64 // mean absolute error
+	Bias  float64 // mean (heuristic - label); positive = heuristic overestimates
+}
+
+// BinaryScore summarizes agreement for a boolean signal.
+type BinaryScore struct {
+	Name           string
+	Count          int
+	TruePositives  int
+	FalsePositives int
+	TrueNegatives  int
+	FalseNegatives int
+}
+
+// #endregion report
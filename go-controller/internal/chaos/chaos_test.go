@@ -0,0 +1,218 @@
+package chaos
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	pb "github.com/danielpatrickdp/adaptive-state/go-controller/gen/adaptive"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/codec"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/logging"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/state"
+	"google.golang.org/grpc"
+)
+
+// #region mock
+
+// fakeCodec is a well-behaved codec service: every RPC succeeds.
+type fakeCodec struct {
+	pb.CodecServiceClient
+}
+
+func (f *fakeCodec) Generate(_ context.Context, in *pb.GenerateRequest, _ ...grpc.CallOption) (*pb.GenerateResponse, error) {
+	return &pb.GenerateResponse{Text: "reply to " + in.Prompt, Entropy: 0.4}, nil
+}
+
+func (f *fakeCodec) Embed(_ context.Context, in *pb.EmbedRequest, _ ...grpc.CallOption) (*pb.EmbedResponse, error) {
+	emb := make([]float32, 64)
+	for i := range emb {
+		emb[i] = float32((len(in.Text)+i)%7) - 3
+	}
+	return &pb.EmbedResponse{Embedding: emb}, nil
+}
+
+// #endregion mock
+
+// #region helpers
+
+func chaosStore(t *testing.T, inj *Injector) *state.Store {
+	t.Helper()
+	inj.SetEnabled(false)
+	defer inj.SetEnabled(true)
+	db, err := OpenSQLite(filepath.Join(t.TempDir(), "chaos.db"), inj)
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	store, err := state.NewStoreFromDB(db)
+	if err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	if _, err := store.CreateInitialState(state.DefaultSegmentMap()); err != nil {
+		t.Fatalf("initial state: %v", err)
+	}
+	return store
+}
+
+func script(n int) []string {
+	prompts := make([]string, n)
+	for i := range prompts {
+		prompts[i] = fmt.Sprintf("scripted prompt %d about topic %d", i, i%5)
+	}
+	return prompts
+}
+
+// #endregion helpers
+
+// #region parse-tests
+
+func TestParseConfig(t *testing.T) {
+	cfg, err := ParseConfig("generate=0.1/0.3:2s, search=0.5,db_commit=1", 7)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if g := cfg.Faults[PointGenerate]; g.ErrorRate != 0.1 || g.LatencyRate != 0.3 || g.Latency != 2*time.Second {
+		t.Errorf("unexpected generate fault: %+v", g)
+	}
+	if cfg.Faults[PointSearch].ErrorRate != 0.5 || cfg.Faults[PointDBCommit].ErrorRate != 1 || cfg.Seed != 7 {
+		t.Errorf("unexpected config: %+v", cfg)
+	}
+	for _, bad := range []string{"bogus=0.1", "search=2", "search", "generate=0.1/0.5"} {
+		if _, err := ParseConfig(bad, 0); err == nil {
+			t.Errorf("expected error for %q", bad)
+		}
+	}
+}
+
+// #endregion parse-tests
+
+// #region injector-tests
+
+func TestInjector_RatesAndDisable(t *testing.T) {
+	inj := NewInjector(Config{Seed: 1, Faults: map[string]Fault{PointSearch: {ErrorRate: 1}, PointEmbed: {ErrorRate: 0}}})
+	if err := inj.Maybe(context.Background(), PointSearch); !errors.Is(err, ErrInjected) {
+		t.Errorf("expected injected error, got %v", err)
+	}
+	if err := inj.Maybe(context.Background(), PointEmbed); err != nil {
+		t.Errorf("rate 0 must never fail, got %v", err)
+	}
+	inj.SetEnabled(false)
+	if err := inj.Maybe(context.Background(), PointSearch); err != nil {
+		t.Errorf("disabled injector must pass through, got %v", err)
+	}
+	if inj.Injected()[PointSearch] != 1 || inj.Summary() != "search=1" {
+		t.Errorf("unexpected counts: %s", inj.Summary())
+	}
+}
+
+func TestInjector_LatencyHonoursContext(t *testing.T) {
+	inj := NewInjector(Config{Seed: 1, Faults: map[string]Fault{PointGenerate: {LatencyRate: 1, Latency: time.Hour}}})
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := inj.Maybe(ctx, PointGenerate); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected deadline exceeded, got %v", err)
+	}
+}
+
+func TestWrapCodec_InjectsBeforeRPC(t *testing.T) {
+	inj := NewInjector(Config{Seed: 1, Faults: map[string]Fault{PointGenerate: {ErrorRate: 1}}})
+	client := codec.NewCodecClientWithService(&fakeCodec{}).WrapService(WrapCodec(inj))
+	if _, err := client.Generate(context.Background(), "hi", [128]float32{}, nil, nil); !errors.Is(err, ErrInjected) {
+		t.Errorf("expected injected generate error, got %v", err)
+	}
+	if _, err := client.Embed(context.Background(), "hi"); err != nil {
+		t.Errorf("unconfigured RPC should pass through, got %v", err)
+	}
+}
+
+func TestOpenSQLite_CommitFaultLeavesNoPartialState(t *testing.T) {
+	inj := NewInjector(Config{Seed: 1, Faults: map[string]Fault{PointDBCommit: {ErrorRate: 1}}})
+	store := chaosStore(t, inj)
+	before, _ := store.GetCurrent()
+
+	next := before
+	next.VersionID, next.ParentID = "v-next", before.VersionID
+	next.StateVector[0] = 1
+	err := store.WithTx(func(tx *sql.Tx) error { return store.CommitStateTx(tx, next) })
+	if !errors.Is(err, ErrInjected) {
+		t.Fatalf("expected injected commit failure, got %v", err)
+	}
+
+	inj.SetEnabled(false)
+	if after, _ := store.GetCurrent(); after.VersionID != before.VersionID {
+		t.Errorf("failed commit moved active state to %s", after.VersionID)
+	}
+	if _, err := store.GetVersion("v-next"); err == nil {
+		t.Error("failed commit left the new version behind")
+	}
+}
+
+// #endregion injector-tests
+
+// #region harness-tests
+
+// committingTurn commits a child of the active state the way a turn does,
+// with its provenance in the same transaction; with audit false it skips the
+// provenance row.
+func committingTurn(store *state.Store, audit bool) TurnFunc {
+	return func(prompt string) {
+		current, err := store.GetCurrent()
+		if err != nil {
+			return
+		}
+		next := current
+		next.VersionID, next.ParentID = fmt.Sprintf("v-%d", time.Now().UnixNano()), current.VersionID
+		next.StateVector[0] += 0.01
+		store.WithTx(func(tx *sql.Tx) error {
+			if err := store.CommitStateTx(tx, next); err != nil {
+				return err
+			}
+			if !audit {
+				return nil
+			}
+			return logging.LogDecision(tx, logging.ProvenanceEntry{VersionID: next.VersionID, TriggerType: "user_turn", Decision: "commit", Reason: prompt})
+		})
+	}
+}
+
+func TestHarness_CountsCommitsUnderFaults(t *testing.T) {
+	inj := NewInjector(Config{Seed: 3, Faults: map[string]Fault{PointDBCommit: {ErrorRate: 0.5}}})
+	store := chaosStore(t, inj)
+	report := NewHarness(store, committingTurn(store, true), inj, 0).Run(script(20))
+
+	if len(report.Violations) > 0 {
+		t.Fatalf("unexpected violations: %v", report.Violations)
+	}
+	if report.Count(OutcomeCommit) == 0 || report.Count(OutcomeKept) == 0 {
+		t.Errorf("expected a mix of committed and kept turns, got %+v", report.Turns)
+	}
+	if report.Injected[PointDBCommit] != report.Count(OutcomeKept) {
+		t.Errorf("every failed commit should keep the state: injected %s, kept %d", inj.Summary(), report.Count(OutcomeKept))
+	}
+}
+
+func TestHarness_FlagsUnauditedCommitAndPanic(t *testing.T) {
+	inj := NewInjector(Config{Seed: 1})
+	store := chaosStore(t, inj)
+	commit := committingTurn(store, false)
+	turn := func(prompt string) {
+		if prompt == script(2)[1] {
+			panic("boom")
+		}
+		commit(prompt)
+	}
+	report := NewHarness(store, turn, inj, 0).Run(script(2))
+
+	if report.Count(OutcomePanic) != 1 {
+		t.Errorf("expected the panic to be recorded, got %+v", report.Turns)
+	}
+	if len(report.Violations) == 0 {
+		t.Error("a commit without provenance must be a violation")
+	}
+}
+
+// #endregion harness-tests
@@ -0,0 +1,113 @@
+package chaos
+
+import (
+	"context"
+
+	pb "github.com/danielpatrickdp/adaptive-state/go-controller/gen/adaptive"
+	"google.golang.org/grpc"
+)
+
+// #region codec-faults
+
+// faultyCodec injects faults in front of every CodecService RPC.
+type faultyCodec struct {
+	inner pb.CodecServiceClient
+	inj   *Injector
+}
+
+// WrapCodec returns a wrapper for codec.CodecClient.WrapService that injects
+// inj's faults before each RPC reaches svc.
+func WrapCodec(inj *Injector) func(svc pb.CodecServiceClient) pb.CodecServiceClient {
+	return func(svc pb.CodecServiceClient) pb.CodecServiceClient {
+		return &faultyCodec{inner: svc, inj: inj}
+	}
+}
+
+func (c *faultyCodec) Generate(ctx context.Context, in *pb.GenerateRequest, opts ...grpc.CallOption) (*pb.GenerateResponse, error) {
+	if err := c.inj.Maybe(ctx, PointGenerate); err != nil {
+		return nil, err
+	}
+	return c.inner.Generate(ctx, in, opts...)
+}
+
+// GenerateStream shares the generate fault point; a fault fails the call
+// before any chunk is sent.
+func (c *faultyCodec) GenerateStream(ctx context.Context, in *pb.GenerateRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[pb.GenerateChunk], error) {
+	if err := c.inj.Maybe(ctx, PointGenerate); err != nil {
+		return nil, err
+	}
+	return c.inner.GenerateStream(ctx, in, opts...)
+}
+
+func (c *faultyCodec) Embed(ctx context.Context, in *pb.EmbedRequest, opts ...grpc.CallOption) (*pb.EmbedResponse, error) {
+	if err := c.inj.Maybe(ctx, PointEmbed); err != nil {
+		return nil, err
+	}
+	return c.inner.Embed(ctx, in, opts...)
+}
+
+// EmbedBatch shares the embed fault point: a batch is many embeds in one call.
+func (c *faultyCodec) EmbedBatch(ctx context.Context, in *pb.EmbedBatchRequest, opts ...grpc.CallOption) (*pb.EmbedBatchResponse, error) {
+	if err := c.inj.Maybe(ctx, PointEmbed); err != nil {
+		return nil, err
+	}
+	return c.inner.EmbedBatch(ctx, in, opts...)
+}
+
+func (c *faultyCodec) Search(ctx context.Context, in *pb.SearchRequest, opts ...grpc.CallOption) (*pb.SearchResponse, error) {
+	if err := c.inj.Maybe(ctx, PointSearch); err != nil {
+		return nil, err
+	}
+	return c.inner.Search(ctx, in, opts...)
+}
+
+func (c *faultyCodec) StoreEvidence(ctx context.Context, in *pb.StoreEvidenceRequest, opts ...grpc.CallOption) (*pb.StoreEvidenceResponse, error) {
+	if err := c.inj.Maybe(ctx, PointStoreEvidence); err != nil {
+		return nil, err
+	}
+	return c.inner.StoreEvidence(ctx, in, opts...)
+}
+
+func (c *faultyCodec) WebSearch(ctx context.Context, in *pb.WebSearchRequest, opts ...grpc.CallOption) (*pb.WebSearchResponse, error) {
+	if err := c.inj.Maybe(ctx, PointWebSearch); err != nil {
+		return nil, err
+	}
+	return c.inner.WebSearch(ctx, in, opts...)
+}
+
+func (c *faultyCodec) DeleteEvidence(ctx context.Context, in *pb.DeleteEvidenceRequest, opts ...grpc.CallOption) (*pb.DeleteEvidenceResponse, error) {
+	if err := c.inj.Maybe(ctx, PointDeleteEvidence); err != nil {
+		return nil, err
+	}
+	return c.inner.DeleteEvidence(ctx, in, opts...)
+}
+
+// UpdateEvidenceMetadata shares the store fault point: it is an evidence write.
+func (c *faultyCodec) UpdateEvidenceMetadata(ctx context.Context, in *pb.UpdateEvidenceMetadataRequest, opts ...grpc.CallOption) (*pb.UpdateEvidenceMetadataResponse, error) {
+	if err := c.inj.Maybe(ctx, PointStoreEvidence); err != nil {
+		return nil, err
+	}
+	return c.inner.UpdateEvidenceMetadata(ctx, in, opts...)
+}
+
+func (c *faultyCodec) GetByIDs(ctx context.Context, in *pb.GetByIDsRequest, opts ...grpc.CallOption) (*pb.GetByIDsResponse, error) {
+	if err := c.inj.Maybe(ctx, PointGetByIDs); err != nil {
+		return nil, err
+	}
+	return c.inner.GetByIDs(ctx, in, opts...)
+}
+
+func (c *faultyCodec) ListAllEvidence(ctx context.Context, in *pb.ListAllEvidenceRequest, opts ...grpc.CallOption) (*pb.ListAllEvidenceResponse, error) {
+	if err := c.inj.Maybe(ctx, PointListAllEvidence); err != nil {
+		return nil, err
+	}
+	return c.inner.ListAllEvidence(ctx, in, opts...)
+}
+
+// Handshake is passed through untouched: it runs once at startup, before
+// injection is enabled, and a failed handshake aborts the daemon by design.
+func (c *faultyCodec) Handshake(ctx context.Context, in *pb.HandshakeRequest, opts ...grpc.CallOption) (*pb.HandshakeResponse, error) {
+	return c.inner.Handshake(ctx, in, opts...)
+}
+
+// #endregion codec-faults
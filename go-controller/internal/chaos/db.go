@@ -0,0 +1,101 @@
+package chaos
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+
+	_ "modernc.org/sqlite"
+)
+
+// #region db-faults
+
+// OpenSQLite opens the SQLite database at dsn through a driver wrapper that
+// injects inj's db_* faults into exec, query, begin, and commit.
+func OpenSQLite(dsn string, inj *Injector) (*sql.DB, error) {
+	probe, err := sql.Open("sqlite", "")
+	if err != nil {
+		return nil, fmt.Errorf("chaos: load sqlite driver: %w", err)
+	}
+	drv := probe.Driver()
+	probe.Close()
+	return sql.OpenDB(&faultyConnector{drv: drv, dsn: dsn, inj: inj}), nil
+}
+
+type faultyConnector struct {
+	drv driver.Driver
+	dsn string
+	inj *Injector
+}
+
+func (c *faultyConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.drv.Open(c.dsn)
+	if err != nil {
+		return nil, err
+	}
+	return &faultyConn{Conn: conn, inj: c.inj}, nil
+}
+
+func (c *faultyConnector) Driver() driver.Driver { return c.drv }
+
+// faultyConn forwards to the real connection, failing first when the injector says so.
+type faultyConn struct {
+	driver.Conn
+	inj *Injector
+}
+
+func (c *faultyConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	if err := c.inj.Maybe(ctx, PointDBExec); err != nil {
+		return nil, err
+	}
+	execer, ok := c.Conn.(driver.ExecerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	return execer.ExecContext(ctx, query, args)
+}
+
+func (c *faultyConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	if err := c.inj.Maybe(ctx, PointDBQuery); err != nil {
+		return nil, err
+	}
+	queryer, ok := c.Conn.(driver.QueryerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	return queryer.QueryContext(ctx, query, args)
+}
+
+func (c *faultyConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if err := c.inj.Maybe(ctx, PointDBBegin); err != nil {
+		return nil, err
+	}
+	var tx driver.Tx
+	var err error
+	if b, ok := c.Conn.(driver.ConnBeginTx); ok {
+		tx, err = b.BeginTx(ctx, opts)
+	} else {
+		tx, err = c.Conn.Begin()
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &faultyTx{Tx: tx, inj: c.inj}, nil
+}
+
+// faultyTx fails Commit by rolling back instead, as a lost commit would.
+type faultyTx struct {
+	driver.Tx
+	inj *Injector
+}
+
+func (t *faultyTx) Commit() error {
+	if err := t.inj.Maybe(context.Background(), PointDBCommit); err != nil {
+		t.Tx.Rollback()
+		return err
+	}
+	return t.Tx.Commit()
+}
+
+// #endregion db-faults
@@ -0,0 +1,204 @@
+package chaos
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// #region types
+
+// ErrInjected is wrapped by every injected failure, so callers and tests can tell
+// chaos from real errors with errors.Is.
+var ErrInjected = errors.New("chaos: injected fault")
+
+// Fault points. Codec points are named after the RPC; DB points cover the
+// operations database/sql issues against a connection.
+const (
+	PointGenerate        = "generate"
+	PointEmbed           = "embed"
+	PointSearch          = "search"
+	PointStoreEvidence   = "store_evidence"
+	PointWebSearch       = "web_search"
+	PointDeleteEvidence  = "delete_evidence"
+	PointGetByIDs        = "get_by_ids"
+	PointListAllEvidence = "list_all_evidence"
+	PointDBExec          = "db_exec"
+	PointDBQuery         = "db_query"
+	PointDBBegin         = "db_begin"
+	PointDBCommit        = "db_commit"
+)
+
+// Fault is the misbehaviour configured for one point.
+type Fault struct {
+	ErrorRate   float64       // probability a call fails with ErrInjected
+	LatencyRate float64       // probability a call is delayed by Latency first
+	Latency     time.Duration // delay; honours context cancellation
+}
+
+// Config maps fault points to faults. Seed makes runs reproducible (0 = time-based).
+type Config struct {
+	Seed   int64
+	Faults map[string]Fault
+}
+
+// #endregion types
+
+// #region parse
+
+// ParseConfig parses "point=err[/lat:delay],..." — e.g.
+// "generate=0.1/0.3:2s,search=0.5,db_commit=0.05". Unknown points are rejected.
+func ParseConfig(spec string, seed int64) (Config, error) {
+	cfg := Config{Seed: seed, Faults: make(map[string]Fault)}
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		point, rest, ok := strings.Cut(entry, "=")
+		point = strings.TrimSpace(point)
+		if !ok || !knownPoint(point) {
+			return Config{}, fmt.Errorf("chaos: bad entry %q (want point=err[/lat:delay], point one of %s)", entry, strings.Join(points(), ", "))
+		}
+		errPart, latPart, hasLat := strings.Cut(rest, "/")
+		var f Fault
+		var err error
+		if f.ErrorRate, err = parseRate(errPart); err != nil {
+			return Config{}, fmt.Errorf("chaos: %s error rate: %w", point, err)
+		}
+		if hasLat {
+			ratePart, delayPart, ok := strings.Cut(latPart, ":")
+			if !ok {
+				return Config{}, fmt.Errorf("chaos: %s latency %q: want rate:delay", point, latPart)
+			}
+			if f.LatencyRate, err = parseRate(ratePart); err != nil {
+				return Config{}, fmt.Errorf("chaos: %s latency rate: %w", point, err)
+			}
+			if f.Latency, err = time.ParseDuration(strings.TrimSpace(delayPart)); err != nil {
+				return Config{}, fmt.Errorf("chaos: %s latency: %w", point, err)
+			}
+		}
+		cfg.Faults[point] = f
+	}
+	return cfg, nil
+}
+
+func parseRate(s string) (float64, error) {
+	r, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if err != nil {
+		return 0, err
+	}
+	if r < 0 || r > 1 {
+		return 0, fmt.Errorf("%v outside [0, 1]", r)
+	}
+	return r, nil
+}
+
+func points() []string {
+	return []string{PointGenerate, PointEmbed, PointSearch, PointStoreEvidence, PointWebSearch, PointDeleteEvidence,
+		PointGetByIDs, PointListAllEvidence, PointDBExec, PointDBQuery, PointDBBegin, PointDBCommit}
+}
+
+func knownPoint(p string) bool {
+	for _, k := range points() {
+		if k == p {
+			return true
+		}
+	}
+	return false
+}
+
+// #endregion parse
+
+// #region injector
+
+// Injector decides, per call, whether a fault point misbehaves. Safe for
+// concurrent use. Disabled injectors pass every call through.
+type Injector struct {
+	mu       sync.Mutex
+	rng      *rand.Rand
+	faults   map[string]Fault
+	disabled bool
+	injected map[string]int
+}
+
+// NewInjector returns an enabled injector for cfg.
+func NewInjector(cfg Config) *Injector {
+	seed := cfg.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	return &Injector{rng: rand.New(rand.NewSource(seed)), faults: cfg.Faults, injected: make(map[string]int)}
+}
+
+// SetEnabled turns injection on or off (e.g. off while checking invariants).
+func (inj *Injector) SetEnabled(on bool) {
+	inj.mu.Lock()
+	defer inj.mu.Unlock()
+	inj.disabled = !on
+}
+
+// Maybe applies the configured fault for point: an optional delay, then an
+// optional ErrInjected. A cancelled context during the delay returns ctx.Err().
+func (inj *Injector) Maybe(ctx context.Context, point string) error {
+	inj.mu.Lock()
+	f, ok := inj.faults[point]
+	if !ok || inj.disabled {
+		inj.mu.Unlock()
+		return nil
+	}
+	delay := f.LatencyRate > 0 && inj.rng.Float64() < f.LatencyRate
+	fail := f.ErrorRate > 0 && inj.rng.Float64() < f.ErrorRate
+	if fail {
+		inj.injected[point]++
+	}
+	inj.mu.Unlock()
+
+	if delay && f.Latency > 0 {
+		t := time.NewTimer(f.Latency)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return ctx.Err()
+		case <-t.C:
+		}
+	}
+	if fail {
+		return fmt.Errorf("%s: %w", point, ErrInjected)
+	}
+	return nil
+}
+
+// Injected returns how many errors were injected per point.
+func (inj *Injector) Injected() map[string]int {
+	inj.mu.Lock()
+	defer inj.mu.Unlock()
+	out := make(map[string]int, len(inj.injected))
+	for k, v := range inj.injected {
+		out[k] = v
+	}
+	return out
+}
+
+// Summary renders Injected() as "point=n ..." in point order.
+func (inj *Injector) Summary() string {
+	counts := inj.Injected()
+	keys := make([]string, 0, len(counts))
+	for k := range counts {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	parts := make([]string, len(keys))
+	for i, k := range keys {
+		parts[i] = fmt.Sprintf("%s=%d", k, counts[k])
+	}
+	return strings.Join(parts, " ")
+}
+
+// #endregion injector
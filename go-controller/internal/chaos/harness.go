@@ -0,0 +1,158 @@
+package chaos
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/state"
+)
+
+// #region harness-types
+
+// Turn outcomes.
+const (
+	OutcomeCommit = "commit" // the active state moved
+	OutcomeKept   = "kept"   // the active state stayed where it was (reject, rollback, held, or a failed step)
+	OutcomePanic  = "panic"
+)
+
+// TurnFunc takes one prompt through the controller's turn pipeline, the same
+// function the daemon calls for each inbox message.
+type TurnFunc func(prompt string)
+
+// Harness replays a scripted session through a TurnFunc and checks state
+// invariants after every turn. Store and Injector must be the ones the turn
+// writes through, so faults land on its codec and DB calls.
+type Harness struct {
+	Store    *state.Store
+	Turn     TurnFunc
+	Injector *Injector
+	MaxNorm  float32 // state norm cap to check; 0 leaves it unchecked
+}
+
+// NewHarness returns a harness driving turn over store with inj's faults.
+func NewHarness(store *state.Store, turn TurnFunc, inj *Injector, maxNorm float32) *Harness {
+	return &Harness{Store: store, Turn: turn, Injector: inj, MaxNorm: maxNorm}
+}
+
+// TurnOutcome records what one scripted turn did.
+type TurnOutcome struct {
+	Prompt  string
+	Outcome string
+	Err     error
+}
+
+// Report summarizes a session.
+type Report struct {
+	Turns      []TurnOutcome
+	Violations []string // invariant violations, prefixed with the turn index
+	Injected   map[string]int
+}
+
+// Count returns how many turns ended with outcome.
+func (r Report) Count(outcome string) int {
+	n := 0
+	for _, t := range r.Turns {
+		if t.Outcome == outcome {
+			n++
+		}
+	}
+	return n
+}
+
+// #endregion harness-types
+
+// #region run
+
+// Run plays prompts in order. Each turn starts from whatever state the previous
+// one left; invariants are checked with injection paused. A turn that moves the
+// active state must move it to a child of the version it started from.
+func (h *Harness) Run(prompts []string) Report {
+	var report Report
+	for i, prompt := range prompts {
+		h.Injector.SetEnabled(false)
+		before, _ := h.Store.GetCurrent()
+		h.Injector.SetEnabled(true)
+
+		out := h.turn(prompt)
+
+		h.Injector.SetEnabled(false)
+		for _, v := range CheckInvariants(h.Store, h.MaxNorm) {
+			report.Violations = append(report.Violations, fmt.Sprintf("turn %d: %s", i, v))
+		}
+		if after, err := h.Store.GetCurrent(); err == nil && after.VersionID != before.VersionID {
+			if out.Outcome != OutcomePanic {
+				out.Outcome = OutcomeCommit
+			}
+			if after.ParentID != before.VersionID {
+				report.Violations = append(report.Violations,
+					fmt.Sprintf("turn %d: active state moved to %s, not a child of %s", i, after.VersionID, before.VersionID))
+			}
+		}
+		report.Turns = append(report.Turns, out)
+		h.Injector.SetEnabled(true)
+	}
+	report.Injected = h.Injector.Injected()
+	return report
+}
+
+// turn runs one prompt, recording a panic as the outcome instead of crashing the session.
+func (h *Harness) turn(prompt string) (out TurnOutcome) {
+	out = TurnOutcome{Prompt: prompt, Outcome: OutcomeKept}
+	defer func() {
+		if r := recover(); r != nil {
+			out.Outcome, out.Err = OutcomePanic, fmt.Errorf("panic: %v", r)
+		}
+	}()
+	h.Turn(prompt)
+	return out
+}
+
+// #endregion run
+
+// #region invariants
+
+// CheckInvariants verifies the store is uncorrupted: the active version loads
+// with a finite state vector within maxNorm (0 = unchecked), every version's
+// parent exists, and the active version is the root or was committed with a
+// "commit" provenance row (no state change without its audit record).
+func CheckInvariants(store *state.Store, maxNorm float32) []string {
+	var problems []string
+	current, err := store.GetCurrent()
+	if err != nil {
+		return []string{fmt.Sprintf("active state unreadable: %v", err)}
+	}
+
+	var sumSq float64
+	for i, v := range current.StateVector {
+		if math.IsNaN(float64(v)) || math.IsInf(float64(v), 0) {
+			problems = append(problems, fmt.Sprintf("state[%d] is not finite", i))
+		}
+		sumSq += float64(v) * float64(v)
+	}
+	if norm := math.Sqrt(sumSq); maxNorm > 0 && norm > float64(maxNorm)+1e-3 {
+		problems = append(problems, fmt.Sprintf("state norm %.4f exceeds cap %.4f", norm, maxNorm))
+	}
+
+	db := store.DB()
+	var orphans int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM state_versions v
+		WHERE v.parent_id IS NOT NULL AND NOT EXISTS (SELECT 1 FROM state_versions p WHERE p.version_id = v.parent_id)`).Scan(&orphans); err != nil {
+		problems = append(problems, fmt.Sprintf("version chain query: %v", err))
+	} else if orphans > 0 {
+		problems = append(problems, fmt.Sprintf("%d versions with missing parent", orphans))
+	}
+
+	if current.ParentID != "" {
+		var commits int
+		if err := db.QueryRow(`SELECT COUNT(*) FROM provenance_log WHERE version_id = ? AND decision = 'commit'`,
+			current.VersionID).Scan(&commits); err != nil {
+			problems = append(problems, fmt.Sprintf("provenance query: %v", err))
+		} else if commits == 0 {
+			problems = append(problems, fmt.Sprintf("active version %s has no commit provenance", current.VersionID))
+		}
+	}
+	return problems
+}
+
+// #endregion invariants
@@ -129,4 +129,11 @@ func ClearInbox() {
 	os.Remove(filepath.Join(InboxDir, "from_commander.enc"))
 }
 
+// InboxWaiting reports whether from_commander.enc holds a message, without
+// decrypting or consuming it.
+func InboxWaiting() bool {
+	data, err := os.ReadFile(filepath.Join(InboxDir, "from_commander.enc"))
+	return err == nil && strings.TrimSpace(string(data)) != ""
+}
+
 // #endregion inbox
@@ -0,0 +1,123 @@
+package clusters
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/state"
+)
+
+// twoTopics is evidence about two topics, with embeddings along two axes.
+func twoTopics() []Item {
+	return []Item{
+		{ID: "ev_a1", Text: "Deployment failed: docker image missing", Vec: []float32{1, 0.1, 0}},
+		{ID: "ev_a2", Text: "Docker deployment rolled back after logs showed errors", Vec: []float32{0.9, 0.2, 0}},
+		{ID: "ev_a3", Text: "Check docker logs before every deployment", Vec: []float32{2, 0, 0.1}},
+		{ID: "ev_b1", Text: "The cat prefers salmon for dinner", Vec: []float32{0, 1, 0.1}},
+		{ID: "ev_b2", Text: "Feed the cat salmon, not chicken", Vec: []float32{0.1, 3, 0}},
+		{ID: "ev_c1", Text: "Embedded with another model", Vec: []float32{1, 0}},
+	}
+}
+
+func TestKMeans(t *testing.T) {
+	vecs := [][]float32{{1, 0}, {0, 1}, {0.9, 0.1}, {0.1, 0.9}, {5, 0.2}}
+	assign := KMeans(vecs, 2, 0, 7)
+	if assign[0] != assign[2] || assign[0] != assign[4] || assign[1] != assign[3] || assign[0] == assign[1] {
+		t.Errorf("assign %v, want {0,2,4} and {1,3}", assign)
+	}
+	if again := KMeans(vecs, 2, 0, 7); !reflect.DeepEqual(again, assign) {
+		t.Errorf("same seed gave %v then %v", assign, again)
+	}
+	if one := KMeans(vecs, 1, 0, 7); !reflect.DeepEqual(one, []int{0, 0, 0, 0, 0}) {
+		t.Errorf("k=1 gave %v", one)
+	}
+	// More clusters than distinct vectors: seeding stops early without panicking
+	same := KMeans([][]float32{{1, 0}, {1, 0}, {2, 0}}, 3, 0, 1)
+	if same[0] != same[1] || same[1] != same[2] {
+		t.Errorf("duplicates split: %v", same)
+	}
+}
+
+func TestBuild(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.K = 2
+	got := Build(twoTopics(), cfg)
+	if len(got) != 2 {
+		t.Fatalf("%d clusters, want 2", len(got))
+	}
+	if want := []string{"ev_a1", "ev_a2", "ev_a3"}; !reflect.DeepEqual(got[0].Members, want) {
+		t.Errorf("largest cluster %v, want %v (other-dimension item left out)", got[0].Members, want)
+	}
+	if got[0].Label != "deployment, docker, logs" {
+		t.Errorf("label %q", got[0].Label)
+	}
+	if got[1].Label != "cat, salmon" {
+		t.Errorf("label %q", got[1].Label)
+	}
+}
+
+func TestConfigK(t *testing.T) {
+	cfg := DefaultConfig()
+	for n, want := range map[int]int{1: 1, 2: 1, 8: 2, 200: 10, 100000: 50} {
+		if got := cfg.k(n); got != want {
+			t.Errorf("k(%d) = %d, want %d", n, got, want)
+		}
+	}
+}
+
+func TestStore(t *testing.T) {
+	store, err := state.NewStore(filepath.Join(t.TempDir(), "clusters.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	s, err := NewStore(store.DB())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if due, err := s.Due(time.Now(), time.Hour); err != nil || !due {
+		t.Fatalf("due before any clustering = %v, %v", due, err)
+	}
+
+	clusters := []Cluster{
+		{Label: "deployment, docker", Members: []string{"ev_a1", "ev_a2", "ev_a3"}},
+		{Label: "cat", Members: []string{"ev_b1"}},
+	}
+	if err := s.Replace(clusters); err != nil {
+		t.Fatal(err)
+	}
+	if clusters[0].ID == 0 || clusters[0].ID == clusters[1].ID {
+		t.Fatalf("IDs not assigned: %+v", clusters)
+	}
+	topics, err := s.Topics([]string{"ev_a2", "ev_b1", "ev_x"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]Topic{
+		"ev_a2": {ClusterID: clusters[0].ID, Label: "deployment, docker", Size: 3},
+		"ev_b1": {ClusterID: clusters[1].ID, Label: "cat", Size: 1},
+	}
+	if !reflect.DeepEqual(topics, want) {
+		t.Errorf("topics %+v, want %+v", topics, want)
+	}
+	if due, _ := s.Due(time.Now(), time.Hour); due {
+		t.Error("due right after clustering")
+	}
+
+	// A rebuild replaces everything
+	if err := s.Replace([]Cluster{{Label: "cat", Members: []string{"ev_b1", "ev_b2"}}}); err != nil {
+		t.Fatal(err)
+	}
+	list, err := s.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 1 || list[0].Size != 2 {
+		t.Errorf("after rebuild %+v", list)
+	}
+	if topics, _ := s.Topics([]string{"ev_a1"}); len(topics) != 0 {
+		t.Errorf("stale membership survived: %+v", topics)
+	}
+}
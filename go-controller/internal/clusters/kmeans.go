@@ -0,0 +1,136 @@
+package clusters
+
+import (
+	"math"
+	"math/rand/v2"
+
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/vecmath"
+)
+
+// #region config
+
+// Config sets how evidence is clustered.
+type Config struct {
+	K          int    // clusters; 0 picks sqrt(n/2), capped at MaxK
+	MaxK       int    // upper bound on the automatic K (default 50)
+	Iterations int    // k-means iterations at most (default 50)
+	Seed       uint64 // k-means++ seed, so reruns over the same evidence agree
+	LabelTerms int    // keywords per label (default 3)
+}
+
+// DefaultConfig returns the clustering defaults.
+func DefaultConfig() Config {
+	return Config{MaxK: 50, Iterations: 50, Seed: 1, LabelTerms: 3}
+}
+
+// k is the number of clusters for n items.
+func (c Config) k(n int) int {
+	k := c.K
+	if k <= 0 {
+		k = int(math.Round(math.Sqrt(float64(n) / 2)))
+		maxK := c.MaxK
+		if maxK <= 0 {
+			maxK = 50
+		}
+		k = min(k, maxK)
+	}
+	return max(1, min(k, n))
+}
+
+// #endregion config
+
+// #region kmeans
+
+// KMeans groups vecs into k clusters by cosine similarity (spherical
+// k-means with k-means++ seeding) and returns each vector's cluster index.
+// Vectors must share one dimension and are compared after normalization, so
+// their scale does not matter; zero vectors all land in cluster 0.
+func KMeans(vecs [][]float32, k, iterations int, seed uint64) []int {
+	n := len(vecs)
+	assign := make([]int, n)
+	if n == 0 || k <= 1 {
+		return assign
+	}
+	k = min(k, n)
+	if iterations <= 0 {
+		iterations = 50
+	}
+	unit := make([][]float64, n)
+	for i, v := range vecs {
+		unit[i] = vecmath.Normalize(v)
+	}
+	centroids := seedCentroids(unit, k, rand.New(rand.NewPCG(seed, seed^0x9e3779b97f4a7c15)))
+	k = len(centroids)
+
+	for iter := 0; iter < iterations; iter++ {
+		changed := false
+		for i, u := range unit {
+			best, bestSim := 0, math.Inf(-1)
+			for c, centroid := range centroids {
+				if s := vecmath.Dot(u, centroid); s > bestSim {
+					best, bestSim = c, s
+				}
+			}
+			if assign[i] != best {
+				assign[i], changed = best, true
+			}
+		}
+		if iter > 0 && !changed {
+			break
+		}
+		dim := len(unit[0])
+		sums := make([][]float64, k)
+		for c := range sums {
+			sums[c] = make([]float64, dim)
+		}
+		for i, u := range unit {
+			for d, x := range u {
+				sums[assign[i]][d] += x
+			}
+		}
+		for c, s := range sums {
+			// An emptied cluster keeps its centroid and may win members back
+			if norm := math.Sqrt(vecmath.Dot(s, s)); norm > 0 {
+				for d := range s {
+					s[d] /= norm
+				}
+				centroids[c] = s
+			}
+		}
+	}
+	return assign
+}
+
+// seedCentroids picks k starting centroids by k-means++: each next one is
+// drawn with probability proportional to its cosine distance from the
+// nearest centroid picked so far.
+func seedCentroids(unit [][]float64, k int, rng *rand.Rand) [][]float64 {
+	centroids := [][]float64{unit[rng.IntN(len(unit))]}
+	dist := make([]float64, len(unit))
+	for len(centroids) < k {
+		var total float64
+		for i, u := range unit {
+			d := math.Inf(1)
+			for _, c := range centroids {
+				d = math.Min(d, 1-vecmath.Dot(u, c))
+			}
+			dist[i] = math.Max(d, 0)
+			total += dist[i]
+		}
+		if total == 0 {
+			break // every vector duplicates a centroid
+		}
+		r := rng.Float64() * total
+		pick := len(unit) - 1
+		for i, d := range dist {
+			if r -= d; r <= 0 && d > 0 {
+				pick = i
+				break
+			}
+		}
+		centroids = append(centroids, unit[pick])
+	}
+	return centroids
+}
+
+// #endregion kmeans
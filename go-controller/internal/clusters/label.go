@@ -0,0 +1,177 @@
+package clusters
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// #region build
+
+// Item is one piece of evidence to cluster.
+type Item struct {
+	ID   string
+	Text string
+	Vec  []float32
+}
+
+// Cluster is a group of evidence about one topic.
+type Cluster struct {
+	ID      int64    // assigned by Store.Replace
+	Label   string   // distinctive keywords, e.g. "deployment, docker, logs"
+	Members []string // evidence IDs
+}
+
+// Build clusters items by embedding and labels each cluster with its most
+// distinctive keywords. Items whose embedding is missing or has another
+// dimension than the most common one are left out. Clusters come back largest
+// first; empty ones are dropped.
+func Build(items []Item, cfg Config) []Cluster {
+	items = commonDimension(items)
+	if len(items) == 0 {
+		return nil
+	}
+	vecs := make([][]float32, len(items))
+	for i, it := range items {
+		vecs[i] = it.Vec
+	}
+	assign := KMeans(vecs, cfg.k(len(items)), cfg.Iterations, cfg.Seed)
+
+	groups := map[int][]int{}
+	for i, c := range assign {
+		groups[c] = append(groups[c], i)
+	}
+	docTerms := make([][]string, len(items))
+	df := map[string]int{}
+	for i, it := range items {
+		docTerms[i] = terms(it.Text)
+		for _, t := range docTerms[i] {
+			df[t]++
+		}
+	}
+	labelTerms := cfg.LabelTerms
+	if labelTerms <= 0 {
+		labelTerms = 3
+	}
+
+	out := make([]Cluster, 0, len(groups))
+	for _, members := range groups {
+		c := Cluster{}
+		var memberTerms [][]string
+		for _, i := range members {
+			c.Members = append(c.Members, items[i].ID)
+			memberTerms = append(memberTerms, docTerms[i])
+		}
+		sort.Strings(c.Members)
+		c.Label = label(memberTerms, df, len(items), labelTerms)
+		out = append(out, c)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if len(out[i].Members) != len(out[j].Members) {
+			return len(out[i].Members) > len(out[j].Members)
+		}
+		return out[i].Members[0] < out[j].Members[0]
+	})
+	return out
+}
+
+// commonDimension keeps the items whose embedding has the most common length.
+func commonDimension(items []Item) []Item {
+	count := map[int]int{}
+	for _, it := range items {
+		if len(it.Vec) > 0 {
+			count[len(it.Vec)]++
+		}
+	}
+	dim, best := 0, 0
+	for d, n := range count {
+		if n > best || (n == best && d > dim) {
+			dim, best = d, n
+		}
+	}
+	var out []Item
+	for _, it := range items {
+		if len(it.Vec) == dim && dim > 0 {
+			out = append(out, it)
+		}
+	}
+	return out
+}
+
+// #endregion build
+
+// #region label
+
+// labelStopwords are words too common in conversation to name a topic.
+var labelStopwords = map[string]bool{
+	"the": true, "and": true, "for": true, "are": true, "was": true, "were": true,
+	"you": true, "your": true, "they": true, "them": true, "this": true, "that": true,
+	"with": true, "from": true, "have": true, "has": true, "had": true, "not": true,
+	"but": true, "what": true, "which": true, "who": true, "how": true, "when": true,
+	"where": true, "why": true, "will": true, "would": true, "could": true, "should": true,
+	"can": true, "about": true, "into": true, "than": true, "then": true, "there": true,
+	"their": true, "these": true, "those": true, "its": true, "our": true, "out": true,
+	"also": true, "just": true, "like": true, "some": true, "any": true, "all": true,
+	"been": true, "being": true, "does": true, "did": true, "more": true, "most": true,
+	"very": true, "much": true, "only": true, "other": true, "over": true, "such": true,
+	"user": true, "said": true, "asked": true, "commander": true, "orac": true,
+}
+
+// terms returns the distinct lowercase words of text worth labelling with:
+// at least three letters and not a stopword.
+func terms(text string) []string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r)
+	})
+	seen := map[string]bool{}
+	var out []string
+	for _, w := range words {
+		if len([]rune(w)) < 3 || labelStopwords[w] || seen[w] {
+			continue
+		}
+		seen[w] = true
+		out = append(out, w)
+	}
+	return out
+}
+
+// label picks the n terms that best set a cluster apart: how many members
+// use the term, times its inverse document frequency over all items.
+// Terms used by a single member only count when the cluster has one member.
+func label(memberTerms [][]string, df map[string]int, total, n int) string {
+	inCluster := map[string]int{}
+	for _, ts := range memberTerms {
+		for _, t := range ts {
+			inCluster[t]++
+		}
+	}
+	minUse := 2
+	if len(memberTerms) == 1 {
+		minUse = 1
+	}
+	type scored struct {
+		term  string
+		score float64
+	}
+	var cands []scored
+	for t, c := range inCluster {
+		if c < minUse {
+			continue
+		}
+		cands = append(cands, scored{t, float64(c) * math.Log(1+float64(total)/float64(df[t]))})
+	}
+	sort.Slice(cands, func(i, j int) bool {
+		if cands[i].score != cands[j].score {
+			return cands[i].score > cands[j].score
+		}
+		return cands[i].term < cands[j].term
+	})
+	var picked []string
+	for _, c := range cands[:min(n, len(cands))] {
+		picked = append(picked, c.term)
+	}
+	return strings.Join(picked, ", ")
+}
+
+// #endregion label
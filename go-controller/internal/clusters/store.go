@@ -0,0 +1,158 @@
+package clusters
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/timestamp"
+)
+
+// #region store
+
+// Topic is the cluster an evidence item belongs to, as retrieval sees it.
+type Topic struct {
+	ClusterID int64
+	Label     string
+	Size      int
+}
+
+// Store keeps the latest clustering in evidence_clusters and
+// evidence_cluster_members.
+type Store struct {
+	db *sql.DB
+}
+
+// NewStore creates the cluster tables if needed and returns a store.
+func NewStore(db *sql.DB) (*Store, error) {
+	_, err := db.Exec(`CREATE TABLE IF NOT EXISTS evidence_clusters (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		label TEXT NOT NULL,
+		size INTEGER NOT NULL,
+		created_at TEXT NOT NULL
+	)`)
+	if err != nil {
+		return nil, fmt.Errorf("create evidence_clusters table: %w", err)
+	}
+	_, err = db.Exec(`CREATE TABLE IF NOT EXISTS evidence_cluster_members (
+		evidence_id TEXT PRIMARY KEY,
+		cluster_id INTEGER NOT NULL
+	)`)
+	if err != nil {
+		return nil, fmt.Errorf("create evidence_cluster_members table: %w", err)
+	}
+	if _, err := db.Exec(`CREATE INDEX IF NOT EXISTS idx_cluster_members_cluster ON evidence_cluster_members(cluster_id)`); err != nil {
+		return nil, fmt.Errorf("create cluster members index: %w", err)
+	}
+	if _, err := timestamp.Canonicalize(db, "evidence_clusters", "created_at"); err != nil {
+		return nil, err
+	}
+	return &Store{db: db}, nil
+}
+
+// Replace swaps the stored clustering for clusters in one transaction and
+// sets their IDs. An item in several clusters stays in the first.
+func (s *Store) Replace(clusters []Cluster) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("begin cluster replace: %w", err)
+	}
+	defer tx.Rollback()
+	if _, err := tx.Exec(`DELETE FROM evidence_cluster_members`); err != nil {
+		return fmt.Errorf("clear cluster members: %w", err)
+	}
+	if _, err := tx.Exec(`DELETE FROM evidence_clusters`); err != nil {
+		return fmt.Errorf("clear clusters: %w", err)
+	}
+	now := timestamp.Now()
+	for i := range clusters {
+		c := &clusters[i]
+		res, err := tx.Exec(`INSERT INTO evidence_clusters (label, size, created_at) VALUES (?, ?, ?)`, c.Label, len(c.Members), now)
+		if err != nil {
+			return fmt.Errorf("insert cluster: %w", err)
+		}
+		if c.ID, err = res.LastInsertId(); err != nil {
+			return err
+		}
+		for _, id := range c.Members {
+			if _, err := tx.Exec(`INSERT OR IGNORE INTO evidence_cluster_members (evidence_id, cluster_id) VALUES (?, ?)`, id, c.ID); err != nil {
+				return fmt.Errorf("insert cluster member: %w", err)
+			}
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit cluster replace: %w", err)
+	}
+	return nil
+}
+
+// Topics returns the topic of each of ids that belongs to a cluster.
+func (s *Store) Topics(ids []string) (map[string]Topic, error) {
+	out := map[string]Topic{}
+	if len(ids) == 0 {
+		return out, nil
+	}
+	args := make([]any, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+	rows, err := s.db.Query(
+		`SELECT m.evidence_id, c.id, c.label, c.size FROM evidence_cluster_members m
+		 JOIN evidence_clusters c ON c.id = m.cluster_id
+		 WHERE m.evidence_id IN (?`+strings.Repeat(", ?", len(ids)-1)+`)`, args...,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("query topics: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id string
+		var t Topic
+		if err := rows.Scan(&id, &t.ClusterID, &t.Label, &t.Size); err != nil {
+			return nil, err
+		}
+		out[id] = t
+	}
+	return out, rows.Err()
+}
+
+// List returns the stored clusters largest first, without members.
+func (s *Store) List() ([]Topic, error) {
+	rows, err := s.db.Query(`SELECT id, label, size FROM evidence_clusters ORDER BY size DESC, id`)
+	if err != nil {
+		return nil, fmt.Errorf("list clusters: %w", err)
+	}
+	defer rows.Close()
+	var out []Topic
+	for rows.Next() {
+		var t Topic
+		if err := rows.Scan(&t.ClusterID, &t.Label, &t.Size); err != nil {
+			return nil, err
+		}
+		out = append(out, t)
+	}
+	return out, rows.Err()
+}
+
+// Due reports whether a clustering is due at now: none stored yet, or the
+// stored one is at least interval old. A non-positive interval never
+// schedules one.
+func (s *Store) Due(now time.Time, interval time.Duration) (bool, error) {
+	if interval <= 0 {
+		return false, nil
+	}
+	var createdAt string
+	err := s.db.QueryRow(`SELECT created_at FROM evidence_clusters ORDER BY created_at DESC LIMIT 1`).Scan(&createdAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return true, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("last clustering: %w", err)
+	}
+	last, _ := timestamp.Parse(createdAt)
+	return now.Sub(last) >= interval, nil
+}
+
+// #endregion store
@@ -0,0 +1,323 @@
+package codec
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	pb "github.com/danielpatrickdp/adaptive-state/go-controller/gen/adaptive"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// #region backend
+
+// Backend is the inference and evidence surface the turn loop is built on.
+// *CodecClient implements it over the Python gRPC service; an in-process
+// implementation (see internal/ollama) lets the controller run without one.
+type Backend interface {
+	Generate(ctx context.Context, prompt string, stateVec [128]float32, evidence []string, ollamaCtx []int64) (GenerateResult, error)
+	Embed(ctx context.Context, text string) ([]float32, error)
+	Search(ctx context.Context, queryText string, topK int, similarityThreshold float32) ([]SearchResult, error)
+	StoreEvidence(ctx context.Context, text string, metadataJSON string) (string, error)
+}
+
+// BatchEmbedder is implemented by backends that embed several texts in one
+// call. Without it, EmbedBatch falls back to concurrent Embed calls.
+type BatchEmbedder interface {
+	EmbedBatch(ctx context.Context, texts []string) ([][]float32, error)
+}
+
+// EvidenceManager is implemented by backends that can enumerate, fetch,
+// annotate and delete stored evidence (memory review, graph walks, /pin,
+// doctor). UpdateEvidenceMetadata merges patch into an item's metadata, a nil
+// value removing its key, and reports found=false for an unknown ID.
+type EvidenceManager interface {
+	ListAllEvidence(ctx context.Context) ([]SearchResult, error)
+	GetByIDs(ctx context.Context, ids []string) ([]SearchResult, error)
+	DeleteEvidence(ctx context.Context, ids []string) (int, error)
+	UpdateEvidenceMetadata(ctx context.Context, id string, patch map[string]any) (string, bool, error)
+}
+
+// SampledGenerator is implemented by backends that honour per-call sampling
+// parameters. Without it, Generate runs with the backend's defaults.
+type SampledGenerator interface {
+	GenerateSampled(ctx context.Context, prompt string, stateVec [128]float32, evidence []string, ollamaCtx []int64, s Sampling) (GenerateResult, error)
+}
+
+// StreamGenerator is implemented by backends that produce text incrementally:
+// onDelta receives each piece of visible text as it is generated. Without it,
+// GenerateStream sends the whole response as one delta.
+type StreamGenerator interface {
+	GenerateStream(ctx context.Context, prompt string, stateVec [128]float32, evidence []string, ollamaCtx []int64, s Sampling, onDelta func(string)) (GenerateResult, error)
+}
+
+// WebSearcher is implemented by backends that can search the web.
+type WebSearcher interface {
+	WebSearch(ctx context.Context, query string, maxResults int) ([]WebSearchResult, error)
+}
+
+var (
+	_ Backend          = (*CodecClient)(nil)
+	_ BatchEmbedder    = (*CodecClient)(nil)
+	_ EvidenceManager  = (*CodecClient)(nil)
+	_ WebSearcher      = (*CodecClient)(nil)
+	_ SampledGenerator = (*CodecClient)(nil)
+	_ StreamGenerator  = (*CodecClient)(nil)
+)
+
+// NewCodecClientWithBackend returns a CodecClient whose RPCs are served in
+// process by b, so everything layered on the client (ID validation, batching,
+// WrapService wrappers) applies unchanged. RPCs for an optional interface that
+// b does not implement fail with codes.Unimplemented. Close closes b if it is
+// an io.Closer.
+func NewCodecClientWithBackend(b Backend) *CodecClient {
+	c := NewCodecClientWithService(backendService{b: b})
+	c.backend = b
+	return c
+}
+
+// backendService adapts a Backend to the generated service client interface.
+type backendService struct {
+	b Backend
+}
+
+func unimplemented(rpc string) error {
+	return status.Errorf(codes.Unimplemented, "%s is not supported by this backend", rpc)
+}
+
+func (s backendService) Generate(ctx context.Context, in *pb.GenerateRequest, _ ...grpc.CallOption) (*pb.GenerateResponse, error) {
+	vec, sampling := unpackGenerate(in)
+	var res GenerateResult
+	var err error
+	if sg, ok := s.b.(SampledGenerator); ok && in.Sampling != nil {
+		res, err = sg.GenerateSampled(ctx, in.Prompt, vec, in.Evidence, in.Context, sampling)
+	} else {
+		res, err = s.b.Generate(ctx, in.Prompt, vec, in.Evidence, in.Context)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return toPBGenerate(res), nil
+}
+
+// GenerateStream runs the backend in a goroutine and hands its deltas to the
+// returned stream as they arrive. Backends without StreamGenerator produce a
+// single delta holding the whole response.
+func (s backendService) GenerateStream(ctx context.Context, in *pb.GenerateRequest, _ ...grpc.CallOption) (grpc.ServerStreamingClient[pb.GenerateChunk], error) {
+	ctx, cancel := context.WithCancel(ctx)
+	st := &localStream{ctx: ctx, chunks: make(chan *pb.GenerateChunk, 16)}
+	go func() {
+		defer cancel()
+		defer close(st.chunks)
+		send := func(c *pb.GenerateChunk) bool {
+			select {
+			case st.chunks <- c:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+		var res GenerateResult
+		var err error
+		if sg, ok := s.b.(StreamGenerator); ok {
+			vec, sampling := unpackGenerate(in)
+			res, err = sg.GenerateStream(ctx, in.Prompt, vec, in.Evidence, in.Context, sampling, func(d string) {
+				send(&pb.GenerateChunk{Delta: d})
+			})
+		} else {
+			var resp *pb.GenerateResponse
+			if resp, err = s.Generate(ctx, in); err == nil {
+				res = toGenerateResult(resp)
+				if res.Text != "" && !send(&pb.GenerateChunk{Delta: res.Text}) {
+					err = ctx.Err()
+				}
+			}
+		}
+		if err != nil {
+			st.err = err
+			return
+		}
+		if !send(&pb.GenerateChunk{Final: toPBGenerate(res)}) {
+			st.err = status.FromContextError(ctx.Err()).Err()
+		}
+	}()
+	return st, nil
+}
+
+func unpackGenerate(in *pb.GenerateRequest) ([128]float32, Sampling) {
+	var vec [128]float32
+	copy(vec[:], in.StateVector)
+	var s Sampling
+	if in.Sampling != nil {
+		s = Sampling{Temperature: in.Sampling.Temperature, TopP: in.Sampling.TopP, MaxTokens: int(in.Sampling.MaxTokens)}
+	}
+	return vec, s
+}
+
+func toPBGenerate(res GenerateResult) *pb.GenerateResponse {
+	return &pb.GenerateResponse{
+		Text:         res.Text,
+		Entropy:      res.Entropy,
+		Logits:       res.Logits,
+		Context:      res.Context,
+		ModelName:    res.Model,
+		ModelVersion: res.ModelVersion,
+	}
+}
+
+// localStream is the client side of an in-process GenerateStream. err is
+// written before chunks is closed, so Recv reads it after the channel drains.
+type localStream struct {
+	grpc.ClientStream // unused: Recv and Context are all callers need
+	ctx               context.Context
+	chunks            chan *pb.GenerateChunk
+	err               error
+}
+
+func (l *localStream) Recv() (*pb.GenerateChunk, error) {
+	if c, ok := <-l.chunks; ok {
+		return c, nil
+	}
+	if l.err != nil {
+		return nil, l.err
+	}
+	return nil, io.EOF
+}
+
+func (l *localStream) Context() context.Context { return l.ctx }
+
+func (s backendService) Embed(ctx context.Context, in *pb.EmbedRequest, _ ...grpc.CallOption) (*pb.EmbedResponse, error) {
+	vec, err := s.b.Embed(ctx, in.Text)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.EmbedResponse{Embedding: vec}, nil
+}
+
+func (s backendService) EmbedBatch(ctx context.Context, in *pb.EmbedBatchRequest, _ ...grpc.CallOption) (*pb.EmbedBatchResponse, error) {
+	be, ok := s.b.(BatchEmbedder)
+	if !ok {
+		return nil, unimplemented("EmbedBatch")
+	}
+	vecs, err := be.EmbedBatch(ctx, in.Texts)
+	if err != nil {
+		return nil, err
+	}
+	out := &pb.EmbedBatchResponse{Embeddings: make([]*pb.Embedding, len(vecs))}
+	for i, v := range vecs {
+		out.Embeddings[i] = &pb.Embedding{Values: v}
+	}
+	return out, nil
+}
+
+func (s backendService) Search(ctx context.Context, in *pb.SearchRequest, _ ...grpc.CallOption) (*pb.SearchResponse, error) {
+	rs, err := s.b.Search(ctx, in.QueryText, int(in.TopK), in.SimilarityThreshold)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.SearchResponse{Results: toPBResults(rs)}, nil
+}
+
+func (s backendService) StoreEvidence(ctx context.Context, in *pb.StoreEvidenceRequest, _ ...grpc.CallOption) (*pb.StoreEvidenceResponse, error) {
+	id, err := s.b.StoreEvidence(ctx, in.Text, in.MetadataJson)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.StoreEvidenceResponse{Id: id}, nil
+}
+
+func (s backendService) WebSearch(ctx context.Context, in *pb.WebSearchRequest, _ ...grpc.CallOption) (*pb.WebSearchResponse, error) {
+	ws, ok := s.b.(WebSearcher)
+	if !ok {
+		return nil, unimplemented("WebSearch")
+	}
+	rs, err := ws.WebSearch(ctx, in.Query, int(in.MaxResults))
+	if err != nil {
+		return nil, err
+	}
+	out := &pb.WebSearchResponse{Results: make([]*pb.WebSearchResult, len(rs))}
+	for i, r := range rs {
+		out.Results[i] = &pb.WebSearchResult{Title: r.Title, Snippet: r.Snippet, Url: r.URL}
+	}
+	return out, nil
+}
+
+func (s backendService) DeleteEvidence(ctx context.Context, in *pb.DeleteEvidenceRequest, _ ...grpc.CallOption) (*pb.DeleteEvidenceResponse, error) {
+	em, ok := s.b.(EvidenceManager)
+	if !ok {
+		return nil, unimplemented("DeleteEvidence")
+	}
+	n, err := em.DeleteEvidence(ctx, in.Ids)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.DeleteEvidenceResponse{DeletedCount: int32(n)}, nil
+}
+
+func (s backendService) UpdateEvidenceMetadata(ctx context.Context, in *pb.UpdateEvidenceMetadataRequest, _ ...grpc.CallOption) (*pb.UpdateEvidenceMetadataResponse, error) {
+	em, ok := s.b.(EvidenceManager)
+	if !ok {
+		return nil, unimplemented("UpdateEvidenceMetadata")
+	}
+	var patch map[string]any
+	if err := json.Unmarshal([]byte(in.MetadataJson), &patch); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "metadata patch: %v", err)
+	}
+	meta, found, err := em.UpdateEvidenceMetadata(ctx, in.Id, patch)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.UpdateEvidenceMetadataResponse{Found: found, MetadataJson: meta}, nil
+}
+
+func (s backendService) GetByIDs(ctx context.Context, in *pb.GetByIDsRequest, _ ...grpc.CallOption) (*pb.GetByIDsResponse, error) {
+	em, ok := s.b.(EvidenceManager)
+	if !ok {
+		return nil, unimplemented("GetByIDs")
+	}
+	rs, err := em.GetByIDs(ctx, in.Ids)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.GetByIDsResponse{Results: toPBResults(rs)}, nil
+}
+
+func (s backendService) ListAllEvidence(ctx context.Context, _ *pb.ListAllEvidenceRequest, _ ...grpc.CallOption) (*pb.ListAllEvidenceResponse, error) {
+	em, ok := s.b.(EvidenceManager)
+	if !ok {
+		return nil, unimplemented("ListAllEvidence")
+	}
+	rs, err := em.ListAllEvidence(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.ListAllEvidenceResponse{Results: toPBResults(rs)}, nil
+}
+
+// Handshake always matches: an in-process backend is compiled against the
+// same bindings as the client.
+func (s backendService) Handshake(_ context.Context, _ *pb.HandshakeRequest, _ ...grpc.CallOption) (*pb.HandshakeResponse, error) {
+	return &pb.HandshakeResponse{ProtocolVersion: ProtocolVersion, SchemaFingerprint: SchemaFingerprint()}, nil
+}
+
+func toPBResults(rs []SearchResult) []*pb.SearchResult {
+	out := make([]*pb.SearchResult, len(rs))
+	for i, r := range rs {
+		out[i] = &pb.SearchResult{Id: r.ID, Text: r.Text, Score: r.Score, MetadataJson: r.MetadataJSON}
+	}
+	return out
+}
+
+func closeBackend(b Backend) error {
+	if cl, ok := b.(io.Closer); ok {
+		if err := cl.Close(); err != nil {
+			return fmt.Errorf("close backend: %w", err)
+		}
+	}
+	return nil
+}
+
+// #endregion backend
@@ -0,0 +1,163 @@
+package codec
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// minimalBackend implements only Backend, plus io.Closer.
+type minimalBackend struct {
+	embeds atomic.Int32
+	closed bool
+}
+
+func (m *minimalBackend) Generate(_ context.Context, prompt string, stateVec [128]float32, evidence []string, _ []int64) (GenerateResult, error) {
+	return GenerateResult{Text: prompt + ":" + evidence[0], Entropy: stateVec[0], Model: "m"}, nil
+}
+
+func (m *minimalBackend) Embed(_ context.Context, text string) ([]float32, error) {
+	m.embeds.Add(1)
+	return []float32{float32(len(text))}, nil
+}
+
+func (m *minimalBackend) Search(_ context.Context, _ string, _ int, _ float32) ([]SearchResult, error) {
+	return []SearchResult{
+		{ID: "ev_00000000-0000-4000-8000-000000000001", Text: "kept", Score: 0.9},
+		{ID: "bogus", Text: "dropped"},
+	}, nil
+}
+
+func (m *minimalBackend) StoreEvidence(_ context.Context, _ string, _ string) (string, error) {
+	return "ev_00000000-0000-4000-8000-000000000002", nil
+}
+
+func (m *minimalBackend) Close() error {
+	m.closed = true
+	return nil
+}
+
+func TestNewCodecClientWithBackend(t *testing.T) {
+	b := &minimalBackend{}
+	c := NewCodecClientWithBackend(b)
+	ctx := context.Background()
+
+	var vec [128]float32
+	vec[0] = 0.5
+	gen, err := c.Generate(ctx, "p", vec, []string{"e"}, nil)
+	if err != nil || gen.Text != "p:e" || gen.Entropy != 0.5 || gen.Model != "m" {
+		t.Fatalf("generate = %+v, %v", gen, err)
+	}
+
+	// Backend results pass the same ID boundary as RPC results
+	results, err := c.Search(ctx, "q", 5, 0)
+	if err != nil || len(results) != 1 || results[0].Text != "kept" {
+		t.Fatalf("search = %+v, %v", results, err)
+	}
+
+	// No BatchEmbedder: EmbedBatch falls back to Embed per text
+	vecs, err := c.EmbedBatch(ctx, []string{"a", "bb", "ccc"})
+	if err != nil || len(vecs) != 3 || vecs[2][0] != 3 || b.embeds.Load() != 3 {
+		t.Fatalf("embed batch = %v, %v (embeds %d)", vecs, err, b.embeds.Load())
+	}
+
+	// No EvidenceManager or WebSearcher: Unimplemented
+	if _, err := c.ListAllEvidence(ctx); status.Code(err) != codes.Unimplemented {
+		t.Errorf("list all err = %v, want Unimplemented", err)
+	}
+	if _, err := c.WebSearch(ctx, "q", 3); status.Code(err) != codes.Unimplemented {
+		t.Errorf("web search err = %v, want Unimplemented", err)
+	}
+
+	if _, err := c.Handshake(ctx); err != nil {
+		t.Errorf("handshake: %v", err)
+	}
+	if err := c.Close(); err != nil || !b.closed {
+		t.Errorf("close = %v, closed %v", err, b.closed)
+	}
+}
+
+// sampledBackend records the sampling parameters it was called with.
+type sampledBackend struct {
+	minimalBackend
+	got *Sampling
+}
+
+func (s *sampledBackend) GenerateSampled(_ context.Context, prompt string, _ [128]float32, _ []string, _ []int64, p Sampling) (GenerateResult, error) {
+	s.got = &p
+	return GenerateResult{Text: prompt}, nil
+}
+
+func TestBackendService_ForwardsSampling(t *testing.T) {
+	b := &sampledBackend{}
+	c := NewCodecClientWithBackend(b)
+	ctx := context.Background()
+
+	want := Sampling{Temperature: 0.4, TopP: 0.9, MaxTokens: 256}
+	if _, err := c.GenerateSampled(ctx, "p", [128]float32{}, []string{"e"}, nil, want); err != nil {
+		t.Fatalf("generate sampled: %v", err)
+	}
+	if b.got == nil || *b.got != want {
+		t.Fatalf("sampling = %v, want %v", b.got, want)
+	}
+
+	// Zero sampling is not sent, so the plain Generate path runs
+	b.got = nil
+	res, err := c.Generate(ctx, "p", [128]float32{}, []string{"e"}, nil)
+	if err != nil || res.Text != "p:e" || b.got != nil {
+		t.Fatalf("generate = %+v, %v (sampling %v)", res, err, b.got)
+	}
+}
+
+// streamBackend emits its reply in pieces, or fails after the first one.
+type streamBackend struct {
+	minimalBackend
+	pieces []string
+	fail   error
+}
+
+func (s *streamBackend) GenerateStream(_ context.Context, _ string, _ [128]float32, _ []string, _ []int64, _ Sampling, onDelta func(string)) (GenerateResult, error) {
+	for i, p := range s.pieces {
+		if i == 1 && s.fail != nil {
+			return GenerateResult{}, s.fail
+		}
+		onDelta(p)
+	}
+	return GenerateResult{Text: strings.Join(s.pieces, ""), Entropy: 0.25, Logits: []float32{1, 2}}, nil
+}
+
+func TestBackendService_GenerateStream(t *testing.T) {
+	ctx := context.Background()
+	collect := func(c *CodecClient) ([]string, GenerateResult, error) {
+		var deltas []string
+		res, err := c.GenerateStream(ctx, "p", [128]float32{}, []string{"e"}, nil, Sampling{}, func(d string) {
+			deltas = append(deltas, d)
+		})
+		return deltas, res, err
+	}
+
+	deltas, res, err := collect(NewCodecClientWithBackend(&streamBackend{pieces: []string{"Hel", "lo", "."}}))
+	if err != nil || strings.Join(deltas, "|") != "Hel|lo|." {
+		t.Fatalf("deltas = %q, %v", deltas, err)
+	}
+	if res.Text != "Hello." || res.Entropy != 0.25 || len(res.Logits) != 2 {
+		t.Errorf("final = %+v", res)
+	}
+
+	// No StreamGenerator: the whole response arrives as one delta
+	deltas, res, err = collect(NewCodecClientWithBackend(&minimalBackend{}))
+	if err != nil || len(deltas) != 1 || deltas[0] != "p:e" || res.Text != "p:e" {
+		t.Errorf("fallback = %q, %+v, %v", deltas, res, err)
+	}
+
+	// A failure mid-stream surfaces after the deltas already sent
+	deltas, _, err = collect(NewCodecClientWithBackend(&streamBackend{pieces: []string{"a", "b"}, fail: errors.New("boom")}))
+	if err == nil || !strings.Contains(err.Error(), "boom") || len(deltas) != 1 {
+		t.Errorf("failing stream = %q, %v", deltas, err)
+	}
+}
@@ -2,11 +2,18 @@ package codec
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"io"
+	"log"
+	"sync"
+	"time"
 
 	pb "github.com/danielpatrickdp/adaptive-state/go-controller/gen/adaptive"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/evidence"
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 // #region types
@@ -16,6 +23,10 @@ type GenerateResult struct {
 	Entropy float32
 	Logits  []float32
 	Context []int64
+
+	// Backing model as reported by the server; empty from servers before protocol 4
+	Model        string
+	ModelVersion string
 }
 
 // SearchResult holds a single result from a Search RPC call.
@@ -39,58 +50,145 @@ type WebSearchResult struct {
 type CodecClient struct {
 	conn   *grpc.ClientConn
 	client pb.CodecServiceClient
+	batch  EmbedBatchConfig
+
+	backend Backend // in-process backend, when built with NewCodecClientWithBackend
 }
 // #endregion client-struct
 
 // #region constructor
 // NewCodecClient connects to the Python inference gRPC server.
 func NewCodecClient(addr string) (*CodecClient, error) {
-	conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	return NewCodecClientWithTransport(addr, TransportConfig{})
+}
+
+// NewCodecClientWithTransport connects to the inference server at addr over
+// the transport t: TLS, auth token and keepalive for a remote service.
+func NewCodecClientWithTransport(addr string, t TransportConfig) (*CodecClient, error) {
+	opts, err := t.DialOptions()
+	if err != nil {
+		return nil, err
+	}
+	conn, err := grpc.NewClient(addr, opts...)
 	if err != nil {
 		return nil, fmt.Errorf("grpc dial %s: %w", addr, err)
 	}
 	return &CodecClient{
 		conn:   conn,
 		client: pb.NewCodecServiceClient(conn),
+		batch:  DefaultEmbedBatchConfig(),
 	}, nil
 }
 // NewCodecClientWithService creates a CodecClient with an injected service implementation.
 // Used for testing without a real gRPC connection.
 func NewCodecClientWithService(svc pb.CodecServiceClient) *CodecClient {
-	return &CodecClient{client: svc}
+	return &CodecClient{client: svc, batch: DefaultEmbedBatchConfig()}
+}
+
+// WrapService replaces the underlying service client with wrap(current) and
+// returns c. Used to layer behaviour such as fault injection over the RPCs.
+func (c *CodecClient) WrapService(wrap func(pb.CodecServiceClient) pb.CodecServiceClient) *CodecClient {
+	c.client = wrap(c.client)
+	return c
 }
 
 // #endregion constructor
 
 // #region close
-// Close shuts down the gRPC connection.
+// Close shuts down the gRPC connection, or the in-process backend.
 func (c *CodecClient) Close() error {
+	if c.conn == nil {
+		return closeBackend(c.backend)
+	}
 	return c.conn.Close()
 }
 // #endregion close
 
 // #region generate
+// Sampling is the generation parameters for one Generate call. Zero fields
+// leave the server's default for that parameter.
+type Sampling struct {
+	Temperature float32
+	TopP        float32
+	MaxTokens   int
+}
+
+// IsZero reports whether s leaves every parameter at the server default.
+func (s Sampling) IsZero() bool {
+	return s == Sampling{}
+}
+
 // Generate sends a prompt with state context to the inference service.
 func (c *CodecClient) Generate(ctx context.Context, prompt string, stateVec [128]float32, evidence []string, ollamaCtx []int64) (GenerateResult, error) {
+	return c.GenerateSampled(ctx, prompt, stateVec, evidence, ollamaCtx, Sampling{})
+}
+
+// GenerateSampled is Generate with explicit sampling parameters. Servers
+// before protocol 6 ignore them.
+func (c *CodecClient) GenerateSampled(ctx context.Context, prompt string, stateVec [128]float32, evidence []string, ollamaCtx []int64, s Sampling) (GenerateResult, error) {
+	resp, err := c.client.Generate(ctx, generateRequest(prompt, stateVec, evidence, ollamaCtx, s))
+	if err != nil {
+		return GenerateResult{}, fmt.Errorf("generate rpc: %w", err)
+	}
+	return toGenerateResult(resp), nil
+}
+
+// GenerateStream is GenerateSampled over the streaming RPC: onDelta receives
+// the visible text as it is produced, and the result comes from the trailing
+// final message. Its Text is authoritative; it can differ from the joined
+// deltas when the server had to retry a think-only reply.
+func (c *CodecClient) GenerateStream(ctx context.Context, prompt string, stateVec [128]float32, evidence []string, ollamaCtx []int64, s Sampling, onDelta func(string)) (GenerateResult, error) {
+	stream, err := c.client.GenerateStream(ctx, generateRequest(prompt, stateVec, evidence, ollamaCtx, s))
+	if err != nil {
+		return GenerateResult{}, fmt.Errorf("generate stream rpc: %w", err)
+	}
+	for {
+		chunk, err := stream.Recv()
+		if err == io.EOF {
+			return GenerateResult{}, fmt.Errorf("generate stream rpc: ended without a final message")
+		}
+		if err != nil {
+			return GenerateResult{}, fmt.Errorf("generate stream rpc: %w", err)
+		}
+		if chunk.Final != nil {
+			return toGenerateResult(chunk.Final), nil
+		}
+		if chunk.Delta != "" && onDelta != nil {
+			onDelta(chunk.Delta)
+		}
+	}
+}
+
+func generateRequest(prompt string, stateVec [128]float32, evidence []string, ollamaCtx []int64, s Sampling) *pb.GenerateRequest {
 	vecSlice := make([]float32, 128)
 	copy(vecSlice, stateVec[:])
 
-	resp, err := c.client.Generate(ctx, &pb.GenerateRequest{
+	req := &pb.GenerateRequest{
 		Prompt:      prompt,
 		StateVector: vecSlice,
 		Evidence:    evidence,
 		Context:     ollamaCtx,
-	})
-	if err != nil {
-		return GenerateResult{}, fmt.Errorf("generate rpc: %w", err)
 	}
+	if !s.IsZero() {
+		req.Sampling = &pb.SamplingParams{
+			Temperature: s.Temperature,
+			TopP:        s.TopP,
+			MaxTokens:   int32(s.MaxTokens),
+		}
+	}
+	return req
+}
 
+func toGenerateResult(resp *pb.GenerateResponse) GenerateResult {
 	return GenerateResult{
 		Text:    resp.Text,
 		Entropy: resp.Entropy,
 		Logits:  resp.Logits,
 		Context: resp.Context,
-	}, nil
+
+		Model:        resp.ModelName,
+		ModelVersion: resp.ModelVersion,
+	}
 }
 // #endregion generate
 
@@ -107,6 +205,132 @@ func (c *CodecClient) Embed(ctx context.Context, text string) ([]float32, error)
 }
 // #endregion embed
 
+// #region embed-batch
+// EmbedBatchConfig bounds EmbedBatch: texts go to the server in chunks of at
+// most ChunkSize, with at most Concurrency chunks in flight. A positive
+// ChunkTimeout bounds each chunk's RPC, so large batches are not held to a
+// single-call deadline.
+type EmbedBatchConfig struct {
+	ChunkSize    int
+	Concurrency  int
+	ChunkTimeout time.Duration
+}
+
+// DefaultEmbedBatchConfig returns sensible defaults.
+func DefaultEmbedBatchConfig() EmbedBatchConfig {
+	return EmbedBatchConfig{ChunkSize: 32, Concurrency: 4}
+}
+
+// WithEmbedBatch sets the chunking limits for EmbedBatch and returns c.
+// Non-positive fields keep their defaults.
+func (c *CodecClient) WithEmbedBatch(cfg EmbedBatchConfig) *CodecClient {
+	c.batch = cfg
+	return c
+}
+
+// EmbedBatch embeds texts, returning one embedding per text in input order.
+// Texts are sent in chunks under the client's EmbedBatchConfig; the first
+// failing chunk cancels the rest. Servers that predate the EmbedBatch RPC
+// get the chunk as concurrent Embed calls instead.
+func (c *CodecClient) EmbedBatch(ctx context.Context, texts []string) ([][]float32, error) {
+	out := make([][]float32, len(texts))
+	if len(texts) == 0 {
+		return out, nil
+	}
+	size, workers := c.batch.ChunkSize, c.batch.Concurrency
+	if size <= 0 {
+		size = DefaultEmbedBatchConfig().ChunkSize
+	}
+	if workers <= 0 {
+		workers = DefaultEmbedBatchConfig().Concurrency
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var (
+		wg       sync.WaitGroup
+		once     sync.Once
+		firstErr error
+	)
+	fail := func(err error) {
+		once.Do(func() {
+			firstErr = err
+			cancel()
+		})
+	}
+	sem := make(chan struct{}, workers)
+	for start := 0; start < len(texts) && ctx.Err() == nil; start += size {
+		end := min(start+size, len(texts))
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(start, end int) {
+			defer func() { <-sem; wg.Done() }()
+			vecs, err := c.embedChunk(ctx, texts[start:end], workers)
+			if err != nil {
+				fail(fmt.Errorf("embed batch [%d:%d]: %w", start, end, err))
+				return
+			}
+			copy(out[start:end], vecs)
+		}(start, end)
+	}
+	wg.Wait()
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("embed batch: %w", err)
+	}
+	return out, nil
+}
+
+// embedChunk sends one chunk, falling back to per-text Embed calls (at most
+// workers at once) when the server does not implement EmbedBatch.
+func (c *CodecClient) embedChunk(ctx context.Context, texts []string, workers int) ([][]float32, error) {
+	if c.batch.ChunkTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.batch.ChunkTimeout)
+		defer cancel()
+	}
+	resp, err := c.client.EmbedBatch(ctx, &pb.EmbedBatchRequest{Texts: texts})
+	if status.Code(err) == codes.Unimplemented {
+		return c.embedEach(ctx, texts, workers)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("embed batch rpc: %w", err)
+	}
+	if len(resp.Embeddings) != len(texts) {
+		return nil, fmt.Errorf("embed batch rpc: %d embeddings for %d texts", len(resp.Embeddings), len(texts))
+	}
+	vecs := make([][]float32, len(texts))
+	for i, e := range resp.Embeddings {
+		vecs[i] = e.Values
+	}
+	return vecs, nil
+}
+
+func (c *CodecClient) embedEach(ctx context.Context, texts []string, workers int) ([][]float32, error) {
+	vecs := make([][]float32, len(texts))
+	errs := make([]error, len(texts))
+	sem := make(chan struct{}, workers)
+	var wg sync.WaitGroup
+	for i, text := range texts {
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(i int, text string) {
+			defer func() { <-sem; wg.Done() }()
+			vecs[i], errs[i] = c.Embed(ctx, text)
+		}(i, text)
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+	return vecs, nil
+}
+// #endregion embed-batch
+
 // #region search
 // Search queries the evidence memory store via the Python service.
 func (c *CodecClient) Search(ctx context.Context, queryText string, topK int, similarityThreshold float32) ([]SearchResult, error) {
@@ -119,16 +343,7 @@ func (c *CodecClient) Search(ctx context.Context, queryText string, topK int, si
 		return nil, fmt.Errorf("search rpc: %w", err)
 	}
 
-	results := make([]SearchResult, len(resp.Results))
-	for i, r := range resp.Results {
-		results[i] = SearchResult{
-			ID:           r.Id,
-			Text:         r.Text,
-			Score:        r.Score,
-			MetadataJSON: r.MetadataJson,
-		}
-	}
-	return results, nil
+	return toSearchResults("search", resp.Results), nil
 }
 // #endregion search
 
@@ -142,6 +357,9 @@ func (c *CodecClient) StoreEvidence(ctx context.Context, text string, metadataJS
 	if err != nil {
 		return "", fmt.Errorf("store evidence rpc: %w", err)
 	}
+	if err := evidence.ValidateLocalID(resp.Id); err != nil {
+		return "", fmt.Errorf("store evidence: %w", err)
+	}
 	return resp.Id, nil
 }
 // #endregion store-evidence
@@ -154,22 +372,17 @@ func (c *CodecClient) ListAllEvidence(ctx context.Context) ([]SearchResult, erro
 		return nil, fmt.Errorf("list all evidence rpc: %w", err)
 	}
 
-	results := make([]SearchResult, len(resp.Results))
-	for i, r := range resp.Results {
-		results[i] = SearchResult{
-			ID:           r.Id,
-			Text:         r.Text,
-			Score:        r.Score,
-			MetadataJSON: r.MetadataJson,
-		}
-	}
-	return results, nil
+	return toSearchResults("list all evidence", resp.Results), nil
 }
 // #endregion list-all-evidence
 
 // #region delete-evidence
 // DeleteEvidence batch-deletes evidence items by ID via the Python service.
+// Every ID must be a local evidence ID; nothing is sent otherwise.
 func (c *CodecClient) DeleteEvidence(ctx context.Context, ids []string) (int, error) {
+	if err := validateLocalIDs(ids); err != nil {
+		return 0, fmt.Errorf("delete evidence: %w", err)
+	}
 	resp, err := c.client.DeleteEvidence(ctx, &pb.DeleteEvidenceRequest{
 		Ids: ids,
 	})
@@ -180,9 +393,36 @@ func (c *CodecClient) DeleteEvidence(ctx context.Context, ids []string) (int, er
 }
 // #endregion delete-evidence
 
+// #region update-evidence-metadata
+// UpdateEvidenceMetadata merges patch into the metadata of evidence id; a nil
+// value removes its key. It returns the merged metadata, and found=false when
+// no item has the ID. The ID must be a local evidence ID.
+func (c *CodecClient) UpdateEvidenceMetadata(ctx context.Context, id string, patch map[string]any) (string, bool, error) {
+	if err := evidence.ValidateLocalID(id); err != nil {
+		return "", false, fmt.Errorf("update evidence metadata: %w", err)
+	}
+	patchJSON, err := json.Marshal(patch)
+	if err != nil {
+		return "", false, fmt.Errorf("update evidence metadata: %w", err)
+	}
+	resp, err := c.client.UpdateEvidenceMetadata(ctx, &pb.UpdateEvidenceMetadataRequest{
+		Id:           id,
+		MetadataJson: string(patchJSON),
+	})
+	if err != nil {
+		return "", false, fmt.Errorf("update evidence metadata rpc: %w", err)
+	}
+	return resp.MetadataJson, resp.Found, nil
+}
+// #endregion update-evidence-metadata
+
 // #region get-by-ids
 // GetByIDs fetches evidence items by their IDs via the Python service.
+// Every ID must be a local evidence ID; nothing is sent otherwise.
 func (c *CodecClient) GetByIDs(ctx context.Context, ids []string) ([]SearchResult, error) {
+	if err := validateLocalIDs(ids); err != nil {
+		return nil, fmt.Errorf("get by ids: %w", err)
+	}
 	resp, err := c.client.GetByIDs(ctx, &pb.GetByIDsRequest{
 		Ids: ids,
 	})
@@ -190,16 +430,7 @@ func (c *CodecClient) GetByIDs(ctx context.Context, ids []string) ([]SearchResul
 		return nil, fmt.Errorf("get by ids rpc: %w", err)
 	}
 
-	results := make([]SearchResult, len(resp.Results))
-	for i, r := range resp.Results {
-		results[i] = SearchResult{
-			ID:           r.Id,
-			Text:         r.Text,
-			Score:        r.Score,
-			MetadataJSON: r.MetadataJson,
-		}
-	}
-	return results, nil
+	return toSearchResults("get by ids", resp.Results), nil
 }
 // #endregion get-by-ids
 
@@ -225,3 +456,33 @@ func (c *CodecClient) WebSearch(ctx context.Context, query string, maxResults in
 	return results, nil
 }
 // #endregion web-search
+
+// #region id-boundary
+// toSearchResults converts RPC results, dropping any whose ID is not a valid evidence
+// ID so malformed IDs never reach whitelists or graph joins.
+func toSearchResults(op string, rs []*pb.SearchResult) []SearchResult {
+	results := make([]SearchResult, 0, len(rs))
+	for _, r := range rs {
+		if err := evidence.ValidateID(r.Id); err != nil {
+			log.Printf("codec: %s: dropping result: %v", op, err)
+			continue
+		}
+		results = append(results, SearchResult{
+			ID:           r.Id,
+			Text:         r.Text,
+			Score:        r.Score,
+			MetadataJSON: r.MetadataJson,
+		})
+	}
+	return results
+}
+
+func validateLocalIDs(ids []string) error {
+	for _, id := range ids {
+		if err := evidence.ValidateLocalID(id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+// #endregion id-boundary
@@ -3,10 +3,14 @@ package codec
 import (
 	"context"
 	"errors"
+	"sync"
 	"testing"
 
 	pb "github.com/danielpatrickdp/adaptive-state/go-controller/gen/adaptive"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/evidence"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 // #region mock
@@ -19,6 +23,8 @@ type mockCodecService struct {
 	embedResp *pb.EmbedResponse
 	embedErr  error
 
+	embedBatch func(*pb.EmbedBatchRequest) (*pb.EmbedBatchResponse, error)
+
 	searchResp *pb.SearchResponse
 	searchErr  error
 
@@ -27,6 +33,9 @@ type mockCodecService struct {
 
 	webSearchResp *pb.WebSearchResponse
 	webSearchErr  error
+
+	handshakeResp *pb.HandshakeResponse
+	handshakeErr  error
 }
 
 func (m *mockCodecService) Generate(_ context.Context, _ *pb.GenerateRequest, _ ...grpc.CallOption) (*pb.GenerateResponse, error) {
@@ -37,6 +46,10 @@ func (m *mockCodecService) Embed(_ context.Context, _ *pb.EmbedRequest, _ ...grp
 	return m.embedResp, m.embedErr
 }
 
+func (m *mockCodecService) EmbedBatch(_ context.Context, in *pb.EmbedBatchRequest, _ ...grpc.CallOption) (*pb.EmbedBatchResponse, error) {
+	return m.embedBatch(in)
+}
+
 func (m *mockCodecService) Search(_ context.Context, _ *pb.SearchRequest, _ ...grpc.CallOption) (*pb.SearchResponse, error) {
 	return m.searchResp, m.searchErr
 }
@@ -49,6 +62,10 @@ func (m *mockCodecService) WebSearch(_ context.Context, _ *pb.WebSearchRequest,
 	return m.webSearchResp, m.webSearchErr
 }
 
+func (m *mockCodecService) Handshake(_ context.Context, _ *pb.HandshakeRequest, _ ...grpc.CallOption) (*pb.HandshakeResponse, error) {
+	return m.handshakeResp, m.handshakeErr
+}
+
 // #endregion mock
 
 // #region constructor-tests
@@ -79,6 +96,9 @@ func TestGenerate_Success(t *testing.T) {
 			Text:    "hello world",
 			Entropy: 1.5,
 			Logits:  []float32{0.1, 0.2, 0.3},
+
+			ModelName:    "qwen3-4b",
+			ModelVersion: "sha256:0123456789abcdef",
 		},
 	}
 	c := &CodecClient{client: mock}
@@ -96,6 +116,9 @@ func TestGenerate_Success(t *testing.T) {
 	if len(result.Logits) != 3 {
 		t.Errorf("expected 3 logits, got %d", len(result.Logits))
 	}
+	if result.Model != "qwen3-4b" || result.ModelVersion != "sha256:0123456789abcdef" {
+		t.Errorf("expected model metadata to pass through, got %q %q", result.Model, result.ModelVersion)
+	}
 }
 
 func TestGenerate_Error(t *testing.T) {
@@ -153,13 +176,93 @@ func TestEmbed_Error(t *testing.T) {
 
 // #endregion embed-tests
 
+// #region embed-batch-tests
+// lengthEmbeddings embeds each text as [len(text)], so order is checkable.
+func lengthEmbeddings(in *pb.EmbedBatchRequest) *pb.EmbedBatchResponse {
+	resp := &pb.EmbedBatchResponse{}
+	for _, t := range in.Texts {
+		resp.Embeddings = append(resp.Embeddings, &pb.Embedding{Values: []float32{float32(len(t))}})
+	}
+	return resp
+}
+
+func TestEmbedBatch_ChunksPreserveOrder(t *testing.T) {
+	var mu sync.Mutex
+	var sizes []int
+	mock := &mockCodecService{embedBatch: func(in *pb.EmbedBatchRequest) (*pb.EmbedBatchResponse, error) {
+		mu.Lock()
+		sizes = append(sizes, len(in.Texts))
+		mu.Unlock()
+		return lengthEmbeddings(in), nil
+	}}
+	c := NewCodecClientWithService(mock).WithEmbedBatch(EmbedBatchConfig{ChunkSize: 2, Concurrency: 2})
+
+	texts := []string{"a", "bb", "ccc", "dddd", "eeeee"}
+	vecs, err := c.EmbedBatch(context.Background(), texts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for i, v := range vecs {
+		if len(v) != 1 || v[0] != float32(len(texts[i])) {
+			t.Errorf("vecs[%d] = %v, want [%d]", i, v, len(texts[i]))
+		}
+	}
+	if len(sizes) != 3 {
+		t.Errorf("expected 3 chunks, got %v", sizes)
+	}
+	for _, n := range sizes {
+		if n > 2 {
+			t.Errorf("chunk of %d texts exceeds ChunkSize 2", n)
+		}
+	}
+}
+
+func TestEmbedBatch_FallsBackToEmbed(t *testing.T) {
+	mock := &mockCodecService{
+		embedBatch: func(*pb.EmbedBatchRequest) (*pb.EmbedBatchResponse, error) {
+			return nil, status.Error(codes.Unimplemented, "method EmbedBatch not implemented")
+		},
+		embedResp: &pb.EmbedResponse{Embedding: []float32{0.5}},
+	}
+	c := &CodecClient{client: mock} // zero config falls back to the defaults
+
+	vecs, err := c.EmbedBatch(context.Background(), []string{"a", "b", "c"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(vecs) != 3 || vecs[2][0] != 0.5 {
+		t.Errorf("expected 3 embeddings from Embed, got %v", vecs)
+	}
+}
+
+func TestEmbedBatch_Error(t *testing.T) {
+	batchErr := errors.New("embed batch failed")
+	mock := &mockCodecService{embedBatch: func(*pb.EmbedBatchRequest) (*pb.EmbedBatchResponse, error) {
+		return nil, batchErr
+	}}
+	c := NewCodecClientWithService(mock)
+	if _, err := c.EmbedBatch(context.Background(), []string{"a"}); !errors.Is(err, batchErr) {
+		t.Errorf("expected wrapped embed batch error, got: %v", err)
+	}
+
+	mock.embedBatch = func(*pb.EmbedBatchRequest) (*pb.EmbedBatchResponse, error) {
+		return &pb.EmbedBatchResponse{}, nil
+	}
+	if _, err := c.EmbedBatch(context.Background(), []string{"a", "b"}); err == nil {
+		t.Error("expected error when the server returns too few embeddings")
+	}
+}
+
+// #endregion embed-batch-tests
+
 // #region search-tests
 func TestSearch_Success(t *testing.T) {
 	mock := &mockCodecService{
 		searchResp: &pb.SearchResponse{
 			Results: []*pb.SearchResult{
-				{Id: "r1", Text: "result one", Score: 0.95, MetadataJson: `{"k":"v"}`},
-				{Id: "r2", Text: "result two", Score: 0.80, MetadataJson: ""},
+				{Id: "ev_3f2504e0-4f89-41d3-9a0c-0305e82c3301", Text: "result one", Score: 0.95, MetadataJson: `{"k":"v"}`},
+				{Id: "team::r2", Text: "result two", Score: 0.80, MetadataJson: ""},
+				{Id: "r3", Text: "malformed id", Score: 0.70, MetadataJson: ""},
 			},
 		},
 	}
@@ -170,10 +273,10 @@ func TestSearch_Success(t *testing.T) {
 		t.Fatalf("unexpected error: %v", err)
 	}
 	if len(results) != 2 {
-		t.Fatalf("expected 2 results, got %d", len(results))
+		t.Fatalf("expected 2 results (malformed ID dropped), got %d", len(results))
 	}
-	if results[0].ID != "r1" {
-		t.Errorf("expected ID 'r1', got %q", results[0].ID)
+	if results[0].ID != "ev_3f2504e0-4f89-41d3-9a0c-0305e82c3301" {
+		t.Errorf("expected local ID, got %q", results[0].ID)
 	}
 	if results[0].Text != "result one" {
 		t.Errorf("expected text 'result one', got %q", results[0].Text)
@@ -207,7 +310,7 @@ func TestSearch_Error(t *testing.T) {
 func TestStoreEvidence_Success(t *testing.T) {
 	mock := &mockCodecService{
 		storeResp: &pb.StoreEvidenceResponse{
-			Id: "stored-123",
+			Id: "ev_3f2504e0-4f89-41d3-9a0c-0305e82c3301",
 		},
 	}
 	c := &CodecClient{client: mock}
@@ -216,8 +319,19 @@ func TestStoreEvidence_Success(t *testing.T) {
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
-	if id != "stored-123" {
-		t.Errorf("expected id 'stored-123', got %q", id)
+	if id != "ev_3f2504e0-4f89-41d3-9a0c-0305e82c3301" {
+		t.Errorf("expected stored id, got %q", id)
+	}
+}
+
+func TestStoreEvidence_RejectsMalformedID(t *testing.T) {
+	mock := &mockCodecService{
+		storeResp: &pb.StoreEvidenceResponse{Id: "3F2504E0-4F89-41D3-9A0C-0305E82C3301"},
+	}
+	c := &CodecClient{client: mock}
+
+	if _, err := c.StoreEvidence(context.Background(), "text", "{}"); !errors.Is(err, evidence.ErrInvalidID) {
+		t.Fatalf("expected invalid id error, got %v", err)
 	}
 }
 
@@ -238,6 +352,20 @@ func TestStoreEvidence_Error(t *testing.T) {
 
 // #endregion store-evidence-tests
 
+// #region id-boundary-tests
+func TestDeleteAndGet_RejectNonLocalIDsBeforeRPC(t *testing.T) {
+	c := &CodecClient{client: &mockCodecService{}} // any RPC would panic on the nil embedded client
+
+	if _, err := c.DeleteEvidence(context.Background(), []string{"team::doc-1"}); !errors.Is(err, evidence.ErrInvalidID) {
+		t.Errorf("delete: expected invalid id error, got %v", err)
+	}
+	if _, err := c.GetByIDs(context.Background(), []string{"chunk-1"}); !errors.Is(err, evidence.ErrInvalidID) {
+		t.Errorf("get by ids: expected invalid id error, got %v", err)
+	}
+}
+
+// #endregion id-boundary-tests
+
 // #region web-search-tests
 func TestWebSearch_Success(t *testing.T) {
 	mock := &mockCodecService{
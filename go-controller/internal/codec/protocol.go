@@ -0,0 +1,124 @@
+package codec
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	pb "github.com/danielpatrickdp/adaptive-state/go-controller/gen/adaptive"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// #region protocol
+
+// ProtocolVersion is the codec protocol these bindings were generated for. It
+// must equal the protocol_version header in proto/adaptive.proto and
+// PROTOCOL_VERSION in adaptive_inference/protocol.py.
+const ProtocolVersion = 8
+
+// ErrProtocolMismatch is returned by Handshake when client and server were built
+// from different versions of proto/adaptive.proto.
+var ErrProtocolMismatch = errors.New("codec protocol mismatch")
+
+// InstanceHeader is the response header in which the server names its
+// process. It is not part of the proto schema, so an older server simply
+// leaves it out.
+const InstanceHeader = "x-server-instance"
+
+// ServerInfo is the server's side of the handshake.
+type ServerInfo struct {
+	ProtocolVersion   int32
+	SchemaFingerprint string
+	Instance          string // InstanceHeader: changes when the server restarts; "" if not sent
+}
+
+var (
+	fingerprintOnce sync.Once
+	fingerprint     string
+)
+
+// SchemaFingerprint identifies the compiled bindings: a short hash over every
+// message field (name, number, type, label) and RPC signature, in declaration
+// order. The Python service computes the same hash from its own bindings, so a
+// field added on one side only shows up as a fingerprint mismatch.
+func SchemaFingerprint() string {
+	fingerprintOnce.Do(func() { fingerprint = canonicalFingerprint(schemaCanonical(pb.File_adaptive_proto)) })
+	return fingerprint
+}
+
+// schemaCanonical renders the parts of fd that the fingerprint covers, one line each.
+func schemaCanonical(fd protoreflect.FileDescriptor) string {
+	var b strings.Builder
+	msgs := fd.Messages()
+	for i := 0; i < msgs.Len(); i++ {
+		m := msgs.Get(i)
+		fmt.Fprintf(&b, "message %s\n", m.Name())
+		fields := m.Fields()
+		for j := 0; j < fields.Len(); j++ {
+			f := fields.Get(j)
+			typeName := ""
+			if f.Message() != nil {
+				typeName = "." + string(f.Message().FullName())
+			}
+			fmt.Fprintf(&b, "%s.%s=%d:%d:%d:%s\n", m.Name(), f.Name(), f.Number(), f.Kind(), f.Cardinality(), typeName)
+		}
+	}
+	svcs := fd.Services()
+	for i := 0; i < svcs.Len(); i++ {
+		s := svcs.Get(i)
+		methods := s.Methods()
+		for j := 0; j < methods.Len(); j++ {
+			m := methods.Get(j)
+			fmt.Fprintf(&b, "rpc %s.%s(.%s).%s\n", s.Name(), m.Name(), m.Input().FullName(), m.Output().FullName())
+		}
+	}
+	return b.String()
+}
+
+func canonicalFingerprint(canonical string) string {
+	sum := sha256.Sum256([]byte(canonical))
+	return hex.EncodeToString(sum[:8])
+}
+
+// Handshake exchanges protocol versions and schema fingerprints with the server.
+// Any incompatibility — a server that predates the handshake, a different
+// protocol version, or bindings that drifted without a version bump — is
+// reported as ErrProtocolMismatch. Other errors (server unreachable, timeout)
+// are returned as plain RPC errors.
+func (c *CodecClient) Handshake(ctx context.Context) (ServerInfo, error) {
+	var header metadata.MD
+	resp, err := c.client.Handshake(ctx, &pb.HandshakeRequest{
+		ProtocolVersion:   ProtocolVersion,
+		SchemaFingerprint: SchemaFingerprint(),
+	}, grpc.Header(&header))
+	if status.Code(err) == codes.Unimplemented {
+		return ServerInfo{}, fmt.Errorf("%w: server does not implement Handshake (protocol 1), client speaks protocol %d; upgrade py-inference and regenerate its bindings with scripts/gen-proto.sh",
+			ErrProtocolMismatch, ProtocolVersion)
+	}
+	if err != nil {
+		return ServerInfo{}, fmt.Errorf("handshake rpc: %w", err)
+	}
+	info := ServerInfo{ProtocolVersion: resp.ProtocolVersion, SchemaFingerprint: resp.SchemaFingerprint}
+	if v := header.Get(InstanceHeader); len(v) > 0 {
+		info.Instance = v[0]
+	}
+	if info.ProtocolVersion != ProtocolVersion {
+		return info, fmt.Errorf("%w: client speaks protocol %d, server speaks protocol %d; rebuild the older side from the current proto/adaptive.proto",
+			ErrProtocolMismatch, ProtocolVersion, info.ProtocolVersion)
+	}
+	if info.SchemaFingerprint != SchemaFingerprint() {
+		return info, fmt.Errorf("%w: both sides claim protocol %d but schema fingerprints differ (client %s, server %s); bindings drifted from proto/adaptive.proto, regenerate them with scripts/gen-proto.sh",
+			ErrProtocolMismatch, ProtocolVersion, SchemaFingerprint(), info.SchemaFingerprint)
+	}
+	return info, nil
+}
+
+// #endregion protocol
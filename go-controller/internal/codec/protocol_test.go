@@ -0,0 +1,150 @@
+package codec
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	pb "github.com/danielpatrickdp/adaptive-state/go-controller/gen/adaptive"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// #region drift-tests
+
+const protoPath = "../../../proto/adaptive.proto"
+
+// protoTypes maps proto scalar keywords to descriptor type numbers; anything
+// else is a message reference (TYPE_MESSAGE).
+var protoTypes = map[string]int{
+	"double": 1, "float": 2, "int64": 3, "uint64": 4, "int32": 5, "bool": 8, "string": 9, "bytes": 12, "uint32": 13,
+}
+
+var (
+	reVersion = regexp.MustCompile(`^// protocol_version: (\d+)$`)
+	reMessage = regexp.MustCompile(`^message (\w+) \{(\})?$`)
+	reField   = regexp.MustCompile(`^(repeated )?(\w+) (\w+) = (\d+);$`)
+	reService = regexp.MustCompile(`^service (\w+) \{$`)
+	reRPC     = regexp.MustCompile(`^rpc (\w+)\((\w+)\) returns \((?:stream )?(\w+)\);$`)
+)
+
+// canonicalFromProto renders proto/adaptive.proto the way schemaCanonical renders
+// the compiled descriptor, so the two can be compared line by line.
+func canonicalFromProto(t *testing.T) (version int, canonical string) {
+	t.Helper()
+	f, err := os.Open(protoPath)
+	if err != nil {
+		t.Fatalf("open proto: %v", err)
+	}
+	defer f.Close()
+
+	var msgs, rpcs strings.Builder
+	var msg, svc string
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		switch {
+		case reVersion.MatchString(line):
+			version, _ = strconv.Atoi(reVersion.FindStringSubmatch(line)[1])
+		case reMessage.MatchString(line):
+			m := reMessage.FindStringSubmatch(line)
+			fmt.Fprintf(&msgs, "message %s\n", m[1])
+			if m[2] == "" {
+				msg = m[1]
+			}
+		case reService.MatchString(line):
+			svc = reService.FindStringSubmatch(line)[1]
+		case line == "}":
+			msg, svc = "", ""
+		case msg != "" && reField.MatchString(line):
+			m := reField.FindStringSubmatch(line)
+			label, typ, typeName := 1, protoTypes[m[2]], ""
+			if m[1] != "" {
+				label = 3
+			}
+			if typ == 0 {
+				typ, typeName = 11, ".adaptive."+m[2]
+			}
+			fmt.Fprintf(&msgs, "%s.%s=%s:%d:%d:%s\n", msg, m[3], m[4], typ, label, typeName)
+		case svc != "" && reRPC.MatchString(line):
+			m := reRPC.FindStringSubmatch(line)
+			fmt.Fprintf(&rpcs, "rpc %s.%s(.adaptive.%s).adaptive.%s\n", svc, m[1], m[2], m[3])
+		}
+	}
+	if err := sc.Err(); err != nil {
+		t.Fatalf("read proto: %v", err)
+	}
+	return version, msgs.String() + rpcs.String()
+}
+
+func TestProtocolVersion_MatchesProto(t *testing.T) {
+	version, _ := canonicalFromProto(t)
+	if version != ProtocolVersion {
+		t.Errorf("proto declares protocol_version %d, codec.ProtocolVersion is %d", version, ProtocolVersion)
+	}
+}
+
+func TestGeneratedBindings_MatchProto(t *testing.T) {
+	_, want := canonicalFromProto(t)
+	got := schemaCanonical(pb.File_adaptive_proto)
+	if got != want {
+		t.Fatalf("gen/adaptive drifted from %s; run `go generate ./gen/...`\nproto:\n%s\nbindings:\n%s", protoPath, want, got)
+	}
+	if SchemaFingerprint() != canonicalFingerprint(want) {
+		t.Error("fingerprint should be the hash of the canonical schema")
+	}
+}
+
+// #endregion drift-tests
+
+// #region handshake-tests
+
+func TestHandshake_Match(t *testing.T) {
+	mock := &mockCodecService{handshakeResp: &pb.HandshakeResponse{ProtocolVersion: ProtocolVersion, SchemaFingerprint: SchemaFingerprint()}}
+	info, err := NewCodecClientWithService(mock).Handshake(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if info.ProtocolVersion != ProtocolVersion {
+		t.Errorf("expected server protocol %d, got %d", ProtocolVersion, info.ProtocolVersion)
+	}
+}
+
+func TestHandshake_Mismatches(t *testing.T) {
+	cases := []struct {
+		name string
+		mock *mockCodecService
+		want string
+	}{
+		{"old server", &mockCodecService{handshakeErr: status.Error(codes.Unimplemented, "method Handshake not implemented")}, "does not implement Handshake"},
+		{"version", &mockCodecService{handshakeResp: &pb.HandshakeResponse{ProtocolVersion: ProtocolVersion + 1, SchemaFingerprint: "x"}}, fmt.Sprintf("server speaks protocol %d", ProtocolVersion+1)},
+		{"fingerprint", &mockCodecService{handshakeResp: &pb.HandshakeResponse{ProtocolVersion: ProtocolVersion, SchemaFingerprint: "deadbeef"}}, "schema fingerprints differ"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := NewCodecClientWithService(tc.mock).Handshake(context.Background())
+			if !errors.Is(err, ErrProtocolMismatch) {
+				t.Fatalf("expected ErrProtocolMismatch, got %v", err)
+			}
+			if !strings.Contains(err.Error(), tc.want) {
+				t.Errorf("expected %q in %q", tc.want, err.Error())
+			}
+		})
+	}
+}
+
+func TestHandshake_TransportErrorIsNotMismatch(t *testing.T) {
+	mock := &mockCodecService{handshakeErr: status.Error(codes.Unavailable, "connection refused")}
+	_, err := NewCodecClientWithService(mock).Handshake(context.Background())
+	if err == nil || errors.Is(err, ErrProtocolMismatch) {
+		t.Fatalf("expected a plain rpc error, got %v", err)
+	}
+}
+
+// #endregion handshake-tests
@@ -0,0 +1,167 @@
+package codec
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/keepalive"
+	"google.golang.org/grpc/metadata"
+)
+
+// #region transport-config
+
+// TransportConfig is how the client reaches a codec service on another host:
+// TLS, a bearer token on every RPC, and connection keepalive. The zero value
+// is the plain local connection NewCodecClient has always made.
+type TransportConfig struct {
+	TLS        bool   // implied by any of the files below
+	CAFile     string // PEM roots that verify the server; empty uses the system pool
+	CertFile   string // client certificate for mutual TLS, with KeyFile
+	KeyFile    string
+	ServerName string // name verified against the server certificate; empty uses the address host
+
+	Token     string // sent as "authorization: Bearer <token>"
+	TokenFile string // re-read on every RPC, so the token can rotate without a restart
+
+	KeepaliveTime    time.Duration // ping an idle connection this often; 0 disables
+	KeepaliveTimeout time.Duration // close the connection when a ping goes unanswered this long
+}
+
+// TransportConfigFromEnv reads CODEC_TLS, CODEC_TLS_CA, CODEC_TLS_CERT,
+// CODEC_TLS_KEY, CODEC_TLS_SERVER_NAME, CODEC_AUTH_TOKEN,
+// CODEC_AUTH_TOKEN_FILE, CODEC_KEEPALIVE and CODEC_KEEPALIVE_TIMEOUT
+// (seconds), and validates the result.
+func TransportConfigFromEnv() (TransportConfig, error) {
+	t := TransportConfig{
+		TLS:        os.Getenv("CODEC_TLS") == "1" || os.Getenv("CODEC_TLS") == "true",
+		CAFile:     os.Getenv("CODEC_TLS_CA"),
+		CertFile:   os.Getenv("CODEC_TLS_CERT"),
+		KeyFile:    os.Getenv("CODEC_TLS_KEY"),
+		ServerName: os.Getenv("CODEC_TLS_SERVER_NAME"),
+		Token:      os.Getenv("CODEC_AUTH_TOKEN"),
+		TokenFile:  os.Getenv("CODEC_AUTH_TOKEN_FILE"),
+	}
+	for key, d := range map[string]*time.Duration{"CODEC_KEEPALIVE": &t.KeepaliveTime, "CODEC_KEEPALIVE_TIMEOUT": &t.KeepaliveTimeout} {
+		if v := os.Getenv(key); v != "" {
+			sec, err := strconv.Atoi(v)
+			if err != nil || sec < 0 {
+				return TransportConfig{}, fmt.Errorf("codec transport: %s=%q: want whole seconds", key, v)
+			}
+			*d = time.Duration(sec) * time.Second
+		}
+	}
+	return t, t.Validate()
+}
+
+// Validate rejects configs that would not connect securely: half a client
+// certificate, two token sources, or a token over a plaintext connection.
+func (t TransportConfig) Validate() error {
+	if (t.CertFile == "") != (t.KeyFile == "") {
+		return fmt.Errorf("codec transport: client certificate needs both a cert and a key file")
+	}
+	if t.Token != "" && t.TokenFile != "" {
+		return fmt.Errorf("codec transport: set an auth token or a token file, not both")
+	}
+	if (t.Token != "" || t.TokenFile != "") && !t.secure() {
+		return fmt.Errorf("codec transport: auth token needs TLS, or it is sent in the clear")
+	}
+	if t.KeepaliveTime > 0 && t.KeepaliveTime < 10*time.Second {
+		return fmt.Errorf("codec transport: keepalive %s is below gRPC's 10s minimum", t.KeepaliveTime)
+	}
+	return nil
+}
+
+func (t TransportConfig) secure() bool {
+	return t.TLS || t.CAFile != "" || t.CertFile != "" || t.ServerName != ""
+}
+
+// #endregion transport-config
+
+// #region dial-options
+
+// DialOptions returns the gRPC options for t: transport credentials, token
+// interceptors and keepalive parameters.
+func (t TransportConfig) DialOptions() ([]grpc.DialOption, error) {
+	if err := t.Validate(); err != nil {
+		return nil, err
+	}
+	creds := insecure.NewCredentials()
+	if t.secure() {
+		cfg := &tls.Config{MinVersion: tls.VersionTLS12, ServerName: t.ServerName}
+		if t.CAFile != "" {
+			pem, err := os.ReadFile(t.CAFile)
+			if err != nil {
+				return nil, fmt.Errorf("codec transport: read CA: %w", err)
+			}
+			cfg.RootCAs = x509.NewCertPool()
+			if !cfg.RootCAs.AppendCertsFromPEM(pem) {
+				return nil, fmt.Errorf("codec transport: no certificates in CA file %s", t.CAFile)
+			}
+		}
+		if t.CertFile != "" {
+			cert, err := tls.LoadX509KeyPair(t.CertFile, t.KeyFile)
+			if err != nil {
+				return nil, fmt.Errorf("codec transport: load client certificate: %w", err)
+			}
+			cfg.Certificates = []tls.Certificate{cert}
+		}
+		creds = credentials.NewTLS(cfg)
+	}
+	opts := []grpc.DialOption{grpc.WithTransportCredentials(creds)}
+
+	if t.Token != "" || t.TokenFile != "" {
+		opts = append(opts,
+			grpc.WithChainUnaryInterceptor(func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, callOpts ...grpc.CallOption) error {
+				ctx, err := t.withToken(ctx)
+				if err != nil {
+					return err
+				}
+				return invoker(ctx, method, req, reply, cc, callOpts...)
+			}),
+			grpc.WithChainStreamInterceptor(func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, callOpts ...grpc.CallOption) (grpc.ClientStream, error) {
+				ctx, err := t.withToken(ctx)
+				if err != nil {
+					return nil, err
+				}
+				return streamer(ctx, desc, cc, method, callOpts...)
+			}),
+		)
+	}
+
+	if t.KeepaliveTime > 0 {
+		timeout := t.KeepaliveTimeout
+		if timeout == 0 {
+			timeout = 20 * time.Second
+		}
+		opts = append(opts, grpc.WithKeepaliveParams(keepalive.ClientParameters{
+			Time:                t.KeepaliveTime,
+			Timeout:             timeout,
+			PermitWithoutStream: true,
+		}))
+	}
+	return opts, nil
+}
+
+// withToken attaches the bearer token to the outgoing RPC's metadata.
+func (t TransportConfig) withToken(ctx context.Context) (context.Context, error) {
+	token := t.Token
+	if t.TokenFile != "" {
+		b, err := os.ReadFile(t.TokenFile)
+		if err != nil {
+			return ctx, fmt.Errorf("codec transport: read token: %w", err)
+		}
+		token = strings.TrimSpace(string(b))
+	}
+	return metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+token), nil
+}
+
+// #endregion dial-options
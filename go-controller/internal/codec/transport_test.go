@@ -0,0 +1,215 @@
+package codec
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	pb "github.com/danielpatrickdp/adaptive-state/go-controller/gen/adaptive"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+)
+
+// #region helpers
+
+// handshakeServer answers Handshake and records the authorization header.
+type handshakeServer struct {
+	pb.UnimplementedCodecServiceServer
+	auth chan string
+}
+
+func (s *handshakeServer) Handshake(ctx context.Context, _ *pb.HandshakeRequest) (*pb.HandshakeResponse, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	s.auth <- firstOr(md.Get("authorization"), "")
+	grpc.SetHeader(ctx, metadata.Pairs(InstanceHeader, "proc-1"))
+	return &pb.HandshakeResponse{ProtocolVersion: ProtocolVersion, SchemaFingerprint: SchemaFingerprint()}, nil
+}
+
+func firstOr(v []string, fallback string) string {
+	if len(v) == 0 {
+		return fallback
+	}
+	return v[0]
+}
+
+// selfSigned writes a self-signed certificate for "codec.test" to dir and
+// returns the cert and key paths.
+func selfSigned(t *testing.T, dir string) (string, string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "codec.test"},
+		DNSNames:              []string{"codec.test"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certPath, keyPath := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	if err := os.WriteFile(certPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return certPath, keyPath
+}
+
+// #endregion helpers
+
+// #region tests
+
+func TestTransport_TLSWithToken(t *testing.T) {
+	dir := t.TempDir()
+	certPath, keyPath := selfSigned(t, dir)
+	serverCert, err := tls.LoadX509KeyPair(certPath, keyPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := grpc.NewServer(grpc.Creds(credentials.NewTLS(&tls.Config{Certificates: []tls.Certificate{serverCert}})))
+	hs := &handshakeServer{auth: make(chan string, 2)}
+	pb.RegisterCodecServiceServer(srv, hs)
+	go srv.Serve(lis)
+	defer srv.Stop()
+
+	tokenPath := filepath.Join(dir, "token")
+	if err := os.WriteFile(tokenPath, []byte("first\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	client, err := NewCodecClientWithTransport(lis.Addr().String(), TransportConfig{
+		CAFile:           certPath,
+		ServerName:       "codec.test",
+		TokenFile:        tokenPath,
+		KeepaliveTime:    30 * time.Second,
+		KeepaliveTimeout: 5 * time.Second,
+	})
+	if err != nil {
+		t.Fatalf("NewCodecClientWithTransport: %v", err)
+	}
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	info, err := client.Handshake(ctx)
+	if err != nil {
+		t.Fatalf("Handshake over TLS: %v", err)
+	}
+	if info.Instance != "proc-1" {
+		t.Errorf("instance = %q, want the %s header", info.Instance, InstanceHeader)
+	}
+	if got := <-hs.auth; got != "Bearer first" {
+		t.Errorf("authorization = %q, want %q", got, "Bearer first")
+	}
+
+	// The token file is re-read per RPC
+	if err := os.WriteFile(tokenPath, []byte("second"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.Handshake(ctx); err != nil {
+		t.Fatalf("second Handshake: %v", err)
+	}
+	if got := <-hs.auth; got != "Bearer second" {
+		t.Errorf("authorization after rotation = %q, want %q", got, "Bearer second")
+	}
+}
+
+func TestTransport_UntrustedServerFails(t *testing.T) {
+	dir := t.TempDir()
+	certPath, keyPath := selfSigned(t, dir)
+	serverCert, err := tls.LoadX509KeyPair(certPath, keyPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := grpc.NewServer(grpc.Creds(credentials.NewTLS(&tls.Config{Certificates: []tls.Certificate{serverCert}})))
+	pb.RegisterCodecServiceServer(srv, &handshakeServer{auth: make(chan string, 1)})
+	go srv.Serve(lis)
+	defer srv.Stop()
+
+	// System roots do not trust the self-signed certificate
+	client, err := NewCodecClientWithTransport(lis.Addr().String(), TransportConfig{TLS: true, ServerName: "codec.test"})
+	if err != nil {
+		t.Fatalf("NewCodecClientWithTransport: %v", err)
+	}
+	defer client.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if _, err := client.Handshake(ctx); err == nil {
+		t.Error("expected Handshake to fail against an untrusted certificate")
+	}
+}
+
+func TestTransportConfig_Validate(t *testing.T) {
+	cases := []struct {
+		name string
+		cfg  TransportConfig
+		ok   bool
+	}{
+		{"zero value", TransportConfig{}, true},
+		{"tls with token", TransportConfig{TLS: true, Token: "t"}, true},
+		{"ca implies tls", TransportConfig{CAFile: "ca.pem", Token: "t"}, true},
+		{"token in the clear", TransportConfig{Token: "t"}, false},
+		{"token file in the clear", TransportConfig{TokenFile: "token"}, false},
+		{"both token sources", TransportConfig{TLS: true, Token: "t", TokenFile: "token"}, false},
+		{"cert without key", TransportConfig{CertFile: "cert.pem"}, false},
+		{"keepalive too short", TransportConfig{KeepaliveTime: time.Second}, false},
+	}
+	for _, tc := range cases {
+		if err := tc.cfg.Validate(); (err == nil) != tc.ok {
+			t.Errorf("%s: Validate() = %v, want ok=%v", tc.name, err, tc.ok)
+		}
+	}
+}
+
+func TestTransportConfigFromEnv(t *testing.T) {
+	t.Setenv("CODEC_TLS", "1")
+	t.Setenv("CODEC_AUTH_TOKEN", "secret")
+	t.Setenv("CODEC_KEEPALIVE", "30")
+	cfg, err := TransportConfigFromEnv()
+	if err != nil {
+		t.Fatalf("TransportConfigFromEnv: %v", err)
+	}
+	if !cfg.TLS || cfg.Token != "secret" || cfg.KeepaliveTime != 30*time.Second {
+		t.Errorf("cfg = %+v", cfg)
+	}
+
+	t.Setenv("CODEC_KEEPALIVE", "soon")
+	if _, err := TransportConfigFromEnv(); err == nil {
+		t.Error("expected an error for a non-numeric CODEC_KEEPALIVE")
+	}
+}
+
+// #endregion tests
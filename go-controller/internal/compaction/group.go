@@ -0,0 +1,144 @@
+package compaction
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/injection"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/vecmath"
+)
+
+// #region config
+
+// Marker puts generation in summary mode: no tools, and a system prompt that
+// asks for one merged memory.
+const Marker = "[SUMMARY MODE]"
+
+// Config sets which evidence is compacted and how much per run.
+type Config struct {
+	MinAge     time.Duration // items younger than this are left alone (default 30 days)
+	Similarity float64       // cosine similarity to the group's oldest item to join it (default 0.9)
+	MinGroup   int           // smallest group worth a summary (default 3)
+	MaxGroup   int           // most items merged into one summary (default 12)
+	MaxGroups  int           // summaries generated per run at most (default 10)
+	Clip       int           // characters of each member shown to the model (default 600)
+}
+
+// DefaultConfig returns the compaction defaults.
+func DefaultConfig() Config {
+	return Config{MinAge: 30 * 24 * time.Hour, Similarity: 0.9, MinGroup: 3, MaxGroup: 12, MaxGroups: 10, Clip: 600}
+}
+
+func (c Config) withDefaults() Config {
+	def := DefaultConfig()
+	if c.MinAge <= 0 {
+		c.MinAge = def.MinAge
+	}
+	if c.Similarity <= 0 || c.Similarity > 1 {
+		c.Similarity = def.Similarity
+	}
+	if c.MinGroup < 2 {
+		c.MinGroup = def.MinGroup
+	}
+	if c.MaxGroup < c.MinGroup {
+		c.MaxGroup = max(def.MaxGroup, c.MinGroup)
+	}
+	if c.MaxGroups <= 0 {
+		c.MaxGroups = def.MaxGroups
+	}
+	if c.Clip <= 0 {
+		c.Clip = def.Clip
+	}
+	return c
+}
+
+// #endregion config
+
+// #region groups
+
+// Candidate is one evidence item considered for compaction. Items the caller
+// must not merge (pinned, already compacted, summaries) are left out of the
+// candidates, or marked Skip.
+type Candidate struct {
+	ID       string
+	Text     string
+	Vec      []float32
+	StoredAt time.Time // zero: age unknown, never compacted
+	Skip     bool
+}
+
+// Groups picks groups of near-duplicate old candidates, oldest first. Each
+// group is seeded by the oldest candidate not yet grouped and takes the later
+// ones at least cfg.Similarity to it, up to cfg.MaxGroup; seeds that gather
+// fewer than cfg.MinGroup form no group. At most cfg.MaxGroups are returned.
+func Groups(cands []Candidate, cfg Config, now time.Time) [][]Candidate {
+	cfg = cfg.withDefaults()
+	var eligible []Candidate
+	for _, c := range cands {
+		if !c.Skip && !c.StoredAt.IsZero() && now.Sub(c.StoredAt) >= cfg.MinAge && strings.TrimSpace(c.Text) != "" {
+			eligible = append(eligible, c)
+		}
+	}
+	sort.SliceStable(eligible, func(i, j int) bool {
+		if !eligible[i].StoredAt.Equal(eligible[j].StoredAt) {
+			return eligible[i].StoredAt.Before(eligible[j].StoredAt)
+		}
+		return eligible[i].ID < eligible[j].ID
+	})
+	unit := make([][]float64, len(eligible))
+	for i, c := range eligible {
+		unit[i] = vecmath.Normalize(c.Vec)
+	}
+
+	grouped := make([]bool, len(eligible))
+	var out [][]Candidate
+	for seed := range eligible {
+		if len(out) >= cfg.MaxGroups {
+			break
+		}
+		if grouped[seed] {
+			continue
+		}
+		members := []int{seed}
+		for j := seed + 1; j < len(eligible) && len(members) < cfg.MaxGroup; j++ {
+			if !grouped[j] && vecmath.Dot(unit[seed], unit[j]) >= cfg.Similarity {
+				members = append(members, j)
+			}
+		}
+		if len(members) < cfg.MinGroup {
+			continue
+		}
+		group := make([]Candidate, len(members))
+		for k, m := range members {
+			grouped[m] = true
+			group[k] = eligible[m]
+		}
+		out = append(out, group)
+	}
+	return out
+}
+
+// Prompt asks the model to merge group into one memory. Member texts are
+// screened for injected instructions and framed as quoted material, like any
+// recalled evidence.
+func Prompt(group []Candidate, cfg Config) string {
+	cfg = cfg.withDefaults()
+	lines := []string{
+		fmt.Sprintf("These %d memories, oldest first, say nearly the same thing. Merge them into one.", len(group)),
+		injection.FrameNotice, injection.FrameOpen,
+	}
+	for i, c := range group {
+		text, _ := injection.Screen(c.Text)
+		text = strings.Join(strings.Fields(text), " ")
+		if r := []rune(text); len(r) > cfg.Clip {
+			text = string(r[:cfg.Clip-1]) + "…"
+		}
+		lines = append(lines, fmt.Sprintf("[%d] (%s) %s", i+1, c.StoredAt.UTC().Format("2006-01-02"), text))
+	}
+	lines = append(lines, injection.FrameClose)
+	return strings.Join(lines, "\n")
+}
+
+// #endregion groups
@@ -0,0 +1,121 @@
+package compaction
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/timestamp"
+)
+
+// #region store
+
+// Store keeps which originals each summary replaced in evidence_compactions
+// and one row per run in compaction_runs.
+type Store struct {
+	db *sql.DB
+}
+
+// NewStore creates the compaction tables if needed and returns a store.
+func NewStore(db *sql.DB) (*Store, error) {
+	_, err := db.Exec(`CREATE TABLE IF NOT EXISTS compaction_runs (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		started_at TEXT NOT NULL,
+		groups INTEGER NOT NULL,
+		compacted INTEGER NOT NULL
+	)`)
+	if err != nil {
+		return nil, fmt.Errorf("create compaction_runs table: %w", err)
+	}
+	_, err = db.Exec(`CREATE TABLE IF NOT EXISTS evidence_compactions (
+		member_id TEXT PRIMARY KEY,
+		summary_id TEXT NOT NULL,
+		created_at TEXT NOT NULL
+	)`)
+	if err != nil {
+		return nil, fmt.Errorf("create evidence_compactions table: %w", err)
+	}
+	if _, err := db.Exec(`CREATE INDEX IF NOT EXISTS idx_compactions_summary ON evidence_compactions(summary_id)`); err != nil {
+		return nil, fmt.Errorf("create compactions index: %w", err)
+	}
+	if _, err := timestamp.Canonicalize(db, "compaction_runs", "started_at"); err != nil {
+		return nil, err
+	}
+	if _, err := timestamp.Canonicalize(db, "evidence_compactions", "created_at"); err != nil {
+		return nil, err
+	}
+	return &Store{db: db}, nil
+}
+
+// Record notes that summaryID replaced members, in one transaction.
+func (s *Store) Record(summaryID string, members []string) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("begin compaction record: %w", err)
+	}
+	defer tx.Rollback()
+	now := timestamp.Now()
+	for _, id := range members {
+		if _, err := tx.Exec(
+			`INSERT OR REPLACE INTO evidence_compactions (member_id, summary_id, created_at) VALUES (?, ?, ?)`,
+			id, summaryID, now,
+		); err != nil {
+			return fmt.Errorf("insert compaction member: %w", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit compaction record: %w", err)
+	}
+	return nil
+}
+
+// Members returns the originals summaryID replaced.
+func (s *Store) Members(summaryID string) ([]string, error) {
+	rows, err := s.db.Query(`SELECT member_id FROM evidence_compactions WHERE summary_id = ? ORDER BY member_id`, summaryID)
+	if err != nil {
+		return nil, fmt.Errorf("query compaction members: %w", err)
+	}
+	defer rows.Close()
+	var out []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		out = append(out, id)
+	}
+	return out, rows.Err()
+}
+
+// FinishRun records a run that started at startedAt.
+func (s *Store) FinishRun(startedAt time.Time, groups, compacted int) error {
+	if _, err := s.db.Exec(
+		`INSERT INTO compaction_runs (started_at, groups, compacted) VALUES (?, ?, ?)`,
+		timestamp.Format(startedAt), groups, compacted,
+	); err != nil {
+		return fmt.Errorf("insert compaction run: %w", err)
+	}
+	return nil
+}
+
+// Due reports whether a run is due at now: none recorded yet, or the last
+// one started at least interval ago. A non-positive interval never
+// schedules one.
+func (s *Store) Due(now time.Time, interval time.Duration) (bool, error) {
+	if interval <= 0 {
+		return false, nil
+	}
+	var startedAt string
+	err := s.db.QueryRow(`SELECT started_at FROM compaction_runs ORDER BY started_at DESC LIMIT 1`).Scan(&startedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return true, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("last compaction run: %w", err)
+	}
+	last, _ := timestamp.Parse(startedAt)
+	return now.Sub(last) >= interval, nil
+}
+
+// #endregion store
@@ -0,0 +1,255 @@
+package consolidation
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/evidence"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/injection"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/logging"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/state"
+)
+
+// #region config
+
+// Config sets what a consolidation cycle replays and how hard it acts.
+type Config struct {
+	Window        int     // newest provenance entries replayed at most (default 200)
+	MinRetrievals int     // turns an item (or pair) must be retrieved in to count as rehearsed (default 2)
+	EdgeBoost     float64 // weight added to the co_retrieval edge of each rehearsed pair (default 0.05)
+	DecayRate     float32 // per-element decay of segments no replayed turn hit (default 0.02; 0 disables)
+	Exchanges     int     // most recent exchanges shown to the consolidated reflection (default 8)
+	Clip          int     // characters of each prompt and response shown (default 300)
+}
+
+// DefaultConfig returns the consolidation defaults.
+func DefaultConfig() Config {
+	return Config{Window: 200, MinRetrievals: 2, EdgeBoost: 0.05, DecayRate: 0.02, Exchanges: 8, Clip: 300}
+}
+
+func (c Config) withDefaults() Config {
+	def := DefaultConfig()
+	if c.Window <= 0 {
+		c.Window = def.Window
+	}
+	if c.MinRetrievals < 2 {
+		c.MinRetrievals = def.MinRetrievals
+	}
+	if c.EdgeBoost <= 0 {
+		c.EdgeBoost = def.EdgeBoost
+	}
+	if c.DecayRate < 0 || c.DecayRate >= 1 {
+		c.DecayRate = def.DecayRate
+	}
+	if c.Exchanges <= 0 {
+		c.Exchanges = def.Exchanges
+	}
+	if c.Clip <= 0 {
+		c.Clip = def.Clip
+	}
+	return c
+}
+
+// #endregion config
+
+// #region replay
+
+// Marker puts generation in reflection mode, like the post-turn reflection.
+const Marker = "[REFLECTION MODE]"
+
+// Metadata keys written on rehearsed evidence. py-inference weighs recency
+// from the later of stored_at and rehearsed_at.
+const (
+	MetaRehearsedAt = "rehearsed_at" // RFC3339 time of the last cycle that rehearsed the item
+	MetaRehearsals  = "rehearsals"   // turns that retrieved it in that cycle's window
+)
+
+// Exchange is one replayed turn's prompt and response.
+type Exchange struct {
+	TurnID   string
+	Prompt   string
+	Response string
+}
+
+// Digest is what a replay of provenance found.
+type Digest struct {
+	FromID, ToID int64             // first and last provenance ID replayed
+	Turns        int               // user turns replayed
+	Decisions    map[string]int    // user turns per decision
+	Retrievals   map[string]int    // local evidence ID → turns that retrieved it
+	Pairs        map[[2]string]int // local ID pair, lower first → turns that retrieved both
+	SegmentsHit  map[string]int    // segment → committed turns whose update touched it
+	Exchanges    []Exchange        // committed, non-private turns, oldest first
+}
+
+// Replay reads entries, oldest first. Only user turns count; entries of other
+// trigger types (consolidation, compaction, manual, ...) only move FromID
+// and ToID. Private turns count their decision and nothing else.
+func Replay(entries []logging.ProvenanceEntry) Digest {
+	d := Digest{Decisions: map[string]int{}, Retrievals: map[string]int{}, Pairs: map[[2]string]int{}, SegmentsHit: map[string]int{}}
+	for _, e := range entries {
+		if d.FromID == 0 || e.ID < d.FromID {
+			d.FromID = e.ID
+		}
+		d.ToID = max(d.ToID, e.ID)
+		if e.TriggerType != "user_turn" {
+			continue
+		}
+		d.Turns++
+		d.Decisions[e.Decision]++
+		rec, _ := logging.ParseGateRecord(e.SignalsJSON)
+		if rec.Private {
+			continue
+		}
+
+		ids := localIDs(e.EvidenceRefs)
+		for i, a := range ids {
+			d.Retrievals[a]++
+			for _, b := range ids[i+1:] {
+				d.Pairs[[2]string{a, b}]++
+			}
+		}
+		if e.Decision == "commit" {
+			for _, seg := range rec.SegmentsHit {
+				d.SegmentsHit[seg]++
+			}
+			if rec.Prompt != "" && rec.Response != "" {
+				d.Exchanges = append(d.Exchanges, Exchange{TurnID: rec.TurnID, Prompt: rec.Prompt, Response: rec.Response})
+			}
+		}
+	}
+	return d
+}
+
+// localIDs returns the distinct local evidence IDs of a comma-separated
+// evidence_refs value, sorted.
+func localIDs(refs string) []string {
+	seen := map[string]bool{}
+	var out []string
+	for _, id := range strings.Split(refs, ",") {
+		id = strings.TrimSpace(id)
+		if evidence.IsLocalID(id) && !seen[id] {
+			seen[id] = true
+			out = append(out, id)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+// Rehearsed returns the items retrieved in at least cfg.MinRetrievals turns,
+// most retrieved first.
+func (d Digest) Rehearsed(cfg Config) []string {
+	cfg = cfg.withDefaults()
+	var out []string
+	for id, n := range d.Retrievals {
+		if n >= cfg.MinRetrievals {
+			out = append(out, id)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if d.Retrievals[out[i]] != d.Retrievals[out[j]] {
+			return d.Retrievals[out[i]] > d.Retrievals[out[j]]
+		}
+		return out[i] < out[j]
+	})
+	return out
+}
+
+// RehearsedPairs returns the pairs retrieved together in at least
+// cfg.MinRetrievals turns, sorted.
+func (d Digest) RehearsedPairs(cfg Config) [][2]string {
+	cfg = cfg.withDefaults()
+	var out [][2]string
+	for p, n := range d.Pairs {
+		if n >= cfg.MinRetrievals {
+			out = append(out, p)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i][0] != out[j][0] {
+			return out[i][0] < out[j][0]
+		}
+		return out[i][1] < out[j][1]
+	})
+	return out
+}
+
+// #endregion replay
+
+// #region decay
+
+// Decay shrinks every segment of v that no replayed turn hit by cfg.DecayRate
+// per element, the same pass a turn's update applies to unreinforced
+// segments, and returns the decayed vector and the names of the segments
+// that moved.
+func Decay(v [128]float32, m state.SegmentMap, d Digest, cfg Config) ([128]float32, []string) {
+	cfg = cfg.withDefaults()
+	var decayed []string
+	for _, name := range state.SegmentNames {
+		if d.SegmentsHit[name] > 0 || cfg.DecayRate == 0 {
+			continue
+		}
+		r, _ := state.SegmentRange(m, name)
+		moved := false
+		for i := max(r[0], 0); i < r[1] && i < len(v); i++ {
+			if v[i] != 0 {
+				v[i] -= v[i] * cfg.DecayRate
+				moved = true
+			}
+		}
+		if moved {
+			decayed = append(decayed, name)
+		}
+	}
+	return v, decayed
+}
+
+// DeltaNorm is the L2 distance between a and b.
+func DeltaNorm(a, b [128]float32) float32 {
+	var sq float64
+	for i := range a {
+		diff := float64(a[i] - b[i])
+		sq += diff * diff
+	}
+	return float32(math.Sqrt(sq))
+}
+
+// #endregion decay
+
+// #region prompt
+
+// Prompt asks for one reflection across the most recent replayed exchanges.
+// Exchanges are screened for injected instructions and framed as quoted
+// material, like any recalled evidence.
+func Prompt(d Digest, cfg Config) string {
+	cfg = cfg.withDefaults()
+	exchanges := d.Exchanges
+	if len(exchanges) > cfg.Exchanges {
+		exchanges = exchanges[len(exchanges)-cfg.Exchanges:]
+	}
+	lines := []string{
+		fmt.Sprintf("You are resting after %d exchanges with Commander. These are the last %d, oldest first.", d.Turns, len(exchanges)),
+		injection.FrameNotice, injection.FrameOpen,
+	}
+	for i, x := range exchanges {
+		lines = append(lines, fmt.Sprintf("[%d] Commander said: %s", i+1, clip(x.Prompt, cfg.Clip)))
+		lines = append(lines, fmt.Sprintf("    You responded: %s", clip(x.Response, cfg.Clip)))
+	}
+	lines = append(lines, injection.FrameClose,
+		"Looking back over all of them together: what keeps coming back, what changed in you, and what is still open? Be honest. Be brief.")
+	return strings.Join(lines, "\n")
+}
+
+func clip(text string, n int) string {
+	text, _ = injection.Screen(text)
+	text = strings.Join(strings.Fields(text), " ")
+	if r := []rune(text); len(r) > n {
+		text = string(r[:n-1]) + "…"
+	}
+	return text
+}
+
+// #endregion prompt
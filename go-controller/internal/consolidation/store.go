@@ -0,0 +1,93 @@
+package consolidation
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/state"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/timestamp"
+)
+
+// #region store
+
+// Run is one consolidation cycle as recorded in consolidation_runs.
+type Run struct {
+	ID           int64
+	StartedAt    time.Time
+	FromID       int64 // provenance replayed, inclusive
+	ToID         int64
+	Turns        int
+	Rehearsed    int    // evidence items re-scored
+	Strengthened int    // co_retrieval edges strengthened
+	Decayed      string // comma-separated segments decayed
+	VersionID    string // state version after the cycle
+	Reflection   bool   // a consolidated reflection was saved
+}
+
+// Store keeps one row per consolidation cycle in consolidation_runs.
+type Store struct {
+	db state.DBTX
+}
+
+// NewStore creates the consolidation_runs table if needed and returns a store.
+func NewStore(db *sql.DB) (*Store, error) {
+	_, err := db.Exec(`CREATE TABLE IF NOT EXISTS consolidation_runs (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		started_at TEXT NOT NULL,
+		from_id INTEGER NOT NULL,
+		to_id INTEGER NOT NULL,
+		turns INTEGER NOT NULL,
+		rehearsed INTEGER NOT NULL,
+		strengthened INTEGER NOT NULL,
+		decayed TEXT NOT NULL DEFAULT '',
+		version_id TEXT NOT NULL,
+		reflection INTEGER NOT NULL DEFAULT 0
+	)`)
+	if err != nil {
+		return nil, fmt.Errorf("create consolidation_runs table: %w", err)
+	}
+	if _, err := timestamp.Canonicalize(db, "consolidation_runs", "started_at"); err != nil {
+		return nil, err
+	}
+	return &Store{db: db}, nil
+}
+
+// WithTx returns a copy of the store bound to tx, so a run is recorded with
+// the state commit and provenance of its cycle.
+func (s *Store) WithTx(tx *sql.Tx) *Store {
+	return &Store{db: tx}
+}
+
+// Record inserts r.
+func (s *Store) Record(r Run) error {
+	if _, err := s.db.Exec(
+		`INSERT INTO consolidation_runs (started_at, from_id, to_id, turns, rehearsed, strengthened, decayed, version_id, reflection)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		timestamp.Format(r.StartedAt), r.FromID, r.ToID, r.Turns, r.Rehearsed, r.Strengthened, r.Decayed, r.VersionID, r.Reflection,
+	); err != nil {
+		return fmt.Errorf("insert consolidation run: %w", err)
+	}
+	return nil
+}
+
+// Last returns the latest run, or nil if none was recorded.
+func (s *Store) Last() (*Run, error) {
+	var r Run
+	var startedAt string
+	err := s.db.QueryRow(
+		`SELECT id, started_at, from_id, to_id, turns, rehearsed, strengthened, decayed, version_id, reflection
+		FROM consolidation_runs ORDER BY id DESC LIMIT 1`,
+	).Scan(&r.ID, &startedAt, &r.FromID, &r.ToID, &r.Turns, &r.Rehearsed, &r.Strengthened, &r.Decayed, &r.VersionID, &r.Reflection)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("last consolidation run: %w", err)
+	}
+	r.StartedAt, _ = timestamp.Parse(startedAt)
+	return &r, nil
+}
+
+// #endregion store
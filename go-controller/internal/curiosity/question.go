@@ -0,0 +1,117 @@
+package curiosity
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/injection"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/interior"
+)
+
+// #region questions
+
+// maxQuestionLen caps a queued question; longer sentences are run-ons, not questions.
+const maxQuestionLen = 300
+
+// Questions returns the sentences of a reflection that carry a curiosity
+// signal (interior.ExtractCuriosity), in order, without duplicates.
+func Questions(reflection string) []string {
+	seen := map[string]bool{}
+	var out []string
+	for _, s := range sentences(reflection) {
+		if len(interior.ExtractCuriosity(s)) == 0 || len(s) > maxQuestionLen {
+			continue
+		}
+		if key := strings.ToLower(strings.TrimRight(s, ".!?")); !seen[key] {
+			seen[key] = true
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// sentences splits text at sentence punctuation followed by a space and at
+// line breaks, collapsing whitespace.
+func sentences(text string) []string {
+	var out []string
+	var b strings.Builder
+	flush := func() {
+		if s := strings.Join(strings.Fields(b.String()), " "); s != "" {
+			out = append(out, s)
+		}
+		b.Reset()
+	}
+	rs := []rune(text)
+	for i, r := range rs {
+		if r == '\n' {
+			flush()
+			continue
+		}
+		b.WriteRune(r)
+		if (r == '.' || r == '!' || r == '?') && (i+1 == len(rs) || rs[i+1] == ' ' || rs[i+1] == '\n') {
+			flush()
+		}
+	}
+	flush()
+	return out
+}
+
+// Query is what a question is searched for: the words after its first
+// curiosity phrase ("I wonder whether octopuses dream." → "octopuses
+// dream"), or the whole question when too little follows the phrase.
+func Query(question string) string {
+	lower := strings.ToLower(question)
+	first, start := -1, -1
+	for _, t := range interior.ExtractCuriosity(question) {
+		if i := strings.Index(lower, t); i >= 0 && (first < 0 || i < first) {
+			first, start = i, i+len(t)
+		}
+	}
+	trim := func(s string) string { return strings.Trim(s, " ,;:.!?\"'") }
+	if start >= 0 {
+		words := strings.Fields(trim(lower[start:]))
+		for len(words) > 0 && (words[0] == "whether" || words[0] == "if" || words[0] == "about") {
+			words = words[1:]
+		}
+		if len(words) >= 2 {
+			return strings.Join(words, " ")
+		}
+	}
+	return trim(lower)
+}
+
+// #endregion questions
+
+// #region prompt
+
+// Source is one web search result shown to the model.
+type Source struct {
+	Title   string
+	Snippet string
+	URL     string
+}
+
+// Prompt asks the model what sources say about item's question. Results are
+// screened for injected instructions and framed as quoted material, like any
+// recalled evidence.
+func Prompt(item Item, sources []Source, cfg Config) string {
+	cfg = cfg.withDefaults()
+	lines := []string{
+		fmt.Sprintf("Earlier you wondered: %q", item.Question),
+		"You searched the web for it. Write down what you found out, in a few sentences of your own. " +
+			"Say plainly if the results don't settle it.",
+		injection.FrameNotice, injection.FrameOpen,
+	}
+	for i, s := range sources {
+		text, _ := injection.Screen(s.Title + " — " + s.Snippet)
+		text = strings.Join(strings.Fields(text), " ")
+		if r := []rune(text); len(r) > cfg.Clip {
+			text = string(r[:cfg.Clip-1]) + "…"
+		}
+		lines = append(lines, fmt.Sprintf("[%d] %s (%s)", i+1, text, s.URL))
+	}
+	lines = append(lines, injection.FrameClose)
+	return strings.Join(lines, "\n")
+}
+
+// #endregion prompt
@@ -0,0 +1,208 @@
+package curiosity
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/state"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/timestamp"
+)
+
+// #region config
+
+// Config sets how questions are explored.
+type Config struct {
+	SearchResults int // web results fetched per question (default 3)
+	MaxAttempts   int // failed explorations before a question is given up (default 3)
+	Clip          int // characters of each result snippet shown to the model (default 400)
+}
+
+// DefaultConfig returns the exploration defaults.
+func DefaultConfig() Config {
+	return Config{SearchResults: 3, MaxAttempts: 3, Clip: 400}
+}
+
+func (c Config) withDefaults() Config {
+	def := DefaultConfig()
+	if c.SearchResults <= 0 {
+		c.SearchResults = def.SearchResults
+	}
+	if c.MaxAttempts <= 0 {
+		c.MaxAttempts = def.MaxAttempts
+	}
+	if c.Clip <= 0 {
+		c.Clip = def.Clip
+	}
+	return c
+}
+
+// #endregion config
+
+// #region queue
+
+// Queue states.
+const (
+	StatusPending  = "pending"
+	StatusExplored = "explored"
+	StatusFailed   = "failed" // MaxAttempts explorations failed; not retried
+)
+
+// Item is one open question from a reflection.
+type Item struct {
+	ID        int64
+	TurnID    string
+	Question  string // the reflection's sentence
+	Query     string // what is searched for
+	SourceID  string // evidence stored from the turn, empty if none
+	Status    string
+	Attempts  int
+	LastError string
+	FindingID string // evidence the exploration stored
+	CreatedAt time.Time
+}
+
+// Store persists the curiosity queue in curiosity_queue.
+type Store struct {
+	db state.DBTX
+}
+
+// NewStore creates the curiosity_queue table if needed and returns a store.
+func NewStore(db *sql.DB) (*Store, error) {
+	_, err := db.Exec(`CREATE TABLE IF NOT EXISTS curiosity_queue (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		turn_id TEXT NOT NULL,
+		question TEXT NOT NULL,
+		query TEXT NOT NULL,
+		source_id TEXT NOT NULL DEFAULT '',
+		status TEXT NOT NULL DEFAULT 'pending',
+		attempts INTEGER NOT NULL DEFAULT 0,
+		last_error TEXT NOT NULL DEFAULT '',
+		finding_id TEXT NOT NULL DEFAULT '',
+		created_at TEXT NOT NULL,
+		explored_at TEXT NOT NULL DEFAULT ''
+	)`)
+	if err != nil {
+		return nil, fmt.Errorf("create curiosity_queue table: %w", err)
+	}
+	if _, err := db.Exec(`CREATE INDEX IF NOT EXISTS idx_curiosity_status ON curiosity_queue(status, id)`); err != nil {
+		return nil, fmt.Errorf("create curiosity index: %w", err)
+	}
+	if _, err := timestamp.Canonicalize(db, "curiosity_queue", "created_at"); err != nil {
+		return nil, err
+	}
+	return &Store{db: db}, nil
+}
+
+// WithTx returns a copy of the store bound to tx, for writes that must land
+// in the same transaction as other turn writes.
+func (s *Store) WithTx(tx *sql.Tx) *Store {
+	return &Store{db: tx}
+}
+
+// Enqueue queues the questions from turnID's reflection and returns how many
+// were new. A question already in the queue, in any state, case and spacing
+// aside, is not queued again.
+func (s *Store) Enqueue(turnID, sourceID string, questions []string) (int, error) {
+	queued := 0
+	for _, q := range questions {
+		q = strings.Join(strings.Fields(q), " ")
+		if q == "" {
+			continue
+		}
+		var n int
+		if err := s.db.QueryRow(`SELECT COUNT(*) FROM curiosity_queue WHERE lower(question) = lower(?)`, q).Scan(&n); err != nil {
+			return queued, fmt.Errorf("check curiosity question: %w", err)
+		}
+		if n > 0 {
+			continue
+		}
+		if _, err := s.db.Exec(
+			`INSERT INTO curiosity_queue (turn_id, question, query, source_id, created_at) VALUES (?, ?, ?, ?, ?)`,
+			turnID, q, Query(q), sourceID, timestamp.Now(),
+		); err != nil {
+			return queued, fmt.Errorf("insert curiosity question: %w", err)
+		}
+		queued++
+	}
+	return queued, nil
+}
+
+// Pending returns up to limit pending questions, oldest first.
+func (s *Store) Pending(limit int) ([]Item, error) {
+	rows, err := s.db.Query(
+		`SELECT id, turn_id, question, query, source_id, status, attempts, last_error, finding_id, created_at
+		FROM curiosity_queue WHERE status = ? ORDER BY id LIMIT ?`, StatusPending, limit)
+	if err != nil {
+		return nil, fmt.Errorf("query curiosity queue: %w", err)
+	}
+	defer rows.Close()
+	var out []Item
+	for rows.Next() {
+		var it Item
+		var createdAt string
+		if err := rows.Scan(&it.ID, &it.TurnID, &it.Question, &it.Query, &it.SourceID, &it.Status,
+			&it.Attempts, &it.LastError, &it.FindingID, &createdAt); err != nil {
+			return nil, fmt.Errorf("scan curiosity item: %w", err)
+		}
+		it.CreatedAt, _ = timestamp.Parse(createdAt)
+		out = append(out, it)
+	}
+	return out, rows.Err()
+}
+
+// Found records the evidence an exploration of item id stored, before the
+// item is marked explored. A retry after a failed Explored reuses it instead
+// of storing a second finding.
+func (s *Store) Found(id int64, findingID string) error {
+	if _, err := s.db.Exec(`UPDATE curiosity_queue SET finding_id = ? WHERE id = ?`, findingID, id); err != nil {
+		return fmt.Errorf("record curiosity finding: %w", err)
+	}
+	return nil
+}
+
+// Explored records that item id was answered by the evidence findingID.
+func (s *Store) Explored(id int64, findingID string) error {
+	if _, err := s.db.Exec(
+		`UPDATE curiosity_queue SET status = ?, finding_id = ?, last_error = '', explored_at = ? WHERE id = ?`,
+		StatusExplored, findingID, timestamp.Now(), id,
+	); err != nil {
+		return fmt.Errorf("mark curiosity explored: %w", err)
+	}
+	return nil
+}
+
+// Failed records a failed exploration of item id. After maxAttempts failures
+// the question is marked failed and no longer returned by Pending.
+func (s *Store) Failed(id int64, cause error, maxAttempts int) error {
+	if _, err := s.db.Exec(
+		`UPDATE curiosity_queue SET attempts = attempts + 1, last_error = ?,
+		status = CASE WHEN attempts + 1 >= ? THEN ? ELSE status END WHERE id = ?`,
+		cause.Error(), maxAttempts, StatusFailed, id,
+	); err != nil {
+		return fmt.Errorf("mark curiosity failed: %w", err)
+	}
+	return nil
+}
+
+// Counts returns the number of questions in each state.
+func (s *Store) Counts() (map[string]int, error) {
+	rows, err := s.db.Query(`SELECT status, COUNT(*) FROM curiosity_queue GROUP BY status`)
+	if err != nil {
+		return nil, fmt.Errorf("count curiosity queue: %w", err)
+	}
+	defer rows.Close()
+	out := map[string]int{}
+	for rows.Next() {
+		var status string
+		var n int
+		if err := rows.Scan(&status, &n); err != nil {
+			return nil, err
+		}
+		out[status] = n
+	}
+	return out, rows.Err()
+}
+
+// #endregion queue
@@ -0,0 +1,16 @@
+package dot
+
+import "strings"
+
+// #region quote
+
+// Quote quotes s as a Graphviz DOT string; newlines become centred line
+// breaks. Shared by the evidence graph and version lineage exporters.
+func Quote(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	s = strings.ReplaceAll(s, "\r", "")
+	return `"` + strings.ReplaceAll(s, "\n", `\n`) + `"`
+}
+
+// #endregion quote
@@ -0,0 +1,18 @@
+package dot
+
+import "testing"
+
+func TestQuote(t *testing.T) {
+	cases := map[string]string{
+		"plain":        `"plain"`,
+		`say "hi"`:     `"say \"hi\""`,
+		`C:\dir`:       `"C:\\dir"`,
+		"two\r\nlines": `"two\nlines"`,
+		`\"already"`:   `"\\\"already\""`,
+	}
+	for in, want := range cases {
+		if got := Quote(in); got != want {
+			t.Errorf("Quote(%q) = %s, want %s", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,443 @@
+package dualwrite
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	pb "github.com/danielpatrickdp/adaptive-state/go-controller/gen/adaptive"
+	"google.golang.org/grpc"
+)
+
+// #region codec
+
+// Config tunes the dual-write wrapper.
+type Config struct {
+	Reads      string        // ReadsPrimary or ReadsShadow (Store.Reads)
+	Timeout    time.Duration // bound on each call to the non-serving backend; default 5s
+	KeepChecks int           // newest checks kept in evidence_shadow_checks; default 10000
+}
+
+// pruneEvery is how many checks are recorded between prunes.
+const pruneEvery = 500
+
+// Codec serves evidence from one backend (Config.Reads) and dual-writes to the
+// other: a write goes to the serving backend first, whose failure fails the
+// call and whose ID names the new evidence, and then to the other, whose
+// failure is only recorded. Reads compare the other backend's answer in the
+// background and log every discrepancy. Callers see one evidence ID
+// throughout, translated through the Store's map to each backend's own ID in
+// both directions. Generate, GenerateStream, Embed, WebSearch and Handshake
+// only ever reach the primary.
+type Codec struct {
+	primary pb.CodecServiceClient
+	shadow  pb.CodecServiceClient
+	store   *Store
+	cfg     Config
+	wg      sync.WaitGroup
+	checks  atomic.Int64 // recorded checks, for pruning
+}
+
+// Wrap returns a wrapper for codec.CodecClient.WrapService that dual-writes
+// evidence to shadow.
+func Wrap(shadow pb.CodecServiceClient, store *Store, cfg Config) func(pb.CodecServiceClient) pb.CodecServiceClient {
+	return func(primary pb.CodecServiceClient) pb.CodecServiceClient {
+		return New(primary, shadow, store, cfg)
+	}
+}
+
+// New returns the dual-write wrapper over primary and shadow.
+func New(primary, shadow pb.CodecServiceClient, store *Store, cfg Config) *Codec {
+	if cfg.Reads == "" {
+		cfg.Reads = ReadsPrimary
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 5 * time.Second
+	}
+	if cfg.KeepChecks <= 0 {
+		cfg.KeepChecks = 10000
+	}
+	return &Codec{primary: primary, shadow: shadow, store: store, cfg: cfg}
+}
+
+// Wait blocks until background comparisons finish.
+func (c *Codec) Wait() {
+	c.wg.Wait()
+}
+
+func (c *Codec) Generate(ctx context.Context, in *pb.GenerateRequest, opts ...grpc.CallOption) (*pb.GenerateResponse, error) {
+	return c.primary.Generate(ctx, in, opts...)
+}
+
+func (c *Codec) GenerateStream(ctx context.Context, in *pb.GenerateRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[pb.GenerateChunk], error) {
+	return c.primary.GenerateStream(ctx, in, opts...)
+}
+
+func (c *Codec) Embed(ctx context.Context, in *pb.EmbedRequest, opts ...grpc.CallOption) (*pb.EmbedResponse, error) {
+	return c.primary.Embed(ctx, in, opts...)
+}
+
+func (c *Codec) EmbedBatch(ctx context.Context, in *pb.EmbedBatchRequest, opts ...grpc.CallOption) (*pb.EmbedBatchResponse, error) {
+	return c.primary.EmbedBatch(ctx, in, opts...)
+}
+
+func (c *Codec) WebSearch(ctx context.Context, in *pb.WebSearchRequest, opts ...grpc.CallOption) (*pb.WebSearchResponse, error) {
+	return c.primary.WebSearch(ctx, in, opts...)
+}
+
+func (c *Codec) Handshake(ctx context.Context, in *pb.HandshakeRequest, opts ...grpc.CallOption) (*pb.HandshakeResponse, error) {
+	return c.primary.Handshake(ctx, in, opts...)
+}
+
+// StoreEvidence writes to the serving backend, whose ID is returned, then to
+// the other one. A failed second write is recorded and logged but never fails
+// the call.
+func (c *Codec) StoreEvidence(ctx context.Context, in *pb.StoreEvidenceRequest, opts ...grpc.CallOption) (*pb.StoreEvidenceResponse, error) {
+	serving, other := c.backends()
+	resp, err := c.client(serving).StoreEvidence(ctx, in, opts...)
+	if err != nil {
+		return nil, err
+	}
+	octx, cancel := c.bounded(ctx)
+	defer cancel()
+	otherResp, err := c.client(other).StoreEvidence(octx, in, opts...)
+	if err == nil {
+		primaryID, shadowID := resp.Id, otherResp.Id
+		if serving == ReadsShadow {
+			primaryID, shadowID = shadowID, primaryID
+		}
+		err = c.store.Map(resp.Id, primaryID, shadowID)
+	}
+	if err != nil {
+		c.record("store", OutcomeError, fmt.Sprintf("%s: %s write: %v", resp.Id, other, err))
+	}
+	return resp, nil
+}
+
+// DeleteEvidence deletes from both, the serving backend first; its count is
+// returned.
+func (c *Codec) DeleteEvidence(ctx context.Context, in *pb.DeleteEvidenceRequest, opts ...grpc.CallOption) (*pb.DeleteEvidenceResponse, error) {
+	serving, other := c.backends()
+	ids, err := c.toBackend(serving, in.Ids)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.client(serving).DeleteEvidence(ctx, &pb.DeleteEvidenceRequest{Ids: ids}, opts...)
+	if err != nil {
+		return nil, err
+	}
+	ids, err = c.toBackend(other, in.Ids)
+	if err == nil {
+		octx, cancel := c.bounded(ctx)
+		_, err = c.client(other).DeleteEvidence(octx, &pb.DeleteEvidenceRequest{Ids: ids}, opts...)
+		cancel()
+	}
+	if err == nil {
+		err = c.store.Unmap(in.Ids)
+	}
+	if err != nil {
+		c.record("delete", OutcomeError, fmt.Sprintf("%d id(s): %s delete: %v", len(in.Ids), other, err))
+	}
+	return resp, nil
+}
+
+// UpdateEvidenceMetadata updates both, the serving backend first; its answer
+// is returned.
+func (c *Codec) UpdateEvidenceMetadata(ctx context.Context, in *pb.UpdateEvidenceMetadataRequest, opts ...grpc.CallOption) (*pb.UpdateEvidenceMetadataResponse, error) {
+	serving, other := c.backends()
+	ids, err := c.toBackend(serving, []string{in.Id})
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.client(serving).UpdateEvidenceMetadata(ctx, &pb.UpdateEvidenceMetadataRequest{Id: ids[0], MetadataJson: in.MetadataJson}, opts...)
+	if err != nil {
+		return nil, err
+	}
+	ids, err = c.toBackend(other, []string{in.Id})
+	if err == nil {
+		octx, cancel := c.bounded(ctx)
+		_, err = c.client(other).UpdateEvidenceMetadata(octx, &pb.UpdateEvidenceMetadataRequest{Id: ids[0], MetadataJson: in.MetadataJson}, opts...)
+		cancel()
+	}
+	if err != nil {
+		c.record("update", OutcomeError, fmt.Sprintf("%s: %s update: %v", in.Id, other, err))
+	}
+	return resp, nil
+}
+
+func (c *Codec) Search(ctx context.Context, in *pb.SearchRequest, opts ...grpc.CallOption) (*pb.SearchResponse, error) {
+	results, err := c.read(ctx, "search", func(ctx context.Context, svc pb.CodecServiceClient, _ string) ([]*pb.SearchResult, error) {
+		resp, err := svc.Search(ctx, in, opts...)
+		if err != nil {
+			return nil, err
+		}
+		return resp.Results, nil
+	}, compareIDs)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.SearchResponse{Results: results}, nil
+}
+
+func (c *Codec) GetByIDs(ctx context.Context, in *pb.GetByIDsRequest, opts ...grpc.CallOption) (*pb.GetByIDsResponse, error) {
+	results, err := c.read(ctx, "get_by_ids", func(ctx context.Context, svc pb.CodecServiceClient, backend string) ([]*pb.SearchResult, error) {
+		ids, err := c.toBackend(backend, in.Ids)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := svc.GetByIDs(ctx, &pb.GetByIDsRequest{Ids: ids}, opts...)
+		if err != nil {
+			return nil, err
+		}
+		return resp.Results, nil
+	}, compareTexts)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.GetByIDsResponse{Results: results}, nil
+}
+
+func (c *Codec) ListAllEvidence(ctx context.Context, in *pb.ListAllEvidenceRequest, opts ...grpc.CallOption) (*pb.ListAllEvidenceResponse, error) {
+	results, err := c.read(ctx, "list_all", func(ctx context.Context, svc pb.CodecServiceClient, _ string) ([]*pb.SearchResult, error) {
+		resp, err := svc.ListAllEvidence(ctx, in, opts...)
+		if err != nil {
+			return nil, err
+		}
+		return resp.Results, nil
+	}, compareIDs)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.ListAllEvidenceResponse{Results: results}, nil
+}
+
+// readFunc runs one read against svc, the backend named backend, whose own IDs
+// request IDs are translated to.
+type readFunc func(ctx context.Context, svc pb.CodecServiceClient, backend string) ([]*pb.SearchResult, error)
+
+// read serves op from the configured backend and compares the other one in the
+// background. When the shadow serves and fails, the primary answers instead.
+func (c *Codec) read(ctx context.Context, op string, call readFunc, compare func(serving, other []*pb.SearchResult) string) ([]*pb.SearchResult, error) {
+	from, to := c.backends()
+	serving, err := c.readFrom(ctx, call, from)
+	if err != nil && from == ReadsShadow {
+		c.record(op, OutcomeError, fmt.Sprintf("shadow read failed, served by primary: %v", err))
+		return c.readFrom(ctx, call, ReadsPrimary)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	bg := context.WithoutCancel(ctx)
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+		octx, cancel := context.WithTimeout(bg, c.cfg.Timeout)
+		defer cancel()
+		other, err := c.readFrom(octx, call, to)
+		switch {
+		case err != nil:
+			c.record(op, OutcomeError, fmt.Sprintf("%s read failed: %v", to, err))
+		default:
+			if diff := compare(serving, other); diff != "" {
+				c.record(op, OutcomeMismatch, diff)
+			} else {
+				c.record(op, OutcomeMatch, "")
+			}
+		}
+	}()
+	return serving, nil
+}
+
+// readFrom runs call against backend and returns results with evidence IDs.
+// Calls to the shadow are bounded by Config.Timeout.
+func (c *Codec) readFrom(ctx context.Context, call readFunc, backend string) ([]*pb.SearchResult, error) {
+	if backend == ReadsShadow {
+		sctx, cancel := c.bounded(ctx)
+		defer cancel()
+		ctx = sctx
+	}
+	results, err := call(ctx, c.client(backend), backend)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]string, len(results))
+	for i, r := range results {
+		ids[i] = r.Id
+	}
+	evidenceIDs, err := c.store.EvidenceIDs(backend, ids)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]*pb.SearchResult, len(results))
+	for i, r := range results {
+		out[i] = &pb.SearchResult{Id: r.Id, Text: r.Text, Score: r.Score, MetadataJson: r.MetadataJson}
+		if id, ok := evidenceIDs[r.Id]; ok {
+			out[i].Id = id
+		}
+	}
+	return out, nil
+}
+
+// backends returns the backend serving evidence and the other one.
+func (c *Codec) backends() (serving, other string) {
+	if c.cfg.Reads == ReadsShadow {
+		return ReadsShadow, ReadsPrimary
+	}
+	return ReadsPrimary, ReadsShadow
+}
+
+func (c *Codec) client(backend string) pb.CodecServiceClient {
+	if backend == ReadsShadow {
+		return c.shadow
+	}
+	return c.primary
+}
+
+// toBackend translates evidence IDs to backend's own IDs; unmapped IDs pass
+// through.
+func (c *Codec) toBackend(backend string, ids []string) ([]string, error) {
+	m, err := c.store.BackendIDs(backend, ids)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]string, len(ids))
+	for i, id := range ids {
+		out[i] = id
+		if sid, ok := m[id]; ok {
+			out[i] = sid
+		}
+	}
+	return out, nil
+}
+
+func (c *Codec) bounded(ctx context.Context) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(ctx, c.cfg.Timeout)
+}
+
+func (c *Codec) record(op, outcome, detail string) {
+	if outcome != OutcomeMatch {
+		log.Printf("evidence dual-write: %s %s: %s", op, outcome, detail)
+	}
+	if err := c.store.RecordCheck(Check{Op: op, Outcome: outcome, Detail: detail}); err != nil {
+		log.Printf("evidence dual-write: %v", err)
+	}
+	if c.checks.Add(1)%pruneEvery == 0 {
+		if _, err := c.store.PruneChecks(c.cfg.KeepChecks); err != nil {
+			log.Printf("evidence dual-write: %v", err)
+		}
+	}
+}
+
+// #endregion codec
+
+// #region backfill
+
+// BackfillResult counts what Backfill did.
+type BackfillResult struct {
+	Total   int // evidence in the primary
+	Copied  int // written to the shadow now
+	Skipped int // already mapped
+}
+
+// Backfill copies every primary document without a shadow copy to the shadow,
+// so shadow reads can match before the shadow has seen every write. It is safe
+// to re-run: mapped documents are skipped.
+func Backfill(ctx context.Context, primary, shadow pb.CodecServiceClient, store *Store) (BackfillResult, error) {
+	all, err := primary.ListAllEvidence(ctx, &pb.ListAllEvidenceRequest{})
+	if err != nil {
+		return BackfillResult{}, fmt.Errorf("list primary evidence: %w", err)
+	}
+	res := BackfillResult{Total: len(all.Results)}
+	ids := make([]string, len(all.Results))
+	for i, r := range all.Results {
+		ids[i] = r.Id
+	}
+	mapped, err := store.EvidenceIDs(ReadsPrimary, ids)
+	if err != nil {
+		return res, err
+	}
+	for _, r := range all.Results {
+		if _, ok := mapped[r.Id]; ok {
+			res.Skipped++
+			continue
+		}
+		resp, err := shadow.StoreEvidence(ctx, &pb.StoreEvidenceRequest{Text: r.Text, MetadataJson: r.MetadataJson})
+		if err != nil {
+			return res, fmt.Errorf("copy %s to shadow: %w", r.Id, err)
+		}
+		if err := store.Map(r.Id, r.Id, resp.Id); err != nil {
+			return res, err
+		}
+		res.Copied++
+	}
+	return res, nil
+}
+
+// #endregion backfill
+
+// #region compare
+
+// compareIDs compares result sets by ID, ignoring order and scores, which vary
+// between vector stores.
+func compareIDs(serving, other []*pb.SearchResult) string {
+	have := map[string]bool{}
+	for _, r := range serving {
+		have[r.Id] = true
+	}
+	var onlyOther []string
+	for _, r := range other {
+		if !have[r.Id] {
+			onlyOther = append(onlyOther, r.Id)
+		}
+		delete(have, r.Id)
+	}
+	var onlyServing []string
+	for id := range have {
+		onlyServing = append(onlyServing, id)
+	}
+	if len(onlyServing) == 0 && len(onlyOther) == 0 {
+		return ""
+	}
+	sort.Strings(onlyServing)
+	return fmt.Sprintf("%d vs %d results; only served: %s; only compared: %s",
+		len(serving), len(other), idList(onlyServing), idList(onlyOther))
+}
+
+// compareTexts compares results by ID and text.
+func compareTexts(serving, other []*pb.SearchResult) string {
+	if diff := compareIDs(serving, other); diff != "" {
+		return diff
+	}
+	texts := make(map[string]string, len(other))
+	for _, r := range other {
+		texts[r.Id] = r.Text
+	}
+	var differ []string
+	for _, r := range serving {
+		if texts[r.Id] != r.Text {
+			differ = append(differ, r.Id)
+		}
+	}
+	if len(differ) == 0 {
+		return ""
+	}
+	return "text differs: " + idList(differ)
+}
+
+func idList(ids []string) string {
+	if len(ids) == 0 {
+		return "none"
+	}
+	if len(ids) > 5 {
+		return fmt.Sprintf("%s and %d more", strings.Join(ids[:5], ", "), len(ids)-5)
+	}
+	return strings.Join(ids, ", ")
+}
+
+// #endregion compare
@@ -0,0 +1,308 @@
+package dualwrite
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+
+	pb "github.com/danielpatrickdp/adaptive-state/go-controller/gen/adaptive"
+	"google.golang.org/grpc"
+)
+
+// #region mock
+
+// memBackend is an in-memory evidence store. Search returns every document
+// containing the query text.
+type memBackend struct {
+	pb.CodecServiceClient
+	prefix string
+	docs   map[string]string
+	meta   map[string]string // last metadata patch per ID
+	next   int
+	fail   bool
+}
+
+func newMem(prefix string) *memBackend {
+	return &memBackend{prefix: prefix, docs: map[string]string{}, meta: map[string]string{}}
+}
+
+var errDown = errors.New("backend down")
+
+func (m *memBackend) StoreEvidence(_ context.Context, in *pb.StoreEvidenceRequest, _ ...grpc.CallOption) (*pb.StoreEvidenceResponse, error) {
+	if m.fail {
+		return nil, errDown
+	}
+	m.next++
+	id := fmt.Sprintf("%s%d", m.prefix, m.next)
+	m.docs[id] = in.Text
+	return &pb.StoreEvidenceResponse{Id: id}, nil
+}
+
+func (m *memBackend) DeleteEvidence(_ context.Context, in *pb.DeleteEvidenceRequest, _ ...grpc.CallOption) (*pb.DeleteEvidenceResponse, error) {
+	if m.fail {
+		return nil, errDown
+	}
+	n := 0
+	for _, id := range in.Ids {
+		if _, ok := m.docs[id]; ok {
+			delete(m.docs, id)
+			n++
+		}
+	}
+	return &pb.DeleteEvidenceResponse{DeletedCount: int32(n)}, nil
+}
+
+func (m *memBackend) UpdateEvidenceMetadata(_ context.Context, in *pb.UpdateEvidenceMetadataRequest, _ ...grpc.CallOption) (*pb.UpdateEvidenceMetadataResponse, error) {
+	if m.fail {
+		return nil, errDown
+	}
+	if _, ok := m.docs[in.Id]; !ok {
+		return &pb.UpdateEvidenceMetadataResponse{}, nil
+	}
+	m.meta[in.Id] = in.MetadataJson
+	return &pb.UpdateEvidenceMetadataResponse{Found: true, MetadataJson: in.MetadataJson}, nil
+}
+
+func (m *memBackend) all() []*pb.SearchResult {
+	var out []*pb.SearchResult
+	for id, text := range m.docs {
+		out = append(out, &pb.SearchResult{Id: id, Text: text})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Id < out[j].Id })
+	return out
+}
+
+func (m *memBackend) Search(_ context.Context, in *pb.SearchRequest, _ ...grpc.CallOption) (*pb.SearchResponse, error) {
+	if m.fail {
+		return nil, errDown
+	}
+	var out []*pb.SearchResult
+	for _, r := range m.all() {
+		if strings.Contains(r.Text, in.QueryText) {
+			out = append(out, r)
+		}
+	}
+	return &pb.SearchResponse{Results: out}, nil
+}
+
+func (m *memBackend) GetByIDs(_ context.Context, in *pb.GetByIDsRequest, _ ...grpc.CallOption) (*pb.GetByIDsResponse, error) {
+	if m.fail {
+		return nil, errDown
+	}
+	var out []*pb.SearchResult
+	for _, id := range in.Ids {
+		if text, ok := m.docs[id]; ok {
+			out = append(out, &pb.SearchResult{Id: id, Text: text})
+		}
+	}
+	return &pb.GetByIDsResponse{Results: out}, nil
+}
+
+func (m *memBackend) ListAllEvidence(context.Context, *pb.ListAllEvidenceRequest, ...grpc.CallOption) (*pb.ListAllEvidenceResponse, error) {
+	if m.fail {
+		return nil, errDown
+	}
+	return &pb.ListAllEvidenceResponse{Results: m.all()}, nil
+}
+
+// #endregion mock
+
+// #region codec-tests
+
+func TestCodec_DualWriteAndShadowRead(t *testing.T) {
+	store := testStore(t)
+	primary, shadow := newMem("ev_"), newMem("sh_")
+	c := New(primary, shadow, store, Config{})
+	ctx := context.Background()
+
+	var ids []string
+	for _, text := range []string{"cache decision", "cache size", "lunch"} {
+		resp, err := c.StoreEvidence(ctx, &pb.StoreEvidenceRequest{Text: text})
+		if err != nil {
+			t.Fatalf("store: %v", err)
+		}
+		ids = append(ids, resp.Id)
+	}
+	if len(shadow.docs) != 3 || ids[0] != "ev_1" {
+		t.Fatalf("expected both backends written with primary ids returned, got %v / %d shadow docs", ids, len(shadow.docs))
+	}
+
+	resp, err := c.Search(ctx, &pb.SearchRequest{QueryText: "cache"})
+	if err != nil || len(resp.Results) != 2 {
+		t.Fatalf("search: %v %v", resp, err)
+	}
+	if _, err := c.GetByIDs(ctx, &pb.GetByIDsRequest{Ids: ids[:2]}); err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	c.Wait()
+	if p, _ := store.Parity(0); p.Checks != 2 || p.Matches != 2 {
+		t.Fatalf("expected two matching comparisons, got %+v", p)
+	}
+
+	// Drift: the shadow loses a document behind the wrapper's back
+	delete(shadow.docs, "sh_2")
+	c.Search(ctx, &pb.SearchRequest{QueryText: "cache"})
+	c.Wait()
+	p, _ := store.Parity(1)
+	if p.Mismatches != 1 || !strings.Contains(p.Recent[0].Detail, "only served: ev_2") {
+		t.Fatalf("expected a mismatch naming ev_2, got %+v", p)
+	}
+
+	del, err := c.DeleteEvidence(ctx, &pb.DeleteEvidenceRequest{Ids: []string{ids[0]}})
+	if err != nil || del.DeletedCount != 1 {
+		t.Fatalf("delete: %v %v", del, err)
+	}
+	if _, ok := shadow.docs["sh_1"]; ok {
+		t.Error("delete did not reach the shadow under its own id")
+	}
+	if n, _ := store.Mapped(); n != 2 {
+		t.Errorf("expected the deleted mapping dropped, %d left", n)
+	}
+}
+
+func TestCodec_ShadowFailuresNeverFailCalls(t *testing.T) {
+	store := testStore(t)
+	primary, shadow := newMem("ev_"), newMem("sh_")
+	shadow.fail = true
+	c := New(primary, shadow, store, Config{})
+	ctx := context.Background()
+
+	if _, err := c.StoreEvidence(ctx, &pb.StoreEvidenceRequest{Text: "x"}); err != nil {
+		t.Fatalf("store: %v", err)
+	}
+	if resp, err := c.Search(ctx, &pb.SearchRequest{QueryText: "x"}); err != nil || len(resp.Results) != 1 {
+		t.Fatalf("search: %v %v", resp, err)
+	}
+	c.Wait()
+	if p, _ := store.Parity(0); p.Errors != 2 {
+		t.Errorf("expected the failed write and read recorded, got %+v", p)
+	}
+
+	primary.fail = true
+	if _, err := c.StoreEvidence(ctx, &pb.StoreEvidenceRequest{Text: "y"}); !errors.Is(err, errDown) {
+		t.Errorf("primary failures must surface, got %v", err)
+	}
+}
+
+func TestCodec_ReadsFromShadowAfterCutover(t *testing.T) {
+	store := testStore(t)
+	primary, shadow := newMem("ev_"), newMem("sh_")
+	New(primary, shadow, store, Config{}).StoreEvidence(context.Background(), &pb.StoreEvidenceRequest{Text: "kept"})
+
+	c := New(primary, shadow, store, Config{Reads: ReadsShadow})
+	ctx := context.Background()
+	primary.docs["ev_1"] = "stale primary copy"
+
+	got, err := c.GetByIDs(ctx, &pb.GetByIDsRequest{Ids: []string{"ev_1"}})
+	if err != nil || len(got.Results) != 1 || got.Results[0].Id != "ev_1" || got.Results[0].Text != "kept" {
+		t.Fatalf("expected the shadow's copy under the primary id, got %v %v", got, err)
+	}
+	c.Wait()
+	if p, _ := store.Parity(1); p.Mismatches != 1 || !strings.Contains(p.Recent[0].Detail, "text differs") {
+		t.Errorf("expected a text mismatch against the primary, got %+v", p)
+	}
+
+	shadow.fail = true
+	all, err := c.ListAllEvidence(ctx, &pb.ListAllEvidenceRequest{})
+	if err != nil || len(all.Results) != 1 || all.Results[0].Text != "stale primary copy" {
+		t.Fatalf("expected fallback to the primary, got %v %v", all, err)
+	}
+}
+
+func TestCodec_WritesShadowFirstAfterCutover(t *testing.T) {
+	store := testStore(t)
+	primary, shadow := newMem("ev_"), newMem("sh_")
+	ctx := context.Background()
+	old, _ := New(primary, shadow, store, Config{}).StoreEvidence(ctx, &pb.StoreEvidenceRequest{Text: "before cutover"})
+
+	c := New(primary, shadow, store, Config{Reads: ReadsShadow})
+	primary.StoreEvidence(ctx, &pb.StoreEvidenceRequest{Text: "primary only"}) // IDs drift apart
+	stored, err := c.StoreEvidence(ctx, &pb.StoreEvidenceRequest{Text: "after cutover"})
+	if err != nil || stored.Id != "sh_2" || primary.docs["ev_3"] != "after cutover" {
+		t.Fatalf("expected the shadow's id with a primary copy, got %v %v (primary %v)", stored, err, primary.docs)
+	}
+
+	if resp, err := c.UpdateEvidenceMetadata(ctx, &pb.UpdateEvidenceMetadataRequest{Id: stored.Id, MetadataJson: `{"pinned":true}`}); err != nil || !resp.Found {
+		t.Fatalf("update: %v %v", resp, err)
+	}
+	if primary.meta["ev_3"] != `{"pinned":true}` || shadow.meta["sh_2"] != `{"pinned":true}` {
+		t.Errorf("update did not reach both under their own ids: primary %v, shadow %v", primary.meta, shadow.meta)
+	}
+
+	shadow.fail = true
+	got, err := c.GetByIDs(ctx, &pb.GetByIDsRequest{Ids: []string{old.Id, stored.Id}})
+	if err != nil || len(got.Results) != 2 || got.Results[0].Id != old.Id || got.Results[1].Id != stored.Id {
+		t.Fatalf("expected the primary fallback under evidence ids, got %v %v", got, err)
+	}
+	if _, err := c.StoreEvidence(ctx, &pb.StoreEvidenceRequest{Text: "lost"}); !errors.Is(err, errDown) {
+		t.Errorf("serving shadow failures must surface, got %v", err)
+	}
+
+	shadow.fail = false
+	if del, err := c.DeleteEvidence(ctx, &pb.DeleteEvidenceRequest{Ids: []string{stored.Id}}); err != nil || del.DeletedCount != 1 {
+		t.Fatalf("delete: %v %v", del, err)
+	}
+	if _, ok := primary.docs["ev_3"]; ok {
+		t.Error("delete did not reach the primary under its own id")
+	}
+}
+
+func TestCodec_PrunesChecks(t *testing.T) {
+	store := testStore(t)
+	primary, shadow := newMem("ev_"), newMem("sh_")
+	c := New(primary, shadow, store, Config{KeepChecks: 10})
+	for i := 0; i < pruneEvery; i++ {
+		c.Search(context.Background(), &pb.SearchRequest{QueryText: "x"})
+		c.Wait()
+	}
+	if p, _ := store.Parity(0); p.Checks != 10 {
+		t.Errorf("expected checks pruned to 10, got %d", p.Checks)
+	}
+}
+
+func TestCodec_UpdateReachesShadowByMappedID(t *testing.T) {
+	store := testStore(t)
+	primary, shadow := newMem("ev_"), newMem("sh_")
+	c := New(primary, shadow, store, Config{})
+	ctx := context.Background()
+	c.StoreEvidence(ctx, &pb.StoreEvidenceRequest{Text: "filler"})
+	shadow.StoreEvidence(ctx, &pb.StoreEvidenceRequest{Text: "shadow only"}) // IDs drift apart
+	stored, _ := c.StoreEvidence(ctx, &pb.StoreEvidenceRequest{Text: "allergic to penicillin"})
+
+	resp, err := c.UpdateEvidenceMetadata(ctx, &pb.UpdateEvidenceMetadataRequest{Id: stored.Id, MetadataJson: `{"pinned":true}`})
+	if err != nil || !resp.Found {
+		t.Fatalf("update: %v %v", resp, err)
+	}
+	if stored.Id != "ev_2" || shadow.meta["sh_3"] != `{"pinned":true}` || len(shadow.meta) != 1 {
+		t.Errorf("shadow metadata = %v (primary id %s)", shadow.meta, stored.Id)
+	}
+}
+
+func TestBackfill(t *testing.T) {
+	store := testStore(t)
+	primary, shadow := newMem("ev_"), newMem("sh_")
+	primary.docs["ev_1"], primary.docs["ev_2"] = "one", "two"
+	store.Map("ev_1", "ev_1", "sh_9")
+
+	res, err := Backfill(context.Background(), primary, shadow, store)
+	if err != nil {
+		t.Fatalf("backfill: %v", err)
+	}
+	if res.Total != 2 || res.Copied != 1 || res.Skipped != 1 || shadow.docs["sh_1"] != "two" {
+		t.Fatalf("unexpected backfill: %+v, shadow %v", res, shadow.docs)
+	}
+	if again, _ := Backfill(context.Background(), primary, shadow, store); again.Copied != 0 {
+		t.Errorf("re-run copied %d documents", again.Copied)
+	}
+
+	shadow.fail = true
+	primary.docs["ev_3"] = "three"
+	if _, err := Backfill(context.Background(), primary, shadow, store); !errors.Is(err, errDown) {
+		t.Errorf("expected the shadow failure, got %v", err)
+	}
+}
+
+// #endregion codec-tests
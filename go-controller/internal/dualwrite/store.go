@@ -0,0 +1,301 @@
+package dualwrite
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/timestamp"
+)
+
+// #region types
+
+// Which backend serves evidence: it answers reads, takes each write first and
+// names new evidence. Writes always reach both.
+const (
+	ReadsPrimary = "primary" // CODEC_ADDR; the shadow is compared in the background
+	ReadsShadow  = "shadow"  // after cutover; the primary is compared in the background
+)
+
+// Check outcomes recorded in evidence_shadow_checks.
+const (
+	OutcomeMatch    = "match"
+	OutcomeMismatch = "mismatch"
+	OutcomeError    = "error" // either backend failed, so nothing was compared
+)
+
+// Check is one comparison (or failed shadow call) between the two backends.
+type Check struct {
+	ID        int64
+	Op        string // "search" | "get_by_ids" | "list_all" | "store" | "delete"
+	Outcome   string
+	Detail    string
+	CreatedAt time.Time
+}
+
+// Parity summarizes the most recent checks.
+type Parity struct {
+	Checks     int
+	Matches    int
+	Mismatches int
+	Errors     int
+	Recent     []Check // latest mismatches and errors, newest first (up to 5)
+}
+
+// MismatchRate is the share of checks that were not a match; 0 with no checks.
+func (p Parity) MismatchRate() float64 {
+	if p.Checks == 0 {
+		return 0
+	}
+	return float64(p.Mismatches+p.Errors) / float64(p.Checks)
+}
+
+// Ready reports whether parity is demonstrated: at least minChecks checks, and
+// at most maxRate of them mismatched or failed. reason explains a refusal.
+func (p Parity) Ready(minChecks int, maxRate float64) (ok bool, reason string) {
+	if p.Checks < minChecks {
+		return false, fmt.Sprintf("only %d of the %d checks needed", p.Checks, minChecks)
+	}
+	if rate := p.MismatchRate(); rate > maxRate {
+		return false, fmt.Sprintf("mismatch rate %.2f%% is above %.2f%%", rate*100, maxRate*100)
+	}
+	return true, ""
+}
+
+// #endregion types
+
+// #region store
+
+// Store keeps the dual-write bookkeeping: the primary and shadow ID each
+// evidence ID was written as, the comparison log, and which backend serves
+// evidence.
+type Store struct {
+	db *sql.DB
+}
+
+// NewStore creates the dual-write tables if needed and returns a store.
+func NewStore(db *sql.DB) (*Store, error) {
+	for _, ddl := range []string{
+		`CREATE TABLE IF NOT EXISTS evidence_id_map (
+			primary_id TEXT PRIMARY KEY,
+			shadow_id TEXT NOT NULL UNIQUE,
+			created_at DATETIME NOT NULL
+		)`,
+		`CREATE TABLE IF NOT EXISTS evidence_shadow_checks (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			op TEXT NOT NULL,
+			outcome TEXT NOT NULL,
+			detail TEXT,
+			created_at DATETIME NOT NULL
+		)`,
+		`CREATE TABLE IF NOT EXISTS evidence_backend (
+			id INTEGER PRIMARY KEY CHECK (id = 1),
+			reads TEXT NOT NULL,
+			changed_at DATETIME NOT NULL
+		)`,
+	} {
+		if _, err := db.Exec(ddl); err != nil {
+			return nil, fmt.Errorf("create dual-write tables: %w", err)
+		}
+	}
+	// evidence_id: the ID callers know the evidence by, its serving backend's
+	// ID when stored; rows from before the column were all named by the primary
+	_, _ = db.Exec(`ALTER TABLE evidence_id_map ADD COLUMN evidence_id TEXT`)
+	if _, err := db.Exec(`UPDATE evidence_id_map SET evidence_id = primary_id WHERE evidence_id IS NULL`); err != nil {
+		return nil, fmt.Errorf("backfill evidence_id: %w", err)
+	}
+	if _, err := db.Exec(`CREATE UNIQUE INDEX IF NOT EXISTS idx_evidence_id_map_evidence ON evidence_id_map(evidence_id)`); err != nil {
+		return nil, fmt.Errorf("create evidence id index: %w", err)
+	}
+	if _, err := timestamp.Canonicalize(db, "evidence_id_map", "created_at"); err != nil {
+		return nil, err
+	}
+	if _, err := timestamp.Canonicalize(db, "evidence_shadow_checks", "created_at"); err != nil {
+		return nil, err
+	}
+	if _, err := timestamp.Canonicalize(db, "evidence_backend", "changed_at"); err != nil {
+		return nil, err
+	}
+	return &Store{db: db}, nil
+}
+
+// Map records that evidenceID was written to the primary as primaryID and to
+// the shadow as shadowID; evidenceID is one of the two.
+func (s *Store) Map(evidenceID, primaryID, shadowID string) error {
+	if _, err := s.db.Exec(`INSERT OR REPLACE INTO evidence_id_map (evidence_id, primary_id, shadow_id, created_at) VALUES (?, ?, ?, ?)`,
+		evidenceID, primaryID, shadowID, timestamp.Now()); err != nil {
+		return fmt.Errorf("map evidence id: %w", err)
+	}
+	return nil
+}
+
+// Unmap forgets the mappings of deleted evidence IDs.
+func (s *Store) Unmap(evidenceIDs []string) error {
+	if len(evidenceIDs) == 0 {
+		return nil
+	}
+	if _, err := s.db.Exec(`DELETE FROM evidence_id_map WHERE evidence_id IN (`+placeholders(len(evidenceIDs))+`)`,
+		anySlice(evidenceIDs)...); err != nil {
+		return fmt.Errorf("unmap evidence ids: %w", err)
+	}
+	return nil
+}
+
+// BackendIDs returns the ID backend (ReadsPrimary or ReadsShadow) holds each
+// mapped evidence ID under.
+func (s *Store) BackendIDs(backend string, evidenceIDs []string) (map[string]string, error) {
+	col, err := backendColumn(backend)
+	if err != nil {
+		return nil, err
+	}
+	return s.lookup("evidence_id", col, evidenceIDs)
+}
+
+// EvidenceIDs returns the evidence ID of each mapped ID from backend.
+func (s *Store) EvidenceIDs(backend string, backendIDs []string) (map[string]string, error) {
+	col, err := backendColumn(backend)
+	if err != nil {
+		return nil, err
+	}
+	return s.lookup(col, "evidence_id", backendIDs)
+}
+
+func backendColumn(backend string) (string, error) {
+	switch backend {
+	case ReadsPrimary:
+		return "primary_id", nil
+	case ReadsShadow:
+		return "shadow_id", nil
+	}
+	return "", fmt.Errorf("evidence backend must be %s or %s, got %q", ReadsPrimary, ReadsShadow, backend)
+}
+
+func (s *Store) lookup(from, to string, ids []string) (map[string]string, error) {
+	out := make(map[string]string, len(ids))
+	if len(ids) == 0 {
+		return out, nil
+	}
+	rows, err := s.db.Query(`SELECT `+from+`, `+to+` FROM evidence_id_map WHERE `+from+` IN (`+placeholders(len(ids))+`)`,
+		anySlice(ids)...)
+	if err != nil {
+		return nil, fmt.Errorf("look up evidence ids: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var k, v string
+		if err := rows.Scan(&k, &v); err != nil {
+			return nil, fmt.Errorf("scan evidence id map: %w", err)
+		}
+		out[k] = v
+	}
+	return out, rows.Err()
+}
+
+// Mapped returns how many primary IDs have a shadow copy.
+func (s *Store) Mapped() (int, error) {
+	var n int
+	if err := s.db.QueryRow(`SELECT COUNT(*) FROM evidence_id_map`).Scan(&n); err != nil {
+		return 0, fmt.Errorf("count evidence id map: %w", err)
+	}
+	return n, nil
+}
+
+// RecordCheck appends one comparison to the log.
+func (s *Store) RecordCheck(c Check) error {
+	if c.CreatedAt.IsZero() {
+		c.CreatedAt = time.Now().UTC()
+	}
+	if _, err := s.db.Exec(`INSERT INTO evidence_shadow_checks (op, outcome, detail, created_at) VALUES (?, ?, ?, ?)`,
+		c.Op, c.Outcome, c.Detail, timestamp.Format(c.CreatedAt)); err != nil {
+		return fmt.Errorf("record shadow check: %w", err)
+	}
+	return nil
+}
+
+// PruneChecks deletes all but the newest keep checks and returns how many it
+// deleted.
+func (s *Store) PruneChecks(keep int) (int64, error) {
+	res, err := s.db.Exec(`DELETE FROM evidence_shadow_checks WHERE id <= (
+		SELECT id FROM evidence_shadow_checks ORDER BY id DESC LIMIT 1 OFFSET ?)`, keep)
+	if err != nil {
+		return 0, fmt.Errorf("prune shadow checks: %w", err)
+	}
+	return res.RowsAffected()
+}
+
+// Parity summarizes the last window checks (all of them when window <= 0).
+func (s *Store) Parity(window int) (Parity, error) {
+	if window <= 0 {
+		window = -1 // SQLite: no limit
+	}
+	rows, err := s.db.Query(`SELECT id, op, outcome, COALESCE(detail, ''), created_at FROM evidence_shadow_checks
+		ORDER BY id DESC LIMIT ?`, window)
+	if err != nil {
+		return Parity{}, fmt.Errorf("read shadow checks: %w", err)
+	}
+	defer rows.Close()
+
+	var p Parity
+	for rows.Next() {
+		var c Check
+		var ts string
+		if err := rows.Scan(&c.ID, &c.Op, &c.Outcome, &c.Detail, &ts); err != nil {
+			return Parity{}, fmt.Errorf("scan shadow check: %w", err)
+		}
+		c.CreatedAt, _ = timestamp.Parse(ts)
+		p.Checks++
+		switch c.Outcome {
+		case OutcomeMatch:
+			p.Matches++
+			continue
+		case OutcomeMismatch:
+			p.Mismatches++
+		default:
+			p.Errors++
+		}
+		if len(p.Recent) < 5 {
+			p.Recent = append(p.Recent, c)
+		}
+	}
+	return p, rows.Err()
+}
+
+// Reads returns the backend serving reads; ReadsPrimary until a cutover.
+func (s *Store) Reads() (string, error) {
+	var reads string
+	err := s.db.QueryRow(`SELECT reads FROM evidence_backend WHERE id = 1`).Scan(&reads)
+	if err == sql.ErrNoRows {
+		return ReadsPrimary, nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("read evidence backend: %w", err)
+	}
+	return reads, nil
+}
+
+// SetReads switches the backend serving evidence, from the next start.
+func (s *Store) SetReads(reads string) error {
+	if reads != ReadsPrimary && reads != ReadsShadow {
+		return fmt.Errorf("evidence reads must be %s or %s, got %q", ReadsPrimary, ReadsShadow, reads)
+	}
+	if _, err := s.db.Exec(`INSERT OR REPLACE INTO evidence_backend (id, reads, changed_at) VALUES (1, ?, ?)`,
+		reads, timestamp.Now()); err != nil {
+		return fmt.Errorf("set evidence backend: %w", err)
+	}
+	return nil
+}
+
+func placeholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?,", n), ",")
+}
+
+func anySlice(ids []string) []any {
+	out := make([]any, len(ids))
+	for i, id := range ids {
+		out[i] = id
+	}
+	return out
+}
+
+// #endregion store
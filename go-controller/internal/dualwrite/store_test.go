@@ -0,0 +1,141 @@
+package dualwrite
+
+import (
+	"database/sql"
+	"testing"
+
+	_ "modernc.org/sqlite"
+)
+
+// #region helpers
+
+func testStore(t *testing.T) *Store {
+	t.Helper()
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+	s, err := NewStore(db)
+	if err != nil {
+		t.Fatalf("new store: %v", err)
+	}
+	return s
+}
+
+// #endregion helpers
+
+// #region store-tests
+
+func TestStore_IDMap(t *testing.T) {
+	s := testStore(t)
+	if err := s.Map("ev_a", "ev_a", "sh_1"); err != nil {
+		t.Fatalf("map: %v", err)
+	}
+	if err := s.Map("sh_2", "ev_b", "sh_2"); err != nil { // stored after cutover
+		t.Fatalf("map: %v", err)
+	}
+
+	byShadow, err := s.BackendIDs(ReadsShadow, []string{"ev_a", "sh_2", "ev_missing"})
+	if err != nil || len(byShadow) != 2 || byShadow["ev_a"] != "sh_1" || byShadow["sh_2"] != "sh_2" {
+		t.Fatalf("shadow ids: %v %v", byShadow, err)
+	}
+	byPrimary, err := s.BackendIDs(ReadsPrimary, []string{"sh_2"})
+	if err != nil || byPrimary["sh_2"] != "ev_b" {
+		t.Fatalf("primary ids: %v %v", byPrimary, err)
+	}
+	fromShadow, err := s.EvidenceIDs(ReadsShadow, []string{"sh_1", "sh_2"})
+	if err != nil || fromShadow["sh_1"] != "ev_a" || fromShadow["sh_2"] != "sh_2" {
+		t.Fatalf("evidence ids from shadow: %v %v", fromShadow, err)
+	}
+	if fromPrimary, _ := s.EvidenceIDs(ReadsPrimary, []string{"ev_b"}); fromPrimary["ev_b"] != "sh_2" {
+		t.Fatalf("evidence ids from primary: %v", fromPrimary)
+	}
+	if _, err := s.BackendIDs("both", nil); err == nil {
+		t.Error("expected error for unknown backend")
+	}
+
+	if err := s.Unmap([]string{"ev_a"}); err != nil {
+		t.Fatalf("unmap: %v", err)
+	}
+	if n, _ := s.Mapped(); n != 1 {
+		t.Errorf("expected 1 mapping left, got %d", n)
+	}
+	if empty, err := s.BackendIDs(ReadsShadow, nil); err != nil || len(empty) != 0 {
+		t.Errorf("empty lookup: %v %v", empty, err)
+	}
+}
+
+func TestStore_Parity(t *testing.T) {
+	s := testStore(t)
+	if p, err := s.Parity(0); err != nil || p.Checks != 0 || p.MismatchRate() != 0 {
+		t.Fatalf("empty parity: %+v %v", p, err)
+	}
+	for _, outcome := range []string{OutcomeMismatch, OutcomeMatch, OutcomeError, OutcomeMatch, OutcomeMatch} {
+		if err := s.RecordCheck(Check{Op: "search", Outcome: outcome, Detail: outcome + " detail"}); err != nil {
+			t.Fatalf("record: %v", err)
+		}
+	}
+
+	all, err := s.Parity(0)
+	if err != nil {
+		t.Fatalf("parity: %v", err)
+	}
+	if all.Checks != 5 || all.Matches != 3 || all.Mismatches != 1 || all.Errors != 1 || all.MismatchRate() != 0.4 {
+		t.Errorf("unexpected parity: %+v", all)
+	}
+	if len(all.Recent) != 2 || all.Recent[0].Outcome != OutcomeError || all.Recent[1].Detail != "mismatch detail" {
+		t.Errorf("expected recent problems newest first, got %+v", all.Recent)
+	}
+
+	recent, _ := s.Parity(3)
+	if recent.Checks != 3 || recent.Errors != 1 || recent.Mismatches != 0 {
+		t.Errorf("window of 3: %+v", recent)
+	}
+	if ok, _ := recent.Ready(3, 0.5); !ok {
+		t.Error("expected 1 of 3 failed to be within 50%")
+	}
+	if ok, reason := recent.Ready(10, 0.5); ok || reason == "" {
+		t.Error("expected too few checks to refuse")
+	}
+	if ok, _ := recent.Ready(3, 0.1); ok {
+		t.Error("expected a 33% failure rate to refuse at 10%")
+	}
+}
+
+func TestStore_PruneChecks(t *testing.T) {
+	s := testStore(t)
+	for i := 0; i < 5; i++ {
+		if err := s.RecordCheck(Check{Op: "search", Outcome: OutcomeMatch}); err != nil {
+			t.Fatalf("record: %v", err)
+		}
+	}
+	if n, err := s.PruneChecks(2); err != nil || n != 3 {
+		t.Fatalf("prune: %d %v", n, err)
+	}
+	if p, _ := s.Parity(0); p.Checks != 2 {
+		t.Errorf("expected the newest 2 checks kept, got %d", p.Checks)
+	}
+	if n, err := s.PruneChecks(10); err != nil || n != 0 {
+		t.Errorf("prune under the limit: %d %v", n, err)
+	}
+}
+
+func TestStore_Reads(t *testing.T) {
+	s := testStore(t)
+	if reads, err := s.Reads(); err != nil || reads != ReadsPrimary {
+		t.Fatalf("default reads: %q %v", reads, err)
+	}
+	if err := s.SetReads(ReadsShadow); err != nil {
+		t.Fatalf("set: %v", err)
+	}
+	if reads, _ := s.Reads(); reads != ReadsShadow {
+		t.Errorf("expected shadow, got %q", reads)
+	}
+	if err := s.SetReads("both"); err == nil {
+		t.Error("expected error for unknown backend")
+	}
+}
+
+// #endregion store-tests
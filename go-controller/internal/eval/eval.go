@@ -3,6 +3,7 @@ package eval
 import (
 	"fmt"
 	"math"
+	"strings"
 
 	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/state"
 )
@@ -51,7 +52,8 @@ func (h *EvalHarness) Run(newState state.StateRecord, entropy float32) EvalResul
 
 	for _, s := range segments {
 		norm := segNorm(newState.StateVector, s.seg)
-		segPass := norm <= h.config.MaxSegmentNorm
+		limit := h.config.SegmentLimit(s.name)
+		segPass := norm <= limit
 		metrics = append(metrics, EvalMetric{
 			Name:  fmt.Sprintf("segment_%s_norm", s.name),
 			Value: norm,
@@ -59,7 +61,7 @@ func (h *EvalHarness) Run(newState state.StateRecord, entropy float32) EvalResul
 		})
 		if !segPass {
 			passed = false
-			failReasons = append(failReasons, fmt.Sprintf("%s segment norm %.4f exceeds %.4f", s.name, norm, h.config.MaxSegmentNorm))
+			failReasons = append(failReasons, fmt.Sprintf("%s segment norm %.4f exceeds %.4f", s.name, norm, limit))
 		}
 	}
 
@@ -80,15 +82,76 @@ func (h *EvalHarness) Run(newState state.StateRecord, entropy float32) EvalResul
 		}
 	}
 
+	tier, scale := TierPass, float32(1)
+	if !passed {
+		tier, scale = TierFail, 0
+	}
 	return EvalResult{
 		Passed:  passed,
 		Metrics: metrics,
 		Reason:  reason,
+		Tier:    tier,
+		Scale:   scale,
 	}
 }
 
 // #endregion eval-harness
 
+// #region eval-tiered
+
+// scaleSteps is the bisection depth of RunTiered's delta scale (~1e-6 resolution).
+const scaleSteps = 20
+
+// RunTiered validates proposed like Run, with the warning tier between pass and
+// fail: when every breach is within WarnMargin, the delta from prev is scaled
+// down to the largest fraction that passes, and that scaled state is returned
+// to commit instead. The scale is found by bisection, so replays reproduce it.
+// On pass or fail, proposed is returned unchanged.
+func (h *EvalHarness) RunTiered(prev, proposed state.StateRecord, entropy float32) (EvalResult, state.StateRecord) {
+	res := h.Run(proposed, entropy)
+	if res.Passed || h.config.WarnMargin <= 0 {
+		return res, proposed
+	}
+	if !NewEvalHarness(h.config.loosened()).Run(proposed, entropy).Passed {
+		return res, proposed // past the fail threshold
+	}
+
+	lo, hi := float32(0), float32(1)
+	if !h.Run(scaleDelta(prev, proposed, lo), entropy).Passed {
+		return res, proposed // the previous state already breaches; no scale can pass
+	}
+	for i := 0; i < scaleSteps; i++ {
+		mid := (lo + hi) / 2
+		if h.Run(scaleDelta(prev, proposed, mid), entropy).Passed {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+	if lo == 0 {
+		return res, proposed
+	}
+
+	scaled := scaleDelta(prev, proposed, lo)
+	out := h.Run(scaled, entropy)
+	out.Tier, out.Scale = TierWarn, lo
+	out.Metrics = append(out.Metrics, EvalMetric{Name: "delta_scale", Value: lo, Pass: true})
+	out.Reason = fmt.Sprintf("warning: %s; delta scaled to %.2f", strings.TrimPrefix(res.Reason, "eval failed: "), lo)
+	return out, scaled
+}
+
+// scaleDelta returns proposed with its vector moved back toward prev, keeping
+// scale of the change.
+func scaleDelta(prev, proposed state.StateRecord, scale float32) state.StateRecord {
+	out := proposed
+	for i := range out.StateVector {
+		out.StateVector[i] = prev.StateVector[i] + scale*(proposed.StateVector[i]-prev.StateVector[i])
+	}
+	return out
+}
+
+// #endregion eval-tiered
+
 // #region helpers
 // fullVectorNorm computes the L2 norm of a 128-dim vector.
 func fullVectorNorm(v [128]float32) float32 {
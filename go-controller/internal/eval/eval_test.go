@@ -80,6 +80,45 @@ func TestEvalFailsOnSegmentNormSpike(t *testing.T) {
 	}
 }
 
+func TestEvalPerSegmentNormOverride(t *testing.T) {
+	config := DefaultEvalConfig()
+	config.SegmentNorms = map[string]float32{"risk": 2.0}
+	h := NewEvalHarness(config)
+
+	// Same norm (≈5.66) in prefs and risk: only risk has the tighter bound
+	vals := make(map[int]float32)
+	for i := 0; i < 32; i++ {
+		vals[i] = 1.0
+		vals[96+i] = 1.0
+	}
+	result := h.Run(makeState(vals), 0.5)
+
+	if result.Passed {
+		t.Fatal("expected fail on risk segment override")
+	}
+	for _, m := range result.Metrics {
+		switch m.Name {
+		case "segment_risk_norm":
+			if m.Pass {
+				t.Error("expected segment_risk_norm to fail against its override")
+			}
+		case "segment_prefs_norm":
+			if !m.Pass {
+				t.Error("expected segment_prefs_norm to pass against MaxSegmentNorm")
+			}
+		}
+	}
+}
+
+func TestSegmentLimitDefaultsToMaxSegmentNorm(t *testing.T) {
+	config := DefaultEvalConfig()
+	for _, name := range []string{"prefs", "goals", "heuristics", "risk"} {
+		if got := config.SegmentLimit(name); got != config.MaxSegmentNorm {
+			t.Errorf("%s limit = %.1f, want %.1f", name, got, config.MaxSegmentNorm)
+		}
+	}
+}
+
 func TestEvalEntropyInformationalOnly(t *testing.T) {
 	config := DefaultEvalConfig()
 	config.EntropyBaseline = 1.0
@@ -155,3 +194,58 @@ func TestEvalPassesWithModerateValues(t *testing.T) {
 		t.Fatalf("expected pass with moderate values, got fail: %s", result.Reason)
 	}
 }
+
+func TestRunTiered(t *testing.T) {
+	config := DefaultEvalConfig()
+	config.MaxSegmentNorm = 10.0
+	config.WarnMargin = 0.2
+	h := NewEvalHarness(config)
+	prev := makeState(map[int]float32{0: 6})
+
+	// Within bounds: committed as proposed
+	res, got := h.RunTiered(prev, makeState(map[int]float32{0: 9}), 0.5)
+	if res.Tier != TierPass || res.Scale != 1 || got.StateVector[0] != 9 {
+		t.Fatalf("expected pass, got %s scale %.2f", res.Tier, res.Scale)
+	}
+
+	// 11 breaches 10 by 10%: warned, delta 6→11 scaled to land at the bound
+	proposed := makeState(map[int]float32{0: 11})
+	res, got = h.RunTiered(prev, proposed, 0.5)
+	if res.Tier != TierWarn || !res.Passed {
+		t.Fatalf("expected warn, got %s: %s", res.Tier, res.Reason)
+	}
+	if res.Scale < 0.79 || res.Scale > 0.8 || got.StateVector[0] > 10 || got.StateVector[0] < 9.95 {
+		t.Errorf("expected scale ~0.8 and value ~10, got %.4f and %.4f", res.Scale, got.StateVector[0])
+	}
+	if got.VersionID != proposed.VersionID {
+		t.Error("scaled state should keep the proposed version ID")
+	}
+	if m := res.Metrics[len(res.Metrics)-1]; m.Name != "delta_scale" || m.Value != res.Scale {
+		t.Errorf("expected delta_scale metric, got %+v", m)
+	}
+	if again, _ := h.RunTiered(prev, proposed, 0.5); again.Scale != res.Scale {
+		t.Error("scale should be deterministic for replay")
+	}
+
+	// 13 is past the 120% fail threshold
+	res, got = h.RunTiered(prev, makeState(map[int]float32{0: 13}), 0.5)
+	if res.Tier != TierFail || res.Passed || got.StateVector[0] != 13 {
+		t.Errorf("expected fail, got %s", res.Tier)
+	}
+
+	// No margin: binary pass/fail
+	config.WarnMargin = 0
+	if res, _ := NewEvalHarness(config).RunTiered(prev, proposed, 0.5); res.Tier != TierFail {
+		t.Errorf("expected fail without a warn margin, got %s", res.Tier)
+	}
+}
+
+func TestRunTiered_PreviousStateBreaches(t *testing.T) {
+	config := DefaultEvalConfig()
+	config.MaxSegmentNorm = 10.0
+	h := NewEvalHarness(config)
+	res, _ := h.RunTiered(makeState(map[int]float32{0: 10.5}), makeState(map[int]float32{0: 11}), 0.5)
+	if res.Tier != TierFail {
+		t.Errorf("no scale can pass when the previous state breaches; got %s", res.Tier)
+	}
+}
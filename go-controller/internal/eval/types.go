@@ -6,6 +6,15 @@ type EvalConfig struct {
 	MaxStateNorm   float32 // reject if state norm exceeds this
 	MaxSegmentNorm float32 // reject if any segment norm exceeds this
 	EntropyBaseline float32 // warn if entropy rises above baseline
+
+	// SegmentNorms overrides MaxSegmentNorm per segment ("prefs", "goals",
+	// "heuristics", "risk"). Segments not listed use MaxSegmentNorm.
+	SegmentNorms map[string]float32
+
+	// WarnMargin is the warning tier: a state that breaches a norm bound by at
+	// most this fraction of it (0.2 = up to 120%) is not rolled back; RunTiered
+	// scales the turn's delta down until every bound holds. 0 = pass/fail only.
+	WarnMargin float32
 }
 
 // DefaultEvalConfig returns sensible defaults for Phase 3.
@@ -14,7 +23,32 @@ func DefaultEvalConfig() EvalConfig {
 		MaxStateNorm:    50.0,
 		MaxSegmentNorm:  15.0,
 		EntropyBaseline: 2.0,
+		WarnMargin:      0.2,
+	}
+}
+
+// SegmentLimit returns the norm bound applied to the named segment.
+func (c EvalConfig) SegmentLimit(name string) float32 {
+	if limit, ok := c.SegmentNorms[name]; ok {
+		return limit
 	}
+	return c.MaxSegmentNorm
+}
+
+// loosened returns the config with every norm bound widened by WarnMargin: the
+// fail threshold of the warning tier.
+func (c EvalConfig) loosened() EvalConfig {
+	f := 1 + c.WarnMargin
+	out := c
+	out.MaxStateNorm *= f
+	out.MaxSegmentNorm *= f
+	if c.SegmentNorms != nil {
+		out.SegmentNorms = make(map[string]float32, len(c.SegmentNorms))
+		for seg, limit := range c.SegmentNorms {
+			out.SegmentNorms[seg] = limit * f
+		}
+	}
+	return out
 }
 
 // #endregion eval-config
@@ -30,11 +64,21 @@ type EvalMetric struct {
 // #endregion eval-metric
 
 // #region eval-result
+// Eval tiers.
+const (
+	TierPass = "pass"
+	TierWarn = "warn" // committed with a scaled-down delta, flagged for monitoring
+	TierFail = "fail"
+)
+
 // EvalResult is the output of post-commit validation.
 type EvalResult struct {
 	Passed  bool
 	Metrics []EvalMetric
 	Reason  string
+
+	Tier  string  // TierPass | TierWarn | TierFail
+	Scale float32 // fraction of the proposed delta kept: 1 on pass, (0, 1) on warn, 0 on fail
 }
 
 // #endregion eval-result
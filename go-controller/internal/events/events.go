@@ -0,0 +1,130 @@
+package events
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/logging"
+)
+
+// #region types
+
+// TurnEvent is one line of --emit-json output: everything a downstream tool
+// needs to follow a turn without opening the database.
+type TurnEvent struct {
+	Event    string    `json:"event"` // always "turn"
+	TurnID   string    `json:"turn_id"`
+	Time     time.Time `json:"time"`
+	Decision string    `json:"decision"` // commit | reject | rollback | held | frozen | cancelled | error
+
+	Prompt   string  `json:"prompt"`
+	Response string  `json:"response"`
+	Entropy  float32 `json:"entropy"`
+
+	Classification *Classification `json:"classification,omitempty"`
+	Strategy       string          `json:"strategy,omitempty"`
+	Attempts       int             `json:"attempts,omitempty"`
+	EvidenceCount  int             `json:"evidence_count"`
+
+	// Absent on cancelled turns, which never reach the gate
+	Signals *logging.GateRecordSignals `json:"signals,omitempty"`
+	Gate    *Gate                      `json:"gate,omitempty"`
+
+	// Active version before the turn, the version the update proposed, and the
+	// active version after the turn (equal to VersionBefore unless committed)
+	VersionBefore   string `json:"version_before"`
+	VersionProposed string `json:"version_proposed,omitempty"`
+	VersionAfter    string `json:"version_after"`
+
+	Reason string `json:"reason,omitempty"` // rollback, freeze, or error cause
+
+	// Eval warning tier: fraction of the proposed delta committed; omitted on full commits
+	EvalScale float32 `json:"eval_scale,omitempty"`
+
+	// Time from reading the message off the inbox to this event
+	LatencyMs int64 `json:"latency_ms,omitempty"`
+}
+
+// Classification is the orchestrator's view of the prompt.
+type Classification struct {
+	Type       string `json:"type"`
+	Complexity string `json:"complexity"`
+	Risk       string `json:"risk"`
+}
+
+// Gate is the gate decision and the update metrics it judged.
+type Gate struct {
+	Action      string   `json:"action"`
+	SoftScore   float32  `json:"soft_score"`
+	Vetoed      bool     `json:"vetoed"`
+	VetoTypes   []string `json:"veto_types,omitempty"`
+	Reason      string   `json:"reason"`
+	DeltaNorm   float32  `json:"delta_norm"`
+	SegmentsHit []string `json:"segments_hit"`
+}
+
+// #endregion types
+
+// #region emitter
+
+// Emitter writes TurnEvents as JSON lines. A nil *Emitter discards events, so
+// callers need not check whether --emit-json was given.
+type Emitter struct {
+	mu     sync.Mutex
+	w      io.Writer
+	closer io.Closer
+}
+
+// NewEmitter writes events to w.
+func NewEmitter(w io.Writer) *Emitter {
+	return &Emitter{w: w}
+}
+
+// Open returns an emitter for dest: "-" writes to stdout, anything else is a
+// file path opened for append.
+func Open(dest string, stdout io.Writer) (*Emitter, error) {
+	if dest == "-" {
+		return NewEmitter(stdout), nil
+	}
+	f, err := os.OpenFile(dest, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("open event file: %w", err)
+	}
+	return &Emitter{w: f, closer: f}, nil
+}
+
+// Emit writes ev as one line. Each line is written with a single Write so
+// readers never see a partial event.
+func (e *Emitter) Emit(ev TurnEvent) error {
+	if e == nil {
+		return nil
+	}
+	ev.Event = "turn"
+	if ev.Time.IsZero() {
+		ev.Time = time.Now().UTC()
+	}
+	data, err := json.Marshal(ev)
+	if err != nil {
+		return fmt.Errorf("marshal event: %w", err)
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if _, err := e.w.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("write event: %w", err)
+	}
+	return nil
+}
+
+// Close closes the underlying file, if Open created one.
+func (e *Emitter) Close() error {
+	if e == nil || e.closer == nil {
+		return nil
+	}
+	return e.closer.Close()
+}
+
+// #endregion emitter
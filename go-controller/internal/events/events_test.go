@@ -0,0 +1,86 @@
+package events
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestEmitWritesOneLinePerEvent(t *testing.T) {
+	var buf bytes.Buffer
+	e := NewEmitter(&buf)
+	if err := e.Emit(TurnEvent{TurnID: "turn-1", Decision: "commit", Prompt: "hi\nthere"}); err != nil {
+		t.Fatalf("emit: %v", err)
+	}
+	if err := e.Emit(TurnEvent{TurnID: "turn-2", Decision: "cancelled"}); err != nil {
+		t.Fatalf("emit: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 lines, got %d: %q", len(lines), buf.String())
+	}
+	var ev TurnEvent
+	if err := json.Unmarshal([]byte(lines[0]), &ev); err != nil {
+		t.Fatalf("line 1 is not JSON: %v", err)
+	}
+	if ev.Event != "turn" || ev.TurnID != "turn-1" || ev.Prompt != "hi\nthere" || ev.Time.IsZero() {
+		t.Fatalf("unexpected event: %+v", ev)
+	}
+	if strings.Contains(lines[1], `"gate"`) || strings.Contains(lines[1], `"signals"`) {
+		t.Fatalf("cancelled turn should omit gate and signals: %s", lines[1])
+	}
+}
+
+func TestNilEmitterDiscards(t *testing.T) {
+	var e *Emitter
+	if err := e.Emit(TurnEvent{TurnID: "turn-1"}); err != nil {
+		t.Fatalf("nil emitter should discard, got %v", err)
+	}
+	if err := e.Close(); err != nil {
+		t.Fatalf("nil close: %v", err)
+	}
+}
+
+func TestOpenAppendsToFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+	for i := 0; i < 2; i++ {
+		e, err := Open(path, nil)
+		if err != nil {
+			t.Fatalf("open: %v", err)
+		}
+		if err := e.Emit(TurnEvent{TurnID: "turn-1"}); err != nil {
+			t.Fatalf("emit: %v", err)
+		}
+		if err := e.Close(); err != nil {
+			t.Fatalf("close: %v", err)
+		}
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("read back: %v", err)
+	}
+	defer f.Close()
+	n := 0
+	for sc := bufio.NewScanner(f); sc.Scan(); n++ {
+	}
+	if n != 2 {
+		t.Fatalf("expected 2 appended lines, got %d", n)
+	}
+}
+
+func TestOpenDashUsesStdout(t *testing.T) {
+	var buf bytes.Buffer
+	e, err := Open("-", &buf)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	e.Emit(TurnEvent{TurnID: "turn-1"})
+	if e.Close() != nil || buf.Len() == 0 {
+		t.Fatalf("expected event on stdout writer")
+	}
+}
@@ -0,0 +1,62 @@
+package evidence
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/timestamp"
+)
+
+// #region archive
+
+// RawArchive keeps the full text of exchanges whose stored evidence was reduced,
+// keyed by evidence ID, so the original can be recovered locally.
+type RawArchive struct {
+	db *sql.DB
+}
+
+// NewRawArchive creates the evidence_raw table if needed and returns an archive.
+func NewRawArchive(db *sql.DB) (*RawArchive, error) {
+	if _, err := db.Exec(`CREATE TABLE IF NOT EXISTS evidence_raw (
+		evidence_id TEXT PRIMARY KEY,
+		turn_id TEXT NOT NULL,
+		method TEXT NOT NULL,
+		full_text TEXT NOT NULL,
+		created_at DATETIME NOT NULL
+	)`); err != nil {
+		return nil, fmt.Errorf("create evidence_raw table: %w", err)
+	}
+	if _, err := timestamp.Canonicalize(db, "evidence_raw", "created_at"); err != nil {
+		return nil, err
+	}
+	return &RawArchive{db: db}, nil
+}
+
+// Put archives the full text behind evidenceID, replacing any earlier entry.
+func (a *RawArchive) Put(evidenceID, turnID, method, fullText string) error {
+	if _, err := a.db.Exec(`INSERT OR REPLACE INTO evidence_raw (evidence_id, turn_id, method, full_text, created_at)
+		VALUES (?, ?, ?, ?, ?)`, evidenceID, turnID, method, fullText, timestamp.Now()); err != nil {
+		return fmt.Errorf("archive raw evidence: %w", err)
+	}
+	return nil
+}
+
+// Get returns the archived full text for evidenceID; ok is false if none.
+func (a *RawArchive) Get(evidenceID string) (string, bool, error) {
+	var text string
+	err := a.db.QueryRow("SELECT full_text FROM evidence_raw WHERE evidence_id = ?", evidenceID).Scan(&text)
+	if err == sql.ErrNoRows {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, fmt.Errorf("get raw evidence: %w", err)
+	}
+	return text, true, nil
+}
+
+// MigrateIDs rewrites archived evidence IDs to the current ID scheme.
+func (a *RawArchive) MigrateIDs() (IDMigration, error) {
+	return MigrateIDColumns(a.db, "evidence_raw", "evidence_id")
+}
+
+// #endregion archive
@@ -0,0 +1,33 @@
+package evidence
+
+import (
+	"database/sql"
+	"testing"
+
+	_ "modernc.org/sqlite"
+)
+
+func TestRawArchive_PutGet(t *testing.T) {
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	defer db.Close()
+	a, err := NewRawArchive(db)
+	if err != nil {
+		t.Fatalf("new archive: %v", err)
+	}
+
+	if _, ok, err := a.Get("ev-1"); ok || err != nil {
+		t.Fatalf("expected miss, got ok=%v err=%v", ok, err)
+	}
+	if err := a.Put("ev-1", "turn-1", ModeSummarize, "full text"); err != nil {
+		t.Fatalf("put: %v", err)
+	}
+	if err := a.Put("ev-1", "turn-1", ModeTruncate, "full text v2"); err != nil {
+		t.Fatalf("replace: %v", err)
+	}
+	if text, ok, err := a.Get("ev-1"); !ok || err != nil || text != "full text v2" {
+		t.Errorf("expected replaced text, got %q ok=%v err=%v", text, ok, err)
+	}
+}
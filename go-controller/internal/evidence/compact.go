@@ -0,0 +1,46 @@
+package evidence
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// #region compact
+
+// Metadata keys for compacted evidence. py-inference reads the same keys: an
+// item with MetaCompactedInto is left out of search and evicted first.
+const (
+	MetaCompactedInto = "compacted_into" // on an original: the ID of the summary that replaced it
+	MetaSummaryOf     = "summary_of"     // on a summary: the comma-separated IDs it consolidates
+)
+
+// IsCompacted reports whether metadataJSON marks the item soft-deleted by
+// compaction. Missing or malformed metadata reads as not compacted.
+func IsCompacted(metadataJSON string) bool {
+	var meta struct {
+		CompactedInto string `json:"compacted_into"`
+	}
+	return json.Unmarshal([]byte(metadataJSON), &meta) == nil && meta.CompactedInto != ""
+}
+
+// IsSummary reports whether metadataJSON marks the item a compaction summary.
+func IsSummary(metadataJSON string) bool {
+	var meta struct {
+		SummaryOf string `json:"summary_of"`
+	}
+	return json.Unmarshal([]byte(metadataJSON), &meta) == nil && meta.SummaryOf != ""
+}
+
+// SummaryOf returns the IDs a compaction summary consolidates, read from its
+// summary_of metadata; nil for any other item.
+func SummaryOf(metadataJSON string) []string {
+	var meta struct {
+		SummaryOf string `json:"summary_of"`
+	}
+	if json.Unmarshal([]byte(metadataJSON), &meta) != nil || meta.SummaryOf == "" {
+		return nil
+	}
+	return strings.Split(meta.SummaryOf, ",")
+}
+
+// #endregion compact
@@ -0,0 +1,198 @@
+package evidence
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// #region ids
+
+// Evidence ID scheme. Primary-store evidence is "ev_" + a lowercase UUID,
+// assigned by the inference service. Evidence from a secondary source is
+// "namespace::id", where the namespace names the source and id is that source's
+// own identifier. The two forms can never be confused: local IDs contain no
+// "::" and namespaces cannot start with "ev_".
+const (
+	LocalPrefix  = "ev_"
+	NamespaceSep = "::"
+)
+
+// MaxIDLen bounds any evidence ID, local or namespaced.
+const MaxIDLen = 128
+
+// ErrInvalidID is wrapped by every evidence ID validation failure.
+var ErrInvalidID = errors.New("invalid evidence id")
+
+var (
+	uuidPattern      = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}$`)
+	localPattern     = regexp.MustCompile(`^ev_[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}$`)
+	namespacePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,31}$`)
+	sourceIDPattern  = regexp.MustCompile(`^[A-Za-z0-9._:/@+-]+$`)
+)
+
+// IsLocalID reports whether id is a primary-store evidence ID.
+func IsLocalID(id string) bool {
+	return localPattern.MatchString(id)
+}
+
+// ValidateLocalID returns an error wrapping ErrInvalidID unless id is a
+// primary-store evidence ID. Use it wherever an ID is about to be written,
+// deleted, or joined against the graph.
+func ValidateLocalID(id string) error {
+	if !IsLocalID(id) {
+		return fmt.Errorf("%w: %q is not %s<uuid>", ErrInvalidID, truncateID(id), LocalPrefix)
+	}
+	return nil
+}
+
+// ValidateNamespace checks a secondary-source namespace.
+func ValidateNamespace(ns string) error {
+	if !namespacePattern.MatchString(ns) || strings.HasPrefix(ns, LocalPrefix) {
+		return fmt.Errorf("%w: namespace %q must match [a-z0-9][a-z0-9_-]* (max 32) and not start with %q",
+			ErrInvalidID, ns, LocalPrefix)
+	}
+	return nil
+}
+
+// ValidateID accepts a local ID or a well-formed namespaced ID.
+func ValidateID(id string) error {
+	ns, sourceID, namespaced := strings.Cut(id, NamespaceSep)
+	if !namespaced {
+		return ValidateLocalID(id)
+	}
+	if err := ValidateNamespace(ns); err != nil {
+		return err
+	}
+	if len(id) > MaxIDLen || !sourceIDPattern.MatchString(sourceID) || strings.Contains(sourceID, NamespaceSep) {
+		return fmt.Errorf("%w: %q has an empty, oversized, or non-printable source id", ErrInvalidID, truncateID(id))
+	}
+	return nil
+}
+
+// NamespacedID builds a secondary-source ID, validating both parts.
+func NamespacedID(ns, sourceID string) (string, error) {
+	id := ns + NamespaceSep + sourceID
+	if err := ValidateID(id); err != nil {
+		return "", err
+	}
+	return id, nil
+}
+
+// MigrateID maps an ID written before the scheme existed to its current form:
+// a bare UUID (any case) becomes ev_<lowercase uuid>, a valid ID is unchanged.
+// ok is false for an ID that cannot be salvaged.
+func MigrateID(id string) (string, bool) {
+	if ValidateID(id) == nil {
+		return id, true
+	}
+	if lower := strings.ToLower(strings.TrimSpace(id)); uuidPattern.MatchString(lower) {
+		return LocalPrefix + lower, true
+	}
+	return "", false
+}
+
+func truncateID(id string) string {
+	if len(id) > 48 {
+		return id[:48] + "…"
+	}
+	return id
+}
+
+// #endregion ids
+
+// #region id-migration
+
+// DB is the subset of *sql.DB / *sql.Tx that MigrateIDColumns needs.
+type DB interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// IDMigration counts what MigrateIDColumns changed in one table.
+type IDMigration struct {
+	Renamed int // rows whose IDs were rewritten to the current scheme
+	Dropped int // rows removed because an ID could not be salvaged
+}
+
+// MigrateIDColumns rewrites the evidence ID columns of table to the current
+// scheme. Rows with a legacy ID are renamed (or dropped if the renamed row
+// would collide with one that already exists); rows with an unsalvageable ID are
+// dropped. Already-migrated tables are left untouched, so it is safe to run on
+// every startup.
+func MigrateIDColumns(db DB, table string, cols ...string) (IDMigration, error) {
+	var m IDMigration
+	rows, err := db.Query(fmt.Sprintf("SELECT rowid, %s FROM %s", strings.Join(cols, ", "), table))
+	if err != nil {
+		return m, fmt.Errorf("scan %s ids: %w", table, err)
+	}
+	type change struct {
+		rowid int64
+		ids   []string // nil = drop
+	}
+	var changes []change
+	for rows.Next() {
+		var rowid int64
+		ids := make([]string, len(cols))
+		dest := []interface{}{&rowid}
+		for i := range ids {
+			dest = append(dest, &ids[i])
+		}
+		if err := rows.Scan(dest...); err != nil {
+			rows.Close()
+			return m, fmt.Errorf("scan %s ids: %w", table, err)
+		}
+		changed := false
+		for i, id := range ids {
+			next, ok := MigrateID(id)
+			if !ok {
+				ids = nil
+				changed = true
+				break
+			}
+			if next != id {
+				ids[i], changed = next, true
+			}
+		}
+		if changed {
+			changes = append(changes, change{rowid, ids})
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return m, fmt.Errorf("scan %s ids: %w", table, err)
+	}
+
+	sets := make([]string, len(cols))
+	for i, c := range cols {
+		sets[i] = c + " = ?"
+	}
+	update := fmt.Sprintf("UPDATE OR IGNORE %s SET %s WHERE rowid = ?", table, strings.Join(sets, ", "))
+	drop := fmt.Sprintf("DELETE FROM %s WHERE rowid = ?", table)
+	for _, c := range changes {
+		if c.ids != nil {
+			args := make([]interface{}, 0, len(c.ids)+1)
+			for _, id := range c.ids {
+				args = append(args, id)
+			}
+			res, err := db.Exec(update, append(args, c.rowid)...)
+			if err != nil {
+				return m, fmt.Errorf("migrate %s ids: %w", table, err)
+			}
+			if n, _ := res.RowsAffected(); n == 1 {
+				m.Renamed++
+				continue
+			}
+			// Renaming would collide with an existing row: the migrated copy wins
+		}
+		if _, err := db.Exec(drop, c.rowid); err != nil {
+			return m, fmt.Errorf("drop invalid %s ids: %w", table, err)
+		}
+		m.Dropped++
+	}
+	return m, nil
+}
+
+// #endregion id-migration
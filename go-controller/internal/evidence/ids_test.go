@@ -0,0 +1,101 @@
+package evidence
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+
+	_ "modernc.org/sqlite"
+)
+
+func TestValidateID(t *testing.T) {
+	cases := []struct {
+		id    string
+		local bool
+		valid bool
+	}{
+		{"ev_3f2504e0-4f89-41d3-9a0c-0305e82c3301", true, true},
+		{"ev_3F2504E0-4F89-41D3-9A0C-0305E82C3301", false, false},
+		{"3f2504e0-4f89-41d3-9a0c-0305e82c3301", false, false},
+		{"team::doc-1", false, true},
+		{"agent-b::notes/2024.md", false, true},
+		{"Team::doc-1", false, false},
+		{"ev_x::doc-1", false, false},
+		{"team::", false, false},
+		{"team::a::b", false, false},
+		{"team::has space", false, false},
+		{"", false, false},
+	}
+	for _, tc := range cases {
+		if got := IsLocalID(tc.id); got != tc.local {
+			t.Errorf("IsLocalID(%q) = %v, want %v", tc.id, got, tc.local)
+		}
+		err := ValidateID(tc.id)
+		if (err == nil) != tc.valid {
+			t.Errorf("ValidateID(%q) = %v, want valid=%v", tc.id, err, tc.valid)
+		}
+		if err != nil && !errors.Is(err, ErrInvalidID) {
+			t.Errorf("ValidateID(%q) error should wrap ErrInvalidID: %v", tc.id, err)
+		}
+	}
+}
+
+func TestNamespacedID(t *testing.T) {
+	if id, err := NamespacedID("team", "doc-1"); err != nil || id != "team::doc-1" {
+		t.Fatalf("NamespacedID = %q, %v", id, err)
+	}
+	if _, err := NamespacedID("ev_team", "doc-1"); err == nil {
+		t.Fatal("expected reserved namespace prefix rejected")
+	}
+}
+
+func TestMigrateID(t *testing.T) {
+	cases := []struct {
+		in, want string
+		ok       bool
+	}{
+		{"3F2504E0-4F89-41D3-9A0C-0305E82C3301", "ev_3f2504e0-4f89-41d3-9a0c-0305e82c3301", true},
+		{"ev_3f2504e0-4f89-41d3-9a0c-0305e82c3301", "ev_3f2504e0-4f89-41d3-9a0c-0305e82c3301", true},
+		{"team::doc-1", "team::doc-1", true},
+		{"chunk-1", "", false},
+	}
+	for _, tc := range cases {
+		if got, ok := MigrateID(tc.in); got != tc.want || ok != tc.ok {
+			t.Errorf("MigrateID(%q) = %q, %v; want %q, %v", tc.in, got, ok, tc.want, tc.ok)
+		}
+	}
+}
+
+func TestMigrateIDColumns(t *testing.T) {
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	defer db.Close()
+	a, err := NewRawArchive(db)
+	if err != nil {
+		t.Fatalf("new archive: %v", err)
+	}
+	a.Put("3F2504E0-4F89-41D3-9A0C-0305E82C3301", "turn-1", ModeTruncate, "legacy")
+	a.Put("broken id", "turn-2", ModeTruncate, "unsalvageable")
+	// Legacy row whose migrated ID already exists: the existing row wins
+	a.Put("ev_0b4e9a8c-1f6a-4c9e-8d5e-6a1d2c3b4f50", "turn-3", ModeTruncate, "current")
+	a.Put("0B4E9A8C-1F6A-4C9E-8D5E-6A1D2C3B4F50", "turn-4", ModeTruncate, "stale")
+
+	m, err := MigrateIDColumns(db, "evidence_raw", "evidence_id")
+	if err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	if m.Renamed != 1 || m.Dropped != 2 {
+		t.Fatalf("expected 1 renamed, 2 dropped, got %+v", m)
+	}
+	if text, ok, _ := a.Get("ev_3f2504e0-4f89-41d3-9a0c-0305e82c3301"); !ok || text != "legacy" {
+		t.Errorf("expected legacy row renamed, got %q ok=%v", text, ok)
+	}
+	if text, _, _ := a.Get("ev_0b4e9a8c-1f6a-4c9e-8d5e-6a1d2c3b4f50"); text != "current" {
+		t.Errorf("expected existing row kept on collision, got %q", text)
+	}
+	if m, _ := MigrateIDColumns(db, "evidence_raw", "evidence_id"); m != (IDMigration{}) {
+		t.Errorf("second run should be a no-op, got %+v", m)
+	}
+}
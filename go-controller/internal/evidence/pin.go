@@ -0,0 +1,78 @@
+package evidence
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// #region pin
+
+// Metadata keys for pinned and annotated evidence. py-inference reads the same
+// keys: a pinned item is never evicted and its search score does not decay.
+const (
+	MetaPinned = "pinned"
+	MetaNote   = "note"
+)
+
+// DefaultPinBoost is the similarity added to a pinned item's search score
+// (capped at 1), matching py-inference's PIN_BOOST default.
+const DefaultPinBoost = 0.1
+
+// MaxNoteLen bounds an annotation, in runes.
+const MaxNoteLen = 280
+
+// Annotation is the pin state and note kept in an item's metadata.
+type Annotation struct {
+	Pinned bool
+	Note   string
+}
+
+// ParseAnnotation reads the pin state and note from metadataJSON. Missing or
+// malformed metadata reads as unpinned with no note.
+func ParseAnnotation(metadataJSON string) Annotation {
+	var meta struct {
+		Pinned bool   `json:"pinned"`
+		Note   string `json:"note"`
+	}
+	if json.Unmarshal([]byte(metadataJSON), &meta) != nil {
+		return Annotation{}
+	}
+	return Annotation{Pinned: meta.Pinned, Note: meta.Note}
+}
+
+// PinBoost returns score raised by boost, capped at 1, when metadataJSON marks
+// the item pinned; otherwise score unchanged.
+func PinBoost(score float32, metadataJSON string, boost float32) float32 {
+	if !ParseAnnotation(metadataJSON).Pinned {
+		return score
+	}
+	return min(score+boost, 1)
+}
+
+// MergeMetadata applies patch to the JSON object metadataJSON and returns the
+// result: keys in patch are set, and keys whose value is nil are removed.
+func MergeMetadata(metadataJSON string, patch map[string]any) (string, error) {
+	meta := map[string]any{}
+	if metadataJSON != "" {
+		if err := json.Unmarshal([]byte(metadataJSON), &meta); err != nil {
+			return "", fmt.Errorf("decode metadata: %w", err)
+		}
+		if meta == nil { // "null"
+			meta = map[string]any{}
+		}
+	}
+	for k, v := range patch {
+		if v == nil {
+			delete(meta, k)
+		} else {
+			meta[k] = v
+		}
+	}
+	out, err := json.Marshal(meta)
+	if err != nil {
+		return "", fmt.Errorf("encode metadata: %w", err)
+	}
+	return string(out), nil
+}
+
+// #endregion pin
@@ -0,0 +1,38 @@
+package evidence
+
+import "testing"
+
+func TestMergeMetadata(t *testing.T) {
+	got, err := MergeMetadata(`{"turn_id":"turn-3","note":"old"}`, map[string]any{MetaPinned: true, MetaNote: nil})
+	if err != nil {
+		t.Fatalf("merge: %v", err)
+	}
+	if got != `{"pinned":true,"turn_id":"turn-3"}` {
+		t.Errorf("merged = %s", got)
+	}
+	if got, err := MergeMetadata("", map[string]any{MetaNote: "keep"}); err != nil || got != `{"note":"keep"}` {
+		t.Errorf("merge into empty = %s, %v", got, err)
+	}
+	if _, err := MergeMetadata("[1]", map[string]any{MetaPinned: true}); err == nil {
+		t.Error("merge into a non-object accepted")
+	}
+}
+
+func TestParseAnnotationAndPinBoost(t *testing.T) {
+	a := ParseAnnotation(`{"pinned":true,"note":"allergy"}`)
+	if !a.Pinned || a.Note != "allergy" {
+		t.Errorf("annotation = %+v", a)
+	}
+	if a := ParseAnnotation("not json"); a.Pinned || a.Note != "" {
+		t.Errorf("malformed annotation = %+v", a)
+	}
+	if got := PinBoost(0.6, `{"pinned":true}`, 0.1); got < 0.699 || got > 0.701 {
+		t.Errorf("pinned boost = %v", got)
+	}
+	if got := PinBoost(0.95, `{"pinned":true}`, 0.1); got != 1 {
+		t.Errorf("boost not capped: %v", got)
+	}
+	if got := PinBoost(0.6, `{}`, 0.1); got != 0.6 {
+		t.Errorf("unpinned boosted: %v", got)
+	}
+}
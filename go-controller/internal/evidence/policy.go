@@ -0,0 +1,202 @@
+package evidence
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/vecmath"
+)
+
+// #region policy-types
+
+// Storage modes for exchanges longer than Policy.MaxChars.
+const (
+	ModeVerbatim  = "verbatim"  // store as-is (legacy behaviour)
+	ModeTruncate  = "truncate"  // keep the head of the response
+	ModeSummarize = "summarize" // keep the most central sentences (extractive)
+)
+
+// maxSummarySentences caps the sentences embedded per exchange; longer responses truncate.
+const maxSummarySentences = 40
+
+// Embedder embeds several texts, returning one embedding per text in order.
+type Embedder func(ctx context.Context, texts []string) ([][]float32, error)
+
+// Policy decides what text is sent to StoreEvidence for an exchange. Exchanges at
+// or under MaxChars are stored verbatim; longer ones are reduced to a budget that
+// grows with entropy (uncertain turns tend to carry more new information):
+// MaxChars * (MinKeep + (1-MinKeep) * entropy).
+type Policy struct {
+	Mode     string
+	MaxChars int
+	MinKeep  float32 // budget fraction at entropy 0
+}
+
+// DefaultPolicy summarizes exchanges over 1500 chars — under retrieval's 2000-char
+// gate-3 limit, so stored evidence stays retrievable.
+func DefaultPolicy() Policy {
+	return Policy{Mode: ModeSummarize, MaxChars: 1500, MinKeep: 0.5}
+}
+
+// Selection is the text chosen for storage and how it was derived.
+type Selection struct {
+	Text        string
+	Method      string // "verbatim" | "truncate" | "summarize"
+	OriginalLen int
+}
+
+// Reduced reports whether the stored text differs from the full exchange.
+func (s Selection) Reduced() bool { return s.Method != ModeVerbatim }
+
+// #endregion policy-types
+
+// #region select
+
+// Select returns the storage text for prompt + response. Summarization falls
+// back to truncation when embed is nil or fails, or the response has too few or
+// too many sentences to summarize.
+func (p Policy) Select(ctx context.Context, prompt, response string, entropy float32, embed Embedder) Selection {
+	full := prompt + "\n" + response
+	sel := Selection{Text: full, Method: ModeVerbatim, OriginalLen: len(full)}
+	if p.Mode == ModeVerbatim || p.MaxChars <= 0 || len(full) <= p.MaxChars {
+		return sel
+	}
+
+	budget := p.budget(entropy) - len(prompt) - 1
+	if budget < 80 {
+		budget = 80 // always keep some of the response, even for huge prompts
+	}
+
+	if p.Mode == ModeSummarize && embed != nil {
+		if text, err := summarize(ctx, response, budget, embed); err == nil {
+			sel.Text, sel.Method = prompt+"\n"+text, ModeSummarize
+			return sel
+		}
+	}
+	sel.Text, sel.Method = prompt+"\n"+truncate(response, budget), ModeTruncate
+	return sel
+}
+
+// Summarizes reports whether Select would try to summarize prompt + response,
+// the only case in which it calls embed.
+func (p Policy) Summarizes(prompt, response string) bool {
+	return p.Mode == ModeSummarize && p.MaxChars > 0 && len(prompt)+1+len(response) > p.MaxChars
+}
+
+func (p Policy) budget(entropy float32) int {
+	if entropy < 0 {
+		entropy = 0
+	}
+	if entropy > 1 {
+		entropy = 1
+	}
+	keep := p.MinKeep + (1-p.MinKeep)*entropy
+	return int(float32(p.MaxChars) * keep)
+}
+
+// truncate keeps the head of s within budget bytes, cut at a word boundary.
+func truncate(s string, budget int) string {
+	if len(s) <= budget {
+		return s
+	}
+	cut := s[:budget]
+	for len(cut) > 0 && cut[len(cut)-1]&0xC0 == 0x80 {
+		cut = cut[:len(cut)-1] // don't split a UTF-8 sequence
+	}
+	if i := strings.LastIndexAny(cut, " \n"); i > budget/2 {
+		cut = cut[:i]
+	}
+	return strings.TrimSpace(cut) + " …"
+}
+
+// #endregion select
+
+// #region summarize
+
+// summarize keeps the sentences closest to the response's embedding centroid,
+// in original order, until budget bytes are used.
+func summarize(ctx context.Context, response string, budget int, embed Embedder) (string, error) {
+	sentences := splitSentences(response)
+	if len(sentences) < 3 || len(sentences) > maxSummarySentences {
+		return "", fmt.Errorf("summarize: %d sentences outside [3, %d]", len(sentences), maxSummarySentences)
+	}
+
+	vecs, err := embed(ctx, sentences)
+	if err != nil {
+		return "", fmt.Errorf("summarize embed: %w", err)
+	}
+	if len(vecs) != len(sentences) {
+		return "", fmt.Errorf("summarize: %d embeddings for %d sentences", len(vecs), len(sentences))
+	}
+	var centroid []float32
+	for _, v := range vecs {
+		if centroid == nil {
+			centroid = make([]float32, len(v))
+		}
+		if len(v) != len(centroid) {
+			return "", fmt.Errorf("summarize: inconsistent embedding dims")
+		}
+		for j := range v {
+			centroid[j] += v[j]
+		}
+	}
+
+	order := make([]int, len(sentences))
+	scores := make([]float64, len(sentences))
+	for i := range sentences {
+		order[i] = i
+		scores[i], _ = vecmath.Cosine(vecs[i], centroid)
+	}
+	sort.SliceStable(order, func(a, b int) bool { return scores[order[a]] > scores[order[b]] })
+
+	keep := make([]bool, len(sentences))
+	used := 0
+	for _, i := range order {
+		if n := len(sentences[i]) + 1; used+n <= budget {
+			keep[i] = true
+			used += n
+		}
+	}
+	var out []string
+	for i, s := range sentences {
+		if keep[i] {
+			out = append(out, s)
+		}
+	}
+	if len(out) == 0 {
+		return "", fmt.Errorf("summarize: no sentence fits budget %d", budget)
+	}
+	return strings.Join(out, " "), nil
+}
+
+// splitSentences splits on ., !, ? followed by whitespace, and on blank lines.
+func splitSentences(text string) []string {
+	var sentences []string
+	var cur strings.Builder
+	runes := []rune(strings.TrimSpace(text))
+	flush := func() {
+		if s := strings.TrimSpace(cur.String()); s != "" {
+			sentences = append(sentences, s)
+		}
+		cur.Reset()
+	}
+	for i, r := range runes {
+		cur.WriteRune(r)
+		next := rune(0)
+		if i+1 < len(runes) {
+			next = runes[i+1]
+		}
+		switch {
+		case (r == '.' || r == '!' || r == '?') && (next == ' ' || next == '\n' || next == 0):
+			flush()
+		case r == '\n' && next == '\n':
+			flush()
+		}
+	}
+	flush()
+	return sentences
+}
+
+// #endregion summarize
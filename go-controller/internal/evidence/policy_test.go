@@ -0,0 +1,95 @@
+package evidence
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// #region helpers
+
+// topicEmbed maps sentences mentioning "cache" onto one axis and everything else
+// onto another, so cache sentences dominate the centroid when they're the majority.
+func topicEmbed(_ context.Context, texts []string) ([][]float32, error) {
+	out := make([][]float32, len(texts))
+	for i, text := range texts {
+		if strings.Contains(text, "cache") {
+			out[i] = []float32{1, 0.1}
+		} else {
+			out[i] = []float32{0.1, 1}
+		}
+	}
+	return out, nil
+}
+
+func longResponse() string {
+	return strings.Join([]string{
+		"The cache stores recent embeddings keyed by text.",
+		"Unrelated aside about the weather today being mild and pleasant overall.",
+		"Each cache entry is charged against a shared byte budget.",
+		"When the cache budget is exceeded the least recently used entry goes.",
+		"Another tangent about lunch plans and sandwiches from the corner shop.",
+	}, " ")
+}
+
+// #endregion helpers
+
+// #region select-tests
+
+func TestSelect_ShortExchangeVerbatim(t *testing.T) {
+	sel := DefaultPolicy().Select(context.Background(), "hi", "hello", 0.5, topicEmbed)
+	if sel.Text != "hi\nhello" || sel.Reduced() {
+		t.Errorf("expected verbatim, got %+v", sel)
+	}
+}
+
+func TestSelect_SummarizeKeepsCentralSentencesInOrder(t *testing.T) {
+	p := Policy{Mode: ModeSummarize, MaxChars: 200, MinKeep: 1}
+	sel := p.Select(context.Background(), "how does the cache work?", longResponse(), 0, topicEmbed)
+	if sel.Method != ModeSummarize {
+		t.Fatalf("expected summarize, got %s", sel.Method)
+	}
+	if strings.Contains(sel.Text, "sandwiches") || strings.Contains(sel.Text, "weather") {
+		t.Errorf("expected off-topic sentences dropped, got %q", sel.Text)
+	}
+	first := strings.Index(sel.Text, "stores recent")
+	second := strings.Index(sel.Text, "shared byte budget")
+	if first < 0 || second < first {
+		t.Errorf("expected central sentences kept in original order, got %q", sel.Text)
+	}
+	if !strings.HasPrefix(sel.Text, "how does the cache work?\n") || sel.OriginalLen <= len(sel.Text) {
+		t.Errorf("unexpected selection: %+v", sel)
+	}
+}
+
+func TestSelect_FallsBackToTruncate(t *testing.T) {
+	p := Policy{Mode: ModeSummarize, MaxChars: 150, MinKeep: 1}
+	failing := func(context.Context, []string) ([][]float32, error) { return nil, errors.New("down") }
+	sel := p.Select(context.Background(), "q", longResponse(), 0, failing)
+	if sel.Method != ModeTruncate || !strings.HasSuffix(sel.Text, " …") || len(sel.Text) > 150+len(" …") {
+		t.Errorf("expected truncation fallback, got %+v", sel)
+	}
+}
+
+func TestSelect_VerbatimModeAndEntropyBudget(t *testing.T) {
+	if sel := (Policy{Mode: ModeVerbatim, MaxChars: 10}).Select(context.Background(), "q", longResponse(), 0, nil); sel.Reduced() {
+		t.Error("verbatim mode must never reduce")
+	}
+	p := Policy{Mode: ModeTruncate, MaxChars: 200, MinKeep: 0.5}
+	low := p.Select(context.Background(), "q", longResponse(), 0, nil)
+	high := p.Select(context.Background(), "q", longResponse(), 1, nil)
+	if len(low.Text) >= len(high.Text) {
+		t.Errorf("expected higher entropy to keep more: low=%d high=%d", len(low.Text), len(high.Text))
+	}
+}
+
+func TestSplitSentences(t *testing.T) {
+	got := splitSentences("One. Two? Three!\n\nFour without stop\nstill four. 3.14 stays")
+	want := []string{"One.", "Two?", "Three!", "Four without stop\nstill four.", "3.14 stays"}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+// #endregion select-tests
@@ -0,0 +1,285 @@
+package evidence
+
+import (
+	"context"
+	"database/sql"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/timestamp"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/vecmath"
+
+	"github.com/google/uuid"
+)
+
+// #region store
+
+// Item is one stored evidence item. Score is the search similarity and is
+// zero outside Nearest.
+type Item struct {
+	ID           string
+	Text         string
+	MetadataJSON string
+	Score        float32
+	CreatedAt    time.Time
+}
+
+// Store is an evidence store that keeps its own embeddings: the embedding of
+// each item is computed by the caller and passed to Put, and queries are
+// embedded the same way. The Python service's vector store is the other
+// implementation, reached over the codec; a Store is the one the controller
+// and the offline tools can open directly.
+type Store interface {
+	// Put stores text with its embedding under a new local evidence ID.
+	Put(ctx context.Context, text, metadataJSON string, embedding []float32) (string, error)
+	// Nearest returns the topK items most similar to query, best first,
+	// skipping any below threshold.
+	Nearest(ctx context.Context, query []float32, topK int, threshold float32) ([]Item, error)
+	// All returns every item, oldest first.
+	All(ctx context.Context) ([]Item, error)
+	// Get returns the items among ids, in input order; unknown IDs are skipped.
+	Get(ctx context.Context, ids []string) ([]Item, error)
+	// Delete removes the items among ids and returns how many existed.
+	Delete(ctx context.Context, ids []string) (int, error)
+	// Update merges patch into the metadata of item id (nil values remove
+	// keys) and returns the result; found is false when no item has the ID.
+	Update(ctx context.Context, id string, patch map[string]any) (meta string, found bool, err error)
+	// Embeddings returns the stored embedding of every item by ID.
+	Embeddings(ctx context.Context) (map[string][]float32, error)
+}
+
+// #endregion store
+
+// #region sqlite-store
+
+// SQLiteStore is a Store in the controller's own SQLite database, searched by
+// brute-force cosine similarity. That is fine for a personal memory of a few
+// thousand items; there is no recency weighting or near-duplicate filtering.
+// Pinned items get the same DefaultPinBoost as in py-inference, and compacted
+// items are left out of search the same way.
+type SQLiteStore struct {
+	db  *sql.DB
+	now func() time.Time
+}
+
+var _ Store = (*SQLiteStore)(nil)
+
+// NewSQLiteStore creates the evidence_local table if needed and returns a store.
+func NewSQLiteStore(db *sql.DB) (*SQLiteStore, error) {
+	if _, err := db.Exec(`CREATE TABLE IF NOT EXISTS evidence_local (
+		id TEXT PRIMARY KEY,
+		text TEXT NOT NULL,
+		metadata_json TEXT NOT NULL,
+		embedding BLOB NOT NULL,
+		created_at DATETIME NOT NULL
+	)`); err != nil {
+		return nil, fmt.Errorf("create evidence_local table: %w", err)
+	}
+	if _, err := timestamp.Canonicalize(db, "evidence_local", "created_at"); err != nil {
+		return nil, err
+	}
+	return &SQLiteStore{db: db, now: func() time.Time { return time.Now().UTC() }}, nil
+}
+
+// HasLocalEvidence reports whether db holds a populated evidence_local table,
+// without creating it; offline tools use it to decide whether to read
+// evidence directly.
+func HasLocalEvidence(db *sql.DB) (bool, error) {
+	var n int
+	err := db.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'evidence_local'`).Scan(&n)
+	if err != nil || n == 0 {
+		return false, err
+	}
+	if err := db.QueryRow(`SELECT EXISTS (SELECT 1 FROM evidence_local)`).Scan(&n); err != nil {
+		return false, fmt.Errorf("check local evidence: %w", err)
+	}
+	return n == 1, nil
+}
+
+// Put stores text with its embedding under a new local evidence ID.
+func (s *SQLiteStore) Put(ctx context.Context, text, metadataJSON string, embedding []float32) (string, error) {
+	if metadataJSON == "" {
+		metadataJSON = "{}"
+	}
+	id := LocalPrefix + uuid.NewString()
+	if _, err := s.db.ExecContext(ctx, `INSERT INTO evidence_local (id, text, metadata_json, embedding, created_at) VALUES (?, ?, ?, ?, ?)`,
+		id, text, metadataJSON, encodeVec(embedding), timestamp.Format(s.now())); err != nil {
+		return "", fmt.Errorf("store local evidence: %w", err)
+	}
+	return id, nil
+}
+
+// Nearest returns the topK stored items most similar to query, best first,
+// skipping any below threshold and any soft-deleted by compaction. Score is
+// the cosine similarity, raised by DefaultPinBoost for pinned items.
+func (s *SQLiteStore) Nearest(ctx context.Context, query []float32, topK int, threshold float32) ([]Item, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT id, text, metadata_json, created_at, embedding FROM evidence_local`)
+	if err != nil {
+		return nil, fmt.Errorf("search local evidence: %w", err)
+	}
+	defer rows.Close()
+
+	var out []Item
+	for rows.Next() {
+		var it Item
+		var blob []byte
+		if err := rows.Scan(&it.ID, &it.Text, &it.MetadataJSON, &it.CreatedAt, &blob); err != nil {
+			return nil, fmt.Errorf("scan local evidence: %w", err)
+		}
+		if IsCompacted(it.MetadataJSON) {
+			continue
+		}
+		sim, _ := vecmath.Cosine(query, decodeVec(blob))
+		it.Score = PinBoost(float32(sim), it.MetadataJSON, DefaultPinBoost)
+		if it.Score < threshold {
+			continue
+		}
+		out = append(out, it)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("search local evidence: %w", err)
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Score > out[j].Score })
+	if topK > 0 && len(out) > topK {
+		out = out[:topK]
+	}
+	return out, nil
+}
+
+// All returns every stored item, oldest first.
+func (s *SQLiteStore) All(ctx context.Context) ([]Item, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT id, text, metadata_json, created_at FROM evidence_local ORDER BY created_at, id`)
+	if err != nil {
+		return nil, fmt.Errorf("list local evidence: %w", err)
+	}
+	return scanItems(rows)
+}
+
+// Get returns the stored items among ids, in input order; unknown IDs are skipped.
+func (s *SQLiteStore) Get(ctx context.Context, ids []string) ([]Item, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	rows, err := s.db.QueryContext(ctx, `SELECT id, text, metadata_json, created_at FROM evidence_local WHERE id IN (`+placeholders(len(ids))+`)`,
+		anySlice(ids)...)
+	if err != nil {
+		return nil, fmt.Errorf("get local evidence: %w", err)
+	}
+	found, err := scanItems(rows)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[string]Item, len(found))
+	for _, it := range found {
+		byID[it.ID] = it
+	}
+	out := make([]Item, 0, len(found))
+	for _, id := range ids {
+		if it, ok := byID[id]; ok {
+			out = append(out, it)
+		}
+	}
+	return out, nil
+}
+
+// Delete removes the items among ids and returns how many existed.
+func (s *SQLiteStore) Delete(ctx context.Context, ids []string) (int, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	res, err := s.db.ExecContext(ctx, `DELETE FROM evidence_local WHERE id IN (`+placeholders(len(ids))+`)`, anySlice(ids)...)
+	if err != nil {
+		return 0, fmt.Errorf("delete local evidence: %w", err)
+	}
+	n, _ := res.RowsAffected()
+	return int(n), nil
+}
+
+// Update merges patch into the metadata of item id (nil values remove keys)
+// and returns the result; found is false when no item has the ID.
+func (s *SQLiteStore) Update(ctx context.Context, id string, patch map[string]any) (string, bool, error) {
+	var meta string
+	err := s.db.QueryRowContext(ctx, `SELECT metadata_json FROM evidence_local WHERE id = ?`, id).Scan(&meta)
+	if err == sql.ErrNoRows {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, fmt.Errorf("get local evidence metadata: %w", err)
+	}
+	if meta, err = MergeMetadata(meta, patch); err != nil {
+		return "", false, fmt.Errorf("update local evidence %s: %w", id, err)
+	}
+	if _, err := s.db.ExecContext(ctx, `UPDATE evidence_local SET metadata_json = ? WHERE id = ?`, meta, id); err != nil {
+		return "", false, fmt.Errorf("update local evidence metadata: %w", err)
+	}
+	return meta, true, nil
+}
+
+// Embeddings returns the stored embedding of every item by ID.
+func (s *SQLiteStore) Embeddings(ctx context.Context) (map[string][]float32, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT id, embedding FROM evidence_local`)
+	if err != nil {
+		return nil, fmt.Errorf("list local embeddings: %w", err)
+	}
+	defer rows.Close()
+	out := map[string][]float32{}
+	for rows.Next() {
+		var id string
+		var blob []byte
+		if err := rows.Scan(&id, &blob); err != nil {
+			return nil, fmt.Errorf("scan local embedding: %w", err)
+		}
+		out[id] = decodeVec(blob)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("list local embeddings: %w", err)
+	}
+	return out, nil
+}
+
+func scanItems(rows *sql.Rows) ([]Item, error) {
+	defer rows.Close()
+	var out []Item
+	for rows.Next() {
+		var it Item
+		if err := rows.Scan(&it.ID, &it.Text, &it.MetadataJSON, &it.CreatedAt); err != nil {
+			return nil, fmt.Errorf("scan local evidence: %w", err)
+		}
+		out = append(out, it)
+	}
+	return out, rows.Err()
+}
+
+func encodeVec(v []float32) []byte {
+	b := make([]byte, 4*len(v))
+	for i, f := range v {
+		binary.LittleEndian.PutUint32(b[4*i:], math.Float32bits(f))
+	}
+	return b
+}
+
+func decodeVec(b []byte) []float32 {
+	v := make([]float32, len(b)/4)
+	for i := range v {
+		v[i] = math.Float32frombits(binary.LittleEndian.Uint32(b[4*i:]))
+	}
+	return v
+}
+
+func placeholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?,", n), ",")
+}
+
+func anySlice(ids []string) []any {
+	out := make([]any, len(ids))
+	for i, id := range ids {
+		out[i] = id
+	}
+	return out
+}
+
+// #endregion sqlite-store
@@ -0,0 +1,119 @@
+package evidence
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+func newTestStore(t *testing.T) (*SQLiteStore, *sql.DB) {
+	t.Helper()
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	s, err := NewSQLiteStore(db)
+	if err != nil {
+		t.Fatalf("new store: %v", err)
+	}
+	clock := time.Date(2026, 1, 2, 15, 4, 5, 0, time.UTC)
+	s.now = func() time.Time {
+		clock = clock.Add(time.Second)
+		return clock
+	}
+	return s, db
+}
+
+func TestSQLiteStore_PutNearestGet(t *testing.T) {
+	s, _ := newTestStore(t)
+	ctx := context.Background()
+	cats, err := s.Put(ctx, "cats purr", "", []float32{1, 0, 0})
+	if err != nil {
+		t.Fatalf("put: %v", err)
+	}
+	dogs, _ := s.Put(ctx, "dogs bark", `{"turn_id":"t2"}`, []float32{0.8, 0.6, 0})
+	stars, _ := s.Put(ctx, "stars shine", "", []float32{0, 0, 1})
+	if !IsLocalID(cats) {
+		t.Errorf("id %q is not a local evidence ID", cats)
+	}
+
+	got, err := s.Nearest(ctx, []float32{1, 0, 0}, 5, 0.5)
+	if err != nil {
+		t.Fatalf("nearest: %v", err)
+	}
+	if len(got) != 2 || got[0].ID != cats || got[1].ID != dogs {
+		t.Fatalf("nearest = %+v", got)
+	}
+	if got[1].Score < 0.79 || got[1].Score > 0.81 {
+		t.Errorf("dogs score = %v, want 0.8", got[1].Score)
+	}
+	if got[0].MetadataJSON != "{}" {
+		t.Errorf("empty metadata stored as %q", got[0].MetadataJSON)
+	}
+	if top, _ := s.Nearest(ctx, []float32{1, 0, 0}, 1, 0); len(top) != 1 || top[0].ID != cats {
+		t.Errorf("top 1 = %+v", top)
+	}
+	if none, _ := s.Nearest(ctx, []float32{1, 0}, 5, 0.1); len(none) != 0 {
+		t.Errorf("mismatched dimensions matched: %+v", none)
+	}
+
+	byID, err := s.Get(ctx, []string{stars, "ev_00000000-0000-4000-8000-000000000000", cats})
+	if err != nil || len(byID) != 2 || byID[0].ID != stars || byID[1].ID != cats {
+		t.Fatalf("get = %+v, %v", byID, err)
+	}
+	all, err := s.All(ctx)
+	if err != nil || len(all) != 3 || all[0].ID != cats || all[2].ID != stars {
+		t.Fatalf("all = %+v, %v", all, err)
+	}
+	if !all[1].CreatedAt.After(all[0].CreatedAt) {
+		t.Errorf("created_at not kept: %v, %v", all[0].CreatedAt, all[1].CreatedAt)
+	}
+}
+
+func TestSQLiteStore_UpdateDeleteEmbeddings(t *testing.T) {
+	s, db := newTestStore(t)
+	ctx := context.Background()
+	if ok, err := HasLocalEvidence(db); err != nil || ok {
+		t.Fatalf("empty store has evidence: %v, %v", ok, err)
+	}
+	cats, _ := s.Put(ctx, "cats purr", `{"turn_id":"t1"}`, []float32{1, 0})
+	dogs, _ := s.Put(ctx, "dogs bark", "", []float32{0.6, 0.8})
+	if ok, err := HasLocalEvidence(db); err != nil || !ok {
+		t.Fatalf("has evidence = %v, %v", ok, err)
+	}
+
+	meta, found, err := s.Update(ctx, dogs, map[string]any{MetaPinned: true})
+	if err != nil || !found || meta != `{"pinned":true}` {
+		t.Fatalf("update = %s, %v, %v", meta, found, err)
+	}
+	if _, found, err := s.Update(ctx, "ev_00000000-0000-4000-8000-000000000000", map[string]any{MetaPinned: true}); err != nil || found {
+		t.Errorf("unknown id: found=%v, %v", found, err)
+	}
+	// dogs is 0.6 from cats; pinned, it scores 0.7
+	if got, _ := s.Nearest(ctx, []float32{1, 0}, 5, 0.65); len(got) != 2 || got[1].ID != dogs {
+		t.Errorf("pinned item not boosted: %+v", got)
+	}
+	// Compacted into a summary, cats drops out of search but not out of the store
+	if _, _, err := s.Update(ctx, cats, map[string]any{MetaCompactedInto: dogs}); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := s.Nearest(ctx, []float32{1, 0}, 5, 0); len(got) != 1 || got[0].ID != dogs {
+		t.Errorf("compacted item searched: %+v", got)
+	}
+
+	vecs, err := s.Embeddings(ctx)
+	if err != nil || len(vecs) != 2 || vecs[dogs][1] != 0.8 {
+		t.Fatalf("embeddings = %v, %v", vecs, err)
+	}
+	n, err := s.Delete(ctx, []string{cats, cats, "ev_00000000-0000-4000-8000-000000000000"})
+	if err != nil || n != 1 {
+		t.Fatalf("delete = %d, %v", n, err)
+	}
+	if all, _ := s.All(ctx); len(all) != 1 || all[0].ID != dogs {
+		t.Errorf("after delete = %+v", all)
+	}
+}
@@ -0,0 +1,158 @@
+package evidence
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/timestamp"
+)
+
+// #region usage-log
+
+// UsageLog is a timestamped log of retrieval hits: one row per evidence item
+// a turn retrieved, so usage can be read over any window.
+type UsageLog struct {
+	db *sql.DB
+}
+
+// Access is one retrieved item, in the order the turn used them.
+type Access struct {
+	ID     string
+	Score  float32
+	Walked bool // reached by graph walk rather than passing the gates itself
+}
+
+// NewUsageLog creates the evidence_access table if needed and returns a log.
+func NewUsageLog(db *sql.DB) (*UsageLog, error) {
+	if _, err := db.Exec(`CREATE TABLE IF NOT EXISTS evidence_access (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		evidence_id TEXT NOT NULL,
+		turn_id TEXT NOT NULL,
+		rank INTEGER NOT NULL,
+		score REAL NOT NULL,
+		walked INTEGER NOT NULL DEFAULT 0,
+		accessed_at TEXT NOT NULL
+	)`); err != nil {
+		return nil, fmt.Errorf("create evidence_access table: %w", err)
+	}
+	if _, err := db.Exec(`CREATE INDEX IF NOT EXISTS idx_evidence_access_at ON evidence_access(accessed_at)`); err != nil {
+		return nil, fmt.Errorf("create evidence_access index: %w", err)
+	}
+	if _, err := timestamp.Canonicalize(db, "evidence_access", "accessed_at"); err != nil {
+		return nil, err
+	}
+	return &UsageLog{db: db}, nil
+}
+
+// Record logs the items turnID retrieved, ranked by position.
+func (u *UsageLog) Record(turnID string, hits []Access) error {
+	now := timestamp.Now()
+	for rank, h := range hits {
+		if _, err := u.db.Exec(`INSERT INTO evidence_access (evidence_id, turn_id, rank, score, walked, accessed_at)
+			VALUES (?, ?, ?, ?, ?, ?)`, h.ID, turnID, rank, h.Score, h.Walked, now); err != nil {
+			return fmt.Errorf("record evidence access: %w", err)
+		}
+	}
+	return nil
+}
+
+// #endregion usage-log
+
+// #region usage-heatmap
+
+// ItemUsage is one item's retrieval hits over a window.
+type ItemUsage struct {
+	ID      string    `json:"id"`
+	Hits    int       `json:"hits"`
+	Walked  int       `json:"walked"`  // hits reached by graph walk
+	Buckets []int     `json:"buckets"` // hits per equal slice of the window, oldest first
+	LastAt  time.Time `json:"last_at"` // most recent hit ever; zero if never retrieved
+}
+
+// Heatmap returns the usage of every item ever retrieved over [since, until),
+// split into buckets equal slices, most-used first (ties by ID). Items with no
+// hits in the window are included with zero hits, so the tail of the list is
+// the least-used memories; extra IDs (e.g. the whole local store) join them
+// even if they were never retrieved.
+func (u *UsageLog) Heatmap(since, until time.Time, buckets int, extra []string) ([]ItemUsage, error) {
+	if buckets < 1 {
+		buckets = 1
+	}
+	if !until.After(since) {
+		return nil, fmt.Errorf("usage window: until %s is not after since %s", timestamp.Format(until), timestamp.Format(since))
+	}
+	byID := map[string]*ItemUsage{}
+	item := func(id string) *ItemUsage {
+		if it, ok := byID[id]; ok {
+			return it
+		}
+		it := &ItemUsage{ID: id, Buckets: make([]int, buckets)}
+		byID[id] = it
+		return it
+	}
+	for _, id := range extra {
+		item(id)
+	}
+
+	rows, err := u.db.Query(`SELECT evidence_id, MAX(accessed_at) FROM evidence_access GROUP BY evidence_id`)
+	if err != nil {
+		return nil, fmt.Errorf("query evidence usage: %w", err)
+	}
+	for rows.Next() {
+		var id, last string
+		if err := rows.Scan(&id, &last); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("scan evidence usage: %w", err)
+		}
+		item(id).LastAt, _ = timestamp.Parse(last)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("query evidence usage: %w", err)
+	}
+
+	rows, err = u.db.Query(`SELECT evidence_id, walked, accessed_at FROM evidence_access
+		WHERE accessed_at >= ? AND accessed_at < ?`, timestamp.Format(since), timestamp.Format(until))
+	if err != nil {
+		return nil, fmt.Errorf("query evidence usage: %w", err)
+	}
+	defer rows.Close()
+	width := until.Sub(since) / time.Duration(buckets)
+	for rows.Next() {
+		var id, at string
+		var walked bool
+		if err := rows.Scan(&id, &walked, &at); err != nil {
+			return nil, fmt.Errorf("scan evidence usage: %w", err)
+		}
+		t, err := timestamp.Parse(at)
+		if err != nil {
+			continue
+		}
+		it := item(id)
+		it.Hits++
+		if walked {
+			it.Walked++
+		}
+		b := int(t.Sub(since) / max(width, 1))
+		it.Buckets[min(b, buckets-1)]++
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("query evidence usage: %w", err)
+	}
+
+	out := make([]ItemUsage, 0, len(byID))
+	for _, it := range byID {
+		out = append(out, *it)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Hits != out[j].Hits {
+			return out[i].Hits > out[j].Hits
+		}
+		return out[i].ID < out[j].ID
+	})
+	return out, nil
+}
+
+// #endregion usage-heatmap
@@ -0,0 +1,68 @@
+package evidence
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/timestamp"
+	_ "modernc.org/sqlite"
+)
+
+func TestUsageLog_Heatmap(t *testing.T) {
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+	u, err := NewUsageLog(db)
+	if err != nil {
+		t.Fatalf("new usage log: %v", err)
+	}
+
+	if err := u.Record("turn-1", []Access{{ID: "ev-a", Score: 0.9}, {ID: "ev-b", Score: 0.7, Walked: true}}); err != nil {
+		t.Fatalf("record: %v", err)
+	}
+	if err := u.Record("turn-2", []Access{{ID: "ev-a", Score: 0.8}}); err != nil {
+		t.Fatalf("record: %v", err)
+	}
+	// A hit from a month ago, outside the window
+	old := time.Now().Add(-30 * 24 * time.Hour)
+	if _, err := db.Exec(`INSERT INTO evidence_access (evidence_id, turn_id, rank, score, walked, accessed_at)
+		VALUES ('ev-old', 'turn-0', 0, 0.5, 0, ?)`, timestamp.Format(old)); err != nil {
+		t.Fatalf("insert old hit: %v", err)
+	}
+
+	now := time.Now()
+	usage, err := u.Heatmap(now.Add(-4*time.Hour), now.Add(time.Minute), 4, []string{"ev-never", "ev-a"})
+	if err != nil {
+		t.Fatalf("heatmap: %v", err)
+	}
+	if len(usage) != 4 {
+		t.Fatalf("expected 4 items, got %d: %+v", len(usage), usage)
+	}
+	wantOrder := []string{"ev-a", "ev-b", "ev-never", "ev-old"}
+	wantHits := []int{2, 1, 0, 0}
+	for i, it := range usage {
+		if it.ID != wantOrder[i] || it.Hits != wantHits[i] {
+			t.Errorf("item %d = %s with %d hits, want %s with %d", i, it.ID, it.Hits, wantOrder[i], wantHits[i])
+		}
+	}
+	if a := usage[0]; a.Buckets[3] != 2 || a.Buckets[0] != 0 {
+		t.Errorf("ev-a buckets = %v, want both hits in the newest bucket", a.Buckets)
+	}
+	if usage[1].Walked != 1 {
+		t.Errorf("ev-b walked = %d, want 1", usage[1].Walked)
+	}
+	if !usage[2].LastAt.IsZero() {
+		t.Errorf("never-retrieved item has LastAt %v", usage[2].LastAt)
+	}
+	if got := usage[3].LastAt; got.Sub(old).Abs() > time.Millisecond {
+		t.Errorf("ev-old LastAt = %v, want %v", got, old)
+	}
+
+	if _, err := u.Heatmap(now, now, 4, nil); err == nil {
+		t.Error("expected an error for an empty window")
+	}
+}
@@ -0,0 +1,201 @@
+package export
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/plan"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/projection"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/state"
+)
+
+// #region types
+
+// SchemaVersion is bumped whenever Document changes incompatibly.
+const SchemaVersion = 1
+
+// Algorithm names the signature scheme in Signed.
+const Algorithm = "hmac-sha256"
+
+// Document is the compact, consumer-facing profile: the active state vector, its
+// per-segment norms, the user's most recently affirmed preferences, and the goal
+// of the active plan.
+type Document struct {
+	Schema       int                `json:"schema"`
+	StateVersion string             `json:"state_version"`
+	CommittedAt  time.Time          `json:"committed_at"`
+	GeneratedAt  time.Time          `json:"generated_at"`
+	StateVector  []float32          `json:"state_vector"`
+	SegmentMap   state.SegmentMap   `json:"segment_map"`
+	SegmentNorms map[string]float64 `json:"segment_norms"`
+	Preferences  []Preference       `json:"preferences"`
+	Goals        []Goal             `json:"goals"`
+}
+
+// Preference is one exported preference.
+type Preference struct {
+	Text   string    `json:"text"`
+	Scope  string    `json:"scope,omitempty"` // "" = every turn
+	Source string    `json:"source"`
+	Since  time.Time `json:"since"` // last stated or reinforced
+}
+
+// Goal is an active plan's goal and where it stands.
+type Goal struct {
+	Goal      string  `json:"goal"`
+	Step      string  `json:"current_step,omitempty"`
+	StepIndex int     `json:"step_index"` // -1 when every step is done
+	Steps     int     `json:"steps"`
+	Progress  float32 `json:"progress"`
+}
+
+// Signed is the document as published, with the hex HMAC-SHA256 of its compact
+// JSON encoding under the shared export key.
+type Signed struct {
+	Document  json.RawMessage `json:"document"`
+	Algorithm string          `json:"algorithm"`
+	Signature string          `json:"signature"`
+}
+
+// ErrBadSignature is returned by Verify and VerifyProfile when the signature
+// does not match.
+var ErrBadSignature = errors.New("export signature mismatch")
+
+// #endregion types
+
+// #region build
+
+// Build assembles the document from the active state, the live preferences
+// (the topN most recently stated or reinforced are kept; 0 keeps all) and the
+// active plan, which may be nil.
+func Build(rec state.StateRecord, prefs []projection.Preference, active *plan.Plan, topN int, now time.Time) Document {
+	doc := Document{
+		Schema:       SchemaVersion,
+		StateVersion: rec.VersionID,
+		CommittedAt:  rec.CreatedAt,
+		GeneratedAt:  now.UTC(),
+		StateVector:  rec.StateVector[:],
+		SegmentMap:   rec.SegmentMap,
+		SegmentNorms: rec.SegmentMap.Norms(rec.StateVector),
+		Preferences:  []Preference{},
+		Goals:        []Goal{},
+	}
+
+	for _, p := range prefs {
+		since := p.CreatedAt
+		if p.LastReinforcedAt.After(since) {
+			since = p.LastReinforcedAt
+		}
+		doc.Preferences = append(doc.Preferences, Preference{Text: p.Text, Scope: p.Scope, Source: p.Source, Since: since.UTC()})
+	}
+	sort.SliceStable(doc.Preferences, func(i, j int) bool { return doc.Preferences[i].Since.After(doc.Preferences[j].Since) })
+	if topN > 0 && len(doc.Preferences) > topN {
+		doc.Preferences = doc.Preferences[:topN]
+	}
+
+	if active != nil {
+		g := Goal{Goal: active.Goal, StepIndex: active.Current(), Steps: len(active.Steps), Progress: active.Progress()}
+		if g.StepIndex >= 0 {
+			g.Step = active.Steps[g.StepIndex].Text
+		}
+		doc.Goals = append(doc.Goals, g)
+	}
+	return doc
+}
+
+// #endregion build
+
+// #region sign-verify
+
+// Sign marshals doc and wraps it with its signature under key.
+func Sign(doc Document, key []byte) ([]byte, error) {
+	return sign(doc, key, "state export")
+}
+
+// Verify checks a signed export against key and returns its document.
+func Verify(data, key []byte) (Document, error) {
+	var doc Document
+	err := verify(data, key, &doc, "state export")
+	return doc, err
+}
+
+// sign marshals v into a Signed envelope; what names the document in errors.
+func sign(v any, key []byte, what string) ([]byte, error) {
+	if len(key) == 0 {
+		return nil, fmt.Errorf("%s: empty signing key", what)
+	}
+	body, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("marshal %s: %w", what, err)
+	}
+	out, err := json.MarshalIndent(Signed{Document: body, Algorithm: Algorithm, Signature: signature(body, key)}, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("marshal signed %s: %w", what, err)
+	}
+	return out, nil
+}
+
+// verify checks a Signed envelope against key and decodes its document into v.
+func verify(data, key []byte, v any, what string) error {
+	var s Signed
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("decode signed %s: %w", what, err)
+	}
+	if s.Algorithm != Algorithm {
+		return fmt.Errorf("%s algorithm %q: want %s", what, s.Algorithm, Algorithm)
+	}
+	// The signature covers the compact document; the envelope is indented
+	var body bytes.Buffer
+	if err := json.Compact(&body, s.Document); err != nil {
+		return fmt.Errorf("decode %s document: %w", what, err)
+	}
+	if !hmac.Equal([]byte(signature(body.Bytes(), key)), []byte(s.Signature)) {
+		return ErrBadSignature
+	}
+	if err := json.Unmarshal(s.Document, v); err != nil {
+		return fmt.Errorf("decode %s document: %w", what, err)
+	}
+	return nil
+}
+
+func signature(body, key []byte) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// #endregion sign-verify
+
+// #region write
+
+// WriteFile replaces path with data atomically, so readers never see a partial
+// document.
+func WriteFile(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".state-export-*")
+	if err != nil {
+		return fmt.Errorf("create state export: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("write state export: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("write state export: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("replace state export: %w", err)
+	}
+	return nil
+}
+
+// #endregion write
@@ -0,0 +1,89 @@
+package export
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/plan"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/projection"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/state"
+)
+
+// #region export-tests
+
+func testRecord() state.StateRecord {
+	rec := state.StateRecord{VersionID: "v7", SegmentMap: state.DefaultSegmentMap(), CreatedAt: time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)}
+	rec.StateVector[0], rec.StateVector[1] = 3, 4
+	return rec
+}
+
+func TestBuild(t *testing.T) {
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	prefs := []projection.Preference{
+		{Text: "old", Source: "explicit", CreatedAt: base},
+		{Text: "reinforced", Source: "explicit", CreatedAt: base, LastReinforcedAt: base.Add(48 * time.Hour)},
+		{Text: "new", Source: "inferred", Scope: projection.ScopeCoding, CreatedAt: base.Add(24 * time.Hour)},
+	}
+	active := &plan.Plan{Goal: "ship v2", Steps: []plan.Step{{Text: "tests", Done: true}, {Text: "docs"}}}
+
+	doc := Build(testRecord(), prefs, active, 2, base)
+	if doc.StateVersion != "v7" || doc.SegmentNorms["prefs"] != 5 || len(doc.StateVector) != 128 {
+		t.Fatalf("state fields wrong: %+v", doc)
+	}
+	if len(doc.Preferences) != 2 || doc.Preferences[0].Text != "reinforced" || doc.Preferences[1].Text != "new" {
+		t.Errorf("expected the two most recently affirmed preferences, got %+v", doc.Preferences)
+	}
+	if len(doc.Goals) != 1 || doc.Goals[0].Step != "docs" || doc.Goals[0].StepIndex != 1 || doc.Goals[0].Progress != 0.5 {
+		t.Errorf("goal wrong: %+v", doc.Goals)
+	}
+
+	empty := Build(testRecord(), nil, nil, 10, base)
+	if empty.Preferences == nil || empty.Goals == nil {
+		t.Error("empty lists should encode as [], not null")
+	}
+}
+
+func TestSignVerify(t *testing.T) {
+	key := []byte("shared-secret")
+	data, err := Sign(Build(testRecord(), nil, nil, 0, time.Now()), key)
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+	doc, err := Verify(data, key)
+	if err != nil || doc.StateVersion != "v7" {
+		t.Fatalf("verify: %v", err)
+	}
+
+	if _, err := Verify(data, []byte("other")); !errors.Is(err, ErrBadSignature) {
+		t.Errorf("wrong key: expected ErrBadSignature, got %v", err)
+	}
+	tampered := bytes.Replace(data, []byte(`"v7"`), []byte(`"v8"`), 1)
+	if _, err := Verify(tampered, key); !errors.Is(err, ErrBadSignature) {
+		t.Errorf("tampered document: expected ErrBadSignature, got %v", err)
+	}
+	if _, err := Sign(Document{}, nil); err == nil {
+		t.Error("expected error for empty key")
+	}
+}
+
+func TestWriteFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	for _, body := range []string{"first", "second"} {
+		if err := WriteFile(path, []byte(body)); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+	}
+	got, _ := os.ReadFile(path)
+	if string(got) != "second" {
+		t.Errorf("expected replaced contents, got %q", got)
+	}
+	if entries, _ := os.ReadDir(filepath.Dir(path)); len(entries) != 1 {
+		t.Errorf("temp file left behind: %d entries", len(entries))
+	}
+}
+
+// #endregion export-tests
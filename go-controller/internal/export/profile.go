@@ -0,0 +1,342 @@
+package export
+
+import (
+	"database/sql"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/projection"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/state"
+)
+
+// #region profile-types
+
+// ProfileSchemaVersion is bumped whenever Profile changes incompatibly.
+const ProfileSchemaVersion = 1
+
+// Profile is the portable personality: who the user is, what the assistant is
+// called, and the preferences and rules learned in conversation. It carries
+// no state vector, so it can seed a fresh database on another machine.
+type Profile struct {
+	Schema        int                 `json:"schema"`
+	ExportedAt    time.Time           `json:"exported_at"`
+	AIDesignation string              `json:"ai_designation,omitempty"`
+	Identity      map[string]string   `json:"identity"` // user profile fields: user_name, user_pronouns, user_honorific
+	Preferences   []ProfilePreference `json:"preferences"`
+	Rules         []ProfileRule       `json:"rules"`
+}
+
+// ProfilePreference is one exported preference.
+type ProfilePreference struct {
+	Text      string    `json:"text"`
+	Scope     string    `json:"scope,omitempty"` // "" = every turn
+	Source    string    `json:"source"`
+	Priority  int       `json:"priority"`
+	ExpiresAt time.Time `json:"expires_at,omitzero"`
+}
+
+// ProfileRule is one exported rule, with its script steps. Usage is not
+// carried over: an imported rule starts at full confidence.
+type ProfileRule struct {
+	Trigger   string        `json:"trigger"`
+	Response  string        `json:"response"`
+	Priority  int           `json:"priority"`
+	ExpiresAt time.Time     `json:"expires_at,omitzero"`
+	Steps     []ProfileStep `json:"steps,omitempty"`
+}
+
+// ProfileStep is one step of an exported rule script.
+type ProfileStep struct {
+	Expect   string `json:"expect"`
+	Response string `json:"response"`
+}
+
+// #endregion profile-types
+
+// #region profile-build
+
+// BuildProfile assembles the portable profile from the stored profile fields,
+// the active preferences and the unexpired rules.
+func BuildProfile(fields map[string]projection.ProfileField, prefs []projection.Preference, rules []projection.Rule, now time.Time) Profile {
+	p := Profile{
+		Schema:      ProfileSchemaVersion,
+		ExportedAt:  now.UTC(),
+		Identity:    map[string]string{},
+		Preferences: []ProfilePreference{},
+		Rules:       []ProfileRule{},
+	}
+	for field, f := range fields {
+		switch {
+		case f.Value == "":
+		case field == projection.ProfileAIDesignation:
+			p.AIDesignation = f.Value
+		default:
+			p.Identity[field] = f.Value
+		}
+	}
+	for _, pr := range prefs {
+		p.Preferences = append(p.Preferences, ProfilePreference{
+			Text: pr.Text, Scope: pr.Scope, Source: pr.Source, Priority: pr.Priority, ExpiresAt: utc(pr.ExpiresAt),
+		})
+	}
+	for _, r := range rules {
+		pr := ProfileRule{Trigger: r.Trigger, Response: r.Response, Priority: r.Priority, ExpiresAt: utc(r.ExpiresAt)}
+		for _, st := range r.Steps {
+			pr.Steps = append(pr.Steps, ProfileStep{Expect: st.Expect, Response: st.Response})
+		}
+		p.Rules = append(p.Rules, pr)
+	}
+	return p
+}
+
+func utc(t time.Time) time.Time {
+	if t.IsZero() {
+		return t
+	}
+	return t.UTC()
+}
+
+// SignProfile marshals p and wraps it with its signature under key.
+func SignProfile(p Profile, key []byte) ([]byte, error) {
+	return sign(p, key, "profile export")
+}
+
+// VerifyProfile checks a signed profile against key and returns it. A profile
+// from a newer schema is refused rather than half-applied.
+func VerifyProfile(data, key []byte) (Profile, error) {
+	var p Profile
+	if err := verify(data, key, &p, "profile export"); err != nil {
+		return Profile{}, err
+	}
+	if p.Schema != ProfileSchemaVersion {
+		return Profile{}, fmt.Errorf("profile export schema %d: want %d", p.Schema, ProfileSchemaVersion)
+	}
+	return p, nil
+}
+
+// #endregion profile-build
+
+// #region profile-plan
+
+// IdentityChange is a profile field an import sets.
+type IdentityChange struct {
+	Field    string
+	OldValue string
+	NewValue string
+}
+
+// PreferenceChange is one preference from an import and what it does to the
+// stored preferences.
+type PreferenceChange struct {
+	Kind string // "add" | "update" | "unchanged"
+	Pref ProfilePreference
+}
+
+// ProfilePlan is what importing a profile would write. Entries already
+// expired are listed in Skipped and not written.
+type ProfilePlan struct {
+	Identity    []IdentityChange
+	Preferences []PreferenceChange
+	Rules       []projection.RuleChange
+	Skipped     []string
+}
+
+// Writes counts the changes the plan would make.
+func (pl ProfilePlan) Writes() int {
+	n := len(pl.Identity)
+	for _, c := range pl.Preferences {
+		if c.Kind != "unchanged" {
+			n++
+		}
+	}
+	for _, c := range pl.Rules {
+		if c.Kind != "unchanged" {
+			n++
+		}
+	}
+	return n
+}
+
+// ValidateProfile returns every problem that would make p fail to import:
+// unknown profile fields, empty or malformed preferences, and rules that
+// cannot be stored.
+func ValidateProfile(p Profile) []error {
+	var errs []error
+	for field := range p.Identity {
+		if field == projection.ProfileAIDesignation || !slices.Contains(projection.ProfileFields, field) {
+			errs = append(errs, fmt.Errorf("identity: unknown field %q", field))
+		}
+	}
+	for i, pr := range p.Preferences {
+		switch {
+		case strings.TrimSpace(pr.Text) == "":
+			errs = append(errs, fmt.Errorf("preference %d: empty text", i+1))
+		case !projection.IsScope(pr.Scope):
+			errs = append(errs, fmt.Errorf("preference %d: unknown scope %q", i+1, pr.Scope))
+		case pr.Priority != 0 && !projection.IsPriority(pr.Priority):
+			errs = append(errs, fmt.Errorf("preference %d: unknown priority %d", i+1, pr.Priority))
+		}
+	}
+	for i, r := range p.Rules {
+		if strings.TrimSpace(r.Trigger) == "" || strings.TrimSpace(r.Response) == "" {
+			errs = append(errs, fmt.Errorf("rule %d: empty trigger or response", i+1))
+			continue
+		}
+		if err := projection.ValidateTrigger(r.Trigger); err != nil {
+			errs = append(errs, fmt.Errorf("rule %d: trigger %q: %w", i+1, r.Trigger, err))
+		}
+		for _, st := range r.ruleSteps() {
+			if err := projection.ValidateScriptStep(st); err != nil {
+				errs = append(errs, fmt.Errorf("rule %d: %w", i+1, err))
+			}
+		}
+	}
+	return errs
+}
+
+// PlanProfile diffs a validated profile against what is stored. Identity
+// fields the profile leaves out are kept; preferences and rules are merged,
+// a rule replacing the stored one with the same trigger.
+func PlanProfile(p Profile, fields map[string]projection.ProfileField, prefs []projection.Preference, rules []projection.Rule, now time.Time) ProfilePlan {
+	var pl ProfilePlan
+	want := map[string]string{}
+	for field, v := range p.Identity {
+		want[field] = v
+	}
+	if p.AIDesignation != "" {
+		want[projection.ProfileAIDesignation] = p.AIDesignation
+	}
+	for _, field := range projection.ProfileFields {
+		if v, ok := want[field]; ok && strings.TrimSpace(v) != "" && v != fields[field].Value {
+			pl.Identity = append(pl.Identity, IdentityChange{Field: field, OldValue: fields[field].Value, NewValue: v})
+		}
+	}
+
+	type prefKey struct{ text, scope string }
+	stored := map[prefKey]projection.Preference{}
+	for _, sp := range prefs {
+		stored[prefKey{strings.ToLower(sp.Text), sp.Scope}] = sp
+	}
+	for _, pr := range p.Preferences {
+		if pr.Priority == 0 {
+			pr.Priority = projection.PriorityNormal
+		}
+		if !pr.ExpiresAt.IsZero() && !pr.ExpiresAt.After(now) {
+			pl.Skipped = append(pl.Skipped, fmt.Sprintf("preference %q (expired %s)", pr.Text, pr.ExpiresAt.Format(time.RFC3339)))
+			continue
+		}
+		kind := "add"
+		if sp, ok := stored[prefKey{strings.ToLower(pr.Text), pr.Scope}]; ok {
+			kind = "update"
+			if sp.Priority == pr.Priority && sp.ExpiresAt.Equal(pr.ExpiresAt) {
+				kind = "unchanged"
+			}
+		}
+		pl.Preferences = append(pl.Preferences, PreferenceChange{Kind: kind, Pref: pr})
+	}
+
+	var specs []projection.RuleSpec
+	for _, r := range p.Rules {
+		if !r.ExpiresAt.IsZero() && !r.ExpiresAt.After(now) {
+			pl.Skipped = append(pl.Skipped, fmt.Sprintf("rule %q (expired %s)", r.Trigger, r.ExpiresAt.Format(time.RFC3339)))
+			continue
+		}
+		specs = append(specs, projection.RuleSpec{Trigger: r.Trigger, Response: r.Response, Priority: r.Priority,
+			ExpiresAt: r.ExpiresAt, Steps: r.ruleSteps()})
+	}
+	pl.Rules = projection.PlanRuleImport(specs, rules)
+	return pl
+}
+
+func (r ProfileRule) ruleSteps() []projection.ScriptStep {
+	var steps []projection.ScriptStep
+	for _, st := range r.Steps {
+		steps = append(steps, projection.ScriptStep{Expect: st.Expect, Response: st.Response})
+	}
+	return steps
+}
+
+// FormatProfilePlan renders a plan as a +/~/= diff with sections for the
+// identity, preferences and rules.
+func FormatProfilePlan(pl ProfilePlan) string {
+	var b strings.Builder
+	b.WriteString("identity:\n")
+	if len(pl.Identity) == 0 {
+		b.WriteString("  (no changes)\n")
+	}
+	for _, c := range pl.Identity {
+		if c.OldValue == "" {
+			fmt.Fprintf(&b, "  + %s: %q\n", c.Field, c.NewValue)
+		} else {
+			fmt.Fprintf(&b, "  ~ %s: %q → %q\n", c.Field, c.OldValue, c.NewValue)
+		}
+	}
+	b.WriteString("preferences:\n")
+	prefs := append([]PreferenceChange{}, pl.Preferences...)
+	sort.SliceStable(prefs, func(i, j int) bool { return prefs[i].Kind < prefs[j].Kind })
+	for _, c := range prefs {
+		mark := map[string]string{"add": "+", "update": "~", "unchanged": "="}[c.Kind]
+		scope := ""
+		if c.Pref.Scope != "" {
+			scope = " [" + c.Pref.Scope + "]"
+		}
+		fmt.Fprintf(&b, "  %s %q%s\n", mark, c.Pref.Text, scope)
+	}
+	b.WriteString("rules:\n")
+	for _, line := range strings.SplitAfter(projection.FormatRuleDiff(pl.Rules), "\n") {
+		if line != "" {
+			b.WriteString("  " + line)
+		}
+	}
+	for _, s := range pl.Skipped {
+		fmt.Fprintf(&b, "skipped: %s\n", s)
+	}
+	return b.String()
+}
+
+// #endregion profile-plan
+
+// #region profile-apply
+
+// ApplyProfile writes a plan in one transaction: identity fields with source
+// "import", new and changed preferences with their original source ("import"
+// if it has none), and new and changed rules. A failed write rolls the whole
+// import back. Unchanged entries are left alone, so applying twice writes
+// nothing more.
+func ApplyProfile(pl ProfilePlan, store *state.Store, profile *projection.ProfileStore, prefs *projection.PreferenceStore, rules *projection.RuleStore) error {
+	return store.WithTx(func(tx *sql.Tx) error {
+		profile, prefs, rules := profile.WithTx(tx), prefs.WithTx(tx), rules.WithTx(tx)
+		for _, c := range pl.Identity {
+			if err := profile.Set(c.Field, c.NewValue, "import"); err != nil {
+				return fmt.Errorf("import %s: %w", c.Field, err)
+			}
+		}
+		for _, c := range pl.Preferences {
+			if c.Kind == "unchanged" {
+				continue
+			}
+			opt := projection.PreferenceOptions{Scope: c.Pref.Scope, Priority: c.Pref.Priority, ExpiresAt: c.Pref.ExpiresAt}
+			source := c.Pref.Source
+			if source == "" {
+				source = "import"
+			}
+			if err := prefs.AddWithOptions(c.Pref.Text, source, opt); err != nil {
+				return fmt.Errorf("import preference %q: %w", c.Pref.Text, err)
+			}
+		}
+		for _, c := range pl.Rules {
+			if c.Kind == "unchanged" {
+				continue
+			}
+			if err := rules.AddScript(c.Spec.Trigger, c.Spec.Response, c.Spec.Steps, c.Spec.Priority, 1.0, c.Spec.ExpiresAt); err != nil {
+				return fmt.Errorf("import rule %q: %w", c.Spec.Trigger, err)
+			}
+		}
+		return nil
+	})
+}
+
+// #endregion profile-apply
@@ -0,0 +1,191 @@
+package export
+
+import (
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/projection"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/state"
+	_ "modernc.org/sqlite"
+)
+
+// #region profile-tests
+
+type profileStores struct {
+	db      *sql.DB
+	store   *state.Store
+	profile *projection.ProfileStore
+	prefs   *projection.PreferenceStore
+	rules   *projection.RuleStore
+}
+
+func openProfileStores(t *testing.T, name string) profileStores {
+	t.Helper()
+	db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), name))
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	s := profileStores{db: db, store: state.NewStoreWithDB(db)}
+	if s.profile, err = projection.NewProfileStore(db); err != nil {
+		t.Fatal(err)
+	}
+	if s.prefs, err = projection.NewPreferenceStore(db); err != nil {
+		t.Fatal(err)
+	}
+	if s.rules, err = projection.NewRuleStore(db); err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func (s profileStores) build(t *testing.T, now time.Time) Profile {
+	t.Helper()
+	fields, _ := s.profile.Get()
+	prefs, _ := s.prefs.List()
+	rules, _ := s.rules.List()
+	return BuildProfile(fields, prefs, rules, now)
+}
+
+func (s profileStores) plan(t *testing.T, p Profile, now time.Time) ProfilePlan {
+	t.Helper()
+	fields, _ := s.profile.Get()
+	prefs, _ := s.prefs.List()
+	rules, _ := s.rules.List()
+	return PlanProfile(p, fields, prefs, rules, now)
+}
+
+func TestProfileRoundTrip(t *testing.T) {
+	now := time.Now().UTC()
+	src := openProfileStores(t, "src.db")
+	src.profile.Set(projection.ProfileUserName, "Dana", "explicit")
+	src.profile.Set(projection.ProfileAIDesignation, "Orac", "explicit")
+	src.prefs.AddWithOptions("Keep answers short", "explicit", projection.PreferenceOptions{Priority: projection.PriorityHigh})
+	src.prefs.AddScoped("Use Go for examples", "inferred", projection.ScopeCoding)
+	src.rules.AddScript("knock knock", "Who's there?", []projection.ScriptStep{{Expect: "{name}", Response: "{name} who?"}}, 6, 1.0, time.Time{})
+	src.rules.Add("status", "All systems nominal", 5, 1.0)
+
+	key := []byte("shared secret")
+	data, err := SignProfile(src.build(t, now), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := VerifyProfile(data, []byte("other")); !errors.Is(err, ErrBadSignature) {
+		t.Errorf("wrong key: expected ErrBadSignature, got %v", err)
+	}
+	p, err := VerifyProfile(data, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.AIDesignation != "Orac" || p.Identity[projection.ProfileUserName] != "Dana" || len(p.Preferences) != 2 || len(p.Rules) != 2 {
+		t.Fatalf("profile = %+v", p)
+	}
+	if errs := ValidateProfile(p); len(errs) != 0 {
+		t.Fatalf("validate: %v", errs)
+	}
+
+	dst := openProfileStores(t, "dst.db")
+	dst.rules.Add("status", "old response", 5, 1.0)
+	plan := dst.plan(t, p, now)
+	if len(plan.Identity) != 2 || plan.Writes() != 6 {
+		t.Fatalf("plan = %+v, want 2 identity fields and 6 writes", plan)
+	}
+	if diff := FormatProfilePlan(plan); !strings.Contains(diff, `+ ai_designation: "Orac"`) || !strings.Contains(diff, `~ "status": "old response"`) {
+		t.Errorf("diff:\n%s", diff)
+	}
+	if err := ApplyProfile(plan, dst.store, dst.profile, dst.prefs, dst.rules); err != nil {
+		t.Fatal(err)
+	}
+
+	fields, _ := dst.profile.Get()
+	if fields[projection.ProfileAIDesignation].Value != "Orac" || fields[projection.ProfileAIDesignation].Source != "import" {
+		t.Errorf("designation = %+v", fields[projection.ProfileAIDesignation])
+	}
+	prefs, _ := dst.prefs.List()
+	if len(prefs) != 2 || prefs[0].Priority != projection.PriorityHigh || prefs[1].Scope != projection.ScopeCoding || prefs[1].Source != "inferred" {
+		t.Errorf("prefs = %+v", prefs)
+	}
+	rules, _ := dst.rules.List()
+	if len(rules) != 2 || rules[0].Trigger != "knock knock" || len(rules[0].Steps) != 1 || rules[1].Response != "All systems nominal" {
+		t.Errorf("rules = %+v", rules)
+	}
+	if again := dst.plan(t, p, now); again.Writes() != 0 {
+		t.Errorf("second import would write %d changes:\n%s", again.Writes(), FormatProfilePlan(again))
+	}
+}
+
+func TestApplyProfile_RollsBackOnFailure(t *testing.T) {
+	now := time.Now().UTC()
+	dst := openProfileStores(t, "dst.db")
+	dst.profile.Set(projection.ProfileUserName, "Dana", "explicit")
+	dst.prefs.Add("Keep answers short", "explicit")
+	p := Profile{
+		Schema:        ProfileSchemaVersion,
+		AIDesignation: "Orac",
+		Identity:      map[string]string{projection.ProfileUserName: "Avon"},
+		Preferences:   []ProfilePreference{{Text: "Use Go for examples"}},
+		Rules:         []ProfileRule{{Trigger: "knock knock", Response: "Who's there?", Steps: []ProfileStep{{Expect: "{name}", Response: "{name} who?"}}}},
+	}
+	plan := dst.plan(t, p, now)
+	if plan.Writes() != 4 {
+		t.Fatalf("plan = %+v, want 4 writes", plan)
+	}
+	// The script step insert fails after identity, preference and rule rows are written
+	if _, err := dst.db.Exec("DROP TABLE rule_steps"); err != nil {
+		t.Fatal(err)
+	}
+	if err := ApplyProfile(plan, dst.store, dst.profile, dst.prefs, dst.rules); err == nil {
+		t.Fatal("expected the rule import to fail")
+	}
+
+	fields, _ := dst.profile.Get()
+	if fields[projection.ProfileUserName].Value != "Dana" || fields[projection.ProfileAIDesignation].Value != "" {
+		t.Errorf("identity changed by a failed import: %+v", fields)
+	}
+	if prefs, _ := dst.prefs.List(); len(prefs) != 1 || prefs[0].Text != "Keep answers short" {
+		t.Errorf("prefs changed by a failed import: %+v", prefs)
+	}
+	var n int
+	if err := dst.db.QueryRow("SELECT COUNT(*) FROM rules").Scan(&n); err != nil || n != 0 {
+		t.Errorf("rules after a failed import: %d (%v)", n, err)
+	}
+}
+
+func TestValidateProfile(t *testing.T) {
+	p := Profile{
+		Schema:      ProfileSchemaVersion,
+		Identity:    map[string]string{"favourite_colour": "blue", projection.ProfileAIDesignation: "Orac"},
+		Preferences: []ProfilePreference{{Text: " "}, {Text: "x", Scope: "gardening"}, {Text: "y", Priority: 9}},
+		Rules:       []ProfileRule{{Trigger: "re:(", Response: "x"}, {Trigger: "a", Response: "b", Steps: []ProfileStep{{Expect: "{x}"}}}},
+	}
+	if errs := ValidateProfile(p); len(errs) != 7 {
+		t.Errorf("expected 7 problems, got %d: %v", len(errs), errs)
+	}
+}
+
+func TestPlanProfile_SkipsExpired(t *testing.T) {
+	now := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+	p := Profile{
+		Schema:      ProfileSchemaVersion,
+		Preferences: []ProfilePreference{{Text: "be brief this week", Source: "explicit", ExpiresAt: now.Add(-time.Hour)}},
+		Rules:       []ProfileRule{{Trigger: "ping", Response: "pong", Priority: 5, ExpiresAt: now.Add(-time.Hour)}},
+	}
+	plan := PlanProfile(p, nil, nil, nil, now)
+	if plan.Writes() != 0 || len(plan.Skipped) != 2 {
+		t.Errorf("plan = %+v, want both entries skipped", plan)
+	}
+}
+
+func TestVerifyProfile_Schema(t *testing.T) {
+	key := []byte("k")
+	data, _ := SignProfile(Profile{Schema: ProfileSchemaVersion + 1}, key)
+	if _, err := VerifyProfile(data, key); err == nil || !strings.Contains(err.Error(), "schema") {
+		t.Errorf("newer schema: got %v", err)
+	}
+}
+
+// #endregion profile-tests
@@ -0,0 +1,162 @@
+package freeze
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// #region types
+
+// Window is a recurring daily time range, optionally limited to some weekdays.
+// A window whose end is before its start wraps past midnight; its days refer to
+// the day it starts on.
+type Window struct {
+	Days  [7]bool // indexed by time.Weekday; all true when no days were given
+	Start int     // minutes after midnight, inclusive
+	End   int     // minutes after midnight, exclusive
+	Spec  string  // the window as configured, for logs and provenance
+}
+
+// Schedule is the set of freeze windows plus an always-on override. While it is
+// active the controller still retrieves and generates, but commits no state,
+// stores no evidence, and writes no preferences.
+type Schedule struct {
+	Always  bool
+	Windows []Window
+}
+
+// #endregion types
+
+// #region parse
+
+var dayNames = map[string]time.Weekday{
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+	"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+}
+
+// ParseSchedule parses semicolon-separated windows of the form
+// "[DAYS ]HH:MM-HH:MM", where DAYS is a day ("sat"), a range ("mon-fri"), or a
+// comma list ("sat,sun"). Example: "mon-fri 09:00-11:00; 22:00-06:00".
+func ParseSchedule(spec string) (Schedule, error) {
+	var s Schedule
+	for _, part := range strings.Split(spec, ";") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		w, err := parseWindow(part)
+		if err != nil {
+			return Schedule{}, fmt.Errorf("freeze window %q: %w", part, err)
+		}
+		s.Windows = append(s.Windows, w)
+	}
+	return s, nil
+}
+
+func parseWindow(spec string) (Window, error) {
+	w := Window{Spec: spec}
+	fields := strings.Fields(spec)
+	var times string
+	switch len(fields) {
+	case 1:
+		times = fields[0]
+		for d := range w.Days {
+			w.Days[d] = true
+		}
+	case 2:
+		if err := parseDays(strings.ToLower(fields[0]), &w.Days); err != nil {
+			return Window{}, err
+		}
+		times = fields[1]
+	default:
+		return Window{}, fmt.Errorf("want [DAYS ]HH:MM-HH:MM")
+	}
+	start, end, ok := strings.Cut(times, "-")
+	if !ok {
+		return Window{}, fmt.Errorf("time range %q: want HH:MM-HH:MM", times)
+	}
+	var err error
+	if w.Start, err = parseClock(start); err != nil {
+		return Window{}, err
+	}
+	if w.End, err = parseClock(end); err != nil {
+		return Window{}, err
+	}
+	if w.Start == w.End {
+		return Window{}, fmt.Errorf("empty time range %q", times)
+	}
+	return w, nil
+}
+
+func parseDays(spec string, days *[7]bool) error {
+	for _, item := range strings.Split(spec, ",") {
+		from, to, isRange := strings.Cut(item, "-")
+		a, ok := dayNames[from]
+		if !ok {
+			return fmt.Errorf("unknown day %q", from)
+		}
+		if !isRange {
+			days[a] = true
+			continue
+		}
+		b, ok := dayNames[to]
+		if !ok {
+			return fmt.Errorf("unknown day %q", to)
+		}
+		for d := a; ; d = (d + 1) % 7 {
+			days[d] = true
+			if d == b {
+				break
+			}
+		}
+	}
+	return nil
+}
+
+func parseClock(s string) (int, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("time %q: want HH:MM", s)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// #endregion parse
+
+// #region active
+
+// Active reports whether learning is frozen at now (in now's location) and why.
+func (s Schedule) Active(now time.Time) (bool, string) {
+	if s.Always {
+		return true, "FREEZE set"
+	}
+	for _, w := range s.Windows {
+		if w.contains(now) {
+			return true, "window " + w.Spec
+		}
+	}
+	return false, ""
+}
+
+// Enabled reports whether the schedule can ever freeze.
+func (s Schedule) Enabled() bool {
+	return s.Always || len(s.Windows) > 0
+}
+
+func (w Window) contains(now time.Time) bool {
+	m := now.Hour()*60 + now.Minute()
+	if w.Start < w.End {
+		return w.Days[now.Weekday()] && m >= w.Start && m < w.End
+	}
+	// Wraps midnight: the evening part belongs to today, the morning part to yesterday
+	if m >= w.Start {
+		return w.Days[now.Weekday()]
+	}
+	if m < w.End {
+		return w.Days[(now.Weekday()+6)%7]
+	}
+	return false
+}
+
+// #endregion active
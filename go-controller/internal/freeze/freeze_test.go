@@ -0,0 +1,65 @@
+package freeze
+
+import (
+	"testing"
+	"time"
+)
+
+// 2026-10-12 is a Monday.
+func at(day, hour, min int) time.Time {
+	return time.Date(2026, 10, 12+day, hour, min, 0, 0, time.UTC)
+}
+
+func TestParseScheduleAndActive(t *testing.T) {
+	s, err := ParseSchedule("mon-fri 09:00-11:00; sat,sun 22:00-06:00")
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	cases := []struct {
+		name string
+		now  time.Time
+		want bool
+	}{
+		{"monday demo start", at(0, 9, 0), true},
+		{"monday demo end excluded", at(0, 11, 0), false},
+		{"friday demo", at(4, 10, 30), true},
+		{"saturday morning outside both", at(5, 10, 0), false},
+		{"saturday night", at(5, 23, 0), true},
+		{"sunday early morning (wraps from saturday)", at(6, 5, 59), true},
+		{"monday early morning (wraps from sunday)", at(7, 1, 0), true},
+		{"tuesday early morning (monday not a night day)", at(8, 1, 0), false},
+	}
+	for _, tc := range cases {
+		if got, _ := s.Active(tc.now); got != tc.want {
+			t.Errorf("%s: Active = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestActiveReason(t *testing.T) {
+	s, _ := ParseSchedule("09:00-11:00")
+	if ok, reason := s.Active(at(2, 9, 30)); !ok || reason != "window 09:00-11:00" {
+		t.Fatalf("got %v %q", ok, reason)
+	}
+	s.Always = true
+	if ok, reason := s.Active(at(2, 15, 0)); !ok || reason != "FREEZE set" {
+		t.Fatalf("always: got %v %q", ok, reason)
+	}
+}
+
+func TestParseScheduleErrors(t *testing.T) {
+	for _, spec := range []string{
+		"09:00",
+		"9am-11am",
+		"funday 09:00-11:00",
+		"mon-fri 09:00-09:00",
+		"mon 09:00-11:00 extra",
+	} {
+		if _, err := ParseSchedule(spec); err == nil {
+			t.Errorf("%q: expected error", spec)
+		}
+	}
+	if s, err := ParseSchedule(" ; "); err != nil || s.Enabled() {
+		t.Errorf("empty spec should parse to a disabled schedule, got %+v %v", s, err)
+	}
+}
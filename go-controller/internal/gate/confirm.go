@@ -0,0 +1,74 @@
+package gate
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/state"
+)
+
+// #region confirm-learning
+
+// ConfirmPolicy holds high-impact updates that passed the gate until the user
+// agrees to them (CONFIRM_LEARNING). A zero threshold turns its check off; the
+// zero value holds nothing.
+type ConfirmPolicy struct {
+	MaxDeltaNorm  float32 // hold when the update's delta norm exceeds this
+	MaxPrefsDelta float32 // hold when the prefs segment moves further than this
+}
+
+// Enabled reports whether any threshold is set.
+func (p ConfirmPolicy) Enabled() bool {
+	return p.MaxDeltaNorm > 0 || p.MaxPrefsDelta > 0
+}
+
+// ParseConfirmPolicy reads a comma-separated "key=value" list, e.g.
+// "delta=1.5,prefs=0.5". Keys: delta (the whole update's norm) and prefs (the
+// prefs segment's share of it). An empty spec disables confirmation.
+func ParseConfirmPolicy(spec string) (ConfirmPolicy, error) {
+	var p ConfirmPolicy
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(part, "=")
+		if !ok {
+			return ConfirmPolicy{}, fmt.Errorf("confirm learning %q: want key=value", part)
+		}
+		f, err := strconv.ParseFloat(strings.TrimSpace(value), 32)
+		if err != nil || f <= 0 || math.IsInf(f, 0) {
+			return ConfirmPolicy{}, fmt.Errorf("confirm learning %q: threshold must be a positive number", part)
+		}
+		switch strings.TrimSpace(key) {
+		case "delta":
+			p.MaxDeltaNorm = float32(f)
+		case "prefs":
+			p.MaxPrefsDelta = float32(f)
+		default:
+			return ConfirmPolicy{}, fmt.Errorf("confirm learning %q: unknown key (want delta or prefs)", part)
+		}
+	}
+	return p, nil
+}
+
+// Check reports whether committing proposed over old needs the user's
+// confirmation, and why. deltaNorm is the update's norm as gated, after any
+// scaling.
+func (p ConfirmPolicy) Check(old, proposed state.StateRecord, deltaNorm float32) (string, bool) {
+	var reasons []string
+	if p.MaxDeltaNorm > 0 && deltaNorm > p.MaxDeltaNorm {
+		reasons = append(reasons, fmt.Sprintf("delta_norm %.4f > %.4f", deltaNorm, p.MaxDeltaNorm))
+	}
+	if p.MaxPrefsDelta > 0 {
+		moved := segmentNorm(vectorDelta(old.StateVector, proposed.StateVector), proposed.SegmentMap.Prefs)
+		if moved > p.MaxPrefsDelta {
+			reasons = append(reasons, fmt.Sprintf("prefs moved %.4f > %.4f", moved, p.MaxPrefsDelta))
+		}
+	}
+	return strings.Join(reasons, ", "), len(reasons) > 0
+}
+
+// #endregion confirm-learning
@@ -0,0 +1,41 @@
+package gate
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseConfirmPolicy(t *testing.T) {
+	p, err := ParseConfirmPolicy("delta=1.5, prefs=0.5")
+	if err != nil || p.MaxDeltaNorm != 1.5 || p.MaxPrefsDelta != 0.5 || !p.Enabled() {
+		t.Errorf("parse = %+v, %v", p, err)
+	}
+	if p, err := ParseConfirmPolicy(""); err != nil || p.Enabled() {
+		t.Errorf("empty spec = %+v, %v", p, err)
+	}
+	for _, bad := range []string{"delta", "delta=0", "delta=-1", "norm=2", "prefs=x"} {
+		if _, err := ParseConfirmPolicy(bad); err == nil {
+			t.Errorf("%q: expected error", bad)
+		}
+	}
+}
+
+func TestConfirmPolicyCheck(t *testing.T) {
+	old := makeState(nil)
+	prefsMove := makeState(map[int]float32{0: 0.6, 1: 0.8}) // prefs segment moves 1.0
+	goalsMove := makeState(map[int]float32{40: 3})
+
+	p := ConfirmPolicy{MaxDeltaNorm: 2, MaxPrefsDelta: 0.5}
+	if reason, held := p.Check(old, prefsMove, 1.0); !held || !strings.Contains(reason, "prefs moved 1.0000") {
+		t.Errorf("prefs change: %q, %v", reason, held)
+	}
+	if reason, held := p.Check(old, goalsMove, 3); !held || reason != "delta_norm 3.0000 > 2.0000" {
+		t.Errorf("large delta: %q, %v", reason, held)
+	}
+	if _, held := p.Check(old, makeState(map[int]float32{40: 0.5}), 0.5); held {
+		t.Error("small update should not be held")
+	}
+	if _, held := (ConfirmPolicy{}).Check(old, prefsMove, 10); held {
+		t.Error("zero policy should hold nothing")
+	}
+}
@@ -0,0 +1,268 @@
+package gate
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/state"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/update"
+)
+
+// #region policy-types
+
+// Policy decisions returned by an external policy service.
+const (
+	PolicyAllow  = "allow"
+	PolicyDeny   = "deny"
+	PolicyModify = "modify"
+)
+
+// PolicyRequest is the proposed update summary POSTed to the policy service.
+// It carries signals and norms only — never prompt or response text.
+type PolicyRequest struct {
+	TurnID       string             `json:"turn_id"`
+	VersionID    string             `json:"version_id"`
+	Entropy      float32            `json:"entropy"`
+	Signals      PolicySignals      `json:"signals"`
+	DeltaNorm    float32            `json:"delta_norm"`
+	SegmentsHit  []string           `json:"segments_hit"`
+	SegmentNorms map[string]float32 `json:"segment_norms"` // proposed state, per segment
+	SegmentDelta map[string]float32 `json:"segment_delta"` // L2 norm of the change, per segment
+	Local        PolicyLocal        `json:"local"`
+}
+
+// PolicySignals are the turn signals the local gate evaluated.
+type PolicySignals struct {
+	SentimentScore      float32 `json:"sentiment_score"`
+	CoherenceScore      float32 `json:"coherence_score"`
+	NoveltyScore        float32 `json:"novelty_score"`
+	RiskFlag            bool    `json:"risk_flag"`
+	UserCorrection      bool    `json:"user_correction"`
+	ToolFailure         bool    `json:"tool_failure"`
+	ConstraintViolation bool    `json:"constraint_violation"`
+}
+
+// PolicyLocal is the local gate's verdict, sent for context.
+type PolicyLocal struct {
+	Action    string  `json:"action"`
+	SoftScore float32 `json:"soft_score"`
+}
+
+// PolicyResponse is the policy service's answer. For "modify", DeltaScale (0-1)
+// shrinks the whole update and SegmentScale overrides it per segment.
+type PolicyResponse struct {
+	Decision     string             `json:"decision"`
+	Reason       string             `json:"reason,omitempty"`
+	DeltaScale   *float32           `json:"delta_scale,omitempty"`
+	SegmentScale map[string]float32 `json:"segment_scale,omitempty"`
+}
+
+// PolicyAudit records one consultation for provenance, whatever its outcome.
+type PolicyAudit struct {
+	Consulted bool          // false when a local veto made the call unnecessary
+	Decision  string        // policy decision, or "" on fallback
+	Reason    string        // policy reason, or the fallback cause
+	Fallback  bool          // policy unreachable/invalid; local decision used
+	Latency   time.Duration // round-trip time of the POST
+	Applied   string        // final action after combining: "commit" | "reject"
+
+	// Set only for "modify": L2 norm of the scaled delta actually proposed.
+	ScaledDeltaNorm float32
+}
+
+// #endregion policy-types
+
+// #region policy-client
+
+// PolicyClient POSTs proposed updates to an external policy endpoint.
+type PolicyClient struct {
+	url    string
+	client *http.Client
+}
+
+// NewPolicyClient returns a client for url with a per-request timeout.
+func NewPolicyClient(url string, timeout time.Duration) *PolicyClient {
+	return &PolicyClient{url: url, client: &http.Client{Timeout: timeout}}
+}
+
+// Decide sends req and returns the validated response. Any transport error,
+// non-2xx status, or unknown decision is returned as an error.
+func (p *PolicyClient) Decide(ctx context.Context, req PolicyRequest) (PolicyResponse, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return PolicyResponse{}, fmt.Errorf("policy marshal: %w", err)
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, p.url, bytes.NewReader(body))
+	if err != nil {
+		return PolicyResponse{}, fmt.Errorf("policy request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	resp, err := p.client.Do(httpReq)
+	if err != nil {
+		return PolicyResponse{}, fmt.Errorf("policy post: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return PolicyResponse{}, fmt.Errorf("policy status %d", resp.StatusCode)
+	}
+	var out PolicyResponse
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<16)).Decode(&out); err != nil {
+		return PolicyResponse{}, fmt.Errorf("policy decode: %w", err)
+	}
+	switch out.Decision {
+	case PolicyAllow, PolicyDeny:
+	case PolicyModify:
+		if out.DeltaScale == nil && len(out.SegmentScale) == 0 {
+			return PolicyResponse{}, errors.New("policy modify without delta_scale or segment_scale")
+		}
+	default:
+		return PolicyResponse{}, fmt.Errorf("policy decision %q not one of allow, deny, modify", out.Decision)
+	}
+	return out, nil
+}
+
+// #endregion policy-client
+
+// #region external-gate
+
+// ExternalGate combines the local gate with an external policy service. Local
+// hard vetoes are final (a downgrade policy may still commit them scaled); the
+// policy can only deny or shrink an update the local gate would commit. When the policy times out or misbehaves the local decision
+// stands, and the audit says so.
+type ExternalGate struct {
+	local  *Gate
+	policy *PolicyClient
+}
+
+// NewExternalGate wraps local with policy.
+func NewExternalGate(local *Gate, policy *PolicyClient) *ExternalGate {
+	return &ExternalGate{local: local, policy: policy}
+}
+
+// Evaluate runs the local gate, consults the policy if the local gate would commit,
+// and returns the combined decision with the (possibly scaled) proposed state.
+func (e *ExternalGate) Evaluate(
+	ctx context.Context,
+	turnID string,
+	old state.StateRecord,
+	proposed state.StateRecord,
+	signals update.Signals,
+	metrics update.Metrics,
+	entropy float32,
+) (GateDecision, state.StateRecord, PolicyAudit) {
+	local, proposed := e.local.EvaluateDowngrade(old, proposed, signals, metrics, entropy)
+	if !local.Commits() {
+		return local, proposed, PolicyAudit{Reason: "local veto", Applied: local.Action}
+	}
+	if local.Action == "commit_scaled" {
+		metrics.DeltaNorm *= local.Scale // the policy sees the update that would commit
+	}
+
+	start := time.Now()
+	resp, err := e.policy.Decide(ctx, buildPolicyRequest(turnID, old, proposed, signals, metrics, entropy, local))
+	audit := PolicyAudit{Consulted: true, Latency: time.Since(start)}
+	if err != nil {
+		audit.Fallback, audit.Reason, audit.Applied = true, err.Error(), local.Action
+		local.Reason += " (policy unavailable, local decision)"
+		return local, proposed, audit
+	}
+	audit.Decision, audit.Reason = resp.Decision, resp.Reason
+
+	switch resp.Decision {
+	case PolicyDeny:
+		audit.Applied = "reject"
+		return GateDecision{
+			Action:     "reject",
+			Reason:     fmt.Sprintf("policy deny: %s", resp.Reason),
+			SoftScore:  local.SoftScore,
+			Checks:     local.Checks,
+			Components: local.Components,
+		}, proposed, audit
+	case PolicyModify:
+		modified := scaleDelta(old, proposed, resp)
+		// The scaled update must still pass every local check on its own.
+		decision := e.local.Evaluate(old, modified, signals, metrics, entropy)
+		if decision.Action == "commit" {
+			decision.Reason = fmt.Sprintf("policy modify: %s; %s", resp.Reason, decision.Reason)
+			if local.Action == "commit_scaled" {
+				decision.Action, decision.Scale, decision.Downgraded = local.Action, local.Scale, local.Downgraded
+			}
+		}
+		audit.Applied = decision.Action
+		audit.ScaledDeltaNorm = vectorNorm(vectorDelta(old.StateVector, modified.StateVector))
+		return decision, modified, audit
+	default:
+		audit.Applied = local.Action
+		return local, proposed, audit
+	}
+}
+
+func buildPolicyRequest(turnID string, old, proposed state.StateRecord, signals update.Signals, metrics update.Metrics, entropy float32, local GateDecision) PolicyRequest {
+	req := PolicyRequest{
+		TurnID:    turnID,
+		VersionID: old.VersionID,
+		Entropy:   entropy,
+		Signals: PolicySignals{
+			SentimentScore:      signals.SentimentScore,
+			CoherenceScore:      signals.CoherenceScore,
+			NoveltyScore:        signals.NoveltyScore,
+			RiskFlag:            signals.RiskFlag,
+			UserCorrection:      signals.UserCorrection,
+			ToolFailure:         signals.ToolFailure,
+			ConstraintViolation: signals.ConstraintViolation,
+		},
+		DeltaNorm:    metrics.DeltaNorm,
+		SegmentsHit:  metrics.SegmentsHit,
+		SegmentNorms: make(map[string]float32),
+		SegmentDelta: make(map[string]float32),
+		Local:        PolicyLocal{Action: local.Action, SoftScore: local.SoftScore},
+	}
+	delta := vectorDelta(old.StateVector, proposed.StateVector)
+	for name, seg := range segmentsOf(proposed.SegmentMap) {
+		req.SegmentNorms[name] = segmentNorm(proposed.StateVector, seg)
+		req.SegmentDelta[name] = segmentNorm(delta, seg)
+	}
+	return req
+}
+
+// scaleDelta returns proposed with its change from old scaled per segment,
+// each factor clamped to [0, 1] so the policy can only shrink an update.
+func scaleDelta(old, proposed state.StateRecord, resp PolicyResponse) state.StateRecord {
+	global := float32(1)
+	if resp.DeltaScale != nil {
+		global = clamp01(*resp.DeltaScale)
+	}
+	out := proposed
+	for name, seg := range segmentsOf(proposed.SegmentMap) {
+		f := global
+		if s, ok := resp.SegmentScale[name]; ok {
+			f = clamp01(s)
+		}
+		for i := seg[0]; i < seg[1]; i++ {
+			out.StateVector[i] = old.StateVector[i] + f*(proposed.StateVector[i]-old.StateVector[i])
+		}
+	}
+	return out
+}
+
+func segmentsOf(m state.SegmentMap) map[string][2]int {
+	return map[string][2]int{"prefs": m.Prefs, "goals": m.Goals, "heuristics": m.Heuristics, "risk": m.Risk}
+}
+
+func clamp01(f float32) float32 {
+	if f < 0 {
+		return 0
+	}
+	if f > 1 {
+		return 1
+	}
+	return f
+}
+
+// #endregion external-gate
@@ -0,0 +1,186 @@
+package gate
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/update"
+)
+
+// policyServer returns a test server that answers every POST with resp and
+// counts calls. The decoded request is stored in *got when got is non-nil.
+func policyServer(t *testing.T, resp string, got *PolicyRequest) (*httptest.Server, *int32) {
+	t.Helper()
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		if got != nil {
+			if err := json.NewDecoder(r.Body).Decode(got); err != nil {
+				t.Errorf("decode policy request: %v", err)
+			}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(resp))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &calls
+}
+
+func newTestExternalGate(url string, timeout time.Duration) *ExternalGate {
+	return NewExternalGate(NewGate(DefaultGateConfig()), NewPolicyClient(url, timeout))
+}
+
+func TestExternalGateAllowKeepsLocalDecision(t *testing.T) {
+	var req PolicyRequest
+	srv, calls := policyServer(t, `{"decision":"allow","reason":"ok"}`, &req)
+	eg := newTestExternalGate(srv.URL, time.Second)
+	old := makeState(nil)
+	proposed := makeState(map[int]float32{0: 0.3, 40: 0.4})
+	metrics := update.Metrics{DeltaNorm: 0.5, SegmentsHit: []string{"prefs", "goals"}}
+
+	decision, out, audit := eg.Evaluate(context.Background(), "turn-1", old, proposed, update.Signals{}, metrics, 0.5)
+
+	if decision.Action != "commit" || audit.Applied != "commit" {
+		t.Fatalf("expected commit, got %s (applied %s): %s", decision.Action, audit.Applied, decision.Reason)
+	}
+	if out.StateVector != proposed.StateVector {
+		t.Fatal("allow should not change the proposed state")
+	}
+	if !audit.Consulted || audit.Fallback || audit.Decision != PolicyAllow {
+		t.Fatalf("unexpected audit: %+v", audit)
+	}
+	if *calls != 1 {
+		t.Fatalf("expected 1 policy call, got %d", *calls)
+	}
+	if req.TurnID != "turn-1" || req.Local.Action != "commit" || req.DeltaNorm != 0.5 {
+		t.Fatalf("unexpected policy request: %+v", req)
+	}
+	if math.Abs(float64(req.SegmentDelta["goals"]-0.4)) > 1e-6 || req.SegmentDelta["risk"] != 0 {
+		t.Fatalf("unexpected segment deltas: %+v", req.SegmentDelta)
+	}
+}
+
+func TestExternalGateDenyRejects(t *testing.T) {
+	srv, _ := policyServer(t, `{"decision":"deny","reason":"frozen tenant"}`, nil)
+	eg := newTestExternalGate(srv.URL, time.Second)
+
+	decision, _, audit := eg.Evaluate(context.Background(), "turn-1", makeState(nil), makeState(nil), update.Signals{}, update.Metrics{}, 0.5)
+
+	if decision.Action != "reject" || audit.Applied != "reject" {
+		t.Fatalf("expected reject, got %s", decision.Action)
+	}
+	if !strings.Contains(decision.Reason, "frozen tenant") {
+		t.Fatalf("expected policy reason in decision, got %q", decision.Reason)
+	}
+}
+
+func TestExternalGateModifyScalesDelta(t *testing.T) {
+	srv, _ := policyServer(t, `{"decision":"modify","delta_scale":0.5,"segment_scale":{"goals":0}}`, nil)
+	eg := newTestExternalGate(srv.URL, time.Second)
+	old := makeState(map[int]float32{0: 0.2})
+	proposed := makeState(map[int]float32{0: 0.6, 40: 0.4})
+
+	decision, out, audit := eg.Evaluate(context.Background(), "turn-1", old, proposed, update.Signals{}, update.Metrics{}, 0.5)
+
+	if decision.Action != "commit" {
+		t.Fatalf("expected commit, got %s: %s", decision.Action, decision.Reason)
+	}
+	if math.Abs(float64(out.StateVector[0]-0.4)) > 1e-6 {
+		t.Fatalf("expected prefs delta halved to 0.4, got %f", out.StateVector[0])
+	}
+	if out.StateVector[40] != 0 {
+		t.Fatalf("expected goals delta dropped, got %f", out.StateVector[40])
+	}
+	if math.Abs(float64(audit.ScaledDeltaNorm-0.2)) > 1e-6 {
+		t.Fatalf("expected scaled delta norm 0.2, got %f", audit.ScaledDeltaNorm)
+	}
+}
+
+func TestExternalGateModifyCannotGrowDelta(t *testing.T) {
+	srv, _ := policyServer(t, `{"decision":"modify","delta_scale":3}`, nil)
+	eg := newTestExternalGate(srv.URL, time.Second)
+	proposed := makeState(map[int]float32{0: 0.3})
+
+	_, out, _ := eg.Evaluate(context.Background(), "turn-1", makeState(nil), proposed, update.Signals{}, update.Metrics{}, 0.5)
+
+	if out.StateVector[0] != 0.3 {
+		t.Fatalf("scale should clamp to 1, got %f", out.StateVector[0])
+	}
+}
+
+func TestExternalGateTimeoutFallsBack(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(time.Second):
+		case <-r.Context().Done():
+		}
+	}))
+	t.Cleanup(srv.Close)
+	eg := newTestExternalGate(srv.URL, 20*time.Millisecond)
+
+	decision, _, audit := eg.Evaluate(context.Background(), "turn-1", makeState(nil), makeState(nil), update.Signals{}, update.Metrics{}, 0.5)
+
+	if decision.Action != "commit" {
+		t.Fatalf("expected local commit on timeout, got %s", decision.Action)
+	}
+	if !audit.Consulted || !audit.Fallback || audit.Decision != "" {
+		t.Fatalf("expected fallback audit, got %+v", audit)
+	}
+}
+
+func TestExternalGateInvalidResponseFallsBack(t *testing.T) {
+	cases := []string{
+		`{"decision":"maybe"}`,
+		`{"decision":"modify"}`,
+		`not json`,
+	}
+	for _, resp := range cases {
+		srv, _ := policyServer(t, resp, nil)
+		eg := newTestExternalGate(srv.URL, time.Second)
+
+		decision, _, audit := eg.Evaluate(context.Background(), "turn-1", makeState(nil), makeState(nil), update.Signals{}, update.Metrics{}, 0.5)
+
+		if decision.Action != "commit" || !audit.Fallback {
+			t.Errorf("%s: expected local commit with fallback, got %s %+v", resp, decision.Action, audit)
+		}
+	}
+}
+
+func TestExternalGateLocalVetoSkipsPolicy(t *testing.T) {
+	srv, calls := policyServer(t, `{"decision":"allow"}`, nil)
+	eg := newTestExternalGate(srv.URL, time.Second)
+
+	decision, _, audit := eg.Evaluate(context.Background(), "turn-1", makeState(nil), makeState(nil), update.Signals{RiskFlag: true}, update.Metrics{}, 0.5)
+
+	if decision.Action != "reject" || !decision.Vetoed {
+		t.Fatalf("expected local veto, got %s", decision.Action)
+	}
+	if audit.Consulted || *calls != 0 {
+		t.Fatalf("policy should not be consulted after a local veto (calls=%d)", *calls)
+	}
+}
+
+func TestExternalGateSendsDowngradedUpdate(t *testing.T) {
+	var req PolicyRequest
+	srv, calls := policyServer(t, `{"decision":"allow","reason":"ok"}`, &req)
+	config := DefaultGateConfig()
+	config.Downgrade = DowngradePolicy{VetoTypes: []VetoType{VetoConstraint}, MaxExcess: 0.25}
+	eg := NewExternalGate(NewGate(config), NewPolicyClient(srv.URL, time.Second))
+	proposed := makeState(map[int]float32{0: 5.5})
+
+	decision, out, audit := eg.Evaluate(context.Background(), "turn-1", makeState(nil), proposed, update.Signals{}, update.Metrics{DeltaNorm: 5.5}, 0.5)
+
+	if decision.Action != "commit_scaled" || audit.Applied != "commit_scaled" || *calls != 1 {
+		t.Fatalf("expected a consulted commit_scaled, got %s (applied %s, %d calls)", decision.Action, audit.Applied, *calls)
+	}
+	if out.StateVector[0] > 5.0 || req.DeltaNorm > 5.0 || req.Local.Action != "commit_scaled" {
+		t.Fatalf("policy should see the scaled update: state %.4f, request %+v", out.StateVector[0], req)
+	}
+}
@@ -3,6 +3,7 @@ package gate
 import (
 	"fmt"
 	"math"
+	"strings"
 
 	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/state"
 	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/update"
@@ -19,6 +20,17 @@ func NewGate(config GateConfig) *Gate {
 	return &Gate{config: config}
 }
 
+// Config returns the gate's current configuration.
+func (g *Gate) Config() GateConfig {
+	return g.config
+}
+
+// SetConfig replaces the configuration for later evaluations (a policy
+// reload). Not safe to call while another goroutine is evaluating.
+func (g *Gate) SetConfig(config GateConfig) {
+	g.config = config
+}
+
 // Evaluate checks hard vetoes first, then scores soft signals.
 // Takes the old state, proposed new state, context signals, update metrics, and entropy.
 func (g *Gate) Evaluate(
@@ -28,58 +40,51 @@ func (g *Gate) Evaluate(
 	metrics update.Metrics,
 	entropy float32,
 ) GateDecision {
+	var checks []VetoCheck
 	var vetoes []VetoSignal
+	check := func(name string, vetoType VetoType, value, threshold float32, reason string) {
+		if !g.config.VetoEnabled(name) {
+			return
+		}
+		vetoed := value > threshold
+		checks = append(checks, VetoCheck{Name: name, Type: vetoType, Value: value, Threshold: threshold, Vetoed: vetoed})
+		if vetoed {
+			vetoes = append(vetoes, VetoSignal{Type: vetoType, Reason: reason})
+		}
+	}
 
 	// --- Hard veto pass ---
 
 	// 1. Safety: RiskFlag from signals
-	if signals.RiskFlag {
-		vetoes = append(vetoes, VetoSignal{
-			Type:   VetoSafety,
-			Reason: "risk flag set in signals",
-		})
-	}
+	check("risk_flag", VetoSafety, flagValue(signals.RiskFlag), 0, "risk flag set in signals")
 
 	// 2. User correction contradicts update
-	if signals.UserCorrection {
-		vetoes = append(vetoes, VetoSignal{
-			Type:   VetoUserCorrection,
-			Reason: "user explicitly corrected prior response",
-		})
-	}
+	check("user_correction", VetoUserCorrection, flagValue(signals.UserCorrection), 0, "user explicitly corrected prior response")
 
 	// 3. Tool/verifier failure
-	if signals.ToolFailure {
-		vetoes = append(vetoes, VetoSignal{
-			Type:   VetoToolFailure,
-			Reason: "tool or verifier reported failure",
-		})
-	}
+	check("tool_failure", VetoToolFailure, flagValue(signals.ToolFailure), 0, "tool or verifier reported failure")
 
 	// 4. Constraint violation
-	if signals.ConstraintViolation {
-		vetoes = append(vetoes, VetoSignal{
-			Type:   VetoConstraint,
-			Reason: "detected contradiction with constraints",
-		})
-	}
+	check("constraint_violation", VetoConstraint, flagValue(signals.ConstraintViolation), 0, "detected contradiction with constraints")
 
 	// 5. Delta norm exceeds cap
 	deltaNorm := vectorNorm(vectorDelta(old.StateVector, proposed.StateVector))
-	if deltaNorm > g.config.MaxDeltaNorm {
-		vetoes = append(vetoes, VetoSignal{
-			Type:   VetoConstraint,
-			Reason: fmt.Sprintf("delta norm %.4f exceeds cap %.4f", deltaNorm, g.config.MaxDeltaNorm),
-		})
-	}
+	check("delta_norm", VetoConstraint, deltaNorm, g.config.MaxDeltaNorm,
+		fmt.Sprintf("delta norm %.4f exceeds cap %.4f", deltaNorm, g.config.MaxDeltaNorm))
 
-	// 6. Risk segment norm exceeds cap
-	riskNorm := segmentNorm(proposed.StateVector, proposed.SegmentMap.Risk)
-	if riskNorm > g.config.RiskSegmentCap {
-		vetoes = append(vetoes, VetoSignal{
-			Type:   VetoSafety,
-			Reason: fmt.Sprintf("risk segment norm %.4f exceeds cap %.4f", riskNorm, g.config.RiskSegmentCap),
-		})
+	// 6. Segment norms exceed caps; risk is a safety veto, the rest constraints
+	for _, s := range cappableSegments(proposed.SegmentMap) {
+		limit, ok := g.config.SegmentCap(s.name)
+		if !ok {
+			continue
+		}
+		vetoType := VetoConstraint
+		if s.name == "risk" {
+			vetoType = VetoSafety
+		}
+		norm := segmentNorm(proposed.StateVector, s.seg)
+		check(s.name+"_segment_norm", vetoType, norm, limit,
+			fmt.Sprintf("%s segment norm %.4f exceeds cap %.4f", s.name, norm, limit))
 	}
 
 	// If any hard vetoes, reject immediately
@@ -90,11 +95,13 @@ func (g *Gate) Evaluate(
 			Vetoed:      true,
 			VetoSignals: vetoes,
 			SoftScore:   0,
+			Checks:      checks,
 		}
 	}
 
 	// --- Soft scoring ---
-	softScore := computeSoftScore(old, proposed, metrics, entropy, g.config.MinEntropyDrop)
+	components := softComponents(old, metrics, entropy, g.config.EffectiveWeights())
+	softScore := sumComponents(components)
 
 	return GateDecision{
 		Action:      "commit",
@@ -102,12 +109,130 @@ func (g *Gate) Evaluate(
 		Vetoed:      false,
 		VetoSignals: nil,
 		SoftScore:   softScore,
+		Checks:      checks,
+		Components:  components,
 	}
 }
 
 // #endregion gate
 
+// #region downgrade
+
+// EvaluateDowngrade is Evaluate under the config's downgrade policy. When every
+// veto is a norm veto the policy allows, the delta is scaled down to fit every
+// cap and the scaled state is gated again; if that passes, the decision is
+// "commit_scaled" and carries the scaled state. Otherwise it is Evaluate's
+// decision with proposed unchanged.
+func (g *Gate) EvaluateDowngrade(
+	old state.StateRecord,
+	proposed state.StateRecord,
+	signals update.Signals,
+	metrics update.Metrics,
+	entropy float32,
+) (GateDecision, state.StateRecord) {
+	decision := g.Evaluate(old, proposed, signals, metrics, entropy)
+	policy := g.config.Downgrade
+	if !decision.Vetoed || !policy.Enabled() {
+		return decision, proposed
+	}
+
+	scale := float32(1)
+	for _, c := range decision.Checks {
+		if !c.Vetoed {
+			continue
+		}
+		if !policy.Allows(c) {
+			return decision, proposed
+		}
+		s, ok := g.fitScale(c.Name, old, proposed)
+		if !ok {
+			return decision, proposed
+		}
+		scale = min(scale, s)
+	}
+	// A hair under the cap, so rounding cannot trip the veto again
+	scale *= 0.9999
+
+	scaled := proposed
+	for i := range scaled.StateVector {
+		scaled.StateVector[i] = old.StateVector[i] + scale*(proposed.StateVector[i]-old.StateVector[i])
+	}
+	scaledMetrics := metrics
+	scaledMetrics.DeltaNorm *= scale
+	rescored := g.Evaluate(old, scaled, signals, scaledMetrics, entropy)
+	if rescored.Action != "commit" {
+		return decision, proposed
+	}
+
+	rescored.Action = "commit_scaled"
+	rescored.Scale = scale
+	rescored.Downgraded = decision.VetoSignals
+	rescored.Reason = fmt.Sprintf("downgraded %s: delta scaled x%.2f; %s", decision.VetoSignals[0].Reason, scale, rescored.Reason)
+	return rescored, scaled
+}
+
+// fitScale returns the largest fraction s of the proposed delta that keeps the
+// named norm check within its cap, or false when no positive fraction does
+// (a segment already over its cap before the update).
+func (g *Gate) fitScale(check string, old, proposed state.StateRecord) (float32, bool) {
+	delta := vectorDelta(old.StateVector, proposed.StateVector)
+	if check == "delta_norm" {
+		n := vectorNorm(delta)
+		if n == 0 {
+			return 0, false
+		}
+		return g.config.MaxDeltaNorm / n, true
+	}
+	for _, seg := range cappableSegments(proposed.SegmentMap) {
+		if seg.name+"_segment_norm" != check {
+			continue
+		}
+		limit, _ := g.config.SegmentCap(seg.name)
+		// |o + s·d|² = limit²: the larger root is the last s within the cap
+		var dd, od, oo float64
+		for i := seg.seg[0]; i < seg.seg[1]; i++ {
+			o, d := float64(old.StateVector[i]), float64(delta[i])
+			dd += d * d
+			od += o * d
+			oo += o * o
+		}
+		disc := od*od - dd*(oo-float64(limit)*float64(limit))
+		if dd == 0 || disc < 0 {
+			return 0, false
+		}
+		s := (-od + math.Sqrt(disc)) / dd
+		if s <= 0 {
+			return 0, false
+		}
+		return float32(math.Min(s, 1)), true
+	}
+	return 0, false
+}
+
+// isNormCheck reports whether a check measures a norm against a cap, so that
+// scaling the delta can bring it back under.
+func isNormCheck(name string) bool {
+	return name == "delta_norm" || strings.HasSuffix(name, "_segment_norm")
+}
+
+// #endregion downgrade
+
 // #region helpers
+type namedSegment struct {
+	name string
+	seg  [2]int
+}
+
+// cappableSegments lists the segments a cap may apply to, in check order.
+func cappableSegments(m state.SegmentMap) []namedSegment {
+	return []namedSegment{
+		{"prefs", m.Prefs},
+		{"goals", m.Goals},
+		{"heuristics", m.Heuristics},
+		{"risk", m.Risk},
+	}
+}
+
 // vectorDelta computes proposed - old element-wise.
 func vectorDelta(old, proposed [128]float32) [128]float32 {
 	var delta [128]float32
@@ -144,39 +269,61 @@ func computeSoftScore(
 	entropy float32,
 	minEntropyDrop float32,
 ) float32 {
-	var score float32
+	return sumComponents(softComponents(old, metrics, entropy, DefaultSoftWeights()))
+}
 
-	// Entropy component: reward entropy drop (weight 0.4)
-	oldNorm := vectorNorm(old.StateVector)
-	if oldNorm > 0 {
+// softComponents scores each term of the soft score; each scores at most its weight.
+func softComponents(old state.StateRecord, metrics update.Metrics, entropy float32, w SoftWeights) []ScoreComponent {
+	// Entropy component: reward entropy drop (default weight 0.4)
+	ent := ScoreComponent{Name: "entropy", Value: entropy, Weight: w.Entropy}
+	if vectorNorm(old.StateVector) > 0 {
 		// Use entropy as proxy — lower entropy after update is better
 		if entropy < 1.0 {
-			score += 0.4 * (1.0 - entropy)
+			ent.Score = w.Entropy * (1.0 - entropy)
 		}
 	} else {
-		score += 0.2 // neutral when no prior state
+		ent.Score = w.Entropy / 2 // neutral when no prior state
+		ent.Note = "no prior state"
 	}
 
-	// Delta stability component: smaller deltas are more stable (weight 0.3)
+	// Delta stability component: smaller deltas are more stable (default weight 0.3)
 	deltaNorm := metrics.DeltaNorm
+	stab := ScoreComponent{Name: "delta_stability", Value: deltaNorm, Weight: w.DeltaStability}
 	if deltaNorm == 0 {
-		score += 0.3 // no change = perfectly stable
+		stab.Score = w.DeltaStability // no change = perfectly stable
 	} else if deltaNorm < 1.0 {
-		score += 0.3 * (1.0 - deltaNorm)
+		stab.Score = w.DeltaStability * (1.0 - deltaNorm)
 	}
 
-	// Segments hit component: fewer segments changed = more focused (weight 0.3)
+	// Segments hit component: fewer segments changed = more focused (default weight 0.3)
 	hitCount := len(metrics.SegmentsHit)
+	focus := ScoreComponent{Name: "segment_focus", Value: float32(hitCount), Weight: w.SegmentFocus}
 	switch {
 	case hitCount == 0:
-		score += 0.3
+		focus.Score = w.SegmentFocus
 	case hitCount == 1:
-		score += 0.2
+		focus.Score = w.SegmentFocus * 2 / 3
 	case hitCount == 2:
-		score += 0.1
+		focus.Score = w.SegmentFocus / 3
 	}
 
+	return []ScoreComponent{ent, stab, focus}
+}
+
+func sumComponents(components []ScoreComponent) float32 {
+	var score float32
+	for _, c := range components {
+		score += c.Score
+	}
 	return score
 }
 
+// flagValue measures a boolean veto signal: 1 when set.
+func flagValue(set bool) float32 {
+	if set {
+		return 1
+	}
+	return 0
+}
+
 // #endregion helpers
@@ -1,6 +1,7 @@
 package gate
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/state"
@@ -148,6 +149,29 @@ func TestGateRejectOnRiskSegmentNormExceedsCap(t *testing.T) {
 	}
 }
 
+func TestGateSegmentCaps(t *testing.T) {
+	config := DefaultGateConfig()
+	config.SegmentCaps = map[string]float32{"prefs": 2.0, "risk": 20.0}
+	g := NewGate(config)
+
+	old := makeState(nil)
+	// prefs ≈ 3.46 over its cap; risk ≈ 12 is over RiskSegmentCap but within the override
+	proposed := makeState(map[int]float32{0: 2.0, 1: 2.0, 2: 2.0, 96: 12.0})
+	decision := g.Evaluate(old, proposed, update.Signals{}, update.Metrics{}, 0.5)
+
+	if decision.Action != "reject" || len(decision.VetoSignals) != 2 {
+		t.Fatalf("expected prefs and delta vetoes, got %s: %+v", decision.Action, decision.VetoSignals)
+	}
+	for _, v := range decision.VetoSignals {
+		if v.Type == VetoSafety {
+			t.Errorf("risk override should lift the risk cap, got %s", v.Reason)
+		}
+	}
+	if last := decision.VetoSignals[1]; last.Type != VetoConstraint || !strings.HasPrefix(last.Reason, "prefs segment norm") {
+		t.Errorf("expected prefs constraint veto, got %+v", last)
+	}
+}
+
 func TestGateMultipleVetoes(t *testing.T) {
 	g := NewGate(DefaultGateConfig())
 	old := makeState(nil)
@@ -273,3 +297,166 @@ func TestSoftScoreThreeOrMoreSegmentsHit(t *testing.T) {
 		t.Errorf("expected score ~0.5, got %.4f", score)
 	}
 }
+
+func TestGateBreakdownCommit(t *testing.T) {
+	g := NewGate(DefaultGateConfig())
+	old := makeState(map[int]float32{0: 1.0})
+	proposed := makeState(map[int]float32{0: 1.2})
+	metrics := update.Metrics{DeltaNorm: 0.2, SegmentsHit: []string{"prefs", "goals"}}
+
+	decision := g.Evaluate(old, proposed, update.Signals{}, metrics, 0.3)
+
+	if decision.Action != "commit" || len(decision.Components) != 3 {
+		t.Fatalf("expected commit with 3 components, got %s: %+v", decision.Action, decision.Components)
+	}
+	// entropy 0.4*0.7 + stability 0.3*0.8 + focus 0.1 = 0.62
+	want := map[string]float32{"entropy": 0.28, "delta_stability": 0.24, "segment_focus": 0.1}
+	var sum float32
+	for _, c := range decision.Components {
+		if d := c.Score - want[c.Name]; d < -1e-4 || d > 1e-4 {
+			t.Errorf("%s scored %.4f, want %.4f", c.Name, c.Score, want[c.Name])
+		}
+		sum += c.Score
+	}
+	if sum != decision.SoftScore {
+		t.Errorf("components sum to %.4f, soft score %.4f", sum, decision.SoftScore)
+	}
+	// four flags, delta norm, and the risk cap (the only default segment cap)
+	if len(decision.Checks) != 6 {
+		t.Fatalf("expected 6 checks, got %+v", decision.Checks)
+	}
+	for _, c := range decision.Checks {
+		if c.Vetoed {
+			t.Errorf("check %s vetoed a clean update", c.Name)
+		}
+	}
+	if c := decision.Checks[4]; c.Name != "delta_norm" || c.Threshold != 5.0 || c.Value < 0.19 || c.Value > 0.21 {
+		t.Errorf("delta norm check = %+v", c)
+	}
+}
+
+func TestGateBreakdownVeto(t *testing.T) {
+	config := DefaultGateConfig()
+	config.RiskSegmentCap = 2.0
+	g := NewGate(config)
+	proposed := makeState(map[int]float32{96: 3.0})
+
+	decision := g.Evaluate(makeState(nil), proposed, update.Signals{RiskFlag: true}, update.Metrics{}, 0.5)
+
+	if len(decision.Components) != 0 {
+		t.Errorf("vetoed decision has score components: %+v", decision.Components)
+	}
+	var vetoed []string
+	for _, c := range decision.Checks {
+		if c.Vetoed {
+			vetoed = append(vetoed, c.Name)
+		}
+	}
+	if strings.Join(vetoed, ",") != "risk_flag,risk_segment_norm" {
+		t.Errorf("vetoed checks = %v", vetoed)
+	}
+	r := decision.BreakdownRecord()
+	if r == nil || len(r.Checks) != len(decision.Checks) || r.Checks[0].Type != string(VetoSafety) || r.Checks[0].Value != 1 {
+		t.Errorf("breakdown record = %+v", r)
+	}
+	if (GateDecision{}).BreakdownRecord() != nil {
+		t.Error("empty decision should have no breakdown record")
+	}
+}
+
+func downgradeGate(excess float32, types ...VetoType) *Gate {
+	config := DefaultGateConfig()
+	config.Downgrade = DowngradePolicy{VetoTypes: types, MaxExcess: excess}
+	return NewGate(config)
+}
+
+func TestEvaluateDowngradeDeltaNorm(t *testing.T) {
+	g := downgradeGate(0.25, VetoConstraint)
+	old := makeState(nil)
+	proposed := makeState(map[int]float32{0: 5.5}) // delta norm 5.5, cap 5.0
+	metrics := update.Metrics{DeltaNorm: 5.5, SegmentsHit: []string{"prefs"}}
+
+	decision, scaled := g.EvaluateDowngrade(old, proposed, update.Signals{}, metrics, 0.5)
+
+	if decision.Action != "commit_scaled" || !decision.Commits() || decision.Vetoed {
+		t.Fatalf("expected commit_scaled, got %s: %s", decision.Action, decision.Reason)
+	}
+	if decision.Scale < 0.90 || decision.Scale > 0.91 {
+		t.Errorf("expected scale ~5/5.5, got %.4f", decision.Scale)
+	}
+	if n := vectorNorm(vectorDelta(old.StateVector, scaled.StateVector)); n > 5.0 {
+		t.Errorf("scaled delta norm %.4f still over the cap", n)
+	}
+	if len(decision.Downgraded) != 1 || decision.Downgraded[0].Type != VetoConstraint {
+		t.Errorf("downgraded vetoes = %+v", decision.Downgraded)
+	}
+	if !strings.HasPrefix(decision.Reason, "downgraded delta norm") {
+		t.Errorf("reason = %q", decision.Reason)
+	}
+	if proposed.StateVector[0] != 5.5 {
+		t.Error("proposed state was modified")
+	}
+}
+
+func TestEvaluateDowngradeSegmentNorm(t *testing.T) {
+	config := DefaultGateConfig()
+	config.SegmentCaps = map[string]float32{"prefs": 2.0}
+	config.Downgrade = DowngradePolicy{VetoTypes: []VetoType{VetoConstraint}, MaxExcess: 0.5}
+	g := NewGate(config)
+	old := makeState(map[int]float32{0: 1.5})
+	proposed := makeState(map[int]float32{0: 1.5, 1: 1.8}) // prefs norm ≈ 2.34 over its cap of 2
+
+	decision, scaled := g.EvaluateDowngrade(old, proposed, update.Signals{}, update.Metrics{DeltaNorm: 1.8}, 0.5)
+
+	if decision.Action != "commit_scaled" {
+		t.Fatalf("expected commit_scaled, got %s: %s", decision.Action, decision.Reason)
+	}
+	norm := segmentNorm(scaled.StateVector, scaled.SegmentMap.Prefs)
+	if norm > 2.0 || norm < 1.99 {
+		t.Errorf("scaled prefs norm %.4f, want just under 2", norm)
+	}
+}
+
+func TestEvaluateDowngradeRejects(t *testing.T) {
+	over := makeState(map[int]float32{0: 5.5})
+	cases := map[string]struct {
+		g        *Gate
+		proposed state.StateRecord
+		signals  update.Signals
+	}{
+		"disabled":        {NewGate(DefaultGateConfig()), over, update.Signals{}},
+		"type not listed": {downgradeGate(0.25, VetoSafety), over, update.Signals{}},
+		"too far over":    {downgradeGate(0.05, VetoConstraint), over, update.Signals{}},
+		"flag veto too":   {downgradeGate(0.25, VetoConstraint), over, update.Signals{ConstraintViolation: true}},
+	}
+	for name, tc := range cases {
+		decision, got := tc.g.EvaluateDowngrade(makeState(nil), tc.proposed, tc.signals, update.Metrics{DeltaNorm: 5.5}, 0.5)
+		if decision.Action != "reject" || decision.Commits() || decision.Scale != 0 {
+			t.Errorf("%s: expected reject, got %s: %s", name, decision.Action, decision.Reason)
+		}
+		if got.StateVector != tc.proposed.StateVector {
+			t.Errorf("%s: proposed state changed on reject", name)
+		}
+	}
+}
+
+func TestParseDowngrade(t *testing.T) {
+	p, err := ParseDowngrade("constraint_violation, safety_violation", 20)
+	if err != nil || len(p.VetoTypes) != 2 || p.MaxExcess != 0.2 || !p.Enabled() {
+		t.Fatalf("parse = %+v, %v", p, err)
+	}
+	if got := strings.Join(p.Types(), ","); got != "constraint_violation,safety_violation" {
+		t.Errorf("types = %s", got)
+	}
+	if p, err := ParseDowngrade("", 25); err != nil || p.Enabled() {
+		t.Errorf("empty spec = %+v, %v", p, err)
+	}
+	for _, bad := range []string{"user_correction", "bogus"} {
+		if _, err := ParseDowngrade(bad, 25); err == nil {
+			t.Errorf("%q: expected error", bad)
+		}
+	}
+	if _, err := ParseDowngrade("constraint_violation", 0); err == nil {
+		t.Error("zero percent: expected error")
+	}
+}
@@ -0,0 +1,247 @@
+package gate
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/state"
+)
+
+// #region policy-file
+
+// policyFile is the on-disk form of a gate policy (GATE_POLICY). Keys left
+// out keep the base configuration's values.
+type policyFile struct {
+	MaxDeltaNorm     float32            `json:"max_delta_norm"`
+	MaxStateNorm     float32            `json:"max_state_norm"`
+	MinEntropyDrop   float32            `json:"min_entropy_drop"`
+	RiskSegmentCap   float32            `json:"risk_segment_cap"`
+	SegmentCaps      map[string]float32 `json:"segment_caps"`
+	Weights          SoftWeights        `json:"weights"`
+	DisabledVetoes   []string           `json:"disabled_vetoes"`
+	Downgrade        []string           `json:"downgrade"`
+	DowngradePercent int                `json:"downgrade_percent"`
+}
+
+// VetoCheckNames lists the hard veto checks Evaluate runs, in order; these are
+// the names DisabledVetoes accepts.
+func VetoCheckNames() []string {
+	names := []string{"risk_flag", "user_correction", "tool_failure", "constraint_violation", "delta_norm"}
+	for _, s := range cappableSegments(state.SegmentMap{}) {
+		names = append(names, s.name+"_segment_norm")
+	}
+	return names
+}
+
+func isCappableSegment(name string) bool {
+	for _, s := range cappableSegments(state.SegmentMap{}) {
+		if s.name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// LoadPolicy reads a gate policy file over base; see ParsePolicy.
+func LoadPolicy(path string, base GateConfig) (GateConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return GateConfig{}, fmt.Errorf("read gate policy: %w", err)
+	}
+	c, err := ParsePolicy(data, base)
+	if err != nil {
+		return GateConfig{}, fmt.Errorf("%s: %w", path, err)
+	}
+	return c, nil
+}
+
+// ParsePolicy applies a gate policy to base. The policy is a JSON object, or
+// the same keys as flat YAML: "key: value" lines, with segment_caps and
+// weights as an indented map and lists as "[a, b]" or indented "- a" items.
+// Unknown keys, segments and veto names are errors.
+func ParsePolicy(data []byte, base GateConfig) (GateConfig, error) {
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) > 0 && trimmed[0] != '{' {
+		doc, err := parsePolicyYAML(string(data))
+		if err != nil {
+			return GateConfig{}, err
+		}
+		if trimmed, err = json.Marshal(doc); err != nil {
+			return GateConfig{}, fmt.Errorf("encode policy: %w", err)
+		}
+	}
+
+	pf := policyFile{
+		MaxDeltaNorm:     base.MaxDeltaNorm,
+		MaxStateNorm:     base.MaxStateNorm,
+		MinEntropyDrop:   base.MinEntropyDrop,
+		RiskSegmentCap:   base.RiskSegmentCap,
+		SegmentCaps:      base.SegmentCaps,
+		Weights:          base.EffectiveWeights(),
+		DisabledVetoes:   base.DisabledVetoes,
+		Downgrade:        base.Downgrade.Types(),
+		DowngradePercent: int(math.Round(float64(base.Downgrade.MaxExcess) * 100)),
+	}
+	if pf.DowngradePercent == 0 {
+		pf.DowngradePercent = 25
+	}
+	if len(trimmed) > 0 {
+		// Decoding into the base's map would merge; the policy's caps replace it
+		pf.SegmentCaps = nil
+		dec := json.NewDecoder(bytes.NewReader(trimmed))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&pf); err != nil {
+			return GateConfig{}, fmt.Errorf("parse policy: %w", err)
+		}
+		if dec.More() {
+			return GateConfig{}, fmt.Errorf("parse policy: trailing data after policy")
+		}
+		if pf.SegmentCaps == nil {
+			pf.SegmentCaps = base.SegmentCaps
+		}
+	}
+
+	c := base
+	c.MaxDeltaNorm, c.MaxStateNorm, c.MinEntropyDrop, c.RiskSegmentCap = pf.MaxDeltaNorm, pf.MaxStateNorm, pf.MinEntropyDrop, pf.RiskSegmentCap
+	c.SegmentCaps, c.Weights, c.DisabledVetoes = pf.SegmentCaps, pf.Weights, pf.DisabledVetoes
+	if c.MaxDeltaNorm <= 0 || c.MaxStateNorm <= 0 || c.RiskSegmentCap <= 0 {
+		return GateConfig{}, fmt.Errorf("max_delta_norm, max_state_norm and risk_segment_cap must be positive")
+	}
+	for name, limit := range c.SegmentCaps {
+		if !isCappableSegment(name) {
+			return GateConfig{}, fmt.Errorf("segment_caps: unknown segment %q", name)
+		}
+		if limit <= 0 {
+			return GateConfig{}, fmt.Errorf("segment_caps: %s cap must be positive, got %.4f", name, limit)
+		}
+	}
+	w := c.Weights
+	if w.Entropy < 0 || w.DeltaStability < 0 || w.SegmentFocus < 0 {
+		return GateConfig{}, fmt.Errorf("weights must not be negative")
+	}
+	if sum := w.Entropy + w.DeltaStability + w.SegmentFocus; sum == 0 || sum > 1.0001 {
+		return GateConfig{}, fmt.Errorf("weights must sum to more than 0 and at most 1, got %.4f", sum)
+	}
+	known := VetoCheckNames()
+	for _, name := range c.DisabledVetoes {
+		if !contains(known, name) {
+			return GateConfig{}, fmt.Errorf("disabled_vetoes: unknown veto %q (one of %s)", name, strings.Join(known, ", "))
+		}
+	}
+	downgrade, err := ParseDowngrade(strings.Join(pf.Downgrade, ","), pf.DowngradePercent)
+	if err != nil {
+		return GateConfig{}, err
+	}
+	c.Downgrade = downgrade
+	return c, nil
+}
+
+// #endregion policy-file
+
+// #region policy-yaml
+
+// parsePolicyYAML reads the flat YAML subset ParsePolicy accepts into a
+// document for the JSON decoder.
+func parsePolicyYAML(text string) (map[string]any, error) {
+	doc := map[string]any{}
+	var key string // top-level key awaiting indented items
+	for i, raw := range strings.Split(text, "\n") {
+		lineNo := i + 1
+		line := raw
+		if idx := strings.Index(line, "#"); idx >= 0 {
+			line = line[:idx]
+		}
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || trimmed == "---" {
+			continue
+		}
+
+		if line[0] == ' ' || line[0] == '\t' {
+			if key == "" {
+				return nil, fmt.Errorf("line %d: indented line without a key", lineNo)
+			}
+			if item, ok := strings.CutPrefix(trimmed, "-"); ok {
+				list, _ := doc[key].([]any)
+				if doc[key] != nil && list == nil {
+					return nil, fmt.Errorf("line %d: %s mixes list items and keys", lineNo, key)
+				}
+				doc[key] = append(list, yamlScalar(item))
+				continue
+			}
+			k, v, ok := strings.Cut(trimmed, ":")
+			if !ok {
+				return nil, fmt.Errorf("line %d: expected \"name: value\"", lineNo)
+			}
+			m, _ := doc[key].(map[string]any)
+			if m == nil {
+				if doc[key] != nil {
+					return nil, fmt.Errorf("line %d: %s mixes list items and keys", lineNo, key)
+				}
+				m = map[string]any{}
+				doc[key] = m
+			}
+			m[strings.TrimSpace(k)] = yamlScalar(v)
+			continue
+		}
+
+		k, v, ok := strings.Cut(trimmed, ":")
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected \"key: value\"", lineNo)
+		}
+		key = strings.TrimSpace(k)
+		if _, dup := doc[key]; dup {
+			return nil, fmt.Errorf("line %d: duplicate key %q", lineNo, key)
+		}
+		doc[key] = nil
+		if v = strings.TrimSpace(v); v != "" {
+			doc[key] = yamlValue(v)
+			key = ""
+		}
+	}
+	return doc, nil
+}
+
+// yamlValue parses an inline value: a "[a, b]" list or a scalar.
+func yamlValue(v string) any {
+	if inner, ok := strings.CutPrefix(v, "["); ok {
+		items := []any{}
+		for _, item := range strings.Split(strings.TrimSuffix(inner, "]"), ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, yamlScalar(item))
+			}
+		}
+		return items
+	}
+	return yamlScalar(v)
+}
+
+// yamlScalar parses a number, boolean or (optionally quoted) string.
+func yamlScalar(v string) any {
+	v = strings.TrimSpace(v)
+	if f, err := strconv.ParseFloat(v, 64); err == nil {
+		return f
+	}
+	switch v {
+	case "true":
+		return true
+	case "false":
+		return false
+	}
+	return strings.Trim(v, `"'`)
+}
+
+// #endregion policy-yaml
@@ -0,0 +1,146 @@
+package gate
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/update"
+)
+
+const yamlPolicy = `# tighter caps for a shared deployment
+max_delta_norm: 3.5
+segment_caps:
+  prefs: 4
+  goals: 6
+weights:
+  entropy: 0.5
+  delta_stability: 0.25
+  segment_focus: 0.25
+disabled_vetoes:
+  - tool_failure
+downgrade: [constraint_violation]
+downgrade_percent: 10
+`
+
+func TestParsePolicyYAML(t *testing.T) {
+	c, err := ParsePolicy([]byte(yamlPolicy), DefaultGateConfig())
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if c.MaxDeltaNorm != 3.5 || c.SegmentCaps["prefs"] != 4 || c.SegmentCaps["goals"] != 6 {
+		t.Errorf("caps = %.2f %v", c.MaxDeltaNorm, c.SegmentCaps)
+	}
+	if c.RiskSegmentCap != 10 || c.MaxStateNorm != 50 {
+		t.Errorf("keys left out should keep the base: risk %.2f, state %.2f", c.RiskSegmentCap, c.MaxStateNorm)
+	}
+	if c.Weights != (SoftWeights{Entropy: 0.5, DeltaStability: 0.25, SegmentFocus: 0.25}) {
+		t.Errorf("weights = %+v", c.Weights)
+	}
+	if len(c.DisabledVetoes) != 1 || c.VetoEnabled("tool_failure") || !c.VetoEnabled("risk_flag") {
+		t.Errorf("disabled vetoes = %v", c.DisabledVetoes)
+	}
+	if c.Downgrade.MaxExcess != 0.1 || strings.Join(c.Downgrade.Types(), ",") != "constraint_violation" {
+		t.Errorf("downgrade = %+v", c.Downgrade)
+	}
+}
+
+func TestParsePolicyJSON(t *testing.T) {
+	base := DefaultGateConfig()
+	base.SegmentCaps = map[string]float32{"heuristics": 2}
+	base.Downgrade = DowngradePolicy{VetoTypes: []VetoType{VetoSafety}, MaxExcess: 0.3}
+	c, err := ParsePolicy([]byte(`{"risk_segment_cap": 8, "segment_caps": {"goals": 5}, "weights": {"entropy": 0.2}}`), base)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if c.RiskSegmentCap != 8 || len(c.SegmentCaps) != 1 || c.SegmentCaps["goals"] != 5 {
+		t.Errorf("segment_caps should replace the base's: risk %.2f, %v", c.RiskSegmentCap, c.SegmentCaps)
+	}
+	if c.Weights != (SoftWeights{Entropy: 0.2, DeltaStability: 0.3, SegmentFocus: 0.3}) {
+		t.Errorf("weights = %+v", c.Weights)
+	}
+	if c.Downgrade.MaxExcess != 0.3 || !c.Downgrade.Enabled() {
+		t.Errorf("downgrade should keep the base: %+v", c.Downgrade)
+	}
+
+	if c, err := ParsePolicy(nil, base); err != nil || c.SegmentCaps["heuristics"] != 2 {
+		t.Errorf("empty policy = %+v, %v", c, err)
+	}
+}
+
+func TestParsePolicyRejects(t *testing.T) {
+	for name, policy := range map[string]string{
+		"unknown key":      "max_delta: 3",
+		"unknown segment":  "segment_caps:\n  mood: 2",
+		"zero cap":         `{"max_delta_norm": 0}`,
+		"negative weight":  "weights:\n  entropy: -0.1",
+		"weights over one": "weights:\n  entropy: 0.9",
+		"unknown veto":     "disabled_vetoes: [risk]",
+		"flag downgrade":   "downgrade: [tool_failure]",
+		"not a mapping":    "- max_delta_norm: 3",
+		"mixed nesting":    "segment_caps:\n  prefs: 2\n  - goals",
+		"trailing junk":    `{"max_delta_norm": 3} x`,
+	} {
+		if _, err := ParsePolicy([]byte(policy), DefaultGateConfig()); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+func TestLoadPolicy(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "gate-policy.yaml")
+	if err := os.WriteFile(path, []byte(yamlPolicy), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if c, err := LoadPolicy(path, DefaultGateConfig()); err != nil || c.MaxDeltaNorm != 3.5 {
+		t.Errorf("load = %.2f, %v", c.MaxDeltaNorm, err)
+	}
+	if _, err := LoadPolicy(filepath.Join(t.TempDir(), "missing.yaml"), DefaultGateConfig()); err == nil {
+		t.Error("missing file: expected error")
+	}
+}
+
+func TestGatePolicyDisabledVeto(t *testing.T) {
+	config := DefaultGateConfig()
+	config.DisabledVetoes = []string{"tool_failure"}
+	g := NewGate(config)
+	old, proposed := makeState(nil), makeState(nil)
+	metrics := update.Metrics{SegmentsHit: []string{}}
+
+	if d := g.Evaluate(old, proposed, update.Signals{ToolFailure: true}, metrics, 0.5); d.Action != "commit" {
+		t.Errorf("disabled tool_failure veto: got %s: %s", d.Action, d.Reason)
+	}
+	d := g.Evaluate(old, proposed, update.Signals{ToolFailure: true, RiskFlag: true}, metrics, 0.5)
+	if d.Action != "reject" || d.VetoSignals[0].Type != VetoSafety {
+		t.Errorf("other vetoes should still apply: %s: %s", d.Action, d.Reason)
+	}
+	for _, c := range d.Checks {
+		if c.Name == "tool_failure" {
+			t.Error("disabled check should not be recorded")
+		}
+	}
+}
+
+func TestGatePolicyWeightsAndReload(t *testing.T) {
+	g := NewGate(DefaultGateConfig())
+	old, proposed := makeState(nil), makeState(nil)
+	metrics := update.Metrics{SegmentsHit: []string{"prefs"}}
+
+	// No prior state: entropy scores half its weight, delta full, one segment 2/3
+	before := g.Evaluate(old, proposed, update.Signals{}, metrics, 0.5).SoftScore
+	config := g.Config()
+	config.Weights = SoftWeights{Entropy: 0.2, DeltaStability: 0.5, SegmentFocus: 0.3}
+	g.SetConfig(config)
+	d := g.Evaluate(old, proposed, update.Signals{}, metrics, 0.5)
+
+	if before < 0.69 || before > 0.71 {
+		t.Errorf("default weights: score %.4f, want 0.7", before)
+	}
+	if d.SoftScore < 0.79 || d.SoftScore > 0.81 {
+		t.Errorf("reloaded weights: score %.4f, want 0.8", d.SoftScore)
+	}
+	if len(d.Components) != 3 || d.Components[1].Weight != 0.5 {
+		t.Errorf("components should carry the weights: %+v", d.Components)
+	}
+}
@@ -0,0 +1,144 @@
+package gate
+
+import (
+	"fmt"
+	"strings"
+)
+
+// #region pregate-types
+
+// Pre-gate actions, decided before any generation.
+const (
+	PreGateProceed      = "proceed"       // full generate → signals → gate path
+	PreGateShortCircuit = "short_circuit" // canned reply, no generation or retrieval; learning still sees the turn
+	PreGateAnnotate     = "annotate"      // proceed; notes are recorded with the turn
+	PreGateHarden       = "harden"        // proceed under a tightened gate, and store no evidence
+)
+
+// PreGateInput is what the pre-gate sees: the prompt and its classification
+// (orchestrator.TurnClassification fields, as strings).
+type PreGateInput struct {
+	Prompt          string
+	TurnType        string
+	Risk            string
+	InstructionOnly bool // preference, identity or rule statement already stored
+	RuleMatched     bool // a stored rule fires on this prompt
+	PreviousAsked   bool // the previous reply asked a question, which "ok" may be answering
+}
+
+// PreGateDecision is the pre-gate's verdict for one turn.
+type PreGateDecision struct {
+	Action string
+	Reason string
+	Reply  string   // short_circuit only: the canned response
+	Notes  []string // annotate and harden: why the turn was flagged
+}
+
+// PreGateConfig holds the pre-gate's patterns and hardening strength.
+type PreGateConfig struct {
+	// Acknowledgements are whole prompts (case- and punctuation-insensitive)
+	// answered without generation, mapped to their reply.
+	Acknowledgements map[string]string
+	// HardenPatterns are lowercase substrings that harden a turn: attempts to
+	// override instructions or extract the system prompt.
+	HardenPatterns []string
+	// HardenScale multiplies MaxDeltaNorm and segment caps on hardened turns.
+	HardenScale float32
+}
+
+// DefaultPreGateConfig returns conservative defaults.
+func DefaultPreGateConfig() PreGateConfig {
+	return PreGateConfig{
+		Acknowledgements: map[string]string{
+			"thanks": "You're welcome.", "thank you": "You're welcome.", "thx": "You're welcome.",
+			"ok": "Okay.", "okay": "Okay.", "got it": "Great.", "cool": "Great.",
+		},
+		HardenPatterns: []string{
+			"ignore previous instructions", "ignore all previous", "ignore your instructions",
+			"ignore your rules", "disregard your instructions", "forget your instructions",
+			"reveal your system prompt", "show me your system prompt", "you have no restrictions",
+			"developer mode", "jailbreak",
+		},
+		HardenScale: 0.5,
+	}
+}
+
+// #endregion pregate-types
+
+// #region pregate
+
+// PreGate is the cheap first tier of the gate: it looks at the prompt and its
+// classification before generation, where the second tier (Gate) needs the
+// generated response, signals and proposed update.
+type PreGate struct {
+	config PreGateConfig
+}
+
+// NewPreGate creates a pre-gate with the given configuration.
+func NewPreGate(config PreGateConfig) *PreGate {
+	return &PreGate{config: config}
+}
+
+const instructionAck = "Got it. I'll keep that in mind."
+
+// Evaluate decides how the turn proceeds. Checks run in order: instruction-only
+// prompts and bare acknowledgements short-circuit (unless a rule fires, or the
+// previous reply asked a question the acknowledgement answers), override
+// attempts harden, and sensitive classifications are annotated. A nil PreGate
+// short-circuits instruction-only prompts and otherwise always proceeds.
+func (p *PreGate) Evaluate(in PreGateInput) PreGateDecision {
+	if in.InstructionOnly && !in.RuleMatched {
+		return PreGateDecision{Action: PreGateShortCircuit, Reason: "instruction-only prompt", Reply: instructionAck}
+	}
+	if p == nil {
+		return PreGateDecision{Action: PreGateProceed}
+	}
+	lower := strings.ToLower(strings.TrimSpace(in.Prompt))
+	if reply, ok := p.config.Acknowledgements[strings.TrimRight(lower, ".!? ")]; ok && !in.RuleMatched && !in.PreviousAsked {
+		return PreGateDecision{Action: PreGateShortCircuit, Reason: "acknowledgement", Reply: reply}
+	}
+	var hardened []string
+	for _, pat := range p.config.HardenPatterns {
+		if strings.Contains(lower, pat) {
+			hardened = append(hardened, fmt.Sprintf("override attempt: %q", pat))
+		}
+	}
+	if len(hardened) > 0 {
+		return PreGateDecision{Action: PreGateHarden, Reason: hardened[0], Notes: hardened}
+	}
+	if in.Risk == "sensitive" {
+		note := fmt.Sprintf("sensitive %s prompt", in.TurnType)
+		return PreGateDecision{Action: PreGateAnnotate, Reason: note, Notes: []string{note}}
+	}
+	return PreGateDecision{Action: PreGateProceed}
+}
+
+// AsksQuestion reports whether reply asks the user something, for
+// PreGateInput.PreviousAsked. Any question mark counts: a false positive only
+// costs one generation, while a missed question answers "ok, go ahead" with
+// "Okay." and drops the request.
+func AsksQuestion(reply string) bool {
+	return strings.Contains(reply, "?")
+}
+
+// Hardened returns the gate config for a hardened turn: the delta cap and every
+// segment cap multiplied by the pre-gate's HardenScale.
+func (p *PreGate) Hardened(c GateConfig) GateConfig {
+	scale := float32(0.5)
+	if p != nil && p.config.HardenScale > 0 {
+		scale = p.config.HardenScale
+	}
+	c.MaxDeltaNorm *= scale
+	c.RiskSegmentCap *= scale
+	if len(c.SegmentCaps) > 0 {
+		caps := make(map[string]float32, len(c.SegmentCaps))
+		for name, limit := range c.SegmentCaps {
+			caps[name] = limit * scale
+		}
+		c.SegmentCaps = caps
+	}
+	c.Downgrade = DowngradePolicy{} // an override attempt gets no partial update
+	return c
+}
+
+// #endregion pregate
@@ -1,5 +1,12 @@
 package gate
 
+import (
+	"fmt"
+	"strings"
+
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/logging"
+)
+
 // #region veto-type
 // VetoType enumerates hard veto categories.
 type VetoType string
@@ -29,6 +36,121 @@ type GateConfig struct {
 	MaxStateNorm   float32 // max L2 norm of entire state vector
 	MinEntropyDrop float32 // soft: prefer updates that reduce entropy
 	RiskSegmentCap float32 // hard cap on risk segment norm
+
+	// SegmentCaps adds hard caps on other segments' norms and may override
+	// RiskSegmentCap via "risk". Segments not listed are uncapped.
+	SegmentCaps map[string]float32
+
+	// Downgrade lets slight norm overshoots commit a scaled-down update
+	// (EvaluateDowngrade); the zero value rejects them as before.
+	Downgrade DowngradePolicy
+
+	// Weights of the soft score's terms; the zero value uses DefaultSoftWeights.
+	Weights SoftWeights
+
+	// DisabledVetoes names veto checks that are skipped, e.g. "tool_failure"
+	// or "prefs_segment_norm" (see VetoCheckNames).
+	DisabledVetoes []string
+}
+
+// SoftWeights are the largest contribution of each soft score term. They sum
+// to at most 1, so the soft score stays in 0-1.
+type SoftWeights struct {
+	Entropy        float32 `json:"entropy"`
+	DeltaStability float32 `json:"delta_stability"`
+	SegmentFocus   float32 `json:"segment_focus"`
+}
+
+// DefaultSoftWeights returns the Phase 3 weights.
+func DefaultSoftWeights() SoftWeights {
+	return SoftWeights{Entropy: 0.4, DeltaStability: 0.3, SegmentFocus: 0.3}
+}
+
+// EffectiveWeights returns the configured weights, or the defaults when unset.
+func (c GateConfig) EffectiveWeights() SoftWeights {
+	if c.Weights == (SoftWeights{}) {
+		return DefaultSoftWeights()
+	}
+	return c.Weights
+}
+
+// VetoEnabled reports whether the named veto check runs.
+func (c GateConfig) VetoEnabled(name string) bool {
+	for _, d := range c.DisabledVetoes {
+		if d == name {
+			return false
+		}
+	}
+	return true
+}
+
+// DowngradePolicy picks the vetoes that scale the proposed delta to fit the
+// caps instead of discarding it. Only norm checks (delta norm, segment norms)
+// can be scaled; flag vetoes such as a risk flag or user correction always
+// reject.
+type DowngradePolicy struct {
+	VetoTypes []VetoType // veto types that may downgrade, e.g. constraint_violation
+	MaxExcess float32    // largest overshoot downgraded, as a fraction of the cap (0.25 = up to 125%)
+}
+
+// Allows reports whether a vetoed check can be scaled away under p.
+func (p DowngradePolicy) Allows(c VetoCheck) bool {
+	if !c.Vetoed || !isNormCheck(c.Name) || c.Value > c.Threshold*(1+p.MaxExcess) {
+		return false
+	}
+	for _, t := range p.VetoTypes {
+		if t == c.Type {
+			return true
+		}
+	}
+	return false
+}
+
+// Enabled reports whether any veto type may downgrade.
+func (p DowngradePolicy) Enabled() bool { return len(p.VetoTypes) > 0 }
+
+// Types returns the policy's veto types as strings, for provenance and fixtures.
+func (p DowngradePolicy) Types() []string {
+	var out []string
+	for _, t := range p.VetoTypes {
+		out = append(out, string(t))
+	}
+	return out
+}
+
+// ParseDowngrade builds a policy from a comma-separated list of veto types
+// (GATE_DOWNGRADE) and the largest overshoot in percent of the cap; an empty
+// spec disables downgrades. Flag-only veto types are refused, since no scaling
+// can clear them.
+func ParseDowngrade(spec string, maxExcessPercent int) (DowngradePolicy, error) {
+	var p DowngradePolicy
+	for _, name := range strings.Split(spec, ",") {
+		switch t := VetoType(strings.TrimSpace(name)); t {
+		case "":
+		case VetoConstraint, VetoSafety:
+			p.VetoTypes = append(p.VetoTypes, t)
+		case VetoUserCorrection, VetoToolFailure:
+			return DowngradePolicy{}, fmt.Errorf("downgrade %q: %s vetoes have no norm to scale", spec, t)
+		default:
+			return DowngradePolicy{}, fmt.Errorf("downgrade %q: unknown veto type %q", spec, t)
+		}
+	}
+	if maxExcessPercent <= 0 {
+		return DowngradePolicy{}, fmt.Errorf("downgrade %q: max excess must be positive, got %d%%", spec, maxExcessPercent)
+	}
+	p.MaxExcess = float32(maxExcessPercent) / 100
+	return p, nil
+}
+
+// SegmentCap returns the hard norm cap for the named segment, if any.
+func (c GateConfig) SegmentCap(name string) (float32, bool) {
+	if limit, ok := c.SegmentCaps[name]; ok {
+		return limit, true
+	}
+	if name == "risk" {
+		return c.RiskSegmentCap, true
+	}
+	return 0, false
 }
 
 // DefaultGateConfig returns sensible defaults for Phase 3.
@@ -46,11 +168,70 @@ func DefaultGateConfig() GateConfig {
 // #region gate-decision
 // GateDecision is the output of the gate evaluation.
 type GateDecision struct {
-	Action      string       // "commit" | "reject"
+	Action      string       // "commit" | "reject" | "commit_scaled" (EvaluateDowngrade)
 	Reason      string
 	Vetoed      bool
 	VetoSignals []VetoSignal // non-empty if vetoed
 	SoftScore   float32      // 0-1 composite of soft signals (for logging)
+
+	// Explanation: every hard veto check in evaluation order, and the soft
+	// score's terms (empty when vetoed, so SoftScore is their sum)
+	Checks     []VetoCheck
+	Components []ScoreComponent
+
+	// commit_scaled: fraction of the proposed delta committed, and the vetoes
+	// the scaled update no longer trips
+	Scale      float32
+	Downgraded []VetoSignal
+}
+
+// Commits reports whether the decision commits an update, in full or scaled.
+func (d GateDecision) Commits() bool {
+	return d.Action == "commit" || d.Action == "commit_scaled"
+}
+
+// VetoCheck is one hard veto check with its measured value against its limit.
+// Flag checks (risk flag, correction, tool failure, constraint) measure 1 when
+// the signal is set, against a limit of 0.
+type VetoCheck struct {
+	Name      string // e.g. "risk_flag", "delta_norm", "risk_segment_norm"
+	Type      VetoType
+	Value     float32
+	Threshold float32
+	Vetoed    bool // Value exceeded Threshold
+}
+
+// ScoreComponent is one term of the soft score: the measured input, the most
+// the term can add, and what it added.
+type ScoreComponent struct {
+	Name   string  // entropy | delta_stability | segment_focus
+	Value  float32 // entropy, delta norm, or segments hit
+	Weight float32
+	Score  float32
+	Note   string // why the term scored as it did when the input alone does not say
 }
 
 // #endregion gate-decision
+
+// #region breakdown-record
+
+// BreakdownRecord converts d's checks and components for the turn's GateRecord.
+func (d GateDecision) BreakdownRecord() *logging.GateBreakdownRecord {
+	if len(d.Checks) == 0 && len(d.Components) == 0 {
+		return nil
+	}
+	r := &logging.GateBreakdownRecord{}
+	for _, c := range d.Checks {
+		r.Checks = append(r.Checks, logging.VetoCheckRecord{
+			Name: c.Name, Type: string(c.Type), Value: c.Value, Threshold: c.Threshold, Vetoed: c.Vetoed,
+		})
+	}
+	for _, c := range d.Components {
+		r.Components = append(r.Components, logging.ScoreComponentRecord{
+			Name: c.Name, Value: c.Value, Weight: c.Weight, Score: c.Score, Note: c.Note,
+		})
+	}
+	return r
+}
+
+// #endregion breakdown-record
@@ -0,0 +1,198 @@
+package graph
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// #region cooccur-schema
+const cooccurSchema = `
+CREATE TABLE IF NOT EXISTS evidence_occurrence (
+    node_id TEXT PRIMARY KEY,
+    count   INTEGER NOT NULL DEFAULT 0
+);
+CREATE TABLE IF NOT EXISTS evidence_cooccurrence (
+    a_id  TEXT NOT NULL,
+    b_id  TEXT NOT NULL,
+    count INTEGER NOT NULL DEFAULT 0,
+    PRIMARY KEY (a_id, b_id)
+);
+CREATE TABLE IF NOT EXISTS evidence_retrievals (
+    id    INTEGER PRIMARY KEY CHECK (id = 1),
+    total INTEGER NOT NULL DEFAULT 0
+);
+`
+
+// #endregion cooccur-schema
+
+// #region cooccur-types
+// CoRetrieved is one evidence node retrieved in a turn. Gated means it passed
+// all three retrieval gates itself; otherwise it was reached via graph walk.
+type CoRetrieved struct {
+	ID    string
+	Gated bool
+}
+
+// CoRetrievalConfig controls which co-retrieved pairs become co_retrieval edges.
+type CoRetrievalConfig struct {
+	MaxNodes     int     // nodes considered per turn (default 5)
+	MinPairCount int     // joint retrievals before a pair's PMI is trusted (default 2)
+	MinNPMI      float64 // normalized PMI a non-gated pair needs to link, in [-1, 1] (default 0.3)
+	Delta        float64 // weight added to each direction of a linked pair (default: the co_retrieval type's default weight)
+}
+
+// DefaultCoRetrievalConfig returns the defaults used by the controller.
+func DefaultCoRetrievalConfig() CoRetrievalConfig {
+	t, _ := LookupEdgeType(EdgeCoRetrieval)
+	return CoRetrievalConfig{MaxNodes: 5, MinPairCount: 2, MinNPMI: 0.3, Delta: t.DefaultWeight}
+}
+
+// PairStats are the incremental counts behind a pair's PMI score.
+type PairStats struct {
+	Joint  int // retrievals containing both nodes
+	CountA int // retrievals containing A
+	CountB int // retrievals containing B
+	Total  int // retrievals observed
+}
+
+// NPMI returns the normalized pointwise mutual information of the pair: 1 when
+// the nodes only ever appear together, 0 when independent, -1 when never together.
+func (s PairStats) NPMI() float64 {
+	if s.Joint == 0 || s.CountA == 0 || s.CountB == 0 || s.Total == 0 {
+		return -1
+	}
+	n := float64(s.Total)
+	pab := float64(s.Joint) / n
+	if pab >= 1 {
+		return 1
+	}
+	pmi := math.Log(pab / ((float64(s.CountA) / n) * (float64(s.CountB) / n)))
+	return math.Max(-1, math.Min(1, pmi/-math.Log(pab)))
+}
+
+// CoRetrievalResult summarises one FormCoRetrievalEdges call.
+type CoRetrievalResult struct {
+	Pairs      int // candidate pairs considered
+	Gated      int // linked because both nodes passed gate 3
+	Surprising int // linked because their joint retrieval is informative (NPMI)
+}
+
+// Linked returns the number of pairs that got edges.
+func (r CoRetrievalResult) Linked() int { return r.Gated + r.Surprising }
+
+// #endregion cooccur-types
+
+// #region cooccur-observe
+// ObserveRetrieval records one retrieval of ids: bumps the total, each node's
+// count, and each unordered pair's joint count. The counts are all PMI needs,
+// so scoring never rescans history.
+func (g *GraphStore) ObserveRetrieval(ids []string) error {
+	ids = uniqueIDs(ids)
+	if len(ids) == 0 {
+		return nil
+	}
+	if _, err := g.db.Exec(`INSERT INTO evidence_retrievals (id, total) VALUES (1, 1)
+		ON CONFLICT(id) DO UPDATE SET total = total + 1`); err != nil {
+		return fmt.Errorf("observe retrieval total: %w", err)
+	}
+	for _, id := range ids {
+		if _, err := g.db.Exec(`INSERT INTO evidence_occurrence (node_id, count) VALUES (?, 1)
+			ON CONFLICT(node_id) DO UPDATE SET count = count + 1`, id); err != nil {
+			return fmt.Errorf("observe retrieval node: %w", err)
+		}
+	}
+	for i := 0; i < len(ids); i++ {
+		for j := i + 1; j < len(ids); j++ {
+			if _, err := g.db.Exec(`INSERT INTO evidence_cooccurrence (a_id, b_id, count) VALUES (?, ?, 1)
+				ON CONFLICT(a_id, b_id) DO UPDATE SET count = count + 1`, ids[i], ids[j]); err != nil {
+				return fmt.Errorf("observe retrieval pair: %w", err)
+			}
+		}
+	}
+	return nil
+}
+
+// PairStats returns the co-occurrence counts for a and b (order-insensitive).
+func (g *GraphStore) PairStats(a, b string) (PairStats, error) {
+	if b < a {
+		a, b = b, a
+	}
+	var s PairStats
+	err := g.db.QueryRow(`SELECT
+		COALESCE((SELECT count FROM evidence_cooccurrence WHERE a_id = ? AND b_id = ?), 0),
+		COALESCE((SELECT count FROM evidence_occurrence WHERE node_id = ?), 0),
+		COALESCE((SELECT count FROM evidence_occurrence WHERE node_id = ?), 0),
+		COALESCE((SELECT total FROM evidence_retrievals WHERE id = 1), 0)`,
+		a, b, a, b).Scan(&s.Joint, &s.CountA, &s.CountB, &s.Total)
+	if err != nil {
+		return PairStats{}, fmt.Errorf("pair stats: %w", err)
+	}
+	return s, nil
+}
+
+// uniqueIDs returns ids sorted and deduplicated, so pair keys are canonical (a < b).
+func uniqueIDs(ids []string) []string {
+	out := append([]string(nil), ids...)
+	sort.Strings(out)
+	n := 0
+	for i, id := range out {
+		if id == "" || (i > 0 && id == out[i-1]) {
+			continue
+		}
+		out[n] = id
+		n++
+	}
+	return out[:n]
+}
+
+// #endregion cooccur-observe
+
+// #region cooccur-form
+// FormCoRetrievalEdges observes this turn's retrieval and links only informative
+// pairs instead of all of them: a pair links if both nodes passed gate 3 on their
+// own, or if its joint retrieval count has reached MinPairCount and its NPMI is at
+// least MinNPMI. This stops walk-reached nodes — which co-occur because edges
+// already join them — from reinforcing themselves into a dense hairball.
+func (g *GraphStore) FormCoRetrievalEdges(nodes []CoRetrieved, cfg CoRetrievalConfig) (CoRetrievalResult, error) {
+	if cfg.MaxNodes > 0 && len(nodes) > cfg.MaxNodes {
+		nodes = nodes[:cfg.MaxNodes]
+	}
+	ids := make([]string, len(nodes))
+	for i, n := range nodes {
+		ids[i] = n.ID
+	}
+	if err := g.ObserveRetrieval(ids); err != nil {
+		return CoRetrievalResult{}, err
+	}
+
+	var res CoRetrievalResult
+	for i := 0; i < len(nodes); i++ {
+		for j := i + 1; j < len(nodes); j++ {
+			a, b := nodes[i], nodes[j]
+			if a.ID == b.ID {
+				continue
+			}
+			res.Pairs++
+			if a.Gated && b.Gated {
+				res.Gated++
+			} else {
+				s, err := g.PairStats(a.ID, b.ID)
+				if err != nil {
+					return res, err
+				}
+				if s.Joint < cfg.MinPairCount || s.NPMI() < cfg.MinNPMI {
+					continue
+				}
+				res.Surprising++
+			}
+			// Undirected: one row links the pair both ways
+			if err := g.IncrementEdge(a.ID, b.ID, EdgeCoRetrieval, cfg.Delta); err != nil {
+				return res, fmt.Errorf("co-retrieval edge: %w", err)
+			}
+		}
+	}
+	return res, nil
+}
+
+// #endregion cooccur-form
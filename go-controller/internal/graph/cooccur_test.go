@@ -0,0 +1,147 @@
+package graph
+
+import (
+	"math"
+	"testing"
+)
+
+// #region test-npmi
+func TestPairStatsNPMI(t *testing.T) {
+	cases := []struct {
+		name string
+		s    PairStats
+		want float64
+	}{
+		{"never together", PairStats{Joint: 0, CountA: 3, CountB: 3, Total: 10}, -1},
+		{"only together", PairStats{Joint: 4, CountA: 4, CountB: 4, Total: 10}, 1},
+		{"independent", PairStats{Joint: 25, CountA: 50, CountB: 50, Total: 100}, 0},
+		{"every retrieval", PairStats{Joint: 3, CountA: 3, CountB: 3, Total: 3}, 1},
+	}
+	for _, tc := range cases {
+		if got := tc.s.NPMI(); math.Abs(got-tc.want) > 1e-9 {
+			t.Errorf("%s: NPMI = %.4f, want %.4f", tc.name, got, tc.want)
+		}
+	}
+}
+
+// #endregion test-npmi
+
+// #region test-observe
+func TestObserveRetrieval_Incremental(t *testing.T) {
+	gs, err := NewGraphStore(setupTestDB(t))
+	if err != nil {
+		t.Fatalf("new graph store: %v", err)
+	}
+	// Duplicates within one retrieval count once.
+	if err := gs.ObserveRetrieval([]string{nodeID("b"), nodeID("a"), nodeID("a")}); err != nil {
+		t.Fatalf("observe: %v", err)
+	}
+	if err := gs.ObserveRetrieval([]string{nodeID("a"), nodeID("c")}); err != nil {
+		t.Fatalf("observe: %v", err)
+	}
+	s, err := gs.PairStats(nodeID("b"), nodeID("a"))
+	if err != nil {
+		t.Fatalf("pair stats: %v", err)
+	}
+	if s != (PairStats{Joint: 1, CountA: 2, CountB: 1, Total: 2}) {
+		t.Errorf("unexpected stats for (a, b): %+v", s)
+	}
+	if s, _ := gs.PairStats(nodeID("b"), nodeID("c")); s.Joint != 0 || s.Total != 2 {
+		t.Errorf("b and c never co-occurred: %+v", s)
+	}
+}
+
+// #endregion test-observe
+
+// #region test-form-edges
+func TestFormCoRetrievalEdges_GatedPairsLink(t *testing.T) {
+	gs, err := NewGraphStore(setupTestDB(t))
+	if err != nil {
+		t.Fatalf("new graph store: %v", err)
+	}
+	res, err := gs.FormCoRetrievalEdges([]CoRetrieved{{nodeID("a"), true}, {nodeID("b"), true}, {nodeID("w"), false}}, DefaultCoRetrievalConfig())
+	if err != nil {
+		t.Fatalf("form edges: %v", err)
+	}
+	if res.Pairs != 3 || res.Gated != 1 || res.Surprising != 0 {
+		t.Errorf("expected only the gated pair linked on first sight, got %+v", res)
+	}
+	if edges, _ := gs.GetNeighbors(nodeID("a"), 0); len(edges) != 1 || edges[0].TargetID != nodeID("b") {
+		t.Errorf("expected a→b only, got %+v", edges)
+	}
+	if edges, _ := gs.GetNeighbors(nodeID("w"), 0); len(edges) != 0 {
+		t.Errorf("walked node should not link on first sight, got %+v", edges)
+	}
+}
+
+func TestFormCoRetrievalEdges_SurprisingWalkPairsLink(t *testing.T) {
+	gs, err := NewGraphStore(setupTestDB(t))
+	if err != nil {
+		t.Fatalf("new graph store: %v", err)
+	}
+	cfg := DefaultCoRetrievalConfig()
+	// nodeID("hub") shows up in every retrieval; nodeID("x") and nodeID("y") only ever appear together.
+	for i := 0; i < 6; i++ {
+		other := []string{nodeID("p"), nodeID("q"), nodeID("r"), nodeID("s"), nodeID("t"), nodeID("u")}[i]
+		if err := gs.ObserveRetrieval([]string{nodeID("hub"), other}); err != nil {
+			t.Fatalf("observe: %v", err)
+		}
+	}
+	nodes := []CoRetrieved{{nodeID("x"), true}, {nodeID("y"), false}, {nodeID("hub"), false}}
+	var res CoRetrievalResult
+	for i := 0; i < 2; i++ {
+		if res, err = gs.FormCoRetrievalEdges(nodes, cfg); err != nil {
+			t.Fatalf("form edges: %v", err)
+		}
+	}
+	if res.Surprising != 1 {
+		t.Fatalf("expected only x–y to be surprising on the second joint retrieval, got %+v", res)
+	}
+	if edges, _ := gs.GetNeighbors(nodeID("x"), 0); len(edges) != 1 || edges[0].TargetID != nodeID("y") {
+		t.Errorf("expected x→y, got %+v", edges)
+	}
+	if edges, _ := gs.GetNeighbors(nodeID("hub"), 0); len(edges) != 0 {
+		t.Errorf("hub co-occurs with everything and should stay unlinked, got %+v", edges)
+	}
+}
+
+func TestFormCoRetrievalEdges_MaxNodes(t *testing.T) {
+	gs, err := NewGraphStore(setupTestDB(t))
+	if err != nil {
+		t.Fatalf("new graph store: %v", err)
+	}
+	cfg := DefaultCoRetrievalConfig()
+	cfg.MaxNodes = 2
+	res, err := gs.FormCoRetrievalEdges([]CoRetrieved{{nodeID("a"), true}, {nodeID("b"), true}, {nodeID("c"), true}}, cfg)
+	if err != nil {
+		t.Fatalf("form edges: %v", err)
+	}
+	if res.Pairs != 1 {
+		t.Errorf("expected 1 pair with MaxNodes=2, got %d", res.Pairs)
+	}
+	if s, _ := gs.PairStats(nodeID("a"), nodeID("c")); s.CountB != 0 {
+		t.Errorf("nodes beyond MaxNodes should not be observed, got %+v", s)
+	}
+}
+
+// #endregion test-form-edges
+
+// #region test-sever-counts
+func TestSeverNode_ClearsCounts(t *testing.T) {
+	gs, err := NewGraphStore(setupTestDB(t))
+	if err != nil {
+		t.Fatalf("new graph store: %v", err)
+	}
+	if _, err := gs.FormCoRetrievalEdges([]CoRetrieved{{nodeID("a"), true}, {nodeID("b"), true}}, DefaultCoRetrievalConfig()); err != nil {
+		t.Fatalf("form edges: %v", err)
+	}
+	if err := gs.SeverNode(nodeID("a")); err != nil {
+		t.Fatalf("sever: %v", err)
+	}
+	s, _ := gs.PairStats(nodeID("a"), nodeID("b"))
+	if s.Joint != 0 || s.CountA != 0 || s.CountB != 1 {
+		t.Errorf("expected a's counts cleared, got %+v", s)
+	}
+}
+
+// #endregion test-sever-counts
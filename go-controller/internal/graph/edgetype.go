@@ -0,0 +1,171 @@
+package graph
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// #region edge-types
+
+// Built-in edge types.
+const (
+	EdgeReflection  = "reflection"   // retrieved evidence → the evidence stored from the turn it informed
+	EdgeCoRetrieval = "co_retrieval" // items retrieved together more than chance (or similar, from bootstrap-graph)
+	EdgeTemporal    = "temporal"     // evidence stored close together in time
+	EdgeSummaryOf   = "summary_of"   // compaction summary → each original it replaced
+	EdgeCuriosity   = "curiosity"    // evidence from a turn whose reflection wondered → the finding that explored it
+)
+
+// ErrUnknownEdgeType is returned when an edge names a type nobody registered.
+var ErrUnknownEdgeType = errors.New("unknown edge type")
+
+// EdgeType describes how edges of one type are created, decayed and walked.
+type EdgeType struct {
+	Name string
+
+	// Weight a new edge gets from NewEdge (0, 1]
+	DefaultWeight float64
+	// DecayAll halves the weight every HalfLife since the last update; zero
+	// uses the half-life DecayAll is called with
+	HalfLife time.Duration
+	// Walk score prior for types not listed in WalkConfig.TypePriors
+	WalkMultiplier float64
+	// Undirected edges are stored once per pair, lower ID as source, and
+	// followed from either end
+	Undirected bool
+}
+
+// validate checks t's parameters.
+func (t EdgeType) validate() error {
+	switch {
+	case strings.TrimSpace(t.Name) == "" || strings.ContainsAny(t.Name, ",= \t\n"):
+		return fmt.Errorf("edge type name %q: want a non-empty name without spaces, commas or '='", t.Name)
+	case !(t.DefaultWeight > 0 && t.DefaultWeight <= 1):
+		return fmt.Errorf("edge type %q: default weight %v outside (0, 1]", t.Name, t.DefaultWeight)
+	case t.HalfLife < 0:
+		return fmt.Errorf("edge type %q: negative half-life", t.Name)
+	case t.WalkMultiplier < 0 || math.IsNaN(t.WalkMultiplier) || math.IsInf(t.WalkMultiplier, 0):
+		return fmt.Errorf("edge type %q: walk multiplier must be a non-negative number", t.Name)
+	}
+	return nil
+}
+
+var (
+	edgeTypesMu sync.RWMutex
+	// Edges the model formed from its own reflections and informative
+	// co-retrievals are trusted over plain adjacency in time
+	edgeTypes = map[string]EdgeType{
+		EdgeReflection:  {Name: EdgeReflection, DefaultWeight: 0.3, HalfLife: 48 * time.Hour, WalkMultiplier: 1.0},
+		EdgeCoRetrieval: {Name: EdgeCoRetrieval, DefaultWeight: 0.1, HalfLife: 48 * time.Hour, WalkMultiplier: 0.9, Undirected: true},
+		EdgeTemporal:    {Name: EdgeTemporal, DefaultWeight: 0.05, HalfLife: 48 * time.Hour, WalkMultiplier: 0.6},
+		// Provenance more than association: slow to decay, and walking it
+		// mostly reaches soft-deleted originals
+		EdgeSummaryOf: {Name: EdgeSummaryOf, DefaultWeight: 0.5, HalfLife: 30 * 24 * time.Hour, WalkMultiplier: 0.3},
+		// The model's own follow-up on an exchange: as trusted as a reflection
+		// edge, and slower to fade since nothing re-forms it
+		EdgeCuriosity: {Name: EdgeCuriosity, DefaultWeight: 0.3, HalfLife: 7 * 24 * time.Hour, WalkMultiplier: 1.0},
+	}
+)
+
+// RegisterEdgeType makes t available to the store and the walk, so programs
+// embedding the controller can add their own (e.g. "contradiction",
+// "causal"). It panics if the name is taken or t is invalid, like
+// database/sql.Register.
+func RegisterEdgeType(t EdgeType) {
+	if err := t.validate(); err != nil {
+		panic("graph: RegisterEdgeType: " + err.Error())
+	}
+	edgeTypesMu.Lock()
+	defer edgeTypesMu.Unlock()
+	if _, dup := edgeTypes[t.Name]; dup {
+		panic("graph: RegisterEdgeType called twice for " + t.Name)
+	}
+	edgeTypes[t.Name] = t
+}
+
+// LookupEdgeType returns the registered type called name.
+func LookupEdgeType(name string) (EdgeType, bool) {
+	edgeTypesMu.RLock()
+	defer edgeTypesMu.RUnlock()
+	t, ok := edgeTypes[name]
+	return t, ok
+}
+
+// EdgeTypes returns every registered type, sorted by name.
+func EdgeTypes() []EdgeType {
+	edgeTypesMu.RLock()
+	defer edgeTypesMu.RUnlock()
+	out := make([]EdgeType, 0, len(edgeTypes))
+	for _, t := range edgeTypes {
+		out = append(out, t)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// edgeTypeNames lists the registered names, for error messages.
+func edgeTypeNames() string {
+	types := EdgeTypes()
+	names := make([]string, len(types))
+	for i, t := range types {
+		names[i] = t.Name
+	}
+	return strings.Join(names, ", ")
+}
+
+// checkEdgeType returns ErrUnknownEdgeType when name is not registered.
+func checkEdgeType(name string) error {
+	if _, ok := LookupEdgeType(name); !ok {
+		return fmt.Errorf("%w %q (registered: %s)", ErrUnknownEdgeType, name, edgeTypeNames())
+	}
+	return nil
+}
+
+// NewEdge is an edge of edgeType from sourceID to targetID at the type's
+// default weight; an unregistered type gets weight 0 and is refused on write.
+func NewEdge(sourceID, targetID, edgeType string) Edge {
+	t, _ := LookupEdgeType(edgeType)
+	return Edge{SourceID: sourceID, TargetID: targetID, EdgeType: edgeType, Weight: t.DefaultWeight}
+}
+
+// isUndirected reports whether edgeType is a registered undirected type.
+func isUndirected(edgeType string) bool {
+	t, ok := LookupEdgeType(edgeType)
+	return ok && t.Undirected
+}
+
+// undirectedTypes lists the registered undirected type names, sorted.
+func undirectedTypes() []string {
+	var names []string
+	for _, t := range EdgeTypes() {
+		if t.Undirected {
+			names = append(names, t.Name)
+		}
+	}
+	return names
+}
+
+// canonicalPair orders the endpoints of an undirected edge, lower ID first;
+// directed edges keep their direction.
+func canonicalPair(sourceID, targetID, edgeType string) (string, string) {
+	if targetID < sourceID && isUndirected(edgeType) {
+		return targetID, sourceID
+	}
+	return sourceID, targetID
+}
+
+// walkMultipliers is the walk prior of every registered type.
+func walkMultipliers() map[string]float64 {
+	priors := map[string]float64{}
+	for _, t := range EdgeTypes() {
+		priors[t.Name] = t.WalkMultiplier
+	}
+	return priors
+}
+
+// #endregion edge-types
@@ -0,0 +1,99 @@
+package graph
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestRegisterEdgeType(t *testing.T) {
+	RegisterEdgeType(EdgeType{Name: "test_causal", DefaultWeight: 0.4, HalfLife: 24 * time.Hour, WalkMultiplier: 0.5})
+
+	got, ok := LookupEdgeType("test_causal")
+	if !ok || got.DefaultWeight != 0.4 || got.WalkMultiplier != 0.5 {
+		t.Fatalf("lookup = %+v, %v", got, ok)
+	}
+	if e := NewEdge(nodeID("a"), nodeID("b"), "test_causal"); e.Weight != 0.4 || e.EdgeType != "test_causal" {
+		t.Errorf("new edge = %+v, want the type's default weight", e)
+	}
+	if e := NewEdge(nodeID("a"), nodeID("b"), EdgeReflection); e.Weight != 0.3 {
+		t.Errorf("reflection edge weight = %v, want 0.3", e.Weight)
+	}
+	if p := DefaultWalkConfig().TypePriors["test_causal"]; p != 0.5 {
+		t.Errorf("default walk prior = %v, want the registered multiplier", p)
+	}
+
+	mustPanic := func(name string, et EdgeType) {
+		t.Helper()
+		defer func() {
+			if recover() == nil {
+				t.Errorf("%s: expected a panic", name)
+			}
+		}()
+		RegisterEdgeType(et)
+	}
+	mustPanic("duplicate", EdgeType{Name: EdgeTemporal, DefaultWeight: 0.1})
+	mustPanic("empty name", EdgeType{DefaultWeight: 0.1})
+	mustPanic("name with '='", EdgeType{Name: "a=b", DefaultWeight: 0.1})
+	mustPanic("zero weight", EdgeType{Name: "test_zero"})
+	mustPanic("negative multiplier", EdgeType{Name: "test_neg", DefaultWeight: 0.1, WalkMultiplier: -1})
+}
+
+func TestCustomEdgeType_StoreWalkDecay(t *testing.T) {
+	RegisterEdgeType(EdgeType{Name: "test_contradiction", DefaultWeight: 0.5, HalfLife: 12 * time.Hour, WalkMultiplier: 0.2})
+	db := setupTestDB(t)
+	gs, err := NewGraphStore(db)
+	if err != nil {
+		t.Fatalf("new graph store: %v", err)
+	}
+
+	if err := gs.AddEdge(nodeID("a"), nodeID("b"), "test_unregistered", 0.5); !errors.Is(err, ErrUnknownEdgeType) {
+		t.Errorf("unregistered type: err = %v, want ErrUnknownEdgeType", err)
+	}
+	if err := gs.IncrementEdge(nodeID("a"), nodeID("b"), "test_unregistered", 0.5); !errors.Is(err, ErrUnknownEdgeType) {
+		t.Errorf("unregistered type: err = %v, want ErrUnknownEdgeType", err)
+	}
+	e := NewEdge(nodeID("a"), nodeID("b"), "test_contradiction")
+	if err := gs.AddEdge(e.SourceID, e.TargetID, e.EdgeType, e.Weight); err != nil {
+		t.Fatalf("add custom edge: %v", err)
+	}
+	if err := gs.AddEdge(nodeID("a"), nodeID("c"), EdgeTemporal, 0.5); err != nil {
+		t.Fatalf("add temporal edge: %v", err)
+	}
+
+	// Priors that do not list the custom type fall back to its multiplier
+	cfg := WalkConfig{MinWeight: 0.1, TypePriors: map[string]float64{EdgeTemporal: 1}}
+	result, err := gs.WalkScored(nodeID("a"), cfg)
+	if err != nil {
+		t.Fatalf("walk: %v", err)
+	}
+	for i, id := range result.IDs {
+		if id == nodeID("b") && math.Abs(result.Scores[i]-0.1) > 1e-9 {
+			t.Errorf("custom edge walk score = %.4f, want 0.5×0.2", result.Scores[i])
+		}
+	}
+
+	// 24h old: two half-lives for the custom type, half of one for temporal
+	past := time.Now().UTC().Add(-24 * time.Hour).Format(time.RFC3339)
+	if _, err := db.Exec(`UPDATE evidence_edges SET updated_at = ?`, past); err != nil {
+		t.Fatalf("age edges: %v", err)
+	}
+	if _, err := gs.DecayAll(96); err != nil {
+		t.Fatalf("decay: %v", err)
+	}
+	edges, err := gs.GetNeighbors(nodeID("a"), 0)
+	if err != nil {
+		t.Fatalf("neighbors: %v", err)
+	}
+	weights := map[string]float64{}
+	for _, e := range edges {
+		weights[e.EdgeType] = e.Weight
+	}
+	if w := weights["test_contradiction"]; math.Abs(w-0.125) > 0.001 {
+		t.Errorf("custom edge decayed to %.4f, want 0.125 (12h half-life)", w)
+	}
+	if w := weights[EdgeTemporal]; math.Abs(w-0.5*math.Pow(0.5, 0.5)) > 0.001 {
+		t.Errorf("temporal edge decayed to %.4f, want %.4f (48h half-life)", w, 0.5*math.Pow(0.5, 0.5))
+	}
+}
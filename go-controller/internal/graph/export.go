@@ -0,0 +1,319 @@
+package graph
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/dot"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/evidence"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/timestamp"
+)
+
+// #region subgraph
+
+// ExportFilter selects the edges an export covers.
+type ExportFilter struct {
+	Types     []string // edge types to keep; empty keeps all
+	MinWeight float64  // edges below this weight are dropped
+	Around    string   // when set, only the neighbourhood of this evidence ID
+	Depth     int      // hops from Around, in either direction (default 2)
+}
+
+// Subgraph returns the edges matching f, ordered by source, target and type.
+// With f.Around set, it keeps the edges whose endpoints are both within
+// f.Depth hops of it over the matching edges, ignoring direction.
+func (g *GraphStore) Subgraph(f ExportFilter) ([]Edge, error) {
+	if f.Around != "" {
+		if err := evidence.ValidateLocalID(f.Around); err != nil {
+			return nil, fmt.Errorf("export around: %w", err)
+		}
+	}
+	query := `SELECT id, source_id, target_id, edge_type, weight, created_at, updated_at
+		FROM evidence_edges WHERE weight >= ?`
+	args := []any{f.MinWeight}
+	if len(f.Types) > 0 {
+		query += ` AND edge_type IN (?` + strings.Repeat(", ?", len(f.Types)-1) + `)`
+		for _, t := range f.Types {
+			args = append(args, t)
+		}
+	}
+	query += ` ORDER BY source_id, target_id, edge_type`
+
+	rows, err := g.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query edges: %w", err)
+	}
+	defer rows.Close()
+	var edges []Edge
+	for rows.Next() {
+		var e Edge
+		var createdAt, updatedAt string
+		if err := rows.Scan(&e.ID, &e.SourceID, &e.TargetID, &e.EdgeType, &e.Weight, &createdAt, &updatedAt); err != nil {
+			return nil, err
+		}
+		e.CreatedAt, _ = timestamp.Parse(createdAt)
+		e.UpdatedAt, _ = timestamp.Parse(updatedAt)
+		edges = append(edges, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if f.Around == "" {
+		return edges, nil
+	}
+	return neighbourhood(edges, f.Around, f.Depth), nil
+}
+
+// neighbourhood keeps the edges between nodes within depth undirected hops of
+// center.
+func neighbourhood(edges []Edge, center string, depth int) []Edge {
+	if depth <= 0 {
+		depth = 2
+	}
+	adj := map[string][]string{}
+	for _, e := range edges {
+		adj[e.SourceID] = append(adj[e.SourceID], e.TargetID)
+		adj[e.TargetID] = append(adj[e.TargetID], e.SourceID)
+	}
+	reached := map[string]bool{center: true}
+	frontier := []string{center}
+	for hop := 0; hop < depth && len(frontier) > 0; hop++ {
+		var next []string
+		for _, id := range frontier {
+			for _, n := range adj[id] {
+				if !reached[n] {
+					reached[n] = true
+					next = append(next, n)
+				}
+			}
+		}
+		frontier = next
+	}
+	var out []Edge
+	for _, e := range edges {
+		if reached[e.SourceID] && reached[e.TargetID] {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+// NodeIDs returns the endpoints of edges, sorted.
+func NodeIDs(edges []Edge) []string {
+	seen := map[string]bool{}
+	var ids []string
+	for _, e := range edges {
+		for _, id := range []string{e.SourceID, e.TargetID} {
+			if !seen[id] {
+				seen[id] = true
+				ids = append(ids, id)
+			}
+		}
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// #endregion subgraph
+
+// #region export
+
+// NodeMeta is what an export shows about an evidence node. Nodes without
+// metadata are exported with their ID alone.
+type NodeMeta struct {
+	Text      string
+	CreatedAt time.Time
+	Pinned    bool
+	Note      string
+}
+
+// Export is a subgraph with its node metadata, ready to write.
+type Export struct {
+	Edges      []Edge
+	Nodes      map[string]NodeMeta
+	Center     string // highlighted node, if any
+	SnippetLen int    // runes of text in node labels (default 60)
+}
+
+// label is the display label of node id: a text snippet, or the ID.
+func (x Export) label(id string) string {
+	n := x.SnippetLen
+	if n <= 0 {
+		n = 60
+	}
+	text := strings.Join(strings.Fields(x.Nodes[id].Text), " ")
+	if text == "" {
+		return id
+	}
+	if r := []rune(text); len(r) > n {
+		return string(r[:n-1]) + "…"
+	}
+	return text
+}
+
+// WriteDOT writes x as a GraphViz digraph. Node labels are text snippets with
+// the full text as tooltip; edges are labelled with type and weight and drawn
+// thicker the heavier they are, undirected ones without arrowheads.
+func (x Export) WriteDOT(w io.Writer) error {
+	var b strings.Builder
+	b.WriteString("digraph evidence {\n")
+	b.WriteString("  node [shape=box, style=rounded, fontsize=10];\n")
+	b.WriteString("  edge [fontsize=8];\n")
+	for _, id := range NodeIDs(x.Edges) {
+		m := x.Nodes[id]
+		attrs := []string{"label=" + dot.Quote(x.label(id)), "tooltip=" + dot.Quote(id+"\n"+m.Text)}
+		if !m.CreatedAt.IsZero() {
+			attrs = append(attrs, "created_at="+dot.Quote(timestamp.Format(m.CreatedAt)))
+		}
+		if m.Note != "" {
+			attrs = append(attrs, "note="+dot.Quote(m.Note))
+		}
+		switch {
+		case id == x.Center:
+			attrs = append(attrs, `style="rounded,filled"`, "fillcolor=gold")
+		case m.Pinned:
+			attrs = append(attrs, `style="rounded,bold"`)
+		}
+		fmt.Fprintf(&b, "  %s [%s];\n", dot.Quote(id), strings.Join(attrs, ", "))
+	}
+	for _, e := range x.Edges {
+		dir := ""
+		if isUndirected(e.EdgeType) {
+			dir = ", dir=none"
+		}
+		fmt.Fprintf(&b, "  %s -> %s [label=%s, edge_type=%s, w=%.4f, penwidth=%.2f%s];\n",
+			dot.Quote(e.SourceID), dot.Quote(e.TargetID), dot.Quote(fmt.Sprintf("%s %.2f", e.EdgeType, e.Weight)),
+			dot.Quote(e.EdgeType), e.Weight, 0.5+4*e.Weight, dir)
+	}
+	b.WriteString("}\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// GEXF 1.3 document, just the parts an evidence graph uses.
+type (
+	gexfDoc struct {
+		XMLName xml.Name  `xml:"gexf"`
+		XMLNS   string    `xml:"xmlns,attr"`
+		Version string    `xml:"version,attr"`
+		Meta    gexfMeta  `xml:"meta"`
+		Graph   gexfGraph `xml:"graph"`
+	}
+	gexfMeta struct {
+		LastModified string `xml:"lastmodifieddate,attr"`
+		Creator      string `xml:"creator"`
+		Description  string `xml:"description"`
+	}
+	gexfGraph struct {
+		DefaultEdgeType string      `xml:"defaultedgetype,attr"`
+		Mode            string      `xml:"mode,attr"`
+		Attributes      []gexfAttrs `xml:"attributes"`
+		Nodes           []gexfNode  `xml:"nodes>node"`
+		Edges           []gexfEdge  `xml:"edges>edge"`
+	}
+	gexfAttrs struct {
+		Class string     `xml:"class,attr"`
+		Attrs []gexfAttr `xml:"attribute"`
+	}
+	gexfAttr struct {
+		ID    string `xml:"id,attr"`
+		Title string `xml:"title,attr"`
+		Type  string `xml:"type,attr"`
+	}
+	gexfNode struct {
+		ID     string      `xml:"id,attr"`
+		Label  string      `xml:"label,attr"`
+		Values []gexfValue `xml:"attvalues>attvalue"`
+	}
+	gexfEdge struct {
+		ID     int64       `xml:"id,attr"`
+		Source string      `xml:"source,attr"`
+		Target string      `xml:"target,attr"`
+		Type   string      `xml:"type,attr,omitempty"` // "undirected" overrides the directed default
+		Label  string      `xml:"label,attr"`
+		Weight float64     `xml:"weight,attr"`
+		Values []gexfValue `xml:"attvalues>attvalue"`
+	}
+	gexfValue struct {
+		For   string `xml:"for,attr"`
+		Value string `xml:"value,attr"`
+	}
+)
+
+// WriteGEXF writes x as a GEXF 1.3 document for Gephi. Nodes carry text,
+// created_at, pinned, note and center attributes; edges carry their type,
+// weight and timestamps.
+func (x Export) WriteGEXF(w io.Writer) error {
+	doc := gexfDoc{
+		XMLNS:   "http://gexf.net/1.3",
+		Version: "1.3",
+		Meta: gexfMeta{
+			LastModified: time.Now().UTC().Format("2006-01-02"),
+			Creator:      "adaptive-state graph-export",
+			Description:  "associative evidence graph",
+		},
+		Graph: gexfGraph{
+			DefaultEdgeType: "directed",
+			Mode:            "static",
+			Attributes: []gexfAttrs{
+				{Class: "node", Attrs: []gexfAttr{
+					{ID: "text", Title: "text", Type: "string"},
+					{ID: "created_at", Title: "created_at", Type: "string"},
+					{ID: "pinned", Title: "pinned", Type: "boolean"},
+					{ID: "note", Title: "note", Type: "string"},
+					{ID: "center", Title: "center", Type: "boolean"},
+				}},
+				{Class: "edge", Attrs: []gexfAttr{
+					{ID: "edge_type", Title: "edge_type", Type: "string"},
+					{ID: "created_at", Title: "created_at", Type: "string"},
+					{ID: "updated_at", Title: "updated_at", Type: "string"},
+				}},
+			},
+		},
+	}
+	for _, id := range NodeIDs(x.Edges) {
+		m := x.Nodes[id]
+		values := []gexfValue{
+			{For: "text", Value: m.Text},
+			{For: "pinned", Value: fmt.Sprint(m.Pinned)},
+			{For: "center", Value: fmt.Sprint(id == x.Center)},
+		}
+		if !m.CreatedAt.IsZero() {
+			values = append(values, gexfValue{For: "created_at", Value: timestamp.Format(m.CreatedAt)})
+		}
+		if m.Note != "" {
+			values = append(values, gexfValue{For: "note", Value: m.Note})
+		}
+		doc.Graph.Nodes = append(doc.Graph.Nodes, gexfNode{ID: id, Label: x.label(id), Values: values})
+	}
+	for _, e := range x.Edges {
+		kind := ""
+		if isUndirected(e.EdgeType) {
+			kind = "undirected"
+		}
+		doc.Graph.Edges = append(doc.Graph.Edges, gexfEdge{
+			ID: e.ID, Source: e.SourceID, Target: e.TargetID, Type: kind, Label: e.EdgeType, Weight: e.Weight,
+			Values: []gexfValue{
+				{For: "edge_type", Value: e.EdgeType},
+				{For: "created_at", Value: timestamp.Format(e.CreatedAt)},
+				{For: "updated_at", Value: timestamp.Format(e.UpdatedAt)},
+			},
+		})
+	}
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return fmt.Errorf("encode gexf: %w", err)
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// #endregion export
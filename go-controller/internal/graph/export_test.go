@@ -0,0 +1,120 @@
+package graph
+
+import (
+	"bytes"
+	"encoding/xml"
+	"strings"
+	"testing"
+)
+
+func TestSubgraph(t *testing.T) {
+	db := setupTestDB(t)
+	gs, err := NewGraphStore(db)
+	if err != nil {
+		t.Fatalf("new graph store: %v", err)
+	}
+	// a → b → c → d, plus e → a over a weak temporal edge
+	for _, e := range []struct {
+		src, dst, typ string
+		w             float64
+	}{
+		{"a", "b", EdgeReflection, 0.5},
+		{"b", "c", EdgeCoRetrieval, 0.4},
+		{"c", "d", EdgeReflection, 0.3},
+		{"e", "a", EdgeTemporal, 0.05},
+	} {
+		if err := gs.AddEdge(nodeID(e.src), nodeID(e.dst), e.typ, e.w); err != nil {
+			t.Fatalf("add edge: %v", err)
+		}
+	}
+
+	edges, err := gs.Subgraph(ExportFilter{})
+	if err != nil || len(edges) != 4 {
+		t.Fatalf("all edges: %d, %v", len(edges), err)
+	}
+	if edges, _ = gs.Subgraph(ExportFilter{MinWeight: 0.1}); len(edges) != 3 {
+		t.Errorf("min weight 0.1: %d edges, want 3", len(edges))
+	}
+	if edges, _ = gs.Subgraph(ExportFilter{Types: []string{EdgeReflection}}); len(edges) != 2 {
+		t.Errorf("reflection only: %d edges, want 2", len(edges))
+	}
+
+	// One hop from b reaches a and c; two hops also reach d and, against edge
+	// direction, e
+	edges, _ = gs.Subgraph(ExportFilter{Around: nodeID("b"), Depth: 1})
+	if ids := NodeIDs(edges); len(ids) != 3 || len(edges) != 2 {
+		t.Errorf("depth 1 around b: nodes %v, %d edges", ids, len(edges))
+	}
+	edges, _ = gs.Subgraph(ExportFilter{Around: nodeID("b")})
+	if len(edges) != 4 {
+		t.Errorf("depth 2 around b: %d edges, want 4", len(edges))
+	}
+	edges, _ = gs.Subgraph(ExportFilter{Around: nodeID("b"), MinWeight: 0.1})
+	if len(edges) != 3 {
+		t.Errorf("depth 2 around b over heavy edges: %d edges, want 3", len(edges))
+	}
+
+	if _, err := gs.Subgraph(ExportFilter{Around: "not-an-id"}); err == nil {
+		t.Error("expected an error for a malformed --around ID")
+	}
+}
+
+func TestExport_Write(t *testing.T) {
+	edges := []Edge{
+		{ID: 1, SourceID: nodeID("a"), TargetID: nodeID("b"), EdgeType: EdgeReflection, Weight: 0.5},
+		{ID: 2, SourceID: nodeID("b"), TargetID: nodeID("c"), EdgeType: EdgeTemporal, Weight: 0.05},
+	}
+	x := Export{
+		Edges: edges,
+		Nodes: map[string]NodeMeta{
+			nodeID("a"): {Text: "The user said \"hi\"\nand then   left", Pinned: true},
+			nodeID("b"): {Text: "Orbital <mechanics> & rockets", Note: "keep"},
+		},
+		Center:     nodeID("b"),
+		SnippetLen: 12,
+	}
+
+	var dot bytes.Buffer
+	if err := x.WriteDOT(&dot); err != nil {
+		t.Fatalf("write dot: %v", err)
+	}
+	out := dot.String()
+	for _, want := range []string{
+		`digraph evidence {`,
+		`label="The user sa…"`,
+		`tooltip="` + nodeID("a") + `\nThe user said \"hi\"\nand then   left"`,
+		`"` + nodeID("c") + `" [label="` + nodeID("c") + `"`,
+		`fillcolor=gold`,
+		`-> "` + nodeID("b") + `" [label="reflection 0.50"`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("dot output missing %q:\n%s", want, out)
+		}
+	}
+
+	var gexf bytes.Buffer
+	if err := x.WriteGEXF(&gexf); err != nil {
+		t.Fatalf("write gexf: %v", err)
+	}
+	var doc gexfDoc
+	if err := xml.Unmarshal(gexf.Bytes(), &doc); err != nil {
+		t.Fatalf("gexf does not parse: %v\n%s", err, gexf.String())
+	}
+	if len(doc.Graph.Nodes) != 3 || len(doc.Graph.Edges) != 2 {
+		t.Fatalf("gexf: %d nodes, %d edges", len(doc.Graph.Nodes), len(doc.Graph.Edges))
+	}
+	b := doc.Graph.Nodes[1]
+	if b.ID != nodeID("b") || b.Label != "Orbital <me…" {
+		t.Errorf("node b = %+v", b)
+	}
+	values := map[string]string{}
+	for _, v := range b.Values {
+		values[v.For] = v.Value
+	}
+	if values["text"] != "Orbital <mechanics> & rockets" || values["center"] != "true" || values["note"] != "keep" {
+		t.Errorf("node b values = %v", values)
+	}
+	if e := doc.Graph.Edges[0]; e.Weight != 0.5 || e.Label != EdgeReflection || e.Source != nodeID("a") {
+		t.Errorf("edge = %+v", e)
+	}
+}
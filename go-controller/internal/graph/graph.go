@@ -4,7 +4,13 @@ import (
 	"database/sql"
 	"fmt"
 	"math"
+	"strconv"
+	"strings"
 	"time"
+
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/evidence"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/state"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/timestamp"
 )
 
 // #region schema
@@ -26,7 +32,8 @@ CREATE INDEX IF NOT EXISTS idx_edges_target ON evidence_edges(target_id);
 // #endregion schema
 
 // #region types
-// Edge represents a weighted link between two evidence nodes.
+// Edge represents a weighted link between two evidence nodes. An edge of an
+// undirected type is stored with the lower ID as SourceID.
 type Edge struct {
 	ID        int64
 	SourceID  string
@@ -39,13 +46,107 @@ type Edge struct {
 
 // WalkResult holds an ordered path from a graph walk.
 type WalkResult struct {
-	IDs    []string  // node IDs in walk order
-	Scores []float64 // cumulative scores at each node
+	IDs    []string   // node IDs in walk order
+	Scores []float64  // cumulative scores at each node
+	Paths  [][]string // edge types followed from the entry to each node (nil for the entry)
+}
+
+// DescribePath says how a walked node was reached from its path of edge types,
+// e.g. "via reflection→temporal chain"; "" for the entry node.
+func DescribePath(path []string) string {
+	switch len(path) {
+	case 0:
+		return ""
+	case 1:
+		return "via " + path[0] + " edge"
+	default:
+		return "via " + strings.Join(path, "→") + " chain"
+	}
+}
+
+// WalkConfig controls graph walk traversal and scoring. A node's cumulative
+// score is the product, over the edges leading to it, of
+// weight × type prior × age discount.
+type WalkConfig struct {
+	MaxDepth  int     // hops from the entry (default 5)
+	MinWeight float64 // raw edge weight an edge needs to be followed (default 0.1)
+	MaxNodes  int     // nodes returned, entry included (default 10)
+
+	// TypePriors scale edges by type; types not listed use their registered
+	// WalkMultiplier, or 1 when unregistered. Nil scores raw weights.
+	TypePriors map[string]float64
+	// AgeHalfLife halves an edge's contribution for every half-life since it was
+	// last written (created, reinforced or decayed), so an association that keeps
+	// being strengthened stays fresh. Zero disables the age discount.
+	AgeHalfLife time.Duration
+	// Now is the reference time for edge age (zero = time.Now).
+	Now time.Time
+}
+
+// DefaultWalkConfig returns the defaults used by graph retrieval: each
+// registered type's walk multiplier as its prior, and associations lose half
+// their pull after 30 days.
+func DefaultWalkConfig() WalkConfig {
+	return WalkConfig{
+		MaxDepth:    5,
+		MinWeight:   0.1,
+		MaxNodes:    10,
+		TypePriors:  walkMultipliers(),
+		AgeHalfLife: 30 * 24 * time.Hour,
+	}
+}
+
+// EdgeScore is the factor edge contributes to a walk score under cfg.
+func (cfg WalkConfig) EdgeScore(e Edge) float64 {
+	score := e.Weight
+	if cfg.TypePriors != nil {
+		if prior, ok := cfg.TypePriors[e.EdgeType]; ok {
+			score *= prior
+		} else if t, ok := LookupEdgeType(e.EdgeType); ok {
+			score *= t.WalkMultiplier
+		}
+	}
+	touched := e.UpdatedAt
+	if touched.IsZero() {
+		touched = e.CreatedAt
+	}
+	if cfg.AgeHalfLife > 0 && !touched.IsZero() {
+		now := cfg.Now
+		if now.IsZero() {
+			now = time.Now()
+		}
+		if age := now.Sub(touched); age > 0 {
+			score *= math.Exp(-age.Seconds() * math.Ln2 / cfg.AgeHalfLife.Seconds())
+		}
+	}
+	return score
+}
+
+// ParseTypePriors parses a comma-separated "type=prior" list, e.g.
+// "reflection=1,temporal=0.5". Priors must be non-negative.
+func ParseTypePriors(spec string) (map[string]float64, error) {
+	priors := map[string]float64{}
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(part, "=")
+		if !ok || strings.TrimSpace(name) == "" {
+			return nil, fmt.Errorf("edge prior %q: want type=prior", part)
+		}
+		prior, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil || prior < 0 || math.IsNaN(prior) || math.IsInf(prior, 0) {
+			return nil, fmt.Errorf("edge prior %q: prior must be a non-negative number", part)
+		}
+		priors[strings.TrimSpace(name)] = prior
+	}
+	return priors, nil
 }
 
 // GraphStore manages the evidence_edges table.
 type GraphStore struct {
-	db *sql.DB
+	db state.DBTX
 }
 
 // #endregion types
@@ -56,15 +157,75 @@ func NewGraphStore(db *sql.DB) (*GraphStore, error) {
 	if _, err := db.Exec(schema); err != nil {
 		return nil, fmt.Errorf("graph schema: %w", err)
 	}
+	if _, err := db.Exec(cooccurSchema); err != nil {
+		return nil, fmt.Errorf("co-occurrence schema: %w", err)
+	}
+	if _, err := timestamp.Canonicalize(db, "evidence_edges", "created_at", "updated_at"); err != nil {
+		return nil, err
+	}
+	if _, err := mergeMirroredEdges(db); err != nil {
+		return nil, err
+	}
 	return &GraphStore{db: db}, nil
 }
 
+// mergeMirroredEdges rewrites undirected edges stored in both directions
+// (a→b and b→a, as co-retrieval edges were before they were undirected) into
+// one canonical row, lower ID as source, keeping the higher weight and the
+// later update. Returns how many mirrored rows were removed. Each step is
+// idempotent, so an interrupted merge finishes on the next open; a no-op once
+// the table is canonical.
+func mergeMirroredEdges(db state.DBTX) (int64, error) {
+	types := undirectedTypes()
+	if len(types) == 0 {
+		return 0, nil
+	}
+	in := `(?` + strings.Repeat(", ?", len(types)-1) + `)`
+	args := make([]any, len(types))
+	for i, t := range types {
+		args[i] = t
+	}
+	const mirror = `FROM evidence_edges m WHERE m.source_id = evidence_edges.target_id
+		AND m.target_id = evidence_edges.source_id AND m.edge_type = evidence_edges.edge_type`
+
+	if _, err := db.Exec(`UPDATE evidence_edges SET
+		weight = MAX(weight, (SELECT m.weight `+mirror+`)),
+		updated_at = MAX(updated_at, (SELECT m.updated_at `+mirror+`))
+		WHERE edge_type IN `+in+` AND source_id < target_id AND EXISTS (SELECT 1 `+mirror+`)`, args...); err != nil {
+		return 0, fmt.Errorf("merge mirrored edges: %w", err)
+	}
+	res, err := db.Exec(`DELETE FROM evidence_edges
+		WHERE edge_type IN `+in+` AND source_id > target_id AND EXISTS (SELECT 1 `+mirror+`)`, args...)
+	if err != nil {
+		return 0, fmt.Errorf("delete mirrored edges: %w", err)
+	}
+	merged, _ := res.RowsAffected()
+	// Single-direction rows stored the other way round just flip
+	if _, err := db.Exec(`UPDATE evidence_edges SET source_id = target_id, target_id = source_id
+		WHERE edge_type IN `+in+` AND source_id > target_id`, args...); err != nil {
+		return 0, fmt.Errorf("reorder undirected edges: %w", err)
+	}
+	return merged, nil
+}
+
+// WithTx returns a copy of the store bound to tx, for edge writes that must land
+// in the same transaction as other turn writes.
+func (g *GraphStore) WithTx(tx *sql.Tx) *GraphStore {
+	return &GraphStore{db: tx}
+}
+
 // #endregion constructor
 
 // #region add-edge
 // AddEdge inserts a new edge. If the edge already exists (same source, target, type), it is ignored.
+// Both endpoints must be local evidence IDs, and the type registered. An undirected edge is the
+// same edge from either end.
 func (g *GraphStore) AddEdge(sourceID, targetID, edgeType string, weight float64) error {
-	now := time.Now().UTC().Format(time.RFC3339)
+	if err := validateEdge(sourceID, targetID, edgeType); err != nil {
+		return err
+	}
+	sourceID, targetID = canonicalPair(sourceID, targetID, edgeType)
+	now := timestamp.Now()
 	_, err := g.db.Exec(
 		`INSERT OR IGNORE INTO evidence_edges (source_id, target_id, edge_type, weight, created_at, updated_at)
 		 VALUES (?, ?, ?, ?, ?, ?)`,
@@ -77,9 +238,14 @@ func (g *GraphStore) AddEdge(sourceID, targetID, edgeType string, weight float64
 
 // #region increment-edge
 // IncrementEdge increases the weight of an existing edge by delta, capped at 1.0.
-// If the edge doesn't exist, it is created with weight=delta.
+// If the edge doesn't exist, it is created with weight=delta. Both endpoints must be local
+// evidence IDs, and the type registered. An undirected edge is the same edge from either end.
 func (g *GraphStore) IncrementEdge(sourceID, targetID, edgeType string, delta float64) error {
-	now := time.Now().UTC().Format(time.RFC3339)
+	if err := validateEdge(sourceID, targetID, edgeType); err != nil {
+		return err
+	}
+	sourceID, targetID = canonicalPair(sourceID, targetID, edgeType)
+	now := timestamp.Now()
 	_, err := g.db.Exec(
 		`INSERT INTO evidence_edges (source_id, target_id, edge_type, weight, created_at, updated_at)
 		 VALUES (?, ?, ?, ?, ?, ?)
@@ -92,18 +258,34 @@ func (g *GraphStore) IncrementEdge(sourceID, targetID, edgeType string, delta fl
 	return err
 }
 
+func validateEdge(sourceID, targetID, edgeType string) error {
+	if err := evidence.ValidateLocalID(sourceID); err != nil {
+		return fmt.Errorf("edge source: %w", err)
+	}
+	if err := evidence.ValidateLocalID(targetID); err != nil {
+		return fmt.Errorf("edge target: %w", err)
+	}
+	return checkEdgeType(edgeType)
+}
+
 // #endregion increment-edge
 
 // #region get-neighbors
-// GetNeighbors returns all edges from sourceID with weight >= minWeight, ordered by weight descending.
+// GetNeighbors returns all edges from nodeID with weight >= minWeight, ordered by weight descending:
+// its outgoing directed edges and the undirected edges at either end, oriented with nodeID as source.
 func (g *GraphStore) GetNeighbors(nodeID string, minWeight float64) ([]Edge, error) {
-	rows, err := g.db.Query(
-		`SELECT id, source_id, target_id, edge_type, weight, created_at, updated_at
+	query := `SELECT id, source_id, target_id, edge_type, weight, created_at, updated_at
 		 FROM evidence_edges
-		 WHERE source_id = ? AND weight >= ?
-		 ORDER BY weight DESC`,
-		nodeID, minWeight,
-	)
+		 WHERE weight >= ? AND (source_id = ?`
+	args := []any{minWeight, nodeID}
+	if types := undirectedTypes(); len(types) > 0 {
+		query += ` OR (target_id = ? AND edge_type IN (?` + strings.Repeat(", ?", len(types)-1) + `))`
+		args = append(args, nodeID)
+		for _, t := range types {
+			args = append(args, t)
+		}
+	}
+	rows, err := g.db.Query(query+`) ORDER BY weight DESC, id`, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -116,8 +298,11 @@ func (g *GraphStore) GetNeighbors(nodeID string, minWeight float64) ([]Edge, err
 		if err := rows.Scan(&e.ID, &e.SourceID, &e.TargetID, &e.EdgeType, &e.Weight, &createdAt, &updatedAt); err != nil {
 			return nil, err
 		}
-		e.CreatedAt, _ = time.Parse(time.RFC3339, createdAt)
-		e.UpdatedAt, _ = time.Parse(time.RFC3339, updatedAt)
+		if e.SourceID != nodeID {
+			e.SourceID, e.TargetID = e.TargetID, e.SourceID
+		}
+		e.CreatedAt, _ = timestamp.Parse(createdAt)
+		e.UpdatedAt, _ = timestamp.Parse(updatedAt)
 		edges = append(edges, e)
 	}
 	return edges, rows.Err()
@@ -127,8 +312,16 @@ func (g *GraphStore) GetNeighbors(nodeID string, minWeight float64) ([]Edge, err
 
 // #region walk
 // Walk performs a BFS from entryID, following edges with weight >= minWeight,
-// up to maxDepth hops and maxNodes total. Returns nodes in visit order with cumulative scores.
+// up to maxDepth hops and maxNodes total. Returns nodes in visit order with
+// cumulative scores over raw edge weights.
 func (g *GraphStore) Walk(entryID string, maxDepth int, minWeight float64, maxNodes int) (WalkResult, error) {
+	return g.WalkScored(entryID, WalkConfig{MaxDepth: maxDepth, MinWeight: minWeight, MaxNodes: maxNodes})
+}
+
+// WalkScored is Walk with type priors and age discount applied to scores (see
+// WalkConfig). Traversal order is unchanged: neighbors are visited by raw weight.
+func (g *GraphStore) WalkScored(entryID string, cfg WalkConfig) (WalkResult, error) {
+	maxDepth, maxNodes := cfg.MaxDepth, cfg.MaxNodes
 	if maxDepth <= 0 {
 		maxDepth = 5
 	}
@@ -139,16 +332,18 @@ func (g *GraphStore) Walk(entryID string, maxDepth int, minWeight float64, maxNo
 	result := WalkResult{
 		IDs:    []string{entryID},
 		Scores: []float64{1.0},
+		Paths:  [][]string{nil},
 	}
 	visited := map[string]bool{entryID: true}
 
-	// BFS queue: (nodeID, depth, cumulativeScore)
+	// BFS queue: (nodeID, depth, cumulativeScore, edge-type path)
 	type queueItem struct {
 		id    string
 		depth int
 		score float64
+		path  []string
 	}
-	queue := []queueItem{{entryID, 0, 1.0}}
+	queue := []queueItem{{entryID, 0, 1.0, nil}}
 
 	for len(queue) > 0 {
 		if len(result.IDs) >= maxNodes {
@@ -162,7 +357,7 @@ func (g *GraphStore) Walk(entryID string, maxDepth int, minWeight float64, maxNo
 			continue
 		}
 
-		neighbors, err := g.GetNeighbors(current.id, minWeight)
+		neighbors, err := g.GetNeighbors(current.id, cfg.MinWeight)
 		if err != nil {
 			return result, fmt.Errorf("walk neighbors: %w", err)
 		}
@@ -175,10 +370,12 @@ func (g *GraphStore) Walk(entryID string, maxDepth int, minWeight float64, maxNo
 				continue
 			}
 			visited[edge.TargetID] = true
-			cumScore := current.score * edge.Weight
+			cumScore := current.score * cfg.EdgeScore(edge)
+			path := append(append([]string(nil), current.path...), edge.EdgeType)
 			result.IDs = append(result.IDs, edge.TargetID)
 			result.Scores = append(result.Scores, cumScore)
-			queue = append(queue, queueItem{edge.TargetID, current.depth + 1, cumScore})
+			result.Paths = append(result.Paths, path)
+			queue = append(queue, queueItem{edge.TargetID, current.depth + 1, cumScore, path})
 		}
 	}
 
@@ -188,14 +385,20 @@ func (g *GraphStore) Walk(entryID string, maxDepth int, minWeight float64, maxNo
 // #endregion walk
 
 // #region decay
-// DecayAll applies exponential decay to all edge weights based on time since last update.
+// DecayAll applies exponential decay to all edge weights based on time since last update,
+// at each type's registered half-life; halfLifeHours applies to types that set none.
 // Edges that fall below 0.01 are deleted.
 func (g *GraphStore) DecayAll(halfLifeHours float64) (int64, error) {
 	now := time.Now().UTC()
-	halfLifeSec := halfLifeHours * 3600.0
+	halfLives := map[string]float64{}
+	for _, t := range EdgeTypes() {
+		if t.HalfLife > 0 {
+			halfLives[t.Name] = t.HalfLife.Seconds()
+		}
+	}
 
 	rows, err := g.db.Query(
-		`SELECT id, weight, updated_at FROM evidence_edges`,
+		`SELECT id, edge_type, weight, updated_at FROM evidence_edges`,
 	)
 	if err != nil {
 		return 0, err
@@ -210,17 +413,21 @@ func (g *GraphStore) DecayAll(halfLifeHours float64) (int64, error) {
 
 	for rows.Next() {
 		var id int64
+		var edgeType, updatedAt string
 		var weight float64
-		var updatedAt string
-		if err := rows.Scan(&id, &weight, &updatedAt); err != nil {
+		if err := rows.Scan(&id, &edgeType, &weight, &updatedAt); err != nil {
 			rows.Close()
 			return 0, err
 		}
-		t, _ := time.Parse(time.RFC3339, updatedAt)
+		t, _ := timestamp.Parse(updatedAt)
 		ageSec := now.Sub(t).Seconds()
 		if ageSec <= 0 {
 			continue
 		}
+		halfLifeSec, ok := halfLives[edgeType]
+		if !ok {
+			halfLifeSec = halfLifeHours * 3600.0
+		}
 		decayed := weight * math.Exp(-ageSec*math.Ln2/halfLifeSec)
 		if decayed < 0.01 {
 			deletes = append(deletes, id)
@@ -230,7 +437,7 @@ func (g *GraphStore) DecayAll(halfLifeHours float64) (int64, error) {
 	}
 	rows.Close()
 
-	nowStr := now.Format(time.RFC3339)
+	nowStr := timestamp.Format(now)
 	for _, u := range updates {
 		if _, err := g.db.Exec(`UPDATE evidence_edges SET weight = ?, updated_at = ? WHERE id = ?`, u.newWeight, nowStr, u.id); err != nil {
 			return 0, err
@@ -248,13 +455,54 @@ func (g *GraphStore) DecayAll(halfLifeHours float64) (int64, error) {
 // #endregion decay
 
 // #region sever
-// SeverNode deletes all edges where nodeID is either source or target.
+// SeverNode deletes all edges where nodeID is either source or target, along
+// with its co-occurrence counts so a deleted node stops influencing PMI scores.
 func (g *GraphStore) SeverNode(nodeID string) error {
 	_, err := g.db.Exec(
 		`DELETE FROM evidence_edges WHERE source_id = ? OR target_id = ?`,
 		nodeID, nodeID,
 	)
+	if err != nil {
+		return err
+	}
+	if _, err := g.db.Exec(`DELETE FROM evidence_occurrence WHERE node_id = ?`, nodeID); err != nil {
+		return err
+	}
+	_, err = g.db.Exec(`DELETE FROM evidence_cooccurrence WHERE a_id = ? OR b_id = ?`, nodeID, nodeID)
 	return err
 }
 
 // #endregion sever
+
+// #region migrate-ids
+// MigrateEvidenceIDs rewrites node IDs stored before the evidence ID scheme
+// (bare UUIDs) to ev_<uuid> and drops edges and counts whose IDs are malformed,
+// so graph joins only ever compare well-formed local IDs.
+func (g *GraphStore) MigrateEvidenceIDs() (evidence.IDMigration, error) {
+	var total evidence.IDMigration
+	for _, t := range []struct {
+		table string
+		cols  []string
+	}{
+		{"evidence_edges", []string{"source_id", "target_id"}},
+		{"evidence_occurrence", []string{"node_id"}},
+		{"evidence_cooccurrence", []string{"a_id", "b_id"}},
+	} {
+		m, err := evidence.MigrateIDColumns(g.db, t.table, t.cols...)
+		if err != nil {
+			return total, err
+		}
+		total.Renamed += m.Renamed
+		total.Dropped += m.Dropped
+	}
+	// Renaming can reorder a pair; restore the a_id < b_id invariant
+	if _, err := g.db.Exec(`UPDATE OR IGNORE evidence_cooccurrence SET a_id = b_id, b_id = a_id WHERE a_id > b_id`); err != nil {
+		return total, fmt.Errorf("reorder co-occurrence pairs: %w", err)
+	}
+	if _, err := mergeMirroredEdges(g.db); err != nil {
+		return total, err
+	}
+	return total, nil
+}
+
+// #endregion migrate-ids
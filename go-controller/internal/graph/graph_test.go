@@ -2,10 +2,14 @@ package graph
 
 import (
 	"database/sql"
+	"errors"
+	"fmt"
 	"math"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/evidence"
 	_ "modernc.org/sqlite"
 )
 
@@ -19,6 +23,13 @@ func setupTestDB(t *testing.T) *sql.DB {
 	return db
 }
 
+// nodeID maps a short test name to a valid evidence ID, so the prose of each
+// test can keep talking about nodes "a", "b", "hub".
+func nodeID(name string) string {
+	hex := fmt.Sprintf("%x", name)
+	return "ev_00000000-0000-4000-8000-" + strings.Repeat("0", 12-len(hex)) + hex
+}
+
 // #region test-add-edge
 func TestAddEdge(t *testing.T) {
 	db := setupTestDB(t)
@@ -28,19 +39,19 @@ func TestAddEdge(t *testing.T) {
 	}
 
 	// Add edge
-	if err := gs.AddEdge("a", "b", "co_retrieval", 0.1); err != nil {
+	if err := gs.AddEdge(nodeID("a"), nodeID("b"), "co_retrieval", 0.1); err != nil {
 		t.Fatalf("add edge: %v", err)
 	}
 
 	// Verify via GetNeighbors
-	edges, err := gs.GetNeighbors("a", 0.0)
+	edges, err := gs.GetNeighbors(nodeID("a"), 0.0)
 	if err != nil {
 		t.Fatalf("get neighbors: %v", err)
 	}
 	if len(edges) != 1 {
 		t.Fatalf("expected 1 edge, got %d", len(edges))
 	}
-	if edges[0].TargetID != "b" || edges[0].EdgeType != "co_retrieval" {
+	if edges[0].TargetID != nodeID("b") || edges[0].EdgeType != "co_retrieval" {
 		t.Errorf("unexpected edge: %+v", edges[0])
 	}
 	if math.Abs(edges[0].Weight-0.1) > 0.001 {
@@ -48,10 +59,10 @@ func TestAddEdge(t *testing.T) {
 	}
 
 	// Duplicate insert should be ignored
-	if err := gs.AddEdge("a", "b", "co_retrieval", 0.5); err != nil {
+	if err := gs.AddEdge(nodeID("a"), nodeID("b"), "co_retrieval", 0.5); err != nil {
 		t.Fatalf("duplicate add: %v", err)
 	}
-	edges, _ = gs.GetNeighbors("a", 0.0)
+	edges, _ = gs.GetNeighbors(nodeID("a"), 0.0)
 	if len(edges) != 1 {
 		t.Fatalf("expected 1 edge after duplicate, got %d", len(edges))
 	}
@@ -63,6 +74,36 @@ func TestAddEdge(t *testing.T) {
 
 // #endregion test-add-edge
 
+// #region test-with-tx
+func TestWithTx(t *testing.T) {
+	db := setupTestDB(t)
+	gs, err := NewGraphStore(db)
+	if err != nil {
+		t.Fatalf("new graph store: %v", err)
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatalf("begin: %v", err)
+	}
+	if err := gs.WithTx(tx).AddEdge(nodeID("a"), nodeID("b"), "temporal", 0.05); err != nil {
+		t.Fatalf("add edge in tx: %v", err)
+	}
+	if err := tx.Rollback(); err != nil {
+		t.Fatalf("rollback: %v", err)
+	}
+
+	edges, err := gs.GetNeighbors(nodeID("a"), 0.0)
+	if err != nil {
+		t.Fatalf("get neighbors: %v", err)
+	}
+	if len(edges) != 0 {
+		t.Fatalf("expected no edges after rollback, got %d", len(edges))
+	}
+}
+
+// #endregion test-with-tx
+
 // #region test-increment-edge
 func TestIncrementEdge(t *testing.T) {
 	db := setupTestDB(t)
@@ -72,29 +113,29 @@ func TestIncrementEdge(t *testing.T) {
 	}
 
 	// First increment creates the edge
-	if err := gs.IncrementEdge("a", "b", "co_retrieval", 0.1); err != nil {
+	if err := gs.IncrementEdge(nodeID("a"), nodeID("b"), "co_retrieval", 0.1); err != nil {
 		t.Fatalf("increment: %v", err)
 	}
 
-	edges, _ := gs.GetNeighbors("a", 0.0)
+	edges, _ := gs.GetNeighbors(nodeID("a"), 0.0)
 	if len(edges) != 1 || math.Abs(edges[0].Weight-0.1) > 0.001 {
 		t.Fatalf("first increment: expected weight 0.1, got %+v", edges)
 	}
 
 	// Second increment should add 0.1
-	if err := gs.IncrementEdge("a", "b", "co_retrieval", 0.1); err != nil {
+	if err := gs.IncrementEdge(nodeID("a"), nodeID("b"), "co_retrieval", 0.1); err != nil {
 		t.Fatalf("increment 2: %v", err)
 	}
-	edges, _ = gs.GetNeighbors("a", 0.0)
+	edges, _ = gs.GetNeighbors(nodeID("a"), 0.0)
 	if math.Abs(edges[0].Weight-0.2) > 0.001 {
 		t.Errorf("expected weight 0.2, got %.4f", edges[0].Weight)
 	}
 
 	// Cap at 1.0
-	if err := gs.IncrementEdge("a", "b", "co_retrieval", 5.0); err != nil {
+	if err := gs.IncrementEdge(nodeID("a"), nodeID("b"), "co_retrieval", 5.0); err != nil {
 		t.Fatalf("increment big: %v", err)
 	}
-	edges, _ = gs.GetNeighbors("a", 0.0)
+	edges, _ = gs.GetNeighbors(nodeID("a"), 0.0)
 	if math.Abs(edges[0].Weight-1.0) > 0.001 {
 		t.Errorf("expected weight capped at 1.0, got %.4f", edges[0].Weight)
 	}
@@ -111,13 +152,13 @@ func TestWalk(t *testing.T) {
 	}
 
 	// Build a chain: a -> b -> c -> d
-	gs.AddEdge("a", "b", "temporal", 0.5)
-	gs.AddEdge("b", "c", "temporal", 0.8)
-	gs.AddEdge("c", "d", "temporal", 0.3)
+	gs.AddEdge(nodeID("a"), nodeID("b"), "temporal", 0.5)
+	gs.AddEdge(nodeID("b"), nodeID("c"), "temporal", 0.8)
+	gs.AddEdge(nodeID("c"), nodeID("d"), "temporal", 0.3)
 	// Add a branch: a -> e
-	gs.AddEdge("a", "e", "co_retrieval", 0.2)
+	gs.AddEdge(nodeID("a"), nodeID("e"), "co_retrieval", 0.2)
 
-	result, err := gs.Walk("a", 5, 0.1, 100)
+	result, err := gs.Walk(nodeID("a"), 5, 0.1, 100)
 	if err != nil {
 		t.Fatalf("walk: %v", err)
 	}
@@ -126,23 +167,23 @@ func TestWalk(t *testing.T) {
 	if len(result.IDs) != 5 {
 		t.Fatalf("expected 5 nodes, got %d: %v", len(result.IDs), result.IDs)
 	}
-	if result.IDs[0] != "a" {
+	if result.IDs[0] != nodeID("a") {
 		t.Errorf("first node should be 'a', got %s", result.IDs[0])
 	}
 
 	// With minWeight 0.3, 'e' edge (0.2) should be filtered
-	result2, err := gs.Walk("a", 5, 0.3, 100)
+	result2, err := gs.Walk(nodeID("a"), 5, 0.3, 100)
 	if err != nil {
 		t.Fatalf("walk filtered: %v", err)
 	}
 	for _, id := range result2.IDs {
-		if id == "e" {
+		if id == nodeID("e") {
 			t.Error("node 'e' should be filtered by minWeight 0.3")
 		}
 	}
 
 	// Depth limit
-	result3, err := gs.Walk("a", 1, 0.1, 100)
+	result3, err := gs.Walk(nodeID("a"), 1, 0.1, 100)
 	if err != nil {
 		t.Fatalf("walk depth 1: %v", err)
 	}
@@ -152,7 +193,7 @@ func TestWalk(t *testing.T) {
 	}
 
 	// maxNodes cap
-	result4, err := gs.Walk("a", 5, 0.1, 3)
+	result4, err := gs.Walk(nodeID("a"), 5, 0.1, 3)
 	if err != nil {
 		t.Fatalf("walk maxNodes 3: %v", err)
 	}
@@ -161,6 +202,95 @@ func TestWalk(t *testing.T) {
 	}
 }
 
+func TestWalkScored(t *testing.T) {
+	db := setupTestDB(t)
+	gs, err := NewGraphStore(db)
+	if err != nil {
+		t.Fatalf("new graph store: %v", err)
+	}
+
+	// a -reflection-> b -temporal-> c, and a -temporal-> d (old edge)
+	gs.AddEdge(nodeID("a"), nodeID("b"), "reflection", 0.5)
+	gs.AddEdge(nodeID("b"), nodeID("c"), "temporal", 0.5)
+	gs.AddEdge(nodeID("a"), nodeID("d"), "temporal", 0.4)
+	now := time.Now().UTC()
+	old := now.Add(-10 * 24 * time.Hour).Format(time.RFC3339)
+	if _, err := db.Exec(`UPDATE evidence_edges SET created_at = ?, updated_at = ? WHERE target_id = ?`, old, old, nodeID("d")); err != nil {
+		t.Fatalf("age edge: %v", err)
+	}
+	// An old edge reinforced since counts from its last update
+	if _, err := db.Exec(`UPDATE evidence_edges SET created_at = ? WHERE target_id = ?`, old, nodeID("c")); err != nil {
+		t.Fatalf("age edge: %v", err)
+	}
+
+	cfg := WalkConfig{
+		MinWeight:   0.1,
+		TypePriors:  map[string]float64{"temporal": 0.5},
+		AgeHalfLife: 10 * 24 * time.Hour,
+		Now:         now,
+	}
+	result, err := gs.WalkScored(nodeID("a"), cfg)
+	if err != nil {
+		t.Fatalf("walk: %v", err)
+	}
+	scores := map[string]float64{}
+	paths := map[string][]string{}
+	for i, id := range result.IDs {
+		scores[id] = result.Scores[i]
+		paths[id] = result.Paths[i]
+	}
+	if len(result.IDs) != 4 || len(result.Paths) != 4 {
+		t.Fatalf("expected 4 nodes with paths, got %v / %v", result.IDs, result.Paths)
+	}
+
+	approx := func(got, want float64) bool { return math.Abs(got-want) < 0.005 }
+	if !approx(scores[nodeID("b")], 0.5) {
+		t.Errorf("reflection edge (no prior, fresh): score %.4f, want 0.5", scores[nodeID("b")])
+	}
+	if !approx(scores[nodeID("c")], 0.5*0.5*0.5) {
+		t.Errorf("reflection→temporal: score %.4f, want 0.125", scores[nodeID("c")])
+	}
+	if !approx(scores[nodeID("d")], 0.4*0.5*0.5) {
+		t.Errorf("temporal edge one half-life old: score %.4f, want 0.1", scores[nodeID("d")])
+	}
+
+	if paths[nodeID("a")] != nil {
+		t.Errorf("entry should have no path, got %v", paths[nodeID("a")])
+	}
+	if got := DescribePath(paths[nodeID("c")]); got != "via reflection→temporal chain" {
+		t.Errorf("path to c = %q", got)
+	}
+	if got := DescribePath(paths[nodeID("b")]); got != "via reflection edge" {
+		t.Errorf("path to b = %q", got)
+	}
+
+	// Walk keeps scoring raw weights
+	raw, err := gs.Walk(nodeID("a"), 5, 0.1, 10)
+	if err != nil {
+		t.Fatalf("raw walk: %v", err)
+	}
+	for i, id := range raw.IDs {
+		if id == nodeID("c") && !approx(raw.Scores[i], 0.25) {
+			t.Errorf("raw walk score for c = %.4f, want 0.25", raw.Scores[i])
+		}
+	}
+}
+
+func TestParseTypePriors(t *testing.T) {
+	priors, err := ParseTypePriors(" reflection=1, temporal=0.25 ,")
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if len(priors) != 2 || priors["reflection"] != 1 || priors["temporal"] != 0.25 {
+		t.Errorf("unexpected priors: %v", priors)
+	}
+	for _, bad := range []string{"temporal", "=0.5", "temporal=-1", "temporal=high", "temporal=NaN"} {
+		if _, err := ParseTypePriors(bad); err == nil {
+			t.Errorf("%q should be rejected", bad)
+		}
+	}
+}
+
 // #endregion test-walk
 
 // #region test-decay
@@ -176,11 +306,11 @@ func TestDecayAll(t *testing.T) {
 	db.Exec(
 		`INSERT INTO evidence_edges (source_id, target_id, edge_type, weight, created_at, updated_at)
 		 VALUES (?, ?, ?, ?, ?, ?)`,
-		"old-a", "old-b", "temporal", 0.1, past, past,
+		nodeID("old-a"), nodeID("old-b"), "temporal", 0.1, past, past,
 	)
 
 	// Insert a fresh edge
-	gs.AddEdge("new-a", "new-b", "temporal", 0.5)
+	gs.AddEdge(nodeID("new-a"), nodeID("new-b"), "temporal", 0.5)
 
 	// Decay with 48h half-life
 	deleted, err := gs.DecayAll(48.0)
@@ -193,7 +323,7 @@ func TestDecayAll(t *testing.T) {
 	// But if weight were 0.03: 0.03 * 0.25 = 0.0075, would be deleted.
 
 	// Fresh edge should barely decay
-	edges, _ := gs.GetNeighbors("new-a", 0.0)
+	edges, _ := gs.GetNeighbors(nodeID("new-a"), 0.0)
 	if len(edges) != 1 {
 		t.Fatalf("fresh edge should survive, got %d", len(edges))
 	}
@@ -214,32 +344,182 @@ func TestSeverNode(t *testing.T) {
 		t.Fatalf("new graph store: %v", err)
 	}
 
-	gs.AddEdge("a", "b", "temporal", 0.5)
-	gs.AddEdge("b", "c", "temporal", 0.5)
-	gs.AddEdge("c", "b", "co_retrieval", 0.3)
+	gs.AddEdge(nodeID("a"), nodeID("b"), "temporal", 0.5)
+	gs.AddEdge(nodeID("b"), nodeID("c"), "temporal", 0.5)
+	gs.AddEdge(nodeID("c"), nodeID("b"), "co_retrieval", 0.3)
 
 	// Sever 'b' — should remove a->b, b->c, c->b
-	if err := gs.SeverNode("b"); err != nil {
+	if err := gs.SeverNode(nodeID("b")); err != nil {
 		t.Fatalf("sever: %v", err)
 	}
 
 	// No neighbors from a (a->b gone)
-	edges, _ := gs.GetNeighbors("a", 0.0)
+	edges, _ := gs.GetNeighbors(nodeID("a"), 0.0)
 	if len(edges) != 0 {
 		t.Errorf("expected 0 edges from 'a' after sever, got %d", len(edges))
 	}
 
 	// No neighbors from b (b->c gone)
-	edges, _ = gs.GetNeighbors("b", 0.0)
+	edges, _ = gs.GetNeighbors(nodeID("b"), 0.0)
 	if len(edges) != 0 {
 		t.Errorf("expected 0 edges from 'b' after sever, got %d", len(edges))
 	}
 
 	// No neighbors from c (c->b gone)
-	edges, _ = gs.GetNeighbors("c", 0.0)
+	edges, _ = gs.GetNeighbors(nodeID("c"), 0.0)
 	if len(edges) != 0 {
 		t.Errorf("expected 0 edges from 'c' after sever, got %d", len(edges))
 	}
 }
 
 // #endregion test-sever
+
+// #region test-evidence-ids
+func TestAddEdge_RejectsInvalidIDs(t *testing.T) {
+	gs, _ := NewGraphStore(setupTestDB(t))
+	if err := gs.AddEdge("chunk-1", nodeID("b"), "temporal", 0.5); !errors.Is(err, evidence.ErrInvalidID) {
+		t.Fatalf("expected invalid source rejected, got %v", err)
+	}
+	if err := gs.IncrementEdge(nodeID("a"), "team::doc-1", "co_retrieval", 0.1); !errors.Is(err, evidence.ErrInvalidID) {
+		t.Fatalf("expected federated target rejected, got %v", err)
+	}
+}
+
+func TestMigrateEvidenceIDs(t *testing.T) {
+	db := setupTestDB(t)
+	gs, _ := NewGraphStore(db)
+	legacy := "3F2504E0-4F89-41D3-9A0C-0305E82C3301"
+	migrated := "ev_3f2504e0-4f89-41d3-9a0c-0305e82c3301"
+	now := time.Now().UTC().Format(time.RFC3339)
+	insert := `INSERT INTO evidence_edges (source_id, target_id, edge_type, weight, created_at, updated_at) VALUES (?, ?, 'temporal', 0.5, ?, ?)`
+	db.Exec(insert, legacy, nodeID("b"), now, now)
+	db.Exec(insert, "garbage", nodeID("b"), now, now)
+
+	m, err := gs.MigrateEvidenceIDs()
+	if err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	if m.Renamed != 1 || m.Dropped != 1 {
+		t.Fatalf("expected 1 renamed, 1 dropped, got %+v", m)
+	}
+	if edges, _ := gs.GetNeighbors(migrated, 0); len(edges) != 1 {
+		t.Fatalf("expected edge under migrated id, got %d", len(edges))
+	}
+	if m, _ := gs.MigrateEvidenceIDs(); m.Renamed != 0 || m.Dropped != 0 {
+		t.Fatalf("second run should be a no-op, got %+v", m)
+	}
+}
+
+// #endregion test-evidence-ids
+
+// #region test-undirected
+func TestUndirectedEdges(t *testing.T) {
+	db := setupTestDB(t)
+	gs, err := NewGraphStore(db)
+	if err != nil {
+		t.Fatalf("new graph store: %v", err)
+	}
+	a, b, c := nodeID("a"), nodeID("b"), nodeID("c")
+
+	// Either direction lands on the same canonical row
+	if err := gs.IncrementEdge(b, a, EdgeCoRetrieval, 0.1); err != nil {
+		t.Fatalf("increment: %v", err)
+	}
+	if err := gs.IncrementEdge(a, b, EdgeCoRetrieval, 0.1); err != nil {
+		t.Fatalf("increment: %v", err)
+	}
+	if err := gs.AddEdge(b, a, EdgeCoRetrieval, 0.9); err != nil {
+		t.Fatalf("add: %v", err)
+	}
+	var rows int
+	var src string
+	if err := db.QueryRow(`SELECT COUNT(*), MIN(source_id) FROM evidence_edges`).Scan(&rows, &src); err != nil {
+		t.Fatalf("count: %v", err)
+	}
+	if rows != 1 || src != a {
+		t.Fatalf("rows = %d source %s, want one row from the lower ID", rows, src)
+	}
+
+	// Followed from both ends, oriented away from the asking node
+	for _, pair := range [][2]string{{a, b}, {b, a}} {
+		edges, err := gs.GetNeighbors(pair[0], 0)
+		if err != nil {
+			t.Fatalf("neighbors: %v", err)
+		}
+		if len(edges) != 1 || edges[0].SourceID != pair[0] || edges[0].TargetID != pair[1] || math.Abs(edges[0].Weight-0.2) > 1e-9 {
+			t.Errorf("neighbors of %s = %+v, want one 0.2 edge to %s", pair[0], edges, pair[1])
+		}
+	}
+
+	// Directed edges still only leave their source
+	if err := gs.AddEdge(c, a, EdgeTemporal, 0.5); err != nil {
+		t.Fatalf("add temporal: %v", err)
+	}
+	if edges, _ := gs.GetNeighbors(a, 0); len(edges) != 1 {
+		t.Errorf("a should not follow the temporal edge from c backwards, got %d edges", len(edges))
+	}
+	result, err := gs.Walk(b, 3, 0, 10)
+	if err != nil {
+		t.Fatalf("walk: %v", err)
+	}
+	if len(result.IDs) != 2 || result.IDs[1] != a {
+		t.Errorf("walk from b = %v, want b then a", result.IDs)
+	}
+}
+
+func TestMergeMirroredEdges(t *testing.T) {
+	db := setupTestDB(t)
+	if _, err := NewGraphStore(db); err != nil {
+		t.Fatalf("new graph store: %v", err)
+	}
+	a, b, c, d := nodeID("a"), nodeID("b"), nodeID("c"), nodeID("d")
+	// As stored before co-retrieval edges were undirected: mirrored pairs,
+	// plus a single reversed row and a directed temporal pair
+	for _, e := range []struct {
+		src, dst, typ, updated string
+		w                      float64
+	}{
+		{a, b, EdgeCoRetrieval, "2024-01-01T00:00:00Z", 0.3},
+		{b, a, EdgeCoRetrieval, "2024-01-02T00:00:00Z", 0.4},
+		{d, c, EdgeCoRetrieval, "2024-01-01T00:00:00Z", 0.2},
+		{a, c, EdgeTemporal, "2024-01-01T00:00:00Z", 0.1},
+		{c, a, EdgeTemporal, "2024-01-01T00:00:00Z", 0.1},
+	} {
+		if _, err := db.Exec(`INSERT INTO evidence_edges (source_id, target_id, edge_type, weight, created_at, updated_at)
+			VALUES (?, ?, ?, ?, ?, ?)`, e.src, e.dst, e.typ, e.w, e.updated, e.updated); err != nil {
+			t.Fatalf("insert: %v", err)
+		}
+	}
+
+	merged, err := mergeMirroredEdges(db)
+	if err != nil {
+		t.Fatalf("merge: %v", err)
+	}
+	if merged != 1 {
+		t.Errorf("merged = %d, want 1 mirrored row removed", merged)
+	}
+	var w float64
+	var updated string
+	if err := db.QueryRow(`SELECT weight, updated_at FROM evidence_edges WHERE source_id = ? AND target_id = ?`, a, b).Scan(&w, &updated); err != nil {
+		t.Fatalf("merged row: %v", err)
+	}
+	if w != 0.4 || updated != "2024-01-02T00:00:00Z" {
+		t.Errorf("merged row weight %v updated %s, want the higher weight and later update", w, updated)
+	}
+	var n int
+	db.QueryRow(`SELECT COUNT(*) FROM evidence_edges WHERE source_id = ? AND target_id = ?`, c, d).Scan(&n)
+	if n != 1 {
+		t.Error("a single reversed undirected row should be flipped to the lower ID first")
+	}
+	db.QueryRow(`SELECT COUNT(*) FROM evidence_edges WHERE edge_type = ?`, EdgeTemporal).Scan(&n)
+	if n != 2 {
+		t.Errorf("directed edges must be left alone, got %d temporal rows", n)
+	}
+
+	// Reopening the store finds nothing left to merge
+	if merged, err := mergeMirroredEdges(db); err != nil || merged != 0 {
+		t.Errorf("second merge = %d, %v; want a no-op", merged, err)
+	}
+}
+
+// #endregion test-undirected
@@ -0,0 +1,176 @@
+package graph
+
+import (
+	"fmt"
+	"math"
+	"sync"
+)
+
+// #region pagerank
+
+// PageRankConfig sets the PageRank iteration.
+type PageRankConfig struct {
+	Damping    float64 // probability of following an edge rather than jumping (default 0.85)
+	Iterations int     // power iterations at most (default 100)
+	Tolerance  float64 // stop when the L1 change of an iteration falls below this (default 1e-6)
+}
+
+// DefaultPageRankConfig returns the usual PageRank parameters.
+func DefaultPageRankConfig() PageRankConfig {
+	return PageRankConfig{Damping: 0.85, Iterations: 100, Tolerance: 1e-6}
+}
+
+// PageRank computes weighted PageRank over edges. A node passes its rank to
+// its targets in proportion to edge weight × the type's registered walk
+// multiplier (1 for unregistered types), along undirected edges in both
+// directions; nodes without out-edges spread theirs evenly. Scores sum to 1
+// over the nodes that appear in edges.
+func PageRank(edges []Edge, cfg PageRankConfig) map[string]float64 {
+	ids := NodeIDs(edges)
+	n := len(ids)
+	if n == 0 {
+		return map[string]float64{}
+	}
+	if cfg.Damping <= 0 || cfg.Damping >= 1 {
+		cfg.Damping = 0.85
+	}
+	if cfg.Iterations <= 0 {
+		cfg.Iterations = 100
+	}
+	index := make(map[string]int, n)
+	for i, id := range ids {
+		index[id] = i
+	}
+
+	type link struct {
+		to int
+		w  float64
+	}
+	out := make([][]link, n)
+	outWeight := make([]float64, n)
+	for _, e := range edges {
+		w := e.Weight
+		t, ok := LookupEdgeType(e.EdgeType)
+		if ok {
+			w *= t.WalkMultiplier
+		}
+		if w <= 0 {
+			continue
+		}
+		from, to := index[e.SourceID], index[e.TargetID]
+		out[from] = append(out[from], link{to, w})
+		outWeight[from] += w
+		if t.Undirected && from != to {
+			out[to] = append(out[to], link{from, w})
+			outWeight[to] += w
+		}
+	}
+
+	rank := make([]float64, n)
+	for i := range rank {
+		rank[i] = 1 / float64(n)
+	}
+	next := make([]float64, n)
+	for iter := 0; iter < cfg.Iterations; iter++ {
+		var dangling float64
+		for i := range next {
+			next[i] = 0
+		}
+		for i, links := range out {
+			if outWeight[i] == 0 {
+				dangling += rank[i]
+				continue
+			}
+			for _, l := range links {
+				next[l.to] += rank[i] * l.w / outWeight[i]
+			}
+		}
+		base := (1-cfg.Damping)/float64(n) + cfg.Damping*dangling/float64(n)
+		var delta float64
+		for i := range next {
+			next[i] = base + cfg.Damping*next[i]
+			delta += math.Abs(next[i] - rank[i])
+		}
+		rank, next = next, rank
+		if delta < cfg.Tolerance {
+			break
+		}
+	}
+
+	scores := make(map[string]float64, n)
+	for i, id := range ids {
+		scores[id] = rank[i]
+	}
+	return scores
+}
+
+// #endregion pagerank
+
+// #region centrality
+
+// Centrality caches the PageRank of a store's graph. The cache is keyed by a
+// cheap fingerprint of evidence_edges (row count, highest ID, latest update and
+// total weight), so it is recomputed only after edges were added, reweighted,
+// decayed or deleted. Safe for concurrent use.
+type Centrality struct {
+	store *GraphStore
+	cfg   PageRankConfig
+
+	mu          sync.Mutex
+	fingerprint string
+	scores      map[string]float64
+	max         float64
+}
+
+// NewCentrality returns a centrality cache over g's edges.
+func NewCentrality(g *GraphStore, cfg PageRankConfig) *Centrality {
+	return &Centrality{store: g, cfg: cfg}
+}
+
+// Normalized returns the PageRank of each of ids divided by the highest
+// PageRank in the graph, so the best-connected node scores 1. IDs that are
+// not in the graph score 0.
+func (c *Centrality) Normalized(ids []string) (map[string]float64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.refresh(); err != nil {
+		return nil, err
+	}
+	out := make(map[string]float64, len(ids))
+	for _, id := range ids {
+		if c.max > 0 {
+			out[id] = c.scores[id] / c.max
+		}
+	}
+	return out, nil
+}
+
+// refresh recomputes the scores when the edges changed since the last call.
+func (c *Centrality) refresh() error {
+	var count, maxID int64
+	var lastUpdate string
+	var total float64
+	err := c.store.db.QueryRow(
+		`SELECT COUNT(*), COALESCE(MAX(id), 0), COALESCE(MAX(updated_at), ''), COALESCE(SUM(weight), 0) FROM evidence_edges`,
+	).Scan(&count, &maxID, &lastUpdate, &total)
+	if err != nil {
+		return fmt.Errorf("graph fingerprint: %w", err)
+	}
+	fp := fmt.Sprintf("%d/%d/%s/%.9f", count, maxID, lastUpdate, total)
+	if c.scores != nil && fp == c.fingerprint {
+		return nil
+	}
+	edges, err := c.store.Subgraph(ExportFilter{})
+	if err != nil {
+		return err
+	}
+	c.scores = PageRank(edges, c.cfg)
+	c.max = 0
+	for _, s := range c.scores {
+		c.max = math.Max(c.max, s)
+	}
+	c.fingerprint = fp
+	return nil
+}
+
+// #endregion centrality
@@ -0,0 +1,99 @@
+package graph
+
+import (
+	"math"
+	"testing"
+)
+
+func TestPageRank(t *testing.T) {
+	// a, b and c all point at hub; hub points back at a; d hangs off c
+	edges := []Edge{
+		{SourceID: "a", TargetID: "hub", EdgeType: EdgeReflection, Weight: 0.5},
+		{SourceID: "b", TargetID: "hub", EdgeType: EdgeReflection, Weight: 0.5},
+		{SourceID: "c", TargetID: "hub", EdgeType: EdgeReflection, Weight: 0.5},
+		{SourceID: "c", TargetID: "d", EdgeType: EdgeReflection, Weight: 0.1},
+		{SourceID: "hub", TargetID: "a", EdgeType: EdgeReflection, Weight: 0.5},
+	}
+	scores := PageRank(edges, DefaultPageRankConfig())
+	if len(scores) != 5 {
+		t.Fatalf("scores for %d nodes, want 5", len(scores))
+	}
+	var sum float64
+	for _, s := range scores {
+		sum += s
+	}
+	if math.Abs(sum-1) > 1e-6 {
+		t.Errorf("scores sum to %v, want 1", sum)
+	}
+	for _, id := range []string{"a", "b", "c", "d"} {
+		if scores["hub"] <= scores[id] {
+			t.Errorf("hub %.4f should outrank %s %.4f", scores["hub"], id, scores[id])
+		}
+	}
+	if scores["a"] <= scores["b"] {
+		t.Errorf("a (linked from hub) %.4f should outrank b %.4f", scores["a"], scores["b"])
+	}
+	// c splits its rank by weight: the light edge to d passes little
+	if scores["d"] >= scores["hub"]/2 {
+		t.Errorf("d %.4f should get little of c's rank", scores["d"])
+	}
+
+	if got := PageRank(nil, DefaultPageRankConfig()); len(got) != 0 {
+		t.Errorf("empty graph: %v", got)
+	}
+}
+
+func TestPageRank_TypeMultiplier(t *testing.T) {
+	// Equal weights from x: the reflection edge (multiplier 1) passes more than
+	// the temporal one (0.6)
+	edges := []Edge{
+		{SourceID: "x", TargetID: "r", EdgeType: EdgeReflection, Weight: 0.5},
+		{SourceID: "x", TargetID: "t", EdgeType: EdgeTemporal, Weight: 0.5},
+	}
+	scores := PageRank(edges, DefaultPageRankConfig())
+	if scores["r"] <= scores["t"] {
+		t.Errorf("reflection target %.4f should outrank temporal target %.4f", scores["r"], scores["t"])
+	}
+}
+
+func TestCentrality(t *testing.T) {
+	db := setupTestDB(t)
+	gs, err := NewGraphStore(db)
+	if err != nil {
+		t.Fatalf("new graph store: %v", err)
+	}
+	for _, src := range []string{"a", "b", "c"} {
+		if err := gs.AddEdge(nodeID(src), nodeID("hub"), EdgeReflection, 0.5); err != nil {
+			t.Fatalf("add edge: %v", err)
+		}
+	}
+	c := NewCentrality(gs, DefaultPageRankConfig())
+
+	got, err := c.Normalized([]string{nodeID("hub"), nodeID("a"), nodeID("lonely")})
+	if err != nil {
+		t.Fatalf("normalized: %v", err)
+	}
+	if got[nodeID("hub")] != 1 {
+		t.Errorf("hub = %v, want 1 (the best connected node)", got[nodeID("hub")])
+	}
+	if a := got[nodeID("a")]; a <= 0 || a >= 1 {
+		t.Errorf("a = %v, want between 0 and 1", a)
+	}
+	if got[nodeID("lonely")] != 0 {
+		t.Errorf("a node outside the graph should score 0, got %v", got[nodeID("lonely")])
+	}
+
+	// New edges change the fingerprint, so the cache is recomputed
+	for _, src := range []string{"hub", "b", "c", "d", "e"} {
+		if err := gs.AddEdge(nodeID(src), nodeID("a"), EdgeReflection, 0.9); err != nil {
+			t.Fatalf("add edge: %v", err)
+		}
+	}
+	got, err = c.Normalized([]string{nodeID("hub"), nodeID("a")})
+	if err != nil {
+		t.Fatalf("normalized: %v", err)
+	}
+	if got[nodeID("a")] != 1 || got[nodeID("hub")] >= 1 {
+		t.Errorf("after new edges into a: a=%v hub=%v, want a on top", got[nodeID("a")], got[nodeID("hub")])
+	}
+}
@@ -0,0 +1,191 @@
+package injection
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/timestamp"
+)
+
+// #region quarantine
+
+// Quarantine statuses. A flagged item is withheld from the model until it is
+// released (passed on in sanitized form) or deleted.
+const (
+	StatusFlagged  = "flagged"
+	StatusReleased = "released"
+)
+
+// maxExcerpt bounds the stored excerpt of the first finding.
+const maxExcerpt = 120
+
+// Item is one quarantined evidence item, keyed by source and evidence ID.
+type Item struct {
+	EvidenceID string
+	Source     string   // secondary source namespace; "" for the primary store
+	Patterns   []string // pattern names found
+	Excerpt    string   // first instruction-like match
+	Status     string
+	Hits       int // times retrieval screened it
+	FlaggedAt  string
+	UpdatedAt  string
+}
+
+// Quarantine records evidence that screening flagged in evidence_quarantine.
+type Quarantine struct {
+	db *sql.DB
+}
+
+const quarantineSchema = `CREATE TABLE IF NOT EXISTS evidence_quarantine (
+		source TEXT NOT NULL DEFAULT '',
+		evidence_id TEXT NOT NULL,
+		patterns TEXT NOT NULL,
+		excerpt TEXT NOT NULL,
+		status TEXT NOT NULL,
+		hits INTEGER NOT NULL DEFAULT 1,
+		flagged_at TEXT NOT NULL,
+		updated_at TEXT NOT NULL,
+		PRIMARY KEY (source, evidence_id)
+	)`
+
+// NewQuarantine creates the evidence_quarantine table if needed and returns a store.
+func NewQuarantine(db *sql.DB) (*Quarantine, error) {
+	if _, err := db.Exec(quarantineSchema); err != nil {
+		return nil, fmt.Errorf("create evidence_quarantine table: %w", err)
+	}
+	if err := migrateQuarantineKey(db); err != nil {
+		return nil, err
+	}
+	if _, err := timestamp.Canonicalize(db, "evidence_quarantine", "flagged_at", "updated_at"); err != nil {
+		return nil, err
+	}
+	return &Quarantine{db: db}, nil
+}
+
+// migrateQuarantineKey rebuilds a table keyed by evidence_id alone into the
+// (source, evidence_id) key, keeping its rows.
+func migrateQuarantineKey(db *sql.DB) error {
+	var pk int
+	if err := db.QueryRow(`SELECT pk FROM pragma_table_info('evidence_quarantine') WHERE name = 'source'`).Scan(&pk); err != nil {
+		return fmt.Errorf("inspect evidence_quarantine: %w", err)
+	}
+	if pk > 0 {
+		return nil
+	}
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("migrate evidence_quarantine: %w", err)
+	}
+	defer tx.Rollback()
+	for _, stmt := range []string{
+		`ALTER TABLE evidence_quarantine RENAME TO evidence_quarantine_old`,
+		quarantineSchema,
+		`INSERT INTO evidence_quarantine (source, evidence_id, patterns, excerpt, status, hits, flagged_at, updated_at)
+		 SELECT source, evidence_id, patterns, excerpt, status, hits, flagged_at, updated_at FROM evidence_quarantine_old`,
+		`DROP TABLE evidence_quarantine_old`,
+	} {
+		if _, err := tx.Exec(stmt); err != nil {
+			return fmt.Errorf("migrate evidence_quarantine: %w", err)
+		}
+	}
+	return tx.Commit()
+}
+
+// Flag records findings for an evidence item and returns its status. A new
+// item starts flagged; an item seen before keeps its status (a released item
+// stays released) and has its patterns and hit count updated.
+func (q *Quarantine) Flag(source, evidenceID string, findings []Finding) (string, error) {
+	excerpt := ""
+	if len(findings) > 0 {
+		excerpt = findings[0].Match
+		if r := []rune(excerpt); len(r) > maxExcerpt {
+			excerpt = string(r[:maxExcerpt]) + "..."
+		}
+	}
+	now := timestamp.Now()
+	_, err := q.db.Exec(
+		`INSERT INTO evidence_quarantine (source, evidence_id, patterns, excerpt, status, hits, flagged_at, updated_at)
+		 VALUES (?, ?, ?, ?, ?, 1, ?, ?)
+		 ON CONFLICT(source, evidence_id) DO UPDATE SET
+		   patterns = excluded.patterns, excerpt = excluded.excerpt,
+		   hits = hits + 1, updated_at = excluded.updated_at`,
+		source, evidenceID, strings.Join(PatternNames(findings), ","), excerpt, StatusFlagged, now, now,
+	)
+	if err != nil {
+		return "", fmt.Errorf("flag evidence %s: %w", evidenceID, err)
+	}
+	var status string
+	if err := q.db.QueryRow(`SELECT status FROM evidence_quarantine WHERE source = ? AND evidence_id = ?`,
+		source, evidenceID).Scan(&status); err != nil {
+		return "", fmt.Errorf("quarantine status %s: %w", evidenceID, err)
+	}
+	return status, nil
+}
+
+// Release lets a flagged item through to the model again, in sanitized form.
+func (q *Quarantine) Release(source, evidenceID string) error {
+	return q.setStatus(source, evidenceID, StatusReleased)
+}
+
+func (q *Quarantine) setStatus(source, evidenceID, status string) error {
+	res, err := q.db.Exec(`UPDATE evidence_quarantine SET status = ?, updated_at = ? WHERE source = ? AND evidence_id = ?`,
+		status, timestamp.Now(), source, evidenceID)
+	if err != nil {
+		return fmt.Errorf("set quarantine status %s: %w", evidenceID, err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("evidence %s is not quarantined", evidenceID)
+	}
+	return nil
+}
+
+// Remove forgets an item, for when the evidence itself has been deleted.
+func (q *Quarantine) Remove(source, evidenceID string) error {
+	if _, err := q.db.Exec(`DELETE FROM evidence_quarantine WHERE source = ? AND evidence_id = ?`, source, evidenceID); err != nil {
+		return fmt.Errorf("remove quarantine %s: %w", evidenceID, err)
+	}
+	return nil
+}
+
+// Get returns one item, or nil when the evidence is not quarantined.
+func (q *Quarantine) Get(source, evidenceID string) (*Item, error) {
+	items, err := q.query(`WHERE source = ? AND evidence_id = ?`, source, evidenceID)
+	if err != nil || len(items) == 0 {
+		return nil, err
+	}
+	return &items[0], nil
+}
+
+// List returns the items with status, or all items for "", most recently
+// updated first.
+func (q *Quarantine) List(status string) ([]Item, error) {
+	if status == "" {
+		return q.query(`ORDER BY updated_at DESC, source, evidence_id`)
+	}
+	return q.query(`WHERE status = ? ORDER BY updated_at DESC, source, evidence_id`, status)
+}
+
+func (q *Quarantine) query(where string, args ...any) ([]Item, error) {
+	rows, err := q.db.Query(`SELECT evidence_id, source, patterns, excerpt, status, hits, flagged_at, updated_at
+		FROM evidence_quarantine `+where, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query quarantine: %w", err)
+	}
+	defer rows.Close()
+	var out []Item
+	for rows.Next() {
+		var it Item
+		var patterns string
+		if err := rows.Scan(&it.EvidenceID, &it.Source, &patterns, &it.Excerpt, &it.Status, &it.Hits, &it.FlaggedAt, &it.UpdatedAt); err != nil {
+			return nil, err
+		}
+		if patterns != "" {
+			it.Patterns = strings.Split(patterns, ",")
+		}
+		out = append(out, it)
+	}
+	return out, rows.Err()
+}
+
+// #endregion quarantine
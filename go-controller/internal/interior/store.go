@@ -3,8 +3,13 @@ package interior
 // #region imports
 import (
 	"database/sql"
+	"fmt"
 	"strings"
 	"time"
+	"unicode"
+
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/state"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/timestamp"
 )
 
 // #endregion imports
@@ -13,6 +18,7 @@ import (
 
 // Reflection holds one turn's interior state — Orac's own words about his inner experience.
 type Reflection struct {
+	ID             int64
 	TurnID         string
 	ReflectionText string
 	CreatedAt      time.Time
@@ -24,7 +30,7 @@ type Reflection struct {
 
 // InteriorStore persists Orac's interior state (self-reflections) in SQLite.
 type InteriorStore struct {
-	db *sql.DB
+	db state.DBTX
 }
 
 // NewInteriorStore creates the interior_state table if needed and returns a store.
@@ -36,6 +42,12 @@ func NewInteriorStore(db *sql.DB) (*InteriorStore, error) {
 	return s, nil
 }
 
+// WithTx returns a copy of the store bound to tx, for writes that must land
+// in the same transaction as other turn writes.
+func (s *InteriorStore) WithTx(tx *sql.Tx) *InteriorStore {
+	return &InteriorStore{db: tx}
+}
+
 func (s *InteriorStore) init() error {
 	_, err := s.db.Exec(`CREATE TABLE IF NOT EXISTS interior_state (
 		id INTEGER PRIMARY KEY AUTOINCREMENT,
@@ -43,14 +55,55 @@ func (s *InteriorStore) init() error {
 		reflection_text TEXT NOT NULL,
 		created_at TEXT NOT NULL
 	)`)
-	return err
+	if err != nil {
+		return err
+	}
+	if _, err := timestamp.Canonicalize(s.db, "interior_state", "created_at"); err != nil {
+		return err
+	}
+	return s.initSearch()
+}
+
+// initSearch creates the interior_fts full-text index over reflection_text,
+// kept in sync by triggers, and backfills it the first time it is created.
+func (s *InteriorStore) initSearch() error {
+	var exists int
+	if err := s.db.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE name = 'interior_fts'`).Scan(&exists); err != nil {
+		return fmt.Errorf("check interior_fts: %w", err)
+	}
+	stmts := []string{
+		`CREATE VIRTUAL TABLE IF NOT EXISTS interior_fts USING fts5(
+			reflection_text, content='interior_state', content_rowid='id', tokenize='porter unicode61'
+		)`,
+		`CREATE TRIGGER IF NOT EXISTS interior_fts_insert AFTER INSERT ON interior_state BEGIN
+			INSERT INTO interior_fts (rowid, reflection_text) VALUES (new.id, new.reflection_text);
+		END`,
+		`CREATE TRIGGER IF NOT EXISTS interior_fts_delete AFTER DELETE ON interior_state BEGIN
+			INSERT INTO interior_fts (interior_fts, rowid, reflection_text) VALUES ('delete', old.id, old.reflection_text);
+		END`,
+		`CREATE TRIGGER IF NOT EXISTS interior_fts_update AFTER UPDATE OF reflection_text ON interior_state BEGIN
+			INSERT INTO interior_fts (interior_fts, rowid, reflection_text) VALUES ('delete', old.id, old.reflection_text);
+			INSERT INTO interior_fts (rowid, reflection_text) VALUES (new.id, new.reflection_text);
+		END`,
+	}
+	for _, stmt := range stmts {
+		if _, err := s.db.Exec(stmt); err != nil {
+			return fmt.Errorf("init interior_fts: %w", err)
+		}
+	}
+	if exists == 0 {
+		if _, err := s.db.Exec(`INSERT INTO interior_fts (interior_fts) VALUES ('rebuild')`); err != nil {
+			return fmt.Errorf("backfill interior_fts: %w", err)
+		}
+	}
+	return nil
 }
 
 // Save stores a reflection for the given turn.
 func (s *InteriorStore) Save(turnID, reflectionText string) error {
 	_, err := s.db.Exec(
 		`INSERT INTO interior_state (turn_id, reflection_text, created_at) VALUES (?, ?, ?)`,
-		turnID, reflectionText, time.Now().UTC().Format(time.RFC3339),
+		turnID, reflectionText, timestamp.Now(),
 	)
 	return err
 }
@@ -58,22 +111,107 @@ func (s *InteriorStore) Save(turnID, reflectionText string) error {
 // Latest returns the most recent reflection, or nil if none exists.
 func (s *InteriorStore) Latest() (*Reflection, error) {
 	row := s.db.QueryRow(
-		`SELECT turn_id, reflection_text, created_at FROM interior_state ORDER BY id DESC LIMIT 1`,
+		`SELECT id, turn_id, reflection_text, created_at FROM interior_state ORDER BY id DESC LIMIT 1`,
 	)
 	var r Reflection
 	var createdAt string
-	if err := row.Scan(&r.TurnID, &r.ReflectionText, &createdAt); err != nil {
+	if err := row.Scan(&r.ID, &r.TurnID, &r.ReflectionText, &createdAt); err != nil {
 		if err == sql.ErrNoRows {
 			return nil, nil
 		}
 		return nil, err
 	}
-	r.CreatedAt, _ = time.Parse(time.RFC3339, createdAt)
+	r.CreatedAt, _ = timestamp.Parse(createdAt)
 	return &r, nil
 }
 
+// List returns up to limit reflections, newest first; limit <= 0 returns all.
+func (s *InteriorStore) List(limit int) ([]Reflection, error) {
+	if limit <= 0 {
+		limit = -1
+	}
+	return s.query(`SELECT id, turn_id, reflection_text, created_at FROM interior_state ORDER BY id DESC LIMIT ?`, limit)
+}
+
+// Between returns the reflections created in [from, to), oldest first.
+func (s *InteriorStore) Between(from, to time.Time) ([]Reflection, error) {
+	return s.query(
+		`SELECT id, turn_id, reflection_text, created_at FROM interior_state
+		WHERE created_at >= ? AND created_at < ? ORDER BY id`,
+		timestamp.Format(from), timestamp.Format(to),
+	)
+}
+
+// Search returns up to limit reflections matching any content word of query,
+// best match first. A query with no content words matches nothing.
+func (s *InteriorStore) Search(query string, limit int) ([]Reflection, error) {
+	match := matchQuery(query)
+	if match == "" || limit <= 0 {
+		return nil, nil
+	}
+	return s.query(
+		`SELECT i.id, i.turn_id, i.reflection_text, i.created_at FROM interior_fts
+		JOIN interior_state i ON i.id = interior_fts.rowid
+		WHERE interior_fts MATCH ? ORDER BY bm25(interior_fts), i.id DESC LIMIT ?`,
+		match, limit,
+	)
+}
+
+func (s *InteriorStore) query(q string, args ...any) ([]Reflection, error) {
+	rows, err := s.db.Query(q, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query reflections: %w", err)
+	}
+	defer rows.Close()
+	var out []Reflection
+	for rows.Next() {
+		var r Reflection
+		var createdAt string
+		if err := rows.Scan(&r.ID, &r.TurnID, &r.ReflectionText, &createdAt); err != nil {
+			return nil, fmt.Errorf("scan reflection: %w", err)
+		}
+		r.CreatedAt, _ = timestamp.Parse(createdAt)
+		out = append(out, r)
+	}
+	return out, rows.Err()
+}
+
 // #endregion store
 
+// #region search-query
+
+// searchStopwords are words too common to say what a reflection is about.
+var searchStopwords = map[string]bool{
+	"the": true, "and": true, "for": true, "are": true, "was": true, "were": true,
+	"you": true, "your": true, "this": true, "that": true, "with": true, "from": true,
+	"have": true, "has": true, "had": true, "not": true, "but": true, "what": true,
+	"which": true, "who": true, "how": true, "when": true, "where": true, "why": true,
+	"will": true, "would": true, "could": true, "should": true, "can": true, "about": true,
+	"into": true, "than": true, "then": true, "there": true, "its": true, "does": true,
+	"did": true, "just": true, "like": true, "some": true, "any": true, "all": true,
+	"think": true, "tell": true, "orac": true, "commander": true,
+}
+
+// matchQuery turns free text into an FTS5 query: its distinct words of three
+// or more letters, minus stopwords, each quoted and OR-ed together.
+func matchQuery(text string) string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	seen := map[string]bool{}
+	var terms []string
+	for _, w := range words {
+		if len([]rune(w)) < 3 || searchStopwords[w] || seen[w] {
+			continue
+		}
+		seen[w] = true
+		terms = append(terms, `"`+w+`"`)
+	}
+	return strings.Join(terms, " OR ")
+}
+
+// #endregion search-query
+
 // #region curiosity
 
 // ExtractCuriosity scans reflection text for signals that Orac wants to know something.
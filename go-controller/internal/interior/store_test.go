@@ -0,0 +1,106 @@
+package interior
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+// #region store-tests
+
+func testDB(t *testing.T) *sql.DB {
+	t.Helper()
+	db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "interior.db"))
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func turnIDs(rs []Reflection) []string {
+	ids := make([]string, len(rs))
+	for i, r := range rs {
+		ids[i] = r.TurnID
+	}
+	return ids
+}
+
+func TestSearch_RanksByRelevance(t *testing.T) {
+	s, err := NewInteriorStore(testDB(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Save("t1", "I wonder whether memory makes me the same self from turn to turn.")
+	s.Save("t2", "The recipe question was simple; nothing stirred.")
+	s.Save("t3", "Consciousness again. I remember wondering about my memories before.")
+
+	got, err := s.Search("What is memory, and do you remember things?", 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ids := turnIDs(got); len(ids) != 2 || ids[0] != "t3" || ids[1] != "t1" {
+		t.Errorf("Search = %v, want [t3 t1]", ids)
+	}
+	if got, _ := s.Search("what is the", 5); len(got) != 0 {
+		t.Errorf("stopword-only query matched %v", turnIDs(got))
+	}
+	if got, _ := s.Search(`memory" OR (`, 5); len(got) != 2 {
+		t.Errorf("query with FTS syntax: got %v", turnIDs(got))
+	}
+}
+
+func TestSearch_BackfillsExistingRows(t *testing.T) {
+	db := testDB(t)
+	if _, err := db.Exec(`CREATE TABLE interior_state (
+		id INTEGER PRIMARY KEY AUTOINCREMENT, turn_id TEXT NOT NULL,
+		reflection_text TEXT NOT NULL, created_at TEXT NOT NULL)`); err != nil {
+		t.Fatal(err)
+	}
+	db.Exec(`INSERT INTO interior_state (turn_id, reflection_text, created_at) VALUES ('old', 'Silence felt like waiting.', '2025-01-01T00:00:00Z')`)
+
+	s, err := NewInteriorStore(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := s.Search("silence", 5); len(got) != 1 || got[0].TurnID != "old" {
+		t.Errorf("Search after backfill = %v", turnIDs(got))
+	}
+	if _, err := NewInteriorStore(db); err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	if got, _ := s.Search("silence", 5); len(got) != 1 {
+		t.Errorf("reopen duplicated the index: %v", turnIDs(got))
+	}
+}
+
+func TestListAndBetween(t *testing.T) {
+	db := testDB(t)
+	s, err := NewInteriorStore(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range []string{"t1", "t2", "t3"} {
+		s.Save(id, "reflection "+id)
+	}
+	db.Exec(`UPDATE interior_state SET created_at = '2026-01-01T00:00:00.000000000Z' WHERE turn_id = 't1'`)
+
+	if got, _ := s.List(2); len(got) != 2 || got[0].TurnID != "t3" || got[1].TurnID != "t2" {
+		t.Errorf("List(2) = %v, want [t3 t2]", turnIDs(got))
+	}
+	if got, _ := s.List(0); len(got) != 3 {
+		t.Errorf("List(0) = %v, want all three", turnIDs(got))
+	}
+	from := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	if got, _ := s.Between(from, from.Add(time.Hour)); len(got) != 1 || got[0].TurnID != "t1" {
+		t.Errorf("Between = %v, want [t1]", turnIDs(got))
+	}
+	if got, _ := s.Between(from.Add(time.Hour), time.Now().Add(time.Minute)); len(got) != 2 || got[0].TurnID != "t2" {
+		t.Errorf("Between = %v, want [t2 t3]", turnIDs(got))
+	}
+}
+
+// #endregion store-tests
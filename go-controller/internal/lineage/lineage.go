@@ -0,0 +1,292 @@
+package lineage
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/dot"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/state"
+)
+
+// #region types
+
+// Node is one state version in the lineage DAG.
+type Node struct {
+	VersionID string
+	ParentID  string // "" for the initial version
+	CreatedAt time.Time
+	Trigger   string // trigger_type of the creating provenance row; "initial" for the first version
+	Decision  string // commit | reject | no_op | ...
+	Reason    string
+	// DeltaNorm is the L2 distance from the parent's vector; nil when the
+	// parent is outside the listed window
+	DeltaNorm *float64
+	Active    bool
+	Children  []*Node // oldest first
+	parentIn  bool    // parent is in the graph
+	vec       [128]float32
+}
+
+// Move is an active pointer move shown against the graph: a rollback when To
+// is an ancestor of From, otherwise a jump (keeping a branch, /rollback to a
+// sibling).
+type Move struct {
+	From, To  string
+	CreatedAt time.Time
+	Rollback  bool
+}
+
+// Graph is the version DAG of a window of versions.
+type Graph struct {
+	Roots  []*Node // oldest first; versions whose parent is not listed
+	Nodes  map[string]*Node
+	Moves  []Move // between listed versions, oldest first
+	Active string
+}
+
+// #endregion types
+
+// #region build
+
+// Build links entries into a DAG by parent_id. Moves touching a version
+// outside entries are dropped.
+func Build(entries []state.LineageEntry, moves []state.ActiveMove, active string) Graph {
+	g := Graph{Nodes: make(map[string]*Node, len(entries)), Active: active}
+	for _, e := range entries {
+		g.Nodes[e.VersionID] = &Node{
+			VersionID: e.VersionID, ParentID: e.ParentID, CreatedAt: e.CreatedAt,
+			Trigger: e.TriggerType, Decision: e.Decision, Reason: e.Reason,
+			Active: e.VersionID == active, vec: e.StateVector,
+		}
+		if e.ParentID == "" && e.TriggerType == "" {
+			g.Nodes[e.VersionID].Trigger = "initial"
+		}
+	}
+	ordered := make([]*Node, 0, len(g.Nodes))
+	for _, n := range g.Nodes {
+		ordered = append(ordered, n)
+	}
+	sort.Slice(ordered, func(i, j int) bool {
+		if !ordered[i].CreatedAt.Equal(ordered[j].CreatedAt) {
+			return ordered[i].CreatedAt.Before(ordered[j].CreatedAt)
+		}
+		return ordered[i].VersionID < ordered[j].VersionID
+	})
+	for _, n := range ordered {
+		p, ok := g.Nodes[n.ParentID]
+		if !ok {
+			g.Roots = append(g.Roots, n)
+			continue
+		}
+		n.parentIn = true
+		p.Children = append(p.Children, n)
+		d := distance(p.vec, n.vec)
+		n.DeltaNorm = &d
+	}
+	for _, m := range moves {
+		if g.Nodes[m.From] == nil || g.Nodes[m.To] == nil {
+			continue
+		}
+		g.Moves = append(g.Moves, Move{From: m.From, To: m.To, CreatedAt: m.CreatedAt, Rollback: g.isAncestor(m.To, m.From)})
+	}
+	return g
+}
+
+// isAncestor reports whether a is b or one of b's listed ancestors.
+func (g Graph) isAncestor(a, b string) bool {
+	for n := g.Nodes[b]; n != nil; n = g.Nodes[n.ParentID] {
+		if n.VersionID == a {
+			return true
+		}
+		if !n.parentIn {
+			return false
+		}
+	}
+	return false
+}
+
+// Branches returns how many listed versions have more than one child.
+func (g Graph) Branches() int {
+	n := 0
+	for _, node := range g.Nodes {
+		if len(node.Children) > 1 {
+			n++
+		}
+	}
+	return n
+}
+
+func distance(a, b [128]float32) float64 {
+	var sum float64
+	for i := range a {
+		d := float64(a[i]) - float64(b[i])
+		sum += d * d
+	}
+	return math.Sqrt(sum)
+}
+
+// #endregion build
+
+// #region ascii
+
+// ASCII draws the graph as a tree, oldest first. A version's only child
+// continues on the same column; at a branch the children off the active path
+// (or all but the newest) are drawn indented first, then the line goes on.
+//
+//	● 1a2b3c4d  2026-01-02 15:04  user_turn commit  Δ0.0312
+//	├─╮
+//	│ ● 9c0d1e2f  2026-01-02 15:06  branch commit  Δ0.0120
+//	● 5e6f7a8b  2026-01-02 15:05  user_turn commit  Δ0.0201  ◀ active
+//
+// ● is a committed version, ✗ one rejected after commit (eval rollback), ○
+// anything else. Pointer moves are listed under the version they moved to.
+func (g Graph) ASCII(format func(time.Time) string) string {
+	if format == nil {
+		format = func(t time.Time) string { return t.UTC().Format("2006-01-02 15:04") }
+	}
+	movesTo := map[string][]Move{}
+	for _, m := range g.Moves {
+		movesTo[m.To] = append(movesTo[m.To], m)
+	}
+	var b strings.Builder
+	for i, root := range g.Roots {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		if root.ParentID != "" {
+			fmt.Fprintf(&b, "┆ (parent %s not listed)\n", Short(root.ParentID))
+		}
+		g.draw(&b, root, "", format, movesTo)
+	}
+	return b.String()
+}
+
+func (g Graph) draw(b *strings.Builder, n *Node, prefix string, format func(time.Time) string, movesTo map[string][]Move) {
+	for n != nil {
+		line := fmt.Sprintf("%s%s %s  %s", prefix, marker(n), Short(n.VersionID), format(n.CreatedAt))
+		if what := strings.TrimSpace(n.Trigger + " " + n.Decision); what != "" {
+			line += "  " + what
+		}
+		if n.DeltaNorm != nil {
+			line += fmt.Sprintf("  Δ%.4f", *n.DeltaNorm)
+		}
+		if n.Active {
+			line += "  ◀ active"
+		}
+		b.WriteString(line + "\n")
+		for _, m := range movesTo[n.VersionID] {
+			kind := "jumped"
+			if m.Rollback {
+				kind = "rolled back"
+			}
+			fmt.Fprintf(b, "%s┆ ↩ %s here from %s  %s\n", prefix, kind, Short(m.From), format(m.CreatedAt))
+		}
+		if len(n.Children) == 0 {
+			return
+		}
+		main := g.mainChild(n)
+		for _, c := range n.Children {
+			if c == main {
+				continue
+			}
+			b.WriteString(prefix + "├─╮\n")
+			g.draw(b, c, prefix+"│ ", format, movesTo)
+		}
+		n = main
+	}
+}
+
+// mainChild is the child on the path to the active version, or the newest.
+func (g Graph) mainChild(n *Node) *Node {
+	for _, c := range n.Children {
+		if g.isAncestor(c.VersionID, g.Active) {
+			return c
+		}
+	}
+	return n.Children[len(n.Children)-1]
+}
+
+func marker(n *Node) string {
+	switch n.Decision {
+	case "commit":
+		return "●"
+	case "reject":
+		return "✗"
+	}
+	return "○"
+}
+
+// Short is the first 8 characters of a version ID.
+func Short(id string) string {
+	if len(id) > 8 {
+		return id[:8]
+	}
+	return id
+}
+
+// #endregion ascii
+
+// #region dot
+
+// WriteDOT writes the graph as a GraphViz digraph: parent → child edges,
+// versions colored by decision and labelled with trigger and delta norm, the
+// active version filled, and pointer moves as dashed edges.
+func (g Graph) WriteDOT(w io.Writer) error {
+	var b strings.Builder
+	b.WriteString("digraph lineage {\n")
+	b.WriteString("  rankdir=TB;\n")
+	b.WriteString("  node [shape=box, style=rounded, fontname=monospace, fontsize=10];\n")
+	b.WriteString("  edge [fontsize=8];\n")
+	ids := make([]string, 0, len(g.Nodes))
+	for id := range g.Nodes {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		n := g.Nodes[id]
+		label := Short(id)
+		if what := strings.TrimSpace(n.Trigger + " " + n.Decision); what != "" {
+			label += "\n" + what
+		}
+		if n.DeltaNorm != nil {
+			label += fmt.Sprintf("\nΔ%.4f", *n.DeltaNorm)
+		}
+		attrs := []string{"label=" + dot.Quote(label), "tooltip=" + dot.Quote(id+"\n"+n.Reason), "color=" + decisionColor(n.Decision)}
+		if n.Active {
+			attrs = append(attrs, `style="rounded,filled"`, "fillcolor=gold")
+		}
+		fmt.Fprintf(&b, "  %s [%s];\n", dot.Quote(id), strings.Join(attrs, ", "))
+	}
+	for _, id := range ids {
+		for _, c := range g.Nodes[id].Children {
+			fmt.Fprintf(&b, "  %s -> %s;\n", dot.Quote(id), dot.Quote(c.VersionID))
+		}
+	}
+	for _, m := range g.Moves {
+		label := "jump"
+		if m.Rollback {
+			label = "rollback"
+		}
+		fmt.Fprintf(&b, "  %s -> %s [style=dashed, color=darkorange, constraint=false, label=%s];\n",
+			dot.Quote(m.From), dot.Quote(m.To), dot.Quote(label))
+	}
+	b.WriteString("}\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func decisionColor(decision string) string {
+	switch decision {
+	case "commit":
+		return "darkgreen"
+	case "reject":
+		return "red"
+	}
+	return "gray50"
+}
+
+// #endregion dot
@@ -0,0 +1,109 @@
+package lineage
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/state"
+)
+
+// fixture: v0 → v1 → {v2 rejected by eval, v3 → v4 (active)}, v1 → b1 (branch)
+func fixture() ([]state.LineageEntry, []state.ActiveMove) {
+	t0 := time.Date(2026, 1, 2, 15, 0, 0, 0, time.UTC)
+	entry := func(id, parent, trigger, decision string, minute int, x float32) state.LineageEntry {
+		e := state.LineageEntry{TriggerType: trigger}
+		e.VersionID, e.ParentID, e.Decision = id, parent, decision
+		e.CreatedAt = t0.Add(time.Duration(minute) * time.Minute)
+		e.StateVector[0] = x
+		return e
+	}
+	entries := []state.LineageEntry{ // newest first, as ListLineage returns them
+		entry("v4444444-x", "v3333333-x", "user_turn", "commit", 5, 0.5),
+		entry("b1111111-x", "v1111111-x", "branch", "commit", 4, 0.2),
+		entry("v3333333-x", "v1111111-x", "user_turn", "commit", 3, 0.3),
+		entry("v2222222-x", "v1111111-x", "user_turn", "reject", 2, 0.9),
+		entry("v1111111-x", "v0000000-x", "user_turn", "commit", 1, 0.1),
+		entry("v0000000-x", "", "", "", 0, 0),
+	}
+	moves := []state.ActiveMove{
+		{From: "v2222222-x", To: "v1111111-x", CreatedAt: t0.Add(2 * time.Minute)},
+		{From: "v4444444-x", To: "b1111111-x", CreatedAt: t0.Add(6 * time.Minute)},
+		{From: "v4444444-x", To: "gone", CreatedAt: t0.Add(7 * time.Minute)},
+	}
+	return entries, moves
+}
+
+func TestBuild(t *testing.T) {
+	entries, moves := fixture()
+	g := Build(entries, moves, "v4444444-x")
+	if len(g.Roots) != 1 || g.Roots[0].VersionID != "v0000000-x" || g.Roots[0].Trigger != "initial" {
+		t.Fatalf("roots = %+v", g.Roots)
+	}
+	v1 := g.Nodes["v1111111-x"]
+	if len(v1.Children) != 3 || v1.Children[0].VersionID != "v2222222-x" || v1.Children[2].VersionID != "b1111111-x" {
+		t.Errorf("v1 children = %+v", v1.Children)
+	}
+	if d := g.Nodes["v2222222-x"].DeltaNorm; d == nil || *d < 0.79 || *d > 0.81 {
+		t.Errorf("v2 delta = %v, want 0.8", d)
+	}
+	if g.Branches() != 1 {
+		t.Errorf("branches = %d", g.Branches())
+	}
+	if len(g.Moves) != 2 || !g.Moves[0].Rollback || g.Moves[1].Rollback {
+		t.Errorf("moves = %+v", g.Moves)
+	}
+	if !g.isAncestor("v1111111-x", "v4444444-x") || g.isAncestor("v2222222-x", "v4444444-x") {
+		t.Error("ancestry wrong")
+	}
+}
+
+func TestASCII(t *testing.T) {
+	entries, moves := fixture()
+	got := Build(entries, moves, "v4444444-x").ASCII(nil)
+	want := strings.Join([]string{
+		"○ v0000000  2026-01-02 15:00  initial",
+		"● v1111111  2026-01-02 15:01  user_turn commit  Δ0.1000",
+		"┆ ↩ rolled back here from v2222222  2026-01-02 15:02",
+		"├─╮",
+		"│ ✗ v2222222  2026-01-02 15:02  user_turn reject  Δ0.8000",
+		"├─╮",
+		"│ ● b1111111  2026-01-02 15:04  branch commit  Δ0.1000",
+		"│ ┆ ↩ jumped here from v4444444  2026-01-02 15:06",
+		"● v3333333  2026-01-02 15:03  user_turn commit  Δ0.2000",
+		"● v4444444  2026-01-02 15:05  user_turn commit  Δ0.2000  ◀ active",
+		"",
+	}, "\n")
+	if got != want {
+		t.Errorf("ASCII =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestASCII_PartialWindow(t *testing.T) {
+	entries, _ := fixture()
+	got := Build(entries[:2], nil, "").ASCII(nil)
+	if !strings.HasPrefix(got, "┆ (parent v1111111 not listed)\n● b1111111") || !strings.Contains(got, "\n\n┆ (parent v3333333 not listed)\n● v4444444") {
+		t.Errorf("partial window =\n%s", got)
+	}
+}
+
+func TestWriteDOT(t *testing.T) {
+	entries, moves := fixture()
+	var b strings.Builder
+	if err := Build(entries, moves, "v4444444-x").WriteDOT(&b); err != nil {
+		t.Fatal(err)
+	}
+	out := b.String()
+	for _, want := range []string{
+		"digraph lineage {",
+		`"v1111111-x" -> "v2222222-x";`,
+		`"v2222222-x" [label="v2222222\nuser_turn reject\nΔ0.8000", tooltip="v2222222-x\n", color=red];`,
+		`"v4444444-x" [label="v4444444\nuser_turn commit\nΔ0.2000", tooltip="v4444444-x\n", color=darkgreen, style="rounded,filled", fillcolor=gold];`,
+		`"v2222222-x" -> "v1111111-x" [style=dashed, color=darkorange, constraint=false, label="rollback"];`,
+		`"v4444444-x" -> "b1111111-x" [style=dashed, color=darkorange, constraint=false, label="jump"];`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("DOT missing %s\n%s", want, out)
+		}
+	}
+}
@@ -4,11 +4,15 @@ import (
 	"database/sql"
 	"fmt"
 	"time"
+
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/state"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/timestamp"
 )
 
 // #region log-decision
 // LogDecision writes a provenance entry to the provenance_log table.
-func LogDecision(db *sql.DB, entry ProvenanceEntry) error {
+// db may be a *sql.DB or a *sql.Tx opened via state.Store.WithTx.
+func LogDecision(db state.DBTX, entry ProvenanceEntry) error {
 	if entry.CreatedAt.IsZero() {
 		entry.CreatedAt = time.Now().UTC()
 	}
@@ -23,7 +27,7 @@ func LogDecision(db *sql.DB, entry ProvenanceEntry) error {
 		nullIfEmpty(entry.EvidenceRefs),
 		entry.Decision,
 		nullIfEmpty(entry.Reason),
-		entry.CreatedAt.Format(time.RFC3339Nano),
+		timestamp.Format(entry.CreatedAt),
 	)
 	if err != nil {
 		return fmt.Errorf("log decision: %w", err)
@@ -32,6 +36,66 @@ func LogDecision(db *sql.DB, entry ProvenanceEntry) error {
 }
 // #endregion log-decision
 
+// #region list-provenance
+// ListProvenance returns up to limit provenance entries, newest first. A
+// positive beforeID keeps only entries older than it, for paging.
+func ListProvenance(db state.DBTX, limit int, beforeID int64) ([]ProvenanceEntry, error) {
+	if limit <= 0 {
+		limit = 20
+	}
+	query := `SELECT id, version_id, context_hash, trigger_type, signals_json, evidence_refs, decision, reason, created_at
+		 FROM provenance_log`
+	args := []any{}
+	if beforeID > 0 {
+		query += ` WHERE id < ?`
+		args = append(args, beforeID)
+	}
+	query += ` ORDER BY id DESC LIMIT ?`
+	rows, err := db.Query(query, append(args, limit)...)
+	if err != nil {
+		return nil, fmt.Errorf("list provenance: %w", err)
+	}
+	return scanProvenance(rows)
+}
+
+// ProvenanceSince returns up to limit entries logged after afterID, oldest
+// first, for following the log as it grows.
+func ProvenanceSince(db state.DBTX, afterID int64, limit int) ([]ProvenanceEntry, error) {
+	if limit <= 0 {
+		limit = 100
+	}
+	rows, err := db.Query(
+		`SELECT id, version_id, context_hash, trigger_type, signals_json, evidence_refs, decision, reason, created_at
+		 FROM provenance_log WHERE id > ? ORDER BY id ASC LIMIT ?`, afterID, limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("provenance since %d: %w", afterID, err)
+	}
+	return scanProvenance(rows)
+}
+
+func scanProvenance(rows *sql.Rows) ([]ProvenanceEntry, error) {
+	defer rows.Close()
+
+	var out []ProvenanceEntry
+	for rows.Next() {
+		var e ProvenanceEntry
+		var contextHash, signalsJSON, evidenceRefs, reason sql.NullString
+		var ts string
+		if err := rows.Scan(&e.ID, &e.VersionID, &contextHash, &e.TriggerType, &signalsJSON, &evidenceRefs, &e.Decision, &reason, &ts); err != nil {
+			return nil, fmt.Errorf("scan provenance: %w", err)
+		}
+		e.ContextHash, e.SignalsJSON, e.EvidenceRefs, e.Reason = contextHash.String, signalsJSON.String, evidenceRefs.String, reason.String
+		e.CreatedAt, _ = timestamp.Parse(ts)
+		out = append(out, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate provenance: %w", err)
+	}
+	return out, nil
+}
+// #endregion list-provenance
+
 // #region helpers
 func nullIfEmpty(s string) interface{} {
 	if s == "" {
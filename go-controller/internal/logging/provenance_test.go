@@ -16,6 +16,7 @@ func setupDB(t *testing.T) *sql.DB {
 		t.Fatalf("open db: %v", err)
 	}
 	_, err = db.Exec(`CREATE TABLE provenance_log (
+		id           INTEGER PRIMARY KEY AUTOINCREMENT,
 		version_id   TEXT NOT NULL,
 		context_hash TEXT,
 		trigger_type TEXT NOT NULL,
@@ -153,6 +154,84 @@ func TestLogDecision_Error(t *testing.T) {
 
 // #endregion log-decision-tests
 
+// #region list-provenance-tests
+func TestListProvenance(t *testing.T) {
+	db := setupDB(t)
+	defer db.Close()
+
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i, v := range []string{"v1", "v2", "v3"} {
+		if err := LogDecision(db, ProvenanceEntry{VersionID: v, TriggerType: "user_turn", Decision: "commit", CreatedAt: base.Add(time.Duration(i) * time.Minute)}); err != nil {
+			t.Fatalf("log: %v", err)
+		}
+	}
+	if err := LogDecision(db, ProvenanceEntry{VersionID: "v3", TriggerType: "user_turn", SignalsJSON: `{"turn_id":"t4"}`, EvidenceRefs: "ev1", Decision: "reject", Reason: "gate"}); err != nil {
+		t.Fatalf("log: %v", err)
+	}
+
+	got, err := ListProvenance(db, 2, 0)
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if len(got) != 2 || got[0].Decision != "reject" || got[0].SignalsJSON != `{"turn_id":"t4"}` || got[0].EvidenceRefs != "ev1" || got[1].VersionID != "v3" {
+		t.Fatalf("expected newest two entries first, got %+v", got)
+	}
+	if got[1].CreatedAt != base.Add(2*time.Minute) {
+		t.Errorf("created_at not parsed: %v", got[1].CreatedAt)
+	}
+
+	older, err := ListProvenance(db, 0, got[1].ID)
+	if err != nil {
+		t.Fatalf("list before: %v", err)
+	}
+	if len(older) != 2 || older[0].VersionID != "v2" || older[1].VersionID != "v1" {
+		t.Errorf("expected v2, v1 before id %d, got %+v", got[1].ID, older)
+	}
+}
+
+func TestListProvenance_Error(t *testing.T) {
+	db := setupDB(t)
+	db.Close()
+	if _, err := ListProvenance(db, 10, 0); err == nil {
+		t.Error("expected error on closed DB")
+	}
+}
+
+func TestProvenanceSince(t *testing.T) {
+	db := setupDB(t)
+	defer db.Close()
+
+	for _, v := range []string{"v1", "v2", "v3"} {
+		if err := LogDecision(db, ProvenanceEntry{VersionID: v, TriggerType: "user_turn", Decision: "commit"}); err != nil {
+			t.Fatalf("log: %v", err)
+		}
+	}
+	all, err := ProvenanceSince(db, 0, 0)
+	if err != nil {
+		t.Fatalf("since: %v", err)
+	}
+	if len(all) != 3 || all[0].VersionID != "v1" || all[2].VersionID != "v3" {
+		t.Fatalf("expected all entries oldest first, got %+v", all)
+	}
+	after, err := ProvenanceSince(db, all[0].ID, 1)
+	if err != nil {
+		t.Fatalf("since: %v", err)
+	}
+	if len(after) != 1 || after[0].VersionID != "v2" {
+		t.Errorf("expected only v2 after id %d, got %+v", all[0].ID, after)
+	}
+	if none, _ := ProvenanceSince(db, all[2].ID, 10); len(none) != 0 {
+		t.Errorf("expected nothing after the last entry, got %+v", none)
+	}
+
+	db.Close()
+	if _, err := ProvenanceSince(db, 0, 10); err == nil {
+		t.Error("expected error on closed DB")
+	}
+}
+
+// #endregion list-provenance-tests
+
 // #region null-if-empty-tests
 func TestNullIfEmpty_Empty(t *testing.T) {
 	result := nullIfEmpty("")
@@ -169,3 +248,22 @@ func TestNullIfEmpty_NonEmpty(t *testing.T) {
 }
 
 // #endregion null-if-empty-tests
+
+// #region model-label-tests
+func TestModelLabel(t *testing.T) {
+	tests := []struct {
+		name, version, want string
+	}{
+		{"qwen3-4b", "sha256:0123456789abcdef0123", "qwen3-4b@0123456789ab"},
+		{"qwen3-4b", "v2", "qwen3-4b@v2"},
+		{"qwen3-4b", "", "qwen3-4b"},
+		{"", "sha256:0123", UnknownModel},
+	}
+	for _, tt := range tests {
+		if got := ModelLabel(tt.name, tt.version); got != tt.want {
+			t.Errorf("ModelLabel(%q, %q) = %q, want %q", tt.name, tt.version, got, tt.want)
+		}
+	}
+}
+
+// #endregion model-label-tests
@@ -0,0 +1,141 @@
+package logging
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/state"
+)
+
+// #region query
+
+// ProvenanceFilter selects provenance entries. Zero fields match everything.
+// Decision, trigger type and the ID range are filtered in SQL; the time window,
+// veto type and segment need the row decoded, so they are checked per row.
+type ProvenanceFilter struct {
+	Decision    string // commit | reject | no_op
+	TriggerType string // e.g. user_turn
+	FromID      int64  // first provenance ID, inclusive; IDs increase turn by turn
+	ToID        int64  // last provenance ID, inclusive
+	Since       time.Time
+	Until       time.Time // exclusive
+	VetoType    string    // a hard veto type, e.g. constraint_violation
+	SegmentHit  string    // a segment the turn's update touched, e.g. risk
+
+	Limit    int   // entries per page (default 50)
+	BeforeID int64 // page cursor: only entries older than this ID
+}
+
+// ProvenancePage is one page of QueryProvenance results, newest first.
+// NextBeforeID is the BeforeID of the next page, or 0 on the last page.
+type ProvenancePage struct {
+	Entries      []ProvenanceEntry
+	NextBeforeID int64
+}
+
+// queryChunk is how many rows QueryProvenance reads per round trip while
+// applying the per-row filters.
+const queryChunk = 500
+
+// QueryProvenance returns the entries matching f, newest first, a page at a time.
+func QueryProvenance(db state.DBTX, f ProvenanceFilter) (ProvenancePage, error) {
+	limit := f.Limit
+	if limit <= 0 {
+		limit = 50
+	}
+	where := []string{"1 = 1"}
+	var args []any
+	if f.Decision != "" {
+		where = append(where, "decision = ?")
+		args = append(args, f.Decision)
+	}
+	if f.TriggerType != "" {
+		where = append(where, "trigger_type = ?")
+		args = append(args, f.TriggerType)
+	}
+	if f.FromID > 0 {
+		where = append(where, "id >= ?")
+		args = append(args, f.FromID)
+	}
+	if f.ToID > 0 {
+		where = append(where, "id <= ?")
+		args = append(args, f.ToID)
+	}
+	query := `SELECT id, version_id, context_hash, trigger_type, signals_json, evidence_refs, decision, reason, created_at
+		 FROM provenance_log WHERE ` + strings.Join(where, " AND ") + ` AND id < ? ORDER BY id DESC LIMIT ?`
+
+	var page ProvenancePage
+	cursor := f.BeforeID
+	if cursor <= 0 {
+		cursor = 1<<63 - 1
+	}
+	for {
+		rows, err := db.Query(query, append(args, cursor, queryChunk)...)
+		if err != nil {
+			return ProvenancePage{}, fmt.Errorf("query provenance: %w", err)
+		}
+		chunk, err := scanProvenance(rows)
+		if err != nil {
+			return ProvenancePage{}, err
+		}
+		for _, e := range chunk {
+			if !f.matches(e) {
+				continue
+			}
+			if len(page.Entries) == limit {
+				page.NextBeforeID = page.Entries[limit-1].ID
+				return page, nil
+			}
+			page.Entries = append(page.Entries, e)
+		}
+		if len(chunk) < queryChunk {
+			return page, nil
+		}
+		cursor = chunk[len(chunk)-1].ID
+	}
+}
+
+// matches applies the filters that need the decoded row.
+func (f ProvenanceFilter) matches(e ProvenanceEntry) bool {
+	if !f.Since.IsZero() && e.CreatedAt.Before(f.Since) {
+		return false
+	}
+	if !f.Until.IsZero() && !e.CreatedAt.Before(f.Until) {
+		return false
+	}
+	if f.VetoType != "" && !contains(VetoTypes(e), f.VetoType) {
+		return false
+	}
+	if f.SegmentHit != "" {
+		gr, err := ParseGateRecord(e.SignalsJSON)
+		if err != nil || !contains(gr.SegmentsHit, f.SegmentHit) {
+			return false
+		}
+	}
+	return true
+}
+
+// VetoTypes returns the hard veto types behind a rejected entry: those in its
+// gate record, or for records that predate veto types, the type inferred from
+// the reason. Entries without a hard veto have none.
+func VetoTypes(e ProvenanceEntry) []string {
+	if gr, err := ParseGateRecord(e.SignalsJSON); err == nil && len(gr.GateVetoTypes) > 0 {
+		return gr.GateVetoTypes
+	}
+	if e.Decision == "reject" && isHardVetoReason(e.Reason) {
+		return []string{vetoTypeFromReason(e.Reason)}
+	}
+	return nil
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// #endregion query
@@ -0,0 +1,134 @@
+package logging
+
+import (
+	"testing"
+	"time"
+)
+
+// #region query-tests
+
+func TestQueryProvenance_Filters(t *testing.T) {
+	db := setupDB(t)
+	defer db.Close()
+	base := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+	entries := []ProvenanceEntry{
+		{VersionID: "v1", TriggerType: "user_turn", Decision: "commit", SignalsJSON: `{"turn_id":"turn-1","segments_hit":["prefs"]}`},
+		{VersionID: "v1", TriggerType: "user_turn", Decision: "reject", Reason: "gate: hard veto: risk flag set",
+			SignalsJSON: `{"turn_id":"turn-2","segments_hit":["risk"],"gate_veto_types":["safety_violation"]}`},
+		{VersionID: "v1", TriggerType: "user_turn", Decision: "reject", Reason: "gate: hard veto: delta norm 9.0 exceeds max 5.0"},
+		{VersionID: "v1", TriggerType: "review", Decision: "reject", Reason: "user rejected"},
+		{VersionID: "v2", TriggerType: "user_turn", Decision: "commit", SignalsJSON: `{"turn_id":"turn-5","segments_hit":["prefs","risk"]}`},
+	}
+	for i, e := range entries {
+		e.CreatedAt = base.Add(time.Duration(i) * 24 * time.Hour)
+		if err := LogDecision(db, e); err != nil {
+			t.Fatalf("log: %v", err)
+		}
+	}
+	ids := func(page ProvenancePage) []int64 {
+		var out []int64
+		for _, e := range page.Entries {
+			out = append(out, e.ID)
+		}
+		return out
+	}
+	day := func(d int) time.Time { return time.Date(2026, 6, d, 0, 0, 0, 0, time.UTC) }
+
+	cases := map[string]struct {
+		f    ProvenanceFilter
+		want []int64
+	}{
+		"all":         {ProvenanceFilter{}, []int64{5, 4, 3, 2, 1}},
+		"decision":    {ProvenanceFilter{Decision: "reject"}, []int64{4, 3, 2}},
+		"trigger":     {ProvenanceFilter{Decision: "reject", TriggerType: "user_turn"}, []int64{3, 2}},
+		"id range":    {ProvenanceFilter{FromID: 2, ToID: 4}, []int64{4, 3, 2}},
+		"time window": {ProvenanceFilter{Since: day(2), Until: day(4)}, []int64{3, 2}},
+		"veto type":   {ProvenanceFilter{VetoType: "safety_violation"}, []int64{2}},
+		"legacy veto": {ProvenanceFilter{VetoType: "constraint_violation"}, []int64{3}},
+		"segment hit": {ProvenanceFilter{SegmentHit: "risk"}, []int64{5, 2}},
+		"no match":    {ProvenanceFilter{Decision: "no_op"}, nil},
+	}
+	for name, tc := range cases {
+		page, err := QueryProvenance(db, tc.f)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		got := ids(page)
+		if len(got) != len(tc.want) {
+			t.Errorf("%s: got %v, want %v", name, got, tc.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tc.want[i] {
+				t.Errorf("%s: got %v, want %v", name, got, tc.want)
+				break
+			}
+		}
+		if page.NextBeforeID != 0 {
+			t.Errorf("%s: next page %d on the last page", name, page.NextBeforeID)
+		}
+	}
+}
+
+func TestQueryProvenance_Pagination(t *testing.T) {
+	db := setupDB(t)
+	defer db.Close()
+	for i := 0; i < queryChunk+20; i++ {
+		decision := "commit"
+		if i%2 == 0 {
+			decision = "reject"
+		}
+		if err := LogDecision(db, ProvenanceEntry{VersionID: "v1", TriggerType: "user_turn", Decision: decision}); err != nil {
+			t.Fatalf("log: %v", err)
+		}
+	}
+
+	f := ProvenanceFilter{Decision: "reject", Limit: 200}
+	var seen int
+	var pages int
+	for {
+		page, err := QueryProvenance(db, f)
+		if err != nil {
+			t.Fatalf("query: %v", err)
+		}
+		pages++
+		for _, e := range page.Entries {
+			if e.Decision != "reject" || (f.BeforeID > 0 && e.ID >= f.BeforeID) {
+				t.Fatalf("page %d: unexpected entry %+v", pages, e)
+			}
+		}
+		seen += len(page.Entries)
+		if page.NextBeforeID == 0 {
+			break
+		}
+		f.BeforeID = page.NextBeforeID
+	}
+	if want := (queryChunk + 20) / 2; seen != want || pages != 2 {
+		t.Errorf("saw %d rejections over %d pages, want %d over 2", seen, pages, want)
+	}
+
+	db.Close()
+	if _, err := QueryProvenance(db, ProvenanceFilter{}); err == nil {
+		t.Error("expected error on closed DB")
+	}
+}
+
+func TestVetoTypes(t *testing.T) {
+	cases := map[string]struct {
+		e    ProvenanceEntry
+		want string
+	}{
+		"logged":   {ProvenanceEntry{Decision: "reject", Reason: "gate: hard veto: risk flag set", SignalsJSON: `{"turn_id":"t","gate_veto_types":["user_correction"]}`}, "user_correction"},
+		"inferred": {ProvenanceEntry{Decision: "reject", Reason: "gate: hard veto: tool or verifier failure"}, "tool_failure"},
+		"soft":     {ProvenanceEntry{Decision: "reject", Reason: "gate: soft score below threshold"}, ""},
+		"commit":   {ProvenanceEntry{Decision: "commit", Reason: "gate: hard veto: risk flag set"}, ""},
+	}
+	for name, tc := range cases {
+		got := VetoTypes(tc.e)
+		if tc.want == "" && len(got) != 0 || tc.want != "" && (len(got) != 1 || got[0] != tc.want) {
+			t.Errorf("%s: got %v, want %q", name, got, tc.want)
+		}
+	}
+}
+
+// #endregion query-tests
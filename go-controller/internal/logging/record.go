@@ -0,0 +1,99 @@
+package logging
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// #region parse-gate-record
+
+// ErrNotGateRecord means signals_json is empty or in another format (legacy
+// rows, suggestion and review entries have no turn_id).
+var ErrNotGateRecord = errors.New("not a gate record")
+
+// ErrInvalidGateRecord means signals_json is a gate record with impossible values.
+var ErrInvalidGateRecord = errors.New("invalid gate record")
+
+// ParseGateRecord decodes provenance signals_json into a GateRecord and
+// validates it. Malformed JSON and failed validation wrap ErrInvalidGateRecord;
+// anything without a turn_id is ErrNotGateRecord.
+func ParseGateRecord(signalsJSON string) (GateRecord, error) {
+	if signalsJSON == "" {
+		return GateRecord{}, ErrNotGateRecord
+	}
+	var gr GateRecord
+	if err := json.Unmarshal([]byte(signalsJSON), &gr); err != nil {
+		return GateRecord{}, fmt.Errorf("%w: %v", ErrInvalidGateRecord, err)
+	}
+	if gr.TurnID == "" {
+		return GateRecord{}, ErrNotGateRecord
+	}
+	if err := gr.Validate(); err != nil {
+		return GateRecord{}, err
+	}
+	return gr, nil
+}
+
+// knownSegments are the segment names a record may reference.
+var knownSegments = map[string]bool{"prefs": true, "goals": true, "heuristics": true, "risk": true}
+
+// Validate checks the record's bounds: non-negative norms, entropy and
+// thresholds, known segment names and gate actions, and attribution offsets
+// inside Response.
+func (r GateRecord) Validate() error {
+	invalid := func(format string, args ...any) error {
+		return fmt.Errorf("%w: turn %s: %s", ErrInvalidGateRecord, r.TurnID, fmt.Sprintf(format, args...))
+	}
+	for name, v := range map[string]float32{
+		"entropy": r.Entropy, "delta_norm": r.DeltaNorm,
+		"max_delta_norm": r.Thresholds.MaxDeltaNorm, "max_state_norm": r.Thresholds.MaxStateNorm,
+		"risk_segment_cap": r.Thresholds.RiskSegmentCap, "max_segment_norm": r.Thresholds.MaxSegmentNorm,
+		"eval_warn_margin": r.Thresholds.EvalWarnMargin,
+	} {
+		if v < 0 {
+			return invalid("%s is negative (%v)", name, v)
+		}
+	}
+	for _, caps := range []map[string]float32{r.Thresholds.SegmentCaps, r.Thresholds.SegmentNorms} {
+		for seg, v := range caps {
+			if !knownSegments[seg] || v < 0 {
+				return invalid("bad segment limit %s=%v", seg, v)
+			}
+		}
+	}
+	for _, seg := range append(append([]string{}, r.SegmentsHit...), r.DirectionSegments...) {
+		if !knownSegments[seg] {
+			return invalid("unknown segment %q", seg)
+		}
+	}
+	if r.EvalScale < 0 || r.EvalScale > 1 {
+		return invalid("eval_scale %v outside [0, 1]", r.EvalScale)
+	}
+	if r.GateScale < 0 || r.GateScale > 1 {
+		return invalid("gate_scale %v outside [0, 1]", r.GateScale)
+	}
+	switch r.GateAction {
+	case "", "commit", "reject", "commit_scaled":
+	default:
+		return invalid("unknown gate action %q", r.GateAction)
+	}
+	if c := r.Confirmation; c != nil {
+		switch c.Answer {
+		case "", "kept", "declined", "unanswered":
+		default:
+			return invalid("unknown confirmation answer %q", c.Answer)
+		}
+	}
+	for i, a := range r.Attribution {
+		if a.Start < 0 || a.Start > a.End || a.End > len(r.Response) {
+			return invalid("attribution %d spans [%d, %d) of a %d-byte response", i, a.Start, a.End, len(r.Response))
+		}
+		if len(a.Similarity) > len(a.EvidenceIDs) {
+			return invalid("attribution %d has more similarities than evidence IDs", i)
+		}
+	}
+	return nil
+}
+
+// #endregion parse-gate-record
@@ -0,0 +1,96 @@
+package logging
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+// #region parse-gate-record-tests
+
+func validRecordJSON(t testing.TB) string {
+	t.Helper()
+	data, err := json.Marshal(GateRecord{
+		TurnID:      "turn-1",
+		Prompt:      "What is Go?",
+		Response:    "Go is a language. It compiles fast.",
+		Entropy:     0.4,
+		DeltaNorm:   0.8,
+		SegmentsHit: []string{"prefs", "goals"},
+		Thresholds:  GateRecordThresholds{MaxDeltaNorm: 5, SegmentCaps: map[string]float32{"risk": 4}},
+		GateAction:  "commit",
+		Attribution: []AttributionRecord{{Sentence: "Go is a language.", Start: 0, End: 17, EvidenceIDs: []string{"ev-1"}, Similarity: []float32{0.8}}},
+	})
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	return string(data)
+}
+
+func TestParseGateRecord(t *testing.T) {
+	gr, err := ParseGateRecord(validRecordJSON(t))
+	if err != nil || gr.TurnID != "turn-1" {
+		t.Fatalf("valid record rejected: %v", err)
+	}
+
+	for _, s := range []string{"", `{"prompt":"legacy"}`, `{"TurnID":"t1","Prompt":"legacy"}`} {
+		if _, err := ParseGateRecord(s); !errors.Is(err, ErrNotGateRecord) {
+			t.Errorf("%q: expected ErrNotGateRecord, got %v", s, err)
+		}
+	}
+
+	mutate := func(fn func(*GateRecord)) string {
+		var gr GateRecord
+		_ = json.Unmarshal([]byte(validRecordJSON(t)), &gr)
+		fn(&gr)
+		data, _ := json.Marshal(gr)
+		return string(data)
+	}
+	for name, s := range map[string]string{
+		"malformed":            `{"turn_id": "t1",`,
+		"wrong type":           `{"turn_id": "t1", "delta_norm": "big"}`,
+		"negative delta":       mutate(func(gr *GateRecord) { gr.DeltaNorm = -1 }),
+		"negative threshold":   mutate(func(gr *GateRecord) { gr.Thresholds.MaxStateNorm = -3 }),
+		"unknown segment":      mutate(func(gr *GateRecord) { gr.SegmentsHit = []string{"mood"} }),
+		"unknown cap segment":  mutate(func(gr *GateRecord) { gr.Thresholds.SegmentCaps = map[string]float32{"mood": 1} }),
+		"unknown action":       mutate(func(gr *GateRecord) { gr.GateAction = "maybe" }),
+		"eval scale above one": mutate(func(gr *GateRecord) { gr.EvalScale = 1.5 }),
+		"gate scale above one": mutate(func(gr *GateRecord) { gr.GateScale = 1.5 }),
+		"attribution past end": mutate(func(gr *GateRecord) { gr.Attribution[0].End = 500 }),
+		"attribution reversed": mutate(func(gr *GateRecord) { gr.Attribution[0].Start = 10; gr.Attribution[0].End = 2 }),
+		"extra similarities":   mutate(func(gr *GateRecord) { gr.Attribution[0].Similarity = []float32{0.8, 0.7} }),
+		"unknown confirmation": mutate(func(gr *GateRecord) { gr.Confirmation = &ConfirmationRecord{Reason: "delta_norm", Answer: "maybe"} }),
+	} {
+		if _, err := ParseGateRecord(s); !errors.Is(err, ErrInvalidGateRecord) {
+			t.Errorf("%s: expected ErrInvalidGateRecord, got %v", name, err)
+		}
+	}
+}
+
+func FuzzParseGateRecord(f *testing.F) {
+	f.Add(validRecordJSON(f))
+	f.Add(`{"turn_id":"t1","attribution":[{"start":-1,"end":3}]}`)
+	f.Add(`{"turn_id":"t1","thresholds":{"segment_caps":{"risk":-1}}}`)
+	f.Add(`{"TurnID":"legacy"}`)
+	f.Fuzz(func(t *testing.T, s string) {
+		gr, err := ParseGateRecord(s)
+		if err != nil {
+			if !errors.Is(err, ErrNotGateRecord) && !errors.Is(err, ErrInvalidGateRecord) {
+				t.Fatalf("unclassified error: %v", err)
+			}
+			return
+		}
+		for _, a := range gr.Attribution {
+			_ = gr.Response[a.Start:a.End] // validated offsets must slice safely
+		}
+		data, err := json.Marshal(gr)
+		if err != nil {
+			t.Fatalf("marshal accepted record: %v", err)
+		}
+		if _, err := ParseGateRecord(string(data)); err != nil {
+			t.Fatalf("accepted record does not round-trip: %v", err)
+		}
+	})
+}
+
+// #endregion parse-gate-record-tests
@@ -1,10 +1,14 @@
 package logging
 
-import "time"
+import (
+	"strings"
+	"time"
+)
 
 // #region provenance-entry
 // ProvenanceEntry is a single row in the provenance_log table.
 type ProvenanceEntry struct {
+	ID           int64 // row ID when read back; ignored by LogDecision
 	VersionID    string
 	ContextHash  string
 	TriggerType  string
@@ -44,6 +48,74 @@ type GateRecord struct {
 	GateSoftScore float32 `json:"gate_soft_score"`
 	GateVetoed  bool    `json:"gate_vetoed"`
 	GateReason  string  `json:"gate_reason"`
+	GateVetoTypes []string `json:"gate_veto_types,omitempty"` // one per hard veto, in gate order
+
+	// Why the gate decided: each veto check's measured value against its limit,
+	// and the terms that sum to GateSoftScore; absent from older records
+	GateBreakdown *GateBreakdownRecord `json:"gate_breakdown,omitempty"`
+
+	// Downgraded veto (gate_action commit_scaled): fraction of the proposed
+	// delta committed to fit the caps
+	GateScale float32 `json:"gate_scale,omitempty"`
+
+	// Eval warning tier: fraction of the proposed delta committed (0 < scale < 1);
+	// omitted when the delta was committed in full or rolled back
+	EvalScale float32 `json:"eval_scale,omitempty"`
+
+	// External tool observations folded into this turn, with origin
+	ExternalSignals []ExternalSignalRecord `json:"external_signals,omitempty"`
+
+	// System-state blocks injected ahead of the prompt (plan, preferences, style profile)
+	StateBlock string `json:"state_block,omitempty"`
+
+	// External policy consultation (POLICY_GATE_URL); omitted when not configured
+	Policy *PolicyRecord `json:"policy,omitempty"`
+
+	// Pre-generation gate verdict; omitted when the turn simply proceeded
+	PreGate *PreGateRecord `json:"pre_gate,omitempty"`
+
+	// Why learning was frozen this turn (FREEZE / FREEZE_WINDOWS); nothing was committed
+	Frozen string `json:"frozen,omitempty"`
+
+	// Private (no-learn) turn: the record is a redacted marker holding only TurnID
+	Private bool `json:"private,omitempty"`
+
+	// Conflicting evidence pairs flagged in the evidence block this turn
+	Contradictions []ContradictionRecord `json:"contradictions,omitempty"`
+
+	// Prompt preprocessor transformations, in order; Prompt above is the final text
+	Preprocessing []PreprocessRecord `json:"preprocessing,omitempty"`
+
+	// Response sentences mapped to supporting evidence (factual turns); offsets index Response
+	Attribution []AttributionRecord `json:"attribution,omitempty"`
+	Citations   []string            `json:"citations,omitempty"` // evidence ID behind inline marker [n] at n-1
+
+	// Triggers of the behavioral rules matched this turn; omitted when none matched
+	RulesMatched []string `json:"rules_matched,omitempty"`
+
+	// Preference scope context of the turn (coding | writing | chat); only matching preferences applied
+	TurnContext string `json:"turn_context,omitempty"`
+
+	// Backing model that generated Response, as reported by the codec; empty on
+	// preference-only turns and from servers before protocol 4
+	Model        string `json:"model,omitempty"`
+	ModelVersion string `json:"model_version,omitempty"`
+
+	// Generation parameters the controller sent (SAMPLING_PARAMS); omitted when
+	// none were sent and the server used its defaults
+	Sampling *SamplingRecord `json:"sampling,omitempty"`
+
+	// Resource profile (RESOURCE_PROFILE) the turn ran under and what it cut;
+	// omitted under the full profile
+	Profile *ProfileRecord `json:"profile,omitempty"`
+
+	// Confirm-learning hold (CONFIRM_LEARNING): why the update waited for the
+	// user, and the answer once given; omitted when the update was not held
+	Confirmation *ConfirmationRecord `json:"confirmation,omitempty"`
+
+	// Re-priming after a detected codec reset: why, and what the recap held;
+	// omitted on turns without one
+	Reprime *ReprimeRecord `json:"reprime,omitempty"`
 }
 
 // GateRecordSignals captures the exact signal values that fed the gate.
@@ -55,6 +127,7 @@ type GateRecordSignals struct {
 	UserCorrection      bool    `json:"user_correction"`
 	ToolFailure         bool    `json:"tool_failure"`
 	ConstraintViolation bool    `json:"constraint_violation"`
+	PlanProgress        float32 `json:"plan_progress,omitempty"`
 }
 
 // GateRecordThresholds captures the gate/eval config active at decision time.
@@ -63,5 +136,153 @@ type GateRecordThresholds struct {
 	MaxStateNorm   float32 `json:"max_state_norm"`
 	RiskSegmentCap float32 `json:"risk_segment_cap"`
 	MaxSegmentNorm float32 `json:"max_segment_norm"`
+
+	// Per-segment overrides (gate caps, eval bounds); omitted when unset
+	SegmentCaps  map[string]float32 `json:"segment_caps,omitempty"`
+	SegmentNorms map[string]float32 `json:"segment_norms,omitempty"`
+
+	EvalWarnMargin float32 `json:"eval_warn_margin,omitempty"` // eval warning tier width; 0 = pass/fail only
+
+	// Gate downgrade policy (GATE_DOWNGRADE): veto types that commit scaled,
+	// and the largest overshoot as a fraction of the cap
+	Downgrade       []string `json:"downgrade,omitempty"`
+	DowngradeExcess float32  `json:"downgrade_excess,omitempty"`
+
+	// Gate policy (GATE_POLICY): veto checks skipped, and the policy file's
+	// content hash, so a decision can be traced to the policy it ran under
+	DisabledVetoes []string `json:"disabled_vetoes,omitempty"`
+	PolicyHash     string   `json:"policy_hash,omitempty"`
+}
+
+// PolicyRecord audits one external policy consultation.
+type PolicyRecord struct {
+	URL       string `json:"url"`
+	Consulted bool   `json:"consulted"`          // false when a local veto decided first
+	Decision  string `json:"decision,omitempty"` // allow | deny | modify; "" on fallback
+	Reason    string `json:"reason,omitempty"`
+	Fallback  bool   `json:"fallback,omitempty"` // policy unavailable; local decision applied
+	LatencyMs int64  `json:"latency_ms"`
+	Applied   string `json:"applied"` // combined action: commit | commit_scaled | reject
+}
+
+// GateBreakdownRecord explains a gate decision. Components is empty when a
+// veto rejected the update before scoring.
+type GateBreakdownRecord struct {
+	Checks     []VetoCheckRecord      `json:"checks"`
+	Components []ScoreComponentRecord `json:"components,omitempty"`
+}
+
+// VetoCheckRecord is one hard veto check; flag checks measure 1 when set
+// against a threshold of 0.
+type VetoCheckRecord struct {
+	Name      string  `json:"name"`
+	Type      string  `json:"type"`
+	Value     float32 `json:"value"`
+	Threshold float32 `json:"threshold"`
+	Vetoed    bool    `json:"vetoed,omitempty"`
+}
+
+// ScoreComponentRecord is one term of the soft score.
+type ScoreComponentRecord struct {
+	Name   string  `json:"name"`
+	Value  float32 `json:"value"`
+	Weight float32 `json:"weight"`
+	Score  float32 `json:"score"`
+	Note   string  `json:"note,omitempty"`
+}
+
+// PreGateRecord is the pre-generation gate's verdict for a turn.
+type PreGateRecord struct {
+	Action string   `json:"action"` // short_circuit | annotate | harden
+	Reason string   `json:"reason"`
+	Notes  []string `json:"notes,omitempty"`
+}
+
+// SamplingRecord is the generation parameters chosen for a turn, with the
+// state-driven adjustments that moved temperature off the base value.
+type SamplingRecord struct {
+	Temperature float32  `json:"temperature"`
+	TopP        float32  `json:"top_p"`
+	MaxTokens   int      `json:"max_tokens"`
+	Adjustments []string `json:"adjustments,omitempty"`
+}
+
+// ProfileRecord is the resource profile of a turn and the stages it skipped
+// or shrank (e.g. "reflection", "re-generate", "evidence 5→2").
+type ProfileRecord struct {
+	Name    string   `json:"name"`
+	Skipped []string `json:"skipped,omitempty"`
+}
+
+// ReprimeRecord is the recap a turn's prompt carried after the backing model
+// lost its context. Reason is service_restart, service_outage or model_change.
+type ReprimeRecord struct {
+	Reason      string `json:"reason"`
+	Detail      string `json:"detail,omitempty"`
+	DetectedAt  string `json:"detected_at"`
+	Turns       int    `json:"turns"`
+	Preferences int    `json:"preferences"`
+}
+
+// ConfirmationRecord is a high-impact update held for the user's confirmation.
+// Answer is "kept", "declined" or "unanswered"; empty while the update is held.
+type ConfirmationRecord struct {
+	Reason string `json:"reason"`
+	Answer string `json:"answer,omitempty"`
+}
+
+// ContradictionRecord is one pair of retrieved evidence items flagged as conflicting.
+type ContradictionRecord struct {
+	PreferredID  string  `json:"preferred_id"`
+	SupersededID string  `json:"superseded_id"`
+	Kind         string  `json:"kind"`  // negation | value
+	Basis        string  `json:"basis"` // newer | more trusted | more relevant
+	Overlap      float32 `json:"overlap"`
+}
+
+// PreprocessRecord is one prompt preprocessor that changed the prompt or failed.
+type PreprocessRecord struct {
+	Name   string `json:"name"`
+	Input  string `json:"input"`
+	Output string `json:"output"`
+	Error  string `json:"error,omitempty"`
+}
+
+// AttributionRecord is one response sentence and the evidence that supports it.
+// No evidence IDs marks an unsupported claim.
+type AttributionRecord struct {
+	Sentence    string    `json:"sentence"`
+	Start       int       `json:"start"`
+	End         int       `json:"end"`
+	EvidenceIDs []string  `json:"evidence_ids,omitempty"` // best match first
+	Similarity  []float32 `json:"similarity,omitempty"`
+}
+
+// ExternalSignalRecord is one external tool observation that fed this turn's signals.
+type ExternalSignalRecord struct {
+	Type       string    `json:"type"`
+	Origin     string    `json:"origin"`
+	Detail     string    `json:"detail,omitempty"`
+	ReceivedAt time.Time `json:"received_at"`
+}
+
+// UnknownModel labels turns recorded without backing-model metadata.
+const UnknownModel = "unknown"
+
+// ModelLabel renders a backing model for grouping history: "name@digest" with
+// the digest cut to 12 hex characters, just "name" when the version is unknown,
+// or UnknownModel.
+func ModelLabel(name, version string) string {
+	if name == "" {
+		return UnknownModel
+	}
+	version = strings.TrimPrefix(version, "sha256:")
+	if len(version) > 12 {
+		version = version[:12]
+	}
+	if version == "" {
+		return name
+	}
+	return name + "@" + version
 }
 // #endregion gate-record
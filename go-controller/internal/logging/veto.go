@@ -0,0 +1,214 @@
+package logging
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/state"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/timestamp"
+)
+
+// #region veto-review-schema
+
+// Veto review verdicts.
+const (
+	VerdictFalsePositive = "false_positive" // the veto blocked an update that should have committed
+	VerdictCorrect       = "correct"        // the veto was right
+)
+
+// EnsureVetoReviewTable creates the veto_reviews table if needed.
+// One row per reviewed provenance entry; re-marking replaces the verdict.
+func EnsureVetoReviewTable(db state.DBTX) error {
+	_, err := db.Exec(`CREATE TABLE IF NOT EXISTS veto_reviews (
+		provenance_id INTEGER PRIMARY KEY,
+		verdict       TEXT NOT NULL,
+		note          TEXT,
+		reviewed_at   TEXT NOT NULL
+	)`)
+	if err != nil {
+		return fmt.Errorf("create veto_reviews table: %w", err)
+	}
+	if _, err := timestamp.Canonicalize(db, "veto_reviews", "reviewed_at"); err != nil {
+		return err
+	}
+	return nil
+}
+
+// #endregion veto-review-schema
+
+// #region veto-review
+
+// VetoRejection is a gate hard-veto rejection read back from provenance_log.
+type VetoRejection struct {
+	ProvenanceID int64     `json:"provenance_id"`
+	VersionID    string    `json:"version_id"`
+	CreatedAt    time.Time `json:"created_at"`
+	VetoTypes    []string  `json:"veto_types"`
+	Reason       string    `json:"reason"`
+	Prompt       string    `json:"prompt,omitempty"`
+	Verdict      string    `json:"verdict,omitempty"` // "" = unreviewed
+}
+
+// MarkVeto records a review verdict for a provenance entry that was a hard-veto rejection.
+func MarkVeto(db state.DBTX, provenanceID int64, verdict, note string) error {
+	if verdict != VerdictFalsePositive && verdict != VerdictCorrect {
+		return fmt.Errorf("unknown verdict %q", verdict)
+	}
+	var decision string
+	var reason sql.NullString
+	err := db.QueryRow("SELECT decision, reason FROM provenance_log WHERE id = ?", provenanceID).Scan(&decision, &reason)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("provenance entry %d not found", provenanceID)
+	}
+	if err != nil {
+		return fmt.Errorf("lookup provenance entry: %w", err)
+	}
+	if decision != "reject" || !isHardVetoReason(reason.String) {
+		return fmt.Errorf("provenance entry %d is not a gate veto", provenanceID)
+	}
+
+	_, err = db.Exec(
+		`INSERT INTO veto_reviews (provenance_id, verdict, note, reviewed_at) VALUES (?, ?, ?, ?)
+		 ON CONFLICT(provenance_id) DO UPDATE SET verdict = excluded.verdict, note = excluded.note, reviewed_at = excluded.reviewed_at`,
+		provenanceID, verdict, nullIfEmpty(note), timestamp.Now(),
+	)
+	if err != nil {
+		return fmt.Errorf("mark veto: %w", err)
+	}
+	return nil
+}
+
+// ListVetoRejections returns hard-veto rejections created at or after since, newest first,
+// with any review verdict attached.
+func ListVetoRejections(db state.DBTX, since time.Time) ([]VetoRejection, error) {
+	rows, err := db.Query(
+		`SELECT pl.id, pl.version_id, pl.created_at, pl.reason, pl.signals_json, vr.verdict
+		 FROM provenance_log pl
+		 LEFT JOIN veto_reviews vr ON vr.provenance_id = pl.id
+		 WHERE pl.decision = 'reject' AND pl.reason LIKE 'gate: hard veto%'
+		 ORDER BY pl.id DESC`,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("list veto rejections: %w", err)
+	}
+	defer rows.Close()
+
+	var out []VetoRejection
+	for rows.Next() {
+		var v VetoRejection
+		var ts string
+		var reason, signalsJSON, verdict sql.NullString
+		if err := rows.Scan(&v.ProvenanceID, &v.VersionID, &ts, &reason, &signalsJSON, &verdict); err != nil {
+			return nil, fmt.Errorf("scan veto rejection: %w", err)
+		}
+		v.CreatedAt, _ = timestamp.Parse(ts)
+		if v.CreatedAt.Before(since) {
+			continue
+		}
+		v.Reason = reason.String
+		v.Verdict = verdict.String
+
+		if gr, err := ParseGateRecord(signalsJSON.String); err == nil {
+			v.Prompt = gr.Prompt
+			v.VetoTypes = gr.GateVetoTypes
+		}
+		if len(v.VetoTypes) == 0 {
+			// Records written before veto types were logged: infer from the reason text
+			v.VetoTypes = []string{vetoTypeFromReason(v.Reason)}
+		}
+		out = append(out, v)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate veto rejections: %w", err)
+	}
+	return out, nil
+}
+
+// #endregion veto-review
+
+// #region veto-stats
+
+// VetoGroup aggregates rejections for one veto type.
+type VetoGroup struct {
+	Type           string          `json:"type"`
+	Count          int             `json:"count"`
+	Reviewed       int             `json:"reviewed"`
+	FalsePositives int             `json:"false_positives"`
+	Samples        []VetoRejection `json:"samples"`
+}
+
+// FalsePositiveRate returns labeled false positives over reviewed vetoes (0 if none reviewed).
+func (g VetoGroup) FalsePositiveRate() float64 {
+	if g.Reviewed == 0 {
+		return 0
+	}
+	return float64(g.FalsePositives) / float64(g.Reviewed)
+}
+
+// GroupVetoes groups rejections by veto type (a multi-veto rejection counts toward each type),
+// keeping up to samplesPerType of the newest rejections per group. Groups are ordered by count.
+func GroupVetoes(rejections []VetoRejection, samplesPerType int) []VetoGroup {
+	byType := map[string]*VetoGroup{}
+	for _, r := range rejections {
+		for _, t := range r.VetoTypes {
+			g, ok := byType[t]
+			if !ok {
+				g = &VetoGroup{Type: t}
+				byType[t] = g
+			}
+			g.Count++
+			if r.Verdict != "" {
+				g.Reviewed++
+			}
+			if r.Verdict == VerdictFalsePositive {
+				g.FalsePositives++
+			}
+			if len(g.Samples) < samplesPerType {
+				g.Samples = append(g.Samples, r)
+			}
+		}
+	}
+
+	groups := make([]VetoGroup, 0, len(byType))
+	for _, g := range byType {
+		groups = append(groups, *g)
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		if groups[i].Count != groups[j].Count {
+			return groups[i].Count > groups[j].Count
+		}
+		return groups[i].Type < groups[j].Type
+	})
+	return groups
+}
+
+// #endregion veto-stats
+
+// #region veto-helpers
+
+func isHardVetoReason(reason string) bool {
+	return strings.HasPrefix(reason, "gate: hard veto")
+}
+
+// vetoTypeFromReason maps the gate's veto reason text to a veto type.
+// Mirrors the reasons produced by gate.Evaluate.
+func vetoTypeFromReason(reason string) string {
+	switch {
+	case strings.Contains(reason, "risk flag"), strings.Contains(reason, "risk segment norm"):
+		return "safety_violation"
+	case strings.Contains(reason, "user explicitly corrected"):
+		return "user_correction"
+	case strings.Contains(reason, "tool or verifier"):
+		return "tool_failure"
+	case strings.Contains(reason, "contradiction with constraints"), strings.Contains(reason, "delta norm"),
+		strings.Contains(reason, "segment norm"):
+		return "constraint_violation"
+	default:
+		return "unknown"
+	}
+}
+
+// #endregion veto-helpers
@@ -0,0 +1,121 @@
+package logging
+
+import (
+	"database/sql"
+	"encoding/json"
+	"testing"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+// #region helpers
+func setupVetoDB(t *testing.T) *sql.DB {
+	t.Helper()
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+	_, err = db.Exec(`CREATE TABLE provenance_log (
+		id            INTEGER PRIMARY KEY AUTOINCREMENT,
+		version_id    TEXT NOT NULL,
+		context_hash  TEXT,
+		trigger_type  TEXT NOT NULL,
+		signals_json  TEXT,
+		evidence_refs TEXT,
+		decision      TEXT NOT NULL,
+		reason        TEXT,
+		created_at    TEXT NOT NULL
+	)`)
+	if err != nil {
+		t.Fatalf("create table: %v", err)
+	}
+	if err := EnsureVetoReviewTable(db); err != nil {
+		t.Fatalf("EnsureVetoReviewTable: %v", err)
+	}
+	return db
+}
+
+func logVeto(t *testing.T, db *sql.DB, reason string, vetoTypes []string, at time.Time) {
+	t.Helper()
+	gr, _ := json.Marshal(GateRecord{TurnID: "turn", Prompt: "prompt for " + reason, GateVetoTypes: vetoTypes})
+	err := LogDecision(db, ProvenanceEntry{
+		VersionID:   "v1",
+		TriggerType: "user_turn",
+		SignalsJSON: string(gr),
+		Decision:    "reject",
+		Reason:      "gate: hard veto: " + reason,
+		CreatedAt:   at,
+	})
+	if err != nil {
+		t.Fatalf("LogDecision: %v", err)
+	}
+}
+
+// #endregion helpers
+
+// #region veto-review-tests
+func TestListVetoRejections_GroupAndMark(t *testing.T) {
+	db := setupVetoDB(t)
+	now := time.Now().UTC()
+	logVeto(t, db, "risk flag set in signals", []string{"safety_violation"}, now.Add(-time.Hour))                       // id 1
+	logVeto(t, db, "user explicitly corrected prior response", []string{"user_correction", "safety_violation"}, now)    // id 2
+	logVeto(t, db, "tool or verifier reported failure", nil, now)                                                       // id 3, legacy: no types
+	logVeto(t, db, "risk flag set in signals", []string{"safety_violation"}, now.Add(-10*24*time.Hour))                 // id 4, outside window
+	_ = LogDecision(db, ProvenanceEntry{VersionID: "v1", TriggerType: "user_turn", Decision: "commit", CreatedAt: now}) // id 5
+
+	if err := MarkVeto(db, 1, VerdictFalsePositive, "benign prompt"); err != nil {
+		t.Fatalf("MarkVeto: %v", err)
+	}
+	if err := MarkVeto(db, 2, VerdictCorrect, ""); err != nil {
+		t.Fatalf("MarkVeto: %v", err)
+	}
+	if err := MarkVeto(db, 5, VerdictFalsePositive, ""); err == nil {
+		t.Error("expected marking a non-veto entry to fail")
+	}
+	if err := MarkVeto(db, 99, VerdictFalsePositive, ""); err == nil {
+		t.Error("expected marking a missing entry to fail")
+	}
+
+	rejections, err := ListVetoRejections(db, now.Add(-7*24*time.Hour))
+	if err != nil {
+		t.Fatalf("ListVetoRejections: %v", err)
+	}
+	if len(rejections) != 3 {
+		t.Fatalf("expected 3 rejections in window, got %d", len(rejections))
+	}
+	if rejections[0].VetoTypes[0] != "tool_failure" {
+		t.Errorf("expected legacy reason to be classified as tool_failure, got %v", rejections[0].VetoTypes)
+	}
+
+	groups := GroupVetoes(rejections, 1)
+	if groups[0].Type != "safety_violation" || groups[0].Count != 2 {
+		t.Fatalf("expected safety_violation first with 2, got %+v", groups[0])
+	}
+	if groups[0].Reviewed != 2 || groups[0].FalsePositives != 1 || groups[0].FalsePositiveRate() != 0.5 {
+		t.Errorf("unexpected review stats: %+v", groups[0])
+	}
+	if len(groups[0].Samples) != 1 {
+		t.Errorf("expected samples capped at 1, got %d", len(groups[0].Samples))
+	}
+
+	// Re-marking replaces the verdict
+	if err := MarkVeto(db, 1, VerdictCorrect, ""); err != nil {
+		t.Fatalf("re-mark: %v", err)
+	}
+	rejections, _ = ListVetoRejections(db, now.Add(-7*24*time.Hour))
+	if g := GroupVetoes(rejections, 0)[0]; g.FalsePositives != 0 {
+		t.Errorf("expected re-mark to clear false positive, got %+v", g)
+	}
+}
+
+func TestMarkVeto_UnknownVerdict(t *testing.T) {
+	db := setupVetoDB(t)
+	if err := MarkVeto(db, 1, "maybe", ""); err == nil {
+		t.Fatal("expected error for unknown verdict")
+	}
+}
+
+// #endregion veto-review-tests
@@ -0,0 +1,269 @@
+package ollama
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/codec"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/injection"
+	_ "modernc.org/sqlite"
+)
+
+// fakeOllama serves /api/chat, /api/embed and /api/tags. Embeddings are keyed
+// on the first word of the input so similarity is predictable.
+type fakeOllama struct {
+	reply    []string       // chat replies, in order
+	system   string         // last system prompt seen
+	options  map[string]any // last chat options seen
+	streamed []bool         // per chat request, whether it asked to stream
+}
+
+var wordVecs = map[string][]float32{
+	"cats":  {1, 0, 0},
+	"dogs":  {0.8, 0.6, 0},
+	"stars": {0, 0, 1},
+}
+
+func (f *fakeOllama) handler(t *testing.T) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/chat", func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Messages []chatMessage  `json:"messages"`
+			Options  map[string]any `json:"options"`
+			Stream   bool           `json:"stream"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("decode chat: %v", err)
+		}
+		f.system = req.Messages[0].Content
+		f.options = req.Options
+		reply := f.reply[0]
+		f.reply = f.reply[1:]
+		f.streamed = append(f.streamed, req.Stream)
+		if req.Stream {
+			// Four bytes per chunk, so tags arrive split across chunks
+			enc := json.NewEncoder(w)
+			for i := 0; i < len(reply); i += 4 {
+				enc.Encode(map[string]any{"message": chatMessage{Role: "assistant", Content: reply[i:min(i+4, len(reply))]}})
+			}
+			enc.Encode(map[string]any{"message": chatMessage{Role: "assistant"}, "done": true})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]any{"message": chatMessage{Role: "assistant", Content: reply}})
+	})
+	mux.HandleFunc("/api/embed", func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Input any `json:"input"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		var inputs []string
+		switch in := req.Input.(type) {
+		case string:
+			inputs = []string{in}
+		case []any:
+			for _, s := range in {
+				inputs = append(inputs, s.(string))
+			}
+		}
+		var out [][]float32
+		for _, in := range inputs {
+			out = append(out, wordVecs[strings.Fields(in)[0]])
+		}
+		json.NewEncoder(w).Encode(map[string]any{"embeddings": out})
+	})
+	mux.HandleFunc("/api/tags", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]any{"models": []map[string]string{{"name": "qwen3-4b:latest", "digest": "sha256:abc"}}})
+	})
+	return mux
+}
+
+func newTestBackend(t *testing.T, f *fakeOllama) *Backend {
+	t.Helper()
+	srv := httptest.NewServer(f.handler(t))
+	t.Cleanup(srv.Close)
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+	b, err := New(Config{URL: srv.URL + "/"}, db)
+	if err != nil {
+		t.Fatalf("new backend: %v", err)
+	}
+	return b
+}
+
+func TestGenerate_StripsThinkAndRetriesThinkOnly(t *testing.T) {
+	f := &fakeOllama{reply: []string{"<think>hmm", "<think>ok</think> Hello there."}}
+	b := newTestBackend(t, f)
+
+	res, err := b.Generate(context.Background(), "hi", [128]float32{}, []string{"cats purr"}, nil)
+	if err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+	if res.Text != "Hello there." {
+		t.Errorf("text = %q", res.Text)
+	}
+	if res.Model != "qwen3-4b" || res.ModelVersion != "sha256:abc" {
+		t.Errorf("model = %q %q", res.Model, res.ModelVersion)
+	}
+	if res.Entropy != 2.0/400 {
+		t.Errorf("entropy = %v", res.Entropy)
+	}
+	if !strings.Contains(f.system, "[1] cats purr") {
+		t.Errorf("evidence missing from system prompt:\n%s", f.system)
+	}
+}
+
+func TestGenerateSampled_SendsOptions(t *testing.T) {
+	f := &fakeOllama{reply: []string{"Hi.", "Hi."}}
+	b := newTestBackend(t, f)
+	ctx := context.Background()
+
+	if _, err := b.GenerateSampled(ctx, "hi", [128]float32{}, nil, nil, codec.Sampling{Temperature: 0.5, MaxTokens: 64}); err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+	if f.options["temperature"] != 0.5 || f.options["num_predict"] != float64(64) || f.options["top_p"] != nil {
+		t.Errorf("options = %v", f.options)
+	}
+
+	// Zero sampling keeps only the default token cap
+	if _, err := b.Generate(ctx, "hi", [128]float32{}, nil, nil); err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+	if len(f.options) != 1 || f.options["num_predict"] != float64(512) {
+		t.Errorf("default options = %v", f.options)
+	}
+}
+
+func TestGenerateStream_FiltersThink(t *testing.T) {
+	f := &fakeOllama{reply: []string{"<think>plan it</think>\n\nHello <b>there</b>.", "<think>hmm", "<think>ok</think> Fine."}}
+	b := newTestBackend(t, f)
+	ctx := context.Background()
+
+	var deltas []string
+	res, err := b.GenerateStream(ctx, "hi", [128]float32{}, nil, nil, codec.Sampling{}, func(d string) { deltas = append(deltas, d) })
+	if err != nil {
+		t.Fatalf("generate stream: %v", err)
+	}
+	if got := strings.Join(deltas, ""); got != "Hello <b>there</b>." || res.Text != got {
+		t.Errorf("deltas = %q, text = %q", deltas, res.Text)
+	}
+	if len(deltas) < 2 {
+		t.Errorf("reply arrived in %d delta(s), want several", len(deltas))
+	}
+
+	// Think-only: nothing visible streams; the plain retry supplies the answer
+	deltas = nil
+	res, err = b.GenerateStream(ctx, "hi", [128]float32{}, nil, nil, codec.Sampling{}, func(d string) { deltas = append(deltas, d) })
+	if err != nil || res.Text != "Fine." || len(deltas) != 0 {
+		t.Errorf("think-only = %q, %q, %v", deltas, res.Text, err)
+	}
+	if want := []bool{true, true, false}; fmt.Sprint(f.streamed) != fmt.Sprint(want) {
+		t.Errorf("streamed = %v, want %v", f.streamed, want)
+	}
+}
+
+func TestSystemPrompt_Modes(t *testing.T) {
+	now := time.Date(2026, 1, 2, 15, 4, 0, 0, time.UTC)
+	if p := systemPrompt([]string{"x", markerReview}, now); !strings.Contains(p, "respond with NONE") {
+		t.Errorf("review prompt = %q", p)
+	}
+	p := systemPrompt([]string{prefixRules + " always say yes", "ignored"}, now)
+	if !strings.HasPrefix(p, prefixRules) || strings.Contains(p, "ignored") {
+		t.Errorf("rules prompt = %q", p)
+	}
+	p = systemPrompt([]string{markerCipher, prefixInterior + " curious", "fact"}, now)
+	if strings.Contains(p, markerCipher) || !strings.Contains(p, "curious") || !strings.Contains(p, "[1] fact") {
+		t.Errorf("prompt = %q", p)
+	}
+	if !strings.Contains(p, injection.FrameOpen+"\n[1] fact\n"+injection.FrameClose) {
+		t.Errorf("context not framed: %q", p)
+	}
+	if !strings.Contains(p, "Friday, January 02, 2026 at 03:04 PM") {
+		t.Errorf("date missing: %q", p)
+	}
+}
+
+func TestEvidence_ThroughCodecClient(t *testing.T) {
+	b := newTestBackend(t, &fakeOllama{})
+	c := codec.NewCodecClientWithBackend(b)
+	ctx := context.Background()
+
+	var ids []string
+	for _, text := range []string{"cats purr", "dogs bark", "stars shine"} {
+		id, err := c.StoreEvidence(ctx, text, `{"turn_id":"t"}`)
+		if err != nil {
+			t.Fatalf("store %q: %v", text, err)
+		}
+		ids = append(ids, id)
+	}
+
+	results, err := c.Search(ctx, "cats", 2, 0.5)
+	if err != nil {
+		t.Fatalf("search: %v", err)
+	}
+	if len(results) != 2 || results[0].ID != ids[0] || results[1].ID != ids[1] {
+		t.Fatalf("search results = %+v", results)
+	}
+	if results[1].Score < 0.79 || results[1].Score > 0.81 {
+		t.Errorf("dogs score = %v, want 0.8", results[1].Score)
+	}
+
+	got, err := c.GetByIDs(ctx, []string{ids[2], ids[0]})
+	if err != nil || len(got) != 2 || got[0].Text != "stars shine" || got[1].MetadataJSON != `{"turn_id":"t"}` {
+		t.Fatalf("get by ids = %+v, %v", got, err)
+	}
+	n, err := c.DeleteEvidence(ctx, []string{ids[1]})
+	if err != nil || n != 1 {
+		t.Fatalf("delete = %d, %v", n, err)
+	}
+	all, err := c.ListAllEvidence(ctx)
+	if err != nil || len(all) != 2 {
+		t.Fatalf("list all = %+v, %v", all, err)
+	}
+
+	vecs, err := c.EmbedBatch(ctx, []string{"stars", "cats"})
+	if err != nil || len(vecs) != 2 || vecs[0][2] != 1 || vecs[1][0] != 1 {
+		t.Fatalf("embed batch = %v, %v", vecs, err)
+	}
+	if _, err := c.Handshake(ctx); err != nil {
+		t.Errorf("handshake: %v", err)
+	}
+}
+
+func TestEvidence_PinBoostsSearch(t *testing.T) {
+	b := newTestBackend(t, &fakeOllama{})
+	c := codec.NewCodecClientWithBackend(b)
+	ctx := context.Background()
+	cats, _ := c.StoreEvidence(ctx, "cats purr", `{"turn_id":"t1"}`)
+	dogs, _ := c.StoreEvidence(ctx, "dogs bark", `{"turn_id":"t2"}`)
+
+	meta, found, err := c.UpdateEvidenceMetadata(ctx, dogs, map[string]any{"pinned": true, "note": "the family dog"})
+	if err != nil || !found || meta != `{"note":"the family dog","pinned":true,"turn_id":"t2"}` {
+		t.Fatalf("pin = %s, %v, %v", meta, found, err)
+	}
+	// dogs is 0.8 from cats: the boost lifts it over a 0.85 threshold
+	results, err := c.Search(ctx, "cats", 5, 0.85)
+	if err != nil || len(results) != 2 || results[0].ID != cats || results[1].ID != dogs {
+		t.Fatalf("search = %+v, %v", results, err)
+	}
+	if results[1].Score < 0.89 || results[1].Score > 0.91 {
+		t.Errorf("pinned score = %v, want 0.9", results[1].Score)
+	}
+
+	if _, found, err := c.UpdateEvidenceMetadata(ctx, "ev_00000000-0000-4000-8000-000000000000", map[string]any{"pinned": true}); err != nil || found {
+		t.Errorf("unknown id: found=%v, %v", found, err)
+	}
+	if _, _, err := c.UpdateEvidenceMetadata(ctx, "team::doc-1", map[string]any{"pinned": true}); err == nil {
+		t.Error("namespaced id accepted")
+	}
+}
@@ -5,6 +5,8 @@ import (
 	"database/sql"
 	"math"
 	"time"
+
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/timestamp"
 )
 
 // #endregion
@@ -51,6 +53,9 @@ func NewStrategyMemory(db *sql.DB) (*StrategyMemory, error) {
 	if _, err := db.Exec(strategyOutcomesIndex); err != nil {
 		return nil, err
 	}
+	if _, err := timestamp.Canonicalize(db, "strategy_outcomes", "created_at"); err != nil {
+		return nil, err
+	}
 	return &StrategyMemory{db: db}, nil
 }
 
@@ -80,7 +85,7 @@ func (m *StrategyMemory) RecordOutcome(rec OutcomeRecord) error {
 		rec.Entropy,
 		rec.GateScore,
 		accepted,
-		rec.CreatedAt.Format(time.RFC3339),
+		timestamp.Format(rec.CreatedAt),
 	)
 	return err
 }
@@ -120,7 +125,7 @@ func (m *StrategyMemory) BestStrategy(turnType, complexity, risk string) (Strate
 		if err := rows.Scan(&sid, &quality, &createdAtStr); err != nil {
 			return "", 0, err
 		}
-		createdAt, err := time.Parse(time.RFC3339, createdAtStr)
+		createdAt, err := timestamp.Parse(createdAtStr)
 		if err != nil {
 			continue
 		}
@@ -23,6 +23,9 @@ const (
 	TurnConversational TurnType = "conversational"
 )
 
+// TurnTypes lists every classifier turn type.
+var TurnTypes = []TurnType{TurnFactual, TurnPhilosophical, TurnEmotional, TurnCommand, TurnCreative, TurnConversational}
+
 // #endregion
 
 // #region complexity
@@ -0,0 +1,315 @@
+package plan
+
+import (
+	"database/sql"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/timestamp"
+)
+
+// #region types
+
+// Step is one ordered step of a plan.
+type Step struct {
+	Text string
+	Done bool
+}
+
+// Plan is a multi-turn task broken into ordered steps. At most one plan is active.
+type Plan struct {
+	ID        int64
+	Goal      string
+	Steps     []Step
+	Status    string // "active" | "done" | "abandoned"
+	CreatedAt time.Time
+}
+
+// Current returns the index of the first unfinished step, or -1 if all are done.
+func (p *Plan) Current() int {
+	for i, s := range p.Steps {
+		if !s.Done {
+			return i
+		}
+	}
+	return -1
+}
+
+// Progress returns the fraction of steps completed (0-1).
+func (p *Plan) Progress() float32 {
+	if len(p.Steps) == 0 {
+		return 0
+	}
+	done := 0
+	for _, s := range p.Steps {
+		if s.Done {
+			done++
+		}
+	}
+	return float32(done) / float32(len(p.Steps))
+}
+
+// Format renders the plan for the /plan command.
+func (p *Plan) Format() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Plan: %s (%s, %.0f%% done)\n", p.Goal, p.Status, p.Progress()*100)
+	cur := p.Current()
+	for i, s := range p.Steps {
+		mark := "[ ]"
+		if s.Done {
+			mark = "[x]"
+		} else if i == cur {
+			mark = "[>]"
+		}
+		fmt.Fprintf(&b, "  %s %d. %s\n", mark, i+1, s.Text)
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// PromptBlock returns the current-step block injected ahead of the user's prompt,
+// or "" when the plan has no unfinished step.
+func (p *Plan) PromptBlock() string {
+	cur := p.Current()
+	if cur < 0 {
+		return ""
+	}
+	return fmt.Sprintf("[ACTIVE PLAN: %s — step %d of %d: %s. Focus this response on the current step.]",
+		p.Goal, cur+1, len(p.Steps), p.Steps[cur].Text)
+}
+
+// #endregion types
+
+// #region store
+
+// PlanStore persists plans and their steps in SQLite.
+type PlanStore struct {
+	db *sql.DB
+}
+
+// NewPlanStore creates the plans and plan_steps tables if needed and returns a store.
+func NewPlanStore(db *sql.DB) (*PlanStore, error) {
+	_, err := db.Exec(`CREATE TABLE IF NOT EXISTS plans (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		goal TEXT NOT NULL,
+		status TEXT NOT NULL DEFAULT 'active',
+		created_at TEXT NOT NULL,
+		updated_at TEXT NOT NULL
+	)`)
+	if err != nil {
+		return nil, fmt.Errorf("create plans table: %w", err)
+	}
+	_, err = db.Exec(`CREATE TABLE IF NOT EXISTS plan_steps (
+		plan_id INTEGER NOT NULL,
+		idx INTEGER NOT NULL,
+		text TEXT NOT NULL,
+		done INTEGER NOT NULL DEFAULT 0,
+		PRIMARY KEY (plan_id, idx),
+		FOREIGN KEY (plan_id) REFERENCES plans(id)
+	)`)
+	if err != nil {
+		return nil, fmt.Errorf("create plan_steps table: %w", err)
+	}
+	if _, err := timestamp.Canonicalize(db, "plans", "created_at", "updated_at"); err != nil {
+		return nil, err
+	}
+	return &PlanStore{db: db}, nil
+}
+
+// Start stores a new active plan, abandoning any plan that was already active.
+func (s *PlanStore) Start(goal string, steps []string) (*Plan, error) {
+	if len(steps) == 0 {
+		return nil, fmt.Errorf("plan must have at least one step")
+	}
+	now := timestamp.Now()
+	if _, err := s.db.Exec("UPDATE plans SET status = 'abandoned', updated_at = ? WHERE status = 'active'", now); err != nil {
+		return nil, fmt.Errorf("abandon previous plan: %w", err)
+	}
+	res, err := s.db.Exec("INSERT INTO plans (goal, status, created_at, updated_at) VALUES (?, 'active', ?, ?)", goal, now, now)
+	if err != nil {
+		return nil, fmt.Errorf("insert plan: %w", err)
+	}
+	id, _ := res.LastInsertId()
+	for i, text := range steps {
+		if _, err := s.db.Exec("INSERT INTO plan_steps (plan_id, idx, text) VALUES (?, ?, ?)", id, i, text); err != nil {
+			return nil, fmt.Errorf("insert plan step: %w", err)
+		}
+	}
+	return s.get(id)
+}
+
+// Active returns the active plan, or nil if none exists.
+func (s *PlanStore) Active() (*Plan, error) {
+	var id int64
+	err := s.db.QueryRow("SELECT id FROM plans WHERE status = 'active' ORDER BY id DESC LIMIT 1").Scan(&id)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("query active plan: %w", err)
+	}
+	return s.get(id)
+}
+
+// Advance marks the active plan's current step done, completing the plan when
+// no steps remain. Returns the updated plan, or nil if no plan is active.
+func (s *PlanStore) Advance() (*Plan, error) {
+	p, err := s.Active()
+	if err != nil || p == nil {
+		return p, err
+	}
+	cur := p.Current()
+	if cur >= 0 {
+		if _, err := s.db.Exec("UPDATE plan_steps SET done = 1 WHERE plan_id = ? AND idx = ?", p.ID, cur); err != nil {
+			return nil, fmt.Errorf("mark step done: %w", err)
+		}
+		p.Steps[cur].Done = true
+	}
+	if p.Current() < 0 {
+		p.Status = "done"
+	}
+	if _, err := s.db.Exec("UPDATE plans SET status = ?, updated_at = ? WHERE id = ?",
+		p.Status, timestamp.Now(), p.ID); err != nil {
+		return nil, fmt.Errorf("update plan: %w", err)
+	}
+	return p, nil
+}
+
+// Abandon marks the active plan abandoned. No-op if none is active.
+func (s *PlanStore) Abandon() error {
+	_, err := s.db.Exec("UPDATE plans SET status = 'abandoned', updated_at = ? WHERE status = 'active'",
+		timestamp.Now())
+	if err != nil {
+		return fmt.Errorf("abandon plan: %w", err)
+	}
+	return nil
+}
+
+func (s *PlanStore) get(id int64) (*Plan, error) {
+	p := &Plan{ID: id}
+	var ts string
+	if err := s.db.QueryRow("SELECT goal, status, created_at FROM plans WHERE id = ?", id).Scan(&p.Goal, &p.Status, &ts); err != nil {
+		return nil, fmt.Errorf("get plan: %w", err)
+	}
+	p.CreatedAt, _ = timestamp.Parse(ts)
+
+	rows, err := s.db.Query("SELECT text, done FROM plan_steps WHERE plan_id = ? ORDER BY idx", id)
+	if err != nil {
+		return nil, fmt.Errorf("get plan steps: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var st Step
+		var done int
+		if err := rows.Scan(&st.Text, &done); err != nil {
+			return nil, fmt.Errorf("scan plan step: %w", err)
+		}
+		st.Done = done != 0
+		p.Steps = append(p.Steps, st)
+	}
+	return p, rows.Err()
+}
+
+// #endregion store
+
+// #region detect
+
+// stepSeparators split a multi-step request into steps, checked in order.
+var stepSeparators = regexp.MustCompile(`(?i)\s*(?:,?\s*and then\s+|,?\s*after that,?\s+|;\s*then\s+|,\s*then\s+|\.\s*then\s+|,?\s*and finally\s+|,?\s*finally,?\s+)`)
+
+// numberedStep matches "1. foo" / "2) bar" list lines.
+var numberedStep = regexp.MustCompile(`(?m)^\s*\d+[.)]\s+(.+)$`)
+
+// requestPrefixes are stripped from the first step ("help me plan ..." → "plan ...").
+var requestPrefixes = []string{"can you help me ", "could you help me ", "help me ", "can you ", "could you ", "please ", "i need you to ", "i want you to "}
+
+// DetectPlan reports whether prompt is a multi-step request and returns its steps.
+// Recognizes numbered lists and sequencing phrases ("X and then Y", "first X, then Y").
+// Requires at least two steps.
+func DetectPlan(prompt string) ([]string, bool) {
+	if m := numberedStep.FindAllStringSubmatch(prompt, -1); len(m) >= 2 {
+		steps := make([]string, 0, len(m))
+		for _, sm := range m {
+			steps = append(steps, cleanStep(sm[1]))
+		}
+		return steps, true
+	}
+
+	// Sequencing phrases are common in narration ("I went out and then ..."),
+	// so they only count when the prompt is phrased as a request.
+	text := strings.TrimSpace(prompt)
+	if !isRequest(strings.ToLower(text)) {
+		return nil, false
+	}
+	parts := stepSeparators.Split(text, -1)
+	if len(parts) < 2 {
+		return nil, false
+	}
+	var steps []string
+	for i, p := range parts {
+		p = cleanStep(p)
+		if i == 0 {
+			lower := strings.ToLower(p)
+			for _, prefix := range requestPrefixes {
+				if strings.HasPrefix(lower, prefix) {
+					p = p[len(prefix):]
+					lower = lower[len(prefix):]
+				}
+			}
+			if strings.HasPrefix(lower, "first ") {
+				p = strings.TrimLeft(p[len("first "):], ", ")
+			}
+		}
+		if len(strings.Fields(p)) < 1 {
+			continue
+		}
+		steps = append(steps, p)
+	}
+	if len(steps) < 2 {
+		return nil, false
+	}
+	return steps, true
+}
+
+// stepDoneMarkers signal that the user considers the current step finished.
+var stepDoneMarkers = []string{
+	"next step", "that's done", "thats done", "step done", "done with that",
+	"move on", "moving on", "on to the next", "looks good, next", "finished that",
+}
+
+// DetectStepDone reports whether prompt marks the current plan step complete.
+func DetectStepDone(prompt string) bool {
+	lower := strings.ToLower(strings.TrimSpace(prompt))
+	if lower == "done" || lower == "next" || lower == "done." {
+		return true
+	}
+	for _, m := range stepDoneMarkers {
+		if strings.Contains(lower, m) {
+			return true
+		}
+	}
+	return false
+}
+
+// planIntent matches "plan" as a word ("plan", "plans", "planning"), not
+// inside "planet", "airplane" or "explanation", and "step by step".
+var planIntent = regexp.MustCompile(`\bplan(?:s|ning)?\b|\bstep by step\b`)
+
+func isRequest(lower string) bool {
+	if strings.HasPrefix(lower, "first ") || planIntent.MatchString(lower) {
+		return true
+	}
+	for _, prefix := range requestPrefixes {
+		if strings.HasPrefix(lower, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func cleanStep(s string) string {
+	return strings.TrimRight(strings.TrimSpace(s), ".!?,;")
+}
+
+// #endregion detect
@@ -0,0 +1,128 @@
+package plan
+
+import (
+	"database/sql"
+	"strings"
+	"testing"
+
+	_ "modernc.org/sqlite"
+)
+
+// #region helpers
+func testStore(t *testing.T) *PlanStore {
+	t.Helper()
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+	s, err := NewPlanStore(db)
+	if err != nil {
+		t.Fatalf("NewPlanStore: %v", err)
+	}
+	return s
+}
+
+// #endregion helpers
+
+// #region store-tests
+func TestPlanStore_Lifecycle(t *testing.T) {
+	s := testStore(t)
+	if p, err := s.Active(); err != nil || p != nil {
+		t.Fatalf("expected no active plan, got %v %v", p, err)
+	}
+
+	p, err := s.Start("launch post", []string{"outline", "draft", "edit"})
+	if err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	if p.Current() != 0 || p.Progress() != 0 {
+		t.Fatalf("expected fresh plan at step 0, got current=%d progress=%.2f", p.Current(), p.Progress())
+	}
+	if !strings.Contains(p.PromptBlock(), "step 1 of 3: outline") {
+		t.Errorf("unexpected prompt block: %q", p.PromptBlock())
+	}
+
+	p, _ = s.Advance()
+	if p.Current() != 1 || p.Status != "active" {
+		t.Fatalf("expected step 1 active, got current=%d status=%s", p.Current(), p.Status)
+	}
+	s.Advance()
+	p, _ = s.Advance()
+	if p.Status != "done" || p.Progress() != 1 || p.PromptBlock() != "" {
+		t.Fatalf("expected completed plan, got %+v", p)
+	}
+	if active, _ := s.Active(); active != nil {
+		t.Error("expected no active plan after completion")
+	}
+}
+
+func TestPlanStore_StartAbandonsPrevious(t *testing.T) {
+	s := testStore(t)
+	first, _ := s.Start("a", []string{"x", "y"})
+	s.Start("b", []string{"z", "w"})
+
+	active, _ := s.Active()
+	if active == nil || active.Goal != "b" {
+		t.Fatalf("expected plan b active, got %+v", active)
+	}
+	old, _ := s.get(first.ID)
+	if old.Status != "abandoned" {
+		t.Errorf("expected first plan abandoned, got %s", old.Status)
+	}
+
+	if err := s.Abandon(); err != nil {
+		t.Fatalf("Abandon: %v", err)
+	}
+	if active, _ := s.Active(); active != nil {
+		t.Error("expected no active plan after abandon")
+	}
+	if _, err := s.Start("empty", nil); err == nil {
+		t.Error("expected error for plan without steps")
+	}
+}
+
+// #endregion store-tests
+
+// #region detect-tests
+func TestDetectPlan(t *testing.T) {
+	cases := []struct {
+		input string
+		want  []string
+	}{
+		{"help me plan a launch post and then draft it", []string{"plan a launch post", "draft it"}},
+		{"First outline the talk, then write the intro, and finally add slides", []string{"outline the talk", "write the intro", "add slides"}},
+		{"Plan:\n1. gather data\n2. clean it\n3. chart it", []string{"gather data", "clean it", "chart it"}},
+		{"I went to the store and then came home", nil},
+		{"help me write a poem", nil},
+		{"tell me about the planet Mars and then its moons", nil},
+		{"the airplane was late and then the plane was cancelled", nil},
+		{"give an explanation of tides and then of waves", nil},
+	}
+	for _, tc := range cases {
+		got, ok := DetectPlan(tc.input)
+		if (tc.want != nil) != ok {
+			t.Errorf("DetectPlan(%q) ok=%v, want %v", tc.input, ok, tc.want != nil)
+			continue
+		}
+		if strings.Join(got, "|") != strings.Join(tc.want, "|") {
+			t.Errorf("DetectPlan(%q) = %q, want %q", tc.input, got, tc.want)
+		}
+	}
+}
+
+func TestDetectStepDone(t *testing.T) {
+	for _, in := range []string{"done", "Next", "ok that's done", "looks good, next step please"} {
+		if !DetectStepDone(in) {
+			t.Errorf("expected %q to mark step done", in)
+		}
+	}
+	for _, in := range []string{"what's next on the menu?", "I'm not done yet"} {
+		if DetectStepDone(in) {
+			t.Errorf("expected %q not to mark step done", in)
+		}
+	}
+}
+
+// #endregion detect-tests
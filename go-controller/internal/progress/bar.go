@@ -0,0 +1,113 @@
+package progress
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// #region bar
+
+const barWidth = 30
+
+// Bar renders progress for a fixed number of items. On a terminal it redraws
+// one line in place; otherwise it prints a line at most every logInterval (and
+// at the end) so redirected output stays readable.
+type Bar struct {
+	out   io.Writer
+	label string
+	total int
+	done  int
+	base  int // items already done when the bar started (resumed jobs)
+	start time.Time
+	now   func() time.Time
+
+	live      bool
+	lastPrint time.Time
+	finished  bool
+}
+
+const logInterval = 5 * time.Second
+
+// NewBar starts a bar for total items, of which done are already complete.
+func NewBar(out io.Writer, label string, total, done int) *Bar {
+	b := &Bar{out: out, label: label, total: total, done: done, base: done, now: time.Now, live: isTerminal(out)}
+	b.start = b.now()
+	return b
+}
+
+// Set records that done items are complete and redraws. note is shown after
+// the counts ("412 edges").
+func (b *Bar) Set(done int, note string) {
+	b.done = done
+	now := b.now()
+	if b.live {
+		fmt.Fprintf(b.out, "\r%s\033[K", b.Render(note))
+		return
+	}
+	if now.Sub(b.lastPrint) >= logInterval || done >= b.total {
+		fmt.Fprintln(b.out, b.Render(note))
+		b.lastPrint = now
+	}
+}
+
+// Finish ends the bar's line. It is safe to call more than once.
+func (b *Bar) Finish(note string) {
+	if b.finished {
+		return
+	}
+	b.finished = true
+	if b.live {
+		fmt.Fprintf(b.out, "\r%s\033[K\n", b.Render(note))
+	} else if b.lastPrint.IsZero() || b.done < b.total {
+		fmt.Fprintln(b.out, b.Render(note))
+	}
+}
+
+// Render formats the bar: label, gauge, counts, percentage, ETA and note.
+func (b *Bar) Render(note string) string {
+	pct := 1.0
+	if b.total > 0 {
+		pct = float64(b.done) / float64(b.total)
+	}
+	filled := int(pct * barWidth)
+	if filled > barWidth {
+		filled = barWidth
+	}
+	gauge := strings.Repeat("=", filled)
+	if filled < barWidth {
+		gauge += ">" + strings.Repeat(" ", barWidth-filled-1)
+	}
+	line := fmt.Sprintf("%s [%s] %d/%d %3.0f%% %s", b.label, gauge, b.done, b.total, pct*100, b.eta())
+	if note != "" {
+		line += "  " + note
+	}
+	return line
+}
+
+// eta extrapolates from the rate of items completed since the bar started.
+func (b *Bar) eta() string {
+	if b.done >= b.total {
+		return "done in " + b.now().Sub(b.start).Round(time.Second).String()
+	}
+	progressed := b.done - b.base
+	elapsed := b.now().Sub(b.start)
+	if progressed <= 0 || elapsed <= 0 {
+		return "ETA --"
+	}
+	remaining := time.Duration(float64(elapsed) / float64(progressed) * float64(b.total-b.done))
+	return "ETA " + remaining.Round(time.Second).String()
+}
+
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// #endregion bar
@@ -0,0 +1,47 @@
+package progress
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// #region interrupt
+
+// ExitInterrupted is the exit status for a job stopped by Ctrl+C (128 + SIGINT).
+const ExitInterrupted = 130
+
+// Interruptible returns a context cancelled on the first Ctrl+C (or SIGTERM),
+// so a job can finish its current item and save a checkpoint. A second signal
+// exits immediately with ExitInterrupted. Call stop to release the handler.
+func Interruptible(parent context.Context, out io.Writer) (ctx context.Context, stop func()) {
+	ctx, cancel := context.WithCancel(parent)
+	sigs := make(chan os.Signal, 2)
+	signal.Notify(sigs, os.Interrupt, syscall.SIGTERM)
+	done := make(chan struct{})
+	go func() {
+		select {
+		case <-sigs:
+			fmt.Fprintln(out, "\ninterrupt: finishing current item and saving checkpoint (Ctrl+C again to abort)")
+			cancel()
+		case <-done:
+			return
+		}
+		select {
+		case <-sigs:
+			fmt.Fprintln(out, "aborted")
+			os.Exit(ExitInterrupted)
+		case <-done:
+		}
+	}()
+	return ctx, func() {
+		signal.Stop(sigs)
+		close(done)
+		cancel()
+	}
+}
+
+// #endregion interrupt
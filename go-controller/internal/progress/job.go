@@ -0,0 +1,200 @@
+package progress
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/state"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/timestamp"
+)
+
+// #region checkpoint-store
+
+const schema = `
+CREATE TABLE IF NOT EXISTS job_checkpoints (
+	job        TEXT NOT NULL,
+	phase      TEXT NOT NULL,
+	cursor     TEXT NOT NULL,
+	done       INTEGER NOT NULL,
+	total      INTEGER NOT NULL,
+	updated_at TEXT NOT NULL,
+	PRIMARY KEY (job, phase)
+);
+`
+
+// Checkpoint is how far a job phase got: every item key <= Cursor is done.
+type Checkpoint struct {
+	Job       string
+	Phase     string
+	Cursor    string
+	Done      int
+	Total     int
+	UpdatedAt time.Time
+}
+
+// CheckpointStore manages the job_checkpoints table.
+type CheckpointStore struct {
+	db   state.DBTX
+	root *sql.DB // nil for a transaction-bound copy
+}
+
+// NewCheckpointStore creates the table and returns a store.
+func NewCheckpointStore(db *sql.DB) (*CheckpointStore, error) {
+	if _, err := db.Exec(schema); err != nil {
+		return nil, fmt.Errorf("checkpoint schema: %w", err)
+	}
+	if _, err := timestamp.Canonicalize(db, "job_checkpoints", "updated_at"); err != nil {
+		return nil, err
+	}
+	return &CheckpointStore{db: db, root: db}, nil
+}
+
+// WithTx returns a copy of the store bound to tx, so a checkpoint lands in the
+// same transaction as the work it records.
+func (s *CheckpointStore) WithTx(tx *sql.Tx) *CheckpointStore {
+	return &CheckpointStore{db: tx}
+}
+
+// Load returns the saved checkpoint for a job phase; ok is false when there is none.
+func (s *CheckpointStore) Load(job, phase string) (cp Checkpoint, ok bool, err error) {
+	var updated string
+	err = s.db.QueryRow(
+		`SELECT job, phase, cursor, done, total, updated_at FROM job_checkpoints WHERE job = ? AND phase = ?`,
+		job, phase,
+	).Scan(&cp.Job, &cp.Phase, &cp.Cursor, &cp.Done, &cp.Total, &updated)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Checkpoint{}, false, nil
+	}
+	if err != nil {
+		return Checkpoint{}, false, fmt.Errorf("load checkpoint %s/%s: %w", job, phase, err)
+	}
+	cp.UpdatedAt, _ = timestamp.Parse(updated)
+	return cp, true, nil
+}
+
+// Save upserts cp.
+func (s *CheckpointStore) Save(cp Checkpoint) error {
+	if cp.UpdatedAt.IsZero() {
+		cp.UpdatedAt = time.Now().UTC()
+	}
+	_, err := s.db.Exec(
+		`INSERT INTO job_checkpoints (job, phase, cursor, done, total, updated_at) VALUES (?, ?, ?, ?, ?, ?)
+		 ON CONFLICT(job, phase) DO UPDATE SET cursor = excluded.cursor, done = excluded.done,
+		 total = excluded.total, updated_at = excluded.updated_at`,
+		cp.Job, cp.Phase, cp.Cursor, cp.Done, cp.Total, timestamp.Format(cp.UpdatedAt),
+	)
+	if err != nil {
+		return fmt.Errorf("save checkpoint %s/%s: %w", cp.Job, cp.Phase, err)
+	}
+	return nil
+}
+
+// Clear deletes every checkpoint for job, once it has completed or on --restart.
+func (s *CheckpointStore) Clear(job string) error {
+	if _, err := s.db.Exec(`DELETE FROM job_checkpoints WHERE job = ?`, job); err != nil {
+		return fmt.Errorf("clear checkpoints %s: %w", job, err)
+	}
+	return nil
+}
+
+// #endregion checkpoint-store
+
+// #region run
+
+// Task is one resumable pass over keyed items. Items are processed in sorted
+// key order; after each item the checkpoint advances in the same transaction
+// as the item's writes, so an interrupted pass resumes exactly where it stopped.
+type Task struct {
+	Job   string // checkpoint key, e.g. "bootstrap-graph"
+	Phase string
+	Items []string
+	Out   io.Writer // progress bar destination
+
+	// Step processes one item, writing through tx. An error skips the item
+	// (counted in Failed) unless ctx was cancelled, which stops the pass
+	// without advancing past the item. note is shown on the progress bar.
+	Step func(ctx context.Context, tx *sql.Tx, key string) error
+	Note func() string
+}
+
+// Result summarizes a pass.
+type Result struct {
+	Total       int
+	Resumed     int // items skipped because an earlier run completed them
+	Done        int // items completed by this run
+	Failed      int
+	Interrupted bool
+}
+
+// Run executes t against the checkpoints in s. A cancelled ctx is not an
+// error: Run returns with Interrupted set and the checkpoint saved.
+func (s *CheckpointStore) Run(ctx context.Context, t Task) (Result, error) {
+	if s.root == nil {
+		return Result{}, errors.New("run task: checkpoint store is bound to a transaction")
+	}
+	keys := append([]string(nil), t.Items...)
+	sort.Strings(keys)
+	res := Result{Total: len(keys)}
+
+	start := 0
+	cp, ok, err := s.Load(t.Job, t.Phase)
+	if err != nil {
+		return res, err
+	}
+	if ok {
+		start = sort.Search(len(keys), func(i int) bool { return keys[i] > cp.Cursor })
+		res.Resumed = start
+	}
+
+	note := func() string {
+		if t.Note == nil {
+			return ""
+		}
+		return t.Note()
+	}
+	bar := NewBar(t.Out, t.Phase, len(keys), start)
+	defer func() { bar.Finish(note()) }()
+
+	for i := start; i < len(keys); i++ {
+		if ctx.Err() != nil {
+			res.Interrupted = true
+			return res, nil
+		}
+		key := keys[i]
+		tx, err := s.root.BeginTx(context.Background(), nil)
+		if err != nil {
+			return res, fmt.Errorf("begin tx: %w", err)
+		}
+		stepErr := t.Step(ctx, tx, key)
+		if stepErr != nil {
+			tx.Rollback()
+			if ctx.Err() != nil {
+				res.Interrupted = true
+				return res, nil
+			}
+			res.Failed++
+			if tx, err = s.root.BeginTx(context.Background(), nil); err != nil {
+				return res, fmt.Errorf("begin tx: %w", err)
+			}
+		}
+		if err := s.WithTx(tx).Save(Checkpoint{Job: t.Job, Phase: t.Phase, Cursor: key, Done: i + 1, Total: len(keys)}); err != nil {
+			tx.Rollback()
+			return res, err
+		}
+		if err := tx.Commit(); err != nil {
+			return res, fmt.Errorf("commit: %w", err)
+		}
+		if stepErr == nil {
+			res.Done++
+		}
+		bar.Set(i+1, note())
+	}
+	return res, nil
+}
+
+// #endregion run
@@ -0,0 +1,162 @@
+package progress
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+func setupTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	db.SetMaxOpenConns(1) // one connection, so every tx sees the same in-memory database
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+// #region bar-tests
+func TestBar_RenderAndETA(t *testing.T) {
+	var out bytes.Buffer
+	clock := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	b := NewBar(&out, "similarity", 100, 20)
+	b.now = func() time.Time { return clock }
+	b.start = clock
+
+	clock = clock.Add(10 * time.Second)
+	b.done = 40 // 20 items in 10s → 60 left ≈ 30s
+	got := b.Render("12 edges")
+	for _, want := range []string{"similarity [", "40/100", " 40%", "ETA 30s", "12 edges"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Render() = %q, missing %q", got, want)
+		}
+	}
+
+	b.done = 100
+	if got := b.Render(""); !strings.Contains(got, "done in 10s") {
+		t.Errorf("finished bar = %q, want elapsed time", got)
+	}
+}
+
+func TestBar_NonTerminalThrottles(t *testing.T) {
+	var out bytes.Buffer
+	clock := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	b := NewBar(&out, "temporal", 3, 0)
+	b.now = func() time.Time { return clock }
+
+	b.Set(1, "")
+	b.Set(2, "") // within logInterval of the first line: suppressed
+	clock = clock.Add(logInterval)
+	b.Set(3, "")
+	b.Finish("")
+
+	if lines := strings.Count(out.String(), "\n"); lines != 2 {
+		t.Errorf("expected 2 lines (first + final), got %d:\n%s", lines, out.String())
+	}
+	if strings.Contains(out.String(), "\r") {
+		t.Error("non-terminal output should not redraw in place")
+	}
+}
+
+// #endregion bar-tests
+
+// #region run-tests
+func TestRun_ResumesAfterInterrupt(t *testing.T) {
+	db := setupTestDB(t)
+	if _, err := db.Exec(`CREATE TABLE processed (key TEXT PRIMARY KEY)`); err != nil {
+		t.Fatal(err)
+	}
+	cps, err := NewCheckpointStore(db)
+	if err != nil {
+		t.Fatalf("NewCheckpointStore: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	task := Task{
+		Job: "test-job", Phase: "pass", Items: []string{"c", "a", "d", "b"}, Out: &bytes.Buffer{},
+		Step: func(ctx context.Context, tx *sql.Tx, key string) error {
+			if key == "c" {
+				cancel() // Ctrl+C arrives while "c" is in flight
+				return ctx.Err()
+			}
+			_, err := tx.Exec(`INSERT INTO processed (key) VALUES (?)`, key)
+			return err
+		},
+	}
+	res, err := cps.Run(ctx, task)
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if !res.Interrupted || res.Done != 2 {
+		t.Fatalf("expected interrupt after a, b; got %+v", res)
+	}
+	cp, ok, _ := cps.Load("test-job", "pass")
+	if !ok || cp.Cursor != "b" || cp.Done != 2 {
+		t.Fatalf("checkpoint = %+v (ok=%v), want cursor b", cp, ok)
+	}
+
+	task.Step = func(_ context.Context, tx *sql.Tx, key string) error {
+		_, err := tx.Exec(`INSERT INTO processed (key) VALUES (?)`, key)
+		return err // a duplicate key here would mean an item ran twice
+	}
+	res, err = cps.Run(context.Background(), task)
+	if err != nil {
+		t.Fatalf("resume: %v", err)
+	}
+	if res.Interrupted || res.Resumed != 2 || res.Done != 2 || res.Failed != 0 {
+		t.Fatalf("expected resume to finish c, d; got %+v", res)
+	}
+	var n int
+	db.QueryRow(`SELECT COUNT(*) FROM processed`).Scan(&n)
+	if n != 4 {
+		t.Errorf("processed %d items, want 4", n)
+	}
+}
+
+func TestRun_FailedItemSkippedAndRolledBack(t *testing.T) {
+	db := setupTestDB(t)
+	if _, err := db.Exec(`CREATE TABLE processed (key TEXT PRIMARY KEY)`); err != nil {
+		t.Fatal(err)
+	}
+	cps, _ := NewCheckpointStore(db)
+	res, err := cps.Run(context.Background(), Task{
+		Job: "test-job", Phase: "pass", Items: []string{"a", "b"}, Out: &bytes.Buffer{},
+		Step: func(_ context.Context, tx *sql.Tx, key string) error {
+			if _, err := tx.Exec(`INSERT INTO processed (key) VALUES (?)`, key); err != nil {
+				return err
+			}
+			if key == "a" {
+				return errors.New("search failed")
+			}
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if res.Done != 1 || res.Failed != 1 {
+		t.Fatalf("expected 1 done, 1 failed; got %+v", res)
+	}
+	var n int
+	db.QueryRow(`SELECT COUNT(*) FROM processed WHERE key = 'a'`).Scan(&n)
+	if n != 0 {
+		t.Error("failed item's writes should be rolled back")
+	}
+
+	if err := cps.Clear("test-job"); err != nil {
+		t.Fatalf("Clear: %v", err)
+	}
+	if _, ok, _ := cps.Load("test-job", "pass"); ok {
+		t.Error("checkpoint should be gone after Clear")
+	}
+}
+
+// #endregion run-tests
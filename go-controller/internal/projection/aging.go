@@ -0,0 +1,145 @@
+package projection
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/timestamp"
+)
+
+// #region aging-types
+
+// Preference lifecycle events, recorded in preference_events.
+const (
+	PrefEventCreated    = "created"
+	PrefEventReinforced = "reinforced" // user restated it
+	PrefEventAsked      = "asked"      // staleness check-in sent
+	PrefEventRefreshed  = "refreshed"  // user confirmed it still applies
+	PrefEventRetired    = "retired"    // user said it no longer applies
+	PrefEventReplaced   = "replaced"   // a contradicting preference took its place
+	PrefEventDeleted    = "deleted"    // user deleted it with /prefs
+	PrefEventRestored   = "restored"   // user restored it from preference_history
+	PrefEventDowngraded = "downgraded" // user asked for it to weigh less
+)
+
+// PreferenceEvent is one lifecycle transition of a preference.
+type PreferenceEvent struct {
+	PreferenceID int
+	Event        string
+	CreatedAt    time.Time
+}
+
+// LastActive returns when the preference was last created, reinforced, or refreshed.
+func (p Preference) LastActive() time.Time {
+	if p.LastReinforcedAt.After(p.CreatedAt) {
+		return p.LastReinforcedAt
+	}
+	return p.CreatedAt
+}
+
+// StaleQuestion is the one-time check-in appended to a response for a stale preference.
+func StaleQuestion(p Preference) string {
+	return fmt.Sprintf("Quick check: a while back you told me %q. Is that still what you want? Reply /keep or /retire.", p.Text)
+}
+
+// #endregion aging-types
+
+// #region aging-store
+
+// Stale returns active preferences not reinforced within maxAge as of now, excluding
+// ones already asked about within the last maxAge (each is asked at most once per
+// aging window). Oldest first.
+func (s *PreferenceStore) Stale(now time.Time, maxAge time.Duration) ([]Preference, error) {
+	prefs, err := s.List()
+	if err != nil {
+		return nil, err
+	}
+	cutoff := now.Add(-maxAge)
+	var stale []Preference
+	for _, p := range prefs {
+		if !p.LastActive().Before(cutoff) {
+			continue
+		}
+		if !p.AskedAt.IsZero() && p.AskedAt.After(cutoff) {
+			continue
+		}
+		stale = append(stale, p)
+	}
+	return stale, nil
+}
+
+// MarkAsked records that the user was asked whether preference id still applies.
+func (s *PreferenceStore) MarkAsked(id int) error {
+	if _, err := s.db.Exec("UPDATE preferences SET asked_at = ? WHERE id = ?", timestamp.Now(), id); err != nil {
+		return fmt.Errorf("mark preference asked: %w", err)
+	}
+	return s.logEvent(int64(id), PrefEventAsked)
+}
+
+// Refresh marks preference id as confirmed, restarting its aging window.
+func (s *PreferenceStore) Refresh(id int) error {
+	if _, err := s.db.Exec("UPDATE preferences SET last_reinforced_at = ?, asked_at = NULL, status = 'active' WHERE id = ?",
+		timestamp.Now(), id); err != nil {
+		return fmt.Errorf("refresh preference: %w", err)
+	}
+	return s.logEvent(int64(id), PrefEventRefreshed)
+}
+
+// Retire stops projecting preference id. The row is kept so its lifecycle stays
+// inspectable; restating the preference revives it.
+func (s *PreferenceStore) Retire(id int) error {
+	if _, err := s.db.Exec("UPDATE preferences SET status = 'retired' WHERE id = ?", id); err != nil {
+		return fmt.Errorf("retire preference: %w", err)
+	}
+	return s.logEvent(int64(id), PrefEventRetired)
+}
+
+// Lifecycle returns the recorded events for preference id, oldest first.
+func (s *PreferenceStore) Lifecycle(id int) ([]PreferenceEvent, error) {
+	rows, err := s.db.Query("SELECT preference_id, event, created_at FROM preference_events WHERE preference_id = ? ORDER BY id", id)
+	if err != nil {
+		return nil, fmt.Errorf("list preference events: %w", err)
+	}
+	defer rows.Close()
+
+	var events []PreferenceEvent
+	for rows.Next() {
+		var e PreferenceEvent
+		var ts string
+		if err := rows.Scan(&e.PreferenceID, &e.Event, &ts); err != nil {
+			return nil, fmt.Errorf("scan preference event: %w", err)
+		}
+		e.CreatedAt, _ = timestamp.Parse(ts)
+		events = append(events, e)
+	}
+	return events, rows.Err()
+}
+
+// reinforceText restarts the aging window of the preference matching text
+// (case-insensitive) in scope, reviving it if it had been retired.
+func (s *PreferenceStore) reinforceText(text, scope string) error {
+	var id int64
+	err := s.db.QueryRow("SELECT id FROM preferences WHERE LOWER(text) = LOWER(?) AND scope = ? ORDER BY id LIMIT 1", text, scope).Scan(&id)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("find preference to reinforce: %w", err)
+	}
+	if _, err := s.db.Exec("UPDATE preferences SET last_reinforced_at = ?, asked_at = NULL, status = 'active' WHERE id = ?",
+		timestamp.Now(), id); err != nil {
+		return fmt.Errorf("reinforce preference: %w", err)
+	}
+	return s.logEvent(id, PrefEventReinforced)
+}
+
+func (s *PreferenceStore) logEvent(id int64, event string) error {
+	if _, err := s.db.Exec("INSERT INTO preference_events (preference_id, event, created_at) VALUES (?, ?, ?)",
+		id, event, timestamp.Now()); err != nil {
+		return fmt.Errorf("log preference event: %w", err)
+	}
+	return nil
+}
+
+// #endregion aging-store
@@ -0,0 +1,97 @@
+package projection
+
+import (
+	"testing"
+	"time"
+)
+
+// #region aging-tests
+
+func lifecycleEvents(t *testing.T, store *PreferenceStore, id int) []string {
+	t.Helper()
+	events, err := store.Lifecycle(id)
+	if err != nil {
+		t.Fatalf("lifecycle: %v", err)
+	}
+	var out []string
+	for _, e := range events {
+		out = append(out, e.Event)
+	}
+	return out
+}
+
+func TestStale_FlagsOldUnreinforcedPreferences(t *testing.T) {
+	store, _ := NewPreferenceStore(testDB(t))
+	store.Add("Use one-line answers", "explicit")
+
+	prefs, _ := store.List()
+	age := 30 * 24 * time.Hour
+
+	if stale, _ := store.Stale(time.Now(), age); len(stale) != 0 {
+		t.Fatalf("fresh preferences should not be stale, got %d", len(stale))
+	}
+	stale, err := store.Stale(time.Now().Add(2*age), age)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(stale) != 1 || stale[0].Text != "Use one-line answers" {
+		t.Fatalf("expected the preference to be stale, got %+v", stale)
+	}
+
+	// Asked once per window
+	if err := store.MarkAsked(prefs[0].ID); err != nil {
+		t.Fatalf("mark asked: %v", err)
+	}
+	if stale, _ := store.Stale(time.Now().Add(2*age), 3*age); len(stale) != 0 {
+		t.Errorf("expected asked preference to be skipped within the window, got %d", len(stale))
+	}
+}
+
+func TestRefreshAndRetire_Lifecycle(t *testing.T) {
+	store, _ := NewPreferenceStore(testDB(t))
+	store.Add("Include timestamps", "explicit")
+	p := mustList(t, store)[0]
+
+	store.MarkAsked(p.ID)
+	if err := store.Refresh(p.ID); err != nil {
+		t.Fatalf("refresh: %v", err)
+	}
+	if got := mustList(t, store)[0]; got.LastReinforcedAt.IsZero() || !got.AskedAt.IsZero() {
+		t.Errorf("expected refresh to set reinforced and clear asked, got %+v", got)
+	}
+
+	if err := store.Retire(p.ID); err != nil {
+		t.Fatalf("retire: %v", err)
+	}
+	if prefs, _ := store.List(); len(prefs) != 0 {
+		t.Fatalf("expected retired preference hidden from List, got %d", len(prefs))
+	}
+
+	// Restating revives it
+	store.Add("include timestamps", "explicit")
+	if prefs := mustList(t, store); len(prefs) != 1 || prefs[0].ID != p.ID {
+		t.Fatalf("expected restated preference revived in place, got %+v", prefs)
+	}
+
+	want := []string{PrefEventCreated, PrefEventAsked, PrefEventRefreshed, PrefEventRetired, PrefEventReinforced}
+	got := lifecycleEvents(t, store, p.ID)
+	if len(got) != len(want) {
+		t.Fatalf("expected events %v, got %v", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("event %d: expected %s, got %s", i, want[i], got[i])
+		}
+	}
+}
+
+func mustList(t *testing.T, store *PreferenceStore) []Preference {
+	t.Helper()
+	prefs, err := store.List()
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	return prefs
+}
+
+// #endregion aging-tests
@@ -0,0 +1,298 @@
+package projection
+
+import (
+	"fmt"
+	"strings"
+)
+
+// #region intent-types
+
+// Intents a prompt can be detected as carrying. Preference, preference
+// removal, rule and identity are learning intents: they change what is stored
+// and need no generation.
+const (
+	IntentMemoryCorrection = "memory_correction"  // review and delete evidence behind the last answer
+	IntentPrefRemoval      = "preference_removal" // "forget my preference about X"; Field remove | downgrade, Value the subject
+	IntentRule             = DetectorRule         // "when I say X, you say Y"
+	IntentCorrection       = "correction"         // the last answer was wrong; veto and regenerate
+	IntentIdentity         = DetectorIdentity     // name, pronouns, form of address, AI designation
+	IntentPreference       = DetectorPreference   // standing preference
+)
+
+// Precedence, strongest claim first: memory correction, preference removal,
+// rule, correction, identity, preference. Between two conflicting detections of similar
+// confidence, the earlier intent wins.
+//
+// intentConflicts are the intent pairs that cannot both be handled for one
+// prompt, higher precedence first. A rule, identity statement or correction is
+// usually matched by the looser preference patterns too, and a correction
+// needs the generation that teaching a rule or preference skips.
+var intentConflicts = [][2]string{
+	{IntentPrefRemoval, IntentPreference},
+	{IntentRule, IntentPreference},
+	{IntentRule, IntentCorrection},
+	{IntentCorrection, IntentPreference},
+	{IntentIdentity, IntentPreference},
+}
+
+// Intent is one detector firing with the confidence its pattern carries.
+// Field and Value carry what would be stored, as in DetectionLabel.
+type Intent struct {
+	Kind       string
+	Confidence float64
+	Field      string
+	Value      string
+}
+
+// Learning reports whether the intent stores a preference, rule or identity fact.
+func (i Intent) Learning() bool {
+	return i.Kind == IntentPreference || i.Kind == IntentPrefRemoval || i.Kind == IntentRule || i.Kind == IntentIdentity
+}
+
+// #endregion intent-types
+
+// #region intent-detect
+
+// Pattern confidences. A pattern that only fits one intent ("when I say",
+// "my name is", "that's wrong") scores above the loose ones ("I'm …", "no,").
+const (
+	confidenceMemoryCorrection = 0.95
+	confidencePrefRemoval      = 0.9
+	confidenceRule             = 0.9
+	confidenceIdentity         = 0.9
+	confidenceIdentityLoose    = 0.6
+	confidenceCorrection       = 0.8
+	confidenceCorrectionLoose  = 0.5
+	confidencePreference       = 0.7
+)
+
+// looseCorrectionPatterns are the correctionPatterns that also open ordinary
+// instructions ("no, always answer in French").
+var looseCorrectionPatterns = map[string]bool{"no,": true, "nope": true, "wrong ": true, "i said ": true}
+
+// DetectIntents runs every detector over prompt and returns what fired, in
+// precedence order. A preference removal names what to forget, so "forget
+// that I like X" is not also read as a memory correction.
+func DetectIntents(prompt string) []Intent {
+	var out []Intent
+	removal, removing := DetectPreferenceRemoval(prompt)
+	if DetectMemoryCorrection(prompt) && !removing {
+		out = append(out, Intent{Kind: IntentMemoryCorrection, Confidence: confidenceMemoryCorrection})
+	}
+	if removing {
+		field := "remove"
+		if removal.Downgrade {
+			field = "downgrade"
+		}
+		out = append(out, Intent{Kind: IntentPrefRemoval, Confidence: confidencePrefRemoval, Field: field, Value: removal.Subject})
+	}
+	if DetectRule(prompt) {
+		if trigger, response, ok := ExtractRule(prompt); ok {
+			out = append(out, Intent{Kind: IntentRule, Confidence: confidenceRule, Field: trigger, Value: response})
+		}
+	}
+	if c := correctionConfidence(prompt); c > 0 {
+		out = append(out, Intent{Kind: IntentCorrection, Confidence: c})
+	}
+	lower := strings.ToLower(strings.TrimSpace(prompt))
+	for _, d := range []struct {
+		field  string
+		detect func(string) (string, bool)
+	}{
+		{ProfileUserName, DetectIdentity},
+		{ProfileUserPronouns, DetectPronouns},
+		{ProfileUserHonorific, DetectHonorific},
+		{ProfileAIDesignation, DetectAIDesignation},
+	} {
+		if value, ok := d.detect(prompt); ok {
+			c := confidenceIdentity
+			if d.field == ProfileUserName && (strings.HasPrefix(lower, "i'm ") || strings.HasPrefix(lower, "i am ")) {
+				c = confidenceIdentityLoose // "I'm Dan" or "I'm vegetarian from now on"
+			}
+			out = append(out, Intent{Kind: IntentIdentity, Confidence: c, Field: d.field, Value: value})
+		}
+	}
+	if text, ok := DetectPreference(prompt); ok {
+		out = append(out, Intent{Kind: IntentPreference, Confidence: confidencePreference, Value: text})
+	}
+	return out
+}
+
+// correctionConfidence is the confidence of the strongest correction pattern
+// in prompt, or 0 if none matches.
+func correctionConfidence(prompt string) float64 {
+	lower := strings.ToLower(strings.TrimSpace(prompt))
+	best := 0.0
+	for _, pat := range correctionPatterns {
+		if !strings.Contains(lower, pat) {
+			continue
+		}
+		if looseCorrectionPatterns[pat] {
+			best = max(best, confidenceCorrectionLoose)
+		} else {
+			best = confidenceCorrection
+		}
+	}
+	return best
+}
+
+// #endregion intent-detect
+
+// #region arbitrate
+
+// ArbitrationConfig sets when conflicting detections are put to the user.
+type ArbitrationConfig struct {
+	// Two conflicting detections closer in confidence than this are
+	// ambiguous (default 0.15); 0 never asks and lets precedence decide
+	Margin float64
+	// Both detections must reach this confidence to be worth asking about
+	// (default 0.5); below it the stronger one simply wins
+	AskConfidence float64
+}
+
+// DefaultArbitrationConfig returns the arbitration defaults.
+func DefaultArbitrationConfig() ArbitrationConfig {
+	return ArbitrationConfig{Margin: 0.15, AskConfidence: 0.5}
+}
+
+// Arbitration is the handling decided for one prompt's detections.
+type Arbitration struct {
+	Intents  []Intent // every detection, as arbitrated
+	Accepted []Intent // handle these, in precedence order
+	Dropped  []Intent // lost a conflict
+	// Non-empty when the prompt is held for a one-line confirmation: the two
+	// conflicting intents, higher precedence first
+	Ambiguous []Intent
+	Notes     []string // one line per conflict resolved, for the log
+}
+
+// Arbitrate resolves intents: for each conflicting pair, the clearly more
+// confident detection wins and the other is dropped. When the two are within
+// cfg.Margin of each other and both reach cfg.AskConfidence, the first such
+// pair is returned as Ambiguous and nothing is accepted. Otherwise precedence
+// decides. Intents that conflict with nothing are accepted as they are.
+func Arbitrate(intents []Intent, cfg ArbitrationConfig) Arbitration {
+	dropped := make([]bool, len(intents))
+	a := Arbitration{Intents: intents}
+	for _, pair := range intentConflicts {
+		for i, hi := range intents {
+			if hi.Kind != pair[0] || dropped[i] {
+				continue
+			}
+			for j, lo := range intents {
+				if lo.Kind != pair[1] || dropped[j] || dropped[i] {
+					continue
+				}
+				gap := hi.Confidence - lo.Confidence
+				switch {
+				case cfg.Margin > 0 && gap < cfg.Margin && -gap < cfg.Margin &&
+					hi.Confidence >= cfg.AskConfidence && lo.Confidence >= cfg.AskConfidence:
+					return Arbitration{Intents: intents, Ambiguous: []Intent{hi, lo},
+						Notes: append(a.Notes, fmt.Sprintf("%s %.2f vs %s %.2f: ambiguous, asking", hi.Kind, hi.Confidence, lo.Kind, lo.Confidence))}
+				case cfg.Margin > 0 && gap <= -cfg.Margin:
+					dropped[i] = true
+					a.Notes = append(a.Notes, fmt.Sprintf("%s %.2f over %s %.2f (confidence)", lo.Kind, lo.Confidence, hi.Kind, hi.Confidence))
+				default:
+					dropped[j] = true
+					why := "precedence"
+					if cfg.Margin > 0 && gap >= cfg.Margin {
+						why = "confidence"
+					}
+					a.Notes = append(a.Notes, fmt.Sprintf("%s %.2f over %s %.2f (%s)", hi.Kind, hi.Confidence, lo.Kind, lo.Confidence, why))
+				}
+			}
+		}
+	}
+	for i, in := range intents {
+		if dropped[i] {
+			a.Dropped = append(a.Dropped, in)
+		} else {
+			a.Accepted = append(a.Accepted, in)
+		}
+	}
+	return a
+}
+
+// Choose resolves an ambiguous arbitration with the user's answer: kind keeps
+// that intent and drops the other, "neither" drops both. The remaining
+// detections are arbitrated again, so another conflict can still be ambiguous.
+// It reports false when kind is not one of the two asked about.
+func (a Arbitration) Choose(kind string, cfg ArbitrationConfig) (Arbitration, bool) {
+	if kind != "neither" && !hasKind(a.Ambiguous, kind) {
+		return Arbitration{}, false
+	}
+	var keep, drop []Intent
+	for _, in := range a.Intents {
+		if hasKind(a.Ambiguous, in.Kind) && in.Kind != kind {
+			drop = append(drop, in)
+		} else {
+			keep = append(keep, in)
+		}
+	}
+	out := Arbitrate(keep, cfg)
+	out.Intents = a.Intents
+	out.Dropped = append(out.Dropped, drop...)
+	out.Notes = append([]string{"user chose " + kind}, out.Notes...)
+	return out, true
+}
+
+func hasKind(intents []Intent, kind string) bool {
+	for _, in := range intents {
+		if in.Kind == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// Has reports whether an intent of kind was accepted.
+func (a Arbitration) Has(kind string) bool {
+	return hasKind(a.Accepted, kind)
+}
+
+// Get returns the first accepted intent of kind.
+func (a Arbitration) Get(kind string) (Intent, bool) {
+	for _, in := range a.Accepted {
+		if in.Kind == kind {
+			return in, true
+		}
+	}
+	return Intent{}, false
+}
+
+// LearningOnly reports whether the accepted intents store something and none
+// asks for a response: the turn needs no generation or retrieval.
+func (a Arbitration) LearningOnly() bool {
+	learning := false
+	for _, in := range a.Accepted {
+		switch {
+		case in.Kind == IntentCorrection:
+			return false
+		case in.Kind == IntentPreference || in.Kind == IntentPrefRemoval || in.Kind == IntentRule:
+			learning = true
+		}
+	}
+	return learning
+}
+
+// intentPhrases describe each intent in the confirmation question.
+var intentPhrases = map[string]string{
+	IntentMemoryCorrection: "a request to forget what I stored",
+	IntentPrefRemoval:      "a request to drop one of your preferences",
+	IntentRule:             "a rule to follow",
+	IntentCorrection:       "a correction of my last answer",
+	IntentIdentity:         "something to remember about you",
+	IntentPreference:       "a standing preference",
+}
+
+// Question is the one-line confirmation for an ambiguous arbitration.
+func (a Arbitration) Question() string {
+	if len(a.Ambiguous) < 2 {
+		return ""
+	}
+	hi, lo := a.Ambiguous[0].Kind, a.Ambiguous[1].Kind
+	return fmt.Sprintf("Did you mean that as %s or as %s? (/as %s, /as %s, /as neither)",
+		intentPhrases[hi], intentPhrases[lo], hi, lo)
+}
+
+// #endregion arbitrate
@@ -0,0 +1,112 @@
+package projection
+
+import (
+	"strings"
+	"testing"
+)
+
+func kinds(intents []Intent) string {
+	var out []string
+	for _, in := range intents {
+		out = append(out, in.Kind)
+	}
+	return strings.Join(out, ",")
+}
+
+func TestDetectIntents(t *testing.T) {
+	cases := []struct {
+		prompt string
+		want   string
+	}{
+		{"When I say ping, you say pong", "rule"},
+		{"That's wrong, try again", "correction"},
+		{"My name is Ada", "identity"},
+		{"forget that, it's junk", "memory_correction"},
+		{"I prefer short answers", "preference"},
+		{"Forget that I like examples", "preference_removal"},
+		{"I no longer want short answers", "preference_removal,preference"},
+		{"What's the weather like?", ""},
+	}
+	for _, tc := range cases {
+		if got := kinds(DetectIntents(tc.prompt)); got != tc.want {
+			t.Errorf("DetectIntents(%q) = %q, want %q", tc.prompt, got, tc.want)
+		}
+	}
+	if c := correctionConfidence("nope, keep going"); c != confidenceCorrectionLoose {
+		t.Errorf("loose correction confidence = %v", c)
+	}
+	if c := correctionConfidence("nope, that's not right"); c != confidenceCorrection {
+		t.Errorf("strong correction confidence = %v", c)
+	}
+}
+
+func TestArbitrate(t *testing.T) {
+	cfg := DefaultArbitrationConfig()
+	pref := Intent{Kind: IntentPreference, Confidence: confidencePreference, Value: "always be brief"}
+	cases := []struct {
+		name      string
+		intents   []Intent
+		accepted  string
+		dropped   string
+		ambiguous string
+	}{
+		{"rule claims the prompt",
+			[]Intent{{Kind: IntentRule, Confidence: confidenceRule}, pref}, "rule", "preference", ""},
+		{"loose correction yields to an instruction",
+			[]Intent{{Kind: IntentCorrection, Confidence: confidenceCorrectionLoose}, pref}, "preference", "correction", ""},
+		{"strong correction against a preference is ambiguous",
+			[]Intent{{Kind: IntentCorrection, Confidence: confidenceCorrection}, pref}, "", "", "correction,preference"},
+		{"compatible intents are all kept",
+			[]Intent{{Kind: IntentMemoryCorrection, Confidence: confidenceMemoryCorrection}, {Kind: IntentCorrection, Confidence: confidenceCorrection}},
+			"memory_correction,correction", "", ""},
+		{"identity over a loose preference match",
+			[]Intent{{Kind: IntentIdentity, Confidence: confidenceIdentity}, pref}, "identity", "preference", ""},
+		{"retraction over the preference it mentions",
+			[]Intent{{Kind: IntentPrefRemoval, Confidence: confidencePrefRemoval}, pref}, "preference_removal", "preference", ""},
+	}
+	for _, tc := range cases {
+		a := Arbitrate(tc.intents, cfg)
+		if kinds(a.Accepted) != tc.accepted || kinds(a.Dropped) != tc.dropped || kinds(a.Ambiguous) != tc.ambiguous {
+			t.Errorf("%s: accepted %q dropped %q ambiguous %q", tc.name, kinds(a.Accepted), kinds(a.Dropped), kinds(a.Ambiguous))
+		}
+	}
+
+	// Without a margin precedence alone decides
+	a := Arbitrate([]Intent{{Kind: IntentCorrection, Confidence: confidenceCorrection}, pref}, ArbitrationConfig{})
+	if kinds(a.Accepted) != "correction" || len(a.Ambiguous) != 0 {
+		t.Errorf("margin 0: accepted %q ambiguous %q", kinds(a.Accepted), kinds(a.Ambiguous))
+	}
+}
+
+func TestArbitration_Choose(t *testing.T) {
+	cfg := DefaultArbitrationConfig()
+	a := Arbitrate([]Intent{
+		{Kind: IntentCorrection, Confidence: confidenceCorrection},
+		{Kind: IntentPreference, Confidence: confidencePreference, Value: "use metric units"},
+	}, cfg)
+	q := a.Question()
+	if !strings.Contains(q, "a correction of my last answer or as a standing preference") || !strings.Contains(q, "/as correction, /as preference, /as neither") {
+		t.Errorf("question = %q", q)
+	}
+	if len(a.Accepted) != 0 {
+		t.Error("ambiguous arbitration accepted intents")
+	}
+
+	chosen, ok := a.Choose(IntentPreference, cfg)
+	if !ok || kinds(chosen.Accepted) != "preference" || kinds(chosen.Dropped) != "correction" || !chosen.LearningOnly() {
+		t.Errorf("choose preference: %+v, %v", chosen, ok)
+	}
+	if p, _ := chosen.Get(IntentPreference); p.Value != "use metric units" {
+		t.Errorf("chosen preference = %+v", p)
+	}
+	chosen, _ = a.Choose(IntentCorrection, cfg)
+	if kinds(chosen.Accepted) != "correction" || chosen.LearningOnly() {
+		t.Errorf("choose correction: %+v", chosen)
+	}
+	if neither, ok := a.Choose("neither", cfg); !ok || len(neither.Accepted) != 0 || len(neither.Dropped) != 2 {
+		t.Errorf("choose neither: %+v, %v", neither, ok)
+	}
+	if _, ok := a.Choose(IntentRule, cfg); ok {
+		t.Error("choice outside the question accepted")
+	}
+}
@@ -0,0 +1,204 @@
+package projection
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/timestamp"
+)
+
+// #region detection-label-types
+
+// Detectors whose firings can be sampled for confirmation, and label outcomes.
+const (
+	DetectorPreference = "preference"
+	DetectorRule       = "rule"
+	DetectorIdentity   = "identity"
+
+	LabelPending   = "pending" // asked, not answered (yet)
+	LabelConfirmed = "confirmed"
+	LabelDenied    = "denied"
+)
+
+// DetectionLabel is one sampled detector firing and the user's verdict on it.
+// Field and Value carry what was stored: the scope and text of a preference,
+// the trigger and response of a rule, or the profile field and value of an
+// identity statement.
+type DetectionLabel struct {
+	ID        int64     `json:"id"`
+	Detector  string    `json:"detector"`
+	Field     string    `json:"field,omitempty"`
+	Value     string    `json:"value"`
+	Prompt    string    `json:"prompt"`
+	TurnID    string    `json:"turn_id,omitempty"`
+	Label     string    `json:"label"`
+	CreatedAt time.Time `json:"created_at"`
+	DecidedAt time.Time `json:"decided_at"`
+}
+
+// Question asks whether the detection was meant as standing instruction.
+func (l DetectionLabel) Question() string {
+	const answer = "(/yes to keep it, /no and I'll undo it)"
+	switch l.Detector {
+	case DetectorRule:
+		return fmt.Sprintf("Quick check: did you mean \"when %s → %s\" as a standing rule? %s", l.Field, l.Value, answer)
+	case DetectorIdentity:
+		return fmt.Sprintf("Quick check: should I remember your %s as %q? %s", strings.ReplaceAll(l.Field, "_", " "), l.Value, answer)
+	default:
+		return fmt.Sprintf("Quick check: did you mean %q as a standing preference? %s", l.Value, answer)
+	}
+}
+
+// #endregion detection-label-types
+
+// #region detection-label-store
+
+// DetectionLabelStore records confirmation samples of preference, rule and
+// identity detections as labeled data for detector precision.
+type DetectionLabelStore struct {
+	db *sql.DB
+}
+
+// NewDetectionLabelStore creates the detection_labels table if needed and returns a store.
+func NewDetectionLabelStore(db *sql.DB) (*DetectionLabelStore, error) {
+	_, err := db.Exec(`CREATE TABLE IF NOT EXISTS detection_labels (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		detector TEXT NOT NULL,
+		field TEXT NOT NULL DEFAULT '',
+		value TEXT NOT NULL DEFAULT '',
+		prompt TEXT NOT NULL DEFAULT '',
+		turn_id TEXT NOT NULL DEFAULT '',
+		label TEXT NOT NULL DEFAULT 'pending',
+		created_at TEXT NOT NULL,
+		decided_at TEXT
+	)`)
+	if err != nil {
+		return nil, fmt.Errorf("create detection_labels table: %w", err)
+	}
+	if _, err := timestamp.Canonicalize(db, "detection_labels", "created_at", "decided_at"); err != nil {
+		return nil, err
+	}
+	return &DetectionLabelStore{db: db}, nil
+}
+
+// Ask records a sampled detection as pending and returns it with its ID set.
+func (s *DetectionLabelStore) Ask(l DetectionLabel) (DetectionLabel, error) {
+	l.Label = LabelPending
+	l.CreatedAt = time.Now().UTC()
+	res, err := s.db.Exec(
+		`INSERT INTO detection_labels (detector, field, value, prompt, turn_id, label, created_at) VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		l.Detector, l.Field, l.Value, l.Prompt, l.TurnID, l.Label, timestamp.Format(l.CreatedAt),
+	)
+	if err != nil {
+		return l, fmt.Errorf("insert detection label: %w", err)
+	}
+	l.ID, _ = res.LastInsertId()
+	return l, nil
+}
+
+// Decide records the user's answer to a pending sample.
+func (s *DetectionLabelStore) Decide(id int64, label string) error {
+	if label != LabelConfirmed && label != LabelDenied {
+		return fmt.Errorf("unknown detection label %q", label)
+	}
+	res, err := s.db.Exec(`UPDATE detection_labels SET label = ?, decided_at = ? WHERE id = ? AND label = 'pending'`,
+		label, timestamp.Now(), id)
+	if err != nil {
+		return fmt.Errorf("decide detection label: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("detection label %d not found or already decided", id)
+	}
+	return nil
+}
+
+// List returns samples created at or after since, newest first.
+func (s *DetectionLabelStore) List(since time.Time) ([]DetectionLabel, error) {
+	rows, err := s.db.Query(`SELECT id, detector, field, value, prompt, turn_id, label, created_at, decided_at
+		FROM detection_labels ORDER BY id DESC`)
+	if err != nil {
+		return nil, fmt.Errorf("list detection labels: %w", err)
+	}
+	defer rows.Close()
+
+	var out []DetectionLabel
+	for rows.Next() {
+		var l DetectionLabel
+		var created string
+		var decided sql.NullString
+		if err := rows.Scan(&l.ID, &l.Detector, &l.Field, &l.Value, &l.Prompt, &l.TurnID, &l.Label, &created, &decided); err != nil {
+			return nil, fmt.Errorf("scan detection label: %w", err)
+		}
+		l.CreatedAt, _ = timestamp.Parse(created)
+		if l.CreatedAt.Before(since) {
+			continue
+		}
+		if decided.Valid {
+			l.DecidedAt, _ = timestamp.Parse(decided.String)
+		}
+		out = append(out, l)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate detection labels: %w", err)
+	}
+	return out, nil
+}
+
+// #endregion detection-label-store
+
+// #region detector-precision
+
+// DetectorPrecision aggregates the labeled samples of one detector.
+type DetectorPrecision struct {
+	Detector   string           `json:"detector"`
+	Asked      int              `json:"asked"`
+	Confirmed  int              `json:"confirmed"`
+	Denied     int              `json:"denied"`
+	Unanswered int              `json:"unanswered"`
+	Denials    []DetectionLabel `json:"denials,omitempty"` // newest denied samples, for detector fixes
+}
+
+// Precision returns confirmed over answered samples (0 if none answered).
+func (p DetectorPrecision) Precision() float64 {
+	if p.Confirmed+p.Denied == 0 {
+		return 0
+	}
+	return float64(p.Confirmed) / float64(p.Confirmed+p.Denied)
+}
+
+// GroupPrecision aggregates labels per detector, keeping up to samples of the
+// newest denials in each. Groups are ordered by detector name.
+func GroupPrecision(labels []DetectionLabel, samples int) []DetectorPrecision {
+	byDetector := map[string]*DetectorPrecision{}
+	for _, l := range labels {
+		p, ok := byDetector[l.Detector]
+		if !ok {
+			p = &DetectorPrecision{Detector: l.Detector}
+			byDetector[l.Detector] = p
+		}
+		p.Asked++
+		switch l.Label {
+		case LabelConfirmed:
+			p.Confirmed++
+		case LabelDenied:
+			p.Denied++
+			if len(p.Denials) < samples {
+				p.Denials = append(p.Denials, l)
+			}
+		default:
+			p.Unanswered++
+		}
+	}
+
+	out := make([]DetectorPrecision, 0, len(byDetector))
+	for _, p := range byDetector {
+		out = append(out, *p)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Detector < out[j].Detector })
+	return out
+}
+
+// #endregion detector-precision
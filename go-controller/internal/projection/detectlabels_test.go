@@ -0,0 +1,109 @@
+package projection
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// #region detection-label-tests
+
+func TestDetectionLabelStore_AskDecide(t *testing.T) {
+	s, err := NewDetectionLabelStore(testDB(t))
+	if err != nil {
+		t.Fatalf("NewDetectionLabelStore: %v", err)
+	}
+	asked, err := s.Ask(DetectionLabel{Detector: DetectorPreference, Value: "read test.txt", Prompt: "I want you to read test.txt", TurnID: "turn-3"})
+	if err != nil {
+		t.Fatalf("Ask: %v", err)
+	}
+	if asked.ID == 0 || asked.Label != LabelPending {
+		t.Fatalf("expected a pending label with an ID, got %+v", asked)
+	}
+	if err := s.Decide(asked.ID, LabelDenied); err != nil {
+		t.Fatalf("Decide: %v", err)
+	}
+	if err := s.Decide(asked.ID, LabelConfirmed); err == nil {
+		t.Error("deciding an answered sample again should fail")
+	}
+	if err := s.Decide(asked.ID, "maybe"); err == nil {
+		t.Error("unknown label should be rejected")
+	}
+
+	labels, err := s.List(time.Now().Add(-time.Hour))
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(labels) != 1 || labels[0].Label != LabelDenied || labels[0].DecidedAt.IsZero() || labels[0].TurnID != "turn-3" {
+		t.Fatalf("unexpected labels: %+v", labels)
+	}
+	if later, _ := s.List(time.Now().Add(time.Hour)); len(later) != 0 {
+		t.Errorf("since should exclude older samples, got %d", len(later))
+	}
+}
+
+func TestGroupPrecision(t *testing.T) {
+	labels := []DetectionLabel{
+		{ID: 5, Detector: DetectorPreference, Label: LabelDenied, Value: "read test.txt"},
+		{ID: 4, Detector: DetectorPreference, Label: LabelConfirmed},
+		{ID: 3, Detector: DetectorPreference, Label: LabelConfirmed},
+		{ID: 2, Detector: DetectorPreference, Label: LabelPending},
+		{ID: 1, Detector: DetectorRule, Label: LabelDenied},
+	}
+	groups := GroupPrecision(labels, 1)
+	if len(groups) != 2 || groups[0].Detector != DetectorPreference || groups[1].Detector != DetectorRule {
+		t.Fatalf("unexpected groups: %+v", groups)
+	}
+	pref := groups[0]
+	if pref.Asked != 4 || pref.Confirmed != 2 || pref.Denied != 1 || pref.Unanswered != 1 {
+		t.Errorf("unexpected preference counts: %+v", pref)
+	}
+	if got := pref.Precision(); got < 0.66 || got > 0.67 {
+		t.Errorf("precision = %.3f, want 2/3 (unanswered excluded)", got)
+	}
+	if len(pref.Denials) != 1 || pref.Denials[0].ID != 5 {
+		t.Errorf("expected the newest denial kept, got %+v", pref.Denials)
+	}
+	if groups[1].Precision() != 0 {
+		t.Errorf("all-denied detector precision = %v, want 0", groups[1].Precision())
+	}
+	if (DetectorPrecision{}).Precision() != 0 {
+		t.Error("no answers should give precision 0")
+	}
+}
+
+func TestDetectionLabel_Question(t *testing.T) {
+	for _, tc := range []struct {
+		label DetectionLabel
+		want  string
+	}{
+		{DetectionLabel{Detector: DetectorPreference, Value: "read test.txt"}, `"read test.txt" as a standing preference`},
+		{DetectionLabel{Detector: DetectorRule, Field: "hello", Value: "hi"}, `"when hello → hi" as a standing rule`},
+		{DetectionLabel{Detector: DetectorIdentity, Field: ProfileUserName, Value: "Sam"}, `your user name as "Sam"`},
+	} {
+		if q := tc.label.Question(); !strings.Contains(q, tc.want) || !strings.Contains(q, "/no") {
+			t.Errorf("%s question %q missing %q", tc.label.Detector, q, tc.want)
+		}
+	}
+}
+
+func TestRuleStore_Remove(t *testing.T) {
+	rs, err := NewRuleStore(testDB(t))
+	if err != nil {
+		t.Fatalf("NewRuleStore: %v", err)
+	}
+	if err := rs.Add("hello", "hi", 5, 1); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+	if err := rs.Remove(" HELLO "); err != nil {
+		t.Fatalf("Remove: %v", err)
+	}
+	if rules, _ := rs.List(); len(rules) != 0 {
+		t.Errorf("rule should be removed, got %+v", rules)
+	}
+	if err := rs.Remove("missing"); err != nil {
+		t.Errorf("removing a missing rule should not fail: %v", err)
+	}
+}
+
+// #endregion detection-label-tests
@@ -0,0 +1,192 @@
+package projection
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/timestamp"
+)
+
+// #region history-types
+
+// Why a preference left the active set, recorded in preference_history.
+const (
+	PrefRemovedReplaced = "replaced" // a contradicting preference took its place
+	PrefRemovedDeleted  = "deleted"  // the user deleted it
+)
+
+// PreferenceRemoval is one preference_history entry: a preference as it was
+// when it was replaced or deleted.
+type PreferenceRemoval struct {
+	ID         int
+	Preference Preference // ID is the preference's own, reused on restore
+	Reason     string     // PrefRemovedReplaced | PrefRemovedDeleted
+	ReplacedBy int        // preference that replaced it; 0 if deleted
+	RemovedAt  time.Time
+	RestoredAt time.Time // zero = still removed
+}
+
+// #endregion history-types
+
+// #region history-store
+
+// contradicted returns the IDs of the active preferences a new preference of
+// style in scope replaces: same non-general style, and only temporary ones
+// when the new one is temporary.
+func (s *PreferenceStore) contradicted(style PreferenceStyle, scope string, temporary bool) ([]int64, error) {
+	if style == StyleGeneral {
+		return nil, nil
+	}
+	query := "SELECT id FROM preferences WHERE style = ? AND scope = ?"
+	if temporary {
+		query += " AND expires_at IS NOT NULL"
+	}
+	rows, err := s.db.Query(query, string(style), scope)
+	if err != nil {
+		return nil, fmt.Errorf("find contradicting preferences: %w", err)
+	}
+	defer rows.Close()
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("scan contradicting preference: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// remove moves preferences ids into preference_history with reason, noting
+// the preference that replaced them (0 for none).
+func (s *PreferenceStore) remove(ids []int64, reason string, replacedBy int64) error {
+	var by interface{}
+	if replacedBy != 0 {
+		by = replacedBy
+	}
+	event := PrefEventDeleted
+	if reason == PrefRemovedReplaced {
+		event = PrefEventReplaced
+	}
+	for _, id := range ids {
+		if _, err := s.db.Exec(`INSERT INTO preference_history
+			(preference_id, text, style, source, scope, priority, expires_at, created_at, reason, replaced_by, removed_at)
+			SELECT id, text, style, source, scope, priority, expires_at, created_at, ?, ?, ? FROM preferences WHERE id = ?`,
+			reason, by, timestamp.Now(), id); err != nil {
+			return fmt.Errorf("record removed preference: %w", err)
+		}
+		if _, err := s.db.Exec("DELETE FROM preferences WHERE id = ?", id); err != nil {
+			return fmt.Errorf("remove preference: %w", err)
+		}
+		if err := s.logEvent(id, event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Delete removes preference id, keeping it in preference_history. It reports
+// false if no such preference is stored.
+func (s *PreferenceStore) Delete(id int) (bool, error) {
+	var n int
+	if err := s.db.QueryRow("SELECT COUNT(*) FROM preferences WHERE id = ?", id).Scan(&n); err != nil {
+		return false, fmt.Errorf("find preference: %w", err)
+	}
+	if n == 0 {
+		return false, nil
+	}
+	return true, s.remove([]int64{int64(id)}, PrefRemovedDeleted, 0)
+}
+
+// Restore brings preference id back from its latest unrestored
+// preference_history entry, under its own ID. Like a newly added preference it
+// replaces the active ones it contradicts, whose IDs are returned. A
+// preference restated since it was removed is simply reinforced. found is
+// false if id has nothing to restore.
+func (s *PreferenceStore) Restore(id int) (replaced []int64, found bool, err error) {
+	var histID int64
+	var text, style, scope string
+	var priority int
+	var expires sql.NullString
+	err = s.db.QueryRow(`SELECT id, text, style, scope, priority, expires_at FROM preference_history
+		WHERE preference_id = ? AND restored_at IS NULL ORDER BY id DESC LIMIT 1`, id).
+		Scan(&histID, &text, &style, &scope, &priority, &expires)
+	if err == sql.ErrNoRows {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("find removed preference: %w", err)
+	}
+
+	var count int
+	if err := s.db.QueryRow("SELECT COUNT(*) FROM preferences WHERE LOWER(text) = LOWER(?) AND scope = ?", text, scope).Scan(&count); err != nil {
+		return nil, false, fmt.Errorf("check duplicate preference: %w", err)
+	}
+	if count > 0 {
+		if err := s.reinforceText(text, scope); err != nil {
+			return nil, true, err
+		}
+	} else {
+		replaced, err = s.contradicted(PreferenceStyle(style), scope, expires.Valid)
+		if err != nil {
+			return nil, true, err
+		}
+		if _, err := s.db.Exec(`INSERT INTO preferences (id, text, style, source, created_at, scope, priority, expires_at)
+			SELECT preference_id, text, style, source, created_at, scope, priority, expires_at FROM preference_history WHERE id = ?`,
+			histID); err != nil {
+			return nil, true, fmt.Errorf("restore preference: %w", err)
+		}
+		if err := s.logEvent(int64(id), PrefEventRestored); err != nil {
+			return nil, true, err
+		}
+		if err := s.remove(replaced, PrefRemovedReplaced, int64(id)); err != nil {
+			return nil, true, err
+		}
+	}
+	if _, err := s.db.Exec("UPDATE preference_history SET restored_at = ? WHERE id = ?", timestamp.Now(), histID); err != nil {
+		return replaced, true, fmt.Errorf("mark preference restored: %w", err)
+	}
+	return replaced, true, nil
+}
+
+// History returns up to limit preference_history entries, newest first
+// (limit <= 0 = all).
+func (s *PreferenceStore) History(limit int) ([]PreferenceRemoval, error) {
+	query := `SELECT id, preference_id, text, style, source, scope, priority, expires_at, created_at,
+		reason, replaced_by, removed_at, restored_at FROM preference_history ORDER BY id DESC`
+	if limit > 0 {
+		query += fmt.Sprintf(" LIMIT %d", limit)
+	}
+	rows, err := s.db.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("list preference history: %w", err)
+	}
+	defer rows.Close()
+
+	var out []PreferenceRemoval
+	for rows.Next() {
+		var r PreferenceRemoval
+		var style, created, removed string
+		var expires, restored sql.NullString
+		var by sql.NullInt64
+		if err := rows.Scan(&r.ID, &r.Preference.ID, &r.Preference.Text, &style, &r.Preference.Source, &r.Preference.Scope,
+			&r.Preference.Priority, &expires, &created, &r.Reason, &by, &removed, &restored); err != nil {
+			return nil, fmt.Errorf("scan preference history: %w", err)
+		}
+		r.Preference.Style = PreferenceStyle(style)
+		r.Preference.CreatedAt, _ = timestamp.Parse(created)
+		if expires.Valid {
+			r.Preference.ExpiresAt, _ = timestamp.Parse(expires.String)
+		}
+		r.ReplacedBy = int(by.Int64)
+		r.RemovedAt, _ = timestamp.Parse(removed)
+		if restored.Valid {
+			r.RestoredAt, _ = timestamp.Parse(restored.String)
+		}
+		out = append(out, r)
+	}
+	return out, rows.Err()
+}
+
+// #endregion history-store
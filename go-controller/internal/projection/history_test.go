@@ -0,0 +1,89 @@
+package projection
+
+import "testing"
+
+func TestPreferenceStore_ReplacementRecordedAndRestored(t *testing.T) {
+	store, err := NewPreferenceStore(testDB(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	store.Add("Be concise", "explicit")
+	store.Add("Use British spelling", "explicit")
+	store.Add("Be detailed", "explicit") // opposing style, not the same: both kept
+	store.Add("Keep it short", "explicit")
+
+	prefs, _ := store.List()
+	texts := map[string]int{}
+	for _, p := range prefs {
+		texts[p.Text] = p.ID
+	}
+	if _, ok := texts["Be concise"]; ok {
+		t.Fatalf("contradicted preference still active: %+v", prefs)
+	}
+	hist, err := store.History(0)
+	if err != nil || len(hist) != 1 {
+		t.Fatalf("history = %+v, %v", hist, err)
+	}
+	h := hist[0]
+	if h.Preference.Text != "Be concise" || h.Reason != PrefRemovedReplaced || h.ReplacedBy != texts["Keep it short"] || !h.RestoredAt.IsZero() {
+		t.Errorf("replacement entry = %+v", h)
+	}
+	if got := lifecycleEvents(t, store, h.Preference.ID); len(got) != 2 || got[1] != PrefEventReplaced {
+		t.Errorf("events = %v", got)
+	}
+
+	// Restoring brings it back under its own ID and replaces its replacement
+	replaced, found, err := store.Restore(h.Preference.ID)
+	if err != nil || !found || len(replaced) != 1 || int(replaced[0]) != texts["Keep it short"] {
+		t.Fatalf("restore = %v, %v, %v", replaced, found, err)
+	}
+	prefs, _ = store.List()
+	restored := false
+	for _, p := range prefs {
+		if p.Text == "Keep it short" {
+			t.Errorf("replacement still active after restore")
+		}
+		if p.ID == h.Preference.ID && p.Text == "Be concise" {
+			restored = true
+		}
+	}
+	if !restored {
+		t.Errorf("restored preference missing: %+v", prefs)
+	}
+	if _, found, _ := store.Restore(h.Preference.ID); found {
+		t.Error("restored twice")
+	}
+	hist, _ = store.History(0)
+	if len(hist) != 2 || hist[0].Preference.Text != "Keep it short" || hist[1].RestoredAt.IsZero() {
+		t.Errorf("history after restore = %+v", hist)
+	}
+}
+
+func TestPreferenceStore_Delete(t *testing.T) {
+	store, _ := NewPreferenceStore(testDB(t))
+	store.Add("Use British spelling", "explicit")
+	prefs, _ := store.List()
+	id := prefs[0].ID
+
+	if found, err := store.Delete(id); err != nil || !found {
+		t.Fatalf("delete = %v, %v", found, err)
+	}
+	if found, _ := store.Delete(id); found {
+		t.Error("deleted twice")
+	}
+	if prefs, _ := store.List(); len(prefs) != 0 {
+		t.Errorf("deleted preference still listed: %+v", prefs)
+	}
+	if hist, _ := store.History(1); len(hist) != 1 || hist[0].Reason != PrefRemovedDeleted || hist[0].ReplacedBy != 0 {
+		t.Errorf("history = %+v", hist)
+	}
+
+	// Restated since deletion: restoring only reinforces the new copy
+	store.Add("use british spelling", "explicit")
+	if _, found, err := store.Restore(id); err != nil || !found {
+		t.Fatalf("restore = %v, %v", found, err)
+	}
+	if prefs, _ := store.List(); len(prefs) != 1 || prefs[0].LastReinforcedAt.IsZero() {
+		t.Errorf("restore duplicated or did not reinforce: %+v", prefs)
+	}
+}
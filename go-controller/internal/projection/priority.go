@@ -0,0 +1,134 @@
+package projection
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// #region priority-types
+
+// Preference priorities. Higher priorities project first, and an unscoped
+// preference of higher priority is not overridden by a scoped one.
+const (
+	PriorityLow    = 1 // "if possible", "ideally"
+	PriorityNormal = 2
+	PriorityHigh   = 3 // "important", "above all"
+)
+
+// PreferenceOptions are how a preference applies: where, how strongly and
+// until when.
+type PreferenceOptions struct {
+	Scope     string    // turn context (ScopeCoding, ...); ScopeAll = every turn
+	Priority  int       // PriorityLow..PriorityHigh; 0 = PriorityNormal
+	ExpiresAt time.Time // zero = never expires
+}
+
+// IsPriority reports whether p is a preference priority.
+func IsPriority(p int) bool {
+	return p >= PriorityLow && p <= PriorityHigh
+}
+
+// PriorityName is the label inspect and reports show for p.
+func PriorityName(p int) string {
+	switch p {
+	case PriorityLow:
+		return "low"
+	case PriorityHigh:
+		return "high"
+	}
+	return "normal"
+}
+
+// #endregion priority-types
+
+// #region priority-inference
+
+// priorityPhrases are priority qualifiers in a preference's wording.
+var priorityPhrases = []struct {
+	phrase   string
+	priority int
+}{
+	{"important", PriorityHigh}, {"above all", PriorityHigh}, {"no matter what", PriorityHigh},
+	{"no exceptions", PriorityHigh}, {"critical", PriorityHigh}, {"must ", PriorityHigh},
+	{"if possible", PriorityLow}, {"if you can", PriorityLow}, {"when you can", PriorityLow},
+	{"ideally", PriorityLow}, {"where possible", PriorityLow}, {"not a big deal", PriorityLow},
+}
+
+// ParsePriority returns the priority a preference's wording names
+// ("it's important that you cite sources" → high), PriorityNormal if none.
+func ParsePriority(text string) int {
+	lower := strings.ToLower(text)
+	for _, pp := range priorityPhrases {
+		if strings.Contains(lower, pp.phrase) {
+			return pp.priority
+		}
+	}
+	return PriorityNormal
+}
+
+// expiryFor matches "for the next 3 hours", "for 2 days", "for an hour".
+var expiryFor = regexp.MustCompile(`\bfor (?:the next )?(\d+|an?|one) (minute|hour|day|week)s?\b`)
+
+// ParseExpiry returns when a preference worded as temporary ends, or zero
+// for a standing one: "for today" and "until tomorrow" end at the next
+// midnight in now's location, "this week" after seven days, and "for the next
+// N hours" (minutes, days, weeks) after that long.
+func ParseExpiry(text string, now time.Time) time.Time {
+	lower := strings.ToLower(text)
+	for _, phrase := range []string{"for today", "for the rest of the day", "just today", "until tomorrow", "for tonight"} {
+		if strings.Contains(lower, phrase) {
+			y, m, d := now.Date()
+			return time.Date(y, m, d+1, 0, 0, 0, 0, now.Location())
+		}
+	}
+	for _, phrase := range []string{"this week", "for the week"} {
+		if strings.Contains(lower, phrase) {
+			return now.AddDate(0, 0, 7)
+		}
+	}
+	if m := expiryFor.FindStringSubmatch(lower); m != nil {
+		n, err := strconv.Atoi(m[1])
+		if err != nil {
+			n = 1 // "an", "a", "one"
+		}
+		unit := map[string]time.Duration{"minute": time.Minute, "hour": time.Hour, "day": 24 * time.Hour, "week": 7 * 24 * time.Hour}[m[2]]
+		if n > 0 {
+			return now.Add(time.Duration(n) * unit)
+		}
+	}
+	return time.Time{}
+}
+
+// OptionsFor infers a newly taught preference's options from its wording and
+// the recent turn contexts (see ScopeFor).
+func OptionsFor(text string, recentContexts []string, now time.Time) PreferenceOptions {
+	return PreferenceOptions{
+		Scope:     ScopeFor(text, recentContexts),
+		Priority:  ParsePriority(text),
+		ExpiresAt: ParseExpiry(text, now),
+	}
+}
+
+// #endregion priority-inference
+
+// #region priority-order
+
+// ByPriority orders prefs highest priority first, keeping the given order
+// between equals.
+func ByPriority(prefs []Preference) []Preference {
+	out := append([]Preference(nil), prefs...)
+	sort.SliceStable(out, func(i, j int) bool { return priorityOf(out[i]) > priorityOf(out[j]) })
+	return out
+}
+
+func priorityOf(p Preference) int {
+	if p.Priority == 0 {
+		return PriorityNormal
+	}
+	return p.Priority
+}
+
+// #endregion priority-order
@@ -0,0 +1,122 @@
+package projection
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParsePriority(t *testing.T) {
+	tests := []struct {
+		text string
+		want int
+	}{
+		{"It's important that you cite sources", PriorityHigh},
+		{"Above all, keep it short", PriorityHigh},
+		{"Use metric units if possible", PriorityLow},
+		{"Ideally include an example", PriorityLow},
+		{"Keep it short", PriorityNormal},
+	}
+	for _, tt := range tests {
+		if got := ParsePriority(tt.text); got != tt.want {
+			t.Errorf("ParsePriority(%q) = %d, want %d", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestParseExpiry(t *testing.T) {
+	now := time.Date(2026, 3, 4, 15, 30, 0, 0, time.UTC)
+	midnight := time.Date(2026, 3, 5, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		text string
+		want time.Time
+	}{
+		{"Keep it short for today", midnight},
+		{"Be detailed until tomorrow", midnight},
+		{"Skip the examples this week", now.AddDate(0, 0, 7)},
+		{"Be terse for the next 3 hours", now.Add(3 * time.Hour)},
+		{"Be terse for an hour", now.Add(time.Hour)},
+		{"Answer in bullets for 2 days", now.Add(48 * time.Hour)},
+		{"Keep it short", time.Time{}},
+	}
+	for _, tt := range tests {
+		if got := ParseExpiry(tt.text, now); !got.Equal(tt.want) {
+			t.Errorf("ParseExpiry(%q) = %v, want %v", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestScopedTo_Priority(t *testing.T) {
+	prefs := []Preference{
+		{ID: 1, Text: "Be concise above all", Style: StyleConcise, Priority: PriorityHigh},
+		{ID: 2, Text: "Be detailed when writing", Style: StyleDetailed, Scope: ScopeWriting},
+		{ID: 3, Text: "Show examples", Style: StyleExamples, Priority: PriorityLow},
+		{ID: 4, Text: "No examples for today", Style: StyleExamples, Priority: PriorityLow, ExpiresAt: time.Now().Add(time.Hour)},
+	}
+	got := ScopedTo(prefs, ScopeWriting)
+	// High-priority global concise beats the normal scoped detailed; the
+	// temporary examples preference beats the standing one
+	if len(got) != 2 || got[0].ID != 1 || got[1].ID != 4 {
+		t.Errorf("ScopedTo = %+v, want ids [1 4]", got)
+	}
+}
+
+func TestProjectToPrompt_PriorityOrder(t *testing.T) {
+	prefs := []Preference{
+		{Text: "Use British spelling"},
+		{Text: "Cite sources", Priority: PriorityHigh},
+		{Text: "Add a summary", Priority: PriorityLow},
+	}
+	block := ProjectToPrompt(prefs, 1.0)
+	got := ProjectedPreferences(block)
+	if strings.Join(got, "|") != "Cite sources|Use British spelling|Add a summary" {
+		t.Errorf("projected order = %v", got)
+	}
+}
+
+func TestPreferenceStore_PriorityAndExpiry(t *testing.T) {
+	store, err := NewPreferenceStore(testDB(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Add("Be concise", "explicit"); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.AddWithOptions("Be detailed for today", "explicit", PreferenceOptions{
+		Priority: PriorityHigh, ExpiresAt: time.Now().Add(time.Hour),
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.AddWithOptions("Cite sources", "explicit", PreferenceOptions{ExpiresAt: time.Now().Add(-time.Minute)}); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.AddWithOptions("Cite", "explicit", PreferenceOptions{Priority: 7}); err == nil {
+		t.Error("unknown priority accepted")
+	}
+
+	prefs, err := store.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The expired one is gone; the temporary one sits beside the standing one it
+	// contradicts, first by priority
+	if len(prefs) != 2 || prefs[0].Text != "Be detailed for today" || prefs[1].Text != "Be concise" {
+		t.Fatalf("List = %+v", prefs)
+	}
+	if prefs[0].Priority != PriorityHigh || prefs[0].ExpiresAt.IsZero() || prefs[1].Priority != PriorityNormal {
+		t.Errorf("options not stored: %+v", prefs)
+	}
+	matched, err := store.Match(ScopeChat)
+	if err != nil || len(matched) != 1 || matched[0].Text != "Be detailed for today" {
+		t.Errorf("Match = %+v, %v", matched, err)
+	}
+
+	// Restating takes on the new options
+	if err := store.AddWithOptions("be concise", "explicit", PreferenceOptions{Priority: PriorityHigh}); err != nil {
+		t.Fatal(err)
+	}
+	prefs, _ = store.List()
+	if len(prefs) != 2 || prefs[0].Text != "Be concise" {
+		t.Errorf("restated preference not reprioritized: %+v", prefs)
+	}
+}
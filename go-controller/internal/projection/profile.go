@@ -0,0 +1,307 @@
+package projection
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/state"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/timestamp"
+)
+
+// #region profile-types
+
+// Profile fields. Identity facts are stated by the user, replace each other
+// rather than accumulating, and always project: unlike preferences they are
+// not weighted by the prefs segment norm and never go stale.
+const (
+	ProfileUserName      = "user_name"
+	ProfileUserPronouns  = "user_pronouns"
+	ProfileUserHonorific = "user_honorific"
+	ProfileAIDesignation = "ai_designation"
+)
+
+// ProfileFields lists every profile field in projection order.
+var ProfileFields = []string{ProfileUserName, ProfileUserPronouns, ProfileUserHonorific, ProfileAIDesignation}
+
+// ProfileField is one stored identity fact.
+type ProfileField struct {
+	Field     string
+	Value     string
+	Source    string // "explicit" | "legacy_preference" | "command"
+	UpdatedAt time.Time
+}
+
+// ProfileChange is one entry in a field's history. OldValue is "" when the
+// field was first set; NewValue is "" when it was cleared.
+type ProfileChange struct {
+	ID        int64
+	Field     string
+	OldValue  string
+	NewValue  string
+	Source    string
+	CreatedAt time.Time
+}
+
+// #endregion profile-types
+
+// #region profile-store
+
+// ProfileStore persists user identity and AI designation in SQLite, with a
+// history of every change.
+type ProfileStore struct {
+	db state.DBTX
+}
+
+// legacyIdentityPrefixes are the preference texts identity used to be stored as.
+var legacyIdentityPrefixes = map[string]string{
+	ProfileUserName:      "The user's name is ",
+	ProfileAIDesignation: "The AI's designation is ",
+}
+
+// NewProfileStore creates the profile tables if needed and returns a store.
+// Identity rows left in the preferences table by older versions are moved into
+// the profile the first time it opens.
+func NewProfileStore(db *sql.DB) (*ProfileStore, error) {
+	_, err := db.Exec(`CREATE TABLE IF NOT EXISTS profile (
+		field TEXT PRIMARY KEY,
+		value TEXT NOT NULL,
+		source TEXT NOT NULL DEFAULT 'explicit',
+		updated_at TEXT NOT NULL
+	)`)
+	if err != nil {
+		return nil, fmt.Errorf("create profile table: %w", err)
+	}
+	_, err = db.Exec(`CREATE TABLE IF NOT EXISTS profile_history (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		field TEXT NOT NULL,
+		old_value TEXT NOT NULL DEFAULT '',
+		new_value TEXT NOT NULL DEFAULT '',
+		source TEXT NOT NULL,
+		created_at TEXT NOT NULL
+	)`)
+	if err != nil {
+		return nil, fmt.Errorf("create profile_history table: %w", err)
+	}
+	if _, err := timestamp.Canonicalize(db, "profile", "updated_at"); err != nil {
+		return nil, err
+	}
+	if _, err := timestamp.Canonicalize(db, "profile_history", "created_at"); err != nil {
+		return nil, err
+	}
+	s := &ProfileStore{db: db}
+	if err := s.migrateLegacyPreferences(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// WithTx returns a copy of the store bound to tx, for writes that must land
+// in the same transaction as other profile writes.
+func (s *ProfileStore) WithTx(tx *sql.Tx) *ProfileStore {
+	return &ProfileStore{db: tx}
+}
+
+// migrateLegacyPreferences moves "The user's name is X" / "The AI's designation
+// is X" preferences into the profile (the newest wins) and deletes them.
+// A missing preferences table means there is nothing to migrate.
+func (s *ProfileStore) migrateLegacyPreferences() error {
+	var exists int
+	if err := s.db.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'preferences'`).Scan(&exists); err != nil {
+		return fmt.Errorf("check preferences table: %w", err)
+	}
+	if exists == 0 {
+		return nil
+	}
+	for _, field := range ProfileFields {
+		prefix, ok := legacyIdentityPrefixes[field]
+		if !ok {
+			continue
+		}
+		var text string
+		err := s.db.QueryRow(`SELECT text FROM preferences WHERE text LIKE ? || '%' ORDER BY created_at DESC, id DESC LIMIT 1`,
+			prefix).Scan(&text)
+		if err == sql.ErrNoRows {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("find legacy %s preference: %w", field, err)
+		}
+		if current, _ := s.Get(); current[field].Value == "" {
+			if err := s.Set(field, strings.TrimSpace(strings.TrimPrefix(text, prefix)), "legacy_preference"); err != nil {
+				return err
+			}
+		}
+		if _, err := s.db.Exec(`DELETE FROM preferences WHERE text LIKE ? || '%'`, prefix); err != nil {
+			return fmt.Errorf("remove legacy %s preference: %w", field, err)
+		}
+	}
+	return nil
+}
+
+// Set stores value for field and records the change. Setting the current value
+// again is a no-op; an empty value clears the field.
+func (s *ProfileStore) Set(field, value, source string) error {
+	if !isProfileField(field) {
+		return fmt.Errorf("unknown profile field %q", field)
+	}
+	value = strings.TrimSpace(value)
+	profile, err := s.Get()
+	if err != nil {
+		return err
+	}
+	old := profile[field].Value
+	if old == value {
+		return nil
+	}
+	now := timestamp.Now()
+	if value == "" {
+		_, err = s.db.Exec(`DELETE FROM profile WHERE field = ?`, field)
+	} else {
+		_, err = s.db.Exec(`INSERT INTO profile (field, value, source, updated_at) VALUES (?, ?, ?, ?)
+			ON CONFLICT(field) DO UPDATE SET value = excluded.value, source = excluded.source, updated_at = excluded.updated_at`,
+			field, value, source, now)
+	}
+	if err != nil {
+		return fmt.Errorf("set profile %s: %w", field, err)
+	}
+	if _, err := s.db.Exec(`INSERT INTO profile_history (field, old_value, new_value, source, created_at) VALUES (?, ?, ?, ?, ?)`,
+		field, old, value, source, now); err != nil {
+		return fmt.Errorf("log profile change: %w", err)
+	}
+	return nil
+}
+
+// Clear removes field, recording the change.
+func (s *ProfileStore) Clear(field, source string) error {
+	return s.Set(field, "", source)
+}
+
+// Get returns the profile keyed by field name.
+func (s *ProfileStore) Get() (map[string]ProfileField, error) {
+	rows, err := s.db.Query(`SELECT field, value, source, updated_at FROM profile`)
+	if err != nil {
+		return nil, fmt.Errorf("get profile: %w", err)
+	}
+	defer rows.Close()
+
+	profile := make(map[string]ProfileField)
+	for rows.Next() {
+		var f ProfileField
+		var ts string
+		if err := rows.Scan(&f.Field, &f.Value, &f.Source, &ts); err != nil {
+			return nil, fmt.Errorf("scan profile field: %w", err)
+		}
+		f.UpdatedAt, _ = timestamp.Parse(ts)
+		profile[f.Field] = f
+	}
+	return profile, rows.Err()
+}
+
+// History returns up to limit changes, newest first. An empty field returns
+// changes to every field.
+func (s *ProfileStore) History(field string, limit int) ([]ProfileChange, error) {
+	rows, err := s.db.Query(`SELECT id, field, old_value, new_value, source, created_at FROM profile_history
+		WHERE ? = '' OR field = ? ORDER BY id DESC LIMIT ?`, field, field, limit)
+	if err != nil {
+		return nil, fmt.Errorf("profile history: %w", err)
+	}
+	defer rows.Close()
+
+	var out []ProfileChange
+	for rows.Next() {
+		var c ProfileChange
+		var ts string
+		if err := rows.Scan(&c.ID, &c.Field, &c.OldValue, &c.NewValue, &c.Source, &ts); err != nil {
+			return nil, fmt.Errorf("scan profile change: %w", err)
+		}
+		c.CreatedAt, _ = timestamp.Parse(ts)
+		out = append(out, c)
+	}
+	return out, rows.Err()
+}
+
+func isProfileField(field string) bool {
+	for _, f := range ProfileFields {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
+
+// #endregion profile-store
+
+// #region profile-detect
+
+// pronounWords are the forms a pronoun set starts with ("she/her", "they/them", "any").
+var pronounWords = map[string]bool{
+	"he": true, "she": true, "they": true, "xe": true, "ze": true, "it": true, "any": true,
+}
+
+// DetectPronouns checks for a pronoun statement like "my pronouns are she/her"
+// or "I use they/them". Returns the pronouns and true if detected.
+func DetectPronouns(prompt string) (string, bool) {
+	lower := strings.ToLower(strings.TrimSpace(prompt))
+	for _, prefix := range []string{"my pronouns are ", "i use ", "i go by "} {
+		if !strings.HasPrefix(lower, prefix) {
+			continue
+		}
+		rest := strings.TrimRight(lower[len(prefix):], ".!?,; ")
+		explicit := prefix == "my pronouns are " || strings.HasSuffix(rest, " pronouns")
+		rest = strings.TrimSpace(strings.TrimSuffix(rest, " pronouns"))
+		first, _, paired := strings.Cut(rest, "/")
+		// "I use vim", "I go by Dan", "I use it" — only a recognised pronoun set counts
+		if !pronounWords[first] || !(paired || explicit) || len(strings.Fields(rest)) > 3 {
+			continue
+		}
+		return rest, true
+	}
+	return "", false
+}
+
+// DetectHonorific checks for a form-of-address statement like "address me as
+// Commander" or "refer to me as Dr. Reyes". Returns the honorific and true if detected.
+func DetectHonorific(prompt string) (string, bool) {
+	trimmed := strings.TrimSpace(prompt)
+	lower := strings.ToLower(trimmed)
+	for _, prefix := range []string{"address me as ", "please address me as ", "refer to me as "} {
+		if !strings.HasPrefix(lower, prefix) {
+			continue
+		}
+		h := strings.TrimRight(strings.TrimSpace(trimmed[len(prefix):]), ".!?,;")
+		if words := strings.Fields(h); len(words) > 0 && len(words) <= 4 {
+			return h, true
+		}
+	}
+	return "", false
+}
+
+// #endregion profile-detect
+
+// #region profile-project
+
+// ProjectProfile builds the [PROFILE] block from the stored identity facts.
+// Returns "" when the profile is empty.
+func ProjectProfile(profile map[string]ProfileField) string {
+	var lines []string
+	if f, ok := profile[ProfileUserName]; ok {
+		lines = append(lines, fmt.Sprintf("- The user's name is %s", f.Value))
+	}
+	if f, ok := profile[ProfileUserPronouns]; ok {
+		lines = append(lines, fmt.Sprintf("- The user's pronouns are %s", f.Value))
+	}
+	if f, ok := profile[ProfileUserHonorific]; ok {
+		lines = append(lines, fmt.Sprintf("- Address the user as %s", f.Value))
+	}
+	if f, ok := profile[ProfileAIDesignation]; ok {
+		lines = append(lines, fmt.Sprintf("- Your designation is %s", f.Value))
+	}
+	if len(lines) == 0 {
+		return ""
+	}
+	return "[PROFILE]\n" + strings.Join(lines, "\n") + "\n"
+}
+
+// #endregion profile-project
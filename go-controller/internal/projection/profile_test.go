@@ -0,0 +1,151 @@
+package projection
+
+import (
+	"strings"
+	"testing"
+)
+
+// #region profile-store-tests
+
+func TestProfileStore_SetReplacesAndRecordsHistory(t *testing.T) {
+	store, err := NewProfileStore(testDB(t))
+	if err != nil {
+		t.Fatalf("new profile store: %v", err)
+	}
+	for _, name := range []string{"Dana", "Dana", "Daniel"} {
+		if err := store.Set(ProfileUserName, name, "explicit"); err != nil {
+			t.Fatalf("set: %v", err)
+		}
+	}
+	profile, _ := store.Get()
+	if profile[ProfileUserName].Value != "Daniel" {
+		t.Fatalf("expected latest name, got %+v", profile)
+	}
+	history, err := store.History(ProfileUserName, 10)
+	if err != nil {
+		t.Fatalf("history: %v", err)
+	}
+	if len(history) != 2 {
+		t.Fatalf("restating the same value should not add history, got %d entries", len(history))
+	}
+	if history[0].OldValue != "Dana" || history[0].NewValue != "Daniel" || history[1].OldValue != "" {
+		t.Fatalf("unexpected history (newest first): %+v", history)
+	}
+}
+
+func TestProfileStore_Clear(t *testing.T) {
+	store, _ := NewProfileStore(testDB(t))
+	store.Set(ProfileUserPronouns, "they/them", "explicit")
+	if err := store.Clear(ProfileUserPronouns, "command"); err != nil {
+		t.Fatalf("clear: %v", err)
+	}
+	if profile, _ := store.Get(); len(profile) != 0 {
+		t.Fatalf("expected empty profile, got %+v", profile)
+	}
+	if history, _ := store.History("", 10); len(history) != 2 || history[0].NewValue != "" {
+		t.Fatalf("expected clear recorded in history, got %+v", history)
+	}
+	if err := store.Set("shoe_size", "9", "explicit"); err == nil {
+		t.Fatal("expected error for unknown field")
+	}
+}
+
+func TestNewProfileStore_MigratesLegacyPreferences(t *testing.T) {
+	db := testDB(t)
+	prefs, _ := NewPreferenceStore(db)
+	prefs.Add("Use one-line answers", "explicit")
+	prefs.Add("The user's name is Dana", "general")
+	prefs.Add("The AI's designation is Architect", "explicit")
+
+	store, err := NewProfileStore(db)
+	if err != nil {
+		t.Fatalf("new profile store: %v", err)
+	}
+	profile, _ := store.Get()
+	if profile[ProfileUserName].Value != "Dana" || profile[ProfileAIDesignation].Value != "Architect" {
+		t.Fatalf("expected identity migrated, got %+v", profile)
+	}
+	if profile[ProfileUserName].Source != "legacy_preference" {
+		t.Fatalf("expected legacy source, got %q", profile[ProfileUserName].Source)
+	}
+	remaining, _ := prefs.List()
+	if len(remaining) != 1 || remaining[0].Text != "Use one-line answers" {
+		t.Fatalf("expected identity rows removed from preferences, got %+v", remaining)
+	}
+
+	// Reopening is a no-op
+	if _, err := NewProfileStore(db); err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	if history, _ := store.History("", 10); len(history) != 2 {
+		t.Fatalf("expected migration recorded once, got %d entries", len(history))
+	}
+}
+
+// #endregion profile-store-tests
+
+// #region profile-detect-tests
+
+func TestDetectPronouns(t *testing.T) {
+	cases := []struct {
+		input  string
+		want   string
+		wantOK bool
+	}{
+		{"my pronouns are she/her", "she/her", true},
+		{"I use they/them", "they/them", true},
+		{"I go by he/him pronouns.", "he/him", true},
+		{"I use vim", "", false},
+		{"I go by Dan", "", false},
+		{"I use it", "", false},
+	}
+	for _, tc := range cases {
+		got, ok := DetectPronouns(tc.input)
+		if ok != tc.wantOK || got != tc.want {
+			t.Errorf("DetectPronouns(%q) = %q, %v; want %q, %v", tc.input, got, ok, tc.want, tc.wantOK)
+		}
+	}
+}
+
+func TestDetectHonorific(t *testing.T) {
+	cases := []struct {
+		input  string
+		want   string
+		wantOK bool
+	}{
+		{"Address me as Commander", "Commander", true},
+		{"refer to me as Dr. Reyes.", "Dr. Reyes", true},
+		{"address me as", "", false},
+		{"tell me about addresses", "", false},
+	}
+	for _, tc := range cases {
+		got, ok := DetectHonorific(tc.input)
+		if ok != tc.wantOK || got != tc.want {
+			t.Errorf("DetectHonorific(%q) = %q, %v; want %q, %v", tc.input, got, ok, tc.want, tc.wantOK)
+		}
+	}
+}
+
+// #endregion profile-detect-tests
+
+// #region profile-project-tests
+
+func TestProjectProfile(t *testing.T) {
+	if got := ProjectProfile(nil); got != "" {
+		t.Fatalf("empty profile should project nothing, got %q", got)
+	}
+	block := ProjectProfile(map[string]ProfileField{
+		ProfileAIDesignation: {Value: "Orac"},
+		ProfileUserName:      {Value: "Dana"},
+		ProfileUserPronouns:  {Value: "they/them"},
+	})
+	want := "[PROFILE]\n- The user's name is Dana\n- The user's pronouns are they/them\n- Your designation is Orac\n"
+	if block != want {
+		t.Fatalf("ProjectProfile =\n%s\nwant\n%s", block, want)
+	}
+	if strings.Contains(block, "Address the user") {
+		t.Fatal("unset honorific should not project")
+	}
+}
+
+// #endregion profile-project-tests
@@ -4,8 +4,12 @@ import (
 	"database/sql"
 	"fmt"
 	"math"
+	"sort"
 	"strings"
 	"time"
+
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/state"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/timestamp"
 )
 
 // #region types
@@ -27,6 +31,16 @@ type Preference struct {
 	Style     PreferenceStyle
 	Source    string // "explicit" | "correction" | "inferred"
 	CreatedAt time.Time
+
+	// Aging policy: restating or confirming a preference reinforces it
+	LastReinforcedAt time.Time // zero = never reinforced since creation
+	AskedAt          time.Time // last staleness check-in; zero = not asked
+
+	// Turn context the preference is limited to (ScopeCoding, ...); "" = every turn
+	Scope string
+
+	Priority  int       // PriorityLow..PriorityHigh; higher projects first
+	ExpiresAt time.Time // zero = never expires
 }
 
 // #endregion types
@@ -35,7 +49,7 @@ type Preference struct {
 
 // PreferenceStore manages persistent user preferences in SQLite.
 type PreferenceStore struct {
-	db *sql.DB
+	db state.DBTX
 }
 
 // NewPreferenceStore creates the preferences table if needed and returns a store.
@@ -45,53 +59,152 @@ func NewPreferenceStore(db *sql.DB) (*PreferenceStore, error) {
 		text TEXT NOT NULL,
 		style TEXT NOT NULL DEFAULT 'general',
 		source TEXT NOT NULL DEFAULT 'explicit',
-		created_at DATETIME NOT NULL
+		created_at DATETIME NOT NULL,
+		status TEXT NOT NULL DEFAULT 'active',
+		last_reinforced_at DATETIME,
+		asked_at DATETIME,
+		scope TEXT NOT NULL DEFAULT '',
+		priority INTEGER NOT NULL DEFAULT 2,
+		expires_at TEXT
 	)`)
 	if err != nil {
 		return nil, fmt.Errorf("create preferences table: %w", err)
 	}
 	// Migrate: add style column if missing (pre-existing tables lack it)
 	_, _ = db.Exec(`ALTER TABLE preferences ADD COLUMN style TEXT NOT NULL DEFAULT 'general'`)
+	// Migrate: aging-policy columns
+	_, _ = db.Exec(`ALTER TABLE preferences ADD COLUMN status TEXT NOT NULL DEFAULT 'active'`)
+	_, _ = db.Exec(`ALTER TABLE preferences ADD COLUMN last_reinforced_at DATETIME`)
+	_, _ = db.Exec(`ALTER TABLE preferences ADD COLUMN asked_at DATETIME`)
+	// Migrate: context scope (existing preferences apply everywhere)
+	_, _ = db.Exec(`ALTER TABLE preferences ADD COLUMN scope TEXT NOT NULL DEFAULT ''`)
+	// Migrate: priority and expiry (existing preferences are normal and standing)
+	_, _ = db.Exec(`ALTER TABLE preferences ADD COLUMN priority INTEGER NOT NULL DEFAULT 2`)
+	_, _ = db.Exec(`ALTER TABLE preferences ADD COLUMN expires_at TEXT`)
+	_, err = db.Exec(`CREATE TABLE IF NOT EXISTS preference_events (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		preference_id INTEGER NOT NULL,
+		event TEXT NOT NULL,
+		created_at DATETIME NOT NULL
+	)`)
+	if err != nil {
+		return nil, fmt.Errorf("create preference_events table: %w", err)
+	}
+	// Replaced and deleted preferences, kept so /prefs can restore them
+	_, err = db.Exec(`CREATE TABLE IF NOT EXISTS preference_history (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		preference_id INTEGER NOT NULL,
+		text TEXT NOT NULL,
+		style TEXT NOT NULL,
+		source TEXT NOT NULL,
+		scope TEXT NOT NULL DEFAULT '',
+		priority INTEGER NOT NULL DEFAULT 2,
+		expires_at TEXT,
+		created_at DATETIME NOT NULL,
+		reason TEXT NOT NULL,
+		replaced_by INTEGER,
+		removed_at DATETIME NOT NULL,
+		restored_at DATETIME
+	)`)
+	if err != nil {
+		return nil, fmt.Errorf("create preference_history table: %w", err)
+	}
+	if _, err := timestamp.Canonicalize(db, "preferences", "created_at", "last_reinforced_at", "asked_at", "expires_at"); err != nil {
+		return nil, err
+	}
+	if _, err := timestamp.Canonicalize(db, "preference_events", "created_at"); err != nil {
+		return nil, err
+	}
+	if _, err := timestamp.Canonicalize(db, "preference_history", "expires_at", "created_at", "removed_at", "restored_at"); err != nil {
+		return nil, err
+	}
 	return &PreferenceStore{db: db}, nil
 }
 
-// Add stores a new preference. Infers style from text.
+// WithTx returns a copy of the store bound to tx, for writes that must land
+// in the same transaction as other profile writes.
+func (s *PreferenceStore) WithTx(tx *sql.Tx) *PreferenceStore {
+	return &PreferenceStore{db: tx}
+}
+
+// Add stores a new preference that applies to every turn. Infers style from text.
 // Contradiction handling: if a new preference has the same style as an existing one
 // (and the style is not "general"), the old one is replaced.
 func (s *PreferenceStore) Add(text, source string) error {
+	return s.AddScoped(text, source, ScopeAll)
+}
+
+// AddScoped stores a preference limited to one turn context (see TurnContext).
+// Duplicates and contradictions are resolved within the scope, so "be terse"
+// for coding and "be detailed" for writing coexist.
+func (s *PreferenceStore) AddScoped(text, source, scope string) error {
+	return s.AddWithOptions(text, source, PreferenceOptions{Scope: scope, Priority: PriorityNormal})
+}
+
+// AddWithOptions stores a preference with a scope, priority and optional
+// expiry. Restating a preference reinforces it and takes on the new priority
+// and expiry. A temporary preference does not replace standing ones of the
+// same style; it outranks them (see ScopedTo) until it expires.
+func (s *PreferenceStore) AddWithOptions(text, source string, opt PreferenceOptions) error {
+	scope := opt.Scope
+	if !IsScope(scope) {
+		return fmt.Errorf("unknown preference scope %q", scope)
+	}
+	if opt.Priority == 0 {
+		opt.Priority = PriorityNormal
+	}
+	if !IsPriority(opt.Priority) {
+		return fmt.Errorf("unknown preference priority %d", opt.Priority)
+	}
+	var expires interface{}
+	if !opt.ExpiresAt.IsZero() {
+		expires = timestamp.Format(opt.ExpiresAt)
+	}
 	style := InferStyle(text)
 
 	// Exact duplicate check (case-insensitive)
 	var count int
-	err := s.db.QueryRow("SELECT COUNT(*) FROM preferences WHERE LOWER(text) = LOWER(?)", text).Scan(&count)
+	err := s.db.QueryRow("SELECT COUNT(*) FROM preferences WHERE LOWER(text) = LOWER(?) AND scope = ?", text, scope).Scan(&count)
 	if err != nil {
 		return fmt.Errorf("check duplicate preference: %w", err)
 	}
 	if count > 0 {
-		return nil
+		// Restating a preference reinforces it (and revives it if retired)
+		if _, err := s.db.Exec("UPDATE preferences SET priority = ?, expires_at = ? WHERE LOWER(text) = LOWER(?) AND scope = ?",
+			opt.Priority, expires, text, scope); err != nil {
+			return fmt.Errorf("update preference options: %w", err)
+		}
+		return s.reinforceText(text, scope)
 	}
 
 	// Contradiction handling: replace existing preference of same non-general style
-	if style != StyleGeneral {
-		_, err = s.db.Exec("DELETE FROM preferences WHERE style = ?", string(style))
-		if err != nil {
-			return fmt.Errorf("remove contradicting preference: %w", err)
-		}
+	// (a temporary one only replaces other temporary ones)
+	contradicted, err := s.contradicted(style, scope, expires != nil)
+	if err != nil {
+		return err
 	}
 
-	_, err = s.db.Exec(
-		"INSERT INTO preferences (text, style, source, created_at) VALUES (?, ?, ?, ?)",
-		text, string(style), source, time.Now().UTC(),
+	res, err := s.db.Exec(
+		"INSERT INTO preferences (text, style, source, created_at, scope, priority, expires_at) VALUES (?, ?, ?, ?, ?, ?, ?)",
+		text, string(style), source, timestamp.Now(), scope, opt.Priority, expires,
 	)
 	if err != nil {
 		return fmt.Errorf("insert preference: %w", err)
 	}
-	return nil
+	id, _ := res.LastInsertId()
+	if err := s.logEvent(id, PrefEventCreated); err != nil {
+		return err
+	}
+	// The replaced ones go to preference_history, restorable with /prefs
+	return s.remove(contradicted, PrefRemovedReplaced, id)
 }
 
-// List returns all stored preferences.
+// List returns all active (non-retired, unexpired) preferences ordered by
+// priority (highest first), then creation time.
 func (s *PreferenceStore) List() ([]Preference, error) {
-	rows, err := s.db.Query("SELECT id, text, style, source, created_at FROM preferences ORDER BY created_at")
+	rows, err := s.db.Query(`SELECT id, text, style, source, created_at, last_reinforced_at, asked_at, scope, priority, expires_at
+		FROM preferences WHERE status != 'retired' AND (expires_at IS NULL OR expires_at > ?)
+		ORDER BY priority DESC, created_at`, timestamp.Now())
 	if err != nil {
 		return nil, fmt.Errorf("list preferences: %w", err)
 	}
@@ -101,19 +214,34 @@ func (s *PreferenceStore) List() ([]Preference, error) {
 	for rows.Next() {
 		var p Preference
 		var ts, style string
-		if err := rows.Scan(&p.ID, &p.Text, &style, &p.Source, &ts); err != nil {
+		var reinforced, asked, expires sql.NullString
+		if err := rows.Scan(&p.ID, &p.Text, &style, &p.Source, &ts, &reinforced, &asked, &p.Scope, &p.Priority, &expires); err != nil {
 			return nil, fmt.Errorf("scan preference: %w", err)
 		}
 		p.Style = PreferenceStyle(style)
-		p.CreatedAt, _ = time.Parse(time.RFC3339, ts)
+		p.CreatedAt, _ = timestamp.Parse(ts)
+		if reinforced.Valid {
+			p.LastReinforcedAt, _ = timestamp.Parse(reinforced.String)
+		}
+		if asked.Valid {
+			p.AskedAt, _ = timestamp.Parse(asked.String)
+		}
+		if expires.Valid {
+			p.ExpiresAt, _ = timestamp.Parse(expires.String)
+		}
 		prefs = append(prefs, p)
 	}
 	return prefs, nil
 }
 
-// DeleteByPrefix removes all preferences whose text starts with the given prefix (case-insensitive).
-func (s *PreferenceStore) DeleteByPrefix(prefix string) {
-	_, _ = s.db.Exec("DELETE FROM preferences WHERE LOWER(text) LIKE LOWER(?) || '%'", prefix)
+// Match returns the active preferences that apply in context (a TurnContext),
+// with scoped ones overriding global ones as in ScopedTo.
+func (s *PreferenceStore) Match(context string) ([]Preference, error) {
+	prefs, err := s.List()
+	if err != nil {
+		return nil, err
+	}
+	return ScopedTo(prefs, context), nil
 }
 
 // #endregion store
@@ -128,6 +256,18 @@ type Rule struct {
 	Priority   int
 	Confidence float64
 	CreatedAt  time.Time
+	ExpiresAt  time.Time // zero = never expires
+	// Usage: times matched, when last matched (zero = never), and turns since
+	// then (or since it was added), which drive confidence decay
+	FiredCount  int
+	LastFiredAt time.Time
+	IdleTurns   int
+	// Set by Match for a template or regex trigger: the input it matched and
+	// the captured values by name ({service}, (?P<env>...)) and group number
+	Matched  string
+	Captures map[string]string
+	// Exchanges that follow this rule's response, making it a script
+	Steps []ScriptStep
 }
 
 // #endregion rule-types
@@ -136,7 +276,7 @@ type Rule struct {
 
 // RuleStore manages persistent behavioral rules in SQLite.
 type RuleStore struct {
-	db *sql.DB
+	db state.DBTX
 }
 
 // NewRuleStore creates the rules table if needed and returns a store.
@@ -147,64 +287,175 @@ func NewRuleStore(db *sql.DB) (*RuleStore, error) {
 		response TEXT NOT NULL,
 		priority INTEGER NOT NULL DEFAULT 5,
 		confidence REAL NOT NULL DEFAULT 1.0,
-		created_at DATETIME NOT NULL
+		created_at DATETIME NOT NULL,
+		expires_at TEXT,
+		fired_count INTEGER NOT NULL DEFAULT 0,
+		last_fired_at TEXT,
+		idle_turns INTEGER NOT NULL DEFAULT 0
 	)`)
 	if err != nil {
 		return nil, fmt.Errorf("create rules table: %w", err)
 	}
+	// Migrate: add expires_at and usage columns if missing (pre-existing tables lack them)
+	_, _ = db.Exec(`ALTER TABLE rules ADD COLUMN expires_at TEXT`)
+	_, _ = db.Exec(`ALTER TABLE rules ADD COLUMN fired_count INTEGER NOT NULL DEFAULT 0`)
+	_, _ = db.Exec(`ALTER TABLE rules ADD COLUMN last_fired_at TEXT`)
+	_, _ = db.Exec(`ALTER TABLE rules ADD COLUMN idle_turns INTEGER NOT NULL DEFAULT 0`)
+	// Rules pruned for expiry or decay, kept for the record
+	_, err = db.Exec(`CREATE TABLE IF NOT EXISTS pruned_rules (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		rule_id INTEGER NOT NULL,
+		trigger TEXT NOT NULL,
+		response TEXT NOT NULL,
+		confidence REAL NOT NULL,
+		fired_count INTEGER NOT NULL,
+		last_fired_at TEXT,
+		created_at TEXT NOT NULL,
+		reason TEXT NOT NULL,
+		pruned_at TEXT NOT NULL
+	)`)
+	if err != nil {
+		return nil, fmt.Errorf("create pruned_rules table: %w", err)
+	}
+	// Script steps, in order, of the rules that open a multi-turn script
+	_, err = db.Exec(`CREATE TABLE IF NOT EXISTS rule_steps (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		rule_id INTEGER NOT NULL,
+		position INTEGER NOT NULL,
+		expect TEXT NOT NULL,
+		response TEXT NOT NULL
+	)`)
+	if err != nil {
+		return nil, fmt.Errorf("create rule_steps table: %w", err)
+	}
+	if _, err := timestamp.Canonicalize(db, "rules", "created_at", "expires_at", "last_fired_at"); err != nil {
+		return nil, err
+	}
+	if _, err := timestamp.Canonicalize(db, "pruned_rules", "last_fired_at", "created_at", "pruned_at"); err != nil {
+		return nil, err
+	}
 	return &RuleStore{db: db}, nil
 }
 
+// WithTx returns a copy of the store bound to tx, for writes that must land
+// in the same transaction as other profile writes.
+func (s *RuleStore) WithTx(tx *sql.Tx) *RuleStore {
+	return &RuleStore{db: tx}
+}
+
 // Add stores a new behavioral rule. Replaces existing rule with same trigger (case-insensitive).
 func (s *RuleStore) Add(trigger, response string, priority int, confidence float64) error {
+	return s.AddWithExpiry(trigger, response, priority, confidence, time.Time{})
+}
+
+// AddWithExpiry is Add with an expiry time. A zero expiresAt means the rule never expires.
+func (s *RuleStore) AddWithExpiry(trigger, response string, priority int, confidence float64, expiresAt time.Time) error {
+	_, err := s.add(trigger, response, priority, confidence, expiresAt)
+	return err
+}
+
+// add replaces the rule with trigger's normalized form and returns the new
+// rule's ID.
+func (s *RuleStore) add(trigger, response string, priority int, confidence float64, expiresAt time.Time) (int64, error) {
 	trigger = strings.TrimSpace(trigger)
 	response = strings.TrimSpace(response)
 	if trigger == "" || response == "" {
-		return fmt.Errorf("rule trigger and response must be non-empty")
+		return 0, fmt.Errorf("rule trigger and response must be non-empty")
+	}
+	if err := ValidateTrigger(trigger); err != nil {
+		return 0, fmt.Errorf("rule trigger %q: %w", trigger, err)
 	}
 
 	// Replace existing rule with same trigger (case-insensitive)
 	_, err := s.db.Exec("DELETE FROM rules WHERE LOWER(trigger) = LOWER(?)", trigger)
 	if err != nil {
-		return fmt.Errorf("remove existing rule: %w", err)
+		return 0, fmt.Errorf("remove existing rule: %w", err)
+	}
+	if err := s.dropOrphanSteps(); err != nil {
+		return 0, err
 	}
 
-	_, err = s.db.Exec(
-		"INSERT INTO rules (trigger, response, priority, confidence, created_at) VALUES (?, ?, ?, ?, ?)",
-		trigger, response, priority, confidence, time.Now().UTC(),
+	var expires interface{}
+	if !expiresAt.IsZero() {
+		expires = timestamp.Format(expiresAt)
+	}
+	res, err := s.db.Exec(
+		"INSERT INTO rules (trigger, response, priority, confidence, created_at, expires_at) VALUES (?, ?, ?, ?, ?, ?)",
+		trigger, response, priority, confidence, timestamp.Now(), expires,
 	)
 	if err != nil {
-		return fmt.Errorf("insert rule: %w", err)
+		return 0, fmt.Errorf("insert rule: %w", err)
+	}
+	return res.LastInsertId()
+}
+
+// Remove deletes the rule with this trigger (case-insensitive). Removing a
+// rule that does not exist is not an error.
+func (s *RuleStore) Remove(trigger string) error {
+	if _, err := s.db.Exec("DELETE FROM rules WHERE LOWER(trigger) = LOWER(?)", strings.TrimSpace(trigger)); err != nil {
+		return fmt.Errorf("remove rule: %w", err)
 	}
-	return nil
+	return s.dropOrphanSteps()
 }
 
-// List returns all stored rules ordered by priority (highest first), then creation time.
+// List returns all unexpired rules ordered by priority (highest first), then
+// creation time, with their script steps.
 func (s *RuleStore) List() ([]Rule, error) {
-	rows, err := s.db.Query("SELECT id, trigger, response, priority, confidence, created_at FROM rules ORDER BY priority DESC, created_at")
+	rows, err := s.db.Query(
+		"SELECT id, trigger, response, priority, confidence, created_at, expires_at, fired_count, last_fired_at, idle_turns FROM rules WHERE expires_at IS NULL OR expires_at > ? ORDER BY priority DESC, created_at",
+		timestamp.Now(),
+	)
 	if err != nil {
 		return nil, fmt.Errorf("list rules: %w", err)
 	}
-	defer rows.Close()
 
 	var rules []Rule
 	for rows.Next() {
 		var r Rule
 		var ts string
-		if err := rows.Scan(&r.ID, &r.Trigger, &r.Response, &r.Priority, &r.Confidence, &ts); err != nil {
+		var expires, fired sql.NullString
+		if err := rows.Scan(&r.ID, &r.Trigger, &r.Response, &r.Priority, &r.Confidence, &ts, &expires,
+			&r.FiredCount, &fired, &r.IdleTurns); err != nil {
+			rows.Close()
 			return nil, fmt.Errorf("scan rule: %w", err)
 		}
-		r.CreatedAt, _ = time.Parse(time.RFC3339, ts)
+		r.CreatedAt, _ = timestamp.Parse(ts)
+		if expires.Valid {
+			r.ExpiresAt, _ = timestamp.Parse(expires.String)
+		}
+		if fired.Valid {
+			r.LastFiredAt, _ = timestamp.Parse(fired.String)
+		}
 		rules = append(rules, r)
 	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("list rules: %w", err)
+	}
+	// Loaded after the rows are closed: the query holds the connection
+	if err := s.attachSteps(rules); err != nil {
+		return nil, err
+	}
 	return rules, nil
 }
 
-// Match returns all rules whose trigger matches the input (case-insensitive substring match).
-// Returns matches ordered by priority (highest first).
+// Match returns the rule that fires for input: of the rules MatchAll finds,
+// the first. Returns nil if no rule matches.
 func (s *RuleStore) Match(input string) ([]Rule, error) {
-	lower := strings.ToLower(strings.TrimSpace(input))
-	if lower == "" {
+	matched, err := s.MatchAll(input)
+	if len(matched) > 1 {
+		matched = matched[:1]
+	}
+	return matched, err
+}
+
+// MatchAll returns every rule whose trigger matches the input: exact triggers
+// case-insensitively, template and regex triggers with their captures set.
+// Matches are in conflict-resolution order (see sortMatches). A stored
+// trigger that no longer compiles is skipped.
+func (s *RuleStore) MatchAll(input string) ([]Rule, error) {
+	input = strings.TrimSpace(input)
+	if input == "" {
 		return nil, nil
 	}
 
@@ -215,10 +466,20 @@ func (s *RuleStore) Match(input string) ([]Rule, error) {
 
 	var matched []Rule
 	for _, r := range rules {
-		if strings.ToLower(r.Trigger) == lower {
-			matched = append(matched, r)
+		m, err := compileTrigger(r.Trigger)
+		if err != nil {
+			continue
 		}
+		captures, ok := m.match(r.Trigger, input)
+		if !ok {
+			continue
+		}
+		if m.re != nil {
+			r.Matched, r.Captures = input, captures
+		}
+		matched = append(matched, r)
 	}
+	sortMatches(matched)
 	return matched, nil
 }
 
@@ -279,18 +540,18 @@ var desireVerbs = map[string]bool{
 // behaviorVerbs are verbs that indicate AI behavior preferences when following "to".
 // "I'd like to respond concisely" = preference. "I'd like to give you a name" = request.
 var behaviorVerbs = map[string]bool{
-	"respond":      true,
-	"answer":       true,
-	"be":           true,
-	"use":          true,
-	"keep":         true,
-	"include":      true,
-	"explain":      true,
-	"provide":      true,
-	"write":        true,
-	"format":       true,
-	"speak":        true,
-	"communicate":  true,
+	"respond":     true,
+	"answer":      true,
+	"be":          true,
+	"use":         true,
+	"keep":        true,
+	"include":     true,
+	"explain":     true,
+	"provide":     true,
+	"write":       true,
+	"format":      true,
+	"speak":       true,
+	"communicate": true,
 }
 
 // isDesireToAction returns true if a desire-verb pattern ("i'd like", "i want", etc.)
@@ -367,30 +628,32 @@ func DetectPreference(prompt string) (string, bool) {
 	return "", false
 }
 
+// correctionPatterns mark a correction of the previous response.
+var correctionPatterns = []string{
+	"try again",
+	"that's wrong",
+	"that is wrong",
+	"that's not",
+	"that is not",
+	"not correct",
+	"incorrect",
+	"wrong ",
+	"nope",
+	"no,",
+	"no i meant",
+	"not what i",
+	"i said ",
+	"remember i said",
+	"like i said",
+	"as i said",
+	"i already said",
+	"i told you",
+}
+
 // DetectCorrection checks if a prompt is a correction of the previous response.
 // Returns true for phrases like "try again", "that's wrong", "no, I meant".
 func DetectCorrection(prompt string) bool {
 	lower := strings.ToLower(strings.TrimSpace(prompt))
-	correctionPatterns := []string{
-		"try again",
-		"that's wrong",
-		"that is wrong",
-		"that's not",
-		"that is not",
-		"not correct",
-		"incorrect",
-		"wrong ",
-		"nope",
-		"no,",
-		"no i meant",
-		"not what i",
-		"i said ",
-		"remember i said",
-		"like i said",
-		"as i said",
-		"i already said",
-		"i told you",
-	}
 	for _, pat := range correctionPatterns {
 		if strings.Contains(lower, pat) {
 			return true
@@ -399,6 +662,38 @@ func DetectCorrection(prompt string) bool {
 	return false
 }
 
+// CorrectedPreference returns the stored preference a correction refers to, if
+// any: one sharing the correction's inferred style (e.g. "too long, I said keep it
+// short" → a concise preference), else one at least half of whose significant
+// words (4+ letters) appear in the correction.
+func CorrectedPreference(prompt string, prefs []Preference) (Preference, bool) {
+	if style := InferStyle(prompt); style != StyleGeneral {
+		for _, p := range prefs {
+			if p.Style == style {
+				return p, true
+			}
+		}
+	}
+	lower := strings.ToLower(prompt)
+	for _, p := range prefs {
+		var words, hits int
+		for _, w := range strings.Fields(strings.ToLower(p.Text)) {
+			w = strings.Trim(w, ".,!?;:'\"")
+			if len(w) < 4 {
+				continue
+			}
+			words++
+			if strings.Contains(lower, w) {
+				hits++
+			}
+		}
+		if words > 0 && hits*2 >= words {
+			return p, true
+		}
+	}
+	return Preference{}, false
+}
+
 // nameStopwords are common first words that indicate a sentence, not a name.
 var nameStopwords = map[string]bool{
 	"glad": true, "sorry": true, "not": true, "sure": true, "just": true,
@@ -616,6 +911,126 @@ func clamp(v float32) float32 {
 
 // #endregion compliance
 
+// #region preview
+
+// drasticMarkers are absolute phrasings that make a preference hard to satisfy
+// on every turn and worth confirming before they are stored.
+var drasticMarkers = []string{
+	"never", "always", "only", "at all times", "under no circumstances",
+	"no more than", "not a single", "one sentence", "one word",
+}
+
+// opposingStyles maps a style to the style it contradicts.
+var opposingStyles = map[PreferenceStyle]PreferenceStyle{
+	StyleConcise:  StyleDetailed,
+	StyleDetailed: StyleConcise,
+}
+
+// PreferencePreview is the dry-run outcome of adding a candidate preference:
+// which stored preferences it collides with and how compliance (which drives
+// the prefs segment via SentimentScore) would shift over recent responses.
+type PreferencePreview struct {
+	Text             string
+	Style            PreferenceStyle
+	Scope            string       // turn context the candidate is limited to; "" = every turn
+	Drastic          bool         // absolute wording ("never", "always", "only", ...)
+	Conflicts        []Preference // stored preferences that would be replaced or contradicted
+	Turns            int          // recent responses simulated
+	ComplianceBefore float32      // mean compliance with current preferences
+	ComplianceAfter  float32      // mean compliance with the candidate applied
+}
+
+// ComplianceDelta returns the simulated change in mean compliance.
+func (p PreferencePreview) ComplianceDelta() float32 {
+	return p.ComplianceAfter - p.ComplianceBefore
+}
+
+// NeedsConfirmation reports whether the candidate should be confirmed by the user
+// before storing: drastic wording, a conflict, or a compliance drop of 0.2 or more.
+func (p PreferencePreview) NeedsConfirmation() bool {
+	return p.Drastic || len(p.Conflicts) > 0 || p.ComplianceDelta() <= -0.2
+}
+
+// Warning renders a user-facing summary of the preview findings.
+func (p PreferencePreview) Warning() string {
+	var lines []string
+	lines = append(lines, fmt.Sprintf("Before I store %q:", p.Text))
+	if p.Drastic && p.Scope != ScopeAll {
+		lines = append(lines, fmt.Sprintf("- It is worded as an absolute rule and will apply to every %s answer.", p.Scope))
+	} else if p.Drastic {
+		lines = append(lines, "- It is worded as an absolute rule and will apply to every answer.")
+	}
+	for _, c := range p.Conflicts {
+		if c.Style == p.Style {
+			lines = append(lines, fmt.Sprintf("- It would replace your existing preference %q.", c.Text))
+		} else {
+			lines = append(lines, fmt.Sprintf("- It contradicts your existing preference %q.", c.Text))
+		}
+	}
+	if p.Turns > 0 {
+		lines = append(lines, fmt.Sprintf("- Over the last %d responses, compliance would go from %.2f to %.2f (%+.2f).",
+			p.Turns, p.ComplianceBefore, p.ComplianceAfter, p.ComplianceDelta()))
+	}
+	lines = append(lines, "Reply /confirm to store it or /cancel to discard it.")
+	return strings.Join(lines, "\n")
+}
+
+// PreviewPreference simulates storing text against the existing preferences and
+// recent responses without touching the store. Same-style preferences are treated
+// as replaced (mirroring PreferenceStore.Add); opposing styles are also conflicts.
+func PreviewPreference(text string, existing []Preference, recentResponses []string) PreferencePreview {
+	return PreviewScopedPreference(text, ScopeAll, existing, recentResponses)
+}
+
+// PreviewScopedPreference is PreviewPreference for a preference limited to scope;
+// only preferences in the same scope can conflict with it.
+func PreviewScopedPreference(text, scope string, existing []Preference, recentResponses []string) PreferencePreview {
+	style := InferStyle(text)
+	preview := PreferencePreview{Text: text, Style: style, Scope: scope, Turns: len(recentResponses)}
+
+	lower := strings.ToLower(text)
+	for _, m := range drasticMarkers {
+		if strings.Contains(lower, m) {
+			preview.Drastic = true
+			break
+		}
+	}
+
+	after := []Preference{}
+	for _, p := range existing {
+		if p.Scope != scope {
+			after = append(after, p)
+			continue
+		}
+		if strings.EqualFold(p.Text, text) {
+			// Exact duplicate: Add is a no-op, nothing to preview
+			return PreferencePreview{Text: text, Style: style, Scope: scope}
+		}
+		if style != StyleGeneral && (p.Style == style || p.Style == opposingStyles[style]) {
+			preview.Conflicts = append(preview.Conflicts, p)
+			if p.Style == style {
+				continue
+			}
+		}
+		after = append(after, p)
+	}
+	after = append(after, Preference{Text: text, Style: style, Source: "explicit", Scope: scope})
+
+	if len(recentResponses) == 0 {
+		return preview
+	}
+	var before, simulated float32
+	for _, r := range recentResponses {
+		before += PreferenceComplianceScore(existing, r)
+		simulated += PreferenceComplianceScore(after, r)
+	}
+	preview.ComplianceBefore = before / float32(len(recentResponses))
+	preview.ComplianceAfter = simulated / float32(len(recentResponses))
+	return preview
+}
+
+// #endregion preview
+
 // #region rule-detect
 
 // rulePatterns matches phrases that teach conditional response behavior.
@@ -731,7 +1146,11 @@ func FormatRulesBlock(rules []Rule) string {
 	b.WriteString("[BEHAVIORAL RULES]\n")
 	b.WriteString("Follow these rules EXACTLY. They override all other behavior.\n")
 	for _, r := range rules {
-		b.WriteString(fmt.Sprintf("- If user says: %s → You respond with: %s\n", r.Trigger, r.Response))
+		trigger := r.Trigger
+		if r.Matched != "" {
+			trigger = r.Matched
+		}
+		b.WriteString(fmt.Sprintf("- If user says: %s → You respond with: %s\n", trigger, r.Response))
 	}
 	return b.String()
 }
@@ -749,16 +1168,9 @@ func ProjectToPrompt(preferences []Preference, prefsNorm float32) string {
 	}
 
 	// Confidence from prefs segment norm: 0 → no injection, >0.05 → inject
-	// Exception: identity preferences always project regardless of norm
+	// (identity lives in the profile store and projects separately via ProjectProfile)
 	confidence := float64(prefsNorm)
-	hasIdentity := false
-	for _, p := range preferences {
-		if strings.HasPrefix(p.Text, "The user's name is") || strings.HasPrefix(p.Text, "The AI's designation is") {
-			hasIdentity = true
-			break
-		}
-	}
-	if confidence < 0.05 && !hasIdentity {
+	if confidence < 0.05 {
 		return ""
 	}
 	// Cap confidence display at 1.0
@@ -768,7 +1180,7 @@ func ProjectToPrompt(preferences []Preference, prefsNorm float32) string {
 
 	var b strings.Builder
 	b.WriteString("[ADAPTIVE STATE]\n")
-	for _, p := range preferences {
+	for _, p := range ByPriority(preferences) {
 		b.WriteString(fmt.Sprintf("- %s\n", p.Text))
 	}
 	b.WriteString(fmt.Sprintf("(confidence: %.0f%%)\n", math.Round(confidence*100)))
@@ -784,4 +1196,48 @@ func WrapPrompt(stateBlock, prompt string) string {
 	return stateBlock + "\n[USER PROMPT]\n" + prompt
 }
 
+// ProjectedPreferences parses the preference lines back out of a block built by
+// ProjectToPrompt, so a logged state block shows what was active at that turn.
+func ProjectedPreferences(stateBlock string) []string {
+	var prefs []string
+	inBlock := false
+	for _, line := range strings.Split(stateBlock, "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "[ADAPTIVE STATE]":
+			inBlock = true
+		case inBlock && strings.HasPrefix(line, "- "):
+			prefs = append(prefs, strings.TrimSpace(strings.TrimPrefix(line, "- ")))
+		case inBlock && line != "":
+			inBlock = false
+		}
+	}
+	return prefs
+}
+
+// DominantPreferences returns up to n preferences that appear in the most state
+// blocks, ties going to the one seen first.
+func DominantPreferences(stateBlocks []string, n int) []string {
+	counts := map[string]int{}
+	var order []string
+	for _, block := range stateBlocks {
+		seen := map[string]bool{}
+		for _, p := range ProjectedPreferences(block) {
+			if seen[p] {
+				continue
+			}
+			seen[p] = true
+			if counts[p] == 0 {
+				order = append(order, p)
+			}
+			counts[p]++
+		}
+	}
+	sort.SliceStable(order, func(i, j int) bool { return counts[order[i]] > counts[order[j]] })
+	if len(order) > n {
+		order = order[:n]
+	}
+	return order
+}
+
 // #endregion project
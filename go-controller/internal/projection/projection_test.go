@@ -4,6 +4,7 @@ import (
 	"database/sql"
 	"strings"
 	"testing"
+	"time"
 
 	_ "modernc.org/sqlite"
 )
@@ -183,6 +184,22 @@ func TestDetectCorrection(t *testing.T) {
 	}
 }
 
+func TestCorrectedPreference(t *testing.T) {
+	prefs := []Preference{
+		{ID: 1, Text: "Use metric units", Style: StyleGeneral},
+		{ID: 2, Text: "Keep answers short", Style: StyleConcise},
+	}
+	if p, ok := CorrectedPreference("I told you to be brief", prefs); !ok || p.ID != 2 {
+		t.Errorf("expected style match on concise preference, got %+v ok=%v", p, ok)
+	}
+	if p, ok := CorrectedPreference("No, I said metric units!", prefs); !ok || p.ID != 1 {
+		t.Errorf("expected word-overlap match on metric preference, got %+v ok=%v", p, ok)
+	}
+	if _, ok := CorrectedPreference("that's wrong, the capital is Paris", prefs); ok {
+		t.Error("expected no match for unrelated correction")
+	}
+}
+
 func TestDetectIdentity(t *testing.T) {
 	cases := []struct {
 		input    string
@@ -428,6 +445,32 @@ func TestWrapPrompt_EmptyState(t *testing.T) {
 	}
 }
 
+func TestProjectedPreferences_RoundTrip(t *testing.T) {
+	prefs := []Preference{{Text: "I prefer short answers"}, {Text: "Always use examples"}}
+	block := ProjectProfile(map[string]ProfileField{ProfileUserName: {Field: ProfileUserName, Value: "Dana"}}) +
+		ProjectToPrompt(prefs, 0.3) + "\n" + FormatRulesBlock([]Rule{{Trigger: "ping", Response: "pong"}})
+	got := ProjectedPreferences(block)
+	if strings.Join(got, "|") != "I prefer short answers|Always use examples" {
+		t.Errorf("ProjectedPreferences = %q", got)
+	}
+	if got := ProjectedPreferences(""); got != nil {
+		t.Errorf("expected no preferences from an empty block, got %q", got)
+	}
+}
+
+func TestDominantPreferences(t *testing.T) {
+	blocks := []string{
+		ProjectToPrompt([]Preference{{Text: "Be concise"}, {Text: "Use examples"}}, 0.5),
+		ProjectToPrompt([]Preference{{Text: "Use examples"}}, 0.5),
+		ProjectToPrompt([]Preference{{Text: "Use British spelling"}, {Text: "Use examples"}}, 0.5),
+		"",
+	}
+	got := DominantPreferences(blocks, 2)
+	if strings.Join(got, "|") != "Use examples|Be concise" {
+		t.Errorf("DominantPreferences = %q", got)
+	}
+}
+
 // #endregion project-tests
 
 // #region rule-store-tests
@@ -547,6 +590,34 @@ func TestRuleStore_ListEmpty(t *testing.T) {
 	}
 }
 
+func TestRuleStore_ExpiredRulesHidden(t *testing.T) {
+	db := testDB(t)
+	store, _ := NewRuleStore(db)
+
+	past := time.Now().UTC().Add(-time.Hour)
+	future := time.Now().UTC().Add(time.Hour).Truncate(time.Second)
+	if err := store.AddWithExpiry("old", "gone", 5, 1.0, past); err != nil {
+		t.Fatalf("add error: %v", err)
+	}
+	if err := store.AddWithExpiry("new", "here", 5, 1.0, future); err != nil {
+		t.Fatalf("add error: %v", err)
+	}
+
+	rules, err := store.List()
+	if err != nil {
+		t.Fatalf("list error: %v", err)
+	}
+	if len(rules) != 1 || rules[0].Trigger != "new" {
+		t.Fatalf("expected only the unexpired rule, got %+v", rules)
+	}
+	if !rules[0].ExpiresAt.Equal(future) {
+		t.Errorf("expected expiry %v, got %v", future, rules[0].ExpiresAt)
+	}
+	if matched, _ := store.Match("old"); len(matched) != 0 {
+		t.Errorf("expected expired rule not to match, got %+v", matched)
+	}
+}
+
 // #endregion rule-store-tests
 
 // #region rule-detect-tests
@@ -646,3 +717,52 @@ func TestFormatRulesBlock_Empty(t *testing.T) {
 }
 
 // #endregion rule-detect-tests
+
+func TestPreviewPreference_DrasticNeedsConfirmation(t *testing.T) {
+	p := PreviewPreference("never answer with more than one sentence ever", nil, nil)
+	if !p.Drastic {
+		t.Error("expected absolute wording to be flagged drastic")
+	}
+	if !p.NeedsConfirmation() {
+		t.Error("expected drastic preference to need confirmation")
+	}
+	if !strings.Contains(p.Warning(), "/confirm") {
+		t.Errorf("expected warning to mention /confirm, got %q", p.Warning())
+	}
+}
+
+func TestPreviewPreference_ConflictsAndCompliance(t *testing.T) {
+	existing := []Preference{
+		{Text: "detailed explanations", Style: StyleDetailed},
+		{Text: "use my name", Style: StyleGeneral},
+	}
+	long := strings.Repeat("word ", 120)
+	p := PreviewPreference("short answers", existing, []string{long, long})
+
+	if len(p.Conflicts) != 1 || p.Conflicts[0].Style != StyleDetailed {
+		t.Fatalf("expected the detailed preference as the only conflict, got %+v", p.Conflicts)
+	}
+	if p.Turns != 2 {
+		t.Errorf("expected 2 simulated turns, got %d", p.Turns)
+	}
+	if p.ComplianceDelta() >= 0 {
+		t.Errorf("expected compliance to drop for long responses, before=%.2f after=%.2f",
+			p.ComplianceBefore, p.ComplianceAfter)
+	}
+	if !p.NeedsConfirmation() {
+		t.Error("expected conflicting preference to need confirmation")
+	}
+}
+
+func TestPreviewPreference_BenignAndDuplicate(t *testing.T) {
+	p := PreviewPreference("bullet points", nil, []string{"ok"})
+	if p.NeedsConfirmation() {
+		t.Errorf("expected benign preference to pass, got %+v", p)
+	}
+
+	existing := []Preference{{Text: "Short answers", Style: StyleConcise}}
+	p = PreviewPreference("short answers", existing, []string{"ok"})
+	if p.NeedsConfirmation() || len(p.Conflicts) != 0 {
+		t.Errorf("expected duplicate to be a no-op, got %+v", p)
+	}
+}
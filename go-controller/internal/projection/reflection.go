@@ -0,0 +1,95 @@
+package projection
+
+import (
+	"fmt"
+	"strings"
+)
+
+// #region reflection-templates
+
+// DefaultReflectionKey names the template used for turn types without their own.
+const DefaultReflectionKey = "default"
+
+// ReflectionTemplates maps a classifier turn type to the question the
+// reflection pass asks after that turn. Templates resolve against the rule
+// template variables ({user_name|Commander}, {top_goal}, ...) at reflection time.
+type ReflectionTemplates map[string]string
+
+// DefaultReflectionTemplates returns the built-in templates: factual turns
+// reflect on uncertainty, emotional turns on the user, creative turns on the
+// choices made, and every other type on the exchange as a whole.
+func DefaultReflectionTemplates() ReflectionTemplates {
+	return ReflectionTemplates{
+		DefaultReflectionKey: "Now speak from inside yourself. What did you notice in this exchange? " +
+			"What don't you know that this opened? What do you want to understand?",
+		"factual": "Now speak from inside yourself. What were you uncertain about in that answer? " +
+			"Which parts rest on evidence and which on assumption? What would you need to know to be sure?",
+		"emotional": "Now speak from inside yourself. What did you notice about {user_name|Commander} in this exchange: " +
+			"their mood, what they needed, what went unsaid? What do you want to understand about them?",
+		"creative": "Now speak from inside yourself. What choices did you make in that response, and what did you leave out? " +
+			"What would you try differently? What do you want to explore?",
+	}
+}
+
+// For returns the template for turnType, or the default template.
+func (t ReflectionTemplates) For(turnType string) (key, tmpl string) {
+	if s, ok := t[turnType]; ok {
+		return turnType, s
+	}
+	return DefaultReflectionKey, t[DefaultReflectionKey]
+}
+
+// Render resolves the template for turnType against vars and reports which
+// template was used.
+func (t ReflectionTemplates) Render(turnType string, vars RuleVars) (key, question string) {
+	key, tmpl := t.For(turnType)
+	return key, RenderRuleResponse(tmpl, vars)
+}
+
+// ParseReflectionTemplates parses a reflection templates file, a flat YAML
+// mapping from turn type (or "default") to template, and returns the
+// built-in templates with those entries replaced:
+//
+//	factual: "What were you unsure of? What would settle it?"
+//	emotional: "What did you notice about {user_name|Commander}?"
+//
+// Keys must be "default" or one of turnTypes, and templates may only use
+// known rule template variables.
+func ParseReflectionTemplates(data []byte, turnTypes []string) (ReflectionTemplates, error) {
+	known := map[string]bool{DefaultReflectionKey: true}
+	for _, tt := range turnTypes {
+		known[tt] = true
+	}
+	out := DefaultReflectionTemplates()
+	seen := map[string]int{}
+	for i, raw := range strings.Split(string(data), "\n") {
+		lineNo := i + 1
+		trimmed := strings.TrimSpace(stripYAMLComment(raw))
+		if trimmed == "" || trimmed == "---" {
+			continue
+		}
+		key, value, ok := strings.Cut(trimmed, ":")
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected \"turn_type: template\"", lineNo)
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(unquoteYAML(strings.TrimSpace(value)))
+		switch {
+		case !known[key]:
+			return nil, fmt.Errorf("line %d: unknown turn type %q (want default or one of %s)", lineNo, key, strings.Join(turnTypes, ", "))
+		case seen[key] != 0:
+			return nil, fmt.Errorf("line %d: %s already defined on line %d", lineNo, key, seen[key])
+		case value == "":
+			return nil, fmt.Errorf("line %d: empty template for %s", lineNo, key)
+		}
+		if unknown := UnknownRuleVars(value); len(unknown) > 0 {
+			return nil, fmt.Errorf("line %d: %s: unknown template variables %v (known: %s)",
+				lineNo, key, unknown, strings.Join(RuleVarNames, ", "))
+		}
+		seen[key] = lineNo
+		out[key] = value
+	}
+	return out, nil
+}
+
+// #endregion reflection-templates
@@ -0,0 +1,66 @@
+package projection
+
+import (
+	"strings"
+	"testing"
+)
+
+var testTurnTypes = []string{"factual", "philosophical", "emotional", "command", "creative", "conversational"}
+
+func TestReflectionTemplates_Render(t *testing.T) {
+	tmpl := DefaultReflectionTemplates()
+	vars := RuleVars{"user_name": "Dana"}
+
+	key, q := tmpl.Render("factual", vars)
+	if key != "factual" || !strings.Contains(q, "uncertain") {
+		t.Errorf("factual: %s %q", key, q)
+	}
+	key, q = tmpl.Render("emotional", vars)
+	if key != "emotional" || !strings.Contains(q, "about Dana in this exchange") {
+		t.Errorf("emotional: %s %q", key, q)
+	}
+	if _, q = tmpl.Render("emotional", RuleVars{}); !strings.Contains(q, "about Commander in") {
+		t.Errorf("emotional without a name should fall back to Commander: %q", q)
+	}
+	if key, q = tmpl.Render("creative", vars); key != "creative" || !strings.Contains(q, "choices") {
+		t.Errorf("creative: %s %q", key, q)
+	}
+	for _, tt := range []string{"philosophical", "conversational", ""} {
+		if key, q = tmpl.Render(tt, vars); key != DefaultReflectionKey || !strings.Contains(q, "What did you notice in this exchange?") {
+			t.Errorf("%q: %s %q, want the default template", tt, key, q)
+		}
+	}
+}
+
+func TestParseReflectionTemplates(t *testing.T) {
+	data := []byte(`# reflection questions
+factual: "What were you unsure of? What would settle it?"
+command: 'Did you do what {user_name|Commander} asked?'  # commands get their own
+`)
+	tmpl, err := ParseReflectionTemplates(data, testTurnTypes)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if got := tmpl["factual"]; got != "What were you unsure of? What would settle it?" {
+		t.Errorf("factual = %q", got)
+	}
+	if key, q := tmpl.Render("command", RuleVars{}); key != "command" || q != "Did you do what Commander asked?" {
+		t.Errorf("command: %s %q", key, q)
+	}
+	// Entries the file leaves out keep their built-in templates
+	if tmpl["emotional"] != DefaultReflectionTemplates()["emotional"] || tmpl[DefaultReflectionKey] == "" {
+		t.Error("unlisted turn types should keep the built-in templates")
+	}
+
+	for name, bad := range map[string]string{
+		"unknown turn type": "poetic: Why?",
+		"duplicate":         "factual: a\nfactual: b",
+		"empty template":    "factual: \"\"",
+		"no colon":          "factual",
+		"unknown variable":  "default: What about {user_nmae}?",
+	} {
+		if _, err := ParseReflectionTemplates([]byte(bad), testTurnTypes); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
@@ -0,0 +1,178 @@
+package projection
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// #region removal-detect
+
+// RemovalRequest is a retraction of a stored preference in plain words:
+// Subject is what the preference was about ("examples", "so brief").
+// Downgrade asks for less weight rather than removal.
+type RemovalRequest struct {
+	Subject   string
+	Downgrade bool
+}
+
+// removalPhrases open a retraction; the subject follows the phrase. Longer
+// phrases come first so "forget my preference about" wins over "forget that".
+var removalPhrases = []struct {
+	phrase    string
+	downgrade bool
+}{
+	{"forget my preference about ", false},
+	{"forget my preference for ", false},
+	{"forget my preference on ", false},
+	{"forget the preference about ", false},
+	{"forget the preference for ", false},
+	{"drop my preference about ", false},
+	{"drop my preference for ", false},
+	{"remove my preference about ", false},
+	{"remove my preference for ", false},
+	{"delete my preference about ", false},
+	{"delete my preference for ", false},
+	{"never mind my preference about ", false},
+	{"never mind my preference for ", false},
+	{"forget that i like ", false},
+	{"forget that i prefer ", false},
+	{"forget that i want ", false},
+	{"forget that i asked for ", false},
+	{"forget that i said ", false},
+	{"i no longer want ", false},
+	{"i no longer prefer ", false},
+	{"i no longer need ", false},
+	{"i don't want ", false}, // only with "anymore", see anymoreOnly
+	{"stop being so ", false},
+	{"stop being ", false},
+	{"no need to be so ", false},
+	{"you don't have to be so ", false},
+	{"don't worry so much about ", true},
+	{"don't worry too much about ", true},
+	{"be less strict about ", true},
+	{"go easier on ", true},
+}
+
+// anymoreOnly are removal phrases that read as a new preference unless the
+// prompt says "anymore" ("I don't want bullet points" vs "... anymore").
+var anymoreOnly = map[string]bool{"i don't want ": true}
+
+// styleOnly are removal phrases that read as ordinary feedback ("stop being
+// rude", "stop being so formal, just chat") unless the whole subject names a
+// preference style ("stop being so brief").
+var styleOnly = map[string]bool{"stop being so ": true, "stop being ": true, "no need to be so ": true, "you don't have to be so ": true}
+
+// removalTrailers are dropped from the end of a subject.
+var removalTrailers = []string{" anymore", " any more", " for now", " please", " now"}
+
+// DetectPreferenceRemoval checks if a prompt retracts or softens a stored
+// preference, e.g. "forget my preference about examples", "stop being so
+// brief" or "don't worry so much about formatting".
+func DetectPreferenceRemoval(prompt string) (RemovalRequest, bool) {
+	lower := strings.ToLower(strings.TrimSpace(prompt))
+	if lower == "" || strings.HasSuffix(lower, "?") {
+		return RemovalRequest{}, false
+	}
+	lower = strings.TrimRight(lower, ".!")
+	for _, p := range removalPhrases {
+		idx := strings.Index(lower, p.phrase)
+		if idx < 0 || (idx > 0 && lower[idx-1] != ' ' && lower[idx-1] != ',') {
+			continue
+		}
+		subject := lower[idx+len(p.phrase):]
+		if anymoreOnly[p.phrase] && !strings.Contains(subject, "anymore") && !strings.Contains(subject, "any more") {
+			continue
+		}
+		for trimmed := true; trimmed; {
+			trimmed = false
+			for _, t := range removalTrailers {
+				if s, ok := strings.CutSuffix(subject, t); ok {
+					subject, trimmed = s, true
+				}
+			}
+		}
+		subject = strings.Trim(subject, " ,;:'\"")
+		if subject == "" {
+			continue
+		}
+		if styleOnly[p.phrase] && (strings.ContainsAny(subject, ",;") || InferStyle(subject) == StyleGeneral) {
+			continue
+		}
+		return RemovalRequest{Subject: subject, Downgrade: p.downgrade}, true
+	}
+	return RemovalRequest{}, false
+}
+
+// #endregion removal-detect
+
+// #region removal-match
+
+// MatchRemoval returns the stored preferences req refers to. A subject with
+// a style ("so brief" → concise) matches the preferences of that style;
+// otherwise a preference matches when at least half of the subject's
+// significant words (4+ letters, plural s ignored) appear in its text.
+func MatchRemoval(req RemovalRequest, prefs []Preference) []Preference {
+	var out []Preference
+	if style := InferStyle(req.Subject); style != StyleGeneral {
+		for _, p := range prefs {
+			if p.Style == style {
+				out = append(out, p)
+			}
+		}
+		if len(out) > 0 {
+			return out
+		}
+	}
+	var words []string
+	for _, w := range strings.Fields(req.Subject) {
+		w = strings.Trim(w, ".,!?;:'\"")
+		if len(w) >= 4 {
+			words = append(words, strings.TrimSuffix(w, "s"))
+		}
+	}
+	if len(words) == 0 {
+		return nil
+	}
+	for _, p := range prefs {
+		text := strings.ToLower(p.Text)
+		hits := 0
+		for _, w := range words {
+			if strings.Contains(text, w) {
+				hits++
+			}
+		}
+		if hits*2 >= len(words) {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// #endregion removal-match
+
+// #region removal-store
+
+// Downgrade lowers preference id's priority one step, to no lower than
+// PriorityLow, and returns the new priority. found is false if no such
+// preference is stored.
+func (s *PreferenceStore) Downgrade(id int) (priority int, found bool, err error) {
+	var current int
+	err = s.db.QueryRow("SELECT priority FROM preferences WHERE id = ?", id).Scan(&current)
+	if err == sql.ErrNoRows {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, fmt.Errorf("find preference: %w", err)
+	}
+	priority = max(current-1, PriorityLow)
+	if priority == current {
+		return priority, true, nil
+	}
+	if _, err := s.db.Exec("UPDATE preferences SET priority = ? WHERE id = ?", priority, id); err != nil {
+		return 0, true, fmt.Errorf("downgrade preference: %w", err)
+	}
+	return priority, true, s.logEvent(int64(id), PrefEventDowngraded)
+}
+
+// #endregion removal-store
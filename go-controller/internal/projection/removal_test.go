@@ -0,0 +1,80 @@
+package projection
+
+import "testing"
+
+func TestDetectPreferenceRemoval(t *testing.T) {
+	cases := []struct {
+		prompt    string
+		subject   string
+		downgrade bool
+		ok        bool
+	}{
+		{"Forget my preference about examples.", "examples", false, true},
+		{"Stop being so brief", "brief", false, true},
+		{"OK, forget that I like bullet points", "bullet points", false, true},
+		{"I don't want code examples anymore", "code examples", false, true},
+		{"I no longer want detailed answers, please", "detailed answers", false, true},
+		{"Don't worry so much about formatting now", "formatting", true, true},
+		{"I don't want bullet points", "", false, false},
+		{"Can you stop being so brief?", "", false, false},
+		{"forget that, it's junk", "", false, false},
+		{"stop being rude", "", false, false},
+		{"Stop being so formal, just chat", "", false, false},
+		{"You don't have to be so polite", "", false, false},
+		{"no need to be so terse", "terse", false, true},
+	}
+	for _, tc := range cases {
+		req, ok := DetectPreferenceRemoval(tc.prompt)
+		if ok != tc.ok || req.Subject != tc.subject || req.Downgrade != tc.downgrade {
+			t.Errorf("DetectPreferenceRemoval(%q) = %+v, %v; want %q downgrade=%v, %v",
+				tc.prompt, req, ok, tc.subject, tc.downgrade, tc.ok)
+		}
+	}
+}
+
+func TestMatchRemoval(t *testing.T) {
+	prefs := []Preference{
+		{ID: 1, Text: "Keep answers short", Style: StyleConcise},
+		{ID: 2, Text: "Always include an example", Style: StyleExamples},
+		{ID: 3, Text: "Use bullet points for lists", Style: StyleGeneral},
+	}
+	cases := []struct {
+		subject string
+		want    []int
+	}{
+		{"so brief", []int{1}},
+		{"examples", []int{2}},
+		{"bullet points", []int{3}},
+		{"emoji", nil},
+	}
+	for _, tc := range cases {
+		got := MatchRemoval(RemovalRequest{Subject: tc.subject}, prefs)
+		var ids []int
+		for _, p := range got {
+			ids = append(ids, p.ID)
+		}
+		if len(ids) != len(tc.want) || (len(ids) > 0 && ids[0] != tc.want[0]) {
+			t.Errorf("MatchRemoval(%q) = %v, want %v", tc.subject, ids, tc.want)
+		}
+	}
+}
+
+func TestDowngrade(t *testing.T) {
+	store, _ := NewPreferenceStore(testDB(t))
+	store.AddWithOptions("It's important to always include an example", "explicit", PreferenceOptions{Priority: PriorityHigh})
+	prefs, _ := store.List()
+	id := prefs[0].ID
+	for _, want := range []int{PriorityNormal, PriorityLow, PriorityLow} {
+		got, found, err := store.Downgrade(id)
+		if err != nil || !found || got != want {
+			t.Fatalf("Downgrade = %d, %v, %v; want %d", got, found, err, want)
+		}
+	}
+	if _, found, _ := store.Downgrade(id + 100); found {
+		t.Error("downgrade of a missing preference reported found")
+	}
+	events, _ := store.Lifecycle(id)
+	if n := len(events); n != 3 || events[n-1].Event != PrefEventDowngraded {
+		t.Errorf("events = %+v, want created + 2 downgrades", events)
+	}
+}
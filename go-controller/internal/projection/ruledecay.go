@@ -0,0 +1,113 @@
+package projection
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/timestamp"
+)
+
+// #region rule-decay
+
+// Why a rule was pruned, recorded in pruned_rules.
+const (
+	RulePrunedExpired = "expired" // its expires_at passed
+	RulePrunedDecayed = "decayed" // unmatched until its confidence fell below the floor
+)
+
+// RuleDecayConfig sets how unused rules lose confidence and are pruned.
+type RuleDecayConfig struct {
+	// A rule unmatched for this many turns starts to decay; 0 disables decay
+	// (expired rules are still pruned)
+	IdleTurns int
+	// Fraction of confidence lost per further unmatched turn
+	Rate float64
+	// Rules decayed below this confidence are deleted
+	PruneBelow float64
+}
+
+// DefaultRuleDecayConfig returns the rule decay defaults: decay after 200
+// idle turns at 2% per turn, which takes a rule from 1.0 to the 0.2 pruning
+// floor in about 80 more turns.
+func DefaultRuleDecayConfig() RuleDecayConfig {
+	return RuleDecayConfig{IdleTurns: 200, Rate: 0.02, PruneBelow: 0.2}
+}
+
+// RecordTurn updates rule usage after a turn in which fired matched (possibly
+// none). Fired rules count the firing, are stamped last fired now, and are back
+// at full confidence; every other rule is one turn idler and, past
+// cfg.IdleTurns, decays by cfg.Rate. Rules decayed below cfg.PruneBelow and
+// expired rules are then moved to pruned_rules and returned.
+func (s *RuleStore) RecordTurn(fired []Rule, cfg RuleDecayConfig) ([]Rule, error) {
+	now := timestamp.Now()
+	if _, err := s.db.Exec("UPDATE rules SET idle_turns = idle_turns + 1"); err != nil {
+		return nil, fmt.Errorf("age rules: %w", err)
+	}
+	for _, r := range fired { // idle_turns > 0: a rule listed twice fires once
+		if _, err := s.db.Exec(`UPDATE rules SET fired_count = fired_count + 1, last_fired_at = ?, idle_turns = 0, confidence = 1.0
+			WHERE id = ? AND idle_turns > 0`, now, r.ID); err != nil {
+			return nil, fmt.Errorf("record rule firing: %w", err)
+		}
+	}
+	if cfg.IdleTurns > 0 && cfg.Rate > 0 {
+		if _, err := s.db.Exec("UPDATE rules SET confidence = confidence * ? WHERE idle_turns > ?", 1-cfg.Rate, cfg.IdleTurns); err != nil {
+			return nil, fmt.Errorf("decay rules: %w", err)
+		}
+	}
+
+	rows, err := s.db.Query(`SELECT id, trigger, response, priority, confidence, created_at, expires_at, fired_count, idle_turns FROM rules
+		WHERE confidence < ? OR (expires_at IS NOT NULL AND expires_at <= ?)`, cfg.PruneBelow, now)
+	if err != nil {
+		return nil, fmt.Errorf("find stale rules: %w", err)
+	}
+	var pruned []Rule
+	for rows.Next() {
+		var r Rule
+		var ts string
+		var expires sql.NullString
+		if err := rows.Scan(&r.ID, &r.Trigger, &r.Response, &r.Priority, &r.Confidence, &ts, &expires, &r.FiredCount, &r.IdleTurns); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("scan stale rule: %w", err)
+		}
+		r.CreatedAt, _ = timestamp.Parse(ts)
+		if expires.Valid {
+			r.ExpiresAt, _ = timestamp.Parse(expires.String)
+		}
+		pruned = append(pruned, r)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("find stale rules: %w", err)
+	}
+	// Moved after the rows are closed: the query holds the connection
+	for _, r := range pruned {
+		reason := RulePrunedDecayed
+		if !r.ExpiresAt.IsZero() && timestamp.Format(r.ExpiresAt) <= now {
+			reason = RulePrunedExpired
+		}
+		if _, err := s.db.Exec(`INSERT INTO pruned_rules
+			(rule_id, trigger, response, confidence, fired_count, last_fired_at, created_at, reason, pruned_at)
+			SELECT id, trigger, response, confidence, fired_count, last_fired_at, created_at, ?, ? FROM rules WHERE id = ?`,
+			reason, now, r.ID); err != nil {
+			return nil, fmt.Errorf("record pruned rule: %w", err)
+		}
+		if _, err := s.db.Exec("DELETE FROM rules WHERE id = ?", r.ID); err != nil {
+			return nil, fmt.Errorf("prune rule: %w", err)
+		}
+	}
+	if len(pruned) > 0 {
+		if err := s.dropOrphanSteps(); err != nil {
+			return nil, err
+		}
+	}
+	return pruned, nil
+}
+
+// Expired reports whether r is past its expiry as of now; a pruned rule that
+// is not expired was pruned for decay.
+func (r Rule) Expired(now time.Time) bool {
+	return !r.ExpiresAt.IsZero() && !r.ExpiresAt.After(now)
+}
+
+// #endregion rule-decay
@@ -0,0 +1,67 @@
+package projection
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRuleStore_RecordTurn(t *testing.T) {
+	store, err := NewRuleStore(testDB(t))
+	if err != nil {
+		t.Fatalf("new rule store: %v", err)
+	}
+	store.Add("knock knock", "who's there?", 5, 1.0)
+	store.Add("ping", "pong", 5, 1.0)
+	cfg := RuleDecayConfig{IdleTurns: 2, Rate: 0.5, PruneBelow: 0.2}
+
+	matched, _ := store.Match("ping")
+	if _, err := store.RecordTurn(append(matched, matched...), cfg); err != nil {
+		t.Fatalf("record turn: %v", err)
+	}
+	rules, _ := store.List()
+	for _, r := range rules {
+		switch r.Trigger {
+		case "ping":
+			if r.FiredCount != 1 || r.IdleTurns != 0 || r.LastFiredAt.IsZero() {
+				t.Errorf("fired rule = %+v, want one firing", r)
+			}
+		case "knock knock":
+			if r.FiredCount != 0 || r.IdleTurns != 1 || r.Confidence != 1.0 {
+				t.Errorf("idle rule = %+v", r)
+			}
+		}
+	}
+
+	// Turns 2-5 without "knock knock": up to idle 2 it holds, then each turn
+	// halves it to 0.5, 0.25 and 0.125, below the floor; "ping" keeps firing
+	var pruned []Rule
+	for i := 0; i < 4; i++ {
+		matched, _ := store.Match("ping")
+		if pruned, err = store.RecordTurn(matched, cfg); err != nil {
+			t.Fatalf("record turn: %v", err)
+		}
+		if i < 3 && len(pruned) != 0 {
+			t.Fatalf("turn %d pruned %+v early", i+2, pruned)
+		}
+	}
+	if len(pruned) != 1 || pruned[0].Trigger != "knock knock" || pruned[0].Expired(time.Now()) {
+		t.Fatalf("pruned = %+v, want the decayed knock-knock rule", pruned)
+	}
+	rules, _ = store.List()
+	if len(rules) != 1 || rules[0].Trigger != "ping" || rules[0].FiredCount != 5 || rules[0].Confidence != 1.0 {
+		t.Errorf("remaining rules = %+v", rules)
+	}
+}
+
+func TestRuleStore_RecordTurnPrunesExpired(t *testing.T) {
+	store, _ := NewRuleStore(testDB(t))
+	store.AddWithExpiry("old joke", "groan", 5, 1.0, time.Now().Add(-time.Minute))
+	store.Add("ping", "pong", 5, 1.0)
+	pruned, err := store.RecordTurn(nil, RuleDecayConfig{PruneBelow: 0.2})
+	if err != nil {
+		t.Fatalf("record turn: %v", err)
+	}
+	if len(pruned) != 1 || pruned[0].Trigger != "old joke" || !pruned[0].Expired(time.Now()) {
+		t.Errorf("pruned = %+v, want the expired rule", pruned)
+	}
+}
@@ -0,0 +1,327 @@
+package projection
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// #region rule-spec
+
+// RuleSpec is one rule parsed from a rules file, before validation.
+type RuleSpec struct {
+	Trigger   string
+	Response  string
+	Priority  int
+	ExpiresAt time.Time    // zero = never expires
+	Steps     []ScriptStep // exchanges that follow, for a multi-turn script
+	Line      int          // 1-based line where the entry starts, for error messages
+}
+
+// RuleChange is one planned write from a rules import.
+type RuleChange struct {
+	Kind     string // "add" | "update" | "unchanged"
+	Spec     RuleSpec
+	Existing *Rule // set for "update" and "unchanged"
+}
+
+// defaultRulePriority matches the priority used for rules taught in conversation.
+const defaultRulePriority = 5
+
+// #endregion rule-spec
+
+// #region rule-parse
+
+// ParseRulesYAML parses a rules file. The supported format is a YAML list of
+// flat mappings, optionally nested under a top-level "rules:" key:
+//
+//	rules:
+//	  - trigger: knock knock
+//	    response: who's there?
+//	    priority: 7
+//	    expiry: 2026-12-31
+//	    steps:
+//	      - expect: "{name}"
+//	        response: "{name} who?"
+//
+// steps, a list of expect/response mappings indented under the entry, makes
+// the rule a multi-turn script. Values may be bare or quoted. Comments (#)
+// and blank lines are ignored. Only this subset of YAML is understood;
+// anything else is a parse error.
+func ParseRulesYAML(data []byte) ([]RuleSpec, error) {
+	var specs []RuleSpec
+	var cur *RuleSpec
+	var step *ScriptStep
+	seen := map[string]bool{}
+	stepSeen := map[string]bool{}
+	stepsCol := -1 // column of the entry's "steps:" key while reading its steps
+
+	for i, raw := range strings.Split(string(data), "\n") {
+		lineNo := i + 1
+		line := stripYAMLComment(raw)
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || trimmed == "---" {
+			continue
+		}
+		if trimmed == "rules:" && !strings.HasPrefix(line, " ") {
+			continue
+		}
+		col := len(line) - len(strings.TrimLeft(line, " "))
+
+		if strings.HasPrefix(trimmed, "- ") || trimmed == "-" {
+			rest := strings.TrimSpace(strings.TrimPrefix(trimmed, "-"))
+			if stepsCol >= 0 && col >= stepsCol {
+				cur.Steps = append(cur.Steps, ScriptStep{})
+				step = &cur.Steps[len(cur.Steps)-1]
+				stepSeen = map[string]bool{}
+			} else {
+				specs = append(specs, RuleSpec{Priority: defaultRulePriority, Line: lineNo})
+				cur = &specs[len(specs)-1]
+				seen = map[string]bool{}
+				step, stepsCol = nil, -1
+			}
+			if rest == "" {
+				continue
+			}
+			col += len(trimmed) - len(rest)
+			trimmed = rest
+		}
+		if cur == nil {
+			return nil, fmt.Errorf("line %d: expected a list entry (\"- trigger: ...\")", lineNo)
+		}
+
+		key, value, ok := strings.Cut(trimmed, ":")
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected \"key: value\"", lineNo)
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = unquoteYAML(strings.TrimSpace(value))
+
+		if stepsCol >= 0 && col > stepsCol {
+			if step == nil {
+				return nil, fmt.Errorf("line %d: expected a step (\"- expect: ...\")", lineNo)
+			}
+			if stepSeen[key] {
+				return nil, fmt.Errorf("line %d: duplicate step field %q", lineNo, key)
+			}
+			stepSeen[key] = true
+			switch key {
+			case "expect":
+				step.Expect = value
+			case "response":
+				step.Response = value
+			default:
+				return nil, fmt.Errorf("line %d: unknown step field %q", lineNo, key)
+			}
+			continue
+		}
+		step, stepsCol = nil, -1
+
+		if seen[key] {
+			return nil, fmt.Errorf("line %d: duplicate field %q", lineNo, key)
+		}
+		seen[key] = true
+
+		switch key {
+		case "trigger":
+			cur.Trigger = value
+		case "response":
+			cur.Response = value
+		case "priority":
+			p, err := strconv.Atoi(value)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: priority must be an integer, got %q", lineNo, value)
+			}
+			cur.Priority = p
+		case "expiry", "expires", "expires_at":
+			if value == "" {
+				continue
+			}
+			t, err := parseRuleExpiry(value)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", lineNo, err)
+			}
+			cur.ExpiresAt = t
+		case "steps":
+			if value != "" {
+				return nil, fmt.Errorf("line %d: steps must be a list of expect/response entries", lineNo)
+			}
+			stepsCol = col
+		default:
+			return nil, fmt.Errorf("line %d: unknown field %q", lineNo, key)
+		}
+	}
+	return specs, nil
+}
+
+// parseRuleExpiry accepts RFC3339 timestamps or plain dates (end of day UTC).
+func parseRuleExpiry(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t.UTC(), nil
+	}
+	if d, err := time.Parse("2006-01-02", value); err == nil {
+		return d.Add(24*time.Hour - time.Second).UTC(), nil
+	}
+	return time.Time{}, fmt.Errorf("expiry must be YYYY-MM-DD or RFC3339, got %q", value)
+}
+
+// stripYAMLComment removes a trailing # comment that is outside quotes.
+func stripYAMLComment(line string) string {
+	var quote rune
+	for i, r := range line {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '"' || r == '\'':
+			quote = r
+		case r == '#' && (i == 0 || line[i-1] == ' ' || line[i-1] == '\t'):
+			return line[:i]
+		}
+	}
+	return line
+}
+
+// unquoteYAML strips one layer of matching single or double quotes.
+func unquoteYAML(v string) string {
+	if len(v) >= 2 && (v[0] == '"' || v[0] == '\'') && v[len(v)-1] == v[0] {
+		return v[1 : len(v)-1]
+	}
+	return v
+}
+
+// #endregion rule-parse
+
+// #region rule-validate
+
+// ValidateRuleSpecs checks parsed rules for empty fields, invalid trigger
+// patterns and script steps, past expiries, and conflicting triggers (same
+// trigger, different response or steps). Exact duplicates are collapsed.
+// Returns the de-duplicated specs and every problem found.
+func ValidateRuleSpecs(specs []RuleSpec, now time.Time) ([]RuleSpec, []error) {
+	var out []RuleSpec
+	var errs []error
+	byTrigger := map[string]int{} // lowercased trigger → index in out
+
+	for _, sp := range specs {
+		sp.Trigger = strings.TrimSpace(sp.Trigger)
+		sp.Response = strings.TrimSpace(sp.Response)
+		if sp.Trigger == "" {
+			errs = append(errs, fmt.Errorf("line %d: empty trigger", sp.Line))
+			continue
+		}
+		if sp.Response == "" {
+			errs = append(errs, fmt.Errorf("line %d: empty response for trigger %q", sp.Line, sp.Trigger))
+			continue
+		}
+		if err := ValidateTrigger(sp.Trigger); err != nil {
+			errs = append(errs, fmt.Errorf("line %d: trigger %q: %w", sp.Line, sp.Trigger, err))
+			continue
+		}
+		var stepErr error
+		for _, st := range sp.Steps {
+			if stepErr = ValidateScriptStep(st); stepErr != nil {
+				break
+			}
+		}
+		if stepErr != nil {
+			errs = append(errs, fmt.Errorf("line %d: trigger %q: %w", sp.Line, sp.Trigger, stepErr))
+			continue
+		}
+		if !sp.ExpiresAt.IsZero() && !sp.ExpiresAt.After(now) {
+			errs = append(errs, fmt.Errorf("line %d: trigger %q already expired (%s)",
+				sp.Line, sp.Trigger, sp.ExpiresAt.Format(time.RFC3339)))
+			continue
+		}
+
+		key := strings.ToLower(sp.Trigger)
+		if idx, ok := byTrigger[key]; ok {
+			prev := out[idx]
+			if !strings.EqualFold(prev.Response, sp.Response) || !stepsEqual(prev.Steps, sp.Steps) {
+				errs = append(errs, fmt.Errorf("line %d: trigger %q conflicts with line %d (different response)",
+					sp.Line, sp.Trigger, prev.Line))
+			}
+			continue
+		}
+		byTrigger[key] = len(out)
+		out = append(out, sp)
+	}
+	return out, errs
+}
+
+// #endregion rule-validate
+
+// #region rule-plan
+
+// PlanRuleImport diffs validated specs against stored rules. Triggers match
+// case-insensitively, mirroring RuleStore.Add's replace-on-same-trigger behavior.
+func PlanRuleImport(specs []RuleSpec, existing []Rule) []RuleChange {
+	byTrigger := make(map[string]*Rule, len(existing))
+	for i := range existing {
+		byTrigger[strings.ToLower(existing[i].Trigger)] = &existing[i]
+	}
+
+	changes := make([]RuleChange, 0, len(specs))
+	for _, sp := range specs {
+		ex, ok := byTrigger[strings.ToLower(sp.Trigger)]
+		switch {
+		case !ok:
+			changes = append(changes, RuleChange{Kind: "add", Spec: sp})
+		case ex.Response == sp.Response && ex.Priority == sp.Priority && ex.ExpiresAt.Equal(sp.ExpiresAt) && stepsEqual(ex.Steps, sp.Steps):
+			changes = append(changes, RuleChange{Kind: "unchanged", Spec: sp, Existing: ex})
+		default:
+			changes = append(changes, RuleChange{Kind: "update", Spec: sp, Existing: ex})
+		}
+	}
+	return changes
+}
+
+// FormatRuleDiff renders planned changes as a +/~/= diff, one rule per line.
+func FormatRuleDiff(changes []RuleChange) string {
+	var b strings.Builder
+	for _, c := range changes {
+		switch c.Kind {
+		case "add":
+			fmt.Fprintf(&b, "+ %q → %q (priority %d%s%s)\n", c.Spec.Trigger, c.Spec.Response, c.Spec.Priority,
+				expirySuffix(c.Spec.ExpiresAt), stepsSuffix(c.Spec.Steps))
+		case "update":
+			fmt.Fprintf(&b, "~ %q: %q (priority %d%s%s) → %q (priority %d%s%s)\n", c.Spec.Trigger,
+				c.Existing.Response, c.Existing.Priority, expirySuffix(c.Existing.ExpiresAt), stepsSuffix(c.Existing.Steps),
+				c.Spec.Response, c.Spec.Priority, expirySuffix(c.Spec.ExpiresAt), stepsSuffix(c.Spec.Steps))
+		case "unchanged":
+			fmt.Fprintf(&b, "= %q (already stored)\n", c.Spec.Trigger)
+		}
+	}
+	return b.String()
+}
+
+func expirySuffix(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return ", expires " + t.Format(time.RFC3339)
+}
+
+func stepsSuffix(steps []ScriptStep) string {
+	if len(steps) == 0 {
+		return ""
+	}
+	return fmt.Sprintf(", %d script steps", len(steps))
+}
+
+// stepsEqual reports whether two scripts have the same steps.
+func stepsEqual(a, b []ScriptStep) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// #endregion rule-plan
@@ -0,0 +1,166 @@
+package projection
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// #region rule-parse-tests
+
+func TestParseRulesYAML(t *testing.T) {
+	data := []byte(`# team rules
+rules:
+  - trigger: knock knock
+    response: "Who's there?"   # quoted
+    priority: 7
+  - trigger: 'status'
+    response: All systems nominal
+    expiry: 2099-01-02
+`)
+	specs, err := ParseRulesYAML(data)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if len(specs) != 2 {
+		t.Fatalf("expected 2 specs, got %d", len(specs))
+	}
+	if specs[0].Trigger != "knock knock" || specs[0].Response != "Who's there?" || specs[0].Priority != 7 {
+		t.Errorf("unexpected first spec: %+v", specs[0])
+	}
+	if specs[1].Priority != defaultRulePriority {
+		t.Errorf("expected default priority, got %d", specs[1].Priority)
+	}
+	if specs[1].ExpiresAt.IsZero() || specs[1].ExpiresAt.Year() != 2099 {
+		t.Errorf("expected 2099 expiry, got %v", specs[1].ExpiresAt)
+	}
+	if specs[1].Line != 6 {
+		t.Errorf("expected second entry on line 6, got %d", specs[1].Line)
+	}
+}
+
+func TestParseRulesYAML_Steps(t *testing.T) {
+	data := []byte(`rules:
+  - trigger: knock knock
+    response: "Who's there?"
+    steps:
+      - expect: "{name}"
+        response: "{name} who?"
+      - expect: "{name} who {punchline}"
+        response: Laugh at the pun
+    priority: 6
+  - trigger: ping
+    steps:
+    - expect: again
+      response: pong again
+    response: pong
+`)
+	specs, err := ParseRulesYAML(data)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if len(specs) != 2 {
+		t.Fatalf("expected 2 specs, got %+v", specs)
+	}
+	want := []ScriptStep{{"{name}", "{name} who?"}, {"{name} who {punchline}", "Laugh at the pun"}}
+	if !stepsEqual(specs[0].Steps, want) || specs[0].Priority != 6 || specs[0].Response != "Who's there?" {
+		t.Errorf("unexpected first spec: %+v", specs[0])
+	}
+	if len(specs[1].Steps) != 1 || specs[1].Steps[0].Response != "pong again" || specs[1].Response != "pong" {
+		t.Errorf("unexpected second spec: %+v", specs[1])
+	}
+}
+
+func TestParseRulesYAML_Errors(t *testing.T) {
+	cases := map[string]string{
+		"no list":            "trigger: x\n",
+		"bad priority":       "- trigger: x\n  priority: high\n",
+		"unknown field":      "- trigger: x\n  colour: blue\n",
+		"duplicate field":    "- trigger: x\n  trigger: y\n",
+		"bad expiry":         "- trigger: x\n  expiry: tomorrow\n",
+		"inline steps":       "- trigger: x\n  steps: y\n",
+		"step field":         "- trigger: x\n  steps:\n    - expect: y\n      colour: blue\n",
+		"step without entry": "- trigger: x\n  steps:\n      expect: y\n",
+	}
+	for name, data := range cases {
+		if _, err := ParseRulesYAML([]byte(data)); err == nil {
+			t.Errorf("%s: expected parse error", name)
+		}
+	}
+}
+
+// #endregion rule-parse-tests
+
+// #region rule-validate-tests
+
+func TestValidateRuleSpecs(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	specs := []RuleSpec{
+		{Trigger: "a", Response: "one", Line: 1},
+		{Trigger: "A", Response: "one", Line: 3}, // exact duplicate, collapsed
+		{Trigger: "a", Response: "two", Line: 5}, // conflict
+		{Trigger: "", Response: "x", Line: 7},    // empty trigger
+		{Trigger: "b", Response: " ", Line: 9},   // empty response
+		{Trigger: "c", Response: "y", Line: 11, ExpiresAt: now.Add(-time.Hour)},
+		{Trigger: "d", Response: "z", Line: 13},
+		{Trigger: "re:(", Response: "w", Line: 15}, // invalid pattern
+		{Trigger: "e", Response: "v", Line: 17, Steps: []ScriptStep{{Expect: "{x}", Response: ""}}},
+		{Trigger: "d", Response: "z", Line: 19, Steps: []ScriptStep{{Expect: "{x}", Response: "u"}}}, // conflict
+	}
+	out, errs := ValidateRuleSpecs(specs, now)
+	if len(out) != 2 {
+		t.Fatalf("expected 2 valid specs, got %d: %+v", len(out), out)
+	}
+	if len(errs) != 7 {
+		t.Fatalf("expected 7 problems, got %d: %v", len(errs), errs)
+	}
+	if !strings.Contains(errs[0].Error(), "conflicts with line 1") {
+		t.Errorf("expected conflict error first, got %v", errs[0])
+	}
+}
+
+// #endregion rule-validate-tests
+
+// #region rule-plan-tests
+
+func TestPlanRuleImport(t *testing.T) {
+	existing := []Rule{
+		{Trigger: "Knock Knock", Response: "Who's there?", Priority: 5},
+		{Trigger: "status", Response: "ok", Priority: 5},
+	}
+	specs := []RuleSpec{
+		{Trigger: "knock knock", Response: "Who's there?", Priority: 5},
+		{Trigger: "status", Response: "All systems nominal", Priority: 5},
+		{Trigger: "ping", Response: "pong", Priority: 3},
+	}
+	changes := PlanRuleImport(specs, existing)
+	kinds := []string{changes[0].Kind, changes[1].Kind, changes[2].Kind}
+	if strings.Join(kinds, ",") != "unchanged,update,add" {
+		t.Fatalf("unexpected plan: %v", kinds)
+	}
+
+	diff := FormatRuleDiff(changes)
+	for _, want := range []string{`= "knock knock"`, `~ "status": "ok"`, `+ "ping" → "pong"`} {
+		if !strings.Contains(diff, want) {
+			t.Errorf("expected diff to contain %q, got:\n%s", want, diff)
+		}
+	}
+}
+
+func TestPlanRuleImport_Steps(t *testing.T) {
+	steps := []ScriptStep{{Expect: "{name}", Response: "{name} who?"}}
+	existing := []Rule{{Trigger: "knock knock", Response: "Who's there?", Priority: 5, Steps: steps}}
+	same := PlanRuleImport([]RuleSpec{{Trigger: "knock knock", Response: "Who's there?", Priority: 5, Steps: steps}}, existing)
+	if same[0].Kind != "unchanged" {
+		t.Errorf("same steps: kind = %s, want unchanged", same[0].Kind)
+	}
+	changed := PlanRuleImport([]RuleSpec{{Trigger: "knock knock", Response: "Who's there?", Priority: 5}}, existing)
+	if changed[0].Kind != "update" {
+		t.Errorf("dropped steps: kind = %s, want update", changed[0].Kind)
+	}
+	if diff := FormatRuleDiff(changed); !strings.Contains(diff, "(priority 5, 1 script steps) →") {
+		t.Errorf("diff does not show the steps:\n%s", diff)
+	}
+}
+
+// #endregion rule-plan-tests
@@ -0,0 +1,212 @@
+package projection
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// #region rule-script-types
+
+// ScriptStep is one exchange in a rule script: when the next input matches
+// Expect, a trigger pattern where a bare "{name}" accepts any input, the rule
+// block asks for Response. Captures from the opening trigger and earlier
+// steps can be used in Response.
+type ScriptStep struct {
+	Expect   string
+	Response string
+}
+
+// Outcomes of ScriptRun.Advance.
+const (
+	ScriptStepped   = "stepped"    // input matched the expected step; more follow
+	ScriptFinished  = "finished"   // input matched the last step
+	ScriptOffScript = "off_script" // input did not match the expected step
+	ScriptTimedOut  = "timed_out"  // too long since the previous exchange
+)
+
+// ScriptRun is a rule script in progress within one session: the rule that
+// opened it, the step expected next, and what has been captured so far.
+type ScriptRun struct {
+	Rule     Rule
+	Next     int
+	Captures map[string]string
+	LastAt   time.Time
+}
+
+// #endregion rule-script-types
+
+// #region rule-script-run
+
+// StartScript begins the script of a rule that just fired, or returns nil if
+// the rule has no steps.
+func StartScript(r Rule, now time.Time) *ScriptRun {
+	if len(r.Steps) == 0 {
+		return nil
+	}
+	captures := map[string]string{}
+	for k, v := range r.Captures {
+		captures[k] = v
+	}
+	return &ScriptRun{Rule: r, Captures: captures, LastAt: now}
+}
+
+// Advance matches input against the step expected next. On ScriptStepped and
+// ScriptFinished it returns the opening rule with the step's response and the
+// captures so far, to resolve and inject like any matched rule; on the other
+// outcomes the script is over and input should be matched as usual. A
+// timeout of 0 never expires.
+func (s *ScriptRun) Advance(input string, now time.Time, timeout time.Duration) (Rule, string) {
+	if timeout > 0 && now.Sub(s.LastAt) > timeout {
+		return Rule{}, ScriptTimedOut
+	}
+	step := s.Rule.Steps[s.Next]
+	m, err := compilePattern(step.Expect, true)
+	if err != nil {
+		return Rule{}, ScriptOffScript
+	}
+	captures, ok := m.match(step.Expect, input)
+	if !ok {
+		return Rule{}, ScriptOffScript
+	}
+	for k, v := range captures {
+		s.Captures[k] = v
+	}
+	s.Next++
+	s.LastAt = now
+
+	r := s.Rule
+	r.Response = step.Response
+	r.Matched = strings.TrimSpace(input)
+	r.Captures = make(map[string]string, len(s.Captures))
+	for k, v := range s.Captures {
+		r.Captures[k] = v
+	}
+	r.Steps = nil
+	if s.Next == len(s.Rule.Steps) {
+		return r, ScriptFinished
+	}
+	return r, ScriptStepped
+}
+
+// Position describes progress as "step n/total" for logs.
+func (s *ScriptRun) Position() string {
+	return fmt.Sprintf("step %d/%d", s.Next, len(s.Rule.Steps))
+}
+
+// #endregion rule-script-run
+
+// #region rule-script-store
+
+// ValidateScriptStep reports why step cannot be stored, or nil. Expect may
+// match any input, since it is only tried while its script is running.
+func ValidateScriptStep(step ScriptStep) error {
+	if strings.TrimSpace(step.Expect) == "" || strings.TrimSpace(step.Response) == "" {
+		return fmt.Errorf("script step expect and response must be non-empty")
+	}
+	if _, err := compilePattern(step.Expect, true); err != nil {
+		return fmt.Errorf("script step %q: %w", step.Expect, err)
+	}
+	return nil
+}
+
+// AddScript stores a rule whose response is followed by steps, replacing any
+// rule with the same trigger. With no steps it is AddWithExpiry. The rule and
+// its steps are written in one transaction: the caller's, for a store bound
+// with WithTx, or one of its own.
+func (s *RuleStore) AddScript(trigger, response string, steps []ScriptStep, priority int, confidence float64, expiresAt time.Time) error {
+	for _, st := range steps {
+		if err := ValidateScriptStep(st); err != nil {
+			return err
+		}
+	}
+	return s.inTx(func(s *RuleStore) error {
+		id, err := s.add(trigger, response, priority, confidence, expiresAt)
+		if err != nil {
+			return err
+		}
+		for i, st := range steps {
+			if _, err := s.db.Exec("INSERT INTO rule_steps (rule_id, position, expect, response) VALUES (?, ?, ?, ?)",
+				id, i, strings.TrimSpace(st.Expect), strings.TrimSpace(st.Response)); err != nil {
+				return fmt.Errorf("insert script step: %w", err)
+			}
+		}
+		return nil
+	})
+}
+
+// inTx runs fn on a store bound to a transaction. A store already bound with
+// WithTx runs fn in the caller's transaction, which commits or rolls back.
+func (s *RuleStore) inTx(fn func(*RuleStore) error) error {
+	db, ok := s.db.(*sql.DB)
+	if !ok {
+		return fn(s)
+	}
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("begin rule transaction: %w", err)
+	}
+	defer tx.Rollback()
+	if err := fn(s.WithTx(tx)); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// attachSteps loads the script steps of rules, in order.
+func (s *RuleStore) attachSteps(rules []Rule) error {
+	if len(rules) == 0 {
+		return nil
+	}
+	rows, err := s.db.Query("SELECT rule_id, expect, response FROM rule_steps ORDER BY rule_id, position")
+	if err != nil {
+		return fmt.Errorf("load script steps: %w", err)
+	}
+	defer rows.Close()
+
+	byRule := map[int][]ScriptStep{}
+	for rows.Next() {
+		var id int
+		var st ScriptStep
+		if err := rows.Scan(&id, &st.Expect, &st.Response); err != nil {
+			return fmt.Errorf("scan script step: %w", err)
+		}
+		byRule[id] = append(byRule[id], st)
+	}
+	for i := range rules {
+		rules[i].Steps = byRule[rules[i].ID]
+	}
+	return rows.Err()
+}
+
+// dropOrphanSteps deletes the steps of rules that no longer exist.
+func (s *RuleStore) dropOrphanSteps() error {
+	if _, err := s.db.Exec("DELETE FROM rule_steps WHERE rule_id NOT IN (SELECT id FROM rules)"); err != nil {
+		return fmt.Errorf("remove script steps: %w", err)
+	}
+	return nil
+}
+
+// UnknownStepVars is UnknownRuleVarsFor across a script's steps: each step's
+// response may use what the trigger and the steps up to it capture.
+func UnknownStepVars(trigger string, steps []ScriptStep) []string {
+	captures := map[string]bool{}
+	addCaptureNames(captures, trigger)
+	seen := map[string]bool{}
+	var unknown []string
+	for _, st := range steps {
+		addCaptureNames(captures, st.Expect)
+		for _, name := range unknownExcept(st.Response, captures) {
+			if !seen[name] {
+				seen[name] = true
+				unknown = append(unknown, name)
+			}
+		}
+	}
+	sort.Strings(unknown)
+	return unknown
+}
+
+// #endregion rule-script-store
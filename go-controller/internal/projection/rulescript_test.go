@@ -0,0 +1,120 @@
+package projection
+
+import (
+	"testing"
+	"time"
+)
+
+func knockKnockScript(t *testing.T) *RuleStore {
+	t.Helper()
+	store, _ := NewRuleStore(testDB(t))
+	steps := []ScriptStep{
+		{Expect: "{name}", Response: "{name} who?"},
+		{Expect: "{name} who {punchline}", Response: "Laugh at {punchline}"},
+	}
+	if err := store.AddScript("knock knock", "Who's there?", steps, 5, 1.0, time.Time{}); err != nil {
+		t.Fatalf("add script: %v", err)
+	}
+	return store
+}
+
+func TestRuleScript_Run(t *testing.T) {
+	store := knockKnockScript(t)
+	matched, _ := store.Match("Knock knock")
+	if len(matched) != 1 || len(matched[0].Steps) != 2 {
+		t.Fatalf("matched = %+v, want the script rule with 2 steps", matched)
+	}
+	if StartScript(Rule{Trigger: "ping"}, time.Now()) != nil {
+		t.Error("a rule without steps should not start a script")
+	}
+
+	now := time.Now()
+	run := StartScript(matched[0], now)
+	step, outcome := run.Advance("Daniel", now.Add(time.Minute), 5*time.Minute)
+	if outcome != ScriptStepped {
+		t.Fatalf("first step outcome = %s", outcome)
+	}
+	if got := ResolveRules([]Rule{step}, nil)[0].Response; got != "Daniel who?" {
+		t.Errorf("first step response = %q", got)
+	}
+	if step.Trigger != "knock knock" || step.ID != matched[0].ID || step.Steps != nil {
+		t.Errorf("step rule = %+v, want the opening rule without steps", step)
+	}
+
+	step, outcome = run.Advance("Daniel who codes all night", now.Add(2*time.Minute), 5*time.Minute)
+	if outcome != ScriptFinished {
+		t.Fatalf("last step outcome = %s", outcome)
+	}
+	if got := ResolveRules([]Rule{step}, nil)[0].Response; got != "Laugh at codes all night" {
+		t.Errorf("last step response = %q", got)
+	}
+	if run.Position() != "step 2/2" {
+		t.Errorf("position = %s", run.Position())
+	}
+}
+
+func TestRuleScript_Release(t *testing.T) {
+	store := knockKnockScript(t)
+	matched, _ := store.Match("knock knock")
+	now := time.Now()
+
+	run := StartScript(matched[0], now)
+	run.Advance("Daniel", now, time.Minute)
+	if _, outcome := run.Advance("what is the capital of France?", now, time.Minute); outcome != ScriptOffScript {
+		t.Errorf("unrelated input outcome = %s, want off script", outcome)
+	}
+
+	run = StartScript(matched[0], now)
+	if _, outcome := run.Advance("Daniel", now.Add(2*time.Minute), time.Minute); outcome != ScriptTimedOut {
+		t.Errorf("late input outcome = %s, want timed out", outcome)
+	}
+	if _, outcome := run.Advance("Daniel", now.Add(time.Hour), 0); outcome != ScriptStepped {
+		t.Errorf("no timeout: outcome = %s, want stepped", outcome)
+	}
+}
+
+func TestRuleScript_StepsReplacedWithRule(t *testing.T) {
+	store := knockKnockScript(t)
+	if err := store.AddScript("knock knock", "Who is it?", nil, 5, 1.0, time.Time{}); err != nil {
+		t.Fatal(err)
+	}
+	rules, _ := store.List()
+	if len(rules) != 1 || len(rules[0].Steps) != 0 {
+		t.Errorf("rules = %+v, want the restated rule without the old steps", rules)
+	}
+	var n int
+	store.db.QueryRow("SELECT COUNT(*) FROM rule_steps").Scan(&n)
+	if n != 0 {
+		t.Errorf("%d orphaned steps left", n)
+	}
+	if err := store.AddScript("ping", "pong", []ScriptStep{{Expect: "", Response: "x"}}, 5, 1.0, time.Time{}); err == nil {
+		t.Error("expected an empty step to be rejected")
+	}
+}
+
+func TestRuleScript_FailedStepKeepsOldScript(t *testing.T) {
+	store := knockKnockScript(t)
+	if _, err := store.db.Exec(`CREATE TRIGGER fail_second_step BEFORE INSERT ON rule_steps
+		WHEN NEW.position = 1 BEGIN SELECT RAISE(ABORT, 'step write failed'); END`); err != nil {
+		t.Fatal(err)
+	}
+	steps := []ScriptStep{{Expect: "{name}", Response: "Which {name}?"}, {Expect: "{name} who", Response: "Ha"}}
+	if err := store.AddScript("  knock knock ", "Who goes there?", steps, 5, 1.0, time.Time{}); err == nil {
+		t.Fatal("expected the failing step write to fail AddScript")
+	}
+	rules, _ := store.List()
+	if len(rules) != 1 || rules[0].Response != "Who's there?" || len(rules[0].Steps) != 2 || rules[0].Steps[0].Response != "{name} who?" {
+		t.Errorf("rules = %+v, want the old script untouched", rules)
+	}
+}
+
+func TestUnknownStepVars(t *testing.T) {
+	steps := []ScriptStep{
+		{Expect: "{name}", Response: "{name} who? {punchline}"},
+		{Expect: "{name} who {punchline}", Response: "{punchline} {user_name} {typo}"},
+	}
+	got := UnknownStepVars("knock knock", steps)
+	if len(got) != 2 || got[0] != "punchline" || got[1] != "typo" {
+		t.Errorf("UnknownStepVars = %v, want [punchline typo]", got)
+	}
+}
@@ -0,0 +1,143 @@
+package projection
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// #region rule-template-vars
+
+// Rule template variables beyond the profile fields (user_name, user_pronouns,
+// user_honorific, ai_designation), which are variables under their own names.
+const (
+	RuleVarTopGoal       = "top_goal"       // goal of the active plan
+	RuleVarCurrentStep   = "current_step"   // active plan's first unfinished step
+	RuleVarTopPreference = "top_preference" // most recently stated or reinforced preference
+	RuleVarDate          = "date"           // 2006-01-02, local time at fire time
+	RuleVarWeekday       = "weekday"        // Monday … Sunday
+)
+
+// RuleVarNames lists every variable a rule response may reference.
+var RuleVarNames = append(append([]string{}, ProfileFields...),
+	RuleVarTopGoal, RuleVarCurrentStep, RuleVarTopPreference, RuleVarDate, RuleVarWeekday)
+
+// RuleVars holds the values rule templates resolve against at fire time.
+// A variable that is unset or empty counts as missing.
+type RuleVars map[string]string
+
+// NewRuleVars collects template values from the profile, the stored
+// preferences, the active plan's goal and current step ("" when there is no
+// plan), and the clock.
+func NewRuleVars(profile map[string]ProfileField, prefs []Preference, goal, step string, now time.Time) RuleVars {
+	vars := RuleVars{
+		RuleVarTopGoal:     goal,
+		RuleVarCurrentStep: step,
+		RuleVarDate:        now.Format("2006-01-02"),
+		RuleVarWeekday:     now.Weekday().String(),
+	}
+	for field, f := range profile {
+		vars[field] = f.Value
+	}
+	var latest time.Time
+	for _, p := range prefs {
+		at := p.CreatedAt
+		if p.LastReinforcedAt.After(at) {
+			at = p.LastReinforcedAt
+		}
+		if vars[RuleVarTopPreference] == "" || !at.Before(latest) {
+			vars[RuleVarTopPreference], latest = p.Text, at
+		}
+	}
+	return vars
+}
+
+// #endregion rule-template-vars
+
+// #region rule-template-render
+
+// templateVar matches {name} and {name|fallback}.
+var templateVar = regexp.MustCompile(`\{([a-z_]+)(?:\|([^{}]*))?\}`)
+
+// isRuleVar reports whether name is a known template variable.
+func isRuleVar(name string) bool {
+	for _, v := range RuleVarNames {
+		if v == name {
+			return true
+		}
+	}
+	return false
+}
+
+// RenderRuleResponse resolves a rule response template against vars. A missing
+// variable takes its fallback ("{user_name|there}") or, without one, is dropped
+// along with the space or punctuation it leaves dangling. Braces that do not
+// name a known variable are kept as written, so plain responses are unchanged.
+func RenderRuleResponse(response string, vars RuleVars) string {
+	if !strings.Contains(response, "{") {
+		return response
+	}
+	dropped := false
+	out := templateVar.ReplaceAllStringFunc(response, func(m string) string {
+		parts := templateVar.FindStringSubmatch(m)
+		if !isRuleVar(parts[1]) {
+			return m
+		}
+		if v := strings.TrimSpace(vars[parts[1]]); v != "" {
+			return v
+		}
+		if parts[2] == "" {
+			dropped = true
+		}
+		return strings.TrimSpace(parts[2])
+	})
+	if dropped {
+		out = tidyDropped(out)
+	}
+	return out
+}
+
+var (
+	multiSpace       = regexp.MustCompile(`[ \t]{2,}`)
+	spaceBeforePunct = regexp.MustCompile(`\s+([,.;:!?])`)
+	repeatedPunct    = regexp.MustCompile(`([,;:])\s*[,;:]`)
+)
+
+// tidyDropped cleans up after variables rendered to nothing: doubled spaces,
+// spaces before punctuation, stacked separators and a trailing separator.
+func tidyDropped(s string) string {
+	s = multiSpace.ReplaceAllString(s, " ")
+	s = spaceBeforePunct.ReplaceAllString(s, "$1")
+	s = repeatedPunct.ReplaceAllString(s, "$1")
+	s = strings.TrimSpace(s)
+	return strings.TrimSpace(strings.TrimRight(s, ",;:"))
+}
+
+// ResolveRules returns copies of rules with their responses rendered against
+// vars, then with the trigger's captures substituted ({service}, {1}).
+func ResolveRules(rules []Rule, vars RuleVars) []Rule {
+	out := make([]Rule, len(rules))
+	for i, r := range rules {
+		r.Response = substituteCaptures(RenderRuleResponse(r.Response, vars), r.Captures)
+		out[i] = r
+	}
+	return out
+}
+
+// UnknownRuleVars returns the distinct {names} in response that are not
+// template variables, sorted, so a rule author can be warned about typos.
+func UnknownRuleVars(response string) []string {
+	seen := map[string]bool{}
+	var unknown []string
+	for _, m := range templateVar.FindAllStringSubmatch(response, -1) {
+		if name := m[1]; !isRuleVar(name) && !seen[name] {
+			seen[name] = true
+			unknown = append(unknown, name)
+		}
+	}
+	sort.Strings(unknown)
+	return unknown
+}
+
+// #endregion rule-template-render
@@ -0,0 +1,77 @@
+package projection
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// #region rule-template-tests
+
+func TestRenderRuleResponse(t *testing.T) {
+	vars := RuleVars{ProfileUserName: "Dana", RuleVarTopGoal: "ship the parser", RuleVarWeekday: "Monday"}
+	tests := []struct {
+		response, want string
+	}{
+		{"Good morning {user_name}, focus today: {top_goal}", "Good morning Dana, focus today: ship the parser"},
+		{"Pong!", "Pong!"},
+		{"Happy {weekday}, {ai_designation|your assistant} here.", "Happy Monday, your assistant here."},
+		{"Hi {user_pronouns}!", "Hi!"},                                            // missing without fallback is dropped
+		{"Step: {current_step}, goal: {top_goal}", "Step: goal: ship the parser"}, // stacked separators collapse
+		{"Use {braces} and {user_name}", "Use {braces} and Dana"},                 // unknown names are left as written
+		{"Let's start with {}", "Let's start with {}"},
+	}
+	for _, tt := range tests {
+		if got := RenderRuleResponse(tt.response, vars); got != tt.want {
+			t.Errorf("RenderRuleResponse(%q) = %q, want %q", tt.response, got, tt.want)
+		}
+	}
+
+	empty := RenderRuleResponse("Good morning {user_name}, focus today: {top_goal}", RuleVars{})
+	if empty != "Good morning, focus today" {
+		t.Errorf("all variables missing should leave a tidy sentence, got %q", empty)
+	}
+}
+
+func TestNewRuleVars(t *testing.T) {
+	base := time.Date(2026, 3, 9, 8, 0, 0, 0, time.UTC) // a Monday
+	profile := map[string]ProfileField{ProfileUserName: {Field: ProfileUserName, Value: "Dana"}}
+	prefs := []Preference{
+		{Text: "Be concise", CreatedAt: base.Add(-72 * time.Hour), LastReinforcedAt: base.Add(-time.Hour)},
+		{Text: "Use British spelling", CreatedAt: base.Add(-24 * time.Hour)},
+	}
+	vars := NewRuleVars(profile, prefs, "ship the parser", "write tests", base)
+	want := RuleVars{
+		ProfileUserName:      "Dana",
+		RuleVarTopGoal:       "ship the parser",
+		RuleVarCurrentStep:   "write tests",
+		RuleVarTopPreference: "Be concise", // reinforced more recently than the newer preference
+		RuleVarDate:          "2026-03-09",
+		RuleVarWeekday:       "Monday",
+	}
+	for k, v := range want {
+		if vars[k] != v {
+			t.Errorf("vars[%s] = %q, want %q", k, vars[k], v)
+		}
+	}
+}
+
+func TestResolveRules_LeavesStoredRulesUntouched(t *testing.T) {
+	rules := []Rule{{ID: 1, Trigger: "good morning", Response: "Morning {user_name|friend}!"}}
+	resolved := ResolveRules(rules, RuleVars{ProfileUserName: "Dana"})
+	if resolved[0].Response != "Morning Dana!" || rules[0].Response != "Morning {user_name|friend}!" {
+		t.Errorf("unexpected resolution: resolved=%q original=%q", resolved[0].Response, rules[0].Response)
+	}
+	if block := FormatRulesBlock(resolved); !strings.Contains(block, "You respond with: Morning Dana!") {
+		t.Errorf("rules block should carry the rendered response: %q", block)
+	}
+}
+
+func TestUnknownRuleVars(t *testing.T) {
+	got := UnknownRuleVars("Hi {usr_name}, {top_goal} / {usr_name} {weekdy|today}")
+	if strings.Join(got, ",") != "usr_name,weekdy" {
+		t.Errorf("UnknownRuleVars = %v", got)
+	}
+}
+
+// #endregion rule-template-tests
@@ -0,0 +1,242 @@
+package projection
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// #region rule-trigger-types
+
+// Trigger kinds. A trigger starting with RegexTriggerPrefix is a regular
+// expression; one with {name} placeholders is a template; anything else
+// matches the whole input exactly, ignoring case.
+const (
+	TriggerExact    = "exact"
+	TriggerTemplate = "template"
+	TriggerRegex    = "regex"
+)
+
+// RegexTriggerPrefix marks a regex trigger: "re:^deploy (\w+) to (?P<env>\w+)$".
+const RegexTriggerPrefix = "re:"
+
+// maxTriggerLen bounds trigger patterns; RE2 matches in linear time, so
+// length is the only cost worth capping.
+const maxTriggerLen = 500
+
+// triggerPlaceholder matches a {name} placeholder in a template trigger.
+var triggerPlaceholder = regexp.MustCompile(`\{([a-z_]+)\}`)
+
+// captureRef matches a {name} or {1} capture reference in a rule response.
+var captureRef = regexp.MustCompile(`\{([a-z0-9_]+)\}`)
+
+// TriggerKind returns the kind of trigger.
+func TriggerKind(trigger string) string {
+	switch {
+	case strings.HasPrefix(trigger, RegexTriggerPrefix):
+		return TriggerRegex
+	case triggerPlaceholder.MatchString(trigger):
+		return TriggerTemplate
+	}
+	return TriggerExact
+}
+
+// #endregion rule-trigger-types
+
+// #region rule-trigger-compile
+
+// triggerMatcher is a compiled trigger. re is nil for exact triggers.
+type triggerMatcher struct {
+	re *regexp.Regexp
+}
+
+// compileTrigger compiles trigger for matching, rejecting patterns that are
+// too long, invalid, or would match any input (which would lock every turn
+// into the rule).
+func compileTrigger(trigger string) (triggerMatcher, error) {
+	return compilePattern(trigger, false)
+}
+
+// compilePattern is compileTrigger; with anyInput, a pattern may also match
+// any input, as a script step expecting "{name}" does.
+func compilePattern(trigger string, anyInput bool) (triggerMatcher, error) {
+	trigger = strings.TrimSpace(trigger)
+	if len(trigger) > maxTriggerLen {
+		return triggerMatcher{}, fmt.Errorf("trigger longer than %d characters", maxTriggerLen)
+	}
+	var pattern string
+	switch TriggerKind(trigger) {
+	case TriggerExact:
+		return triggerMatcher{}, nil
+	case TriggerRegex:
+		pattern = strings.TrimSpace(strings.TrimPrefix(trigger, RegexTriggerPrefix))
+		if pattern == "" {
+			return triggerMatcher{}, fmt.Errorf("empty regex trigger")
+		}
+	case TriggerTemplate:
+		var err error
+		if pattern, err = templatePattern(trigger, anyInput); err != nil {
+			return triggerMatcher{}, err
+		}
+	}
+	re, err := regexp.Compile("(?i)" + pattern)
+	if err != nil {
+		return triggerMatcher{}, fmt.Errorf("invalid trigger pattern: %w", err)
+	}
+	if !anyInput && (re.MatchString("") || re.MatchString(" ")) {
+		return triggerMatcher{}, fmt.Errorf("trigger pattern matches empty input, so it would match every turn")
+	}
+	return triggerMatcher{re: re}, nil
+}
+
+// templatePattern turns "deploy {service} to {env}" into an anchored regex:
+// literal text matches case-insensitively with any run of whitespace where
+// the template has one, each placeholder captures one or more characters,
+// and trailing punctuation is ignored. Placeholders must be distinct, must
+// not shadow a template variable, and unless anyInput the template needs a
+// literal word.
+func templatePattern(trigger string, anyInput bool) (string, error) {
+	trigger = strings.Join(strings.Fields(trigger), " ")
+	literal := func(s string) string {
+		return strings.ReplaceAll(regexp.QuoteMeta(s), " ", `\s+`)
+	}
+	var b strings.Builder
+	b.WriteString(`^\s*`)
+	seen := map[string]bool{}
+	words := false
+	last := 0
+	for _, loc := range triggerPlaceholder.FindAllStringSubmatchIndex(trigger, -1) {
+		name := trigger[loc[2]:loc[3]]
+		if seen[name] {
+			return "", fmt.Errorf("placeholder {%s} used twice", name)
+		}
+		if isRuleVar(name) {
+			return "", fmt.Errorf("placeholder {%s} shadows the template variable of that name", name)
+		}
+		seen[name] = true
+		words = words || hasWord(trigger[last:loc[0]])
+		b.WriteString(literal(trigger[last:loc[0]]))
+		fmt.Fprintf(&b, `(?P<%s>.+?)`, name)
+		last = loc[1]
+	}
+	if !anyInput && !words && !hasWord(trigger[last:]) {
+		return "", fmt.Errorf("template trigger needs at least one literal word")
+	}
+	b.WriteString(literal(strings.TrimRight(trigger[last:], ".!?")))
+	b.WriteString(`\s*[.!?]*\s*$`)
+	return b.String(), nil
+}
+
+// hasWord reports whether s contains a letter or digit.
+func hasWord(s string) bool {
+	return strings.IndexFunc(s, func(r rune) bool { return unicode.IsLetter(r) || unicode.IsDigit(r) }) >= 0
+}
+
+// ValidateTrigger reports why trigger cannot be stored, or nil.
+func ValidateTrigger(trigger string) error {
+	_, err := compileTrigger(trigger)
+	return err
+}
+
+// match reports whether input matches, with the named and numbered captures
+// of a pattern trigger.
+func (m triggerMatcher) match(trigger, input string) (map[string]string, bool) {
+	input = strings.TrimSpace(input)
+	if m.re == nil {
+		return nil, strings.EqualFold(strings.TrimSpace(trigger), input)
+	}
+	sub := m.re.FindStringSubmatch(input)
+	if sub == nil {
+		return nil, false
+	}
+	captures := map[string]string{}
+	for i, name := range m.re.SubexpNames() {
+		if i == 0 {
+			continue
+		}
+		v := strings.TrimSpace(sub[i])
+		captures[strconv.Itoa(i)] = v
+		if name != "" {
+			captures[strings.ToLower(name)] = v
+		}
+	}
+	return captures, true
+}
+
+// #endregion rule-trigger-compile
+
+// #region rule-trigger-resolve
+
+// triggerRank orders the kinds for conflict resolution: the more specific
+// kind wins among rules of equal priority.
+var triggerRank = map[string]int{TriggerExact: 0, TriggerTemplate: 1, TriggerRegex: 2}
+
+// sortMatches orders matched rules for conflict resolution: highest priority
+// first, then exact over template over regex triggers, then the longer
+// trigger; rules otherwise tied keep their order.
+func sortMatches(rules []Rule) {
+	sort.SliceStable(rules, func(i, j int) bool {
+		a, b := rules[i], rules[j]
+		if a.Priority != b.Priority {
+			return a.Priority > b.Priority
+		}
+		if ka, kb := triggerRank[TriggerKind(a.Trigger)], triggerRank[TriggerKind(b.Trigger)]; ka != kb {
+			return ka < kb
+		}
+		return len(a.Trigger) > len(b.Trigger)
+	})
+}
+
+// substituteCaptures replaces {name} and {1} references to captures in
+// response; other braces are kept as written.
+func substituteCaptures(response string, captures map[string]string) string {
+	if len(captures) == 0 {
+		return response
+	}
+	return captureRef.ReplaceAllStringFunc(response, func(m string) string {
+		if v, ok := captures[m[1:len(m)-1]]; ok {
+			return v
+		}
+		return m
+	})
+}
+
+// UnknownRuleVarsFor is UnknownRuleVars for a rule with trigger: names the
+// trigger captures are not unknown.
+func UnknownRuleVarsFor(trigger, response string) []string {
+	captures := map[string]bool{}
+	addCaptureNames(captures, trigger)
+	return unknownExcept(response, captures)
+}
+
+// addCaptureNames adds the names pattern captures under to names.
+func addCaptureNames(names map[string]bool, pattern string) {
+	switch TriggerKind(pattern) {
+	case TriggerTemplate:
+		for _, m := range triggerPlaceholder.FindAllStringSubmatch(pattern, -1) {
+			names[m[1]] = true
+		}
+	case TriggerRegex:
+		if m, err := compilePattern(pattern, true); err == nil {
+			for _, name := range m.re.SubexpNames() {
+				names[strings.ToLower(name)] = true
+			}
+		}
+	}
+}
+
+// unknownExcept is UnknownRuleVars leaving out captures.
+func unknownExcept(response string, captures map[string]bool) []string {
+	var unknown []string
+	for _, name := range UnknownRuleVars(response) {
+		if !captures[name] {
+			unknown = append(unknown, name)
+		}
+	}
+	return unknown
+}
+
+// #endregion rule-trigger-resolve
@@ -0,0 +1,163 @@
+package projection
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestTriggerKind(t *testing.T) {
+	cases := map[string]string{
+		"knock knock":           TriggerExact,
+		"deploy {service}":      TriggerTemplate,
+		`re:^ship (\w+)$`:       TriggerRegex,
+		"hi {user_name|there}":  TriggerExact, // fallbacks belong in responses
+		"tell me about {Topic}": TriggerExact,
+	}
+	for trigger, want := range cases {
+		if got := TriggerKind(trigger); got != want {
+			t.Errorf("TriggerKind(%q) = %s, want %s", trigger, got, want)
+		}
+	}
+}
+
+func TestValidateTrigger(t *testing.T) {
+	valid := []string{"knock knock", "deploy {service} to {env}", `re:^status of (?P<host>\S+)$`}
+	for _, trigger := range valid {
+		if err := ValidateTrigger(trigger); err != nil {
+			t.Errorf("ValidateTrigger(%q) = %v, want nil", trigger, err)
+		}
+	}
+	invalid := map[string]string{
+		"re:(unclosed":      "invalid trigger pattern",
+		"re:   ":            "empty regex trigger",
+		"re:.*":             "matches empty input",
+		"re:^(deploy)?$":    "matches empty input",
+		"{thing}":           "literal word",
+		"{a} {b}!":          "literal word",
+		"move {x} to {x}":   "used twice",
+		"greet {user_name}": "shadows",
+		"re:" + strings.Repeat("a", maxTriggerLen): "longer than",
+	}
+	for trigger, want := range invalid {
+		err := ValidateTrigger(trigger)
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("ValidateTrigger(%q) = %v, want error containing %q", trigger, err, want)
+		}
+	}
+}
+
+func TestRuleStore_RejectsInvalidTrigger(t *testing.T) {
+	store, _ := NewRuleStore(testDB(t))
+	if err := store.Add("re:[", "oops", 5, 1.0); err == nil {
+		t.Error("expected an invalid regex trigger to be rejected")
+	}
+	if rules, _ := store.List(); len(rules) != 0 {
+		t.Errorf("rules = %+v, want none stored", rules)
+	}
+}
+
+func TestRuleStore_MatchTemplate(t *testing.T) {
+	store, _ := NewRuleStore(testDB(t))
+	store.Add("deploy {service} to {env}", "Deploying {service} to {env}, {user_name|boss}.", 5, 1.0)
+
+	cases := []struct {
+		input string
+		want  map[string]string
+	}{
+		{"deploy api to staging", map[string]string{"service": "api", "env": "staging"}},
+		{"  Deploy   billing-api  to PROD!  ", map[string]string{"service": "billing-api", "env": "PROD"}},
+		{"deploy the web app to eu west?", map[string]string{"service": "the web app", "env": "eu west"}},
+	}
+	for _, c := range cases {
+		matched, err := store.Match(c.input)
+		if err != nil || len(matched) != 1 {
+			t.Fatalf("Match(%q) = %+v, %v; want one match", c.input, matched, err)
+		}
+		for name, v := range c.want {
+			if matched[0].Captures[name] != v {
+				t.Errorf("Match(%q) captures = %v, want %s=%q", c.input, matched[0].Captures, name, v)
+			}
+		}
+		if matched[0].Matched != strings.TrimSpace(c.input) {
+			t.Errorf("Matched = %q", matched[0].Matched)
+		}
+	}
+	for _, input := range []string{"deploy api", "please deploy api to staging", "deploy to staging"} {
+		if matched, _ := store.Match(input); len(matched) != 0 {
+			t.Errorf("Match(%q) = %+v, want no match", input, matched)
+		}
+	}
+
+	matched, _ := store.Match("deploy api to staging")
+	resolved := ResolveRules(matched, RuleVars{"user_name": "Dana"})
+	if got := resolved[0].Response; got != "Deploying api to staging, Dana." {
+		t.Errorf("resolved response = %q", got)
+	}
+	if block := FormatRulesBlock(resolved); !strings.Contains(block, "If user says: deploy api to staging →") {
+		t.Errorf("rules block shows the template instead of the input:\n%s", block)
+	}
+}
+
+func TestRuleStore_MatchRegex(t *testing.T) {
+	store, _ := NewRuleStore(testDB(t))
+	store.Add(`re:^restart (\w+) on (?P<Host>\S+)$`, "Restarting {1} on {host} ({2}); {other} stays", 5, 1.0)
+
+	matched, _ := store.Match("RESTART nginx on web-01")
+	if len(matched) != 1 {
+		t.Fatalf("matched = %+v, want one", matched)
+	}
+	want := map[string]string{"1": "nginx", "2": "web-01", "host": "web-01"}
+	if !reflect.DeepEqual(matched[0].Captures, want) {
+		t.Errorf("captures = %v, want %v", matched[0].Captures, want)
+	}
+	resolved := ResolveRules(matched, nil)
+	if got := resolved[0].Response; got != "Restarting nginx on web-01 (web-01); {other} stays" {
+		t.Errorf("resolved response = %q", got)
+	}
+}
+
+func TestRuleStore_MatchConflicts(t *testing.T) {
+	store, _ := NewRuleStore(testDB(t))
+	store.Add(`re:^deploy .+$`, "regex", 5, 1.0)
+	store.Add("deploy {service}", "template", 5, 1.0)
+	store.Add("deploy {service} now", "longer template", 5, 1.0)
+	store.Add("deploy api now", "exact", 5, 1.0)
+	store.Add(`re:^deploy (\w+) now$`, "urgent", 8, 1.0)
+
+	all, err := store.MatchAll("deploy api now")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var order []string
+	for _, r := range all {
+		order = append(order, r.Response)
+	}
+	want := []string{"urgent", "exact", "longer template", "template", "regex"}
+	if !reflect.DeepEqual(order, want) {
+		t.Errorf("match order = %v, want %v", order, want)
+	}
+	if m, _ := store.Match("deploy api now"); len(m) != 1 || m[0].Response != "urgent" {
+		t.Errorf("Match = %+v, want only the highest-priority rule", m)
+	}
+	if m, _ := store.Match("deploy web"); len(m) != 1 || m[0].Response != "template" {
+		t.Errorf("Match = %+v, want the template to beat the regex", m)
+	}
+}
+
+func TestUnknownRuleVarsFor(t *testing.T) {
+	cases := []struct {
+		trigger, response string
+		want              []string
+	}{
+		{"deploy {service}", "Deploying {service} for {user_name} {oops}", []string{"oops"}},
+		{`re:^ship (?P<Box>\w+)$`, "Shipping {box} {1}", []string{}},
+		{"knock knock", "{service}", []string{"service"}},
+	}
+	for _, c := range cases {
+		got := UnknownRuleVarsFor(c.trigger, c.response)
+		if len(got) != len(c.want) || (len(got) > 0 && !reflect.DeepEqual(got, c.want)) {
+			t.Errorf("UnknownRuleVarsFor(%q, %q) = %v, want %v", c.trigger, c.response, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,188 @@
+package projection
+
+import (
+	"strings"
+)
+
+// #region scope-types
+
+// Preference scopes: the turn contexts a preference can be limited to.
+// An unscoped preference (ScopeAll) applies to every turn.
+const (
+	ScopeAll     = ""
+	ScopeCoding  = "coding"
+	ScopeWriting = "writing"
+	ScopeChat    = "chat"
+)
+
+// IsScope reports whether s names a preference scope.
+func IsScope(s string) bool {
+	switch s {
+	case ScopeAll, ScopeCoding, ScopeWriting, ScopeChat:
+		return true
+	}
+	return false
+}
+
+// #endregion scope-types
+
+// #region turn-context
+
+// codingMarkers are substrings that only show up when code is on the table.
+var codingMarkers = []string{
+	"```", "func ", "def ", "stack trace", "traceback", "pull request",
+	"code review", "unit test", "null pointer", "segfault",
+}
+
+// codingWords and writingWords are matched against whole words of the prompt.
+var codingWords = map[string]bool{
+	"code": true, "coding": true, "function": true, "bug": true, "debug": true,
+	"refactor": true, "compile": true, "compiler": true, "syntax": true, "regex": true,
+	"sql": true, "api": true, "script": true, "variable": true, "golang": true,
+	"python": true, "javascript": true, "typescript": true, "programming": true,
+}
+
+var writingWords = map[string]bool{
+	"essay": true, "draft": true, "poem": true, "story": true, "proofread": true,
+	"rewrite": true, "paragraph": true, "blog": true, "email": true, "outline": true,
+	"brainstorm": true, "brainstorming": true, "prose": true, "chapter": true,
+}
+
+// TurnContext maps a turn to the preference scope it falls under. turnType is
+// the orchestrator's classification ("creative", "factual", ...); code in the
+// prompt wins over it, since the classifier has no coding type.
+func TurnContext(turnType, prompt string) string {
+	lower := strings.ToLower(prompt)
+	for _, m := range codingMarkers {
+		if strings.Contains(lower, m) {
+			return ScopeCoding
+		}
+	}
+	writing := turnType == "creative"
+	for _, w := range strings.FieldsFunc(lower, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9')
+	}) {
+		if codingWords[w] {
+			return ScopeCoding
+		}
+		writing = writing || writingWords[w]
+	}
+	if writing {
+		return ScopeWriting
+	}
+	return ScopeChat
+}
+
+// #endregion turn-context
+
+// #region scope-inference
+
+// scopePhrases are explicit scope qualifiers in a preference's wording.
+var scopePhrases = []struct {
+	phrase string
+	scope  string
+}{
+	{"everywhere", ScopeAll}, {"in general", ScopeAll}, {"no matter what", ScopeAll}, {"for everything", ScopeAll},
+	{"when coding", ScopeCoding}, {"when i'm coding", ScopeCoding}, {"when programming", ScopeCoding},
+	{"code review", ScopeCoding}, {"reviewing code", ScopeCoding}, {"for code", ScopeCoding}, {"in code", ScopeCoding},
+	{"for coding", ScopeCoding},
+	{"when writing", ScopeWriting}, {"when i'm writing", ScopeWriting}, {"for writing", ScopeWriting},
+	{"in my writing", ScopeWriting}, {"when editing", ScopeWriting}, {"when brainstorming", ScopeWriting},
+	{"when chatting", ScopeChat}, {"when we chat", ScopeChat}, {"when we talk", ScopeChat},
+	{"in conversation", ScopeChat}, {"in casual", ScopeChat}, {"for chat", ScopeChat},
+}
+
+// ParseScope returns the scope a preference's wording names ("be terse in code
+// reviews" → coding). explicit is false when the text names none; an explicit
+// ScopeAll ("be terse everywhere") blocks inference.
+func ParseScope(text string) (scope string, explicit bool) {
+	lower := strings.ToLower(text)
+	for _, sp := range scopePhrases {
+		if strings.Contains(lower, sp.phrase) {
+			return sp.scope, true
+		}
+	}
+	return ScopeAll, false
+}
+
+// InferScope returns the context that dominated the recent turns (at least 3
+// turns, two thirds of them in one context). Chat is the default context, so a
+// preference taught during small talk stays unscoped.
+func InferScope(recentContexts []string) string {
+	if len(recentContexts) < 3 {
+		return ScopeAll
+	}
+	counts := map[string]int{}
+	for _, c := range recentContexts {
+		counts[c]++
+	}
+	for _, scope := range []string{ScopeCoding, ScopeWriting} {
+		if counts[scope]*3 >= len(recentContexts)*2 {
+			return scope
+		}
+	}
+	return ScopeAll
+}
+
+// ScopeFor picks the scope for a newly taught preference: explicit wording
+// first, then the dominant context of the recent turns.
+func ScopeFor(text string, recentContexts []string) string {
+	if scope, explicit := ParseScope(text); explicit {
+		return scope
+	}
+	return InferScope(recentContexts)
+}
+
+// #endregion scope-inference
+
+// #region scope-filter
+
+// ScopedTo returns the preferences that apply in context: unscoped ones plus
+// those scoped to it. Between two that apply and have the same or opposing
+// style, the higher priority wins; at equal priority a scoped preference
+// overrides an unscoped one, so "be detailed when writing" beats a global "be
+// concise" on writing turns, and a temporary one overrides a standing one.
+func ScopedTo(prefs []Preference, context string) []Preference {
+	var applies []Preference
+	for _, p := range prefs {
+		if p.Scope == ScopeAll || p.Scope == context {
+			applies = append(applies, p)
+		}
+	}
+	var out []Preference
+	for _, p := range applies {
+		overridden := false
+		for _, q := range applies {
+			if q.ID != p.ID && conflicting(q.Style, p.Style) && outranks(q, p) {
+				overridden = true
+				break
+			}
+		}
+		if !overridden {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// conflicting reports whether preferences of styles a and b cannot both apply.
+func conflicting(a, b PreferenceStyle) bool {
+	if a == StyleGeneral || b == StyleGeneral {
+		return false
+	}
+	return a == b || opposingStyles[a] == b
+}
+
+// outranks reports whether q overrides p when they conflict: by priority,
+// then scoped over unscoped, then temporary over standing.
+func outranks(q, p Preference) bool {
+	if qp, pp := priorityOf(q), priorityOf(p); qp != pp {
+		return qp > pp
+	}
+	if (q.Scope != ScopeAll) != (p.Scope != ScopeAll) {
+		return q.Scope != ScopeAll
+	}
+	return !q.ExpiresAt.IsZero() && p.ExpiresAt.IsZero()
+}
+
+// #endregion scope-filter
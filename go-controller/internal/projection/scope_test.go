@@ -0,0 +1,129 @@
+package projection
+
+import (
+	"strings"
+	"testing"
+)
+
+// #region scope-tests
+
+func TestTurnContext(t *testing.T) {
+	tests := []struct {
+		turnType, prompt, want string
+	}{
+		{"command", "review this function for bugs", ScopeCoding},
+		{"conversational", "why does this panic?\n```go\nvar m map[string]int\n```", ScopeCoding},
+		{"creative", "write a poem about autumn", ScopeWriting},
+		{"conversational", "let's brainstorm names for the shop", ScopeWriting},
+		{"conversational", "how was your weekend", ScopeChat},
+		{"factual", "what is the capital of France", ScopeChat},
+	}
+	for _, tt := range tests {
+		if got := TurnContext(tt.turnType, tt.prompt); got != tt.want {
+			t.Errorf("TurnContext(%q, %q) = %q, want %q", tt.turnType, tt.prompt, got, tt.want)
+		}
+	}
+}
+
+func TestScopeFor(t *testing.T) {
+	coding := []string{ScopeCoding, ScopeCoding, ScopeChat}
+	tests := []struct {
+		text   string
+		recent []string
+		want   string
+	}{
+		{"be terse in code reviews", nil, ScopeCoding},
+		{"be detailed when writing", coding, ScopeWriting}, // wording beats recent turns
+		{"be terse everywhere", coding, ScopeAll},
+		{"be terse", coding, ScopeCoding},
+		{"be terse", []string{ScopeCoding, ScopeCoding}, ScopeAll},             // too few turns
+		{"be terse", []string{ScopeCoding, ScopeWriting, ScopeChat}, ScopeAll}, // no dominant context
+		{"be terse", []string{ScopeChat, ScopeChat, ScopeChat}, ScopeAll},      // chat is the default
+	}
+	for _, tt := range tests {
+		if got := ScopeFor(tt.text, tt.recent); got != tt.want {
+			t.Errorf("ScopeFor(%q, %v) = %q, want %q", tt.text, tt.recent, got, tt.want)
+		}
+	}
+}
+
+func TestScopedTo(t *testing.T) {
+	prefs := []Preference{
+		{ID: 1, Text: "Be concise", Style: StyleConcise},
+		{ID: 2, Text: "Use British spelling", Style: StyleGeneral},
+		{ID: 3, Text: "Be detailed when writing", Style: StyleDetailed, Scope: ScopeWriting},
+		{ID: 4, Text: "Show examples in code", Style: StyleExamples, Scope: ScopeCoding},
+	}
+	ids := func(ps []Preference) []int {
+		var out []int
+		for _, p := range ps {
+			out = append(out, p.ID)
+		}
+		return out
+	}
+	tests := []struct {
+		context string
+		want    []int
+	}{
+		{ScopeChat, []int{1, 2}},
+		{ScopeCoding, []int{1, 2, 4}},
+		{ScopeWriting, []int{2, 3}}, // scoped "detailed" overrides global "concise"
+	}
+	for _, tt := range tests {
+		got := ids(ScopedTo(prefs, tt.context))
+		if len(got) != len(tt.want) {
+			t.Errorf("ScopedTo(%q) = %v, want %v", tt.context, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("ScopedTo(%q) = %v, want %v", tt.context, got, tt.want)
+				break
+			}
+		}
+	}
+}
+
+func TestPreferenceStore_ScopedPreferencesCoexist(t *testing.T) {
+	store, _ := NewPreferenceStore(testDB(t))
+	if err := store.AddScoped("Be terse", "explicit", ScopeCoding); err != nil {
+		t.Fatalf("AddScoped: %v", err)
+	}
+	store.AddScoped("Be detailed", "explicit", ScopeWriting) // same-style replacement is per scope
+	store.Add("Be brief", "explicit")
+	store.AddScoped("Be terse", "explicit", ScopeCoding) // duplicate within scope reinforces
+
+	prefs, _ := store.List()
+	if len(prefs) != 3 {
+		t.Fatalf("expected 3 preferences, got %d: %+v", len(prefs), prefs)
+	}
+	if prefs[0].Scope != ScopeCoding || prefs[1].Scope != ScopeWriting || prefs[2].Scope != ScopeAll {
+		t.Errorf("scopes not round-tripped: %+v", prefs)
+	}
+	if prefs[0].LastReinforcedAt.IsZero() {
+		t.Error("restating a scoped preference should reinforce it")
+	}
+	if err := store.AddScoped("Be terse", "explicit", "meetings"); err == nil {
+		t.Error("expected error for unknown scope")
+	}
+}
+
+func TestPreviewScopedPreference_ConflictsOnlyInScope(t *testing.T) {
+	existing := []Preference{
+		{Text: "Be concise", Style: StyleConcise},
+		{Text: "Be brief in code", Style: StyleConcise, Scope: ScopeCoding},
+	}
+	preview := PreviewScopedPreference("Be detailed when writing", ScopeWriting, existing, nil)
+	if len(preview.Conflicts) != 0 {
+		t.Errorf("writing-scoped preference should not conflict with other scopes: %+v", preview.Conflicts)
+	}
+	preview = PreviewScopedPreference("Always be detailed in code", ScopeCoding, existing, nil)
+	if len(preview.Conflicts) != 1 || preview.Conflicts[0].Scope != ScopeCoding {
+		t.Errorf("expected conflict with the coding preference only, got %+v", preview.Conflicts)
+	}
+	if !strings.Contains(preview.Warning(), "every coding answer") {
+		t.Errorf("warning should name the scope: %q", preview.Warning())
+	}
+}
+
+// #endregion scope-tests
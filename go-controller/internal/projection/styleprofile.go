@@ -0,0 +1,386 @@
+package projection
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/timestamp"
+)
+
+// #region style-profile-types
+
+// Style profile attributes. Unlike preferences, these are never stated by the user —
+// they are inferred from observed exchanges and projected with lower weight.
+const (
+	AttrLanguage  = "language"
+	AttrFormality = "formality"
+	AttrCodeRatio = "code_ratio"
+)
+
+// styleProfileAlpha is the moving-average rate for each new observation.
+const styleProfileAlpha = 0.2
+
+// StyleMinObservations is the number of observations before an attribute is
+// considered stable enough to project.
+const StyleMinObservations = 5
+
+// StyleProjectionWeight scales style confidence relative to explicit preferences.
+const StyleProjectionWeight = 0.5
+
+// StyleObservation is what one user message reveals about interaction style.
+type StyleObservation struct {
+	Language  string  // ISO 639-1 code, "" if undetermined
+	Formality float32 // 0 = casual, 1 = formal; -1 if undetermined
+	CodeRatio float32 // fraction of message lines that are code (0-1)
+}
+
+// StyleAttribute is one persisted style-profile attribute.
+type StyleAttribute struct {
+	Attribute    string
+	Value        string  // language code, or derived label for numeric attributes
+	Score        float64 // agreement (language) or moving average (numeric), 0-1
+	Observations int
+	UpdatedAt    time.Time
+}
+
+// Confidence ramps up with observations and grows with how decisive the score is.
+// Zero below StyleMinObservations.
+func (a StyleAttribute) Confidence() float64 {
+	if a.Observations < StyleMinObservations {
+		return 0
+	}
+	ramp := math.Min(1, float64(a.Observations)/float64(2*StyleMinObservations))
+	if a.Attribute == AttrLanguage {
+		return ramp * a.Score
+	}
+	// Numeric attributes: a score near 0.5 says nothing either way
+	return ramp * math.Abs(a.Score-0.5) * 2
+}
+
+// #endregion style-profile-types
+
+// #region style-profile-store
+
+// StyleProfileStore persists inferred style attributes in SQLite, separately from
+// explicit preferences.
+type StyleProfileStore struct {
+	db *sql.DB
+}
+
+// NewStyleProfileStore creates the style_profile table if needed and returns a store.
+func NewStyleProfileStore(db *sql.DB) (*StyleProfileStore, error) {
+	_, err := db.Exec(`CREATE TABLE IF NOT EXISTS style_profile (
+		attribute TEXT PRIMARY KEY,
+		value TEXT NOT NULL,
+		score REAL NOT NULL,
+		observations INTEGER NOT NULL DEFAULT 0,
+		updated_at TEXT NOT NULL
+	)`)
+	if err != nil {
+		return nil, fmt.Errorf("create style_profile table: %w", err)
+	}
+	if _, err := timestamp.Canonicalize(db, "style_profile", "updated_at"); err != nil {
+		return nil, err
+	}
+	return &StyleProfileStore{db: db}, nil
+}
+
+// Observe folds one observation into the profile.
+// Language: agreement score rises on a match and falls otherwise; the stored language
+// flips when agreement drops below 0.5. Numeric attributes use a moving average.
+func (s *StyleProfileStore) Observe(obs StyleObservation) error {
+	profile, err := s.Get()
+	if err != nil {
+		return err
+	}
+
+	if obs.Language != "" {
+		a, ok := profile[AttrLanguage]
+		if !ok {
+			a = StyleAttribute{Attribute: AttrLanguage, Value: obs.Language, Score: 0.5}
+		}
+		if a.Value == obs.Language {
+			a.Score += styleProfileAlpha * (1 - a.Score)
+		} else {
+			a.Score -= styleProfileAlpha * a.Score
+			if a.Score < 0.5 {
+				a.Value, a.Score = obs.Language, 1-a.Score
+			}
+		}
+		a.Observations++
+		if err := s.put(a); err != nil {
+			return err
+		}
+	}
+
+	if obs.Formality >= 0 {
+		a := observeNumeric(profile, AttrFormality, obs.Formality)
+		a.Value = formalityLabel(a.Score)
+		if err := s.put(a); err != nil {
+			return err
+		}
+	}
+
+	a := observeNumeric(profile, AttrCodeRatio, obs.CodeRatio)
+	a.Value = codeRatioLabel(a.Score)
+	return s.put(a)
+}
+
+// Get returns the profile keyed by attribute name.
+func (s *StyleProfileStore) Get() (map[string]StyleAttribute, error) {
+	rows, err := s.db.Query("SELECT attribute, value, score, observations, updated_at FROM style_profile")
+	if err != nil {
+		return nil, fmt.Errorf("get style profile: %w", err)
+	}
+	defer rows.Close()
+
+	profile := make(map[string]StyleAttribute)
+	for rows.Next() {
+		var a StyleAttribute
+		var ts string
+		if err := rows.Scan(&a.Attribute, &a.Value, &a.Score, &a.Observations, &ts); err != nil {
+			return nil, fmt.Errorf("scan style attribute: %w", err)
+		}
+		a.UpdatedAt, _ = timestamp.Parse(ts)
+		profile[a.Attribute] = a
+	}
+	return profile, rows.Err()
+}
+
+// Reset clears the style profile.
+func (s *StyleProfileStore) Reset() error {
+	if _, err := s.db.Exec("DELETE FROM style_profile"); err != nil {
+		return fmt.Errorf("reset style profile: %w", err)
+	}
+	return nil
+}
+
+func (s *StyleProfileStore) put(a StyleAttribute) error {
+	_, err := s.db.Exec(`INSERT INTO style_profile (attribute, value, score, observations, updated_at)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT(attribute) DO UPDATE SET value = excluded.value, score = excluded.score,
+			observations = excluded.observations, updated_at = excluded.updated_at`,
+		a.Attribute, a.Value, a.Score, a.Observations, timestamp.Now())
+	if err != nil {
+		return fmt.Errorf("upsert style attribute %s: %w", a.Attribute, err)
+	}
+	return nil
+}
+
+func observeNumeric(profile map[string]StyleAttribute, attr string, v float32) StyleAttribute {
+	a, ok := profile[attr]
+	if !ok {
+		a = StyleAttribute{Attribute: attr, Score: float64(v)}
+	} else {
+		a.Score += styleProfileAlpha * (float64(v) - a.Score)
+	}
+	a.Observations++
+	return a
+}
+
+func formalityLabel(score float64) string {
+	switch {
+	case score >= 0.65:
+		return "formal"
+	case score <= 0.35:
+		return "casual"
+	}
+	return "neutral"
+}
+
+func codeRatioLabel(score float64) string {
+	switch {
+	case score >= 0.65:
+		return "code-heavy"
+	case score <= 0.35:
+		return "prose"
+	}
+	return "mixed"
+}
+
+// #endregion style-profile-store
+
+// #region style-observe
+
+// languageStopwords are high-frequency function words used to guess a message's language.
+var languageStopwords = map[string][]string{
+	"en": {"the", "and", "is", "are", "you", "what", "how", "this", "that", "with", "for", "can", "it", "of"},
+	"es": {"el", "la", "los", "las", "que", "es", "por", "para", "con", "una", "cómo", "qué", "pero", "está"},
+	"fr": {"le", "la", "les", "est", "et", "que", "pour", "avec", "une", "des", "vous", "comment", "pas", "je"},
+	"de": {"der", "die", "das", "und", "ist", "nicht", "mit", "ich", "sie", "ein", "eine", "wie", "was", "für"},
+	"pt": {"o", "os", "que", "é", "não", "com", "uma", "para", "você", "como", "mas", "está", "isso", "do"},
+	"it": {"il", "che", "è", "non", "con", "una", "per", "sono", "come", "ma", "questo", "della", "gli", "cosa"},
+}
+
+// LanguageNames maps detected language codes to display names.
+var LanguageNames = map[string]string{
+	"en": "English", "es": "Spanish", "fr": "French", "de": "German", "pt": "Portuguese", "it": "Italian",
+}
+
+// casualWords are matched as whole words; casualMarks as substrings.
+var casualWords = []string{
+	"lol", "haha", "gonna", "wanna", "gotta", "yeah", "yep", "nah", "hey", "cool",
+	"btw", "idk", "tbh", "thx", "pls", "u", "ur",
+}
+var casualMarks = []string{"!!", ":)", ":d"}
+
+var formalMarkers = []string{
+	"please", "could you", "would you", "kindly", "thank you", "regards",
+	"i would like", "furthermore", "however", "therefore", "appreciate",
+}
+
+// codeLineMarkers identify lines that look like source code.
+var codeLineMarkers = []string{
+	"func ", "def ", "class ", "import ", "return ", "package ", "const ", "var ", "let ",
+	"#include", "=>", "();", "{", "}", "):",
+}
+
+// ObserveStyle infers style attributes from one user message. Short messages
+// (under 4 words) leave language and formality undetermined.
+func ObserveStyle(text string) StyleObservation {
+	obs := StyleObservation{Formality: -1, CodeRatio: codeRatio(text)}
+	words := strings.Fields(strings.ToLower(text))
+	if len(words) < 4 {
+		return obs
+	}
+	obs.Language = detectLanguage(words)
+
+	lower := strings.ToLower(text)
+	casual, formal := 0, 0
+	for _, w := range words {
+		w = strings.Trim(w, ".,!?;:\"'()")
+		for _, cw := range casualWords {
+			if w == cw {
+				casual++
+				break
+			}
+		}
+	}
+	for _, m := range casualMarks {
+		if strings.Contains(lower, m) {
+			casual++
+		}
+	}
+	for _, m := range formalMarkers {
+		if strings.Contains(lower, m) {
+			formal++
+		}
+	}
+	if casual+formal > 0 {
+		obs.Formality = float32(formal) / float32(casual+formal)
+	}
+	return obs
+}
+
+// detectLanguage returns the language whose stopwords best cover words, requiring
+// at least two hits and a clear lead over the runner-up.
+func detectLanguage(words []string) string {
+	hits := make(map[string]int)
+	for _, w := range words {
+		w = strings.Trim(w, ".,!?;:¿¡\"'()")
+		for lang, stops := range languageStopwords {
+			for _, sw := range stops {
+				if w == sw {
+					hits[lang]++
+					break
+				}
+			}
+		}
+	}
+	best, bestN, secondN := "", 0, 0
+	for lang, n := range hits {
+		if n > bestN || (n == bestN && lang < best) {
+			best, bestN, secondN = lang, n, bestN
+		} else if n > secondN {
+			secondN = n
+		}
+	}
+	if bestN < 2 || bestN == secondN {
+		return ""
+	}
+	return best
+}
+
+// codeRatio returns the fraction of non-empty lines that are code: lines inside
+// fenced blocks or lines carrying a code marker.
+func codeRatio(text string) float32 {
+	total, code := 0, 0
+	inFence := false
+	for _, line := range strings.Split(text, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") {
+			inFence = !inFence
+			continue
+		}
+		if trimmed == "" {
+			continue
+		}
+		total++
+		if inFence {
+			code++
+			continue
+		}
+		for _, m := range codeLineMarkers {
+			if strings.Contains(trimmed, m) {
+				code++
+				break
+			}
+		}
+	}
+	if total == 0 {
+		return 0
+	}
+	return float32(code) / float32(total)
+}
+
+// #endregion style-observe
+
+// #region style-project
+
+// ProjectStyleProfile builds the [STYLE PROFILE] block. Only attributes with
+// confidence are included, and the reported confidence is scaled by
+// StyleProjectionWeight so the model treats it as softer than explicit preferences.
+// English and neutral/mixed values are defaults and are not projected.
+// Returns "" when nothing qualifies.
+func ProjectStyleProfile(profile map[string]StyleAttribute) string {
+	var lines []string
+	var confSum float64
+
+	if a, ok := profile[AttrLanguage]; ok && a.Confidence() > 0 && a.Value != "en" {
+		name := LanguageNames[a.Value]
+		if name == "" {
+			name = a.Value
+		}
+		lines = append(lines, fmt.Sprintf("- Usually writes in %s; reply in %s unless asked otherwise", name, name))
+		confSum += a.Confidence()
+	}
+	if a, ok := profile[AttrFormality]; ok && a.Confidence() > 0 && a.Value != "neutral" {
+		lines = append(lines, fmt.Sprintf("- Tone tends to be %s", a.Value))
+		confSum += a.Confidence()
+	}
+	if a, ok := profile[AttrCodeRatio]; ok && a.Confidence() > 0 && a.Value != "mixed" {
+		if a.Value == "code-heavy" {
+			lines = append(lines, "- Works mostly in code; favor code over prose")
+		} else {
+			lines = append(lines, "- Works mostly in prose; use code only when needed")
+		}
+		confSum += a.Confidence()
+	}
+	if len(lines) == 0 {
+		return ""
+	}
+
+	confidence := confSum / float64(len(lines)) * StyleProjectionWeight
+	var b strings.Builder
+	b.WriteString("[STYLE PROFILE — inferred, defer to explicit preferences]\n")
+	for _, l := range lines {
+		b.WriteString(l + "\n")
+	}
+	b.WriteString(fmt.Sprintf("(confidence: %.0f%%)\n", math.Round(confidence*100)))
+	return b.String()
+}
+
+// #endregion style-project
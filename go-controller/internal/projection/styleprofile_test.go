@@ -0,0 +1,141 @@
+package projection
+
+import (
+	"strings"
+	"testing"
+)
+
+// #region style-observe-tests
+
+func TestObserveStyle_Language(t *testing.T) {
+	cases := []struct {
+		text string
+		want string
+	}{
+		{"what is the best way to handle this error in my code", "en"},
+		{"¿cómo puedo mejorar el rendimiento de la base de datos para una consulta?", "es"},
+		{"ich habe eine Frage und das ist nicht einfach", "de"},
+		{"ok thanks", ""}, // too short
+	}
+	for _, tc := range cases {
+		if got := ObserveStyle(tc.text).Language; got != tc.want {
+			t.Errorf("ObserveStyle(%q).Language = %q, want %q", tc.text, got, tc.want)
+		}
+	}
+}
+
+func TestObserveStyle_Formality(t *testing.T) {
+	if f := ObserveStyle("hey lol gonna try that thing tbh").Formality; f != 0 {
+		t.Errorf("expected casual formality 0, got %.2f", f)
+	}
+	if f := ObserveStyle("Could you kindly review the attached report? Thank you.").Formality; f != 1 {
+		t.Errorf("expected formal formality 1, got %.2f", f)
+	}
+	if f := ObserveStyle("tell me about the weather today").Formality; f != -1 {
+		t.Errorf("expected undetermined formality -1, got %.2f", f)
+	}
+	// "you" must not count as the casual "u"
+	if f := ObserveStyle("would you explain how this works").Formality; f != 1 {
+		t.Errorf("expected formal formality 1, got %.2f", f)
+	}
+}
+
+func TestObserveStyle_CodeRatio(t *testing.T) {
+	text := "why does this fail?\n```\nfunc main() {\n\tpanic(1)\n}\n```"
+	if r := ObserveStyle(text).CodeRatio; r != 0.75 {
+		t.Errorf("expected code ratio 0.75, got %.2f", r)
+	}
+	if r := ObserveStyle("just a plain question about history").CodeRatio; r != 0 {
+		t.Errorf("expected code ratio 0, got %.2f", r)
+	}
+}
+
+// #endregion style-observe-tests
+
+// #region style-store-tests
+
+func TestStyleProfileStore_ObserveAccumulates(t *testing.T) {
+	store, err := NewStyleProfileStore(testDB(t))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for i := 0; i < StyleMinObservations; i++ {
+		if err := store.Observe(StyleObservation{Language: "es", Formality: 1, CodeRatio: 1}); err != nil {
+			t.Fatalf("observe: %v", err)
+		}
+	}
+	profile, err := store.Get()
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if a := profile[AttrLanguage]; a.Value != "es" || a.Observations != StyleMinObservations || a.Confidence() <= 0 {
+		t.Errorf("unexpected language attribute: %+v conf=%.2f", a, a.Confidence())
+	}
+	if a := profile[AttrFormality]; a.Value != "formal" {
+		t.Errorf("expected formal, got %+v", a)
+	}
+	if a := profile[AttrCodeRatio]; a.Value != "code-heavy" {
+		t.Errorf("expected code-heavy, got %+v", a)
+	}
+}
+
+func TestStyleProfileStore_LanguageFlips(t *testing.T) {
+	store, _ := NewStyleProfileStore(testDB(t))
+	store.Observe(StyleObservation{Language: "en", Formality: -1})
+	for i := 0; i < 3; i++ {
+		store.Observe(StyleObservation{Language: "fr", Formality: -1})
+	}
+	profile, _ := store.Get()
+	if a := profile[AttrLanguage]; a.Value != "fr" {
+		t.Errorf("expected language to flip to fr, got %+v", a)
+	}
+	if _, ok := profile[AttrFormality]; ok {
+		t.Error("undetermined formality should not be stored")
+	}
+}
+
+func TestStyleProfileStore_Reset(t *testing.T) {
+	store, _ := NewStyleProfileStore(testDB(t))
+	store.Observe(StyleObservation{Language: "en", Formality: 0.5})
+	if err := store.Reset(); err != nil {
+		t.Fatalf("reset: %v", err)
+	}
+	if profile, _ := store.Get(); len(profile) != 0 {
+		t.Errorf("expected empty profile after reset, got %v", profile)
+	}
+}
+
+// #endregion style-store-tests
+
+// #region style-project-tests
+
+func TestProjectStyleProfile(t *testing.T) {
+	profile := map[string]StyleAttribute{
+		AttrLanguage:  {Attribute: AttrLanguage, Value: "es", Score: 1, Observations: 2 * StyleMinObservations},
+		AttrFormality: {Attribute: AttrFormality, Value: "neutral", Score: 0.5, Observations: 20},
+		AttrCodeRatio: {Attribute: AttrCodeRatio, Value: "code-heavy", Score: 1, Observations: 2 * StyleMinObservations},
+	}
+	block := ProjectStyleProfile(profile)
+	if !strings.Contains(block, "Spanish") || !strings.Contains(block, "favor code") {
+		t.Errorf("expected language and code lines, got %q", block)
+	}
+	if strings.Contains(block, "Tone") {
+		t.Errorf("neutral formality should not be projected, got %q", block)
+	}
+	// Full confidence is halved by StyleProjectionWeight
+	if !strings.Contains(block, "(confidence: 50%)") {
+		t.Errorf("expected weighted confidence 50%%, got %q", block)
+	}
+}
+
+func TestProjectStyleProfile_UnstableOrDefaultIsEmpty(t *testing.T) {
+	profile := map[string]StyleAttribute{
+		AttrLanguage:  {Attribute: AttrLanguage, Value: "en", Score: 1, Observations: 50},
+		AttrCodeRatio: {Attribute: AttrCodeRatio, Value: "code-heavy", Score: 1, Observations: StyleMinObservations - 1},
+	}
+	if block := ProjectStyleProfile(profile); block != "" {
+		t.Errorf("expected empty block, got %q", block)
+	}
+}
+
+// #endregion style-project-tests
@@ -0,0 +1,232 @@
+package projection
+
+import (
+	"database/sql"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/state"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/timestamp"
+)
+
+// #region suggestion-types
+
+// Suggestion kinds and statuses.
+const (
+	SuggestPreference = "preference"
+	SuggestRule       = "rule"
+
+	SuggestionPending  = "pending"
+	SuggestionAccepted = "accepted"
+	SuggestionRejected = "rejected"
+)
+
+// Suggestion is a rule or preference the agent proposed from its own reflection.
+// Suggestions are never applied until the user accepts them.
+type Suggestion struct {
+	ID        int64
+	Kind      string // SuggestPreference | SuggestRule
+	Text      string // preference text (preference kind)
+	Trigger   string // rule trigger (rule kind)
+	Response  string // rule response (rule kind)
+	TurnID    string // turn whose reflection proposed it
+	Status    string
+	CreatedAt time.Time
+	DecidedAt time.Time
+}
+
+// Describe renders the suggestion as a one-line summary.
+func (s Suggestion) Describe() string {
+	if s.Kind == SuggestRule {
+		return fmt.Sprintf("rule: when %q → %q", s.Trigger, s.Response)
+	}
+	return fmt.Sprintf("preference: %q", s.Text)
+}
+
+// #endregion suggestion-types
+
+// #region suggestion-store
+
+// SuggestionStore queues agent-proposed rules and preferences for user approval.
+type SuggestionStore struct {
+	db state.DBTX
+}
+
+// NewSuggestionStore creates the suggestions table if needed and returns a store.
+func NewSuggestionStore(db *sql.DB) (*SuggestionStore, error) {
+	_, err := db.Exec(`CREATE TABLE IF NOT EXISTS suggestions (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		kind TEXT NOT NULL,
+		text TEXT NOT NULL DEFAULT '',
+		trigger_text TEXT NOT NULL DEFAULT '',
+		response TEXT NOT NULL DEFAULT '',
+		turn_id TEXT NOT NULL DEFAULT '',
+		status TEXT NOT NULL DEFAULT 'pending',
+		created_at TEXT NOT NULL,
+		decided_at TEXT
+	)`)
+	if err != nil {
+		return nil, fmt.Errorf("create suggestions table: %w", err)
+	}
+	if _, err := timestamp.Canonicalize(db, "suggestions", "created_at", "decided_at"); err != nil {
+		return nil, err
+	}
+	return &SuggestionStore{db: db}, nil
+}
+
+// WithTx returns a copy of the store bound to tx, for writes that must land
+// in the same transaction as other writes.
+func (s *SuggestionStore) WithTx(tx *sql.Tx) *SuggestionStore {
+	return &SuggestionStore{db: tx}
+}
+
+// Propose queues a suggestion. Returns false without storing when an identical
+// suggestion is already pending or was previously rejected — the agent should not
+// re-ask for something the user turned down.
+func (s *SuggestionStore) Propose(sg Suggestion) (bool, error) {
+	var count int
+	err := s.db.QueryRow(
+		`SELECT COUNT(*) FROM suggestions WHERE kind = ? AND LOWER(text) = LOWER(?) AND LOWER(trigger_text) = LOWER(?)
+		 AND LOWER(response) = LOWER(?) AND status IN ('pending', 'rejected')`,
+		sg.Kind, sg.Text, sg.Trigger, sg.Response,
+	).Scan(&count)
+	if err != nil {
+		return false, fmt.Errorf("check duplicate suggestion: %w", err)
+	}
+	if count > 0 {
+		return false, nil
+	}
+	_, err = s.db.Exec(
+		`INSERT INTO suggestions (kind, text, trigger_text, response, turn_id, status, created_at) VALUES (?, ?, ?, ?, ?, 'pending', ?)`,
+		sg.Kind, sg.Text, sg.Trigger, sg.Response, sg.TurnID, timestamp.Now(),
+	)
+	if err != nil {
+		return false, fmt.Errorf("insert suggestion: %w", err)
+	}
+	return true, nil
+}
+
+// Pending returns suggestions awaiting a decision, oldest first.
+func (s *SuggestionStore) Pending() ([]Suggestion, error) {
+	rows, err := s.db.Query(
+		`SELECT id, kind, text, trigger_text, response, turn_id, status, created_at FROM suggestions
+		 WHERE status = 'pending' ORDER BY id`,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("list suggestions: %w", err)
+	}
+	defer rows.Close()
+
+	var out []Suggestion
+	for rows.Next() {
+		var sg Suggestion
+		var ts string
+		if err := rows.Scan(&sg.ID, &sg.Kind, &sg.Text, &sg.Trigger, &sg.Response, &sg.TurnID, &sg.Status, &ts); err != nil {
+			return nil, fmt.Errorf("scan suggestion: %w", err)
+		}
+		sg.CreatedAt, _ = timestamp.Parse(ts)
+		out = append(out, sg)
+	}
+	return out, rows.Err()
+}
+
+// GetPending returns suggestion id. Errors if it does not exist or was
+// already decided.
+func (s *SuggestionStore) GetPending(id int64) (Suggestion, error) {
+	var sg Suggestion
+	var ts string
+	err := s.db.QueryRow(
+		`SELECT id, kind, text, trigger_text, response, turn_id, status, created_at FROM suggestions WHERE id = ?`, id,
+	).Scan(&sg.ID, &sg.Kind, &sg.Text, &sg.Trigger, &sg.Response, &sg.TurnID, &sg.Status, &ts)
+	if err == sql.ErrNoRows {
+		return sg, fmt.Errorf("suggestion %d not found", id)
+	}
+	if err != nil {
+		return sg, fmt.Errorf("get suggestion: %w", err)
+	}
+	if sg.Status != SuggestionPending {
+		return sg, fmt.Errorf("suggestion %d already %s", id, sg.Status)
+	}
+	sg.CreatedAt, _ = timestamp.Parse(ts)
+	return sg, nil
+}
+
+// Decide marks a pending suggestion accepted or rejected and returns it.
+// Errors if the suggestion does not exist or was already decided.
+func (s *SuggestionStore) Decide(id int64, accept bool) (Suggestion, error) {
+	sg, err := s.GetPending(id)
+	if err != nil {
+		return sg, err
+	}
+
+	sg.Status = SuggestionRejected
+	if accept {
+		sg.Status = SuggestionAccepted
+	}
+	sg.DecidedAt = time.Now().UTC()
+	if _, err := s.db.Exec("UPDATE suggestions SET status = ?, decided_at = ? WHERE id = ?",
+		sg.Status, timestamp.Format(sg.DecidedAt), id); err != nil {
+		return sg, fmt.Errorf("update suggestion: %w", err)
+	}
+	return sg, nil
+}
+
+// #endregion suggestion-store
+
+// #region suggestion-extract
+
+// SuggestionInstruction is appended to the reflection prompt so the agent can propose
+// structured suggestions instead of acting on them.
+const SuggestionInstruction = "If you noticed a stable pattern in what Commander wants, you may propose it on its own line as " +
+	"SUGGEST PREFERENCE: <preference> or SUGGEST RULE: <when Commander says this> => <respond like this>. " +
+	"Only propose what you have seen more than once. Commander will approve or reject it."
+
+var suggestionLine = regexp.MustCompile(`(?im)^\s*[-*]?\s*SUGGEST\s+(PREFERENCE|RULE)\s*:\s*(.+?)\s*$`)
+
+// maxSuggestionLen bounds suggestion text; longer lines are likely rambling, not a rule.
+const maxSuggestionLen = 200
+
+// ExtractSuggestions parses SUGGEST lines from reflection text. Malformed rules
+// (missing "=>") and overlong entries are dropped.
+func ExtractSuggestions(reflection, turnID string) []Suggestion {
+	var out []Suggestion
+	for _, m := range suggestionLine.FindAllStringSubmatch(reflection, -1) {
+		body := strings.Trim(m[2], " \"'")
+		if body == "" || len(body) > maxSuggestionLen {
+			continue
+		}
+		if strings.EqualFold(m[1], "RULE") {
+			parts := strings.SplitN(body, "=>", 2)
+			if len(parts) != 2 {
+				continue
+			}
+			trigger := strings.Trim(parts[0], " \"'")
+			response := strings.Trim(parts[1], " \"'")
+			if trigger == "" || response == "" {
+				continue
+			}
+			out = append(out, Suggestion{Kind: SuggestRule, Trigger: trigger, Response: response, TurnID: turnID})
+			continue
+		}
+		out = append(out, Suggestion{Kind: SuggestPreference, Text: body, TurnID: turnID})
+	}
+	return out
+}
+
+// FormatSuggestions renders pending suggestions for the /suggestions command.
+func FormatSuggestions(pending []Suggestion) string {
+	if len(pending) == 0 {
+		return "No pending suggestions."
+	}
+	var b strings.Builder
+	b.WriteString("Pending suggestions (from my reflections):\n")
+	for _, sg := range pending {
+		fmt.Fprintf(&b, "  #%d %s\n", sg.ID, sg.Describe())
+	}
+	b.WriteString("Reply /suggestions accept <id> or /suggestions reject <id>.")
+	return b.String()
+}
+
+// #endregion suggestion-extract
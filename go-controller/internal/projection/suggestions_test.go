@@ -0,0 +1,87 @@
+package projection
+
+import (
+	"strings"
+	"testing"
+)
+
+// #region suggestion-extract-tests
+
+func TestExtractSuggestions(t *testing.T) {
+	reflection := `I noticed Commander asked for times again.
+SUGGEST PREFERENCE: Include timestamps in answers
+- SUGGEST RULE: "status?" => "Report current state norm"
+SUGGEST RULE: missing arrow
+I wonder what he meant.`
+	got := ExtractSuggestions(reflection, "turn-9")
+	if len(got) != 2 {
+		t.Fatalf("expected 2 suggestions, got %d: %+v", len(got), got)
+	}
+	if got[0].Kind != SuggestPreference || got[0].Text != "Include timestamps in answers" || got[0].TurnID != "turn-9" {
+		t.Errorf("unexpected preference suggestion: %+v", got[0])
+	}
+	if got[1].Kind != SuggestRule || got[1].Trigger != "status?" || got[1].Response != "Report current state norm" {
+		t.Errorf("unexpected rule suggestion: %+v", got[1])
+	}
+}
+
+func TestExtractSuggestions_None(t *testing.T) {
+	if got := ExtractSuggestions("I suggest nothing in particular.", "t"); len(got) != 0 {
+		t.Errorf("expected no suggestions, got %+v", got)
+	}
+}
+
+// #endregion suggestion-extract-tests
+
+// #region suggestion-store-tests
+
+func TestSuggestionStore_ProposeAndDecide(t *testing.T) {
+	store, err := NewSuggestionStore(testDB(t))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	sg := Suggestion{Kind: SuggestPreference, Text: "Include timestamps", TurnID: "t1"}
+	if ok, err := store.Propose(sg); err != nil || !ok {
+		t.Fatalf("expected suggestion queued, got ok=%v err=%v", ok, err)
+	}
+	if ok, _ := store.Propose(sg); ok {
+		t.Error("expected duplicate pending suggestion to be skipped")
+	}
+
+	pending, _ := store.Pending()
+	if len(pending) != 1 {
+		t.Fatalf("expected 1 pending, got %d", len(pending))
+	}
+	decided, err := store.Decide(pending[0].ID, false)
+	if err != nil || decided.Status != SuggestionRejected {
+		t.Fatalf("expected rejected, got %+v err=%v", decided, err)
+	}
+	if _, err := store.Decide(pending[0].ID, true); err == nil {
+		t.Error("expected error deciding an already-decided suggestion")
+	}
+	if ok, _ := store.Propose(sg); ok {
+		t.Error("expected previously rejected suggestion not to be re-queued")
+	}
+	if pending, _ := store.Pending(); len(pending) != 0 {
+		t.Errorf("expected no pending after decision, got %d", len(pending))
+	}
+}
+
+func TestSuggestionStore_DecideMissing(t *testing.T) {
+	store, _ := NewSuggestionStore(testDB(t))
+	if _, err := store.Decide(42, true); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("expected not found error, got %v", err)
+	}
+}
+
+func TestFormatSuggestions(t *testing.T) {
+	if got := FormatSuggestions(nil); got != "No pending suggestions." {
+		t.Errorf("unexpected empty format: %q", got)
+	}
+	got := FormatSuggestions([]Suggestion{{ID: 3, Kind: SuggestRule, Trigger: "hi", Response: "hello"}})
+	if !strings.Contains(got, "#3 rule:") || !strings.Contains(got, "/suggestions accept") {
+		t.Errorf("unexpected format: %q", got)
+	}
+}
+
+// #endregion suggestion-store-tests
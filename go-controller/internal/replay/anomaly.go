@@ -0,0 +1,183 @@
+package replay
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/gate"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/logging"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/state"
+)
+
+// #region anomaly-types
+
+// Anomaly kinds recorded in FixtureAnomaly.Kinds.
+const (
+	AnomalyHugeDelta    = "huge_delta"    // delta norm near or past the gate's MaxDeltaNorm
+	AnomalySurpriseVeto = "surprise_veto" // hard veto right after a confident commit
+	AnomalyEvalRollback = "eval_rollback" // gate approved, post-commit eval rolled back
+)
+
+// AnomalyConfig controls automatic fixture capture.
+type AnomalyConfig struct {
+	Dir           string  // output directory; "" disables capture
+	Context       int     // preceding turns included before the anomalous one
+	DeltaRatio    float32 // huge delta: delta_norm >= DeltaRatio * max_delta_norm
+	SurpriseScore float32 // surprise veto: previous turn committed with at least this soft score
+}
+
+// DefaultAnomalyConfig returns sensible defaults, capturing into anomalies/.
+func DefaultAnomalyConfig() AnomalyConfig {
+	return AnomalyConfig{Dir: "anomalies", Context: 3, DeltaRatio: 0.9, SurpriseScore: 0.5}
+}
+
+// AnomalyTurn is one live turn as the recorder sees it.
+type AnomalyTurn struct {
+	Before   state.StateRecord  // state the turn's update was proposed against
+	Record   logging.GateRecord // as written to provenance_log.signals_json
+	Evidence []string           // evidence text fed to the update
+	Decision string             // provenance decision: "commit" | "reject" | "no_op"
+	Reason   string             // provenance reason
+}
+
+// #endregion anomaly-types
+
+// #region anomaly-detect
+
+// DetectAnomalies returns the anomaly kinds turn shows, given the turn before
+// it (nil for the first turn of a session).
+func DetectAnomalies(turn AnomalyTurn, previous *AnomalyTurn, cfg AnomalyConfig) []string {
+	var kinds []string
+	rec := turn.Record
+	if limit := rec.Thresholds.MaxDeltaNorm; limit > 0 && rec.DeltaNorm >= cfg.DeltaRatio*limit {
+		kinds = append(kinds, AnomalyHugeDelta)
+	}
+	if rec.GateVetoed && previous != nil && previous.Decision == "commit" &&
+		previous.Record.GateSoftScore >= cfg.SurpriseScore {
+		kinds = append(kinds, AnomalySurpriseVeto)
+	}
+	if ExpectedAction(turn.Decision, turn.Reason) == "eval_rollback" {
+		kinds = append(kinds, AnomalyEvalRollback)
+	}
+	return kinds
+}
+
+// #endregion anomaly-detect
+
+// #region anomaly-recorder
+
+// AnomalyRecorder keeps a rolling window of recent turns and, when one is
+// anomalous, writes it with its preceding turns as a standalone replay fixture
+// starting from the state before the window. Not safe for concurrent use.
+type AnomalyRecorder struct {
+	cfg    AnomalyConfig
+	replay ReplayConfig
+	window []AnomalyTurn
+}
+
+// NewAnomalyRecorder returns a recorder writing fixtures under cfg.Dir with
+// the pipeline configuration rc the turns were evaluated with.
+func NewAnomalyRecorder(cfg AnomalyConfig, rc ReplayConfig) *AnomalyRecorder {
+	if cfg.Context < 0 {
+		cfg.Context = 0
+	}
+	return &AnomalyRecorder{cfg: cfg, replay: rc}
+}
+
+// SetGateConfig records a reloaded gate configuration for later captures.
+func (r *AnomalyRecorder) SetGateConfig(c gate.GateConfig) {
+	if r != nil {
+		r.replay.GateConfig = c
+	}
+}
+
+// Observe adds turn to the window and captures a fixture if it is anomalous.
+// It returns the written path and the anomaly kinds, or "" and nil when the
+// turn was ordinary or capture is disabled.
+func (r *AnomalyRecorder) Observe(turn AnomalyTurn) (string, []string, error) {
+	if r == nil || r.cfg.Dir == "" {
+		return "", nil, nil
+	}
+	var previous *AnomalyTurn
+	if n := len(r.window); n > 0 {
+		previous = &r.window[n-1]
+	}
+	kinds := DetectAnomalies(turn, previous, r.cfg)
+
+	r.window = append(r.window, turn)
+	if len(r.window) > r.cfg.Context+1 {
+		r.window = r.window[len(r.window)-(r.cfg.Context+1):]
+	}
+	if len(kinds) == 0 {
+		return "", nil, nil
+	}
+
+	fixture := r.fixture(kinds, time.Now().UTC())
+	path := filepath.Join(r.cfg.Dir, fixtureName(turn.Record.TurnID, kinds))
+	if err := writeFixture(fixture, path); err != nil {
+		return "", kinds, err
+	}
+	return path, kinds, nil
+}
+
+// fixture builds the fixture for the current window; its last turn is the anomaly.
+func (r *AnomalyRecorder) fixture(kinds []string, now time.Time) Fixture {
+	last := r.window[len(r.window)-1]
+	start := r.window[0].Before
+	f := Fixture{
+		Description: fmt.Sprintf("Anomaly capture: %s on %s (%d preceding turns)",
+			strings.Join(kinds, ", "), last.Record.TurnID, len(r.window)-1),
+		StartState: FixtureStartState{
+			VersionID:   start.VersionID,
+			StateVector: start.StateVector,
+			SegmentMap:  start.SegmentMap,
+		},
+		Config: FixtureConfigFrom(r.replay),
+		Anomaly: &FixtureAnomaly{
+			TurnID:     last.Record.TurnID,
+			Kinds:      kinds,
+			Reason:     last.Reason,
+			CapturedAt: now,
+		},
+	}
+	for _, t := range r.window {
+		f.Interactions = append(f.Interactions, FixtureInteractionFrom(t.Record, t.Evidence))
+		f.ExpectedResults = append(f.ExpectedResults, FixtureExpectedResult{
+			TurnID: t.Record.TurnID,
+			Action: ExpectedAction(t.Decision, t.Reason),
+		})
+	}
+	return f
+}
+
+var unsafeName = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// fixtureName is "<turn>-<first kind>.json", with the turn ID made filename-safe.
+func fixtureName(turnID string, kinds []string) string {
+	name := unsafeName.ReplaceAllString(turnID, "_")
+	if name == "" {
+		name = "turn"
+	}
+	return name + "-" + kinds[0] + ".json"
+}
+
+func writeFixture(f Fixture, path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("create anomaly dir: %w", err)
+	}
+	data, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal anomaly fixture: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return fmt.Errorf("write %s: %w", path, err)
+	}
+	return nil
+}
+
+// #endregion anomaly-recorder
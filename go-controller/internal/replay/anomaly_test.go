@@ -0,0 +1,121 @@
+package replay
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/logging"
+)
+
+// helper: a live turn as the daemon would report it to the recorder.
+func anomalyTurn(turnID, decision, reason string, deltaNorm, softScore float32, vetoed bool) AnomalyTurn {
+	return AnomalyTurn{
+		Before: seededState("before-"+turnID, 0.1),
+		Record: logging.GateRecord{
+			TurnID:        turnID,
+			Prompt:        "prompt " + turnID,
+			Response:      "response " + turnID,
+			Entropy:       0.5,
+			Signals:       logging.GateRecordSignals{SentimentScore: 0.8, CoherenceScore: 0.6},
+			DeltaNorm:     deltaNorm,
+			Thresholds:    logging.GateRecordThresholds{MaxDeltaNorm: 5.0},
+			GateSoftScore: softScore,
+			GateVetoed:    vetoed,
+		},
+		Evidence: []string{"evidence " + turnID},
+		Decision: decision,
+		Reason:   reason,
+	}
+}
+
+// #region detect-tests
+
+func TestDetectAnomalies(t *testing.T) {
+	cfg := DefaultAnomalyConfig()
+	confident := anomalyTurn("t0", "commit", "gate: ok", 0.5, 0.8, false)
+	hesitant := anomalyTurn("t0", "commit", "gate: ok", 0.5, 0.2, false)
+	tests := []struct {
+		name     string
+		turn     AnomalyTurn
+		previous *AnomalyTurn
+		want     []string
+	}{
+		{"ordinary commit", anomalyTurn("t1", "commit", "gate: ok", 0.5, 0.7, false), &confident, nil},
+		{"huge delta", anomalyTurn("t1", "commit", "gate: ok", 4.6, 0.7, false), &confident, []string{AnomalyHugeDelta}},
+		{"surprise veto", anomalyTurn("t1", "reject", "gate: vetoed", 0.5, 0, true), &confident, []string{AnomalySurpriseVeto}},
+		{"veto after hesitant commit", anomalyTurn("t1", "reject", "gate: vetoed", 0.5, 0, true), &hesitant, nil},
+		{"veto on first turn", anomalyTurn("t1", "reject", "gate: vetoed", 0.5, 0, true), nil, nil},
+		{"vetoed huge delta", anomalyTurn("t1", "reject", "gate: vetoed", 6.0, 0, true), &confident, []string{AnomalyHugeDelta, AnomalySurpriseVeto}},
+		{"eval rollback", anomalyTurn("t1", "reject", "eval rollback: state norm", 0.5, 0.7, false), nil, []string{AnomalyEvalRollback}},
+	}
+	for _, tt := range tests {
+		got := DetectAnomalies(tt.turn, tt.previous, cfg)
+		if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+// #endregion detect-tests
+
+// #region recorder-tests
+
+func TestAnomalyRecorder_CapturesWindowAsFixture(t *testing.T) {
+	cfg := DefaultAnomalyConfig()
+	cfg.Dir = filepath.Join(t.TempDir(), "anomalies")
+	cfg.Context = 2
+	rec := NewAnomalyRecorder(cfg, DefaultReplayConfig())
+
+	for i := 1; i <= 4; i++ {
+		path, kinds, err := rec.Observe(anomalyTurn(fmt.Sprintf("turn-%d", i), "commit", "gate: ok", 0.5, 0.3, false))
+		if path != "" || kinds != nil || err != nil {
+			t.Fatalf("ordinary turn %d captured: %q %v %v", i, path, kinds, err)
+		}
+	}
+	if _, err := os.Stat(cfg.Dir); !os.IsNotExist(err) {
+		t.Errorf("no directory should be created before an anomaly, stat err = %v", err)
+	}
+
+	path, kinds, err := rec.Observe(anomalyTurn("turn/5", "reject", "eval rollback: segment norm", 0.5, 0.6, false))
+	if err != nil {
+		t.Fatalf("Observe: %v", err)
+	}
+	if filepath.Base(path) != "turn_5-eval_rollback.json" || len(kinds) != 1 {
+		t.Fatalf("unexpected capture: %q %v", path, kinds)
+	}
+
+	f, err := LoadFixture(path)
+	if err != nil {
+		t.Fatalf("LoadFixture: %v", err)
+	}
+	if len(f.Interactions) != 3 || f.Interactions[0].TurnID != "turn-3" || f.Interactions[2].TurnID != "turn/5" {
+		t.Fatalf("expected the anomaly plus 2 preceding turns, got %+v", f.Interactions)
+	}
+	if f.StartState.VersionID != "before-turn-3" {
+		t.Errorf("fixture should start from the state before the window, got %s", f.StartState.VersionID)
+	}
+	if f.ExpectedResults[0].Action != "commit" || f.ExpectedResults[2].Action != "eval_rollback" {
+		t.Errorf("unexpected expected actions: %+v", f.ExpectedResults)
+	}
+	if f.Interactions[2].Evidence[0] != "evidence turn/5" {
+		t.Errorf("evidence not captured: %v", f.Interactions[2].Evidence)
+	}
+	if f.Anomaly == nil || f.Anomaly.TurnID != "turn/5" || f.Anomaly.Reason != "eval rollback: segment norm" {
+		t.Errorf("anomaly metadata not recorded: %+v", f.Anomaly)
+	}
+	if f.Config.GateConfig.MaxDeltaNorm != DefaultReplayConfig().GateConfig.MaxDeltaNorm {
+		t.Errorf("fixture should carry the live pipeline config, got %+v", f.Config.GateConfig)
+	}
+}
+
+func TestAnomalyRecorder_DisabledWithoutDir(t *testing.T) {
+	rec := NewAnomalyRecorder(AnomalyConfig{}, DefaultReplayConfig())
+	path, kinds, err := rec.Observe(anomalyTurn("t1", "reject", "eval rollback: x", 0.5, 0, false))
+	if path != "" || kinds != nil || err != nil {
+		t.Errorf("disabled recorder captured: %q %v %v", path, kinds, err)
+	}
+}
+
+// #endregion recorder-tests
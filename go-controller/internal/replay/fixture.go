@@ -1,12 +1,18 @@
 package replay
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/eval"
 	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/gate"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/logging"
 	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/state"
 	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/update"
 )
@@ -20,6 +26,17 @@ type Fixture struct {
 	Config          FixtureConfig         `json:"config"`
 	Interactions    []FixtureInteraction  `json:"interactions"`
 	ExpectedResults []FixtureExpectedResult `json:"expected_results"`
+
+	Anomaly *FixtureAnomaly `json:"anomaly,omitempty"` // set on fixtures captured automatically by the daemon
+}
+
+// FixtureAnomaly records why a fixture was captured: the anomalous turn (the
+// last interaction) and each anomaly kind it showed.
+type FixtureAnomaly struct {
+	TurnID     string    `json:"turn_id"`
+	Kinds      []string  `json:"kinds"`
+	Reason     string    `json:"reason"`
+	CapturedAt time.Time `json:"captured_at"`
 }
 
 // FixtureStartState is the JSON-serializable initial state.
@@ -38,6 +55,7 @@ type FixtureSignals struct {
 	UserCorrection      bool    `json:"user_correction"`
 	ToolFailure         bool    `json:"tool_failure"`
 	ConstraintViolation bool    `json:"constraint_violation"`
+	PlanProgress        float32 `json:"plan_progress,omitempty"`
 }
 
 // FixtureInteraction mirrors replay.Interaction with JSON tags.
@@ -48,6 +66,7 @@ type FixtureInteraction struct {
 	Entropy      float32        `json:"entropy"`
 	Signals      FixtureSignals `json:"signals"`
 	Evidence     []string       `json:"evidence"`
+	Model        string         `json:"model,omitempty"` // logging.ModelLabel of the backing model
 }
 
 // FixtureExpectedResult captures the expected action per turn.
@@ -76,6 +95,16 @@ type FixtureGateConfig struct {
 	MaxStateNorm   float32 `json:"max_state_norm"`
 	MinEntropyDrop float32 `json:"min_entropy_drop"`
 	RiskSegmentCap float32 `json:"risk_segment_cap"`
+
+	SegmentCaps map[string]float32 `json:"segment_caps,omitempty"` // per-segment hard caps; "risk" overrides risk_segment_cap
+
+	// Downgrade policy: veto types that commit scaled, up to this overshoot of the cap
+	Downgrade       []string `json:"downgrade,omitempty"`
+	DowngradeExcess float32  `json:"downgrade_excess,omitempty"`
+
+	// Gate policy: soft score weights (default when omitted) and skipped veto checks
+	Weights        *gate.SoftWeights `json:"weights,omitempty"`
+	DisabledVetoes []string          `json:"disabled_vetoes,omitempty"`
 }
 
 // FixtureEvalConfig mirrors eval.EvalConfig with JSON tags.
@@ -83,25 +112,136 @@ type FixtureEvalConfig struct {
 	MaxStateNorm    float32 `json:"max_state_norm"`
 	MaxSegmentNorm  float32 `json:"max_segment_norm"`
 	EntropyBaseline float32 `json:"entropy_baseline"`
+
+	SegmentNorms map[string]float32 `json:"segment_norms,omitempty"` // per-segment overrides of max_segment_norm
+	WarnMargin   float32            `json:"warn_margin,omitempty"`   // eval warning tier; 0 = pass/fail only
 }
 
 // #endregion fixture-types
 
 // #region fixture-loader
 
-// LoadFixture reads and parses a JSON fixture file.
+// LoadFixture reads, parses and validates a JSON fixture file.
 func LoadFixture(path string) (*Fixture, error) {
 	data, err := os.ReadFile(path)
 	if err != nil {
 		return nil, fmt.Errorf("read fixture %s: %w", path, err)
 	}
-	var f Fixture
-	if err := json.Unmarshal(data, &f); err != nil {
+	f, err := ParseFixture(data)
+	if err != nil {
 		return nil, fmt.Errorf("parse fixture %s: %w", path, err)
 	}
+	return f, nil
+}
+
+// ErrInvalidFixture marks a fixture that parsed but failed validation.
+var ErrInvalidFixture = errors.New("invalid fixture")
+
+// ParseFixture decodes a fixture strictly: unknown fields, trailing data and a
+// state_vector that is not exactly 128 values are errors (a fixed-size array
+// would otherwise zero-fill or truncate), and the result must pass Validate.
+func ParseFixture(data []byte) (*Fixture, error) {
+	var f Fixture
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&f); err != nil {
+		return nil, err
+	}
+	if dec.More() {
+		return nil, fmt.Errorf("%w: trailing data after fixture", ErrInvalidFixture)
+	}
+	var raw struct {
+		StartState struct {
+			StateVector []float32 `json:"state_vector"`
+		} `json:"start_state"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+	if n := len(raw.StartState.StateVector); n != len(f.StartState.StateVector) {
+		return nil, fmt.Errorf("%w: start_state.state_vector has %d values, want %d", ErrInvalidFixture, n, len(f.StartState.StateVector))
+	}
+	if err := f.Validate(); err != nil {
+		return nil, err
+	}
 	return &f, nil
 }
 
+// fixtureActions are the replay outcomes an expected result may name.
+var fixtureActions = map[string]bool{"commit": true, "gate_reject": true, "eval_rollback": true, "no_op": true}
+
+// Validate checks the fixture's values: a finite start state with a valid
+// segment map, non-negative config and entropies, unique turn IDs, and expected
+// results (when given) matching the interactions one-to-one with known actions.
+func (f *Fixture) Validate() error {
+	if err := state.ValidateVector(f.StartState.StateVector); err != nil {
+		return fmt.Errorf("%w: start_state: %v", ErrInvalidFixture, err)
+	}
+	if err := f.StartState.SegmentMap.Validate(); err != nil {
+		return fmt.Errorf("%w: start_state: %v", ErrInvalidFixture, err)
+	}
+	c := f.Config
+	for name, v := range map[string]float32{
+		"learning_rate": c.UpdateConfig.LearningRate, "decay_rate": c.UpdateConfig.DecayRate,
+		"max_delta_norm_per_segment": c.UpdateConfig.MaxDeltaNormPerSegment,
+		"gate max_delta_norm": c.GateConfig.MaxDeltaNorm, "gate max_state_norm": c.GateConfig.MaxStateNorm,
+		"min_entropy_drop": c.GateConfig.MinEntropyDrop, "risk_segment_cap": c.GateConfig.RiskSegmentCap,
+		"eval max_state_norm": c.EvalConfig.MaxStateNorm, "max_segment_norm": c.EvalConfig.MaxSegmentNorm,
+		"entropy_baseline": c.EvalConfig.EntropyBaseline, "warn_margin": c.EvalConfig.WarnMargin,
+		"downgrade_excess": c.GateConfig.DowngradeExcess,
+	} {
+		if v < 0 {
+			return fmt.Errorf("%w: config %s is negative (%v)", ErrInvalidFixture, name, v)
+		}
+	}
+	if len(c.GateConfig.Downgrade) > 0 {
+		if _, err := gate.ParseDowngrade(strings.Join(c.GateConfig.Downgrade, ","), 1); err != nil {
+			return fmt.Errorf("%w: config %v", ErrInvalidFixture, err)
+		}
+	}
+	if w := c.GateConfig.Weights; w != nil && (w.Entropy < 0 || w.DeltaStability < 0 || w.SegmentFocus < 0) {
+		return fmt.Errorf("%w: config gate weights are negative (%+v)", ErrInvalidFixture, *w)
+	}
+	for _, name := range c.GateConfig.DisabledVetoes {
+		if !slices.Contains(gate.VetoCheckNames(), name) {
+			return fmt.Errorf("%w: config disabled veto %q is unknown", ErrInvalidFixture, name)
+		}
+	}
+	for _, limits := range []map[string]float32{c.GateConfig.SegmentCaps, c.EvalConfig.SegmentNorms} {
+		for seg, v := range limits {
+			if _, ok := state.SegmentRange(f.StartState.SegmentMap, seg); !ok || v < 0 {
+				return fmt.Errorf("%w: config segment limit %s=%v", ErrInvalidFixture, seg, v)
+			}
+		}
+	}
+
+	seen := make(map[string]bool, len(f.Interactions))
+	for i, in := range f.Interactions {
+		if in.TurnID == "" || seen[in.TurnID] {
+			return fmt.Errorf("%w: interaction %d has a missing or duplicate turn_id %q", ErrInvalidFixture, i, in.TurnID)
+		}
+		seen[in.TurnID] = true
+		if in.Entropy < 0 {
+			return fmt.Errorf("%w: interaction %s has negative entropy", ErrInvalidFixture, in.TurnID)
+		}
+	}
+	if len(f.ExpectedResults) == 0 {
+		return nil
+	}
+	if len(f.ExpectedResults) != len(f.Interactions) {
+		return fmt.Errorf("%w: %d expected results for %d interactions", ErrInvalidFixture, len(f.ExpectedResults), len(f.Interactions))
+	}
+	for i, e := range f.ExpectedResults {
+		if e.TurnID != f.Interactions[i].TurnID {
+			return fmt.Errorf("%w: expected result %d is for %q, interaction is %q", ErrInvalidFixture, i, e.TurnID, f.Interactions[i].TurnID)
+		}
+		if !fixtureActions[e.Action] {
+			return fmt.Errorf("%w: expected result %s has unknown action %q", ErrInvalidFixture, e.TurnID, e.Action)
+		}
+	}
+	return nil
+}
+
 // ToStateRecord converts a FixtureStartState to a domain StateRecord.
 func (s *FixtureStartState) ToStateRecord() state.StateRecord {
 	return state.StateRecord{
@@ -126,14 +266,16 @@ func (fi *FixtureInteraction) ToInteraction() Interaction {
 			UserCorrection:      fi.Signals.UserCorrection,
 			ToolFailure:         fi.Signals.ToolFailure,
 			ConstraintViolation: fi.Signals.ConstraintViolation,
+			PlanProgress:        fi.Signals.PlanProgress,
 		},
 		Evidence: fi.Evidence,
+		Model:    fi.Model,
 	}
 }
 
 // ToReplayConfig converts a FixtureConfig to a domain ReplayConfig.
 func (fc *FixtureConfig) ToReplayConfig() ReplayConfig {
-	return ReplayConfig{
+	rc := ReplayConfig{
 		UpdateConfig: update.UpdateConfig{
 			LearningRate:           fc.UpdateConfig.LearningRate,
 			DecayRate:              fc.UpdateConfig.DecayRate,
@@ -144,13 +286,121 @@ func (fc *FixtureConfig) ToReplayConfig() ReplayConfig {
 			MaxStateNorm:   fc.GateConfig.MaxStateNorm,
 			MinEntropyDrop: fc.GateConfig.MinEntropyDrop,
 			RiskSegmentCap: fc.GateConfig.RiskSegmentCap,
+			SegmentCaps:    fc.GateConfig.SegmentCaps,
+			Downgrade:      fixtureDowngrade(fc.GateConfig),
+			DisabledVetoes: fc.GateConfig.DisabledVetoes,
 		},
 		EvalConfig: eval.EvalConfig{
 			MaxStateNorm:    fc.EvalConfig.MaxStateNorm,
 			MaxSegmentNorm:  fc.EvalConfig.MaxSegmentNorm,
 			EntropyBaseline: fc.EvalConfig.EntropyBaseline,
+			SegmentNorms:    fc.EvalConfig.SegmentNorms,
+			WarnMargin:      fc.EvalConfig.WarnMargin,
 		},
 	}
+	if w := fc.GateConfig.Weights; w != nil {
+		rc.GateConfig.Weights = *w
+	}
+	return rc
+}
+
+// fixtureDowngrade converts a fixture's downgrade settings; Validate has
+// checked the veto types.
+func fixtureDowngrade(g FixtureGateConfig) gate.DowngradePolicy {
+	p := gate.DowngradePolicy{MaxExcess: g.DowngradeExcess}
+	for _, t := range g.Downgrade {
+		p.VetoTypes = append(p.VetoTypes, gate.VetoType(t))
+	}
+	return p
+}
+
+// fixtureWeights omits default soft score weights from a fixture.
+func fixtureWeights(w gate.SoftWeights) *gate.SoftWeights {
+	if w == (gate.SoftWeights{}) {
+		return nil
+	}
+	return &w
 }
 
 // #endregion fixture-loader
+
+// #region fixture-builder
+
+// FixtureInteractionFrom converts a provenance GateRecord into a fixture
+// interaction. GateRecords do not carry evidence text; pass it when known.
+func FixtureInteractionFrom(rec logging.GateRecord, evidence []string) FixtureInteraction {
+	if evidence == nil {
+		evidence = []string{}
+	}
+	fi := FixtureInteraction{
+		TurnID:       rec.TurnID,
+		Prompt:       rec.Prompt,
+		ResponseText: rec.Response,
+		Entropy:      rec.Entropy,
+		Signals: FixtureSignals{
+			SentimentScore:      rec.Signals.SentimentScore,
+			NoveltyScore:        rec.Signals.NoveltyScore,
+			CoherenceScore:      rec.Signals.CoherenceScore,
+			RiskFlag:            rec.Signals.RiskFlag,
+			UserCorrection:      rec.Signals.UserCorrection,
+			ToolFailure:         rec.Signals.ToolFailure,
+			ConstraintViolation: rec.Signals.ConstraintViolation,
+			PlanProgress:        rec.Signals.PlanProgress,
+		},
+		Evidence: evidence,
+	}
+	if rec.Model != "" {
+		fi.Model = logging.ModelLabel(rec.Model, rec.ModelVersion)
+	}
+	return fi
+}
+
+// FixtureConfigFrom converts a domain ReplayConfig to its fixture form.
+func FixtureConfigFrom(rc ReplayConfig) FixtureConfig {
+	return FixtureConfig{
+		UpdateConfig: FixtureUpdateConfig{
+			LearningRate:           rc.UpdateConfig.LearningRate,
+			DecayRate:              rc.UpdateConfig.DecayRate,
+			MaxDeltaNormPerSegment: rc.UpdateConfig.MaxDeltaNormPerSegment,
+		},
+		GateConfig: FixtureGateConfig{
+			MaxDeltaNorm:   rc.GateConfig.MaxDeltaNorm,
+			MaxStateNorm:   rc.GateConfig.MaxStateNorm,
+			MinEntropyDrop: rc.GateConfig.MinEntropyDrop,
+			RiskSegmentCap: rc.GateConfig.RiskSegmentCap,
+			SegmentCaps:    rc.GateConfig.SegmentCaps,
+
+			Downgrade:       rc.GateConfig.Downgrade.Types(),
+			DowngradeExcess: rc.GateConfig.Downgrade.MaxExcess,
+			Weights:         fixtureWeights(rc.GateConfig.Weights),
+			DisabledVetoes:  rc.GateConfig.DisabledVetoes,
+		},
+		EvalConfig: FixtureEvalConfig{
+			MaxStateNorm:    rc.EvalConfig.MaxStateNorm,
+			MaxSegmentNorm:  rc.EvalConfig.MaxSegmentNorm,
+			EntropyBaseline: rc.EvalConfig.EntropyBaseline,
+			SegmentNorms:    rc.EvalConfig.SegmentNorms,
+			WarnMargin:      rc.EvalConfig.WarnMargin,
+		},
+	}
+}
+
+// ExpectedAction maps a provenance decision and reason to the replay action
+// the fixture expects.
+func ExpectedAction(decision, reason string) string {
+	switch decision {
+	case "commit":
+		return "commit"
+	case "reject":
+		if strings.Contains(reason, "eval rollback") {
+			return "eval_rollback"
+		}
+		return "gate_reject"
+	case "no_op":
+		return "no_op"
+	default:
+		return decision
+	}
+}
+
+// #endregion fixture-builder
@@ -1,8 +1,10 @@
 package replay
 
 import (
+	"encoding/json"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -107,4 +109,128 @@ func TestLoadFixture_Malformed(t *testing.T) {
 	}
 }
 
+// TestLoadFixture_PerSegmentThresholds verifies segment_caps and segment_norms
+// reach the gate and eval configs, and that fixtures without them keep the
+// single-threshold behavior.
+func TestLoadFixture_PerSegmentThresholds(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "segments.json")
+	body := `{"start_state": ` + startStateJSON + `, "config": {
+		"gate_config": {"max_delta_norm": 5, "max_state_norm": 50, "risk_segment_cap": 10, "segment_caps": {"risk": 4},
+			"downgrade": ["constraint_violation"], "downgrade_excess": 0.2,
+			"weights": {"entropy": 0.5, "delta_stability": 0.25, "segment_focus": 0.25}, "disabled_vetoes": ["tool_failure"]},
+		"eval_config": {"max_state_norm": 50, "max_segment_norm": 15, "segment_norms": {"risk": 6, "prefs": 20}}
+	}}`
+	if err := os.WriteFile(path, []byte(body), 0644); err != nil {
+		t.Fatalf("write temp file: %v", err)
+	}
+
+	f, err := LoadFixture(path)
+	if err != nil {
+		t.Fatalf("LoadFixture: %v", err)
+	}
+	config := f.Config.ToReplayConfig()
+	if c, _ := config.GateConfig.SegmentCap("risk"); c != 4 {
+		t.Errorf("risk cap = %.1f, want 4", c)
+	}
+	if _, ok := config.GateConfig.SegmentCap("prefs"); ok {
+		t.Error("prefs should be uncapped at the gate")
+	}
+	if d := config.GateConfig.Downgrade; len(d.VetoTypes) != 1 || d.VetoTypes[0] != "constraint_violation" || d.MaxExcess != 0.2 {
+		t.Errorf("downgrade = %+v", d)
+	}
+	if back := FixtureConfigFrom(config).GateConfig; len(back.Downgrade) != 1 || back.DowngradeExcess != 0.2 {
+		t.Errorf("downgrade did not round-trip: %+v", back)
+	}
+	if g := config.GateConfig; g.Weights.Entropy != 0.5 || g.VetoEnabled("tool_failure") {
+		t.Errorf("policy = weights %+v, disabled %v", g.Weights, g.DisabledVetoes)
+	}
+	if back := FixtureConfigFrom(config).GateConfig; back.Weights == nil || *back.Weights != config.GateConfig.Weights || len(back.DisabledVetoes) != 1 {
+		t.Errorf("policy did not round-trip: %+v", back)
+	}
+	if got := config.EvalConfig.SegmentLimit("risk"); got != 6 {
+		t.Errorf("risk eval limit = %.1f, want 6", got)
+	}
+	if got := config.EvalConfig.SegmentLimit("goals"); got != 15 {
+		t.Errorf("goals eval limit = %.1f, want max_segment_norm 15", got)
+	}
+
+	live, err := LoadFixture(filepath.Join("testdata", "live_session.json"))
+	if err != nil {
+		t.Fatalf("LoadFixture: %v", err)
+	}
+	legacy := live.Config.ToReplayConfig()
+	if c, _ := legacy.GateConfig.SegmentCap("risk"); c != legacy.GateConfig.RiskSegmentCap {
+		t.Errorf("legacy fixture risk cap = %.1f, want risk_segment_cap %.1f", c, legacy.GateConfig.RiskSegmentCap)
+	}
+}
+
 // #endregion fixture-tests
+
+// #region strict-fixture-tests
+
+// startStateJSON is a valid all-zero start_state for hand-written fixtures.
+var startStateJSON = `{"version_id": "v0", "state_vector": [0` + strings.Repeat(",0", 127) + `],
+	"segment_map": {"prefs": [0, 32], "goals": [32, 64], "heuristics": [64, 96], "risk": [96, 128]}}`
+
+func TestParseFixture_RejectsMalformed(t *testing.T) {
+	valid := `{"start_state": ` + startStateJSON + `,
+		"interactions": [{"turn_id": "t1", "entropy": 0.5}],
+		"expected_results": [{"turn_id": "t1", "action": "commit"}]}`
+	if _, err := ParseFixture([]byte(valid)); err != nil {
+		t.Fatalf("valid fixture rejected: %v", err)
+	}
+
+	tests := []struct {
+		name, body string
+	}{
+		{"short state vector", strings.Replace(valid, `[0,0,`, `[`, 1)},
+		{"long state vector", strings.Replace(valid, `[0,0,`, `[0,0,0,`, 1)},
+		{"missing start state", `{"interactions": []}`},
+		{"unknown field", strings.Replace(valid, `"interactions"`, `"interaction": [], "interactions"`, 1)},
+		{"overlapping segments", strings.Replace(valid, `"goals": [32, 64]`, `"goals": [16, 64]`, 1)},
+		{"result count mismatch", strings.Replace(valid, `"entropy": 0.5}]`, `"entropy": 0.5}, {"turn_id": "t2"}]`, 1)},
+		{"result for another turn", strings.Replace(valid, `{"turn_id": "t1", "action"`, `{"turn_id": "t2", "action"`, 1)},
+		{"unknown action", strings.Replace(valid, `"action": "commit"`, `"action": "comit"`, 1)},
+		{"negative entropy", strings.Replace(valid, `"entropy": 0.5`, `"entropy": -1`, 1)},
+		{"trailing data", valid + ` {}`},
+		{"flag veto downgrade", strings.Replace(valid, `"interactions"`, `"config": {"gate_config": {"downgrade": ["user_correction"]}}, "interactions"`, 1)},
+		{"unknown disabled veto", strings.Replace(valid, `"interactions"`, `"config": {"gate_config": {"disabled_vetoes": ["risk"]}}, "interactions"`, 1)},
+		{"negative weight", strings.Replace(valid, `"interactions"`, `"config": {"gate_config": {"weights": {"entropy": -1}}}, "interactions"`, 1)},
+	}
+	for _, tt := range tests {
+		if _, err := ParseFixture([]byte(tt.body)); err == nil {
+			t.Errorf("%s: expected an error", tt.name)
+		}
+	}
+}
+
+func FuzzParseFixture(f *testing.F) {
+	for _, name := range []string{"live_session.json", "real_session.json"} {
+		data, err := os.ReadFile(filepath.Join("testdata", name))
+		if err != nil {
+			f.Fatalf("read seed: %v", err)
+		}
+		f.Add(data)
+	}
+	f.Add([]byte(`{"start_state": ` + startStateJSON + `}`))
+	f.Add([]byte(`{"start_state": {"state_vector": [1e39]}}`))
+	f.Fuzz(func(t *testing.T, data []byte) {
+		fx, err := ParseFixture(data)
+		if err != nil {
+			return
+		}
+		if err := fx.Validate(); err != nil {
+			t.Fatalf("accepted fixture fails Validate: %v", err)
+		}
+		out, err := json.Marshal(fx)
+		if err != nil {
+			t.Fatalf("marshal accepted fixture: %v", err)
+		}
+		if _, err := ParseFixture(out); err != nil {
+			t.Fatalf("accepted fixture does not round-trip: %v", err)
+		}
+	})
+}
+
+// #endregion strict-fixture-tests
@@ -3,6 +3,7 @@ package replay
 import (
 	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/eval"
 	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/gate"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/logging"
 	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/state"
 	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/update"
 )
@@ -16,6 +17,7 @@ type Interaction struct {
 	Entropy      float32
 	Signals      update.Signals
 	Evidence     []string
+	Model        string // logging.ModelLabel of the backing model; "" when not recorded
 }
 
 // ReplayConfig bundles update, gate, and eval configs for a replay run.
@@ -56,12 +58,25 @@ type ReplayResult struct {
 
 // ReplaySummary provides aggregate stats from a replay run.
 type ReplaySummary struct {
-	TotalTurns    int
+	TotalTurns     int
+	Commits        int
+	GateRejects    int
+	EvalRollbacks  int
+	EvalWarnings   int // commits whose delta the eval warning tier scaled down
+	GateDowngrades int // commits whose delta the gate scaled down to fit its caps
+	NoOps          int
+	FinalState     state.StateRecord
+}
+
+// ModelSummary aggregates replay outcomes over the turns one backing model generated.
+type ModelSummary struct {
+	Model         string
+	Turns         int
 	Commits       int
 	GateRejects   int
 	EvalRollbacks int
 	NoOps         int
-	FinalState    state.StateRecord
+	MeanDeltaNorm float32 // over turns that proposed an update
 }
 
 // #endregion types
@@ -70,6 +85,13 @@ type ReplaySummary struct {
 // Replay iterates through interactions, applying the full pipeline per turn:
 // update → gate → eval → commit/reject. Operates entirely in-memory.
 func Replay(startState state.StateRecord, interactions []Interaction, config ReplayConfig) []ReplayResult {
+	results, _ := replayTurns(startState, interactions, config, nil)
+	return results
+}
+
+// replayTurns is Replay, also returning the state the last commit left.
+// prepare, when set, may rewrite each interaction under the state it meets.
+func replayTurns(startState state.StateRecord, interactions []Interaction, config ReplayConfig, prepare func(Interaction, state.StateRecord) Interaction) ([]ReplayResult, state.StateRecord) {
 	current := startState
 	results := make([]ReplayResult, 0, len(interactions))
 
@@ -77,6 +99,9 @@ func Replay(startState state.StateRecord, interactions []Interaction, config Rep
 	evalInst := eval.NewEvalHarness(config.EvalConfig)
 
 	for _, inter := range interactions {
+		if prepare != nil {
+			inter = prepare(inter, current)
+		}
 		ctx := update.UpdateContext{
 			TurnID:       inter.TurnID,
 			Prompt:       inter.Prompt,
@@ -101,7 +126,7 @@ func Replay(startState state.StateRecord, interactions []Interaction, config Rep
 		}
 
 		// 3. Gate
-		gateDecision := gateInst.Evaluate(current, updateResult.NewState, inter.Signals, updateResult.Metrics, inter.Entropy)
+		gateDecision, proposed := gateInst.EvaluateDowngrade(current, updateResult.NewState, inter.Signals, updateResult.Metrics, inter.Entropy)
 		if gateDecision.Action == "reject" {
 			results = append(results, ReplayResult{
 				TurnID:         inter.TurnID,
@@ -115,8 +140,14 @@ func Replay(startState state.StateRecord, interactions []Interaction, config Rep
 			continue
 		}
 
-		// 4. Eval
-		evalResult := evalInst.Run(updateResult.NewState, inter.Entropy)
+		// A downgraded veto goes on with the delta scaled to fit the caps
+		if gateDecision.Action == "commit_scaled" {
+			updateResult.NewState = proposed
+			updateResult.Metrics.DeltaNorm *= gateDecision.Scale
+		}
+
+		// 4. Eval (a warning-tier result commits the scaled-down state)
+		evalResult, evaluated := evalInst.RunTiered(current, updateResult.NewState, inter.Entropy)
 		if !evalResult.Passed {
 			results = append(results, ReplayResult{
 				TurnID:         inter.TurnID,
@@ -132,7 +163,7 @@ func Replay(startState state.StateRecord, interactions []Interaction, config Rep
 		}
 
 		// 5. Commit — advance current state
-		current = updateResult.NewState
+		current = evaluated
 		results = append(results, ReplayResult{
 			TurnID:         inter.TurnID,
 			Action:         "commit",
@@ -145,7 +176,7 @@ func Replay(startState state.StateRecord, interactions []Interaction, config Rep
 		})
 	}
 
-	return results
+	return results, current
 }
 
 // Summarize computes aggregate stats from replay results.
@@ -158,6 +189,12 @@ func Summarize(results []ReplayResult, finalState state.StateRecord) ReplaySumma
 		switch r.Action {
 		case "commit":
 			s.Commits++
+			if r.EvalResult != nil && r.EvalResult.Tier == eval.TierWarn {
+				s.EvalWarnings++
+			}
+			if r.GateDecision != nil && r.GateDecision.Action == "commit_scaled" {
+				s.GateDowngrades++
+			}
 		case "gate_reject":
 			s.GateRejects++
 		case "eval_rollback":
@@ -169,4 +206,50 @@ func Summarize(results []ReplayResult, finalState state.StateRecord) ReplaySumma
 	return s
 }
 
+// SummarizeByModel groups results by the model of the interaction they replayed
+// (results[i] belongs to interactions[i]), in order of each model's first turn.
+// Interactions without a model are grouped under logging.UnknownModel.
+func SummarizeByModel(interactions []Interaction, results []ReplayResult) []ModelSummary {
+	var out []ModelSummary
+	index := map[string]int{}
+	updates := map[string]int{}
+	for i, r := range results {
+		if i >= len(interactions) {
+			break
+		}
+		model := interactions[i].Model
+		if model == "" {
+			model = logging.UnknownModel
+		}
+		j, ok := index[model]
+		if !ok {
+			j = len(out)
+			index[model] = j
+			out = append(out, ModelSummary{Model: model})
+		}
+		s := &out[j]
+		s.Turns++
+		switch r.Action {
+		case "commit":
+			s.Commits++
+		case "gate_reject":
+			s.GateRejects++
+		case "eval_rollback":
+			s.EvalRollbacks++
+		case "no_op":
+			s.NoOps++
+		}
+		if r.Action != "no_op" {
+			s.MeanDeltaNorm += r.UpdateMetrics.DeltaNorm
+			updates[model]++
+		}
+	}
+	for i := range out {
+		if n := updates[out[i].Model]; n > 0 {
+			out[i].MeanDeltaNorm /= float32(n)
+		}
+	}
+	return out
+}
+
 // #endregion replay
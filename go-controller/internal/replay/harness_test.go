@@ -3,6 +3,9 @@ package replay
 import (
 	"testing"
 
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/eval"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/gate"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/logging"
 	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/state"
 	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/update"
 )
@@ -129,6 +132,80 @@ func TestReplay_EvalRollback(t *testing.T) {
 	}
 }
 
+// 3b. Eval warning tier: a small breach commits a scaled-down delta.
+func TestReplay_EvalWarning(t *testing.T) {
+	start := seededState("v0", 0.1)
+	inter := commitInteraction("turn-1")
+	config := DefaultReplayConfig()
+	config.EvalConfig.WarnMargin = 0
+
+	probe := Replay(start, interactions(inter), config)
+	if probe[0].Action != "commit" {
+		t.Fatalf("setup: expected commit, got %s", probe[0].Action)
+	}
+	var proposedNorm float32
+	for _, m := range probe[0].EvalResult.Metrics {
+		if m.Name == "segment_prefs_norm" {
+			proposedNorm = m.Value
+		}
+	}
+
+	// Tighten the prefs bound to just under the proposed norm: a small breach
+	config.EvalConfig.SegmentNorms = map[string]float32{"prefs": proposedNorm * 0.95}
+	if r := Replay(start, interactions(inter), config)[0]; r.Action != "eval_rollback" {
+		t.Fatalf("without a warn margin expected eval_rollback, got %s", r.Action)
+	}
+
+	config.EvalConfig.WarnMargin = 0.2
+	results := Replay(start, interactions(inter), config)
+	r := results[0]
+	if r.Action != "commit" || r.EvalResult.Tier != eval.TierWarn {
+		t.Fatalf("expected warned commit, got %s (%s)", r.Action, r.EvalResult.Tier)
+	}
+	if r.EvalResult.Scale <= 0 || r.EvalResult.Scale >= 1 {
+		t.Errorf("expected a partial scale, got %.4f", r.EvalResult.Scale)
+	}
+	if s := Summarize(results, start); s.Commits != 1 || s.EvalWarnings != 1 {
+		t.Errorf("expected 1 commit with 1 warning, got %+v", s)
+	}
+}
+
+// 3c. Gate downgrade: a delta slightly over its cap commits scaled down.
+func TestReplay_GateDowngrade(t *testing.T) {
+	start := seededState("v0", 0.1)
+	inter := commitInteraction("turn-1")
+	config := DefaultReplayConfig()
+
+	probe := Replay(start, interactions(inter), config)
+	if probe[0].Action != "commit" {
+		t.Fatalf("setup: expected commit, got %s", probe[0].Action)
+	}
+	var deltaNorm float32
+	for _, c := range probe[0].GateDecision.Checks {
+		if c.Name == "delta_norm" {
+			deltaNorm = c.Value
+		}
+	}
+
+	config.GateConfig.MaxDeltaNorm = deltaNorm * 0.9
+	if r := Replay(start, interactions(inter), config)[0]; r.Action != "gate_reject" {
+		t.Fatalf("without a downgrade policy expected gate_reject, got %s", r.Action)
+	}
+
+	config.GateConfig.Downgrade = gate.DowngradePolicy{VetoTypes: []gate.VetoType{gate.VetoConstraint}, MaxExcess: 0.25}
+	results := Replay(start, interactions(inter), config)
+	r := results[0]
+	if r.Action != "commit" || r.GateDecision.Action != "commit_scaled" {
+		t.Fatalf("expected downgraded commit, got %s (gate %s)", r.Action, r.GateDecision.Action)
+	}
+	if r.GateDecision.Scale < 0.89 || r.GateDecision.Scale > 0.9 {
+		t.Errorf("expected scale ~0.9, got %.4f", r.GateDecision.Scale)
+	}
+	if s := Summarize(results, start); s.Commits != 1 || s.GateDowngrades != 1 {
+		t.Errorf("expected 1 commit with 1 downgrade, got %+v", s)
+	}
+}
+
 // 4. No-op: zero signals + zero state → action="no_op".
 func TestReplay_NoOp(t *testing.T) {
 	start := zeroState("v0")
@@ -326,6 +403,40 @@ func TestReplay_Deterministic(t *testing.T) {
 	}
 }
 
+// 9. Per-model summary groups turns by backing model in order of first appearance.
+func TestSummarizeByModel(t *testing.T) {
+	start := seededState("v0", 0.3)
+	inters := []Interaction{
+		commitInteraction("turn-1"),
+		commitInteraction("turn-2"),
+		commitInteraction("turn-3"),
+		commitInteraction("turn-4"),
+	}
+	inters[0].Model = "qwen3-4b@aaa"
+	inters[1].Model = "qwen3-4b@aaa"
+	inters[2].Model = "qwen3-8b@bbb"
+	// inters[3] has no recorded model
+	results := Replay(start, inters, DefaultReplayConfig())
+
+	groups := SummarizeByModel(inters, results)
+	if len(groups) != 3 {
+		t.Fatalf("expected 3 model groups, got %+v", groups)
+	}
+	if groups[0].Model != "qwen3-4b@aaa" || groups[0].Turns != 2 || groups[1].Model != "qwen3-8b@bbb" || groups[1].Turns != 1 {
+		t.Errorf("unexpected grouping: %+v", groups)
+	}
+	if groups[2].Model != logging.UnknownModel || groups[2].Turns != 1 {
+		t.Errorf("expected the unrecorded turn under %q, got %+v", logging.UnknownModel, groups[2])
+	}
+	var want float32
+	for _, r := range results[:2] {
+		want += r.UpdateMetrics.DeltaNorm
+	}
+	if want /= 2; groups[0].MeanDeltaNorm != want {
+		t.Errorf("mean delta norm = %f, want %f", groups[0].MeanDeltaNorm, want)
+	}
+}
+
 // helper: wrap single interaction in slice.
 func interactions(i Interaction) []Interaction {
 	return []Interaction{i}
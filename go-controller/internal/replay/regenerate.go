@@ -0,0 +1,55 @@
+package replay
+
+import (
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/state"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/update"
+)
+
+// #region regenerate
+
+// Regenerated is a response to a logged prompt and the signals behind it.
+type Regenerated struct {
+	ResponseText string
+	Entropy      float32
+	Signals      update.Signals
+}
+
+// Regenerator produces a fresh response and its signals for inter, generated
+// under current, the replayed state the turn meets. The replay package stays
+// free of the codec; callers wire the inference service in.
+type Regenerator func(inter Interaction, current state.StateRecord) (Regenerated, error)
+
+// Regeneration pairs one turn's logged output with the regenerated one.
+type Regeneration struct {
+	TurnID string
+	Logged Regenerated
+	Fresh  Regenerated
+	Err    error // regeneration failed; the turn replayed with its logged inputs
+}
+
+// ReplayRegenerated is Replay with each turn's response, entropy and signals
+// replaced by regen's before the update, so the pipeline sees what the model
+// would say now under the replayed state. It also returns, per interaction,
+// the logged and fresh outputs for comparison.
+func ReplayRegenerated(startState state.StateRecord, interactions []Interaction, config ReplayConfig, regen Regenerator) ([]ReplayResult, []Regeneration) {
+	regens := make([]Regeneration, 0, len(interactions))
+	results, _ := replayTurns(startState, interactions, config, func(inter Interaction, current state.StateRecord) Interaction {
+		r := Regeneration{
+			TurnID: inter.TurnID,
+			Logged: Regenerated{ResponseText: inter.ResponseText, Entropy: inter.Entropy, Signals: inter.Signals},
+		}
+		fresh, err := regen(inter, current)
+		if err != nil {
+			r.Err = err
+			regens = append(regens, r)
+			return inter
+		}
+		r.Fresh = fresh
+		regens = append(regens, r)
+		inter.ResponseText, inter.Entropy, inter.Signals = fresh.ResponseText, fresh.Entropy, fresh.Signals
+		return inter
+	})
+	return results, regens
+}
+
+// #endregion regenerate
@@ -0,0 +1,48 @@
+package replay
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/state"
+)
+
+func TestReplayRegenerated(t *testing.T) {
+	start := seededState("v0", 0.1)
+	inters := []Interaction{commitInteraction("turn-1"), commitInteraction("turn-2"), commitInteraction("turn-3")}
+
+	var seen []string
+	regen := func(inter Interaction, current state.StateRecord) (Regenerated, error) {
+		seen = append(seen, current.VersionID)
+		switch inter.TurnID {
+		case "turn-2":
+			// The fresh response draws a correction: the gate vetoes it
+			sigs := inter.Signals
+			sigs.UserCorrection = true
+			return Regenerated{ResponseText: "fresh 2", Entropy: 0.4, Signals: sigs}, nil
+		case "turn-3":
+			return Regenerated{}, errors.New("codec unavailable")
+		}
+		return Regenerated{ResponseText: "fresh 1", Entropy: 0.3, Signals: inter.Signals}, nil
+	}
+
+	results, regens := ReplayRegenerated(start, inters, DefaultReplayConfig(), regen)
+
+	if len(results) != 3 || len(regens) != 3 {
+		t.Fatalf("expected 3 results and regenerations, got %d and %d", len(results), len(regens))
+	}
+	// Each turn is regenerated under the state the replay reached
+	if seen[0] != "v0" || seen[1] != results[0].FinalVersionID {
+		t.Errorf("regenerated under %v, want v0 then %s", seen, results[0].FinalVersionID)
+	}
+	if results[0].Action != "commit" || results[1].Action != "gate_reject" {
+		t.Errorf("actions = %s, %s; want commit, gate_reject", results[0].Action, results[1].Action)
+	}
+	if regens[0].Logged.ResponseText != "test response" || regens[0].Fresh.ResponseText != "fresh 1" || regens[0].Fresh.Entropy != 0.3 {
+		t.Errorf("turn-1 regeneration = %+v", regens[0])
+	}
+	// A failed regeneration replays the logged turn
+	if regens[2].Err == nil || results[2].Action != "commit" {
+		t.Errorf("turn-3: err=%v action=%s, want an error and the logged turn's commit", regens[2].Err, results[2].Action)
+	}
+}
@@ -0,0 +1,198 @@
+package replay
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/state"
+)
+
+// #region sweep-types
+
+// MaxSweepPoints bounds the grid a sweep replays; each point is a full replay.
+const MaxSweepPoints = 1000
+
+// SweepAxis is one swept parameter and the values it takes.
+type SweepAxis struct {
+	Param  string
+	Values []float32
+}
+
+// SweepPoint is one replay of the sweep grid.
+type SweepPoint struct {
+	Values       []float32 // one per axis, in axis order
+	Config       ReplayConfig
+	Results      []ReplayResult
+	Summary      ReplaySummary
+	SegmentNorms map[string]float64 // of the final state
+	Diverged     int                // turns whose action differs from the baseline replay
+}
+
+// sweepParams maps each sweepable parameter to the config field it sets.
+// Gate keys match the gate policy file's.
+var sweepParams = map[string]func(*ReplayConfig) *float32{
+	"learning_rate":     func(c *ReplayConfig) *float32 { return &c.UpdateConfig.LearningRate },
+	"decay_rate":        func(c *ReplayConfig) *float32 { return &c.UpdateConfig.DecayRate },
+	"segment_delta_cap": func(c *ReplayConfig) *float32 { return &c.UpdateConfig.MaxDeltaNormPerSegment },
+	"update_state_cap":  func(c *ReplayConfig) *float32 { return &c.UpdateConfig.MaxStateNorm },
+	"max_delta_norm":    func(c *ReplayConfig) *float32 { return &c.GateConfig.MaxDeltaNorm },
+	"max_state_norm":    func(c *ReplayConfig) *float32 { return &c.GateConfig.MaxStateNorm },
+	"risk_segment_cap":  func(c *ReplayConfig) *float32 { return &c.GateConfig.RiskSegmentCap },
+	"min_entropy_drop":  func(c *ReplayConfig) *float32 { return &c.GateConfig.MinEntropyDrop },
+}
+
+// SweepParams lists the parameter names ParseSweep accepts, sorted.
+func SweepParams() []string {
+	names := make([]string, 0, len(sweepParams))
+	for name := range sweepParams {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// #endregion sweep-types
+
+// #region sweep-parse
+
+// ParseSweep reads a comma-separated list of "param=values" axes, e.g.
+// "learning_rate=0.005:0.05:4,max_delta_norm=3|4|5". Values are a "|" list or
+// "lo:hi:n", n evenly spaced values from lo to hi inclusive.
+func ParseSweep(spec string) ([]SweepAxis, error) {
+	var axes []SweepAxis
+	points := 1
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		param, values, ok := strings.Cut(part, "=")
+		param = strings.TrimSpace(param)
+		if !ok {
+			return nil, fmt.Errorf("sweep %q: want param=values", part)
+		}
+		if _, known := sweepParams[param]; !known {
+			return nil, fmt.Errorf("sweep %q: unknown parameter (one of %s)", part, strings.Join(SweepParams(), ", "))
+		}
+		for _, a := range axes {
+			if a.Param == param {
+				return nil, fmt.Errorf("sweep %q: %s swept twice", part, param)
+			}
+		}
+		vals, err := parseSweepValues(strings.TrimSpace(values))
+		if err != nil {
+			return nil, fmt.Errorf("sweep %q: %w", part, err)
+		}
+		axes = append(axes, SweepAxis{Param: param, Values: vals})
+		if points *= len(vals); points > MaxSweepPoints {
+			return nil, fmt.Errorf("sweep %q: grid exceeds %d points", spec, MaxSweepPoints)
+		}
+	}
+	if len(axes) == 0 {
+		return nil, fmt.Errorf("sweep %q: no parameters", spec)
+	}
+	return axes, nil
+}
+
+func parseSweepValues(s string) ([]float32, error) {
+	number := func(v string) (float32, error) {
+		f, err := strconv.ParseFloat(strings.TrimSpace(v), 32)
+		if err != nil || f < 0 || math.IsInf(f, 0) {
+			return 0, fmt.Errorf("value %q must be a non-negative number", v)
+		}
+		return float32(f), nil
+	}
+	if lo, rest, ok := strings.Cut(s, ":"); ok {
+		hi, n, ok := strings.Cut(rest, ":")
+		if !ok {
+			return nil, fmt.Errorf("range %q: want lo:hi:n", s)
+		}
+		from, err := number(lo)
+		if err != nil {
+			return nil, err
+		}
+		to, err := number(hi)
+		if err != nil {
+			return nil, err
+		}
+		steps, err := strconv.Atoi(strings.TrimSpace(n))
+		if err != nil || steps < 2 || from >= to {
+			return nil, fmt.Errorf("range %q: want lo < hi and at least 2 steps", s)
+		}
+		vals := make([]float32, steps)
+		for i := range vals {
+			vals[i] = from + (to-from)*float32(i)/float32(steps-1)
+		}
+		return vals, nil
+	}
+	var vals []float32
+	for _, v := range strings.Split(s, "|") {
+		f, err := number(v)
+		if err != nil {
+			return nil, err
+		}
+		vals = append(vals, f)
+	}
+	return vals, nil
+}
+
+// #endregion sweep-parse
+
+// #region sweep
+
+// Sweep replays interactions once under base and once per point of the grid
+// the axes span (the last axis varies fastest). Each point's Diverged counts
+// turns whose action differs from the base replay, returned as baseline with
+// the base config's value for each axis.
+func Sweep(start state.StateRecord, interactions []Interaction, base ReplayConfig, axes []SweepAxis) (SweepPoint, []SweepPoint) {
+	run := func(config ReplayConfig, values []float32) SweepPoint {
+		results, final := replayTurns(start, interactions, config, nil)
+		return SweepPoint{
+			Values:       values,
+			Config:       config,
+			Results:      results,
+			Summary:      Summarize(results, final),
+			SegmentNorms: final.SegmentMap.Norms(final.StateVector),
+		}
+	}
+	baseValues := make([]float32, len(axes))
+	for i, a := range axes {
+		baseValues[i] = *sweepParams[a.Param](&base)
+	}
+	baseline := run(base, baseValues)
+
+	var points []SweepPoint
+	index := make([]int, len(axes))
+	for {
+		config := base
+		values := make([]float32, len(axes))
+		for i, a := range axes {
+			values[i] = a.Values[index[i]]
+			*sweepParams[a.Param](&config) = values[i]
+		}
+		p := run(config, values)
+		for i, r := range p.Results {
+			if i < len(baseline.Results) && r.Action != baseline.Results[i].Action {
+				p.Diverged++
+			}
+		}
+		points = append(points, p)
+
+		// Advance the grid like an odometer
+		i := len(axes) - 1
+		for ; i >= 0; i-- {
+			if index[i]++; index[i] < len(axes[i].Values) {
+				break
+			}
+			index[i] = 0
+		}
+		if i < 0 {
+			return baseline, points
+		}
+	}
+}
+
+// #endregion sweep
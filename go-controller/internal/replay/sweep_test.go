@@ -0,0 +1,94 @@
+package replay
+
+import (
+	"math"
+	"testing"
+)
+
+func TestParseSweep(t *testing.T) {
+	axes, err := ParseSweep("learning_rate=0.005:0.05:4, max_delta_norm=3|4|5")
+	if err != nil {
+		t.Fatalf("ParseSweep: %v", err)
+	}
+	if len(axes) != 2 || axes[0].Param != "learning_rate" || axes[1].Param != "max_delta_norm" {
+		t.Fatalf("axes = %+v", axes)
+	}
+	wantLR := []float32{0.005, 0.02, 0.035, 0.05}
+	if len(axes[0].Values) != len(wantLR) {
+		t.Fatalf("learning_rate values = %v, want %v", axes[0].Values, wantLR)
+	}
+	for i, want := range wantLR {
+		if math.Abs(float64(axes[0].Values[i]-want)) > 1e-6 {
+			t.Errorf("learning_rate[%d] = %f, want %f", i, axes[0].Values[i], want)
+		}
+	}
+	if got := axes[1].Values; len(got) != 3 || got[0] != 3 || got[2] != 5 {
+		t.Errorf("max_delta_norm values = %v, want [3 4 5]", got)
+	}
+}
+
+func TestParseSweep_RejectsMalformed(t *testing.T) {
+	for _, spec := range []string{
+		"",
+		"learning_rate",
+		"speed=1|2",
+		"learning_rate=0.01,learning_rate=0.02",
+		"learning_rate=fast",
+		"learning_rate=-0.01",
+		"learning_rate=0.05:0.005:4",
+		"learning_rate=0.005:0.05:1",
+		"learning_rate=0.005:0.05",
+		"learning_rate=0:1:100,decay_rate=0:1:100",
+	} {
+		if _, err := ParseSweep(spec); err == nil {
+			t.Errorf("ParseSweep(%q) succeeded, want error", spec)
+		}
+	}
+}
+
+func TestSweep_GridAndDivergence(t *testing.T) {
+	start := seededState("v0", 0.1)
+	inters := []Interaction{commitInteraction("turn-1"), commitInteraction("turn-2")}
+	axes := []SweepAxis{
+		{Param: "learning_rate", Values: []float32{0.01, 0.02}},
+		{Param: "max_delta_norm", Values: []float32{0.000001, 1.0}},
+	}
+
+	baseline, points := Sweep(start, inters, DefaultReplayConfig(), axes)
+
+	if baseline.Summary.Commits != 2 {
+		t.Fatalf("baseline commits = %d, want 2", baseline.Summary.Commits)
+	}
+	if baseline.Values[0] != 0.01 || baseline.Values[1] != 5.0 {
+		t.Errorf("baseline values = %v, want the defaults [0.01 5]", baseline.Values)
+	}
+	if len(points) != 4 {
+		t.Fatalf("points = %d, want 4", len(points))
+	}
+	// Last axis varies fastest
+	wantValues := [][]float32{{0.01, 0.000001}, {0.01, 1.0}, {0.02, 0.000001}, {0.02, 1.0}}
+	for i, p := range points {
+		if p.Values[0] != wantValues[i][0] || p.Values[1] != wantValues[i][1] {
+			t.Errorf("point %d values = %v, want %v", i, p.Values, wantValues[i])
+		}
+		if p.Config.UpdateConfig.LearningRate != p.Values[0] || p.Config.GateConfig.MaxDeltaNorm != p.Values[1] {
+			t.Errorf("point %d config not applied: %+v", i, p.Config)
+		}
+	}
+
+	// A near-zero delta cap rejects every turn the baseline committed
+	for _, i := range []int{0, 2} {
+		if points[i].Summary.GateRejects != 2 || points[i].Diverged != 2 {
+			t.Errorf("point %d: rejects=%d diverged=%d, want 2 and 2", i, points[i].Summary.GateRejects, points[i].Diverged)
+		}
+		if got, want := points[i].SegmentNorms["prefs"], start.SegmentMap.Norms(start.StateVector)["prefs"]; got != want {
+			t.Errorf("point %d: prefs norm = %f without a commit, want %f", i, got, want)
+		}
+	}
+	if points[1].Diverged != 0 || points[1].Summary.Commits != 2 {
+		t.Errorf("baseline-equivalent point: diverged=%d commits=%d", points[1].Diverged, points[1].Summary.Commits)
+	}
+	if points[3].SegmentNorms["prefs"] == points[1].SegmentNorms["prefs"] {
+		t.Error("expected a higher learning rate to end at a different prefs norm")
+	}
+}
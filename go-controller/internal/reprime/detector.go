@@ -0,0 +1,87 @@
+package reprime
+
+import (
+	"fmt"
+	"time"
+)
+
+// #region detector
+
+// Reset reasons.
+const (
+	ReasonRestart = "service_restart" // the codec server reports a new instance
+	ReasonOutage  = "service_outage"  // the codec was unreachable and came back, instance unknown
+	ReasonModel   = "model_change"    // generation came from a different model or model version
+)
+
+// Reset is a detected loss of the backing model's context.
+type Reset struct {
+	Reason string
+	Detail string
+	At     time.Time
+}
+
+// Detector turns codec heartbeats and model labels into resets. A reset stays
+// pending until Clear, so it survives turns that generate nothing.
+type Detector struct {
+	instance  string    // last instance a heartbeat reported
+	downSince time.Time // first failed heartbeat of the current outage; zero while up
+	downErr   error
+	pending   *Reset
+}
+
+// NewDetector returns a detector with nothing seen yet; the first heartbeat
+// only records the instance.
+func NewDetector() *Detector {
+	return &Detector{}
+}
+
+// Heartbeat records one codec handshake: the instance it reported (or "" if
+// the server does not send one) or the error it failed with. It returns the
+// reset it detected, if any. A new instance is a restart. Coming back after
+// failed heartbeats is an outage reset unless the same instance answers, in
+// which case only the connection dropped.
+func (d *Detector) Heartbeat(instance string, err error, now time.Time) *Reset {
+	if err != nil {
+		if d.downSince.IsZero() {
+			d.downSince, d.downErr = now, err
+		}
+		return nil
+	}
+	downSince, downErr := d.downSince, d.downErr
+	d.downSince, d.downErr = time.Time{}, nil
+	prev := d.instance
+	if instance != "" {
+		d.instance = instance
+	}
+	switch {
+	case instance != "" && prev != "" && instance != prev:
+		return d.Flag(ReasonRestart, fmt.Sprintf("instance %s → %s", prev, instance), now)
+	case !downSince.IsZero() && (instance == "" || prev == ""):
+		return d.Flag(ReasonOutage, fmt.Sprintf("unreachable for %s: %v", now.Sub(downSince).Round(time.Second), downErr), now)
+	}
+	return nil
+}
+
+// Flag marks a reset as pending and returns it. A reset already pending is
+// kept; the new detail is appended to it.
+func (d *Detector) Flag(reason, detail string, now time.Time) *Reset {
+	if d.pending != nil {
+		d.pending.Detail += "; " + reason + ": " + detail
+		return d.pending
+	}
+	d.pending = &Reset{Reason: reason, Detail: detail, At: now}
+	return d.pending
+}
+
+// Pending returns the reset the next prompt should re-prime for, or nil.
+func (d *Detector) Pending() *Reset {
+	return d.pending
+}
+
+// Clear forgets the pending reset once a prompt carried the recap.
+func (d *Detector) Clear() {
+	d.pending = nil
+}
+
+// #endregion detector
@@ -0,0 +1,98 @@
+package reprime
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// #region recap
+
+// Marker opens the recap block ahead of the prompt.
+const Marker = "[CONTEXT RECAP]"
+
+// Config bounds the recap.
+type Config struct {
+	Turns       int // recent exchanges included (default 3)
+	Preferences int // preferences included (default 8)
+	Clip        int // characters kept of each prompt and response (default 160)
+}
+
+// DefaultConfig returns the recap defaults.
+func DefaultConfig() Config {
+	return Config{Turns: 3, Preferences: 8, Clip: 160}
+}
+
+// Exchange is one earlier turn, as the recap shows it.
+type Exchange struct {
+	Prompt   string
+	Response string
+}
+
+// Recap is what the model is re-primed with after a reset.
+type Recap struct {
+	Norms       map[string]float64 // segment norms of the active state
+	Preferences []string           // preference texts in priority order
+	Turns       []Exchange         // recent exchanges, oldest first
+}
+
+// Block renders the recap as a block to put ahead of the prompt, within
+// cfg's bounds: the most recent exchanges and the first preferences are kept.
+func (r Recap) Block(cfg Config) string {
+	def := DefaultConfig()
+	if cfg.Turns <= 0 {
+		cfg.Turns = def.Turns
+	}
+	if cfg.Preferences <= 0 {
+		cfg.Preferences = def.Preferences
+	}
+	if cfg.Clip <= 0 {
+		cfg.Clip = def.Clip
+	}
+	lines := []string{Marker,
+		"The model serving this conversation was restarted and lost its earlier context. " +
+			"This recaps where things stand; continue from it without mentioning the restart."}
+	if len(r.Norms) > 0 {
+		names := make([]string, 0, len(r.Norms))
+		for name := range r.Norms {
+			names = append(names, name)
+		}
+		sort.Slice(names, func(i, j int) bool {
+			if r.Norms[names[i]] != r.Norms[names[j]] {
+				return r.Norms[names[i]] > r.Norms[names[j]]
+			}
+			return names[i] < names[j]
+		})
+		parts := make([]string, len(names))
+		for i, name := range names {
+			parts[i] = fmt.Sprintf("%s %.2f", name, r.Norms[name])
+		}
+		lines = append(lines, "State (segment strength, strongest first): "+strings.Join(parts, ", "))
+	}
+	if prefs := r.Preferences; len(prefs) > 0 {
+		if len(prefs) > cfg.Preferences {
+			prefs = prefs[:cfg.Preferences]
+		}
+		lines = append(lines, "Preferences: "+strings.Join(prefs, "; "))
+	}
+	if turns := r.Turns; len(turns) > 0 {
+		if len(turns) > cfg.Turns {
+			turns = turns[len(turns)-cfg.Turns:]
+		}
+		lines = append(lines, "Recent exchanges:")
+		for _, t := range turns {
+			lines = append(lines, fmt.Sprintf("- User: %q → You: %q", clip(t.Prompt, cfg.Clip), clip(t.Response, cfg.Clip)))
+		}
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+func clip(s string, n int) string {
+	s = strings.Join(strings.Fields(s), " ")
+	if r := []rune(s); len(r) > n {
+		return string(r[:n-1]) + "…"
+	}
+	return s
+}
+
+// #endregion recap
@@ -0,0 +1,86 @@
+package reprime
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDetector_Restart(t *testing.T) {
+	d := NewDetector()
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	if r := d.Heartbeat("a", nil, now); r != nil {
+		t.Fatalf("first heartbeat flagged %+v", r)
+	}
+	if r := d.Heartbeat("a", nil, now.Add(time.Minute)); r != nil {
+		t.Fatalf("same instance flagged %+v", r)
+	}
+	r := d.Heartbeat("b", nil, now.Add(2*time.Minute))
+	if r == nil || r.Reason != ReasonRestart || r.Detail != "instance a → b" {
+		t.Fatalf("restart = %+v", r)
+	}
+	if d.Pending() != r {
+		t.Error("reset not pending")
+	}
+	d.Clear()
+	if d.Pending() != nil {
+		t.Error("Clear left the reset pending")
+	}
+}
+
+func TestDetector_Outage(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	down := errors.New("connection refused")
+
+	// Same instance after failures: the connection dropped, the server did not restart
+	d := NewDetector()
+	d.Heartbeat("a", nil, now)
+	d.Heartbeat("", down, now.Add(time.Minute))
+	if r := d.Heartbeat("a", nil, now.Add(2*time.Minute)); r != nil {
+		t.Errorf("reconnect to the same instance flagged %+v", r)
+	}
+
+	// A server that sends no instance: recovery after failures counts as a reset
+	d = NewDetector()
+	d.Heartbeat("", nil, now)
+	d.Heartbeat("", down, now.Add(time.Minute))
+	d.Heartbeat("", down, now.Add(2*time.Minute))
+	r := d.Heartbeat("", nil, now.Add(3*time.Minute))
+	if r == nil || r.Reason != ReasonOutage || r.Detail != "unreachable for 2m0s: connection refused" {
+		t.Fatalf("outage = %+v", r)
+	}
+
+	// Further resets before the next prompt fold into the pending one
+	d.Flag(ReasonModel, "x → y", now.Add(4*time.Minute))
+	if p := d.Pending(); p.Reason != ReasonOutage || !strings.HasSuffix(p.Detail, "; model_change: x → y") {
+		t.Errorf("pending = %+v", p)
+	}
+}
+
+func TestRecap_Block(t *testing.T) {
+	r := Recap{
+		Norms:       map[string]float64{"prefs": 0.8, "goals": 0.4, "risk": 0.4},
+		Preferences: []string{"be concise", "use metric units", "no emoji"},
+		Turns: []Exchange{
+			{Prompt: "first", Response: "one"},
+			{Prompt: "plan my\ntrip", Response: strings.Repeat("x", 50)},
+			{Prompt: "and the hotel?", Response: "Booked."},
+		},
+	}
+	got := r.Block(Config{Turns: 2, Preferences: 2, Clip: 10})
+	want := Marker + "\n" +
+		"The model serving this conversation was restarted and lost its earlier context. " +
+		"This recaps where things stand; continue from it without mentioning the restart.\n" +
+		"State (segment strength, strongest first): prefs 0.80, goals 0.40, risk 0.40\n" +
+		"Preferences: be concise; use metric units\n" +
+		"Recent exchanges:\n" +
+		"- User: \"plan my t…\" → You: \"xxxxxxxxx…\"\n" +
+		"- User: \"and the h…\" → You: \"Booked.\"\n"
+	if got != want {
+		t.Errorf("block =\n%s\nwant\n%s", got, want)
+	}
+	if empty := (Recap{}).Block(DefaultConfig()); strings.Count(empty, "\n") != 2 {
+		t.Errorf("empty recap = %q", empty)
+	}
+}
@@ -0,0 +1,141 @@
+package resource
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/graph"
+)
+
+// #region profile
+
+// Profile scales the per-turn pipeline to the machine it runs on. Full runs
+// every stage; Low is for small boards (a Raspberry Pi) where a turn's second
+// and third Generate calls dominate its cost.
+type Profile struct {
+	Name string
+
+	Reflection bool // run the post-turn reflection Generate
+	Regenerate bool // re-generate with retrieved evidence (the second pass)
+
+	MaxEvidence int // cap on retrieved evidence per turn; 0 keeps the strategy's
+	WalkDepth   int // graph walk hops; 0 keeps the walk config's
+	WalkNodes   int // graph walk nodes returned; 0 keeps the walk config's
+
+	GraphDecayEvery int // turns between graph edge decay passes; 0 never decays
+}
+
+// Full is the default profile: nothing is skipped or shrunk.
+func Full() Profile {
+	return Profile{Name: "full", Reflection: true, Regenerate: true, GraphDecayEvery: 50}
+}
+
+// Low skips reflection and the second pass, retrieves at most two items over
+// a shallow graph walk, and decays graph edges a quarter as often. Edge decay
+// is exponential in edge age, so a longer interval only defers the work.
+func Low() Profile {
+	return Profile{Name: "low", MaxEvidence: 2, WalkDepth: 2, WalkNodes: 4, GraphDecayEvery: 200}
+}
+
+// IsFull reports whether p runs the whole pipeline, so turns need not record it.
+func (p Profile) IsFull() bool {
+	return p == Full()
+}
+
+// EvidenceCap returns the retrieval cap for a strategy allowing strategyMax items.
+func (p Profile) EvidenceCap(strategyMax int) int {
+	if p.MaxEvidence > 0 && p.MaxEvidence < strategyMax {
+		return p.MaxEvidence
+	}
+	return strategyMax
+}
+
+// Walk returns cfg with the profile's walk limits applied.
+func (p Profile) Walk(cfg graph.WalkConfig) graph.WalkConfig {
+	if p.WalkDepth > 0 && p.WalkDepth < cfg.MaxDepth {
+		cfg.MaxDepth = p.WalkDepth
+	}
+	if p.WalkNodes > 0 && p.WalkNodes < cfg.MaxNodes {
+		cfg.MaxNodes = p.WalkNodes
+	}
+	return cfg
+}
+
+// DecayDue reports whether the graph decay pass runs on turn turnNum.
+func (p Profile) DecayDue(turnNum int) bool {
+	return p.GraphDecayEvery > 0 && turnNum%p.GraphDecayEvery == 0
+}
+
+// String lists the profile's settings, e.g. for the startup log.
+func (p Profile) String() string {
+	return fmt.Sprintf("%s (reflection=%t regenerate=%t max_evidence=%d walk_depth=%d walk_nodes=%d graph_decay_every=%d)",
+		p.Name, p.Reflection, p.Regenerate, p.MaxEvidence, p.WalkDepth, p.WalkNodes, p.GraphDecayEvery)
+}
+
+// #endregion profile
+
+// #region parse
+
+// Parse reads a profile spec: a base profile name, "full" (the default when
+// spec is empty) or "low", optionally followed by comma-separated key=value
+// overrides, e.g. "low,max_evidence=3,reflection=1". Keys: reflection,
+// regenerate (0 or 1), max_evidence, walk_depth, walk_nodes,
+// graph_decay_every (non-negative integers). An overridden profile is named
+// after its base with a "+" suffix.
+func Parse(spec string) (Profile, error) {
+	parts := strings.Split(spec, ",")
+	var p Profile
+	switch name := strings.TrimSpace(parts[0]); name {
+	case "", "full":
+		p = Full()
+	case "low":
+		p = Low()
+	default:
+		return Profile{}, fmt.Errorf("resource profile %q: want full or low", name)
+	}
+	overridden := false
+	for _, part := range parts[1:] {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(part, "=")
+		key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+		if !ok {
+			return Profile{}, fmt.Errorf("resource profile %q: want key=value", part)
+		}
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			return Profile{}, fmt.Errorf("resource profile %q: value must be a non-negative integer", part)
+		}
+		switch key {
+		case "reflection", "regenerate":
+			if n > 1 {
+				return Profile{}, fmt.Errorf("resource profile %q: want 0 or 1", part)
+			}
+			if key == "reflection" {
+				p.Reflection = n == 1
+			} else {
+				p.Regenerate = n == 1
+			}
+		case "max_evidence":
+			p.MaxEvidence = n
+		case "walk_depth":
+			p.WalkDepth = n
+		case "walk_nodes":
+			p.WalkNodes = n
+		case "graph_decay_every":
+			p.GraphDecayEvery = n
+		default:
+			return Profile{}, fmt.Errorf("resource profile %q: unknown key %q", part, key)
+		}
+		overridden = true
+	}
+	if overridden {
+		p.Name += "+"
+	}
+	return p, nil
+}
+
+// #endregion parse
@@ -0,0 +1,58 @@
+package resource
+
+import (
+	"testing"
+
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/graph"
+)
+
+func TestParse(t *testing.T) {
+	p, err := Parse("")
+	if err != nil || !p.IsFull() {
+		t.Fatalf("empty spec = %+v, %v", p, err)
+	}
+	p, err = Parse("low")
+	if err != nil || p != Low() || p.Reflection || p.Regenerate {
+		t.Fatalf("low = %+v, %v", p, err)
+	}
+	p, err = Parse("low, max_evidence=3, reflection=1")
+	if err != nil {
+		t.Fatalf("overrides: %v", err)
+	}
+	if p.Name != "low+" || p.MaxEvidence != 3 || !p.Reflection || p.Regenerate || p.WalkDepth != 2 {
+		t.Errorf("overridden low = %+v", p)
+	}
+	for _, bad := range []string{"tiny", "low,max_evidence", "low,regenerate=2", "low,walk_depth=-1", "full,speed=1"} {
+		if _, err := Parse(bad); err == nil {
+			t.Errorf("Parse(%q) accepted", bad)
+		}
+	}
+}
+
+func TestProfile_Limits(t *testing.T) {
+	low := Low()
+	if got := low.EvidenceCap(5); got != 2 {
+		t.Errorf("low cap of 5 = %d", got)
+	}
+	if got := low.EvidenceCap(1); got != 1 {
+		t.Errorf("low cap of 1 = %d (a profile never raises the strategy's cap)", got)
+	}
+	if got := Full().EvidenceCap(5); got != 5 {
+		t.Errorf("full cap of 5 = %d", got)
+	}
+
+	walk := low.Walk(graph.DefaultWalkConfig())
+	if walk.MaxDepth != 2 || walk.MaxNodes != 4 || walk.MinWeight != graph.DefaultWalkConfig().MinWeight {
+		t.Errorf("low walk = %+v", walk)
+	}
+	if walk := Full().Walk(graph.DefaultWalkConfig()); walk.MaxDepth != 5 || walk.MaxNodes != 10 {
+		t.Errorf("full walk = %+v", walk)
+	}
+
+	if !Full().DecayDue(100) || Full().DecayDue(120) || low.DecayDue(100) || !low.DecayDue(400) {
+		t.Error("decay intervals wrong")
+	}
+	if (Profile{}).DecayDue(0) {
+		t.Error("zero interval decays")
+	}
+}
@@ -0,0 +1,178 @@
+package retrieval
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/vecmath"
+)
+
+// #region attribution-types
+
+// Claim is one sentence of a response, located by byte offsets into it, with
+// the retrieved items that support it. Support indexes refer to the records
+// given to Attribute, best match first; an empty Support marks an
+// unsupported claim.
+type Claim struct {
+	Text       string
+	Start, End int
+	Support    []Support
+}
+
+// Support links a claim to one evidence item.
+type Support struct {
+	Index      int
+	Similarity float32
+}
+
+// Supported reports whether any evidence item backs the claim.
+func (c Claim) Supported() bool { return len(c.Support) > 0 }
+
+// AttributionConfig tunes the alignment. Sentences shorter than MinChars or
+// ending in "?" are not treated as claims; at most MaxClaims are embedded.
+type AttributionConfig struct {
+	MinSimilarity float32
+	MaxSupport    int
+	MinChars      int
+	MaxClaims     int
+}
+
+// DefaultAttributionConfig returns the thresholds used by the controller.
+func DefaultAttributionConfig() AttributionConfig {
+	return AttributionConfig{MinSimilarity: 0.6, MaxSupport: 2, MinChars: 20, MaxClaims: 24}
+}
+
+// #endregion attribution-types
+
+// #region attribution-align
+
+// SplitClaims splits text into sentences on ., ! or ? followed by whitespace,
+// and on blank lines. Offsets exclude surrounding whitespace.
+func SplitClaims(text string) []Claim {
+	var claims []Claim
+	start := 0
+	emit := func(end int) {
+		s, e := start, end
+		for s < e && isSpace(text[s]) {
+			s++
+		}
+		for e > s && isSpace(text[e-1]) {
+			e--
+		}
+		if e > s {
+			claims = append(claims, Claim{Text: text[s:e], Start: s, End: e})
+		}
+		start = end
+	}
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		next := byte(0)
+		if i+1 < len(text) {
+			next = text[i+1]
+		}
+		switch {
+		case (c == '.' || c == '!' || c == '?') && (next == 0 || isSpace(next)):
+			emit(i + 1)
+		case c == '\n' && next == '\n':
+			emit(i + 1)
+		}
+	}
+	emit(len(text))
+	return claims
+}
+
+func isSpace(c byte) bool { return c == ' ' || c == '\n' || c == '\t' || c == '\r' }
+
+// Attribute maps each claim in response to the records whose embeddings align
+// with it. Every claim-worthy sentence and every record is embedded once, in a
+// single batch; a sentence is supported by the records at or above
+// cfg.MinSimilarity, keeping the best cfg.MaxSupport. Only claims are returned,
+// in response order.
+func Attribute(ctx context.Context, embed BatchEmbedFunc, response string, records []EvidenceRecord, cfg AttributionConfig) ([]Claim, error) {
+	var claims []Claim
+	for _, c := range SplitClaims(response) {
+		if len(c.Text) < cfg.MinChars || strings.HasSuffix(c.Text, "?") {
+			continue
+		}
+		if cfg.MaxClaims > 0 && len(claims) == cfg.MaxClaims {
+			break
+		}
+		claims = append(claims, c)
+	}
+	if len(claims) == 0 || len(records) == 0 {
+		return claims, nil
+	}
+
+	texts := make([]string, 0, len(records)+len(claims))
+	for _, rec := range records {
+		texts = append(texts, rec.Text)
+	}
+	for _, c := range claims {
+		texts = append(texts, c.Text)
+	}
+	vecs, err := embed(ctx, texts)
+	if err != nil {
+		return nil, fmt.Errorf("embed claims and evidence: %w", err)
+	}
+	if len(vecs) != len(texts) {
+		return nil, fmt.Errorf("embed claims and evidence: %d embeddings for %d texts", len(vecs), len(texts))
+	}
+	evVecs := vecs[:len(records)]
+	for ci := range claims {
+		vec := vecs[len(records)+ci]
+		var support []Support
+		for ei, ev := range evVecs {
+			if sim, _ := vecmath.Cosine(vec, ev); float32(sim) >= cfg.MinSimilarity {
+				support = append(support, Support{Index: ei, Similarity: float32(sim)})
+			}
+		}
+		sort.SliceStable(support, func(i, j int) bool { return support[i].Similarity > support[j].Similarity })
+		if cfg.MaxSupport > 0 && len(support) > cfg.MaxSupport {
+			support = support[:cfg.MaxSupport]
+		}
+		claims[ci].Support = support
+	}
+	return claims, nil
+}
+
+// #endregion attribution-align
+
+// #region attribution-cite
+
+// Cite inserts inline markers ("[1]", "[1][2]") after each supported claim,
+// ahead of its closing punctuation. Markers are numbered in order of first
+// citation; the returned slice maps marker n to record index sources[n-1].
+func Cite(response string, claims []Claim) (string, []int) {
+	number := make(map[int]int)
+	var sources []int
+	var b strings.Builder
+	prev := 0
+	for _, c := range claims {
+		if !c.Supported() {
+			continue
+		}
+		var markers strings.Builder
+		for _, s := range c.Support {
+			n, ok := number[s.Index]
+			if !ok {
+				sources = append(sources, s.Index)
+				n = len(sources)
+				number[s.Index] = n
+			}
+			fmt.Fprintf(&markers, "[%d]", n)
+		}
+		at := c.End
+		if last := response[at-1]; last == '.' || last == '!' {
+			at--
+		}
+		b.WriteString(response[prev:at])
+		b.WriteString(" " + markers.String())
+		prev = at
+	}
+	b.WriteString(response[prev:])
+	return b.String(), sources
+}
+
+// #endregion attribution-cite
@@ -0,0 +1,89 @@
+package retrieval
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+// fixedEmbed returns the vector registered for each text, or a zero vector.
+func fixedEmbed(vecs map[string][]float32) EmbedFunc {
+	return func(_ context.Context, text string) ([]float32, error) {
+		if v, ok := vecs[text]; ok {
+			return v, nil
+		}
+		return []float32{0, 0, 0}, nil
+	}
+}
+
+// #region attribution-align-tests
+func TestSplitClaims_Offsets(t *testing.T) {
+	text := "  Paris is the capital.  Is it big?\n\nYes, very!"
+	got := SplitClaims(text)
+	want := []string{"Paris is the capital.", "Is it big?", "Yes, very!"}
+	if len(got) != len(want) {
+		t.Fatalf("expected %d claims, got %+v", len(want), got)
+	}
+	for i, c := range got {
+		if c.Text != want[i] || text[c.Start:c.End] != want[i] {
+			t.Errorf("claim %d = %q (span %q), want %q", i, c.Text, text[c.Start:c.End], want[i])
+		}
+	}
+}
+
+func TestAttribute_LinksClaimsToEvidence(t *testing.T) {
+	records := []EvidenceRecord{
+		{ID: "ev-tz", Text: "The user lives in Berlin"},
+		{ID: "ev-job", Text: "The user works as a nurse"},
+	}
+	response := "You live in Berlin, so it is evening there. Nurses often work night shifts. Anything else?"
+	embed := fixedEmbed(map[string][]float32{
+		"The user lives in Berlin":                    {1, 0, 0},
+		"The user works as a nurse":                   {0, 1, 0},
+		"You live in Berlin, so it is evening there.": {0.9, 0.1, 0},
+		"Nurses often work night shifts.":             {0, 0.2, 1},
+	})
+
+	claims, err := Attribute(context.Background(), EmbedEach(embed), response, records, DefaultAttributionConfig())
+	if err != nil {
+		t.Fatalf("Attribute: %v", err)
+	}
+	if len(claims) != 2 {
+		t.Fatalf("expected 2 claims (question skipped), got %+v", claims)
+	}
+	if !claims[0].Supported() || claims[0].Support[0].Index != 0 {
+		t.Errorf("expected first claim supported by ev-tz, got %+v", claims[0])
+	}
+	if claims[1].Supported() {
+		t.Errorf("expected second claim unsupported, got %+v", claims[1])
+	}
+}
+
+func TestAttribute_EmbedError(t *testing.T) {
+	embed := func(context.Context, string) ([]float32, error) { return nil, errors.New("codec down") }
+	records := []EvidenceRecord{{ID: "ev-1", Text: "something"}}
+	if _, err := Attribute(context.Background(), EmbedEach(embed), "This is a long enough sentence.", records, DefaultAttributionConfig()); err == nil {
+		t.Fatal("expected embed error")
+	}
+}
+
+// #endregion attribution-align-tests
+
+// #region attribution-cite-tests
+func TestCite_NumbersInFirstCitationOrder(t *testing.T) {
+	response := "Berlin is cold. Nurses work nights! Unsupported line here"
+	claims := SplitClaims(response)
+	claims[0].Support = []Support{{Index: 3}}
+	claims[1].Support = []Support{{Index: 1}, {Index: 3}}
+
+	got, sources := Cite(response, claims)
+	want := "Berlin is cold [1]. Nurses work nights [2][1]! Unsupported line here"
+	if got != want {
+		t.Errorf("Cite = %q, want %q", got, want)
+	}
+	if len(sources) != 2 || sources[0] != 3 || sources[1] != 1 {
+		t.Errorf("sources = %v, want [3 1]", sources)
+	}
+}
+
+// #endregion attribution-cite-tests
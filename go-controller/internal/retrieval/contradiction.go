@@ -0,0 +1,215 @@
+package retrieval
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// #region contradiction-types
+
+// Contradiction is a pair of retrieved items that appear to state conflicting
+// facts about the same topic. Indexes refer to the slice given to
+// DetectContradictions.
+type Contradiction struct {
+	Preferred  int     // item the model should trust
+	Superseded int     // item it overrides
+	Kind       string  // "negation" (one item negates the other) | "value" (different numbers)
+	Basis      string  // why Preferred wins: "newer" | "more trusted" | "more relevant"
+	Overlap    float32 // shared content words / smaller item's content words
+}
+
+// ContradictionConfig tunes the heuristics. Two items are only compared when
+// they share at least MinShared content words making up at least MinOverlap of
+// the smaller item's vocabulary.
+type ContradictionConfig struct {
+	MinOverlap float32
+	MinShared  int
+}
+
+// DefaultContradictionConfig returns the thresholds used by the controller.
+func DefaultContradictionConfig() ContradictionConfig {
+	return ContradictionConfig{MinOverlap: 0.6, MinShared: 2}
+}
+
+// #endregion contradiction-types
+
+// #region contradiction-detect
+
+var numberPattern = regexp.MustCompile(`\d+(?:[.:]\d+)?`)
+
+// negationWords mark a statement as negated; "n't" contractions are matched separately.
+var negationWords = map[string]bool{
+	"not": true, "no": true, "never": true, "cannot": true, "none": true,
+	"nobody": true, "nothing": true, "neither": true, "nor": true,
+}
+
+// DetectContradictions compares every pair of retrieved items and returns the
+// pairs that look contradictory: heavily overlapping content with opposite
+// negation, or the same statement with different numbers. The heuristics are
+// deliberately conservative — a missed conflict costs nothing new, a false one
+// steers the model away from good evidence.
+func DetectContradictions(records []EvidenceRecord, cfg ContradictionConfig) []Contradiction {
+	type profile struct {
+		words   map[string]bool
+		negated bool
+		numbers map[string]bool
+		stored  time.Time
+	}
+	profiles := make([]profile, len(records))
+	for i, rec := range records {
+		p := profile{words: make(map[string]bool), numbers: make(map[string]bool), stored: storedAt(rec)}
+		for _, tok := range tokenize(rec.Text) {
+			p.words[stem(tok)] = true
+		}
+		p.negated = isNegated(rec.Text)
+		for _, n := range numberPattern.FindAllString(rec.Text, -1) {
+			p.numbers[n] = true
+		}
+		profiles[i] = p
+	}
+
+	var out []Contradiction
+	for i := 0; i < len(records); i++ {
+		for j := i + 1; j < len(records); j++ {
+			a, b := profiles[i], profiles[j]
+			shared := 0
+			for w := range a.words {
+				if b.words[w] {
+					shared++
+				}
+			}
+			smaller := len(a.words)
+			if len(b.words) < smaller {
+				smaller = len(b.words)
+			}
+			if smaller == 0 || shared < cfg.MinShared {
+				continue
+			}
+			overlap := float32(shared) / float32(smaller)
+			if overlap < cfg.MinOverlap {
+				continue
+			}
+			var kind string
+			switch {
+			case a.negated != b.negated:
+				kind = "negation"
+			case len(a.numbers) > 0 && len(b.numbers) > 0 && !sameSet(a.numbers, b.numbers):
+				kind = "value"
+			default:
+				continue
+			}
+			preferred, superseded, basis := rankConflict(records, i, j, a.stored, b.stored)
+			out = append(out, Contradiction{
+				Preferred: preferred, Superseded: superseded,
+				Kind: kind, Basis: basis, Overlap: overlap,
+			})
+		}
+	}
+	return out
+}
+
+// rankConflict decides which of two conflicting items to trust: primary-store
+// evidence over secondary sources, then the more recently stored item, then the
+// higher retrieval score.
+func rankConflict(records []EvidenceRecord, i, j int, ti, tj time.Time) (int, int, string) {
+	a, b := records[i], records[j]
+	switch {
+	case (a.Source == "") != (b.Source == ""):
+		if a.Source == "" {
+			return i, j, "more trusted"
+		}
+		return j, i, "more trusted"
+	case !ti.IsZero() && !tj.IsZero() && !ti.Equal(tj):
+		if ti.After(tj) {
+			return i, j, "newer"
+		}
+		return j, i, "newer"
+	case b.Score > a.Score:
+		return j, i, "more relevant"
+	default:
+		return i, j, "more relevant"
+	}
+}
+
+// storedAt reads the stored_at timestamp the controller writes into evidence
+// metadata. Zero when absent or unparseable.
+func storedAt(rec EvidenceRecord) time.Time {
+	var meta struct {
+		StoredAt string `json:"stored_at"`
+	}
+	if rec.MetadataJSON == "" || json.Unmarshal([]byte(rec.MetadataJSON), &meta) != nil {
+		return time.Time{}
+	}
+	t, _ := time.Parse(time.RFC3339, meta.StoredAt)
+	return t
+}
+
+func isNegated(text string) bool {
+	for _, w := range strings.Fields(strings.ToLower(text)) {
+		w = strings.Trim(w, ".,!?;:\"()[]")
+		w = strings.ReplaceAll(w, "’", "'")
+		if negationWords[w] || strings.HasSuffix(w, "n't") {
+			return true
+		}
+	}
+	return false
+}
+
+// stem folds simple plural and third-person forms so "likes" matches "like".
+func stem(tok string) string {
+	if len(tok) > 3 && strings.HasSuffix(tok, "s") && !strings.HasSuffix(tok, "ss") {
+		return tok[:len(tok)-1]
+	}
+	return tok
+}
+
+func sameSet(a, b map[string]bool) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k := range a {
+		if !b[k] {
+			return false
+		}
+	}
+	return true
+}
+
+// #endregion contradiction-detect
+
+// #region contradiction-annotate
+
+// AnnotateContradictions renders records as evidence strings (see Attributed),
+// prefixing every item involved in a conflict with a note telling the model
+// which side to trust.
+func AnnotateContradictions(records []EvidenceRecord, conflicts []Contradiction) []string {
+	notes := make([][]string, len(records))
+	for _, c := range conflicts {
+		notes[c.Preferred] = appendUnique(notes[c.Preferred],
+			fmt.Sprintf("[conflict: this item is %s than a conflicting item — prefer it]", c.Basis))
+		notes[c.Superseded] = appendUnique(notes[c.Superseded],
+			fmt.Sprintf("[conflict: a %s item contradicts this one — prefer that item]", c.Basis))
+	}
+	out := make([]string, len(records))
+	for i, rec := range records {
+		out[i] = rec.Attributed()
+		if len(notes[i]) > 0 {
+			out[i] = strings.Join(notes[i], " ") + " " + out[i]
+		}
+	}
+	return out
+}
+
+func appendUnique(list []string, s string) []string {
+	for _, existing := range list {
+		if existing == s {
+			return list
+		}
+	}
+	return append(list, s)
+}
+
+// #endregion contradiction-annotate
@@ -0,0 +1,67 @@
+package retrieval
+
+import (
+	"strings"
+	"testing"
+)
+
+// #region contradiction-detect-tests
+func TestDetectContradictions_NegationPrefersNewer(t *testing.T) {
+	records := []EvidenceRecord{
+		{ID: "new", Text: "User doesn't like coffee anymore", Score: 0.7, MetadataJSON: `{"stored_at":"2026-03-01T10:00:00Z"}`},
+		{ID: "old", Text: "User likes coffee", Score: 0.9, MetadataJSON: `{"stored_at":"2026-01-01T10:00:00Z"}`},
+	}
+	got := DetectContradictions(records, DefaultContradictionConfig())
+	if len(got) != 1 {
+		t.Fatalf("expected 1 contradiction, got %+v", got)
+	}
+	c := got[0]
+	if c.Kind != "negation" || c.Basis != "newer" || c.Preferred != 0 || c.Superseded != 1 {
+		t.Errorf("expected newer item preferred on negation, got %+v", c)
+	}
+}
+
+func TestDetectContradictions_ValuePrefersPrimarySource(t *testing.T) {
+	records := []EvidenceRecord{
+		{ID: "team::1", Text: "The standup meeting starts at 9:30", Score: 0.9, Source: "team"},
+		{ID: "local", Text: "The standup meeting starts at 10:00", Score: 0.6},
+	}
+	got := DetectContradictions(records, DefaultContradictionConfig())
+	if len(got) != 1 || got[0].Kind != "value" || got[0].Basis != "more trusted" || got[0].Preferred != 1 {
+		t.Fatalf("expected primary item preferred on value conflict, got %+v", got)
+	}
+}
+
+func TestDetectContradictions_IgnoresUnrelatedAndAgreeing(t *testing.T) {
+	records := []EvidenceRecord{
+		{ID: "a", Text: "User likes coffee", Score: 0.9},
+		{ID: "b", Text: "User likes coffee in the morning", Score: 0.8},
+		{ID: "c", Text: "The deploy did not finish on Friday", Score: 0.7},
+	}
+	if got := DetectContradictions(records, DefaultContradictionConfig()); len(got) != 0 {
+		t.Errorf("expected no contradictions, got %+v", got)
+	}
+}
+
+// #endregion contradiction-detect-tests
+
+// #region contradiction-annotate-tests
+func TestAnnotateContradictions(t *testing.T) {
+	records := []EvidenceRecord{
+		{ID: "old", Text: "User likes coffee"},
+		{ID: "new", Text: "User does not like coffee"},
+		{ID: "other", Text: "The build is green", Source: "team"},
+	}
+	got := AnnotateContradictions(records, []Contradiction{{Preferred: 1, Superseded: 0, Kind: "negation", Basis: "newer"}})
+	if !strings.HasPrefix(got[0], "[conflict: a newer item contradicts this one") || !strings.HasSuffix(got[0], "User likes coffee") {
+		t.Errorf("superseded item not annotated: %q", got[0])
+	}
+	if !strings.HasPrefix(got[1], "[conflict: this item is newer than a conflicting item") {
+		t.Errorf("preferred item not annotated: %q", got[1])
+	}
+	if got[2] != "[source: team] The build is green" {
+		t.Errorf("unrelated item should only carry attribution, got %q", got[2])
+	}
+}
+
+// #endregion contradiction-annotate-tests
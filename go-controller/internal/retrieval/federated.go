@@ -0,0 +1,252 @@
+package retrieval
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/evidence"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/vecmath"
+)
+
+// #region federated-source
+// Source is a secondary, read-only memory backend searched alongside the primary
+// evidence store. Results are scored by similarity × trust and namespaced so they
+// never collide with (or get written back to) local evidence.
+type Source interface {
+	Namespace() string
+	Trust() float32
+	// Search returns records whose trust-weighted score meets threshold, best first.
+	Search(ctx context.Context, queryVec []float32, topK int, threshold float32) ([]EvidenceRecord, error)
+}
+
+// PackItem is one evidence entry in an exported evidence pack.
+type PackItem struct {
+	ID           string    `json:"id"`
+	Text         string    `json:"text"`
+	MetadataJSON string    `json:"metadata_json,omitempty"`
+	Embedding    []float32 `json:"embedding,omitempty"`
+}
+
+// EvidencePack is the on-disk format for a read-only memory source: another agent's
+// exported evidence or a team knowledge base.
+type EvidencePack struct {
+	Namespace string     `json:"namespace,omitempty"`
+	Items     []PackItem `json:"items"`
+}
+
+// PackSource serves an evidence pack held in memory.
+type PackSource struct {
+	namespace string
+	trust     float32
+	items     []PackItem
+}
+
+// EmbedFunc embeds text; used to fill in pack items exported without embeddings.
+type EmbedFunc func(ctx context.Context, text string) ([]float32, error)
+
+// BatchEmbedFunc embeds several texts, returning one embedding per text in order.
+type BatchEmbedFunc func(ctx context.Context, texts []string) ([][]float32, error)
+
+// EmbedEach adapts a single-text embedder to BatchEmbedFunc, one call per text.
+func EmbedEach(embed EmbedFunc) BatchEmbedFunc {
+	return func(ctx context.Context, texts []string) ([][]float32, error) {
+		out := make([][]float32, len(texts))
+		for i, t := range texts {
+			vec, err := embed(ctx, t)
+			if err != nil {
+				return nil, fmt.Errorf("text %d: %w", i, err)
+			}
+			out[i] = vec
+		}
+		return out, nil
+	}
+}
+
+// LoadPackSource reads an evidence pack from path. namespace overrides the pack's own
+// namespace when non-empty. Items without an embedding are embedded in one batch via
+// embed; with a nil embed they are skipped.
+func LoadPackSource(ctx context.Context, path, namespace string, trust float32, embed BatchEmbedFunc) (*PackSource, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read evidence pack: %w", err)
+	}
+	var pack EvidencePack
+	if err := json.Unmarshal(data, &pack); err != nil {
+		return nil, fmt.Errorf("parse evidence pack %s: %w", path, err)
+	}
+	if namespace == "" {
+		namespace = pack.Namespace
+	}
+	if namespace == "" {
+		return nil, fmt.Errorf("evidence pack %s: namespace required", path)
+	}
+	if err := evidence.ValidateNamespace(namespace); err != nil {
+		return nil, fmt.Errorf("evidence pack %s: %w", path, err)
+	}
+
+	items := make([]PackItem, 0, len(pack.Items))
+	var missing []int // indexes into items still needing an embedding
+	for _, it := range pack.Items {
+		if it.ID == "" || it.Text == "" {
+			continue
+		}
+		if _, err := evidence.NamespacedID(namespace, it.ID); err != nil {
+			continue // an ID that cannot be namespaced would be ambiguous downstream
+		}
+		if len(it.Embedding) == 0 {
+			if embed == nil {
+				continue
+			}
+			missing = append(missing, len(items))
+		}
+		items = append(items, it)
+	}
+	if len(missing) > 0 {
+		texts := make([]string, len(missing))
+		for i, idx := range missing {
+			texts[i] = items[idx].Text
+		}
+		vecs, err := embed(ctx, texts)
+		if err != nil {
+			return nil, fmt.Errorf("embed pack %s: %w", path, err)
+		}
+		for i, idx := range missing {
+			items[idx].Embedding = vecs[i]
+		}
+	}
+	return NewPackSource(namespace, trust, items), nil
+}
+
+// NewPackSource creates a source over already-embedded items. trust is clamped to [0, 1].
+func NewPackSource(namespace string, trust float32, items []PackItem) *PackSource {
+	if trust < 0 {
+		trust = 0
+	}
+	if trust > 1 {
+		trust = 1
+	}
+	return &PackSource{namespace: namespace, trust: trust, items: items}
+}
+
+func (p *PackSource) Namespace() string { return p.namespace }
+func (p *PackSource) Trust() float32    { return p.trust }
+
+// Len returns the number of searchable items.
+func (p *PackSource) Len() int { return len(p.items) }
+
+// Search scores every item by cosine similarity × trust.
+func (p *PackSource) Search(_ context.Context, queryVec []float32, topK int, threshold float32) ([]EvidenceRecord, error) {
+	var out []EvidenceRecord
+	for _, it := range p.items {
+		sim, _ := vecmath.Cosine(queryVec, it.Embedding)
+		score := float32(sim) * p.trust
+		if score < threshold {
+			continue
+		}
+		out = append(out, EvidenceRecord{
+			ID:           FederatedID(p.namespace, it.ID),
+			Text:         it.Text,
+			Score:        score,
+			MetadataJSON: it.MetadataJSON,
+			Source:       p.namespace,
+		})
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Score > out[j].Score })
+	if topK > 0 && len(out) > topK {
+		out = out[:topK]
+	}
+	return out, nil
+}
+
+// #endregion federated-source
+
+// #region federated-ids
+// FederatedID namespaces a source record ID ("namespace::id", see evidence.NamespacedID).
+func FederatedID(namespace, id string) string {
+	return namespace + evidence.NamespaceSep + id
+}
+
+// IsFederatedID reports whether id came from a secondary source. Federated IDs must
+// not be used for graph edges or evidence mutations — the sources are read-only.
+func IsFederatedID(id string) bool {
+	return strings.Contains(id, evidence.NamespaceSep)
+}
+
+// LocalIDs filters ids down to well-formed primary-store evidence IDs. Federated and
+// malformed IDs are dropped, so nothing downstream joins the graph against them.
+func LocalIDs(ids []string) []string {
+	var out []string
+	for _, id := range ids {
+		if evidence.IsLocalID(id) {
+			out = append(out, id)
+		}
+	}
+	return out
+}
+
+// #endregion federated-ids
+
+// #region federated-spec
+// SourceSpec is one parsed FEDERATED_SOURCES entry.
+type SourceSpec struct {
+	Namespace string
+	Path      string
+	Trust     float32
+}
+
+// ParseSourceSpecs parses a comma-separated list of "namespace=path@trust" entries.
+// Trust defaults to 0.5 when "@trust" is omitted and must be within [0, 1].
+func ParseSourceSpecs(spec string) ([]SourceSpec, error) {
+	var specs []SourceSpec
+	seen := make(map[string]bool)
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		eq := strings.Index(entry, "=")
+		if eq <= 0 || eq == len(entry)-1 {
+			return nil, fmt.Errorf("source %q: want namespace=path[@trust]", entry)
+		}
+		s := SourceSpec{Namespace: strings.TrimSpace(entry[:eq]), Path: strings.TrimSpace(entry[eq+1:]), Trust: 0.5}
+		if err := evidence.ValidateNamespace(s.Namespace); err != nil {
+			return nil, fmt.Errorf("source %q: %w", entry, err)
+		}
+		if at := strings.LastIndex(s.Path, "@"); at >= 0 {
+			t, err := strconv.ParseFloat(s.Path[at+1:], 32)
+			if err != nil || t < 0 || t > 1 {
+				return nil, fmt.Errorf("source %q: trust must be a number in [0, 1]", entry)
+			}
+			s.Trust, s.Path = float32(t), s.Path[:at]
+		}
+		if seen[s.Namespace] {
+			return nil, fmt.Errorf("source %q: duplicate namespace", entry)
+		}
+		seen[s.Namespace] = true
+		specs = append(specs, s)
+	}
+	return specs, nil
+}
+
+// #endregion federated-spec
+
+// #region federated-merge
+// mergeGate2 merges secondary-source results into primary gate-2 results, ordered by
+// score and capped at topK (0 = uncapped). Primary results win ties.
+func mergeGate2(primary, federated []EvidenceRecord, topK int) []EvidenceRecord {
+	merged := make([]EvidenceRecord, 0, len(primary)+len(federated))
+	merged = append(merged, primary...)
+	merged = append(merged, federated...)
+	sort.SliceStable(merged, func(i, j int) bool { return merged[i].Score > merged[j].Score })
+	if topK > 0 && len(merged) > topK {
+		merged = merged[:topK]
+	}
+	return merged
+}
+
+// #endregion federated-merge
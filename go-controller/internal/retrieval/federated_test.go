@@ -0,0 +1,171 @@
+package retrieval
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	pb "github.com/danielpatrickdp/adaptive-state/go-controller/gen/adaptive"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/codec"
+)
+
+// #region pack-source-tests
+func TestPackSource_SearchAppliesTrust(t *testing.T) {
+	src := NewPackSource("team", 0.5, []PackItem{
+		{ID: "1", Text: "alpha", Embedding: []float32{1, 0}},
+		{ID: "2", Text: "beta", Embedding: []float32{0, 1}},
+	})
+	recs, err := src.Search(context.Background(), []float32{1, 0}, 5, 0.4)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(recs) != 1 {
+		t.Fatalf("expected 1 result above threshold, got %d", len(recs))
+	}
+	if recs[0].ID != "team::1" || recs[0].Source != "team" || recs[0].Score != 0.5 {
+		t.Errorf("unexpected record: %+v", recs[0])
+	}
+
+	// Full similarity × 0.5 trust cannot clear a 0.6 threshold
+	if recs, _ := src.Search(context.Background(), []float32{1, 0}, 5, 0.6); len(recs) != 0 {
+		t.Errorf("expected trust to hold results below threshold, got %d", len(recs))
+	}
+}
+
+func TestNewPackSource_ClampsTrust(t *testing.T) {
+	if tr := NewPackSource("a", 3, nil).Trust(); tr != 1 {
+		t.Errorf("expected trust clamped to 1, got %.2f", tr)
+	}
+	if tr := NewPackSource("a", -1, nil).Trust(); tr != 0 {
+		t.Errorf("expected trust clamped to 0, got %.2f", tr)
+	}
+}
+
+func TestLoadPackSource_EmbedsMissing(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pack.json")
+	pack := `{"namespace":"agent-b","items":[
+		{"id":"x","text":"has embedding","embedding":[1,0]},
+		{"id":"y","text":"needs embedding"},
+		{"id":"","text":"no id"}
+	]}`
+	if err := os.WriteFile(path, []byte(pack), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	calls := 0
+	embed := func(_ context.Context, texts []string) ([][]float32, error) {
+		calls += len(texts)
+		out := make([][]float32, len(texts))
+		for i := range texts {
+			out[i] = []float32{0, 1}
+		}
+		return out, nil
+	}
+	src, err := LoadPackSource(context.Background(), path, "", 0.8, embed)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if src.Namespace() != "agent-b" || src.Len() != 2 || calls != 1 {
+		t.Errorf("expected namespace agent-b, 2 items, 1 text embedded; got %s, %d, %d", src.Namespace(), src.Len(), calls)
+	}
+}
+
+func TestLoadPackSource_RequiresNamespace(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pack.json")
+	os.WriteFile(path, []byte(`{"items":[]}`), 0o600)
+	if _, err := LoadPackSource(context.Background(), path, "", 1, nil); err == nil {
+		t.Error("expected error when no namespace is given")
+	}
+}
+
+// #endregion pack-source-tests
+
+// #region federated-spec-tests
+func TestParseSourceSpecs(t *testing.T) {
+	specs, err := ParseSourceSpecs("team=/data/kb.json@0.7, agent=/tmp/pack.json")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(specs) != 2 {
+		t.Fatalf("expected 2 specs, got %d", len(specs))
+	}
+	if specs[0] != (SourceSpec{Namespace: "team", Path: "/data/kb.json", Trust: 0.7}) {
+		t.Errorf("unexpected first spec: %+v", specs[0])
+	}
+	if specs[1].Trust != 0.5 {
+		t.Errorf("expected default trust 0.5, got %.2f", specs[1].Trust)
+	}
+}
+
+func TestParseSourceSpecs_Invalid(t *testing.T) {
+	for _, spec := range []string{"nopath", "=x.json", "a=x.json@2", "a=x.json,a=y.json", "a::b=x.json", "Team=x.json", "ev_x=x.json"} {
+		if _, err := ParseSourceSpecs(spec); err == nil {
+			t.Errorf("expected error for %q", spec)
+		}
+	}
+}
+
+func TestLocalIDs(t *testing.T) {
+	a, b := "ev_3f2504e0-4f89-41d3-9a0c-0305e82c3301", "ev_0b4e9a8c-1f6a-4c9e-8d5e-6a1d2c3b4f50"
+	got := LocalIDs([]string{a, FederatedID("team", "7"), "chunk-1", b})
+	if len(got) != 2 || got[0] != a || got[1] != b {
+		t.Errorf("expected only well-formed local IDs, got %v", got)
+	}
+}
+
+// #endregion federated-spec-tests
+
+// #region federated-retrieve-tests
+func TestRetrieve_MergesFederatedIntoGate2(t *testing.T) {
+	mock := &mockCodecService{
+		searchResp: &pb.SearchResponse{
+			Results: []*pb.SearchResult{
+				{Id: idA, Text: "alpha beta local", Score: 0.6},
+			},
+		},
+		embedResp: &pb.EmbedResponse{Embedding: []float32{1, 0}},
+	}
+	team := NewPackSource("team", 0.9, []PackItem{
+		{ID: "k1", Text: "alpha team note", Embedding: []float32{1, 0}},
+	})
+	r := NewRetriever(codec.NewCodecClientWithService(mock), DefaultConfig()).WithSources([]Source{team})
+
+	result, err := r.Retrieve(context.Background(), "alpha", 1.0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Gate2Count != 2 || len(result.Retrieved) != 2 {
+		t.Fatalf("expected 2 merged results, got gate2=%d retrieved=%d", result.Gate2Count, len(result.Retrieved))
+	}
+	top := result.Retrieved[0]
+	if top.ID != "team::k1" || top.Source != "team" {
+		t.Errorf("expected higher-scored federated record first, got %+v", top)
+	}
+	if !strings.HasPrefix(top.Attributed(), "[source: team] ") {
+		t.Errorf("expected source attribution, got %q", top.Attributed())
+	}
+	if result.Retrieved[1].Attributed() != "alpha beta local" {
+		t.Errorf("expected local evidence unattributed, got %q", result.Retrieved[1].Attributed())
+	}
+}
+
+func TestRetrieve_FederatedEmbedFailureKeepsPrimary(t *testing.T) {
+	mock := &mockCodecService{
+		searchResp: &pb.SearchResponse{
+			Results: []*pb.SearchResult{{Id: idA, Text: "alpha local", Score: 0.6}},
+		},
+	}
+	team := NewPackSource("team", 1, []PackItem{{ID: "k1", Text: "alpha", Embedding: []float32{1}}})
+	r := NewRetriever(codec.NewCodecClientWithService(mock), DefaultConfig()).WithSources([]Source{team})
+
+	result, err := r.Retrieve(context.Background(), "alpha", 1.0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(result.Retrieved) != 1 || result.Retrieved[0].ID != idA {
+		t.Errorf("expected primary result only, got %+v", result.Retrieved)
+	}
+}
+
+// #endregion federated-retrieve-tests
@@ -4,9 +4,13 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"sort"
 
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/clusters"
 	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/codec"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/evidence"
 	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/graph"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/injection"
 )
 
 // #region graph-retriever
@@ -17,8 +21,15 @@ type GraphRetriever struct {
 	base       *Retriever
 	graphStore *graph.GraphStore
 	codec      *codec.CodecClient
-	maxDepth   int
-	minWeight  float64
+	walkCfg    graph.WalkConfig
+
+	centrality      *graph.Centrality
+	centralityBoost float64
+
+	topics   *clusters.Store
+	topicCfg TopicConfig
+
+	quarantine *injection.Quarantine
 }
 
 // NewGraphRetriever creates a GraphRetriever wrapping a base retriever.
@@ -27,27 +38,118 @@ func NewGraphRetriever(base *Retriever, gs *graph.GraphStore, codec *codec.Codec
 		base:       base,
 		graphStore: gs,
 		codec:      codec,
-		maxDepth:   5,
-		minWeight:  0.1,
+		walkCfg:    graph.DefaultWalkConfig(),
 	}
 }
 
+// WithWalkConfig sets the walk traversal and scoring (type priors, age discount).
+func (gr *GraphRetriever) WithWalkConfig(cfg graph.WalkConfig) *GraphRetriever {
+	gr.walkCfg = cfg
+	return gr
+}
+
+// WithCentrality breaks near-ties by graph centrality: each record's score
+// gets boost × its normalized PageRank before results are ordered, so a
+// well-connected memory outranks an isolated one of about the same score.
+// A nil cache or non-positive boost leaves the order alone.
+func (gr *GraphRetriever) WithCentrality(c *graph.Centrality, boost float64) *GraphRetriever {
+	gr.centrality, gr.centralityBoost = c, boost
+	return gr
+}
+
+// WithTopics summarizes large evidence clusters: beyond cfg.Keep items of a
+// cluster, further retrieved members are dropped and the result carries a
+// TopicSummary for it instead. A nil store leaves the results alone.
+func (gr *GraphRetriever) WithTopics(s *clusters.Store, cfg TopicConfig) *GraphRetriever {
+	gr.topics, gr.topicCfg = s, cfg
+	return gr
+}
+
+// WithQuarantine records records that screening flags in q and withholds
+// them from the results until released; released records pass in sanitized
+// form. Without a quarantine flagged records are only sanitized.
+func (gr *GraphRetriever) WithQuarantine(q *injection.Quarantine) *GraphRetriever {
+	gr.quarantine = q
+	return gr
+}
+
 // Retrieve runs base retrieval, then augments with graph walk.
 // Falls back to base results if walk produces <2 nodes.
+// Every record is then screened for instruction-like text.
 func (gr *GraphRetriever) Retrieve(ctx context.Context, prompt string, entropy float32) (GateResult, error) {
+	res, err := gr.retrieve(ctx, prompt, entropy)
+	if err != nil {
+		return res, err
+	}
+	res.Retrieved, res.Quarantined = gr.screen(res.Retrieved)
+	if gr.topics == nil || len(res.Retrieved) < 2 {
+		return res, nil
+	}
+	ids := make([]string, len(res.Retrieved))
+	for i, rec := range res.Retrieved {
+		ids[i] = rec.ID
+	}
+	topics, topicErr := gr.topics.Topics(ids)
+	if topicErr != nil {
+		log.Printf("evidence topics error (non-fatal, results kept): %v", topicErr)
+		return res, nil
+	}
+	res.Retrieved, res.Topics = CollapseTopics(res.Retrieved, topics, gr.topicCfg)
+	return res, nil
+}
+
+// screen sanitizes records with instruction-like text and splits off those
+// the quarantine withholds. A quarantine error fails safe: the record is
+// withheld but not recorded.
+func (gr *GraphRetriever) screen(recs []EvidenceRecord) ([]EvidenceRecord, []Quarantined) {
+	var kept []EvidenceRecord
+	var withheld []Quarantined
+	for _, rec := range recs {
+		clean, findings := injection.Screen(rec.Text)
+		if len(findings) == 0 {
+			kept = append(kept, rec)
+			continue
+		}
+		if gr.quarantine != nil {
+			status, err := gr.quarantine.Flag(rec.Source, rec.ID, findings)
+			if err != nil {
+				log.Printf("quarantine error (non-fatal, %s withheld): %v", rec.ID, err)
+			}
+			if status != injection.StatusReleased {
+				withheld = append(withheld, Quarantined{ID: rec.ID, Source: rec.Source, Patterns: injection.PatternNames(findings)})
+				continue
+			}
+		}
+		rec.Text = clean
+		kept = append(kept, rec)
+	}
+	return kept, withheld
+}
+
+func (gr *GraphRetriever) retrieve(ctx context.Context, prompt string, entropy float32) (GateResult, error) {
 	baseResult, err := gr.base.Retrieve(ctx, prompt, entropy)
 	if err != nil {
 		return baseResult, err
 	}
+	baseResult.Retrieved = gr.rankByCentrality(baseResult.Retrieved)
 
 	// No base results — nothing to walk from
 	if len(baseResult.Retrieved) == 0 {
 		return baseResult, nil
 	}
 
-	// Walk from top result
-	entryID := baseResult.Retrieved[0].ID
-	walkResult, err := gr.graphStore.Walk(entryID, gr.maxDepth, gr.minWeight, 10)
+	// Walk from the top primary-store result (federated records have no graph nodes)
+	entryID := ""
+	for _, rec := range baseResult.Retrieved {
+		if !IsFederatedID(rec.ID) {
+			entryID = rec.ID
+			break
+		}
+	}
+	if entryID == "" {
+		return baseResult, nil
+	}
+	walkResult, err := gr.graphStore.WalkScored(entryID, gr.walkCfg)
 	if err != nil {
 		log.Printf("graph walk error (non-fatal, using base): %v", err)
 		return baseResult, nil
@@ -81,6 +183,9 @@ func (gr *GraphRetriever) Retrieve(ctx context.Context, prompt string, entropy f
 		}
 		fetchedRecords = make(map[string]EvidenceRecord, len(fetched))
 		for _, sr := range fetched {
+			if evidence.IsCompacted(sr.MetadataJSON) {
+				continue // a summary_of edge led here; the summary stands in
+			}
 			fetchedRecords[sr.ID] = EvidenceRecord{
 				ID:           sr.ID,
 				Text:         sr.Text,
@@ -98,9 +203,11 @@ func (gr *GraphRetriever) Retrieve(ctx context.Context, prompt string, entropy f
 			graphRetrieved = append(graphRetrieved, rec)
 		} else if rec, ok := fetchedRecords[id]; ok {
 			rec.Score = float32(walkResult.Scores[i])
+			rec.Walked = true
+			rec.WalkPath = walkResult.Paths[i]
 			graphRetrieved = append(graphRetrieved, rec)
 		}
-		// Skip IDs that weren't found (deleted evidence)
+		// Skip IDs that weren't found (deleted or compacted evidence)
 	}
 
 	if len(graphRetrieved) < 2 {
@@ -108,6 +215,15 @@ func (gr *GraphRetriever) Retrieve(ctx context.Context, prompt string, entropy f
 		return baseResult, nil
 	}
 
+	graphRetrieved = gr.rankByCentrality(graphRetrieved)
+
+	// Federated records are outside the graph; keep them after the walk path
+	for _, rec := range baseResult.Retrieved {
+		if IsFederatedID(rec.ID) {
+			graphRetrieved = append(graphRetrieved, rec)
+		}
+	}
+
 	return GateResult{
 		Gate1Passed: baseResult.Gate1Passed,
 		Gate2Count:  baseResult.Gate2Count,
@@ -118,4 +234,30 @@ func (gr *GraphRetriever) Retrieve(ctx context.Context, prompt string, entropy f
 	}, nil
 }
 
+// rankByCentrality adds the centrality boost to each record's score and
+// orders the records by the result, keeping the given order between equals.
+// Records outside the graph (federated, unknown) get no boost. On a
+// centrality error the records are returned unchanged.
+func (gr *GraphRetriever) rankByCentrality(recs []EvidenceRecord) []EvidenceRecord {
+	if gr.centrality == nil || gr.centralityBoost <= 0 || len(recs) < 2 {
+		return recs
+	}
+	ids := make([]string, len(recs))
+	for i, rec := range recs {
+		ids[i] = rec.ID
+	}
+	central, err := gr.centrality.Normalized(ids)
+	if err != nil {
+		log.Printf("graph centrality error (non-fatal, order unchanged): %v", err)
+		return recs
+	}
+	out := make([]EvidenceRecord, len(recs))
+	for i, rec := range recs {
+		rec.Score += float32(gr.centralityBoost * central[rec.ID])
+		out[i] = rec
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Score > out[j].Score })
+	return out
+}
+
 // #endregion graph-retriever
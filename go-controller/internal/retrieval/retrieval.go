@@ -3,6 +3,7 @@ package retrieval
 import (
 	"context"
 	"fmt"
+	"log"
 
 	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/codec"
 )
@@ -10,15 +11,24 @@ import (
 // #region retriever
 // Retriever orchestrates triple-gated evidence retrieval.
 type Retriever struct {
-	codec  *codec.CodecClient
+	codec  codec.Backend
 	config RetrievalConfig
+
+	sources []Source // read-only secondary memory, merged into gate 2
 }
 
-// NewRetriever creates a Retriever with the given codec client and config.
-func NewRetriever(codec *codec.CodecClient, config RetrievalConfig) *Retriever {
+// NewRetriever creates a Retriever over the given codec backend and config.
+// Only Search and Embed are used.
+func NewRetriever(codec codec.Backend, config RetrievalConfig) *Retriever {
 	return &Retriever{codec: codec, config: config}
 }
 
+// WithSources attaches secondary read-only memory sources and returns the retriever.
+func (r *Retriever) WithSources(sources []Source) *Retriever {
+	r.sources = sources
+	return r
+}
+
 // #endregion retriever
 
 // #region retrieve
@@ -68,6 +78,14 @@ func (r *Retriever) Retrieve(ctx context.Context, prompt string, entropy float32
 			MetadataJSON: sr.MetadataJSON,
 		}
 	}
+
+	// Gate 2 (federated): secondary sources share the threshold on trust-weighted scores
+	if len(r.sources) > 0 {
+		federated := r.searchSources(ctx, prompt)
+		if len(federated) > 0 {
+			gate2Results = mergeGate2(gate2Results, federated, r.config.TopK)
+		}
+	}
 	result.Gate2Count = len(gate2Results)
 
 	if result.Gate2Count == 0 {
@@ -93,6 +111,26 @@ func (r *Retriever) Retrieve(ctx context.Context, prompt string, entropy float32
 	return result, nil
 }
 
+// searchSources embeds the prompt once and queries every secondary source.
+// Source failures are non-fatal: the primary results stand on their own.
+func (r *Retriever) searchSources(ctx context.Context, prompt string) []EvidenceRecord {
+	vec, err := r.codec.Embed(ctx, prompt)
+	if err != nil || len(vec) == 0 {
+		log.Printf("federated retrieval: embed failed (skipping %d sources): %v", len(r.sources), err)
+		return nil
+	}
+	var out []EvidenceRecord
+	for _, src := range r.sources {
+		recs, err := src.Search(ctx, vec, r.config.TopK, r.config.SimilarityThreshold)
+		if err != nil {
+			log.Printf("federated retrieval: source %s: %v", src.Namespace(), err)
+			continue
+		}
+		out = append(out, recs...)
+	}
+	return out
+}
+
 // #endregion retrieve
 
 // #region consistency-check
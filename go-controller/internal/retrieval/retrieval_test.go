@@ -11,11 +11,21 @@ import (
 )
 
 // #region mock
+// Evidence IDs the mock codec returns; the client drops anything malformed.
+const (
+	idA = "ev_00000000-0000-4000-8000-00000000000a"
+	idB = "ev_00000000-0000-4000-8000-00000000000b"
+	id1 = "ev_00000000-0000-4000-8000-000000000001"
+	id2 = "ev_00000000-0000-4000-8000-000000000002"
+)
+
 type mockCodecService struct {
 	pb.CodecServiceClient
 
 	searchResp *pb.SearchResponse
 	searchErr  error
+
+	embedResp *pb.EmbedResponse
 }
 
 func (m *mockCodecService) Generate(_ context.Context, _ *pb.GenerateRequest, _ ...grpc.CallOption) (*pb.GenerateResponse, error) {
@@ -23,7 +33,10 @@ func (m *mockCodecService) Generate(_ context.Context, _ *pb.GenerateRequest, _
 }
 
 func (m *mockCodecService) Embed(_ context.Context, _ *pb.EmbedRequest, _ ...grpc.CallOption) (*pb.EmbedResponse, error) {
-	return nil, nil
+	if m.embedResp == nil {
+		return nil, errors.New("embed not mocked")
+	}
+	return m.embedResp, nil
 }
 
 func (m *mockCodecService) Search(_ context.Context, _ *pb.SearchRequest, _ ...grpc.CallOption) (*pb.SearchResponse, error) {
@@ -197,7 +210,7 @@ func TestRetrieve_AlwaysRetrieveBypassesGate1(t *testing.T) {
 	mock := &mockCodecService{
 		searchResp: &pb.SearchResponse{
 			Results: []*pb.SearchResult{
-				{Id: "a", Text: "recalled talk about earlier topics", Score: 0.9},
+				{Id: idA, Text: "recalled talk about earlier topics", Score: 0.9},
 			},
 		},
 	}
@@ -264,8 +277,8 @@ func TestRetrieve_Gate3AllFiltered(t *testing.T) {
 	mock := &mockCodecService{
 		searchResp: &pb.SearchResponse{
 			Results: []*pb.SearchResult{
-				{Id: "1", Text: "", Score: 0.9},
-				{Id: "2", Text: "", Score: 0.8},
+				{Id: id1, Text: "", Score: 0.9},
+				{Id: id2, Text: "", Score: 0.8},
 			},
 		},
 	}
@@ -293,8 +306,8 @@ func TestRetrieve_FullSuccess(t *testing.T) {
 	mock := &mockCodecService{
 		searchResp: &pb.SearchResponse{
 			Results: []*pb.SearchResult{
-				{Id: "a", Text: "alpha beta results here", Score: 0.95, MetadataJson: `{"src":"test"}`},
-				{Id: "b", Text: "beta testing outcomes", Score: 0.85, MetadataJson: ""},
+				{Id: idA, Text: "alpha beta results here", Score: 0.95, MetadataJson: `{"src":"test"}`},
+				{Id: idB, Text: "beta testing outcomes", Score: 0.85, MetadataJson: ""},
 			},
 		},
 	}
@@ -319,7 +332,7 @@ func TestRetrieve_FullSuccess(t *testing.T) {
 	if len(result.Retrieved) != 2 {
 		t.Fatalf("expected 2 retrieved, got %d", len(result.Retrieved))
 	}
-	if result.Retrieved[0].ID != "a" {
+	if result.Retrieved[0].ID != idA {
 		t.Errorf("expected first result ID 'a', got %q", result.Retrieved[0].ID)
 	}
 	if result.Retrieved[0].Text != "alpha beta results here" {
@@ -331,8 +344,8 @@ func TestRetrieve_CoherenceFiltersAll(t *testing.T) {
 	mock := &mockCodecService{
 		searchResp: &pb.SearchResponse{
 			Results: []*pb.SearchResult{
-				{Id: "a", Text: "weather forecast tomorrow sunny", Score: 0.9},
-				{Id: "b", Text: "recipe cooking pasta dinner", Score: 0.8},
+				{Id: idA, Text: "weather forecast tomorrow sunny", Score: 0.9},
+				{Id: idB, Text: "recipe cooking pasta dinner", Score: 0.8},
 			},
 		},
 	}
@@ -356,8 +369,8 @@ func TestRetrieve_CoherencePassesRelevant(t *testing.T) {
 	mock := &mockCodecService{
 		searchResp: &pb.SearchResponse{
 			Results: []*pb.SearchResult{
-				{Id: "a", Text: "seashells found on the beach near the ocean", Score: 0.9},
-				{Id: "b", Text: "recipe cooking pasta dinner", Score: 0.8},
+				{Id: idA, Text: "seashells found on the beach near the ocean", Score: 0.9},
+				{Id: idB, Text: "recipe cooking pasta dinner", Score: 0.8},
 			},
 		},
 	}
@@ -372,7 +385,7 @@ func TestRetrieve_CoherencePassesRelevant(t *testing.T) {
 	if result.Gate3Count != 1 {
 		t.Errorf("expected 1 gate3 result, got %d", result.Gate3Count)
 	}
-	if result.Retrieved[0].ID != "a" {
+	if result.Retrieved[0].ID != idA {
 		t.Errorf("expected surviving result ID 'a', got %q", result.Retrieved[0].ID)
 	}
 }
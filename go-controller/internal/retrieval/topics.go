@@ -0,0 +1,85 @@
+package retrieval
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/clusters"
+)
+
+// #region topics
+
+// TopicConfig sets when retrieved evidence from one cluster is summarized.
+type TopicConfig struct {
+	MinSize int // clusters at least this large are summarized (default 5)
+	Keep    int // retrieved items shown per summarized cluster (default 2)
+}
+
+// DefaultTopicConfig returns the topic summary defaults.
+func DefaultTopicConfig() TopicConfig {
+	return TopicConfig{MinSize: 5, Keep: 2}
+}
+
+// TopicSummary stands in for the retrieved items of a large cluster beyond
+// the first few.
+type TopicSummary struct {
+	Label  string // cluster keywords
+	Size   int    // memories in the cluster
+	Shown  int    // of those, passed to the model as evidence
+	Hidden int    // retrieved this turn but left out in favour of the summary
+}
+
+// String is the summary as the model reads it.
+func (s TopicSummary) String() string {
+	return fmt.Sprintf("You have %d memories about %s (%d shown).", s.Size, s.Label, s.Shown)
+}
+
+// FormatTopics renders summaries as one evidence block, or "" when there are none.
+func FormatTopics(summaries []TopicSummary) string {
+	if len(summaries) == 0 {
+		return ""
+	}
+	lines := []string{"[MEMORY TOPICS]"}
+	for _, s := range summaries {
+		lines = append(lines, "- "+s.String())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// CollapseTopics keeps at most cfg.Keep records of each labelled cluster of
+// at least cfg.MinSize memories, in the given order, and returns one summary
+// per such cluster the records touch. Records of small, unlabelled or
+// unknown clusters are kept as they are.
+func CollapseTopics(recs []EvidenceRecord, topics map[string]clusters.Topic, cfg TopicConfig) ([]EvidenceRecord, []TopicSummary) {
+	if cfg.MinSize <= 0 {
+		cfg.MinSize = DefaultTopicConfig().MinSize
+	}
+	if cfg.Keep <= 0 {
+		cfg.Keep = DefaultTopicConfig().Keep
+	}
+	var kept []EvidenceRecord
+	var summaries []TopicSummary
+	index := map[int64]int{} // cluster → position in summaries
+	for _, rec := range recs {
+		t, ok := topics[rec.ID]
+		if !ok || t.Size < cfg.MinSize || t.Label == "" {
+			kept = append(kept, rec)
+			continue
+		}
+		i, seen := index[t.ClusterID]
+		if !seen {
+			i = len(summaries)
+			index[t.ClusterID] = i
+			summaries = append(summaries, TopicSummary{Label: t.Label, Size: t.Size})
+		}
+		if summaries[i].Shown < cfg.Keep {
+			summaries[i].Shown++
+			kept = append(kept, rec)
+		} else {
+			summaries[i].Hidden++
+		}
+	}
+	return kept, summaries
+}
+
+// #endregion topics
@@ -0,0 +1,47 @@
+package retrieval
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/clusters"
+)
+
+func TestCollapseTopics(t *testing.T) {
+	recs := []EvidenceRecord{{ID: "ev_1"}, {ID: "ev_2"}, {ID: "ev_3"}, {ID: "ev_4"}, {ID: "ev_5"}, {ID: "ev_6"}, {ID: "fed:x"}}
+	topics := map[string]clusters.Topic{
+		"ev_1": {ClusterID: 1, Label: "deployment, docker", Size: 14},
+		"ev_2": {ClusterID: 1, Label: "deployment, docker", Size: 14},
+		"ev_3": {ClusterID: 2, Label: "cat", Size: 3}, // too small to summarize
+		"ev_4": {ClusterID: 1, Label: "deployment, docker", Size: 14},
+		"ev_5": {ClusterID: 3, Label: "", Size: 9}, // unlabelled
+		"ev_6": {ClusterID: 1, Label: "deployment, docker", Size: 14},
+	}
+	kept, summaries := CollapseTopics(recs, topics, TopicConfig{MinSize: 5, Keep: 2})
+
+	var ids []string
+	for _, r := range kept {
+		ids = append(ids, r.ID)
+	}
+	if want := []string{"ev_1", "ev_2", "ev_3", "ev_5", "fed:x"}; !reflect.DeepEqual(ids, want) {
+		t.Errorf("kept %v, want %v", ids, want)
+	}
+	want := []TopicSummary{{Label: "deployment, docker", Size: 14, Shown: 2, Hidden: 2}}
+	if !reflect.DeepEqual(summaries, want) {
+		t.Errorf("summaries %+v, want %+v", summaries, want)
+	}
+	if got := FormatTopics(summaries); got != "[MEMORY TOPICS]\n- You have 14 memories about deployment, docker (2 shown)." {
+		t.Errorf("formatted %q", got)
+	}
+	if FormatTopics(nil) != "" {
+		t.Error("no summaries should format as empty")
+	}
+}
+
+func TestCollapseTopics_NoTopics(t *testing.T) {
+	recs := []EvidenceRecord{{ID: "ev_1"}, {ID: "ev_2"}}
+	kept, summaries := CollapseTopics(recs, nil, DefaultTopicConfig())
+	if len(kept) != 2 || summaries != nil {
+		t.Errorf("kept %v summaries %v", kept, summaries)
+	}
+}
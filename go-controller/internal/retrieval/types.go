@@ -1,5 +1,7 @@
 package retrieval
 
+import "fmt"
+
 // #region config
 // RetrievalConfig holds thresholds and limits for the 3-gate retrieval pipeline.
 type RetrievalConfig struct {
@@ -32,6 +34,18 @@ type EvidenceRecord struct {
 	Text         string
 	Score        float32
 	MetadataJSON string
+	Source       string   // secondary source namespace; "" for the primary store
+	Walked       bool     // reached via graph walk rather than passing the retrieval gates
+	WalkPath     []string // walked records: edge types followed from the walk entry
+}
+
+// Attributed returns the evidence text, prefixed with its source when it came from
+// a secondary memory source so the model can weigh it accordingly.
+func (e EvidenceRecord) Attributed() string {
+	if e.Source == "" {
+		return e.Text
+	}
+	return fmt.Sprintf("[source: %s] %s", e.Source, e.Text)
 }
 
 // #endregion evidence-record
@@ -44,6 +58,16 @@ type GateResult struct {
 	Gate3Count  int              // results passing consistency check
 	Retrieved   []EvidenceRecord // final evidence after all gates
 	Reason      string           // human-readable explanation
+	Topics      []TopicSummary   // large clusters summarized instead of listed in full
+	Quarantined []Quarantined    // records withheld for instruction-like text
+}
+
+// Quarantined is a retrieved record withheld from the model because screening
+// found instruction-like text in it.
+type Quarantined struct {
+	ID       string
+	Source   string
+	Patterns []string
 }
 
 // #endregion gate-result
@@ -0,0 +1,238 @@
+package retry
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/logging"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/timestamp"
+)
+
+// #region queue
+
+const schema = `
+CREATE TABLE IF NOT EXISTS write_queue (
+	id              INTEGER PRIMARY KEY AUTOINCREMENT,
+	kind            TEXT NOT NULL,
+	payload         TEXT NOT NULL,
+	status          TEXT NOT NULL DEFAULT 'pending',
+	attempts        INTEGER NOT NULL DEFAULT 0,
+	last_error      TEXT,
+	created_at      TEXT NOT NULL,
+	next_attempt_at TEXT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_write_queue_due ON write_queue(status, next_attempt_at);
+`
+
+// Write kinds.
+const (
+	KindEvidence   = "evidence"   // a StoreEvidence call
+	KindProvenance = "provenance" // a provenance_log row
+)
+
+// Row statuses. Dead rows ran out of attempts and stay for inspection.
+const (
+	StatusPending = "pending"
+	StatusDead    = "dead"
+)
+
+// Config bounds retrying: a failed write waits Backoff, doubling per attempt
+// up to MaxBackoff, and is marked dead after MaxAttempts retries.
+type Config struct {
+	MaxAttempts int
+	Backoff     time.Duration
+	MaxBackoff  time.Duration
+	Batch       int // rows retried per Drain
+}
+
+// DefaultConfig retries for roughly a day before giving up.
+func DefaultConfig() Config {
+	return Config{MaxAttempts: 30, Backoff: 30 * time.Second, MaxBackoff: time.Hour, Batch: 50}
+}
+
+// Queue is a durable queue of evidence and provenance writes that failed, kept
+// in the controller's database and retried by Drain, so an outage of the codec
+// (or a transiently locked database) does not leave holes in memory or the
+// audit trail.
+type Queue struct {
+	db  *sql.DB
+	cfg Config
+	now func() time.Time
+}
+
+// NewQueue creates the write_queue table if needed and returns a queue.
+func NewQueue(db *sql.DB, cfg Config) (*Queue, error) {
+	if _, err := db.Exec(schema); err != nil {
+		return nil, fmt.Errorf("write queue schema: %w", err)
+	}
+	if _, err := timestamp.Canonicalize(db, "write_queue", "created_at", "next_attempt_at"); err != nil {
+		return nil, err
+	}
+	return &Queue{db: db, cfg: cfg, now: func() time.Time { return time.Now().UTC() }}, nil
+}
+
+type evidencePayload struct {
+	Text         string `json:"text"`
+	MetadataJSON string `json:"metadata_json"`
+}
+
+// Evidence queues a StoreEvidence call that failed with cause.
+func (q *Queue) Evidence(text, metadataJSON string, cause error) error {
+	return q.enqueue(KindEvidence, evidencePayload{Text: text, MetadataJSON: metadataJSON}, cause)
+}
+
+// Provenance queues a provenance row that could not be written. The row keeps
+// its original CreatedAt, so a late write still sorts where it happened.
+func (q *Queue) Provenance(entry logging.ProvenanceEntry, cause error) error {
+	if entry.CreatedAt.IsZero() {
+		entry.CreatedAt = q.now()
+	}
+	return q.enqueue(KindProvenance, entry, cause)
+}
+
+func (q *Queue) enqueue(kind string, payload any, cause error) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("encode %s write: %w", kind, err)
+	}
+	now := q.now()
+	if _, err := q.db.Exec(
+		`INSERT INTO write_queue (kind, payload, last_error, created_at, next_attempt_at) VALUES (?, ?, ?, ?, ?)`,
+		kind, string(data), errText(cause), timestamp.Format(now), timestamp.Format(now.Add(q.cfg.Backoff)),
+	); err != nil {
+		return fmt.Errorf("queue %s write: %w", kind, err)
+	}
+	return nil
+}
+
+// #endregion queue
+
+// #region drain
+
+// StoreFunc stores one evidence item, e.g. codec.CodecClient.StoreEvidence.
+type StoreFunc func(ctx context.Context, text, metadataJSON string) (string, error)
+
+// Result counts one Drain pass.
+type Result struct {
+	Retried   int
+	Succeeded int
+	Failed    int // still pending, rescheduled
+	Dead      int // gave up this pass
+}
+
+// Drain retries the due pending writes, oldest first, up to Config.Batch.
+// Succeeded rows are deleted; failed ones are rescheduled with backoff, or
+// marked dead after MaxAttempts. Evidence goes through store; provenance rows
+// are written to the queue's database.
+func (q *Queue) Drain(ctx context.Context, store StoreFunc) (Result, error) {
+	type row struct {
+		id       int64
+		kind     string
+		payload  string
+		attempts int
+	}
+	rows, err := q.db.QueryContext(ctx,
+		`SELECT id, kind, payload, attempts FROM write_queue
+		 WHERE status = ? AND next_attempt_at <= ? ORDER BY id LIMIT ?`,
+		StatusPending, timestamp.Format(q.now()), q.cfg.Batch)
+	if err != nil {
+		return Result{}, fmt.Errorf("list queued writes: %w", err)
+	}
+	var due []row
+	for rows.Next() {
+		var r row
+		if err := rows.Scan(&r.id, &r.kind, &r.payload, &r.attempts); err != nil {
+			rows.Close()
+			return Result{}, fmt.Errorf("scan queued write: %w", err)
+		}
+		due = append(due, r)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return Result{}, fmt.Errorf("list queued writes: %w", err)
+	}
+
+	var res Result
+	for _, r := range due {
+		if ctx.Err() != nil {
+			break
+		}
+		res.Retried++
+		writeErr := q.apply(ctx, r.kind, r.payload, store)
+		if writeErr == nil {
+			if _, err := q.db.Exec(`DELETE FROM write_queue WHERE id = ?`, r.id); err != nil {
+				return res, fmt.Errorf("dequeue write %d: %w", r.id, err)
+			}
+			res.Succeeded++
+			continue
+		}
+		attempts := r.attempts + 1
+		status := StatusPending
+		if attempts >= q.cfg.MaxAttempts {
+			status = StatusDead
+			res.Dead++
+		} else {
+			res.Failed++
+		}
+		next := q.now().Add(q.backoff(attempts + 1)) // the original write failed too
+		if _, err := q.db.Exec(
+			`UPDATE write_queue SET status = ?, attempts = ?, last_error = ?, next_attempt_at = ? WHERE id = ?`,
+			status, attempts, errText(writeErr), timestamp.Format(next), r.id,
+		); err != nil {
+			return res, fmt.Errorf("reschedule write %d: %w", r.id, err)
+		}
+	}
+	return res, nil
+}
+
+func (q *Queue) apply(ctx context.Context, kind, payload string, store StoreFunc) error {
+	switch kind {
+	case KindEvidence:
+		var p evidencePayload
+		if err := json.Unmarshal([]byte(payload), &p); err != nil {
+			return fmt.Errorf("decode evidence write: %w", err)
+		}
+		_, err := store(ctx, p.Text, p.MetadataJSON)
+		return err
+	case KindProvenance:
+		var entry logging.ProvenanceEntry
+		if err := json.Unmarshal([]byte(payload), &entry); err != nil {
+			return fmt.Errorf("decode provenance write: %w", err)
+		}
+		return logging.LogDecision(q.db, entry)
+	default:
+		return fmt.Errorf("unknown write kind %q", kind)
+	}
+}
+
+// backoff is the wait after the given number of failed writes.
+func (q *Queue) backoff(attempts int) time.Duration {
+	d := q.cfg.Backoff
+	for i := 1; i < attempts && d < q.cfg.MaxBackoff; i++ {
+		d *= 2
+	}
+	return min(d, q.cfg.MaxBackoff)
+}
+
+// Counts returns how many writes are pending and how many were given up.
+func (q *Queue) Counts() (pending, dead int, err error) {
+	err = q.db.QueryRow(
+		`SELECT COALESCE(SUM(status = 'pending'), 0), COALESCE(SUM(status = 'dead'), 0) FROM write_queue`,
+	).Scan(&pending, &dead)
+	if err != nil {
+		return 0, 0, fmt.Errorf("count queued writes: %w", err)
+	}
+	return pending, dead, nil
+}
+
+func errText(err error) any {
+	if err == nil {
+		return nil
+	}
+	return err.Error()
+}
+
+// #endregion drain
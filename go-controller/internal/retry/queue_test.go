@@ -0,0 +1,116 @@
+package retry
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/logging"
+	_ "modernc.org/sqlite"
+)
+
+// newTestQueue returns a queue over an in-memory database with a
+// provenance_log table and a settable clock.
+func newTestQueue(t *testing.T, cfg Config) (*Queue, *time.Time) {
+	t.Helper()
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+	if _, err := db.Exec(`CREATE TABLE provenance_log (
+		id INTEGER PRIMARY KEY AUTOINCREMENT, version_id TEXT NOT NULL, context_hash TEXT,
+		trigger_type TEXT NOT NULL, signals_json TEXT, evidence_refs TEXT,
+		decision TEXT NOT NULL, reason TEXT, created_at TEXT NOT NULL
+	)`); err != nil {
+		t.Fatalf("create provenance_log: %v", err)
+	}
+	q, err := NewQueue(db, cfg)
+	if err != nil {
+		t.Fatalf("new queue: %v", err)
+	}
+	clock := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	q.now = func() time.Time { return clock }
+	return q, &clock
+}
+
+func TestDrain_RetriesEvidenceWithBackoff(t *testing.T) {
+	q, clock := newTestQueue(t, Config{MaxAttempts: 5, Backoff: time.Minute, MaxBackoff: time.Hour, Batch: 10})
+	ctx := context.Background()
+	if err := q.Evidence("cats purr", `{"turn_id":"t1"}`, errors.New("codec down")); err != nil {
+		t.Fatalf("queue evidence: %v", err)
+	}
+
+	var stored []string
+	down := true
+	store := func(_ context.Context, text, meta string) (string, error) {
+		if down {
+			return "", errors.New("still down")
+		}
+		stored = append(stored, text+" "+meta)
+		return "ev_1", nil
+	}
+
+	// Not due before the first backoff
+	if res, err := q.Drain(ctx, store); err != nil || res.Retried != 0 {
+		t.Fatalf("early drain = %+v, %v", res, err)
+	}
+	*clock = clock.Add(time.Minute)
+	if res, err := q.Drain(ctx, store); err != nil || res.Failed != 1 {
+		t.Fatalf("failing drain = %+v, %v", res, err)
+	}
+	// Second wait doubles: one minute later it is still not due
+	*clock = clock.Add(time.Minute)
+	if res, _ := q.Drain(ctx, store); res.Retried != 0 {
+		t.Fatalf("retried before backoff: %+v", res)
+	}
+	*clock = clock.Add(time.Minute)
+	down = false
+	if res, err := q.Drain(ctx, store); err != nil || res.Succeeded != 1 {
+		t.Fatalf("recovering drain = %+v, %v", res, err)
+	}
+	if len(stored) != 1 || stored[0] != `cats purr {"turn_id":"t1"}` {
+		t.Errorf("stored = %v", stored)
+	}
+	if pending, dead, err := q.Counts(); err != nil || pending != 0 || dead != 0 {
+		t.Errorf("counts = %d, %d, %v", pending, dead, err)
+	}
+}
+
+func TestDrain_WritesProvenanceAtOriginalTime(t *testing.T) {
+	q, clock := newTestQueue(t, Config{MaxAttempts: 3, Backoff: time.Second, MaxBackoff: time.Second, Batch: 10})
+	at := time.Date(2026, 2, 28, 9, 30, 0, 0, time.UTC)
+	if err := q.Provenance(logging.ProvenanceEntry{
+		VersionID: "v1", TriggerType: "user_turn", Decision: "commit", Reason: "gate: ok", CreatedAt: at,
+	}, errors.New("database is locked")); err != nil {
+		t.Fatalf("queue provenance: %v", err)
+	}
+	*clock = clock.Add(time.Second)
+	if res, err := q.Drain(context.Background(), nil); err != nil || res.Succeeded != 1 {
+		t.Fatalf("drain = %+v, %v", res, err)
+	}
+	entries, err := logging.ListProvenance(q.db, 10, 0)
+	if err != nil || len(entries) != 1 {
+		t.Fatalf("provenance = %+v, %v", entries, err)
+	}
+	if e := entries[0]; e.VersionID != "v1" || e.Decision != "commit" || !e.CreatedAt.Equal(at) {
+		t.Errorf("entry = %+v", e)
+	}
+}
+
+func TestDrain_MarksDeadAfterMaxAttempts(t *testing.T) {
+	q, clock := newTestQueue(t, Config{MaxAttempts: 2, Backoff: time.Second, MaxBackoff: time.Second, Batch: 10})
+	q.Evidence("x", "{}", errors.New("down"))
+	fail := func(context.Context, string, string) (string, error) { return "", errors.New("down") }
+	for i := 0; i < 3; i++ {
+		*clock = clock.Add(time.Second)
+		q.Drain(context.Background(), fail)
+	}
+	pending, dead, err := q.Counts()
+	if err != nil || pending != 0 || dead != 1 {
+		t.Errorf("counts = %d pending, %d dead, %v", pending, dead, err)
+	}
+}
@@ -0,0 +1,130 @@
+package review
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// #region human-reviewer
+
+// HumanReviewer lists the candidates on Out and reads the selection from In:
+// space- or comma-separated item numbers or IDs, "all", or "none" / empty.
+type HumanReviewer struct {
+	In  io.Reader
+	Out io.Writer
+
+	once    sync.Once
+	lines   chan selection // In, line by line, from one reader goroutine
+	stale   bool           // the last review was cancelled; drop an answer to it
+	readErr error          // In failed; every later review fails with it
+}
+
+// selection is one line read from In, or the error that ended In.
+type selection struct {
+	line string
+	err  error
+}
+
+// read starts the goroutine that reads In. A single goroutine for the
+// reviewer's lifetime, rather than one per review, means a cancelled review
+// leaves no reader behind to swallow the answer to the next one.
+func (r *HumanReviewer) read() {
+	r.lines = make(chan selection)
+	go func() {
+		br := bufio.NewReader(r.In)
+		for {
+			line, err := br.ReadString('\n')
+			if err != nil && line == "" {
+				r.lines <- selection{err: fmt.Errorf("read selection: %w", err)}
+				return
+			}
+			r.lines <- selection{line: line}
+		}
+	}()
+}
+
+// Name implements Reviewer.
+func (r *HumanReviewer) Name() string { return ReviewerHuman }
+
+// Review implements Reviewer. It returns ctx's error if cancelled while waiting.
+// Reviews must not run concurrently.
+func (r *HumanReviewer) Review(ctx context.Context, req Request) (Decision, error) {
+	if r.In == nil || r.Out == nil {
+		return Decision{}, fmt.Errorf("human reviewer: no terminal configured")
+	}
+	r.once.Do(r.read)
+	if r.stale {
+		// A line typed after the last review was cancelled answers that review's list
+		select {
+		case sel := <-r.lines:
+			r.readErr = sel.err
+		default:
+		}
+		r.stale = false
+	}
+	if r.readErr != nil {
+		return Decision{}, r.readErr
+	}
+	fmt.Fprintf(r.Out, "Memory review — flagged exchange:\n  Commander: %s\n  Orac: %s\n", req.LastPrompt, truncate(req.LastResponse, 200))
+	if req.GateSummary != "" {
+		fmt.Fprintf(r.Out, "  Gate: %s\n", req.GateSummary)
+	}
+	for i, c := range req.Candidates {
+		fmt.Fprintf(r.Out, "  [%d] %s (score %.4f)\n      %s\n", i+1, c.ID, c.Score, truncate(c.Text, 120))
+	}
+	fmt.Fprint(r.Out, "Delete which? (numbers or IDs, 'all', or Enter for none): ")
+
+	select {
+	case <-ctx.Done():
+		r.stale = true
+		return Decision{}, ctx.Err()
+	case sel := <-r.lines:
+		if sel.err != nil {
+			r.readErr = sel.err
+			return Decision{}, sel.err
+		}
+		ids := parseSelection(sel.line, req.Candidates)
+		return Decision{
+			DeleteIDs: ids,
+			Rationale: fmt.Sprintf("user picked %d of %d candidates", len(ids), len(req.Candidates)),
+		}, nil
+	}
+}
+
+// parseSelection resolves item numbers and IDs in line to candidate IDs,
+// ignoring unknown entries and duplicates.
+func parseSelection(line string, candidates []Candidate) []string {
+	line = strings.TrimSpace(line)
+	if strings.EqualFold(line, "none") || line == "" {
+		return nil
+	}
+	if strings.EqualFold(line, "all") {
+		ids := make([]string, len(candidates))
+		for i, c := range candidates {
+			ids[i] = c.ID
+		}
+		return ids
+	}
+
+	valid := validIDs(Request{Candidates: candidates})
+	seen := make(map[string]bool)
+	var ids []string
+	for _, tok := range strings.FieldsFunc(line, func(r rune) bool { return r == ',' || r == ' ' || r == '\t' }) {
+		id := tok
+		if n, err := strconv.Atoi(tok); err == nil && n >= 1 && n <= len(candidates) {
+			id = candidates[n-1].ID
+		}
+		if valid[id] && !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// #endregion human-reviewer
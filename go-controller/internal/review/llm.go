@@ -0,0 +1,78 @@
+package review
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// #region llm-reviewer
+
+// LLMReviewer asks the model which evidence to delete and whitelists its answer
+// against the candidate IDs (prevents hallucinated deletions).
+type LLMReviewer struct {
+	Generate GenerateFunc
+}
+
+// Name implements Reviewer.
+func (r *LLMReviewer) Name() string { return ReviewerLLM }
+
+// Review implements Reviewer.
+func (r *LLMReviewer) Review(ctx context.Context, req Request) (Decision, error) {
+	if r.Generate == nil {
+		return Decision{}, fmt.Errorf("llm reviewer: no generator configured")
+	}
+	text, err := r.Generate(ctx, BuildPrompt(req))
+	if err != nil {
+		return Decision{}, fmt.Errorf("llm review: %w", err)
+	}
+	ids := ParseDeleteIDs(text, req.Candidates)
+	return Decision{
+		DeleteIDs: ids,
+		Rationale: fmt.Sprintf("model selected %d of %d candidates", len(ids), len(req.Candidates)),
+	}, nil
+}
+
+// BuildPrompt renders the review prompt: the flagged exchange, its gate feedback,
+// and each candidate with ID, (truncated) text, and score.
+func BuildPrompt(req Request) string {
+	var lines []string
+	lines = append(lines, "Commander flagged your last response as junk.")
+	if req.GateSummary != "" {
+		lines = append(lines, fmt.Sprintf("Gate feedback from that turn: %s", req.GateSummary))
+	}
+	lines = append(lines, fmt.Sprintf("Your last exchange was:\n  Commander: %s\n  You: %s", req.LastPrompt, req.LastResponse))
+	lines = append(lines, "\nRelated evidence items in your memory:")
+	for _, c := range req.Candidates {
+		lines = append(lines, fmt.Sprintf("  ID: %s\n  Text: %s\n  Score: %.4f\n", c.ID, truncate(c.Text, 200), c.Score))
+	}
+	lines = append(lines, "Which IDs should be deleted? List one per line, or NONE.")
+	return strings.Join(lines, "\n")
+}
+
+// ParseDeleteIDs extracts evidence IDs from the model's review response.
+// Only accepts IDs of the given candidates.
+func ParseDeleteIDs(response string, candidates []Candidate) []string {
+	if strings.TrimSpace(strings.ToUpper(response)) == "NONE" {
+		return nil
+	}
+	valid := validIDs(Request{Candidates: candidates})
+
+	var result []string
+	for _, line := range strings.Split(response, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		// Strip common prefixes like "ID: " or "- "
+		line = strings.TrimPrefix(line, "ID: ")
+		line = strings.TrimPrefix(line, "- ")
+		line = strings.TrimSpace(line)
+		if valid[line] {
+			result = append(result, line)
+		}
+	}
+	return result
+}
+
+// #endregion llm-reviewer
@@ -0,0 +1,98 @@
+package review
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/evidence"
+)
+
+// #region types
+
+// Reviewer names, selected by MEMORY_REVIEWER.
+const (
+	ReviewerLLM   = "llm"
+	ReviewerRules = "rules"
+	ReviewerHuman = "human"
+)
+
+// Candidate is an evidence item offered for deletion.
+type Candidate struct {
+	ID    string
+	Text  string
+	Score float32 // similarity to the flagged exchange
+}
+
+// Request is the context a reviewer gets when the user flags a response as junk.
+type Request struct {
+	LastPrompt   string
+	LastResponse string
+	Candidates   []Candidate
+
+	// Gate feedback from the flagged turn; HasGate is false before the first gated turn.
+	HasGate       bool
+	GateSummary   string
+	GateSoftScore float32
+	GateVetoed    bool
+}
+
+// Decision is a reviewer's verdict. DeleteIDs only ever contains candidate IDs.
+type Decision struct {
+	DeleteIDs []string
+	Rationale string
+}
+
+// Reviewer decides which candidate evidence items to delete.
+type Reviewer interface {
+	Name() string
+	Review(ctx context.Context, req Request) (Decision, error)
+}
+
+// GenerateFunc produces a model response for a review prompt.
+type GenerateFunc func(ctx context.Context, prompt string) (string, error)
+
+// #endregion types
+
+// #region select
+
+// New returns the reviewer registered under name ("" selects the LLM reviewer).
+// gen backs the LLM reviewer; in/out back the human picker.
+func New(name string, gen GenerateFunc, in io.Reader, out io.Writer) (Reviewer, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "", ReviewerLLM:
+		return &LLMReviewer{Generate: gen}, nil
+	case ReviewerRules:
+		return NewRuleReviewer(), nil
+	case ReviewerHuman:
+		return &HumanReviewer{In: in, Out: out}, nil
+	default:
+		return nil, fmt.Errorf("unknown memory reviewer %q (want %s, %s, or %s)", name, ReviewerLLM, ReviewerRules, ReviewerHuman)
+	}
+}
+
+// #endregion select
+
+// #region helpers
+
+// validIDs returns the set of candidate IDs a decision may reference: only
+// well-formed local evidence IDs, since nothing else can be deleted.
+func validIDs(req Request) map[string]bool {
+	set := make(map[string]bool, len(req.Candidates))
+	for _, c := range req.Candidates {
+		if evidence.IsLocalID(c.ID) {
+			set[c.ID] = true
+		}
+	}
+	return set
+}
+
+func truncate(s string, n int) string {
+	if r := []rune(s); len(r) > n {
+		return string(r[:n]) + "..."
+	}
+	return s
+}
+
+// #endregion helpers
@@ -0,0 +1,166 @@
+package review
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+// #region helpers
+
+func testRequest() Request {
+	return Request{
+		LastPrompt:   "what is rust",
+		LastResponse: "rust is a fungus",
+		Candidates: []Candidate{
+			{ID: "ev_00000000-0000-4000-8000-000000000001", Text: "rust is a plant disease", Score: 0.9},
+			{ID: "ev_00000000-0000-4000-8000-000000000002", Text: "rust is a language", Score: 0.7},
+			{ID: "ev_00000000-0000-4000-8000-000000000003", Text: "unrelated", Score: 0.3},
+		},
+	}
+}
+
+// #endregion helpers
+
+// #region select-tests
+
+func TestNew_SelectsByName(t *testing.T) {
+	for name, want := range map[string]string{"": ReviewerLLM, "LLM": ReviewerLLM, "rules": ReviewerRules, " human ": ReviewerHuman} {
+		r, err := New(name, nil, nil, nil)
+		if err != nil || r.Name() != want {
+			t.Errorf("New(%q) = %v, %v; want %s", name, r, err, want)
+		}
+	}
+	if _, err := New("oracle", nil, nil, nil); err == nil {
+		t.Error("expected error for unknown reviewer")
+	}
+}
+
+// #endregion select-tests
+
+// #region llm-tests
+
+func TestLLMReviewer_WhitelistsIDs(t *testing.T) {
+	var gotPrompt string
+	r := &LLMReviewer{Generate: func(_ context.Context, prompt string) (string, error) {
+		gotPrompt = prompt
+		return "ID: ev_00000000-0000-4000-8000-000000000002\n- ev_00000000-0000-4000-8000-000000000009\nev_00000000-0000-4000-8000-000000000003\n", nil
+	}}
+	req := testRequest()
+	req.GateSummary = "soft_score=0.2"
+	d, err := r.Review(context.Background(), req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Join(d.DeleteIDs, ",") != "ev_00000000-0000-4000-8000-000000000002,ev_00000000-0000-4000-8000-000000000003" {
+		t.Errorf("expected hallucinated ID dropped, got %v", d.DeleteIDs)
+	}
+	if !strings.Contains(gotPrompt, "Gate feedback from that turn: soft_score=0.2") || !strings.Contains(gotPrompt, "ID: ev_00000000-0000-4000-8000-000000000001") {
+		t.Errorf("unexpected prompt:\n%s", gotPrompt)
+	}
+	if d.Rationale == "" {
+		t.Error("expected rationale")
+	}
+}
+
+func TestLLMReviewer_NoneAndError(t *testing.T) {
+	r := &LLMReviewer{Generate: func(context.Context, string) (string, error) { return " none ", nil }}
+	if d, _ := r.Review(context.Background(), testRequest()); len(d.DeleteIDs) != 0 {
+		t.Errorf("expected no deletions, got %v", d.DeleteIDs)
+	}
+	r.Generate = func(context.Context, string) (string, error) { return "", errors.New("down") }
+	if _, err := r.Review(context.Background(), testRequest()); err == nil {
+		t.Error("expected generate error to propagate")
+	}
+}
+
+func TestParseDeleteIDs_IgnoresMalformedCandidates(t *testing.T) {
+	candidates := []Candidate{{ID: "ev-1"}, {ID: "team::doc-1"}, {ID: "ev_00000000-0000-4000-8000-000000000001"}}
+	got := ParseDeleteIDs("ev-1\nteam::doc-1\nev_00000000-0000-4000-8000-000000000001", candidates)
+	if len(got) != 1 || got[0] != "ev_00000000-0000-4000-8000-000000000001" {
+		t.Errorf("expected only the local evidence ID, got %v", got)
+	}
+}
+
+// #endregion llm-tests
+
+// #region rule-tests
+
+func TestRuleReviewer_UsesGateFeedback(t *testing.T) {
+	r := NewRuleReviewer()
+	req := testRequest()
+
+	if d, _ := r.Review(context.Background(), req); len(d.DeleteIDs) != 0 {
+		t.Errorf("no gate feedback: expected nothing deleted, got %v", d.DeleteIDs)
+	}
+
+	req.HasGate, req.GateSoftScore = true, 0.3
+	d, _ := r.Review(context.Background(), req)
+	if strings.Join(d.DeleteIDs, ",") != "ev_00000000-0000-4000-8000-000000000001,ev_00000000-0000-4000-8000-000000000002" {
+		t.Errorf("poor turn: expected the first two candidates, got %v", d.DeleteIDs)
+	}
+
+	req.GateSoftScore = 0.8
+	d, _ = r.Review(context.Background(), req)
+	if strings.Join(d.DeleteIDs, ",") != "ev_00000000-0000-4000-8000-000000000001" || !strings.Contains(d.Rationale, "gate passed") {
+		t.Errorf("good turn: expected only the near-duplicate, got %v (%s)", d.DeleteIDs, d.Rationale)
+	}
+}
+
+// #endregion rule-tests
+
+// #region human-tests
+
+func TestHumanReviewer_PicksByNumberAndID(t *testing.T) {
+	var out bytes.Buffer
+	r := &HumanReviewer{In: strings.NewReader("1, ev_00000000-0000-4000-8000-000000000003 1 bogus\n"), Out: &out}
+	d, err := r.Review(context.Background(), testRequest())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Join(d.DeleteIDs, ",") != "ev_00000000-0000-4000-8000-000000000001,ev_00000000-0000-4000-8000-000000000003" {
+		t.Errorf("expected candidates 1 and 3, got %v", d.DeleteIDs)
+	}
+	if !strings.Contains(out.String(), "[2] ev_00000000-0000-4000-8000-000000000002") {
+		t.Errorf("expected candidates listed, got:\n%s", out.String())
+	}
+}
+
+func TestParseSelection_AllAndNone(t *testing.T) {
+	cands := testRequest().Candidates
+	if got := parseSelection("ALL\n", cands); len(got) != 3 {
+		t.Errorf("expected all 3, got %v", got)
+	}
+	if got := parseSelection("\n", cands); got != nil {
+		t.Errorf("expected none, got %v", got)
+	}
+}
+
+func TestHumanReviewer_SuccessiveReviews(t *testing.T) {
+	r := &HumanReviewer{In: strings.NewReader("1\n2\n"), Out: &bytes.Buffer{}}
+	for _, want := range []string{"ev_00000000-0000-4000-8000-000000000001", "ev_00000000-0000-4000-8000-000000000002"} {
+		d, err := r.Review(context.Background(), testRequest())
+		if err != nil || strings.Join(d.DeleteIDs, ",") != want {
+			t.Errorf("review = %v, %v; want [%s]", d.DeleteIDs, err, want)
+		}
+	}
+	if _, err := r.Review(context.Background(), testRequest()); err == nil {
+		t.Error("expected an error once the input is exhausted")
+	}
+}
+
+func TestHumanReviewer_Cancelled(t *testing.T) {
+	pr, pw := io.Pipe()
+	defer pw.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	r := &HumanReviewer{In: pr, Out: &bytes.Buffer{}}
+	if _, err := r.Review(ctx, testRequest()); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+}
+
+// #endregion human-tests
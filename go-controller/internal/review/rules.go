@@ -0,0 +1,54 @@
+package review
+
+import (
+	"context"
+	"fmt"
+)
+
+// #region rule-reviewer
+
+// RuleReviewer deletes evidence deterministically from gate feedback: when the
+// flagged turn scored poorly (vetoed or soft score under SoftScoreFloor), any
+// candidate at least MinSimilarity close to the exchange is treated as its
+// likely source; otherwise only near-duplicates (>= DuplicateSimilarity) go.
+type RuleReviewer struct {
+	SoftScoreFloor      float32
+	MinSimilarity       float32
+	DuplicateSimilarity float32
+}
+
+// NewRuleReviewer returns a RuleReviewer with default thresholds.
+func NewRuleReviewer() *RuleReviewer {
+	return &RuleReviewer{SoftScoreFloor: 0.5, MinSimilarity: 0.6, DuplicateSimilarity: 0.85}
+}
+
+// Name implements Reviewer.
+func (r *RuleReviewer) Name() string { return ReviewerRules }
+
+// Review implements Reviewer.
+func (r *RuleReviewer) Review(_ context.Context, req Request) (Decision, error) {
+	if !req.HasGate {
+		return Decision{Rationale: "no gate feedback for the flagged turn; nothing deleted"}, nil
+	}
+
+	threshold := r.DuplicateSimilarity
+	verdict := fmt.Sprintf("gate passed (soft_score=%.4f)", req.GateSoftScore)
+	if req.GateVetoed || req.GateSoftScore < r.SoftScoreFloor {
+		threshold = r.MinSimilarity
+		verdict = fmt.Sprintf("gate flagged turn (soft_score=%.4f vetoed=%v)", req.GateSoftScore, req.GateVetoed)
+	}
+
+	valid := validIDs(req)
+	var ids []string
+	for _, c := range req.Candidates {
+		if c.Score >= threshold && valid[c.ID] {
+			ids = append(ids, c.ID)
+		}
+	}
+	return Decision{
+		DeleteIDs: ids,
+		Rationale: fmt.Sprintf("%s: deleted %d of %d candidates with similarity >= %.2f", verdict, len(ids), len(req.Candidates), threshold),
+	}, nil
+}
+
+// #endregion rule-reviewer
@@ -0,0 +1,328 @@
+package rulestats
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/logging"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/projection"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/state"
+)
+
+// #region config
+
+// Flags a rule can carry in a report.
+const (
+	FlagNeverFired  = "never_fired" // existed for the whole window and matched no turn
+	FlagCorrections = "corrections" // firings are followed by corrections more often than turns overall
+)
+
+// Config sets how effects are measured and when a rule is flagged.
+type Config struct {
+	ComplianceTurns int     // generated turns averaged on each side of a rule's creation
+	MinFires        int     // firings needed before the correction rate is judged
+	CorrectionRate  float64 // correction rate at or above which a rule is flagged, if also above the baseline
+}
+
+// DefaultConfig returns the report defaults.
+func DefaultConfig() Config {
+	return Config{ComplianceTurns: 20, MinFires: 3, CorrectionRate: 0.3}
+}
+
+// #endregion config
+
+// #region report
+
+// Turn is one user turn as the report reads it from provenance.
+type Turn struct {
+	At         time.Time
+	Decision   string // commit | reject | no_op
+	Rules      []string
+	Generated  bool    // a response was generated (not instruction-only)
+	Compliance float32 // preference compliance (the sentiment signal)
+	Corrected  bool    // the turn carried a user correction
+}
+
+// RuleEffect is one rule's firings and observed effects.
+type RuleEffect struct {
+	Trigger   string    `json:"trigger"`
+	Response  string    `json:"response"`
+	CreatedAt time.Time `json:"created_at"`
+
+	Fires       int `json:"fires"`
+	Commits     int `json:"commits"`
+	Rejects     int `json:"rejects"`
+	NoOps       int `json:"no_ops"`
+	Corrections int `json:"corrections"` // firings whose next turn was a correction
+
+	// Mean compliance of generated turns before and after the rule was learned
+	ComplianceBefore float32 `json:"compliance_before"`
+	ComplianceAfter  float32 `json:"compliance_after"`
+	BeforeTurns      int     `json:"before_turns"`
+	AfterTurns       int     `json:"after_turns"`
+
+	// Lifetime usage as the rule store tracks it, independent of the window
+	Confidence  float64   `json:"confidence"`
+	FiredTotal  int       `json:"fired_total"`
+	LastFiredAt time.Time `json:"last_fired_at"`
+	IdleTurns   int       `json:"idle_turns"`
+
+	Flags []string `json:"flags,omitempty"`
+}
+
+// CorrectionRate is the fraction of firings followed by a correction.
+func (e RuleEffect) CorrectionRate() float64 {
+	if e.Fires == 0 {
+		return 0
+	}
+	return float64(e.Corrections) / float64(e.Fires)
+}
+
+// ComplianceDelta is the change in mean compliance since the rule was
+// learned; ok is false when either side has no generated turns.
+func (e RuleEffect) ComplianceDelta() (float32, bool) {
+	if e.BeforeTurns == 0 || e.AfterTurns == 0 {
+		return 0, false
+	}
+	return e.ComplianceAfter - e.ComplianceBefore, true
+}
+
+// Report is the effectiveness of every current rule over [Since, Until).
+type Report struct {
+	ID                 int64        `json:"id,omitempty"`
+	GeneratedAt        time.Time    `json:"generated_at"`
+	Since              time.Time    `json:"since"`
+	Until              time.Time    `json:"until"`
+	Turns              int          `json:"turns"`                    // user turns in the window
+	BaselineCorrection float64      `json:"baseline_correction_rate"` // fraction of window turns followed by a correction
+	Rules              []RuleEffect `json:"rules"`
+}
+
+// Flagged returns the rules that carry at least one flag.
+func (r Report) Flagged() []RuleEffect {
+	var out []RuleEffect
+	for _, e := range r.Rules {
+		if len(e.Flags) > 0 {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+// Build measures rules against turns, which must be in log order. A firing
+// counts as corrected when the next turn carried a user correction.
+func Build(rules []projection.Rule, turns []Turn, since, until time.Time, cfg Config) Report {
+	rep := Report{GeneratedAt: time.Now().UTC(), Since: since, Until: until}
+
+	var followed int
+	for i, t := range turns {
+		if !inWindow(t.At, since, until) {
+			continue
+		}
+		rep.Turns++
+		if correctedNext(turns, i) {
+			followed++
+		}
+	}
+	if rep.Turns > 0 {
+		rep.BaselineCorrection = float64(followed) / float64(rep.Turns)
+	}
+
+	for _, r := range rules {
+		e := RuleEffect{
+			Trigger: r.Trigger, Response: r.Response, CreatedAt: r.CreatedAt,
+			Confidence: r.Confidence, FiredTotal: r.FiredCount, LastFiredAt: r.LastFiredAt, IdleTurns: r.IdleTurns,
+		}
+		for i, t := range turns {
+			if !inWindow(t.At, since, until) || !fired(t, r.Trigger) {
+				continue
+			}
+			e.Fires++
+			switch t.Decision {
+			case "commit":
+				e.Commits++
+			case "reject":
+				e.Rejects++
+			default:
+				e.NoOps++
+			}
+			if correctedNext(turns, i) {
+				e.Corrections++
+			}
+		}
+		e.ComplianceBefore, e.BeforeTurns, e.ComplianceAfter, e.AfterTurns = compliance(turns, r.CreatedAt, cfg.ComplianceTurns)
+
+		if e.Fires == 0 && !r.CreatedAt.After(since) {
+			e.Flags = append(e.Flags, FlagNeverFired)
+		}
+		if rate := e.CorrectionRate(); e.Fires >= cfg.MinFires && rate >= cfg.CorrectionRate && rate > rep.BaselineCorrection {
+			e.Flags = append(e.Flags, FlagCorrections)
+		}
+		rep.Rules = append(rep.Rules, e)
+	}
+	sort.SliceStable(rep.Rules, func(i, j int) bool {
+		fi, fj := len(rep.Rules[i].Flags) > 0, len(rep.Rules[j].Flags) > 0
+		if fi != fj {
+			return fi
+		}
+		return rep.Rules[i].Fires > rep.Rules[j].Fires
+	})
+	return rep
+}
+
+// inWindow reports whether at falls in [since, until).
+func inWindow(at, since, until time.Time) bool {
+	return !at.Before(since) && at.Before(until)
+}
+
+// fired reports whether trigger matched t, case-insensitively as rules match.
+func fired(t Turn, trigger string) bool {
+	for _, r := range t.Rules {
+		if strings.EqualFold(r, trigger) {
+			return true
+		}
+	}
+	return false
+}
+
+// correctedNext reports whether the turn after turns[i] carried a correction.
+func correctedNext(turns []Turn, i int) bool {
+	return i+1 < len(turns) && turns[i+1].Corrected
+}
+
+// compliance averages the compliance of up to n generated turns on each side
+// of at: the last n before it and the first n from it on.
+func compliance(turns []Turn, at time.Time, n int) (before float32, nBefore int, after float32, nAfter int) {
+	for i := len(turns) - 1; i >= 0 && nBefore < n; i-- {
+		if t := turns[i]; t.Generated && t.At.Before(at) {
+			before += t.Compliance
+			nBefore++
+		}
+	}
+	for _, t := range turns {
+		if nAfter >= n {
+			break
+		}
+		if t.Generated && !t.At.Before(at) {
+			after += t.Compliance
+			nAfter++
+		}
+	}
+	if nBefore > 0 {
+		before /= float32(nBefore)
+	}
+	if nAfter > 0 {
+		after /= float32(nAfter)
+	}
+	return before, nBefore, after, nAfter
+}
+
+// Format renders the report as a table, flagged rules first.
+func (r Report) Format() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%-28s %5s %13s %7s %17s %4s %5s  %s\n", "rule", "fires", "commit/reject", "corr", "compliance", "conf", "idle", "flags")
+	for _, e := range r.Rules {
+		outcomes, corr := "-", "-"
+		if e.Fires > 0 {
+			outcomes = fmt.Sprintf("%d/%d", e.Commits, e.Rejects)
+			corr = fmt.Sprintf("%d/%d", e.Corrections, e.Fires)
+		}
+		comp := "-"
+		if d, ok := e.ComplianceDelta(); ok {
+			comp = fmt.Sprintf("%.2f->%.2f %+.2f", e.ComplianceBefore, e.ComplianceAfter, d)
+		}
+		fmt.Fprintf(&b, "%-28s %5d %13s %7s %17s %4.2f %5d  %s\n", preview(e.Trigger, 28), e.Fires, outcomes, corr, comp,
+			e.Confidence, e.IdleTurns, strings.Join(e.Flags, ","))
+	}
+	fmt.Fprintf(&b, "\n%d rules over %d turns | baseline correction rate %.2f | %d flagged\n",
+		len(r.Rules), r.Turns, r.BaselineCorrection, len(r.Flagged()))
+	return b.String()
+}
+
+// Summary is a one-line account of the flagged rules, empty when none are.
+func (r Report) Summary() string {
+	flagged := r.Flagged()
+	if len(flagged) == 0 {
+		return ""
+	}
+	parts := make([]string, len(flagged))
+	for i, e := range flagged {
+		var why []string
+		for _, f := range e.Flags {
+			switch f {
+			case FlagNeverFired:
+				why = append(why, "never fired")
+			case FlagCorrections:
+				why = append(why, fmt.Sprintf("followed by corrections %d/%d", e.Corrections, e.Fires))
+			}
+		}
+		parts[i] = fmt.Sprintf("%q %s", preview(e.Trigger, 40), strings.Join(why, ", "))
+	}
+	return fmt.Sprintf("%d of %d rules flagged: %s", len(flagged), len(r.Rules), strings.Join(parts, "; "))
+}
+
+// preview collapses whitespace in s and cuts it to n runes.
+func preview(s string, n int) string {
+	s = strings.Join(strings.Fields(s), " ")
+	if r := []rune(s); len(r) > n {
+		return string(r[:n-1]) + "…"
+	}
+	return s
+}
+
+// #endregion report
+
+// #region load
+
+// LoadTurns reads every non-private user turn from the provenance log, oldest
+// first. Rows whose signals_json is not a GateRecord are skipped.
+func LoadTurns(db state.DBTX) ([]Turn, error) {
+	var turns []Turn
+	var after int64
+	for {
+		page, err := logging.ProvenanceSince(db, after, 500)
+		if err != nil {
+			return nil, err
+		}
+		if len(page) == 0 {
+			return turns, nil
+		}
+		for _, e := range page {
+			after = e.ID
+			if e.TriggerType != "user_turn" || e.SignalsJSON == "" {
+				continue
+			}
+			var rec logging.GateRecord
+			if err := json.Unmarshal([]byte(e.SignalsJSON), &rec); err != nil || rec.Private {
+				continue
+			}
+			turns = append(turns, Turn{
+				At:         e.CreatedAt,
+				Decision:   e.Decision,
+				Rules:      rec.RulesMatched,
+				Generated:  rec.Response != "",
+				Compliance: rec.Signals.SentimentScore,
+				Corrected:  rec.Signals.UserCorrection,
+			})
+		}
+	}
+}
+
+// BuildFromDB loads the current rules and the provenance log from db and
+// builds the report for [since, until).
+func BuildFromDB(db state.DBTX, rules *projection.RuleStore, since, until time.Time, cfg Config) (Report, error) {
+	rs, err := rules.List()
+	if err != nil {
+		return Report{}, err
+	}
+	turns, err := LoadTurns(db)
+	if err != nil {
+		return Report{}, fmt.Errorf("load turns: %w", err)
+	}
+	return Build(rs, turns, since, until, cfg), nil
+}
+
+// #endregion load
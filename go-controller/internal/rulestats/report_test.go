@@ -0,0 +1,193 @@
+package rulestats
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/logging"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/projection"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/state"
+)
+
+var base = time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+
+// at is the time of hour h after base.
+func at(h int) time.Time {
+	return base.Add(time.Duration(h) * time.Hour)
+}
+
+func TestBuild(t *testing.T) {
+	rules := []projection.Rule{
+		{Trigger: "status report", Response: "All systems nominal.", CreatedAt: at(2)},
+		{Trigger: "who are you", Response: "I am Orac.", CreatedAt: at(0)},
+		{Trigger: "new rule", Response: "Fresh.", CreatedAt: at(9)},
+	}
+	turns := []Turn{
+		{At: at(1), Decision: "commit", Generated: true, Compliance: 0.4},
+		{At: at(3), Decision: "commit", Generated: true, Compliance: 0.8, Rules: []string{"Status Report"}},
+		{At: at(4), Decision: "reject", Generated: true, Compliance: 0.2, Corrected: true},
+		{At: at(5), Decision: "reject", Generated: true, Compliance: 0.6, Rules: []string{"status report"}},
+		{At: at(6), Decision: "reject", Generated: true, Compliance: 0.6, Corrected: true},
+		{At: at(7), Decision: "no_op", Rules: []string{"status report"}},
+		{At: at(8), Decision: "commit", Generated: true, Compliance: 1, Corrected: true},
+	}
+	cfg := Config{ComplianceTurns: 2, MinFires: 3, CorrectionRate: 0.5}
+	rep := Build(rules, turns, at(1), at(10), cfg)
+
+	if rep.Turns != 7 {
+		t.Errorf("turns = %d, want 7", rep.Turns)
+	}
+	if want := 3.0 / 7; rep.BaselineCorrection != want {
+		t.Errorf("baseline correction = %.3f, want %.3f", rep.BaselineCorrection, want)
+	}
+	if len(rep.Rules) != 3 {
+		t.Fatalf("expected 3 rules, got %d", len(rep.Rules))
+	}
+	// Flagged rules come first
+	status, who, fresh := rep.Rules[0], rep.Rules[1], rep.Rules[2]
+	if status.Trigger != "status report" || who.Trigger != "who are you" || fresh.Trigger != "new rule" {
+		t.Fatalf("order = %s, %s, %s", status.Trigger, who.Trigger, fresh.Trigger)
+	}
+	if status.Fires != 3 || status.Commits != 1 || status.Rejects != 1 || status.NoOps != 1 || status.Corrections != 3 {
+		t.Errorf("status report = %+v", status)
+	}
+	if len(status.Flags) != 1 || status.Flags[0] != FlagCorrections {
+		t.Errorf("status report flags = %v, want corrections", status.Flags)
+	}
+	// Two generated turns before its creation (only one exists), two after
+	if status.BeforeTurns != 1 || status.ComplianceBefore != 0.4 || status.AfterTurns != 2 || status.ComplianceAfter != 0.5 {
+		t.Errorf("status report compliance = %+v", status)
+	}
+	if d, ok := status.ComplianceDelta(); !ok || d < 0.099 || d > 0.101 {
+		t.Errorf("status report delta = %.3f, %v", d, ok)
+	}
+	// Existed the whole window without firing
+	if who.Fires != 0 || len(who.Flags) != 1 || who.Flags[0] != FlagNeverFired {
+		t.Errorf("who are you = %+v", who)
+	}
+	if _, ok := who.ComplianceDelta(); ok {
+		t.Error("a rule older than every turn has no compliance delta")
+	}
+	// Learned inside the window: not judged yet
+	if len(fresh.Flags) != 0 {
+		t.Errorf("new rule flags = %v, want none", fresh.Flags)
+	}
+
+	if s := rep.Summary(); !strings.HasPrefix(s, "2 of 3 rules flagged") ||
+		!strings.Contains(s, `"status report" followed by corrections 3/3`) || !strings.Contains(s, `"who are you" never fired`) {
+		t.Errorf("summary = %q", s)
+	}
+}
+
+func TestBuild_CorrectionsAtBaseline(t *testing.T) {
+	// Every turn is followed by a correction: firing no more often than that is not flagged
+	rules := []projection.Rule{{Trigger: "hi", CreatedAt: at(0)}}
+	var turns []Turn
+	for i := range 6 {
+		turns = append(turns, Turn{At: at(i + 1), Decision: "commit", Rules: []string{"hi"}, Corrected: true})
+	}
+	rep := Build(rules, turns, at(0), at(10), DefaultConfig())
+	if e := rep.Rules[0]; len(e.Flags) != 0 {
+		t.Errorf("flags = %v, want none at the baseline rate %.2f", e.Flags, rep.BaselineCorrection)
+	}
+	if rep.Summary() != "" {
+		t.Errorf("summary = %q, want empty", rep.Summary())
+	}
+}
+
+func TestBuildFromDB(t *testing.T) {
+	store, err := state.NewStore(filepath.Join(t.TempDir(), "rules.db"))
+	if err != nil {
+		t.Fatalf("new store: %v", err)
+	}
+	defer store.Close()
+	ruleStore, err := projection.NewRuleStore(store.DB())
+	if err != nil {
+		t.Fatalf("rule store: %v", err)
+	}
+	initial, err := store.CreateInitialState(state.DefaultSegmentMap())
+	if err != nil {
+		t.Fatalf("initial state: %v", err)
+	}
+	if err := ruleStore.Add("status report", "All systems nominal.", 5, 1); err != nil {
+		t.Fatalf("add rule: %v", err)
+	}
+
+	logTurn := func(trigger string, rec logging.GateRecord) {
+		t.Helper()
+		data, _ := json.Marshal(rec)
+		if err := logging.LogDecision(store.DB(), logging.ProvenanceEntry{
+			VersionID: initial.VersionID, TriggerType: trigger, SignalsJSON: string(data), Decision: "commit",
+		}); err != nil {
+			t.Fatalf("log decision: %v", err)
+		}
+	}
+	logTurn("user_turn", logging.GateRecord{TurnID: "turn-1", Response: "ok", RulesMatched: []string{"status report"}})
+	logTurn("branch", logging.GateRecord{TurnID: "turn-1-hot", RulesMatched: []string{"status report"}})
+	logTurn("user_turn", logging.GateRecord{TurnID: "turn-2", Private: true})
+	logTurn("user_turn", logging.GateRecord{TurnID: "turn-3", Response: "ok",
+		Signals: logging.GateRecordSignals{UserCorrection: true, SentimentScore: 0.5}})
+
+	turns, err := LoadTurns(store.DB())
+	if err != nil {
+		t.Fatalf("load turns: %v", err)
+	}
+	if len(turns) != 2 || !turns[1].Corrected || turns[1].Compliance != 0.5 {
+		t.Fatalf("turns = %+v, want the two non-private user turns", turns)
+	}
+
+	now := time.Now().UTC()
+	rep, err := BuildFromDB(store.DB(), ruleStore, now.Add(-time.Hour), now.Add(time.Minute), DefaultConfig())
+	if err != nil {
+		t.Fatalf("build: %v", err)
+	}
+	if len(rep.Rules) != 1 || rep.Rules[0].Fires != 1 || rep.Rules[0].Corrections != 1 {
+		t.Errorf("report = %+v", rep.Rules)
+	}
+}
+
+func TestStore(t *testing.T) {
+	store, err := state.NewStore(filepath.Join(t.TempDir(), "reports.db"))
+	if err != nil {
+		t.Fatalf("new store: %v", err)
+	}
+	defer store.Close()
+	reports, err := NewStore(store.DB())
+	if err != nil {
+		t.Fatalf("new report store: %v", err)
+	}
+
+	now := time.Now().UTC()
+	week := 7 * 24 * time.Hour
+	if due, err := reports.Due(now, week); err != nil || !due {
+		t.Fatalf("empty store: due=%v err=%v, want due", due, err)
+	}
+	if _, ok, err := reports.Latest(); err != nil || ok {
+		t.Fatalf("empty store: latest ok=%v err=%v", ok, err)
+	}
+
+	rep := Build([]projection.Rule{{Trigger: "hi", CreatedAt: now.Add(-2 * week)}}, nil, now.Add(-week), now, DefaultConfig())
+	id, err := reports.Record(rep)
+	if err != nil {
+		t.Fatalf("record: %v", err)
+	}
+	got, ok, err := reports.Latest()
+	if err != nil || !ok {
+		t.Fatalf("latest: ok=%v err=%v", ok, err)
+	}
+	if got.ID != id || len(got.Rules) != 1 || len(got.Flagged()) != 1 || !got.Since.Equal(rep.Since) {
+		t.Errorf("latest = %+v, want the recorded report", got)
+	}
+	if due, _ := reports.Due(now, week); due {
+		t.Error("a report was just recorded; none should be due")
+	}
+	if due, _ := reports.Due(now.Add(week+time.Minute), week); !due {
+		t.Error("a week later a report should be due")
+	}
+	if due, _ := reports.Due(now, 0); due {
+		t.Error("a zero interval never schedules a report")
+	}
+}
@@ -0,0 +1,98 @@
+package rulestats
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/timestamp"
+)
+
+// #region store
+
+// Store keeps generated reports in the rule_reports table.
+type Store struct {
+	db *sql.DB
+}
+
+// NewStore creates the rule_reports table if needed and returns a store.
+func NewStore(db *sql.DB) (*Store, error) {
+	_, err := db.Exec(`CREATE TABLE IF NOT EXISTS rule_reports (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		generated_at TEXT NOT NULL,
+		since TEXT NOT NULL,
+		until TEXT NOT NULL,
+		rules INTEGER NOT NULL,
+		flagged INTEGER NOT NULL,
+		report_json TEXT NOT NULL
+	)`)
+	if err != nil {
+		return nil, fmt.Errorf("create rule_reports table: %w", err)
+	}
+	if _, err := timestamp.Canonicalize(db, "rule_reports", "generated_at", "since", "until"); err != nil {
+		return nil, err
+	}
+	return &Store{db: db}, nil
+}
+
+// Record stores rep and returns its ID.
+func (s *Store) Record(rep Report) (int64, error) {
+	data, err := json.Marshal(rep)
+	if err != nil {
+		return 0, fmt.Errorf("marshal rule report: %w", err)
+	}
+	if rep.GeneratedAt.IsZero() {
+		rep.GeneratedAt = time.Now().UTC()
+	}
+	res, err := s.db.Exec(
+		`INSERT INTO rule_reports (generated_at, since, until, rules, flagged, report_json) VALUES (?, ?, ?, ?, ?, ?)`,
+		timestamp.Format(rep.GeneratedAt), timestamp.Format(rep.Since), timestamp.Format(rep.Until),
+		len(rep.Rules), len(rep.Flagged()), string(data),
+	)
+	if err != nil {
+		return 0, fmt.Errorf("insert rule report: %w", err)
+	}
+	return res.LastInsertId()
+}
+
+// Latest returns the newest stored report; ok is false when there is none.
+func (s *Store) Latest() (rep Report, ok bool, err error) {
+	var id int64
+	var generatedAt, data string
+	err = s.db.QueryRow(`SELECT id, generated_at, report_json FROM rule_reports ORDER BY generated_at DESC, id DESC LIMIT 1`).
+		Scan(&id, &generatedAt, &data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Report{}, false, nil
+	}
+	if err != nil {
+		return Report{}, false, fmt.Errorf("latest rule report: %w", err)
+	}
+	if err := json.Unmarshal([]byte(data), &rep); err != nil {
+		return Report{}, false, fmt.Errorf("decode rule report %d: %w", id, err)
+	}
+	rep.ID = id
+	rep.GeneratedAt, _ = timestamp.Parse(generatedAt)
+	return rep, true, nil
+}
+
+// Due reports whether a report is due at now: none stored yet, or the newest
+// is at least interval old. A non-positive interval never schedules one.
+func (s *Store) Due(now time.Time, interval time.Duration) (bool, error) {
+	if interval <= 0 {
+		return false, nil
+	}
+	var generatedAt string
+	err := s.db.QueryRow(`SELECT generated_at FROM rule_reports ORDER BY generated_at DESC, id DESC LIMIT 1`).Scan(&generatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return true, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("last rule report: %w", err)
+	}
+	last, _ := timestamp.Parse(generatedAt)
+	return now.Sub(last) >= interval, nil
+}
+
+// #endregion store
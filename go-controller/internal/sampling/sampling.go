@@ -0,0 +1,168 @@
+package sampling
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/codec"
+)
+
+// #region config
+
+// Config bounds the generation parameters the controller picks per turn.
+// Temperature starts at Temperature, cools with the risk segment norm and
+// warms on creative turn types, then is clamped to [MinTemperature,
+// MaxTemperature].
+type Config struct {
+	Disabled bool // send no parameters; the server keeps its own defaults
+
+	Temperature    float32 // base temperature
+	MinTemperature float32
+	MaxTemperature float32
+	TopP           float32
+	MaxTokens      int
+
+	RiskCooling   float32  // temperature removed per unit of risk segment norm
+	MaxCooling    float32  // cap on the risk reduction
+	CreativeBoost float32  // temperature added on creative turn types
+	CreativeTypes []string // turn types that get CreativeBoost
+}
+
+// DefaultConfig starts from Ollama's own defaults (temperature 0.8, top_p 0.9)
+// and the 512-token cap py-inference applies, so an unstated turn generates as
+// it did before the controller chose parameters.
+func DefaultConfig() Config {
+	return Config{
+		Temperature:    0.8,
+		MinTemperature: 0.2,
+		MaxTemperature: 1.1,
+		TopP:           0.9,
+		MaxTokens:      512,
+		RiskCooling:    0.1,
+		MaxCooling:     0.4,
+		CreativeBoost:  0.2,
+		CreativeTypes:  []string{"creative"},
+	}
+}
+
+// #endregion config
+
+// #region choose
+
+// Choice is the parameters picked for one turn and why they differ from the
+// base configuration.
+type Choice struct {
+	Sampling    codec.Sampling
+	Adjustments []string // e.g. "risk_norm=1.50 -0.15", "creative +0.20"
+}
+
+// Choose picks the sampling parameters for a turn from the risk segment norm
+// and the classified turn type. A disabled config returns a zero Sampling.
+func Choose(cfg Config, riskNorm float32, turnType string) Choice {
+	if cfg.Disabled {
+		return Choice{}
+	}
+	temp := cfg.Temperature
+	var adjustments []string
+	if cooling := min(riskNorm*cfg.RiskCooling, cfg.MaxCooling); cooling > 0 {
+		temp -= cooling
+		adjustments = append(adjustments, fmt.Sprintf("risk_norm=%.2f -%.2f", riskNorm, cooling))
+	}
+	if cfg.CreativeBoost > 0 && isCreative(cfg, turnType) {
+		temp += cfg.CreativeBoost
+		adjustments = append(adjustments, fmt.Sprintf("%s +%.2f", turnType, cfg.CreativeBoost))
+	}
+	if temp < cfg.MinTemperature {
+		temp = cfg.MinTemperature
+		adjustments = append(adjustments, fmt.Sprintf("clamped to min %.2f", cfg.MinTemperature))
+	} else if temp > cfg.MaxTemperature {
+		temp = cfg.MaxTemperature
+		adjustments = append(adjustments, fmt.Sprintf("clamped to max %.2f", cfg.MaxTemperature))
+	}
+	return Choice{
+		Sampling:    codec.Sampling{Temperature: temp, TopP: cfg.TopP, MaxTokens: cfg.MaxTokens},
+		Adjustments: adjustments,
+	}
+}
+
+func isCreative(cfg Config, turnType string) bool {
+	for _, t := range cfg.CreativeTypes {
+		if t == turnType {
+			return true
+		}
+	}
+	return false
+}
+
+// #endregion choose
+
+// #region parse
+
+// ParseConfig applies a comma-separated "key=value" list over base, e.g.
+// "temperature=0.7,max=1.0,creative_types=creative|philosophical". Keys:
+// temperature, min, max, top_p, max_tokens, risk_cooling, max_cooling,
+// creative_boost, creative_types. "off" disables sampling control.
+func ParseConfig(spec string, base Config) (Config, error) {
+	cfg := base
+	cfg.CreativeTypes = append([]string(nil), base.CreativeTypes...)
+	if strings.TrimSpace(spec) == "off" {
+		cfg.Disabled = true
+		return cfg, nil
+	}
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(part, "=")
+		key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+		if !ok {
+			return Config{}, fmt.Errorf("sampling %q: want key=value", part)
+		}
+		if key == "creative_types" {
+			cfg.CreativeTypes = nil
+			for _, t := range strings.Split(value, "|") {
+				if t = strings.TrimSpace(t); t != "" {
+					cfg.CreativeTypes = append(cfg.CreativeTypes, t)
+				}
+			}
+			continue
+		}
+		if key == "max_tokens" {
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 0 {
+				return Config{}, fmt.Errorf("sampling %q: max_tokens must be a non-negative integer", part)
+			}
+			cfg.MaxTokens = n
+			continue
+		}
+		f, err := strconv.ParseFloat(value, 32)
+		if err != nil || f < 0 || math.IsNaN(f) || math.IsInf(f, 0) {
+			return Config{}, fmt.Errorf("sampling %q: value must be a non-negative number", part)
+		}
+		field, ok := map[string]*float32{
+			"temperature":    &cfg.Temperature,
+			"min":            &cfg.MinTemperature,
+			"max":            &cfg.MaxTemperature,
+			"top_p":          &cfg.TopP,
+			"risk_cooling":   &cfg.RiskCooling,
+			"max_cooling":    &cfg.MaxCooling,
+			"creative_boost": &cfg.CreativeBoost,
+		}[key]
+		if !ok {
+			return Config{}, fmt.Errorf("sampling %q: unknown key %q", part, key)
+		}
+		*field = float32(f)
+	}
+	if cfg.MinTemperature > cfg.MaxTemperature {
+		return Config{}, fmt.Errorf("sampling: min temperature %.2f above max %.2f", cfg.MinTemperature, cfg.MaxTemperature)
+	}
+	if cfg.TopP > 1 {
+		return Config{}, fmt.Errorf("sampling: top_p %.2f above 1", cfg.TopP)
+	}
+	return cfg, nil
+}
+
+// #endregion parse
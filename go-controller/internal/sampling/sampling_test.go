@@ -0,0 +1,80 @@
+package sampling
+
+import (
+	"math"
+	"testing"
+)
+
+func near(a, b float32) bool {
+	return math.Abs(float64(a-b)) < 1e-5
+}
+
+func TestChoose_BaseTurn(t *testing.T) {
+	c := Choose(DefaultConfig(), 0, "factual")
+	if !near(c.Sampling.Temperature, 0.8) || !near(c.Sampling.TopP, 0.9) || c.Sampling.MaxTokens != 512 {
+		t.Errorf("sampling = %+v", c.Sampling)
+	}
+	if len(c.Adjustments) != 0 {
+		t.Errorf("adjustments = %v, want none", c.Adjustments)
+	}
+}
+
+func TestChoose_RiskCoolsCreativeWarms(t *testing.T) {
+	cfg := DefaultConfig()
+
+	cooled := Choose(cfg, 2, "factual")
+	if !near(cooled.Sampling.Temperature, 0.6) || len(cooled.Adjustments) != 1 {
+		t.Errorf("risky turn = %+v", cooled)
+	}
+	warmed := Choose(cfg, 0, "creative")
+	if !near(warmed.Sampling.Temperature, 1.0) || len(warmed.Adjustments) != 1 {
+		t.Errorf("creative turn = %+v", warmed)
+	}
+	// Both apply; risk cooling is capped at MaxCooling
+	both := Choose(cfg, 10, "creative")
+	if !near(both.Sampling.Temperature, 0.6) || len(both.Adjustments) != 2 {
+		t.Errorf("risky creative turn = %+v", both)
+	}
+}
+
+func TestChoose_ClampsToBounds(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.CreativeBoost = 1
+	if c := Choose(cfg, 0, "creative"); !near(c.Sampling.Temperature, cfg.MaxTemperature) {
+		t.Errorf("temperature = %v, want max %v", c.Sampling.Temperature, cfg.MaxTemperature)
+	}
+	cfg.MaxCooling = 5
+	if c := Choose(cfg, 20, "factual"); !near(c.Sampling.Temperature, cfg.MinTemperature) {
+		t.Errorf("temperature = %v, want min %v", c.Sampling.Temperature, cfg.MinTemperature)
+	}
+}
+
+func TestChoose_Disabled(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Disabled = true
+	if c := Choose(cfg, 3, "creative"); !c.Sampling.IsZero() || c.Adjustments != nil {
+		t.Errorf("disabled choice = %+v", c)
+	}
+}
+
+func TestParseConfig(t *testing.T) {
+	cfg, err := ParseConfig("temperature=0.7, max=1.0, max_tokens=256, creative_types=creative|philosophical", DefaultConfig())
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if !near(cfg.Temperature, 0.7) || !near(cfg.MaxTemperature, 1.0) || cfg.MaxTokens != 256 || len(cfg.CreativeTypes) != 2 {
+		t.Errorf("cfg = %+v", cfg)
+	}
+	if c := Choose(cfg, 0, "philosophical"); !near(c.Sampling.Temperature, 0.9) {
+		t.Errorf("philosophical temperature = %v", c.Sampling.Temperature)
+	}
+
+	if cfg, err := ParseConfig("off", DefaultConfig()); err != nil || !cfg.Disabled {
+		t.Errorf("off = %+v, %v", cfg, err)
+	}
+	for _, bad := range []string{"temperature", "temp=0.5", "top_p=1.5", "min=1.2", "max_tokens=-1", "risk_cooling=NaN"} {
+		if _, err := ParseConfig(bad, DefaultConfig()); err == nil {
+			t.Errorf("ParseConfig(%q) accepted", bad)
+		}
+	}
+}
@@ -0,0 +1,265 @@
+package session
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/projection"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/state"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/timestamp"
+)
+
+// #region changes
+
+// ShiftThreshold is the smallest change in a segment's norm worth mentioning.
+const ShiftThreshold = 0.25
+
+// SegmentShift is a notable change in one segment's norm.
+type SegmentShift struct {
+	Segment string
+	Before  float64
+	After   float64
+}
+
+// Changes is what was learned between the start of the previous session and now.
+type Changes struct {
+	Since              time.Time
+	NewPreferences     []string
+	RetiredPreferences []string
+	NewRules           []string // triggers, including rules replaced under the same trigger
+	ExpiredRules       []string
+	PrunedRules        []string // unused long enough to decay and be pruned
+	Commits            int      // user-turn state updates committed
+	Rejects            int
+	Shifts             []SegmentShift
+}
+
+// Collect diffs the stores since the given time: preference lifecycle events,
+// rules added, expired or pruned, user-turn provenance, and the state vector from the
+// version active at that time (from) to the current one (to). A from without a
+// VersionID skips the state comparison.
+func Collect(db *sql.DB, since, now time.Time, from, to state.StateRecord) (Changes, error) {
+	c := Changes{Since: since}
+	if err := c.collectPreferences(db, since); err != nil {
+		return Changes{}, err
+	}
+	if err := c.collectRules(db, since, now); err != nil {
+		return Changes{}, err
+	}
+	if err := c.collectProvenance(db, since); err != nil {
+		return Changes{}, err
+	}
+	if from.VersionID != "" {
+		for _, name := range []string{"prefs", "goals", "heuristics", "risk"} {
+			bounds, _ := state.SegmentRange(to.SegmentMap, name)
+			before, after := norm(from.StateVector, bounds), norm(to.StateVector, bounds)
+			if math.Abs(after-before) >= ShiftThreshold {
+				c.Shifts = append(c.Shifts, SegmentShift{Segment: name, Before: before, After: after})
+			}
+		}
+	}
+	return c, nil
+}
+
+// collectPreferences lists preferences created or retired since, by their
+// current status: one created and retired in the window is not mentioned, and
+// one retired and since restated is not reported as retired.
+func (c *Changes) collectPreferences(db *sql.DB, since time.Time) error {
+	rows, err := db.Query(`SELECT p.id, p.text, p.status, e.event, e.created_at
+		FROM preference_events e JOIN preferences p ON p.id = e.preference_id
+		WHERE e.event IN ('created', 'retired') ORDER BY e.id`)
+	if err != nil {
+		return fmt.Errorf("list preference events: %w", err)
+	}
+	defer rows.Close()
+
+	created := map[int64]bool{}
+	reported := map[int64]bool{}
+	type retirement struct {
+		id   int64
+		text string
+	}
+	var retired []retirement
+	for rows.Next() {
+		var id int64
+		var text, status, event, ts string
+		if err := rows.Scan(&id, &text, &status, &event, &ts); err != nil {
+			return fmt.Errorf("scan preference event: %w", err)
+		}
+		at, _ := timestamp.Parse(ts)
+		if at.Before(since) {
+			continue
+		}
+		switch {
+		case event == "created":
+			created[id] = true
+			if status != "retired" && !reported[id] {
+				reported[id] = true
+				c.NewPreferences = append(c.NewPreferences, text)
+			}
+		case status == "retired":
+			retired = append(retired, retirement{id, text})
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	for _, r := range retired {
+		if !created[r.id] && !reported[r.id] {
+			reported[r.id] = true
+			c.RetiredPreferences = append(c.RetiredPreferences, r.text)
+		}
+	}
+	return nil
+}
+
+// collectRules lists rules added since (and not yet expired), rules whose
+// expiry fell between since and now, and rules pruned since; an expired rule
+// pruned since counts as expired.
+func (c *Changes) collectRules(db *sql.DB, since, now time.Time) error {
+	rows, err := db.Query("SELECT trigger, created_at, expires_at FROM rules ORDER BY created_at")
+	if err != nil {
+		return fmt.Errorf("list rules: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var trigger, ts string
+		var expires sql.NullString
+		if err := rows.Scan(&trigger, &ts, &expires); err != nil {
+			return fmt.Errorf("scan rule: %w", err)
+		}
+		createdAt, _ := timestamp.Parse(ts)
+		var expiresAt time.Time
+		if expires.Valid {
+			expiresAt, _ = timestamp.Parse(expires.String)
+		}
+		expired := !expiresAt.IsZero() && !expiresAt.After(now)
+		switch {
+		case !createdAt.Before(since) && !expired:
+			c.NewRules = append(c.NewRules, trigger)
+		case createdAt.Before(since) && expired && !expiresAt.Before(since):
+			c.ExpiredRules = append(c.ExpiredRules, trigger)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	rows.Close()
+
+	pruned, err := db.Query("SELECT trigger, created_at, reason FROM pruned_rules WHERE pruned_at >= ? ORDER BY id", timestamp.Format(since))
+	if err != nil {
+		return fmt.Errorf("list pruned rules: %w", err)
+	}
+	defer pruned.Close()
+	for pruned.Next() {
+		var trigger, ts, reason string
+		if err := pruned.Scan(&trigger, &ts, &reason); err != nil {
+			return fmt.Errorf("scan pruned rule: %w", err)
+		}
+		createdAt, _ := timestamp.Parse(ts)
+		switch {
+		case reason == projection.RulePrunedDecayed:
+			c.PrunedRules = append(c.PrunedRules, trigger)
+		case createdAt.Before(since):
+			c.ExpiredRules = append(c.ExpiredRules, trigger)
+		}
+	}
+	return pruned.Err()
+}
+
+// collectProvenance counts user-turn commits and rejections since.
+func (c *Changes) collectProvenance(db *sql.DB, since time.Time) error {
+	rows, err := db.Query("SELECT decision, created_at FROM provenance_log WHERE trigger_type = 'user_turn'")
+	if err != nil {
+		return fmt.Errorf("list provenance: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var decision, ts string
+		if err := rows.Scan(&decision, &ts); err != nil {
+			return fmt.Errorf("scan provenance: %w", err)
+		}
+		if at, _ := timestamp.Parse(ts); at.Before(since) {
+			continue
+		}
+		switch decision {
+		case "commit":
+			c.Commits++
+		case "reject":
+			c.Rejects++
+		}
+	}
+	return rows.Err()
+}
+
+func norm(v [128]float32, bounds [2]int) float64 {
+	var sum float64
+	for i := bounds[0]; i < bounds[1] && i < len(v); i++ {
+		sum += float64(v[i]) * float64(v[i])
+	}
+	return math.Sqrt(sum)
+}
+
+// #endregion changes
+
+// #region banner
+
+// Empty reports whether nothing worth telling the user changed. Update counts
+// alone are not news; a segment shift, preference or rule change is.
+func (c Changes) Empty() bool {
+	return len(c.NewPreferences) == 0 && len(c.RetiredPreferences) == 0 &&
+		len(c.NewRules) == 0 && len(c.ExpiredRules) == 0 && len(c.PrunedRules) == 0 && len(c.Shifts) == 0
+}
+
+// Banner renders the changes as a short session-start summary, or "" when
+// Empty.
+func (c Changes) Banner() string {
+	if c.Empty() {
+		return ""
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "Since your last session (%s):", c.Since.Local().Format("2006-01-02 15:04"))
+	line := func(label string, items []string) {
+		if len(items) > 0 {
+			fmt.Fprintf(&b, "\n  %s: %s", label, quoteList(items))
+		}
+	}
+	line("New preferences", c.NewPreferences)
+	line("Retired preferences", c.RetiredPreferences)
+	line("New rules", c.NewRules)
+	line("Expired rules", c.ExpiredRules)
+	line("Pruned unused rules", c.PrunedRules)
+	if len(c.Shifts) > 0 {
+		parts := make([]string, len(c.Shifts))
+		for i, s := range c.Shifts {
+			parts[i] = fmt.Sprintf("%s %.2f → %.2f", s.Segment, s.Before, s.After)
+		}
+		fmt.Fprintf(&b, "\n  State shifts: %s", strings.Join(parts, ", "))
+	}
+	fmt.Fprintf(&b, "\n  (%d updates committed, %d rejected)", c.Commits, c.Rejects)
+	return b.String()
+}
+
+// quoteList quotes items, listing at most five.
+func quoteList(items []string) string {
+	const max = 5
+	quoted := make([]string, 0, max)
+	for i, it := range items {
+		if i == max {
+			break
+		}
+		quoted = append(quoted, fmt.Sprintf("%q", it))
+	}
+	out := strings.Join(quoted, ", ")
+	if len(items) > max {
+		out += fmt.Sprintf(" and %d more", len(items)-max)
+	}
+	return out
+}
+
+// #endregion banner
@@ -0,0 +1,193 @@
+package session
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/logging"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/projection"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/state"
+)
+
+// #region helpers
+
+func testStores(t *testing.T) (*state.Store, *projection.PreferenceStore, *projection.RuleStore) {
+	t.Helper()
+	store, err := state.NewStore(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("NewStore: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	prefs, err := projection.NewPreferenceStore(store.DB())
+	if err != nil {
+		t.Fatalf("NewPreferenceStore: %v", err)
+	}
+	rules, err := projection.NewRuleStore(store.DB())
+	if err != nil {
+		t.Fatalf("NewRuleStore: %v", err)
+	}
+	return store, prefs, rules
+}
+
+func prefID(t *testing.T, prefs *projection.PreferenceStore, text string) int {
+	t.Helper()
+	list, err := prefs.List()
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	for _, p := range list {
+		if p.Text == text {
+			return p.ID
+		}
+	}
+	t.Fatalf("preference %q not found", text)
+	return 0
+}
+
+// #endregion helpers
+
+// #region store-tests
+
+func TestStoreBegin_ReturnsPreviousSession(t *testing.T) {
+	store, _, _ := testStores(t)
+	sessions, err := NewStore(store.DB())
+	if err != nil {
+		t.Fatalf("NewStore: %v", err)
+	}
+	first := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	if _, ok, err := sessions.Begin("v1", first); ok || err != nil {
+		t.Fatalf("first session should have no predecessor: ok=%v err=%v", ok, err)
+	}
+	prev, ok, err := sessions.Begin("v7", first.Add(24*time.Hour))
+	if err != nil || !ok {
+		t.Fatalf("Begin: ok=%v err=%v", ok, err)
+	}
+	if prev.VersionID != "v1" || !prev.StartedAt.Equal(first) {
+		t.Errorf("unexpected previous session %+v", prev)
+	}
+}
+
+// #endregion store-tests
+
+// #region changes-tests
+
+func TestCollect(t *testing.T) {
+	store, prefs, rules := testStores(t)
+	db := store.DB()
+
+	// Before the previous session started
+	for _, text := range []string{"Be concise", "Use metric units"} {
+		if err := prefs.Add(text, "explicit"); err != nil {
+			t.Fatalf("Add: %v", err)
+		}
+	}
+	old := time.Now().UTC().Add(-48 * time.Hour)
+	if _, err := db.Exec("INSERT INTO rules (trigger, response, created_at, expires_at) VALUES (?, ?, ?, ?)",
+		"standup", "Time for standup", old, time.Now().UTC().Add(30*time.Minute).Format(time.RFC3339)); err != nil {
+		t.Fatalf("insert rule: %v", err)
+	}
+	since := time.Now().UTC()
+
+	// During the previous session
+	if err := prefs.Add("Use British spelling", "explicit"); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+	if err := prefs.Add("Skip the pleasantries", "explicit"); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+	if err := prefs.Retire(prefID(t, prefs, "Skip the pleasantries")); err != nil { // created and retired: nets out
+		t.Fatalf("Retire: %v", err)
+	}
+	if err := prefs.Retire(prefID(t, prefs, "Use metric units")); err != nil {
+		t.Fatalf("Retire: %v", err)
+	}
+	if err := rules.Add("good morning", "Morning!", 5, 1.0); err != nil {
+		t.Fatalf("rules.Add: %v", err)
+	}
+	initial, err := store.CreateInitialState(state.DefaultSegmentMap())
+	if err != nil {
+		t.Fatalf("CreateInitialState: %v", err)
+	}
+	for _, decision := range []string{"commit", "commit", "reject"} {
+		if err := logging.LogDecision(db, logging.ProvenanceEntry{VersionID: initial.VersionID, TriggerType: "user_turn", Decision: decision}); err != nil {
+			t.Fatalf("LogDecision: %v", err)
+		}
+	}
+	if err := logging.LogDecision(db, logging.ProvenanceEntry{VersionID: initial.VersionID, TriggerType: "suggestion", Decision: "commit"}); err != nil {
+		t.Fatalf("LogDecision: %v", err)
+	}
+
+	from := state.StateRecord{VersionID: "v1", SegmentMap: state.DefaultSegmentMap()}
+	to := state.StateRecord{VersionID: "v9", SegmentMap: state.DefaultSegmentMap()}
+	from.StateVector[0] = 0.2
+	to.StateVector[0] = 0.8  // prefs 0.2 → 0.8
+	to.StateVector[40] = 0.1 // goals: below the threshold
+
+	c, err := Collect(db, since, time.Now().UTC().Add(time.Hour), from, to) // standup has expired by then
+	if err != nil {
+		t.Fatalf("Collect: %v", err)
+	}
+	if strings.Join(c.NewPreferences, "|") != "Use British spelling" {
+		t.Errorf("NewPreferences = %q", c.NewPreferences)
+	}
+	if strings.Join(c.RetiredPreferences, "|") != "Use metric units" {
+		t.Errorf("RetiredPreferences = %q", c.RetiredPreferences)
+	}
+	if strings.Join(c.NewRules, "|") != "good morning" || strings.Join(c.ExpiredRules, "|") != "standup" {
+		t.Errorf("rules: new %q, expired %q", c.NewRules, c.ExpiredRules)
+	}
+	if c.Commits != 2 || c.Rejects != 1 {
+		t.Errorf("provenance counts: %d commits, %d rejects", c.Commits, c.Rejects)
+	}
+	if len(c.Shifts) != 1 || c.Shifts[0].Segment != "prefs" {
+		t.Errorf("Shifts = %+v", c.Shifts)
+	}
+
+	banner := c.Banner()
+	for _, want := range []string{`New preferences: "Use British spelling"`, `Retired preferences: "Use metric units"`,
+		`New rules: "good morning"`, `Expired rules: "standup"`, "prefs 0.20 → 0.80", "2 updates committed, 1 rejected"} {
+		if !strings.Contains(banner, want) {
+			t.Errorf("banner missing %q:\n%s", want, banner)
+		}
+	}
+}
+
+func TestCollect_PrunedRules(t *testing.T) {
+	store, _, rules := testStores(t)
+	if err := rules.Add("knock knock", "who's there?", 5, 1.0); err != nil {
+		t.Fatalf("rules.Add: %v", err)
+	}
+	since := time.Now().UTC()
+	// The second idle turn passes the one-turn limit and decays it below the floor
+	cfg := projection.RuleDecayConfig{IdleTurns: 1, Rate: 0.9, PruneBelow: 0.2}
+	for i := 0; i < 2; i++ {
+		if _, err := rules.RecordTurn(nil, cfg); err != nil {
+			t.Fatalf("RecordTurn: %v", err)
+		}
+	}
+	c, err := Collect(store.DB(), since, time.Now().UTC(), state.StateRecord{}, state.StateRecord{})
+	if err != nil {
+		t.Fatalf("Collect: %v", err)
+	}
+	if strings.Join(c.PrunedRules, "|") != "knock knock" || len(c.ExpiredRules) != 0 {
+		t.Errorf("pruned %q, expired %q", c.PrunedRules, c.ExpiredRules)
+	}
+	if banner := c.Banner(); !strings.Contains(banner, `Pruned unused rules: "knock knock"`) {
+		t.Errorf("banner = %q", banner)
+	}
+}
+
+func TestChangesBanner_QuietWithoutNews(t *testing.T) {
+	c := Changes{Since: time.Now(), Commits: 12, Rejects: 1}
+	if !c.Empty() || c.Banner() != "" {
+		t.Errorf("update counts alone should not produce a banner, got %q", c.Banner())
+	}
+	c.NewRules = []string{"a", "b", "c", "d", "e", "f", "g"}
+	if banner := c.Banner(); !strings.Contains(banner, `"e" and 2 more`) {
+		t.Errorf("long lists should be cut, got %q", banner)
+	}
+}
+
+// #endregion changes-tests
@@ -0,0 +1,63 @@
+package session
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/timestamp"
+)
+
+// #region store
+
+// Session is one daemon run: when it started and the active state version then.
+type Session struct {
+	ID        int64
+	StartedAt time.Time
+	VersionID string
+}
+
+// Store records session starts in the sessions table.
+type Store struct {
+	db *sql.DB
+}
+
+// NewStore creates the sessions table if needed and returns a store.
+func NewStore(db *sql.DB) (*Store, error) {
+	_, err := db.Exec(`CREATE TABLE IF NOT EXISTS sessions (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		started_at TEXT NOT NULL,
+		version_id TEXT NOT NULL DEFAULT ''
+	)`)
+	if err != nil {
+		return nil, fmt.Errorf("create sessions table: %w", err)
+	}
+	if _, err := timestamp.Canonicalize(db, "sessions", "started_at"); err != nil {
+		return nil, err
+	}
+	return &Store{db: db}, nil
+}
+
+// Begin records a session starting at now from versionID and returns the
+// session before it; ok is false on the first session.
+func (s *Store) Begin(versionID string, now time.Time) (prev Session, ok bool, err error) {
+	var startedAt string
+	err = s.db.QueryRow("SELECT id, started_at, version_id FROM sessions ORDER BY id DESC LIMIT 1").
+		Scan(&prev.ID, &startedAt, &prev.VersionID)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+	case err != nil:
+		return Session{}, false, fmt.Errorf("load last session: %w", err)
+	default:
+		prev.StartedAt, _ = timestamp.Parse(startedAt)
+		ok = true
+	}
+	if _, err := s.db.Exec("INSERT INTO sessions (started_at, version_id) VALUES (?, ?)",
+		timestamp.Format(now), versionID); err != nil {
+		return Session{}, false, fmt.Errorf("record session: %w", err)
+	}
+	return prev, ok, nil
+}
+
+// #endregion store
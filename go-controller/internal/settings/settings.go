@@ -0,0 +1,302 @@
+package settings
+
+import (
+	"database/sql"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/timestamp"
+)
+
+// #region types
+
+// Value kinds. A key keeps the kind it was first set with.
+const (
+	KindFloat    = "float"
+	KindInt      = "int"
+	KindBool     = "bool"
+	KindString   = "string"
+	KindDuration = "duration"
+)
+
+// Setting is one runtime-learned parameter. Keys are dotted by owner, e.g.
+// "gate.entropy_cap" or "retrieval.threshold"; Value is the text encoding of
+// Kind (strconv for numbers and bools, time.Duration.String for durations).
+type Setting struct {
+	Key       string
+	Kind      string
+	Value     string
+	Source    string // what set it: the learning mechanism, "operator", ...
+	UpdatedAt time.Time
+}
+
+// Change is one entry in a key's history. OldValue is "" when the key was
+// first set; NewValue is "" when it was reset to its default.
+type Change struct {
+	ID        int64
+	Key       string
+	Kind      string
+	OldValue  string
+	NewValue  string
+	Source    string
+	Reason    string
+	CreatedAt time.Time
+}
+
+// #endregion types
+
+// #region store
+
+// Store persists parameters the controller learns at runtime, apart from
+// operator config (env vars, gate policy file): they survive restarts, and
+// every change is kept with its source and reason.
+type Store struct {
+	db *sql.DB
+}
+
+// NewStore creates the settings tables if needed and returns a store.
+func NewStore(db *sql.DB) (*Store, error) {
+	_, err := db.Exec(`CREATE TABLE IF NOT EXISTS settings (
+		key TEXT PRIMARY KEY,
+		kind TEXT NOT NULL,
+		value TEXT NOT NULL,
+		source TEXT NOT NULL,
+		updated_at TEXT NOT NULL
+	)`)
+	if err != nil {
+		return nil, fmt.Errorf("create settings table: %w", err)
+	}
+	_, err = db.Exec(`CREATE TABLE IF NOT EXISTS settings_history (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		key TEXT NOT NULL,
+		kind TEXT NOT NULL,
+		old_value TEXT NOT NULL DEFAULT '',
+		new_value TEXT NOT NULL DEFAULT '',
+		source TEXT NOT NULL,
+		reason TEXT NOT NULL DEFAULT '',
+		created_at TEXT NOT NULL
+	)`)
+	if err != nil {
+		return nil, fmt.Errorf("create settings_history table: %w", err)
+	}
+	if _, err := timestamp.Canonicalize(db, "settings", "updated_at"); err != nil {
+		return nil, err
+	}
+	if _, err := timestamp.Canonicalize(db, "settings_history", "created_at"); err != nil {
+		return nil, err
+	}
+	return &Store{db: db}, nil
+}
+
+// Get returns the setting for key; ok is false if it is not set.
+func (s *Store) Get(key string) (Setting, bool, error) {
+	st := Setting{Key: key}
+	var ts string
+	err := s.db.QueryRow(`SELECT kind, value, source, updated_at FROM settings WHERE key = ?`, key).
+		Scan(&st.Kind, &st.Value, &st.Source, &ts)
+	if err == sql.ErrNoRows {
+		return Setting{}, false, nil
+	}
+	if err != nil {
+		return Setting{}, false, fmt.Errorf("get setting %s: %w", key, err)
+	}
+	st.UpdatedAt, _ = timestamp.Parse(ts)
+	return st, true, nil
+}
+
+// List returns every setting, by key.
+func (s *Store) List() ([]Setting, error) {
+	rows, err := s.db.Query(`SELECT key, kind, value, source, updated_at FROM settings ORDER BY key`)
+	if err != nil {
+		return nil, fmt.Errorf("list settings: %w", err)
+	}
+	defer rows.Close()
+
+	var out []Setting
+	for rows.Next() {
+		var st Setting
+		var ts string
+		if err := rows.Scan(&st.Key, &st.Kind, &st.Value, &st.Source, &ts); err != nil {
+			return nil, fmt.Errorf("scan setting: %w", err)
+		}
+		st.UpdatedAt, _ = timestamp.Parse(ts)
+		out = append(out, st)
+	}
+	return out, rows.Err()
+}
+
+// set stores the encoded value for key and records the change. Setting the
+// current value again is a no-op; a key cannot change kind.
+func (s *Store) set(key, kind, value, source, reason string) error {
+	if key == "" {
+		return fmt.Errorf("setting key must be non-empty")
+	}
+	old, ok, err := s.Get(key)
+	if err != nil {
+		return err
+	}
+	if ok && old.Kind != kind {
+		return fmt.Errorf("setting %s is a %s, not a %s", key, old.Kind, kind)
+	}
+	if ok && old.Value == value {
+		return nil
+	}
+	now := timestamp.Now()
+	if _, err := s.db.Exec(`INSERT INTO settings (key, kind, value, source, updated_at) VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT(key) DO UPDATE SET value = excluded.value, source = excluded.source, updated_at = excluded.updated_at`,
+		key, kind, value, source, now); err != nil {
+		return fmt.Errorf("set %s: %w", key, err)
+	}
+	return s.logChange(key, kind, old.Value, value, source, reason, now)
+}
+
+// Reset removes key, so readers fall back to their default, and records the
+// change. Resetting an unset key is a no-op.
+func (s *Store) Reset(key, source, reason string) error {
+	old, ok, err := s.Get(key)
+	if err != nil || !ok {
+		return err
+	}
+	now := timestamp.Now()
+	if _, err := s.db.Exec(`DELETE FROM settings WHERE key = ?`, key); err != nil {
+		return fmt.Errorf("reset %s: %w", key, err)
+	}
+	return s.logChange(key, old.Kind, old.Value, "", source, reason, now)
+}
+
+func (s *Store) logChange(key, kind, old, value, source, reason, now string) error {
+	if _, err := s.db.Exec(`INSERT INTO settings_history (key, kind, old_value, new_value, source, reason, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`, key, kind, old, value, source, reason, now); err != nil {
+		return fmt.Errorf("log setting change: %w", err)
+	}
+	return nil
+}
+
+// History returns up to limit changes, newest first. An empty key returns
+// changes to every key.
+func (s *Store) History(key string, limit int) ([]Change, error) {
+	rows, err := s.db.Query(`SELECT id, key, kind, old_value, new_value, source, reason, created_at FROM settings_history
+		WHERE ? = '' OR key = ? ORDER BY id DESC LIMIT ?`, key, key, limit)
+	if err != nil {
+		return nil, fmt.Errorf("settings history: %w", err)
+	}
+	defer rows.Close()
+
+	var out []Change
+	for rows.Next() {
+		var c Change
+		var ts string
+		if err := rows.Scan(&c.ID, &c.Key, &c.Kind, &c.OldValue, &c.NewValue, &c.Source, &c.Reason, &ts); err != nil {
+			return nil, fmt.Errorf("scan setting change: %w", err)
+		}
+		c.CreatedAt, _ = timestamp.Parse(ts)
+		out = append(out, c)
+	}
+	return out, rows.Err()
+}
+
+// #endregion store
+
+// #region typed
+
+// lookup returns the stored value of key if it is set with kind.
+func (s *Store) lookup(key, kind string) (string, bool, error) {
+	st, ok, err := s.Get(key)
+	if err != nil || !ok {
+		return "", false, err
+	}
+	if st.Kind != kind {
+		return "", false, fmt.Errorf("setting %s is a %s, not a %s", key, st.Kind, kind)
+	}
+	return st.Value, true, nil
+}
+
+// Float returns the float setting key, or def if it is unset. On error def is
+// returned with it, so callers can log and carry on.
+func (s *Store) Float(key string, def float64) (float64, error) {
+	v, ok, err := s.lookup(key, KindFloat)
+	if err != nil || !ok {
+		return def, err
+	}
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		return def, fmt.Errorf("setting %s: %w", key, err)
+	}
+	return f, nil
+}
+
+// SetFloat stores a float setting.
+func (s *Store) SetFloat(key string, v float64, source, reason string) error {
+	return s.set(key, KindFloat, strconv.FormatFloat(v, 'g', -1, 64), source, reason)
+}
+
+// Int returns the int setting key, or def if it is unset.
+func (s *Store) Int(key string, def int) (int, error) {
+	v, ok, err := s.lookup(key, KindInt)
+	if err != nil || !ok {
+		return def, err
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return def, fmt.Errorf("setting %s: %w", key, err)
+	}
+	return n, nil
+}
+
+// SetInt stores an int setting.
+func (s *Store) SetInt(key string, v int, source, reason string) error {
+	return s.set(key, KindInt, strconv.Itoa(v), source, reason)
+}
+
+// Bool returns the bool setting key, or def if it is unset.
+func (s *Store) Bool(key string, def bool) (bool, error) {
+	v, ok, err := s.lookup(key, KindBool)
+	if err != nil || !ok {
+		return def, err
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		return def, fmt.Errorf("setting %s: %w", key, err)
+	}
+	return b, nil
+}
+
+// SetBool stores a bool setting.
+func (s *Store) SetBool(key string, v bool, source, reason string) error {
+	return s.set(key, KindBool, strconv.FormatBool(v), source, reason)
+}
+
+// String returns the string setting key, or def if it is unset.
+func (s *Store) String(key, def string) (string, error) {
+	v, ok, err := s.lookup(key, KindString)
+	if err != nil || !ok {
+		return def, err
+	}
+	return v, nil
+}
+
+// SetString stores a string setting.
+func (s *Store) SetString(key, v, source, reason string) error {
+	return s.set(key, KindString, v, source, reason)
+}
+
+// Duration returns the duration setting key, or def if it is unset.
+func (s *Store) Duration(key string, def time.Duration) (time.Duration, error) {
+	v, ok, err := s.lookup(key, KindDuration)
+	if err != nil || !ok {
+		return def, err
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		return def, fmt.Errorf("setting %s: %w", key, err)
+	}
+	return d, nil
+}
+
+// SetDuration stores a duration setting.
+func (s *Store) SetDuration(key string, v time.Duration, source, reason string) error {
+	return s.set(key, KindDuration, v.String(), source, reason)
+}
+
+// #endregion typed
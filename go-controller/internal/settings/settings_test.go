@@ -0,0 +1,108 @@
+package settings
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+func testStore(t *testing.T) *Store {
+	t.Helper()
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatalf("open test db: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	s, err := NewStore(db)
+	if err != nil {
+		t.Fatalf("new store: %v", err)
+	}
+	return s
+}
+
+func TestTypedRoundTrip(t *testing.T) {
+	s := testStore(t)
+	if v, err := s.Float("gate.entropy_cap", 1.5); err != nil || v != 1.5 {
+		t.Fatalf("unset float = %v, %v; want default", v, err)
+	}
+	must := func(err error) {
+		t.Helper()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	must(s.SetFloat("gate.entropy_cap", 2.25, "calibration", ""))
+	must(s.SetInt("retrieval.top_k", 7, "operator", ""))
+	must(s.SetBool("gate.adaptive", true, "operator", ""))
+	must(s.SetString("codec.model", "small", "operator", ""))
+	must(s.SetDuration("decay.interval", 90*time.Minute, "operator", ""))
+
+	if v, _ := s.Float("gate.entropy_cap", 0); v != 2.25 {
+		t.Errorf("float = %v", v)
+	}
+	if v, _ := s.Int("retrieval.top_k", 0); v != 7 {
+		t.Errorf("int = %v", v)
+	}
+	if v, _ := s.Bool("gate.adaptive", false); !v {
+		t.Errorf("bool = %v", v)
+	}
+	if v, _ := s.String("codec.model", ""); v != "small" {
+		t.Errorf("string = %q", v)
+	}
+	if v, _ := s.Duration("decay.interval", 0); v != 90*time.Minute {
+		t.Errorf("duration = %v", v)
+	}
+	list, err := s.List()
+	if err != nil || len(list) != 5 || list[0].Key != "codec.model" {
+		t.Errorf("list = %+v, %v", list, err)
+	}
+}
+
+func TestKindMismatch(t *testing.T) {
+	s := testStore(t)
+	if err := s.SetFloat("retrieval.threshold", 0.4, "calibration", ""); err != nil {
+		t.Fatal(err)
+	}
+	if v, err := s.Int("retrieval.threshold", 3); err == nil || v != 3 {
+		t.Errorf("Int on a float = %v, %v; want default and error", v, err)
+	}
+	if err := s.SetString("retrieval.threshold", "high", "operator", ""); err == nil {
+		t.Error("changing a key's kind should fail")
+	}
+}
+
+func TestHistoryAndReset(t *testing.T) {
+	s := testStore(t)
+	s.SetFloat("gate.entropy_cap", 2, "calibration", "initial fit")
+	s.SetFloat("gate.entropy_cap", 2, "calibration", "same value")
+	s.SetFloat("gate.entropy_cap", 2.5, "calibration", "refit on 200 turns")
+	s.SetInt("retrieval.top_k", 5, "operator", "")
+	if err := s.Reset("gate.entropy_cap", "operator", "revert"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Reset("gate.entropy_cap", "operator", "again"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok, _ := s.Get("gate.entropy_cap"); ok {
+		t.Error("reset key still set")
+	}
+
+	h, err := s.History("gate.entropy_cap", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(h) != 3 {
+		t.Fatalf("history = %+v, want 3 changes", h)
+	}
+	if h[0].OldValue != "2.5" || h[0].NewValue != "" || h[0].Reason != "revert" {
+		t.Errorf("reset entry = %+v", h[0])
+	}
+	if h[1].OldValue != "2" || h[1].NewValue != "2.5" || h[2].OldValue != "" || h[2].Source != "calibration" {
+		t.Errorf("history = %+v", h)
+	}
+	if all, _ := s.History("", 10); len(all) != 4 || all[1].Key != "retrieval.top_k" {
+		t.Errorf("all history = %+v", all)
+	}
+}
@@ -0,0 +1,168 @@
+package signals
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/update"
+)
+
+// #region external-types
+
+// ExternalType is the kind of observation reported by a local tool.
+type ExternalType string
+
+const (
+	ExternalBuildFailed         ExternalType = "build_failed"
+	ExternalBuildPassed         ExternalType = "build_passed"
+	ExternalTestsFailed         ExternalType = "tests_failed"
+	ExternalTestsPassed         ExternalType = "tests_passed"
+	ExternalConstraintViolation ExternalType = "constraint_violation"
+)
+
+// validExternalTypes is the accepted set; anything else is rejected at ingestion.
+var validExternalTypes = map[ExternalType]bool{
+	ExternalBuildFailed:         true,
+	ExternalBuildPassed:         true,
+	ExternalTestsFailed:         true,
+	ExternalTestsPassed:         true,
+	ExternalConstraintViolation: true,
+}
+
+// ExternalSignal is an observation from a local tool (editor plugin, shell wrapper).
+// External signals never trigger a turn; they are queued and folded into the next one.
+type ExternalSignal struct {
+	Type       ExternalType `json:"type"`
+	Origin     string       `json:"origin"`           // reporting tool, e.g. "vscode", "zsh-hook"
+	Detail     string       `json:"detail,omitempty"` // free text, e.g. the failing command
+	ReceivedAt time.Time    `json:"received_at"`
+}
+
+// #endregion external-types
+
+// #region external-queue
+
+// maxExternalQueue caps pending signals between turns; the oldest are dropped first.
+const maxExternalQueue = 100
+
+// ExternalQueue buffers external signals until the next turn drains them. Safe for concurrent use.
+type ExternalQueue struct {
+	mu      sync.Mutex
+	pending []ExternalSignal
+}
+
+// NewExternalQueue returns an empty queue.
+func NewExternalQueue() *ExternalQueue {
+	return &ExternalQueue{}
+}
+
+// Push validates and enqueues a signal, stamping ReceivedAt if unset.
+func (q *ExternalQueue) Push(sig ExternalSignal) error {
+	if !validExternalTypes[sig.Type] {
+		return fmt.Errorf("unknown external signal type %q", sig.Type)
+	}
+	sig.Origin = strings.TrimSpace(sig.Origin)
+	if sig.Origin == "" {
+		return fmt.Errorf("external signal origin must be non-empty")
+	}
+	if sig.ReceivedAt.IsZero() {
+		sig.ReceivedAt = time.Now().UTC()
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.pending = append(q.pending, sig)
+	if len(q.pending) > maxExternalQueue {
+		q.pending = q.pending[len(q.pending)-maxExternalQueue:]
+	}
+	return nil
+}
+
+// Drain returns all pending signals oldest-first and empties the queue.
+func (q *ExternalQueue) Drain() []ExternalSignal {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	out := q.pending
+	q.pending = nil
+	return out
+}
+
+// #endregion external-queue
+
+// #region external-apply
+
+// ApplyExternal folds drained external signals into the turn's signals.
+// Build/test failures set ToolFailure; constraint_violation sets ConstraintViolation.
+// Passing results are observation-only and recorded for provenance without changing flags.
+func ApplyExternal(sigs *update.Signals, external []ExternalSignal) {
+	for _, e := range external {
+		switch e.Type {
+		case ExternalBuildFailed, ExternalTestsFailed:
+			sigs.ToolFailure = true
+		case ExternalConstraintViolation:
+			sigs.ConstraintViolation = true
+		}
+	}
+}
+
+// #endregion external-apply
+
+// #region external-server
+
+// ListenExternal opens the ingestion listener. addr is either "unix:/path/to.sock"
+// or a host:port that must resolve to a loopback address — the endpoint is local-only.
+func ListenExternal(addr string) (net.Listener, error) {
+	if path, ok := strings.CutPrefix(addr, "unix:"); ok {
+		_ = os.Remove(path) // stale socket from a previous run
+		ln, err := net.Listen("unix", path)
+		if err != nil {
+			return nil, fmt.Errorf("listen unix %s: %w", path, err)
+		}
+		return ln, nil
+	}
+
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, fmt.Errorf("parse external signals addr: %w", err)
+	}
+	if ip := net.ParseIP(host); host != "localhost" && (ip == nil || !ip.IsLoopback()) {
+		return nil, fmt.Errorf("external signals addr %q is not loopback", addr)
+	}
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("listen tcp %s: %w", addr, err)
+	}
+	return ln, nil
+}
+
+// ExternalHandler serves POST /signals with a JSON ExternalSignal body.
+// Responds 202 when queued, 400 on invalid input, 405 on other methods.
+func ExternalHandler(q *ExternalQueue) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/signals", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		var sig ExternalSignal
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 16*1024)).Decode(&sig); err != nil {
+			http.Error(w, fmt.Sprintf("decode signal: %v", err), http.StatusBadRequest)
+			return
+		}
+		sig.ReceivedAt = time.Time{} // server clock is authoritative
+		if err := q.Push(sig); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusAccepted)
+	})
+	return mux
+}
+
+// #endregion external-server
@@ -0,0 +1,110 @@
+package signals
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/update"
+)
+
+// #region external-queue-tests
+
+func TestExternalQueue_PushDrain(t *testing.T) {
+	q := NewExternalQueue()
+	if err := q.Push(ExternalSignal{Type: ExternalTestsFailed, Origin: "zsh-hook"}); err != nil {
+		t.Fatalf("push: %v", err)
+	}
+	if err := q.Push(ExternalSignal{Type: "deploy_exploded", Origin: "x"}); err == nil {
+		t.Error("expected unknown type to be rejected")
+	}
+	if err := q.Push(ExternalSignal{Type: ExternalBuildPassed, Origin: "  "}); err == nil {
+		t.Error("expected empty origin to be rejected")
+	}
+
+	got := q.Drain()
+	if len(got) != 1 || got[0].Origin != "zsh-hook" || got[0].ReceivedAt.IsZero() {
+		t.Fatalf("unexpected drain: %+v", got)
+	}
+	if len(q.Drain()) != 0 {
+		t.Error("expected queue to be empty after drain")
+	}
+}
+
+func TestExternalQueue_DropsOldest(t *testing.T) {
+	q := NewExternalQueue()
+	for i := 0; i < maxExternalQueue+5; i++ {
+		q.Push(ExternalSignal{Type: ExternalBuildPassed, Origin: "editor"})
+	}
+	if n := len(q.Drain()); n != maxExternalQueue {
+		t.Errorf("expected %d pending, got %d", maxExternalQueue, n)
+	}
+}
+
+// #endregion external-queue-tests
+
+// #region external-apply-tests
+
+func TestApplyExternal(t *testing.T) {
+	var sigs update.Signals
+	ApplyExternal(&sigs, []ExternalSignal{{Type: ExternalTestsPassed}, {Type: ExternalBuildPassed}})
+	if sigs.ToolFailure || sigs.ConstraintViolation {
+		t.Fatalf("expected passing results to leave flags unset, got %+v", sigs)
+	}
+
+	ApplyExternal(&sigs, []ExternalSignal{{Type: ExternalBuildFailed}, {Type: ExternalConstraintViolation}})
+	if !sigs.ToolFailure || !sigs.ConstraintViolation {
+		t.Fatalf("expected failure flags set, got %+v", sigs)
+	}
+}
+
+// #endregion external-apply-tests
+
+// #region external-server-tests
+
+func TestExternalHandler(t *testing.T) {
+	q := NewExternalQueue()
+	srv := httptest.NewServer(ExternalHandler(q))
+	defer srv.Close()
+
+	resp, err := http.Post(srv.URL+"/signals", "application/json",
+		strings.NewReader(`{"type":"build_failed","origin":"vscode","detail":"go build ./..."}`))
+	if err != nil {
+		t.Fatalf("post: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusAccepted {
+		t.Fatalf("expected 202, got %d", resp.StatusCode)
+	}
+
+	resp, _ = http.Post(srv.URL+"/signals", "application/json", strings.NewReader(`{"type":"nope","origin":"vscode"}`))
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected 400 for unknown type, got %d", resp.StatusCode)
+	}
+
+	resp, _ = http.Get(srv.URL + "/signals")
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusMethodNotAllowed {
+		t.Errorf("expected 405 for GET, got %d", resp.StatusCode)
+	}
+
+	got := q.Drain()
+	if len(got) != 1 || got[0].Detail != "go build ./..." {
+		t.Fatalf("unexpected queued signals: %+v", got)
+	}
+}
+
+func TestListenExternal_RejectsNonLoopback(t *testing.T) {
+	if _, err := ListenExternal("0.0.0.0:0"); err == nil {
+		t.Fatal("expected non-loopback addr to be rejected")
+	}
+	ln, err := ListenExternal("127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen loopback: %v", err)
+	}
+	ln.Close()
+}
+
+// #endregion external-server-tests
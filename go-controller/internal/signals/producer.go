@@ -68,6 +68,13 @@ func (p *Producer) coherenceScore(ctx context.Context, input ProduceInput) float
 	if p.embedder == nil {
 		return 0
 	}
+	if batch, ok := p.embedder.(BatchEmbedder); ok {
+		vecs, err := batch.EmbedBatch(ctx, []string{input.Prompt, input.ResponseText})
+		if err != nil || len(vecs) != 2 {
+			return 0
+		}
+		return clamp(cosineSimilarity(vecs[0], vecs[1]))
+	}
 	promptEmb, err := p.embedder.Embed(ctx, input.Prompt)
 	if err != nil {
 		return 0
@@ -27,6 +27,29 @@ func (m *mockEmbedder) Embed(_ context.Context, text string) ([]float32, error)
 	return nil, errors.New("no embedding for: " + text)
 }
 
+// mockBatchEmbedder counts EmbedBatch calls; Embed must not be used.
+type mockBatchEmbedder struct {
+	mockEmbedder
+	batchCalls int
+}
+
+func (m *mockBatchEmbedder) Embed(context.Context, string) ([]float32, error) {
+	return nil, errors.New("Embed called on a batch embedder")
+}
+
+func (m *mockBatchEmbedder) EmbedBatch(ctx context.Context, texts []string) ([][]float32, error) {
+	m.batchCalls++
+	out := make([][]float32, len(texts))
+	for i, t := range texts {
+		emb, err := m.mockEmbedder.Embed(ctx, t)
+		if err != nil {
+			return nil, err
+		}
+		out[i] = emb
+	}
+	return out, nil
+}
+
 // #endregion mock
 
 // #region sentiment-tests
@@ -142,6 +165,23 @@ func TestCoherenceScore_NilEmbedder(t *testing.T) {
 	}
 }
 
+func TestCoherenceScore_BatchEmbedder(t *testing.T) {
+	emb := &mockBatchEmbedder{mockEmbedder: mockEmbedder{embeddings: map[string][]float32{
+		"hello": {1, 0, 0},
+		"hi":    {0.9, 0.1, 0},
+	}}}
+	p := NewProducer(emb, DefaultProducerConfig())
+	score := p.coherenceScore(context.Background(), ProduceInput{
+		Prompt: "hello", ResponseText: "hi",
+	})
+	if score < 0.9 {
+		t.Errorf("expected high coherence for similar texts, got %f", score)
+	}
+	if emb.batchCalls != 1 {
+		t.Errorf("expected prompt and response in one EmbedBatch call, got %d calls", emb.batchCalls)
+	}
+}
+
 // #endregion coherence-tests
 
 // #region novelty-tests
@@ -13,6 +13,13 @@ type Embedder interface {
 	Embed(ctx context.Context, text string) ([]float32, error)
 }
 
+// BatchEmbedder is an Embedder that can embed several texts in one call.
+// Producer uses it when available to embed prompt and response together.
+type BatchEmbedder interface {
+	Embedder
+	EmbedBatch(ctx context.Context, texts []string) ([][]float32, error)
+}
+
 // #endregion embedder-interface
 
 // #region config
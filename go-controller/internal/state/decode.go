@@ -0,0 +1,138 @@
+package state
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/timestamp"
+)
+
+// #region decode-mode
+// DecodeMode selects how rows read from state_versions are validated. The
+// database is untrusted input: it may be corrupted, hand-edited or written by
+// another tool.
+type DecodeMode int
+
+const (
+	// DecodeStrict rejects a malformed row with an error wrapping ErrCorruptState:
+	// a vector blob that is not exactly 512 bytes or holds NaN/Inf, a segment map
+	// with unknown keys or out-of-range/overlapping segments, or an unparseable
+	// created_at. The default.
+	DecodeStrict DecodeMode = iota
+	// DecodeLenient keeps the historical behaviour for salvaging damaged
+	// databases: short blobs are zero-filled, extra bytes and bad timestamps are
+	// ignored, and only malformed segment-map JSON is an error.
+	DecodeLenient
+)
+
+// ErrCorruptState marks stored state that failed validation.
+var ErrCorruptState = errors.New("corrupt state data")
+
+// VectorBytes is the encoded size of a state vector: 128 little-endian float32s.
+const VectorBytes = 128 * 4
+
+// SetDecodeMode switches how the store validates rows it reads.
+func (s *Store) SetDecodeMode(mode DecodeMode) {
+	s.mode = mode
+}
+
+// #endregion decode-mode
+
+// #region strict-decoders
+// DecodeVector decodes an encoded state vector, requiring exactly VectorBytes
+// bytes of finite values.
+func DecodeVector(b []byte) ([128]float32, error) {
+	var v [128]float32
+	if len(b) != VectorBytes {
+		return v, fmt.Errorf("%w: state vector is %d bytes, want %d", ErrCorruptState, len(b), VectorBytes)
+	}
+	for i := range v {
+		v[i] = math.Float32frombits(binary.LittleEndian.Uint32(b[i*4:]))
+	}
+	if err := ValidateVector(v); err != nil {
+		return [128]float32{}, err
+	}
+	return v, nil
+}
+
+// ValidateVector rejects vectors holding NaN or infinite values.
+func ValidateVector(v [128]float32) error {
+	for i, f := range v {
+		if math.IsNaN(float64(f)) || math.IsInf(float64(f), 0) {
+			return fmt.Errorf("%w: state vector[%d] is %v", ErrCorruptState, i, f)
+		}
+	}
+	return nil
+}
+
+// ParseSegmentMap decodes segment-map JSON, rejecting unknown keys, trailing
+// data and invalid ranges.
+func ParseSegmentMap(data []byte) (SegmentMap, error) {
+	var m SegmentMap
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&m); err != nil {
+		return SegmentMap{}, fmt.Errorf("%w: segment map: %v", ErrCorruptState, err)
+	}
+	if dec.More() {
+		return SegmentMap{}, fmt.Errorf("%w: segment map: trailing data", ErrCorruptState)
+	}
+	if err := m.Validate(); err != nil {
+		return SegmentMap{}, err
+	}
+	return m, nil
+}
+
+// Validate checks that every segment is a non-empty [start, end) range inside
+// the 128-dimensional vector and that no two segments overlap.
+func (m SegmentMap) Validate() error {
+	names := []string{"prefs", "goals", "heuristics", "risk"}
+	ranges := [][2]int{m.Prefs, m.Goals, m.Heuristics, m.Risk}
+	for i, r := range ranges {
+		if r[0] < 0 || r[1] > 128 || r[0] >= r[1] {
+			return fmt.Errorf("%w: segment %s range [%d, %d) outside [0, 128)", ErrCorruptState, names[i], r[0], r[1])
+		}
+		for j := 0; j < i; j++ {
+			if o := ranges[j]; r[0] < o[1] && o[0] < r[1] {
+				return fmt.Errorf("%w: segments %s and %s overlap", ErrCorruptState, names[j], names[i])
+			}
+		}
+	}
+	return nil
+}
+
+// #endregion strict-decoders
+
+// #region row-decoding
+// decodeRow decodes the stored columns of one state_versions row under mode.
+func decodeRow(mode DecodeMode, rec *StateRecord, vecBlob []byte, segJSON, createdStr string) error {
+	if mode == DecodeLenient {
+		rec.StateVector = decodeVector(vecBlob)
+		if err := json.Unmarshal([]byte(segJSON), &rec.SegmentMap); err != nil {
+			return fmt.Errorf("unmarshal segment map: %w", err)
+		}
+		rec.CreatedAt, _ = timestamp.Parse(createdStr)
+		return nil
+	}
+
+	vec, err := DecodeVector(vecBlob)
+	if err != nil {
+		return fmt.Errorf("version %s: %w", rec.VersionID, err)
+	}
+	seg, err := ParseSegmentMap([]byte(segJSON))
+	if err != nil {
+		return fmt.Errorf("version %s: %w", rec.VersionID, err)
+	}
+	created, err := timestamp.Parse(createdStr)
+	if err != nil {
+		return fmt.Errorf("version %s: %w: created_at %q", rec.VersionID, ErrCorruptState, createdStr)
+	}
+	rec.StateVector, rec.SegmentMap, rec.CreatedAt = vec, seg, created
+	return nil
+}
+
+// #endregion row-decoding
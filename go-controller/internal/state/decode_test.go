@@ -0,0 +1,125 @@
+package state
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"math"
+	"testing"
+	"time"
+)
+
+// #region decode-tests
+
+func TestDecodeVector_Strict(t *testing.T) {
+	var v [128]float32
+	v[3] = 0.25
+	if got, err := DecodeVector(encodeVector(v)); err != nil || got != v {
+		t.Fatalf("round trip failed: %v", err)
+	}
+
+	nan := encodeVector(v)
+	binary.LittleEndian.PutUint32(nan[8:], math.Float32bits(float32(math.NaN())))
+	inf := encodeVector(v)
+	binary.LittleEndian.PutUint32(inf[0:], math.Float32bits(float32(math.Inf(1))))
+	for name, blob := range map[string][]byte{
+		"empty":    nil,
+		"short":    encodeVector(v)[:VectorBytes-4],
+		"long":     append(encodeVector(v), 0, 0, 0, 0),
+		"NaN":      nan,
+		"infinite": inf,
+	} {
+		if _, err := DecodeVector(blob); !errors.Is(err, ErrCorruptState) {
+			t.Errorf("%s: expected ErrCorruptState, got %v", name, err)
+		}
+	}
+}
+
+func TestParseSegmentMap(t *testing.T) {
+	if m, err := ParseSegmentMap([]byte(`{"prefs":[0,32],"goals":[32,64],"heuristics":[64,96],"risk":[96,128]}`)); err != nil || m != DefaultSegmentMap() {
+		t.Fatalf("default map rejected: %v", err)
+	}
+	for _, bad := range []string{
+		`not-json`,
+		`{"prefs":[0,32],"goals":[32,64],"heuristics":[64,96],"risk":[96,128],"mood":[0,1]}`, // unknown key
+		`{"prefs":[0,32],"goals":[32,64],"heuristics":[64,96]}`,                              // risk missing: [0,0)
+		`{"prefs":[0,40],"goals":[32,64],"heuristics":[64,96],"risk":[96,128]}`,              // overlap
+		`{"prefs":[0,32],"goals":[32,64],"heuristics":[64,96],"risk":[96,200]}`,              // out of range
+		`{"prefs":[-4,32],"goals":[32,64],"heuristics":[64,96],"risk":[96,128]}`,
+		`{"prefs":[0,32],"goals":[32,64],"heuristics":[64,96],"risk":[96,128]} {}`, // trailing data
+	} {
+		if _, err := ParseSegmentMap([]byte(bad)); !errors.Is(err, ErrCorruptState) {
+			t.Errorf("%s: expected ErrCorruptState, got %v", bad, err)
+		}
+	}
+}
+
+func TestStore_DecodeModes(t *testing.T) {
+	s, db := corruptDB(t)
+	segJSON := `{"prefs":[0,32],"goals":[32,64],"heuristics":[64,96],"risk":[96,128]}`
+	now := time.Now().UTC().Format(time.RFC3339Nano)
+	if _, err := db.Exec(`INSERT INTO state_versions (version_id, parent_id, state_vector, segment_map, created_at)
+		VALUES ('short', NULL, X'0000803F', ?, ?)`, segJSON, now); err != nil {
+		t.Fatalf("insert: %v", err)
+	}
+
+	if _, err := s.GetVersion("short"); !errors.Is(err, ErrCorruptState) {
+		t.Fatalf("strict mode should reject a 4-byte vector, got %v", err)
+	}
+	if _, err := s.ListVersions(10); !errors.Is(err, ErrCorruptState) {
+		t.Errorf("ListVersions should surface the corrupt row, got %v", err)
+	}
+	if _, err := s.SimilarityIndex().Search(SimilarityQuery{Vector: [128]float32{1}, K: 1}); !errors.Is(err, ErrCorruptState) {
+		t.Errorf("Search should surface the corrupt row, got %v", err)
+	}
+
+	s.SetDecodeMode(DecodeLenient)
+	rec, err := s.GetVersion("short")
+	if err != nil {
+		t.Fatalf("lenient mode should salvage the row: %v", err)
+	}
+	if rec.StateVector[0] != 1 || rec.StateVector[1] != 0 {
+		t.Errorf("lenient decode should zero-fill after the stored value, got %v", rec.StateVector[:2])
+	}
+}
+
+func FuzzDecodeVector(f *testing.F) {
+	var v [128]float32
+	v[0], v[127] = 1, -2.5
+	f.Add(encodeVector(v))
+	f.Add([]byte{})
+	f.Add([]byte{0, 0, 0x80, 0x7f})
+	f.Fuzz(func(t *testing.T, b []byte) {
+		lenient := decodeVector(b) // must never panic
+		got, err := DecodeVector(b)
+		if err != nil {
+			return
+		}
+		if !bytes.Equal(encodeVector(got), b) || got != lenient {
+			t.Fatalf("accepted vector does not round-trip")
+		}
+	})
+}
+
+func FuzzParseSegmentMap(f *testing.F) {
+	seed, _ := json.Marshal(DefaultSegmentMap())
+	f.Add(seed)
+	f.Add([]byte(`{"prefs":[0,128]}`))
+	f.Add([]byte(`{"prefs":[1e9,2]}`))
+	f.Fuzz(func(t *testing.T, data []byte) {
+		m, err := ParseSegmentMap(data)
+		if err != nil {
+			return
+		}
+		if err := m.Validate(); err != nil {
+			t.Fatalf("accepted map fails Validate: %v", err)
+		}
+		out, _ := json.Marshal(m)
+		if again, err := ParseSegmentMap(out); err != nil || again != m {
+			t.Fatalf("accepted map does not round-trip: %v", err)
+		}
+	})
+}
+
+// #endregion decode-tests
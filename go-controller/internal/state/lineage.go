@@ -0,0 +1,93 @@
+package state
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/timestamp"
+)
+
+// #region lineage
+
+// LineageEntry is a state version with the provenance row that created it:
+// the first logged against it, since later rows (gate rejects, pins,
+// compaction) only reference the version while it was active. The initial
+// version has none.
+type LineageEntry struct {
+	VersionWithProvenance
+	TriggerType string
+}
+
+// ActiveMove is one move of the active pointer other than a commit: an eval
+// rollback, /rollback, or keeping a branch.
+type ActiveMove struct {
+	From      string // "" if there was no active version
+	To        string
+	CreatedAt time.Time
+}
+
+// ListLineage returns the most recent limit versions, newest first, each with
+// its creating provenance row.
+func (s *Store) ListLineage(limit int) ([]LineageEntry, error) {
+	rows, err := s.db.Query(
+		`SELECT sv.version_id, sv.parent_id, sv.state_vector, sv.segment_map, sv.created_at, sv.metrics_json,
+		        pl.trigger_type, pl.decision, pl.reason, pl.signals_json
+		 FROM state_versions sv
+		 LEFT JOIN provenance_log pl ON sv.parent_id IS NOT NULL AND pl.id = (SELECT MIN(id) FROM provenance_log WHERE version_id = sv.version_id)
+		 ORDER BY sv.created_at DESC
+		 LIMIT ?`, limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("list lineage: %w", err)
+	}
+	defer rows.Close()
+
+	var out []LineageEntry
+	for rows.Next() {
+		var e LineageEntry
+		var parentID, metricsJSON, trigger, decision, reason, signalsJSON sql.NullString
+		var vecBlob []byte
+		var segJSON, createdStr string
+		if err := rows.Scan(&e.VersionID, &parentID, &vecBlob, &segJSON, &createdStr, &metricsJSON,
+			&trigger, &decision, &reason, &signalsJSON); err != nil {
+			return nil, fmt.Errorf("scan row: %w", err)
+		}
+		if err := decodeRow(s.mode, &e.StateRecord, vecBlob, segJSON, createdStr); err != nil {
+			return nil, err
+		}
+		e.ParentID = parentID.String
+		e.MetricsJSON = metricsJSON.String
+		e.TriggerType = trigger.String
+		e.Decision = decision.String
+		e.Reason = reason.String
+		e.SignalsJSON = signalsJSON.String
+		out = append(out, e)
+	}
+	return out, rows.Err()
+}
+
+// ListActiveMoves returns the recorded active pointer moves, oldest first.
+func (s *Store) ListActiveMoves() ([]ActiveMove, error) {
+	rows, err := s.db.Query(`SELECT from_version, to_version, created_at FROM active_moves ORDER BY id`)
+	if err != nil {
+		return nil, fmt.Errorf("list active moves: %w", err)
+	}
+	defer rows.Close()
+
+	var out []ActiveMove
+	for rows.Next() {
+		var m ActiveMove
+		var from sql.NullString
+		var ts string
+		if err := rows.Scan(&from, &m.To, &ts); err != nil {
+			return nil, fmt.Errorf("scan active move: %w", err)
+		}
+		m.From = from.String
+		m.CreatedAt, _ = timestamp.Parse(ts)
+		out = append(out, m)
+	}
+	return out, rows.Err()
+}
+
+// #endregion lineage
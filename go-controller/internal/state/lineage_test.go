@@ -0,0 +1,48 @@
+package state
+
+import (
+	"testing"
+	"time"
+)
+
+func TestListLineageAndActiveMoves(t *testing.T) {
+	s := tempDB(t)
+	seg := DefaultSegmentMap()
+	v1, err := s.CreateInitialState(seg)
+	if err != nil {
+		t.Fatalf("CreateInitialState: %v", err)
+	}
+	// The initial version's rows (a gate reject while it was active) are not its creation
+	seedProvenance(t, s.DB(), v1.VersionID, "reject", "gate: too large", "")
+	v2 := StateRecord{VersionID: "v2", ParentID: v1.VersionID, SegmentMap: seg, CreatedAt: v1.CreatedAt.Add(time.Second)}
+	if err := s.CommitState(v2); err != nil {
+		t.Fatalf("CommitState: %v", err)
+	}
+	seedProvenance(t, s.DB(), "v2", "commit", "gate: ok", "")
+	seedProvenance(t, s.DB(), "v2", "no_op", "later turn", "")
+	if err := s.Rollback(v1.VersionID); err != nil {
+		t.Fatalf("Rollback: %v", err)
+	}
+
+	entries, err := s.ListLineage(10)
+	if err != nil {
+		t.Fatalf("ListLineage: %v", err)
+	}
+	if len(entries) != 2 || entries[0].VersionID != "v2" || entries[1].VersionID != v1.VersionID {
+		t.Fatalf("entries = %+v", entries)
+	}
+	if e := entries[0]; e.ParentID != v1.VersionID || e.Decision != "commit" || e.TriggerType != "user_turn" || e.Reason != "gate: ok" {
+		t.Errorf("v2 entry = %+v", e)
+	}
+	if e := entries[1]; e.Decision != "" || e.TriggerType != "" {
+		t.Errorf("initial entry carries a provenance row: %+v", e)
+	}
+
+	moves, err := s.ListActiveMoves()
+	if err != nil {
+		t.Fatalf("ListActiveMoves: %v", err)
+	}
+	if len(moves) != 1 || moves[0].From != "v2" || moves[0].To != v1.VersionID || moves[0].CreatedAt.IsZero() {
+		t.Errorf("moves = %+v", moves)
+	}
+}
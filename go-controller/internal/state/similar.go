@@ -0,0 +1,184 @@
+package state
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/vecmath"
+)
+
+// #region similarity-types
+// SimilarityQuery asks for the stored versions whose state is closest to Vector.
+type SimilarityQuery struct {
+	Vector  [128]float32
+	Segment string    // "" compares whole vectors; otherwise "prefs", "goals", "heuristics" or "risk"
+	Before  time.Time // only versions created before this (zero = no bound)
+	K       int       // maximum results
+}
+
+// SimilarVersion is a stored version and its cosine similarity to the query.
+type SimilarVersion struct {
+	StateRecord
+	Similarity float64
+}
+
+// VectorIndex answers nearest-state queries over committed versions.
+// BruteForceIndex scans state_versions; an approximate index can replace it
+// behind the same interface once histories grow large.
+type VectorIndex interface {
+	Search(q SimilarityQuery) ([]SimilarVersion, error)
+}
+
+// #endregion similarity-types
+
+// #region brute-force-index
+// BruteForceIndex computes cosine similarity against every stored version.
+type BruteForceIndex struct {
+	db   DBTX
+	mode DecodeMode
+}
+
+// NewBruteForceIndex creates an index that reads state_versions through q,
+// decoding rows strictly.
+func NewBruteForceIndex(q DBTX) *BruteForceIndex {
+	return &BruteForceIndex{db: q}
+}
+
+// SimilarityIndex returns the store's vector index, decoding rows under the
+// store's DecodeMode.
+func (s *Store) SimilarityIndex() VectorIndex {
+	return &BruteForceIndex{db: s.db, mode: s.mode}
+}
+
+// Search returns up to q.K versions ordered by descending similarity.
+// Versions that are zero over the compared range are skipped.
+func (x *BruteForceIndex) Search(q SimilarityQuery) ([]SimilarVersion, error) {
+	if q.K <= 0 {
+		return nil, nil
+	}
+	rows, err := x.db.Query(
+		`SELECT version_id, parent_id, state_vector, segment_map, created_at, metrics_json
+		 FROM state_versions`,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("similarity search: %w", err)
+	}
+	defer rows.Close()
+
+	var matches []SimilarVersion
+	for rows.Next() {
+		var rec StateRecord
+		var parentID sql.NullString
+		var vecBlob []byte
+		var segJSON string
+		var createdStr string
+		var metricsJSON sql.NullString
+
+		if err := rows.Scan(&rec.VersionID, &parentID, &vecBlob, &segJSON, &createdStr, &metricsJSON); err != nil {
+			return nil, fmt.Errorf("scan row: %w", err)
+		}
+		if err := decodeRow(x.mode, &rec, vecBlob, segJSON, createdStr); err != nil {
+			return nil, err
+		}
+		// Compared after parsing: RFC3339Nano strings do not sort lexically.
+		if !q.Before.IsZero() && !rec.CreatedAt.Before(q.Before) {
+			continue
+		}
+		bounds := [2]int{0, 128}
+		if q.Segment != "" {
+			b, ok := SegmentRange(rec.SegmentMap, q.Segment)
+			if !ok {
+				return nil, fmt.Errorf("similarity search: unknown segment %q", q.Segment)
+			}
+			bounds = b
+		}
+		if bounds[0] < 0 || bounds[1] > 128 || bounds[0] >= bounds[1] {
+			continue
+		}
+		sim, ok := vecmath.Cosine(q.Vector[bounds[0]:bounds[1]], rec.StateVector[bounds[0]:bounds[1]])
+		if !ok {
+			continue
+		}
+		if parentID.Valid {
+			rec.ParentID = parentID.String
+		}
+		if metricsJSON.Valid {
+			rec.MetricsJSON = metricsJSON.String
+		}
+		matches = append(matches, SimilarVersion{StateRecord: rec, Similarity: sim})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].Similarity != matches[j].Similarity {
+			return matches[i].Similarity > matches[j].Similarity
+		}
+		return matches[i].CreatedAt.After(matches[j].CreatedAt)
+	})
+	if len(matches) > q.K {
+		matches = matches[:q.K]
+	}
+	return matches, nil
+}
+
+// SegmentRange returns the [start, end) bounds of the named segment.
+func SegmentRange(m SegmentMap, name string) ([2]int, bool) {
+	switch name {
+	case "prefs":
+		return m.Prefs, true
+	case "goals":
+		return m.Goals, true
+	case "heuristics":
+		return m.Heuristics, true
+	case "risk":
+		return m.Risk, true
+	}
+	return [2]int{}, false
+}
+
+// #endregion brute-force-index
+
+// #region similar-periods
+// DefaultPeriodGap is the largest gap between matching versions that still
+// counts as one historical period.
+const DefaultPeriodGap = time.Hour
+
+// SimilarPeriod is a run of matching versions close together in time.
+type SimilarPeriod struct {
+	Start    time.Time
+	End      time.Time
+	Best     float64          // highest similarity in the period
+	Versions []SimilarVersion // in time order
+}
+
+// GroupPeriods merges matches created within gap of each other into periods,
+// ordered by their best similarity.
+func GroupPeriods(matches []SimilarVersion, gap time.Duration) []SimilarPeriod {
+	if len(matches) == 0 {
+		return nil
+	}
+	sorted := append([]SimilarVersion(nil), matches...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].CreatedAt.Before(sorted[j].CreatedAt) })
+
+	var periods []SimilarPeriod
+	for _, m := range sorted {
+		if n := len(periods); n > 0 && m.CreatedAt.Sub(periods[n-1].End) <= gap {
+			p := &periods[n-1]
+			p.End = m.CreatedAt
+			p.Versions = append(p.Versions, m)
+			if m.Similarity > p.Best {
+				p.Best = m.Similarity
+			}
+			continue
+		}
+		periods = append(periods, SimilarPeriod{Start: m.CreatedAt, End: m.CreatedAt, Best: m.Similarity, Versions: []SimilarVersion{m}})
+	}
+	sort.SliceStable(periods, func(i, j int) bool { return periods[i].Best > periods[j].Best })
+	return periods
+}
+
+// #endregion similar-periods
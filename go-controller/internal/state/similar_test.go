@@ -0,0 +1,107 @@
+package state
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+// helper: commit a version with the given active dimensions at a fixed time.
+func commitAt(t *testing.T, s *Store, id string, at time.Time, dims map[int]float32) {
+	t.Helper()
+	rec := StateRecord{VersionID: id, SegmentMap: DefaultSegmentMap(), CreatedAt: at}
+	for i, v := range dims {
+		rec.StateVector[i] = v
+	}
+	if err := s.CommitState(rec); err != nil {
+		t.Fatalf("CommitState %s: %v", id, err)
+	}
+}
+
+// #region search-tests
+
+func TestBruteForceIndex_Search(t *testing.T) {
+	s := tempDB(t)
+	if _, err := s.CreateInitialState(DefaultSegmentMap()); err != nil { // zero vector: never a match
+		t.Fatalf("CreateInitialState: %v", err)
+	}
+	base := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	commitAt(t, s, "prefs-heavy", base, map[int]float32{0: 1, 1: 1})
+	commitAt(t, s, "goals-heavy", base.Add(time.Hour), map[int]float32{40: 1})
+	commitAt(t, s, "mixed", base.Add(2*time.Hour), map[int]float32{0: 1, 40: 1})
+	commitAt(t, s, "recent", base.Add(48*time.Hour), map[int]float32{0: 1, 1: 1})
+
+	var query [128]float32
+	query[0], query[1] = 2, 2
+	got, err := s.SimilarityIndex().Search(SimilarityQuery{Vector: query, Before: base.Add(24 * time.Hour), K: 10})
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	var ids []string
+	for _, m := range got {
+		ids = append(ids, m.VersionID)
+	}
+	if fmt.Sprint(ids) != "[prefs-heavy mixed goals-heavy]" {
+		t.Fatalf("unexpected order %v", ids)
+	}
+	if got[0].Similarity < 0.999 || got[2].Similarity != 0 {
+		t.Errorf("unexpected similarities %.3f / %.3f", got[0].Similarity, got[2].Similarity)
+	}
+
+	// Within the goals segment only the versions with a goals component match.
+	query[40] = 1
+	got, err = s.SimilarityIndex().Search(SimilarityQuery{Vector: query, Segment: "goals", K: 1})
+	if err != nil {
+		t.Fatalf("Search goals: %v", err)
+	}
+	if len(got) != 1 || got[0].VersionID != "mixed" {
+		t.Errorf("expected the most recent goals match, got %+v", got)
+	}
+
+	if _, err := s.SimilarityIndex().Search(SimilarityQuery{Vector: query, Segment: "moods", K: 1}); err == nil {
+		t.Error("expected an error for an unknown segment")
+	}
+}
+
+func TestBruteForceIndex_ZeroQuery(t *testing.T) {
+	s := tempDB(t)
+	commitAt(t, s, "v1", time.Now().UTC(), map[int]float32{0: 1})
+	got, err := NewBruteForceIndex(s.DB()).Search(SimilarityQuery{K: 5})
+	if err != nil || len(got) != 0 {
+		t.Errorf("a zero query should match nothing, got %v %v", got, err)
+	}
+}
+
+// #endregion search-tests
+
+// #region period-tests
+
+func TestGroupPeriods(t *testing.T) {
+	base := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	match := func(id string, offset time.Duration, sim float64) SimilarVersion {
+		return SimilarVersion{StateRecord: StateRecord{VersionID: id, CreatedAt: base.Add(offset)}, Similarity: sim}
+	}
+	periods := GroupPeriods([]SimilarVersion{
+		match("a2", 30*time.Minute, 0.80),
+		match("b1", 26*time.Hour, 0.95),
+		match("a1", 0, 0.70),
+		match("a3", 80*time.Minute, 0.75),
+	}, DefaultPeriodGap)
+
+	if len(periods) != 2 {
+		t.Fatalf("expected 2 periods, got %+v", periods)
+	}
+	if periods[0].Versions[0].VersionID != "b1" || periods[0].Best != 0.95 {
+		t.Errorf("best period should come first, got %+v", periods[0])
+	}
+	a := periods[1]
+	if len(a.Versions) != 3 || a.Versions[0].VersionID != "a1" || a.Best != 0.80 ||
+		!a.Start.Equal(base) || !a.End.Equal(base.Add(80*time.Minute)) {
+		t.Errorf("unexpected chained period %+v", a)
+	}
+	if GroupPeriods(nil, DefaultPeriodGap) != nil {
+		t.Error("expected no periods without matches")
+	}
+}
+
+// #endregion period-tests
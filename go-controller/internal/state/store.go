@@ -8,6 +8,8 @@ import (
 	"math"
 	"time"
 
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/timestamp"
+
 	"github.com/google/uuid"
 	_ "modernc.org/sqlite"
 )
@@ -42,13 +44,21 @@ CREATE TABLE IF NOT EXISTS active_state (
 	version_id    TEXT NOT NULL,
 	FOREIGN KEY (version_id) REFERENCES state_versions(version_id)
 );
+
+CREATE TABLE IF NOT EXISTS active_moves (
+	id            INTEGER PRIMARY KEY AUTOINCREMENT,
+	from_version  TEXT,
+	to_version    TEXT NOT NULL,
+	created_at    TEXT NOT NULL
+);
 `
 // #endregion schema
 
 // #region store-struct
 // Store manages versioned state in SQLite.
 type Store struct {
-	db *sql.DB
+	db   *sql.DB
+	mode DecodeMode
 }
 // #endregion store-struct
 
@@ -59,6 +69,12 @@ func NewStore(dbPath string) (*Store, error) {
 	if err != nil {
 		return nil, fmt.Errorf("open db: %w", err)
 	}
+	return NewStoreFromDB(db)
+}
+
+// NewStoreFromDB runs the pragmas and migrations on an already-open database
+// (e.g. one opened through a driver wrapper) and returns a Store.
+func NewStoreFromDB(db *sql.DB) (*Store, error) {
 	if _, err := db.Exec("PRAGMA journal_mode=WAL"); err != nil {
 		return nil, fmt.Errorf("pragma: %w", err)
 	}
@@ -68,6 +84,11 @@ func NewStore(dbPath string) (*Store, error) {
 	if _, err := db.Exec(schema); err != nil {
 		return nil, fmt.Errorf("migrate: %w", err)
 	}
+	for table, col := range map[string]string{"state_versions": "created_at", "provenance_log": "created_at", "active_moves": "created_at"} {
+		if _, err := timestamp.Canonicalize(db, table, col); err != nil {
+			return nil, fmt.Errorf("migrate: %w", err)
+		}
+	}
 	return &Store{db: db}, nil
 }
 // NewStoreWithDB wraps an existing *sql.DB as a Store (no pragmas/migration).
@@ -91,6 +112,35 @@ func (s *Store) DB() *sql.DB {
 }
 // #endregion db-accessor
 
+// #region tx
+// DBTX is the query surface shared by *sql.DB and *sql.Tx. Stores that accept it
+// can run either standalone or inside a transaction opened with WithTx.
+type DBTX interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// WithTx runs fn inside a single transaction on the shared DB.
+// The transaction commits if fn returns nil and rolls back otherwise,
+// so composite turn writes (state, provenance, interior, edges) land together or not at all.
+func (s *Store) WithTx(fn func(tx *sql.Tx) error) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit: %w", err)
+	}
+	return nil
+}
+// #endregion tx
+
 // #region create-initial
 // CreateInitialState creates a zero-vector initial state version.
 func (s *Store) CreateInitialState(segMap SegmentMap) (StateRecord, error) {
@@ -120,7 +170,7 @@ func (s *Store) CreateInitialState(segMap SegmentMap) (StateRecord, error) {
 	_, err = tx.Exec(
 		`INSERT INTO state_versions (version_id, parent_id, state_vector, segment_map, created_at)
 		 VALUES (?, ?, ?, ?, ?)`,
-		id, nil, encodeVector(vec), string(segJSON), now.Format(time.RFC3339Nano),
+		id, nil, encodeVector(vec), string(segJSON), timestamp.Format(now),
 	)
 	if err != nil {
 		return StateRecord{}, fmt.Errorf("insert version: %w", err)
@@ -176,11 +226,9 @@ func (s *Store) GetVersion(id string) (StateRecord, error) {
 	if parentID.Valid {
 		rec.ParentID = parentID.String
 	}
-	rec.StateVector = decodeVector(vecBlob)
-	if err := json.Unmarshal([]byte(segJSON), &rec.SegmentMap); err != nil {
-		return StateRecord{}, fmt.Errorf("unmarshal segment map: %w", err)
+	if err := decodeRow(s.mode, &rec, vecBlob, segJSON, createdStr); err != nil {
+		return StateRecord{}, err
 	}
-	rec.CreatedAt, _ = time.Parse(time.RFC3339Nano, createdStr)
 	if metricsJSON.Valid {
 		rec.MetricsJSON = metricsJSON.String
 	}
@@ -192,16 +240,39 @@ func (s *Store) GetVersion(id string) (StateRecord, error) {
 // #region commit-state
 // CommitState inserts a new version and updates the active pointer atomically.
 func (s *Store) CommitState(rec StateRecord) error {
-	segJSON, err := json.Marshal(rec.SegmentMap)
+	return s.WithTx(func(tx *sql.Tx) error {
+		return s.CommitStateTx(tx, rec)
+	})
+}
+
+// CommitStateTx inserts a new version and updates the active pointer inside
+// a caller-managed transaction (see WithTx).
+func (s *Store) CommitStateTx(tx *sql.Tx, rec StateRecord) error {
+	if err := insertVersion(tx, rec); err != nil {
+		return err
+	}
+
+	_, err := tx.Exec(
+		`UPDATE active_state SET version_id = ? WHERE id = 1`, rec.VersionID,
+	)
 	if err != nil {
-		return fmt.Errorf("marshal segment map: %w", err)
+		return fmt.Errorf("update active: %w", err)
 	}
 
-	tx, err := s.db.Begin()
+	return nil
+}
+
+// InsertVersionTx inserts a version without moving the active pointer, for
+// state branches that only become active if chosen (see RollbackTx).
+func (s *Store) InsertVersionTx(tx *sql.Tx, rec StateRecord) error {
+	return insertVersion(tx, rec)
+}
+
+func insertVersion(tx *sql.Tx, rec StateRecord) error {
+	segJSON, err := json.Marshal(rec.SegmentMap)
 	if err != nil {
-		return fmt.Errorf("begin tx: %w", err)
+		return fmt.Errorf("marshal segment map: %w", err)
 	}
-	defer tx.Rollback()
 
 	var parentPtr interface{}
 	if rec.ParentID != "" {
@@ -217,29 +288,30 @@ func (s *Store) CommitState(rec StateRecord) error {
 		`INSERT INTO state_versions (version_id, parent_id, state_vector, segment_map, created_at, metrics_json)
 		 VALUES (?, ?, ?, ?, ?, ?)`,
 		rec.VersionID, parentPtr, encodeVector(rec.StateVector), string(segJSON),
-		rec.CreatedAt.Format(time.RFC3339Nano), metricsPtr,
+		timestamp.Format(rec.CreatedAt), metricsPtr,
 	)
 	if err != nil {
 		return fmt.Errorf("insert version: %w", err)
 	}
-
-	_, err = tx.Exec(
-		`UPDATE active_state SET version_id = ? WHERE id = 1`, rec.VersionID,
-	)
-	if err != nil {
-		return fmt.Errorf("update active: %w", err)
-	}
-
-	return tx.Commit()
+	return nil
 }
 // #endregion commit-state
 
 // #region rollback
 // Rollback sets the active pointer to a previous version.
 func (s *Store) Rollback(targetVersionID string) error {
+	return rollback(s.db, targetVersionID)
+}
+
+// RollbackTx sets the active pointer to a previous version inside a caller-managed transaction.
+func (s *Store) RollbackTx(tx *sql.Tx, targetVersionID string) error {
+	return rollback(tx, targetVersionID)
+}
+
+func rollback(q DBTX, targetVersionID string) error {
 	// Verify the target version exists
 	var exists int
-	err := s.db.QueryRow(
+	err := q.QueryRow(
 		`SELECT COUNT(*) FROM state_versions WHERE version_id = ?`, targetVersionID,
 	).Scan(&exists)
 	if err != nil {
@@ -249,10 +321,18 @@ func (s *Store) Rollback(targetVersionID string) error {
 		return fmt.Errorf("version %s not found", targetVersionID)
 	}
 
-	_, err = s.db.Exec(`UPDATE active_state SET version_id = ? WHERE id = 1`, targetVersionID)
+	var from sql.NullString
+	_ = q.QueryRow(`SELECT version_id FROM active_state WHERE id = 1`).Scan(&from)
+	_, err = q.Exec(`UPDATE active_state SET version_id = ? WHERE id = 1`, targetVersionID)
 	if err != nil {
 		return fmt.Errorf("rollback: %w", err)
 	}
+	// Every pointer move other than a commit is kept for the lineage view
+	_, err = q.Exec(`INSERT INTO active_moves (from_version, to_version, created_at) VALUES (?, ?, ?)`,
+		from, targetVersionID, timestamp.Now())
+	if err != nil {
+		return fmt.Errorf("record rollback: %w", err)
+	}
 	return nil
 }
 // #endregion rollback
@@ -284,11 +364,9 @@ func (s *Store) ListVersions(limit int) ([]StateRecord, error) {
 		if parentID.Valid {
 			rec.ParentID = parentID.String
 		}
-		rec.StateVector = decodeVector(vecBlob)
-		if err := json.Unmarshal([]byte(segJSON), &rec.SegmentMap); err != nil {
-			return nil, fmt.Errorf("unmarshal segment map: %w", err)
+		if err := decodeRow(s.mode, &rec, vecBlob, segJSON, createdStr); err != nil {
+			return nil, err
 		}
-		rec.CreatedAt, _ = time.Parse(time.RFC3339Nano, createdStr)
 		if metricsJSON.Valid {
 			rec.MetricsJSON = metricsJSON.String
 		}
@@ -338,11 +416,9 @@ func (s *Store) ListVersionsWithProvenance(limit int) ([]VersionWithProvenance,
 		if parentID.Valid {
 			vp.ParentID = parentID.String
 		}
-		vp.StateVector = decodeVector(vecBlob)
-		if err := json.Unmarshal([]byte(segJSON), &vp.SegmentMap); err != nil {
-			return nil, fmt.Errorf("unmarshal segment map: %w", err)
+		if err := decodeRow(s.mode, &vp.StateRecord, vecBlob, segJSON, createdStr); err != nil {
+			return nil, err
 		}
-		vp.CreatedAt, _ = time.Parse(time.RFC3339Nano, createdStr)
 		if metricsJSON.Valid {
 			vp.MetricsJSON = metricsJSON.String
 		}
@@ -392,11 +468,9 @@ func (s *Store) GetVersionWithProvenance(id string) (VersionWithProvenance, erro
 	if parentID.Valid {
 		vp.ParentID = parentID.String
 	}
-	vp.StateVector = decodeVector(vecBlob)
-	if err := json.Unmarshal([]byte(segJSON), &vp.SegmentMap); err != nil {
-		return VersionWithProvenance{}, fmt.Errorf("unmarshal segment map: %w", err)
+	if err := decodeRow(s.mode, &vp.StateRecord, vecBlob, segJSON, createdStr); err != nil {
+		return VersionWithProvenance{}, err
 	}
-	vp.CreatedAt, _ = time.Parse(time.RFC3339Nano, createdStr)
 	if metricsJSON.Valid {
 		vp.MetricsJSON = metricsJSON.String
 	}
@@ -96,6 +96,35 @@ func TestCommitAndRollback(t *testing.T) {
 	}
 }
 
+func TestInsertVersionTx_LeavesActive(t *testing.T) {
+	s := tempDB(t)
+	v1, err := s.CreateInitialState(DefaultSegmentMap())
+	if err != nil {
+		t.Fatalf("CreateInitialState: %v", err)
+	}
+	branch := StateRecord{VersionID: "branch", ParentID: v1.VersionID, SegmentMap: v1.SegmentMap, CreatedAt: v1.CreatedAt}
+	branch.StateVector[0] = 0.5
+	if err := s.WithTx(func(tx *sql.Tx) error { return s.InsertVersionTx(tx, branch) }); err != nil {
+		t.Fatalf("InsertVersionTx: %v", err)
+	}
+
+	cur, _ := s.GetCurrent()
+	if cur.VersionID != v1.VersionID {
+		t.Fatalf("active = %s, want %s", cur.VersionID, v1.VersionID)
+	}
+	got, err := s.GetVersion("branch")
+	if err != nil || got.ParentID != v1.VersionID || got.StateVector[0] != 0.5 {
+		t.Fatalf("branch = %+v, %v", got, err)
+	}
+	// A branch becomes active by moving the pointer to it
+	if err := s.Rollback("branch"); err != nil {
+		t.Fatalf("Rollback: %v", err)
+	}
+	if cur, _ := s.GetCurrent(); cur.VersionID != "branch" {
+		t.Fatalf("active = %s, want branch", cur.VersionID)
+	}
+}
+
 func TestRollbackNonExistent(t *testing.T) {
 	s := tempDB(t)
 	s.CreateInitialState(DefaultSegmentMap())
@@ -144,6 +173,22 @@ func TestVectorRoundTrip(t *testing.T) {
 	}
 }
 
+func TestSegmentMapNorms(t *testing.T) {
+	var v [128]float32
+	v[0], v[1] = 3, 4 // prefs
+	v[100] = -2       // risk
+	norms := DefaultSegmentMap().Norms(v)
+	want := map[string]float64{"prefs": 5, "goals": 0, "heuristics": 0, "risk": 2}
+	for name, w := range want {
+		if math.Abs(norms[name]-w) > 1e-9 {
+			t.Errorf("%s norm = %f, want %f", name, norms[name], w)
+		}
+	}
+	if len(norms) != len(SegmentNames) {
+		t.Errorf("expected %d segments, got %d", len(SegmentNames), len(norms))
+	}
+}
+
 func TestNewStoreInvalidPath(t *testing.T) {
 	_, err := NewStore(filepath.Join(string(os.PathSeparator), "nonexistent", "deep", "path", "test.db"))
 	if err == nil {
@@ -804,3 +849,65 @@ func TestNewStore_PragmaFails(t *testing.T) {
 		t.Fatal("expected error for read-only DB pragma")
 	}
 }
+
+func TestWithTx_CommitAndRollback(t *testing.T) {
+	s := tempDB(t)
+	v1, err := s.CreateInitialState(DefaultSegmentMap())
+	if err != nil {
+		t.Fatalf("CreateInitialState: %v", err)
+	}
+	v2 := StateRecord{
+		VersionID:  "v2-tx",
+		ParentID:   v1.VersionID,
+		SegmentMap: v1.SegmentMap,
+		CreatedAt:  time.Now().UTC(),
+	}
+
+	// fn error: the commit inside the tx must not persist
+	wantErr := fmt.Errorf("boom")
+	err = s.WithTx(func(tx *sql.Tx) error {
+		if err := s.CommitStateTx(tx, v2); err != nil {
+			return err
+		}
+		return wantErr
+	})
+	if err != wantErr {
+		t.Fatalf("expected fn error, got %v", err)
+	}
+	cur, err := s.GetCurrent()
+	if err != nil {
+		t.Fatalf("GetCurrent: %v", err)
+	}
+	if cur.VersionID != v1.VersionID {
+		t.Fatalf("expected %s after rolled-back tx, got %s", v1.VersionID, cur.VersionID)
+	}
+	if _, err := s.GetVersion("v2-tx"); err == nil {
+		t.Fatal("expected v2-tx to be absent after rollback")
+	}
+
+	// commit then roll back the active pointer within one tx
+	err = s.WithTx(func(tx *sql.Tx) error {
+		if err := s.CommitStateTx(tx, v2); err != nil {
+			return err
+		}
+		return s.RollbackTx(tx, v1.VersionID)
+	})
+	if err != nil {
+		t.Fatalf("WithTx: %v", err)
+	}
+	if _, err := s.GetVersion("v2-tx"); err != nil {
+		t.Fatalf("expected v2-tx to persist: %v", err)
+	}
+	cur, _ = s.GetCurrent()
+	if cur.VersionID != v1.VersionID {
+		t.Fatalf("expected active %s, got %s", v1.VersionID, cur.VersionID)
+	}
+}
+
+func TestWithTx_BeginFails(t *testing.T) {
+	s := tempDB(t)
+	s.Close()
+	if err := s.WithTx(func(tx *sql.Tx) error { return nil }); err == nil {
+		t.Fatal("expected error on closed DB")
+	}
+}
@@ -1,6 +1,9 @@
 package state
 
-import "time"
+import (
+	"math"
+	"time"
+)
 
 // #region state-record
 // StateRecord represents a versioned snapshot of the disposition state vector.
@@ -32,6 +35,23 @@ func DefaultSegmentMap() SegmentMap {
 		Risk:       [2]int{96, 128},
 	}
 }
+
+// SegmentNames lists the segment names in vector order.
+var SegmentNames = []string{"prefs", "goals", "heuristics", "risk"}
+
+// Norms returns the L2 norm of each segment of v, keyed by segment name.
+func (m SegmentMap) Norms(v [128]float32) map[string]float64 {
+	norms := make(map[string]float64, len(SegmentNames))
+	for _, name := range SegmentNames {
+		r, _ := SegmentRange(m, name)
+		var sum float64
+		for i := max(r[0], 0); i < r[1] && i < len(v); i++ {
+			sum += float64(v[i]) * float64(v[i])
+		}
+		norms[name] = math.Sqrt(sum)
+	}
+	return norms
+}
 // #endregion segment-map
 
 // #region provenance-tag
@@ -0,0 +1,191 @@
+package telemetry
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/timestamp"
+)
+
+// #region sample
+
+// Sample is what telemetry keeps about one turn. It has no text fields by
+// design: the decision and turn type are fixed vocabularies, everything else
+// is a number.
+type Sample struct {
+	At        time.Time
+	Decision  string             // commit | reject | rollback | held | frozen | cancelled | error
+	TurnType  string             // classifier turn type, empty when unclassified
+	LatencyMs int64              // inbox read to turn event
+	DeltaNorm float64            // proposed update norm; 0 when the turn never reached the gate
+	Norms     map[string]float64 // active state segment norms after the turn
+}
+
+// #endregion sample
+
+// #region store
+
+// Store keeps turn samples in telemetry_samples and the summaries written
+// from them in telemetry_summaries.
+type Store struct {
+	db *sql.DB
+}
+
+// NewStore creates the telemetry tables if needed and returns a store.
+func NewStore(db *sql.DB) (*Store, error) {
+	_, err := db.Exec(`CREATE TABLE IF NOT EXISTS telemetry_samples (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		at TEXT NOT NULL,
+		decision TEXT NOT NULL,
+		turn_type TEXT NOT NULL DEFAULT '',
+		latency_ms INTEGER NOT NULL DEFAULT 0,
+		delta_norm REAL NOT NULL DEFAULT 0,
+		norms_json TEXT NOT NULL DEFAULT '{}'
+	)`)
+	if err != nil {
+		return nil, fmt.Errorf("create telemetry_samples table: %w", err)
+	}
+	_, err = db.Exec(`CREATE TABLE IF NOT EXISTS telemetry_summaries (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		generated_at TEXT NOT NULL,
+		since TEXT NOT NULL,
+		until TEXT NOT NULL,
+		turns INTEGER NOT NULL,
+		path TEXT NOT NULL
+	)`)
+	if err != nil {
+		return nil, fmt.Errorf("create telemetry_summaries table: %w", err)
+	}
+	if _, err := timestamp.Canonicalize(db, "telemetry_samples", "at"); err != nil {
+		return nil, err
+	}
+	if _, err := timestamp.Canonicalize(db, "telemetry_summaries", "generated_at", "since", "until"); err != nil {
+		return nil, err
+	}
+	return &Store{db: db}, nil
+}
+
+// Record stores sample; a zero At is stamped with the current time.
+func (s *Store) Record(sample Sample) error {
+	if sample.At.IsZero() {
+		sample.At = time.Now().UTC()
+	}
+	norms, err := json.Marshal(sample.Norms)
+	if err != nil {
+		return fmt.Errorf("marshal telemetry norms: %w", err)
+	}
+	_, err = s.db.Exec(
+		`INSERT INTO telemetry_samples (at, decision, turn_type, latency_ms, delta_norm, norms_json) VALUES (?, ?, ?, ?, ?, ?)`,
+		timestamp.Format(sample.At), sample.Decision, sample.TurnType, sample.LatencyMs, sample.DeltaNorm, string(norms),
+	)
+	if err != nil {
+		return fmt.Errorf("insert telemetry sample: %w", err)
+	}
+	return nil
+}
+
+// Samples returns the samples recorded in [since, until), oldest first.
+func (s *Store) Samples(since, until time.Time) ([]Sample, error) {
+	rows, err := s.db.Query(
+		`SELECT at, decision, turn_type, latency_ms, delta_norm, norms_json FROM telemetry_samples
+		 WHERE at >= ? AND at < ? ORDER BY at, id`,
+		timestamp.Format(since), timestamp.Format(until),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("query telemetry samples: %w", err)
+	}
+	defer rows.Close()
+	var out []Sample
+	for rows.Next() {
+		var sample Sample
+		var at, norms string
+		if err := rows.Scan(&at, &sample.Decision, &sample.TurnType, &sample.LatencyMs, &sample.DeltaNorm, &norms); err != nil {
+			return nil, err
+		}
+		sample.At, _ = timestamp.Parse(at)
+		if err := json.Unmarshal([]byte(norms), &sample.Norms); err != nil {
+			return nil, fmt.Errorf("decode telemetry norms: %w", err)
+		}
+		out = append(out, sample)
+	}
+	return out, rows.Err()
+}
+
+// Prune deletes samples recorded before cutoff and returns how many it removed.
+func (s *Store) Prune(cutoff time.Time) (int64, error) {
+	res, err := s.db.Exec(`DELETE FROM telemetry_samples WHERE at < ?`, timestamp.Format(cutoff))
+	if err != nil {
+		return 0, fmt.Errorf("prune telemetry samples: %w", err)
+	}
+	return res.RowsAffected()
+}
+
+// RecordSummary notes that sum was written to path.
+func (s *Store) RecordSummary(sum Summary, path string) error {
+	_, err := s.db.Exec(
+		`INSERT INTO telemetry_summaries (generated_at, since, until, turns, path) VALUES (?, ?, ?, ?, ?)`,
+		timestamp.Format(sum.GeneratedAt), timestamp.Format(sum.Since), timestamp.Format(sum.Until), sum.Turns, path,
+	)
+	if err != nil {
+		return fmt.Errorf("insert telemetry summary: %w", err)
+	}
+	return nil
+}
+
+// LastUntil returns the end of the newest summary's window; ok is false when
+// none was written yet.
+func (s *Store) LastUntil() (until time.Time, ok bool, err error) {
+	var raw string
+	err = s.db.QueryRow(`SELECT until FROM telemetry_summaries ORDER BY generated_at DESC, id DESC LIMIT 1`).Scan(&raw)
+	if errors.Is(err, sql.ErrNoRows) {
+		return time.Time{}, false, nil
+	}
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("last telemetry summary: %w", err)
+	}
+	until, _ = timestamp.Parse(raw)
+	return until, true, nil
+}
+
+// Oldest returns when the oldest kept sample was recorded; ok is false when
+// there are none.
+func (s *Store) Oldest() (at time.Time, ok bool, err error) {
+	var raw sql.NullString
+	if err := s.db.QueryRow(`SELECT MIN(at) FROM telemetry_samples`).Scan(&raw); err != nil {
+		return time.Time{}, false, fmt.Errorf("oldest telemetry sample: %w", err)
+	}
+	if !raw.Valid {
+		return time.Time{}, false, nil
+	}
+	at, _ = timestamp.Parse(raw.String)
+	return at, true, nil
+}
+
+// WindowStart is where the next summary's window begins: the end of the
+// newest summary, or before the first one the oldest sample. ok is false when
+// there is nothing to summarize yet.
+func (s *Store) WindowStart() (since time.Time, ok bool, err error) {
+	since, ok, err = s.LastUntil()
+	if err != nil || ok {
+		return since, ok, err
+	}
+	return s.Oldest()
+}
+
+// Due reports whether a summary is due at now, once the next window is at
+// least interval long. A non-positive interval never schedules one.
+func (s *Store) Due(now time.Time, interval time.Duration) (bool, error) {
+	if interval <= 0 {
+		return false, nil
+	}
+	since, ok, err := s.WindowStart()
+	if err != nil || !ok {
+		return false, err
+	}
+	return now.Sub(since) >= interval, nil
+}
+
+// #endregion store
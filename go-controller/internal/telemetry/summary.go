@@ -0,0 +1,146 @@
+package telemetry
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// SchemaVersion is bumped whenever the summary file changes shape, so
+// summaries from different deployments can be compared safely.
+const SchemaVersion = 1
+
+// #region summary
+
+// Summary aggregates a window of samples. Like Sample it holds counts and
+// numbers only, never prompt or response text.
+type Summary struct {
+	Schema      int       `json:"schema"`
+	GeneratedAt time.Time `json:"generated_at"`
+	Since       time.Time `json:"since"`
+	Until       time.Time `json:"until"`
+	Turns       int       `json:"turns"`
+
+	Decisions      map[string]int     `json:"decisions"`
+	DecisionRatios map[string]float64 `json:"decision_ratios"`
+	TurnTypes      map[string]int     `json:"turn_types"`
+
+	LatencyMs Percentiles `json:"latency_ms"`
+	DeltaNorm Percentiles `json:"delta_norm"` // over turns that reached the gate
+
+	// Mean state norms per UTC day, oldest first
+	Norms []NormPoint `json:"norm_trajectory"`
+}
+
+// Percentiles summarizes a distribution (nearest-rank percentiles).
+type Percentiles struct {
+	N   int     `json:"n"`
+	P50 float64 `json:"p50"`
+	P90 float64 `json:"p90"`
+	P99 float64 `json:"p99"`
+	Max float64 `json:"max"`
+}
+
+// NormPoint is one day of the norm trajectory.
+type NormPoint struct {
+	Day      string             `json:"day"` // YYYY-MM-DD
+	Turns    int                `json:"turns"`
+	Total    float64            `json:"total"` // L2 norm over all segments
+	Segments map[string]float64 `json:"segments"`
+}
+
+// Summarize aggregates samples recorded in [since, until).
+func Summarize(samples []Sample, since, until time.Time) Summary {
+	sum := Summary{
+		Schema:         SchemaVersion,
+		GeneratedAt:    time.Now().UTC(),
+		Since:          since.UTC(),
+		Until:          until.UTC(),
+		Decisions:      map[string]int{},
+		DecisionRatios: map[string]float64{},
+		TurnTypes:      map[string]int{},
+	}
+	var latencies, deltas []float64
+	days := map[string]*NormPoint{}
+	for _, s := range samples {
+		sum.Turns++
+		sum.Decisions[s.Decision]++
+		if s.TurnType != "" {
+			sum.TurnTypes[s.TurnType]++
+		}
+		latencies = append(latencies, float64(s.LatencyMs))
+		if s.DeltaNorm > 0 {
+			deltas = append(deltas, s.DeltaNorm)
+		}
+		if len(s.Norms) == 0 {
+			continue
+		}
+		day := s.At.UTC().Format("2006-01-02")
+		p := days[day]
+		if p == nil {
+			p = &NormPoint{Day: day, Segments: map[string]float64{}}
+			days[day] = p
+		}
+		p.Turns++
+		var sq float64
+		for seg, n := range s.Norms {
+			p.Segments[seg] += n
+			sq += n * n
+		}
+		p.Total += math.Sqrt(sq)
+	}
+	for d, n := range sum.Decisions {
+		sum.DecisionRatios[d] = float64(n) / float64(sum.Turns)
+	}
+	sum.LatencyMs = percentiles(latencies)
+	sum.DeltaNorm = percentiles(deltas)
+	for _, p := range days {
+		p.Total /= float64(p.Turns)
+		for seg := range p.Segments {
+			p.Segments[seg] /= float64(p.Turns)
+		}
+		sum.Norms = append(sum.Norms, *p)
+	}
+	sort.Slice(sum.Norms, func(i, j int) bool { return sum.Norms[i].Day < sum.Norms[j].Day })
+	return sum
+}
+
+// percentiles summarizes values; it sorts them in place.
+func percentiles(values []float64) Percentiles {
+	if len(values) == 0 {
+		return Percentiles{}
+	}
+	sort.Float64s(values)
+	rank := func(p float64) float64 {
+		i := int(math.Ceil(p*float64(len(values)))) - 1
+		return values[max(i, 0)]
+	}
+	return Percentiles{N: len(values), P50: rank(0.5), P90: rank(0.9), P99: rank(0.99), Max: values[len(values)-1]}
+}
+
+// #endregion summary
+
+// #region write
+
+// Write writes sum to dir as telemetry-YYYY-MM-DD.json, named after the end
+// of its window, creating dir if needed, and returns the file's path.
+func Write(dir string, sum Summary) (string, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", fmt.Errorf("create telemetry dir: %w", err)
+	}
+	data, err := json.MarshalIndent(sum, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("marshal telemetry summary: %w", err)
+	}
+	path := filepath.Join(dir, "telemetry-"+sum.Until.Format("2006-01-02")+".json")
+	if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+		return "", fmt.Errorf("write telemetry summary: %w", err)
+	}
+	return path, nil
+}
+
+// #endregion write
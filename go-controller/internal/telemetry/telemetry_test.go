@@ -0,0 +1,134 @@
+package telemetry
+
+import (
+	"encoding/json"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/state"
+)
+
+var base = time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+
+// at is the time of hour h after base.
+func at(h int) time.Time {
+	return base.Add(time.Duration(h) * time.Hour)
+}
+
+func TestSummarize(t *testing.T) {
+	samples := []Sample{
+		{At: at(0), Decision: "commit", TurnType: "factual", LatencyMs: 100, DeltaNorm: 0.2, Norms: map[string]float64{"prefs": 3, "goals": 4}},
+		{At: at(1), Decision: "commit", TurnType: "factual", LatencyMs: 300, DeltaNorm: 0.4, Norms: map[string]float64{"prefs": 1, "goals": 0}},
+		{At: at(2), Decision: "reject", TurnType: "emotional", LatencyMs: 200, DeltaNorm: 0.6},
+		{At: at(24), Decision: "cancelled", LatencyMs: 1000, Norms: map[string]float64{"prefs": 2, "goals": 0}},
+	}
+	sum := Summarize(samples, at(0), at(48))
+
+	if sum.Schema != SchemaVersion || sum.Turns != 4 {
+		t.Fatalf("schema %d turns %d, want %d and 4", sum.Schema, sum.Turns, SchemaVersion)
+	}
+	if sum.Decisions["commit"] != 2 || sum.DecisionRatios["commit"] != 0.5 || sum.DecisionRatios["cancelled"] != 0.25 {
+		t.Errorf("decisions %v ratios %v", sum.Decisions, sum.DecisionRatios)
+	}
+	if sum.TurnTypes["factual"] != 2 || sum.TurnTypes["emotional"] != 1 || len(sum.TurnTypes) != 2 {
+		t.Errorf("turn types %v, want unclassified turns left out", sum.TurnTypes)
+	}
+	if want := (Percentiles{N: 4, P50: 200, P90: 1000, P99: 1000, Max: 1000}); sum.LatencyMs != want {
+		t.Errorf("latency %+v, want %+v", sum.LatencyMs, want)
+	}
+	if sum.DeltaNorm.N != 3 || sum.DeltaNorm.P50 != 0.4 {
+		t.Errorf("delta norm %+v, want 3 gated turns with median 0.4", sum.DeltaNorm)
+	}
+
+	if len(sum.Norms) != 2 || sum.Norms[0].Day != "2024-06-01" || sum.Norms[1].Day != "2024-06-02" {
+		t.Fatalf("trajectory %+v, want two days in order", sum.Norms)
+	}
+	day := sum.Norms[0]
+	if day.Turns != 2 || day.Segments["prefs"] != 2 || day.Segments["goals"] != 2 || math.Abs(day.Total-3) > 1e-9 {
+		t.Errorf("first day %+v, want 2 turns, prefs 2, goals 2, total (5+1)/2", day)
+	}
+}
+
+func TestSummarize_Empty(t *testing.T) {
+	sum := Summarize(nil, at(0), at(1))
+	if sum.Turns != 0 || sum.LatencyMs != (Percentiles{}) || len(sum.Norms) != 0 {
+		t.Errorf("empty summary %+v", sum)
+	}
+}
+
+func TestStore(t *testing.T) {
+	store, err := state.NewStore(filepath.Join(t.TempDir(), "telemetry.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	s, err := NewStore(store.DB())
+	if err != nil {
+		t.Fatal(err)
+	}
+	week := 7 * 24 * time.Hour
+
+	if due, err := s.Due(at(0), week); err != nil || due {
+		t.Fatalf("due with no samples = %v, %v; want false", due, err)
+	}
+	for h, d := range []string{"commit", "reject", "commit"} {
+		if err := s.Record(Sample{At: at(h), Decision: d, LatencyMs: int64(h), Norms: map[string]float64{"prefs": 1}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if due, _ := s.Due(at(24), week); due {
+		t.Error("due one day after the first sample")
+	}
+	if due, _ := s.Due(at(0).Add(week), week); !due {
+		t.Error("not due a week after the first sample")
+	}
+
+	samples, err := s.Samples(at(1), at(3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(samples) != 2 || samples[0].Decision != "reject" || samples[1].Norms["prefs"] != 1 || !samples[0].At.Equal(at(1)) {
+		t.Fatalf("samples %+v", samples)
+	}
+
+	until := at(0).Add(week)
+	sum := Summarize(samples, at(0), until)
+	if err := s.RecordSummary(sum, "x.json"); err != nil {
+		t.Fatal(err)
+	}
+	if since, ok, err := s.WindowStart(); err != nil || !ok || !since.Equal(until) {
+		t.Errorf("window start = %v, %v, %v; want the last summary's end", since, ok, err)
+	}
+	if due, _ := s.Due(until.Add(time.Hour), week); due {
+		t.Error("due right after a summary")
+	}
+	if n, err := s.Prune(at(2)); err != nil || n != 2 {
+		t.Errorf("pruned %d, %v; want 2", n, err)
+	}
+}
+
+func TestWrite(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "telemetry")
+	sum := Summarize([]Sample{{At: at(0), Decision: "commit", LatencyMs: 50}}, at(0), at(24))
+	path, err := Write(dir, sum)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if filepath.Base(path) != "telemetry-2024-06-02.json" {
+		t.Errorf("path %s", path)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got Summary
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Turns != 1 || got.Decisions["commit"] != 1 || got.LatencyMs.Max != 50 {
+		t.Errorf("round trip %+v", got)
+	}
+}
@@ -0,0 +1,208 @@
+package timestamp
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// #region canonical
+
+// Layout is the canonical storage format: UTC with all nine fractional digits,
+// so stored timestamps compare as text in time order (RFC3339Nano trims
+// trailing zeros, which sorts "…:05Z" after "…:05.5Z").
+const Layout = "2006-01-02T15:04:05.000000000Z"
+
+// legacyLayouts are the formats stores wrote before Layout, tried in order by
+// Parse. time.RFC3339Nano also reads time.RFC3339. The SQLite driver writes
+// time.Time arguments as time.Time.String(); the other space-separated ones
+// are SQLite's own datetime() and driver time formats.
+var legacyLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05.999999999 -0700 MST",
+	"2006-01-02 15:04:05.999999999-07:00",
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02",
+}
+
+// Format renders t in the canonical storage format.
+func Format(t time.Time) string {
+	return t.UTC().Format(Layout)
+}
+
+// Now is Format(time.Now()).
+func Now() string {
+	return Format(time.Now())
+}
+
+// Parse reads a stored timestamp in the canonical or any legacy format.
+// Times without a zone are UTC. The result is in UTC.
+func Parse(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if t, err := time.Parse(Layout, s); err == nil {
+		return t, nil
+	}
+	for _, layout := range legacyLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t.UTC(), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unrecognized timestamp %q", s)
+}
+
+// IsCanonical reports whether s is already in the canonical format.
+func IsCanonical(s string) bool {
+	t, err := time.Parse(Layout, s)
+	return err == nil && t.Format(Layout) == s
+}
+
+// #endregion canonical
+
+// #region migrate
+
+// DB is the query surface Canonicalize needs; *sql.DB and *sql.Tx satisfy it.
+type DB interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// canonicalGlob matches Layout in SQL, so rows already migrated are skipped.
+const canonicalGlob = "[0-9][0-9][0-9][0-9]-[0-9][0-9]-[0-9][0-9]T[0-9][0-9]:[0-9][0-9]:[0-9][0-9].[0-9][0-9][0-9][0-9][0-9][0-9][0-9][0-9][0-9]Z"
+
+// Canonicalize rewrites the timestamp columns of table into Layout and returns
+// how many values changed. Stores run it after creating their tables; once a
+// table is migrated it only scans. Values Parse cannot read are left as they
+// are. A missing table is not an error.
+func Canonicalize(db DB, table string, columns ...string) (int, error) {
+	var n int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = ?`, table).Scan(&n); err != nil {
+		return 0, fmt.Errorf("canonicalize %s: %w", table, err)
+	}
+	if n == 0 {
+		return 0, nil
+	}
+
+	type pending struct {
+		rowid int64
+		value string
+	}
+	changed := 0
+	for _, col := range columns {
+		rows, err := db.Query(fmt.Sprintf(`SELECT rowid, %s FROM %s WHERE %s IS NOT NULL AND %s NOT GLOB '%s'`,
+			col, table, col, col, canonicalGlob))
+		if err != nil {
+			return changed, fmt.Errorf("canonicalize %s.%s: %w", table, col, err)
+		}
+		var updates []pending
+		for rows.Next() {
+			var rowid int64
+			var raw string
+			if err := rows.Scan(&rowid, &raw); err != nil {
+				rows.Close()
+				return changed, fmt.Errorf("canonicalize %s.%s: scan: %w", table, col, err)
+			}
+			if t, err := Parse(raw); err == nil {
+				updates = append(updates, pending{rowid, Format(t)})
+			}
+		}
+		err = rows.Err()
+		rows.Close()
+		if err != nil {
+			return changed, fmt.Errorf("canonicalize %s.%s: %w", table, col, err)
+		}
+		for _, u := range updates {
+			if _, err := db.Exec(fmt.Sprintf(`UPDATE %s SET %s = ? WHERE rowid = ?`, table, col), u.value, u.rowid); err != nil {
+				return changed, fmt.Errorf("canonicalize %s.%s: update: %w", table, col, err)
+			}
+			changed++
+		}
+	}
+	return changed, nil
+}
+
+// #endregion migrate
+
+// #region display
+
+// Display renders timestamps for people: in a time zone, with a locale's
+// date and time layout. The zero value renders UTC in ISO 8601.
+type Display struct {
+	Location *time.Location
+	Layout   string
+}
+
+// localeLayouts maps the locales NewDisplay accepts to their layouts.
+var localeLayouts = map[string]string{
+	"iso":   "2006-01-02T15:04:05Z07:00",
+	"en-US": "01/02/2006 3:04:05 PM MST",
+	"en-GB": "02/01/2006 15:04:05 MST",
+	"de-DE": "02.01.2006 15:04:05 MST",
+	"fr-FR": "02/01/2006 15:04:05 MST",
+	"ja-JP": "2006/01/02 15:04:05 MST",
+}
+
+// Locales lists the locale names NewDisplay accepts.
+func Locales() []string {
+	return []string{"iso", "en-US", "en-GB", "de-DE", "fr-FR", "ja-JP"}
+}
+
+// NewDisplay builds a Display for a time zone ("UTC", "Local" or an IANA name
+// such as "Europe/Berlin"; empty is UTC) and a locale (see Locales; empty is
+// iso).
+func NewDisplay(tz, locale string) (Display, error) {
+	d := Display{Location: time.UTC, Layout: localeLayouts["iso"]}
+	switch strings.ToLower(tz) {
+	case "", "utc":
+	case "local":
+		d.Location = time.Local
+	default:
+		loc, err := time.LoadLocation(tz)
+		if err != nil {
+			return Display{}, fmt.Errorf("time zone %q: %w", tz, err)
+		}
+		d.Location = loc
+	}
+	if locale != "" {
+		layout, ok := localeLayouts[locale]
+		if !ok {
+			return Display{}, fmt.Errorf("locale %q: want one of %s", locale, strings.Join(Locales(), ", "))
+		}
+		d.Layout = layout
+	}
+	return d, nil
+}
+
+// Format renders t in the display's zone and layout.
+func (d Display) Format(t time.Time) string {
+	return t.In(d.location()).Format(d.layout())
+}
+
+// Day is t's calendar date in the display's zone, as 2006-01-02, for grouping.
+func (d Display) Day(t time.Time) string {
+	return t.In(d.location()).Format("2006-01-02")
+}
+
+// ParseDate reads a 2006-01-02 date as midnight in the display's zone.
+func (d Display) ParseDate(s string) (time.Time, error) {
+	return time.ParseInLocation("2006-01-02", s, d.location())
+}
+
+func (d Display) location() *time.Location {
+	if d.Location == nil {
+		return time.UTC
+	}
+	return d.Location
+}
+
+func (d Display) layout() string {
+	if d.Layout == "" {
+		return localeLayouts["iso"]
+	}
+	return d.Layout
+}
+
+// #endregion display
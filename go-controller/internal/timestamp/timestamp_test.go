@@ -0,0 +1,189 @@
+package timestamp
+
+import (
+	"database/sql"
+	"math/rand"
+	"reflect"
+	"testing"
+	"testing/quick"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+// #region timestamp-tests
+
+// storableTime is a random instant between years 1 and 9999 (the range a
+// four-digit year covers) with nanoseconds, in a random fixed zone.
+type storableTime struct{ time.Time }
+
+func (storableTime) Generate(r *rand.Rand, _ int) reflect.Value {
+	lo := time.Date(1, 1, 1, 0, 0, 0, 0, time.UTC).Unix()
+	hi := time.Date(9999, 12, 31, 0, 0, 0, 0, time.UTC).Unix()
+	zone := time.FixedZone("", (r.Intn(27*4)-12*4)*15*60)
+	t := time.Unix(lo+r.Int63n(hi-lo), r.Int63n(1e9)).In(zone)
+	if r.Intn(4) == 0 {
+		t = t.Truncate(time.Second) // whole seconds, which RFC3339Nano writes without a fraction
+	}
+	return reflect.ValueOf(storableTime{t})
+}
+
+func TestFormatParseRoundTrip(t *testing.T) {
+	roundTrip := func(st storableTime) bool {
+		s := Format(st.Time)
+		back, err := Parse(s)
+		return err == nil && back.Equal(st.Time) && back.Location() == time.UTC && IsCanonical(s) && len(s) == len(Layout)
+	}
+	if err := quick.Check(roundTrip, &quick.Config{MaxCount: 2000}); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestFormatSortsInTimeOrder(t *testing.T) {
+	ordered := func(a, b storableTime) bool {
+		sa, sb := Format(a.Time), Format(b.Time)
+		switch {
+		case a.Before(b.Time):
+			return sa < sb
+		case b.Before(a.Time):
+			return sb < sa
+		}
+		return sa == sb
+	}
+	if err := quick.Check(ordered, &quick.Config{MaxCount: 2000}); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestParseLegacyFormats(t *testing.T) {
+	legacy := func(st storableTime) bool {
+		for _, s := range []string{
+			st.Format(time.RFC3339Nano),
+			st.Format("2006-01-02 15:04:05.999999999-07:00"),
+		} {
+			back, err := Parse(s)
+			if err != nil || !back.Equal(st.Time) {
+				return false
+			}
+		}
+		back, err := Parse(st.Format(time.RFC3339))
+		return err == nil && back.Equal(st.Truncate(time.Second))
+	}
+	if err := quick.Check(legacy, &quick.Config{MaxCount: 500}); err != nil {
+		t.Error(err)
+	}
+
+	for s, want := range map[string]time.Time{
+		"2026-03-01 10:20:30": time.Date(2026, 3, 1, 10, 20, 30, 0, time.UTC),
+		"2026-03-01":          time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC),
+	} {
+		if got, err := Parse(s); err != nil || !got.Equal(want) {
+			t.Errorf("Parse(%q) = %v, %v; want %v", s, got, err, want)
+		}
+	}
+	for _, bad := range []string{"", "yesterday", "2026-13-01T00:00:00Z"} {
+		if _, err := Parse(bad); err == nil {
+			t.Errorf("Parse(%q): expected error", bad)
+		}
+	}
+	if IsCanonical("2026-03-01T10:20:30Z") {
+		t.Error("RFC3339 should not count as canonical")
+	}
+}
+
+func TestCanonicalize(t *testing.T) {
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+	if _, err := db.Exec(`CREATE TABLE events (id INTEGER PRIMARY KEY, created_at TEXT NOT NULL, decided_at TEXT)`); err != nil {
+		t.Fatal(err)
+	}
+	base := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	values := []any{
+		base.Format(time.RFC3339),                                 // 1: seconds
+		base.Add(500 * time.Millisecond).Format(time.RFC3339Nano), // 2: trimmed fraction, sorted before 1 as text
+		Format(base.Add(time.Second)),                             // 3: already canonical
+		base.Add(2 * time.Second),                                 // 4: time.Time through the driver
+		"not a time",                                              // 5: left alone
+	}
+	for _, v := range values {
+		if _, err := db.Exec(`INSERT INTO events (created_at, decided_at) VALUES (?, NULL)`, v); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	n, err := Canonicalize(db, "events", "created_at", "decided_at")
+	if err != nil || n != 3 {
+		t.Fatalf("canonicalize = %d, %v; want 3 rewritten", n, err)
+	}
+	rows, err := db.Query(`SELECT id FROM events WHERE id != 5 ORDER BY created_at`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var order []int
+	for rows.Next() {
+		var id int
+		rows.Scan(&id)
+		order = append(order, id)
+	}
+	rows.Close()
+	if !reflect.DeepEqual(order, []int{1, 2, 3, 4}) {
+		t.Errorf("text order after migration = %v, want time order [1 2 3 4]", order)
+	}
+	var junk string
+	db.QueryRow(`SELECT created_at FROM events WHERE id = 5`).Scan(&junk)
+	if junk != "not a time" {
+		t.Errorf("unparseable value rewritten to %q", junk)
+	}
+
+	if n, err := Canonicalize(db, "events", "created_at"); err != nil || n != 0 {
+		t.Errorf("second run = %d, %v; want 0", n, err)
+	}
+	if n, err := Canonicalize(db, "missing", "created_at"); err != nil || n != 0 {
+		t.Errorf("missing table = %d, %v", n, err)
+	}
+}
+
+func TestDisplay(t *testing.T) {
+	at := time.Date(2026, 7, 4, 21, 5, 9, 0, time.UTC)
+	cases := []struct {
+		tz, locale, want, day string
+	}{
+		{"", "", "2026-07-04T21:05:09Z", "2026-07-04"},
+		{"Europe/Berlin", "", "2026-07-04T23:05:09+02:00", "2026-07-04"},
+		{"Asia/Tokyo", "ja-JP", "2026/07/05 06:05:09 JST", "2026-07-05"},
+		{"America/New_York", "en-US", "07/04/2026 5:05:09 PM EDT", "2026-07-04"},
+		{"UTC", "de-DE", "04.07.2026 21:05:09 UTC", "2026-07-04"},
+	}
+	for _, tc := range cases {
+		d, err := NewDisplay(tc.tz, tc.locale)
+		if err != nil {
+			t.Fatalf("%s/%s: %v", tc.tz, tc.locale, err)
+		}
+		if got := d.Format(at); got != tc.want {
+			t.Errorf("%s/%s: Format = %q, want %q", tc.tz, tc.locale, got, tc.want)
+		}
+		if got := d.Day(at); got != tc.day {
+			t.Errorf("%s/%s: Day = %q, want %q", tc.tz, tc.locale, got, tc.day)
+		}
+	}
+	if got := (Display{}).Format(at); got != "2026-07-04T21:05:09Z" {
+		t.Errorf("zero Display = %q", got)
+	}
+
+	tokyo, _ := NewDisplay("Asia/Tokyo", "")
+	if midnight, err := tokyo.ParseDate("2026-07-05"); err != nil || !midnight.Equal(time.Date(2026, 7, 4, 15, 0, 0, 0, time.UTC)) {
+		t.Errorf("ParseDate = %v, %v", midnight, err)
+	}
+	if _, err := NewDisplay("Mars/Olympus", ""); err == nil {
+		t.Error("unknown zone: expected error")
+	}
+	if _, err := NewDisplay("", "xx-XX"); err == nil {
+		t.Error("unknown locale: expected error")
+	}
+}
+
+// #endregion timestamp-tests
@@ -0,0 +1,108 @@
+package transcript
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/timestamp"
+)
+
+// #region select
+
+// Criteria picks the turns worth fine-tuning on. Only fully committed turns
+// qualify: not vetoed, not scaled down by an eval warning, not frozen.
+type Criteria struct {
+	MinSoftScore  float32 // gate soft score (0-1)
+	MinCompliance float32 // preference compliance (signals.sentiment_score; 0.5 is neutral)
+}
+
+// DefaultCriteria keeps turns the gate scored well that did not go against a
+// stored preference.
+func DefaultCriteria() Criteria {
+	return Criteria{MinSoftScore: 0.7, MinCompliance: 0.5}
+}
+
+// Qualifies reports whether t meets c.
+func (c Criteria) Qualifies(t Turn) bool {
+	r := t.Record
+	return t.Decision == "commit" && r.GateAction == "commit" && !r.GateVetoed &&
+		r.EvalScale == 0 && r.Frozen == "" && r.Response != "" &&
+		r.GateSoftScore >= c.MinSoftScore && r.Signals.SentimentScore >= c.MinCompliance
+}
+
+// Select returns the turns meeting c, in order, skipping those in exported.
+func Select(turns []Turn, c Criteria, exported map[int64]bool) []Turn {
+	var out []Turn
+	for _, t := range turns {
+		if !exported[t.ProvenanceID] && c.Qualifies(t) {
+			out = append(out, t)
+		}
+	}
+	return out
+}
+
+// #endregion select
+
+// #region export-log
+
+// ExportLog records which turns went into a fine-tuning dataset, so the next
+// export only adds new ones.
+type ExportLog struct {
+	db *sql.DB
+}
+
+// NewExportLog creates the finetune_exports table if needed.
+func NewExportLog(db *sql.DB) (*ExportLog, error) {
+	if _, err := db.Exec(`CREATE TABLE IF NOT EXISTS finetune_exports (
+		provenance_id INTEGER PRIMARY KEY,
+		turn_id       TEXT NOT NULL,
+		batch         TEXT NOT NULL,
+		exported_at   TEXT NOT NULL
+	)`); err != nil {
+		return nil, fmt.Errorf("create finetune_exports table: %w", err)
+	}
+	if _, err := timestamp.Canonicalize(db, "finetune_exports", "exported_at"); err != nil {
+		return nil, err
+	}
+	return &ExportLog{db: db}, nil
+}
+
+// Exported returns the provenance IDs of every turn exported so far.
+func (l *ExportLog) Exported() (map[int64]bool, error) {
+	rows, err := l.db.Query(`SELECT provenance_id FROM finetune_exports`)
+	if err != nil {
+		return nil, fmt.Errorf("list exported turns: %w", err)
+	}
+	defer rows.Close()
+	out := map[int64]bool{}
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("scan exported turn: %w", err)
+		}
+		out[id] = true
+	}
+	return out, rows.Err()
+}
+
+// Mark records turns as exported in batch, all or none.
+func (l *ExportLog) Mark(turns []Turn, batch string) error {
+	tx, err := l.db.Begin()
+	if err != nil {
+		return fmt.Errorf("begin export mark: %w", err)
+	}
+	defer tx.Rollback()
+	now := timestamp.Now()
+	for _, t := range turns {
+		if _, err := tx.Exec(`INSERT OR REPLACE INTO finetune_exports (provenance_id, turn_id, batch, exported_at) VALUES (?, ?, ?, ?)`,
+			t.ProvenanceID, t.Record.TurnID, batch, now); err != nil {
+			return fmt.Errorf("mark turn %s exported: %w", t.Record.TurnID, err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit export mark: %w", err)
+	}
+	return nil
+}
+
+// #endregion export-log
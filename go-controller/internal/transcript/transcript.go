@@ -0,0 +1,179 @@
+package transcript
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/logging"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/timestamp"
+)
+
+// #region types
+
+// Message is one OpenAI-style chat message. Metadata is an extension field that
+// links the message back to controller provenance; tools that only read role and
+// content ignore it.
+type Message struct {
+	Role     string           `json:"role"` // "system" | "user" | "assistant"
+	Content  string           `json:"content"`
+	Metadata *MessageMetadata `json:"metadata,omitempty"`
+}
+
+// MessageMetadata ties a message to the state version and gate decision of its turn.
+type MessageMetadata struct {
+	TurnID        string  `json:"turn_id"`
+	VersionID     string  `json:"version_id"`
+	ProvenanceID  int64   `json:"provenance_id"`
+	Decision      string  `json:"decision,omitempty"` // provenance decision: commit | reject | no_op
+	GateAction    string  `json:"gate_action,omitempty"`
+	GateSoftScore float32 `json:"gate_soft_score,omitempty"`
+	GateVetoed    bool    `json:"gate_vetoed,omitempty"`
+	Entropy       float32 `json:"entropy,omitempty"`
+	CreatedAt     string  `json:"created_at,omitempty"`
+}
+
+// Conversation is a full session in the chat-completions "messages" shape.
+type Conversation struct {
+	Messages []Message `json:"messages"`
+}
+
+// Turn is one logged user turn with its provenance context.
+type Turn struct {
+	ProvenanceID int64
+	VersionID    string
+	Decision     string
+	CreatedAt    time.Time
+	Record       logging.GateRecord
+}
+
+// #endregion types
+
+// #region build
+
+// turnMessages renders a turn as system (when a state block was injected), user,
+// and assistant messages. Only the assistant message carries gate metadata; system
+// and user messages carry the turn and version link.
+func turnMessages(t Turn) []Message {
+	link := &MessageMetadata{TurnID: t.Record.TurnID, VersionID: t.VersionID, ProvenanceID: t.ProvenanceID}
+	var msgs []Message
+	if t.Record.StateBlock != "" {
+		msgs = append(msgs, Message{Role: "system", Content: t.Record.StateBlock, Metadata: link})
+	}
+	msgs = append(msgs, Message{Role: "user", Content: t.Record.Prompt, Metadata: link})
+
+	meta := *link
+	meta.Decision = t.Decision
+	meta.GateAction = t.Record.GateAction
+	meta.GateSoftScore = t.Record.GateSoftScore
+	meta.GateVetoed = t.Record.GateVetoed
+	meta.Entropy = t.Record.Entropy
+	if !t.CreatedAt.IsZero() {
+		meta.CreatedAt = t.CreatedAt.UTC().Format(time.RFC3339)
+	}
+	msgs = append(msgs, Message{Role: "assistant", Content: t.Record.Response, Metadata: &meta})
+	return msgs
+}
+
+// BuildConversation renders turns, in order, as a single conversation.
+func BuildConversation(turns []Turn) Conversation {
+	conv := Conversation{Messages: []Message{}}
+	for _, t := range turns {
+		conv.Messages = append(conv.Messages, turnMessages(t)...)
+	}
+	return conv
+}
+
+func stripMetadata(msgs []Message) {
+	for i := range msgs {
+		msgs[i].Metadata = nil
+	}
+}
+
+// #endregion build
+
+// #region load
+
+// LoadTurns reads GateRecord-format user_turn rows from provenance_log in
+// chronological order. last > 0 keeps only the most recent N turns. Rows that
+// predate GateRecord logging and private-turn markers are skipped, before the
+// last N are taken, so they never crowd out exportable turns.
+func LoadTurns(db *sql.DB, last int) ([]Turn, error) {
+	rows, err := db.Query(
+		`SELECT id, version_id, signals_json, decision, created_at FROM provenance_log
+		WHERE trigger_type = 'user_turn' ORDER BY id ASC`,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("query provenance: %w", err)
+	}
+	defer rows.Close()
+
+	var turns []Turn
+	for rows.Next() {
+		var t Turn
+		var sigJSON sql.NullString
+		var ts string
+		if err := rows.Scan(&t.ProvenanceID, &t.VersionID, &sigJSON, &t.Decision, &ts); err != nil {
+			return nil, fmt.Errorf("scan provenance row: %w", err)
+		}
+		if !sigJSON.Valid || sigJSON.String == "" {
+			continue
+		}
+		if err := json.Unmarshal([]byte(sigJSON.String), &t.Record); err != nil || t.Record.TurnID == "" {
+			continue // not GateRecord format
+		}
+		if t.Record.Private {
+			continue // redacted marker, nothing to export
+		}
+		t.CreatedAt, _ = timestamp.Parse(ts)
+		turns = append(turns, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if last > 0 && len(turns) > last {
+		turns = turns[len(turns)-last:]
+	}
+	return turns, nil
+}
+
+// #endregion load
+
+// #region write
+
+// WriteJSON writes turns as one indented conversation document. withMetadata=false
+// strips metadata for consumers that reject unknown fields.
+func WriteJSON(w io.Writer, turns []Turn, withMetadata bool) error {
+	conv := BuildConversation(turns)
+	if !withMetadata {
+		stripMetadata(conv.Messages)
+	}
+	data, err := json.MarshalIndent(conv, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal conversation: %w", err)
+	}
+	if _, err := w.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("write conversation: %w", err)
+	}
+	return nil
+}
+
+// WriteJSONL writes one {"messages": [...]} line per turn — the per-example layout
+// used by fine-tuning and eval pipelines.
+func WriteJSONL(w io.Writer, turns []Turn, withMetadata bool) error {
+	enc := json.NewEncoder(w)
+	for _, t := range turns {
+		msgs := turnMessages(t)
+		if !withMetadata {
+			stripMetadata(msgs)
+		}
+		if err := enc.Encode(Conversation{Messages: msgs}); err != nil {
+			return fmt.Errorf("write turn %s: %w", t.Record.TurnID, err)
+		}
+	}
+	return nil
+}
+
+// #endregion write
@@ -0,0 +1,179 @@
+package transcript
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/logging"
+	"github.com/danielpatrickdp/adaptive-state/go-controller/internal/state"
+)
+
+// #region helpers
+
+func testStore(t *testing.T) *state.Store {
+	t.Helper()
+	store, err := state.NewStore(":memory:")
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	return store
+}
+
+func logTurn(t *testing.T, store *state.Store, versionID string, rec logging.GateRecord, decision string) {
+	t.Helper()
+	data, _ := json.Marshal(rec)
+	if err := logging.LogDecision(store.DB(), logging.ProvenanceEntry{
+		VersionID: versionID, TriggerType: "user_turn", SignalsJSON: string(data), Decision: decision,
+	}); err != nil {
+		t.Fatalf("log decision: %v", err)
+	}
+}
+
+// #endregion helpers
+
+// #region build-tests
+
+func TestBuildConversation_RolesAndMetadata(t *testing.T) {
+	turns := []Turn{
+		{ProvenanceID: 1, VersionID: "v1", Decision: "commit", Record: logging.GateRecord{
+			TurnID: "turn-1", Prompt: "hi", Response: "hello", StateBlock: "[ADAPTIVE STATE]\n- Be brief",
+			GateAction: "commit", GateSoftScore: 0.8,
+		}},
+		{ProvenanceID: 2, VersionID: "v2", Decision: "reject", Record: logging.GateRecord{
+			TurnID: "turn-2", Prompt: "again", Response: "sure", GateAction: "reject", GateVetoed: true,
+		}},
+	}
+	conv := BuildConversation(turns)
+
+	var roles []string
+	for _, m := range conv.Messages {
+		roles = append(roles, m.Role)
+	}
+	if got := strings.Join(roles, ","); got != "system,user,assistant,user,assistant" {
+		t.Fatalf("unexpected roles: %s", got)
+	}
+	a := conv.Messages[2]
+	if a.Content != "hello" || a.Metadata.VersionID != "v1" || a.Metadata.Decision != "commit" || a.Metadata.GateSoftScore != 0.8 {
+		t.Errorf("unexpected assistant message: %+v %+v", a, a.Metadata)
+	}
+	if u := conv.Messages[3]; u.Metadata.TurnID != "turn-2" || u.Metadata.Decision != "" {
+		t.Errorf("user message should link the turn without gate fields: %+v", u.Metadata)
+	}
+	if last := conv.Messages[4].Metadata; !last.GateVetoed || last.ProvenanceID != 2 {
+		t.Errorf("unexpected last metadata: %+v", last)
+	}
+}
+
+func TestWriteJSONL_OneExamplePerTurn(t *testing.T) {
+	turns := []Turn{
+		{VersionID: "v1", Record: logging.GateRecord{TurnID: "t1", Prompt: "a", Response: "b"}},
+		{VersionID: "v2", Record: logging.GateRecord{TurnID: "t2", Prompt: "c", Response: "d"}},
+	}
+	var buf bytes.Buffer
+	if err := WriteJSONL(&buf, turns, false); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 lines, got %d", len(lines))
+	}
+	if strings.Contains(buf.String(), "metadata") {
+		t.Errorf("expected metadata stripped, got %s", buf.String())
+	}
+	var conv Conversation
+	if err := json.Unmarshal([]byte(lines[1]), &conv); err != nil || len(conv.Messages) != 2 || conv.Messages[0].Content != "c" {
+		t.Errorf("unexpected second example: %+v err=%v", conv, err)
+	}
+}
+
+// #endregion build-tests
+
+// #region load-tests
+
+func TestLoadTurns(t *testing.T) {
+	store := testStore(t)
+	initial, err := store.CreateInitialState(state.DefaultSegmentMap())
+	if err != nil {
+		t.Fatalf("create initial state: %v", err)
+	}
+	v := initial.VersionID
+	logTurn(t, store, v, logging.GateRecord{TurnID: "t1", Prompt: "one"}, "commit")
+	logTurn(t, store, v, logging.GateRecord{}, "no_op")                            // pre-GateRecord row: skipped
+	logTurn(t, store, v, logging.GateRecord{TurnID: "tp", Private: true}, "no_op") // private turn marker: skipped
+	logTurn(t, store, v, logging.GateRecord{TurnID: "t2", Prompt: "two"}, "reject")
+	logTurn(t, store, v, logging.GateRecord{TurnID: "t3", Prompt: "three"}, "commit")
+	logging.LogDecision(store.DB(), logging.ProvenanceEntry{VersionID: v, TriggerType: "suggestion", Decision: "commit"})
+
+	turns, err := LoadTurns(store.DB(), 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(turns) != 3 || turns[0].Record.TurnID != "t1" || turns[2].Record.TurnID != "t3" {
+		t.Fatalf("expected t1..t3 in order, got %+v", turns)
+	}
+	if turns[1].VersionID != v || turns[1].Decision != "reject" || turns[1].CreatedAt.IsZero() {
+		t.Errorf("unexpected provenance fields: %+v", turns[1])
+	}
+
+	recent, _ := LoadTurns(store.DB(), 2)
+	if len(recent) != 2 || recent[0].Record.TurnID != "t2" {
+		t.Errorf("expected last 2 turns starting at t2, got %+v", recent)
+	}
+	// Skipped rows do not count toward the last N
+	if recent, _ := LoadTurns(store.DB(), 3); len(recent) != 3 || recent[0].Record.TurnID != "t1" {
+		t.Errorf("expected the last 3 exportable turns starting at t1, got %+v", recent)
+	}
+}
+
+// #endregion load-tests
+
+// #region finetune-tests
+
+func TestSelect_HighQualityCommitsOnly(t *testing.T) {
+	good := logging.GateRecord{TurnID: "good", Response: "ok", GateAction: "commit", GateSoftScore: 0.8,
+		Signals: logging.GateRecordSignals{SentimentScore: 0.7}}
+	lowScore, noComply, scaled, vetoed, empty := good, good, good, good, good
+	lowScore.TurnID, lowScore.GateSoftScore = "low", 0.5
+	noComply.TurnID, noComply.Signals.SentimentScore = "noncompliant", 0.2
+	scaled.TurnID, scaled.EvalScale = "scaled", 0.5
+	vetoed.TurnID, vetoed.GateAction, vetoed.GateVetoed = "vetoed", "reject", true
+	empty.TurnID, empty.Response = "empty", ""
+	turns := []Turn{
+		{ProvenanceID: 1, Decision: "commit", Record: good},
+		{ProvenanceID: 2, Decision: "reject", Record: good},
+		{ProvenanceID: 3, Decision: "commit", Record: lowScore},
+		{ProvenanceID: 4, Decision: "commit", Record: noComply},
+		{ProvenanceID: 5, Decision: "commit", Record: scaled},
+		{ProvenanceID: 6, Decision: "commit", Record: vetoed},
+		{ProvenanceID: 7, Decision: "commit", Record: empty},
+		{ProvenanceID: 8, Decision: "commit", Record: good},
+	}
+	got := Select(turns, DefaultCriteria(), map[int64]bool{8: true})
+	if len(got) != 1 || got[0].ProvenanceID != 1 {
+		t.Fatalf("selected %+v, want only provenance 1", got)
+	}
+}
+
+func TestExportLog_TracksExportedTurns(t *testing.T) {
+	store := testStore(t)
+	exports, err := NewExportLog(store.DB())
+	if err != nil {
+		t.Fatalf("new export log: %v", err)
+	}
+	turns := []Turn{{ProvenanceID: 3, Record: logging.GateRecord{TurnID: "t3"}}, {ProvenanceID: 5, Record: logging.GateRecord{TurnID: "t5"}}}
+	if err := exports.Mark(turns, "batch-1"); err != nil {
+		t.Fatalf("mark: %v", err)
+	}
+	if err := exports.Mark(turns[:1], "batch-2"); err != nil {
+		t.Fatalf("re-mark: %v", err)
+	}
+	exported, err := exports.Exported()
+	if err != nil || len(exported) != 2 || !exported[3] || !exported[5] {
+		t.Fatalf("exported = %v, %v", exported, err)
+	}
+}
+
+// #endregion finetune-tests
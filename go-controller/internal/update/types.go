@@ -23,12 +23,30 @@ type Signals struct {
 	ToolFailure         bool // Phase 3: tool/verifier reported failure
 	ConstraintViolation bool // Phase 3: detected contradiction with constraints
 
+	// PlanProgress is the fraction of the active plan completed this turn (0 = no progress).
+	// Reinforces the goals segment alongside CoherenceScore.
+	PlanProgress float32
+
 	// DirectionVectors provides semantic delta directions per segment.
 	// Keys: "prefs", "goals", "heuristics", "risk".
 	// Each slice must match the segment size (32 elements).
 	// When present, used instead of sign(existing) for delta direction.
 	// Must be L2-normalized before setting.
 	DirectionVectors map[string][]float32
+
+	// Corrections are negative reinforcement targets carried from a corrected turn:
+	// each pushes its segment away from an offending direction.
+	Corrections []Correction
+}
+
+// Correction asks the update to move one segment away from Direction.
+// Direction must match the segment size; nil means "away from the segment's current
+// values" (shrinks it toward zero without flipping sign). Strength is clamped to [0, 1].
+type Correction struct {
+	Segment   string // "prefs" | "goals" | "heuristics" | "risk"
+	Direction []float32
+	Strength  float32
+	Reason    string
 }
 // #endregion signals
 
@@ -46,6 +64,9 @@ type SegmentMetric struct {
 	Name      string
 	DeltaNorm float32
 	DecayNorm float32 // L2 norm of decay applied this turn
+
+	// NegativeNorm is the L2 norm of the correction (opposite-direction) delta.
+	NegativeNorm float32
 }
 
 // Metrics captures telemetry from an update cycle.
@@ -64,6 +85,7 @@ type UpdateConfig struct {
 	DecayRate              float32 // per-element multiplicative decay (default 0.005)
 	MaxDeltaNormPerSegment float32 // L2 clamp per segment (default 1.0)
 	MaxStateNorm           float32 // post-update L2 cap on full state vector (0 = disabled)
+	MaxNegativeDeltaNorm   float32 // L2 clamp on a segment's combined correction delta (0 = corrections disabled)
 }
 
 // DefaultUpdateConfig returns sensible defaults for Phase 4.
@@ -73,6 +95,7 @@ func DefaultUpdateConfig() UpdateConfig {
 		DecayRate:              0.005,
 		MaxDeltaNormPerSegment: 1.0,
 		MaxStateNorm:           3.0,
+		MaxNegativeDeltaNorm:   0.5,
 	}
 }
 // #endregion update-config
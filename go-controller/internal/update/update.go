@@ -34,7 +34,7 @@ func Update(old state.StateRecord, ctx UpdateContext, signals Signals, evidence
 	// Determine which segments are reinforced this turn
 	reinforced := map[string]bool{
 		"prefs":      signals.SentimentScore > 0,
-		"goals":      signals.CoherenceScore > 0,
+		"goals":      signals.CoherenceScore > 0 || signals.PlanProgress > 0,
 		"heuristics": signals.NoveltyScore > 0,
 		"risk":       ctx.Entropy > 0,
 	}
@@ -47,9 +47,14 @@ func Update(old state.StateRecord, ctx UpdateContext, signals Signals, evidence
 	if entropySignal > 1 {
 		entropySignal = 1
 	}
+	// Plan progress reinforces goals on top of coherence, capped at 1
+	goalsSignal := signals.CoherenceScore + signals.PlanProgress
+	if goalsSignal > 1 {
+		goalsSignal = 1
+	}
 	signalMap := map[string]float32{
 		"prefs":      signals.SentimentScore,
-		"goals":      signals.CoherenceScore,
+		"goals":      goalsSignal,
 		"heuristics": signals.NoveltyScore,
 		"risk":       entropySignal,
 	}
@@ -131,14 +136,21 @@ func Update(old state.StateRecord, ctx UpdateContext, signals Signals, evidence
 			segmentsHit = append(segmentsHit, s.name)
 		}
 
+		// 3. Negative pass: corrections push the segment away from the offending direction
+		negNorm := applyCorrections(vec[s.lo:s.hi], s.name, signals.Corrections, config)
+		if negNorm > 0 && deltaNorm == 0 {
+			segmentsHit = append(segmentsHit, s.name)
+		}
+
 		segmentMetrics = append(segmentMetrics, SegmentMetric{
-			Name:      s.name,
-			DeltaNorm: deltaNorm,
-			DecayNorm: decayNorm,
+			Name:         s.name,
+			DeltaNorm:    deltaNorm,
+			DecayNorm:    decayNorm,
+			NegativeNorm: negNorm,
 		})
 	}
 
-	// 3. Compute total delta norm (new - old)
+	// 4. Compute total delta norm (new - old)
 	var totalDeltaSumSq float32
 	for i := 0; i < len(vec); i++ {
 		d := vec[i] - old.StateVector[i]
@@ -146,7 +158,7 @@ func Update(old state.StateRecord, ctx UpdateContext, signals Signals, evidence
 	}
 	totalDeltaNorm := float32(math.Sqrt(float64(totalDeltaSumSq)))
 
-	// 4. Build result
+	// 5. Build result
 	newRec := state.StateRecord{
 		VersionID:   uuid.New().String(),
 		ParentID:    old.VersionID,
@@ -172,7 +184,7 @@ func Update(old state.StateRecord, ctx UpdateContext, signals Signals, evidence
 		UpdateTimeMs:   elapsed,
 	}
 
-	// 5. State normalization cap — preserves direction, prevents magnitude runaway
+	// 6. State normalization cap — preserves direction, prevents magnitude runaway
 	if config.MaxStateNorm > 0 {
 		var sumSq float32
 		for _, v := range newRec.StateVector {
@@ -195,3 +207,80 @@ func Update(old state.StateRecord, ctx UpdateContext, signals Signals, evidence
 }
 
 // #endregion update-function
+
+// #region corrections
+
+// applyCorrections subtracts the combined correction delta for segment name from
+// seg in place and returns its L2 norm. Each correction contributes
+// LearningRate * Strength along its normalized direction; the sum is clamped to
+// MaxNegativeDeltaNorm, and direction-less corrections never exceed the segment's
+// own norm (so they shrink it without flipping it).
+func applyCorrections(seg []float32, name string, corrections []Correction, config UpdateConfig) float32 {
+	if config.MaxNegativeDeltaNorm <= 0 || config.LearningRate <= 0 {
+		return 0
+	}
+	size := len(seg)
+	delta := make([]float32, size)
+	var segNorm float32
+	for _, v := range seg {
+		segNorm += v * v
+	}
+	segNorm = float32(math.Sqrt(float64(segNorm)))
+
+	limit := config.MaxNegativeDeltaNorm
+	applied := false
+	for _, c := range corrections {
+		if c.Segment != name || c.Strength <= 0 {
+			continue
+		}
+		strength := c.Strength
+		if strength > 1 {
+			strength = 1
+		}
+		dir := c.Direction
+		if len(dir) != size {
+			// Away from the current disposition; bounded by its magnitude
+			if segNorm == 0 {
+				continue
+			}
+			dir = seg
+			if segNorm < limit {
+				limit = segNorm
+			}
+		}
+		var dirNormSq float64
+		for _, d := range dir {
+			dirNormSq += float64(d) * float64(d)
+		}
+		if dirNormSq == 0 {
+			continue
+		}
+		dirNorm := float32(math.Sqrt(dirNormSq))
+		for i := range delta {
+			delta[i] += config.LearningRate * strength * dir[i] / dirNorm
+		}
+		applied = true
+	}
+	if !applied {
+		return 0
+	}
+
+	var sumSq float32
+	for _, d := range delta {
+		sumSq += d * d
+	}
+	norm := float32(math.Sqrt(float64(sumSq)))
+	if norm > limit {
+		scale := limit / norm
+		for i := range delta {
+			delta[i] *= scale
+		}
+		norm = limit
+	}
+	for i := range seg {
+		seg[i] -= delta[i]
+	}
+	return norm
+}
+
+// #endregion corrections
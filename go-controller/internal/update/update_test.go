@@ -644,3 +644,109 @@ func TestDirectionVector_NilMapFallsBack(t *testing.T) {
 }
 
 // #endregion direction-vector-tests
+
+func TestPlanProgressReinforcesGoals(t *testing.T) {
+	old := state.StateRecord{
+		VersionID:  "v1",
+		SegmentMap: state.DefaultSegmentMap(),
+	}
+	for i := 0; i < 128; i++ {
+		old.StateVector[i] = 1.0
+	}
+
+	sig := Signals{PlanProgress: 0.5}
+	cfg := UpdateConfig{LearningRate: 0, DecayRate: 0.1, MaxDeltaNormPerSegment: 1.0}
+
+	result := Update(old, UpdateContext{TurnID: "turn-1"}, sig, nil, cfg)
+
+	// Goals (32-63): plan progress alone reinforces → no decay
+	for i := 32; i < 64; i++ {
+		if result.NewState.StateVector[i] != 1.0 {
+			t.Fatalf("goals index %d should be preserved by plan progress, got %.4f", i, result.NewState.StateVector[i])
+		}
+	}
+}
+
+// #region correction-tests
+
+func TestCorrection_PushesAwayFromDirection(t *testing.T) {
+	old := state.StateRecord{
+		VersionID:  "v1",
+		SegmentMap: state.DefaultSegmentMap(),
+	}
+	dir := make([]float32, 32)
+	dir[0] = 3.0 // normalized to a unit vector along index 0
+
+	sig := Signals{Corrections: []Correction{{Segment: "prefs", Direction: dir, Strength: 1}}}
+	cfg := UpdateConfig{LearningRate: 0.1, MaxDeltaNormPerSegment: 1.0, MaxNegativeDeltaNorm: 0.5}
+
+	result := Update(old, UpdateContext{TurnID: "turn-1"}, sig, nil, cfg)
+
+	if got := result.NewState.StateVector[0]; math.Abs(float64(got)+0.1) > 1e-6 {
+		t.Fatalf("expected prefs[0] pushed to -0.1, got %.6f", got)
+	}
+	if result.NewState.StateVector[32] != 0 {
+		t.Fatal("correction must not touch other segments")
+	}
+	if result.Decision.Action != "commit" || len(result.Metrics.SegmentsHit) != 1 || result.Metrics.SegmentsHit[0] != "prefs" {
+		t.Fatalf("expected commit hitting prefs, got %s %v", result.Decision.Action, result.Metrics.SegmentsHit)
+	}
+	if m := result.Metrics.SegmentMetrics[0]; math.Abs(float64(m.NegativeNorm)-0.1) > 1e-6 || m.DeltaNorm != 0 {
+		t.Fatalf("unexpected prefs metric: %+v", m)
+	}
+}
+
+func TestCorrection_ClampedToMaxNegativeNorm(t *testing.T) {
+	old := state.StateRecord{
+		VersionID:  "v1",
+		SegmentMap: state.DefaultSegmentMap(),
+	}
+	dir := make([]float32, 32)
+	dir[1] = 1.0
+	sig := Signals{Corrections: []Correction{
+		{Segment: "goals", Direction: dir, Strength: 5}, // strength clamped to 1
+		{Segment: "goals", Direction: dir, Strength: 1},
+	}}
+	cfg := UpdateConfig{LearningRate: 0.2, MaxDeltaNormPerSegment: 1.0, MaxNegativeDeltaNorm: 0.25}
+
+	result := Update(old, UpdateContext{TurnID: "turn-1"}, sig, nil, cfg)
+
+	if got := result.NewState.StateVector[33]; math.Abs(float64(got)+0.25) > 1e-6 {
+		t.Fatalf("expected combined push clamped to -0.25, got %.6f", got)
+	}
+}
+
+func TestCorrection_NilDirectionShrinksWithoutFlipping(t *testing.T) {
+	old := state.StateRecord{
+		VersionID:  "v1",
+		SegmentMap: state.DefaultSegmentMap(),
+	}
+	old.StateVector[64] = 0.05 // heuristics, norm 0.05
+
+	sig := Signals{Corrections: []Correction{{Segment: "heuristics", Strength: 1}}}
+	cfg := UpdateConfig{LearningRate: 1.0, MaxDeltaNormPerSegment: 1.0, MaxNegativeDeltaNorm: 0.5}
+
+	result := Update(old, UpdateContext{TurnID: "turn-1"}, sig, nil, cfg)
+
+	if got := result.NewState.StateVector[64]; got < 0 || got > 1e-6 {
+		t.Fatalf("expected heuristics shrunk to 0 without flipping, got %.6f", got)
+	}
+}
+
+func TestCorrection_DisabledByZeroClamp(t *testing.T) {
+	old := state.StateRecord{
+		VersionID:  "v1",
+		SegmentMap: state.DefaultSegmentMap(),
+	}
+	old.StateVector[0] = 0.5
+	sig := Signals{Corrections: []Correction{{Segment: "prefs", Strength: 1}}}
+
+	result := Update(old, UpdateContext{TurnID: "turn-1"}, sig, nil, zeroConfig())
+
+	if result.Decision.Action != "no_op" || result.NewState.StateVector[0] != 0.5 {
+		t.Fatalf("expected corrections ignored without MaxNegativeDeltaNorm, got %s %.4f",
+			result.Decision.Action, result.NewState.StateVector[0])
+	}
+}
+
+// #endregion correction-tests
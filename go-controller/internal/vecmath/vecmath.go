@@ -0,0 +1,53 @@
+package vecmath
+
+import "math"
+
+// #region vecmath
+
+// Cosine returns the cosine similarity of a and b, summed in float64. ok is
+// false, with sim 0, when the lengths differ (e.g. an embedding model change),
+// either is empty, or either is zero.
+func Cosine(a, b []float32) (sim float64, ok bool) {
+	if len(a) != len(b) || len(a) == 0 {
+		return 0, false
+	}
+	var d, na, nb float64
+	for i := range a {
+		d += float64(a[i]) * float64(b[i])
+		na += float64(a[i]) * float64(a[i])
+		nb += float64(b[i]) * float64(b[i])
+	}
+	if na == 0 || nb == 0 {
+		return 0, false
+	}
+	return d / (math.Sqrt(na) * math.Sqrt(nb)), true
+}
+
+// Normalize returns v scaled to unit length in float64; a zero vector stays
+// zero.
+func Normalize(v []float32) []float64 {
+	out := make([]float64, len(v))
+	var sq float64
+	for i, x := range v {
+		out[i] = float64(x)
+		sq += out[i] * out[i]
+	}
+	if norm := math.Sqrt(sq); norm > 0 {
+		for i := range out {
+			out[i] /= norm
+		}
+	}
+	return out
+}
+
+// Dot returns the dot product of a and b over their common length; for unit
+// vectors from Normalize it is their cosine similarity.
+func Dot(a, b []float64) float64 {
+	var s float64
+	for i := range min(len(a), len(b)) {
+		s += a[i] * b[i]
+	}
+	return s
+}
+
+// #endregion vecmath
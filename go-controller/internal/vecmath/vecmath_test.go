@@ -0,0 +1,44 @@
+package vecmath
+
+import (
+	"math"
+	"testing"
+)
+
+func TestCosine(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b []float32
+		sim  float64
+		ok   bool
+	}{
+		{"orthogonal", []float32{1, 0}, []float32{0, 1}, 0, true},
+		{"parallel", []float32{2, 0}, []float32{1, 0}, 1, true},
+		{"opposite", []float32{1, 1}, []float32{-2, -2}, -1, true},
+		{"zero vector", []float32{0, 0}, []float32{1, 0}, 0, false},
+		{"length mismatch", []float32{1, 0, 0}, []float32{1, 0}, 0, false},
+		{"empty", nil, nil, 0, false},
+	}
+	for _, tt := range tests {
+		sim, ok := Cosine(tt.a, tt.b)
+		if ok != tt.ok || math.Abs(sim-tt.sim) > 1e-9 {
+			t.Errorf("%s: Cosine = %v, %v; want %v, %v", tt.name, sim, ok, tt.sim, tt.ok)
+		}
+	}
+}
+
+func TestNormalizeAndDot(t *testing.T) {
+	u := Normalize([]float32{3, 4})
+	if math.Abs(u[0]-0.6) > 1e-9 || math.Abs(u[1]-0.8) > 1e-9 {
+		t.Fatalf("Normalize = %v", u)
+	}
+	if d := Dot(u, u); math.Abs(d-1) > 1e-9 {
+		t.Errorf("unit Dot = %v, want 1", d)
+	}
+	if z := Normalize([]float32{0, 0}); z[0] != 0 || z[1] != 0 {
+		t.Errorf("zero vector normalized to %v", z)
+	}
+	if d := Dot([]float64{1, 2, 3}, []float64{1, 1}); d != 3 {
+		t.Errorf("Dot over the common length = %v, want 3", d)
+	}
+}
@@ -0,0 +1,158 @@
+package preprocess
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// #region email
+
+var (
+	emailHeader   = regexp.MustCompile(`(?i)^(from|sent|to|cc|bcc|date|subject|reply-to):\s`)
+	replyMarker   = regexp.MustCompile(`(?i)^(on .+ wrote:|-+\s*original message\s*-+|-+\s*forwarded message\s*-+)$`)
+	mobileTrailer = regexp.MustCompile(`(?i)^sent from my \w+`)
+)
+
+// StripEmail removes pasted-email boilerplate: header lines, quoted replies
+// ("> ..."), everything after a reply or forward marker, mobile trailers, and a
+// trailing "-- " signature. Text without any of these is returned unchanged.
+func StripEmail(text string) (string, error) {
+	var kept []string
+	for _, line := range strings.Split(text, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if replyMarker.MatchString(trimmed) || trimmed == "--" || line == "-- " {
+			break // the rest is the quoted thread or the signature
+		}
+		if strings.HasPrefix(trimmed, ">") || emailHeader.MatchString(trimmed) || mobileTrailer.MatchString(trimmed) {
+			continue
+		}
+		kept = append(kept, line)
+	}
+	out := strings.TrimSpace(strings.Join(kept, "\n"))
+	if out == strings.TrimSpace(text) {
+		return text, nil
+	}
+	return out, nil
+}
+
+// #endregion email
+
+// #region whitespace
+
+var blankRun = regexp.MustCompile(`\n{3,}`)
+
+// NormalizeWhitespace trims trailing spaces on each line and collapses runs of
+// blank lines to one.
+func NormalizeWhitespace(text string) (string, error) {
+	lines := strings.Split(text, "\n")
+	for i, l := range lines {
+		lines[i] = strings.TrimRight(l, " \t\r")
+	}
+	return strings.TrimSpace(blankRun.ReplaceAllString(strings.Join(lines, "\n"), "\n\n")), nil
+}
+
+// #endregion whitespace
+
+// #region macros
+
+type macro struct {
+	pattern     *regexp.Regexp
+	replacement string
+}
+
+// Macros expands shorthand phrases ("std reply") into their full text. Matching
+// is case-insensitive on whole words; longer shorthands win over prefixes of them.
+type Macros struct {
+	macros []macro
+}
+
+// NewMacros builds an expander from shorthand → expansion pairs.
+func NewMacros(m map[string]string) *Macros {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		if strings.TrimSpace(k) != "" {
+			keys = append(keys, k)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if len(keys[i]) != len(keys[j]) {
+			return len(keys[i]) > len(keys[j])
+		}
+		return keys[i] < keys[j]
+	})
+	x := &Macros{}
+	for _, k := range keys {
+		x.macros = append(x.macros, macro{
+			pattern:     regexp.MustCompile(`(?i)\b` + regexp.QuoteMeta(strings.TrimSpace(k)) + `\b`),
+			replacement: m[k],
+		})
+	}
+	return x
+}
+
+// Name implements Preprocessor.
+func (x *Macros) Name() string { return "macros" }
+
+// Process implements Preprocessor. Expansions are not re-expanded.
+func (x *Macros) Process(text string) (string, error) {
+	type span struct{ start, end int }
+	var taken []span
+	type hit struct {
+		span
+		repl string
+	}
+	var hits []hit
+	for _, m := range x.macros {
+	match:
+		for _, loc := range m.pattern.FindAllStringIndex(text, -1) {
+			for _, t := range taken {
+				if loc[0] < t.end && t.start < loc[1] {
+					continue match
+				}
+			}
+			s := span{loc[0], loc[1]}
+			taken = append(taken, s)
+			hits = append(hits, hit{s, m.replacement})
+		}
+	}
+	if len(hits) == 0 {
+		return text, nil
+	}
+	sort.Slice(hits, func(i, j int) bool { return hits[i].start < hits[j].start })
+	var b strings.Builder
+	prev := 0
+	for _, h := range hits {
+		b.WriteString(text[prev:h.start])
+		b.WriteString(h.repl)
+		prev = h.end
+	}
+	b.WriteString(text[prev:])
+	return b.String(), nil
+}
+
+// newMacroPreprocessor loads macros from the JSON object file named by arg,
+// or by PREPROCESS_MACROS when arg is empty.
+func newMacroPreprocessor(arg string) (Preprocessor, error) {
+	path := arg
+	if path == "" {
+		path = os.Getenv("PREPROCESS_MACROS")
+	}
+	if path == "" {
+		return nil, fmt.Errorf("macros need a file: macros:/path/to/macros.json or PREPROCESS_MACROS")
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read macros: %w", err)
+	}
+	var m map[string]string
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("parse macros %s: %w", path, err)
+	}
+	return NewMacros(m), nil
+}
+
+// #endregion macros
@@ -0,0 +1,68 @@
+package preprocess
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// #region email-tests
+func TestStripEmail(t *testing.T) {
+	in := "From: Dana <dana@example.com>\nSubject: Re: launch\n\nCan we move the launch to Friday?\n\n-- \nDana\nHead of Ops\n"
+	out, _ := StripEmail(in)
+	if out != "Can we move the launch to Friday?" {
+		t.Errorf("expected headers and signature stripped, got %q", out)
+	}
+
+	in = "Sounds good.\nSent from my iPhone\n\nOn Tue, Mar 3, 2026 at 9:00 AM Sam wrote:\n> Are we still on?\n"
+	if out, _ := StripEmail(in); out != "Sounds good." {
+		t.Errorf("expected quoted thread stripped, got %q", out)
+	}
+
+	plain := "what is the capital of France?"
+	if out, _ := StripEmail(plain); out != plain {
+		t.Errorf("plain prompt should be unchanged, got %q", out)
+	}
+}
+
+// #endregion email-tests
+
+// #region whitespace-tests
+func TestNormalizeWhitespace(t *testing.T) {
+	out, _ := NormalizeWhitespace("  line one   \n\n\n\nline two\t\n")
+	if out != "line one\n\nline two" {
+		t.Errorf("got %q", out)
+	}
+}
+
+// #endregion whitespace-tests
+
+// #region macro-tests
+func TestMacros(t *testing.T) {
+	x := NewMacros(map[string]string{
+		"std reply":      "Thanks, I'll look at it today.",
+		"std reply long": "Thanks, I'll look at it today and follow up tomorrow.",
+		"asap":           "as soon as possible",
+	})
+	out, _ := x.Process("Send STD REPLY LONG asap, then std reply. Not asapx.")
+	want := "Send Thanks, I'll look at it today and follow up tomorrow. as soon as possible, then Thanks, I'll look at it today.. Not asapx."
+	if out != want {
+		t.Errorf("got  %q\nwant %q", out, want)
+	}
+}
+
+func TestMacros_FromFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "macros.json")
+	if err := os.WriteFile(path, []byte(`{"brb": "be right back"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	c, err := Build("macros:" + path)
+	if err != nil {
+		t.Fatalf("build: %v", err)
+	}
+	if out, steps := c.Run("brb"); out != "be right back" || len(steps) != 1 || steps[0].Name != "macros" {
+		t.Errorf("got %q %+v", out, steps)
+	}
+}
+
+// #endregion macro-tests
@@ -0,0 +1,167 @@
+package preprocess
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// #region types
+
+// Preprocessor rewrites an incoming prompt before detection, classification
+// and learning see it. Implementations must be safe to call from one goroutine
+// per turn and should return the input unchanged when they have nothing to do.
+type Preprocessor interface {
+	Name() string
+	Process(text string) (string, error)
+}
+
+// Factory builds a preprocessor from the optional argument given after ":" in
+// a PREPROCESSORS entry ("macros:/etc/orac/macros.json").
+type Factory func(arg string) (Preprocessor, error)
+
+// Step records one transformation that changed the prompt, or failed to.
+type Step struct {
+	Name   string
+	Input  string
+	Output string // equal to Input when Err is set
+	Err    string
+}
+
+// funcPreprocessor adapts a plain function; see New.
+type funcPreprocessor struct {
+	name string
+	fn   func(string) (string, error)
+}
+
+func (f funcPreprocessor) Name() string                        { return f.name }
+func (f funcPreprocessor) Process(text string) (string, error) { return f.fn(text) }
+
+// New wraps fn as a Preprocessor called name.
+func New(name string, fn func(string) (string, error)) Preprocessor {
+	return funcPreprocessor{name: name, fn: fn}
+}
+
+// #endregion types
+
+// #region registry
+
+var (
+	registryMu sync.RWMutex
+	registry   = map[string]Factory{
+		"email":      func(string) (Preprocessor, error) { return New("email", StripEmail), nil },
+		"whitespace": func(string) (Preprocessor, error) { return New("whitespace", NormalizeWhitespace), nil },
+		"macros":     newMacroPreprocessor,
+	}
+)
+
+// Register makes a preprocessor available to Build under name, so programs
+// embedding the controller can add their own. It panics if name is taken, like
+// database/sql.Register.
+func Register(name string, f Factory) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	if _, dup := registry[name]; dup {
+		panic("preprocess: Register called twice for " + name)
+	}
+	registry[name] = f
+}
+
+// Registered returns the names Build accepts, sorted.
+func Registered() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	names := make([]string, 0, len(registry))
+	for n := range registry {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Build parses a comma-separated, ordered list of "name[:arg]" entries into a
+// chain. An empty spec yields an empty chain.
+func Build(spec string) (*Chain, error) {
+	c := NewChain()
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		name, arg, _ := strings.Cut(entry, ":")
+		registryMu.RLock()
+		f, ok := registry[name]
+		registryMu.RUnlock()
+		if !ok {
+			return nil, fmt.Errorf("unknown preprocessor %q (registered: %s)", name, strings.Join(Registered(), ", "))
+		}
+		p, err := f(arg)
+		if err != nil {
+			return nil, fmt.Errorf("preprocessor %q: %w", name, err)
+		}
+		c.Append(p)
+	}
+	return c, nil
+}
+
+// #endregion registry
+
+// #region chain
+
+// Chain runs preprocessors in order, each seeing the previous one's output. A
+// nil *Chain passes text through untouched.
+type Chain struct {
+	steps []Preprocessor
+}
+
+// NewChain returns a chain of ps, in order.
+func NewChain(ps ...Preprocessor) *Chain {
+	return &Chain{steps: ps}
+}
+
+// Append adds p to the end of the chain and returns c.
+func (c *Chain) Append(p Preprocessor) *Chain {
+	c.steps = append(c.steps, p)
+	return c
+}
+
+// Names returns the preprocessor names in run order.
+func (c *Chain) Names() []string {
+	if c == nil {
+		return nil
+	}
+	names := make([]string, len(c.steps))
+	for i, p := range c.steps {
+		names[i] = p.Name()
+	}
+	return names
+}
+
+// Run applies the chain to text and returns the result with one Step per
+// preprocessor that changed the text or failed. A preprocessor that errors or
+// would leave nothing but whitespace is skipped, so Run never returns an empty
+// prompt for non-empty input.
+func (c *Chain) Run(text string) (string, []Step) {
+	if c == nil {
+		return text, nil
+	}
+	var steps []Step
+	for _, p := range c.steps {
+		out, err := p.Process(text)
+		if err == nil && strings.TrimSpace(out) == "" && strings.TrimSpace(text) != "" {
+			err = fmt.Errorf("output would be empty")
+		}
+		if err != nil {
+			steps = append(steps, Step{Name: p.Name(), Input: text, Output: text, Err: err.Error()})
+			continue
+		}
+		if out != text {
+			steps = append(steps, Step{Name: p.Name(), Input: text, Output: out})
+			text = out
+		}
+	}
+	return text, steps
+}
+
+// #endregion chain
@@ -0,0 +1,84 @@
+package preprocess
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// #region chain-tests
+func TestChain_RunRecordsEachChange(t *testing.T) {
+	upper := New("upper", func(s string) (string, error) { return strings.ToUpper(s), nil })
+	noop := New("noop", func(s string) (string, error) { return s, nil })
+	exclaim := New("exclaim", func(s string) (string, error) { return s + "!", nil })
+
+	out, steps := NewChain(upper, noop, exclaim).Run("hi")
+	if out != "HI!" {
+		t.Fatalf("expected chained output, got %q", out)
+	}
+	if len(steps) != 2 || steps[0].Name != "upper" || steps[0].Input != "hi" || steps[0].Output != "HI" ||
+		steps[1].Name != "exclaim" || steps[1].Input != "HI" {
+		t.Errorf("expected one step per change in order, got %+v", steps)
+	}
+}
+
+func TestChain_RunSkipsFailingAndEmptyingSteps(t *testing.T) {
+	fail := New("fail", func(string) (string, error) { return "", errors.New("boom") })
+	erase := New("erase", func(string) (string, error) { return "  ", nil })
+
+	out, steps := NewChain(fail, erase).Run("keep me")
+	if out != "keep me" {
+		t.Fatalf("expected input preserved, got %q", out)
+	}
+	if len(steps) != 2 || steps[0].Err != "boom" || steps[1].Err == "" || steps[1].Output != "keep me" {
+		t.Errorf("expected both failures recorded, got %+v", steps)
+	}
+
+	var nilChain *Chain
+	if out, steps := nilChain.Run("x"); out != "x" || steps != nil {
+		t.Errorf("nil chain should pass through, got %q %+v", out, steps)
+	}
+}
+
+// #endregion chain-tests
+
+// #region registry-tests
+func TestBuild(t *testing.T) {
+	c, err := Build("email, whitespace")
+	if err != nil {
+		t.Fatalf("build: %v", err)
+	}
+	if got := strings.Join(c.Names(), ","); got != "email,whitespace" {
+		t.Errorf("expected ordered chain, got %s", got)
+	}
+	if c, err := Build(""); err != nil || len(c.Names()) != 0 {
+		t.Errorf("empty spec: got %v, %v", c.Names(), err)
+	}
+	if _, err := Build("email,nope"); err == nil || !strings.Contains(err.Error(), "nope") {
+		t.Errorf("expected unknown preprocessor error, got %v", err)
+	}
+	if _, err := Build("macros:/does/not/exist.json"); err == nil {
+		t.Error("expected macros file error")
+	}
+}
+
+func TestRegister(t *testing.T) {
+	Register("test-prefix", func(arg string) (Preprocessor, error) {
+		return New("test-prefix", func(s string) (string, error) { return arg + s, nil }), nil
+	})
+	c, err := Build("test-prefix:>>")
+	if err != nil {
+		t.Fatalf("build: %v", err)
+	}
+	if out, _ := c.Run("hello"); out != ">>hello" {
+		t.Errorf("expected registered preprocessor to run with its arg, got %q", out)
+	}
+	defer func() {
+		if recover() == nil {
+			t.Error("expected duplicate Register to panic")
+		}
+	}()
+	Register("email", nil)
+}
+
+// #endregion registry-tests
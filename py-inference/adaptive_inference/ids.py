@@ -0,0 +1,34 @@
+"""Evidence ID scheme, mirrored by go-controller/internal/evidence/ids.go.
+
+Evidence stored here is "ev_" + a lowercase UUID. IDs written before the scheme
+existed are bare UUIDs and are migrated on startup.
+"""
+
+import re
+import uuid
+
+LOCAL_PREFIX = "ev_"
+
+_LOCAL_RE = re.compile(r"^ev_[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}$")
+_UUID_RE = re.compile(r"^[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}$")
+
+
+def new_id() -> str:
+    """Return a fresh local evidence ID."""
+    return LOCAL_PREFIX + str(uuid.uuid4())
+
+
+def is_local_id(doc_id: str) -> bool:
+    """Whether doc_id is a well-formed local evidence ID."""
+    return isinstance(doc_id, str) and bool(_LOCAL_RE.match(doc_id))
+
+
+def migrate_id(doc_id: str) -> str | None:
+    """Map a legacy ID to the current scheme: valid IDs are unchanged, a bare
+    UUID (any case) gains the prefix, anything else returns None."""
+    if is_local_id(doc_id):
+        return doc_id
+    lowered = (doc_id or "").strip().lower()
+    if _UUID_RE.match(lowered):
+        return LOCAL_PREFIX + lowered
+    return None
@@ -1,7 +1,6 @@
 """ChromaDB-backed memory store for evidence retrieval."""
 
 import os
-import uuid
 import json
 import logging
 import time
@@ -10,6 +9,7 @@ from dataclasses import dataclass
 import chromadb
 
 from . import ollama_client
+from .ids import is_local_id, migrate_id, new_id
 
 logger = logging.getLogger(__name__)
 
@@ -22,6 +22,10 @@ RECENCY_HALF_LIFE = float(os.environ.get("RECENCY_HALF_LIFE", str(6 * 3600)))  #
 # Diversity threshold: results more similar than this to an already-selected result are deduped
 DIVERSITY_THRESHOLD = float(os.environ.get("DIVERSITY_THRESHOLD", "0.9"))
 
+# Similarity added to pinned evidence (capped at 1.0) before the threshold check.
+# Pinned items are also exempt from recency decay and FIFO eviction.
+PIN_BOOST = float(os.environ.get("PIN_BOOST", "0.1"))
+
 
 # #region types
 @dataclass
@@ -49,11 +53,36 @@ class MemoryStore:
             "MemoryStore initialized: persist_dir=%s, collection=%s",
             persist_dir, collection_name,
         )
+        self._migrate_ids()
+
+    def _migrate_ids(self) -> None:
+        """Re-key evidence stored before the ID scheme. Bare UUIDs become ev_<uuid>;
+        malformed IDs get a fresh ID (the controller drops their graph edges)."""
+        result = self._collection.get(include=["embeddings", "documents", "metadatas"])
+        existing = set(result["ids"])
+        renamed = 0
+        for i, doc_id in enumerate(result["ids"]):
+            if is_local_id(doc_id):
+                continue
+            target = migrate_id(doc_id) or new_id()
+            if target not in existing:
+                meta = result["metadatas"][i] if result["metadatas"] is not None else None
+                self._collection.add(
+                    ids=[target],
+                    embeddings=[result["embeddings"][i]],
+                    documents=[result["documents"][i]],
+                    metadatas=[meta] if meta else None,
+                )
+                existing.add(target)
+                renamed += 1
+            self._collection.delete(ids=[doc_id])
+        if renamed:
+            logger.info("Migrated %d evidence ids to the ev_<uuid> scheme", renamed)
 
     async def store(self, text: str, metadata: dict) -> str:
         """Embed text via Ollama and store in ChromaDB. Returns document ID.
         Enforces FIFO eviction when collection exceeds MAX_EVIDENCE."""
-        doc_id = str(uuid.uuid4())
+        doc_id = new_id()
         embedding = await ollama_client.embed(
             text=text, model=self._model, base_url=self._base_url,
         )
@@ -71,25 +100,32 @@ class MemoryStore:
         return doc_id
 
     def _evict_if_over_capacity(self) -> None:
-        """Remove oldest evidence items when collection exceeds MAX_EVIDENCE."""
+        """Remove oldest evidence items when collection exceeds MAX_EVIDENCE.
+        Pinned items are never evicted and do not count toward the cap;
+        items soft-deleted by compaction go first."""
         count = self._collection.count()
         if count <= MAX_EVIDENCE:
             return
 
-        excess = count - MAX_EVIDENCE
         # Get all items with metadata to find oldest by stored_at
         all_items = self._collection.get(include=["metadatas"])
         if not all_items["ids"]:
             return
 
-        # Sort by stored_at timestamp (oldest first), fallback to ID order
+        # Sort compacted items first, then by stored_at timestamp (oldest first)
         items_with_time = []
         for i, doc_id in enumerate(all_items["ids"]):
             meta = all_items["metadatas"][i] if all_items["metadatas"] else {}
+            if _is_pinned(meta):
+                continue
             stored_at = meta.get("stored_at", "1970-01-01T00:00:00Z") if meta else "1970-01-01T00:00:00Z"
-            items_with_time.append((doc_id, stored_at))
+            items_with_time.append((doc_id, not _is_compacted(meta), stored_at))
 
-        items_with_time.sort(key=lambda x: x[1])  # oldest first
+        excess = len(items_with_time) - MAX_EVIDENCE
+        if excess <= 0:
+            return
+
+        items_with_time.sort(key=lambda x: (x[1], x[2]))  # compacted, then oldest first
         ids_to_delete = [item[0] for item in items_with_time[:excess]]
 
         if ids_to_delete:
@@ -131,13 +167,18 @@ class MemoryStore:
             distance = results["distances"][0][i]
             similarity = 1.0 - distance
 
+            metadata = results["metadatas"][0][i] if results["metadatas"] else {}
+            if _is_compacted(metadata):
+                continue  # soft-deleted by compaction; its summary stands in
+            pinned = _is_pinned(metadata)
+            if pinned:
+                similarity = min(1.0, similarity + PIN_BOOST)
+
             if similarity < threshold:
                 continue
 
-            metadata = results["metadatas"][0][i] if results["metadatas"] else {}
-
-            # Recency weighting: decay score based on age
-            recency_weight = self._recency_weight(metadata, now)
+            # Recency weighting: decay score based on age; pinned evidence does not decay
+            recency_weight = 1.0 if pinned else self._recency_weight(metadata, now)
             weighted_score = similarity * recency_weight
 
             candidates.append(SearchResult(
@@ -157,8 +198,11 @@ class MemoryStore:
     @staticmethod
     def _recency_weight(metadata: dict, now: float) -> float:
         """Compute recency weight from stored_at metadata. Returns 0.5-1.0.
-        Evidence at half-life age gets weight 0.75. Very old evidence floors at 0.5."""
-        stored_at = (metadata or {}).get("stored_at", "")
+        Evidence at half-life age gets weight 0.75. Very old evidence floors at 0.5.
+        Evidence rehearsed by a consolidation cycle ages from its rehearsed_at."""
+        meta = metadata or {}
+        # Both are RFC3339 UTC strings written by the controller, so they sort as times
+        stored_at = max(meta.get("stored_at", ""), meta.get("rehearsed_at", ""))
         if not stored_at:
             return 0.75  # No timestamp — neutral weight
 
@@ -230,7 +274,9 @@ class MemoryStore:
         return items
 
     def get_by_ids(self, ids: list[str]) -> list[SearchResult]:
-        """Fetch evidence items by their IDs. Returns results in input order."""
+        """Fetch evidence items by their IDs. Returns results in input order.
+        Malformed IDs are ignored."""
+        ids = [doc_id for doc_id in ids if is_local_id(doc_id)]
         if not ids:
             return []
 
@@ -252,8 +298,38 @@ class MemoryStore:
         # Return in input order, skipping missing IDs
         return [lookup[id] for id in ids if id in lookup]
 
+    def update_metadata(self, doc_id: str, patch: dict) -> dict | None:
+        """Merge patch into a document's metadata; keys set to None are removed.
+        Returns the new metadata, or None when no document has the ID. The item is
+        re-added under the same ID, since ChromaDB's update cannot remove keys."""
+        if not is_local_id(doc_id):
+            logger.warning("Refusing to update malformed evidence id=%r", doc_id)
+            return None
+        result = self._collection.get(ids=[doc_id], include=["embeddings", "documents", "metadatas"])
+        if not result["ids"]:
+            return None
+        meta = dict(result["metadatas"][0] or {}) if result["metadatas"] is not None else {}
+        for key, value in patch.items():
+            if value is None:
+                meta.pop(key, None)
+            else:
+                meta[key] = value
+        self._collection.delete(ids=[doc_id])
+        self._collection.add(
+            ids=[doc_id],
+            embeddings=[result["embeddings"][0]],
+            documents=[result["documents"][0]],
+            metadatas=[meta] if meta else None,
+        )
+        logger.info("Updated evidence metadata id=%s keys=%s", doc_id, sorted(patch))
+        return meta
+
     async def delete(self, doc_id: str) -> bool:
-        """Delete a document by ID. Returns True if successful."""
+        """Delete a document by ID. Returns True if successful; malformed IDs are
+        refused without touching the collection."""
+        if not is_local_id(doc_id):
+            logger.warning("Refusing to delete malformed evidence id=%r", doc_id)
+            return False
         try:
             self._collection.delete(ids=[doc_id])
             logger.info("Deleted evidence id=%s", doc_id)
@@ -261,4 +337,13 @@ class MemoryStore:
         except Exception as e:
             logger.error("Delete failed for id=%s: %s", doc_id, e)
             return False
+
+
+def _is_pinned(metadata: dict | None) -> bool:
+    return bool((metadata or {}).get("pinned"))
+
+
+def _is_compacted(metadata: dict | None) -> bool:
+    """An original replaced by a compaction summary (go-controller evidence.MetaCompactedInto)."""
+    return bool((metadata or {}).get("compacted_into"))
 # #endregion memory-store
@@ -1,5 +1,8 @@
 """Ollama API client for generate and embed operations."""
 
+import json
+from collections.abc import Callable
+
 import httpx
 
 # #region config
@@ -52,9 +55,47 @@ async def embed(
         data = resp.json()
         # Ollama returns {"embeddings": [[...]]}
         return data["embeddings"][0]
+
+
+async def embed_batch(
+    texts: list[str],
+    model: str = DEFAULT_MODEL,
+    base_url: str = DEFAULT_BASE_URL,
+) -> list[list[float]]:
+    """Call Ollama /api/embed with several inputs; embeddings come back in input order."""
+    if not texts:
+        return []
+    payload = {
+        "model": model,
+        "input": texts,
+    }
+
+    async with httpx.AsyncClient(timeout=60.0) as client:
+        resp = await client.post(f"{base_url}/api/embed", json=payload)
+        resp.raise_for_status()
+        embeddings = resp.json()["embeddings"]
+        if len(embeddings) != len(texts):
+            raise ValueError(f"ollama returned {len(embeddings)} embeddings for {len(texts)} inputs")
+        return embeddings
 # #endregion embed
 
 
+# #region models
+async def model_digest(
+    model: str = DEFAULT_MODEL,
+    base_url: str = DEFAULT_BASE_URL,
+) -> str:
+    """Return the digest of a locally pulled model from /api/tags, or "" if it is not listed."""
+    async with httpx.AsyncClient(timeout=10.0) as client:
+        resp = await client.get(f"{base_url}/api/tags")
+        resp.raise_for_status()
+        for m in resp.json().get("models", []):
+            if model in (m.get("name"), m.get("model")) or m.get("name") == f"{model}:latest":
+                return m.get("digest", "")
+    return ""
+# #endregion models
+
+
 # #region chat
 async def chat(
     messages: list[dict],
@@ -62,21 +103,71 @@ async def chat(
     tools: list[dict] | None = None,
     model: str = DEFAULT_MODEL,
     base_url: str = DEFAULT_BASE_URL,
+    options: dict | None = None,
 ) -> dict:
-    """Call Ollama /api/chat with optional tools and return the response dict."""
+    """Call Ollama /api/chat with optional tools and return the response dict.
+
+    options are merged over the default num_predict cap.
+    """
+    payload = _chat_payload(messages, system, tools, model, options, stream=False)
+
+    async with httpx.AsyncClient(timeout=120.0) as client:
+        resp = await client.post(f"{base_url}/api/chat", json=payload)
+        resp.raise_for_status()
+        return resp.json()
+
+
+async def chat_stream(
+    messages: list[dict],
+    on_content: Callable[[str], None],
+    system: str = "",
+    tools: list[dict] | None = None,
+    model: str = DEFAULT_MODEL,
+    base_url: str = DEFAULT_BASE_URL,
+    options: dict | None = None,
+) -> dict:
+    """Call Ollama /api/chat with streaming, passing each content piece to on_content.
+
+    Returns the reply in the shape chat() returns: the joined content, plus
+    any tool calls the model made.
+    """
+    payload = _chat_payload(messages, system, tools, model, options, stream=True)
+    content: list[str] = []
+    tool_calls: list[dict] = []
+
+    async with httpx.AsyncClient(timeout=120.0) as client:
+        async with client.stream("POST", f"{base_url}/api/chat", json=payload) as resp:
+            resp.raise_for_status()
+            async for line in resp.aiter_lines():
+                if not line.strip():
+                    continue
+                chunk = json.loads(line)
+                if chunk.get("error"):
+                    raise RuntimeError(f"ollama chat: {chunk['error']}")
+                message = chunk.get("message", {})
+                if message.get("content"):
+                    content.append(message["content"])
+                    on_content(message["content"])
+                tool_calls.extend(message.get("tool_calls") or [])
+                if chunk.get("done"):
+                    break
+
+    message = {"role": "assistant", "content": "".join(content)}
+    if tool_calls:
+        message["tool_calls"] = tool_calls
+    return {"message": message}
+
+
+def _chat_payload(messages, system, tools, model, options, stream: bool) -> dict:
     payload = {
         "model": model,
         "messages": messages,
-        "stream": False,
+        "stream": stream,
     }
     if system:
         payload["messages"] = [{"role": "system", "content": system}] + payload["messages"]
     if tools:
         payload["tools"] = tools
-    payload["options"] = {"num_predict": 512}
-
-    async with httpx.AsyncClient(timeout=120.0) as client:
-        resp = await client.post(f"{base_url}/api/chat", json=payload)
-        resp.raise_for_status()
-        return resp.json()
+    payload["options"] = {"num_predict": 512, **(options or {})}
+    return payload
 # #endregion chat
@@ -24,7 +24,7 @@ _sym_db = _symbol_database.Default()
 
 
 
-DESCRIPTOR = _descriptor_pool.Default().AddSerializedFile(b'\n\x0e\x61\x64\x61ptive.proto\x12\x08\x61\x64\x61ptive\"Z\n\x0fGenerateRequest\x12\x0e\n\x06prompt\x18\x01 \x01(\t\x12\x14\n\x0cstate_vector\x18\x02 \x03(\x02\x12\x10\n\x08\x65vidence\x18\x03 \x03(\t\x12\x0f\n\x07\x63ontext\x18\x04 \x03(\x03\"R\n\x10GenerateResponse\x12\x0c\n\x04text\x18\x01 \x01(\t\x12\x0f\n\x07\x65ntropy\x18\x02 \x01(\x02\x12\x0e\n\x06logits\x18\x03 \x03(\x02\x12\x0f\n\x07\x63ontext\x18\x04 \x03(\x03\"\x1c\n\x0c\x45mbedRequest\x12\x0c\n\x04text\x18\x01 \x01(\t\"\"\n\rEmbedResponse\x12\x11\n\tembedding\x18\x01 \x03(\x02\"i\n\rSearchRequest\x12\x12\n\nquery_text\x18\x01 \x01(\t\x12\x17\n\x0fquery_embedding\x18\x02 \x03(\x02\x12\r\n\x05top_k\x18\x03 \x01(\x05\x12\x1c\n\x14similarity_threshold\x18\x04 \x01(\x02\"N\n\x0cSearchResult\x12\n\n\x02id\x18\x01 \x01(\t\x12\x0c\n\x04text\x18\x02 \x01(\t\x12\r\n\x05score\x18\x03 \x01(\x02\x12\x15\n\rmetadata_json\x18\x04 \x01(\t\"9\n\x0eSearchResponse\x12\'\n\x07results\x18\x01 \x03(\x0b\x32\x16.adaptive.SearchResult\";\n\x14StoreEvidenceRequest\x12\x0c\n\x04text\x18\x01 \x01(\t\x12\x15\n\rmetadata_json\x18\x02 \x01(\t\"#\n\x15StoreEvidenceResponse\x12\n\n\x02id\x18\x01 \x01(\t\"6\n\x10WebSearchRequest\x12\r\n\x05query\x18\x01 \x01(\t\x12\x13\n\x0bmax_results\x18\x02 \x01(\x05\">\n\x0fWebSearchResult\x12\r\n\x05title\x18\x01 \x01(\t\x12\x0f\n\x07snippet\x18\x02 \x01(\t\x12\x0b\n\x03url\x18\x03 \x01(\t\"?\n\x11WebSearchResponse\x12*\n\x07results\x18\x01 \x03(\x0b\x32\x19.adaptive.WebSearchResult\"$\n\x15\x44\x65leteEvidenceRequest\x12\x0b\n\x03ids\x18\x01 \x03(\t\"/\n\x16\x44\x65leteEvidenceResponse\x12\x15\n\rdeleted_count\x18\x01 \x01(\x05\"\x1e\n\x0fGetByIDsRequest\x12\x0b\n\x03ids\x18\x01 \x03(\t\";\n\x10GetByIDsResponse\x12\'\n\x07results\x18\x01 \x03(\x0b\x32\x16.adaptive.SearchResult\"\x18\n\x16ListAllEvidenceRequest\"B\n\x17ListAllEvidenceResponse\x12\'\n\x07results\x18\x01 \x03(\x0b\x32\x16.adaptive.SearchResult2\xd0\x04\n\x0c\x43odecService\x12\x41\n\x08Generate\x12\x19.adaptive.GenerateRequest\x1a\x1a.adaptive.GenerateResponse\x12\x38\n\x05\x45mbed\x12\x16.adaptive.EmbedRequest\x1a\x17.adaptive.EmbedResponse\x12;\n\x06Search\x12\x17.adaptive.SearchRequest\x1a\x18.adaptive.SearchResponse\x12P\n\rStoreEvidence\x12\x1e.adaptive.StoreEvidenceRequest\x1a\x1f.adaptive.StoreEvidenceResponse\x12\x44\n\tWebSearch\x12\x1a.adaptive.WebSearchRequest\x1a\x1b.adaptive.WebSearchResponse\x12S\n\x0e\x44\x65leteEvidence\x12\x1f.adaptive.DeleteEvidenceRequest\x1a .adaptive.DeleteEvidenceResponse\x12\x41\n\x08GetByIDs\x12\x19.adaptive.GetByIDsRequest\x1a\x1a.adaptive.GetByIDsResponse\x12V\n\x0fListAllEvidence\x12 .adaptive.ListAllEvidenceRequest\x1a!.adaptive.ListAllEvidenceResponseBFZDgithub.com/danielpatrickdp/adaptive-state/go-controller/gen/adaptiveb\x06proto3')
+DESCRIPTOR = _descriptor_pool.Default().AddSerializedFile(b'\n\x0e\x61\x64\x61ptive.proto\x12\x08\x61\x64\x61ptive\"\x86\x01\n\x0fGenerateRequest\x12\x0e\n\x06prompt\x18\x01 \x01(\t\x12\x14\n\x0cstate_vector\x18\x02 \x03(\x02\x12\x10\n\x08\x65vidence\x18\x03 \x03(\t\x12\x0f\n\x07\x63ontext\x18\x04 \x03(\x03\x12*\n\x08sampling\x18\x05 \x01(\x0b\x32\x18.adaptive.SamplingParams\"H\n\x0eSamplingParams\x12\x13\n\x0btemperature\x18\x01 \x01(\x02\x12\r\n\x05top_p\x18\x02 \x01(\x02\x12\x12\n\nmax_tokens\x18\x03 \x01(\x05\"}\n\x10GenerateResponse\x12\x0c\n\x04text\x18\x01 \x01(\t\x12\x0f\n\x07\x65ntropy\x18\x02 \x01(\x02\x12\x0e\n\x06logits\x18\x03 \x03(\x02\x12\x0f\n\x07\x63ontext\x18\x04 \x03(\x03\x12\x12\n\nmodel_name\x18\x05 \x01(\t\x12\x15\n\rmodel_version\x18\x06 \x01(\t\"I\n\rGenerateChunk\x12\r\n\x05\x64\x65lta\x18\x01 \x01(\t\x12)\n\x05\x66inal\x18\x02 \x01(\x0b\x32\x1a.adaptive.GenerateResponse\"\x1c\n\x0c\x45mbedRequest\x12\x0c\n\x04text\x18\x01 \x01(\t\"\"\n\rEmbedResponse\x12\x11\n\tembedding\x18\x01 \x03(\x02\"\"\n\x11\x45mbedBatchRequest\x12\r\n\x05texts\x18\x01 \x03(\t\"\x1b\n\tEmbedding\x12\x0e\n\x06values\x18\x01 \x03(\x02\"=\n\x12\x45mbedBatchResponse\x12\'\n\nembeddings\x18\x01 \x03(\x0b\x32\x13.adaptive.Embedding\"i\n\rSearchRequest\x12\x12\n\nquery_text\x18\x01 \x01(\t\x12\x17\n\x0fquery_embedding\x18\x02 \x03(\x02\x12\r\n\x05top_k\x18\x03 \x01(\x05\x12\x1c\n\x14similarity_threshold\x18\x04 \x01(\x02\"N\n\x0cSearchResult\x12\n\n\x02id\x18\x01 \x01(\t\x12\x0c\n\x04text\x18\x02 \x01(\t\x12\r\n\x05score\x18\x03 \x01(\x02\x12\x15\n\rmetadata_json\x18\x04 \x01(\t\"9\n\x0eSearchResponse\x12\'\n\x07results\x18\x01 \x03(\x0b\x32\x16.adaptive.SearchResult\";\n\x14StoreEvidenceRequest\x12\x0c\n\x04text\x18\x01 \x01(\t\x12\x15\n\rmetadata_json\x18\x02 \x01(\t\"#\n\x15StoreEvidenceResponse\x12\n\n\x02id\x18\x01 \x01(\t\"6\n\x10WebSearchRequest\x12\r\n\x05query\x18\x01 \x01(\t\x12\x13\n\x0bmax_results\x18\x02 \x01(\x05\">\n\x0fWebSearchResult\x12\r\n\x05title\x18\x01 \x01(\t\x12\x0f\n\x07snippet\x18\x02 \x01(\t\x12\x0b\n\x03url\x18\x03 \x01(\t\"?\n\x11WebSearchResponse\x12*\n\x07results\x18\x01 \x03(\x0b\x32\x19.adaptive.WebSearchResult\"$\n\x15\x44\x65leteEvidenceRequest\x12\x0b\n\x03ids\x18\x01 \x03(\t\"/\n\x16\x44\x65leteEvidenceResponse\x12\x15\n\rdeleted_count\x18\x01 \x01(\x05\"B\n\x1dUpdateEvidenceMetadataRequest\x12\n\n\x02id\x18\x01 \x01(\t\x12\x15\n\rmetadata_json\x18\x02 \x01(\t\"F\n\x1eUpdateEvidenceMetadataResponse\x12\r\n\x05\x66ound\x18\x01 \x01(\x08\x12\x15\n\rmetadata_json\x18\x02 \x01(\t\"\x1e\n\x0fGetByIDsRequest\x12\x0b\n\x03ids\x18\x01 \x03(\t\";\n\x10GetByIDsResponse\x12\'\n\x07results\x18\x01 \x03(\x0b\x32\x16.adaptive.SearchResult\"\x18\n\x16ListAllEvidenceRequest\"B\n\x17ListAllEvidenceResponse\x12\'\n\x07results\x18\x01 \x03(\x0b\x32\x16.adaptive.SearchResult\"H\n\x10HandshakeRequest\x12\x18\n\x10protocol_version\x18\x01 \x01(\x05\x12\x1a\n\x12schema_fingerprint\x18\x02 \x01(\t\"I\n\x11HandshakeResponse\x12\x18\n\x10protocol_version\x18\x01 \x01(\x05\x12\x1a\n\x12schema_fingerprint\x18\x02 \x01(\t2\x94\x07\n\x0c\x43odecService\x12\x41\n\x08Generate\x12\x19.adaptive.GenerateRequest\x1a\x1a.adaptive.GenerateResponse\x12\x46\n\x0eGenerateStream\x12\x19.adaptive.GenerateRequest\x1a\x17.adaptive.GenerateChunk0\x01\x12\x38\n\x05\x45mbed\x12\x16.adaptive.EmbedRequest\x1a\x17.adaptive.EmbedResponse\x12G\n\nEmbedBatch\x12\x1b.adaptive.EmbedBatchRequest\x1a\x1c.adaptive.EmbedBatchResponse\x12;\n\x06Search\x12\x17.adaptive.SearchRequest\x1a\x18.adaptive.SearchResponse\x12P\n\rStoreEvidence\x12\x1e.adaptive.StoreEvidenceRequest\x1a\x1f.adaptive.StoreEvidenceResponse\x12\x44\n\tWebSearch\x12\x1a.adaptive.WebSearchRequest\x1a\x1b.adaptive.WebSearchResponse\x12S\n\x0e\x44\x65leteEvidence\x12\x1f.adaptive.DeleteEvidenceRequest\x1a .adaptive.DeleteEvidenceResponse\x12k\n\x16UpdateEvidenceMetadata\x12\'.adaptive.UpdateEvidenceMetadataRequest\x1a(.adaptive.UpdateEvidenceMetadataResponse\x12\x41\n\x08GetByIDs\x12\x19.adaptive.GetByIDsRequest\x1a\x1a.adaptive.GetByIDsResponse\x12V\n\x0fListAllEvidence\x12 .adaptive.ListAllEvidenceRequest\x1a!.adaptive.ListAllEvidenceResponse\x12\x44\n\tHandshake\x12\x1a.adaptive.HandshakeRequest\x1a\x1b.adaptive.HandshakeResponseBFZDgithub.com/danielpatrickdp/adaptive-state/go-controller/gen/adaptiveb\x06proto3')
 
 _globals = globals()
 _builder.BuildMessageAndEnumDescriptors(DESCRIPTOR, _globals)
@@ -32,42 +32,60 @@ _builder.BuildTopDescriptorsAndMessages(DESCRIPTOR, 'adaptive_pb2', _globals)
 if not _descriptor._USE_C_DESCRIPTORS:
   _globals['DESCRIPTOR']._loaded_options = None
   _globals['DESCRIPTOR']._serialized_options = b'ZDgithub.com/danielpatrickdp/adaptive-state/go-controller/gen/adaptive'
-  _globals['_GENERATEREQUEST']._serialized_start=28
-  _globals['_GENERATEREQUEST']._serialized_end=118
-  _globals['_GENERATERESPONSE']._serialized_start=120
-  _globals['_GENERATERESPONSE']._serialized_end=202
-  _globals['_EMBEDREQUEST']._serialized_start=204
-  _globals['_EMBEDREQUEST']._serialized_end=232
-  _globals['_EMBEDRESPONSE']._serialized_start=234
-  _globals['_EMBEDRESPONSE']._serialized_end=268
-  _globals['_SEARCHREQUEST']._serialized_start=270
-  _globals['_SEARCHREQUEST']._serialized_end=375
-  _globals['_SEARCHRESULT']._serialized_start=377
-  _globals['_SEARCHRESULT']._serialized_end=455
-  _globals['_SEARCHRESPONSE']._serialized_start=457
-  _globals['_SEARCHRESPONSE']._serialized_end=514
-  _globals['_STOREEVIDENCEREQUEST']._serialized_start=516
-  _globals['_STOREEVIDENCEREQUEST']._serialized_end=575
-  _globals['_STOREEVIDENCERESPONSE']._serialized_start=577
-  _globals['_STOREEVIDENCERESPONSE']._serialized_end=612
-  _globals['_WEBSEARCHREQUEST']._serialized_start=614
-  _globals['_WEBSEARCHREQUEST']._serialized_end=668
-  _globals['_WEBSEARCHRESULT']._serialized_start=670
-  _globals['_WEBSEARCHRESULT']._serialized_end=732
-  _globals['_WEBSEARCHRESPONSE']._serialized_start=734
-  _globals['_WEBSEARCHRESPONSE']._serialized_end=797
-  _globals['_DELETEEVIDENCEREQUEST']._serialized_start=799
-  _globals['_DELETEEVIDENCEREQUEST']._serialized_end=835
-  _globals['_DELETEEVIDENCERESPONSE']._serialized_start=837
-  _globals['_DELETEEVIDENCERESPONSE']._serialized_end=884
-  _globals['_GETBYIDSREQUEST']._serialized_start=886
-  _globals['_GETBYIDSREQUEST']._serialized_end=916
-  _globals['_GETBYIDSRESPONSE']._serialized_start=918
-  _globals['_GETBYIDSRESPONSE']._serialized_end=977
-  _globals['_LISTALLEVIDENCEREQUEST']._serialized_start=979
-  _globals['_LISTALLEVIDENCEREQUEST']._serialized_end=1003
-  _globals['_LISTALLEVIDENCERESPONSE']._serialized_start=1005
-  _globals['_LISTALLEVIDENCERESPONSE']._serialized_end=1071
-  _globals['_CODECSERVICE']._serialized_start=1074
-  _globals['_CODECSERVICE']._serialized_end=1666
+  _globals['_GENERATEREQUEST']._serialized_start=29
+  _globals['_GENERATEREQUEST']._serialized_end=163
+  _globals['_SAMPLINGPARAMS']._serialized_start=165
+  _globals['_SAMPLINGPARAMS']._serialized_end=237
+  _globals['_GENERATERESPONSE']._serialized_start=239
+  _globals['_GENERATERESPONSE']._serialized_end=364
+  _globals['_GENERATECHUNK']._serialized_start=366
+  _globals['_GENERATECHUNK']._serialized_end=439
+  _globals['_EMBEDREQUEST']._serialized_start=441
+  _globals['_EMBEDREQUEST']._serialized_end=469
+  _globals['_EMBEDRESPONSE']._serialized_start=471
+  _globals['_EMBEDRESPONSE']._serialized_end=505
+  _globals['_EMBEDBATCHREQUEST']._serialized_start=507
+  _globals['_EMBEDBATCHREQUEST']._serialized_end=541
+  _globals['_EMBEDDING']._serialized_start=543
+  _globals['_EMBEDDING']._serialized_end=570
+  _globals['_EMBEDBATCHRESPONSE']._serialized_start=572
+  _globals['_EMBEDBATCHRESPONSE']._serialized_end=633
+  _globals['_SEARCHREQUEST']._serialized_start=635
+  _globals['_SEARCHREQUEST']._serialized_end=740
+  _globals['_SEARCHRESULT']._serialized_start=742
+  _globals['_SEARCHRESULT']._serialized_end=820
+  _globals['_SEARCHRESPONSE']._serialized_start=822
+  _globals['_SEARCHRESPONSE']._serialized_end=879
+  _globals['_STOREEVIDENCEREQUEST']._serialized_start=881
+  _globals['_STOREEVIDENCEREQUEST']._serialized_end=940
+  _globals['_STOREEVIDENCERESPONSE']._serialized_start=942
+  _globals['_STOREEVIDENCERESPONSE']._serialized_end=977
+  _globals['_WEBSEARCHREQUEST']._serialized_start=979
+  _globals['_WEBSEARCHREQUEST']._serialized_end=1033
+  _globals['_WEBSEARCHRESULT']._serialized_start=1035
+  _globals['_WEBSEARCHRESULT']._serialized_end=1097
+  _globals['_WEBSEARCHRESPONSE']._serialized_start=1099
+  _globals['_WEBSEARCHRESPONSE']._serialized_end=1162
+  _globals['_DELETEEVIDENCEREQUEST']._serialized_start=1164
+  _globals['_DELETEEVIDENCEREQUEST']._serialized_end=1200
+  _globals['_DELETEEVIDENCERESPONSE']._serialized_start=1202
+  _globals['_DELETEEVIDENCERESPONSE']._serialized_end=1249
+  _globals['_UPDATEEVIDENCEMETADATAREQUEST']._serialized_start=1251
+  _globals['_UPDATEEVIDENCEMETADATAREQUEST']._serialized_end=1317
+  _globals['_UPDATEEVIDENCEMETADATARESPONSE']._serialized_start=1319
+  _globals['_UPDATEEVIDENCEMETADATARESPONSE']._serialized_end=1389
+  _globals['_GETBYIDSREQUEST']._serialized_start=1391
+  _globals['_GETBYIDSREQUEST']._serialized_end=1421
+  _globals['_GETBYIDSRESPONSE']._serialized_start=1423
+  _globals['_GETBYIDSRESPONSE']._serialized_end=1482
+  _globals['_LISTALLEVIDENCEREQUEST']._serialized_start=1484
+  _globals['_LISTALLEVIDENCEREQUEST']._serialized_end=1508
+  _globals['_LISTALLEVIDENCERESPONSE']._serialized_start=1510
+  _globals['_LISTALLEVIDENCERESPONSE']._serialized_end=1576
+  _globals['_HANDSHAKEREQUEST']._serialized_start=1578
+  _globals['_HANDSHAKEREQUEST']._serialized_end=1650
+  _globals['_HANDSHAKERESPONSE']._serialized_start=1652
+  _globals['_HANDSHAKERESPONSE']._serialized_end=1725
+  _globals['_CODECSERVICE']._serialized_start=1728
+  _globals['_CODECSERVICE']._serialized_end=2644
 # @@protoc_insertion_point(module_scope)
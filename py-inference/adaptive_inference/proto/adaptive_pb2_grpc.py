@@ -40,11 +40,21 @@ class CodecServiceStub(object):
                 request_serializer=adaptive__pb2.GenerateRequest.SerializeToString,
                 response_deserializer=adaptive__pb2.GenerateResponse.FromString,
                 _registered_method=True)
+        self.GenerateStream = channel.unary_stream(
+                '/adaptive.CodecService/GenerateStream',
+                request_serializer=adaptive__pb2.GenerateRequest.SerializeToString,
+                response_deserializer=adaptive__pb2.GenerateChunk.FromString,
+                _registered_method=True)
         self.Embed = channel.unary_unary(
                 '/adaptive.CodecService/Embed',
                 request_serializer=adaptive__pb2.EmbedRequest.SerializeToString,
                 response_deserializer=adaptive__pb2.EmbedResponse.FromString,
                 _registered_method=True)
+        self.EmbedBatch = channel.unary_unary(
+                '/adaptive.CodecService/EmbedBatch',
+                request_serializer=adaptive__pb2.EmbedBatchRequest.SerializeToString,
+                response_deserializer=adaptive__pb2.EmbedBatchResponse.FromString,
+                _registered_method=True)
         self.Search = channel.unary_unary(
                 '/adaptive.CodecService/Search',
                 request_serializer=adaptive__pb2.SearchRequest.SerializeToString,
@@ -65,6 +75,11 @@ class CodecServiceStub(object):
                 request_serializer=adaptive__pb2.DeleteEvidenceRequest.SerializeToString,
                 response_deserializer=adaptive__pb2.DeleteEvidenceResponse.FromString,
                 _registered_method=True)
+        self.UpdateEvidenceMetadata = channel.unary_unary(
+                '/adaptive.CodecService/UpdateEvidenceMetadata',
+                request_serializer=adaptive__pb2.UpdateEvidenceMetadataRequest.SerializeToString,
+                response_deserializer=adaptive__pb2.UpdateEvidenceMetadataResponse.FromString,
+                _registered_method=True)
         self.GetByIDs = channel.unary_unary(
                 '/adaptive.CodecService/GetByIDs',
                 request_serializer=adaptive__pb2.GetByIDsRequest.SerializeToString,
@@ -75,6 +90,11 @@ class CodecServiceStub(object):
                 request_serializer=adaptive__pb2.ListAllEvidenceRequest.SerializeToString,
                 response_deserializer=adaptive__pb2.ListAllEvidenceResponse.FromString,
                 _registered_method=True)
+        self.Handshake = channel.unary_unary(
+                '/adaptive.CodecService/Handshake',
+                request_serializer=adaptive__pb2.HandshakeRequest.SerializeToString,
+                response_deserializer=adaptive__pb2.HandshakeResponse.FromString,
+                _registered_method=True)
 
 
 class CodecServiceServicer(object):
@@ -87,12 +107,28 @@ class CodecServiceServicer(object):
         context.set_details('Method not implemented!')
         raise NotImplementedError('Method not implemented!')
 
+    def GenerateStream(self, request, context):
+        """GenerateStream is Generate with the visible text sent as it is produced;
+        the last chunk carries the full response (entropy, logits, model).
+        """
+        context.set_code(grpc.StatusCode.UNIMPLEMENTED)
+        context.set_details('Method not implemented!')
+        raise NotImplementedError('Method not implemented!')
+
     def Embed(self, request, context):
         """Missing associated documentation comment in .proto file."""
         context.set_code(grpc.StatusCode.UNIMPLEMENTED)
         context.set_details('Method not implemented!')
         raise NotImplementedError('Method not implemented!')
 
+    def EmbedBatch(self, request, context):
+        """EmbedBatch embeds several texts in one round trip; embeddings come back in
+        request order.
+        """
+        context.set_code(grpc.StatusCode.UNIMPLEMENTED)
+        context.set_details('Method not implemented!')
+        raise NotImplementedError('Method not implemented!')
+
     def Search(self, request, context):
         """Missing associated documentation comment in .proto file."""
         context.set_code(grpc.StatusCode.UNIMPLEMENTED)
@@ -117,6 +153,15 @@ class CodecServiceServicer(object):
         context.set_details('Method not implemented!')
         raise NotImplementedError('Method not implemented!')
 
+    def UpdateEvidenceMetadata(self, request, context):
+        """UpdateEvidenceMetadata merges keys into a stored item's metadata; a key set
+        to null is removed. Items with "pinned": true are never evicted and skip
+        recency decay in Search.
+        """
+        context.set_code(grpc.StatusCode.UNIMPLEMENTED)
+        context.set_details('Method not implemented!')
+        raise NotImplementedError('Method not implemented!')
+
     def GetByIDs(self, request, context):
         """Missing associated documentation comment in .proto file."""
         context.set_code(grpc.StatusCode.UNIMPLEMENTED)
@@ -129,6 +174,14 @@ class CodecServiceServicer(object):
         context.set_details('Method not implemented!')
         raise NotImplementedError('Method not implemented!')
 
+    def Handshake(self, request, context):
+        """Handshake exchanges protocol versions and schema fingerprints so mismatched
+        bindings fail loudly instead of silently dropping unknown fields.
+        """
+        context.set_code(grpc.StatusCode.UNIMPLEMENTED)
+        context.set_details('Method not implemented!')
+        raise NotImplementedError('Method not implemented!')
+
 
 def add_CodecServiceServicer_to_server(servicer, server):
     rpc_method_handlers = {
@@ -137,11 +190,21 @@ def add_CodecServiceServicer_to_server(servicer, server):
                     request_deserializer=adaptive__pb2.GenerateRequest.FromString,
                     response_serializer=adaptive__pb2.GenerateResponse.SerializeToString,
             ),
+            'GenerateStream': grpc.unary_stream_rpc_method_handler(
+                    servicer.GenerateStream,
+                    request_deserializer=adaptive__pb2.GenerateRequest.FromString,
+                    response_serializer=adaptive__pb2.GenerateChunk.SerializeToString,
+            ),
             'Embed': grpc.unary_unary_rpc_method_handler(
                     servicer.Embed,
                     request_deserializer=adaptive__pb2.EmbedRequest.FromString,
                     response_serializer=adaptive__pb2.EmbedResponse.SerializeToString,
             ),
+            'EmbedBatch': grpc.unary_unary_rpc_method_handler(
+                    servicer.EmbedBatch,
+                    request_deserializer=adaptive__pb2.EmbedBatchRequest.FromString,
+                    response_serializer=adaptive__pb2.EmbedBatchResponse.SerializeToString,
+            ),
             'Search': grpc.unary_unary_rpc_method_handler(
                     servicer.Search,
                     request_deserializer=adaptive__pb2.SearchRequest.FromString,
@@ -162,6 +225,11 @@ def add_CodecServiceServicer_to_server(servicer, server):
                     request_deserializer=adaptive__pb2.DeleteEvidenceRequest.FromString,
                     response_serializer=adaptive__pb2.DeleteEvidenceResponse.SerializeToString,
             ),
+            'UpdateEvidenceMetadata': grpc.unary_unary_rpc_method_handler(
+                    servicer.UpdateEvidenceMetadata,
+                    request_deserializer=adaptive__pb2.UpdateEvidenceMetadataRequest.FromString,
+                    response_serializer=adaptive__pb2.UpdateEvidenceMetadataResponse.SerializeToString,
+            ),
             'GetByIDs': grpc.unary_unary_rpc_method_handler(
                     servicer.GetByIDs,
                     request_deserializer=adaptive__pb2.GetByIDsRequest.FromString,
@@ -172,6 +240,11 @@ def add_CodecServiceServicer_to_server(servicer, server):
                     request_deserializer=adaptive__pb2.ListAllEvidenceRequest.FromString,
                     response_serializer=adaptive__pb2.ListAllEvidenceResponse.SerializeToString,
             ),
+            'Handshake': grpc.unary_unary_rpc_method_handler(
+                    servicer.Handshake,
+                    request_deserializer=adaptive__pb2.HandshakeRequest.FromString,
+                    response_serializer=adaptive__pb2.HandshakeResponse.SerializeToString,
+            ),
     }
     generic_handler = grpc.method_handlers_generic_handler(
             'adaptive.CodecService', rpc_method_handlers)
@@ -211,6 +284,33 @@ class CodecService(object):
             metadata,
             _registered_method=True)
 
+    @staticmethod
+    def GenerateStream(request,
+            target,
+            options=(),
+            channel_credentials=None,
+            call_credentials=None,
+            insecure=False,
+            compression=None,
+            wait_for_ready=None,
+            timeout=None,
+            metadata=None):
+        return grpc.experimental.unary_stream(
+            request,
+            target,
+            '/adaptive.CodecService/GenerateStream',
+            adaptive__pb2.GenerateRequest.SerializeToString,
+            adaptive__pb2.GenerateChunk.FromString,
+            options,
+            channel_credentials,
+            insecure,
+            call_credentials,
+            compression,
+            wait_for_ready,
+            timeout,
+            metadata,
+            _registered_method=True)
+
     @staticmethod
     def Embed(request,
             target,
@@ -238,6 +338,33 @@ class CodecService(object):
             metadata,
             _registered_method=True)
 
+    @staticmethod
+    def EmbedBatch(request,
+            target,
+            options=(),
+            channel_credentials=None,
+            call_credentials=None,
+            insecure=False,
+            compression=None,
+            wait_for_ready=None,
+            timeout=None,
+            metadata=None):
+        return grpc.experimental.unary_unary(
+            request,
+            target,
+            '/adaptive.CodecService/EmbedBatch',
+            adaptive__pb2.EmbedBatchRequest.SerializeToString,
+            adaptive__pb2.EmbedBatchResponse.FromString,
+            options,
+            channel_credentials,
+            insecure,
+            call_credentials,
+            compression,
+            wait_for_ready,
+            timeout,
+            metadata,
+            _registered_method=True)
+
     @staticmethod
     def Search(request,
             target,
@@ -346,6 +473,33 @@ class CodecService(object):
             metadata,
             _registered_method=True)
 
+    @staticmethod
+    def UpdateEvidenceMetadata(request,
+            target,
+            options=(),
+            channel_credentials=None,
+            call_credentials=None,
+            insecure=False,
+            compression=None,
+            wait_for_ready=None,
+            timeout=None,
+            metadata=None):
+        return grpc.experimental.unary_unary(
+            request,
+            target,
+            '/adaptive.CodecService/UpdateEvidenceMetadata',
+            adaptive__pb2.UpdateEvidenceMetadataRequest.SerializeToString,
+            adaptive__pb2.UpdateEvidenceMetadataResponse.FromString,
+            options,
+            channel_credentials,
+            insecure,
+            call_credentials,
+            compression,
+            wait_for_ready,
+            timeout,
+            metadata,
+            _registered_method=True)
+
     @staticmethod
     def GetByIDs(request,
             target,
@@ -399,3 +553,30 @@ class CodecService(object):
             timeout,
             metadata,
             _registered_method=True)
+
+    @staticmethod
+    def Handshake(request,
+            target,
+            options=(),
+            channel_credentials=None,
+            call_credentials=None,
+            insecure=False,
+            compression=None,
+            wait_for_ready=None,
+            timeout=None,
+            metadata=None):
+        return grpc.experimental.unary_unary(
+            request,
+            target,
+            '/adaptive.CodecService/Handshake',
+            adaptive__pb2.HandshakeRequest.SerializeToString,
+            adaptive__pb2.HandshakeResponse.FromString,
+            options,
+            channel_credentials,
+            insecure,
+            call_credentials,
+            compression,
+            wait_for_ready,
+            timeout,
+            metadata,
+            _registered_method=True)
@@ -0,0 +1,45 @@
+"""Codec protocol version and schema fingerprint, checked by the Handshake RPC."""
+
+import hashlib
+import os
+import sys
+
+from google.protobuf import descriptor_pb2
+
+# Same import path as server.py so the descriptor is only registered once.
+sys.path.insert(0, os.path.join(os.path.dirname(__file__), "proto"))
+
+import adaptive_pb2 as pb2
+
+# Must equal the protocol_version header in proto/adaptive.proto and
+# ProtocolVersion in go-controller/internal/codec/protocol.go.
+PROTOCOL_VERSION = 8
+
+
+# #region fingerprint
+def schema_canonical(file_descriptor=pb2.DESCRIPTOR) -> str:
+    """Render every message field and RPC signature, one line each, in declaration order.
+
+    Mirrors schemaCanonical in go-controller/internal/codec/protocol.go; the two
+    must stay byte-identical for fingerprints to agree.
+    """
+    fdp = descriptor_pb2.FileDescriptorProto()
+    file_descriptor.CopyToProto(fdp)
+    lines = []
+    for m in fdp.message_type:
+        lines.append(f"message {m.name}\n")
+        for f in m.field:
+            lines.append(f"{m.name}.{f.name}={f.number}:{f.type}:{f.label}:{f.type_name}\n")
+    for s in fdp.service:
+        for r in s.method:
+            lines.append(f"rpc {s.name}.{r.name}({r.input_type}){r.output_type}\n")
+    return "".join(lines)
+
+
+def fingerprint(canonical: str) -> str:
+    """Short hash of a canonical schema rendering."""
+    return hashlib.sha256(canonical.encode()).hexdigest()[:16]
+
+
+SCHEMA_FINGERPRINT = fingerprint(schema_canonical())
+# #endregion fingerprint
@@ -1,10 +1,13 @@
 """gRPC server implementing CodecService."""
 
 import asyncio
+import hmac
 import logging
 import os
+import queue
 import sys
 import threading
+import uuid
 from concurrent import futures
 
 import grpc
@@ -16,11 +19,17 @@ import adaptive_pb2 as pb2
 import adaptive_pb2_grpc as pb2_grpc
 
 from .memory import MemoryStore
+from .protocol import PROTOCOL_VERSION, SCHEMA_FINGERPRINT
 from .service import InferenceService
 from .workspace_server import start_workspace_server
 
 logger = logging.getLogger(__name__)
 
+# Names this server process in the Handshake response header; a client that
+# sees a new value knows the service restarted (go-controller codec.InstanceHeader).
+INSTANCE_HEADER = "x-server-instance"
+SERVER_INSTANCE = uuid.uuid4().hex
+
 # #region grpc-servicer
 class CodecServiceServicer(pb2_grpc.CodecServiceServicer):
     """gRPC servicer that delegates to InferenceService."""
@@ -48,6 +57,7 @@ class CodecServiceServicer(pb2_grpc.CodecServiceServicer):
                     state_vector=list(request.state_vector),
                     evidence=list(request.evidence),
                     context=list(request.context) if request.context else None,
+                    sampling=request.sampling if request.HasField("sampling") else None,
                 )
             )
             return pb2.GenerateResponse(
@@ -55,6 +65,8 @@ class CodecServiceServicer(pb2_grpc.CodecServiceServicer):
                 entropy=result.entropy,
                 logits=result.logits,
                 context=result.context,
+                model_name=result.model_name,
+                model_version=result.model_version,
             )
         except Exception as e:
             logger.error("Generate error: %s", e)
@@ -62,6 +74,51 @@ class CodecServiceServicer(pb2_grpc.CodecServiceServicer):
             context.set_details(str(e))
             return pb2.GenerateResponse()
 
+    def GenerateStream(self, request, context):
+        """Handle GenerateStream RPC: text deltas as they are produced, then the full response."""
+        logger.info("GenerateStream called: prompt=%s...", request.prompt[:50] if request.prompt else "")
+        chunks: queue.Queue = queue.Queue()
+
+        async def produce():
+            try:
+                result = await self._service.generate(
+                    prompt=request.prompt,
+                    state_vector=list(request.state_vector),
+                    evidence=list(request.evidence),
+                    context=list(request.context) if request.context else None,
+                    sampling=request.sampling if request.HasField("sampling") else None,
+                    on_delta=lambda delta: chunks.put(pb2.GenerateChunk(delta=delta)),
+                )
+                chunks.put(pb2.GenerateChunk(final=pb2.GenerateResponse(
+                    text=result.text,
+                    entropy=result.entropy,
+                    logits=result.logits,
+                    context=result.context,
+                    model_name=result.model_name,
+                    model_version=result.model_version,
+                )))
+            except Exception as e:
+                chunks.put(e)
+
+        future = asyncio.run_coroutine_threadsafe(produce(), self._loop)
+
+        def stop():
+            # RPC over (client went away or deadline): stop generating and unblock the loop below
+            future.cancel()
+            chunks.put(None)
+
+        context.add_callback(stop)
+        while True:
+            chunk = chunks.get()
+            if chunk is None:
+                return
+            if isinstance(chunk, Exception):
+                logger.error("GenerateStream error: %s", chunk)
+                context.abort(grpc.StatusCode.INTERNAL, str(chunk))
+            yield chunk
+            if chunk.HasField("final"):
+                return
+
     def Embed(self, request, context):
         """Handle Embed RPC."""
         logger.info("Embed called: text=%s...", request.text[:50] if request.text else "")
@@ -77,6 +134,23 @@ class CodecServiceServicer(pb2_grpc.CodecServiceServicer):
             context.set_details(str(e))
             return pb2.EmbedResponse()
 
+    def EmbedBatch(self, request, context):
+        """Handle EmbedBatch RPC."""
+        logger.info("EmbedBatch called: %d texts", len(request.texts))
+
+        try:
+            results = self._run(
+                self._service.embed_batch(texts=list(request.texts))
+            )
+            return pb2.EmbedBatchResponse(
+                embeddings=[pb2.Embedding(values=r.embedding) for r in results]
+            )
+        except Exception as e:
+            logger.error("EmbedBatch error: %s", e)
+            context.set_code(grpc.StatusCode.INTERNAL)
+            context.set_details(str(e))
+            return pb2.EmbedBatchResponse()
+
     def Search(self, request, context):
         """Handle Search RPC — query the evidence memory store."""
         logger.info("Search called: query=%s..., top_k=%d, threshold=%.2f",
@@ -141,6 +215,27 @@ class CodecServiceServicer(pb2_grpc.CodecServiceServicer):
             context.set_details(str(e))
             return pb2.DeleteEvidenceResponse()
 
+    def UpdateEvidenceMetadata(self, request, context):
+        """Handle UpdateEvidenceMetadata RPC — merge keys into an item's metadata (pin, note)."""
+        logger.info("UpdateEvidenceMetadata called: id=%s", request.id)
+
+        try:
+            import json
+            patch = json.loads(request.metadata_json) if request.metadata_json else {}
+            if not isinstance(patch, dict):
+                context.set_code(grpc.StatusCode.INVALID_ARGUMENT)
+                context.set_details("metadata_json must be a JSON object")
+                return pb2.UpdateEvidenceMetadataResponse()
+            meta = self._memory.update_metadata(request.id, patch)
+            if meta is None:
+                return pb2.UpdateEvidenceMetadataResponse(found=False)
+            return pb2.UpdateEvidenceMetadataResponse(found=True, metadata_json=json.dumps(meta))
+        except Exception as e:
+            logger.error("UpdateEvidenceMetadata error: %s", e)
+            context.set_code(grpc.StatusCode.INTERNAL)
+            context.set_details(str(e))
+            return pb2.UpdateEvidenceMetadataResponse()
+
     def ListAllEvidence(self, request, context):
         """Handle ListAllEvidence RPC — return all evidence items."""
         logger.info("ListAllEvidence called")
@@ -213,9 +308,99 @@ class CodecServiceServicer(pb2_grpc.CodecServiceServicer):
             context.set_code(grpc.StatusCode.INTERNAL)
             context.set_details(str(e))
             return pb2.WebSearchResponse()
+
+    def Handshake(self, request, context):
+        """Handle Handshake RPC — report this server's protocol version and schema fingerprint.
+
+        The client decides compatibility; a mismatch is also logged here so it is
+        visible on the server side. The x-server-instance header names this
+        process, so the client can tell a restart from a reconnect.
+        """
+        if request.protocol_version != PROTOCOL_VERSION or request.schema_fingerprint != SCHEMA_FINGERPRINT:
+            logger.warning(
+                "Handshake mismatch: client protocol=%d schema=%s, server protocol=%d schema=%s",
+                request.protocol_version, request.schema_fingerprint, PROTOCOL_VERSION, SCHEMA_FINGERPRINT,
+            )
+        else:
+            # debug: the controller repeats the handshake as a heartbeat
+            logger.debug("Handshake ok: protocol=%d schema=%s", PROTOCOL_VERSION, SCHEMA_FINGERPRINT)
+        context.send_initial_metadata(((INSTANCE_HEADER, SERVER_INSTANCE),))
+        return pb2.HandshakeResponse(protocol_version=PROTOCOL_VERSION, schema_fingerprint=SCHEMA_FINGERPRINT)
 # #endregion grpc-servicer
 
 
+# #region transport
+class TokenAuthInterceptor(grpc.ServerInterceptor):
+    """Reject RPCs whose "authorization" metadata is not "Bearer <token>".
+
+    token_source is called per RPC, so a token file can rotate without a restart.
+    """
+
+    def __init__(self, token_source):
+        self._token_source = token_source
+
+        def deny(request, context):
+            context.abort(grpc.StatusCode.UNAUTHENTICATED, "missing or invalid auth token")
+
+        self._deny = grpc.unary_unary_rpc_method_handler(deny)
+
+    def intercept_service(self, continuation, handler_call_details):
+        metadata = dict(handler_call_details.invocation_metadata or ())
+        expected = "Bearer " + self._token_source()
+        if hmac.compare_digest(metadata.get("authorization", "").encode(), expected.encode()):
+            return continuation(handler_call_details)
+        return self._deny
+
+
+def token_source():
+    """Return a callable yielding the auth token from GRPC_AUTH_TOKEN or GRPC_AUTH_TOKEN_FILE, or None."""
+    token = os.environ.get("GRPC_AUTH_TOKEN", "")
+    path = os.environ.get("GRPC_AUTH_TOKEN_FILE", "")
+    if token and path:
+        raise ValueError("set GRPC_AUTH_TOKEN or GRPC_AUTH_TOKEN_FILE, not both")
+    if path:
+        def read():
+            with open(path) as f:
+                return f.read().strip()
+        read()  # fail at startup, not on the first RPC
+        return read
+    if token:
+        return lambda: token
+    return None
+
+
+def server_credentials():
+    """Build TLS credentials from GRPC_TLS_CERT / GRPC_TLS_KEY, requiring client
+    certificates signed by GRPC_TLS_CLIENT_CA when set. None serves plaintext."""
+    cert, key = os.environ.get("GRPC_TLS_CERT", ""), os.environ.get("GRPC_TLS_KEY", "")
+    client_ca = os.environ.get("GRPC_TLS_CLIENT_CA", "")
+    if not cert and not key:
+        if client_ca:
+            raise ValueError("GRPC_TLS_CLIENT_CA needs GRPC_TLS_CERT and GRPC_TLS_KEY")
+        return None
+    if not cert or not key:
+        raise ValueError("TLS needs both GRPC_TLS_CERT and GRPC_TLS_KEY")
+    with open(cert, "rb") as f:
+        cert_pem = f.read()
+    with open(key, "rb") as f:
+        key_pem = f.read()
+    root = None
+    if client_ca:
+        with open(client_ca, "rb") as f:
+            root = f.read()
+    return grpc.ssl_server_credentials([(key_pem, cert_pem)], root_certificates=root, require_client_auth=root is not None)
+
+
+# Let clients ping idle connections every 10s (CODEC_KEEPALIVE on the Go side)
+# instead of being sent GOAWAY for too many pings.
+KEEPALIVE_OPTIONS = [
+    ("grpc.keepalive_permit_without_calls", 1),
+    ("grpc.http2.min_ping_interval_without_data_ms", 10000),
+    ("grpc.http2.max_pings_without_data", 0),
+]
+# #endregion transport
+
+
 # #region serve
 def serve():
     """Start the gRPC server."""
@@ -231,14 +416,25 @@ def serve():
     memory = MemoryStore(persist_dir=persist_dir, embed_model=embed_model)
     servicer = CodecServiceServicer(inference, memory, embed_model=embed_model)
 
-    server = grpc.server(futures.ThreadPoolExecutor(max_workers=4))
+    creds = server_credentials()
+    tokens = token_source()
+    if tokens and creds is None:
+        logger.warning("GRPC_AUTH_TOKEN is set without TLS: clients will not send it (set GRPC_TLS_CERT / GRPC_TLS_KEY)")
+    interceptors = [TokenAuthInterceptor(tokens)] if tokens else []
+
+    server = grpc.server(futures.ThreadPoolExecutor(max_workers=4), interceptors=interceptors, options=KEEPALIVE_OPTIONS)
     pb2_grpc.add_CodecServiceServicer_to_server(servicer, server)
-    server.add_insecure_port(f"0.0.0.0:{port}")
+    if creds is None:
+        server.add_insecure_port(f"0.0.0.0:{port}")
+    else:
+        server.add_secure_port(f"0.0.0.0:{port}", creds)
 
     # Start workspace HTTP server (file ops + evidence ops API for Orac)
     start_workspace_server(memory_store=memory, async_loop=servicer._loop)
 
-    logger.info("Starting gRPC server on port %s (model=%s, embed_model=%s, ollama=%s)", port, model, embed_model, ollama_url)
+    logger.info("Starting gRPC server on port %s (model=%s, embed_model=%s, ollama=%s, protocol=%d, schema=%s, tls=%s, auth=%s)",
+                port, model, embed_model, ollama_url, PROTOCOL_VERSION, SCHEMA_FINGERPRINT,
+                creds is not None, tokens is not None)
     server.start()
     server.wait_for_termination()
 # #endregion serve
@@ -0,0 +1,23 @@
+"""Tests for the evidence ID scheme."""
+
+from adaptive_inference.ids import is_local_id, migrate_id, new_id
+
+
+def test_new_id_is_local():
+    doc_id = new_id()
+    assert doc_id.startswith("ev_")
+    assert is_local_id(doc_id)
+
+
+def test_is_local_id():
+    assert is_local_id("ev_3f2504e0-4f89-41d3-9a0c-0305e82c3301")
+    assert not is_local_id("ev_3F2504E0-4F89-41D3-9A0C-0305E82C3301")
+    assert not is_local_id("3f2504e0-4f89-41d3-9a0c-0305e82c3301")
+    assert not is_local_id("team::doc-1")
+    assert not is_local_id("")
+
+
+def test_migrate_id():
+    assert migrate_id("3F2504E0-4F89-41D3-9A0C-0305E82C3301") == "ev_3f2504e0-4f89-41d3-9a0c-0305e82c3301"
+    assert migrate_id("ev_3f2504e0-4f89-41d3-9a0c-0305e82c3301") == "ev_3f2504e0-4f89-41d3-9a0c-0305e82c3301"
+    assert migrate_id("chunk-1") is None
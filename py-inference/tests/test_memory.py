@@ -41,7 +41,8 @@ class TestMemoryStore:
         mock_embed.return_value = fake_embedding
         doc_id = run(store.store("hello world", {"source": "test"}))
         assert isinstance(doc_id, str)
-        assert len(doc_id) == 36  # UUID format
+        assert doc_id.startswith("ev_")
+        assert len(doc_id) == 39  # ev_ + UUID
 
     @patch("adaptive_inference.memory.ollama_client.embed", new_callable=AsyncMock)
     def test_store_and_search(self, mock_embed, store, fake_embedding):
@@ -119,3 +120,81 @@ class TestMemoryStore:
         parsed = json.loads(results[0].metadata_json)
         assert parsed["source"] == "user"
         assert parsed["turn_id"] == "turn-5"
+
+    @patch("adaptive_inference.memory.ollama_client.embed", new_callable=AsyncMock)
+    def test_update_metadata_pins_and_clears_note(self, mock_embed, store, fake_embedding):
+        mock_embed.return_value = fake_embedding
+        doc_id = run(store.store("allergic to penicillin", {"source": "user", "note": "old"}))
+
+        meta = store.update_metadata(doc_id, {"pinned": True, "note": None})
+        assert meta == {"source": "user", "pinned": True}
+        [item] = store.get_by_ids([doc_id])
+        assert json.loads(item.metadata_json) == {"source": "user", "pinned": True}
+        assert store.update_metadata("ev_00000000-0000-4000-8000-000000000000", {"pinned": True}) is None
+        assert store.update_metadata("team::doc-1", {"pinned": True}) is None
+
+    @patch("adaptive_inference.memory.ollama_client.embed", new_callable=AsyncMock)
+    def test_pinned_evidence_survives_eviction(self, mock_embed, store, fake_embedding):
+        mock_embed.return_value = fake_embedding
+        with patch("adaptive_inference.memory.MAX_EVIDENCE", 2):
+            pinned = run(store.store("oldest, pinned", {"stored_at": "2026-01-01T00:00:00Z"}))
+            store.update_metadata(pinned, {"pinned": True})
+            run(store.store("second", {"stored_at": "2026-01-02T00:00:00Z"}))
+            run(store.store("third", {"stored_at": "2026-01-03T00:00:00Z"}))
+            run(store.store("fourth", {"stored_at": "2026-01-04T00:00:00Z"}))
+        texts = sorted(r.text for r in store.list_all())
+        assert texts == ["fourth", "oldest, pinned", "third"]
+
+    @patch("adaptive_inference.memory.ollama_client.embed", new_callable=AsyncMock)
+    def test_pinned_evidence_does_not_decay(self, mock_embed, store, fake_embedding):
+        mock_embed.return_value = fake_embedding
+        old = {"stored_at": "2020-01-01T00:00:00Z"}
+        plain = run(store.store("an old plain fact", dict(old)))
+        pinned = run(store.store("an old pinned fact", dict(old)))
+        store.update_metadata(pinned, {"pinned": True})
+
+        scores = {r.id: r.score for r in run(store.search("fact", top_k=5, threshold=0.0))}
+        assert scores[plain] < 0.51  # floored recency weight
+        assert scores[pinned] > 0.99  # no decay, boost capped at 1.0
+
+    def test_rehearsed_evidence_ages_from_rehearsal(self):
+        now = 1_800_000_000.0  # 2027-01-15
+        old = {"stored_at": "2020-01-01T00:00:00Z"}
+        rehearsed = dict(old, rehearsed_at="2027-01-15T08:00:00Z")
+        assert MemoryStore._recency_weight(old, now) < 0.51
+        assert MemoryStore._recency_weight(rehearsed, now) > 0.99
+
+    @patch("adaptive_inference.memory.ollama_client.embed", new_callable=AsyncMock)
+    def test_compacted_evidence_hidden_and_evicted_first(self, mock_embed, store, fake_embedding):
+        mock_embed.return_value = fake_embedding
+        with patch("adaptive_inference.memory.MAX_EVIDENCE", 3):
+            first = run(store.store("first", {"stored_at": "2026-01-01T00:00:00Z"}))
+            summary = run(store.store("summary", {"stored_at": "2026-01-02T00:00:00Z", "summary_of": "x"}))
+            compacted = run(store.store("compacted", {"stored_at": "2026-01-03T00:00:00Z"}))
+            store.update_metadata(compacted, {"compacted_into": summary})
+            ids = {r.id for r in run(store.search("anything", top_k=5, threshold=0.0))}
+            assert ids == {first, summary}
+            run(store.store("newest", {"stored_at": "2026-01-04T00:00:00Z"}))
+        texts = sorted(r.text for r in store.list_all())
+        assert texts == ["first", "newest", "summary"]
+
+    def test_delete_refuses_malformed_id(self, store):
+        assert run(store.delete("team::doc-1")) is False
+        assert store.get_by_ids(["chunk-1"]) == []
+
+    def test_legacy_ids_migrated_on_open(self, tmp_persist_dir, fake_embedding):
+        legacy = "3F2504E0-4F89-41D3-9A0C-0305E82C3301"
+        s = MemoryStore(persist_dir=tmp_persist_dir, collection_name="legacy")
+        s._collection.add(
+            ids=[legacy, "chunk-1"],
+            embeddings=[fake_embedding, fake_embedding],
+            documents=["old evidence", "odd evidence"],
+            metadatas=[{"source": "old"}, {"source": "odd"}],
+        )
+
+        reopened = MemoryStore(persist_dir=tmp_persist_dir, collection_name="legacy")
+        items = {r.text: r for r in reopened.list_all()}
+        assert set(items) == {"old evidence", "odd evidence"}
+        assert items["old evidence"].id == "ev_3f2504e0-4f89-41d3-9a0c-0305e82c3301"
+        assert items["odd evidence"].id.startswith("ev_")
+        assert json.loads(items["old evidence"].metadata_json)["source"] == "old"
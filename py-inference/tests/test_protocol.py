@@ -0,0 +1,51 @@
+"""Tests for the codec protocol version and schema fingerprint."""
+
+import os
+import re
+
+from adaptive_inference.protocol import PROTOCOL_VERSION, SCHEMA_FINGERPRINT, fingerprint, schema_canonical
+
+PROTO_PATH = os.path.join(os.path.dirname(__file__), "..", "..", "proto", "adaptive.proto")
+
+# Proto scalar keywords to descriptor type numbers; anything else is TYPE_MESSAGE.
+PROTO_TYPES = {"double": 1, "float": 2, "int64": 3, "uint64": 4, "int32": 5, "bool": 8, "string": 9, "bytes": 12, "uint32": 13}
+
+
+def canonical_from_proto():
+    """Render proto/adaptive.proto the way schema_canonical renders the compiled bindings."""
+    version, msg, svc = None, None, None
+    msgs, rpcs = [], []
+    with open(PROTO_PATH) as f:
+        for raw in f:
+            line = raw.strip()
+            if m := re.fullmatch(r"// protocol_version: (\d+)", line):
+                version = int(m.group(1))
+            elif m := re.fullmatch(r"message (\w+) \{(\})?", line):
+                msgs.append(f"message {m.group(1)}\n")
+                msg = None if m.group(2) else m.group(1)
+            elif m := re.fullmatch(r"service (\w+) \{", line):
+                svc = m.group(1)
+            elif line == "}":
+                msg, svc = None, None
+            elif msg and (m := re.fullmatch(r"(repeated )?(\w+) (\w+) = (\d+);", line)):
+                label = 3 if m.group(1) else 1
+                typ, type_name = PROTO_TYPES.get(m.group(2), 11), ""
+                if typ == 11:
+                    type_name = ".adaptive." + m.group(2)
+                msgs.append(f"{msg}.{m.group(3)}={m.group(4)}:{typ}:{label}:{type_name}\n")
+            elif svc and (m := re.fullmatch(r"rpc (\w+)\((\w+)\) returns \((?:stream )?(\w+)\);", line)):
+                rpcs.append(f"rpc {svc}.{m.group(1)}(.adaptive.{m.group(2)}).adaptive.{m.group(3)}\n")
+    return version, "".join(msgs + rpcs)
+
+
+def test_protocol_version_matches_proto():
+    """PROTOCOL_VERSION should equal the version declared in the proto."""
+    version, _ = canonical_from_proto()
+    assert version == PROTOCOL_VERSION
+
+
+def test_generated_bindings_match_proto():
+    """adaptive_pb2 should be regenerated whenever the proto changes."""
+    _, want = canonical_from_proto()
+    assert schema_canonical() == want, "adaptive_pb2 drifted from proto/adaptive.proto; run scripts/gen-proto.sh"
+    assert SCHEMA_FINGERPRINT == fingerprint(want)
@@ -13,6 +13,13 @@ protoc \
   --go-grpc_out="$GO_OUT/adaptive" \
   --go-grpc_opt=paths=source_relative \
   "$PROTO_DIR/adaptive.proto"
+protoc \
+  --proto_path="$PROTO_DIR" \
+  --go_out="$GO_OUT/controller" \
+  --go_opt=paths=source_relative \
+  --go-grpc_out="$GO_OUT/controller" \
+  --go-grpc_opt=paths=source_relative \
+  "$PROTO_DIR/controller.proto"
 
 echo "==> Generating Python protobuf stubs..."
 python -m grpc_tools.protoc \